@@ -0,0 +1,239 @@
+// Package accounts aggregates multiple accounts (credential sets) on the same exchange, it
+// provides combined views of the balances & open orders of all the accounts, and routes new
+// orders to an account based on configurable rules.
+package accounts
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+var (
+	// ErrNoAccounts is returned when no accounts have been added for an exchange
+	ErrNoAccounts = errors.New("no accounts for exchange")
+	// ErrAccountNotFound is returned when an account doesn't exist
+	ErrAccountNotFound = errors.New("account not found")
+)
+
+// Account is a single set of credentials on an exchange
+type Account struct {
+	Name     string
+	Exchange exchange.IBotExchangeEx
+}
+
+// Rule routes the orders for a currency pair and/or side to an account, a zero Pair matches all
+// currency pairs and an empty Side matches both sides.
+type Rule struct {
+	Pair    pair.CurrencyPair
+	Side    exchange.OrderSide
+	Account string
+}
+
+func (r *Rule) matches(p pair.CurrencyPair, side exchange.OrderSide) bool {
+	if r.Pair.FirstCurrency != "" && !r.Pair.Equal(p) {
+		return false
+	}
+	return r.Side == "" || r.Side == side
+}
+
+// AccountBalance holds the balances of a single account
+type AccountBalance struct {
+	Account    string                         `json:"account"`
+	Currencies []exchange.AccountCurrencyInfo `json:"currencies"`
+}
+
+// Balances holds the combined balances of all the accounts on an exchange, along with the
+// balances of each account
+type Balances struct {
+	ExchangeName string                         `json:"exchangeName"`
+	Currencies   []exchange.AccountCurrencyInfo `json:"currencies"`
+	Accounts     []AccountBalance               `json:"accounts"`
+}
+
+// AccountOrder is an order tagged with the account it was placed with
+type AccountOrder struct {
+	Account string `json:"account"`
+	*exchange.Order
+}
+
+type venue struct {
+	accounts []Account
+	rules    []Rule
+}
+
+// Aggregator holds the accounts of each exchange
+type Aggregator struct {
+	m      sync.RWMutex
+	venues map[string]*venue
+}
+
+// NewAggregator creates an empty aggregator
+func NewAggregator() *Aggregator {
+	return &Aggregator{venues: make(map[string]*venue)}
+}
+
+// AddAccount adds an account for the exchange, the first account added for an exchange is the
+// default account orders are routed to when no rule matches.
+func (a *Aggregator) AddAccount(accountName string, exch exchange.IBotExchangeEx) error {
+	a.m.Lock()
+	defer a.m.Unlock()
+	v, ok := a.venues[exch.GetName()]
+	if !ok {
+		v = &venue{}
+		a.venues[exch.GetName()] = v
+	}
+	for i := range v.accounts {
+		if v.accounts[i].Name == accountName {
+			return fmt.Errorf("%s account %s already exists", exch.GetName(), accountName)
+		}
+	}
+	v.accounts = append(v.accounts, Account{Name: accountName, Exchange: exch})
+	return nil
+}
+
+// AddRule adds a routing rule for the exchange, rules are evaluated in the order they're added.
+func (a *Aggregator) AddRule(exchangeName string, rule Rule) error {
+	a.m.Lock()
+	defer a.m.Unlock()
+	v, ok := a.venues[exchangeName]
+	if !ok {
+		return ErrNoAccounts
+	}
+	if _, err := v.account(rule.Account); err != nil {
+		return err
+	}
+	v.rules = append(v.rules, rule)
+	return nil
+}
+
+func (v *venue) account(accountName string) (Account, error) {
+	for i := range v.accounts {
+		if v.accounts[i].Name == accountName {
+			return v.accounts[i], nil
+		}
+	}
+	return Account{}, ErrAccountNotFound
+}
+
+func (a *Aggregator) venue(exchangeName string) (*venue, error) {
+	a.m.RLock()
+	defer a.m.RUnlock()
+	v, ok := a.venues[exchangeName]
+	if !ok || len(v.accounts) == 0 {
+		return nil, ErrNoAccounts
+	}
+	return v, nil
+}
+
+// Accounts returns the accounts of an exchange
+func (a *Aggregator) Accounts(exchangeName string) []Account {
+	v, err := a.venue(exchangeName)
+	if err != nil {
+		return nil
+	}
+	a.m.RLock()
+	defer a.m.RUnlock()
+	return append([]Account(nil), v.accounts...)
+}
+
+// Account returns an account of an exchange
+func (a *Aggregator) Account(exchangeName, accountName string) (Account, error) {
+	v, err := a.venue(exchangeName)
+	if err != nil {
+		return Account{}, err
+	}
+	a.m.RLock()
+	defer a.m.RUnlock()
+	return v.account(accountName)
+}
+
+// Route returns the account orders for the currency pair & side should be placed with
+func (a *Aggregator) Route(exchangeName string, p pair.CurrencyPair, side exchange.OrderSide) (Account, error) {
+	v, err := a.venue(exchangeName)
+	if err != nil {
+		return Account{}, err
+	}
+	a.m.RLock()
+	defer a.m.RUnlock()
+	for i := range v.rules {
+		if v.rules[i].matches(p, side) {
+			return v.account(v.rules[i].Account)
+		}
+	}
+	return v.accounts[0], nil
+}
+
+// NewOrder places an order with the account selected by the routing rules, returns the name of
+// the account along with the order ID.
+func (a *Aggregator) NewOrder(exchangeName string, p pair.CurrencyPair, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, string, error) {
+	account, err := a.Route(exchangeName, p, side)
+	if err != nil {
+		return "", "", err
+	}
+	orderID, err := account.Exchange.NewOrder(p, amount, price, side, orderType, opts...)
+	return account.Name, orderID, err
+}
+
+// Balances returns the combined balances of all the accounts of an exchange
+func (a *Aggregator) Balances(exchangeName string) (Balances, error) {
+	result := Balances{ExchangeName: exchangeName}
+	accounts := a.Accounts(exchangeName)
+	if len(accounts) == 0 {
+		return result, ErrNoAccounts
+	}
+
+	totals := make(map[string]*exchange.AccountCurrencyInfo)
+	for _, account := range accounts {
+		info, err := account.Exchange.GetExchangeAccountInfo()
+		if err != nil {
+			return result, fmt.Errorf("failed to get %s account %s balances: %s", exchangeName,
+				account.Name, err)
+		}
+		result.Accounts = append(result.Accounts,
+			AccountBalance{Account: account.Name, Currencies: info.Currencies})
+		for _, c := range info.Currencies {
+			total, ok := totals[c.CurrencyName]
+			if !ok {
+				total = &exchange.AccountCurrencyInfo{CurrencyName: c.CurrencyName}
+				totals[c.CurrencyName] = total
+			}
+			total.TotalValue += c.TotalValue
+			total.Hold += c.Hold
+			total.Available += c.Available
+		}
+	}
+	for _, total := range totals {
+		result.Currencies = append(result.Currencies, *total)
+	}
+	sort.Slice(result.Currencies, func(i, j int) bool {
+		return result.Currencies[i].CurrencyName < result.Currencies[j].CurrencyName
+	})
+	return result, nil
+}
+
+// Orders returns the active orders of all the accounts of an exchange, tagged by account.
+// The pairs parameter has the same meaning as in IBotExchangeEx.GetOrders.
+func (a *Aggregator) Orders(exchangeName string, pairs []pair.CurrencyPair) ([]AccountOrder, error) {
+	accounts := a.Accounts(exchangeName)
+	if len(accounts) == 0 {
+		return nil, ErrNoAccounts
+	}
+	var result []AccountOrder
+	for _, account := range accounts {
+		orders, err := account.Exchange.GetOrders(pairs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s account %s orders: %s", exchangeName,
+				account.Name, err)
+		}
+		for _, order := range orders {
+			result = append(result, AccountOrder{Account: account.Name, Order: order})
+		}
+	}
+	return result, nil
+}
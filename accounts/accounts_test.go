@@ -0,0 +1,146 @@
+package accounts
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+type mockExchange struct {
+	exchange.IBotExchangeEx
+	account  string
+	balances []exchange.AccountCurrencyInfo
+	orders   []*exchange.Order
+	placed   int
+	err      error
+}
+
+func (m *mockExchange) GetName() string {
+	return "Mock"
+}
+
+func (m *mockExchange) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return exchange.AccountInfo{ExchangeName: "Mock", Currencies: m.balances}, m.err
+}
+
+func (m *mockExchange) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return m.orders, m.err
+}
+
+func (m *mockExchange) NewOrder(symbol pair.CurrencyPair, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	m.placed++
+	return m.account + "-1", nil
+}
+
+func newTestAggregator(t *testing.T) (*Aggregator, *mockExchange, *mockExchange) {
+	main := &mockExchange{
+		account: "main",
+		balances: []exchange.AccountCurrencyInfo{
+			{CurrencyName: "BTC", TotalValue: 2, Hold: 1, Available: 1},
+			{CurrencyName: "USD", TotalValue: 1000, Available: 1000},
+		},
+		orders: []*exchange.Order{{OrderID: "1"}},
+	}
+	hedge := &mockExchange{
+		account: "hedge",
+		balances: []exchange.AccountCurrencyInfo{
+			{CurrencyName: "BTC", TotalValue: 0.5, Available: 0.5},
+			{CurrencyName: "ETH", TotalValue: 10, Available: 10},
+		},
+		orders: []*exchange.Order{{OrderID: "2"}, {OrderID: "3"}},
+	}
+	a := NewAggregator()
+	if err := a.AddAccount("main", main); err != nil {
+		t.Fatalf("Test failed. AddAccount error: %s", err)
+	}
+	if err := a.AddAccount("hedge", hedge); err != nil {
+		t.Fatalf("Test failed. AddAccount error: %s", err)
+	}
+	return a, main, hedge
+}
+
+func TestBalances(t *testing.T) {
+	a, _, hedge := newTestAggregator(t)
+	balances, err := a.Balances("Mock")
+	if err != nil {
+		t.Fatalf("Test failed. Balances error: %s", err)
+	}
+	expected := []exchange.AccountCurrencyInfo{
+		{CurrencyName: "BTC", TotalValue: 2.5, Hold: 1, Available: 1.5},
+		{CurrencyName: "ETH", TotalValue: 10, Available: 10},
+		{CurrencyName: "USD", TotalValue: 1000, Available: 1000},
+	}
+	if len(balances.Currencies) != len(expected) {
+		t.Fatalf("Test failed. Unexpected combined balances %v", balances.Currencies)
+	}
+	for i := range expected {
+		if balances.Currencies[i] != expected[i] {
+			t.Errorf("Test failed. Expected %v, got %v", expected[i], balances.Currencies[i])
+		}
+	}
+	if len(balances.Accounts) != 2 || balances.Accounts[1].Account != "hedge" {
+		t.Errorf("Test failed. Unexpected account balances %v", balances.Accounts)
+	}
+
+	hedge.err = errors.New("invalid API key")
+	if _, err = a.Balances("Mock"); err == nil {
+		t.Error("Test failed. Expected error when an account fails")
+	}
+	if _, err = a.Balances("Other"); err != ErrNoAccounts {
+		t.Errorf("Test failed. Expected ErrNoAccounts, got %v", err)
+	}
+}
+
+func TestOrders(t *testing.T) {
+	a, _, _ := newTestAggregator(t)
+	orders, err := a.Orders("Mock", nil)
+	if err != nil {
+		t.Fatalf("Test failed. Orders error: %s", err)
+	}
+	if len(orders) != 3 || orders[0].Account != "main" || orders[0].OrderID != "1" ||
+		orders[2].Account != "hedge" || orders[2].OrderID != "3" {
+		t.Errorf("Test failed. Unexpected orders %v", orders)
+	}
+}
+
+func TestRouting(t *testing.T) {
+	a, main, hedge := newTestAggregator(t)
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+	ethbtc := pair.NewCurrencyPair("ETH", "BTC")
+
+	if err := a.AddRule("Mock", Rule{Pair: ethbtc, Account: "hedge"}); err != nil {
+		t.Fatalf("Test failed. AddRule error: %s", err)
+	}
+	if err := a.AddRule("Mock", Rule{Side: exchange.OrderSideSell, Account: "hedge"}); err != nil {
+		t.Fatalf("Test failed. AddRule error: %s", err)
+	}
+	if err := a.AddRule("Mock", Rule{Account: "missing"}); err != ErrAccountNotFound {
+		t.Errorf("Test failed. Expected ErrAccountNotFound, got %v", err)
+	}
+
+	tests := []struct {
+		p       pair.CurrencyPair
+		side    exchange.OrderSide
+		account string
+	}{
+		{btcusd, exchange.OrderSideBuy, "main"},
+		{btcusd, exchange.OrderSideSell, "hedge"},
+		{pair.NewCurrencyPair("eth", "btc"), exchange.OrderSideBuy, "hedge"},
+	}
+	for _, test := range tests {
+		account, err := a.Route("Mock", test.p, test.side)
+		if err != nil || account.Name != test.account {
+			t.Errorf("Test failed. Expected %s %s to be routed to %s, got %s %v",
+				test.side, test.p.Pair(), test.account, account.Name, err)
+		}
+	}
+
+	account, orderID, err := a.NewOrder("Mock", ethbtc, 1, 0.05, exchange.OrderSideBuy,
+		exchange.OrderTypeExchangeLimit)
+	if err != nil || account != "hedge" || orderID != "hedge-1" || hedge.placed != 1 || main.placed != 0 {
+		t.Errorf("Test failed. Unexpected NewOrder result %s %s %v", account, orderID, err)
+	}
+}
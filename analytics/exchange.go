@@ -0,0 +1,146 @@
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+// Max number of trades fetched to calculate the average fill price of an order
+const maxFillTrades = 500
+
+// TrackedExchange wraps an exchange and records the execution of all the orders placed through
+// it in a Tracker.
+type TrackedExchange struct {
+	exchange.Decorator
+	Tracker  *Tracker
+	Strategy string
+}
+
+// NewTrackedExchange returns a wrapper that records the execution of all the orders placed on
+// the given exchange by the given strategy.
+func NewTrackedExchange(exch exchange.IBotExchangeEx, tracker *Tracker, strategy string) *TrackedExchange {
+	return &TrackedExchange{exchange.Decorator{IBotExchangeEx: exch}, tracker, strategy}
+}
+
+// NewOrder creates a new order on the exchange and records the mid price of the market at the
+// time of submission.
+func (t *TrackedExchange) NewOrder(symbol pair.CurrencyPair, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return t.NewOrderContext(context.Background(), symbol, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (t *TrackedExchange) NewOrderContext(ctx context.Context, symbol pair.CurrencyPair, amount,
+	price float64, side exchange.OrderSide, orderType exchange.OrderType,
+	opts ...exchange.OrderOptions) (string, error) {
+	midPrice := t.midPrice(symbol)
+	submittedAt := time.Now()
+	orderID, err := exchange.WithContext(t.IBotExchangeEx).NewOrderContext(ctx, symbol, amount, price,
+		side, orderType, opts...)
+	// Orders that were filled immediately without being assigned an ID can't be tracked.
+	if err == nil && orderID != "" {
+		t.Tracker.OrderSubmitted(t.Strategy, t.GetName(), orderID, side, amount, midPrice, submittedAt)
+	}
+	return orderID, err
+}
+
+// CancelOrder cancels an active order on the exchange and records the cancellation.
+func (t *TrackedExchange) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return t.CancelOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (t *TrackedExchange) CancelOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) error {
+	err := exchange.WithContext(t.IBotExchangeEx).CancelOrderContext(ctx, orderID, currencyPair)
+	if err == nil {
+		t.Tracker.OrderCancelled(t.GetName(), orderID)
+	}
+	return err
+}
+
+// GetOrder returns information about a previously placed order and records its filled amount.
+func (t *TrackedExchange) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	return t.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (t *TrackedExchange) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	order, err := exchange.WithContext(t.IBotExchangeEx).GetOrderContext(ctx, orderID, currencyPair)
+	if err == nil && order != nil {
+		t.recordFill(order)
+	}
+	return order, err
+}
+
+// GetOrders returns information about currently active orders and records their filled amounts.
+func (t *TrackedExchange) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return t.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (t *TrackedExchange) GetOrdersContext(ctx context.Context,
+	pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	orders, err := exchange.WithContext(t.IBotExchangeEx).GetOrdersContext(ctx, pairs)
+	for _, order := range orders {
+		if order != nil {
+			t.recordFill(order)
+		}
+	}
+	return orders, err
+}
+
+func (t *TrackedExchange) recordFill(order *exchange.Order) {
+	name := t.GetName()
+	if order.FilledAmount > 0 {
+		// the trades are only fetched when the filled amount of an active order has changed
+		submittedAt, filledAmount, ok := t.Tracker.active(name, order.OrderID)
+		if ok && order.FilledAmount != filledAmount {
+			t.Tracker.OrderFilled(name, order.OrderID, order.FilledAmount, t.fillPrice(order, submittedAt),
+				time.Now())
+		}
+	}
+	if order.Status == exchange.OrderStatusFilled || order.Status == exchange.OrderStatusAborted {
+		t.Tracker.OrderClosed(name, order.OrderID)
+	}
+}
+
+// Returns the average price of the trades that filled the order, or the order rate if the
+// exchange can't return the trades of the account.
+func (t *TrackedExchange) fillPrice(order *exchange.Order, submittedAt time.Time) float64 {
+	history, ok := exchange.TradeHistory(t.IBotExchangeEx)
+	if !ok {
+		return order.Rate
+	}
+	// allow for the clock of the exchange being behind the local clock
+	trades, err := history.GetAccountTrades(order.CurrencyPair, submittedAt.Add(-time.Minute),
+		maxFillTrades)
+	if err != nil {
+		return order.Rate
+	}
+	var amount, notional float64
+	for i := range trades {
+		if trades[i].OrderID == order.OrderID {
+			amount += trades[i].Amount
+			notional += trades[i].Amount * trades[i].Price
+		}
+	}
+	if amount == 0 {
+		return order.Rate
+	}
+	return notional / amount
+}
+
+// Returns the mid price of the market, or zero if the ticker is unavailable.
+func (t *TrackedExchange) midPrice(symbol pair.CurrencyPair) float64 {
+	price, err := t.GetTickerPrice(symbol, ticker.Spot)
+	if err != nil || price.Bid == 0 || price.Ask == 0 {
+		return 0
+	}
+	return (price.Bid + price.Ask) / 2
+}
@@ -0,0 +1,257 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+// OrderRecord holds the execution history of a single order
+type OrderRecord struct {
+	Strategy string
+	Exchange string
+	OrderID  string
+	Side     exchange.OrderSide
+	Amount   float64
+	// Mid price of the market at the time the order was submitted
+	MidPrice     float64
+	SubmittedAt  time.Time
+	FilledAmount float64
+	// Average price of the filled amount
+	AveragePrice float64
+	// Time at which the order was completely filled, zero if it hasn't been
+	FilledAt  time.Time
+	Cancelled bool
+}
+
+// ExecutionStats holds execution quality metrics for all the orders submitted by a strategy
+// to an exchange
+type ExecutionStats struct {
+	Strategy      string        `json:"strategy"`
+	Exchange      string        `json:"exchange"`
+	Orders        int           `json:"orders"`
+	FilledOrders  int           `json:"filledOrders"`
+	Cancels       int           `json:"cancels"`
+	FillRatio     float64       `json:"fillRatio"` // Filled amount / submitted amount
+	AvgTimeToFill time.Duration `json:"avgTimeToFill"`
+	// Average slippage (weighted by filled amount) of the fill price relative to the mid price
+	// at submission, in basis points. Positive values mean the fill price was worse than the mid.
+	AvgSlippageBps float64 `json:"avgSlippageBps"`
+}
+
+// Tracker keeps track of the execution of orders and computes execution quality metrics
+type Tracker struct {
+	m sync.Mutex
+	// Records of the orders that are still active, keyed by exchange & order ID. The orders are
+	// removed once they're filled, cancelled or closed.
+	orders map[string]*OrderRecord
+	// Records in the order they were submitted
+	records []*OrderRecord
+	// If set the orders submitted without a strategy are attributed to the strategy it returns,
+	// e.g. when the strategies tag their orders further up the exchange wrapper chain
+	StrategyOf func(exchangeName, orderID string) string
+}
+
+// NewTracker creates a new execution Tracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		orders: make(map[string]*OrderRecord),
+	}
+}
+
+func orderKey(exchangeName, orderID string) string {
+	return exchangeName + "/" + orderID
+}
+
+// OrderSubmitted records the submission of a new order, midPrice should be the mid price of
+// the market at the time of submission.
+func (t *Tracker) OrderSubmitted(strategy, exchangeName, orderID string, side exchange.OrderSide,
+	amount, midPrice float64, at time.Time) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	record := &OrderRecord{
+		Strategy:    strategy,
+		Exchange:    exchangeName,
+		OrderID:     orderID,
+		Side:        side,
+		Amount:      amount,
+		MidPrice:    midPrice,
+		SubmittedAt: at,
+	}
+	t.orders[orderKey(exchangeName, orderID)] = record
+	t.records = append(t.records, record)
+}
+
+// OrderFilled records the current filled amount and average fill price of an order.
+func (t *Tracker) OrderFilled(exchangeName, orderID string, filledAmount, averagePrice float64, at time.Time) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	key := orderKey(exchangeName, orderID)
+	record, ok := t.orders[key]
+	if !ok {
+		return
+	}
+	record.FilledAmount = filledAmount
+	record.AveragePrice = averagePrice
+	if filledAmount >= record.Amount {
+		record.FilledAt = at
+		delete(t.orders, key)
+	}
+}
+
+// OrderCancelled records the cancellation of an order.
+func (t *Tracker) OrderCancelled(exchangeName, orderID string) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	key := orderKey(exchangeName, orderID)
+	if record, ok := t.orders[key]; ok {
+		record.Cancelled = true
+		delete(t.orders, key)
+	}
+}
+
+// OrderClosed records that an order is no longer active on the exchange, the fills of the order
+// are no longer tracked.
+func (t *Tracker) OrderClosed(exchangeName, orderID string) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	delete(t.orders, orderKey(exchangeName, orderID))
+}
+
+// active returns the submission time & the last recorded filled amount of an order, ok is false
+// if the order isn't tracked or is no longer active.
+func (t *Tracker) active(exchangeName, orderID string) (submittedAt time.Time, filledAmount float64, ok bool) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	record, ok := t.orders[orderKey(exchangeName, orderID)]
+	if !ok {
+		return time.Time{}, 0, false
+	}
+	return record.SubmittedAt, record.FilledAmount, true
+}
+
+// Records returns a copy of all the order records.
+func (t *Tracker) Records() []OrderRecord {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	records := make([]OrderRecord, len(t.records))
+	for i := range t.records {
+		records[i] = *t.records[i]
+		if records[i].Strategy == "" && t.StrategyOf != nil {
+			records[i].Strategy = t.StrategyOf(records[i].Exchange, records[i].OrderID)
+		}
+	}
+	return records
+}
+
+// Stats computes the execution metrics of all the tracked orders, grouped by strategy and
+// exchange.
+func (t *Tracker) Stats() []ExecutionStats {
+	return ComputeStats(t.Records())
+}
+
+// ComputeStats computes the execution metrics of the given orders, grouped by strategy and
+// exchange.
+func ComputeStats(records []OrderRecord) []ExecutionStats {
+	type accumulator struct {
+		stats         ExecutionStats
+		amount        float64
+		filledAmount  float64
+		timeToFill    time.Duration
+		slippage      float64
+		slippageBasis float64
+	}
+
+	groups := make(map[string]*accumulator)
+	for i := range records {
+		r := &records[i]
+		key := r.Strategy + "/" + r.Exchange
+		acc, ok := groups[key]
+		if !ok {
+			acc = &accumulator{stats: ExecutionStats{Strategy: r.Strategy, Exchange: r.Exchange}}
+			groups[key] = acc
+		}
+
+		acc.stats.Orders++
+		if r.Cancelled {
+			acc.stats.Cancels++
+		}
+		acc.amount += r.Amount
+		acc.filledAmount += r.FilledAmount
+		if !r.FilledAt.IsZero() {
+			acc.stats.FilledOrders++
+			acc.timeToFill += r.FilledAt.Sub(r.SubmittedAt)
+		}
+		if r.FilledAmount > 0 && r.MidPrice > 0 {
+			slippage := (r.AveragePrice - r.MidPrice) / r.MidPrice * 10000
+			if r.Side == exchange.OrderSideSell {
+				slippage = -slippage
+			}
+			acc.slippage += slippage * r.FilledAmount
+			acc.slippageBasis += r.FilledAmount
+		}
+	}
+
+	result := make([]ExecutionStats, 0, len(groups))
+	for _, acc := range groups {
+		if acc.amount > 0 {
+			acc.stats.FillRatio = acc.filledAmount / acc.amount
+		}
+		if acc.stats.FilledOrders > 0 {
+			acc.stats.AvgTimeToFill = acc.timeToFill / time.Duration(acc.stats.FilledOrders)
+		}
+		if acc.slippageBasis > 0 {
+			acc.stats.AvgSlippageBps = acc.slippage / acc.slippageBasis
+		}
+		result = append(result, acc.stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Strategy != result[j].Strategy {
+			return result[i].Strategy < result[j].Strategy
+		}
+		return result[i].Exchange < result[j].Exchange
+	})
+	return result
+}
+
+// WriteCSV writes the execution metrics to w in CSV format (with a header row).
+func WriteCSV(w io.Writer, stats []ExecutionStats) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{
+		"strategy", "exchange", "orders", "filled_orders", "cancels",
+		"fill_ratio", "avg_time_to_fill_secs", "avg_slippage_bps",
+	})
+	if err != nil {
+		return err
+	}
+	for i := range stats {
+		s := &stats[i]
+		err = writer.Write([]string{
+			s.Strategy,
+			s.Exchange,
+			strconv.Itoa(s.Orders),
+			strconv.Itoa(s.FilledOrders),
+			strconv.Itoa(s.Cancels),
+			strconv.FormatFloat(s.FillRatio, 'f', 4, 64),
+			strconv.FormatFloat(s.AvgTimeToFill.Seconds(), 'f', 3, 64),
+			strconv.FormatFloat(s.AvgSlippageBps, 'f', 2, 64),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write execution stats: %s", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,140 @@
+package analytics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+func TestTrackerStats(t *testing.T) {
+	tracker := NewTracker()
+	start := time.Now()
+
+	tracker.OrderSubmitted("momentum", "Bitfinex", "1", exchange.OrderSideBuy, 2, 100, start)
+	tracker.OrderFilled("Bitfinex", "1", 2, 101, start.Add(10*time.Second))
+
+	tracker.OrderSubmitted("momentum", "Bitfinex", "2", exchange.OrderSideSell, 2, 100, start)
+	tracker.OrderFilled("Bitfinex", "2", 1, 99, start.Add(5*time.Second))
+	tracker.OrderCancelled("Bitfinex", "2")
+	// Finished orders are no longer tracked
+	tracker.OrderFilled("Bitfinex", "2", 2, 90, start.Add(6*time.Second))
+
+	tracker.OrderSubmitted("momentum", "Kraken", "A", exchange.OrderSideBuy, 1, 100, start)
+
+	// Unknown orders should be ignored
+	tracker.OrderFilled("Kraken", "B", 1, 100, start)
+	if len(tracker.orders) != 1 {
+		t.Errorf("Test failed. Expected only the active order to be tracked, got %d orders", len(tracker.orders))
+	}
+
+	stats := tracker.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("Test failed. Expected stats for 2 exchanges but got %d", len(stats))
+	}
+
+	bfx := stats[0]
+	if bfx.Exchange != "Bitfinex" || bfx.Orders != 2 || bfx.FilledOrders != 1 ||
+		bfx.Cancels != 1 {
+		t.Errorf("Test failed. Unexpected order counts: %+v", bfx)
+	}
+	if bfx.FillRatio != 0.75 {
+		t.Errorf("Test failed. Expected fill ratio 0.75 but got %f", bfx.FillRatio)
+	}
+	if bfx.AvgTimeToFill != 10*time.Second {
+		t.Errorf("Test failed. Expected avg time to fill 10s but got %s", bfx.AvgTimeToFill)
+	}
+	// (100bps * 2 + 100bps * 1) / 3
+	if bfx.AvgSlippageBps < 99.99 || bfx.AvgSlippageBps > 100.01 {
+		t.Errorf("Test failed. Expected avg slippage 100bps but got %f", bfx.AvgSlippageBps)
+	}
+
+	kraken := stats[1]
+	if kraken.Exchange != "Kraken" || kraken.Orders != 1 || kraken.FillRatio != 0 {
+		t.Errorf("Test failed. Unexpected Kraken stats: %+v", kraken)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	stats := []ExecutionStats{{
+		Strategy:       "momentum",
+		Exchange:       "Bitfinex",
+		Orders:         2,
+		FilledOrders:   1,
+		FillRatio:      0.5,
+		AvgTimeToFill:  1500 * time.Millisecond,
+		AvgSlippageBps: 12.5,
+	}}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, stats); err != nil {
+		t.Fatalf("Test failed. WriteCSV returned an error: %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Test failed. Expected 2 CSV lines but got %d", len(lines))
+	}
+	if lines[1] != "momentum,Bitfinex,2,1,0,0.5000,1.500,12.50" {
+		t.Errorf("Test failed. Unexpected CSV row: %s", lines[1])
+	}
+}
+
+type mockTradesExchange struct {
+	exchange.IBotExchangeEx
+	orders []*exchange.Order
+	trades []exchange.Trade
+}
+
+func (m *mockTradesExchange) GetName() string {
+	return "Mock"
+}
+
+func (m *mockTradesExchange) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return ticker.Price{Bid: 99, Ask: 101}, nil
+}
+
+func (m *mockTradesExchange) NewOrder(p pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return "1", nil
+}
+
+func (m *mockTradesExchange) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return m.orders, nil
+}
+
+func (m *mockTradesExchange) GetAccountTrades(p pair.CurrencyPair, since time.Time,
+	limit int) ([]exchange.Trade, error) {
+	return m.trades, nil
+}
+
+func TestTrackedExchangeFillPrice(t *testing.T) {
+	tracker := NewTracker()
+	mock := &mockTradesExchange{}
+	// the trade history is found through the other decorators
+	paused := exchange.NewPausableExchange(mock, exchange.NewTradingSwitch())
+	exch := NewTrackedExchange(paused, tracker, "momentum")
+	p := pair.NewCurrencyPair("BTC", "USD")
+	if _, err := exch.NewOrder(p, 2, 105, exchange.OrderSideBuy, exchange.OrderTypeExchangeLimit); err != nil {
+		t.Fatalf("Test failed. NewOrder returned an error: %s", err)
+	}
+
+	// the limit order was filled below its rate
+	mock.orders = []*exchange.Order{{OrderID: "1", CurrencyPair: p, Amount: 2, FilledAmount: 2, Rate: 105,
+		Status: exchange.OrderStatusFilled}}
+	mock.trades = []exchange.Trade{
+		{OrderID: "1", Amount: 1, Price: 100},
+		{OrderID: "2", Amount: 5, Price: 110},
+		{OrderID: "1", Amount: 1, Price: 102},
+	}
+	exch.GetOrders([]pair.CurrencyPair{p})
+	records := tracker.Records()
+	if len(records) != 1 || records[0].AveragePrice != 101 || records[0].FilledAt.IsZero() {
+		t.Errorf("Test failed. Expected the average price of the trades, got %+v", records)
+	}
+	if len(tracker.orders) != 0 {
+		t.Error("Test failed. Expected the filled order to no longer be tracked")
+	}
+}
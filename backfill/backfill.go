@@ -0,0 +1,192 @@
+// Package backfill imports the past trades of the account on each exchange into the store, so the
+// P&L and tax reports have the complete trade history from the first time the bot runs with
+// credentials. The progress is saved after every page of trades, an interrupted backfill resumes
+// where it left off the next time it runs.
+package backfill
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/storage"
+)
+
+const (
+	tradesBucket = "trades"
+	stateBucket  = "backfill"
+	// DefaultPageSize is the number of trades requested per page
+	DefaultPageSize = 500
+	// DefaultPageDelay is the delay between page requests
+	DefaultPageDelay = 2 * time.Second
+)
+
+// State is the progress of the backfill of an exchange
+type State struct {
+	// Time of the newest trade imported for each currency pair, keyed by the pair delimited by "/"
+	Cursors map[string]time.Time `json:"cursors"`
+	// Number of trades imported
+	Trades int `json:"trades"`
+	// Zero until the trades of every currency pair have been imported
+	Completed time.Time `json:"completed,omitempty"`
+}
+
+// Backfiller pages through the trade history of exchanges and saves the trades in a store
+type Backfiller struct {
+	store    storage.Store
+	PageSize int
+	// Delay between page requests, keeps the backfill within the rate limits of the exchange
+	PageDelay time.Duration
+	// Trades executed before this aren't imported, zero imports all the available history
+	Start time.Time
+}
+
+// New returns a backfiller that saves the trades in the store
+func New(store storage.Store) *Backfiller {
+	return &Backfiller{
+		store:     store,
+		PageSize:  DefaultPageSize,
+		PageDelay: DefaultPageDelay,
+	}
+}
+
+// State returns the progress of the backfill of an exchange
+func (b *Backfiller) State(exchangeName string) (State, error) {
+	state := State{}
+	if err := b.store.Get(stateBucket, exchangeName, &state); err != nil && err != storage.ErrNotFound {
+		return state, err
+	}
+	if state.Cursors == nil {
+		state.Cursors = make(map[string]time.Time)
+	}
+	return state, nil
+}
+
+// Run imports the trades of the currency pairs that haven't been imported yet, nothing is done if
+// the backfill of the exchange has already completed. Returns the number of trades imported,
+// the backfill stops early (without completing) if stop is closed.
+func (b *Backfiller) Run(exchangeName string, provider exchange.TradeHistoryProvider,
+	pairs []pair.CurrencyPair, stop <-chan struct{}) (int, error) {
+	state, err := b.State(exchangeName)
+	if err != nil || !state.Completed.IsZero() {
+		return 0, err
+	}
+
+	imported := 0
+	for _, p := range pairs {
+		n, done, err := b.backfillPair(exchangeName, provider, p, &state, stop)
+		imported += n
+		if err != nil || !done {
+			return imported, err
+		}
+	}
+	state.Completed = time.Now()
+	return imported, b.store.Put(stateBucket, exchangeName, &state)
+}
+
+// backfillPair pages through the trades of a currency pair from the saved cursor, returns false
+// if it was stopped before reaching the newest trade.
+func (b *Backfiller) backfillPair(exchangeName string, provider exchange.TradeHistoryProvider,
+	p pair.CurrencyPair, state *State, stop <-chan struct{}) (int, bool, error) {
+	pairKey := p.Display("/", true).String()
+	since, ok := state.Cursors[pairKey]
+	if !ok {
+		since = b.Start
+	}
+
+	imported := 0
+	// IDs of the trades imported at the cursor time, pages start at the cursor (inclusive) so the
+	// first trades of each page are usually the last trades of the previous page
+	seen := make(map[string]bool)
+	for {
+		select {
+		case <-stop:
+			return imported, false, nil
+		default:
+		}
+
+		trades, err := provider.GetAccountTrades(p, since, b.PageSize)
+		if err != nil {
+			return imported, false, err
+		}
+		for i := range trades {
+			t := &trades[i]
+			if seen[t.ID] {
+				continue
+			}
+			t.Exchange = exchangeName
+			if err = b.store.Put(tradesBucket, tradeKey(t), t); err != nil {
+				return imported, false, err
+			}
+			imported++
+			state.Trades++
+		}
+
+		if len(trades) == 0 {
+			break
+		}
+		last := trades[len(trades)-1].Time
+		if !last.After(since) {
+			if len(trades) >= b.PageSize {
+				// A full page of trades executed at the same time, the exchange can't page past it
+				log.Printf("%s: Trade backfill of %s stopped at %s, too many trades at the same time.\n",
+					exchangeName, pairKey, since)
+			}
+			break
+		}
+		seen = make(map[string]bool)
+		for i := range trades {
+			if trades[i].Time.Equal(last) {
+				seen[trades[i].ID] = true
+			}
+		}
+		since = last
+		state.Cursors[pairKey] = since
+		if err = b.store.Put(stateBucket, exchangeName, state); err != nil {
+			return imported, false, err
+		}
+		if len(trades) < b.PageSize {
+			break
+		}
+
+		select {
+		case <-stop:
+			return imported, false, nil
+		case <-time.After(b.PageDelay):
+		}
+	}
+	return imported, true, nil
+}
+
+// tradeKey returns the key of a trade, keys sort the trades of an exchange chronologically
+func tradeKey(t *exchange.Trade) string {
+	return strings.Join([]string{
+		t.Exchange,
+		t.Time.UTC().Format("20060102T150405.000000000"),
+		t.CurrencyPair.Display("/", true).String(),
+		t.ID,
+	}, "|")
+}
+
+// LoadTrades returns the imported trades of an exchange sorted from oldest to newest, or the
+// trades of every exchange if exchangeName is empty (sorted by exchange first).
+func LoadTrades(store storage.Store, exchangeName string) ([]exchange.Trade, error) {
+	keys, err := store.Keys(tradesBucket)
+	if err != nil {
+		return nil, err
+	}
+	trades := []exchange.Trade{}
+	for _, key := range keys {
+		if exchangeName != "" && !strings.HasPrefix(key, exchangeName+"|") {
+			continue
+		}
+		var t exchange.Trade
+		if err = store.Get(tradesBucket, key, &t); err != nil {
+			return nil, err
+		}
+		trades = append(trades, t)
+	}
+	return trades, nil
+}
@@ -0,0 +1,133 @@
+package backfill
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/storage"
+)
+
+type mockProvider struct {
+	trades   []exchange.Trade
+	requests int
+	failAt   int
+}
+
+func (m *mockProvider) GetAccountTrades(p pair.CurrencyPair, since time.Time, limit int) ([]exchange.Trade, error) {
+	m.requests++
+	if m.failAt > 0 && m.requests == m.failAt {
+		return nil, errors.New("rate limited")
+	}
+	result := []exchange.Trade{}
+	for _, t := range m.trades {
+		if !t.Time.Before(since) && t.CurrencyPair.Equal(p) && len(result) < limit {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+func newMockProvider(p pair.CurrencyPair, n int) *mockProvider {
+	m := &mockProvider{}
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		m.trades = append(m.trades, exchange.Trade{
+			ID:           strconv.Itoa(i),
+			CurrencyPair: p,
+			Side:         exchange.OrderSideBuy,
+			Amount:       1,
+			Price:        100,
+			// Pairs of trades executed at the same time
+			Time: start.Add(time.Duration(i/2) * time.Minute),
+		})
+	}
+	return m
+}
+
+func TestRun(t *testing.T) {
+	p := pair.NewCurrencyPair("BTC", "USD")
+	provider := newMockProvider(p, 25)
+	store := storage.NewMemoryStore()
+	b := New(store)
+	b.PageSize = 5
+	b.PageDelay = 0
+
+	n, err := b.Run("Bitfinex", provider, []pair.CurrencyPair{p}, nil)
+	if err != nil {
+		t.Fatalf("Test failed. Run error: %s", err)
+	}
+	if n != 25 {
+		t.Errorf("Test failed. Expected 25 trades imported, got %d", n)
+	}
+	trades, err := LoadTrades(store, "Bitfinex")
+	if err != nil {
+		t.Fatalf("Test failed. LoadTrades error: %s", err)
+	}
+	if len(trades) != 25 || trades[0].ID != "0" || trades[24].Exchange != "Bitfinex" {
+		t.Errorf("Test failed. Unexpected trades %+v", trades)
+	}
+	for i := 1; i < len(trades); i++ {
+		if trades[i].Time.Before(trades[i-1].Time) {
+			t.Error("Test failed. Trades aren't sorted")
+		}
+	}
+	state, err := b.State("Bitfinex")
+	if err != nil || state.Completed.IsZero() || state.Trades != 25 {
+		t.Errorf("Test failed. Unexpected state %+v %v", state, err)
+	}
+
+	// Completed backfills aren't repeated
+	requests := provider.requests
+	if n, err = b.Run("Bitfinex", provider, []pair.CurrencyPair{p}, nil); n != 0 || err != nil ||
+		provider.requests != requests {
+		t.Errorf("Test failed. Expected the backfill to be skipped, got %d %v", n, err)
+	}
+}
+
+func TestRunResume(t *testing.T) {
+	p := pair.NewCurrencyPair("ETH", "BTC")
+	provider := newMockProvider(p, 20)
+	provider.failAt = 3
+	store := storage.NewMemoryStore()
+	b := New(store)
+	b.PageSize = 4
+	b.PageDelay = 0
+
+	if _, err := b.Run("Poloniex", provider, []pair.CurrencyPair{p}, nil); err == nil {
+		t.Fatal("Test failed. Expected an error")
+	}
+	state, err := b.State("Poloniex")
+	if err != nil || !state.Completed.IsZero() || state.Cursors["ETH/BTC"].IsZero() {
+		t.Fatalf("Test failed. Unexpected state %+v %v", state, err)
+	}
+
+	if _, err = b.Run("Poloniex", provider, []pair.CurrencyPair{p}, nil); err != nil {
+		t.Fatalf("Test failed. Run error: %s", err)
+	}
+	trades, err := LoadTrades(store, "Poloniex")
+	if err != nil || len(trades) != 20 {
+		t.Errorf("Test failed. Expected 20 trades, got %d %v", len(trades), err)
+	}
+	if trades, _ = LoadTrades(store, "Bitfinex"); len(trades) != 0 {
+		t.Errorf("Test failed. Expected no Bitfinex trades, got %d", len(trades))
+	}
+}
+
+func TestRunStop(t *testing.T) {
+	p := pair.NewCurrencyPair("BTC", "USD")
+	store := storage.NewMemoryStore()
+	b := New(store)
+	stop := make(chan struct{})
+	close(stop)
+
+	if _, err := b.Run("Bitfinex", newMockProvider(p, 10), []pair.CurrencyPair{p}, stop); err != nil {
+		t.Fatalf("Test failed. Run error: %s", err)
+	}
+	if state, _ := b.State("Bitfinex"); !state.Completed.IsZero() {
+		t.Error("Test failed. Stopped backfill shouldn't be completed")
+	}
+}
@@ -1,6 +1,8 @@
 package common
 
 import (
+	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/md5"
 	"crypto/sha1"
@@ -260,6 +262,12 @@ func CalculateNetProfit(amount, priceThen, priceNow, costs float64) float64 {
 // SendHTTPRequest sends a request using the http package and returns a response
 // as a string and an error
 func SendHTTPRequest(method, path string, headers map[string]string, body io.Reader) (string, error) {
+	return SendHTTPRequestContext(context.Background(), method, path, headers, body)
+}
+
+// SendHTTPRequestContext is SendHTTPRequest, the request is cancelled when the context is done
+func SendHTTPRequestContext(ctx context.Context, method, path string, headers map[string]string,
+	body io.Reader) (string, error) {
 	upperMethod := strings.ToUpper(method)
 
 	if upperMethod != "POST" && upperMethod != "GET" && upperMethod != "DELETE" {
@@ -272,6 +280,8 @@ func SendHTTPRequest(method, path string, headers map[string]string, body io.Rea
 		return "", err
 	}
 
+	req = req.WithContext(ctx)
+
 	for k, v := range headers {
 		req.Header.Add(k, v)
 	}
@@ -306,6 +316,12 @@ func SendHTTPRequest(method, path string, headers map[string]string, body io.Rea
 // SendHTTPRequest2 sends an HTTP request.
 // Returns the response body and status code, or an error.
 func SendHTTPRequest2(method, path string, headers http.Header, body io.Reader) (string, int, error) {
+	return SendHTTPRequest2Context(context.Background(), method, path, headers, body)
+}
+
+// SendHTTPRequest2Context is SendHTTPRequest2, the request is cancelled when the context is done
+func SendHTTPRequest2Context(ctx context.Context, method, path string, headers http.Header,
+	body io.Reader) (string, int, error) {
 	upperMethod := strings.ToUpper(method)
 
 	if upperMethod != "POST" && upperMethod != "GET" && upperMethod != "DELETE" {
@@ -318,6 +334,7 @@ func SendHTTPRequest2(method, path string, headers http.Header, body io.Reader)
 		return "", 0, err
 	}
 
+	req = req.WithContext(ctx)
 	req.Header = headers
 
 	requestDump, err := httputil.DumpRequest(req, true)
@@ -351,11 +368,18 @@ func SendHTTPRequest2(method, path string, headers http.Header, body io.Reader)
 // decodes the response into a struct pointer you have supplied. Returns an error
 // on failure.
 func SendHTTPGetRequest(url string, jsonDecode, isVerbose bool, result interface{}) error {
+	return SendHTTPGetRequestContext(context.Background(), url, jsonDecode, isVerbose, result)
+}
+
+// SendHTTPGetRequestContext is SendHTTPGetRequest, the request is cancelled when the context is
+// done
+func SendHTTPGetRequestContext(ctx context.Context, url string, jsonDecode, isVerbose bool,
+	result interface{}) error {
 	if isVerbose {
 		log.Println("Raw URL: ", url)
 	}
 
-	res, err := http.Get(url)
+	res, err := httpGet(ctx, url)
 	if err != nil {
 		return err
 	}
@@ -386,6 +410,56 @@ func SendHTTPGetRequest(url string, jsonDecode, isVerbose bool, result interface
 	return nil
 }
 
+// SendHTTPGetRequestStream sends a simple get request and decodes the JSON
+// response directly from the response body, avoiding reading the whole
+// response into memory first. Use it for endpoints with very large responses.
+func SendHTTPGetRequestStream(url string, isVerbose bool, result interface{}) error {
+	return SendHTTPGetRequestStreamContext(context.Background(), url, isVerbose, result)
+}
+
+// SendHTTPGetRequestStreamContext is SendHTTPGetRequestStream, the request is cancelled when the
+// context is done
+func SendHTTPGetRequestStreamContext(ctx context.Context, url string, isVerbose bool,
+	result interface{}) error {
+	if isVerbose {
+		log.Println("Raw URL: ", url)
+	}
+
+	res, err := httpGet(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != 200 {
+		return fmt.Errorf("common.SendHTTPGetRequestStream() error: HTTP status code %d", res.StatusCode)
+	}
+
+	var body io.Reader = res.Body
+	var raw bytes.Buffer
+	if isVerbose {
+		body = io.TeeReader(res.Body, &raw)
+	}
+
+	err = JSONDecodeStream(body, result)
+	if isVerbose {
+		log.Println("Raw Resp: ", raw.String())
+	}
+	return err
+}
+
+// Client used by the GET helpers, the timeout also bounds requests that aren't bound to a context
+var httpGetClient = &http.Client{Timeout: 30 * time.Second}
+
+// httpGet is http.Get with a context
+func httpGet(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return httpGetClient.Do(req.WithContext(ctx))
+}
+
 // JSONEncode encodes structure data into JSON
 func JSONEncode(v interface{}) ([]byte, error) {
 	return json.Marshal(v)
@@ -399,6 +473,14 @@ func JSONDecode(data []byte, to interface{}) error {
 	return json.Unmarshal(data, to)
 }
 
+// JSONDecodeStream decodes a JSON value read from r into a structure
+func JSONDecodeStream(r io.Reader, to interface{}) error {
+	if !StringContains(reflect.ValueOf(to).Type().String(), "*") {
+		return errors.New("json decode error - memory address not supplied")
+	}
+	return json.NewDecoder(r).Decode(to)
+}
+
 // EncodeURLValues concatenates url values onto a url string and returns a
 // string
 func EncodeURLValues(url string, values url.Values) string {
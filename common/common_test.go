@@ -2,7 +2,10 @@ package common
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"reflect"
 	"strings"
@@ -502,6 +505,72 @@ func TestSendHTTPGetRequest(t *testing.T) {
 	}
 }
 
+func TestSendHTTPGetRequestStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"BTC_LTC":{"last":"0.0251"},"BTC_ETH":{"last":"0.0712"}}`))
+	}))
+	defer server.Close()
+
+	result := map[string]struct {
+		Last float64 `json:"last,string"`
+	}{}
+	err := SendHTTPGetRequestStream(server.URL, true, &result)
+	if err != nil {
+		t.Fatalf("Test failed - common SendHTTPGetRequestStream error: %s", err)
+	}
+	if len(result) != 2 || result["BTC_ETH"].Last != 0.0712 {
+		t.Errorf("Test failed - common SendHTTPGetRequestStream unexpected result %v", result)
+	}
+
+	err = SendHTTPGetRequestStream(server.URL+"/missing", false, &result)
+	if err == nil {
+		t.Error("Test failed - common SendHTTPGetRequestStream expected HTTP status error")
+	}
+}
+
+func TestSendHTTPRequestContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var result interface{}
+	if err := SendHTTPGetRequestContext(ctx, server.URL, true, false, &result); err == nil {
+		t.Error("Test failed - common SendHTTPGetRequestContext expected the request to be cancelled")
+	}
+	if err := SendHTTPGetRequestStreamContext(ctx, server.URL, false, &result); err == nil {
+		t.Error("Test failed - common SendHTTPGetRequestStreamContext expected the request to be cancelled")
+	}
+	if _, err := SendHTTPRequestContext(ctx, "GET", server.URL, nil, nil); err == nil {
+		t.Error("Test failed - common SendHTTPRequestContext expected the request to be cancelled")
+	}
+	if _, _, err := SendHTTPRequest2Context(ctx, "GET", server.URL, http.Header{}, nil); err == nil {
+		t.Error("Test failed - common SendHTTPRequest2Context expected the request to be cancelled")
+	}
+}
+
+func TestJSONDecodeStream(t *testing.T) {
+	t.Parallel()
+	var result struct {
+		Name string `json:"name"`
+	}
+	err := JSONDecodeStream(strings.NewReader(`{"name":"cryptofiend"}`), &result)
+	if err != nil || result.Name != "cryptofiend" {
+		t.Errorf("Test failed - common JSONDecodeStream got %v, %v", result, err)
+	}
+	if err = JSONDecodeStream(strings.NewReader(`{}`), result); err == nil {
+		t.Error("Test failed - common JSONDecodeStream expected error for non-pointer")
+	}
+}
+
 func TestJSONEncode(t *testing.T) {
 	type test struct {
 		Status int `json:"status"`
@@ -697,3 +766,77 @@ func TestGetURIPath(t *testing.T) {
 		}
 	}
 }
+
+// benchmarkOrderbooks is a Poloniex style response with many large orderbooks
+var benchmarkOrderbooks = func() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i := 0; i < 100; i++ {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		fmt.Fprintf(&buf, `"BTC_%d":{"asks":[`, i)
+		for j := 0; j < 100; j++ {
+			if j > 0 {
+				buf.WriteString(",")
+			}
+			fmt.Fprintf(&buf, `["0.%08d",%d.5]`, j+1, j)
+		}
+		buf.WriteString(`],"isFrozen":"0","seq":1}`)
+	}
+	buf.WriteString("}")
+	return buf.Bytes()
+}()
+
+type benchmarkOrderbook struct {
+	Asks     [][]interface{} `json:"asks"`
+	IsFrozen string          `json:"isFrozen"`
+	Seq      int64           `json:"seq"`
+}
+
+func BenchmarkGetHMAC(b *testing.B) {
+	input := []byte("/api/v1/order/new" + strings.Repeat("nonce=1234567890&", 4))
+	key := []byte("b0aa4c8ec4c3a5fdc5a0f8a34fbd8f8a")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		GetHMAC(HashSHA512_384, input, key)
+	}
+}
+
+// TestGetHMACAllocs guards against regressions in request signing, which runs for every
+// authenticated request.
+func TestGetHMACAllocs(t *testing.T) {
+	input := []byte("/api/v1/order/new")
+	key := []byte("b0aa4c8ec4c3a5fdc5a0f8a34fbd8f8a")
+	allocs := testing.AllocsPerRun(100, func() {
+		GetHMAC(HashSHA512_384, input, key)
+	})
+	if allocs > 8 {
+		t.Errorf("Test failed. GetHMAC expected at most 8 allocs, got %v", allocs)
+	}
+}
+
+func BenchmarkJSONDecode(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchmarkOrderbooks)))
+	for i := 0; i < b.N; i++ {
+		var result map[string]benchmarkOrderbook
+		// read the whole response first, as SendHTTPGetRequest does
+		var buf bytes.Buffer
+		buf.ReadFrom(bytes.NewReader(benchmarkOrderbooks))
+		if err := JSONDecode(buf.Bytes(), &result); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkJSONDecodeStream(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchmarkOrderbooks)))
+	for i := 0; i < b.N; i++ {
+		var result map[string]benchmarkOrderbook
+		if err := JSONDecodeStream(bytes.NewReader(benchmarkOrderbooks), &result); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package compliance
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/sweep"
+	"github.com/mattkanwisher/cryptofiend/transfers"
+)
+
+// Number of trades requested per page of the trade history
+const tradeHistoryLimit = 500
+
+// History the exchanges may not be able to provide, listed in Activity.Unavailable
+const (
+	UnavailableClosedOrders = "closed orders"
+	UnavailableFills        = "fills"
+	UnavailableLedger       = "deposits & withdrawals"
+)
+
+// Activity holds the orders, fills & movements of funds of an exchange account, as collected from
+// the exchange and the components of the bot that move funds.
+type Activity struct {
+	Orders []*exchange.Order
+	Fills  []exchange.Trade
+	Ledger []exchange.LedgerEntry
+	// History the exchange couldn't provide, so the activity is incomplete
+	Unavailable []string
+}
+
+// Collect fetches the activity of the exchange account between start & end from the history APIs
+// the exchange implements. The fills of the given pairs are fetched, and their active orders if
+// the exchange can't return the closed orders.
+func Collect(exch exchange.IBotExchangeEx, pairs []pair.CurrencyPair, start, end time.Time) (Activity, error) {
+	activity := Activity{}
+
+	if p, ok := exch.(exchange.OrderHistoryProvider); ok {
+		orders, err := p.GetOrderHistory(start, end)
+		if err != nil {
+			return activity, err
+		}
+		activity.Orders = orders
+	} else {
+		orders, err := exch.GetOrders(pairs)
+		if err != nil {
+			return activity, err
+		}
+		activity.Orders = orders
+		activity.Unavailable = append(activity.Unavailable, UnavailableClosedOrders)
+	}
+
+	if p, ok := exch.(exchange.TradeHistoryProvider); ok {
+		for _, currencyPair := range pairs {
+			fills, err := fetchFills(p, currencyPair, start, end)
+			if err != nil {
+				return activity, err
+			}
+			activity.Fills = append(activity.Fills, fills...)
+		}
+	} else {
+		activity.Unavailable = append(activity.Unavailable, UnavailableFills)
+	}
+
+	if p, ok := exch.(exchange.LedgerHistoryProvider); ok {
+		entries, err := p.GetLedgerEntries(start, end)
+		if err != nil {
+			return activity, err
+		}
+		activity.Ledger = entries
+	} else {
+		activity.Unavailable = append(activity.Unavailable, UnavailableLedger)
+	}
+	return activity, nil
+}
+
+// fetchFills pages through the trade history of a currency pair until a trade executed at or
+// after end is returned
+func fetchFills(p exchange.TradeHistoryProvider, currencyPair pair.CurrencyPair, start, end time.Time) ([]exchange.Trade, error) {
+	var result []exchange.Trade
+	seen := make(map[string]bool)
+	since := start
+	for {
+		trades, err := p.GetAccountTrades(currencyPair, since, tradeHistoryLimit)
+		if err != nil {
+			return nil, err
+		}
+		added := 0
+		for _, t := range trades {
+			if !t.Time.Before(end) {
+				return result, nil
+			}
+			// Pages start at the time of the last trade of the previous page, which is returned again
+			if seen[t.ID] {
+				continue
+			}
+			seen[t.ID] = true
+			result = append(result, t)
+			since = t.Time
+			added++
+		}
+		if len(trades) < tradeHistoryLimit || added == 0 {
+			return result, nil
+		}
+	}
+}
+
+// TransferEntries converts the transfers between exchanges recorded by the bot to the withdrawals
+// from & deposits to the exchange.
+func TransferEntries(exchangeName string, recorded []transfers.Transfer) []exchange.LedgerEntry {
+	var entries []exchange.LedgerEntry
+	for _, t := range recorded {
+		if t.From == exchangeName {
+			entries = append(entries, exchange.LedgerEntry{
+				ID:       t.ID,
+				Exchange: exchangeName,
+				Kind:     exchange.LedgerWithdrawal,
+				Currency: t.Currency,
+				Amount:   t.Amount,
+				Fee:      t.Fee,
+				Details:  "to " + t.To,
+				Time:     t.InitiatedAt,
+			})
+		}
+		if t.To == exchangeName {
+			entries = append(entries, exchange.LedgerEntry{
+				ID:       t.ID,
+				Exchange: exchangeName,
+				Kind:     exchange.LedgerDeposit,
+				Currency: t.Currency,
+				// The fee is charged by the source exchange
+				Amount:  t.Amount - t.Fee,
+				Details: "from " + t.From,
+				Time:    t.CompletedAt,
+			})
+		}
+	}
+	return entries
+}
+
+// SweepEntries converts the sweeps made on the exchange to transfers between its wallets, dry runs
+// & failed sweeps are skipped.
+func SweepEntries(exchangeName string, sweeps []sweep.Sweep) []exchange.LedgerEntry {
+	var entries []exchange.LedgerEntry
+	for _, s := range sweeps {
+		if s.Exchange != exchangeName || s.DryRun || s.Error != "" {
+			continue
+		}
+		entries = append(entries, exchange.LedgerEntry{
+			ID:       fmt.Sprintf("sweep/%s/%d", s.Policy, s.Time.Unix()),
+			Exchange: exchangeName,
+			Kind:     exchange.LedgerTransfer,
+			Currency: s.Currency,
+			Amount:   s.Amount,
+			Details:  fmt.Sprintf("%s to %s wallet (policy %s)", s.From, s.To, s.Policy),
+			Time:     s.Time,
+		})
+	}
+	return entries
+}
+
+// FeeSummary holds the fees paid in a currency over the period of a report
+type FeeSummary struct {
+	Currency   string  `json:"currency"`
+	Trading    float64 `json:"trading"`
+	Withdrawal float64 `json:"withdrawal"`
+	// Fees charged on deposits & transfers, and fees that aren't charged on a movement of funds
+	Other float64 `json:"other"`
+	Total float64 `json:"total"`
+}
+
+// Report is the activity of an exchange account over a period, for audits & record-keeping
+type Report struct {
+	Exchange    string    `json:"exchange"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	// History the exchange couldn't provide, so the report is incomplete
+	Unavailable []string               `json:"unavailable,omitempty"`
+	Orders      []*exchange.Order      `json:"orders"`
+	Fills       []exchange.Trade       `json:"fills"`
+	Deposits    []exchange.LedgerEntry `json:"deposits"`
+	Withdrawals []exchange.LedgerEntry `json:"withdrawals"`
+	Transfers   []exchange.LedgerEntry `json:"transfers"`
+	Fees        []exchange.LedgerEntry `json:"fees"`
+	FeeSummary  []FeeSummary           `json:"feeSummary"`
+}
+
+// NewReport builds the report of the activity between start & end, activity outside the period
+// and duplicate records (with the same ID) are dropped. Records are sorted from oldest to newest.
+func NewReport(exchangeName string, start, end time.Time, activity Activity, now time.Time) Report {
+	report := Report{
+		Exchange:    exchangeName,
+		Start:       start,
+		End:         end,
+		GeneratedAt: now,
+		Unavailable: activity.Unavailable,
+		Orders:      []*exchange.Order{},
+		Fills:       []exchange.Trade{},
+		Deposits:    []exchange.LedgerEntry{},
+		Withdrawals: []exchange.LedgerEntry{},
+		Transfers:   []exchange.LedgerEntry{},
+		Fees:        []exchange.LedgerEntry{},
+		FeeSummary:  []FeeSummary{},
+	}
+	inPeriod := func(t time.Time) bool {
+		return !t.Before(start) && t.Before(end)
+	}
+	seen := make(map[string]bool)
+	// unseen returns true the first time a record is seen, records without an ID are never
+	// considered duplicates
+	unseen := func(kind, id string) bool {
+		if id == "" {
+			return true
+		}
+		key := kind + "/" + id
+		if seen[key] {
+			return false
+		}
+		seen[key] = true
+		return true
+	}
+
+	for _, o := range activity.Orders {
+		// Active orders may have been created before the period, but they were still open in it
+		created := time.Unix(o.CreatedAt, 0)
+		if created.Before(end) && (inPeriod(created) || o.Status == exchange.OrderStatusActive) &&
+			unseen("order", o.OrderID) {
+			report.Orders = append(report.Orders, o)
+		}
+	}
+	sort.SliceStable(report.Orders, func(i, j int) bool {
+		return report.Orders[i].CreatedAt < report.Orders[j].CreatedAt
+	})
+
+	fees := make(map[string]*FeeSummary)
+	feeSummary := func(currency string) *FeeSummary {
+		currency = strings.ToUpper(currency)
+		s, ok := fees[currency]
+		if !ok {
+			s = &FeeSummary{Currency: currency}
+			fees[currency] = s
+		}
+		return s
+	}
+
+	for _, f := range activity.Fills {
+		if !inPeriod(f.Time) || !unseen("fill", f.ID) {
+			continue
+		}
+		report.Fills = append(report.Fills, f)
+		if f.Fee != 0 {
+			feeCurrency := f.FeeCurrency
+			if feeCurrency == "" {
+				feeCurrency = f.CurrencyPair.SecondCurrency.String()
+			}
+			feeSummary(feeCurrency).Trading += f.Fee
+		}
+	}
+	sort.SliceStable(report.Fills, func(i, j int) bool { return report.Fills[i].Time.Before(report.Fills[j].Time) })
+
+	for _, e := range activity.Ledger {
+		if !inPeriod(e.Time) || !unseen(e.Kind, e.ID) {
+			continue
+		}
+		switch e.Kind {
+		case exchange.LedgerDeposit:
+			report.Deposits = append(report.Deposits, e)
+		case exchange.LedgerWithdrawal:
+			report.Withdrawals = append(report.Withdrawals, e)
+		case exchange.LedgerTransfer:
+			report.Transfers = append(report.Transfers, e)
+		default:
+			report.Fees = append(report.Fees, e)
+		}
+		switch {
+		case e.Kind == exchange.LedgerWithdrawal && e.Fee != 0:
+			feeSummary(e.Currency).Withdrawal += e.Fee
+		case e.Kind == exchange.LedgerFee:
+			feeSummary(e.Currency).Other += e.Amount + e.Fee
+		case e.Fee != 0:
+			feeSummary(e.Currency).Other += e.Fee
+		}
+	}
+	for _, entries := range [][]exchange.LedgerEntry{report.Deposits, report.Withdrawals, report.Transfers, report.Fees} {
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	}
+
+	for _, s := range fees {
+		s.Total = s.Trading + s.Withdrawal + s.Other
+		report.FeeSummary = append(report.FeeSummary, *s)
+	}
+	sort.Slice(report.FeeSummary, func(i, j int) bool {
+		return report.FeeSummary[i].Currency < report.FeeSummary[j].Currency
+	})
+	return report
+}
@@ -0,0 +1,156 @@
+package compliance
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/sweep"
+	"github.com/mattkanwisher/cryptofiend/transfers"
+)
+
+var testStart = time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+
+type mockExchange struct {
+	exchange.IBotExchangeEx
+	orders []*exchange.Order
+}
+
+func (m *mockExchange) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return m.orders, nil
+}
+
+// mockHistoryExchange returns a page of trades per day, starting at the since time
+type mockHistoryExchange struct {
+	mockExchange
+	trades []exchange.Trade
+	pages  int
+}
+
+func (m *mockHistoryExchange) GetAccountTrades(p pair.CurrencyPair, since time.Time, limit int) ([]exchange.Trade, error) {
+	m.pages++
+	var result []exchange.Trade
+	for _, t := range m.trades {
+		if !t.Time.Before(since) && len(result) < limit {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockHistoryExchange) GetOrderHistory(start, end time.Time) ([]*exchange.Order, error) {
+	return m.orders, nil
+}
+
+func (m *mockHistoryExchange) GetLedgerEntries(start, end time.Time) ([]exchange.LedgerEntry, error) {
+	return []exchange.LedgerEntry{
+		{ID: "w1", Kind: exchange.LedgerWithdrawal, Currency: "BTC", Amount: 1, Fee: 0.0005,
+			TxID: "abc", Time: testStart.Add(time.Hour)},
+		{ID: "f1", Kind: exchange.LedgerFee, Currency: "USD", Amount: 2, Time: testStart.Add(2 * time.Hour)},
+	}, nil
+}
+
+func TestCollect(t *testing.T) {
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+	end := testStart.AddDate(0, 1, 0)
+	activity, err := Collect(&mockExchange{orders: []*exchange.Order{{OrderID: "1"}}},
+		[]pair.CurrencyPair{btcusd}, testStart, end)
+	if err != nil {
+		t.Fatalf("Test failed. Collect error: %s", err)
+	}
+	if len(activity.Orders) != 1 || len(activity.Unavailable) != 3 {
+		t.Errorf("Test failed. Expected only the active orders, got %+v", activity)
+	}
+
+	exch := &mockHistoryExchange{}
+	for i := 0; i < tradeHistoryLimit+10; i++ {
+		exch.trades = append(exch.trades, exchange.Trade{ID: string(rune('a'+i%26)) + string(rune('0'+i/26)),
+			CurrencyPair: btcusd, Amount: 1, Price: 6000, Fee: 0.01, FeeCurrency: "USD",
+			Time: testStart.Add(time.Duration(i) * time.Minute)})
+	}
+	// Executed after the end of the period
+	exch.trades = append(exch.trades, exchange.Trade{ID: "late", Time: end})
+	activity, err = Collect(exch, []pair.CurrencyPair{btcusd}, testStart, end)
+	if err != nil {
+		t.Fatalf("Test failed. Collect error: %s", err)
+	}
+	if len(activity.Fills) != tradeHistoryLimit+10 || exch.pages != 2 || len(activity.Unavailable) != 0 ||
+		len(activity.Ledger) != 2 {
+		t.Errorf("Test failed. Unexpected activity: %d fills in %d pages, unavailable %v", len(activity.Fills),
+			exch.pages, activity.Unavailable)
+	}
+}
+
+func TestReport(t *testing.T) {
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+	end := testStart.AddDate(0, 1, 0)
+	activity, _ := Collect(&mockHistoryExchange{}, nil, testStart, end)
+	activity.Orders = []*exchange.Order{
+		{OrderID: "2", CurrencyPair: btcusd, Status: exchange.OrderStatusFilled, CreatedAt: testStart.Add(time.Hour).Unix()},
+		{OrderID: "1", CurrencyPair: btcusd, Status: exchange.OrderStatusActive, CreatedAt: testStart.Add(-time.Hour).Unix()},
+		// Closed before the period
+		{OrderID: "0", CurrencyPair: btcusd, Status: exchange.OrderStatusFilled, CreatedAt: testStart.Add(-time.Hour).Unix()},
+	}
+	activity.Fills = []exchange.Trade{
+		{ID: "t1", OrderID: "2", CurrencyPair: btcusd, Side: exchange.OrderSideBuy, Amount: 1, Price: 6000,
+			Fee: 12, FeeCurrency: "USD", Time: testStart.Add(time.Hour)},
+		{ID: "t1", OrderID: "2", CurrencyPair: btcusd, Amount: 1, Price: 6000, Fee: 12, Time: testStart.Add(time.Hour)},
+	}
+	activity.Ledger = append(activity.Ledger, TransferEntries("Bitfinex", []transfers.Transfer{
+		{ID: "w1", Currency: "BTC", From: "Bitfinex", To: "Kraken", Amount: 1, Fee: 0.0005,
+			InitiatedAt: testStart.Add(time.Hour), CompletedAt: testStart.Add(2 * time.Hour)},
+		{ID: "d1", Currency: "ETH", From: "Kraken", To: "Bitfinex", Amount: 10, Fee: 0.01,
+			InitiatedAt: testStart.Add(3 * time.Hour), CompletedAt: testStart.Add(4 * time.Hour)},
+	})...)
+	activity.Ledger = append(activity.Ledger, SweepEntries("Bitfinex", []sweep.Sweep{
+		{Policy: "idle", Exchange: "Bitfinex", Currency: "USD", From: "exchange", To: "funding", Amount: 100,
+			Time: testStart.Add(5 * time.Hour)},
+		{Policy: "idle", Exchange: "Bitfinex", Currency: "USD", Amount: 100, DryRun: true, Time: testStart},
+		{Policy: "idle", Exchange: "Kraken", Currency: "USD", Amount: 100, Time: testStart},
+	})...)
+
+	report := NewReport("Bitfinex", testStart, end, activity, end)
+	if len(report.Orders) != 2 || report.Orders[0].OrderID != "1" || len(report.Fills) != 1 {
+		t.Errorf("Test failed. Unexpected orders & fills %+v %+v", report.Orders, report.Fills)
+	}
+	// The withdrawal reported by the exchange & recorded by the bot is only listed once
+	if len(report.Withdrawals) != 1 || len(report.Deposits) != 1 || report.Deposits[0].Amount != 9.99 ||
+		len(report.Transfers) != 1 || len(report.Fees) != 1 {
+		t.Errorf("Test failed. Unexpected ledger entries %+v", report)
+	}
+	if len(report.FeeSummary) != 2 || report.FeeSummary[0].Currency != "BTC" ||
+		report.FeeSummary[0].Withdrawal != 0.0005 || report.FeeSummary[1].Trading != 12 ||
+		report.FeeSummary[1].Other != 2 || report.FeeSummary[1].Total != 14 {
+		t.Errorf("Test failed. Unexpected fee summary %+v", report.FeeSummary)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, report); err != nil {
+		t.Fatalf("Test failed. WriteCSV error: %s", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Test failed. Invalid CSV: %s", err)
+	}
+	// Header, 2 orders, 1 fill & 4 ledger entries
+	if len(records) != 8 || records[1][1] != "order" || records[3][1] != "fill" || records[4][1] != "deposit" ||
+		records[5][15] != "tx abc" {
+		t.Errorf("Test failed. Unexpected CSV records %v", records)
+	}
+
+	buf.Reset()
+	if err = WriteText(&buf, report); err != nil {
+		t.Fatalf("Test failed. WriteText error: %s", err)
+	}
+	text := buf.String()
+	for _, s := range []string{"Activity report: Bitfinex", "Orders (2)", "Fills (1)", "Withdrawals (1)",
+		"exchange to funding wallet (policy idle)", "Fees paid"} {
+		if !strings.Contains(text, s) {
+			t.Errorf("Test failed. Expected the text report to contain '%s':\n%s", s, text)
+		}
+	}
+}
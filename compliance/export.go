@@ -0,0 +1,135 @@
+package compliance
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+// Kinds of the CSV records that aren't ledger entries
+const (
+	recordOrder = "order"
+	recordFill  = "fill"
+)
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// WriteCSV writes the orders, fills & ledger entries of the report to w in CSV format (with a
+// header row), one record per row. The record column holds the kind of the record (order, fill or
+// the ledger entry kind), columns that don't apply to a record are empty.
+func WriteCSV(w io.Writer, report Report) error {
+	writer := csv.NewWriter(w)
+	header := []string{"exchange", "record", "time", "id", "orderId", "pair", "side", "type", "status",
+		"currency", "amount", "filled", "price", "fee", "feeCurrency", "details"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	var rows [][]string
+	for _, o := range report.Orders {
+		rows = append(rows, []string{report.Exchange, recordOrder, formatTime(time.Unix(o.CreatedAt, 0)),
+			o.OrderID, "", o.CurrencyPair.Display("/", true).String(), string(o.Side), string(o.Type),
+			string(o.Status), "", formatFloat(o.Amount), formatFloat(o.FilledAmount), formatFloat(o.Rate),
+			"", "", ""})
+	}
+	for _, f := range report.Fills {
+		rows = append(rows, []string{report.Exchange, recordFill, formatTime(f.Time), f.ID, f.OrderID,
+			f.CurrencyPair.Display("/", true).String(), string(f.Side), "", "", "", formatFloat(f.Amount),
+			"", formatFloat(f.Price), formatFloat(f.Fee), f.FeeCurrency, ""})
+	}
+	for _, entries := range [][]exchange.LedgerEntry{report.Deposits, report.Withdrawals, report.Transfers, report.Fees} {
+		for _, e := range entries {
+			details := e.Details
+			if e.TxID != "" {
+				details = strings.TrimSpace(details + " tx " + e.TxID)
+			}
+			rows = append(rows, []string{report.Exchange, e.Kind, formatTime(e.Time), e.ID, "", "", "", "", "",
+				e.Currency, formatFloat(e.Amount), "", "", formatFloat(e.Fee), e.Currency, details})
+		}
+	}
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write activity report: %s", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteText writes the report to w as plain text, with a table for each kind of activity followed
+// by the fees paid per currency.
+func WriteText(w io.Writer, report Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Activity report: %s\n", report.Exchange)
+	fmt.Fprintf(tw, "Period: %s to %s\n", formatTime(report.Start), formatTime(report.End))
+	fmt.Fprintf(tw, "Generated: %s\n", formatTime(report.GeneratedAt))
+	if len(report.Unavailable) > 0 {
+		fmt.Fprintf(tw, "Incomplete, not provided by the exchange: %s\n", strings.Join(report.Unavailable, ", "))
+	}
+
+	fmt.Fprintf(tw, "\nOrders (%d)\n", len(report.Orders))
+	if len(report.Orders) > 0 {
+		fmt.Fprintln(tw, "Created\tID\tPair\tSide\tType\tAmount\tFilled\tPrice\tStatus\t")
+		for _, o := range report.Orders {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", formatTime(time.Unix(o.CreatedAt, 0)),
+				o.OrderID, o.CurrencyPair.Display("/", true), o.Side, o.Type, formatFloat(o.Amount),
+				formatFloat(o.FilledAmount), formatFloat(o.Rate), o.Status)
+		}
+	}
+
+	fmt.Fprintf(tw, "\nFills (%d)\n", len(report.Fills))
+	if len(report.Fills) > 0 {
+		fmt.Fprintln(tw, "Time\tID\tOrder ID\tPair\tSide\tAmount\tPrice\tFee\t")
+		for _, f := range report.Fills {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s %s\t\n", formatTime(f.Time), f.ID, f.OrderID,
+				f.CurrencyPair.Display("/", true), f.Side, formatFloat(f.Amount), formatFloat(f.Price),
+				formatFloat(f.Fee), f.FeeCurrency)
+		}
+	}
+
+	sections := []struct {
+		title   string
+		entries []exchange.LedgerEntry
+	}{
+		{"Deposits", report.Deposits},
+		{"Withdrawals", report.Withdrawals},
+		{"Transfers", report.Transfers},
+		{"Other fees", report.Fees},
+	}
+	for _, section := range sections {
+		fmt.Fprintf(tw, "\n%s (%d)\n", section.title, len(section.entries))
+		if len(section.entries) == 0 {
+			continue
+		}
+		fmt.Fprintln(tw, "Time\tID\tCurrency\tAmount\tFee\tDetails\t")
+		for _, e := range section.entries {
+			details := e.Details
+			if e.TxID != "" {
+				details = strings.TrimSpace(details + " tx " + e.TxID)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t\n", formatTime(e.Time), e.ID, e.Currency,
+				formatFloat(e.Amount), formatFloat(e.Fee), details)
+		}
+	}
+
+	fmt.Fprintf(tw, "\nFees paid\n")
+	if len(report.FeeSummary) > 0 {
+		fmt.Fprintln(tw, "Currency\tTrading\tWithdrawal\tOther\tTotal\t")
+		for _, s := range report.FeeSummary {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n", s.Currency, formatFloat(s.Trading),
+				formatFloat(s.Withdrawal), formatFloat(s.Other), formatFloat(s.Total))
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,566 @@
+// Package conditional implements synthetic conditional orders (trailing stops, OCO and scheduled
+// orders) for exchanges that don't support them natively. The orders are persisted whenever
+// their state changes, and reconciled against the live exchange orders on startup so that a
+// restart never orphans a protective stop.
+package conditional
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/storage"
+)
+
+const conditionalOrdersBucket = "conditional_orders"
+
+var (
+	// ErrOrderNotFound is returned when a conditional order doesn't exist
+	ErrOrderNotFound = errors.New("conditional order not found")
+	// ErrUnknownExchange is returned when a conditional order is added for an exchange that
+	// hasn't been added to the manager
+	ErrUnknownExchange = errors.New("unknown exchange")
+	// ErrInvalidOrder is returned when the parameters of a conditional order are invalid
+	ErrInvalidOrder = errors.New("invalid conditional order")
+	// ErrOrderPlacing is returned when cancelling a conditional order while one of its exchange
+	// orders is being placed, or hasn't been reconciled since the exchange failed to place it
+	ErrOrderPlacing = errors.New("conditional order is being placed, retry once it's reconciled")
+)
+
+// notPlacedErrors are the errors that mean the exchange (or a decorator) rejected an order
+// without placing it, an order may have been placed despite any other error.
+var notPlacedErrors = []error{
+	exchange.ErrInsufficientFunds, exchange.ErrMinTradeSize, exchange.ErrInvalidAPIKey,
+	exchange.ErrInvalidNonce, exchange.ErrRateLimited, exchange.ErrExchangeMaintenance,
+	exchange.ErrOrderRateExceeded, exchange.ErrNotionalExceeded, exchange.ErrTradingPaused,
+	exchange.ErrReadOnly, exchange.ErrExchangeDegraded, exchange.ErrStaleMarketData,
+	exchange.ErrOrderOptionNotSupported, exchange.ErrTradingNotSupported,
+}
+
+// notPlaced returns true if the error returned by the exchange when placing an order means that
+// the order wasn't placed.
+func notPlaced(exchangeName string, err error) bool {
+	kind := exchange.ErrorKind(exchangeName, err)
+	for _, e := range notPlacedErrors {
+		if err == e || kind == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Kind is the type of a conditional order
+type Kind string
+
+// Conditional order kinds
+const (
+	// Places a limit order once the price moves against the best price seen by the trailing
+	// offset
+	KindTrailingStop Kind = "trailing stop"
+	// Places a limit order (the limit leg) on the exchange straight away, if the price reaches
+	// the stop price first the limit leg is cancelled and a stop order is placed instead
+	KindOCO Kind = "oco"
+	// Places a limit order at the scheduled time
+	KindScheduled Kind = "scheduled"
+)
+
+// State is the state of a conditional order
+type State string
+
+// Conditional order states, orders are removed once they're filled or cancelled
+const (
+	// Waiting for the order to trigger
+	StatePending State = "pending"
+	// The order has triggered and the triggered order is live on the exchange
+	StateTriggered State = "triggered"
+	// The exchange returned an error while an order was being placed, the order may have been
+	// placed anyway. Reconcile matches the order against the live exchange orders.
+	StateUnknown State = "unknown"
+)
+
+// Leg identifies an exchange order placed for a conditional order
+type Leg string
+
+// Order legs
+const (
+	// The limit leg of an OCO order
+	LegLimit Leg = "limit"
+	// The order placed when the conditional order triggers
+	LegTrigger Leg = "trigger"
+)
+
+// Order is a synthetic conditional order
+type Order struct {
+	ID       string             `json:"id"`
+	Kind     Kind               `json:"kind"`
+	Exchange string             `json:"exchange"`
+	Pair     pair.CurrencyPair  `json:"pair"`
+	Side     exchange.OrderSide `json:"side"`
+	Amount   float64            `json:"amount"`
+	// Limit price of scheduled orders & of the limit leg of OCO orders
+	Price float64 `json:"price,omitempty"`
+	// Price that triggers stops, updated as the price moves for trailing stops
+	StopPrice float64 `json:"stopPrice,omitempty"`
+	// Distance of the limit price of a triggered stop from the stop price, so that the order is
+	// filled even if the price keeps moving
+	LimitOffset float64 `json:"limitOffset,omitempty"`
+	// Distance of a trailing stop from the best price seen
+	TrailingOffset float64 `json:"trailingOffset,omitempty"`
+	// Best price seen since a trailing stop was added (highest for sells, lowest for buys)
+	BestPrice float64   `json:"bestPrice,omitempty"`
+	ExecuteAt time.Time `json:"executeAt,omitempty"`
+	State     State     `json:"state"`
+	// ID of the live limit leg of an OCO order
+	LimitOrderID string `json:"limitOrderId,omitempty"`
+	// ID of the live order placed when the order triggered
+	TriggeredOrderID string `json:"triggeredOrderId,omitempty"`
+	// Set while an order is being placed on the exchange, if the bot stops before the exchange
+	// replies the leg is matched against the live exchange orders on startup
+	Placing      Leg       `json:"placing,omitempty"`
+	PlacingPrice float64   `json:"placingPrice,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	// Set while the exchange order is being submitted (without holding the manager lock)
+	inFlight bool
+}
+
+func (o *Order) validate() error {
+	if o.Amount <= 0 || (o.Side != exchange.OrderSideBuy && o.Side != exchange.OrderSideSell) {
+		return ErrInvalidOrder
+	}
+	switch o.Kind {
+	case KindTrailingStop:
+		if o.TrailingOffset <= 0 {
+			return ErrInvalidOrder
+		}
+	case KindOCO:
+		if o.Price <= 0 || o.StopPrice <= 0 {
+			return ErrInvalidOrder
+		}
+		// the stop must be on the other side of the market from the limit leg
+		if (o.Side == exchange.OrderSideSell) != (o.StopPrice < o.Price) {
+			return ErrInvalidOrder
+		}
+	case KindScheduled:
+		if o.Price <= 0 || o.ExecuteAt.IsZero() {
+			return ErrInvalidOrder
+		}
+	default:
+		return ErrInvalidOrder
+	}
+	return nil
+}
+
+// stopLimitPrice returns the limit price of the order placed when a stop triggers
+func (o *Order) stopLimitPrice() float64 {
+	if o.Side == exchange.OrderSideSell {
+		return o.StopPrice - o.LimitOffset
+	}
+	return o.StopPrice + o.LimitOffset
+}
+
+// updateTrailingStop moves the stop price of a trailing stop if the price has improved, and
+// returns true if the order changed.
+func (o *Order) updateTrailingStop(price float64) bool {
+	if o.BestPrice != 0 && (o.Side == exchange.OrderSideSell) == (price <= o.BestPrice) {
+		return false
+	}
+	o.BestPrice = price
+	if o.Side == exchange.OrderSideSell {
+		o.StopPrice = price - o.TrailingOffset
+	} else {
+		o.StopPrice = price + o.TrailingOffset
+	}
+	return true
+}
+
+// stopTriggered returns true if the price has reached the stop price
+func (o *Order) stopTriggered(price float64) bool {
+	if o.Side == exchange.OrderSideSell {
+		return price <= o.StopPrice
+	}
+	return price >= o.StopPrice
+}
+
+// Manager keeps track of the conditional orders, and places the exchange orders when they
+// trigger.
+type Manager struct {
+	mtx       sync.Mutex
+	store     storage.Store
+	exchanges map[string]exchange.IBotExchangeEx
+	orders    map[string]*Order
+	lastID    int64
+	now       func() time.Time
+}
+
+// NewManager returns a manager that persists the conditional orders to the store.
+func NewManager(store storage.Store) *Manager {
+	return &Manager{
+		store:     store,
+		exchanges: make(map[string]exchange.IBotExchangeEx),
+		orders:    make(map[string]*Order),
+		now:       time.Now,
+	}
+}
+
+// AddExchange adds an exchange conditional orders can be placed on.
+func (m *Manager) AddExchange(exch exchange.IBotExchangeEx) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.exchanges[exch.GetName()] = exch
+}
+
+// Load replaces the conditional orders with the ones previously saved to the store, and returns
+// the number of orders loaded. Reconcile should be called once the exchanges are running.
+func (m *Manager) Load() (int, error) {
+	keys, err := m.store.Keys(conditionalOrdersBucket)
+	if err != nil {
+		return 0, err
+	}
+	orders := make(map[string]*Order, len(keys))
+	var lastID int64
+	for _, key := range keys {
+		o := &Order{}
+		if err = m.store.Get(conditionalOrdersBucket, key, o); err != nil {
+			return 0, err
+		}
+		orders[o.ID] = o
+		if id, _ := strconv.ParseInt(o.ID, 10, 64); id > lastID {
+			lastID = id
+		}
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.orders = orders
+	m.lastID = lastID
+	return len(orders), nil
+}
+
+func (m *Manager) save(o *Order) error {
+	return m.store.Put(conditionalOrdersBucket, o.ID, o)
+}
+
+func (m *Manager) remove(o *Order) error {
+	delete(m.orders, o.ID)
+	return m.store.Delete(conditionalOrdersBucket, o.ID)
+}
+
+func (m *Manager) nextID() string {
+	id := m.now().UnixNano()
+	if id <= m.lastID {
+		id = m.lastID + 1
+	}
+	m.lastID = id
+	return strconv.FormatInt(id, 10)
+}
+
+// place submits the order for a leg to the exchange, m.mtx must be held and is released while the
+// exchange order is submitted. The conditional order is saved before the exchange order is
+// submitted, so that the exchange order can be recovered if the bot stops before the exchange
+// replies. Unless the exchange error means the order was rejected the conditional order is left in
+// StateUnknown, with Placing set, until Reconcile finds out if the order was placed.
+func (m *Manager) place(exch exchange.IBotExchangeEx, o *Order, leg Leg, price float64) error {
+	o.Placing, o.PlacingPrice = leg, price
+	if err := m.save(o); err != nil {
+		o.Placing, o.PlacingPrice = "", 0
+		return err
+	}
+	o.inFlight = true
+	placing := *o
+	m.mtx.Unlock()
+	orderID, err := exch.NewOrder(placing.Pair, placing.Amount, price, placing.Side,
+		exchange.OrderTypeExchangeLimit)
+	m.mtx.Lock()
+	o.inFlight = false
+
+	switch {
+	case err == nil:
+		o.Placing, o.PlacingPrice = "", 0
+		m.setLegOrderID(o, leg, orderID)
+	case notPlaced(exch.GetName(), err):
+		o.Placing, o.PlacingPrice = "", 0
+	default:
+		o.State = StateUnknown
+	}
+	if saveErr := m.save(o); err == nil {
+		err = saveErr
+	}
+	return err
+}
+
+func (m *Manager) setLegOrderID(o *Order, leg Leg, orderID string) {
+	if leg == LegLimit {
+		o.LimitOrderID = orderID
+	} else {
+		o.TriggeredOrderID = orderID
+		o.State = StateTriggered
+	}
+}
+
+// Add validates and adds a conditional order, the limit leg of an OCO order is placed on the
+// exchange straight away. Returns the order with its ID and state set.
+func (m *Manager) Add(o Order) (Order, error) {
+	if err := o.validate(); err != nil {
+		return o, err
+	}
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	exch, ok := m.exchanges[o.Exchange]
+	if !ok {
+		return o, ErrUnknownExchange
+	}
+
+	o.ID = m.nextID()
+	o.State = StatePending
+	o.LimitOrderID, o.TriggeredOrderID, o.Placing, o.PlacingPrice = "", "", "", 0
+	o.CreatedAt = m.now()
+	order := &o
+	if o.Kind == KindTrailingStop && o.BestPrice != 0 {
+		price := o.BestPrice
+		o.BestPrice = 0
+		o.updateTrailingStop(price)
+	}
+	if o.Kind == KindOCO {
+		// added before the limit leg is placed so that the order is reconciled if the placement
+		// is ambiguous
+		m.orders[o.ID] = order
+		if err := m.place(exch, order, LegLimit, o.Price); err != nil {
+			if o.State != StateUnknown && o.LimitOrderID == "" {
+				m.remove(order)
+			}
+			return o, err
+		}
+		return o, nil
+	}
+	if err := m.save(order); err != nil {
+		return o, err
+	}
+	m.orders[o.ID] = order
+	return o, nil
+}
+
+// Orders returns the active conditional orders, sorted by ID.
+func (m *Manager) Orders() []Order {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	orders := make([]Order, 0, len(m.orders))
+	for _, o := range m.orders {
+		orders = append(orders, *o)
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+	return orders
+}
+
+// Cancel cancels a conditional order along with any of its live exchange orders.
+func (m *Manager) Cancel(id string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	o, ok := m.orders[id]
+	if !ok {
+		return ErrOrderNotFound
+	}
+	if o.Placing != "" {
+		return ErrOrderPlacing
+	}
+	if exch, ok := m.exchanges[o.Exchange]; ok {
+		for _, orderID := range []string{o.LimitOrderID, o.TriggeredOrderID} {
+			if orderID == "" {
+				continue
+			}
+			if err := exch.CancelOrder(orderID, o.Pair); err != nil {
+				return err
+			}
+		}
+	}
+	return m.remove(o)
+}
+
+// UpdatePrice checks the pending conditional orders for a currency pair against the latest
+// price, moving trailing stops and placing the orders of the stops that trigger.
+func (m *Manager) UpdatePrice(exchangeName string, p pair.CurrencyPair, price float64) error {
+	if price <= 0 {
+		return nil
+	}
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	exch, ok := m.exchanges[exchangeName]
+	if !ok {
+		return nil
+	}
+
+	var errs []error
+	for _, o := range m.sortedOrders() {
+		if m.orders[o.ID] != o || o.Exchange != exchangeName || !o.Pair.Equal(p) ||
+			o.State != StatePending || o.Placing != "" {
+			continue
+		}
+		var err error
+		switch o.Kind {
+		case KindTrailingStop:
+			if o.updateTrailingStop(price) {
+				err = m.save(o)
+			}
+			if err == nil && o.stopTriggered(price) {
+				err = m.place(exch, o, LegTrigger, o.stopLimitPrice())
+			}
+		case KindOCO:
+			if o.stopTriggered(price) {
+				err = m.triggerOCO(exch, o)
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", o.ID, err))
+		}
+	}
+	return combineErrors(errs)
+}
+
+// triggerOCO cancels the limit leg of an OCO order and places the stop order, unless the limit
+// leg has been filled already.
+func (m *Manager) triggerOCO(exch exchange.IBotExchangeEx, o *Order) error {
+	if o.LimitOrderID != "" {
+		if err := exch.CancelOrder(o.LimitOrderID, o.Pair); err != nil {
+			limitOrder, getErr := exch.GetOrder(o.LimitOrderID, o.Pair)
+			if getErr != nil {
+				return err
+			}
+			if limitOrder.Status == exchange.OrderStatusFilled {
+				return m.remove(o)
+			}
+			if limitOrder.Status != exchange.OrderStatusAborted {
+				return err
+			}
+		}
+		o.LimitOrderID = ""
+	}
+	return m.place(exch, o, LegTrigger, o.stopLimitPrice())
+}
+
+// RunScheduled places the orders of the scheduled orders that are due.
+func (m *Manager) RunScheduled() error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	now := m.now()
+	var errs []error
+	for _, o := range m.sortedOrders() {
+		if m.orders[o.ID] != o || o.Kind != KindScheduled || o.State != StatePending || o.Placing != "" ||
+			now.Before(o.ExecuteAt) {
+			continue
+		}
+		exch, ok := m.exchanges[o.Exchange]
+		if !ok {
+			continue
+		}
+		if err := m.place(exch, o, LegTrigger, o.Price); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", o.ID, err))
+		}
+	}
+	return combineErrors(errs)
+}
+
+// Reconcile checks the live exchange orders of the conditional orders. Orders whose exchange
+// orders have been filled are removed, orders being placed when the bot stopped (or left in
+// StateUnknown) are matched against the open orders of the exchange, and OCO orders whose limit leg was cancelled outside
+// the bot keep their stop. This is called on startup, and periodically to notice fills.
+func (m *Manager) Reconcile() error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	var errs []error
+	for _, o := range m.sortedOrders() {
+		exch, ok := m.exchanges[o.Exchange]
+		if !ok || m.orders[o.ID] != o || o.inFlight {
+			continue
+		}
+		if err := m.reconcile(exch, o); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", o.ID, err))
+		}
+	}
+	return combineErrors(errs)
+}
+
+func (m *Manager) reconcile(exch exchange.IBotExchangeEx, o *Order) error {
+	if o.Placing != "" {
+		if err := m.recoverPlacing(exch, o); err != nil {
+			return err
+		}
+	}
+	if o.LimitOrderID != "" {
+		limitOrder, err := exch.GetOrder(o.LimitOrderID, o.Pair)
+		if err != nil {
+			return err
+		}
+		switch limitOrder.Status {
+		case exchange.OrderStatusFilled:
+			return m.remove(o)
+		case exchange.OrderStatusAborted:
+			// the stop is kept, it's still protecting the position
+			o.LimitOrderID = ""
+			if err = m.save(o); err != nil {
+				return err
+			}
+		}
+	}
+	if o.TriggeredOrderID != "" {
+		triggeredOrder, err := exch.GetOrder(o.TriggeredOrderID, o.Pair)
+		if err != nil {
+			return err
+		}
+		if triggeredOrder.Status == exchange.OrderStatusFilled ||
+			triggeredOrder.Status == exchange.OrderStatusAborted {
+			return m.remove(o)
+		}
+	}
+	return nil
+}
+
+// recoverPlacing looks for the exchange order of a leg that was being placed when the bot
+// stopped, or when the exchange returned an ambiguous error. If the order never reached the exchange the leg is placed again (limit legs), or left
+// to trigger again (stops & scheduled orders).
+func (m *Manager) recoverPlacing(exch exchange.IBotExchangeEx, o *Order) error {
+	liveOrders, err := exch.GetOrders([]pair.CurrencyPair{o.Pair})
+	if err != nil {
+		return err
+	}
+	leg := o.Placing
+	o.State = StatePending
+	for _, live := range liveOrders {
+		if live.Side == o.Side && live.Rate == o.PlacingPrice && math.Abs(live.Amount-o.Amount) < 1e-9 &&
+			live.OrderID != o.LimitOrderID && live.OrderID != o.TriggeredOrderID {
+			o.Placing, o.PlacingPrice = "", 0
+			m.setLegOrderID(o, leg, live.OrderID)
+			return m.save(o)
+		}
+	}
+	o.Placing, o.PlacingPrice = "", 0
+	if leg == LegLimit {
+		return m.place(exch, o, LegLimit, o.Price)
+	}
+	return m.save(o)
+}
+
+// sortedOrders returns the orders sorted by ID, so they're processed in the order they were added.
+// Callers that place orders must skip the orders removed while m.mtx was released.
+func (m *Manager) sortedOrders() []*Order {
+	orders := make([]*Order, 0, len(m.orders))
+	for _, o := range m.orders {
+		orders = append(orders, o)
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+	return orders
+}
+
+func combineErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return fmt.Errorf("%s (and %d more errors)", errs[0], len(errs)-1)
+}
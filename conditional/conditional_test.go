@@ -0,0 +1,290 @@
+package conditional
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/storage"
+)
+
+type mockExchange struct {
+	exchange.IBotExchangeEx
+	orders map[string]*exchange.Order
+	nextID int
+	// returned by NewOrder, the order is placed anyway if placeOnError is set
+	newOrderErr  error
+	placeOnError bool
+	onNewOrder   func()
+}
+
+func newMockExchange() *mockExchange {
+	return &mockExchange{orders: make(map[string]*exchange.Order)}
+}
+
+func (m *mockExchange) GetName() string {
+	return "Mock"
+}
+
+func (m *mockExchange) NewOrder(symbol pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	if m.onNewOrder != nil {
+		m.onNewOrder()
+	}
+	if m.newOrderErr != nil && !m.placeOnError {
+		return "", m.newOrderErr
+	}
+	m.nextID++
+	id := strconv.Itoa(m.nextID)
+	m.orders[id] = &exchange.Order{
+		CurrencyPair: symbol,
+		Side:         side,
+		Amount:       amount,
+		Rate:         price,
+		Status:       exchange.OrderStatusActive,
+		OrderID:      id,
+	}
+	if m.newOrderErr != nil {
+		return "", m.newOrderErr
+	}
+	return id, nil
+}
+
+func (m *mockExchange) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	o, ok := m.orders[orderID]
+	if !ok || o.Status != exchange.OrderStatusActive {
+		return errors.New("order not found")
+	}
+	o.Status = exchange.OrderStatusAborted
+	return nil
+}
+
+func (m *mockExchange) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	o, ok := m.orders[orderID]
+	if !ok {
+		return nil, errors.New("order not found")
+	}
+	return o, nil
+}
+
+func (m *mockExchange) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	var result []*exchange.Order
+	for _, o := range m.orders {
+		if o.Status == exchange.OrderStatusActive {
+			result = append(result, o)
+		}
+	}
+	return result, nil
+}
+
+var btcusd = pair.NewCurrencyPair("BTC", "USD")
+
+func newTestManager(store storage.Store, exch *mockExchange) *Manager {
+	m := NewManager(store)
+	m.AddExchange(exch)
+	return m
+}
+
+func TestTrailingStop(t *testing.T) {
+	exch := newMockExchange()
+	m := newTestManager(storage.NewMemoryStore(), exch)
+	o, err := m.Add(Order{Kind: KindTrailingStop, Exchange: "Mock", Pair: btcusd, Side: exchange.OrderSideSell,
+		Amount: 1, TrailingOffset: 10, LimitOffset: 1})
+	if err != nil {
+		t.Fatalf("Test failed. Add returned an error: %s", err)
+	}
+	if _, err = m.Add(Order{Kind: KindTrailingStop, Exchange: "Other", Pair: btcusd,
+		Side: exchange.OrderSideSell, Amount: 1, TrailingOffset: 10}); err != ErrUnknownExchange {
+		t.Errorf("Test failed. Expected ErrUnknownExchange but got %v", err)
+	}
+
+	for _, price := range []float64{100, 120, 115} {
+		if err = m.UpdatePrice("Mock", btcusd, price); err != nil {
+			t.Fatalf("Test failed. UpdatePrice returned an error: %s", err)
+		}
+	}
+	if orders := m.Orders(); len(orders) != 1 || orders[0].StopPrice != 110 || orders[0].State != StatePending {
+		t.Fatalf("Test failed. Expected the stop to trail the highest price, got %+v", orders)
+	}
+
+	if err = m.UpdatePrice("Mock", btcusd, 109); err != nil {
+		t.Fatalf("Test failed. UpdatePrice returned an error: %s", err)
+	}
+	orders := m.Orders()
+	if len(orders) != 1 || orders[0].State != StateTriggered || orders[0].TriggeredOrderID == "" {
+		t.Fatalf("Test failed. Expected the stop to trigger, got %+v", orders)
+	}
+	if placed := exch.orders[orders[0].TriggeredOrderID]; placed.Rate != 109 || placed.Side != exchange.OrderSideSell {
+		t.Errorf("Test failed. Unexpected triggered order %+v", placed)
+	}
+
+	exch.orders[orders[0].TriggeredOrderID].Status = exchange.OrderStatusFilled
+	if err = m.Reconcile(); err != nil {
+		t.Fatalf("Test failed. Reconcile returned an error: %s", err)
+	}
+	if len(m.Orders()) != 0 {
+		t.Error("Test failed. Expected the filled order to be removed")
+	}
+	if err = m.Cancel(o.ID); err != ErrOrderNotFound {
+		t.Errorf("Test failed. Expected ErrOrderNotFound but got %v", err)
+	}
+}
+
+func TestOCORecovery(t *testing.T) {
+	store := storage.NewMemoryStore()
+	exch := newMockExchange()
+	o, err := newTestManager(store, exch).Add(Order{Kind: KindOCO, Exchange: "Mock", Pair: btcusd,
+		Side: exchange.OrderSideSell, Amount: 1, Price: 120, StopPrice: 90})
+	if err != nil {
+		t.Fatalf("Test failed. Add returned an error: %s", err)
+	}
+	if o.LimitOrderID == "" || exch.orders[o.LimitOrderID].Rate != 120 {
+		t.Fatalf("Test failed. Expected the limit leg to be placed, got %+v", o)
+	}
+
+	// the limit leg is cancelled outside the bot while it's stopped
+	exch.orders[o.LimitOrderID].Status = exchange.OrderStatusAborted
+	m := newTestManager(store, exch)
+	if n, err := m.Load(); n != 1 || err != nil {
+		t.Fatalf("Test failed. Load returned %d %v", n, err)
+	}
+	if err = m.Reconcile(); err != nil {
+		t.Fatalf("Test failed. Reconcile returned an error: %s", err)
+	}
+	orders := m.Orders()
+	if len(orders) != 1 || orders[0].LimitOrderID != "" || orders[0].State != StatePending {
+		t.Fatalf("Test failed. Expected the stop to be kept, got %+v", orders)
+	}
+
+	if err = m.UpdatePrice("Mock", btcusd, 89); err != nil {
+		t.Fatalf("Test failed. UpdatePrice returned an error: %s", err)
+	}
+	if orders = m.Orders(); len(orders) != 1 || orders[0].State != StateTriggered {
+		t.Fatalf("Test failed. Expected the stop to trigger, got %+v", orders)
+	}
+	if err = m.Cancel(o.ID); err != nil {
+		t.Fatalf("Test failed. Cancel returned an error: %s", err)
+	}
+	if exch.orders[orders[0].TriggeredOrderID].Status != exchange.OrderStatusAborted || len(m.Orders()) != 0 {
+		t.Error("Test failed. Expected the triggered order to be cancelled")
+	}
+	if keys, _ := store.Keys(conditionalOrdersBucket); len(keys) != 0 {
+		t.Errorf("Test failed. Expected the cancelled order to be deleted from the store, got %v", keys)
+	}
+}
+
+func TestRecoverPlacing(t *testing.T) {
+	store := storage.NewMemoryStore()
+	exch := newMockExchange()
+	// the bot stopped after submitting the triggered order of the first stop, and before
+	// submitting the order of the second stop
+	liveID, _ := exch.NewOrder(btcusd, 2, 50, exchange.OrderSideSell, exchange.OrderTypeExchangeLimit)
+	for i, amount := range []float64{2, 3} {
+		o := Order{ID: strconv.Itoa(i + 1), Kind: KindTrailingStop, Exchange: "Mock", Pair: btcusd,
+			Side: exchange.OrderSideSell, Amount: amount, TrailingOffset: 5, StopPrice: 50, State: StatePending,
+			Placing: LegTrigger, PlacingPrice: 50}
+		if err := store.Put(conditionalOrdersBucket, o.ID, o); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := newTestManager(store, exch)
+	if _, err := m.Load(); err != nil {
+		t.Fatalf("Test failed. Load returned an error: %s", err)
+	}
+	if err := m.Reconcile(); err != nil {
+		t.Fatalf("Test failed. Reconcile returned an error: %s", err)
+	}
+	orders := m.Orders()
+	if len(orders) != 2 {
+		t.Fatalf("Test failed. Expected 2 orders but got %d", len(orders))
+	}
+	if orders[0].State != StateTriggered || orders[0].TriggeredOrderID != liveID || orders[0].Placing != "" {
+		t.Errorf("Test failed. Expected the live order to be adopted, got %+v", orders[0])
+	}
+	if orders[1].State != StatePending || orders[1].Placing != "" {
+		t.Errorf("Test failed. Expected the unsubmitted order to be pending, got %+v", orders[1])
+	}
+}
+
+func TestScheduledOrder(t *testing.T) {
+	exch := newMockExchange()
+	m := newTestManager(storage.NewMemoryStore(), exch)
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	if _, err := m.Add(Order{Kind: KindScheduled, Exchange: "Mock", Pair: btcusd, Side: exchange.OrderSideBuy,
+		Amount: 1, Price: 100}); err != ErrInvalidOrder {
+		t.Errorf("Test failed. Expected ErrInvalidOrder without an execution time but got %v", err)
+	}
+	if _, err := m.Add(Order{Kind: KindScheduled, Exchange: "Mock", Pair: btcusd, Side: exchange.OrderSideBuy,
+		Amount: 1, Price: 100, ExecuteAt: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("Test failed. Add returned an error: %s", err)
+	}
+
+	if err := m.RunScheduled(); err != nil || len(exch.orders) != 0 {
+		t.Fatalf("Test failed. Expected no orders before the scheduled time, got %d %v", len(exch.orders), err)
+	}
+	now = now.Add(time.Hour)
+	if err := m.RunScheduled(); err != nil || len(exch.orders) != 1 {
+		t.Fatalf("Test failed. Expected the scheduled order to be placed, got %d %v", len(exch.orders), err)
+	}
+	if orders := m.Orders(); len(orders) != 1 || orders[0].State != StateTriggered {
+		t.Errorf("Test failed. Unexpected orders %+v", orders)
+	}
+}
+
+func TestPlaceErrors(t *testing.T) {
+	exch := newMockExchange()
+	m := newTestManager(storage.NewMemoryStore(), exch)
+	// the manager isn't locked while the order is submitted
+	exch.onNewOrder = func() { m.Orders() }
+
+	exch.newOrderErr = exchange.ErrInsufficientFunds
+	if _, err := m.Add(Order{Kind: KindOCO, Exchange: "Mock", Pair: btcusd, Side: exchange.OrderSideSell,
+		Amount: 1, Price: 120, StopPrice: 90}); err != exchange.ErrInsufficientFunds {
+		t.Fatalf("Test failed. Expected ErrInsufficientFunds but got %v", err)
+	}
+	if orders := m.Orders(); len(orders) != 0 {
+		t.Fatalf("Test failed. Expected the rejected order to be removed, got %+v", orders)
+	}
+
+	if _, err := m.Add(Order{Kind: KindTrailingStop, Exchange: "Mock", Pair: btcusd,
+		Side: exchange.OrderSideSell, Amount: 1, TrailingOffset: 10}); err != nil {
+		t.Fatalf("Test failed. Add returned an error: %s", err)
+	}
+	m.UpdatePrice("Mock", btcusd, 100)
+	if err := m.UpdatePrice("Mock", btcusd, 80); err == nil {
+		t.Fatal("Test failed. Expected UpdatePrice to return the NewOrder error")
+	}
+	if orders := m.Orders(); len(orders) != 1 || orders[0].State != StatePending || orders[0].Placing != "" {
+		t.Fatalf("Test failed. Expected the rejected stop to be pending, got %+v", orders)
+	}
+
+	// the exchange times out, but the order is placed anyway
+	exch.newOrderErr, exch.placeOnError = errors.New("request timed out"), true
+	if err := m.UpdatePrice("Mock", btcusd, 80); err == nil {
+		t.Fatal("Test failed. Expected UpdatePrice to return the NewOrder error")
+	}
+	orders := m.Orders()
+	if len(orders) != 1 || orders[0].State != StateUnknown || orders[0].Placing != LegTrigger {
+		t.Fatalf("Test failed. Expected the order to be in an unknown state, got %+v", orders)
+	}
+	if err := m.UpdatePrice("Mock", btcusd, 70); err != nil || len(exch.orders) != 1 {
+		t.Fatalf("Test failed. Expected the unknown order not to be placed again, got %d %v",
+			len(exch.orders), err)
+	}
+	if err := m.Cancel(orders[0].ID); err != ErrOrderPlacing {
+		t.Errorf("Test failed. Expected ErrOrderPlacing but got %v", err)
+	}
+
+	if err := m.Reconcile(); err != nil {
+		t.Fatalf("Test failed. Reconcile returned an error: %s", err)
+	}
+	orders = m.Orders()
+	if len(orders) != 1 || orders[0].State != StateTriggered || orders[0].TriggeredOrderID != "1" {
+		t.Errorf("Test failed. Expected the placed order to be adopted, got %+v", orders)
+	}
+}
@@ -59,6 +59,8 @@ type WebserverConfig struct {
 	ListenAddress                string
 	WebsocketConnectionLimit     int
 	WebsocketAllowInsecureOrigin bool
+	// Serialize floats (amounts, prices etc.) in REST responses as exact decimal strings
+	DecimalStrings bool `json:",omitempty"`
 }
 
 // SMSGlobalConfig structure holds all the variables you need for instant
@@ -70,6 +72,215 @@ type SMSGlobalConfig struct {
 	Contacts []smsglobal.Contact
 }
 
+// AuditLogConfig holds the settings for the audit log that records every mutating API call
+// made to the exchanges.
+type AuditLogConfig struct {
+	Enabled    bool
+	Path       string
+	MaxSize    int64 // Max size of the log file (in bytes) before it's rotated
+	MaxBackups int   // Number of rotated log files to keep
+}
+
+// RecorderConfig holds the settings for recording the polled orderbooks for backtests. Only the
+// price levels that changed are recorded, with a full orderbook (keyframe) recorded every
+// KeyframeInterval updates or KeyframePeriod seconds.
+type RecorderConfig struct {
+	Enabled          bool
+	Path             string
+	KeyframeInterval int   `json:",omitempty"`
+	KeyframePeriod   int64 `json:",omitempty"`
+}
+
+// WebhooksConfig holds the settings for the listener receiving callbacks from the exchanges, the
+// callbacks of each exchange are sent to http://<ListenAddress>/webhooks/<exchange name>.
+type WebhooksConfig struct {
+	Enabled       bool
+	ListenAddress string
+	Exchanges     []WebhookExchangeConfig `json:",omitempty"`
+}
+
+// WebhookExchangeConfig holds the settings for the callbacks of an exchange. Type is either hmac
+// (signed with Secret) or coinbase (signed with the RSA key in PublicKeyFile).
+type WebhookExchangeConfig struct {
+	Exchange      string
+	Type          string
+	Secret        string `json:",omitempty"`
+	PublicKeyFile string `json:",omitempty"`
+}
+
+// AnalyticsConfig holds the settings for the order execution analytics
+type AnalyticsConfig struct {
+	Enabled bool
+}
+
+// BackfillConfig holds the settings for importing the past trades of the account on the exchanges
+// with credentials, the trades are imported once into the store. PageDelay is the number of
+// milliseconds between page requests.
+type BackfillConfig struct {
+	Enabled   bool
+	PageDelay int64 `json:",omitempty"`
+}
+
+// StorageConfig holds the settings for the store used to persist the bot state, Type is one
+// of memory, file or sqlite. Path is the JSON file for the file store and the database for the
+// sqlite store.
+type StorageConfig struct {
+	Type string
+	Path string `json:",omitempty"`
+}
+
+// RateLimitConfig holds the settings for the rate limiters that space out the requests sent to
+// the exchanges, Backend is either memory (the default) or redis. The redis backend allows the
+// rate limits to be shared by multiple bots using the same API keys or IP address.
+type RateLimitConfig struct {
+	Backend       string
+	RedisAddress  string `json:",omitempty"`
+	RedisPassword string `json:",omitempty"`
+}
+
+// EventBusConfig holds the settings for the queues of the subscribers to the bot events (websocket
+// clients, strategies etc.). Policies maps event names (e.g. orderbook_update) to what happens when
+// a queue is full: drop-oldest, drop-newest or block. Market data events drop the oldest events
+// and the other events block by default.
+type EventBusConfig struct {
+	QueueSize int `json:",omitempty"`
+	// Time (in milliseconds) publishers wait for room in a full queue before dropping blocking
+	// events
+	BlockTimeout int64             `json:",omitempty"`
+	Policies     map[string]string `json:",omitempty"`
+}
+
+// SimulationConfig holds the execution models used by paper trading & backtests to simulate the
+// fees, latency and slippage of each exchange. Seed makes the simulated latencies reproducible,
+// zero means a random seed.
+type SimulationConfig struct {
+	Seed      int64                      `json:",omitempty"`
+	Exchanges []SimulationExchangeConfig `json:",omitempty"`
+}
+
+// SimulationExchangeConfig overrides the simulated execution of orders on an exchange, fees are
+// fractions of the order value (e.g. 0.001 for 0.1%), unset fees default to the exchange fees.
+type SimulationExchangeConfig struct {
+	Name     string
+	MakerFee *float64 `json:",omitempty"`
+	TakerFee *float64 `json:",omitempty"`
+	Latency  LatencyConfig
+	Slippage SlippageConfig
+}
+
+// PnLConfig holds the settings of the tax lots used to calculate the realized P&L, Jurisdiction
+// is one of US or DE (defaults to US), LotMethod (FIFO, HIFO or SPECIFIC) overrides the default
+// lot selection method of the jurisdiction.
+type PnLConfig struct {
+	Jurisdiction string `json:",omitempty"`
+	LotMethod    string `json:",omitempty"`
+}
+
+// StrategyQuotaConfig limits the resources used by a strategy, zero disables a limit.
+type StrategyQuotaConfig struct {
+	Name              string
+	RequestsPerMinute uint `json:",omitempty"` // Max API requests per minute to each exchange
+	MaxOpenOrders     int  `json:",omitempty"`
+	MaxSubscriptions  int  `json:",omitempty"`
+}
+
+// ExposureLimitConfig caps the total notional exposure to a quote currency across all the
+// currency pairs quoted in it on all the exchanges, e.g. a limit of 5 BTC caps the value of the
+// holdings of every BTC quoted currency & the open buy orders for them at 5 BTC.
+type ExposureLimitConfig struct {
+	QuoteCurrency string
+	MaxNotional   float64
+}
+
+// PriceBandConfig rejects the limit orders placed on an exchange at prices that deviate too far
+// from the reference price of an oracle
+type PriceBandConfig struct {
+	Exchange string
+	// Name of a configured oracle, or of an exchange whose tickers are used as reference prices
+	Oracle string
+	// Max deviation (in percent) of the order price from the reference price
+	MaxDeviation float64
+	// Currency pairs delimited by "/" & separated by ",", the band applies to all the pairs if empty
+	Pairs string `json:",omitempty"`
+}
+
+// TransferConfig overrides the withdrawal & deposit rules of a currency on an exchange, used to
+// estimate the cost of transferring funds between exchanges.
+type TransferConfig struct {
+	Exchange             string
+	Currency             string
+	WithdrawalFee        float64
+	MinWithdrawal        float64 `json:",omitempty"`
+	DepositConfirmations int     `json:",omitempty"`
+}
+
+// SweepPolicyConfig sweeps the available balance of currencies (comma separated, every currency
+// if empty) from one wallet of an exchange to another once a day at the given time (UTC, HH:MM).
+// Wallets are one of exchange, margin or funding.
+type SweepPolicyConfig struct {
+	Name       string
+	Exchange   string
+	Currencies string `json:",omitempty"`
+	From       string
+	To         string
+	At         string
+	MinAmount  float64 `json:",omitempty"` // Balances smaller than this aren't swept
+	Reserve    float64 `json:",omitempty"` // Amount left in the source wallet
+	DryRun     bool    `json:",omitempty"` // Log & notify the sweeps without transferring funds
+	Notify     bool    `json:",omitempty"` // Send an SMS for every sweep
+}
+
+// LatencyConfig configures the distribution of the simulated order latency (in milliseconds),
+// Distribution is one of fixed (Mean), uniform (Min to Max) or normal (Mean & StdDev).
+type LatencyConfig struct {
+	Distribution string
+	Mean         int64 `json:",omitempty"`
+	StdDev       int64 `json:",omitempty"`
+	Min          int64 `json:",omitempty"`
+	Max          int64 `json:",omitempty"`
+}
+
+// SlippageConfig configures the simulated slippage of taker orders, Model is one of none, fixed
+// (a constant slippage of BPS basis points) or orderbook (the order walks the orderbook).
+type SlippageConfig struct {
+	Model string
+	BPS   float64 `json:",omitempty"`
+}
+
+// MarketDataConfig holds the secondary market data sources used to price currency pairs when
+// the data from an exchange is missing or stale.
+type MarketDataConfig struct {
+	Fallbacks []MarketDataFallbackConfig `json:",omitempty"`
+	// Time (in milliseconds) the tickers & orderbooks fetched by a strategy are shared with the
+	// other strategies, sharing is disabled if zero
+	SharedCacheTTL int64          `json:",omitempty"`
+	Oracles        []OracleConfig `json:",omitempty"`
+}
+
+// OracleConfig configures a source of reference prices, either a JSON HTTP API or the median of
+// other oracles
+type OracleConfig struct {
+	Name string
+	// URL of the price of a currency pair, {base} & {quote} are replaced by the currencies of the
+	// pair, e.g. https://api.coinbase.com/v2/prices/{base}-{quote}/spot
+	URL string `json:",omitempty"`
+	// Path of the price in the response, nested fields delimited by ".", e.g. data.amount
+	PriceField string `json:",omitempty"`
+	// Time (in seconds) the fetched prices are cached for
+	CacheTTL int64 `json:",omitempty"`
+	// Names of the oracles (or exchanges, whose tickers are used) to take the median price of,
+	// used if the URL isn't set
+	Median []string `json:",omitempty"`
+}
+
+// MarketDataFallbackConfig configures a fallback exchange for pricing a currency pair
+type MarketDataFallbackConfig struct {
+	Exchange         string
+	Pair             string // Currency pair delimited by "/", e.g. BTC/USDT
+	FallbackExchange string
+	MaxAge           int64 // Max age of a price (in seconds) before it's considered stale
+}
+
 // Post holds the bot configuration data
 type Post struct {
 	Data Config `json:"Data"`
@@ -92,10 +303,27 @@ type Config struct {
 	CurrencyExchangeProvider string
 	CurrencyPairFormat       *CurrencyPairFormatConfig `json:"CurrencyPairFormat"`
 	FiatDisplayCurrency      string
-	Portfolio                portfolio.Base   `json:"PortfolioAddresses"`
-	SMS                      SMSGlobalConfig  `json:"SMSGlobal"`
-	Webserver                WebserverConfig  `json:"Webserver"`
-	Exchanges                []ExchangeConfig `json:"Exchanges"`
+	Trace                    string                `json:",omitempty"` // Modules debug tracing is enabled for, e.g. bitfinex.http,-bitfinex.ws,orderbook (defaults to ticker,orderbook)
+	Portfolio                portfolio.Base        `json:"PortfolioAddresses"`
+	SMS                      SMSGlobalConfig       `json:"SMSGlobal"`
+	Webserver                WebserverConfig       `json:"Webserver"`
+	AuditLog                 AuditLogConfig        `json:"AuditLog"`
+	Recorder                 RecorderConfig        `json:"Recorder"`
+	Analytics                AnalyticsConfig       `json:"Analytics"`
+	Webhooks                 WebhooksConfig        `json:"Webhooks"`
+	Backfill                 BackfillConfig        `json:"Backfill"`
+	MarketData               MarketDataConfig      `json:"MarketData"`
+	Storage                  StorageConfig         `json:"Storage"`
+	RateLimit                RateLimitConfig       `json:"RateLimit"`
+	EventBus                 EventBusConfig        `json:"EventBus"`
+	Simulation               SimulationConfig      `json:"Simulation"`
+	PnL                      PnLConfig             `json:"PnL"`
+	StrategyQuotas           []StrategyQuotaConfig `json:",omitempty"`
+	ExposureLimits           []ExposureLimitConfig `json:",omitempty"`
+	PriceBands               []PriceBandConfig     `json:",omitempty"`
+	Transfers                []TransferConfig      `json:",omitempty"`
+	Sweeps                   []SweepPolicyConfig   `json:",omitempty"`
+	Exchanges                []ExchangeConfig      `json:"Exchanges"`
 }
 
 // ExchangeConfig holds all the information needed for each enabled Exchange.
@@ -104,7 +332,22 @@ type ExchangeConfig struct {
 	Enabled                   bool
 	Verbose                   bool
 	Websocket                 bool
+	WebsocketRecordFile       string `json:",omitempty"` // Raw websocket frames are appended to the file, see exchanges/wsrecord
+	WebsocketStaleTimeout     int64  `json:",omitempty"` // Seconds without websocket messages before the data is polled & the websocket reconnected, defaults to 60
+	WebsocketCompression      bool   `json:",omitempty"` // Negotiate permessage-deflate compression on the websocket connections
 	UseSandbox                bool
+	APIURL                    string `json:",omitempty"`
+	EthereumNodeURL           string `json:",omitempty"` // JSON-RPC endpoint of the node decentralized exchanges read wallet balances from
+	Testnet                   bool   `json:",omitempty"`
+	SimulateDowntime          bool   `json:",omitempty"`
+	ReadOnly                  bool   `json:",omitempty"`
+	MaxMarketDataAge          int64  `json:",omitempty"` // Max age (in seconds) of the market data before orders are blocked
+	RetryAttempts             int    `json:",omitempty"` // Max attempts for failed requests, retries are disabled if zero
+	DegradeAfterFailures      int    `json:",omitempty"` // Consecutive authenticated API failures before new orders are blocked, disabled if zero
+	TradingPaused             bool   `json:",omitempty"` // Blocks new orders on every pair, market data keeps running
+	PausedPairs               string `json:",omitempty"` // Pairs new orders are blocked on (comma separated & delimited by "/")
+	AmountRounding            string `json:",omitempty"` // Rounding of order amounts, "floor" (default) or "nearest"
+	PriceRounding             string `json:",omitempty"` // Rounding of order prices, "passive" (default), "floor", "ceil" or "nearest"
 	RESTPollingDelay          time.Duration
 	AuthenticatedAPISupport   bool
 	APIKey                    string
@@ -113,9 +356,50 @@ type ExchangeConfig struct {
 	AvailablePairs            string
 	EnabledPairs              string
 	BaseCurrencies            string
-	AssetTypes                string
+	AssetTypes                string                    // Comma separated, e.g. SPOT,MARGIN,FUTURES,PERPETUAL_SWAP,OPTIONS
 	ConfigCurrencyPairFormat  *CurrencyPairFormatConfig `json:"ConfigCurrencyPairFormat"`
 	RequestCurrencyPairFormat *CurrencyPairFormatConfig `json:"RequestCurrencyPairFormat"`
+	Accounts                  []ExchangeAccountConfig   `json:",omitempty"`
+	OrderThrottle             *OrderThrottleConfig      `json:",omitempty"`
+	MaintenanceWindows        []MaintenanceWindowConfig `json:",omitempty"`
+	Tokens                    []TokenConfig             `json:",omitempty"` // ERC-20 tokens traded on a decentralized exchange, in addition to the tokens it knows
+}
+
+// TokenConfig holds an ERC-20 token traded on a decentralized exchange whose API identifies the
+// tokens by address only (e.g. the 0x relayers).
+type TokenConfig struct {
+	Symbol   string
+	Address  string
+	Decimals int32
+}
+
+// MaintenanceWindowConfig holds a recurring maintenance window of an exchange, during which the
+// bot cancels its open orders, pauses trading & polling and resumes automatically afterwards.
+// Schedule is a cron expression (minute hour day-of-month month day-of-week, in UTC) of the
+// start of the window, e.g. "0 2 * * 2" for every Tuesday at 02:00 UTC.
+type MaintenanceWindowConfig struct {
+	Name     string `json:",omitempty"`
+	Schedule string
+	Duration int64 // Minutes
+}
+
+// OrderThrottleConfig holds the limits placed on new orders submitted to an exchange, orders
+// exceeding the limits are rejected locally. Zero disables a limit. NotionalPerHour is denominated
+// in the quote currency of the pairs, and is tracked separately for each quote currency.
+type OrderThrottleConfig struct {
+	OrdersPerMinute int     `json:",omitempty"`
+	NotionalPerHour float64 `json:",omitempty"`
+}
+
+// ExchangeAccountConfig holds the credentials of an additional account on an exchange (the
+// credentials in the ExchangeConfig are the default account). Orders for the currency pairs in
+// RoutePairs (comma separated & delimited by "/", e.g. BTC/USD,ETH/USD) are routed to the account.
+type ExchangeAccountConfig struct {
+	Name       string
+	APIKey     string
+	APISecret  string
+	ClientID   string `json:",omitempty"`
+	RoutePairs string `json:",omitempty"`
 }
 
 // GetConfigEnabledExchanges returns the number of exchanges that are enabled.
@@ -198,7 +482,10 @@ func (c *Config) CheckExchangeConfigValues() error {
 			if exch.BaseCurrencies == "" {
 				return fmt.Errorf(ErrExchangeBaseCurrenciesEmpty, exch.Name)
 			}
-			if exch.AuthenticatedAPISupport { // non-fatal error
+			if exch.ReadOnly {
+				// read-only exchanges are set up without credentials
+				c.Exchanges[i].AuthenticatedAPISupport = false
+			} else if exch.AuthenticatedAPISupport { // non-fatal error
 				if exch.APIKey == "" || exch.APISecret == "" || exch.APIKey == "Key" || exch.APISecret == "Secret" {
 					c.Exchanges[i].AuthenticatedAPISupport = false
 					log.Printf(WarningExchangeAuthAPIDefaultOrEmptyValues, exch.Name)
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -44,13 +45,13 @@ func TestGetExchangeConfig(t *testing.T) {
 		)
 	}
 	r, err := GetExchangeConfig.GetExchangeConfig("ANX")
-	if err != nil && (ExchangeConfig{}) == r {
+	if err != nil && reflect.DeepEqual(ExchangeConfig{}, r) {
 		t.Errorf(
 			"Test failed. GetExchangeConfig.GetExchangeConfig Error: %s", err.Error(),
 		)
 	}
 	r, err = GetExchangeConfig.GetExchangeConfig("Testy")
-	if err == nil && (ExchangeConfig{}) == r {
+	if err == nil && reflect.DeepEqual(ExchangeConfig{}, r) {
 		t.Error("Test failed. GetExchangeConfig.GetExchangeConfig Error")
 	}
 }
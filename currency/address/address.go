@@ -0,0 +1,195 @@
+// Package address validates the format of withdrawal destinations (addresses & tags) so that
+// malformed destinations are rejected before a withdrawal is requested from an exchange. Only the
+// formats of the networks listed in Formats are validated, destinations on other networks are
+// accepted as is.
+package address
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/mattkanwisher/cryptofiend/exchanges/ethereum"
+)
+
+var (
+	// ErrInvalidAddress is returned for addresses that don't have the format of the network
+	ErrInvalidAddress = errors.New("invalid address")
+	// ErrInvalidChecksum is returned for well formed addresses whose checksum doesn't match,
+	// usually a typo
+	ErrInvalidChecksum = errors.New("invalid address checksum")
+	// ErrInvalidTag is returned for tags (destination tags, payment IDs) that don't have the
+	// format of the network, or that the address doesn't accept
+	ErrInvalidTag = errors.New("invalid tag")
+)
+
+// Format is an address format shared by one or more networks
+type Format string
+
+// Address formats
+const (
+	// Bitcoin base58check (P2PKH & P2SH) or bech32 (segwit) mainnet addresses
+	Bitcoin Format = "bitcoin"
+	// Ethereum hex addresses, mixed case addresses must match their EIP-55 checksum
+	Ethereum Format = "ethereum"
+	// Ripple classic addresses, the tag is a 32 bit destination tag
+	Ripple Format = "ripple"
+	// Monero standard, subaddress or integrated addresses, the tag is a payment ID
+	Monero Format = "monero"
+)
+
+// Formats maps the network identifiers the exchanges use to address formats. Tokens withdrawn
+// on the networks of other chains (e.g. USDT on ETH or OMNI) use the format of the chain.
+var Formats = map[string]Format{
+	"BTC":          Bitcoin,
+	"BITCOIN":      Bitcoin,
+	"OMNI":         Bitcoin,
+	"TETHERUSO":    Bitcoin,
+	"ETH":          Ethereum,
+	"ETHEREUM":     Ethereum,
+	"ERC20":        Ethereum,
+	"ETH_CONTRACT": Ethereum,
+	"TETHERUSE":    Ethereum,
+	"XRP":          Ripple,
+	"RIPPLE":       Ripple,
+	"XMR":          Monero,
+	"MONERO":       Monero,
+}
+
+// Validate checks that an address & tag (empty if none) have the format of the network, networks
+// without a known format are accepted. Whether the network requires a tag is checked by the
+// currency metadata, an empty tag is always valid here.
+func Validate(network, addr, tag string) error {
+	format, ok := Formats[strings.ToUpper(network)]
+	if !ok {
+		return nil
+	}
+	if addr != strings.TrimSpace(addr) || addr == "" {
+		return ErrInvalidAddress
+	}
+	switch format {
+	case Bitcoin:
+		return validateBitcoin(addr)
+	case Ethereum:
+		err := ethereum.ValidateAddress(addr)
+		if err == ethereum.ErrInvalidChecksum {
+			return ErrInvalidChecksum
+		} else if err != nil {
+			return ErrInvalidAddress
+		}
+		return nil
+	case Ripple:
+		return validateRipple(addr, tag)
+	case Monero:
+		return validateMonero(addr, tag)
+	}
+	return nil
+}
+
+// Version bytes of the Bitcoin mainnet P2PKH & P2SH addresses
+const (
+	bitcoinP2PKHVersion = 0x00
+	bitcoinP2SHVersion  = 0x05
+	bitcoinSegwitHRP    = "bc"
+)
+
+func validateBitcoin(addr string) error {
+	if strings.HasPrefix(strings.ToLower(addr), bitcoinSegwitHRP+"1") {
+		return validateSegwit(addr)
+	}
+	payload, err := decodeBase58Check(addr, bitcoinAlphabet)
+	if err != nil {
+		return err
+	}
+	if len(payload) != 21 || (payload[0] != bitcoinP2PKHVersion && payload[0] != bitcoinP2SHVersion) {
+		return ErrInvalidAddress
+	}
+	return nil
+}
+
+// validateSegwit checks a segwit address as per BIP 173 (version 0, bech32) & BIP 350
+// (version 1 and up, bech32m)
+func validateSegwit(addr string) error {
+	hrp, data, constant, err := decodeBech32(addr)
+	if err != nil {
+		return err
+	}
+	if hrp != bitcoinSegwitHRP || len(data) == 0 || data[0] > 16 {
+		return ErrInvalidAddress
+	}
+	version := data[0]
+	if (version == 0 && constant != bech32Constant) || (version > 0 && constant != bech32mConstant) {
+		return ErrInvalidChecksum
+	}
+	program, ok := convertBits(data[1:], 5, 8)
+	if !ok || len(program) < 2 || len(program) > 40 {
+		return ErrInvalidAddress
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return ErrInvalidAddress
+	}
+	return nil
+}
+
+// Version byte of the Ripple account IDs, encoded as the leading r of the addresses
+const rippleAccountVersion = 0x00
+
+func validateRipple(addr, tag string) error {
+	if addr[0] != rippleAlphabet[0] {
+		return ErrInvalidAddress
+	}
+	payload, err := decodeBase58Check(addr, rippleAlphabet)
+	if err != nil {
+		return err
+	}
+	if len(payload) != 21 || payload[0] != rippleAccountVersion {
+		return ErrInvalidAddress
+	}
+	if tag != "" {
+		if _, err = strconv.ParseUint(tag, 10, 32); err != nil {
+			return ErrInvalidTag
+		}
+	}
+	return nil
+}
+
+// Network bytes of the Monero mainnet addresses
+const (
+	moneroStandard   = 18
+	moneroIntegrated = 19
+	moneroSubaddress = 42
+)
+
+func validateMonero(addr, tag string) error {
+	data, err := decodeMoneroBase58(addr)
+	if err != nil {
+		return err
+	}
+	// Network byte, public spend & view keys, payment ID of integrated addresses, checksum
+	expected := 1 + 64 + 4
+	if len(data) > 0 && data[0] == moneroIntegrated {
+		expected += 8
+	} else if len(data) > 0 && data[0] != moneroStandard && data[0] != moneroSubaddress {
+		return ErrInvalidAddress
+	}
+	if len(data) != expected {
+		return ErrInvalidAddress
+	}
+	checksum := ethereum.Keccak256(data[:len(data)-4])[:4]
+	if string(checksum) != string(data[len(data)-4:]) {
+		return ErrInvalidChecksum
+	}
+	if tag == "" {
+		return nil
+	}
+	// Integrated addresses carry their own payment ID
+	if data[0] == moneroIntegrated || (len(tag) != 16 && len(tag) != 64) {
+		return ErrInvalidTag
+	}
+	for _, c := range strings.ToLower(tag) {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return ErrInvalidTag
+		}
+	}
+	return nil
+}
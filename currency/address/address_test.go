@@ -0,0 +1,58 @@
+package address
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		network, addr, tag string
+		expected           error
+	}{
+		// Bitcoin P2PKH, P2SH, segwit v0 (BIP 173) & taproot (BIP 350)
+		{"BTC", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "", nil},
+		{"OMNI", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", "", nil},
+		{"BTC", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", "", ErrInvalidChecksum},
+		{"BTC", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfN0", "", ErrInvalidAddress},
+		{"BTC", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", "", nil},
+		{"bitcoin", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", "", nil},
+		{"BTC", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", "", ErrInvalidChecksum},
+		{"BTC", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", "", nil},
+		// Taproot address with a bech32 instead of bech32m checksum
+		{"BTC", "bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7k7grplx", "", ErrInvalidChecksum},
+		{"BTC", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", "", ErrInvalidAddress},
+		{"BTC", " 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "", ErrInvalidAddress},
+		// Ethereum, EIP-55
+		{"ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "", nil},
+		{"ERC20", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "", nil},
+		{"ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", "", ErrInvalidChecksum},
+		{"ETH", "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "", ErrInvalidAddress},
+		// Ripple, 32 bit destination tags
+		{"XRP", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", "", nil},
+		{"RIPPLE", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", "4294967295", nil},
+		{"XRP", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", "4294967296", ErrInvalidTag},
+		{"XRP", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", "memo", ErrInvalidTag},
+		{"XRP", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTj", "", ErrInvalidChecksum},
+		{"XRP", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "", ErrInvalidAddress},
+		// Monero standard & integrated addresses, payment IDs
+		{"XMR", "44AFFq5kSiGBoZ4NMDwYtN18obc8AemS33DBLWs3H7otXft3XjrpDtQGv7SqSsaBYBb98uNbr2VBBEt7f2wfn3RVGQBEP3A", "", nil},
+		{"XMR", "41fNkAL5mFY9QEU6V1akz6JodhnBLvM94UD2wTsgFwJ2dcSB9a1bBte42aLGwgFHBYDRyZxe1asLWNqNoeLLvTVUN1e675F",
+			"0123456789abcdef", nil},
+		{"XMR", "41fNkAL5mFY9QEU6V1akz6JodhnBLvM94UD2wTsgFwJ2dcSB9a1bBte42aLGwgFHBYDRyZxe1asLWNqNoeLLvTVUN1e675F",
+			"0123456789abcdeg", ErrInvalidTag},
+		{"XMR", "41fNkAL5mFY9QEU6V1akz6JodhnBLvM94UD2wTsgFwJ2dcSB9a1bBte42aLGwgFHBYDRyZxe1asLWNqNoeLLvTVUN1e675G",
+			"", ErrInvalidChecksum},
+		{"MONERO", "4BN3ky9aNX49QEU6V1akz6JodhnBLvM94UD2wTsgFwJ2dcSB9a1bBte42aLGwgFHBYDRyZxe1asLWNqNoeLLvTVUY888JSpA3bjU7vMNdU",
+			"", nil},
+		// integrated addresses already carry a payment ID
+		{"XMR", "4BN3ky9aNX49QEU6V1akz6JodhnBLvM94UD2wTsgFwJ2dcSB9a1bBte42aLGwgFHBYDRyZxe1asLWNqNoeLLvTVUY888JSpA3bjU7vMNdU",
+			"0123456789abcdef", ErrInvalidTag},
+		{"XMR", "44AFFq5kSiGBoZ4NMDwYtN18obc8AemS33DBLWs3H7otXft3XjrpDtQGv7Sq", "", ErrInvalidAddress},
+		// networks without a known format aren't validated
+		{"TRX", "anything", "", nil},
+	}
+	for _, test := range tests {
+		if err := Validate(test.network, test.addr, test.tag); err != test.expected {
+			t.Errorf("Test failed. %s %s %q: expected %v but got %v", test.network, test.addr, test.tag,
+				test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package address
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"strings"
+)
+
+// Base58 alphabets, Ripple reorders the Bitcoin alphabet so that its addresses start with r
+const (
+	bitcoinAlphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	rippleAlphabet  = "rpshnaf39wBUDNEGHJKLM4PQRST7VWXYZ2bcdeCg65jkm8oFqi1tuvAxyz"
+)
+
+var bigRadix = big.NewInt(58)
+
+// decodeBase58 decodes a base58 string, each leading zero digit decodes as a zero byte
+func decodeBase58(s, alphabet string) ([]byte, bool) {
+	n := new(big.Int)
+	for _, c := range s {
+		digit := strings.IndexRune(alphabet, c)
+		if digit < 0 {
+			return nil, false
+		}
+		n.Mul(n, bigRadix).Add(n, big.NewInt(int64(digit)))
+	}
+	zeros := 0
+	for zeros < len(s) && s[zeros] == alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), true
+}
+
+// decodeBase58Check decodes a base58 string whose last 4 bytes are the checksum of the payload
+// (the first 4 bytes of its double SHA-256), returns the payload
+func decodeBase58Check(s, alphabet string) ([]byte, error) {
+	b, ok := decodeBase58(s, alphabet)
+	if !ok || len(b) < 5 {
+		return nil, ErrInvalidAddress
+	}
+	payload := b[:len(b)-4]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if string(second[:4]) != string(b[len(b)-4:]) {
+		return nil, ErrInvalidChecksum
+	}
+	return payload, nil
+}
+
+// Monero encodes base58 in blocks of 8 bytes, indexed by the number of bytes in a block this is
+// the number of digits the block is encoded with
+var moneroBlockDigits = []int{0, 2, 3, 5, 6, 7, 9, 10, 11}
+
+const moneroFullBlockDigits = 11
+
+// decodeMoneroBase58 decodes the block based base58 variant used by Monero
+func decodeMoneroBase58(s string) ([]byte, error) {
+	var result []byte
+	for len(s) > 0 {
+		digits := moneroFullBlockDigits
+		if len(s) < digits {
+			digits = len(s)
+		}
+		size := -1
+		for n, d := range moneroBlockDigits {
+			if d == digits {
+				size = n
+			}
+		}
+		if size < 0 {
+			return nil, ErrInvalidAddress
+		}
+		n := new(big.Int)
+		for _, c := range s[:digits] {
+			digit := strings.IndexRune(bitcoinAlphabet, c)
+			if digit < 0 {
+				return nil, ErrInvalidAddress
+			}
+			n.Mul(n, bigRadix).Add(n, big.NewInt(int64(digit)))
+		}
+		if n.BitLen() > size*8 {
+			return nil, ErrInvalidAddress
+		}
+		result = append(result, n.FillBytes(make([]byte, size))...)
+		s = s[digits:]
+	}
+	return result, nil
+}
+
+const (
+	bech32Charset   = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	bech32Constant  = 1
+	bech32mConstant = 0x2bc830a3
+	bech32MaxLength = 90
+)
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+// decodeBech32 decodes a bech32 or bech32m string, returning the human readable part, the data
+// (without the checksum) as 5 bit values & the checksum constant (bech32Constant or
+// bech32mConstant)
+func decodeBech32(s string) (string, []byte, uint32, error) {
+	if len(s) > bech32MaxLength || (s != strings.ToLower(s) && s != strings.ToUpper(s)) {
+		return "", nil, 0, ErrInvalidAddress
+	}
+	s = strings.ToLower(s)
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+7 > len(s) {
+		return "", nil, 0, ErrInvalidAddress
+	}
+	hrp := s[:sep]
+	data := make([]byte, 0, len(s)-sep-1)
+	for _, c := range s[sep+1:] {
+		v := strings.IndexRune(bech32Charset, c)
+		if v < 0 {
+			return "", nil, 0, ErrInvalidAddress
+		}
+		data = append(data, byte(v))
+	}
+	values := make([]byte, 0, 2*len(hrp)+1+len(data))
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]>>5)
+	}
+	values = append(values, 0)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]&31)
+	}
+	constant := bech32Polymod(append(values, data...))
+	if constant != bech32Constant && constant != bech32mConstant {
+		return "", nil, 0, ErrInvalidChecksum
+	}
+	return hrp, data[:len(data)-6], constant, nil
+}
+
+// convertBits regroups a sequence of fromBits values into toBits values, the leftover bits must
+// be zero padding
+func convertBits(data []byte, fromBits, toBits uint) ([]byte, bool) {
+	acc, bits := uint32(0), uint(0)
+	maxv := uint32(1)<<toBits - 1
+	var result []byte
+	for _, v := range data {
+		if uint32(v)>>fromBits != 0 {
+			return nil, false
+		}
+		acc = acc<<fromBits | uint32(v)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			result = append(result, byte(acc>>bits&maxv))
+		}
+	}
+	if bits >= fromBits || (acc<<(toBits-bits))&maxv != 0 {
+		return nil, false
+	}
+	return result, true
+}
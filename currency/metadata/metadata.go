@@ -0,0 +1,207 @@
+// Package metadata aggregates the currency metadata published by the exchanges (long names,
+// deposit methods & networks), so that withdrawals can require the network & tag fields each
+// currency needs on each exchange, and validate the destination address of the network.
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mattkanwisher/cryptofiend/currency/address"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+var (
+	// ErrUnknownCurrency is returned when no exchange has published metadata for a currency
+	ErrUnknownCurrency = errors.New("unknown currency")
+	// ErrNetworkRequired is returned when a withdrawal doesn't specify the network for a currency
+	// that can be withdrawn on several networks
+	ErrNetworkRequired = errors.New("network is required")
+	// ErrUnknownNetwork is returned when a withdrawal specifies a network the currency can't be
+	// withdrawn on
+	ErrUnknownNetwork = errors.New("unknown network")
+	// ErrTagRequired is returned when a withdrawal doesn't specify the tag required by the network
+	ErrTagRequired = errors.New("tag is required")
+)
+
+// Exchanges whose currency names are preferred, in order of preference. Bittrex publishes the
+// most complete long names.
+var namePreference = []string{"Bittrex", "Binance", "Bitfinex"}
+
+// Currency is the metadata of a currency aggregated across exchanges
+type Currency struct {
+	Currency string `json:"currency"`
+	Name     string `json:"name"`
+	// Metadata published by each exchange that supports the currency, keyed by exchange name
+	Exchanges map[string]exchange.CurrencyMetadata `json:"exchanges"`
+}
+
+// WithdrawalRequirements describes the fields required to withdraw a currency from an exchange
+type WithdrawalRequirements struct {
+	Exchange string `json:"exchange"`
+	Currency string `json:"currency"`
+	// True if the currency can be withdrawn on several networks, so the network must be specified
+	NetworkRequired bool `json:"networkRequired"`
+	// Networks the currency can be withdrawn on, the default network first
+	Networks []exchange.CurrencyNetwork `json:"networks"`
+}
+
+// Registry holds the currency metadata published by the exchanges
+type Registry struct {
+	mtx sync.RWMutex
+	// Currency metadata keyed by exchange name & currency
+	exchanges map[string]map[string]exchange.CurrencyMetadata
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{exchanges: make(map[string]map[string]exchange.CurrencyMetadata)}
+}
+
+// Update replaces the currency metadata of an exchange
+func (r *Registry) Update(exchangeName string, currencies []exchange.CurrencyMetadata) {
+	byCurrency := make(map[string]exchange.CurrencyMetadata, len(currencies))
+	for _, c := range currencies {
+		byCurrency[strings.ToUpper(c.Currency)] = c
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.exchanges[exchangeName] = byCurrency
+}
+
+// Refresh fetches the currency metadata from each provider, keyed by exchange name. Exchanges
+// that fail keep their previous metadata.
+func (r *Registry) Refresh(providers map[string]exchange.CurrencyMetadataProvider) error {
+	var failed []string
+	for name, provider := range providers {
+		currencies, err := provider.GetCurrencyMetadata()
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", name, err))
+			continue
+		}
+		r.Update(name, currencies)
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("failed to fetch currency metadata from %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (r *Registry) currency(code string) (Currency, bool) {
+	c := Currency{Currency: code, Exchanges: make(map[string]exchange.CurrencyMetadata)}
+	for name, currencies := range r.exchanges {
+		if m, ok := currencies[code]; ok {
+			c.Exchanges[name] = m
+		}
+	}
+	if len(c.Exchanges) == 0 {
+		return c, false
+	}
+	for _, name := range namePreference {
+		if m, ok := c.Exchanges[name]; ok && m.Name != "" {
+			c.Name = m.Name
+			return c, true
+		}
+	}
+	// fall back to the other exchanges in alphabetical order
+	names := make([]string, 0, len(c.Exchanges))
+	for name := range c.Exchanges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if m := c.Exchanges[name]; m.Name != "" {
+			c.Name = m.Name
+			break
+		}
+	}
+	return c, true
+}
+
+// Currency returns the metadata of a currency aggregated across exchanges
+func (r *Registry) Currency(code string) (Currency, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	c, ok := r.currency(strings.ToUpper(code))
+	if !ok {
+		return c, ErrUnknownCurrency
+	}
+	return c, nil
+}
+
+// Currencies returns the metadata of every currency, sorted by currency code
+func (r *Registry) Currencies() []Currency {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	codes := make(map[string]bool)
+	for _, currencies := range r.exchanges {
+		for code := range currencies {
+			codes[code] = true
+		}
+	}
+	result := make([]Currency, 0, len(codes))
+	for code := range codes {
+		c, _ := r.currency(code)
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Currency < result[j].Currency })
+	return result
+}
+
+// WithdrawalRequirements returns the networks a currency can be withdrawn on from an exchange,
+// along with the fields each network requires
+func (r *Registry) WithdrawalRequirements(exchangeName, code string) (WithdrawalRequirements, error) {
+	code = strings.ToUpper(code)
+	req := WithdrawalRequirements{Exchange: exchangeName, Currency: code, Networks: []exchange.CurrencyNetwork{}}
+	r.mtx.RLock()
+	m, ok := r.exchanges[exchangeName][code]
+	r.mtx.RUnlock()
+	if !ok {
+		return req, ErrUnknownCurrency
+	}
+	for _, n := range m.Networks {
+		if n.WithdrawalEnabled {
+			req.Networks = append(req.Networks, n)
+		}
+	}
+	req.NetworkRequired = len(req.Networks) > 1
+	return req, nil
+}
+
+// ValidateWithdrawal checks that a withdrawal of a currency from an exchange specifies the
+// network & tag if they're required, and that the address & tag have the format of the network
+// (see the address package for the errors). The network can be omitted if the currency can only
+// be withdrawn on one network. Returns the network the withdrawal will be made on.
+func (r *Registry) ValidateWithdrawal(exchangeName, code, network, addr, tag string) (exchange.CurrencyNetwork, error) {
+	req, err := r.WithdrawalRequirements(exchangeName, code)
+	if err != nil {
+		return exchange.CurrencyNetwork{}, err
+	}
+	var selected *exchange.CurrencyNetwork
+	switch {
+	case network != "":
+		for i := range req.Networks {
+			if strings.EqualFold(req.Networks[i].Network, network) {
+				selected = &req.Networks[i]
+				break
+			}
+		}
+		if selected == nil {
+			return exchange.CurrencyNetwork{}, ErrUnknownNetwork
+		}
+	case len(req.Networks) == 1:
+		selected = &req.Networks[0]
+	case len(req.Networks) == 0:
+		return exchange.CurrencyNetwork{}, ErrUnknownNetwork
+	default:
+		return exchange.CurrencyNetwork{}, ErrNetworkRequired
+	}
+	if selected.TagRequired && tag == "" {
+		return *selected, ErrTagRequired
+	}
+	return *selected, address.Validate(selected.Network, addr, tag)
+}
@@ -0,0 +1,110 @@
+package metadata
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/address"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+type mockProvider struct {
+	currencies []exchange.CurrencyMetadata
+	err        error
+}
+
+func (m *mockProvider) GetCurrencyMetadata() ([]exchange.CurrencyMetadata, error) {
+	return m.currencies, m.err
+}
+
+func newTestRegistry(t *testing.T) *Registry {
+	r := NewRegistry()
+	err := r.Refresh(map[string]exchange.CurrencyMetadataProvider{
+		"Binance": &mockProvider{currencies: []exchange.CurrencyMetadata{
+			{Currency: "USDT", Name: "TetherUS", Networks: []exchange.CurrencyNetwork{
+				{Network: "ETH", WithdrawalEnabled: true},
+				{Network: "OMNI", WithdrawalEnabled: true},
+				{Network: "TRX", WithdrawalEnabled: false},
+			}},
+			{Currency: "XRP", Name: "Ripple", Networks: []exchange.CurrencyNetwork{
+				{Network: "XRP", TagRequired: true, WithdrawalEnabled: true},
+			}},
+		}},
+		"Bittrex": &mockProvider{currencies: []exchange.CurrencyMetadata{
+			{Currency: "xrp", Name: "Ripple (XRP)", Networks: []exchange.CurrencyNetwork{
+				{Network: "RIPPLE", TagRequired: true, WithdrawalEnabled: true},
+			}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Test failed. Refresh returned an error: %s", err)
+	}
+	return r
+}
+
+func TestRegistry(t *testing.T) {
+	r := newTestRegistry(t)
+	xrp, err := r.Currency("xrp")
+	if err != nil {
+		t.Fatalf("Test failed. Currency returned an error: %s", err)
+	}
+	if xrp.Name != "Ripple (XRP)" || len(xrp.Exchanges) != 2 {
+		t.Errorf("Test failed. Expected the Bittrex name & both exchanges, got %+v", xrp)
+	}
+	if _, err = r.Currency("ABC"); err != ErrUnknownCurrency {
+		t.Errorf("Test failed. Expected ErrUnknownCurrency but got %v", err)
+	}
+	if currencies := r.Currencies(); len(currencies) != 2 || currencies[0].Currency != "USDT" {
+		t.Errorf("Test failed. Unexpected currencies %+v", currencies)
+	}
+
+	// failed refreshes keep the previous metadata
+	err = r.Refresh(map[string]exchange.CurrencyMetadataProvider{
+		"Bittrex": &mockProvider{err: errors.New("timeout")},
+	})
+	if err == nil {
+		t.Error("Test failed. Expected Refresh to return an error")
+	}
+	if xrp, _ = r.Currency("XRP"); len(xrp.Exchanges) != 2 {
+		t.Errorf("Test failed. Expected the previous metadata to be kept, got %+v", xrp)
+	}
+}
+
+func TestValidateWithdrawal(t *testing.T) {
+	r := newTestRegistry(t)
+	req, err := r.WithdrawalRequirements("Binance", "USDT")
+	if err != nil {
+		t.Fatalf("Test failed. WithdrawalRequirements returned an error: %s", err)
+	}
+	if !req.NetworkRequired || len(req.Networks) != 2 {
+		t.Errorf("Test failed. Expected 2 networks to choose from, got %+v", req)
+	}
+
+	const (
+		btcAddress = "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"
+		ethAddress = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+		xrpAddress = "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh"
+	)
+	tests := []struct {
+		exchange, currency, network, addr, tag string
+		expected                               error
+	}{
+		{"Binance", "USDT", "", ethAddress, "", ErrNetworkRequired},
+		{"Binance", "USDT", "omni", btcAddress, "", nil},
+		{"Binance", "USDT", "TRX", ethAddress, "", ErrUnknownNetwork},
+		{"Binance", "XRP", "", xrpAddress, "", ErrTagRequired},
+		{"Binance", "XRP", "", xrpAddress, "12345", nil},
+		{"Bitfinex", "XRP", "", xrpAddress, "12345", ErrUnknownCurrency},
+		// the address must have the format of the selected network
+		{"Binance", "USDT", "omni", ethAddress, "", address.ErrInvalidAddress},
+		{"Binance", "USDT", "ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", "", address.ErrInvalidChecksum},
+		{"Binance", "XRP", "", xrpAddress, "memo", address.ErrInvalidTag},
+		{"Bittrex", "XRP", "", btcAddress, "12345", address.ErrInvalidAddress},
+	}
+	for _, test := range tests {
+		_, err = r.ValidateWithdrawal(test.exchange, test.currency, test.network, test.addr, test.tag)
+		if err != test.expected {
+			t.Errorf("Test failed. %+v: expected %v but got %v", test, test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Package money provides an amount type that carries its currency code, so that arithmetic
+// between amounts of different currencies (e.g. adding a BTC amount to a USD amount) is caught
+// instead of silently producing a meaningless number. Amounts are exact decimals.
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrCurrencyMismatch is returned when combining amounts of different currencies
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// Amount is a decimal amount of a currency. The zero value is a zero amount without a currency,
+// which can be combined with an amount of any currency (so it can be used to accumulate a sum).
+type Amount struct {
+	value    decimal.Decimal
+	currency string
+}
+
+// New returns an amount of a currency, the currency code is uppercased
+func New(value decimal.Decimal, currency string) Amount {
+	return Amount{value: value, currency: strings.ToUpper(currency)}
+}
+
+// FromFloat returns an amount of a currency from a float value
+func FromFloat(value float64, currency string) Amount {
+	return New(decimal.NewFromFloat(value), currency)
+}
+
+// Zero returns a zero amount of a currency
+func Zero(currency string) Amount {
+	return New(decimal.Zero, currency)
+}
+
+// Parse parses an amount formatted as by String, e.g. "1.5 BTC"
+func Parse(s string) (Amount, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return Amount{}, fmt.Errorf("invalid amount %q", s)
+	}
+	value, err := decimal.NewFromString(fields[0])
+	if err != nil {
+		return Amount{}, fmt.Errorf("invalid amount %q: %s", s, err)
+	}
+	return New(value, fields[1]), nil
+}
+
+// Currency returns the (uppercase) currency code of the amount
+func (a Amount) Currency() string {
+	return a.currency
+}
+
+// Decimal returns the value of the amount
+func (a Amount) Decimal() decimal.Decimal {
+	return a.value
+}
+
+// Float64 returns the value of the amount as a float, which may lose precision
+func (a Amount) Float64() float64 {
+	f, _ := a.value.Float64()
+	return f
+}
+
+// IsZero returns true if the amount is zero
+func (a Amount) IsZero() bool {
+	return a.value.Sign() == 0
+}
+
+// IsNegative returns true if the amount is below zero
+func (a Amount) IsNegative() bool {
+	return a.value.Sign() < 0
+}
+
+// currencyOf returns the currency of the result of combining two amounts
+func currencyOf(a, b Amount) (string, error) {
+	switch {
+	case a.currency == b.currency:
+		return a.currency, nil
+	case a.currency == "" && a.IsZero():
+		return b.currency, nil
+	case b.currency == "" && b.IsZero():
+		return a.currency, nil
+	}
+	return "", fmt.Errorf("%s: %s and %s", ErrCurrencyMismatch, a.currency, b.currency)
+}
+
+// Add returns a + b, or ErrCurrencyMismatch if the amounts are of different currencies
+func (a Amount) Add(b Amount) (Amount, error) {
+	currency, err := currencyOf(a, b)
+	if err != nil {
+		return Amount{}, err
+	}
+	return Amount{value: a.value.Add(b.value), currency: currency}, nil
+}
+
+// Sub returns a - b, or ErrCurrencyMismatch if the amounts are of different currencies
+func (a Amount) Sub(b Amount) (Amount, error) {
+	return a.Add(b.Neg())
+}
+
+// Cmp compares two amounts of the same currency, returning -1 if a < b, 0 if a == b & 1 if a > b
+func (a Amount) Cmp(b Amount) (int, error) {
+	if _, err := currencyOf(a, b); err != nil {
+		return 0, err
+	}
+	return a.value.Cmp(b.value), nil
+}
+
+// Neg returns -a
+func (a Amount) Neg() Amount {
+	return Amount{value: a.value.Neg(), currency: a.currency}
+}
+
+// Mul returns the amount multiplied by a (unitless) factor
+func (a Amount) Mul(factor decimal.Decimal) Amount {
+	return Amount{value: a.value.Mul(factor), currency: a.currency}
+}
+
+// Convert returns the amount converted to another currency at the given rate (the price of one
+// unit of the amount's currency in the other currency)
+func (a Amount) Convert(rate decimal.Decimal, currency string) Amount {
+	return New(a.value.Mul(rate), currency)
+}
+
+// Sum returns the sum of amounts of the same currency, the zero value if there are no amounts
+func Sum(amounts ...Amount) (Amount, error) {
+	var total Amount
+	var err error
+	for _, a := range amounts {
+		if total, err = total.Add(a); err != nil {
+			return Amount{}, err
+		}
+	}
+	return total, nil
+}
+
+// String returns the amount followed by its currency code, e.g. "1.5 BTC"
+func (a Amount) String() string {
+	if a.currency == "" {
+		return a.value.String()
+	}
+	return a.value.String() + " " + a.currency
+}
+
+type jsonAmount struct {
+	Amount   decimal.Decimal `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+// MarshalJSON encodes the amount as an object with the exact decimal value as a string, e.g.
+// {"amount":"1.5","currency":"BTC"}
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonAmount{Amount: a.value, Currency: a.currency})
+}
+
+// UnmarshalJSON decodes an amount encoded by MarshalJSON, the value may also be a JSON number
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var v jsonAmount
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*a = New(v.Amount, v.Currency)
+	return nil
+}
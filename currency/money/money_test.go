@@ -0,0 +1,67 @@
+package money
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestArithmetic(t *testing.T) {
+	btc := FromFloat(0.1, "btc")
+	sum, err := btc.Add(FromFloat(0.2, "BTC"))
+	if err != nil {
+		t.Fatalf("Test failed. Add returned an error: %s", err)
+	}
+	// exact decimal arithmetic
+	if sum.String() != "0.3 BTC" {
+		t.Errorf("Test failed. Expected 0.3 BTC but got %s", sum)
+	}
+	if _, err = btc.Add(FromFloat(100, "USD")); err == nil || !strings.Contains(err.Error(), ErrCurrencyMismatch.Error()) {
+		t.Errorf("Test failed. Expected a currency mismatch, got %v", err)
+	}
+	if _, err = btc.Cmp(FromFloat(100, "USD")); err == nil {
+		t.Error("Test failed. Expected comparing different currencies to fail")
+	}
+	diff, err := btc.Sub(sum)
+	if err != nil || !diff.IsNegative() || diff.String() != "-0.2 BTC" {
+		t.Errorf("Test failed. Expected -0.2 BTC but got %s %v", diff, err)
+	}
+	usd := sum.Convert(decimal.NewFromFloat(8000), "usd")
+	if usd.String() != "2400 USD" {
+		t.Errorf("Test failed. Expected 2400 USD but got %s", usd)
+	}
+
+	total, err := Sum(btc, btc, Zero("BTC"))
+	if err != nil || total.String() != "0.2 BTC" {
+		t.Errorf("Test failed. Expected 0.2 BTC but got %s %v", total, err)
+	}
+	if total, err = Sum(); err != nil || !total.IsZero() {
+		t.Errorf("Test failed. Expected an empty sum to be zero, got %s %v", total, err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	a, err := Parse("0.00000001 BTC")
+	if err != nil {
+		t.Fatalf("Test failed. Parse returned an error: %s", err)
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatalf("Test failed. Marshal returned an error: %s", err)
+	}
+	if string(data) != `{"amount":"0.00000001","currency":"BTC"}` {
+		t.Errorf("Test failed. Unexpected encoding %s", data)
+	}
+	var decoded Amount
+	if err = json.Unmarshal([]byte(`{"amount":1.5,"currency":"eth"}`), &decoded); err != nil {
+		t.Fatalf("Test failed. Unmarshal returned an error: %s", err)
+	}
+	if decoded.String() != "1.5 ETH" {
+		t.Errorf("Test failed. Expected 1.5 ETH but got %s", decoded)
+	}
+	if _, err = Parse("1.5"); err == nil {
+		t.Error("Test failed. Expected an error for an amount without a currency")
+	}
+}
@@ -0,0 +1,379 @@
+// Package eventbus delivers the events of the bot (market data updates, order callbacks etc.) to
+// its subscribers through bounded per-subscriber queues, so a slow subscriber can't cause
+// unbounded memory growth. What happens when a queue is full depends on the overflow policy of
+// the topic of the event.
+package eventbus
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultQueueSize is the number of events queued for a subscriber unless another size is
+	// specified
+	DefaultQueueSize = 1024
+	// DefaultBlockTimeout is how long publishers wait for room in a full queue before dropping
+	// events of topics with the Block policy
+	DefaultBlockTimeout = 5 * time.Second
+)
+
+// Policy is what happens to an event published while the queue of a subscriber is full
+type Policy int
+
+// Overflow policies
+const (
+	// Drop the oldest queued event that may be dropped to make room for the new one, used for
+	// market data where only the latest updates matter. The new event is dropped if all the
+	// queued events have the Block policy.
+	DropOldest Policy = iota
+	// Drop the new event
+	DropNewest
+	// Block the publisher until there's room in the queue, used for order events. The event is
+	// dropped if the queue is still full after the block timeout of the bus.
+	Block
+)
+
+var policyNames = map[Policy]string{
+	DropOldest: "drop-oldest",
+	DropNewest: "drop-newest",
+	Block:      "block",
+}
+
+func (p Policy) String() string {
+	if name, ok := policyNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("Policy(%d)", int(p))
+}
+
+// ParsePolicy returns the policy with the given name: drop-oldest, drop-newest or block
+func ParsePolicy(name string) (Policy, error) {
+	for p, n := range policyNames {
+		if n == name {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown overflow policy %q", name)
+}
+
+// Event is published on the bus
+type Event struct {
+	Topic     string
+	Exchange  string
+	AssetType string
+	Data      interface{}
+}
+
+// Stats are the delivery metrics of a subscriber
+type Stats struct {
+	Subscriber string `json:"subscriber"`
+	QueueSize  int    `json:"queueSize"`
+	// Number of events currently queued
+	Queued int `json:"queued"`
+	// Highest number of events queued at once
+	MaxQueued int    `json:"maxQueued"`
+	Delivered uint64 `json:"delivered"`
+	Dropped   uint64 `json:"dropped"`
+	// Dropped events keyed by topic
+	DroppedByTopic map[string]uint64 `json:"droppedByTopic,omitempty"`
+	// Number of events whose publisher had to wait for room in the queue
+	Blocked uint64 `json:"blocked"`
+}
+
+// Bus delivers the published events to the subscribers of their topic
+type Bus struct {
+	mtx           sync.RWMutex
+	subscriptions map[string]*Subscription
+	policies      map[string]Policy
+	// Policy of the topics without a policy of their own
+	DefaultPolicy Policy
+	// How long publishers wait for room in a full queue, see Block
+	BlockTimeout time.Duration
+}
+
+// New returns a bus that blocks on full queues for up to DefaultBlockTimeout, unless another
+// policy is set for the topic
+func New() *Bus {
+	return &Bus{
+		subscriptions: make(map[string]*Subscription),
+		policies:      make(map[string]Policy),
+		DefaultPolicy: Block,
+		BlockTimeout:  DefaultBlockTimeout,
+	}
+}
+
+// SetPolicy sets the overflow policy of a topic
+func (b *Bus) SetPolicy(topic string, p Policy) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.policies[topic] = p
+}
+
+// Policy returns the overflow policy of a topic
+func (b *Bus) Policy(topic string) Policy {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+	if p, ok := b.policies[topic]; ok {
+		return p
+	}
+	return b.DefaultPolicy
+}
+
+// Subscribe calls the handler with the events of the given topics, or all the events if no
+// topics are given. The handler is called from a goroutine of the subscription, one event at a
+// time, with up to queueSize events queued while it's busy. A subscription with the same name is
+// closed and replaced.
+func (b *Bus) Subscribe(name string, queueSize int, handler func(Event), topics ...string) *Subscription {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	s := &Subscription{
+		name:    name,
+		bus:     b,
+		handler: handler,
+		queue:   make([]queuedEvent, queueSize),
+		ready:   make(chan struct{}, 1),
+		space:   make(chan struct{}),
+		done:    make(chan struct{}),
+		dropped: make(map[string]uint64),
+	}
+	if len(topics) > 0 {
+		s.topics = make(map[string]bool)
+		for _, t := range topics {
+			s.topics[t] = true
+		}
+	}
+
+	b.mtx.Lock()
+	old := b.subscriptions[name]
+	b.subscriptions[name] = s
+	b.mtx.Unlock()
+	if old != nil {
+		old.close()
+	}
+	go s.run()
+	return s
+}
+
+// Publish queues the event for the subscribers of its topic, applying the overflow policy of the
+// topic to the full queues. Publish only blocks on full queues if the policy is Block.
+func (b *Bus) Publish(e Event) {
+	b.mtx.RLock()
+	policy, ok := b.policies[e.Topic]
+	if !ok {
+		policy = b.DefaultPolicy
+	}
+	timeout := b.BlockTimeout
+	subscriptions := make([]*Subscription, 0, len(b.subscriptions))
+	for _, s := range b.subscriptions {
+		if s.topics == nil || s.topics[e.Topic] {
+			subscriptions = append(subscriptions, s)
+		}
+	}
+	b.mtx.RUnlock()
+
+	for _, s := range subscriptions {
+		s.push(e, policy, timeout)
+	}
+}
+
+// Stats returns the delivery metrics of the subscribers sorted by name
+func (b *Bus) Stats() []Stats {
+	b.mtx.RLock()
+	stats := make([]Stats, 0, len(b.subscriptions))
+	for _, s := range b.subscriptions {
+		stats = append(stats, s.Stats())
+	}
+	b.mtx.RUnlock()
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Subscriber < stats[j].Subscriber })
+	return stats
+}
+
+type queuedEvent struct {
+	Event
+	policy Policy
+}
+
+// Subscription is a subscriber of the bus with its own bounded queue
+type Subscription struct {
+	name    string
+	bus     *Bus
+	topics  map[string]bool
+	handler func(Event)
+
+	mtx sync.Mutex
+	// Ring buffer of the queued events
+	queue []queuedEvent
+	head  int
+	count int
+	// Signalled when an event is queued
+	ready chan struct{}
+	// Closed & replaced when an event is dequeued, wakes up the blocked publishers
+	space chan struct{}
+	// Closed when the subscription is closed
+	done   chan struct{}
+	closed bool
+
+	maxQueued int
+	delivered uint64
+	dropped   map[string]uint64
+	blocked   uint64
+}
+
+// Name returns the name of the subscriber
+func (s *Subscription) Name() string {
+	return s.name
+}
+
+// Close unsubscribes from the bus, the queued events are discarded. The handler may still be
+// handling an event when Close returns.
+func (s *Subscription) Close() {
+	s.bus.mtx.Lock()
+	if s.bus.subscriptions[s.name] == s {
+		delete(s.bus.subscriptions, s.name)
+	}
+	s.bus.mtx.Unlock()
+	s.close()
+}
+
+func (s *Subscription) close() {
+	s.mtx.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+	}
+	s.mtx.Unlock()
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Stats returns the delivery metrics of the subscriber
+func (s *Subscription) Stats() Stats {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	stats := Stats{
+		Subscriber: s.name,
+		QueueSize:  len(s.queue),
+		Queued:     s.count,
+		MaxQueued:  s.maxQueued,
+		Delivered:  s.delivered,
+		Blocked:    s.blocked,
+	}
+	if len(s.dropped) > 0 {
+		stats.DroppedByTopic = make(map[string]uint64, len(s.dropped))
+		for topic, n := range s.dropped {
+			stats.DroppedByTopic[topic] = n
+			stats.Dropped += n
+		}
+	}
+	return stats
+}
+
+func (s *Subscription) push(e Event, policy Policy, timeout time.Duration) {
+	var timer *time.Timer
+	s.mtx.Lock()
+	for s.count == len(s.queue) && !s.closed {
+		if policy == DropOldest && s.dropOldest() {
+			break
+		}
+		if policy != Block {
+			s.dropped[e.Topic]++
+			s.mtx.Unlock()
+			return
+		}
+		if timer == nil {
+			s.blocked++
+			timer = time.NewTimer(timeout)
+			defer timer.Stop()
+		}
+		space := s.space
+		s.mtx.Unlock()
+		select {
+		case <-space:
+		case <-s.done:
+		case <-timer.C:
+			s.mtx.Lock()
+			if s.count == len(s.queue) {
+				s.dropped[e.Topic]++
+				s.mtx.Unlock()
+				return
+			}
+			continue
+		}
+		s.mtx.Lock()
+	}
+	if s.closed {
+		s.mtx.Unlock()
+		return
+	}
+	s.queue[(s.head+s.count)%len(s.queue)] = queuedEvent{e, policy}
+	s.count++
+	if s.count > s.maxQueued {
+		s.maxQueued = s.count
+	}
+	s.mtx.Unlock()
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// dropOldest removes the oldest queued event that doesn't have the Block policy, returns false if
+// there's none. Must be called with the lock held.
+func (s *Subscription) dropOldest() bool {
+	for i := 0; i < s.count; i++ {
+		idx := (s.head + i) % len(s.queue)
+		if s.queue[idx].policy == Block {
+			continue
+		}
+		s.dropped[s.queue[idx].Topic]++
+		// Shift the older events up to fill the gap
+		for j := i; j > 0; j-- {
+			s.queue[(s.head+j)%len(s.queue)] = s.queue[(s.head+j-1)%len(s.queue)]
+		}
+		s.queue[s.head] = queuedEvent{}
+		s.head = (s.head + 1) % len(s.queue)
+		s.count--
+		return true
+	}
+	return false
+}
+
+func (s *Subscription) next() (Event, bool) {
+	s.mtx.Lock()
+	for s.count == 0 && !s.closed {
+		s.mtx.Unlock()
+		<-s.ready
+		s.mtx.Lock()
+	}
+	if s.closed {
+		s.mtx.Unlock()
+		return Event{}, false
+	}
+	e := s.queue[s.head].Event
+	s.queue[s.head] = queuedEvent{}
+	s.head = (s.head + 1) % len(s.queue)
+	s.count--
+	close(s.space)
+	s.space = make(chan struct{})
+	s.mtx.Unlock()
+	return e, true
+}
+
+func (s *Subscription) run() {
+	for {
+		e, ok := s.next()
+		if !ok {
+			return
+		}
+		s.handler(e)
+		s.mtx.Lock()
+		s.delivered++
+		s.mtx.Unlock()
+	}
+}
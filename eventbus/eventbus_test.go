@@ -0,0 +1,177 @@
+package eventbus
+
+import (
+	"testing"
+	"time"
+)
+
+// blockingHandler returns a handler that blocks until release is closed, started receives the
+// first event handled
+func blockingHandler() (handler func(Event), started chan Event, release chan struct{}) {
+	started = make(chan Event, 1)
+	release = make(chan struct{})
+	return func(e Event) {
+		select {
+		case started <- e:
+		default:
+		}
+		<-release
+	}, started, release
+}
+
+func TestParsePolicy(t *testing.T) {
+	for _, p := range []Policy{DropOldest, DropNewest, Block} {
+		parsed, err := ParsePolicy(p.String())
+		if err != nil || parsed != p {
+			t.Errorf("Test failed. Expected %s, got %s %v", p, parsed, err)
+		}
+	}
+	if _, err := ParsePolicy("drop-everything"); err == nil {
+		t.Error("Test failed. Expected an error for an unknown policy")
+	}
+}
+
+func TestPublish(t *testing.T) {
+	b := New()
+	received := make(chan Event, 10)
+	b.Subscribe("tickers", 10, func(e Event) { received <- e }, "ticker_update")
+	all := make(chan Event, 10)
+	b.Subscribe("all", 10, func(e Event) { all <- e })
+
+	b.Publish(Event{Topic: "orderbook_update", Exchange: "Kraken"})
+	b.Publish(Event{Topic: "ticker_update", Exchange: "Bitfinex"})
+
+	select {
+	case e := <-received:
+		if e.Exchange != "Bitfinex" {
+			t.Errorf("Test failed. Expected the ticker update, got %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Test failed. Ticker update wasn't delivered")
+	}
+	for _, exch := range []string{"Kraken", "Bitfinex"} {
+		select {
+		case e := <-all:
+			if e.Exchange != exch {
+				t.Errorf("Test failed. Expected the %s event, got %+v", exch, e)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Test failed. Event wasn't delivered to the subscriber of all topics")
+		}
+	}
+}
+
+func TestDropOldest(t *testing.T) {
+	b := New()
+	b.SetPolicy("orderbook_update", DropOldest)
+	handler, started, release := blockingHandler()
+	s := b.Subscribe("slow", 2, handler)
+	defer s.Close()
+
+	b.Publish(Event{Topic: "orderbook_update", Exchange: "first"})
+	<-started
+	// The handler is busy with the first event, the queue holds the next two
+	b.Publish(Event{Topic: "webhook_event", Exchange: "order"})
+	b.Publish(Event{Topic: "orderbook_update", Exchange: "second"})
+	b.Publish(Event{Topic: "orderbook_update", Exchange: "third"})
+	b.Publish(Event{Topic: "orderbook_update", Exchange: "fourth"})
+
+	stats := s.Stats()
+	if stats.Queued != 2 || stats.Dropped != 2 || stats.DroppedByTopic["orderbook_update"] != 2 {
+		t.Fatalf("Test failed. Unexpected stats %+v", stats)
+	}
+	s.mtx.Lock()
+	first, second := s.queue[s.head].Exchange, s.queue[(s.head+1)%len(s.queue)].Exchange
+	s.mtx.Unlock()
+	if first != "order" || second != "fourth" {
+		t.Errorf("Test failed. Expected the order event & the latest update to be queued, got %s %s",
+			first, second)
+	}
+	close(release)
+}
+
+func TestDropNewest(t *testing.T) {
+	b := New()
+	b.SetPolicy("ticker_update", DropNewest)
+	handler, started, release := blockingHandler()
+	s := b.Subscribe("slow", 1, handler)
+	defer s.Close()
+
+	b.Publish(Event{Topic: "ticker_update"})
+	<-started
+	b.Publish(Event{Topic: "ticker_update"})
+	b.Publish(Event{Topic: "ticker_update"})
+	if stats := s.Stats(); stats.Queued != 1 || stats.Dropped != 1 {
+		t.Errorf("Test failed. Unexpected stats %+v", stats)
+	}
+	close(release)
+}
+
+func TestBlock(t *testing.T) {
+	b := New()
+	handler, started, release := blockingHandler()
+	s := b.Subscribe("slow", 1, handler)
+	defer s.Close()
+
+	b.Publish(Event{Topic: "webhook_event"})
+	<-started
+	b.Publish(Event{Topic: "webhook_event"})
+
+	published := make(chan struct{})
+	go func() {
+		b.Publish(Event{Topic: "webhook_event"})
+		close(published)
+	}()
+	select {
+	case <-published:
+		t.Fatal("Test failed. Expected the publisher to block on the full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("Test failed. Publisher wasn't unblocked")
+	}
+	if stats := s.Stats(); stats.Blocked != 1 || stats.Dropped != 0 {
+		t.Errorf("Test failed. Unexpected stats %+v", stats)
+	}
+
+	b.BlockTimeout = 10 * time.Millisecond
+	stuck, stuckStarted, stuckRelease := blockingHandler()
+	s = b.Subscribe("stuck", 1, stuck)
+	b.Publish(Event{Topic: "webhook_event"})
+	<-stuckStarted
+	b.Publish(Event{Topic: "webhook_event"})
+	b.Publish(Event{Topic: "webhook_event"})
+	if stats := s.Stats(); stats.Blocked != 1 || stats.DroppedByTopic["webhook_event"] != 1 {
+		t.Errorf("Test failed. Expected the event to be dropped after the timeout, got %+v", stats)
+	}
+	close(stuckRelease)
+}
+
+func TestClose(t *testing.T) {
+	b := New()
+	handler, started, release := blockingHandler()
+	s := b.Subscribe("slow", 1, handler)
+	b.Publish(Event{Topic: "webhook_event"})
+	<-started
+	b.Publish(Event{Topic: "webhook_event"})
+
+	published := make(chan struct{})
+	go func() {
+		b.Publish(Event{Topic: "webhook_event"})
+		close(published)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.Close()
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("Test failed. Closing the subscription didn't unblock the publisher")
+	}
+	if len(b.Stats()) != 0 {
+		t.Errorf("Test failed. Expected no subscribers, got %+v", b.Stats())
+	}
+	close(release)
+}
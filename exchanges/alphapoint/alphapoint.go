@@ -533,6 +533,8 @@ func (a *Alphapoint) SendRequest(method, path string, data map[string]interface{
 
 // SendAuthenticatedHTTPRequest sends an authenticated request
 func (a *Alphapoint) SendAuthenticatedHTTPRequest(method, path string, data map[string]interface{}, result interface{}) error {
+	apiKey, apiSecret, clientID := a.Credentials()
+
 	if !a.AuthenticatedAPISupport {
 		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, a.Name)
 	}
@@ -545,9 +547,9 @@ func (a *Alphapoint) SendAuthenticatedHTTPRequest(method, path string, data map[
 
 	headers := make(map[string]string)
 	headers["Content-Type"] = "application/json"
-	data["apiKey"] = a.APIKey
+	data["apiKey"] = apiKey
 	data["apiNonce"] = a.Nonce.Get()
-	hmac := common.GetHMAC(common.HashSHA256, []byte(a.Nonce.String()+a.ClientID+a.APIKey), []byte(a.APISecret))
+	hmac := common.GetHMAC(common.HashSHA256, []byte(a.Nonce.String()+clientID+apiKey), []byte(apiSecret))
 	data["apiSig"] = common.StringToUpper(common.HexEncodeToString(hmac))
 	path = fmt.Sprintf("%s/ajax/v%s/%s", a.APIUrl, alphapointAPIVersion, path)
 
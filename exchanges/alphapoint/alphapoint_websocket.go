@@ -15,7 +15,7 @@ const (
 // WebsocketClient starts a new webstocket connection
 func (a *Alphapoint) WebsocketClient() {
 	for a.Enabled && a.Websocket {
-		var Dialer websocket.Dialer
+		Dialer := websocket.Dialer{EnableCompression: a.WebsocketCompression()}
 		var err error
 		a.WebsocketConn, _, err = Dialer.Dial(a.WebsocketURL, http.Header{})
 
@@ -41,6 +41,7 @@ func (a *Alphapoint) WebsocketClient() {
 				log.Println(err)
 				break
 			}
+			a.RecordWebsocketFrame(msgType, resp)
 
 			switch msgType {
 			case websocket.TextMessage:
@@ -79,7 +79,7 @@ func (a *Alphapoint) UpdateOrderbook(p pair.CurrencyPair, assetType string) (ord
 }
 
 // GetOrderbookEx returns the orderbook for a currency pair
-func (a *Alphapoint) GetOrderbookEx(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+func (a *Alphapoint) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
 	ob, err := a.Orderbooks.GetOrderbook(a.GetName(), p, assetType)
 	if err == nil {
 		return a.UpdateOrderbook(p, assetType)
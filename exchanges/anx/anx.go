@@ -32,6 +32,15 @@ type ANX struct {
 	exchange.Base
 }
 
+// New returns a ANX exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *ANX {
+	a := &ANX{}
+	a.SetDefaults()
+	a.Quickstart(a.Setup, apiKey, apiSecret, opts...)
+	return a
+}
+
 func (a *ANX) SetDefaults() {
 	a.Name = "ANX"
 	a.Enabled = false
@@ -302,6 +311,8 @@ func (a *ANX) GetDepositAddress(currency, name string, new bool) (string, error)
 }
 
 func (a *ANX) SendAuthenticatedHTTPRequest(path string, params map[string]interface{}, result interface{}) error {
+	apiKey, apiSecret, _ := a.Credentials()
+
 	if !a.AuthenticatedAPISupport {
 		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, a.Name)
 	}
@@ -332,9 +343,9 @@ func (a *ANX) SendAuthenticatedHTTPRequest(path string, params map[string]interf
 		log.Printf("Request JSON: %s\n", PayloadJSON)
 	}
 
-	hmac := common.GetHMAC(common.HashSHA512, []byte(path+string("\x00")+string(PayloadJSON)), []byte(a.APISecret))
+	hmac := common.GetHMAC(common.HashSHA512, []byte(path+string("\x00")+string(PayloadJSON)), []byte(apiSecret))
 	headers := make(map[string]string)
-	headers["Rest-Key"] = a.APIKey
+	headers["Rest-Key"] = apiKey
 	headers["Rest-Sign"] = common.Base64Encode([]byte(hmac))
 	headers["Content-Type"] = "application/json"
 
@@ -100,7 +100,7 @@ func (a *ANX) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.Pric
 }
 
 // GetOrderbookEx returns the orderbook for a currency pair
-func (a *ANX) GetOrderbookEx(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+func (a *ANX) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
 	ob, err := orderbook.getOrderbook(a.GetName(), p, assetType)
 	if err == nil {
 		return a.UpdateOrderbook(p, assetType)
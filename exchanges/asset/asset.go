@@ -0,0 +1,75 @@
+// Package asset defines the asset types (spot, margin & derivatives) the tickers, orderbooks and
+// currency pairs of an exchange are keyed by. Asset types are plain strings so that they can be
+// used as-is in the config file, REST routes and websocket requests.
+package asset
+
+import (
+	"strings"
+)
+
+// Asset types supported by the bot
+const (
+	Spot   = "SPOT"
+	Margin = "MARGIN"
+	// Futures contracts with an expiry date
+	Futures = "FUTURES"
+	// Futures contracts without an expiry date (perpetual swaps)
+	PerpetualSwap = "PERPETUAL_SWAP"
+	Options       = "OPTIONS"
+)
+
+var supported = []string{Spot, Margin, Futures, PerpetualSwap, Options}
+
+// Supported returns every asset type supported by the bot
+func Supported() []string {
+	return append([]string(nil), supported...)
+}
+
+// Normalize returns the canonical (upper case) form of a supported asset type, an empty asset type
+// is treated as Spot. Exchange specific asset types (e.g. the OKCoin futures contract periods) are
+// returned unchanged.
+func Normalize(assetType string) string {
+	assetType = strings.TrimSpace(assetType)
+	if assetType == "" {
+		return Spot
+	}
+	for _, a := range supported {
+		if strings.EqualFold(a, assetType) {
+			return a
+		}
+	}
+	return assetType
+}
+
+// IsValid returns true if the asset type is supported by the bot
+func IsValid(assetType string) bool {
+	assetType = Normalize(assetType)
+	for _, a := range supported {
+		if a == assetType {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDerivative returns true if the asset type is a derivative contract rather than the
+// underlying currency
+func IsDerivative(assetType string) bool {
+	switch Normalize(assetType) {
+	case Futures, PerpetualSwap, Options:
+		return true
+	}
+	return false
+}
+
+// Split splits a comma separated list of asset types (as stored in the config) and normalizes
+// each asset type
+func Split(assetTypes string) []string {
+	var result []string
+	for _, a := range strings.Split(assetTypes, ",") {
+		if strings.TrimSpace(a) != "" {
+			result = append(result, Normalize(a))
+		}
+	}
+	return result
+}
@@ -0,0 +1,34 @@
+package asset
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsValid(t *testing.T) {
+	tests := []struct {
+		assetType string
+		expected  bool
+	}{
+		{"SPOT", true},
+		{"", true},
+		{"margin", true},
+		{"PERPETUAL_SWAP", true},
+		{"BINARY", false},
+	}
+	for _, test := range tests {
+		if IsValid(test.assetType) != test.expected {
+			t.Errorf("Test failed. IsValid(%q) expected %v", test.assetType, test.expected)
+		}
+	}
+	if IsDerivative(Spot) || IsDerivative(Margin) || !IsDerivative("futures") {
+		t.Error("Test failed. IsDerivative returned an unexpected result")
+	}
+}
+
+func TestSplit(t *testing.T) {
+	result := Split("spot, futures,,this_week")
+	if !reflect.DeepEqual(result, []string{Spot, Futures, "this_week"}) {
+		t.Errorf("Test failed. Unexpected asset types %v", result)
+	}
+}
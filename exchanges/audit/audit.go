@@ -0,0 +1,207 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Const values for the audit package
+const (
+	// DefaultMaxSize is the default size (in bytes) an audit log file can grow to before it's rotated
+	DefaultMaxSize = 10 * 1024 * 1024
+	// DefaultMaxBackups is the default number of rotated audit log files that are kept around
+	DefaultMaxBackups = 5
+)
+
+// Entry is a single record in the audit log, each entry describes one mutating API call.
+type Entry struct {
+	Timestamp time.Time              `json:"timestamp"`
+	Exchange  string                 `json:"exchange"`
+	Method    string                 `json:"method"`
+	Params    map[string]interface{} `json:"params,omitempty"`
+	Response  interface{}            `json:"response,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+	Latency   time.Duration          `json:"latency"`
+	OrderID   string                 `json:"order_id,omitempty"`
+}
+
+// Log is an append-only audit log stored as a file of JSON encoded entries (one per line).
+// Once the file grows beyond the max size it's rotated, the previous file is renamed to
+// <path>.1, <path>.1 is renamed to <path>.2, and so on.
+type Log struct {
+	m          sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// New opens (or creates) the audit log at the given path.
+// A maxSize of 0 disables rotation, a negative maxBackups keeps the default number of backups.
+func New(path string, maxSize int64, maxBackups int) (*Log, error) {
+	if maxBackups < 0 {
+		maxBackups = DefaultMaxBackups
+	}
+	l := &Log{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Path returns the path of the current audit log file.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Record appends an entry to the audit log, rotating the log file first if it's full.
+func (l *Log) Record(entry Entry) error {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.file == nil {
+		return fmt.Errorf("audit log %s is closed", l.path)
+	}
+
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(data)) > l.maxSize {
+		if err = l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	return err
+}
+
+// Close closes the audit log file.
+func (l *Log) Close() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// FindByOrderID returns all the entries (from the current and rotated log files) that relate
+// to the given order ID, ordered from oldest to newest.
+func (l *Log) FindByOrderID(orderID string) ([]Entry, error) {
+	return l.Find(func(e *Entry) bool {
+		return e.OrderID == orderID
+	})
+}
+
+// Find returns all the entries (from the current and rotated log files) matching the given
+// filter, ordered from oldest to newest.
+func (l *Log) Find(filter func(e *Entry) bool) ([]Entry, error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	result := []Entry{}
+	for i := l.maxBackups; i >= 0; i-- {
+		entries, err := ReadEntries(l.backupPath(i))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		for x := range entries {
+			if filter(&entries[x]) {
+				result = append(result, entries[x])
+			}
+		}
+	}
+	return result, nil
+}
+
+// ReadEntries reads all the entries from an audit log file.
+func ReadEntries(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := []Entry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		entry := Entry{}
+		if err = json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("failed to decode audit log entry in %s: %s", path, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func (l *Log) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	l.file = file
+	l.size = info.Size()
+	return nil
+}
+
+func (l *Log) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	l.file = nil
+
+	if l.maxBackups == 0 {
+		if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	} else {
+		for i := l.maxBackups - 1; i >= 0; i-- {
+			err := os.Rename(l.backupPath(i), l.backupPath(i+1))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return l.open()
+}
+
+// Returns the path of the n-th rotated log file, or the current log file if n is zero.
+func (l *Log) backupPath(n int) string {
+	if n == 0 {
+		return l.path
+	}
+	return fmt.Sprintf("%s.%d", l.path, n)
+}
@@ -0,0 +1,87 @@
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestLog(t *testing.T, maxSize int64, maxBackups int) (*Log, func()) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatalf("Test failed. Unable to create temp dir. Error: %s", err)
+	}
+	l, err := New(filepath.Join(dir, "audit.log"), maxSize, maxBackups)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("Test failed. New() error: %s", err)
+	}
+	return l, func() {
+		l.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestRecordAndFindByOrderID(t *testing.T) {
+	l, cleanup := newTestLog(t, 0, 0)
+	defer cleanup()
+
+	entries := []Entry{
+		{Exchange: "Bitfinex", Method: "NewOrder", OrderID: "1", Latency: time.Millisecond},
+		{Exchange: "Bitfinex", Method: "NewOrder", OrderID: "2"},
+		{Exchange: "Bitfinex", Method: "CancelOrder", OrderID: "1", Error: "order not found"},
+	}
+	for _, e := range entries {
+		if err := l.Record(e); err != nil {
+			t.Fatalf("Test failed. Record() error: %s", err)
+		}
+	}
+
+	found, err := l.FindByOrderID("1")
+	if err != nil {
+		t.Fatalf("Test failed. FindByOrderID() error: %s", err)
+	}
+	if len(found) != 2 {
+		t.Fatalf("Test failed. Expected 2 entries, got %d", len(found))
+	}
+	if found[0].Method != "NewOrder" || found[1].Method != "CancelOrder" {
+		t.Errorf("Test failed. Entries returned in unexpected order: %v", found)
+	}
+	if found[0].Latency != time.Millisecond {
+		t.Errorf("Test failed. Expected latency to be preserved, got %s", found[0].Latency)
+	}
+	if found[1].Error != "order not found" {
+		t.Errorf("Test failed. Expected error to be preserved, got %s", found[1].Error)
+	}
+	if found[0].Timestamp.IsZero() {
+		t.Error("Test failed. Expected timestamp to be set")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	l, cleanup := newTestLog(t, 200, 2)
+	defer cleanup()
+
+	for i := 0; i < 20; i++ {
+		if err := l.Record(Entry{Exchange: "Binance", Method: "NewOrder", OrderID: "42"}); err != nil {
+			t.Fatalf("Test failed. Record() error: %s", err)
+		}
+	}
+
+	if _, err := os.Stat(l.Path() + ".1"); err != nil {
+		t.Errorf("Test failed. Expected rotated log file to exist. Error: %s", err)
+	}
+	if _, err := os.Stat(l.Path() + ".3"); !os.IsNotExist(err) {
+		t.Error("Test failed. Expected only 2 rotated log files to be kept")
+	}
+
+	found, err := l.FindByOrderID("42")
+	if err != nil {
+		t.Fatalf("Test failed. FindByOrderID() error: %s", err)
+	}
+	if len(found) == 0 || len(found) >= 20 {
+		t.Errorf("Test failed. Unexpected number of entries after rotation: %d", len(found))
+	}
+}
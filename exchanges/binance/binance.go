@@ -1,6 +1,7 @@
 package binance
 
 import (
+	"context"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -10,11 +11,13 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/mattkanwisher/cryptofiend/common"
 	"github.com/mattkanwisher/cryptofiend/currency/pair"
 	exchange "github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ratelimit"
 )
 
 const (
@@ -25,6 +28,10 @@ const (
 	binanceOrderPath        = "api/v3/order"
 	binanceOrderTestPath    = "api/v3/order/test"
 	binanceDepthPath        = "api/v1/depth"
+	binanceTimePath         = "api/v1/time"
+	binanceSystemStatusPath = "wapi/v3/systemStatus.html"
+	binanceCoinConfigPath   = "sapi/v1/capital/config/getall"
+	binanceUserDataPath     = "api/v1/userDataStream"
 )
 
 // BinanceErrCode enum represents a frequently encountered subset of the error codes documented at:
@@ -38,17 +45,28 @@ const (
 
 type Binance struct {
 	exchange.Base
-	// Maps HTTP method & path to a timestamp (in msecs) of the last time a request was sent
-	rateLimits map[string]int64
-	// Timestamp (in msecs) of the last time the Binance server rate limited a request
-	ipBanStartTime int64
+	// Limits the number of requests sent to each HTTP method & path
+	rateLimiter *ratelimit.Limiter
 	// Maps symbol (exchange specific market identifier) to currency pair info
 	currencyPairs    map[pair.CurrencyItem]*exchange.CurrencyPairInfo
 	symbolDetailsMap map[pair.CurrencyItem]*symbolDetails
-	// Cached data that's returned when HTTP requests are rate-limited
-	lastAccountInfo AccountInfo
-	lastOpenOrders  map[string][]Order
-	lastMarketData  map[string]*MarketData
+	// Cached data that's returned when HTTP requests are rate-limited, along with the time it
+	// was fetched
+	lastAccountInfo     AccountInfo
+	lastAccountInfoTime time.Time
+	lastOpenOrders      map[string][]Order
+	lastOpenOrdersTime  map[string]time.Time
+	lastMarketData      map[string]*MarketData
+	lastMarketDataTime  map[string]time.Time
+	// Caches the results of the bulk symbol <-> currency pair conversions
+	symbolCache exchange.SymbolCache
+	// Orderbooks maintained by the websocket client
+	streams websocketStreams
+	// Account info & open orders pushed by the user data stream
+	userData userDataCache
+	// Difference between the server clock and the local clock (in milliseconds), added to the
+	// timestamps of signed requests
+	timeOffset int64
 }
 
 // CurrencyPairToSymbol converts a currency pair to a symbol (exchange specific market identifier).
@@ -67,34 +85,63 @@ func (b *Binance) SymbolToCurrencyPair(symbol string) (pair.CurrencyPair, error)
 	return pair.CurrencyPair{}, fmt.Errorf("no currency pair found for '%s' symbol", symbol)
 }
 
+// CurrencyPairsToSymbols converts a batch of currency pairs to symbols, conversions are cached.
+func (b *Binance) CurrencyPairsToSymbols(pairs []pair.CurrencyPair) ([]string, error) {
+	return b.symbolCache.CurrencyPairsToSymbols(pairs, func(cp pair.CurrencyPair) (string, error) {
+		return b.CurrencyPairToSymbol(cp), nil
+	})
+}
+
+// SymbolsToCurrencyPairs converts a batch of symbols to currency pairs, conversions are cached.
+func (b *Binance) SymbolsToCurrencyPairs(symbols []string) ([]pair.CurrencyPair, error) {
+	return b.symbolCache.SymbolsToCurrencyPairs(symbols, b.SymbolToCurrencyPair)
+}
+
 // FetchExchangeInfo fetches current exchange trading rules and symbol information.
 func (b *Binance) FetchExchangeInfo() (*ExchangeInfo, error) {
 	response := ExchangeInfo{}
-	err := common.SendHTTPGetRequest(binanceBaseURL+binanceExchangeInfoPath, true, b.Verbose, &response)
+	err := common.SendHTTPGetRequestStream(b.APIUrl+binanceExchangeInfoPath, b.Debug(exchange.TraceHTTP), &response)
 	return &response, err
 }
 
-// FetchAccountInfo fetches current account information.
+// FetchAccountInfo fetches current account information, the account info pushed by the user data
+// stream is returned instead while the stream is connected.
 // If this method gets rate limited it will return the account info obtained during the
-// last successful fetch, and an error matching exchange.WarningHTTPRequestRateLimited.
+// last successful fetch, and an exchange.RateLimitedWarning.
 func (b *Binance) FetchAccountInfo() (*AccountInfo, error) {
+	return b.fetchAccountInfo(context.Background())
+}
+
+func (b *Binance) fetchAccountInfo(ctx context.Context) (*AccountInfo, error) {
+	if pushed, ok := b.userData.getAccountInfo(); ok {
+		return pushed, nil
+	}
 	response := AccountInfo{}
-	err := b.SendRateLimitedHTTPRequest(20, http.MethodGet, binanceAccountPath, nil,
-		RequestSecuritySign, &response, b.lastAccountInfo)
+	err := b.SendRateLimitedHTTPRequestContext(ctx, 20, http.MethodGet, binanceAccountPath, nil,
+		RequestSecuritySign, &response, b.lastAccountInfo, b.lastAccountInfoTime)
 	if err != nil {
 		return &response, err
 	}
 	b.lastAccountInfo = response
+	b.lastAccountInfoTime = time.Now()
 	return &response, nil
 }
 
-// FetchOpenOrders fetches all currently open orders.
+// FetchOpenOrders fetches all currently open orders, the orders tracked by the user data stream
+// are returned instead while the stream is connected.
 // If the symbol parameter is blank all open orders for the account will be returned,
 // this should generally be avoided as it's an expensive operation that can very quickly put
 // you over the request rate limit if this method is called multiple times per minute.
 // If this method gets rate limited it will return the set of orders obtained during the
-// last successful fetch, and an error matching exchange.WarningHTTPRequestRateLimited.
+// last successful fetch, and an exchange.RateLimitedWarning.
 func (b *Binance) FetchOpenOrders(symbol string) ([]Order, error) {
+	return b.fetchOpenOrders(context.Background(), symbol)
+}
+
+func (b *Binance) fetchOpenOrders(ctx context.Context, symbol string) ([]Order, error) {
+	if pushed, ok := b.userData.getOpenOrders(symbol); ok {
+		return pushed, nil
+	}
 	v := url.Values{}
 	if symbol != "" {
 		v.Set("symbol", symbol)
@@ -104,12 +151,13 @@ func (b *Binance) FetchOpenOrders(symbol string) ([]Order, error) {
 		lastOpenOrders = []Order{}
 	}
 	response := []Order{}
-	err := b.SendRateLimitedHTTPRequest(10, http.MethodGet, binanceOpenOrdersPath, v,
-		RequestSecuritySign, &response, lastOpenOrders)
+	err := b.SendRateLimitedHTTPRequestContext(ctx, 10, http.MethodGet, binanceOpenOrdersPath, v,
+		RequestSecuritySign, &response, lastOpenOrders, b.lastOpenOrdersTime[symbol])
 	if err != nil {
 		return response, err
 	}
 	b.lastOpenOrders[symbol] = response
+	b.lastOpenOrdersTime[symbol] = time.Now()
 	return response, nil
 }
 
@@ -129,6 +177,10 @@ type PostOrderParams struct {
 }
 
 func (b *Binance) PostOrderAck(params *PostOrderParams) (*PostOrderAckResponse, error) {
+	return b.postOrderAck(context.Background(), params)
+}
+
+func (b *Binance) postOrderAck(ctx context.Context, params *PostOrderParams) (*PostOrderAckResponse, error) {
 	v := url.Values{}
 	v.Set("symbol", params.Symbol)
 	v.Set("side", string(params.Side))
@@ -152,12 +204,17 @@ func (b *Binance) PostOrderAck(params *PostOrderParams) (*PostOrderAckResponse,
 	if params.ValidateOnly {
 		path = binanceOrderTestPath
 	}
-	_, err := b.SendHTTPRequest(http.MethodPost, path, v, RequestSecuritySign, &response)
+	_, err := b.SendHTTPRequestContext(ctx, http.MethodPost, path, v, RequestSecuritySign, &response)
 	return &response, err
 }
 
 // FetchOrder fetches an order from the exchange, either orderID or clientOrderID must be provided.
 func (b *Binance) FetchOrder(symbol string, orderID int64, clientOrderID string) (*Order, error) {
+	return b.fetchOrder(context.Background(), symbol, orderID, clientOrderID)
+}
+
+func (b *Binance) fetchOrder(ctx context.Context, symbol string, orderID int64,
+	clientOrderID string) (*Order, error) {
 	v := url.Values{}
 	v.Set("symbol", symbol)
 	if orderID != 0 {
@@ -167,12 +224,17 @@ func (b *Binance) FetchOrder(symbol string, orderID int64, clientOrderID string)
 		v.Set("origClientOrderId", clientOrderID)
 	}
 	response := Order{}
-	_, err := b.SendHTTPRequest(http.MethodGet, binanceOrderPath, v, RequestSecuritySign, &response)
+	_, err := b.SendHTTPRequestContext(ctx, http.MethodGet, binanceOrderPath, v, RequestSecuritySign,
+		&response)
 	return &response, err
 }
 
 // DeleteOrder cancels an active order on the exchange, either orderID or clientOrderID must be provided.
 func (b *Binance) DeleteOrder(symbol string, orderID int64, clientOrderID string) error {
+	return b.deleteOrder(context.Background(), symbol, orderID, clientOrderID)
+}
+
+func (b *Binance) deleteOrder(ctx context.Context, symbol string, orderID int64, clientOrderID string) error {
 	v := url.Values{}
 	v.Set("symbol", symbol)
 	if orderID != 0 {
@@ -182,7 +244,8 @@ func (b *Binance) DeleteOrder(symbol string, orderID int64, clientOrderID string
 		v.Set("origClientOrderId", clientOrderID)
 	}
 	response := DeleteOrderResponse{}
-	_, err := b.SendHTTPRequest(http.MethodDelete, binanceOrderPath, v, RequestSecuritySign, &response)
+	_, err := b.SendHTTPRequestContext(ctx, http.MethodDelete, binanceOrderPath, v, RequestSecuritySign,
+		&response)
 	return err
 }
 
@@ -192,8 +255,12 @@ func (b *Binance) DeleteOrder(symbol string, orderID int64, clientOrderID string
 // (this can return a lot of data, so should avoided).
 // NOTE: Unlike most other exchange Binance requires a valid API key when fetching market data.
 // If this method gets rate limited it will return the market data obtained during the
-// last successful fetch, and an error matching exchange.WarningHTTPRequestRateLimited.
+// last successful fetch, and an exchange.RateLimitedWarning.
 func (b *Binance) FetchMarketData(symbol string, limit int64) (*MarketData, error) {
+	return b.fetchMarketData(context.Background(), symbol, limit)
+}
+
+func (b *Binance) fetchMarketData(ctx context.Context, symbol string, limit int64) (*MarketData, error) {
 	v := url.Values{}
 	v.Set("symbol", symbol)
 	if limit > -1 {
@@ -205,10 +272,70 @@ func (b *Binance) FetchMarketData(symbol string, limit int64) (*MarketData, erro
 		lastMarketData = &MarketData{}
 	}
 	response := MarketData{}
-	err := b.SendRateLimitedHTTPRequest(20, http.MethodGet, binanceDepthPath, v, RequestSecurityAuth,
-		&response, lastMarketData)
+	err := b.SendRateLimitedHTTPRequestContext(ctx, 20, http.MethodGet, binanceDepthPath, v,
+		RequestSecurityAuth, &response, lastMarketData, b.lastMarketDataTime[symbol])
+	if err != nil {
+		return &response, err
+	}
 	b.lastMarketData[symbol] = &response
-	return &response, err
+	b.lastMarketDataTime[symbol] = time.Now()
+	return &response, nil
+}
+
+// SyncClock fetches the server time and adjusts the timestamps of signed requests to match the
+// server clock.
+func (b *Binance) SyncClock() error {
+	response := ServerTime{}
+	start := time.Now()
+	if err := common.SendHTTPGetRequestStream(b.APIUrl+binanceTimePath, b.Debug(exchange.TraceHTTP), &response); err != nil {
+		return err
+	}
+	// assume the server time was captured halfway through the request
+	local := start.Add(time.Since(start)/2).UnixNano() / int64(time.Millisecond)
+	atomic.StoreInt64(&b.timeOffset, response.ServerTime-local)
+	return nil
+}
+
+// GetPlatformStatus returns whether the exchange is operational or under maintenance, Binance
+// doesn't announce planned maintenance through the API.
+func (b *Binance) GetPlatformStatus() (exchange.PlatformStatus, error) {
+	response := SystemStatus{}
+	err := common.SendHTTPGetRequestStream(b.APIUrl+binanceSystemStatusPath, b.Debug(exchange.TraceHTTP), &response)
+	if err != nil {
+		return exchange.PlatformStatus{}, err
+	}
+	return exchange.PlatformStatus{Operational: response.Status == 0}, nil
+}
+
+// GetCoinConfigs returns the deposit & withdrawal networks of every coin
+func (b *Binance) GetCoinConfigs() ([]CoinConfig, error) {
+	var response []CoinConfig
+	_, err := b.SendHTTPRequest(http.MethodGet, binanceCoinConfigPath, nil, RequestSecuritySign, &response)
+	return response, err
+}
+
+// CreateListenKey starts a user data stream, the listen key expires after 60 minutes unless it's
+// kept alive.
+func (b *Binance) CreateListenKey() (string, error) {
+	response := ListenKeyResponse{}
+	_, err := b.SendHTTPRequest(http.MethodPost, binanceUserDataPath, nil, RequestSecurityAuth, &response)
+	return response.ListenKey, err
+}
+
+// KeepAliveListenKey extends the validity of the listen key by 60 minutes.
+func (b *Binance) KeepAliveListenKey(listenKey string) error {
+	v := url.Values{}
+	v.Set("listenKey", listenKey)
+	_, err := b.SendHTTPRequest(http.MethodPut, binanceUserDataPath, v, RequestSecurityAuth, &struct{}{})
+	return err
+}
+
+// CloseListenKey closes the user data stream of the listen key.
+func (b *Binance) CloseListenKey(listenKey string) error {
+	v := url.Values{}
+	v.Set("listenKey", listenKey)
+	_, err := b.SendHTTPRequest(http.MethodDelete, binanceUserDataPath, v, RequestSecurityAuth, &struct{}{})
+	return err
 }
 
 type RequestSecurityEnum uint8
@@ -227,11 +354,18 @@ const (
 // Returns the Binance error code and error message (if any).
 func (b *Binance) SendHTTPRequest(method, path string, params url.Values, security RequestSecurityEnum,
 	result interface{}) (int, error) {
+	return b.SendHTTPRequestContext(context.Background(), method, path, params, security, result)
+}
+
+// SendHTTPRequestContext is SendHTTPRequest, the request is cancelled when the context is done
+func (b *Binance) SendHTTPRequestContext(ctx context.Context, method, path string, params url.Values,
+	security RequestSecurityEnum, result interface{}) (int, error) {
 	if (security != RequestSecurityNone) && !b.AuthenticatedAPISupport {
 		return 0, fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, b.Name)
 	}
 
-	if b.Verbose {
+	apiKey, apiSecret, _ := b.Credentials()
+	if b.Debug(exchange.TraceHTTP) {
 		log.Printf("Request params: %v\n", params)
 	}
 
@@ -247,51 +381,54 @@ func (b *Binance) SendHTTPRequest(method, path string, params url.Values, securi
 		recvWindow := 5000
 		// HACK: Subtract 1 sec from the real timestamp to get around incessant timestamp errors
 		// from Binance.
-		timestamp := time.Now().UnixNano()/(1000*1000) - 1000 // must be in milliseconds
+		timestamp := time.Now().UnixNano()/(1000*1000) - 1000 + atomic.LoadInt64(&b.timeOffset) // must be in milliseconds
 		timeWindow := fmt.Sprintf("timestamp=%v&recvWindow=%d", timestamp, recvWindow)
 		if payload != "" {
 			payload += "&" + timeWindow
 		} else {
 			payload = timeWindow
 		}
-		hmac := common.GetHMAC(common.HashSHA256, []byte(payload), []byte(b.APISecret))
+		hmac := common.GetHMAC(common.HashSHA256, []byte(payload), []byte(apiSecret))
 		payload = fmt.Sprintf("%s&signature=%s", payload, hex.EncodeToString(hmac))
 	}
 
 	if security != RequestSecurityNone {
-		headers["X-MBX-APIKEY"] = []string{b.APIKey}
+		headers["X-MBX-APIKEY"] = []string{apiKey}
 	}
 
 	var resp string
 	var statusCode int
 	var err error
 	if method == http.MethodGet {
-		resp, statusCode, err = common.SendHTTPRequest2(
-			method, fmt.Sprintf("%s%s?%s", binanceBaseURL, path, payload), headers, nil)
+		resp, statusCode, err = common.SendHTTPRequest2Context(ctx,
+			method, fmt.Sprintf("%s%s?%s", b.APIUrl, path, payload), headers, nil)
 	} else {
 		headers["Content-Type"] = []string{"application/x-www-form-urlencoded"}
-		resp, statusCode, err = common.SendHTTPRequest2(method,
-			binanceBaseURL+path, headers, strings.NewReader(payload))
+		resp, statusCode, err = common.SendHTTPRequest2Context(ctx, method,
+			b.APIUrl+path, headers, strings.NewReader(payload))
 	}
 
 	if err != nil {
 		return 0, err
 	}
 
-	if b.Verbose {
+	if b.Debug(exchange.TraceHTTP) {
 		log.Printf("Received raw: \n%s\n", resp)
 	}
 
 	if 200 <= statusCode && statusCode <= 299 {
 		if err = common.JSONDecode([]byte(resp), &result); err != nil {
-			return statusCode, errors.New("failed to unmarshal response")
+			return statusCode, exchange.NewExchangeError(b.Name, path, statusCode, 0,
+				"failed to unmarshal response", resp)
 		}
 	} else {
 		var errInfo ErrorInfo
 		if err = common.JSONDecode([]byte(resp), &errInfo); err != nil {
-			return 0, errors.New("failed to unmarshal error info")
+			return 0, exchange.NewExchangeError(b.Name, path, statusCode, 0,
+				"failed to unmarshal error info", resp)
 		}
-		return int(errInfo.Code), errors.New(errInfo.Message)
+		return int(errInfo.Code), exchange.NewExchangeError(b.Name, path, statusCode,
+			int(errInfo.Code), errInfo.Message, resp)
 	}
 
 	return 0, nil
@@ -301,31 +438,33 @@ func (b *Binance) SendHTTPRequest(method, path string, params url.Values, securi
 // hasn't been exceeded for the specified method & path and unmarshals the response into the
 // result parameter. If the number of requests per minute has been exceeded this method will
 // set the result to the default value (which can be a pointer, but must not be nil), and return
-// exchange.WarningHTTPRequestRateLimited.
+// an exchange.RateLimitedWarning with the time the default value was fetched (cachedAt).
 func (b *Binance) SendRateLimitedHTTPRequest(requestsPerMin uint, method string, path string,
-	params url.Values, security RequestSecurityEnum, result interface{}, defaultValue interface{}) error {
-	curTimestamp := time.Now().UnixNano() / (1000 * 1000) // convert to milliseconds
-	requestDelay := int64((60 * 1000) / requestsPerMin)   // min delay between requests in msecs
-	lastRequestTime := b.rateLimits[method+path]
-	// If we got IP banned wait 5 mins before trying again, otherwise we might get banned for longer.
-	skipRequest := (b.ipBanStartTime != 0) && ((curTimestamp - b.ipBanStartTime) < (5 * 60 * 1000))
+	params url.Values, security RequestSecurityEnum, result interface{}, defaultValue interface{},
+	cachedAt time.Time) error {
+	return b.SendRateLimitedHTTPRequestContext(context.Background(), requestsPerMin, method, path,
+		params, security, result, defaultValue, cachedAt)
+}
+
+// SendRateLimitedHTTPRequestContext is SendRateLimitedHTTPRequest, the request is cancelled when
+// the context is done
+func (b *Binance) SendRateLimitedHTTPRequestContext(ctx context.Context, requestsPerMin uint,
+	method string, path string, params url.Values, security RequestSecurityEnum, result interface{},
+	defaultValue interface{}, cachedAt time.Time) error {
 	// Make sure requests are spaced out to avoid getting IP banned in the first place.
-	if !skipRequest {
-		skipRequest = (curTimestamp - lastRequestTime) < requestDelay
-	}
+	skipRequest := !b.rateLimiter.Allow(method, path, requestsPerMin)
 
 	if !skipRequest {
-		code, err := b.SendHTTPRequest(method, path, params, security, result)
+		code, err := b.SendHTTPRequestContext(ctx, method, path, params, security, result)
 		if err != nil {
 			if BinanceErrCode(code) == TooManyRequestsErrCode {
-				b.ipBanStartTime = curTimestamp
+				// If we got IP banned wait 5 mins before trying again, otherwise we might get
+				// banned for longer.
+				b.rateLimiter.Ban(5 * time.Minute)
 				skipRequest = true
 			} else {
 				return err
 			}
-		} else {
-			b.ipBanStartTime = 0
-			b.rateLimits[method+path] = curTimestamp
 		}
 	}
 
@@ -345,7 +484,7 @@ func (b *Binance) SendRateLimitedHTTPRequest(requestsPerMin uint, method string,
 			reflect.Indirect(rv).Set(dv)
 		}
 
-		return exchange.WarningHTTPRequestRateLimited()
+		return exchange.NewRateLimitedWarning(b.Name, path, cachedAt)
 	}
 
 	return nil
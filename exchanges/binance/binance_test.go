@@ -0,0 +1,55 @@
+package binance
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+func TestSyncClockThroughDecorators(t *testing.T) {
+	var paths []string
+	serverTime := time.Now().Add(time.Hour).UnixNano() / int64(time.Millisecond)
+	b, server := newTestBinance(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/" + binanceTimePath:
+			fmt.Fprintf(w, `{"serverTime":%d}`, serverTime)
+		case "/" + binanceOrderPath:
+			if len(paths) == 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"code":-1021,"msg":"Timestamp for this request is outside of the recvWindow."}`)
+				return
+			}
+			fmt.Fprint(w, `{"symbol":"BNBBTC","orderId":1}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	// the decorators are stacked in the same order as the bot does
+	policy, err := exchange.ParseRoundingPolicy("floor", "nearest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exch exchange.IBotExchangeEx = exchange.NewRoundingExchange(b, policy)
+	exch = exchange.NewRetryingExchange(exch, exchange.DefaultRetryPolicy)
+	exch = exchange.NewDegradableExchange(exch, 3)
+	exch = exchange.NewThrottledExchange(exch, exchange.ThrottleLimits{OrdersPerMinute: 10})
+	exch = exchange.NewPausableExchange(exch, exchange.NewTradingSwitch())
+	exch = exchange.NewDowntimeSimulator(exch)
+
+	if err := exch.CancelOrder("1", pair.NewCurrencyPair("BNB", "BTC")); err != nil {
+		t.Fatalf("Test failed. Expected the cancel to succeed after resyncing the clock, got %s", err)
+	}
+	if len(paths) != 3 || paths[1] != "/"+binanceTimePath {
+		t.Errorf("Test failed. Expected the clock to be resynced before retrying, got requests %v", paths)
+	}
+	if b.timeOffset < int64(59*time.Minute/time.Millisecond) {
+		t.Errorf("Test failed. Expected the time offset to match the server clock, got %dms", b.timeOffset)
+	}
+}
@@ -85,6 +85,38 @@ type Order struct {
 	IsWorking     bool        `json:"isWorking"`
 }
 
+// ServerTime is the response of the server time endpoint
+type ServerTime struct {
+	ServerTime int64 `json:"serverTime"` // milliseconds
+}
+
+// CoinConfig holds the deposit & withdrawal networks of a coin
+type CoinConfig struct {
+	Coin        string        `json:"coin"`
+	Name        string        `json:"name"`
+	NetworkList []CoinNetwork `json:"networkList"`
+}
+
+// CoinNetwork holds the deposit & withdrawal details of a coin on a network
+type CoinNetwork struct {
+	Network        string  `json:"network"`
+	Name           string  `json:"name"`
+	IsDefault      bool    `json:"isDefault"`
+	DepositEnable  bool    `json:"depositEnable"`
+	WithdrawEnable bool    `json:"withdrawEnable"`
+	WithdrawFee    float64 `json:"withdrawFee,string"`
+	WithdrawMin    float64 `json:"withdrawMin,string"`
+	MinConfirm     int     `json:"minConfirm"`
+	// True if deposits & withdrawals need a memo (tag) in addition to the address
+	SameAddress bool `json:"sameAddress"`
+}
+
+// SystemStatus is the response of the system status endpoint
+type SystemStatus struct {
+	Status int    `json:"status"` // 0 = normal, 1 = system maintenance
+	Msg    string `json:"msg"`
+}
+
 type ExchangeInfo struct {
 	Symbols []SymbolInfo
 }
@@ -178,3 +210,146 @@ type MarketData struct {
 	Bids         []OrderbookEntry `json:"bids"`
 	Asks         []OrderbookEntry `json:"asks"`
 }
+
+// WebsocketStreamMessage wraps the events of the combined streams with the name of their stream
+type WebsocketStreamMessage struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// WebsocketDepthEvent is an update of the price levels of an orderbook, a zero quantity removes
+// the level
+type WebsocketDepthEvent struct {
+	EventType     string           `json:"e"`
+	EventTime     int64            `json:"E"`
+	Symbol        string           `json:"s"`
+	FirstUpdateID int64            `json:"U"`
+	FinalUpdateID int64            `json:"u"`
+	Bids          []OrderbookEntry `json:"b"`
+	Asks          []OrderbookEntry `json:"a"`
+}
+
+// WebsocketTradeEvent is a trade executed on the exchange. The JSON keys only differ by case, so
+// every key must have a field, otherwise it's decoded into the field of the other key.
+type WebsocketTradeEvent struct {
+	EventType     string  `json:"e"`
+	EventTime     int64   `json:"E"`
+	Symbol        string  `json:"s"`
+	TradeID       int64   `json:"t"`
+	Price         float64 `json:"p,string"`
+	Quantity      float64 `json:"q,string"`
+	BuyerOrderID  int64   `json:"b"`
+	SellerOrderID int64   `json:"a"`
+	TradeTime     int64   `json:"T"` // milliseconds
+	BuyerIsMaker  bool    `json:"m"`
+	Ignore        bool    `json:"M"`
+}
+
+// WebsocketKlineEvent is an update of the current candle of a symbol, it's pushed every two
+// seconds & once more when the candle closes
+type WebsocketKlineEvent struct {
+	EventType string         `json:"e"`
+	EventTime int64          `json:"E"`
+	Symbol    string         `json:"s"`
+	Kline     WebsocketKline `json:"k"`
+}
+
+// WebsocketKline is a candle of the kline stream. As with the trade events every key must have a
+// field, since the keys only differ by case.
+type WebsocketKline struct {
+	StartTime           int64   `json:"t"` // milliseconds
+	CloseTime           int64   `json:"T"` // milliseconds
+	Symbol              string  `json:"s"`
+	Interval            string  `json:"i"`
+	FirstTradeID        int64   `json:"f"`
+	LastTradeID         int64   `json:"L"`
+	Open                float64 `json:"o,string"`
+	Close               float64 `json:"c,string"`
+	High                float64 `json:"h,string"`
+	Low                 float64 `json:"l,string"`
+	Volume              float64 `json:"v,string"`
+	Trades              int64   `json:"n"`
+	Closed              bool    `json:"x"`
+	QuoteVolume         float64 `json:"q,string"`
+	TakerBuyVolume      float64 `json:"V,string"`
+	TakerBuyQuoteVolume float64 `json:"Q,string"`
+	Ignore              string  `json:"B"`
+}
+
+// WebsocketSubscribeRequest subscribes the combined streams connection to more streams
+type WebsocketSubscribeRequest struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	ID     int64    `json:"id"`
+}
+
+// ListenKeyResponse is the response of the user data stream endpoint
+type ListenKeyResponse struct {
+	ListenKey string `json:"listenKey"`
+}
+
+// WebsocketEvent holds the type of a user data stream event
+type WebsocketEvent struct {
+	EventType string `json:"e"`
+	EventTime int64  `json:"E"` // milliseconds
+}
+
+// WebsocketExecutionReport is pushed by the user data stream when an order is placed, filled,
+// cancelled, rejected or expires. The JSON keys only differ by case, so every key must have a field.
+type WebsocketExecutionReport struct {
+	EventType           string      `json:"e"`
+	EventTime           int64       `json:"E"`
+	Symbol              string      `json:"s"`
+	ClientOrderID       string      `json:"c"`
+	Side                OrderSide   `json:"S"`
+	Type                OrderType   `json:"o"`
+	TimeInForce         TimeInForce `json:"f"`
+	Quantity            float64     `json:"q,string"`
+	Price               float64     `json:"p,string"`
+	StopPrice           float64     `json:"P,string"`
+	IcebergQty          float64     `json:"F,string"`
+	OrderListID         int64       `json:"g"`
+	OrigClientOrderID   string      `json:"C"` // client ID of the cancelled order
+	ExecutionType       string      `json:"x"`
+	Status              OrderStatus `json:"X"`
+	RejectReason        string      `json:"r"`
+	OrderID             int64       `json:"i"`
+	LastExecutedQty     float64     `json:"l,string"`
+	CumulativeFilledQty float64     `json:"z,string"`
+	LastExecutedPrice   float64     `json:"L,string"`
+	Commission          float64     `json:"n,string"`
+	CommissionAsset     string      `json:"N"`
+	TransactionTime     int64       `json:"T"`
+	TradeID             int64       `json:"t"`
+	IgnoreI             int64       `json:"I"`
+	IsWorking           bool        `json:"w"`
+	IsMaker             bool        `json:"m"`
+	IgnoreM             bool        `json:"M"`
+	CreationTime        int64       `json:"O"`
+	CumulativeQuoteQty  float64     `json:"Z,string"`
+	LastQuoteQty        float64     `json:"Y,string"`
+	QuoteOrderQty       float64     `json:"Q,string"`
+}
+
+// WebsocketBalance is a balance pushed by the user data stream
+type WebsocketBalance struct {
+	Asset  string  `json:"a"`
+	Free   float64 `json:"f,string"`
+	Locked float64 `json:"l,string"`
+}
+
+// WebsocketAccountInfo is pushed by the user data stream when the balances of the account change.
+// The JSON keys only differ by case, so every key must have a field.
+type WebsocketAccountInfo struct {
+	EventType        string             `json:"e"`
+	EventTime        int64              `json:"E"`
+	MakerCommission  int                `json:"m"`
+	TakerCommission  int                `json:"t"`
+	BuyerCommission  int                `json:"b"`
+	SellerCommission int                `json:"s"`
+	CanTrade         bool               `json:"T"`
+	CanWithdraw      bool               `json:"W"`
+	CanDeposit       bool               `json:"D"`
+	LastUpdateTime   int64              `json:"u"`
+	Balances         []WebsocketBalance `json:"B"`
+}
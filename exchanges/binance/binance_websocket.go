@@ -0,0 +1,680 @@
+package binance
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/stream"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+const (
+	binanceWebsocketURL         = "wss://stream.binance.com:9443/stream?streams="
+	binanceUserDataWebsocketURL = "wss://stream.binance.com:9443/ws/"
+	binanceWebsocketReconnect   = 5 * time.Second
+	// Binance pings the clients every 3 minutes, the connection is checked more often
+	binanceWebsocketPingInterval = 30 * time.Second
+	binanceWebsocketDepthStream  = "@depth"
+	binanceWebsocketTradeStream  = "@trade"
+	binanceWebsocketKlineStream  = "@kline_"
+	binanceWebsocketDepthEvent   = "depthUpdate"
+	binanceWebsocketTradeEvent   = "trade"
+	binanceWebsocketKlineEvent   = "kline"
+	// Depth of the REST snapshot the depth updates are applied to
+	binanceWebsocketSnapshotDepth = 1000
+	// Max number of depth updates buffered while waiting for a snapshot
+	binanceWebsocketMaxBuffered = 1000
+	// User data stream events
+	binanceWebsocketExecutionReport = "executionReport"
+	binanceWebsocketAccountInfo     = "outboundAccountInfo"
+	// Listen keys expire after 60 minutes unless they're kept alive
+	binanceListenKeyKeepAlive = 30 * time.Minute
+)
+
+// binanceKlineIntervals maps the candle intervals to the intervals of the kline streams
+var binanceKlineIntervals = map[exchange.CandleInterval]string{
+	exchange.CandleInterval1m:  "1m",
+	exchange.CandleInterval5m:  "5m",
+	exchange.CandleInterval15m: "15m",
+	exchange.CandleInterval30m: "30m",
+	exchange.CandleInterval1h:  "1h",
+	exchange.CandleInterval4h:  "4h",
+	exchange.CandleInterval1d:  "1d",
+}
+
+// errDepthGap is returned when a depth update doesn't follow the previous one, the orderbook must
+// be synced with a new snapshot
+var errDepthGap = errors.New("depth update out of sequence")
+
+// depthStream is an orderbook maintained from the depth updates of a symbol, the updates are
+// buffered until the book is synced with a REST snapshot
+type depthStream struct {
+	synced       bool
+	lastUpdateID int64
+	bids         map[float64]float64
+	asks         map[float64]float64
+	buffered     []*WebsocketDepthEvent
+	// Set while the orderbook is rebuilt after a gap, it's published once synced
+	resync *exchange.Resynced
+}
+
+// reset discards the orderbook, it'll be synced again with the next depth update
+func (d *depthStream) reset() {
+	d.synced = false
+	d.lastUpdateID = 0
+	d.bids = nil
+	d.asks = nil
+}
+
+// sync replaces the orderbook with the snapshot and applies the buffered depth updates that
+// follow it
+func (d *depthStream) sync(snapshot *MarketData) error {
+	d.lastUpdateID = snapshot.LastUpdateID
+	d.bids = make(map[float64]float64, len(snapshot.Bids))
+	d.asks = make(map[float64]float64, len(snapshot.Asks))
+	applyDepthEntries(d.bids, snapshot.Bids)
+	applyDepthEntries(d.asks, snapshot.Asks)
+	buffered := d.buffered
+	d.buffered = nil
+	for _, event := range buffered {
+		if err := d.apply(event); err != nil {
+			d.reset()
+			return err
+		}
+	}
+	d.synced = true
+	return nil
+}
+
+// apply updates the orderbook with a depth update, updates already included in the orderbook
+// are ignored. Returns errDepthGap if updates were missed.
+func (d *depthStream) apply(event *WebsocketDepthEvent) error {
+	if event.FinalUpdateID <= d.lastUpdateID {
+		return nil
+	}
+	if event.FirstUpdateID > d.lastUpdateID+1 {
+		return errDepthGap
+	}
+	applyDepthEntries(d.bids, event.Bids)
+	applyDepthEntries(d.asks, event.Asks)
+	d.lastUpdateID = event.FinalUpdateID
+	return nil
+}
+
+// orderbook returns the bids sorted from highest to lowest & the asks from lowest to highest
+func (d *depthStream) orderbook() orderbook.Base {
+	book := orderbook.Base{
+		Bids: orderbook.GetItems(len(d.bids)),
+		Asks: orderbook.GetItems(len(d.asks)),
+	}
+	for price, amount := range d.bids {
+		book.Bids = append(book.Bids, orderbook.Item{Price: price, Amount: amount})
+	}
+	for price, amount := range d.asks {
+		book.Asks = append(book.Asks, orderbook.Item{Price: price, Amount: amount})
+	}
+	sort.Slice(book.Bids, func(i, j int) bool { return book.Bids[i].Price > book.Bids[j].Price })
+	sort.Slice(book.Asks, func(i, j int) bool { return book.Asks[i].Price < book.Asks[j].Price })
+	return book
+}
+
+// applyDepthEntries sets the amount of each price level, levels with a zero amount are removed
+func applyDepthEntries(levels map[float64]float64, entries []OrderbookEntry) {
+	for _, entry := range entries {
+		if entry.Quantity == 0 {
+			delete(levels, entry.Price)
+		} else {
+			levels[entry.Price] = entry.Quantity
+		}
+	}
+}
+
+// websocketStreams holds the depth streams of the websocket client
+type websocketStreams struct {
+	mtx   sync.Mutex
+	depth map[string]*depthStream
+
+	// The connection of the websocket client & the kline streams subscribed to on demand, guarded
+	// by klineMtx since mtx is held while the depth snapshots are fetched
+	klineMtx sync.Mutex
+	conn     *stream.Conn
+	klines   []string
+}
+
+// WebsocketClient subscribes to the depth & trade streams of the enabled pairs, the orderbooks
+// are maintained from the depth updates instead of being polled and the trades update the last
+// price of the tickers.
+func (b *Binance) WebsocketClient() {
+	pairs := b.GetEnabledCurrencies()
+	streams := make([]string, 0, len(pairs)*2)
+	for _, p := range pairs {
+		symbol := strings.ToLower(b.CurrencyPairToSymbol(p))
+		streams = append(streams, symbol+binanceWebsocketDepthStream, symbol+binanceWebsocketTradeStream)
+	}
+
+	var conn *stream.Conn
+	conn = stream.NewConn(stream.Config{
+		URL:               binanceWebsocketURL + strings.Join(streams, "/"),
+		MinBackoff:        binanceWebsocketReconnect,
+		PingInterval:      binanceWebsocketPingInterval,
+		StaleTimeout:      b.WebsocketStaleTimeout(),
+		EnableCompression: b.WebsocketCompression(),
+		OnConnect: func(*stream.Conn) error {
+			b.CountWebsocketConnection()
+			return nil
+		},
+		OnMessage: func(msgType int, resp []byte) {
+			if !b.Enabled || !b.Websocket {
+				conn.Close()
+				return
+			}
+			b.websocketMessage(msgType, resp)
+		},
+		OnStateChange: func(state stream.State, err error) {
+			switch state {
+			case stream.Connected:
+				b.SetWebsocketStale(false)
+			case stream.Stale:
+				// The orderbooks are polled until the connection is re-established
+				b.SetWebsocketStale(true)
+				b.resetDepthStreams()
+				conn.Reconnect()
+			case stream.Disconnected, stream.Closed:
+				b.resetDepthStreams()
+				if err != nil {
+					log.Printf("%s Websocket error: %s\n", b.GetName(), err)
+				}
+			}
+		},
+	})
+	b.streams.klineMtx.Lock()
+	b.streams.conn = conn
+	for i, name := range b.streams.klines {
+		conn.Subscribe(name, klineSubscription(name, i))
+	}
+	b.streams.klineMtx.Unlock()
+	conn.Run()
+}
+
+// klineSubscription returns the request subscribing to the kline stream, the ID of the request is
+// the index of the stream
+func klineSubscription(name string, index int) WebsocketSubscribeRequest {
+	return WebsocketSubscribeRequest{Method: "SUBSCRIBE", Params: []string{name}, ID: int64(index + 1)}
+}
+
+// SubscribeCandles returns a channel that receives the candles of the pair & interval as they
+// close, the kline stream is subscribed to on the websocket the first time the candles are
+// requested & stays subscribed.
+func (b *Binance) SubscribeCandles(p pair.CurrencyPair, interval exchange.CandleInterval) (<-chan exchange.Candle, exchange.UnsubscribeFunc, error) {
+	name, ok := binanceKlineIntervals[interval]
+	if !ok {
+		return nil, nil, exchange.ErrUnsupportedCandleInterval(b.GetName(), interval)
+	}
+	ch, unsubscribe, err := b.Base.SubscribeCandles(p, interval)
+	if err != nil {
+		return nil, nil, err
+	}
+	name = strings.ToLower(b.CurrencyPairToSymbol(p)) + binanceWebsocketKlineStream + name
+
+	b.streams.klineMtx.Lock()
+	defer b.streams.klineMtx.Unlock()
+	for _, x := range b.streams.klines {
+		if x == name {
+			return ch, unsubscribe, nil
+		}
+	}
+	b.streams.klines = append(b.streams.klines, name)
+	if b.streams.conn != nil {
+		// The subscription is sent once connected if the websocket is down
+		b.streams.conn.Subscribe(name, klineSubscription(name, len(b.streams.klines)-1))
+	}
+	return ch, unsubscribe, nil
+}
+
+func (b *Binance) websocketMessage(msgType int, resp []byte) {
+	b.RecordWebsocketFrame(msgType, resp)
+	if msgType != websocket.TextMessage {
+		return
+	}
+	if b.Debug(exchange.TraceWebsocket) {
+		log.Printf("%s Websocket received: %s\n", b.GetName(), resp)
+	}
+	if err := b.WebsocketHandleMessage(resp); err != nil {
+		log.Printf("%s Unable to handle Websocket message. Error: %s\n", b.GetName(), err)
+	}
+}
+
+// WebsocketHandleMessage handles a message received from the combined streams
+func (b *Binance) WebsocketHandleMessage(resp []byte) error {
+	msg := WebsocketStreamMessage{}
+	if err := common.JSONDecode(resp, &msg); err != nil {
+		return err
+	}
+	switch {
+	case strings.HasSuffix(msg.Stream, binanceWebsocketDepthStream):
+		event := WebsocketDepthEvent{}
+		if err := common.JSONDecode(msg.Data, &event); err != nil {
+			return err
+		}
+		return b.handleDepthEvent(&event)
+	case strings.HasSuffix(msg.Stream, binanceWebsocketTradeStream):
+		event := WebsocketTradeEvent{}
+		if err := common.JSONDecode(msg.Data, &event); err != nil {
+			return err
+		}
+		return b.handleTradeEvent(&event)
+	case strings.Contains(msg.Stream, binanceWebsocketKlineStream):
+		event := WebsocketKlineEvent{}
+		if err := common.JSONDecode(msg.Data, &event); err != nil {
+			return err
+		}
+		return b.handleKlineEvent(&event)
+	}
+	return nil
+}
+
+// handleKlineEvent publishes the candle of the event once it's closed
+func (b *Binance) handleKlineEvent(event *WebsocketKlineEvent) error {
+	if event.EventType != binanceWebsocketKlineEvent || !event.Kline.Closed {
+		return nil
+	}
+	p, err := b.SymbolToCurrencyPair(event.Symbol)
+	if err != nil {
+		return err
+	}
+	for interval, name := range binanceKlineIntervals {
+		if name != event.Kline.Interval {
+			continue
+		}
+		b.PublishCandle(p, interval, exchange.Candle{
+			Timestamp:   time.Unix(0, event.Kline.StartTime*int64(time.Millisecond)),
+			Open:        event.Kline.Open,
+			High:        event.Kline.High,
+			Low:         event.Kline.Low,
+			Close:       event.Kline.Close,
+			Volume:      event.Kline.Volume,
+			QuoteVolume: event.Kline.QuoteVolume,
+		})
+	}
+	return nil
+}
+
+// handleDepthEvent applies a depth update to the orderbook of the symbol, the orderbook is synced
+// with a REST snapshot first if needed
+func (b *Binance) handleDepthEvent(event *WebsocketDepthEvent) error {
+	if event.EventType != binanceWebsocketDepthEvent {
+		return nil
+	}
+	p, err := b.SymbolToCurrencyPair(event.Symbol)
+	if err != nil {
+		return err
+	}
+
+	b.streams.mtx.Lock()
+	defer b.streams.mtx.Unlock()
+	if b.streams.depth == nil {
+		b.streams.depth = make(map[string]*depthStream)
+	}
+	stream := b.streams.depth[event.Symbol]
+	if stream == nil {
+		stream = &depthStream{}
+		b.streams.depth[event.Symbol] = stream
+	}
+
+	if stream.synced {
+		if err = stream.apply(event); err != errDepthGap {
+			b.Orderbooks.ProcessOrderbook(b.Name, p, stream.orderbook(), ticker.Spot)
+			b.PublishOrderbook(p, stream.orderbook())
+			return err
+		}
+		// The orderbook is rebuilt from a new snapshot straight away
+		stream.resync = &exchange.Resynced{
+			Exchange:         b.Name,
+			CurrencyPair:     p,
+			ExpectedSequence: stream.lastUpdateID + 1,
+			ReceivedSequence: event.FirstUpdateID,
+		}
+		stream.reset()
+	}
+
+	if len(stream.buffered) >= binanceWebsocketMaxBuffered {
+		stream.buffered = stream.buffered[1:]
+	}
+	stream.buffered = append(stream.buffered, event)
+	snapshot, err := b.FetchMarketData(event.Symbol, binanceWebsocketSnapshotDepth)
+	if err != nil {
+		// Rate limited snapshots may be stale, try again with the next update
+		return err
+	}
+	if err = stream.sync(snapshot); err != nil {
+		return err
+	}
+	if stream.resync != nil {
+		stream.resync.Time = time.Now()
+		b.PublishResynced(*stream.resync)
+		stream.resync = nil
+	}
+	b.Orderbooks.ProcessOrderbook(b.Name, p, stream.orderbook(), ticker.Spot)
+	b.PublishOrderbook(p, stream.orderbook())
+	return nil
+}
+
+// handleTradeEvent updates the ticker of the symbol with the trade price, along with the best bid
+// & ask of the streamed orderbook
+func (b *Binance) handleTradeEvent(event *WebsocketTradeEvent) error {
+	if event.EventType != binanceWebsocketTradeEvent {
+		return nil
+	}
+	p, err := b.SymbolToCurrencyPair(event.Symbol)
+	if err != nil {
+		return err
+	}
+	price := ticker.Price{Pair: p, Last: event.Price, LastUpdated: time.Now()}
+	if book, err := b.streamedOrderbook(event.Symbol); err == nil {
+		if len(book.Bids) > 0 {
+			price.Bid = book.Bids[0].Price
+		}
+		if len(book.Asks) > 0 {
+			price.Ask = book.Asks[0].Price
+		}
+		book.Release()
+	}
+	ticker.ProcessTicker(b.Name, p, price, ticker.Spot)
+	b.PublishTicker(p, price)
+
+	// The taker sold if the buyer is the maker
+	side := exchange.OrderSideBuy
+	if event.BuyerIsMaker {
+		side = exchange.OrderSideSell
+	}
+	b.PublishTrade(exchange.Trade{
+		ID:           strconv.FormatInt(event.TradeID, 10),
+		Exchange:     b.Name,
+		CurrencyPair: p,
+		Side:         side,
+		Amount:       event.Quantity,
+		Price:        event.Price,
+		Time:         time.Unix(0, event.TradeTime*int64(time.Millisecond)),
+	})
+	return nil
+}
+
+// streamedOrderbook returns the orderbook of the symbol if it's maintained by the websocket client
+func (b *Binance) streamedOrderbook(symbol string) (orderbook.Base, error) {
+	b.streams.mtx.Lock()
+	stream := b.streams.depth[symbol]
+	synced := stream != nil && stream.synced
+	b.streams.mtx.Unlock()
+	if !synced {
+		return orderbook.Base{}, errors.New("orderbook isn't streamed")
+	}
+	p, err := b.SymbolToCurrencyPair(symbol)
+	if err != nil {
+		return orderbook.Base{}, err
+	}
+	return b.Orderbooks.GetOrderbook(b.Name, p, ticker.Spot)
+}
+
+// resetDepthStreams discards the streamed orderbooks, they're polled again until the websocket
+// client reconnects
+func (b *Binance) resetDepthStreams() {
+	b.streams.mtx.Lock()
+	defer b.streams.mtx.Unlock()
+	b.streams.depth = nil
+}
+
+// userDataCache holds the account info & open orders pushed by the user data stream, it's only
+// used while the stream is connected
+type userDataCache struct {
+	mtx  sync.Mutex
+	live bool
+	// Events sent before the cache was seeded from the REST API are already part of the cache
+	seededAt    int64 // milliseconds
+	accountInfo AccountInfo
+	openOrders  map[int64]Order
+}
+
+// getAccountInfo returns a copy of the pushed account info, false is returned if the user data
+// stream isn't connected
+func (c *userDataCache) getAccountInfo() (*AccountInfo, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if !c.live {
+		return nil, false
+	}
+	info := c.accountInfo
+	info.Balances = make([]*Balance, len(c.accountInfo.Balances))
+	for i, balance := range c.accountInfo.Balances {
+		copied := *balance
+		info.Balances[i] = &copied
+	}
+	return &info, true
+}
+
+// getOpenOrders returns the open orders of the symbol (or of every symbol if it's blank), false
+// is returned if the user data stream isn't connected
+func (c *userDataCache) getOpenOrders(symbol string) ([]Order, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if !c.live {
+		return nil, false
+	}
+	orders := []Order{}
+	for _, order := range c.openOrders {
+		if symbol == "" || order.Symbol == symbol {
+			orders = append(orders, order)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].OrderID < orders[j].OrderID })
+	return orders, true
+}
+
+// seed replaces the cache with the account info & open orders fetched from the REST API at
+// seededAt, and starts serving them
+func (c *userDataCache) seed(info *AccountInfo, orders []Order, seededAt int64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.accountInfo = *info
+	c.openOrders = make(map[int64]Order, len(orders))
+	for _, order := range orders {
+		c.openOrders[order.OrderID] = order
+	}
+	c.seededAt = seededAt
+	c.live = true
+}
+
+// reset stops serving the cache, the REST API is polled until the cache is seeded again
+func (c *userDataCache) reset() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.live = false
+	c.accountInfo = AccountInfo{}
+	c.openOrders = nil
+}
+
+// applyExecutionReport adds an open order to the cache, or removes it once it's no longer open.
+// Returns the order in the report, false if the report is ignored.
+func (c *userDataCache) applyExecutionReport(report *WebsocketExecutionReport) (Order, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if !c.live || report.EventTime < c.seededAt {
+		return Order{}, false
+	}
+	order := Order{
+		Symbol:        report.Symbol,
+		OrderID:       report.OrderID,
+		ClientOrderID: report.ClientOrderID,
+		Price:         report.Price,
+		OrigQty:       report.Quantity,
+		ExecutedQty:   report.CumulativeFilledQty,
+		Status:        report.Status,
+		TimeInForce:   report.TimeInForce,
+		Type:          report.Type,
+		Side:          report.Side,
+		StopPrice:     report.StopPrice,
+		IcebergQty:    report.IcebergQty,
+		Time:          report.CreationTime,
+		IsWorking:     report.IsWorking,
+	}
+	if report.Status != OrderStatusNew && report.Status != OrderStatusPartial {
+		delete(c.openOrders, report.OrderID)
+	} else {
+		c.openOrders[report.OrderID] = order
+	}
+	return order, true
+}
+
+// applyAccountInfo updates the balances of the assets in the event
+func (c *userDataCache) applyAccountInfo(event *WebsocketAccountInfo) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if !c.live || event.EventTime < c.seededAt {
+		return
+	}
+	c.accountInfo.MakerCommission = event.MakerCommission
+	c.accountInfo.TakerCommission = event.TakerCommission
+	c.accountInfo.BuyerCommission = event.BuyerCommission
+	c.accountInfo.SellerCommission = event.SellerCommission
+	c.accountInfo.CanTrade = event.CanTrade
+	c.accountInfo.CanWithdraw = event.CanWithdraw
+	c.accountInfo.CanDeposit = event.CanDeposit
+	for _, pushed := range event.Balances {
+		balance := &Balance{Asset: pushed.Asset, Free: pushed.Free, Locked: pushed.Locked}
+		replaced := false
+		for i, existing := range c.accountInfo.Balances {
+			if existing.Asset == pushed.Asset {
+				c.accountInfo.Balances[i] = balance
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			c.accountInfo.Balances = append(c.accountInfo.Balances, balance)
+		}
+	}
+}
+
+// UserDataClient connects to the user data stream, the account info & open orders returned by
+// FetchAccountInfo & FetchOpenOrders are updated by the stream instead of being polled while
+// it's connected.
+func (b *Binance) UserDataClient() {
+	for b.Enabled && b.Websocket && b.AuthenticatedAPISupport {
+		if err := b.userDataSession(); err != nil {
+			log.Printf("%s User data stream error: %s\n", b.GetName(), err)
+		}
+		b.userData.reset()
+		time.Sleep(binanceWebsocketReconnect)
+	}
+}
+
+// userDataSession runs a single connection to the user data stream until it fails
+func (b *Binance) userDataSession() error {
+	listenKey, err := b.CreateListenKey()
+	if err != nil {
+		return err
+	}
+	defer b.CloseListenKey(listenKey)
+
+	dialer := websocket.Dialer{EnableCompression: b.WebsocketCompression()}
+	conn, _, err := dialer.Dial(binanceUserDataWebsocketURL+listenKey, http.Header{})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	b.CountWebsocketConnection()
+
+	// The events are read once the cache is seeded, so none of them are missed
+	if err = b.SeedUserData(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		keepAlive := time.NewTicker(binanceListenKeyKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-keepAlive.C:
+				if err := b.KeepAliveListenKey(listenKey); err != nil {
+					log.Printf("%s Unable to keep the user data stream alive. Error: %s\n", b.GetName(), err)
+				}
+			}
+		}
+	}()
+
+	for b.Enabled && b.Websocket {
+		msgType, resp, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		b.RecordWebsocketFrame(msgType, resp)
+		if msgType != websocket.TextMessage {
+			continue
+		}
+		if b.Debug(exchange.TraceWebsocket) {
+			log.Printf("%s User data stream received: %s\n", b.GetName(), resp)
+		}
+		if err = b.WebsocketHandleUserData(resp); err != nil {
+			log.Printf("%s Unable to handle user data stream message. Error: %s\n", b.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// SeedUserData fetches the account info & open orders from the REST API, the user data stream
+// events are applied to them from then on. Rate limited responses aren't used since they may be
+// stale.
+func (b *Binance) SeedUserData() error {
+	b.userData.reset()
+	seededAt := time.Now().UnixNano()/int64(time.Millisecond) + atomic.LoadInt64(&b.timeOffset)
+	info, err := b.FetchAccountInfo()
+	if err != nil {
+		return err
+	}
+	orders, err := b.FetchOpenOrders("")
+	if err != nil {
+		return err
+	}
+	b.userData.seed(info, orders, seededAt)
+	return nil
+}
+
+// WebsocketHandleUserData handles a message received from the user data stream
+func (b *Binance) WebsocketHandleUserData(resp []byte) error {
+	event := WebsocketEvent{}
+	if err := common.JSONDecode(resp, &event); err != nil {
+		return err
+	}
+	switch event.EventType {
+	case binanceWebsocketExecutionReport:
+		report := WebsocketExecutionReport{}
+		if err := common.JSONDecode(resp, &report); err != nil {
+			return err
+		}
+		if order, ok := b.userData.applyExecutionReport(&report); ok {
+			b.PublishOrder(*b.convertOrderToExchangeOrder(&order))
+		}
+	case binanceWebsocketAccountInfo:
+		info := WebsocketAccountInfo{}
+		if err := common.JSONDecode(resp, &info); err != nil {
+			return err
+		}
+		b.userData.applyAccountInfo(&info)
+	}
+	return nil
+}
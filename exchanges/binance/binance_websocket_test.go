@@ -0,0 +1,319 @@
+package binance
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ratelimit"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+func newTestBinance(handler http.HandlerFunc) (*Binance, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	b := &Binance{}
+	b.SetDefaults()
+	b.rateLimiter = ratelimit.NewLimiter(b.Name, ratelimit.NewMemoryBackend())
+	b.APIUrl = server.URL + "/"
+	b.Enabled = true
+	b.AuthenticatedAPISupport = true
+	b.SetAPIKeys("key", "secret", "", false)
+	b.currencyPairs = map[pair.CurrencyItem]*exchange.CurrencyPairInfo{
+		"BNBBTC": {Currency: pair.NewCurrencyPair("BNB", "BTC")},
+	}
+	return b, server
+}
+
+func depthMessage(first, final int64, bids, asks string) []byte {
+	return []byte(fmt.Sprintf(`{"stream":"bnbbtc@depth","data":{"e":"depthUpdate","E":1,"s":"BNBBTC",
+		"U":%d,"u":%d,"b":%s,"a":%s}}`, first, final, bids, asks))
+}
+
+func TestWebsocketDepthStream(t *testing.T) {
+	snapshots := 0
+	lastUpdateID := 100
+	b, server := newTestBinance(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+binanceDepthPath || r.URL.Query().Get("symbol") != "BNBBTC" {
+			http.NotFound(w, r)
+			return
+		}
+		snapshots++
+		fmt.Fprintf(w, `{"lastUpdateId":%d,"bids":[["0.0024","10"],["0.0023","5"]],"asks":[["0.0026","100"]]}`,
+			lastUpdateID)
+	})
+	defer server.Close()
+	p := pair.NewCurrencyPair("BNB", "BTC")
+
+	// The first update is already part of the snapshot, the second one overlaps it
+	messages := [][]byte{
+		depthMessage(90, 100, `[["0.0024","1"]]`, `[]`),
+		depthMessage(99, 102, `[["0.0023","0"]]`, `[["0.0025","3"]]`),
+		depthMessage(103, 103, `[["0.0022","7"]]`, `[["0.0026","0"]]`),
+	}
+	for _, msg := range messages {
+		if err := b.WebsocketHandleMessage(msg); err != nil {
+			t.Fatalf("Test failed. WebsocketHandleMessage returned an error: %s", err)
+		}
+	}
+	if snapshots != 1 {
+		t.Errorf("Test failed. Expected a single snapshot, got %d", snapshots)
+	}
+	book, err := b.UpdateOrderbook(p, ticker.Spot)
+	if err != nil {
+		t.Fatalf("Test failed. UpdateOrderbook returned an error: %s", err)
+	}
+	if len(book.Bids) != 2 || book.Bids[0].Price != 0.0024 || book.Bids[0].Amount != 10 ||
+		book.Bids[1].Price != 0.0022 || len(book.Asks) != 1 || book.Asks[0].Price != 0.0025 {
+		t.Errorf("Test failed. Unexpected orderbook %+v", book)
+	}
+	if snapshots != 1 {
+		t.Error("Test failed. The streamed orderbook was polled")
+	}
+
+	// A missed update resyncs the orderbook with a new snapshot straight away, the updates are
+	// buffered while the snapshot is rate limited
+	b.Websocket = true
+	resyncs, unsubscribe, err := b.SubscribeResyncs()
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeResyncs returned an error: %s", err)
+	}
+	defer unsubscribe()
+	lastUpdateID = 109
+	if err = b.WebsocketHandleMessage(depthMessage(110, 111, `[["0.0021","1"]]`, `[]`)); !exchange.IsRateLimited(err) {
+		t.Errorf("Test failed. Expected the snapshot to be rate limited, got %v", err)
+	}
+	if len(resyncs) != 0 {
+		t.Error("Test failed. The orderbook was resynced without a snapshot")
+	}
+	b.rateLimiter = ratelimit.NewLimiter(b.Name, ratelimit.NewMemoryBackend())
+	if err = b.WebsocketHandleMessage(depthMessage(112, 112, `[["0.0020","2"]]`, `[]`)); err != nil {
+		t.Errorf("Test failed. WebsocketHandleMessage returned an error: %s", err)
+	}
+	if snapshots != 2 {
+		t.Errorf("Test failed. Expected the orderbook to be resynced, got %d snapshots", snapshots)
+	}
+	if len(resyncs) != 1 {
+		t.Fatalf("Test failed. Expected a resync event, got %d", len(resyncs))
+	}
+	if r := <-resyncs; r.ExpectedSequence != 104 || r.ReceivedSequence != 110 || !r.CurrencyPair.Equal(p) {
+		t.Errorf("Test failed. Unexpected resync event %+v", r)
+	}
+	if book, _ = b.UpdateOrderbook(p, ticker.Spot); len(book.Bids) != 4 || book.Bids[2].Price != 0.0021 ||
+		book.Bids[3].Price != 0.0020 {
+		t.Errorf("Test failed. The buffered updates weren't applied %+v", book)
+	}
+}
+
+func TestWebsocketTradeStream(t *testing.T) {
+	b, server := newTestBinance(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"lastUpdateId":100,"bids":[["0.0024","10"]],"asks":[["0.0026","100"]]}`)
+	})
+	defer server.Close()
+	b.Name = "BinanceTradeStream"
+	p := pair.NewCurrencyPair("BNB", "BTC")
+	b.Websocket = true
+	trades, unsubscribeTrades, err := b.SubscribeTrades(p)
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeTrades returned an error: %s", err)
+	}
+	defer unsubscribeTrades()
+	tickers, unsubscribeTicker, err := b.SubscribeTicker(p)
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeTicker returned an error: %s", err)
+	}
+	defer unsubscribeTicker()
+
+	if err := b.WebsocketHandleMessage(depthMessage(101, 101, `[]`, `[]`)); err != nil {
+		t.Fatalf("Test failed. WebsocketHandleMessage returned an error: %s", err)
+	}
+	err = b.WebsocketHandleMessage([]byte(`{"stream":"bnbbtc@trade","data":{"e":"trade","E":1,"s":"BNBBTC",
+		"t":12345,"p":"0.0025","q":"100","b":88,"a":50,"T":1,"m":true,"M":false}}`))
+	if err != nil {
+		t.Fatalf("Test failed. WebsocketHandleMessage returned an error: %s", err)
+	}
+	event := WebsocketTradeEvent{}
+	common.JSONDecode([]byte(`{"e":"trade","E":1,"t":2,"T":3,"m":true,"M":false}`), &event)
+	if event.EventTime != 1 || event.TradeID != 2 || event.TradeTime != 3 || !event.BuyerIsMaker {
+		t.Errorf("Test failed. Keys that only differ by case were mixed up %+v", event)
+	}
+	tick, err := ticker.GetTicker(b.Name, p, ticker.Spot)
+	if err != nil || tick.Last != 0.0025 || tick.Bid != 0.0024 || tick.Ask != 0.0026 {
+		t.Errorf("Test failed. Unexpected ticker %+v %v", tick, err)
+	}
+	if len(tickers) != 1 || (<-tickers).Last != 0.0025 {
+		t.Error("Test failed. The ticker wasn't streamed")
+	}
+	if len(trades) != 1 {
+		t.Fatalf("Test failed. Expected a streamed trade, got %d", len(trades))
+	}
+	if trade := <-trades; trade.ID != "12345" || trade.Side != exchange.OrderSideSell || trade.Amount != 100 ||
+		trade.Price != 0.0025 || !trade.CurrencyPair.Equal(p) {
+		t.Errorf("Test failed. Unexpected streamed trade %+v", trade)
+	}
+
+	err = b.WebsocketHandleMessage([]byte(`{"stream":"ethbtc@trade","data":{"e":"trade","s":"ETHBTC","p":"1"}}`))
+	if err == nil {
+		t.Error("Test failed. Expected an unknown symbol error")
+	}
+}
+
+func klineMessage(interval string, start int64, closed bool) []byte {
+	return []byte(fmt.Sprintf(`{"stream":"bnbbtc@kline_%s","data":{"e":"kline","E":1,"s":"BNBBTC","k":{
+		"t":%d,"T":%d,"s":"BNBBTC","i":"%s","f":100,"L":200,"o":"0.0010","c":"0.0020","h":"0.0025",
+		"l":"0.0015","v":"1000","n":100,"x":%t,"q":"1.0000","V":"500","Q":"0.500","B":"123456"}}}`,
+		interval, start, start+59999, interval, closed))
+}
+
+func TestWebsocketKlineStream(t *testing.T) {
+	b, server := newTestBinance(http.NotFound)
+	defer server.Close()
+	p := pair.NewCurrencyPair("BNB", "BTC")
+	b.Websocket = true
+	if _, _, err := b.SubscribeCandles(p, exchange.CandleInterval(2*time.Minute)); err == nil {
+		t.Error("Test failed. Expected an unsupported interval error")
+	}
+	candles, unsubscribe, err := b.SubscribeCandles(p, exchange.CandleInterval1m)
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeCandles returned an error: %s", err)
+	}
+	defer unsubscribe()
+	b.SubscribeCandles(p, exchange.CandleInterval1m)
+	if len(b.streams.klines) != 1 || b.streams.klines[0] != "bnbbtc@kline_1m" {
+		t.Errorf("Test failed. Unexpected kline streams %v", b.streams.klines)
+	}
+
+	// Only closed candles are published
+	for _, msg := range [][]byte{klineMessage("1m", 60000, false), klineMessage("5m", 0, true),
+		klineMessage("1m", 60000, true)} {
+		if err := b.WebsocketHandleMessage(msg); err != nil {
+			t.Fatalf("Test failed. WebsocketHandleMessage returned an error: %s", err)
+		}
+	}
+	if len(candles) != 1 {
+		t.Fatalf("Test failed. Expected a single candle, got %d", len(candles))
+	}
+	if c := <-candles; !c.Timestamp.Equal(time.Unix(60, 0)) || c.Open != 0.001 || c.High != 0.0025 ||
+		c.Low != 0.0015 || c.Close != 0.002 || c.Volume != 1000 || c.QuoteVolume != 1 {
+		t.Errorf("Test failed. Unexpected candle %+v", c)
+	}
+}
+
+func TestUserDataStream(t *testing.T) {
+	requests := 0
+	b, server := newTestBinance(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/" + binanceAccountPath:
+			fmt.Fprint(w, `{"canTrade":true,"balances":[{"asset":"BTC","free":"1","locked":"0"},
+				{"asset":"BNB","free":"10","locked":"2"}]}`)
+		case "/" + binanceOpenOrdersPath:
+			fmt.Fprint(w, `[{"symbol":"BNBBTC","orderId":1,"price":"0.0024","origQty":"2",
+				"executedQty":"0","status":"NEW","side":"SELL","type":"LIMIT","time":1000}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	if err := b.SeedUserData(); err != nil {
+		t.Fatalf("Test failed. SeedUserData returned an error: %s", err)
+	}
+	b.Websocket = true
+	streamed, unsubscribe, err := b.SubscribeOrders()
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeOrders returned an error: %s", err)
+	}
+	defer unsubscribe()
+	seedRequests := requests
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	messages := []string{
+		// A new order, the first order is partially filled, then the new order is cancelled
+		fmt.Sprintf(`{"e":"executionReport","E":%d,"s":"BNBBTC","c":"new","S":"BUY","o":"LIMIT",
+			"f":"GTC","q":"5","p":"0.0020","P":"0","F":"0","g":-1,"C":"","x":"NEW","X":"NEW","r":"NONE",
+			"i":2,"l":"0","z":"0","L":"0","n":"0","N":null,"T":1,"t":-1,"I":8,"w":true,"m":false,
+			"M":false,"O":2000,"Z":"0","Y":"0","Q":"0"}`, now),
+		fmt.Sprintf(`{"e":"executionReport","E":%d,"s":"BNBBTC","c":"","S":"SELL","o":"LIMIT",
+			"q":"2","p":"0.0024","x":"TRADE","X":"PARTIALLY_FILLED","i":1,"l":"0.5","z":"0.5",
+			"t":7,"T":2,"w":true,"m":true,"M":true,"O":1000}`, now+1),
+		fmt.Sprintf(`{"e":"executionReport","E":%d,"s":"BNBBTC","c":"cancel","C":"new","S":"BUY",
+			"q":"5","p":"0.0020","x":"CANCELED","X":"CANCELED","i":2,"z":"0","O":2000}`, now+2),
+		fmt.Sprintf(`{"e":"outboundAccountInfo","E":%d,"m":10,"t":10,"b":0,"s":0,"T":true,"W":true,
+			"D":true,"u":1,"B":[{"a":"BNB","f":"9.5","l":"1.5"},{"a":"ETH","f":"3","l":"0"}]}`, now+3),
+		// Sent before the cache was seeded, so already part of it
+		`{"e":"executionReport","E":1,"s":"BNBBTC","X":"FILLED","i":1}`,
+	}
+	for _, msg := range messages {
+		if err := b.WebsocketHandleUserData([]byte(msg)); err != nil {
+			t.Fatalf("Test failed. WebsocketHandleUserData returned an error: %s", err)
+		}
+	}
+
+	// The stale report isn't streamed
+	if len(streamed) != 3 {
+		t.Fatalf("Test failed. Expected 3 streamed orders, got %d", len(streamed))
+	}
+	if o := <-streamed; o.OrderID != "2" || o.Status != exchange.OrderStatusActive || o.Side != exchange.OrderSideBuy {
+		t.Errorf("Test failed. Unexpected new order %+v", o)
+	}
+	if o := <-streamed; o.OrderID != "1" || o.Status != exchange.OrderStatusActive || o.FilledAmount != 0.5 {
+		t.Errorf("Test failed. Unexpected filled order %+v", o)
+	}
+	if o := <-streamed; o.OrderID != "2" || o.Status != exchange.OrderStatusAborted {
+		t.Errorf("Test failed. Unexpected cancelled order %+v", o)
+	}
+
+	orders, err := b.FetchOpenOrders("BNBBTC")
+	if err != nil || len(orders) != 1 || orders[0].OrderID != 1 || orders[0].ExecutedQty != 0.5 ||
+		orders[0].Status != OrderStatusPartial || orders[0].Side != OrderSideSell {
+		t.Errorf("Test failed. Unexpected open orders %+v %v", orders, err)
+	}
+	if orders, _ = b.FetchOpenOrders("ETHBTC"); len(orders) != 0 {
+		t.Errorf("Test failed. Unexpected ETHBTC orders %+v", orders)
+	}
+	info, err := b.FetchAccountInfo()
+	if err != nil || len(info.Balances) != 3 || info.MakerCommission != 10 {
+		t.Fatalf("Test failed. Unexpected account info %+v %v", info, err)
+	}
+	balances := map[string]Balance{}
+	for _, balance := range info.Balances {
+		balances[balance.Asset] = *balance
+	}
+	if balances["BTC"].Free != 1 || balances["BNB"].Free != 9.5 || balances["BNB"].Locked != 1.5 ||
+		balances["ETH"].Free != 3 {
+		t.Errorf("Test failed. Unexpected balances %+v", balances)
+	}
+	if requests != seedRequests {
+		t.Error("Test failed. The pushed account info & orders were polled")
+	}
+
+	// The REST API is polled once the stream disconnects
+	b.userData.reset()
+	b.rateLimiter = ratelimit.NewLimiter(b.Name, ratelimit.NewMemoryBackend())
+	if orders, err = b.FetchOpenOrders(""); err != nil || len(orders) != 1 || orders[0].Status != OrderStatusNew {
+		t.Errorf("Test failed. Unexpected polled orders %+v %v", orders, err)
+	}
+}
+
+func TestGetFeeInfo(t *testing.T) {
+	b, server := newTestBinance(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+binanceAccountPath {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"makerCommission":8,"takerCommission":10,"balances":[]}`))
+	})
+	defer server.Close()
+
+	maker, taker, err := b.GetFeeInfo(pair.NewCurrencyPair("BNB", "BTC"))
+	if err != nil {
+		t.Fatalf("Test failed. GetFeeInfo returned an error: %s", err)
+	}
+	if maker != 0.08 || taker != 0.1 {
+		t.Errorf("Test failed. Expected the commissions as percentages, got %v %v", maker, taker)
+	}
+}
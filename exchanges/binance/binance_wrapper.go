@@ -1,35 +1,58 @@
 package binance
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mattkanwisher/cryptofiend/common"
 	"github.com/mattkanwisher/cryptofiend/config"
 	"github.com/mattkanwisher/cryptofiend/currency/pair"
 	"github.com/mattkanwisher/cryptofiend/exchanges"
 	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ratelimit"
 	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
 	"github.com/shopspring/decimal"
 )
 
+var _ exchange.IExchange = (*Binance)(nil)
+var _ exchange.ContextExchange = (*Binance)(nil)
+
+// New returns a Binance exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *Binance {
+	b := &Binance{}
+	b.SetDefaults()
+	b.Quickstart(b.Setup, apiKey, apiSecret, opts...)
+	return b
+}
+
 // SetDefaults sets the basic defaults for Binance
 func (b *Binance) SetDefaults() {
 	b.Name = "Binance"
+	b.APIUrl = binanceBaseURL
 	b.Enabled = false
 	b.Verbose = false
 	b.Websocket = false
 	b.RESTPollingDelay = 10
+	b.TakerFee = 0.1
+	b.MakerFee = 0.1
 	b.RequestCurrencyPairFormat.Delimiter = ""
 	b.RequestCurrencyPairFormat.Uppercase = true
 	b.ConfigCurrencyPairFormat.Delimiter = ""
 	b.ConfigCurrencyPairFormat.Uppercase = true
 	b.AssetTypes = []string{ticker.Spot}
 	b.Orderbooks = orderbook.Init()
-	b.rateLimits = map[string]int64{}
+	b.rateLimiter = ratelimit.NewLimiter(b.Name, nil)
+	b.SetStreams(exchange.StreamOrderbook | exchange.StreamTrades | exchange.StreamTicker |
+		exchange.StreamOrders | exchange.StreamCandles)
 	b.lastOpenOrders = map[string][]Order{}
 	b.lastMarketData = map[string]*MarketData{}
+	b.lastOpenOrdersTime = map[string]time.Time{}
+	b.lastMarketDataTime = map[string]time.Time{}
 }
 
 // Setup takes in the supplied exchange configuration details and sets params
@@ -43,6 +66,7 @@ func (b *Binance) Setup(exch config.ExchangeConfig) {
 		b.RESTPollingDelay = exch.RESTPollingDelay
 		b.Verbose = exch.Verbose
 		b.Websocket = exch.Websocket
+		b.SetAPIURL(exch)
 		b.BaseCurrencies = common.SplitStrings(exch.BaseCurrencies, ",")
 		b.AvailablePairs = common.SplitStrings(exch.AvailablePairs, ",")
 		b.EnabledPairs = common.SplitStrings(exch.EnabledPairs, ",")
@@ -64,7 +88,7 @@ func (b *Binance) Start() {
 
 // Run implements the Binance wrapper
 func (b *Binance) Run() {
-	if b.Verbose {
+	if b.Debug("") {
 		log.Printf("%s polling delay: %ds.\n", b.GetName(), b.RESTPollingDelay)
 		log.Printf("%s %d currencies enabled: %s.\n", b.GetName(), len(b.EnabledPairs), b.EnabledPairs)
 	}
@@ -76,6 +100,7 @@ func (b *Binance) Run() {
 	}
 
 	exchangeProducts := make([]string, len(exchangeInfo.Symbols))
+	b.symbolCache.Reset()
 	b.currencyPairs = make(map[pair.CurrencyItem]*exchange.CurrencyPairInfo, len(exchangeInfo.Symbols))
 	b.symbolDetailsMap = make(map[pair.CurrencyItem]*symbolDetails, len(exchangeInfo.Symbols))
 	for i := range exchangeInfo.Symbols {
@@ -83,7 +108,7 @@ func (b *Binance) Run() {
 		exchangeProducts[i] = symbolInfo.Symbol
 		currencyPair := pair.NewCurrencyPair(symbolInfo.BaseAsset, symbolInfo.QuoteAsset)
 		b.currencyPairs[pair.CurrencyItem(symbolInfo.Symbol)] = &exchange.CurrencyPairInfo{Currency: currencyPair}
-		sd := symbolDetails{}
+		sd := symbolDetails{IcebergAllowed: symbolInfo.Iceberg}
 		for _, filter := range symbolInfo.Filters {
 			switch filter.Type {
 			case FilterTypePrice:
@@ -104,10 +129,24 @@ func (b *Binance) Run() {
 	if err != nil {
 		log.Printf("%s failed to update available currencies\n", b.Name)
 	}
+
+	// The symbols must be known before the stream events can be converted to currency pairs
+	if b.Websocket {
+		go b.WebsocketClient()
+		if b.AuthenticatedAPISupport {
+			go b.UserDataClient()
+		}
+	}
 }
 
 // UpdateTicker updates and returns the ticker for a currency pair
 func (b *Binance) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return b.UpdateTickerContext(context.Background(), p, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (b *Binance) UpdateTickerContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
 	panic("not implemented")
 }
 
@@ -117,7 +156,7 @@ func (b *Binance) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.
 }
 
 // GetOrderbookEx returns the orderbook for a currency pair
-func (b *Binance) GetOrderbookEx(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+func (b *Binance) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
 	ob, err := b.Orderbooks.GetOrderbook(b.GetName(), p, assetType)
 	if err == nil {
 		return b.UpdateOrderbook(p, assetType)
@@ -125,16 +164,27 @@ func (b *Binance) GetOrderbookEx(p pair.CurrencyPair, assetType string) (orderbo
 	return ob, nil
 }
 
-// UpdateOrderbook updates and returns the orderbook for a currency pair
+// UpdateOrderbook updates and returns the orderbook for a currency pair, orderbooks maintained by
+// the websocket client are returned without polling the exchange.
 func (b *Binance) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return b.UpdateOrderbookContext(context.Background(), p, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (b *Binance) UpdateOrderbookContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
 	book := orderbook.Base{}
 	symbol := b.CurrencyPairToSymbol(p)
-	marketData, err := b.FetchMarketData(symbol, 100)
+	if streamed, err := b.streamedOrderbook(symbol); err == nil {
+		return streamed, nil
+	}
+	marketData, err := b.fetchMarketData(ctx, symbol, 100)
 
-	if (err != nil) && (err != exchange.WarningHTTPRequestRateLimited()) {
+	if (err != nil) && !exchange.IsRateLimited(err) {
 		return book, err
 	}
 
+	book.Asks = orderbook.GetItems(len(marketData.Asks))
 	for x := range marketData.Asks {
 		book.Asks = append(book.Asks, orderbook.Item{
 			Price:  marketData.Asks[x].Price,
@@ -142,6 +192,7 @@ func (b *Binance) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderb
 		})
 	}
 
+	book.Bids = orderbook.GetItems(len(marketData.Bids))
 	for x := range marketData.Bids {
 		book.Bids = append(book.Bids, orderbook.Item{
 			Price:  marketData.Bids[x].Price,
@@ -156,6 +207,12 @@ func (b *Binance) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderb
 // GetExchangeAccountInfo retrieves balances for all enabled currencies on the
 // Binance exchange
 func (b *Binance) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return b.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (b *Binance) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
 	result := exchange.AccountInfo{}
 	result.ExchangeName = b.Name
 
@@ -163,8 +220,8 @@ func (b *Binance) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
 		return result, nil
 	}
 
-	accountInfo, err := b.FetchAccountInfo()
-	if (err != nil) && (err != exchange.WarningHTTPRequestRateLimited()) {
+	accountInfo, err := b.fetchAccountInfo(ctx)
+	if (err != nil) && !exchange.IsRateLimited(err) {
 		return result, err
 	}
 	result.Currencies = make([]exchange.AccountCurrencyInfo, len(accountInfo.Balances))
@@ -182,20 +239,52 @@ func (b *Binance) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
 // Returns the ID of the new exchange order, or an empty string if the order was filled
 // immediately but no ID was generated.
 func (b *Binance) NewOrder(p pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
-	orderType exchange.OrderType) (string, error) {
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return b.NewOrderContext(context.Background(), p, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (b *Binance) NewOrderContext(ctx context.Context, p pair.CurrencyPair, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	if err := b.GetCapabilities().CheckOrderOptions(opts...); err != nil {
+		return "", err
+	}
 	var newOrderType OrderType
 	if orderType == exchange.OrderTypeExchangeLimit {
 		newOrderType = OrderTypeLimit
 	} else {
 		panic("not implemented")
 	}
-	result, err := b.PostOrderAck(&PostOrderParams{
-		Symbol:      b.CurrencyPairToSymbol(p),
-		Side:        OrderSide(strings.ToUpper(string(side))),
-		Type:        newOrderType,
-		TimeInForce: TimeInForceGTC,
-		Quantity:    amount,
-		Price:       price,
+	var icebergQty float64
+	var clientOrderID string
+	for _, o := range opts {
+		if o.VisibleAmount != 0 {
+			icebergQty = o.VisibleAmount
+		}
+		if o.ClientOrderID != "" {
+			clientOrderID = o.ClientOrderID
+		}
+	}
+	if icebergQty != 0 {
+		// iceberg orders are only allowed on some symbols
+		sd, exists := b.symbolDetailsMap[p.Display("/", false)]
+		if !exists || !sd.IcebergAllowed {
+			return "", exchange.ErrOrderOptionNotSupported
+		}
+		if icebergQty < 0 || icebergQty >= amount {
+			return "", fmt.Errorf("%s iceberg order visible amount must be less than the order amount",
+				b.GetName())
+		}
+	}
+	result, err := b.postOrderAck(ctx, &PostOrderParams{
+		Symbol:           b.CurrencyPairToSymbol(p),
+		Side:             OrderSide(strings.ToUpper(string(side))),
+		Type:             newOrderType,
+		TimeInForce:      TimeInForceGTC,
+		Quantity:         amount,
+		Price:            price,
+		IcebergQty:       icebergQty,
+		NewClientOrderID: clientOrderID,
 	})
 	if err != nil {
 		return "", err
@@ -203,24 +292,64 @@ func (b *Binance) NewOrder(p pair.CurrencyPair, amount, price float64, side exch
 	return strconv.FormatInt(result.OrderID, 10), nil
 }
 
+// GetFeeInfo returns the maker & taker commissions of the account, Binance charges the same
+// commissions for all the symbols. The default fees are returned if the account info request is
+// rate limited before it's been fetched.
+func (b *Binance) GetFeeInfo(currencyPair pair.CurrencyPair) (maker, taker float64, err error) {
+	if !b.AuthenticatedAPISupport {
+		return b.Base.GetFeeInfo(currencyPair)
+	}
+	info, err := b.FetchAccountInfo()
+	if exchange.IsRateLimited(err) {
+		if _, cached := exchange.CachedDataAge(err); !cached {
+			return b.Base.GetFeeInfo(currencyPair)
+		}
+	} else if err != nil {
+		return 0, 0, err
+	}
+	// the commissions are in basis points
+	return float64(info.MakerCommission) / 100, float64(info.TakerCommission) / 100, err
+}
+
+// GetCapabilities returns the capabilities of the exchange, iceberg orders are only allowed on
+// the symbols flagged by the exchange info.
+func (b *Binance) GetCapabilities() exchange.Capabilities {
+	capabilities := b.Base.GetCapabilities()
+	capabilities.IcebergOrders = true
+	capabilities.ClientOrderIDs = true
+	return capabilities
+}
+
 // CancelOrder will attempt to cancel the active order matching the given ID.
 func (b *Binance) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return b.CancelOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (b *Binance) CancelOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) error {
 	id, err := strconv.ParseInt(orderID, 10, 64)
 	if err != nil {
 		return err
 	}
 	symbol := b.CurrencyPairToSymbol(currencyPair)
-	return b.DeleteOrder(symbol, id, "")
+	return b.deleteOrder(ctx, symbol, id, "")
 }
 
 // GetOrder returns information about a previously placed order (which may be active or inactive).
 func (b *Binance) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	return b.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (b *Binance) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
 	id, err := strconv.ParseInt(orderID, 10, 64)
 	if err != nil {
 		return nil, err
 	}
 	symbol := b.CurrencyPairToSymbol(currencyPair)
-	order, err := b.FetchOrder(symbol, id, "")
+	order, err := b.fetchOrder(ctx, symbol, id, "")
 	if err != nil {
 		return nil, err
 	}
@@ -229,19 +358,26 @@ func (b *Binance) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exc
 
 // GetOrders returns information about currently active orders.
 // If this method gets rate limited it will return the set of orders obtained during the
-// last successful fetch, and an error matching exchange.WarningHTTPRequestRateLimited.
+// last successful fetch, and an exchange.RateLimitedWarning (with the oldest fetch time if the
+// orders of multiple pairs were cached).
 func (b *Binance) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return b.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (b *Binance) GetOrdersContext(ctx context.Context,
+	pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
 	var retErr error
 	ret := []*exchange.Order{}
 
 	if len(pairs) > 0 {
-		rateLimitedPairCount := 0
+		var warnings []*exchange.RateLimitedWarning
 		for _, p := range pairs {
 			symbol := b.CurrencyPairToSymbol(p)
-			orders, err := b.FetchOpenOrders(symbol)
+			orders, err := b.fetchOpenOrders(ctx, symbol)
 
-			if err == exchange.WarningHTTPRequestRateLimited() {
-				rateLimitedPairCount++
+			if warning, ok := err.(*exchange.RateLimitedWarning); ok {
+				warnings = append(warnings, warning)
 			} else if err != nil {
 				return nil, err
 			}
@@ -250,13 +386,13 @@ func (b *Binance) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error
 				ret = append(ret, b.convertOrderToExchangeOrder(&order))
 			}
 		}
-		if rateLimitedPairCount == len(pairs) {
-			retErr = exchange.WarningHTTPRequestRateLimited()
+		if len(warnings) == len(pairs) {
+			retErr = exchange.OldestRateLimitedWarning(warnings...)
 		}
 	} else {
-		orders, err := b.FetchOpenOrders("")
+		orders, err := b.fetchOpenOrders(ctx, "")
 
-		if err == exchange.WarningHTTPRequestRateLimited() {
+		if exchange.IsRateLimited(err) {
 			retErr = err
 		} else if err != nil {
 			return nil, err
@@ -273,6 +409,7 @@ func (b *Binance) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error
 func (b *Binance) convertOrderToExchangeOrder(order *Order) *exchange.Order {
 	retOrder := &exchange.Order{}
 	retOrder.OrderID = strconv.FormatInt(order.OrderID, 10)
+	retOrder.InternalOrderID = order.ClientOrderID
 
 	switch order.Status {
 	case OrderStatusCanceled, OrderStatusPendingCancel, OrderStatusExpired, OrderStatusRejected:
@@ -320,6 +457,7 @@ type symbolDetails struct {
 	AmountDecimalPlaces int32
 	MinAmount           float64
 	MinTotal            float64
+	IcebergAllowed      bool
 }
 
 type currencyLimits struct {
@@ -369,3 +507,54 @@ func (cl *currencyLimits) GetMinTotal(p pair.CurrencyPair) float64 {
 	}
 	return 0
 }
+
+// GetCurrencyMetadata returns the names & deposit networks of the coins supported by the
+// exchange, the default network of each coin is returned first.
+func (b *Binance) GetCurrencyMetadata() ([]exchange.CurrencyMetadata, error) {
+	coins, err := b.GetCoinConfigs()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]exchange.CurrencyMetadata, 0, len(coins))
+	for _, c := range coins {
+		networks := make([]exchange.CurrencyNetwork, 0, len(c.NetworkList))
+		for _, n := range c.NetworkList {
+			network := exchange.CurrencyNetwork{
+				Network:           n.Network,
+				Name:              n.Name,
+				TagRequired:       n.SameAddress,
+				WithdrawalEnabled: n.WithdrawEnable,
+				WithdrawalFee:     n.WithdrawFee,
+				MinWithdrawal:     n.WithdrawMin,
+				Confirmations:     n.MinConfirm,
+			}
+			if n.IsDefault {
+				networks = append([]exchange.CurrencyNetwork{network}, networks...)
+			} else {
+				networks = append(networks, network)
+			}
+		}
+		result = append(result, exchange.CurrencyMetadata{Currency: c.Coin, Name: c.Name, Networks: networks})
+	}
+	return result, nil
+}
+
+// ListInstruments returns the symbols that are currently trading on the exchange
+func (b *Binance) ListInstruments() ([]exchange.Instrument, error) {
+	exchangeInfo, err := b.FetchExchangeInfo()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]exchange.Instrument, 0, len(exchangeInfo.Symbols))
+	for i := range exchangeInfo.Symbols {
+		symbolInfo := &exchangeInfo.Symbols[i]
+		if symbolInfo.Status != SymbolStatusTrading {
+			continue
+		}
+		result = append(result, exchange.Instrument{
+			Symbol: symbolInfo.Symbol,
+			Pair:   pair.NewCurrencyPair(symbolInfo.BaseAsset, symbolInfo.QuoteAsset),
+		})
+	}
+	return result, nil
+}
@@ -1,27 +1,33 @@
 package bitfinex
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/gorilla/websocket"
 	"github.com/mattkanwisher/cryptofiend/common"
 	"github.com/mattkanwisher/cryptofiend/config"
 	"github.com/mattkanwisher/cryptofiend/currency/pair"
 	"github.com/mattkanwisher/cryptofiend/exchanges"
 	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ratelimit"
+	"github.com/mattkanwisher/cryptofiend/exchanges/stream"
 	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
 )
 
 const (
-	bitfinexAPIURL                   = "https://api.bitfinex.com/v1/"
+	bitfinexAPIURL                   = "https://api.bitfinex.com/"
+	bitfinexAPI1Path                 = "v1/"
 	bitfinexAPIVersion1        uint8 = 1
 	bitfinexTicker                   = "pubticker/"
 	bitfinexStats                    = "stats/"
@@ -63,9 +69,26 @@ const (
 	bitfinexWithdrawal               = "withdraw"
 	bitfinexActiveCredits            = "credits"
 
-	bitfinexAPI2URL                    = "https://api.bitfinex.com/v2/"
+	bitfinexAPI2Path                   = "v2/"
 	bitfinexAPIVersion2          uint8 = 2
 	bitfinexCalcAvailableBalance       = "auth/calc/order/avail"
+	bitfinexWalletsV2                  = "auth/r/wallets"
+	bitfinexMarginInfoBaseV2           = "auth/r/info/margin/base"
+	bitfinexMarginInfoSymbolsV2        = "auth/r/info/margin/sym_all"
+	bitfinexMarginInfoSymbolV2         = "auth/r/info/margin/"
+	bitfinexCalcTradeAverage           = "calc/trade/avg"
+	bitfinexPositionsV2                = "auth/r/positions"
+	bitfinexOrderbookV2                = "book/t"
+	bitfinexFundingCreditsV2           = "auth/r/funding/credits/"
+	bitfinexPlatformStatusV2           = "platform/status"
+	bitfinexConfV2                     = "conf/"
+	bitfinexConfTxMethods              = "pub:map:tx:method"
+	bitfinexConfCurrencyLabels         = "pub:map:currency:label"
+
+	// Bitfinex keeps 15% of the interest paid on loans
+	bitfinexLendingFee = 0.15
+	// CreditV2.Side of the funds lent by the account
+	bitfinexCreditSideLender = 1
 
 	// bitfinexMaxRequests if exceeded IP address blocked 10-60 sec, JSON response
 	// {"error": "ERR_RATE_LIMIT"}
@@ -80,33 +103,74 @@ const (
 
 var errRateLimit = errors.New("ERR_RATE_LIMIT")
 
+// Currencies whose deposits & withdrawals need a tag (destination tag, memo or payment ID) in
+// addition to the address, Bitfinex doesn't publish this through the API
+var bitfinexTagCurrencies = map[string]bool{
+	"EOS": true,
+	"XLM": true,
+	"XMR": true,
+	"XRP": true,
+}
+
 // Bitfinex is the overarching type across the bitfinex package
 // Notes: Bitfinex has added a rate limit to the number of REST requests.
 // Rate limit policy can vary in a range of 10 to 90 requests per minute
 // depending on some factors (e.g. servers load, endpoint, etc.).
 type Bitfinex struct {
 	exchange.Base
-	WebsocketConn         *websocket.Conn
+	WebsocketConn         *stream.Conn
 	WebsocketSubdChannels map[int]WebsocketChanInfo
+	// Called with the orders & fills pushed on the authenticated websocket channel, event is the
+	// v2 message type (e.g. "on", "oc", "te")
+	OnWebsocketOrder func(event string, order WebsocketOrder)
+	OnWebsocketTrade func(event string, trade WebsocketTradeExecuted)
+	// Called with the outcome of the requests sent by NewOrderWS & CancelOrderWS
+	OnWebsocketNotification func(notification WebsocketNotification)
+	// Guards the websocket connection & authentication state
+	wsMtx               sync.Mutex
+	wsAuthenticated     bool
+	wsLastClientOrderID int64
+	// Orderbooks maintained by the websocket client
+	books websocketBooks
+	// Candles subscribed to on the websocket
+	candles websocketCandles
 	// Maps symbol (exchange specific market identifier) to currency pair info
 	currencyPairs map[pair.CurrencyItem]*exchange.CurrencyPairInfo
 	symbolDetails map[pair.CurrencyItem]*SymbolDetails
-	// Maps HTTP method & path to a timestamp (in msecs) of the last time a request was sent
-	rateLimits map[string]int64
-	// Timestamp (in msecs) of the last time the Binance server rate limited a request
-	ipBanStartTime int64
-	// Cached stuff that's behind rate limited REST API endpoints
-	lastBalances     []Balance
-	lastActiveOrders []Order
+	// Limits the number of requests sent to each HTTP method & path
+	rateLimiter *ratelimit.Limiter
+	// Cached stuff that's behind rate limited REST API endpoints, along with the time it was
+	// fetched
+	lastBalances         []Balance
+	lastBalancesTime     time.Time
+	lastActiveOrders     []Order
+	lastActiveOrdersTime time.Time
+	// Set if the API key was rejected by the v2 API, in which case the v1 API is used instead,
+	// accessed atomically
+	apiV2Unsupported int32
+	// Caches the results of the bulk symbol <-> currency pair conversions
+	symbolCache exchange.SymbolCache
+}
+
+// New returns a Bitfinex exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *Bitfinex {
+	b := &Bitfinex{}
+	b.SetDefaults()
+	b.Quickstart(b.Setup, apiKey, apiSecret, opts...)
+	return b
 }
 
 // SetDefaults sets the basic defaults for bitfinex
 func (b *Bitfinex) SetDefaults() {
 	b.Name = "Bitfinex"
 	b.Enabled = false
+	b.APIUrl = bitfinexAPIURL
 	b.Verbose = false
 	b.Websocket = false
 	b.RESTPollingDelay = 10
+	b.TakerFee = 0.2
+	b.MakerFee = 0.1
 	b.WebsocketSubdChannels = make(map[int]WebsocketChanInfo)
 	b.RequestCurrencyPairFormat.Delimiter = ""
 	b.RequestCurrencyPairFormat.Uppercase = true
@@ -114,7 +178,9 @@ func (b *Bitfinex) SetDefaults() {
 	b.ConfigCurrencyPairFormat.Uppercase = true
 	b.AssetTypes = []string{ticker.Spot}
 	b.Orderbooks = orderbook.Init()
-	b.rateLimits = map[string]int64{}
+	b.rateLimiter = ratelimit.NewLimiter(b.Name, nil)
+	b.SetStreams(exchange.StreamOrderbook | exchange.StreamTrades | exchange.StreamTicker |
+		exchange.StreamOrders | exchange.StreamCandles)
 	b.lastBalances = []Balance{}
 	b.lastActiveOrders = []Order{}
 }
@@ -130,6 +196,7 @@ func (b *Bitfinex) Setup(exch config.ExchangeConfig) {
 		b.RESTPollingDelay = exch.RESTPollingDelay
 		b.Verbose = exch.Verbose
 		b.Websocket = exch.Websocket
+		b.SetAPIURL(exch)
 		b.BaseCurrencies = common.SplitStrings(exch.BaseCurrencies, ",")
 		b.AvailablePairs = common.SplitStrings(exch.AvailablePairs, ",")
 		b.EnabledPairs = common.SplitStrings(exch.EnabledPairs, ",")
@@ -160,6 +227,18 @@ func (b *Bitfinex) SymbolToCurrencyPair(symbol string) (pair.CurrencyPair, error
 	return p.FormatPair(b.RequestCurrencyPairFormat.Delimiter, b.RequestCurrencyPairFormat.Uppercase), nil
 }
 
+// CurrencyPairsToSymbols converts a batch of currency pairs to symbols, conversions are cached.
+func (b *Bitfinex) CurrencyPairsToSymbols(pairs []pair.CurrencyPair) ([]string, error) {
+	return b.symbolCache.CurrencyPairsToSymbols(pairs, func(cp pair.CurrencyPair) (string, error) {
+		return b.CurrencyPairToSymbol(cp), nil
+	})
+}
+
+// SymbolsToCurrencyPairs converts a batch of symbols to currency pairs, conversions are cached.
+func (b *Bitfinex) SymbolsToCurrencyPairs(symbols []string) ([]pair.CurrencyPair, error) {
+	return b.symbolCache.SymbolsToCurrencyPairs(symbols, b.SymbolToCurrencyPair)
+}
+
 type currencyLimits struct {
 	exchangeName string
 	// Maps symbol (lower-case) to symbol details
@@ -215,18 +294,22 @@ func (b *Bitfinex) GetCurrencyPairs() map[pair.CurrencyItem]*exchange.CurrencyPa
 
 // GetTicker returns ticker information
 func (b *Bitfinex) GetTicker(symbol string, values url.Values) (Ticker, error) {
+	return b.getTicker(context.Background(), symbol, values)
+}
+
+func (b *Bitfinex) getTicker(ctx context.Context, symbol string, values url.Values) (Ticker, error) {
 	response := Ticker{}
-	path := common.EncodeURLValues(bitfinexAPIURL+bitfinexTicker+symbol, values)
+	path := common.EncodeURLValues(b.APIUrl+bitfinexAPI1Path+bitfinexTicker+symbol, values)
 
-	return response, common.SendHTTPGetRequest(path, true, b.Verbose, &response)
+	return response, common.SendHTTPGetRequestContext(ctx, path, true, b.Debug(exchange.TraceHTTP), &response)
 }
 
 // GetStats returns various statistics about the requested pair
 func (b *Bitfinex) GetStats(symbol string) ([]Stat, error) {
 	response := []Stat{}
-	path := fmt.Sprint(bitfinexAPIURL + bitfinexStats + symbol)
+	path := fmt.Sprint(b.APIUrl + bitfinexAPI1Path + bitfinexStats + symbol)
 
-	return response, common.SendHTTPGetRequest(path, true, b.Verbose, &response)
+	return response, common.SendHTTPGetRequest(path, true, b.Debug(exchange.TraceHTTP), &response)
 }
 
 // GetFundingBook the entire margin funding book for both bids and asks sides
@@ -234,9 +317,9 @@ func (b *Bitfinex) GetStats(symbol string) ([]Stat, error) {
 // symbol - example "USD"
 func (b *Bitfinex) GetFundingBook(symbol string) (FundingBook, error) {
 	response := FundingBook{}
-	path := fmt.Sprint(bitfinexAPIURL + bitfinexLendbook + symbol)
+	path := fmt.Sprint(b.APIUrl + bitfinexAPI1Path + bitfinexLendbook + symbol)
 
-	return response, common.SendHTTPGetRequest(path, true, b.Verbose, &response)
+	return response, common.SendHTTPGetRequest(path, true, b.Debug(exchange.TraceHTTP), &response)
 }
 
 // GetOrderbook retieves the orderbook bid and ask price points for a currency
@@ -245,12 +328,141 @@ func (b *Bitfinex) GetFundingBook(symbol string) (FundingBook, error) {
 // Values can contain limit amounts for both the asks and bids - Example
 // "limit_bids" = 1000
 func (b *Bitfinex) GetOrderbook(currencyPair string, values url.Values) (Orderbook, error) {
+	return b.getOrderbook(context.Background(), currencyPair, values)
+}
+
+func (b *Bitfinex) getOrderbook(ctx context.Context, currencyPair string, values url.Values) (Orderbook, error) {
 	response := Orderbook{}
 	path := common.EncodeURLValues(
-		bitfinexAPIURL+bitfinexOrderbook+currencyPair,
+		b.APIUrl+bitfinexAPI1Path+bitfinexOrderbook+currencyPair,
 		values,
 	)
-	return response, common.SendHTTPGetRequest(path, true, b.Verbose, &response)
+	return response, common.SendHTTPGetRequestContext(ctx, path, true, b.Debug(exchange.TraceHTTP), &response)
+}
+
+// GetPlatformStatus returns whether the exchange is operational or under maintenance, Bitfinex
+// doesn't announce planned maintenance through the API.
+func (b *Bitfinex) GetPlatformStatus() (exchange.PlatformStatus, error) {
+	var response []int
+	path := b.APIUrl + bitfinexAPI2Path + bitfinexPlatformStatusV2
+	if err := common.SendHTTPGetRequest(path, true, b.Debug(exchange.TraceHTTP), &response); err != nil {
+		return exchange.PlatformStatus{}, err
+	}
+	if len(response) == 0 {
+		return exchange.PlatformStatus{}, errors.New("unexpected platform status response")
+	}
+	return exchange.PlatformStatus{Operational: response[0] == 1}, nil
+}
+
+// GetDepositMethods returns the deposit & withdrawal methods, along with the currencies that can
+// be deposited & withdrawn using each method
+func (b *Bitfinex) GetDepositMethods() ([]MethodCurrencies, error) {
+	var response [][]MethodCurrencies
+	path := b.APIUrl + bitfinexAPI2Path + bitfinexConfV2 + bitfinexConfTxMethods
+	if err := common.SendHTTPGetRequest(path, true, b.Debug(exchange.TraceHTTP), &response); err != nil {
+		return nil, err
+	}
+	if len(response) == 0 {
+		return nil, nil
+	}
+	return response[0], nil
+}
+
+// GetCurrencyLabels returns the long names of the currencies
+func (b *Bitfinex) GetCurrencyLabels() ([]CurrencyLabel, error) {
+	var response [][]CurrencyLabel
+	path := b.APIUrl + bitfinexAPI2Path + bitfinexConfV2 + bitfinexConfCurrencyLabels
+	if err := common.SendHTTPGetRequest(path, true, b.Debug(exchange.TraceHTTP), &response); err != nil {
+		return nil, err
+	}
+	if len(response) == 0 {
+		return nil, nil
+	}
+	return response[0], nil
+}
+
+// GetCurrencyMetadata returns the names & deposit methods of the currencies supported by the
+// exchange, each deposit method is returned as a separate network.
+func (b *Bitfinex) GetCurrencyMetadata() ([]exchange.CurrencyMetadata, error) {
+	methods, err := b.GetDepositMethods()
+	if err != nil {
+		return nil, err
+	}
+	labels, err := b.GetCurrencyLabels()
+	if err != nil {
+		return nil, err
+	}
+
+	currencies := make(map[string]*exchange.CurrencyMetadata)
+	get := func(currency string) *exchange.CurrencyMetadata {
+		c, ok := currencies[currency]
+		if !ok {
+			c = &exchange.CurrencyMetadata{Currency: currency, Networks: []exchange.CurrencyNetwork{}}
+			currencies[currency] = c
+		}
+		return c
+	}
+	for _, l := range labels {
+		get(l.Currency).Name = l.Label
+	}
+	for _, m := range methods {
+		for _, currency := range m.Currencies {
+			c := get(currency)
+			c.Networks = append(c.Networks, exchange.CurrencyNetwork{
+				Network:           m.Method,
+				Method:            common.StringToLower(m.Method),
+				TagRequired:       bitfinexTagCurrencies[currency],
+				WithdrawalEnabled: true,
+			})
+		}
+	}
+
+	result := make([]exchange.CurrencyMetadata, 0, len(currencies))
+	for _, c := range currencies {
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Currency < result[j].Currency })
+	return result, nil
+}
+
+// GetOrderbookV2 retrieves the orderbook aggregated at the given precision from the v2 API.
+// CurrencyPair - Example "BTCUSD"
+// Precision - "P0" to "P3" (P0 being the most precise), "R0" returns raw orders
+// Length - number of price levels per side, 25 or 100
+func (b *Bitfinex) GetOrderbookV2(currencyPair, precision string, length int) (Orderbook, error) {
+	return b.getOrderbookV2(context.Background(), currencyPair, precision, length)
+}
+
+func (b *Bitfinex) getOrderbookV2(ctx context.Context, currencyPair, precision string, length int) (Orderbook, error) {
+	response := Orderbook{}
+	var entries [][]float64
+	vals := url.Values{}
+	vals.Set("len", strconv.Itoa(length))
+	path := common.EncodeURLValues(
+		b.APIUrl+bitfinexAPI2Path+bitfinexOrderbookV2+currencyPair+"/"+precision,
+		vals,
+	)
+	if err := common.SendHTTPGetRequestContext(ctx, path, true, b.Debug(exchange.TraceHTTP), &entries); err != nil {
+		return response, err
+	}
+
+	// Each entry is [PRICE, COUNT, AMOUNT], or [ORDER_ID, PRICE, AMOUNT] for raw books,
+	// positive amounts are bids and negative amounts are asks.
+	priceIdx := 0
+	if precision == exchange.OrderbookPrecisionR0 {
+		priceIdx = 1
+	}
+	for _, entry := range entries {
+		if len(entry) < 3 {
+			return response, fmt.Errorf("unexpected orderbook entry %v", entry)
+		}
+		if entry[2] > 0 {
+			response.Bids = append(response.Bids, Book{Price: entry[priceIdx], Amount: entry[2]})
+		} else {
+			response.Asks = append(response.Asks, Book{Price: entry[priceIdx], Amount: -entry[2]})
+		}
+	}
+	return response, nil
 }
 
 // GetTrades returns a list of the most recent trades for the given curencyPair
@@ -261,10 +473,10 @@ func (b *Bitfinex) GetOrderbook(currencyPair string, values url.Values) (Orderbo
 func (b *Bitfinex) GetTrades(currencyPair string, values url.Values) ([]TradeStructure, error) {
 	response := []TradeStructure{}
 	path := common.EncodeURLValues(
-		bitfinexAPIURL+bitfinexTrades+currencyPair,
+		b.APIUrl+bitfinexAPI1Path+bitfinexTrades+currencyPair,
 		values,
 	)
-	return response, common.SendHTTPGetRequest(path, true, b.Verbose, &response)
+	return response, common.SendHTTPGetRequest(path, true, b.Debug(exchange.TraceHTTP), &response)
 }
 
 // GetLendbook returns a list of the most recent funding data for the given
@@ -276,9 +488,9 @@ func (b *Bitfinex) GetLendbook(symbol string, values url.Values) (Lendbook, erro
 	if len(symbol) == 6 {
 		symbol = symbol[:3]
 	}
-	path := common.EncodeURLValues(bitfinexAPIURL+bitfinexLendbook+symbol, values)
+	path := common.EncodeURLValues(b.APIUrl+bitfinexAPI1Path+bitfinexLendbook+symbol, values)
 
-	return response, common.SendHTTPGetRequest(path, true, b.Verbose, &response)
+	return response, common.SendHTTPGetRequest(path, true, b.Debug(exchange.TraceHTTP), &response)
 }
 
 // GetLends returns a list of the most recent funding data for the given
@@ -287,25 +499,25 @@ func (b *Bitfinex) GetLendbook(symbol string, values url.Values) (Lendbook, erro
 // Symbol - example "USD"
 func (b *Bitfinex) GetLends(symbol string, values url.Values) ([]Lends, error) {
 	response := []Lends{}
-	path := common.EncodeURLValues(bitfinexAPIURL+bitfinexLends+symbol, values)
+	path := common.EncodeURLValues(b.APIUrl+bitfinexAPI1Path+bitfinexLends+symbol, values)
 
-	return response, common.SendHTTPGetRequest(path, true, b.Verbose, &response)
+	return response, common.SendHTTPGetRequest(path, true, b.Debug(exchange.TraceHTTP), &response)
 }
 
 // GetSymbols returns the available currency pairs on the exchange
 func (b *Bitfinex) GetSymbols() ([]string, error) {
 	products := []string{}
-	path := fmt.Sprint(bitfinexAPIURL + bitfinexSymbols)
+	path := fmt.Sprint(b.APIUrl + bitfinexAPI1Path + bitfinexSymbols)
 
-	return products, common.SendHTTPGetRequest(path, true, b.Verbose, &products)
+	return products, common.SendHTTPGetRequest(path, true, b.Debug(exchange.TraceHTTP), &products)
 }
 
 // GetSymbolsDetails a list of valid symbol IDs and the pair details
 func (b *Bitfinex) GetSymbolsDetails() ([]SymbolDetails, error) {
 	response := []SymbolDetails{}
-	path := fmt.Sprint(bitfinexAPIURL + bitfinexSymbolsDetails)
+	path := fmt.Sprint(b.APIUrl + bitfinexAPI1Path + bitfinexSymbolsDetails)
 
-	return response, common.SendHTTPGetRequest(path, true, b.Verbose, &response)
+	return response, common.SendHTTPGetRequest(path, true, b.Debug(exchange.TraceHTTP), &response)
 }
 
 // GetAccountInfo returns information about your account incl. trading fees
@@ -368,14 +580,40 @@ func (b *Bitfinex) GetMarginInfo() ([]MarginInfo, error) {
 		b.SendAuthenticatedHTTPRequest("POST", bitfinexMarginInfo, nil, &response)
 }
 
-// GetAccountBalance returns full wallet balance information
+// GetAccountBalance returns full wallet balance information, the v2 API is used if the API key
+// supports it, otherwise the v1 API is used.
 func (b *Bitfinex) GetAccountBalance() ([]Balance, error) {
+	return b.getAccountBalance(context.Background())
+}
+
+func (b *Bitfinex) getAccountBalance(ctx context.Context) ([]Balance, error) {
+	if atomic.LoadInt32(&b.apiV2Unsupported) == 0 {
+		wallets := []WalletV2{}
+		err := b.SendRateLimitedHTTPRequestContext(ctx, 12, "POST", bitfinexAPIVersion2, bitfinexWalletsV2,
+			map[string]interface{}{}, &wallets, []WalletV2{}, b.lastBalancesTime)
+		if exchange.IsRateLimited(err) {
+			return b.lastBalances, err
+		} else if err == nil {
+			response := make([]Balance, 0, len(wallets))
+			for i := range wallets {
+				response = append(response, wallets[i].toBalance())
+			}
+			b.lastBalances = response
+			b.lastBalancesTime = time.Now()
+			return response, nil
+		} else if !b.checkAPIV2Unsupported(err) {
+			return nil, err
+		}
+	}
+
 	response := []Balance{}
-	err := b.SendRateLimitedHTTPRequest(12, "POST", bitfinexAPIVersion1, bitfinexBalances, nil, &response, b.lastBalances)
+	err := b.SendRateLimitedHTTPRequestContext(ctx, 12, "POST", bitfinexAPIVersion1, bitfinexBalances, nil, &response,
+		b.lastBalances, b.lastBalancesTime)
 	if err != nil {
 		return response, err
 	}
 	b.lastBalances = response
+	b.lastBalancesTime = time.Now()
 	return response, nil
 }
 
@@ -403,7 +641,7 @@ func (b *Bitfinex) CalcAvailableBalance(
 	var availableAmt []float64
 	defVal := []float64{0.0}
 	err := b.SendRateLimitedHTTPRequest(10, "POST", bitfinexAPIVersion2, bitfinexCalcAvailableBalance,
-		params, &availableAmt, defVal)
+		params, &availableAmt, defVal, time.Time{})
 	if err != nil {
 		return 0.0, err
 	}
@@ -443,7 +681,7 @@ func (b *Bitfinex) Withdrawal(withdrawType, wallet, address string, amount float
 
 // newOrder submits a new order and returns a order information
 // Major Upgrade needed on this function to include all query params
-func (b *Bitfinex) newOrder(symbol string, amount float64, price float64, side string,
+func (b *Bitfinex) newOrder(ctx context.Context, symbol string, amount float64, price float64, side string,
 	orderType OrderType, hidden bool) (Order, error) {
 	response := Order{}
 	request := make(map[string]interface{})
@@ -455,10 +693,10 @@ func (b *Bitfinex) newOrder(symbol string, amount float64, price float64, side s
 	request["is_hidden"] = hidden
 	request["side"] = side // this exchange uses the string buy/sell so no conversion neccessary
 
-	err := b.SendAuthenticatedHTTPRequest("POST", bitfinexOrderNew, request, &response)
-	if httpErr, ok := err.(*common.HTTPRequestError); ok {
-		msg := strings.ToLower(err.Error())
-		if (httpErr.StatusCode == 400) && strings.HasPrefix(msg, "invalid order: not enough") {
+	err := b.SendAuthenticatedHTTPRequestContext(ctx, "POST", bitfinexOrderNew, request, &response)
+	if exchErr, ok := err.(*exchange.ExchangeError); ok {
+		msg := strings.ToLower(exchErr.Message)
+		if (exchErr.StatusCode == 400) && strings.HasPrefix(msg, "invalid order: not enough") {
 			return response, exchange.ErrInsufficentFundsForOrder()
 		}
 	}
@@ -467,8 +705,21 @@ func (b *Bitfinex) newOrder(symbol string, amount float64, price float64, side s
 
 // NewOrder submits a new order and returns the ID of the new exchange order
 func (b *Bitfinex) NewOrder(currencyPair pair.CurrencyPair, amount, price float64,
-	side exchange.OrderSide, orderType exchange.OrderType) (string, error) {
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return b.NewOrderContext(context.Background(), currencyPair, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (b *Bitfinex) NewOrderContext(ctx context.Context, currencyPair pair.CurrencyPair, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	if err := b.GetCapabilities().CheckOrderOptions(opts...); err != nil {
+		return "", err
+	}
 	symbol := b.CurrencyPairToSymbol(currencyPair)
+	hidden := false
+	for _, o := range opts {
+		hidden = hidden || o.Hidden
+	}
 
 	var bitfinexOrderType OrderType
 	switch orderType {
@@ -480,7 +731,7 @@ func (b *Bitfinex) NewOrder(currencyPair pair.CurrencyPair, amount, price float6
 		return "", fmt.Errorf("'%s' order type not currently supported for this exchange", string(orderType))
 	}
 
-	order, err := b.newOrder(symbol, amount, price, string(side), bitfinexOrderType, false)
+	order, err := b.newOrder(ctx, symbol, amount, price, string(side), bitfinexOrderType, hidden)
 	if err != nil {
 		return "", err
 	}
@@ -499,23 +750,28 @@ func (b *Bitfinex) NewOrderMulti(orders []PlaceOrder) (OrderMultiResponse, error
 }
 
 func (b *Bitfinex) CancelOrder(orderStr string, currencyPair pair.CurrencyPair) error {
+	return b.CancelOrderContext(context.Background(), orderStr, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (b *Bitfinex) CancelOrderContext(ctx context.Context, orderStr string, currencyPair pair.CurrencyPair) error {
 	var orderID int64
 	var err error
 	if orderID, err = strconv.ParseInt(orderStr, 10, 64); err != nil {
 		return err
 	}
-	_, err = b.cancelOrder(orderID)
+	_, err = b.cancelOrder(ctx, orderID)
 	return err
 }
 
 // CancelOrder cancels a single order
-func (b *Bitfinex) cancelOrder(OrderID int64) (Order, error) {
+func (b *Bitfinex) cancelOrder(ctx context.Context, OrderID int64) (Order, error) {
 	response := Order{}
 	request := make(map[string]interface{})
 	request["order_id"] = OrderID
 
 	return response,
-		b.SendAuthenticatedHTTPRequest("POST", bitfinexOrderCancel, request, &response)
+		b.SendAuthenticatedHTTPRequestContext(ctx, "POST", bitfinexOrderCancel, request, &response)
 }
 
 // CancelMultipleOrders cancels multiple orders
@@ -560,21 +816,31 @@ func (b *Bitfinex) ReplaceOrder(OrderID int64, Symbol string, Amount float64, Pr
 
 // GetOrderStatus returns order status information
 func (b *Bitfinex) GetOrderStatus(OrderID int64) (Order, error) {
+	return b.getOrderStatus(context.Background(), OrderID)
+}
+
+func (b *Bitfinex) getOrderStatus(ctx context.Context, OrderID int64) (Order, error) {
 	orderStatus := Order{}
 	request := make(map[string]interface{})
 	request["order_id"] = OrderID
 
 	return orderStatus,
-		b.SendAuthenticatedHTTPRequest("POST", bitfinexOrderStatus, request, &orderStatus)
+		b.SendAuthenticatedHTTPRequestContext(ctx, "POST", bitfinexOrderStatus, request, &orderStatus)
 }
 
 // GetOrder returns information about the exchange order matching the given ID
 func (b *Bitfinex) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	return b.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (b *Bitfinex) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
 	id, err := strconv.ParseInt(orderID, 10, 64)
 	if err != nil {
 		return nil, err
 	}
-	order, err := b.GetOrderStatus(id)
+	order, err := b.getOrderStatus(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -626,21 +892,31 @@ func (b *Bitfinex) convertOrderToExchangeOrder(order *Order) *exchange.Order {
 
 // GetActiveOrders returns all active orders and statuses
 func (b *Bitfinex) GetActiveOrders() ([]Order, error) {
+	return b.getActiveOrders(context.Background())
+}
+
+func (b *Bitfinex) getActiveOrders(ctx context.Context) ([]Order, error) {
 	response := []Order{}
-	err := b.SendRateLimitedHTTPRequest(10, http.MethodPost, bitfinexAPIVersion1, bitfinexOrders,
-		nil, &response, b.lastActiveOrders)
+	err := b.SendRateLimitedHTTPRequestContext(ctx, 10, http.MethodPost, bitfinexAPIVersion1, bitfinexOrders,
+		nil, &response, b.lastActiveOrders, b.lastActiveOrdersTime)
 	if err != nil {
 		return response, err
 	}
 	b.lastActiveOrders = response
+	b.lastActiveOrdersTime = time.Now()
 	return response, nil
 }
 
 func (b *Bitfinex) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return b.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (b *Bitfinex) GetOrdersContext(ctx context.Context, pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
 	var retErr error
-	orders, err := b.GetActiveOrders()
+	orders, err := b.getActiveOrders(ctx)
 
-	if err == exchange.WarningHTTPRequestRateLimited() {
+	if exchange.IsRateLimited(err) {
 		retErr = err
 	} else if err != nil {
 		return nil, err
@@ -655,14 +931,148 @@ func (b *Bitfinex) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, erro
 	return ret, retErr
 }
 
-// GetActivePositions returns an array of active positions
+// GetActivePositions returns an array of active positions, the v2 API is used if the API key
+// supports it, otherwise the v1 API is used.
 func (b *Bitfinex) GetActivePositions() ([]Position, error) {
+	if atomic.LoadInt32(&b.apiV2Unsupported) == 0 {
+		positions, err := b.GetActivePositionsV2()
+		if err == nil {
+			response := make([]Position, 0, len(positions))
+			for i := range positions {
+				response = append(response, positions[i].toPosition())
+			}
+			return response, nil
+		} else if !b.checkAPIV2Unsupported(err) {
+			return nil, err
+		}
+	}
+
 	response := []Position{}
 
 	return response,
 		b.SendAuthenticatedHTTPRequest("POST", bitfinexPositions, nil, &response)
 }
 
+// GetWalletsV2 returns the balances of all the wallets using the v2 API
+func (b *Bitfinex) GetWalletsV2() ([]WalletV2, error) {
+	response := []WalletV2{}
+	_, err := b.SendAuthenticatedHTTPRequest2("POST", bitfinexWalletsV2, map[string]interface{}{}, &response)
+	return response, err
+}
+
+// GetFundingCreditsHistoryV2 returns the funds lent to margin positions that were closed between
+// start & end using the v2 API, symbol is the currency of the funds (e.g. USD), or empty for all
+// currencies.
+func (b *Bitfinex) GetFundingCreditsHistoryV2(symbol string, start, end time.Time) ([]CreditV2, error) {
+	path := bitfinexFundingCreditsV2 + "hist"
+	if symbol != "" {
+		path = bitfinexFundingCreditsV2 + "f" + common.StringToUpper(symbol) + "/hist"
+	}
+	params := map[string]interface{}{
+		"start": start.UnixNano() / int64(time.Millisecond),
+		"end":   end.UnixNano() / int64(time.Millisecond),
+	}
+	response := []CreditV2{}
+	_, err := b.SendAuthenticatedHTTPRequest2("POST", path, params, &response)
+	return response, err
+}
+
+// GetLendingRecords returns the funds lent to margin positions that were closed between start &
+// end. The history doesn't include the interest payments, so the interest is calculated from the
+// daily rate & the time the funds were lent for. The credits the account borrowed to fund its own
+// margin positions aren't included.
+func (b *Bitfinex) GetLendingRecords(start, end time.Time) ([]exchange.LendingRecord, error) {
+	credits, err := b.GetFundingCreditsHistoryV2("", start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]exchange.LendingRecord, 0, len(credits))
+	for i := range credits {
+		credit := &credits[i]
+		if credit.Side != bitfinexCreditSideLender {
+			continue
+		}
+		record := exchange.LendingRecord{
+			ID:       strconv.FormatInt(credit.ID, 10),
+			Exchange: b.GetName(),
+			Currency: strings.TrimPrefix(credit.Symbol, "f"),
+			Amount:   credit.Amount,
+			Rate:     credit.Rate,
+			Opened:   time.Unix(0, credit.OpenedAt*int64(time.Millisecond)),
+			Closed:   time.Unix(0, credit.UpdatedAt*int64(time.Millisecond)),
+		}
+		days := record.Closed.Sub(record.Opened).Hours() / 24
+		record.Interest = record.Amount * record.Rate * days
+		record.Fee = record.Interest * bitfinexLendingFee
+		record.Earned = record.Interest - record.Fee
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// GetMarginInfoBaseV2 returns account wide margin information using the v2 API
+func (b *Bitfinex) GetMarginInfoBaseV2() (MarginInfoBaseV2, error) {
+	response := MarginInfoBaseV2{}
+	_, err := b.SendAuthenticatedHTTPRequest2("POST", bitfinexMarginInfoBaseV2, map[string]interface{}{}, &response)
+	return response, err
+}
+
+// GetMarginInfoSymbolsV2 returns margin information for all symbols using the v2 API
+func (b *Bitfinex) GetMarginInfoSymbolsV2() ([]MarginInfoSymbolV2, error) {
+	response := []MarginInfoSymbolV2{}
+	_, err := b.SendAuthenticatedHTTPRequest2("POST", bitfinexMarginInfoSymbolsV2, map[string]interface{}{}, &response)
+	return response, err
+}
+
+// GetMarginInfoSymbolV2 returns margin information (including the tradable balance) for a single
+// symbol using the v2 API
+func (b *Bitfinex) GetMarginInfoSymbolV2(symbol string) (MarginInfoSymbolV2, error) {
+	response := MarginInfoSymbolV2{}
+	_, err := b.SendAuthenticatedHTTPRequest2("POST", bitfinexMarginInfoSymbolV2+"t"+symbol,
+		map[string]interface{}{}, &response)
+	return response, err
+}
+
+// CalcTradeAverage calculates the average execution price of a market order for the given symbol,
+// amount should be positive for buy orders and negative for sell orders.
+func (b *Bitfinex) CalcTradeAverage(symbol string, amount float64) (TradeAverage, error) {
+	response := TradeAverage{}
+	values := url.Values{}
+	values.Set("symbol", "t"+symbol)
+	values.Set("amount", strconv.FormatFloat(amount, 'f', -1, 64))
+	path := common.EncodeURLValues(b.APIUrl+bitfinexAPI2Path+bitfinexCalcTradeAverage, values)
+
+	resp, statusCode, err := common.SendHTTPRequest2("POST", path, make(http.Header), strings.NewReader(""))
+	if err != nil {
+		return response, err
+	}
+	if statusCode != 200 {
+		return response, exchange.NewExchangeError(b.Name, bitfinexCalcTradeAverage, statusCode, 0,
+			fmt.Sprintf("HTTP request failed with status code %d", statusCode), resp)
+	}
+	return response, common.JSONDecode([]byte(resp), &response)
+}
+
+// GetActivePositionsV2 returns all the active positions using the v2 API
+func (b *Bitfinex) GetActivePositionsV2() ([]PositionV2, error) {
+	response := []PositionV2{}
+	_, err := b.SendAuthenticatedHTTPRequest2("POST", bitfinexPositionsV2, map[string]interface{}{}, &response)
+	return response, err
+}
+
+// checkAPIV2Unsupported returns true if the error indicates that the API key can't be used with
+// the v2 API, in which case all subsequent requests that can be served by either API version
+// will use the v1 API.
+func (b *Bitfinex) checkAPIV2Unsupported(err error) bool {
+	if exchErr, ok := err.(*exchange.ExchangeError); ok && exchErr.Code == InvalidAPIKeyErrCode {
+		log.Printf("%s API key was rejected by the v2 API, falling back to the v1 API", b.Name)
+		atomic.StoreInt32(&b.apiV2Unsupported, 1)
+		return true
+	}
+	return false
+}
+
 // ClaimPosition allows positions to be claimed
 func (b *Bitfinex) ClaimPosition(PositionID int) (Position, error) {
 	response := Position{}
@@ -723,14 +1133,16 @@ func (b *Bitfinex) GetMovementHistory(symbol, method string, timeSince, timeUnti
 func (b *Bitfinex) GetTradeHistory(currencyPair string, timestamp, until time.Time, limit, reverse int) ([]TradeHistory, error) {
 	response := []TradeHistory{}
 	request := make(map[string]interface{})
-	request["currency"] = currencyPair
-	request["timestamp"] = timestamp
+	request["symbol"] = currencyPair
 
+	if !timestamp.IsZero() {
+		request["timestamp"] = strconv.FormatInt(timestamp.Unix(), 10)
+	}
 	if !until.IsZero() {
-		request["until"] = until
+		request["until"] = strconv.FormatInt(until.Unix(), 10)
 	}
 	if limit > 0 {
-		request["limit"] = limit
+		request["limit_trades"] = limit
 	}
 	if reverse > 0 {
 		request["reverse"] = reverse
@@ -830,6 +1242,15 @@ func (b *Bitfinex) CloseMarginFunding(SwapID int64) (Offer, error) {
 // SendAuthenticatedHTTPRequest sends an autheticated http request and json
 // unmarshals result to a supplied variable
 func (b *Bitfinex) SendAuthenticatedHTTPRequest(method, path string, params map[string]interface{}, result interface{}) error {
+	return b.SendAuthenticatedHTTPRequestContext(context.Background(), method, path, params, result)
+}
+
+// SendAuthenticatedHTTPRequestContext is SendAuthenticatedHTTPRequest, the request is cancelled
+// when the context is done
+func (b *Bitfinex) SendAuthenticatedHTTPRequestContext(ctx context.Context, method, path string,
+	params map[string]interface{}, result interface{}) error {
+	apiKey, apiSecret, _ := b.Credentials()
+
 	if !b.AuthenticatedAPISupport {
 		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, b.Name)
 	}
@@ -855,32 +1276,32 @@ func (b *Bitfinex) SendAuthenticatedHTTPRequest(method, path string, params map[
 		return errors.New("SendAuthenticatedHTTPRequest: Unable to JSON request")
 	}
 
-	if b.Verbose {
+	if b.Debug(exchange.TraceHTTP) {
 		log.Printf("Request JSON: %s\n", PayloadJSON)
 	}
 
 	PayloadBase64 := common.Base64Encode(PayloadJSON)
-	hmac := common.GetHMAC(common.HashSHA512_384, []byte(PayloadBase64), []byte(b.APISecret))
+	hmac := common.GetHMAC(common.HashSHA512_384, []byte(PayloadBase64), []byte(apiSecret))
 	headers := make(http.Header)
-	headers["X-BFX-APIKEY"] = []string{b.APIKey}
+	headers["X-BFX-APIKEY"] = []string{apiKey}
 	headers["X-BFX-PAYLOAD"] = []string{PayloadBase64}
 	headers["X-BFX-SIGNATURE"] = []string{common.HexEncodeToString(hmac)}
 
-	resp, statusCode, err := common.SendHTTPRequest2(
-		method, bitfinexAPIURL+path, headers, strings.NewReader(""),
+	resp, statusCode, err := common.SendHTTPRequest2Context(ctx,
+		method, b.APIUrl+bitfinexAPI1Path+path, headers, strings.NewReader(""),
 	)
 	if err != nil {
 		return err
 	}
 
-	if b.Verbose {
+	if b.Debug(exchange.TraceHTTP) {
 		log.Printf("Received raw: \n%s\n", resp)
 	}
 
 	respErr := ErrorCapture{}
 	if err = common.JSONDecode([]byte(resp), &respErr); err == nil {
 		if len(respErr.Message) != 0 {
-			return &common.HTTPRequestError{statusCode, respErr.Message}
+			return exchange.NewExchangeError(b.Name, path, statusCode, 0, respErr.Message, resp)
 		}
 	}
 
@@ -892,7 +1313,7 @@ func (b *Bitfinex) SendAuthenticatedHTTPRequest(method, path string, params map[
 		if rateLimitErr.Message == "ERR_RATE_LIMIT" {
 			return errRateLimit
 		} else if len(rateLimitErr.Message) != 0 {
-			return &common.HTTPRequestError{statusCode, respErr.Message}
+			return exchange.NewExchangeError(b.Name, path, statusCode, 0, rateLimitErr.Message, resp)
 		}
 	}
 
@@ -901,7 +1322,8 @@ func (b *Bitfinex) SendAuthenticatedHTTPRequest(method, path string, params map[
 	}
 
 	if err = common.JSONDecode([]byte(resp), &result); err != nil {
-		return errors.New("sendAuthenticatedHTTPRequest: Unable to JSON Unmarshal response")
+		return exchange.NewExchangeError(b.Name, path, statusCode, 0,
+			"sendAuthenticatedHTTPRequest: Unable to JSON Unmarshal response", resp)
 	}
 	return nil
 }
@@ -911,6 +1333,15 @@ func (b *Bitfinex) SendAuthenticatedHTTPRequest(method, path string, params map[
 // Returns the Bitfinex error code and error message (if any).
 func (b *Bitfinex) SendAuthenticatedHTTPRequest2(method, path string, params map[string]interface{},
 	result interface{}) (int, error) {
+	return b.SendAuthenticatedHTTPRequest2Context(context.Background(), method, path, params, result)
+}
+
+// SendAuthenticatedHTTPRequest2Context is SendAuthenticatedHTTPRequest2, the request is
+// cancelled when the context is done
+func (b *Bitfinex) SendAuthenticatedHTTPRequest2Context(ctx context.Context, method, path string,
+	params map[string]interface{}, result interface{}) (int, error) {
+	apiKey, apiSecret, _ := b.Credentials()
+
 	if !b.AuthenticatedAPISupport {
 		return 0, fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, b.Name)
 	}
@@ -923,31 +1354,32 @@ func (b *Bitfinex) SendAuthenticatedHTTPRequest2(method, path string, params map
 		return 0, errors.New("SendAuthenticatedHTTPRequest2: Unable to JSON request")
 	}
 
-	if b.Verbose {
+	if b.Debug(exchange.TraceHTTP) {
 		log.Printf("Request JSON: %s\n", payloadJSON)
 	}
 
 	payload := "/api/v2/" + path + nonce + string(payloadJSON)
-	hmac := common.GetHMAC(common.HashSHA512_384, []byte(payload), []byte(b.APISecret))
+	hmac := common.GetHMAC(common.HashSHA512_384, []byte(payload), []byte(apiSecret))
 	headers := make(http.Header)
 	headers["Content-Type"] = []string{"application/json"}
 	headers["Accept"] = []string{"application/json"}
 	headers["bfx-nonce"] = []string{nonce}
-	headers["bfx-apikey"] = []string{b.APIKey}
+	headers["bfx-apikey"] = []string{apiKey}
 	headers["bfx-signature"] = []string{common.HexEncodeToString(hmac)}
 
-	resp, statusCode, err := common.SendHTTPRequest2(method, bitfinexAPI2URL+path, headers, strings.NewReader(string(payloadJSON)))
+	resp, statusCode, err := common.SendHTTPRequest2Context(ctx, method, b.APIUrl+bitfinexAPI2Path+path, headers, strings.NewReader(string(payloadJSON)))
 	if err != nil {
 		return 0, err
 	}
 
-	if b.Verbose {
+	if b.Debug(exchange.TraceHTTP) {
 		log.Printf("Received raw: \n%s\n", resp)
 	}
 
 	if 200 <= statusCode && statusCode <= 299 {
 		if err = common.JSONDecode([]byte(resp), &result); err != nil {
-			return statusCode, errors.New("SendAuthenticatedHTTPRequest2: Unable to JSON Unmarshal response")
+			return statusCode, exchange.NewExchangeError(b.Name, path, statusCode, 0,
+				"SendAuthenticatedHTTPRequest2: Unable to JSON Unmarshal response", resp)
 		}
 	} else if statusCode == 429 /* Too Many Requests */ {
 		return 0, errRateLimit
@@ -971,8 +1403,10 @@ func (b *Bitfinex) SendAuthenticatedHTTPRequest2(method, path string, params map
 			if !ok {
 				return 0, fmt.Errorf("Expected third element to be error message but got %#v", errResp)
 			}
-			return int(code), errors.New(msg)
+			return int(code), exchange.NewExchangeError(b.Name, path, statusCode, int(code), msg, resp)
 		}
+		return 0, exchange.NewExchangeError(b.Name, path, statusCode, 0,
+			fmt.Sprintf("HTTP request failed with status code %d", statusCode), resp)
 	}
 
 	return 0, nil
@@ -982,38 +1416,40 @@ func (b *Bitfinex) SendAuthenticatedHTTPRequest2(method, path string, params map
 // hasn't been exceeded for the specified method & path and unmarshals the response into the
 // result parameter. If the number of requests per minute has been exceeded this method will
 // set the result to the default value (which can be a pointer, but must not be nil), and return
-// exchange.WarningHTTPRequestRateLimited.
+// an exchange.RateLimitedWarning with the time the default value was fetched (cachedAt), which
+// should be zero if the default value isn't cached data.
 func (b *Bitfinex) SendRateLimitedHTTPRequest(requestsPerMin uint, method string, apiVersion uint8,
-	path string, params map[string]interface{}, result interface{}, defaultValue interface{}) error {
-	curTimestamp := time.Now().UnixNano() / (1000 * 1000) // convert to milliseconds
-	requestDelay := int64((60 * 1000) / requestsPerMin)   // min delay between requests in msecs
-	lastRequestTime := b.rateLimits[method+path]
-	// If we got IP banned wait 1 min before trying again
-	skipRequest := (b.ipBanStartTime != 0) && ((curTimestamp - b.ipBanStartTime) < (60 * 1000))
+	path string, params map[string]interface{}, result interface{}, defaultValue interface{},
+	cachedAt time.Time) error {
+	return b.SendRateLimitedHTTPRequestContext(context.Background(), requestsPerMin, method, apiVersion,
+		path, params, result, defaultValue, cachedAt)
+}
+
+// SendRateLimitedHTTPRequestContext is SendRateLimitedHTTPRequest, the request is cancelled when
+// the context is done
+func (b *Bitfinex) SendRateLimitedHTTPRequestContext(ctx context.Context, requestsPerMin uint, method string,
+	apiVersion uint8, path string, params map[string]interface{}, result interface{}, defaultValue interface{},
+	cachedAt time.Time) error {
 	// Make sure requests are spaced out to avoid getting IP banned in the first place.
-	if !skipRequest {
-		skipRequest = (curTimestamp - lastRequestTime) < requestDelay
-	}
+	skipRequest := !b.rateLimiter.Allow(method, path, requestsPerMin)
 
 	if !skipRequest {
 		var err error
 		switch apiVersion {
 		case bitfinexAPIVersion1:
-			err = b.SendAuthenticatedHTTPRequest(method, path, params, result)
+			err = b.SendAuthenticatedHTTPRequestContext(ctx, method, path, params, result)
 		case bitfinexAPIVersion2:
-			_, err = b.SendAuthenticatedHTTPRequest2(method, path, params, result)
+			_, err = b.SendAuthenticatedHTTPRequest2Context(ctx, method, path, params, result)
 		default:
 			err = errors.New("invalid API version")
 		}
 
 		if err == errRateLimit {
-			b.ipBanStartTime = curTimestamp
+			// If we got IP banned wait 1 min before trying again
+			b.rateLimiter.Ban(time.Minute)
 			skipRequest = true
 		} else if err != nil {
 			return err
-		} else {
-			b.ipBanStartTime = 0
-			b.rateLimits[method+path] = curTimestamp
 		}
 	}
 
@@ -1032,7 +1468,7 @@ func (b *Bitfinex) SendRateLimitedHTTPRequest(requestsPerMin uint, method string
 		} else {
 			reflect.Indirect(rv).Set(dv)
 		}
-		return exchange.WarningHTTPRequestRateLimited()
+		return exchange.NewRateLimitedWarning(b.Name, path, cachedAt)
 	}
 
 	return nil
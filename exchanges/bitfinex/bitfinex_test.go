@@ -8,6 +8,8 @@ import (
 
 	"github.com/mattkanwisher/cryptofiend/common"
 	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
 )
 
 // Please supply your own keys here to do better tests
@@ -264,7 +266,8 @@ func TestWithdrawal(t *testing.T) {
 func TestNewOrder(t *testing.T) {
 	t.Parallel()
 
-	err := b.NewOrder("BTCUSD", 1, 2, true, "market", false)
+	_, err := b.NewOrder(pair.NewCurrencyPair("BTC", "USD"), 1, 2, exchange.OrderSideBuy,
+		exchange.OrderTypeExchangeLimit)
 	if err == nil {
 		t.Error("Test Failed - NewOrder() error")
 	}
@@ -293,7 +296,7 @@ func TestNewOrderMulti(t *testing.T) {
 func TestCancelOrder(t *testing.T) {
 	t.Parallel()
 
-	err := b.CancelOrder("1337")
+	err := b.CancelOrder("1337", pair.NewCurrencyPair("BTC", "USD"))
 	if err == nil {
 		t.Error("Test Failed - CancelOrder() error")
 	}
@@ -469,3 +472,98 @@ func TestCloseMarginFunding(t *testing.T) {
 		t.Error("Test Failed - CloseMarginFunding() error")
 	}
 }
+
+func TestUnmarshalPositionV2(t *testing.T) {
+	t.Parallel()
+	var positions []PositionV2
+	data := `[["tBTCUSD","ACTIVE",-0.5,4800.1,-0.0025,0,-12.5,-0.52,5300.2,null,null,142355652]]`
+	if err := common.JSONDecode([]byte(data), &positions); err != nil {
+		t.Fatalf("Test failed. Unable to decode positions: %s", err)
+	}
+	if len(positions) != 1 {
+		t.Fatalf("Test failed. Expected 1 position but got %d", len(positions))
+	}
+	p := positions[0]
+	if p.Symbol != "tBTCUSD" || p.Status != "ACTIVE" || p.Amount != -0.5 || p.BasePrice != 4800.1 ||
+		p.PL != -12.5 || p.LiquidationPrice != 5300.2 || p.Leverage != 0 || p.ID != 142355652 {
+		t.Errorf("Test failed. Position decoded incorrectly: %+v", p)
+	}
+	v1 := p.toPosition()
+	if v1.Symbol != "btcusd" || v1.ID != 142355652 || v1.Base != 4800.1 {
+		t.Errorf("Test failed. Position converted incorrectly: %+v", v1)
+	}
+}
+
+func TestUnmarshalMarginInfoV2(t *testing.T) {
+	t.Parallel()
+	base := MarginInfoBaseV2{}
+	err := common.JSONDecode([]byte(`["base",[-13.01,0,49331.7,49318.6,27]]`), &base)
+	if err != nil {
+		t.Fatalf("Test failed. Unable to decode base margin info: %s", err)
+	}
+	if base.UserPL != -13.01 || base.MarginBalance != 49331.7 || base.MarginNet != 49318.6 || base.MarginMin != 27 {
+		t.Errorf("Test failed. Base margin info decoded incorrectly: %+v", base)
+	}
+
+	var symbols []MarginInfoSymbolV2
+	err = common.JSONDecode([]byte(`[["sym","tBTCUSD",[149361.1,149639.2,8.8,8.9]]]`), &symbols)
+	if err != nil {
+		t.Fatalf("Test failed. Unable to decode symbol margin info: %s", err)
+	}
+	if len(symbols) != 1 || symbols[0].Symbol != "tBTCUSD" || symbols[0].TradableBalance != 149361.1 ||
+		symbols[0].Buy != 8.8 || symbols[0].Sell != 8.9 {
+		t.Errorf("Test failed. Symbol margin info decoded incorrectly: %+v", symbols)
+	}
+
+	err = common.JSONDecode([]byte(`["sym","tBTCUSD",[1,2,3,4]]`), &base)
+	if err == nil {
+		t.Error("Test failed. Expected an error decoding symbol margin info as base margin info")
+	}
+}
+
+func TestUnmarshalWalletV2(t *testing.T) {
+	t.Parallel()
+	var wallets []WalletV2
+	err := common.JSONDecode([]byte(`[["exchange","BTC",1.5,0,1],["margin","USD",100,0,null]]`), &wallets)
+	if err != nil {
+		t.Fatalf("Test failed. Unable to decode wallets: %s", err)
+	}
+	if len(wallets) != 2 {
+		t.Fatalf("Test failed. Expected 2 wallets but got %d", len(wallets))
+	}
+	if wallets[0].Type != "exchange" || wallets[0].Currency != "BTC" || !wallets[0].HasAvailableBalance {
+		t.Errorf("Test failed. Wallet decoded incorrectly: %+v", wallets[0])
+	}
+	if wallets[1].HasAvailableBalance {
+		t.Error("Test failed. Expected wallet without an available balance")
+	}
+	balance := wallets[1].toBalance()
+	if balance.Type != WalletTypeMargin || balance.Currency != "usd" || balance.Amount.String() != "100" {
+		t.Errorf("Test failed. Wallet converted incorrectly: %+v", balance)
+	}
+	// the unknown available balance mustn't be mistaken for the whole balance
+	if !balance.Available.IsZero() {
+		t.Errorf("Test failed. Expected no available balance, got %s", balance.Available)
+	}
+}
+
+func BenchmarkConvertOrderToExchangeOrder(b *testing.B) {
+	exch := Bitfinex{}
+	exch.SetDefaults()
+	order := Order{
+		ID:              448411153,
+		Symbol:          "btcusd",
+		Price:           6500.5,
+		Side:            "buy",
+		Type:            OrderTypeExchangeLimit,
+		Timestamp:       "1444276570.0",
+		IsLive:          true,
+		OriginalAmount:  1.5,
+		RemainingAmount: 1,
+		ExecutedAmount:  0.5,
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		exch.convertOrderToExchangeOrder(&order)
+	}
+}
@@ -1,6 +1,11 @@
 package bitfinex
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mattkanwisher/cryptofiend/common"
 	"github.com/shopspring/decimal"
 )
 
@@ -259,8 +264,8 @@ type GenericResponse struct {
 // Position holds position information
 type Position struct {
 	ID        int64   `json:"id"`
-	Symbol    string  `json:"string"`
-	Status    string  `json:"active"`
+	Symbol    string  `json:"symbol"`
+	Status    string  `json:"status"`
 	Base      float64 `json:"base,string"`
 	Amount    float64 `json:"amount,string"`
 	Timestamp string  `json:"timestamp"`
@@ -268,6 +273,214 @@ type Position struct {
 	PL        float64 `json:"pl,string"`
 }
 
+// WalletV2 holds wallet balance data returned by the v2 API
+type WalletV2 struct {
+	Type              string
+	Currency          string
+	Balance           decimal.Decimal
+	UnsettledInterest decimal.Decimal
+	// Amount available for orders/withdrawal/transfer, the exchange may not supply this in which
+	// case HasAvailableBalance will be false.
+	AvailableBalance    decimal.Decimal
+	HasAvailableBalance bool
+}
+
+// UnmarshalJSON decodes a v2 wallet array:
+// [WALLET_TYPE, CURRENCY, BALANCE, UNSETTLED_INTEREST, BALANCE_AVAILABLE]
+func (w *WalletV2) UnmarshalJSON(data []byte) error {
+	var available *decimal.Decimal
+	err := unmarshalArrayV2(data, &w.Type, &w.Currency, &w.Balance, &w.UnsettledInterest, &available)
+	if err != nil {
+		return err
+	}
+	if available != nil {
+		w.AvailableBalance = *available
+		w.HasAvailableBalance = true
+	}
+	return nil
+}
+
+// toBalance converts the wallet to a v1 balance, the available amount is zero if the exchange
+// didn't supply it since part of the balance may be reserved by orders or positions.
+func (w *WalletV2) toBalance() Balance {
+	balance := Balance{
+		Type:     WalletType(w.Type),
+		Currency: common.StringToLower(w.Currency),
+		Amount:   w.Balance,
+	}
+	// The v1 API uses different names for the margin & funding wallets
+	switch w.Type {
+	case "margin":
+		balance.Type = WalletTypeMargin
+	case "funding":
+		balance.Type = WalletTypeFunding
+	}
+	if w.HasAvailableBalance {
+		balance.Available = w.AvailableBalance
+	}
+	return balance
+}
+
+// MarginInfoBaseV2 holds account wide margin information returned by the v2 API
+type MarginInfoBaseV2 struct {
+	UserPL        float64
+	UserSwaps     float64
+	MarginBalance float64
+	MarginNet     float64
+	MarginMin     float64
+}
+
+// UnmarshalJSON decodes a v2 base margin info array:
+// ["base", [USER_PL, USER_SWAPS, MARGIN_BALANCE, MARGIN_NET, MARGIN_MIN]]
+func (m *MarginInfoBaseV2) UnmarshalJSON(data []byte) error {
+	var infoType string
+	var info json.RawMessage
+	if err := unmarshalArrayV2(data, &infoType, &info); err != nil {
+		return err
+	}
+	if infoType != "base" {
+		return fmt.Errorf("expected base margin info but got %s", infoType)
+	}
+	return unmarshalArrayV2(info, &m.UserPL, &m.UserSwaps, &m.MarginBalance, &m.MarginNet, &m.MarginMin)
+}
+
+// MarginInfoSymbolV2 holds margin information for a single symbol returned by the v2 API
+type MarginInfoSymbolV2 struct {
+	Symbol          string
+	TradableBalance float64
+	GrossBalance    float64
+	Buy             float64
+	Sell            float64
+}
+
+// UnmarshalJSON decodes a v2 symbol margin info array:
+// ["sym", SYMBOL, [TRADABLE_BALANCE, GROSS_BALANCE, BUY, SELL]]
+func (m *MarginInfoSymbolV2) UnmarshalJSON(data []byte) error {
+	var infoType string
+	var info json.RawMessage
+	if err := unmarshalArrayV2(data, &infoType, &m.Symbol, &info); err != nil {
+		return err
+	}
+	if infoType != "sym" {
+		return fmt.Errorf("expected symbol margin info but got %s", infoType)
+	}
+	return unmarshalArrayV2(info, &m.TradableBalance, &m.GrossBalance, &m.Buy, &m.Sell)
+}
+
+// TradeAverage holds the result of a v2 average execution price calculation
+type TradeAverage struct {
+	Price  float64
+	Amount float64
+}
+
+// UnmarshalJSON decodes a v2 trade average array: [PRICE_AVG, AMOUNT]
+func (t *TradeAverage) UnmarshalJSON(data []byte) error {
+	return unmarshalArrayV2(data, &t.Price, &t.Amount)
+}
+
+// CreditV2 holds the details of funds lent to a margin position, returned by the v2 API
+type CreditV2 struct {
+	ID        int64
+	Symbol    string
+	Side      int   // 1 if the funds were lent, -1 if they were borrowed & 0 for both
+	CreatedAt int64 // Timestamp in milliseconds
+	UpdatedAt int64 // Timestamp in milliseconds
+	Amount    float64
+	Flags     json.RawMessage
+	Status    string
+	Rate      float64 // Daily rate
+	Period    int     // Days
+	OpenedAt  int64   // Timestamp in milliseconds
+	PayoutAt  int64   // Timestamp of the last interest payout in milliseconds
+}
+
+// UnmarshalJSON decodes a v2 credit array:
+// [ID, SYMBOL, SIDE, MTS_CREATE, MTS_UPDATE, AMOUNT, FLAGS, STATUS, _PLACEHOLDER, _PLACEHOLDER,
+// _PLACEHOLDER, RATE, PERIOD, MTS_OPENING, MTS_LAST_PAYOUT, ...]
+func (c *CreditV2) UnmarshalJSON(data []byte) error {
+	var placeholder json.RawMessage
+	return unmarshalArrayV2(data, &c.ID, &c.Symbol, &c.Side, &c.CreatedAt, &c.UpdatedAt, &c.Amount,
+		&c.Flags, &c.Status, &placeholder, &placeholder, &placeholder, &c.Rate, &c.Period, &c.OpenedAt,
+		&c.PayoutAt)
+}
+
+// PositionV2 holds position information returned by the v2 API
+type PositionV2 struct {
+	Symbol            string
+	Status            string
+	Amount            float64
+	BasePrice         float64
+	MarginFunding     float64
+	MarginFundingType int
+	PL                float64
+	PLPercent         float64
+	LiquidationPrice  float64
+	Leverage          float64
+	ID                int64
+}
+
+// UnmarshalJSON decodes a v2 position array:
+// [SYMBOL, STATUS, AMOUNT, BASE_PRICE, MARGIN_FUNDING, MARGIN_FUNDING_TYPE, PL, PL_PERC,
+// PRICE_LIQ, LEVERAGE, _PLACEHOLDER, POSITION_ID, ...]
+func (p *PositionV2) UnmarshalJSON(data []byte) error {
+	var placeholder json.RawMessage
+	return unmarshalArrayV2(data, &p.Symbol, &p.Status, &p.Amount, &p.BasePrice, &p.MarginFunding,
+		&p.MarginFundingType, &p.PL, &p.PLPercent, &p.LiquidationPrice, &p.Leverage, &placeholder, &p.ID)
+}
+
+func (p *PositionV2) toPosition() Position {
+	return Position{
+		ID:     p.ID,
+		Symbol: common.StringToLower(strings.TrimPrefix(p.Symbol, "t")),
+		Status: p.Status,
+		Base:   p.BasePrice,
+		Amount: p.Amount,
+		Swap:   p.MarginFunding,
+		PL:     p.PL,
+	}
+}
+
+// unmarshalArrayV2 decodes the elements of a JSON array into the given fields (in order).
+// Missing trailing elements and null elements leave the corresponding fields untouched, which
+// allows for the exchange appending new elements to responses and omitting optional values.
+func unmarshalArrayV2(data []byte, fields ...interface{}) error {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return err
+	}
+	for i := 0; i < len(fields) && i < len(elements); i++ {
+		if string(elements[i]) == "null" {
+			continue
+		}
+		if err := json.Unmarshal(elements[i], fields[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MethodCurrencies holds a deposit & withdrawal method, and the currencies it's used for
+type MethodCurrencies struct {
+	Method     string
+	Currencies []string
+}
+
+// UnmarshalJSON unmarshals a deposit method from the [method, [currencies]] array format
+func (m *MethodCurrencies) UnmarshalJSON(data []byte) error {
+	return unmarshalArrayV2(data, &m.Method, &m.Currencies)
+}
+
+// CurrencyLabel holds the long name of a currency
+type CurrencyLabel struct {
+	Currency string
+	Label    string
+}
+
+// UnmarshalJSON unmarshals a currency label from the [currency, label] array format
+func (c *CurrencyLabel) UnmarshalJSON(data []byte) error {
+	return unmarshalArrayV2(data, &c.Currency, &c.Label)
+}
+
 // BalanceHistory holds balance history information
 type BalanceHistory struct {
 	Currency    string  `json:"currency"`
@@ -379,6 +592,8 @@ type WebsocketTicker struct {
 	DialyChangePerc float64
 	LastPrice       float64
 	Volume          float64
+	High            float64
+	Low             float64
 }
 
 // WebsocketPosition holds position information
@@ -397,30 +612,49 @@ type WebsocketWallet struct {
 	Currency          string
 	Balance           float64
 	UnsettledInterest float64
+	BalanceAvailable  float64
 }
 
-// WebsocketOrder holds order data
+// WebsocketOrder holds order data, sell orders have negative amounts
 type WebsocketOrder struct {
-	OrderID    int64
-	Pair       string
-	Amount     float64
-	OrigAmount float64
-	OrderType  string
-	Status     string
-	Price      float64
-	PriceAvg   float64
-	Timestamp  string
-	Notify     int
-}
-
-// WebsocketTradeExecuted holds executed trade data
+	OrderID       int64
+	GroupID       int64
+	ClientOrderID int64
+	Symbol        string
+	Created       int64 // milliseconds
+	Updated       int64 // milliseconds
+	Amount        float64
+	OrigAmount    float64
+	OrderType     string
+	Flags         int64
+	Status        string
+	Price         float64
+	PriceAvg      float64
+}
+
+// WebsocketTradeExecuted holds executed trade data, the fee is only set once the trade is settled
 type WebsocketTradeExecuted struct {
 	TradeID        int64
-	Pair           string
-	Timestamp      int64
+	Symbol         string
+	Timestamp      int64 // milliseconds
 	OrderID        int64
 	AmountExecuted float64
 	PriceExecuted  float64
+	OrderType      string
+	OrderPrice     float64
+	Maker          bool
+	Fee            float64
+	FeeCurrency    string
+}
+
+// WebsocketNotification holds the outcome of a request sent over the authenticated websocket
+// channel, Order is set for order requests
+type WebsocketNotification struct {
+	Timestamp int64  // milliseconds
+	Type      string // e.g. "on-req", "oc-req"
+	Status    string // "SUCCESS", "ERROR" or "FAILURE"
+	Text      string
+	Order     *WebsocketOrder
 }
 
 // ErrorCapture is a simple type for returned errors from Bitfinex
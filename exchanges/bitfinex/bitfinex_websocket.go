@@ -1,19 +1,30 @@
 package bitfinex
 
 import (
+	"errors"
+	"fmt"
 	"log"
-	"net/http"
+	"math"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/stream"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+	"github.com/shopspring/decimal"
 )
 
 const (
-	bitfinexWebsocket                   = "wss://api.bitfinex.com/ws"
-	bitfinexWebsocketVersion            = "1.1"
+	bitfinexWebsocket                   = "wss://api.bitfinex.com/ws/2"
+	bitfinexWebsocketVersion            = "2"
 	bitfinexWebsocketPositionSnapshot   = "ps"
 	bitfinexWebsocketPositionNew        = "pn"
 	bitfinexWebsocketPositionUpdate     = "pu"
@@ -25,6 +36,8 @@ const (
 	bitfinexWebsocketOrderUpdate        = "ou"
 	bitfinexWebsocketOrderCancel        = "oc"
 	bitfinexWebsocketTradeExecuted      = "te"
+	bitfinexWebsocketTradeUpdate        = "tu"
+	bitfinexWebsocketNotification       = "n"
 	bitfinexWebsocketHeartbeat          = "hb"
 	bitfinexWebsocketAlertRestarting    = "20051"
 	bitfinexWebsocketAlertRefreshing    = "20060"
@@ -34,8 +47,24 @@ const (
 	bitfinexWebsocketSubscriptionFailed = "10300"
 	bitfinexWebsocketAlreadySubscribed  = "10301"
 	bitfinexWebsocketUnknownChannel     = "10302"
+	// Order flags of the v2 API
+	bitfinexWebsocketFlagHidden = 64
+	// Ping control frames are sent at this interval, the channels heartbeat every 15 seconds
+	bitfinexWebsocketPingInterval = 30 * time.Second
 )
 
+// bitfinexCandleTimeframes maps the candle intervals to the time frames of the candles channel
+var bitfinexCandleTimeframes = map[exchange.CandleInterval]string{
+	exchange.CandleInterval1m:  "1m",
+	exchange.CandleInterval5m:  "5m",
+	exchange.CandleInterval15m: "15m",
+	exchange.CandleInterval30m: "30m",
+	exchange.CandleInterval1h:  "1h",
+	exchange.CandleInterval1d:  "1D",
+}
+
+var errWebsocketNotAuthenticated = errors.New("websocket isn't connected to the authenticated channel")
+
 // WebsocketPingHandler sends a ping request to the websocket server
 func (b *Bitfinex) WebsocketPingHandler() error {
 	request := make(map[string]string)
@@ -46,15 +75,15 @@ func (b *Bitfinex) WebsocketPingHandler() error {
 
 // WebsocketSend sends data to the websocket server
 func (b *Bitfinex) WebsocketSend(data interface{}) error {
-	json, err := common.JSONEncode(data)
-	if err != nil {
-		return err
+	conn := b.websocketConn()
+	if conn == nil {
+		return stream.ErrNotConnected
 	}
-
-	return b.WebsocketConn.WriteMessage(websocket.TextMessage, json)
+	return conn.Send(data)
 }
 
-// WebsocketSubscribe subscribes to the websocket channel
+// WebsocketSubscribe subscribes to the websocket channel, the subscription is renewed every time
+// the websocket reconnects
 func (b *Bitfinex) WebsocketSubscribe(channel string, params map[string]string) error {
 	request := make(map[string]string)
 	request["event"] = "subscribe"
@@ -65,17 +94,28 @@ func (b *Bitfinex) WebsocketSubscribe(channel string, params map[string]string)
 			request[k] = v
 		}
 	}
-	return b.WebsocketSend(request)
+	conn := b.websocketConn()
+	if conn == nil {
+		return stream.ErrNotConnected
+	}
+	key, err := common.JSONEncode(request)
+	if err != nil {
+		return err
+	}
+	return conn.Subscribe(string(key), request)
 }
 
 // WebsocketSendAuth sends a autheticated event payload
 func (b *Bitfinex) WebsocketSendAuth() error {
 	request := make(map[string]interface{})
-	payload := "AUTH" + strconv.FormatInt(time.Now().UnixNano(), 10)[:13]
+	nonce := strconv.FormatInt(time.Now().UnixNano(), 10)[:13]
+	payload := "AUTH" + nonce
 	request["event"] = "auth"
-	request["apiKey"] = b.APIKey
-	request["authSig"] = common.HexEncodeToString(common.GetHMAC(common.HashSHA512_384, []byte(payload), []byte(b.APISecret)))
+	apiKey, apiSecret, _ := b.Credentials()
+	request["apiKey"] = apiKey
+	request["authSig"] = common.HexEncodeToString(common.GetHMAC(common.HashSHA512_384, []byte(payload), []byte(apiSecret)))
 	request["authPayload"] = payload
+	request["authNonce"] = nonce
 
 	return b.WebsocketSend(request)
 }
@@ -94,210 +134,666 @@ func (b *Bitfinex) WebsocketAddSubscriptionChannel(chanID int, channel, pair str
 	chanInfo := WebsocketChanInfo{Pair: pair, Channel: channel}
 	b.WebsocketSubdChannels[chanID] = chanInfo
 
-	if b.Verbose {
+	if b.Debug(exchange.TraceWebsocket) {
 		log.Printf("%s Subscribed to Channel: %s Pair: %s ChannelID: %d\n", b.GetName(), channel, pair, chanID)
 	}
 }
 
-// WebsocketClient makes a connection with the websocket server
+// WebsocketClient makes a connection with the websocket server, it reconnects with an exponential
+// backoff until the websocket is disabled
 func (b *Bitfinex) WebsocketClient() {
-	channels := []string{"book", "trades", "ticker"}
-	for b.Enabled && b.Websocket {
-		var Dialer websocket.Dialer
-		var err error
-		b.WebsocketConn, _, err = Dialer.Dial(bitfinexWebsocket, http.Header{})
+	conn := stream.NewConn(stream.Config{
+		URL:               bitfinexWebsocket,
+		PingInterval:      bitfinexWebsocketPingInterval,
+		StaleTimeout:      b.WebsocketStaleTimeout(),
+		EnableCompression: b.WebsocketCompression(),
+		OnConnect:         b.websocketConnected,
+		OnMessage:         b.websocketMessage,
+		OnStateChange:     b.websocketStateChanged,
+	})
+	b.wsMtx.Lock()
+	b.WebsocketConn = conn
+	b.wsMtx.Unlock()
 
-		if err != nil {
-			log.Printf("%s Unable to connect to Websocket. Error: %s\n", b.GetName(), err)
-			continue
+	channels := []string{"book", "trades", "ticker"}
+	for _, x := range channels {
+		for _, y := range b.EnabledPairs {
+			params := make(map[string]string)
+			if x == "book" {
+				params["prec"] = "P0"
+				params["len"] = "100"
+			}
+			params["symbol"] = "t" + y
+			b.WebsocketSubscribe(x, params)
 		}
+	}
+	b.candles.mtx.Lock()
+	keys := append([]string(nil), b.candles.keys...)
+	b.candles.mtx.Unlock()
+	for _, key := range keys {
+		b.WebsocketSubscribe("candles", map[string]string{"key": key})
+	}
+	conn.Run()
+}
 
-		msgType, resp, err := b.WebsocketConn.ReadMessage()
-		if err != nil {
-			log.Printf("%s Unable to read from Websocket. Error: %s\n", b.GetName(), err)
-			continue
-		}
-		if msgType != websocket.TextMessage {
-			continue
-		}
+// SubscribeCandles returns a channel that receives the candles of the pair & interval as they
+// close, the candles channel is subscribed to the first time the candles are requested & stays
+// subscribed. A candle is closed once the channel updates the next one.
+func (b *Bitfinex) SubscribeCandles(p pair.CurrencyPair, interval exchange.CandleInterval) (<-chan exchange.Candle, exchange.UnsubscribeFunc, error) {
+	timeframe, ok := bitfinexCandleTimeframes[interval]
+	if !ok {
+		return nil, nil, exchange.ErrUnsupportedCandleInterval(b.GetName(), interval)
+	}
+	ch, unsubscribe, err := b.Base.SubscribeCandles(p, interval)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := "trade:" + timeframe + ":t" + b.CurrencyPairToSymbol(p)
 
-		type WebsocketHandshake struct {
-			Event   string  `json:"event"`
-			Code    int64   `json:"code"`
-			Version float64 `json:"version"`
+	b.candles.mtx.Lock()
+	for _, x := range b.candles.keys {
+		if x == key {
+			b.candles.mtx.Unlock()
+			return ch, unsubscribe, nil
 		}
+	}
+	b.candles.keys = append(b.candles.keys, key)
+	b.candles.mtx.Unlock()
+	// The channel is subscribed to by WebsocketClient if the websocket hasn't been set up yet
+	if b.websocketConn() != nil {
+		b.WebsocketSubscribe("candles", map[string]string{"key": key})
+	}
+	return ch, unsubscribe, nil
+}
 
-		hs := WebsocketHandshake{}
-		err = common.JSONDecode(resp, &hs)
-		if err != nil {
-			log.Println(err)
-			continue
+// websocketConnected authenticates a new connection, the channels are subscribed to once it
+// returns
+func (b *Bitfinex) websocketConnected(conn *stream.Conn) error {
+	b.CountWebsocketConnection()
+	if b.Debug(exchange.TraceWebsocket) {
+		log.Printf("%s Connected to Websocket.\n", b.GetName())
+	}
+	if b.AuthenticatedAPISupport {
+		return b.WebsocketSendAuth()
+	}
+	return nil
+}
+
+func (b *Bitfinex) websocketMessage(msgType int, resp []byte) {
+	if !b.Enabled || !b.Websocket {
+		b.websocketConn().Close()
+		return
+	}
+	b.RecordWebsocketFrame(msgType, resp)
+	if err := b.WebsocketHandleMessage(msgType, resp); err != nil {
+		log.Printf("%s Unable to handle Websocket message. Error: %s\n", b.GetName(), err)
+	}
+}
+
+// websocketStateChanged discards the channels & orderbooks of a lost connection, the channel IDs
+// change when the channels are subscribed to again. A stale connection is dropped, the orderbooks
+// are polled until it's re-established.
+func (b *Bitfinex) websocketStateChanged(state stream.State, err error) {
+	switch state {
+	case stream.Connected:
+		b.SetWebsocketStale(false)
+		return
+	case stream.Stale:
+		b.SetWebsocketStale(true)
+		b.resetBooks()
+		b.websocketConn().Reconnect()
+		return
+	case stream.Disconnected, stream.Closed:
+	default:
+		return
+	}
+	b.WebsocketSubdChannels = make(map[int]WebsocketChanInfo)
+	b.wsMtx.Lock()
+	b.wsAuthenticated = false
+	b.wsMtx.Unlock()
+	b.resetBooks()
+	if err != nil {
+		log.Printf("%s Websocket client disconnected. Error: %s\n", b.GetName(), err)
+	} else {
+		log.Printf("%s Websocket client disconnected.\n", b.GetName())
+	}
+}
+
+func (b *Bitfinex) websocketConn() *stream.Conn {
+	b.wsMtx.Lock()
+	defer b.wsMtx.Unlock()
+	return b.WebsocketConn
+}
+
+// WebsocketHandleMessage parses a message received from the websocket server, a malformed message
+// is returned as an error
+func (b *Bitfinex) WebsocketHandleMessage(msgType int, resp []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed message: %v", r)
 		}
+	}()
 
-		if hs.Event == "info" {
-			if b.Verbose {
-				log.Printf("%s Connected to Websocket.\n", b.GetName())
-			}
+	switch msgType {
+	case websocket.TextMessage:
+		var result interface{}
+		if err = common.JSONDecode(resp, &result); err != nil {
+			return err
 		}
 
-		for _, x := range channels {
-			for _, y := range b.EnabledPairs {
-				params := make(map[string]string)
-				if x == "book" {
-					params["prec"] = "P0"
+		switch reflect.TypeOf(result).String() {
+		case "map[string]interface {}":
+			eventData := result.(map[string]interface{})
+			event := eventData["event"]
+
+			switch event {
+			case "subscribed":
+				// v2 subscriptions are made by symbol, the pair is only included for trading pairs.
+				// Candles are subscribed to by key, which is kept instead of the pair.
+				pair, ok := eventData["pair"].(string)
+				if key, isCandles := eventData["key"].(string); isCandles {
+					pair = key
+				} else if !ok {
+					pair = strings.TrimPrefix(eventData["symbol"].(string), "t")
 				}
-				params["pair"] = y
-				b.WebsocketSubscribe(x, params)
-			}
-		}
+				b.WebsocketAddSubscriptionChannel(int(eventData["chanId"].(float64)), eventData["channel"].(string), pair)
+			case "auth":
+				status := eventData["status"].(string)
 
-		if b.AuthenticatedAPISupport {
-			err = b.WebsocketSendAuth()
-			if err != nil {
-				log.Println(err)
+				if status == "OK" {
+					b.WebsocketAddSubscriptionChannel(0, "account", "N/A")
+					b.wsMtx.Lock()
+					b.wsAuthenticated = true
+					b.wsMtx.Unlock()
+				} else if status == "FAILED" || status == "fail" {
+					log.Printf("%s Websocket unable to AUTH. Error: %v\n", b.GetName(), eventData["msg"])
+					b.AuthenticatedAPISupport = false
+				}
 			}
-		}
+		case "[]interface {}":
+			chanData := result.([]interface{})
+			chanID := int(chanData[0].(float64))
+			chanInfo, ok := b.WebsocketSubdChannels[chanID]
 
-		for b.Enabled && b.Websocket {
-			msgType, resp, err := b.WebsocketConn.ReadMessage()
-			if err != nil {
-				log.Println(err)
-				break
+			if !ok {
+				return fmt.Errorf("unable to locate chanID: %d", chanID)
+			}
+			if len(chanData) == 2 {
+				if reflect.TypeOf(chanData[1]).String() == "string" {
+					if chanData[1].(string) == bitfinexWebsocketHeartbeat {
+						return nil
+					}
+				}
 			}
+			switch chanInfo.Channel {
+			case "book":
+				// A snapshot is a list of price levels, an update is a single price level
+				entries := []WebsocketBook{}
+				data := chanData[1].([]interface{})
+				snapshot := len(data) == 0 || reflect.TypeOf(data[0]).String() == "[]interface {}"
+				if snapshot {
+					for _, x := range data {
+						entries = append(entries, websocketBookEntry(x.([]interface{})))
+					}
+				} else {
+					entries = append(entries, websocketBookEntry(data))
+				}
+				if b.Debug(exchange.TraceWebsocket) {
+					log.Printf("Bitfinex %s Websocket Book %v\n", chanInfo.Pair, entries)
+				}
+				return b.handleBookEntries(chanInfo.Pair, entries, snapshot)
+			case "candles":
+				// A snapshot is a list of candles, an update is a single candle
+				candles := []exchange.Candle{}
+				data := chanData[1].([]interface{})
+				if len(data) == 0 || reflect.TypeOf(data[0]).String() == "[]interface {}" {
+					for _, x := range data {
+						candles = append(candles, websocketCandle(x.([]interface{})))
+					}
+				} else {
+					candles = append(candles, websocketCandle(data))
+				}
+				return b.handleCandles(chanInfo.Pair, candles)
+			case "ticker":
+				data := chanData[1].([]interface{})
+				tick := WebsocketTicker{Bid: data[0].(float64), BidSize: data[1].(float64), Ask: data[2].(float64), AskSize: data[3].(float64),
+					DailyChange: data[4].(float64), DialyChangePerc: data[5].(float64), LastPrice: data[6].(float64), Volume: data[7].(float64),
+					High: data[8].(float64), Low: data[9].(float64)}
 
-			switch msgType {
-			case websocket.TextMessage:
-				var result interface{}
-				err := common.JSONDecode(resp, &result)
+				if b.Debug(exchange.TraceWebsocket) {
+					log.Printf("Bitfinex %s Websocket Last %f Volume %f\n", chanInfo.Pair, tick.LastPrice, tick.Volume)
+				}
+				p, err := b.SymbolToCurrencyPair(chanInfo.Pair)
 				if err != nil {
-					log.Println(err)
-					continue
+					return err
 				}
+				b.PublishTicker(p, ticker.Price{Pair: p, Last: tick.LastPrice, High: tick.High, Low: tick.Low,
+					Bid: tick.Bid, Ask: tick.Ask, Volume: tick.Volume, LastUpdated: time.Now()})
+			case "account":
+				event := chanData[1].(string)
+				switch event {
+				case bitfinexWebsocketPositionSnapshot:
+					positionSnapshot := []WebsocketPosition{}
+					data := chanData[2].([]interface{})
+					for _, x := range data {
+						positionSnapshot = append(positionSnapshot, websocketPosition(x.([]interface{})))
+					}
+					log.Println(positionSnapshot)
+				case bitfinexWebsocketPositionNew, bitfinexWebsocketPositionUpdate, bitfinexWebsocketPositionClose:
+					log.Println(websocketPosition(chanData[2].([]interface{})))
+				case bitfinexWebsocketWalletSnapshot:
+					data := chanData[2].([]interface{})
+					walletSnapshot := []WebsocketWallet{}
+					for _, x := range data {
+						walletSnapshot = append(walletSnapshot, websocketWallet(x.([]interface{})))
+					}
+					log.Println(walletSnapshot)
+				case bitfinexWebsocketWalletUpdate:
+					log.Println(websocketWallet(chanData[2].([]interface{})))
+				case bitfinexWebsocketOrderSnapshot:
+					orderSnapshot := []WebsocketOrder{}
+					data := chanData[2].([]interface{})
+					for _, x := range data {
+						orderSnapshot = append(orderSnapshot, websocketOrder(x.([]interface{})))
+					}
+					for _, order := range orderSnapshot {
+						b.websocketOrderEvent(event, order)
+					}
+				case bitfinexWebsocketOrderNew, bitfinexWebsocketOrderUpdate, bitfinexWebsocketOrderCancel:
+					b.websocketOrderEvent(event, websocketOrder(chanData[2].([]interface{})))
+				case bitfinexWebsocketTradeExecuted, bitfinexWebsocketTradeUpdate:
+					data := chanData[2].([]interface{})
+					trade := WebsocketTradeExecuted{TradeID: int64(data[0].(float64)), Symbol: data[1].(string), Timestamp: int64(data[2].(float64)),
+						OrderID: int64(data[3].(float64)), AmountExecuted: data[4].(float64), PriceExecuted: data[5].(float64),
+						OrderType: data[6].(string), OrderPrice: data[7].(float64), Maker: data[8].(float64) == 1}
+					// The fee is only known once the trade is settled
+					if event == bitfinexWebsocketTradeUpdate {
+						trade.Fee = data[9].(float64)
+						trade.FeeCurrency = data[10].(string)
+					}
+					if b.Debug(exchange.TraceWebsocket) {
+						log.Printf("%s Websocket %s %+v\n", b.GetName(), event, trade)
+					}
+					if b.OnWebsocketTrade != nil {
+						b.OnWebsocketTrade(event, trade)
+					}
+				case bitfinexWebsocketNotification:
+					data := chanData[2].([]interface{})
+					notification := WebsocketNotification{Timestamp: int64(data[0].(float64)), Type: data[1].(string),
+						Status: data[6].(string), Text: data[7].(string)}
+					// Order request notifications hold the order that was submitted or cancelled
+					if info, ok := data[4].([]interface{}); ok && strings.HasPrefix(notification.Type, "o") {
+						order := websocketOrder(info)
+						notification.Order = &order
+					}
+					if b.Debug(exchange.TraceWebsocket) {
+						log.Printf("%s Websocket notification %s %s: %s\n", b.GetName(), notification.Type, notification.Status, notification.Text)
+					}
+					if b.OnWebsocketNotification != nil {
+						b.OnWebsocketNotification(notification)
+					}
+				}
+			case "trades":
+				trades := []WebsocketTrade{}
+				switch len(chanData) {
+				case 2:
+					data := chanData[1].([]interface{})
+					for _, x := range data {
+						trades = append(trades, websocketTrade(x.([]interface{})))
+					}
+				case 3:
+					// Trades are pushed when executed and again once settled
+					if chanData[1].(string) != bitfinexWebsocketTradeExecuted {
+						return nil
+					}
+					trade := websocketTrade(chanData[2].([]interface{}))
+					trades = append(trades, trade)
 
-				switch reflect.TypeOf(result).String() {
-				case "map[string]interface {}":
-					eventData := result.(map[string]interface{})
-					event := eventData["event"]
-
-					switch event {
-					case "subscribed":
-						b.WebsocketAddSubscriptionChannel(int(eventData["chanId"].(float64)), eventData["channel"].(string), eventData["pair"].(string))
-					case "auth":
-						status := eventData["status"].(string)
-
-						if status == "OK" {
-							b.WebsocketAddSubscriptionChannel(0, "account", "N/A")
-						} else if status == "fail" {
-							log.Printf("%s Websocket unable to AUTH. Error code: %s\n", b.GetName(), eventData["code"].(string))
-							b.AuthenticatedAPISupport = false
-						}
+					if b.Debug(exchange.TraceWebsocket) {
+						log.Printf("Bitfinex %s Websocket Trade ID %d Timestamp %d Price %f Amount %f\n", chanInfo.Pair, trade.ID, trade.Timestamp, trade.Price, trade.Amount)
+					}
+					p, err := b.SymbolToCurrencyPair(chanInfo.Pair)
+					if err != nil {
+						return err
 					}
-				case "[]interface {}":
-					chanData := result.([]interface{})
-					chanID := int(chanData[0].(float64))
-					chanInfo, ok := b.WebsocketSubdChannels[chanID]
-
-					if !ok {
-						log.Printf("Unable to locate chanID: %d\n", chanID)
-					} else {
-						if len(chanData) == 2 {
-							if reflect.TypeOf(chanData[1]).String() == "string" {
-								if chanData[1].(string) == bitfinexWebsocketHeartbeat {
-									continue
-								}
-							}
-						}
-						switch chanInfo.Channel {
-						case "book":
-							orderbook := []WebsocketBook{}
-							switch len(chanData) {
-							case 2:
-								data := chanData[1].([]interface{})
-								for _, x := range data {
-									y := x.([]interface{})
-									orderbook = append(orderbook, WebsocketBook{Price: y[0].(float64), Count: int(y[1].(float64)), Amount: y[2].(float64)})
-								}
-							case 4:
-								orderbook = append(orderbook, WebsocketBook{Price: chanData[1].(float64), Count: int(chanData[2].(float64)), Amount: chanData[3].(float64)})
-							}
-							log.Println(orderbook)
-						case "ticker":
-							ticker := WebsocketTicker{Bid: chanData[1].(float64), BidSize: chanData[2].(float64), Ask: chanData[3].(float64), AskSize: chanData[4].(float64),
-								DailyChange: chanData[5].(float64), DialyChangePerc: chanData[6].(float64), LastPrice: chanData[7].(float64), Volume: chanData[8].(float64)}
-
-							log.Printf("Bitfinex %s Websocket Last %f Volume %f\n", chanInfo.Pair, ticker.LastPrice, ticker.Volume)
-						case "account":
-							switch chanData[1].(string) {
-							case bitfinexWebsocketPositionSnapshot:
-								positionSnapshot := []WebsocketPosition{}
-								data := chanData[2].([]interface{})
-								for _, x := range data {
-									y := x.([]interface{})
-									positionSnapshot = append(positionSnapshot, WebsocketPosition{Pair: y[0].(string), Status: y[1].(string), Amount: y[2].(float64), Price: y[3].(float64),
-										MarginFunding: y[4].(float64), MarginFundingType: int(y[5].(float64))})
-								}
-								log.Println(positionSnapshot)
-							case bitfinexWebsocketPositionNew, bitfinexWebsocketPositionUpdate, bitfinexWebsocketPositionClose:
-								data := chanData[2].([]interface{})
-								position := WebsocketPosition{Pair: data[0].(string), Status: data[1].(string), Amount: data[2].(float64), Price: data[3].(float64),
-									MarginFunding: data[4].(float64), MarginFundingType: int(data[5].(float64))}
-								log.Println(position)
-							case bitfinexWebsocketWalletSnapshot:
-								data := chanData[2].([]interface{})
-								walletSnapshot := []WebsocketWallet{}
-								for _, x := range data {
-									y := x.([]interface{})
-									walletSnapshot = append(walletSnapshot, WebsocketWallet{Name: y[0].(string), Currency: y[1].(string), Balance: y[2].(float64), UnsettledInterest: y[3].(float64)})
-								}
-								log.Println(walletSnapshot)
-							case bitfinexWebsocketWalletUpdate:
-								data := chanData[2].([]interface{})
-								wallet := WebsocketWallet{Name: data[0].(string), Currency: data[1].(string), Balance: data[2].(float64), UnsettledInterest: data[3].(float64)}
-								log.Println(wallet)
-							case bitfinexWebsocketOrderSnapshot:
-								orderSnapshot := []WebsocketOrder{}
-								data := chanData[2].([]interface{})
-								for _, x := range data {
-									y := x.([]interface{})
-									orderSnapshot = append(orderSnapshot, WebsocketOrder{OrderID: int64(y[0].(float64)), Pair: y[1].(string), Amount: y[2].(float64), OrigAmount: y[3].(float64),
-										OrderType: y[4].(string), Status: y[5].(string), Price: y[6].(float64), PriceAvg: y[7].(float64), Timestamp: y[8].(string)})
-								}
-								log.Println(orderSnapshot)
-							case bitfinexWebsocketOrderNew, bitfinexWebsocketOrderUpdate, bitfinexWebsocketOrderCancel:
-								data := chanData[2].([]interface{})
-								order := WebsocketOrder{OrderID: int64(data[0].(float64)), Pair: data[1].(string), Amount: data[2].(float64), OrigAmount: data[3].(float64),
-									OrderType: data[4].(string), Status: data[5].(string), Price: data[6].(float64), PriceAvg: data[7].(float64), Timestamp: data[8].(string), Notify: int(data[9].(float64))}
-								log.Println(order)
-							case bitfinexWebsocketTradeExecuted:
-								data := chanData[2].([]interface{})
-								trade := WebsocketTradeExecuted{TradeID: int64(data[0].(float64)), Pair: data[1].(string), Timestamp: int64(data[2].(float64)), OrderID: int64(data[3].(float64)),
-									AmountExecuted: data[4].(float64), PriceExecuted: data[5].(float64)}
-								log.Println(trade)
-							}
-						case "trades":
-							trades := []WebsocketTrade{}
-							switch len(chanData) {
-							case 2:
-								data := chanData[1].([]interface{})
-								for _, x := range data {
-									y := x.([]interface{})
-									trades = append(trades, WebsocketTrade{ID: int64(y[0].(float64)), Timestamp: int64(y[1].(float64)), Price: y[2].(float64), Amount: y[3].(float64)})
-								}
-							case 5:
-								trade := WebsocketTrade{ID: int64(chanData[1].(float64)), Timestamp: int64(chanData[2].(float64)), Price: chanData[3].(float64), Amount: chanData[4].(float64)}
-								trades = append(trades, trade)
-
-								if b.Verbose {
-									log.Printf("Bitfinex %s Websocket Trade ID %d Timestamp %d Price %f Amount %f\n", chanInfo.Pair, trade.ID, trade.Timestamp, trade.Price, trade.Amount)
-								}
-							}
-							log.Println(trades)
-						}
+					// The amount of a trade is negative if the taker sold
+					side := exchange.OrderSideBuy
+					if trade.Amount < 0 {
+						side = exchange.OrderSideSell
 					}
+					b.PublishTrade(exchange.Trade{
+						ID:           strconv.FormatInt(trade.ID, 10),
+						Exchange:     b.Name,
+						CurrencyPair: p,
+						Side:         side,
+						Amount:       math.Abs(trade.Amount),
+						Price:        trade.Price,
+						Time:         time.Unix(0, trade.Timestamp*int64(time.Millisecond)),
+					})
 				}
 			}
 		}
-		b.WebsocketConn.Close()
-		log.Printf("%s Websocket client disconnected.\n", b.GetName())
 	}
+	return nil
+}
+
+// handleBookEntries updates the local orderbook of the pair & publishes it, a snapshot replaces
+// the orderbook
+func (b *Bitfinex) handleBookEntries(symbol string, entries []WebsocketBook, snapshot bool) error {
+	p, err := b.SymbolToCurrencyPair(symbol)
+	if err != nil {
+		return err
+	}
+	b.books.mtx.Lock()
+	defer b.books.mtx.Unlock()
+	book := b.books.pairs[symbol]
+	if snapshot {
+		if b.books.pairs == nil {
+			b.books.pairs = make(map[string]*websocketBook)
+		}
+		book = &websocketBook{bids: make(map[float64]float64), asks: make(map[float64]float64)}
+		b.books.pairs[symbol] = book
+	} else if book == nil {
+		return fmt.Errorf("%s orderbook update received before the snapshot", symbol)
+	}
+	for _, entry := range entries {
+		book.apply(entry)
+	}
+	b.Orderbooks.ProcessOrderbook(b.Name, p, book.orderbook(), ticker.Spot)
+	b.PublishOrderbook(p, book.orderbook())
+	return nil
+}
+
+// streamedOrderbook returns the orderbook maintained by the websocket client, an error is
+// returned if the orderbook of the pair isn't streamed
+func (b *Bitfinex) streamedOrderbook(p pair.CurrencyPair) (orderbook.Base, error) {
+	b.books.mtx.Lock()
+	book := b.books.pairs[b.CurrencyPairToSymbol(p)]
+	b.books.mtx.Unlock()
+	if book == nil {
+		return orderbook.Base{}, errors.New("orderbook isn't streamed")
+	}
+	return b.Orderbooks.GetOrderbook(b.Name, p, ticker.Spot)
+}
+
+// resetBooks discards the streamed orderbooks, they're polled again until the websocket client
+// reconnects
+func (b *Bitfinex) resetBooks() {
+	b.books.mtx.Lock()
+	defer b.books.mtx.Unlock()
+	b.books.pairs = nil
+}
+
+// handleCandles updates the current candle of the key & publishes the candles closed by the
+// update. The candles of the first snapshot are history, only the current one is kept, while a
+// snapshot received after reconnecting fills in the candles closed in the meantime.
+func (b *Bitfinex) handleCandles(key string, candles []exchange.Candle) error {
+	// The key is "trade:<time frame>:t<symbol>"
+	parts := strings.SplitN(key, ":", 3)
+	if len(parts) != 3 {
+		return fmt.Errorf("unexpected candles key %s", key)
+	}
+	var interval exchange.CandleInterval
+	for i, timeframe := range bitfinexCandleTimeframes {
+		if timeframe == parts[1] {
+			interval = i
+		}
+	}
+	if interval == 0 {
+		return fmt.Errorf("unexpected candles key %s", key)
+	}
+	p, err := b.SymbolToCurrencyPair(strings.TrimPrefix(parts[2], "t"))
+	if err != nil {
+		return err
+	}
+	if len(candles) == 0 {
+		return nil
+	}
+	sort.Slice(candles, func(i, j int) bool { return candles[i].Timestamp.Before(candles[j].Timestamp) })
+
+	var closed []exchange.Candle
+	b.candles.mtx.Lock()
+	if b.candles.current == nil {
+		b.candles.current = make(map[string]exchange.Candle)
+	}
+	current, tracked := b.candles.current[key]
+	if !tracked {
+		candles = candles[len(candles)-1:]
+	}
+	for _, c := range candles {
+		if tracked && c.Timestamp.Before(current.Timestamp) {
+			continue
+		}
+		if tracked && c.Timestamp.After(current.Timestamp) {
+			closed = append(closed, current)
+		}
+		current, tracked = c, true
+	}
+	b.candles.current[key] = current
+	b.candles.mtx.Unlock()
+
+	for _, c := range closed {
+		b.PublishCandle(p, interval, c)
+	}
+	return nil
+}
+
+func (b *Bitfinex) websocketOrderEvent(event string, order WebsocketOrder) {
+	if b.Debug(exchange.TraceWebsocket) {
+		log.Printf("%s Websocket %s %+v\n", b.GetName(), event, order)
+	}
+	if b.OnWebsocketOrder != nil {
+		b.OnWebsocketOrder(event, order)
+	}
+	b.PublishOrder(*b.convertWebsocketOrder(&order))
+}
+
+// convertWebsocketOrder converts an order of the authenticated channel, the status of inactive
+// orders starts with "EXECUTED" or "CANCELED" and may be followed by the fills
+func (b *Bitfinex) convertWebsocketOrder(order *WebsocketOrder) *exchange.Order {
+	retOrder := &exchange.Order{}
+	retOrder.OrderID = strconv.FormatInt(order.OrderID, 10)
+
+	switch {
+	case strings.HasPrefix(order.Status, "EXECUTED"):
+		retOrder.Status = exchange.OrderStatusFilled
+	case strings.HasPrefix(order.Status, "CANCELED"):
+		retOrder.Status = exchange.OrderStatusAborted
+	case strings.HasPrefix(order.Status, "ACTIVE"), strings.HasPrefix(order.Status, "PARTIALLY FILLED"):
+		retOrder.Status = exchange.OrderStatusActive
+	default:
+		retOrder.Status = exchange.OrderStatusUnknown
+	}
+
+	retOrder.Side = exchange.OrderSideBuy
+	if order.OrigAmount < 0 {
+		retOrder.Side = exchange.OrderSideSell
+	}
+	retOrder.Amount = math.Abs(order.OrigAmount)
+	retOrder.RemainingAmount = math.Abs(order.Amount)
+	retOrder.FilledAmount, _ = decimal.NewFromFloat(retOrder.Amount).Sub(decimal.NewFromFloat(retOrder.RemainingAmount)).Float64()
+	retOrder.Rate = order.Price
+	if retOrder.Status != exchange.OrderStatusActive && order.PriceAvg != 0 {
+		retOrder.Rate = order.PriceAvg
+	}
+	retOrder.CreatedAt = order.Created / 1000 // milliseconds
+	retOrder.CurrencyPair, _ = b.SymbolToCurrencyPair(strings.TrimPrefix(order.Symbol, "t"))
+	switch order.OrderType {
+	case "EXCHANGE LIMIT":
+		retOrder.Type = exchange.OrderTypeExchangeLimit
+	case "LIMIT":
+		retOrder.Type = exchange.OrderTypeMarginLimit
+	}
+	return retOrder
+}
+
+// NewOrderWS submits an order over the authenticated websocket channel & returns its client order
+// ID. The order ID is only known once the order is acknowledged, OnWebsocketNotification is called
+// with the outcome of the request & OnWebsocketOrder with the new order.
+func (b *Bitfinex) NewOrderWS(currencyPair pair.CurrencyPair, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (int64, error) {
+	if err := b.GetCapabilities().CheckOrderOptions(opts...); err != nil {
+		return 0, err
+	}
+	if !b.isWebsocketAuthenticated() {
+		return 0, errWebsocketNotAuthenticated
+	}
+
+	var bitfinexOrderType string
+	switch orderType {
+	case exchange.OrderTypeMarginLimit:
+		bitfinexOrderType = "LIMIT"
+	case exchange.OrderTypeExchangeLimit:
+		bitfinexOrderType = "EXCHANGE LIMIT"
+	default:
+		return 0, fmt.Errorf("'%s' order type not currently supported for this exchange", string(orderType))
+	}
+	// The v2 API sells negative amounts
+	if side == exchange.OrderSideSell {
+		amount = -amount
+	}
+	flags := 0
+	for _, o := range opts {
+		if o.Hidden {
+			flags |= bitfinexWebsocketFlagHidden
+		}
+	}
+
+	clientOrderID := b.nextClientOrderID()
+	order := map[string]interface{}{
+		"cid":    clientOrderID,
+		"type":   bitfinexOrderType,
+		"symbol": "t" + b.CurrencyPairToSymbol(currencyPair),
+		"amount": strconv.FormatFloat(amount, 'f', -1, 64),
+		"price":  strconv.FormatFloat(price, 'f', -1, 64),
+	}
+	if flags != 0 {
+		order["flags"] = flags
+	}
+	if err := b.WebsocketSend([]interface{}{0, bitfinexWebsocketOrderNew, nil, order}); err != nil {
+		return 0, err
+	}
+	return clientOrderID, nil
+}
+
+// CancelOrderWS cancels an order over the authenticated websocket channel, OnWebsocketNotification
+// is called with the outcome of the request & OnWebsocketOrder with the cancelled order.
+func (b *Bitfinex) CancelOrderWS(orderID int64) error {
+	if !b.isWebsocketAuthenticated() {
+		return errWebsocketNotAuthenticated
+	}
+	return b.WebsocketSend([]interface{}{0, bitfinexWebsocketOrderCancel, nil, map[string]int64{"id": orderID}})
+}
+
+func (b *Bitfinex) isWebsocketAuthenticated() bool {
+	b.wsMtx.Lock()
+	defer b.wsMtx.Unlock()
+	state := stream.Disconnected
+	if b.WebsocketConn != nil {
+		state = b.WebsocketConn.State()
+	}
+	return b.wsAuthenticated && (state == stream.Connected || state == stream.Stale)
+}
+
+// nextClientOrderID returns a millisecond timestamp that's unique to this connection, Bitfinex
+// only requires client order IDs to be unique within a day.
+func (b *Bitfinex) nextClientOrderID() int64 {
+	b.wsMtx.Lock()
+	defer b.wsMtx.Unlock()
+	clientOrderID := time.Now().UnixNano() / int64(time.Millisecond)
+	if clientOrderID <= b.wsLastClientOrderID {
+		clientOrderID = b.wsLastClientOrderID + 1
+	}
+	b.wsLastClientOrderID = clientOrderID
+	return clientOrderID
+}
+
+// websocketBooks holds the orderbooks maintained from the book channel, by symbol
+type websocketBooks struct {
+	mtx   sync.Mutex
+	pairs map[string]*websocketBook
+}
+
+// websocketCandles holds the keys of the candles subscribed to & the current candle of each key
+type websocketCandles struct {
+	mtx     sync.Mutex
+	keys    []string
+	current map[string]exchange.Candle
+}
+
+// websocketBook holds the amount of each price level of an orderbook
+type websocketBook struct {
+	bids map[float64]float64
+	asks map[float64]float64
+}
+
+// apply updates a price level, bids have positive amounts & asks negative amounts. A level without
+// orders is removed, its amount is 1 for bids and -1 for asks.
+func (w *websocketBook) apply(entry WebsocketBook) {
+	levels := w.bids
+	if entry.Amount < 0 {
+		levels = w.asks
+	}
+	if entry.Count == 0 {
+		delete(levels, entry.Price)
+	} else {
+		levels[entry.Price] = math.Abs(entry.Amount)
+	}
+}
+
+// orderbook returns the bids sorted from highest to lowest & the asks from lowest to highest
+func (w *websocketBook) orderbook() orderbook.Base {
+	book := orderbook.Base{
+		Bids: orderbook.GetItems(len(w.bids)),
+		Asks: orderbook.GetItems(len(w.asks)),
+	}
+	for price, amount := range w.bids {
+		book.Bids = append(book.Bids, orderbook.Item{Price: price, Amount: amount})
+	}
+	for price, amount := range w.asks {
+		book.Asks = append(book.Asks, orderbook.Item{Price: price, Amount: amount})
+	}
+	sort.Slice(book.Bids, func(i, j int) bool { return book.Bids[i].Price > book.Bids[j].Price })
+	sort.Slice(book.Asks, func(i, j int) bool { return book.Asks[i].Price < book.Asks[j].Price })
+	return book
+}
+
+// wsNumber returns a number of a v2 message, the fields that don't apply are null
+func wsNumber(v interface{}) float64 {
+	if v == nil {
+		return 0
+	}
+	return v.(float64)
+}
+
+func websocketBookEntry(data []interface{}) WebsocketBook {
+	return WebsocketBook{Price: data[0].(float64), Count: int(data[1].(float64)), Amount: data[2].(float64)}
+}
+
+// websocketCandle converts a candle of the candles channel, [MTS, OPEN, CLOSE, HIGH, LOW, VOLUME]
+func websocketCandle(data []interface{}) exchange.Candle {
+	return exchange.Candle{Timestamp: time.Unix(0, int64(data[0].(float64))*int64(time.Millisecond)),
+		Open: data[1].(float64), Close: data[2].(float64), High: data[3].(float64), Low: data[4].(float64),
+		Volume: data[5].(float64)}
+}
+
+func websocketTrade(data []interface{}) WebsocketTrade {
+	return WebsocketTrade{ID: int64(data[0].(float64)), Timestamp: int64(data[1].(float64)), Amount: data[2].(float64), Price: data[3].(float64)}
+}
+
+func websocketPosition(data []interface{}) WebsocketPosition {
+	return WebsocketPosition{Pair: data[0].(string), Status: data[1].(string), Amount: data[2].(float64), Price: data[3].(float64),
+		MarginFunding: wsNumber(data[4]), MarginFundingType: int(wsNumber(data[5]))}
+}
+
+func websocketWallet(data []interface{}) WebsocketWallet {
+	return WebsocketWallet{Name: data[0].(string), Currency: data[1].(string), Balance: data[2].(float64),
+		UnsettledInterest: wsNumber(data[3]), BalanceAvailable: wsNumber(data[4])}
+}
+
+func websocketOrder(data []interface{}) WebsocketOrder {
+	return WebsocketOrder{OrderID: int64(data[0].(float64)), GroupID: int64(wsNumber(data[1])), ClientOrderID: int64(wsNumber(data[2])),
+		Symbol: data[3].(string), Created: int64(data[4].(float64)), Updated: int64(data[5].(float64)), Amount: data[6].(float64),
+		OrigAmount: data[7].(float64), OrderType: data[8].(string), Flags: int64(wsNumber(data[12])), Status: data[13].(string),
+		Price: data[16].(float64), PriceAvg: wsNumber(data[17])}
 }
@@ -2,62 +2,74 @@ package bitfinex
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/stream"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+	"github.com/mattkanwisher/cryptofiend/exchanges/wsrecord"
 )
 
+// connectTestWebsocket connects the exchange to the websocket server at url, the connection is
+// closed by the returned func
+func connectTestWebsocket(t *testing.T, b *Bitfinex, url string) func() {
+	connected := make(chan struct{})
+	var once sync.Once
+	b.WebsocketConn = stream.NewConn(stream.Config{
+		URL: url,
+		OnStateChange: func(state stream.State, err error) {
+			if state == stream.Connected {
+				once.Do(func() { close(connected) })
+			}
+		},
+	})
+	go b.WebsocketConn.Run()
+	select {
+	case <-connected:
+	case <-time.After(10 * time.Second):
+		t.Errorf("Test Failed - Bitfinex unable to connect to %s", url)
+	}
+	return b.WebsocketConn.Close
+}
+
 func TestWebsocketPingHandler(t *testing.T) {
 	wsPingHandler := Bitfinex{}
-	var Dialer websocket.Dialer
-	var err error
-
-	wsPingHandler.WebsocketConn, _, err = Dialer.Dial(bitfinexWebsocket, http.Header{})
-	if err != nil {
-		t.Errorf("Test Failed - Bitfinex dialer error: %s", err)
+	if err := wsPingHandler.WebsocketPingHandler(); err != stream.ErrNotConnected {
+		t.Errorf("Test Failed - Bitfinex WebsocketPingHandler() expected ErrNotConnected, got %v", err)
 	}
-	err = wsPingHandler.WebsocketPingHandler()
+	defer connectTestWebsocket(t, &wsPingHandler, bitfinexWebsocket)()
+
+	err := wsPingHandler.WebsocketPingHandler()
 	if err != nil {
 		t.Errorf("Test Failed - Bitfinex WebsocketPingHandler() error: %s", err)
 	}
-	err = wsPingHandler.WebsocketConn.Close()
-	if err != nil {
-		t.Errorf("Test Failed - Bitfinex websocketConn.Close() error: %s", err)
-	}
 }
 
 func TestWebsocketSubscribe(t *testing.T) {
 	websocketSubcribe := Bitfinex{}
-	var Dialer websocket.Dialer
-	var err error
 	params := make(map[string]string)
 	params["pair"] = "BTCUSD"
+	defer connectTestWebsocket(t, &websocketSubcribe, bitfinexWebsocket)()
 
-	websocketSubcribe.WebsocketConn, _, err = Dialer.Dial(bitfinexWebsocket, http.Header{})
-	if err != nil {
-		t.Errorf("Test Failed - Bitfinex Dialer error: %s", err)
-	}
-	err = websocketSubcribe.WebsocketSubscribe("ticker", params)
+	err := websocketSubcribe.WebsocketSubscribe("ticker", params)
 	if err != nil {
 		t.Errorf("Test Failed - Bitfinex WebsocketSubscribe() error: %s", err)
 	}
-
-	err = websocketSubcribe.WebsocketConn.Close()
-	if err != nil {
-		t.Errorf("Test Failed - Bitfinex websocketConn.Close() error: %s", err)
-	}
 }
 
 func TestWebsocketSendAuth(t *testing.T) {
 	wsSendAuth := Bitfinex{}
-	var Dialer websocket.Dialer
-	var err error
+	defer connectTestWebsocket(t, &wsSendAuth, bitfinexWebsocket)()
 
-	wsSendAuth.WebsocketConn, _, err = Dialer.Dial(bitfinexWebsocket, http.Header{})
-	if err != nil {
-		t.Errorf("Test Failed - Bitfinex Dialer error: %s", err)
-	}
-	err = wsSendAuth.WebsocketSendAuth()
+	err := wsSendAuth.WebsocketSendAuth()
 	if err != nil {
 		t.Errorf("Test Failed - Bitfinex WebsocketSendAuth() error: %s", err)
 	}
@@ -66,22 +78,278 @@ func TestWebsocketSendAuth(t *testing.T) {
 func TestWebsocketAddSubscriptionChannel(t *testing.T) {
 	wsAddSubscriptionChannel := Bitfinex{}
 	wsAddSubscriptionChannel.SetDefaults()
-	var Dialer websocket.Dialer
-	var err error
-
-	wsAddSubscriptionChannel.WebsocketConn, _, err = Dialer.Dial(bitfinexWebsocket, http.Header{})
-	if err != nil {
-		t.Errorf("Test Failed - Bitfinex Dialer error: %s", err)
-	}
 
 	wsAddSubscriptionChannel.WebsocketAddSubscriptionChannel(1337, "ticker", "BTCUSD")
 	if len(wsAddSubscriptionChannel.WebsocketSubdChannels) == 0 {
-		t.Errorf("Test Failed - Bitfinex WebsocketAddSubscriptionChannel() error: %s", err)
+		t.Error("Test Failed - Bitfinex WebsocketAddSubscriptionChannel() no channels added")
 	}
 	if wsAddSubscriptionChannel.WebsocketSubdChannels[1337].Channel != "ticker" {
-		t.Errorf("Test Failed - Bitfinex WebsocketAddSubscriptionChannel() error: %s", err)
+		t.Error("Test Failed - Bitfinex WebsocketAddSubscriptionChannel() incorrect channel")
 	}
 	if wsAddSubscriptionChannel.WebsocketSubdChannels[1337].Pair != "BTCUSD" {
-		t.Errorf("Test Failed - Bitfinex WebsocketAddSubscriptionChannel() error: %s", err)
+		t.Error("Test Failed - Bitfinex WebsocketAddSubscriptionChannel() incorrect pair")
+	}
+}
+
+func TestWebsocketReplay(t *testing.T) {
+	frames, err := wsrecord.ReadFile("testdata/websocket_session.jsonl")
+	if err != nil {
+		t.Fatalf("Test failed. Unable to read the recorded session: %s", err)
+	}
+	b := Bitfinex{}
+	b.SetDefaults()
+	result := wsrecord.Replay(frames, "Bitfinex", func(f wsrecord.Frame) error {
+		return b.WebsocketHandleMessage(f.Type, []byte(f.Data))
+	})
+	if result.Frames != len(frames) {
+		t.Errorf("Test failed. Expected %d frames to be replayed, got %d", len(frames), result.Frames)
+	}
+	// book update received before the subscription, malformed ticker, truncated ticker, and
+	// malformed trade
+	expected := []int{3, 8, 9, 11}
+	if len(result.Errors) != len(expected) {
+		t.Fatalf("Test failed. Expected %d errors, got %v", len(expected), result.Errors)
+	}
+	for i, index := range expected {
+		if result.Errors[i].Index != index {
+			t.Errorf("Test failed. Expected frame %d to fail, got %v", index, result.Errors[i])
+		}
+	}
+	if len(b.WebsocketSubdChannels) != 3 || b.WebsocketSubdChannels[5].Channel != "book" {
+		t.Errorf("Test failed. Unexpected subscriptions %v", b.WebsocketSubdChannels)
+	}
+}
+
+// newTestWebsocketServer returns a websocket server that forwards the received messages
+func newTestWebsocketServer(received chan<- []interface{}) *httptest.Server {
+	var upgrader websocket.Upgrader
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, resp, err := conn.ReadMessage()
+			if err != nil {
+				close(received)
+				return
+			}
+			var msg []interface{}
+			common.JSONDecode(resp, &msg)
+			received <- msg
+		}
+	}))
+}
+
+func TestWebsocketAuthenticatedChannel(t *testing.T) {
+	received := make(chan []interface{}, 10)
+	server := newTestWebsocketServer(received)
+	defer server.Close()
+
+	b := Bitfinex{}
+	b.SetDefaults()
+	var orders []string
+	var trades []WebsocketTradeExecuted
+	var notifications []WebsocketNotification
+	b.OnWebsocketOrder = func(event string, order WebsocketOrder) {
+		orders = append(orders, event+" "+order.Status)
+	}
+	b.OnWebsocketTrade = func(event string, trade WebsocketTradeExecuted) {
+		trades = append(trades, trade)
+	}
+	b.OnWebsocketNotification = func(notification WebsocketNotification) {
+		notifications = append(notifications, notification)
+	}
+	p := pair.NewCurrencyPair("BTC", "USD")
+	b.Websocket = true
+	b.AuthenticatedAPISupport = true
+	streamed, unsubscribe, err := b.SubscribeOrders()
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeOrders returned an error: %s", err)
+	}
+	defer unsubscribe()
+
+	if _, err := b.NewOrderWS(p, 1, 8000, exchange.OrderSideBuy, exchange.OrderTypeExchangeLimit); err != errWebsocketNotAuthenticated {
+		t.Errorf("Test failed. Expected the order to be rejected before auth, got %v", err)
+	}
+	defer connectTestWebsocket(t, &b, "ws"+strings.TrimPrefix(server.URL, "http"))()
+	if err = b.WebsocketHandleMessage(websocket.TextMessage, []byte(`{"event":"auth","status":"OK","chanId":0,"userId":1}`)); err != nil {
+		t.Fatalf("Test failed. WebsocketHandleMessage returned an error: %s", err)
+	}
+
+	clientOrderID, err := b.NewOrderWS(p, 0.5, 8000.5, exchange.OrderSideSell, exchange.OrderTypeExchangeLimit,
+		exchange.OrderOptions{Hidden: true})
+	if err != nil {
+		t.Fatalf("Test failed. NewOrderWS returned an error: %s", err)
+	}
+	msg := <-received
+	order := msg[3].(map[string]interface{})
+	if len(msg) != 4 || msg[1] != "on" || order["type"] != "EXCHANGE LIMIT" || order["symbol"] != "tBTCUSD" ||
+		order["amount"] != "-0.5" || order["price"] != "8000.5" || order["flags"] != float64(64) ||
+		int64(order["cid"].(float64)) != clientOrderID {
+		t.Errorf("Test failed. Unexpected new order request %v", msg)
+	}
+	if err = b.CancelOrderWS(1234); err != nil {
+		t.Fatalf("Test failed. CancelOrderWS returned an error: %s", err)
+	}
+	if msg = <-received; msg[1] != "oc" || msg[3].(map[string]interface{})["id"] != float64(1234) {
+		t.Errorf("Test failed. Unexpected cancel order request %v", msg)
+	}
+	if nextID, _ := b.NewOrderWS(p, 1, 1, exchange.OrderSideBuy, exchange.OrderTypeMarginLimit); nextID <= clientOrderID {
+		t.Errorf("Test failed. Client order IDs aren't unique %d %d", clientOrderID, nextID)
+	}
+
+	messages := []string{
+		`[0,"os",[[1234,null,1,"tBTCUSD",1519862400000,1519862400000,1,1,"EXCHANGE LIMIT",null,null,null,0,"ACTIVE",null,null,8000,0,0,0,null,null,null,0,0,null,null,null,"API>BFX",null,null,null]]]`,
+		`[0,"n",[1519862401000,"on-req",null,null,[1235,null,2,"tBTCUSD",1519862401000,1519862401000,-0.5,-0.5,"EXCHANGE LIMIT",null,null,null,64,"ACTIVE",null,null,8000.5,0,0,0,null,null,null,0,0,null,null,null,"API>BFX",null,null,null],null,"SUCCESS","Submitting exchange limit sell order for -0.5 BTC."]]`,
+		`[0,"on",[1235,null,2,"tBTCUSD",1519862401000,1519862401000,-0.5,-0.5,"EXCHANGE LIMIT",null,null,null,64,"ACTIVE",null,null,8000.5,0,0,0,null,null,null,0,0,null,null,null,"API>BFX",null,null,null]]`,
+		`[0,"te",[401597395,"tBTCUSD",1519862402000,1235,-0.5,8000.5,"EXCHANGE LIMIT",8000.5,1]]`,
+		`[0,"tu",[401597395,"tBTCUSD",1519862402000,1235,-0.5,8000.5,"EXCHANGE LIMIT",8000.5,1,-4.0,"USD"]]`,
+		`[0,"oc",[1235,null,2,"tBTCUSD",1519862401000,1519862402000,0,-0.5,"EXCHANGE LIMIT",null,null,null,64,"EXECUTED @ 8000.5(-0.5)",null,null,8000.5,8000.5,0,0,null,null,null,0,0,null,null,null,"API>BFX",null,null,null]]`,
+		`[0,"n",[1519862403000,"oc-req",null,null,null,null,"ERROR","Order not found."]]`,
+		`[0,"ws",[["exchange","USD",1000,0,null],["exchange","BTC",1,0,null]]]`,
+	}
+	for _, m := range messages {
+		if err = b.WebsocketHandleMessage(websocket.TextMessage, []byte(m)); err != nil {
+			t.Fatalf("Test failed. WebsocketHandleMessage returned an error: %s", err)
+		}
+	}
+	if len(orders) != 3 || orders[0] != "os ACTIVE" || orders[1] != "on ACTIVE" || orders[2] != "oc EXECUTED @ 8000.5(-0.5)" {
+		t.Errorf("Test failed. Unexpected order events %v", orders)
+	}
+	if len(streamed) != 3 {
+		t.Fatalf("Test failed. Expected 3 streamed orders, got %d", len(streamed))
+	}
+	<-streamed
+	if o := <-streamed; o.OrderID != "1235" || o.Status != exchange.OrderStatusActive || o.Side != exchange.OrderSideSell ||
+		o.Amount != 0.5 || o.RemainingAmount != 0.5 || o.Type != exchange.OrderTypeExchangeLimit {
+		t.Errorf("Test failed. Unexpected new order %+v", o)
+	}
+	if o := <-streamed; o.Status != exchange.OrderStatusFilled || o.FilledAmount != 0.5 || o.Rate != 8000.5 ||
+		o.CurrencyPair.FirstCurrency != "BTC" || o.CreatedAt != 1519862401 {
+		t.Errorf("Test failed. Unexpected filled order %+v", o)
+	}
+	if len(trades) != 2 || trades[0].OrderID != 1235 || trades[0].AmountExecuted != -0.5 || !trades[0].Maker ||
+		trades[0].Fee != 0 || trades[1].Fee != -4 || trades[1].FeeCurrency != "USD" {
+		t.Errorf("Test failed. Unexpected trades %+v", trades)
+	}
+	if len(notifications) != 2 || notifications[0].Status != "SUCCESS" || notifications[0].Order == nil ||
+		notifications[0].Order.ClientOrderID != 2 || notifications[0].Order.Flags != 64 ||
+		notifications[1].Status != "ERROR" || notifications[1].Order != nil {
+		t.Errorf("Test failed. Unexpected notifications %+v", notifications)
+	}
+}
+
+func TestWebsocketOrderbook(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"bids":[{"price":"6400","amount":"1","timestamp":"1"}],"asks":[{"price":"6600","amount":"1","timestamp":"1"}]}`))
+	}))
+	defer server.Close()
+
+	b := Bitfinex{}
+	b.SetDefaults()
+	b.Name = "BitfinexWebsocketOrderbook"
+	b.APIUrl = server.URL + "/"
+	b.Websocket = true
+	p := pair.NewCurrencyPair("BTC", "USD")
+	books, unsubscribe, err := b.SubscribeOrderbook(p)
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeOrderbook returned an error: %s", err)
+	}
+	defer unsubscribe()
+
+	messages := []string{
+		`{"event":"subscribed","channel":"book","chanId":5,"symbol":"tBTCUSD","prec":"P0","freq":"F0","len":"100","pair":"BTCUSD"}`,
+		`[5,[[6500,3,1.5],[6490,1,2],[6510,2,-1],[6520,4,-3]]]`,
+		// A new bid, an updated ask, then the best bid & the worst ask are removed
+		`[5,[6505,1,0.25]]`,
+		`[5,[6510,1,-0.5]]`,
+		`[5,[6505,0,1]]`,
+		`[5,[6520,0,-1]]`,
+	}
+	for _, msg := range messages {
+		if err := b.WebsocketHandleMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatalf("Test failed. WebsocketHandleMessage returned an error: %s", err)
+		}
+	}
+	if len(books) != 5 {
+		t.Errorf("Test failed. Expected 5 streamed orderbooks, got %d", len(books))
+	}
+	var streamedBook orderbook.Base
+	for len(books) > 0 {
+		streamedBook = <-books
+	}
+	if len(streamedBook.Bids) != 2 || len(streamedBook.Asks) != 1 || streamedBook.Asks[0].Amount != 0.5 {
+		t.Errorf("Test failed. Unexpected streamed orderbook %+v", streamedBook)
+	}
+	ob, err := b.GetOrderbookEx(p, ticker.Spot)
+	if err != nil {
+		t.Fatalf("Test failed. GetOrderbookEx returned an error: %s", err)
+	}
+	if len(ob.Bids) != 2 || ob.Bids[0].Price != 6500 || ob.Bids[0].Amount != 1.5 || ob.Bids[1].Price != 6490 ||
+		len(ob.Asks) != 1 || ob.Asks[0].Price != 6510 || ob.Asks[0].Amount != 0.5 {
+		t.Errorf("Test failed. Unexpected orderbook %+v", ob)
+	}
+	if requests != 0 {
+		t.Error("Test failed. The streamed orderbook was polled")
+	}
+
+	// The orderbooks are polled again once the websocket disconnects
+	b.resetBooks()
+	if err = b.WebsocketHandleMessage(websocket.TextMessage, []byte(`[5,[6505,1,0.25]]`)); err == nil {
+		t.Error("Test failed. Expected an error for an update without a snapshot")
+	}
+	if ob, err = b.GetOrderbookEx(p, ticker.Spot); err != nil || requests != 1 || len(ob.Bids) != 1 || ob.Bids[0].Price != 6400 {
+		t.Errorf("Test failed. Unexpected polled orderbook %+v %v", ob, err)
+	}
+}
+
+func TestWebsocketCandles(t *testing.T) {
+	b := Bitfinex{}
+	b.SetDefaults()
+	b.Websocket = true
+	p := pair.NewCurrencyPair("BTC", "USD")
+	if _, _, err := b.SubscribeCandles(p, exchange.CandleInterval4h); err == nil {
+		t.Error("Test failed. Expected an unsupported interval error")
+	}
+	candles, unsubscribe, err := b.SubscribeCandles(p, exchange.CandleInterval1m)
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeCandles returned an error: %s", err)
+	}
+	defer unsubscribe()
+	if len(b.candles.keys) != 1 || b.candles.keys[0] != "trade:1m:tBTCUSD" {
+		t.Errorf("Test failed. Unexpected candle keys %v", b.candles.keys)
+	}
+
+	messages := []string{
+		`{"event":"subscribed","channel":"candles","chanId":7,"key":"trade:1m:tBTCUSD"}`,
+		// The candles of the snapshot are history, newest first
+		`[7,[[180000,6500,6510,6520,6490,2],[120000,6480,6500,6505,6470,1]]]`,
+		`[7,[180000,6500,6515,6520,6490,3]]`,
+		// The next candle closes the current one
+		`[7,[240000,6515,6512,6516,6511,0.5]]`,
+		// Once resubscribed the snapshot closes the candles missed while disconnected
+		`[7,[[360000,6530,6530,6530,6530,1],[300000,6520,6525,6525,6520,1],[240000,6515,6520,6521,6511,1.5]]]`,
+	}
+	for _, msg := range messages {
+		if err := b.WebsocketHandleMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatalf("Test failed. WebsocketHandleMessage returned an error: %s", err)
+		}
+	}
+	if len(candles) != 3 {
+		t.Fatalf("Test failed. Expected 3 closed candles, got %d", len(candles))
+	}
+	if c := <-candles; !c.Timestamp.Equal(time.Unix(180, 0)) || c.Open != 6500 || c.Close != 6515 ||
+		c.High != 6520 || c.Low != 6490 || c.Volume != 3 {
+		t.Errorf("Test failed. Unexpected candle %+v", c)
+	}
+	if c := <-candles; !c.Timestamp.Equal(time.Unix(240, 0)) || c.Close != 6520 || c.Volume != 1.5 {
+		t.Errorf("Test failed. Unexpected candle %+v", c)
+	}
+	if c := <-candles; !c.Timestamp.Equal(time.Unix(300, 0)) {
+		t.Errorf("Test failed. Unexpected candle %+v", c)
 	}
 }
@@ -1,8 +1,13 @@
 package bitfinex
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"math"
 	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/shopspring/decimal"
 
@@ -13,6 +18,9 @@ import (
 	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
 )
 
+var _ exchange.IExchange = (*Bitfinex)(nil)
+var _ exchange.ContextExchange = (*Bitfinex)(nil)
+
 // Start starts the Bitfinex go routine
 func (b *Bitfinex) Start() {
 	go b.Run()
@@ -20,7 +28,7 @@ func (b *Bitfinex) Start() {
 
 // Run implements the Bitfinex wrapper
 func (b *Bitfinex) Run() {
-	if b.Verbose {
+	if b.Debug("") {
 		log.Printf("%s Websocket: %s.", b.GetName(), common.IsEnabled(b.Websocket))
 		log.Printf("%s polling delay: %ds.\n", b.GetName(), b.RESTPollingDelay)
 		log.Printf("%s %d currencies enabled: %s.\n", b.GetName(), len(b.EnabledPairs), b.EnabledPairs)
@@ -56,8 +64,13 @@ func (b *Bitfinex) Run() {
 
 // UpdateTicker updates and returns the ticker for a currency pair
 func (b *Bitfinex) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return b.UpdateTickerContext(context.Background(), p, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (b *Bitfinex) UpdateTickerContext(ctx context.Context, p pair.CurrencyPair, assetType string) (ticker.Price, error) {
 	var tickerPrice ticker.Price
-	tickerNew, err := b.GetTicker(p.Pair().String(), nil)
+	tickerNew, err := b.getTicker(ctx, p.Pair().String(), nil)
 	if err != nil {
 		return tickerPrice, err
 	}
@@ -82,8 +95,13 @@ func (b *Bitfinex) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker
 	return tick, nil
 }
 
-// GetOrderbookEx returns the orderbook for a currency pair
-func (b *Bitfinex) GetOrderbookEx(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+// GetOrderbookEx returns the orderbook for a currency pair.
+// If options are given the orderbook is fetched directly from the exchange at the requested
+// precision & depth, and isn't cached.
+func (b *Bitfinex) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
+	if len(opts) > 0 && !opts[0].IsZero() {
+		return b.fetchOrderbook(context.Background(), p, opts[0])
+	}
 	ob, err := b.Orderbooks.GetOrderbook(b.GetName(), p, assetType)
 	if err == nil {
 		return b.UpdateOrderbook(p, assetType)
@@ -91,37 +109,98 @@ func (b *Bitfinex) GetOrderbookEx(p pair.CurrencyPair, assetType string) (orderb
 	return ob, nil
 }
 
-// UpdateOrderbook updates and returns the orderbook for a currency pair
+// UpdateOrderbook updates and returns the orderbook for a currency pair, the orderbook
+// maintained by the websocket client is returned if the pair is streamed
 func (b *Bitfinex) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return b.UpdateOrderbookContext(context.Background(), p, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (b *Bitfinex) UpdateOrderbookContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
+	if streamed, err := b.streamedOrderbook(p); err == nil {
+		return streamed, nil
+	}
+	orderBook, err := b.fetchOrderbook(ctx, p, exchange.OrderbookOptions{Depth: 100})
+	if err != nil {
+		return orderBook, err
+	}
+	b.Orderbooks.ProcessOrderbook(b.GetName(), p, orderBook, assetType)
+	return b.Orderbooks.GetOrderbook(b.Name, p, assetType)
+}
+
+// fetchOrderbook retrieves the orderbook for a currency pair with the given options, the v1 API
+// is used for the default & raw precision (with grouping disabled for raw), and the v2 API for
+// the P0-P3 aggregation levels.
+func (b *Bitfinex) fetchOrderbook(ctx context.Context, p pair.CurrencyPair, opts exchange.OrderbookOptions) (orderbook.Base, error) {
 	var orderBook orderbook.Base
-	vals := url.Values{}
-	vals.Set("limit_bids", "100")
-	vals.Set("limit_asks", "100")
+	var orderbookNew Orderbook
+	var err error
 	symbol := b.CurrencyPairToSymbol(p)
-	orderbookNew, err := b.GetOrderbook(symbol, vals)
+
+	switch opts.Precision {
+	case "", exchange.OrderbookPrecisionR0:
+		vals := url.Values{}
+		if opts.Depth > 0 {
+			vals.Set("limit_bids", strconv.Itoa(opts.Depth))
+			vals.Set("limit_asks", strconv.Itoa(opts.Depth))
+		}
+		if opts.Precision == exchange.OrderbookPrecisionR0 {
+			vals.Set("group", "0")
+		}
+		orderbookNew, err = b.getOrderbook(ctx, symbol, vals)
+	case exchange.OrderbookPrecisionP0, exchange.OrderbookPrecisionP1,
+		exchange.OrderbookPrecisionP2, exchange.OrderbookPrecisionP3:
+		// The v2 API only supports a length of 25 or 100 price levels
+		length := 25
+		if opts.Depth > length {
+			length = 100
+		}
+		orderbookNew, err = b.getOrderbookV2(ctx, symbol, opts.Precision, length)
+	default:
+		return orderBook, fmt.Errorf("unsupported orderbook precision %s", opts.Precision)
+	}
 	if err != nil {
 		return orderBook, err
 	}
 
+	if opts.Depth > 0 {
+		if len(orderbookNew.Asks) > opts.Depth {
+			orderbookNew.Asks = orderbookNew.Asks[:opts.Depth]
+		}
+		if len(orderbookNew.Bids) > opts.Depth {
+			orderbookNew.Bids = orderbookNew.Bids[:opts.Depth]
+		}
+	}
+
+	orderBook.Asks = orderbook.GetItems(len(orderbookNew.Asks))
 	for x := range orderbookNew.Asks {
 		orderBook.Asks = append(orderBook.Asks, orderbook.Item{Price: orderbookNew.Asks[x].Price, Amount: orderbookNew.Asks[x].Amount})
 	}
 
+	orderBook.Bids = orderbook.GetItems(len(orderbookNew.Bids))
 	for x := range orderbookNew.Bids {
 		orderBook.Bids = append(orderBook.Bids, orderbook.Item{Price: orderbookNew.Bids[x].Price, Amount: orderbookNew.Bids[x].Amount})
 	}
-
-	b.Orderbooks.ProcessOrderbook(b.GetName(), p, orderBook, assetType)
-	return b.Orderbooks.GetOrderbook(b.Name, p, assetType)
+	orderBook.CurrencyPair = p.Pair().String()
+	orderBook.Pair = p
+	orderBook.LastUpdated = time.Now()
+	return orderBook, nil
 }
 
 // GetExchangeAccountInfo retrieves balances for all enabled currencies on the
 // Bitfinex exchange
 func (b *Bitfinex) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return b.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (b *Bitfinex) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
 	var response exchange.AccountInfo
 	response.ExchangeName = b.GetName()
-	accountBalance, err := b.GetAccountBalance()
-	if (err != nil) && (err != exchange.WarningHTTPRequestRateLimited()) {
+	accountBalance, err := b.getAccountBalance(ctx)
+	if (err != nil) && !exchange.IsRateLimited(err) {
 		return response, err
 	}
 
@@ -147,6 +226,44 @@ func (b *Bitfinex) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
 	return response, nil
 }
 
+// GetFeeInfo returns the maker & taker fees of the account, the fees for the base currency of the
+// pair are used if Bitfinex lists them separately.
+func (b *Bitfinex) GetFeeInfo(currencyPair pair.CurrencyPair) (maker, taker float64, err error) {
+	if !b.AuthenticatedAPISupport {
+		return b.Base.GetFeeInfo(currencyPair)
+	}
+	infos, err := b.GetAccountInfo()
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(infos) == 0 {
+		return 0, 0, fmt.Errorf("%s account info missing", b.Name)
+	}
+	makerFees, takerFees := infos[0].MakerFees, infos[0].TakerFees
+	base := currencyPair.FirstCurrency.Upper().String()
+	for _, fees := range infos[0].Fees {
+		if common.StringToUpper(fees.Pairs) == base {
+			makerFees, takerFees = fees.MakerFees, fees.TakerFees
+			break
+		}
+	}
+	if maker, err = strconv.ParseFloat(makerFees, 64); err != nil {
+		return 0, 0, err
+	}
+	if taker, err = strconv.ParseFloat(takerFees, 64); err != nil {
+		return 0, 0, err
+	}
+	return maker, taker, nil
+}
+
+// GetCapabilities returns the capabilities of the exchange, Bitfinex supports hidden orders but
+// neither of its order APIs accept a visible amount for iceberg orders.
+func (b *Bitfinex) GetCapabilities() exchange.Capabilities {
+	capabilities := b.Base.GetCapabilities()
+	capabilities.HiddenOrders = true
+	return capabilities
+}
+
 // GetAvailableBalance will attempt to compute the available balance for an order with the
 // given parameters. This is primarily intended for checking the available balance for margin
 // orders, where simply checking the exchange wallet balance is not sufficient.
@@ -160,3 +277,160 @@ func (b *Bitfinex) GetAvailableBalance(currencyPair pair.CurrencyPair, side exch
 	}
 	return 0, err
 }
+
+// GetTradableBalance returns the maximum amount of the given currency pair that can currently be
+// bought or sold on margin.
+func (b *Bitfinex) GetTradableBalance(currencyPair pair.CurrencyPair, side exchange.OrderSide) (float64, error) {
+	info, err := b.GetMarginInfoSymbolV2(b.CurrencyPairToSymbol(currencyPair))
+	if err != nil {
+		return 0, err
+	}
+	if side == exchange.OrderSideBuy {
+		return math.Abs(info.Buy), nil
+	}
+	return math.Abs(info.Sell), nil
+}
+
+// GetMarketAveragePrice estimates the average price a market order for the given amount of the
+// currency pair would be filled at.
+func (b *Bitfinex) GetMarketAveragePrice(currencyPair pair.CurrencyPair, side exchange.OrderSide,
+	amount float64) (float64, error) {
+	amount = math.Abs(amount)
+	if side == exchange.OrderSideSell {
+		amount = -amount
+	}
+	avg, err := b.CalcTradeAverage(b.CurrencyPairToSymbol(currencyPair), amount)
+	if err != nil {
+		return 0, err
+	}
+	return avg.Price, nil
+}
+
+// Maps the wallet names used by the exchange package to the v1 API wallet types
+var walletTypes = map[string]WalletType{
+	exchange.WalletExchange: WalletTypeExchange,
+	exchange.WalletMargin:   WalletTypeMargin,
+	exchange.WalletFunding:  WalletTypeFunding,
+}
+
+func walletName(t WalletType) string {
+	for name, walletType := range walletTypes {
+		if walletType == t {
+			return name
+		}
+	}
+	return string(t)
+}
+
+// GetWalletBalances returns the balances of the exchange, margin & funding wallets
+func (b *Bitfinex) GetWalletBalances() ([]exchange.WalletBalance, error) {
+	balances, err := b.GetAccountBalance()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]exchange.WalletBalance, 0, len(balances))
+	for i := range balances {
+		src := &balances[i]
+		balance := exchange.WalletBalance{
+			Wallet:   walletName(src.Type),
+			Currency: common.StringToUpper(src.Currency),
+		}
+		balance.Total, _ = src.Amount.Float64()
+		balance.Available, _ = src.Available.Float64()
+		result = append(result, balance)
+	}
+	return result, nil
+}
+
+// TransferBetweenWallets moves an amount of a currency between the exchange, margin & funding
+// wallets
+func (b *Bitfinex) TransferBetweenWallets(currency string, amount float64, from, to string) error {
+	walletFrom, ok := walletTypes[from]
+	if !ok {
+		return fmt.Errorf("unknown wallet %s", from)
+	}
+	walletTo, ok := walletTypes[to]
+	if !ok {
+		return fmt.Errorf("unknown wallet %s", to)
+	}
+	response, err := b.WalletTransfer(amount, common.StringToUpper(currency), string(walletFrom), string(walletTo))
+	if err != nil {
+		return err
+	}
+	if len(response) > 0 && response[0].Status != "success" {
+		return fmt.Errorf("wallet transfer failed: %s", response[0].Message)
+	}
+	return nil
+}
+
+// ListInstruments returns the symbols listed on the exchange, symbols that can't be converted
+// to currency pairs are skipped
+func (b *Bitfinex) ListInstruments() ([]exchange.Instrument, error) {
+	symbols, err := b.GetSymbols()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]exchange.Instrument, 0, len(symbols))
+	for _, symbol := range symbols {
+		currencyPair, err := b.SymbolToCurrencyPair(symbol)
+		if err != nil {
+			continue
+		}
+		result = append(result, exchange.Instrument{Symbol: symbol, Pair: currencyPair})
+	}
+	return result, nil
+}
+
+// GetPositions returns the active margin positions
+func (b *Bitfinex) GetPositions() ([]exchange.Position, error) {
+	positions, err := b.GetActivePositions()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]exchange.Position, 0, len(positions))
+	for _, p := range positions {
+		position := exchange.Position{
+			ID:         strconv.FormatInt(p.ID, 10),
+			Side:       exchange.OrderSideBuy,
+			Amount:     math.Abs(p.Amount),
+			BasePrice:  p.Base,
+			ProfitLoss: p.PL,
+		}
+		if p.Amount < 0 {
+			position.Side = exchange.OrderSideSell
+		}
+		position.CurrencyPair, _ = b.SymbolToCurrencyPair(p.Symbol)
+		result = append(result, position)
+	}
+	return result, nil
+}
+
+// GetAccountTrades returns the trades of the account executed at or after since, oldest first
+func (b *Bitfinex) GetAccountTrades(p pair.CurrencyPair, since time.Time, limit int) ([]exchange.Trade, error) {
+	trades, err := b.GetTradeHistory(b.CurrencyPairToSymbol(p), since, time.Time{}, limit, 1)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]exchange.Trade, 0, len(trades))
+	for _, t := range trades {
+		trade := exchange.Trade{
+			ID:           strconv.FormatInt(t.TID, 10),
+			Exchange:     b.GetName(),
+			OrderID:      strconv.FormatInt(t.OrderID, 10),
+			CurrencyPair: p,
+			Side:         exchange.OrderSideBuy,
+			Amount:       t.Amount,
+			Price:        t.Price,
+			Fee:          math.Abs(t.FeeAmount),
+			FeeCurrency:  t.FeeCurrency,
+		}
+		if t.Type == "Sell" {
+			trade.Side = exchange.OrderSideSell
+		}
+		if ts, err := strconv.ParseFloat(t.Timestamp, 64); err == nil {
+			trade.Time = time.Unix(0, int64(ts*1e9))
+		}
+		result = append(result, trade)
+	}
+	return result, nil
+}
@@ -1,9 +1,15 @@
 package bitfinex
 
 import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
 	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
 )
 
@@ -34,3 +40,119 @@ func TestGetOrderbookEx(t *testing.T) {
 		t.Errorf("Test Failed - Bitfinex GetOrderbookEx() error: %s", err)
 	}
 }
+
+func TestGetOrderbookExWithOptions(t *testing.T) {
+	var requestURI string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestURI = r.URL.RequestURI()
+		w.Write([]byte(`[[6500,3,1.5],[6490,1,2],[6480,2,0.5],[6510,2,-1],[6520,4,-3]]`))
+	}))
+	defer server.Close()
+
+	b := Bitfinex{}
+	b.SetDefaults()
+	b.APIUrl = server.URL + "/"
+	p := pair.NewCurrencyPair("BTC", "USD")
+	ob, err := b.GetOrderbookEx(p, ticker.Spot,
+		exchange.OrderbookOptions{Precision: exchange.OrderbookPrecisionP2, Depth: 2})
+	if err != nil {
+		t.Fatalf("Test Failed - Bitfinex GetOrderbookEx() error: %s", err)
+	}
+	if requestURI != "/v2/book/tBTCUSD/P2?len=25" {
+		t.Errorf("Test Failed - Bitfinex GetOrderbookEx() unexpected request %s", requestURI)
+	}
+	if len(ob.Bids) != 2 || ob.Bids[0].Price != 6500 || ob.Bids[1].Amount != 2 {
+		t.Errorf("Test Failed - Bitfinex GetOrderbookEx() unexpected bids %v", ob.Bids)
+	}
+	if len(ob.Asks) != 2 || ob.Asks[0].Price != 6510 || ob.Asks[1].Amount != 3 {
+		t.Errorf("Test Failed - Bitfinex GetOrderbookEx() unexpected asks %v", ob.Asks)
+	}
+	if _, err := b.Orderbooks.GetOrderbook(b.GetName(), p, ticker.Spot); err == nil {
+		t.Error("Test Failed - Bitfinex GetOrderbookEx() orderbook with options shouldn't be cached")
+	}
+
+	// the fake response isn't a valid v1 orderbook, only the request matters here
+	b.GetOrderbookEx(p, ticker.Spot,
+		exchange.OrderbookOptions{Precision: exchange.OrderbookPrecisionR0})
+	if requestURI != "/v1/book/BTCUSD?group=0" {
+		t.Errorf("Test Failed - Bitfinex GetOrderbookEx() unexpected request %s", requestURI)
+	}
+
+	_, err = b.GetOrderbookEx(p, ticker.Spot, exchange.OrderbookOptions{Precision: "P9"})
+	if err == nil {
+		t.Error("Test Failed - Bitfinex GetOrderbookEx() expected error for invalid precision")
+	}
+}
+
+func TestUpdateTickerContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	b := Bitfinex{}
+	b.SetDefaults()
+	b.APIUrl = server.URL + "/"
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := b.UpdateTickerContext(ctx, pair.NewCurrencyPair("BTC", "USD"), ticker.Spot); err == nil {
+		t.Error("Test Failed - Bitfinex UpdateTickerContext() expected the request to be cancelled")
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Test Failed - Bitfinex UpdateTickerContext() the request wasn't cancelled with the context")
+	}
+	if _, err := b.UpdateOrderbookContext(ctx, pair.NewCurrencyPair("BTC", "USD"), ticker.Spot); err == nil {
+		t.Error("Test Failed - Bitfinex UpdateOrderbookContext() expected the request to be cancelled")
+	}
+}
+
+func TestGetFeeInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"maker_fees":"0.1","taker_fees":"0.2","fees":[{"pairs":"BTC","maker_fees":"0.08","taker_fees":"0.18"}]}]`))
+	}))
+	defer server.Close()
+
+	b := Bitfinex{}
+	b.SetDefaults()
+	b.APIUrl = server.URL + "/"
+	b.AuthenticatedAPISupport = true
+	b.SetAPIKeys("key", "secret", "", false)
+	maker, taker, err := b.GetFeeInfo(pair.NewCurrencyPair("BTC", "USD"))
+	if err != nil || maker != 0.08 || taker != 0.18 {
+		t.Errorf("Test Failed - Bitfinex GetFeeInfo() expected the BTC fees, got %v %v %v", maker, taker, err)
+	}
+	maker, taker, err = b.GetFeeInfo(pair.NewCurrencyPair("ETH", "USD"))
+	if err != nil || maker != 0.1 || taker != 0.2 {
+		t.Errorf("Test Failed - Bitfinex GetFeeInfo() expected the account fees, got %v %v %v", maker, taker, err)
+	}
+}
+
+func TestGetLendingRecords(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a credit lent by the account & one it borrowed for its own margin position
+		w.Write([]byte(`[[1,"fUSD",1,1500000000000,1500172800000,1000,null,"CLOSED",null,null,null,0.001,2,` +
+			`1500000000000,1500086400000],[2,"fUSD",-1,1500000000000,1500172800000,-500,null,"CLOSED",null,` +
+			`null,null,0.002,2,1500000000000,1500086400000]]`))
+	}))
+	defer server.Close()
+
+	b := Bitfinex{}
+	b.SetDefaults()
+	b.APIUrl = server.URL + "/"
+	b.AuthenticatedAPISupport = true
+	b.SetAPIKeys("key", "secret", "", false)
+	records, err := b.GetLendingRecords(time.Unix(1500000000, 0), time.Unix(1500200000, 0))
+	if err != nil {
+		t.Fatalf("Test Failed - Bitfinex GetLendingRecords() error: %s", err)
+	}
+	if len(records) != 1 || records[0].ID != "1" || records[0].Currency != "USD" || records[0].Amount != 1000 {
+		t.Fatalf("Test Failed - Bitfinex GetLendingRecords() expected only the lent credit, got %+v", records)
+	}
+	if r := records[0]; math.Abs(r.Interest-2) > 1e-9 || math.Abs(r.Earned-1.7) > 1e-9 {
+		t.Errorf("Test Failed - Bitfinex GetLendingRecords() unexpected interest %v & earnings %v",
+			r.Interest, r.Earned)
+	}
+}
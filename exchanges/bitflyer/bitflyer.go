@@ -0,0 +1,250 @@
+package bitflyer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	exchange "github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+const (
+	bitflyerBaseURL          = "https://api.bitflyer.com"
+	bitflyerMarkets          = "/v1/markets"
+	bitflyerBoard            = "/v1/board"
+	bitflyerTicker           = "/v1/ticker"
+	bitflyerBalance          = "/v1/me/getbalance"
+	bitflyerSendChildOrder   = "/v1/me/sendchildorder"
+	bitflyerCancelChildOrder = "/v1/me/cancelchildorder"
+	bitflyerChildOrders      = "/v1/me/getchildorders"
+	bitflyerMaxOrders        = 500
+	// Layout of the timestamps returned by the API, which are in UTC without a time zone
+	bitflyerTimeLayout = "2006-01-02T15:04:05.999999999"
+)
+
+// Minimum order sizes of the currencies, bitFlyer doesn't publish them through the API
+var bitflyerMinOrderSizes = map[string]float64{
+	"BTC": 0.001,
+	"ETH": 0.01,
+	"BCH": 0.01,
+}
+
+// BitFlyer is the client of the bitFlyer Lightning exchange, only the spot JPY markets are
+// supported.
+type BitFlyer struct {
+	exchange.Base
+	// Maps symbol (exchange specific market identifier) to currency pair info
+	currencyPairs    map[pair.CurrencyItem]*exchange.CurrencyPairInfo
+	symbolDetailsMap map[pair.CurrencyItem]*symbolDetails
+	// Caches the results of the bulk symbol <-> currency pair conversions
+	symbolCache exchange.SymbolCache
+}
+
+// CurrencyPairToSymbol converts a currency pair to a symbol (exchange specific market identifier),
+// the product codes of the spot markets are the currencies delimited by "_" (e.g. BTC_JPY).
+func (b *BitFlyer) CurrencyPairToSymbol(p pair.CurrencyPair) string {
+	return p.Display("_", true).String()
+}
+
+// SymbolToCurrencyPair converts a symbol (exchange specific market identifier) to a currency pair.
+func (b *BitFlyer) SymbolToCurrencyPair(symbol string) (pair.CurrencyPair, error) {
+	if p, exists := b.currencyPairs[pair.CurrencyItem(symbol)]; exists {
+		return p.Currency, nil
+	}
+	return pair.CurrencyPair{}, fmt.Errorf("no currency pair found for '%s' symbol", symbol)
+}
+
+// CurrencyPairsToSymbols converts a batch of currency pairs to symbols, conversions are cached.
+func (b *BitFlyer) CurrencyPairsToSymbols(pairs []pair.CurrencyPair) ([]string, error) {
+	return b.symbolCache.CurrencyPairsToSymbols(pairs, func(cp pair.CurrencyPair) (string, error) {
+		return b.CurrencyPairToSymbol(cp), nil
+	})
+}
+
+// SymbolsToCurrencyPairs converts a batch of symbols to currency pairs, conversions are cached.
+func (b *BitFlyer) SymbolsToCurrencyPairs(symbols []string) ([]pair.CurrencyPair, error) {
+	return b.symbolCache.SymbolsToCurrencyPairs(symbols, b.SymbolToCurrencyPair)
+}
+
+// FetchMarkets fetches the products listed on the exchange, including the FX & futures products.
+func (b *BitFlyer) FetchMarkets() ([]Market, error) {
+	var response []Market
+	err := b.SendHTTPRequest(http.MethodGet, bitflyerMarkets, nil, nil, false, &response)
+	return response, err
+}
+
+// FetchBoard fetches the orderbook of a product.
+func (b *BitFlyer) FetchBoard(productCode string) (*Board, error) {
+	return b.fetchBoard(context.Background(), productCode)
+}
+
+func (b *BitFlyer) fetchBoard(ctx context.Context, productCode string) (*Board, error) {
+	v := url.Values{}
+	v.Set("product_code", productCode)
+	response := Board{}
+	err := b.SendHTTPRequestContext(ctx, http.MethodGet, bitflyerBoard, v, nil, false, &response)
+	return &response, err
+}
+
+// FetchTicker fetches the market data of a product.
+func (b *BitFlyer) FetchTicker(productCode string) (*Ticker, error) {
+	return b.fetchTicker(context.Background(), productCode)
+}
+
+func (b *BitFlyer) fetchTicker(ctx context.Context, productCode string) (*Ticker, error) {
+	v := url.Values{}
+	v.Set("product_code", productCode)
+	response := Ticker{}
+	err := b.SendHTTPRequestContext(ctx, http.MethodGet, bitflyerTicker, v, nil, false, &response)
+	return &response, err
+}
+
+// FetchBalances fetches the balances of the account.
+func (b *BitFlyer) FetchBalances() ([]Balance, error) {
+	return b.fetchBalances(context.Background())
+}
+
+func (b *BitFlyer) fetchBalances(ctx context.Context) ([]Balance, error) {
+	var response []Balance
+	err := b.SendHTTPRequestContext(ctx, http.MethodGet, bitflyerBalance, nil, nil, true, &response)
+	return response, err
+}
+
+// SendChildOrder places an order, returns the acceptance ID of the order.
+func (b *BitFlyer) SendChildOrder(req *ChildOrderRequest) (string, error) {
+	return b.sendChildOrder(context.Background(), req)
+}
+
+func (b *BitFlyer) sendChildOrder(ctx context.Context, req *ChildOrderRequest) (string, error) {
+	response := ChildOrderResponse{}
+	err := b.SendHTTPRequestContext(ctx, http.MethodPost, bitflyerSendChildOrder, nil, req, true, &response)
+	return response.ChildOrderAcceptanceID, err
+}
+
+// CancelChildOrder cancels an open order identified by its acceptance ID.
+func (b *BitFlyer) CancelChildOrder(productCode, acceptanceID string) error {
+	return b.cancelChildOrder(context.Background(), productCode, acceptanceID)
+}
+
+func (b *BitFlyer) cancelChildOrder(ctx context.Context, productCode, acceptanceID string) error {
+	req := &CancelChildOrderRequest{ProductCode: productCode, ChildOrderAcceptanceID: acceptanceID}
+	return b.SendHTTPRequestContext(ctx, http.MethodPost, bitflyerCancelChildOrder, nil, req, true, nil)
+}
+
+// FetchChildOrders fetches the most recent orders of a product, the state (e.g. ACTIVE) &
+// acceptance ID filters are ignored if empty.
+func (b *BitFlyer) FetchChildOrders(productCode, state, acceptanceID string) ([]ChildOrder, error) {
+	return b.fetchChildOrders(context.Background(), productCode, state, acceptanceID)
+}
+
+func (b *BitFlyer) fetchChildOrders(ctx context.Context, productCode, state,
+	acceptanceID string) ([]ChildOrder, error) {
+	v := url.Values{}
+	v.Set("product_code", productCode)
+	v.Set("count", strconv.Itoa(bitflyerMaxOrders))
+	if state != "" {
+		v.Set("child_order_state", state)
+	}
+	if acceptanceID != "" {
+		v.Set("child_order_acceptance_id", acceptanceID)
+	}
+	var response []ChildOrder
+	err := b.SendHTTPRequestContext(ctx, http.MethodGet, bitflyerChildOrders, v, nil, true, &response)
+	return response, err
+}
+
+// SendHTTPRequest sends a request to the given path, params are sent in the query string and
+// the body (if not nil) is sent as JSON. Authenticated requests are signed with the API secret.
+// The response is decoded into the result object, unless it's nil.
+func (b *BitFlyer) SendHTTPRequest(method, path string, params url.Values, body interface{},
+	authenticated bool, result interface{}) error {
+	return b.SendHTTPRequestContext(context.Background(), method, path, params, body, authenticated, result)
+}
+
+// SendHTTPRequestContext is SendHTTPRequest, the request is cancelled when the context is done
+func (b *BitFlyer) SendHTTPRequestContext(ctx context.Context, method, path string,
+	params url.Values, body interface{}, authenticated bool, result interface{}) error {
+	if authenticated && !b.AuthenticatedAPISupport {
+		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, b.Name)
+	}
+
+	requestPath := path
+	if len(params) > 0 {
+		requestPath += "?" + params.Encode()
+	}
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = common.JSONEncode(body); err != nil {
+			return err
+		}
+	}
+
+	if b.Debug(exchange.TraceHTTP) {
+		log.Printf("Request: %s %s %s\n", method, requestPath, payload)
+	}
+
+	headers := make(http.Header)
+	headers.Set("Content-Type", "application/json")
+	if authenticated {
+		apiKey, apiSecret, _ := b.Credentials()
+
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers.Set("ACCESS-KEY", apiKey)
+		headers.Set("ACCESS-TIMESTAMP", timestamp)
+		headers.Set("ACCESS-SIGN", b.sign(apiSecret, timestamp, method, requestPath, payload))
+	}
+
+	resp, statusCode, err := common.SendHTTPRequest2Context(ctx, method, b.APIUrl+requestPath, headers,
+		bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	if b.Debug(exchange.TraceHTTP) {
+		log.Printf("Received raw: \n%s\n", resp)
+	}
+
+	if 200 <= statusCode && statusCode <= 299 {
+		// Cancellations return an empty body
+		if result == nil || strings.TrimSpace(resp) == "" {
+			return nil
+		}
+		if err = common.JSONDecode([]byte(resp), result); err != nil {
+			return exchange.NewExchangeError(b.Name, path, statusCode, 0,
+				"failed to unmarshal response", resp)
+		}
+		return nil
+	}
+
+	var errResp ErrorResponse
+	if err = common.JSONDecode([]byte(resp), &errResp); err != nil {
+		return exchange.NewExchangeError(b.Name, path, statusCode, 0,
+			"failed to unmarshal error response", resp)
+	}
+	return exchange.NewExchangeError(b.Name, path, statusCode, errResp.Status, errResp.ErrorMessage, resp)
+}
+
+// sign returns the signature of a request, the hex encoded HMAC-SHA256 of the timestamp, method,
+// request path (including the query string) & body.
+func (b *BitFlyer) sign(apiSecret, timestamp, method, requestPath string, body []byte) string {
+	message := timestamp + method + requestPath + string(body)
+	return common.HexEncodeToString(common.GetHMAC(common.HashSHA256, []byte(message), []byte(apiSecret)))
+}
+
+// parseTime parses a timestamp returned by the API, returns the zero time if it's invalid
+func parseTime(s string) time.Time {
+	t, err := time.Parse(bitflyerTimeLayout, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,127 @@
+package bitflyer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+const testMarkets = `[{"product_code":"BTC_JPY"},{"product_code":"FX_BTC_JPY"},{"product_code":"ETH_BTC"},
+	{"product_code":"BTCJPY28DEC2018","alias":"BTCJPY_MAT3M"},{"product_code":"ETH_JPY"}]`
+
+func newTestBitFlyer(handler http.HandlerFunc) (*BitFlyer, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	b := &BitFlyer{}
+	b.SetDefaults()
+	b.APIUrl = server.URL
+	b.AuthenticatedAPISupport = true
+	b.SetAPIKeys("key", "secret", "", false)
+	return b, server
+}
+
+func TestSetMarkets(t *testing.T) {
+	b, server := newTestBitFlyer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testMarkets)
+	})
+	defer server.Close()
+
+	markets, err := b.FetchMarkets()
+	if err != nil {
+		t.Fatalf("Test failed. FetchMarkets returned an error: %s", err)
+	}
+	b.setMarkets(markets)
+	if len(b.GetCurrencyPairs()) != 2 || b.GetCurrencyPairs()["BTC_JPY"] == nil || b.GetCurrencyPairs()["ETH_JPY"] == nil {
+		t.Errorf("Test failed. Expected only the spot JPY markets, got %v", b.GetCurrencyPairs())
+	}
+	btcjpy := pair.NewCurrencyPair("BTC", "JPY")
+	if b.CurrencyPairToSymbol(btcjpy) != "BTC_JPY" {
+		t.Errorf("Test failed. Unexpected symbol %s", b.CurrencyPairToSymbol(btcjpy))
+	}
+	if p, err := b.SymbolToCurrencyPair("ETH_JPY"); err != nil || p.Pair().String() != "ETHJPY" {
+		t.Errorf("Test failed. Unexpected currency pair %v %v", p, err)
+	}
+	limits := b.GetLimits()
+	if limits.GetPriceDecimalPlaces(btcjpy) != 0 || limits.GetMinAmount(btcjpy) != 0.001 {
+		t.Error("Test failed. Unexpected limits")
+	}
+}
+
+func TestSendHTTPRequestSigned(t *testing.T) {
+	b, server := newTestBitFlyer(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		expected := (&BitFlyer{}).sign("secret", r.Header.Get("ACCESS-TIMESTAMP"), r.Method, r.URL.RequestURI(), body)
+		if r.Header.Get("ACCESS-SIGN") != expected || r.Header.Get("ACCESS-KEY") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"status":-500,"error_message":"Invalid signature","data":null}`)
+			return
+		}
+		switch r.URL.Path {
+		case bitflyerSendChildOrder:
+			if string(body) != `{"product_code":"BTC_JPY","child_order_type":"LIMIT","side":"BUY","price":700000,"size":0.01,"time_in_force":"GTC"}` {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, `{"status":-100,"error_message":"unexpected body %s","data":null}`, body)
+				return
+			}
+			fmt.Fprint(w, `{"child_order_acceptance_id":"JRF20150707-050237-639234"}`)
+		case bitflyerCancelChildOrder:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+	defer server.Close()
+
+	btcjpy := pair.NewCurrencyPair("BTC", "JPY")
+	id, err := b.NewOrder(btcjpy, 0.01, 700000, exchange.OrderSideBuy, exchange.OrderTypeExchangeLimit)
+	if err != nil || id != "JRF20150707-050237-639234" {
+		t.Errorf("Test failed. Unexpected order ID %s %v", id, err)
+	}
+	if err = b.CancelOrder(id, btcjpy); err != nil {
+		t.Errorf("Test failed. CancelOrder returned an error: %s", err)
+	}
+
+	b.APISecret = "wrong"
+	_, err = b.FetchBalances()
+	if e, ok := err.(*exchange.ExchangeError); !ok || e.Message != "Invalid signature" ||
+		e.StatusCode != http.StatusUnauthorized || e.Code != -500 {
+		t.Errorf("Test failed. Expected an invalid signature error but got %v", err)
+	}
+}
+
+func TestUpdateTicker(t *testing.T) {
+	b, server := newTestBitFlyer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"product_code":"BTC_JPY","timestamp":"2018-11-20T02:50:59.97","tick_id":3579,
+			"best_bid":700000,"best_ask":700500,"ltp":700100,"volume":16819.82,"volume_by_product":6819.82}`)
+	})
+	defer server.Close()
+
+	btcjpy := pair.NewCurrencyPair("BTC", "JPY")
+	tick, err := b.UpdateTicker(btcjpy, ticker.Spot)
+	if err != nil {
+		t.Fatalf("Test failed. UpdateTicker returned an error: %s", err)
+	}
+	if tick.Bid != 700000 || tick.Ask != 700500 || tick.Last != 700100 || tick.Volume != 16819.82 ||
+		!tick.LastUpdated.Equal(time.Date(2018, 11, 20, 2, 50, 59, 970000000, time.UTC)) {
+		t.Errorf("Test failed. Unexpected ticker %+v", tick)
+	}
+}
+
+func TestConvertOrder(t *testing.T) {
+	b := &BitFlyer{}
+	b.SetDefaults()
+	// the product code of an unlisted pair is parsed
+	order := b.convertOrderToExchangeOrder(&ChildOrder{ChildOrderAcceptanceID: "JRF1", ProductCode: "BTC_JPY",
+		Side: OrderSideSell, ChildOrderType: OrderTypeLimit, Price: 700000, Size: 0.1, ExecutedSize: 0.04,
+		OutstandingSize: 0.06, ChildOrderState: OrderStateActive, ChildOrderDate: "2018-11-20T02:50:59"},
+		b.orderCurrencyPair("BTC_JPY"))
+	if order.OrderID != "JRF1" || order.Status != exchange.OrderStatusActive || order.Side != exchange.OrderSideSell ||
+		order.FilledAmount != 0.04 || order.RemainingAmount != 0.06 || order.CreatedAt != 1542682259 ||
+		order.CurrencyPair.Pair().String() != "BTCJPY" {
+		t.Errorf("Test failed. Unexpected order %+v", order)
+	}
+}
@@ -0,0 +1,118 @@
+package bitflyer
+
+// Order sides
+const (
+	OrderSideBuy  = "BUY"
+	OrderSideSell = "SELL"
+)
+
+// Child order types
+const (
+	OrderTypeLimit  = "LIMIT"
+	OrderTypeMarket = "MARKET"
+)
+
+// Child order states
+const (
+	OrderStateActive    = "ACTIVE"
+	OrderStateCompleted = "COMPLETED"
+	OrderStateCanceled  = "CANCELED"
+	OrderStateExpired   = "EXPIRED"
+	OrderStateRejected  = "REJECTED"
+)
+
+// Time in force of the orders
+const (
+	TimeInForceGTC = "GTC"
+)
+
+// ErrorResponse is the body of a rejected request
+type ErrorResponse struct {
+	Status       int    `json:"status"`
+	ErrorMessage string `json:"error_message"`
+}
+
+// Market is a product listed on the exchange, futures have an alias (e.g. BTCJPY_MAT1WK)
+type Market struct {
+	ProductCode string `json:"product_code"`
+	Alias       string `json:"alias"`
+}
+
+// BoardEntry is a price level of the board (orderbook)
+type BoardEntry struct {
+	Price float64 `json:"price"`
+	Size  float64 `json:"size"`
+}
+
+// Board is the orderbook of a product
+type Board struct {
+	MidPrice float64      `json:"mid_price"`
+	Bids     []BoardEntry `json:"bids"`
+	Asks     []BoardEntry `json:"asks"`
+}
+
+// Ticker holds the market data of a product, the timestamp is in UTC without a time zone
+type Ticker struct {
+	ProductCode     string  `json:"product_code"`
+	Timestamp       string  `json:"timestamp"`
+	TickID          int64   `json:"tick_id"`
+	BestBid         float64 `json:"best_bid"`
+	BestAsk         float64 `json:"best_ask"`
+	BestBidSize     float64 `json:"best_bid_size"`
+	BestAskSize     float64 `json:"best_ask_size"`
+	TotalBidDepth   float64 `json:"total_bid_depth"`
+	TotalAskDepth   float64 `json:"total_ask_depth"`
+	LastTradedPrice float64 `json:"ltp"`
+	Volume          float64 `json:"volume"`
+	VolumeByProduct float64 `json:"volume_by_product"`
+}
+
+// Balance holds the balance of a currency
+type Balance struct {
+	CurrencyCode string  `json:"currency_code"`
+	Amount       float64 `json:"amount"`
+	Available    float64 `json:"available"`
+}
+
+// ChildOrderRequest holds the parameters of a new (child) order
+type ChildOrderRequest struct {
+	ProductCode    string  `json:"product_code"`
+	ChildOrderType string  `json:"child_order_type"`
+	Side           string  `json:"side"`
+	Price          float64 `json:"price,omitempty"`
+	Size           float64 `json:"size"`
+	MinuteToExpire int     `json:"minute_to_expire,omitempty"`
+	TimeInForce    string  `json:"time_in_force,omitempty"`
+}
+
+// ChildOrderResponse is the result of a new order, the acceptance ID identifies the order until
+// the exchange assigns it a child order ID
+type ChildOrderResponse struct {
+	ChildOrderAcceptanceID string `json:"child_order_acceptance_id"`
+}
+
+// CancelChildOrderRequest holds the parameters of an order cancellation
+type CancelChildOrderRequest struct {
+	ProductCode            string `json:"product_code"`
+	ChildOrderAcceptanceID string `json:"child_order_acceptance_id"`
+}
+
+// ChildOrder holds the details of an order, the dates are in UTC without a time zone
+type ChildOrder struct {
+	ID                     int64   `json:"id"`
+	ChildOrderID           string  `json:"child_order_id"`
+	ChildOrderAcceptanceID string  `json:"child_order_acceptance_id"`
+	ProductCode            string  `json:"product_code"`
+	Side                   string  `json:"side"`
+	ChildOrderType         string  `json:"child_order_type"`
+	Price                  float64 `json:"price"`
+	AveragePrice           float64 `json:"average_price"`
+	Size                   float64 `json:"size"`
+	ChildOrderState        string  `json:"child_order_state"`
+	ExpireDate             string  `json:"expire_date"`
+	ChildOrderDate         string  `json:"child_order_date"`
+	OutstandingSize        float64 `json:"outstanding_size"`
+	CancelSize             float64 `json:"cancel_size"`
+	ExecutedSize           float64 `json:"executed_size"`
+	TotalCommission        float64 `json:"total_commission"`
+}
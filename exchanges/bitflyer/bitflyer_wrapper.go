@@ -0,0 +1,434 @@
+package bitflyer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+var _ exchange.IExchange = (*BitFlyer)(nil)
+var _ exchange.ContextExchange = (*BitFlyer)(nil)
+
+// New returns a bitFlyer exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *BitFlyer {
+	b := &BitFlyer{}
+	b.SetDefaults()
+	b.Quickstart(b.Setup, apiKey, apiSecret, opts...)
+	return b
+}
+
+// SetDefaults sets the basic defaults for bitFlyer
+func (b *BitFlyer) SetDefaults() {
+	b.Name = "bitFlyer"
+	b.APIUrl = bitflyerBaseURL
+	b.Enabled = false
+	b.Verbose = false
+	b.Websocket = false
+	b.RESTPollingDelay = 10
+	b.TakerFee = 0.15
+	b.MakerFee = 0.15
+	b.RequestCurrencyPairFormat.Delimiter = "_"
+	b.RequestCurrencyPairFormat.Uppercase = true
+	b.ConfigCurrencyPairFormat.Delimiter = "-"
+	b.ConfigCurrencyPairFormat.Uppercase = true
+	b.AssetTypes = []string{ticker.Spot}
+	b.Orderbooks = orderbook.Init()
+}
+
+// Setup takes in the supplied exchange configuration details and sets params
+func (b *BitFlyer) Setup(exch config.ExchangeConfig) {
+	if !exch.Enabled {
+		b.SetEnabled(false)
+	} else {
+		b.Enabled = true
+		b.AuthenticatedAPISupport = exch.AuthenticatedAPISupport
+		b.SetAPIKeys(exch.APIKey, exch.APISecret, "", false)
+		b.RESTPollingDelay = exch.RESTPollingDelay
+		b.Verbose = exch.Verbose
+		b.Websocket = exch.Websocket
+		b.SetAPIURL(exch)
+		b.BaseCurrencies = common.SplitStrings(exch.BaseCurrencies, ",")
+		b.AvailablePairs = common.SplitStrings(exch.AvailablePairs, ",")
+		b.EnabledPairs = common.SplitStrings(exch.EnabledPairs, ",")
+		err := b.SetCurrencyPairFormat()
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = b.SetAssetTypes()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// Start starts the bitFlyer go routine
+func (b *BitFlyer) Start() {
+	go b.Run()
+}
+
+// Run implements the bitFlyer wrapper
+func (b *BitFlyer) Run() {
+	if b.Debug("") {
+		log.Printf("%s polling delay: %ds.\n", b.GetName(), b.RESTPollingDelay)
+		log.Printf("%s %d currencies enabled: %s.\n", b.GetName(), len(b.EnabledPairs), b.EnabledPairs)
+	}
+
+	markets, err := b.FetchMarkets()
+	if err != nil {
+		log.Printf("%s failed to get markets\n", b.GetName())
+		return
+	}
+	b.setMarkets(markets)
+
+	var exchangeProducts []string
+	for _, info := range b.currencyPairs {
+		exchangeProducts = append(exchangeProducts, info.Currency.Display("-", true).String())
+	}
+	err = b.UpdateAvailableCurrencies(exchangeProducts, false)
+	if err != nil {
+		log.Printf("%s failed to update available currencies\n", b.Name)
+	}
+}
+
+// isSpotJPYMarket returns true for the spot markets quoted in JPY, e.g. BTC_JPY but not the FX
+// (FX_BTC_JPY) & futures (BTCJPY28DEC2018) products.
+func isSpotJPYMarket(productCode string) bool {
+	parts := strings.Split(productCode, "_")
+	return len(parts) == 2 && parts[0] != "" && parts[1] == "JPY"
+}
+
+// productCodeToCurrencyPair converts the product code of a spot market (e.g. BTC_JPY) to a
+// currency pair
+func productCodeToCurrencyPair(productCode string) pair.CurrencyPair {
+	p := pair.NewCurrencyPairDelimiter(productCode, "_")
+	return pair.NewCurrencyPair(p.FirstCurrency.String(), p.SecondCurrency.String())
+}
+
+// setMarkets replaces the currency pairs & trading rules of the exchange, only the spot JPY
+// markets are kept.
+func (b *BitFlyer) setMarkets(markets []Market) {
+	b.symbolCache.Reset()
+	b.currencyPairs = make(map[pair.CurrencyItem]*exchange.CurrencyPairInfo)
+	b.symbolDetailsMap = make(map[pair.CurrencyItem]*symbolDetails)
+	for _, market := range markets {
+		if !isSpotJPYMarket(market.ProductCode) {
+			continue
+		}
+		currencyPair := productCodeToCurrencyPair(market.ProductCode)
+		b.currencyPairs[pair.CurrencyItem(market.ProductCode)] = &exchange.CurrencyPairInfo{
+			Currency:           currencyPair,
+			FirstCurrencyName:  currencyPair.FirstCurrency.String(),
+			SecondCurrencyName: currencyPair.SecondCurrency.String(),
+		}
+		// JPY prices are whole yen, sizes have up to 8 decimal places
+		b.symbolDetailsMap[currencyPair.Display("/", false)] = &symbolDetails{
+			PriceDecimalPlaces:  0,
+			AmountDecimalPlaces: 8,
+			MinAmount:           bitflyerMinOrderSizes[currencyPair.FirstCurrency.String()],
+		}
+	}
+}
+
+// UpdateTicker updates and returns the ticker for a currency pair
+func (b *BitFlyer) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return b.UpdateTickerContext(context.Background(), p, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (b *BitFlyer) UpdateTickerContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
+	var tickerPrice ticker.Price
+	tick, err := b.fetchTicker(ctx, b.CurrencyPairToSymbol(p))
+	if err != nil {
+		return tickerPrice, err
+	}
+	tickerPrice.Pair = p
+	tickerPrice.Ask = tick.BestAsk
+	tickerPrice.Bid = tick.BestBid
+	tickerPrice.Last = tick.LastTradedPrice
+	tickerPrice.Volume = tick.Volume
+	tickerPrice.LastUpdated = parseTime(tick.Timestamp)
+	ticker.ProcessTicker(b.GetName(), p, tickerPrice, assetType)
+	return ticker.GetTicker(b.Name, p, assetType)
+}
+
+// GetTickerPrice returns the ticker for a currency pair
+func (b *BitFlyer) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	tick, err := ticker.GetTicker(b.GetName(), p, assetType)
+	if err != nil {
+		return b.UpdateTicker(p, assetType)
+	}
+	return tick, nil
+}
+
+// GetOrderbookEx returns the orderbook for a currency pair
+func (b *BitFlyer) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
+	ob, err := b.Orderbooks.GetOrderbook(b.GetName(), p, assetType)
+	if err != nil {
+		return b.UpdateOrderbook(p, assetType)
+	}
+	return ob, nil
+}
+
+// UpdateOrderbook updates and returns the orderbook for a currency pair
+func (b *BitFlyer) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return b.UpdateOrderbookContext(context.Background(), p, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (b *BitFlyer) UpdateOrderbookContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
+	book := orderbook.Base{}
+	board, err := b.fetchBoard(ctx, b.CurrencyPairToSymbol(p))
+	if err != nil {
+		return book, err
+	}
+
+	book.Bids = orderbook.GetItems(len(board.Bids))
+	for _, entry := range board.Bids {
+		book.Bids = append(book.Bids, orderbook.Item{Price: entry.Price, Amount: entry.Size})
+	}
+	book.Asks = orderbook.GetItems(len(board.Asks))
+	for _, entry := range board.Asks {
+		book.Asks = append(book.Asks, orderbook.Item{Price: entry.Price, Amount: entry.Size})
+	}
+
+	b.Orderbooks.ProcessOrderbook(b.Name, p, book, assetType)
+	return b.Orderbooks.GetOrderbook(b.Name, p, assetType)
+}
+
+// GetExchangeAccountInfo retrieves the balances of the bitFlyer account
+func (b *BitFlyer) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return b.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (b *BitFlyer) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
+	result := exchange.AccountInfo{}
+	result.ExchangeName = b.Name
+
+	if !b.Enabled {
+		return result, nil
+	}
+
+	balances, err := b.fetchBalances(ctx)
+	if err != nil {
+		return result, err
+	}
+	result.Currencies = make([]exchange.AccountCurrencyInfo, len(balances))
+	for i, src := range balances {
+		dest := &result.Currencies[i]
+		dest.CurrencyName = src.CurrencyCode
+		dest.TotalValue = src.Amount
+		dest.Available = src.Available
+		dest.Hold = src.Amount - src.Available
+	}
+	return result, nil
+}
+
+// NewOrder creates a new order on the exchange.
+// Returns the acceptance ID of the new exchange order, which is used as the order ID.
+func (b *BitFlyer) NewOrder(p pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return b.NewOrderContext(context.Background(), p, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (b *BitFlyer) NewOrderContext(ctx context.Context, p pair.CurrencyPair, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	if err := b.GetCapabilities().CheckOrderOptions(opts...); err != nil {
+		return "", err
+	}
+	if orderType != exchange.OrderTypeExchangeLimit {
+		panic("not implemented")
+	}
+	req := &ChildOrderRequest{
+		ProductCode:    b.CurrencyPairToSymbol(p),
+		ChildOrderType: OrderTypeLimit,
+		Price:          price,
+		Size:           amount,
+		TimeInForce:    TimeInForceGTC,
+	}
+	switch side {
+	case exchange.OrderSideBuy:
+		req.Side = OrderSideBuy
+	case exchange.OrderSideSell:
+		req.Side = OrderSideSell
+	default:
+		return "", fmt.Errorf("can't create order on %s exchange invalid value '%s' for side", b.Name, side)
+	}
+	return b.sendChildOrder(ctx, req)
+}
+
+// CancelOrder will attempt to cancel the active order matching the given ID.
+func (b *BitFlyer) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return b.CancelOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (b *BitFlyer) CancelOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) error {
+	return b.cancelChildOrder(ctx, b.CurrencyPairToSymbol(currencyPair), orderID)
+}
+
+// GetOrder returns information about a previously placed order (which may be active or inactive).
+func (b *BitFlyer) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	return b.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (b *BitFlyer) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	orders, err := b.fetchChildOrders(ctx, b.CurrencyPairToSymbol(currencyPair), "", orderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, exchange.ErrOrderNotFound
+	}
+	return b.convertOrderToExchangeOrder(&orders[0], b.orderCurrencyPair(orders[0].ProductCode)), nil
+}
+
+// GetOrders returns information about currently active orders, the orders of all the enabled
+// pairs are returned if no pairs are given.
+func (b *BitFlyer) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return b.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (b *BitFlyer) GetOrdersContext(ctx context.Context,
+	pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	if len(pairs) == 0 {
+		pairs = b.GetEnabledCurrencies()
+	}
+	symbols, err := b.CurrencyPairsToSymbols(pairs)
+	if err != nil {
+		return nil, err
+	}
+	ret := []*exchange.Order{}
+	for i, symbol := range symbols {
+		orders, err := b.fetchChildOrders(ctx, symbol, OrderStateActive, "")
+		if err != nil {
+			return nil, err
+		}
+		for j := range orders {
+			ret = append(ret, b.convertOrderToExchangeOrder(&orders[j], pairs[i]))
+		}
+	}
+	return ret, nil
+}
+
+// orderCurrencyPair returns the currency pair of a product code, the product codes of pairs that
+// are no longer listed are parsed
+func (b *BitFlyer) orderCurrencyPair(productCode string) pair.CurrencyPair {
+	if p, err := b.SymbolToCurrencyPair(productCode); err == nil {
+		return p
+	}
+	return productCodeToCurrencyPair(productCode)
+}
+
+func (b *BitFlyer) convertOrderToExchangeOrder(order *ChildOrder, currencyPair pair.CurrencyPair) *exchange.Order {
+	retOrder := &exchange.Order{}
+	retOrder.OrderID = order.ChildOrderAcceptanceID
+
+	switch order.ChildOrderState {
+	case OrderStateActive:
+		retOrder.Status = exchange.OrderStatusActive
+	case OrderStateCompleted:
+		retOrder.Status = exchange.OrderStatusFilled
+	case OrderStateCanceled, OrderStateExpired, OrderStateRejected:
+		retOrder.Status = exchange.OrderStatusAborted
+	default:
+		retOrder.Status = exchange.OrderStatusUnknown
+	}
+
+	retOrder.Amount = order.Size
+	retOrder.FilledAmount = order.ExecutedSize
+	retOrder.RemainingAmount = order.OutstandingSize
+	retOrder.Rate = order.Price
+	if t := parseTime(order.ChildOrderDate); !t.IsZero() {
+		retOrder.CreatedAt = t.Unix()
+	}
+	retOrder.CurrencyPair = currencyPair
+	if order.Side == OrderSideSell {
+		retOrder.Side = exchange.OrderSideSell
+	} else {
+		retOrder.Side = exchange.OrderSideBuy
+	}
+	if order.ChildOrderType == OrderTypeLimit {
+		retOrder.Type = exchange.OrderTypeExchangeLimit
+	} else {
+		log.Printf("BitFlyer.convertOrderToExchangeOrder(): unexpected '%s' order", order.ChildOrderType)
+	}
+
+	return retOrder
+}
+
+// GetLimits returns price/amount limits for the exchange.
+func (b *BitFlyer) GetLimits() exchange.ILimits {
+	return newCurrencyLimits(b.Name, b.symbolDetailsMap)
+}
+
+// GetCurrencyPairs returns currency pairs that can be used by the exchange account
+// associated with this bot. Use FormatExchangeCurrency to get the right key.
+func (b *BitFlyer) GetCurrencyPairs() map[pair.CurrencyItem]*exchange.CurrencyPairInfo {
+	return b.currencyPairs
+}
+
+type symbolDetails struct {
+	PriceDecimalPlaces  int32
+	AmountDecimalPlaces int32
+	MinAmount           float64
+}
+
+type currencyLimits struct {
+	exchangeName string
+	// Maps currency pair (lower-case, delimited by "/") to symbol details
+	data map[pair.CurrencyItem]*symbolDetails
+}
+
+func newCurrencyLimits(exchangeName string, data map[pair.CurrencyItem]*symbolDetails) *currencyLimits {
+	return &currencyLimits{exchangeName, data}
+}
+
+// Returns max number of decimal places allowed in the trade price for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetPriceDecimalPlaces(p pair.CurrencyPair) int32 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.PriceDecimalPlaces
+	}
+	return -1
+}
+
+// Returns max number of decimal places allowed in the trade amount for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetAmountDecimalPlaces(p pair.CurrencyPair) int32 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.AmountDecimalPlaces
+	}
+	return -1
+}
+
+// Returns the minimum trade amount for the given currency pair.
+func (cl *currencyLimits) GetMinAmount(p pair.CurrencyPair) float64 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.MinAmount
+	}
+	return 0
+}
+
+// Returns the minimum trade total (amount * price) for the given currency pair, bitFlyer only
+// limits the order size.
+func (cl *currencyLimits) GetMinTotal(p pair.CurrencyPair) float64 {
+	return 0
+}
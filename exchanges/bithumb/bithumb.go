@@ -0,0 +1,316 @@
+package bithumb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	exchange "github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+const (
+	bithumbBaseURL   = "https://api.bithumb.com"
+	bithumbTicker    = "/public/ticker/"
+	bithumbOrderbook = "/public/orderbook/"
+	bithumbBalance   = "/info/balance"
+	bithumbOrders    = "/info/orders"
+	bithumbPlace     = "/trade/place"
+	bithumbCancel    = "/trade/cancel"
+	bithumbMaxOrders = 1000
+	// Status of the successful responses
+	bithumbStatusOK = "0000"
+	// Status & message returned by /info/orders when there are no orders, which isn't an error
+	bithumbStatusNoOrders  = 5600
+	bithumbMessageNoOrders = "거래 진행중인 내역이 존재하지 않습니다."
+	// All the markets are quoted in KRW
+	bithumbPaymentCurrency = "KRW"
+)
+
+// Bithumb is the client of the Bithumb exchange, all the markets are quoted in KRW.
+type Bithumb struct {
+	exchange.Base
+	// Maps symbol (exchange specific market identifier) to currency pair info
+	currencyPairs    map[pair.CurrencyItem]*exchange.CurrencyPairInfo
+	symbolDetailsMap map[pair.CurrencyItem]*symbolDetails
+	// Caches the results of the bulk symbol <-> currency pair conversions
+	symbolCache exchange.SymbolCache
+}
+
+// CurrencyPairToSymbol converts a currency pair to a symbol (exchange specific market identifier),
+// markets are identified by the currency traded against KRW (e.g. BTC for BTC/KRW).
+func (b *Bithumb) CurrencyPairToSymbol(p pair.CurrencyPair) string {
+	return p.FirstCurrency.Upper().String()
+}
+
+// SymbolToCurrencyPair converts a symbol (exchange specific market identifier) to a currency pair.
+func (b *Bithumb) SymbolToCurrencyPair(symbol string) (pair.CurrencyPair, error) {
+	if p, exists := b.currencyPairs[pair.CurrencyItem(symbol)]; exists {
+		return p.Currency, nil
+	}
+	return pair.CurrencyPair{}, fmt.Errorf("no currency pair found for '%s' symbol", symbol)
+}
+
+// CurrencyPairsToSymbols converts a batch of currency pairs to symbols, conversions are cached.
+func (b *Bithumb) CurrencyPairsToSymbols(pairs []pair.CurrencyPair) ([]string, error) {
+	return b.symbolCache.CurrencyPairsToSymbols(pairs, func(cp pair.CurrencyPair) (string, error) {
+		if cp.SecondCurrency.Upper().String() != bithumbPaymentCurrency {
+			return "", fmt.Errorf("%s only has %s markets, got %s", b.Name, bithumbPaymentCurrency,
+				cp.Display("/", true))
+		}
+		return b.CurrencyPairToSymbol(cp), nil
+	})
+}
+
+// SymbolsToCurrencyPairs converts a batch of symbols to currency pairs, conversions are cached.
+func (b *Bithumb) SymbolsToCurrencyPairs(symbols []string) ([]pair.CurrencyPair, error) {
+	return b.symbolCache.SymbolsToCurrencyPairs(symbols, b.SymbolToCurrencyPair)
+}
+
+// FetchTickers fetches the tickers of all the markets, keyed by the order currency.
+func (b *Bithumb) FetchTickers() (map[string]Ticker, error) {
+	response := struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}{}
+	if err := b.SendHTTPRequest(http.MethodGet, bithumbTicker+"ALL", nil, false, &response); err != nil {
+		return nil, err
+	}
+	tickers := make(map[string]Ticker, len(response.Data))
+	for currency, data := range response.Data {
+		// The time of the response is listed alongside the tickers
+		if currency == "date" {
+			continue
+		}
+		var tick Ticker
+		if err := common.JSONDecode(data, &tick); err != nil {
+			return nil, err
+		}
+		tickers[currency] = tick
+	}
+	return tickers, nil
+}
+
+// FetchTicker fetches the market data of a currency.
+func (b *Bithumb) FetchTicker(currency string) (*Ticker, error) {
+	return b.fetchTicker(context.Background(), currency)
+}
+
+func (b *Bithumb) fetchTicker(ctx context.Context, currency string) (*Ticker, error) {
+	response := struct {
+		Data Ticker `json:"data"`
+	}{}
+	err := b.SendHTTPRequestContext(ctx, http.MethodGet, bithumbTicker+currency, nil, false, &response)
+	return &response.Data, err
+}
+
+// FetchOrderbook fetches the orderbook of a currency.
+func (b *Bithumb) FetchOrderbook(currency string) (*Orderbook, error) {
+	return b.fetchOrderbook(context.Background(), currency)
+}
+
+func (b *Bithumb) fetchOrderbook(ctx context.Context, currency string) (*Orderbook, error) {
+	response := struct {
+		Data Orderbook `json:"data"`
+	}{}
+	err := b.SendHTTPRequestContext(ctx, http.MethodGet, bithumbOrderbook+currency, nil, false, &response)
+	return &response.Data, err
+}
+
+// FetchBalances fetches the balances of all the currencies, including KRW.
+func (b *Bithumb) FetchBalances() ([]Balance, error) {
+	return b.fetchBalances(context.Background())
+}
+
+func (b *Bithumb) fetchBalances(ctx context.Context) ([]Balance, error) {
+	v := url.Values{}
+	v.Set("currency", "ALL")
+	response := struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}{}
+	if err := b.SendHTTPRequestContext(ctx, http.MethodPost, bithumbBalance, v, true, &response); err != nil {
+		return nil, err
+	}
+	balances := make(map[string]*Balance)
+	for key, raw := range response.Data {
+		// Keys are total_{currency}, in_use_{currency} & available_{currency}, other keys (e.g.
+		// xcoin_last_{currency}) are ignored
+		var currency string
+		var field func(*Balance) *float64
+		switch {
+		case strings.HasPrefix(key, "total_"):
+			currency = strings.TrimPrefix(key, "total_")
+			field = func(balance *Balance) *float64 { return &balance.Total }
+		case strings.HasPrefix(key, "in_use_"):
+			currency = strings.TrimPrefix(key, "in_use_")
+			field = func(balance *Balance) *float64 { return &balance.InUse }
+		case strings.HasPrefix(key, "available_"):
+			currency = strings.TrimPrefix(key, "available_")
+			field = func(balance *Balance) *float64 { return &balance.Available }
+		default:
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.Trim(string(raw), `"`), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid balance %s: %s", b.Name, key, raw)
+		}
+		currency = strings.ToUpper(currency)
+		balance, exists := balances[currency]
+		if !exists {
+			balance = &Balance{Currency: currency}
+			balances[currency] = balance
+		}
+		*field(balance) = value
+	}
+	result := make([]Balance, 0, len(balances))
+	for _, balance := range balances {
+		result = append(result, *balance)
+	}
+	return result, nil
+}
+
+// PlaceOrder places a limit order to buy (bid) or sell (ask) units of a currency for KRW, returns
+// the ID of the order.
+func (b *Bithumb) PlaceOrder(currency, orderType string, units, price float64) (string, error) {
+	return b.placeOrder(context.Background(), currency, orderType, units, price)
+}
+
+func (b *Bithumb) placeOrder(ctx context.Context, currency, orderType string, units,
+	price float64) (string, error) {
+	v := url.Values{}
+	v.Set("order_currency", currency)
+	v.Set("Payment_currency", bithumbPaymentCurrency)
+	v.Set("units", strconv.FormatFloat(units, 'f', -1, 64))
+	v.Set("price", strconv.FormatFloat(price, 'f', -1, 64))
+	v.Set("type", orderType)
+	response := PlaceResponse{}
+	err := b.SendHTTPRequestContext(ctx, http.MethodPost, bithumbPlace, v, true, &response)
+	return response.OrderID, err
+}
+
+// CancelOrderByID cancels an open order, the type (bid or ask) of the order is required.
+func (b *Bithumb) CancelOrderByID(currency, orderType, orderID string) error {
+	return b.cancelOrderByID(context.Background(), currency, orderType, orderID)
+}
+
+func (b *Bithumb) cancelOrderByID(ctx context.Context, currency, orderType, orderID string) error {
+	v := url.Values{}
+	v.Set("currency", currency)
+	v.Set("type", orderType)
+	v.Set("order_id", orderID)
+	return b.SendHTTPRequestContext(ctx, http.MethodPost, bithumbCancel, v, true, nil)
+}
+
+// FetchOrders fetches the open orders of a currency, the order ID filter requires the type (bid
+// or ask) of the order and is ignored if empty.
+func (b *Bithumb) FetchOrders(currency, orderType, orderID string) ([]Order, error) {
+	return b.fetchOrders(context.Background(), currency, orderType, orderID)
+}
+
+func (b *Bithumb) fetchOrders(ctx context.Context, currency, orderType, orderID string) ([]Order, error) {
+	v := url.Values{}
+	v.Set("currency", currency)
+	v.Set("count", strconv.Itoa(bithumbMaxOrders))
+	if orderID != "" {
+		v.Set("order_id", orderID)
+		v.Set("type", orderType)
+	}
+	response := struct {
+		Data []Order `json:"data"`
+	}{}
+	err := b.SendHTTPRequestContext(ctx, http.MethodPost, bithumbOrders, v, true, &response)
+	if e, ok := err.(*exchange.ExchangeError); ok && e.Code == bithumbStatusNoOrders &&
+		e.Message == bithumbMessageNoOrders {
+		return []Order{}, nil
+	}
+	return response.Data, err
+}
+
+// SendHTTPRequest sends a request to the given path, the params of public requests are sent in the
+// query string. Authenticated requests are signed with the API secret & the params are sent as a
+// form. The response is decoded into the result object, unless it's nil.
+func (b *Bithumb) SendHTTPRequest(method, path string, params url.Values, authenticated bool,
+	result interface{}) error {
+	return b.SendHTTPRequestContext(context.Background(), method, path, params, authenticated, result)
+}
+
+// SendHTTPRequestContext is SendHTTPRequest, the request is cancelled when the context is done
+func (b *Bithumb) SendHTTPRequestContext(ctx context.Context, method, path string, params url.Values,
+	authenticated bool, result interface{}) error {
+	if authenticated && !b.AuthenticatedAPISupport {
+		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, b.Name)
+	}
+	if params == nil {
+		params = url.Values{}
+	}
+
+	headers := make(http.Header)
+	requestURL := b.APIUrl + path
+	var payload string
+	if authenticated {
+		apiKey, apiSecret, _ := b.Credentials()
+
+		if b.Nonce.Get() == 0 {
+			b.Nonce.Set(time.Now().UnixNano() / int64(time.Millisecond))
+		} else {
+			b.Nonce.Inc()
+		}
+		params.Set("endpoint", path)
+		payload = params.Encode()
+		headers.Set("Content-Type", "application/x-www-form-urlencoded")
+		headers.Set("Api-Key", apiKey)
+		headers.Set("Api-Nonce", b.Nonce.String())
+		headers.Set("Api-Sign", b.sign(apiSecret, path, payload, b.Nonce.String()))
+	} else if len(params) > 0 {
+		requestURL += "?" + params.Encode()
+	}
+
+	if b.Debug(exchange.TraceHTTP) {
+		log.Printf("Request: %s %s %s\n", method, requestURL, payload)
+	}
+
+	resp, statusCode, err := common.SendHTTPRequest2Context(ctx, method, requestURL, headers,
+		bytes.NewBufferString(payload))
+	if err != nil {
+		return err
+	}
+
+	if b.Debug(exchange.TraceHTTP) {
+		log.Printf("Received raw: \n%s\n", resp)
+	}
+
+	// Rejected requests are usually returned with a 200 status, and identified by the status of
+	// the response
+	var status Response
+	if err = common.JSONDecode([]byte(resp), &status); err != nil {
+		return exchange.NewExchangeError(b.Name, path, statusCode, 0,
+			"failed to unmarshal response", resp)
+	}
+	if status.Status != bithumbStatusOK {
+		code, _ := strconv.Atoi(status.Status)
+		return exchange.NewExchangeError(b.Name, path, statusCode, code, status.Message, resp)
+	}
+	if result == nil {
+		return nil
+	}
+	if err = common.JSONDecode([]byte(resp), result); err != nil {
+		return exchange.NewExchangeError(b.Name, path, statusCode, 0,
+			"failed to unmarshal response", resp)
+	}
+	return nil
+}
+
+// sign returns the signature of a request, the base64 encoded hex digest of the HMAC-SHA512 of the
+// path, form-encoded params & nonce delimited by NUL characters.
+func (b *Bithumb) sign(apiSecret, path, payload, nonce string) string {
+	message := path + "\x00" + payload + "\x00" + nonce
+	hmac := common.GetHMAC(common.HashSHA512, []byte(message), []byte(apiSecret))
+	return common.Base64Encode([]byte(common.HexEncodeToString(hmac)))
+}
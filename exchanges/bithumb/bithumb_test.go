@@ -0,0 +1,164 @@
+package bithumb
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+func newTestBithumb(handler http.HandlerFunc) (*Bithumb, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	b := &Bithumb{}
+	b.SetDefaults()
+	b.APIUrl = server.URL
+	b.AuthenticatedAPISupport = true
+	b.SetAPIKeys("key", "secret", "", false)
+	return b, server
+}
+
+func TestSetMarkets(t *testing.T) {
+	b, server := newTestBithumb(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"0000","data":{"BTC":{"closing_price":"7100000","buy_price":"7099000",
+			"sell_price":"7100000"},"ETH":{"closing_price":"210000"},"date":"1542682259000"}}`)
+	})
+	defer server.Close()
+
+	tickers, err := b.FetchTickers()
+	if err != nil {
+		t.Fatalf("Test failed. FetchTickers returned an error: %s", err)
+	}
+	if len(tickers) != 2 || tickers["BTC"].BuyPrice != 7099000 {
+		t.Fatalf("Test failed. Unexpected tickers %+v", tickers)
+	}
+	b.setMarkets([]string{"BTC", "ETH"})
+
+	btckrw := pair.NewCurrencyPair("BTC", "KRW")
+	if b.CurrencyPairToSymbol(btckrw) != "BTC" {
+		t.Errorf("Test failed. Unexpected symbol %s", b.CurrencyPairToSymbol(btckrw))
+	}
+	if p, err := b.SymbolToCurrencyPair("ETH"); err != nil || p.Pair().String() != "ETHKRW" {
+		t.Errorf("Test failed. Unexpected currency pair %v %v", p, err)
+	}
+	if _, err := b.CurrencyPairsToSymbols([]pair.CurrencyPair{pair.NewCurrencyPair("BTC", "USD")}); err == nil {
+		t.Error("Test failed. Expected the non KRW pair to be rejected")
+	}
+	limits := b.GetLimits()
+	if limits.GetPriceDecimalPlaces(btckrw) != 0 || limits.GetAmountDecimalPlaces(btckrw) != 4 ||
+		limits.GetMinTotal(btckrw) != 500 {
+		t.Error("Test failed. Unexpected limits")
+	}
+}
+
+func TestSendHTTPRequestSigned(t *testing.T) {
+	b, server := newTestBithumb(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		body := r.PostForm.Encode()
+		expected := (&Bithumb{}).sign("secret", r.URL.Path, body, r.Header.Get("Api-Nonce"))
+		if r.Header.Get("Api-Sign") != expected || r.Header.Get("Api-Key") != "key" ||
+			r.PostForm.Get("endpoint") != r.URL.Path {
+			fmt.Fprint(w, `{"status":"5300","message":"Invalid Apikey"}`)
+			return
+		}
+		switch r.URL.Path {
+		case bithumbPlace:
+			if r.PostForm.Get("order_currency") != "BTC" || r.PostForm.Get("Payment_currency") != "KRW" ||
+				r.PostForm.Get("type") != OrderTypeAsk || r.PostForm.Get("units") != "0.01" ||
+				r.PostForm.Get("price") != "7100000" {
+				fmt.Fprintf(w, `{"status":"5600","message":"unexpected body %s"}`, body)
+				return
+			}
+			fmt.Fprint(w, `{"status":"0000","order_id":"1542682259123456","data":[]}`)
+		case bithumbOrders:
+			if r.PostForm.Get("type") != OrderTypeAsk {
+				fmt.Fprintf(w, `{"status":"%d","message":"%s"}`, bithumbStatusNoOrders, bithumbMessageNoOrders)
+				return
+			}
+			fmt.Fprint(w, `{"status":"0000","data":[{"order_id":"1542682259123456","order_currency":"BTC",
+				"payment_currency":"KRW","order_date":1542682259123456,"type":"ask","status":"placed",
+				"units":"0.01","units_remaining":"0.01","price":"7100000"}]}`)
+		case bithumbCancel:
+			if r.PostForm.Get("type") != OrderTypeAsk || r.PostForm.Get("currency") != "BTC" {
+				fmt.Fprintf(w, `{"status":"5600","message":"unexpected body %s"}`, body)
+				return
+			}
+			fmt.Fprint(w, `{"status":"0000"}`)
+		case bithumbBalance:
+			fmt.Fprint(w, `{"status":"0000","data":{"total_btc":"1.5","total_krw":1000000,"in_use_btc":"0.5",
+				"in_use_krw":0,"available_btc":"1.0","available_krw":1000000,"xcoin_last_btc":"7100000"}}`)
+		}
+	})
+	defer server.Close()
+
+	btckrw := pair.NewCurrencyPair("BTC", "KRW")
+	id, err := b.NewOrder(btckrw, 0.01, 7100000, exchange.OrderSideSell, exchange.OrderTypeExchangeLimit)
+	if err != nil || id != "1542682259123456" {
+		t.Errorf("Test failed. Unexpected order ID %s %v", id, err)
+	}
+	if err = b.CancelOrder(id, btckrw); err != nil {
+		t.Errorf("Test failed. CancelOrder returned an error: %s", err)
+	}
+	if _, err = b.GetOrder("1", btckrw); err == nil {
+		t.Error("Test failed. Expected the missing order not to be found")
+	}
+
+	b.Enabled = true
+	info, err := b.GetExchangeAccountInfo()
+	if err != nil {
+		t.Fatalf("Test failed. GetExchangeAccountInfo returned an error: %s", err)
+	}
+	balances := make(map[string]exchange.AccountCurrencyInfo)
+	for _, c := range info.Currencies {
+		balances[c.CurrencyName] = c
+	}
+	if len(balances) != 2 || balances["BTC"].TotalValue != 1.5 || balances["BTC"].Hold != 0.5 ||
+		balances["BTC"].Available != 1 || balances["KRW"].Available != 1000000 {
+		t.Errorf("Test failed. Unexpected balances %+v", info.Currencies)
+	}
+
+	b.APISecret = "wrong"
+	_, err = b.FetchBalances()
+	if e, ok := err.(*exchange.ExchangeError); !ok || e.Message != "Invalid Apikey" || e.Code != 5300 {
+		t.Errorf("Test failed. Expected an invalid API key error but got %v", err)
+	}
+}
+
+func TestUpdateTicker(t *testing.T) {
+	b, server := newTestBithumb(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != bithumbTicker+"BTC" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"status":"0000","data":{"opening_price":"7000000","closing_price":"7100000",
+			"min_price":"6900000","max_price":"7200000","units_traded":"4000.5","volume_1day":"4000.5",
+			"buy_price":"7099000","sell_price":"7100000","date":"1542682259970"}}`)
+	})
+	defer server.Close()
+
+	tick, err := b.UpdateTicker(pair.NewCurrencyPair("BTC", "KRW"), ticker.Spot)
+	if err != nil {
+		t.Fatalf("Test failed. UpdateTicker returned an error: %s", err)
+	}
+	if tick.Bid != 7099000 || tick.Ask != 7100000 || tick.Last != 7100000 || tick.Volume != 4000.5 ||
+		!tick.LastUpdated.Equal(time.Date(2018, 11, 20, 2, 50, 59, 970000000, time.UTC)) {
+		t.Errorf("Test failed. Unexpected ticker %+v", tick)
+	}
+}
+
+func TestConvertOrder(t *testing.T) {
+	b := &Bithumb{}
+	b.SetDefaults()
+	order := b.convertOrderToExchangeOrder(&Order{OrderID: "1", OrderCurrency: "ETH", PaymentCurrency: "KRW",
+		OrderDate: 1542682259123456, Type: OrderTypeBid, Status: OrderStatusPlaced, Units: 1,
+		UnitsRemaining: 0.25, Price: 210000})
+	if order.OrderID != "1" || order.Status != exchange.OrderStatusActive || order.Side != exchange.OrderSideBuy ||
+		order.FilledAmount != 0.75 || order.RemainingAmount != 0.25 || order.CreatedAt != 1542682259 ||
+		order.CurrencyPair.Pair().String() != "ETHKRW" {
+		t.Errorf("Test failed. Unexpected order %+v", order)
+	}
+}
@@ -0,0 +1,82 @@
+package bithumb
+
+// Order types (sides)
+const (
+	OrderTypeBid = "bid"
+	OrderTypeAsk = "ask"
+)
+
+// Order statuses
+const (
+	OrderStatusPlaced    = "placed"
+	OrderStatusCompleted = "completed"
+	OrderStatusCancelled = "cancel"
+)
+
+// Response is the envelope of the API responses, the status is "0000" on success, otherwise the
+// message describes the error.
+type Response struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// Ticker holds the market data of a currency over the last 24 hours, prices are in KRW
+type Ticker struct {
+	OpeningPrice float64 `json:"opening_price,string"`
+	ClosingPrice float64 `json:"closing_price,string"`
+	MinPrice     float64 `json:"min_price,string"`
+	MaxPrice     float64 `json:"max_price,string"`
+	AveragePrice float64 `json:"average_price,string"`
+	UnitsTraded  float64 `json:"units_traded,string"`
+	Volume1Day   float64 `json:"volume_1day,string"`
+	Volume7Day   float64 `json:"volume_7day,string"`
+	BuyPrice     float64 `json:"buy_price,string"`
+	SellPrice    float64 `json:"sell_price,string"`
+	// Unix timestamp in milliseconds, only set when a single ticker is requested
+	Date int64 `json:"date,string"`
+}
+
+// OrderbookEntry is a price level of the orderbook
+type OrderbookEntry struct {
+	Quantity float64 `json:"quantity,string"`
+	Price    float64 `json:"price,string"`
+}
+
+// Orderbook is the orderbook of a currency
+type Orderbook struct {
+	// Unix timestamp in milliseconds
+	Timestamp       int64            `json:"timestamp,string"`
+	OrderCurrency   string           `json:"order_currency"`
+	PaymentCurrency string           `json:"payment_currency"`
+	Bids            []OrderbookEntry `json:"bids"`
+	Asks            []OrderbookEntry `json:"asks"`
+}
+
+// Balance holds the balance of a currency, the API returns the balances keyed by
+// total_{currency}, in_use_{currency} & available_{currency}
+type Balance struct {
+	Currency  string
+	Total     float64
+	InUse     float64
+	Available float64
+}
+
+// PlaceResponse is the result of a new order
+type PlaceResponse struct {
+	Response
+	OrderID string `json:"order_id"`
+}
+
+// Order holds the details of an order
+type Order struct {
+	OrderID         string `json:"order_id"`
+	OrderCurrency   string `json:"order_currency"`
+	PaymentCurrency string `json:"payment_currency"`
+	// Unix timestamp in microseconds
+	OrderDate      int64   `json:"order_date"`
+	Type           string  `json:"type"`
+	Status         string  `json:"status"`
+	Units          float64 `json:"units,string"`
+	UnitsRemaining float64 `json:"units_remaining,string"`
+	Price          float64 `json:"price,string"`
+}
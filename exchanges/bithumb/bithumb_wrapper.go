@@ -0,0 +1,437 @@
+package bithumb
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+var _ exchange.IExchange = (*Bithumb)(nil)
+var _ exchange.ContextExchange = (*Bithumb)(nil)
+
+const (
+	// KRW prices are whole won, amounts have up to 4 decimal places
+	bithumbPriceDecimalPlaces  = 0
+	bithumbAmountDecimalPlaces = 4
+	// Minimum total (in KRW) of the orders
+	bithumbMinTotal = 500
+)
+
+// New returns a Bithumb exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *Bithumb {
+	b := &Bithumb{}
+	b.SetDefaults()
+	b.Quickstart(b.Setup, apiKey, apiSecret, opts...)
+	return b
+}
+
+// SetDefaults sets the basic defaults for Bithumb
+func (b *Bithumb) SetDefaults() {
+	b.Name = "Bithumb"
+	b.APIUrl = bithumbBaseURL
+	b.Enabled = false
+	b.Verbose = false
+	b.Websocket = false
+	b.RESTPollingDelay = 10
+	b.TakerFee = 0.15
+	b.MakerFee = 0.15
+	b.RequestCurrencyPairFormat.Delimiter = ""
+	b.RequestCurrencyPairFormat.Uppercase = true
+	b.ConfigCurrencyPairFormat.Delimiter = "-"
+	b.ConfigCurrencyPairFormat.Uppercase = true
+	b.AssetTypes = []string{ticker.Spot}
+	b.Orderbooks = orderbook.Init()
+}
+
+// Setup takes in the supplied exchange configuration details and sets params
+func (b *Bithumb) Setup(exch config.ExchangeConfig) {
+	if !exch.Enabled {
+		b.SetEnabled(false)
+	} else {
+		b.Enabled = true
+		b.AuthenticatedAPISupport = exch.AuthenticatedAPISupport
+		b.SetAPIKeys(exch.APIKey, exch.APISecret, "", false)
+		b.RESTPollingDelay = exch.RESTPollingDelay
+		b.Verbose = exch.Verbose
+		b.Websocket = exch.Websocket
+		b.SetAPIURL(exch)
+		b.BaseCurrencies = common.SplitStrings(exch.BaseCurrencies, ",")
+		b.AvailablePairs = common.SplitStrings(exch.AvailablePairs, ",")
+		b.EnabledPairs = common.SplitStrings(exch.EnabledPairs, ",")
+		err := b.SetCurrencyPairFormat()
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = b.SetAssetTypes()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// Start starts the Bithumb go routine
+func (b *Bithumb) Start() {
+	go b.Run()
+}
+
+// Run implements the Bithumb wrapper
+func (b *Bithumb) Run() {
+	if b.Debug("") {
+		log.Printf("%s polling delay: %ds.\n", b.GetName(), b.RESTPollingDelay)
+		log.Printf("%s %d currencies enabled: %s.\n", b.GetName(), len(b.EnabledPairs), b.EnabledPairs)
+	}
+
+	tickers, err := b.FetchTickers()
+	if err != nil {
+		log.Printf("%s failed to get markets\n", b.GetName())
+		return
+	}
+	currencies := make([]string, 0, len(tickers))
+	for currency := range tickers {
+		currencies = append(currencies, currency)
+	}
+	b.setMarkets(currencies)
+
+	var exchangeProducts []string
+	for _, info := range b.currencyPairs {
+		exchangeProducts = append(exchangeProducts, info.Currency.Display("-", true).String())
+	}
+	err = b.UpdateAvailableCurrencies(exchangeProducts, false)
+	if err != nil {
+		log.Printf("%s failed to update available currencies\n", b.Name)
+	}
+}
+
+// setMarkets replaces the currency pairs & trading rules of the exchange, the markets are the
+// given currencies traded against KRW.
+func (b *Bithumb) setMarkets(currencies []string) {
+	b.symbolCache.Reset()
+	b.currencyPairs = make(map[pair.CurrencyItem]*exchange.CurrencyPairInfo)
+	b.symbolDetailsMap = make(map[pair.CurrencyItem]*symbolDetails)
+	for _, currency := range currencies {
+		currencyPair := pair.NewCurrencyPair(currency, bithumbPaymentCurrency)
+		b.currencyPairs[pair.CurrencyItem(currency)] = &exchange.CurrencyPairInfo{
+			Currency:           currencyPair,
+			FirstCurrencyName:  currency,
+			SecondCurrencyName: bithumbPaymentCurrency,
+		}
+		b.symbolDetailsMap[currencyPair.Display("/", false)] = &symbolDetails{
+			PriceDecimalPlaces:  bithumbPriceDecimalPlaces,
+			AmountDecimalPlaces: bithumbAmountDecimalPlaces,
+			MinTotal:            bithumbMinTotal,
+		}
+	}
+}
+
+// UpdateTicker updates and returns the ticker for a currency pair
+func (b *Bithumb) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return b.UpdateTickerContext(context.Background(), p, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (b *Bithumb) UpdateTickerContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
+	var tickerPrice ticker.Price
+	tick, err := b.fetchTicker(ctx, b.CurrencyPairToSymbol(p))
+	if err != nil {
+		return tickerPrice, err
+	}
+	tickerPrice.Pair = p
+	tickerPrice.Ask = tick.SellPrice
+	tickerPrice.Bid = tick.BuyPrice
+	tickerPrice.Last = tick.ClosingPrice
+	tickerPrice.High = tick.MaxPrice
+	tickerPrice.Low = tick.MinPrice
+	tickerPrice.Volume = tick.Volume1Day
+	if tick.Date != 0 {
+		tickerPrice.LastUpdated = time.Unix(0, tick.Date*int64(time.Millisecond)).UTC()
+	}
+	ticker.ProcessTicker(b.GetName(), p, tickerPrice, assetType)
+	return ticker.GetTicker(b.Name, p, assetType)
+}
+
+// GetTickerPrice returns the ticker for a currency pair
+func (b *Bithumb) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	tick, err := ticker.GetTicker(b.GetName(), p, assetType)
+	if err != nil {
+		return b.UpdateTicker(p, assetType)
+	}
+	return tick, nil
+}
+
+// GetOrderbookEx returns the orderbook for a currency pair
+func (b *Bithumb) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
+	ob, err := b.Orderbooks.GetOrderbook(b.GetName(), p, assetType)
+	if err != nil {
+		return b.UpdateOrderbook(p, assetType)
+	}
+	return ob, nil
+}
+
+// UpdateOrderbook updates and returns the orderbook for a currency pair
+func (b *Bithumb) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return b.UpdateOrderbookContext(context.Background(), p, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (b *Bithumb) UpdateOrderbookContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
+	book := orderbook.Base{}
+	ob, err := b.fetchOrderbook(ctx, b.CurrencyPairToSymbol(p))
+	if err != nil {
+		return book, err
+	}
+
+	book.Bids = orderbook.GetItems(len(ob.Bids))
+	for _, entry := range ob.Bids {
+		book.Bids = append(book.Bids, orderbook.Item{Price: entry.Price, Amount: entry.Quantity})
+	}
+	book.Asks = orderbook.GetItems(len(ob.Asks))
+	for _, entry := range ob.Asks {
+		book.Asks = append(book.Asks, orderbook.Item{Price: entry.Price, Amount: entry.Quantity})
+	}
+
+	b.Orderbooks.ProcessOrderbook(b.Name, p, book, assetType)
+	return b.Orderbooks.GetOrderbook(b.Name, p, assetType)
+}
+
+// GetExchangeAccountInfo retrieves the balances of the Bithumb account
+func (b *Bithumb) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return b.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (b *Bithumb) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
+	result := exchange.AccountInfo{}
+	result.ExchangeName = b.Name
+
+	if !b.Enabled {
+		return result, nil
+	}
+
+	balances, err := b.fetchBalances(ctx)
+	if err != nil {
+		return result, err
+	}
+	result.Currencies = make([]exchange.AccountCurrencyInfo, len(balances))
+	for i, src := range balances {
+		dest := &result.Currencies[i]
+		dest.CurrencyName = src.Currency
+		dest.TotalValue = src.Total
+		dest.Available = src.Available
+		dest.Hold = src.InUse
+	}
+	return result, nil
+}
+
+// NewOrder creates a new order on the exchange.
+// Returns the ID of the new exchange order.
+func (b *Bithumb) NewOrder(p pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return b.NewOrderContext(context.Background(), p, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (b *Bithumb) NewOrderContext(ctx context.Context, p pair.CurrencyPair, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	if err := b.GetCapabilities().CheckOrderOptions(opts...); err != nil {
+		return "", err
+	}
+	if orderType != exchange.OrderTypeExchangeLimit {
+		panic("not implemented")
+	}
+	if p.SecondCurrency.Upper().String() != bithumbPaymentCurrency {
+		return "", fmt.Errorf("can't create order on %s exchange, only %s markets are supported",
+			b.Name, bithumbPaymentCurrency)
+	}
+	var typ string
+	switch side {
+	case exchange.OrderSideBuy:
+		typ = OrderTypeBid
+	case exchange.OrderSideSell:
+		typ = OrderTypeAsk
+	default:
+		return "", fmt.Errorf("can't create order on %s exchange invalid value '%s' for side", b.Name, side)
+	}
+	return b.placeOrder(ctx, b.CurrencyPairToSymbol(p), typ, amount, price)
+}
+
+// CancelOrder will attempt to cancel the active order matching the given ID.
+func (b *Bithumb) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return b.CancelOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (b *Bithumb) CancelOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) error {
+	// The type of the order must be given to cancel it
+	order, typ, err := b.findOrder(ctx, orderID, currencyPair)
+	if err != nil {
+		return err
+	}
+	return b.cancelOrderByID(ctx, order.OrderCurrency, typ, orderID)
+}
+
+// GetOrder returns information about a previously placed order, only the active orders can be
+// looked up.
+func (b *Bithumb) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	return b.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (b *Bithumb) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	order, _, err := b.findOrder(ctx, orderID, currencyPair)
+	if err != nil {
+		return nil, err
+	}
+	return b.convertOrderToExchangeOrder(order), nil
+}
+
+// findOrder looks up an active order & its type, the orders are looked up by ID and type so both
+// types are tried.
+func (b *Bithumb) findOrder(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*Order, string, error) {
+	for _, typ := range []string{OrderTypeBid, OrderTypeAsk} {
+		orders, err := b.fetchOrders(ctx, b.CurrencyPairToSymbol(currencyPair), typ, orderID)
+		if err != nil {
+			return nil, "", err
+		}
+		for i := range orders {
+			if orders[i].OrderID == orderID {
+				return &orders[i], typ, nil
+			}
+		}
+	}
+	return nil, "", exchange.ErrOrderNotFound
+}
+
+// GetOrders returns information about currently active orders, the orders of all the enabled
+// pairs are returned if no pairs are given.
+func (b *Bithumb) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return b.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (b *Bithumb) GetOrdersContext(ctx context.Context,
+	pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	if len(pairs) == 0 {
+		pairs = b.GetEnabledCurrencies()
+	}
+	ret := []*exchange.Order{}
+	for _, p := range pairs {
+		orders, err := b.fetchOrders(ctx, b.CurrencyPairToSymbol(p), "", "")
+		if err != nil {
+			return nil, err
+		}
+		for i := range orders {
+			ret = append(ret, b.convertOrderToExchangeOrder(&orders[i]))
+		}
+	}
+	return ret, nil
+}
+
+func (b *Bithumb) convertOrderToExchangeOrder(order *Order) *exchange.Order {
+	retOrder := &exchange.Order{}
+	retOrder.OrderID = order.OrderID
+
+	switch order.Status {
+	case OrderStatusPlaced:
+		retOrder.Status = exchange.OrderStatusActive
+	case OrderStatusCompleted:
+		retOrder.Status = exchange.OrderStatusFilled
+	case OrderStatusCancelled:
+		retOrder.Status = exchange.OrderStatusAborted
+	default:
+		retOrder.Status = exchange.OrderStatusUnknown
+	}
+
+	retOrder.Amount = order.Units
+	retOrder.RemainingAmount = order.UnitsRemaining
+	retOrder.FilledAmount = order.Units - order.UnitsRemaining
+	retOrder.Rate = order.Price
+	// Order dates are in microseconds
+	retOrder.CreatedAt = order.OrderDate / int64(time.Second/time.Microsecond)
+	if p, err := b.SymbolToCurrencyPair(order.OrderCurrency); err == nil {
+		retOrder.CurrencyPair = p
+	} else {
+		retOrder.CurrencyPair = pair.NewCurrencyPair(order.OrderCurrency, bithumbPaymentCurrency)
+	}
+	if order.Type == OrderTypeAsk {
+		retOrder.Side = exchange.OrderSideSell
+	} else {
+		retOrder.Side = exchange.OrderSideBuy
+	}
+	retOrder.Type = exchange.OrderTypeExchangeLimit
+
+	return retOrder
+}
+
+// GetLimits returns price/amount limits for the exchange.
+func (b *Bithumb) GetLimits() exchange.ILimits {
+	return newCurrencyLimits(b.Name, b.symbolDetailsMap)
+}
+
+// GetCurrencyPairs returns currency pairs that can be used by the exchange account
+// associated with this bot. Use FormatExchangeCurrency to get the right key.
+func (b *Bithumb) GetCurrencyPairs() map[pair.CurrencyItem]*exchange.CurrencyPairInfo {
+	return b.currencyPairs
+}
+
+type symbolDetails struct {
+	PriceDecimalPlaces  int32
+	AmountDecimalPlaces int32
+	MinTotal            float64
+}
+
+type currencyLimits struct {
+	exchangeName string
+	// Maps currency pair (lower-case, delimited by "/") to symbol details
+	data map[pair.CurrencyItem]*symbolDetails
+}
+
+func newCurrencyLimits(exchangeName string, data map[pair.CurrencyItem]*symbolDetails) *currencyLimits {
+	return &currencyLimits{exchangeName, data}
+}
+
+// Returns max number of decimal places allowed in the trade price for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetPriceDecimalPlaces(p pair.CurrencyPair) int32 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.PriceDecimalPlaces
+	}
+	return -1
+}
+
+// Returns max number of decimal places allowed in the trade amount for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetAmountDecimalPlaces(p pair.CurrencyPair) int32 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.AmountDecimalPlaces
+	}
+	return -1
+}
+
+// Returns the minimum trade amount for the given currency pair, Bithumb only limits the order
+// total.
+func (cl *currencyLimits) GetMinAmount(p pair.CurrencyPair) float64 {
+	return 0
+}
+
+// Returns the minimum trade total (amount * price) for the given currency pair.
+func (cl *currencyLimits) GetMinTotal(p pair.CurrencyPair) float64 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.MinTotal
+	}
+	return 0
+}
@@ -0,0 +1,275 @@
+package bitmex
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	exchange "github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+const (
+	bitmexBaseURL            = "https://www.bitmex.com"
+	bitmexInstrumentPath     = "/api/v1/instrument"
+	bitmexActiveInstruments  = "/api/v1/instrument/active"
+	bitmexOrderBookL2Path    = "/api/v1/orderBook/L2"
+	bitmexMarginPath         = "/api/v1/user/margin"
+	bitmexOrderPath          = "/api/v1/order"
+	bitmexPositionPath       = "/api/v1/position"
+	bitmexLeveragePath       = "/api/v1/position/leverage"
+	bitmexDefaultBookDepth   = 100
+	bitmexMaxOrders          = 500
+	bitmexRequestExpiry      = time.Minute
+	bitmexSatoshisPerBitcoin = 1e8
+)
+
+// BitMEX is the client of the BitMEX derivatives exchange, only the perpetual swap contracts
+// are supported. The amounts of orders & positions are in contracts, e.g. one XBTUSD contract
+// is worth 1 USD (see the contract specification in the currency pair info).
+type BitMEX struct {
+	exchange.Base
+	// Maps symbol (exchange specific market identifier) to currency pair info
+	currencyPairs    map[pair.CurrencyItem]*exchange.CurrencyPairInfo
+	symbolDetailsMap map[pair.CurrencyItem]*symbolDetails
+	// Maps currency pair (delimited by "/") to symbol
+	symbols map[pair.CurrencyItem]string
+	// Caches the results of the bulk symbol <-> currency pair conversions
+	symbolCache exchange.SymbolCache
+}
+
+// CurrencyPairToSymbol converts a currency pair to a symbol (exchange specific market identifier),
+// the symbols of the perpetual swaps are the underlying & quote currency (e.g. XBTUSD).
+func (b *BitMEX) CurrencyPairToSymbol(p pair.CurrencyPair) string {
+	if symbol, exists := b.symbols[p.Display("/", true)]; exists {
+		return symbol
+	}
+	return p.Display("", true).String()
+}
+
+// SymbolToCurrencyPair converts a symbol (exchange specific market identifier) to a currency pair.
+func (b *BitMEX) SymbolToCurrencyPair(symbol string) (pair.CurrencyPair, error) {
+	if p, exists := b.currencyPairs[pair.CurrencyItem(symbol)]; exists {
+		return p.Currency, nil
+	}
+	return pair.CurrencyPair{}, fmt.Errorf("no currency pair found for '%s' symbol", symbol)
+}
+
+// CurrencyPairsToSymbols converts a batch of currency pairs to symbols, conversions are cached.
+func (b *BitMEX) CurrencyPairsToSymbols(pairs []pair.CurrencyPair) ([]string, error) {
+	return b.symbolCache.CurrencyPairsToSymbols(pairs, func(cp pair.CurrencyPair) (string, error) {
+		return b.CurrencyPairToSymbol(cp), nil
+	})
+}
+
+// SymbolsToCurrencyPairs converts a batch of symbols to currency pairs, conversions are cached.
+func (b *BitMEX) SymbolsToCurrencyPairs(symbols []string) ([]pair.CurrencyPair, error) {
+	return b.symbolCache.SymbolsToCurrencyPairs(symbols, b.SymbolToCurrencyPair)
+}
+
+// FetchActiveInstruments fetches the instruments that are currently trading.
+func (b *BitMEX) FetchActiveInstruments() ([]Instrument, error) {
+	var response []Instrument
+	err := b.SendHTTPRequest(http.MethodGet, bitmexActiveInstruments, nil, nil, false, &response)
+	return response, err
+}
+
+// FetchInstrument fetches the contract specification & market data of an instrument.
+func (b *BitMEX) FetchInstrument(symbol string) (*Instrument, error) {
+	return b.fetchInstrument(context.Background(), symbol)
+}
+
+func (b *BitMEX) fetchInstrument(ctx context.Context, symbol string) (*Instrument, error) {
+	v := url.Values{}
+	v.Set("symbol", symbol)
+	var response []Instrument
+	if err := b.SendHTTPRequestContext(ctx, http.MethodGet, bitmexInstrumentPath, v, nil, false,
+		&response); err != nil {
+		return nil, err
+	}
+	if len(response) == 0 {
+		return nil, fmt.Errorf("%s: instrument %s not found", b.Name, symbol)
+	}
+	return &response[0], nil
+}
+
+// FetchOrderBookL2 fetches the orderbook of an instrument, depth is the number of price levels
+// on each side (zero returns the full orderbook).
+func (b *BitMEX) FetchOrderBookL2(symbol string, depth int) ([]OrderBookL2Entry, error) {
+	return b.fetchOrderBookL2(context.Background(), symbol, depth)
+}
+
+func (b *BitMEX) fetchOrderBookL2(ctx context.Context, symbol string, depth int) ([]OrderBookL2Entry, error) {
+	v := url.Values{}
+	v.Set("symbol", symbol)
+	v.Set("depth", strconv.Itoa(depth))
+	var response []OrderBookL2Entry
+	err := b.SendHTTPRequestContext(ctx, http.MethodGet, bitmexOrderBookL2Path, v, nil, false, &response)
+	return response, err
+}
+
+// FetchMargins fetches the margin balances of all the currencies of the account.
+func (b *BitMEX) FetchMargins() ([]Margin, error) {
+	return b.fetchMargins(context.Background())
+}
+
+func (b *BitMEX) fetchMargins(ctx context.Context) ([]Margin, error) {
+	v := url.Values{}
+	v.Set("currency", "all")
+	var response []Margin
+	err := b.SendHTTPRequestContext(ctx, http.MethodGet, bitmexMarginPath, v, nil, true, &response)
+	return response, err
+}
+
+// PlaceOrder places an order.
+func (b *BitMEX) PlaceOrder(req *OrderRequest) (*Order, error) {
+	return b.placeOrder(context.Background(), req)
+}
+
+func (b *BitMEX) placeOrder(ctx context.Context, req *OrderRequest) (*Order, error) {
+	response := Order{}
+	err := b.SendHTTPRequestContext(ctx, http.MethodPost, bitmexOrderPath, nil, req, true, &response)
+	return &response, err
+}
+
+// DeleteOrder cancels an open order.
+func (b *BitMEX) DeleteOrder(orderID string) (*Order, error) {
+	return b.deleteOrder(context.Background(), orderID)
+}
+
+func (b *BitMEX) deleteOrder(ctx context.Context, orderID string) (*Order, error) {
+	req := map[string]string{"orderID": orderID}
+	var response []Order
+	if err := b.SendHTTPRequestContext(ctx, http.MethodDelete, bitmexOrderPath, nil, req, true,
+		&response); err != nil {
+		return nil, err
+	}
+	if len(response) == 0 {
+		return nil, exchange.ErrOrderNotFound
+	}
+	return &response[0], nil
+}
+
+// FetchOrders fetches the most recent orders matching the filter (e.g. {"open": true}), symbol
+// can be empty to fetch the orders of all the instruments.
+func (b *BitMEX) FetchOrders(symbol string, filter map[string]interface{}) ([]Order, error) {
+	return b.fetchOrders(context.Background(), symbol, filter)
+}
+
+func (b *BitMEX) fetchOrders(ctx context.Context, symbol string,
+	filter map[string]interface{}) ([]Order, error) {
+	v := url.Values{}
+	if symbol != "" {
+		v.Set("symbol", symbol)
+	}
+	if len(filter) > 0 {
+		f, err := common.JSONEncode(filter)
+		if err != nil {
+			return nil, err
+		}
+		v.Set("filter", string(f))
+	}
+	v.Set("count", strconv.Itoa(bitmexMaxOrders))
+	v.Set("reverse", "true")
+	var response []Order
+	err := b.SendHTTPRequestContext(ctx, http.MethodGet, bitmexOrderPath, v, nil, true, &response)
+	return response, err
+}
+
+// FetchPositions fetches the positions of the account.
+func (b *BitMEX) FetchPositions() ([]Position, error) {
+	var response []Position
+	err := b.SendHTTPRequest(http.MethodGet, bitmexPositionPath, nil, nil, true, &response)
+	return response, err
+}
+
+// ChooseLeverage sets the leverage of the position in an instrument, zero switches the position
+// to cross margin.
+func (b *BitMEX) ChooseLeverage(symbol string, leverage float64) (*Position, error) {
+	req := map[string]interface{}{"symbol": symbol, "leverage": leverage}
+	response := Position{}
+	err := b.SendHTTPRequest(http.MethodPost, bitmexLeveragePath, nil, req, true, &response)
+	return &response, err
+}
+
+// SendHTTPRequest sends a request to the given path, params are sent in the query string and
+// the body (if not nil) is sent as JSON. Authenticated requests are signed with the API secret.
+// The response is decoded into the result object.
+func (b *BitMEX) SendHTTPRequest(method, path string, params url.Values, body interface{},
+	authenticated bool, result interface{}) error {
+	return b.SendHTTPRequestContext(context.Background(), method, path, params, body, authenticated, result)
+}
+
+// SendHTTPRequestContext is SendHTTPRequest, the request is cancelled when the context is done
+func (b *BitMEX) SendHTTPRequestContext(ctx context.Context, method, path string, params url.Values,
+	body interface{}, authenticated bool, result interface{}) error {
+	if authenticated && !b.AuthenticatedAPISupport {
+		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, b.Name)
+	}
+
+	requestPath := path
+	if len(params) > 0 {
+		requestPath += "?" + params.Encode()
+	}
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = common.JSONEncode(body); err != nil {
+			return err
+		}
+	}
+
+	if b.Debug(exchange.TraceHTTP) {
+		log.Printf("Request: %s %s %s\n", method, requestPath, payload)
+	}
+
+	headers := make(http.Header)
+	headers.Set("Accept", "application/json")
+	headers.Set("Content-Type", "application/json")
+	if authenticated {
+		apiKey, apiSecret, _ := b.Credentials()
+
+		expires := strconv.FormatInt(time.Now().Add(bitmexRequestExpiry).Unix(), 10)
+		headers.Set("api-key", apiKey)
+		headers.Set("api-expires", expires)
+		headers.Set("api-signature", b.sign(apiSecret, method, requestPath, expires, payload))
+	}
+
+	resp, statusCode, err := common.SendHTTPRequest2Context(ctx, method, b.APIUrl+requestPath, headers,
+		bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	if b.Debug(exchange.TraceHTTP) {
+		log.Printf("Received raw: \n%s\n", resp)
+	}
+
+	if 200 <= statusCode && statusCode <= 299 {
+		if err = common.JSONDecode([]byte(resp), result); err != nil {
+			return exchange.NewExchangeError(b.Name, path, statusCode, 0,
+				"failed to unmarshal response", resp)
+		}
+		return nil
+	}
+
+	var errResp ErrorResponse
+	if err = common.JSONDecode([]byte(resp), &errResp); err != nil {
+		return exchange.NewExchangeError(b.Name, path, statusCode, 0,
+			"failed to unmarshal error response", resp)
+	}
+	return exchange.NewExchangeError(b.Name, path, statusCode, 0, errResp.Error.Message, resp)
+}
+
+// sign returns the signature of a request, the hex encoded HMAC-SHA256 of the method, request
+// path (including the query string), expiry time & body.
+func (b *BitMEX) sign(apiSecret, method, requestPath, expires string, body []byte) string {
+	message := method + requestPath + expires + string(body)
+	return common.HexEncodeToString(common.GetHMAC(common.HashSHA256, []byte(message), []byte(apiSecret)))
+}
@@ -0,0 +1,141 @@
+package bitmex
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/asset"
+)
+
+const testInstruments = `[
+	{"symbol":"XBTUSD","rootSymbol":"XBT","state":"Open","typ":"FFWCSX","underlying":"XBT","quoteCurrency":"USD",
+		"settlCurrency":"XBt","tickSize":0.5,"lotSize":1,"multiplier":-100000000,"isInverse":true},
+	{"symbol":"XBTZ18","rootSymbol":"XBT","state":"Open","typ":"FFCCSX","underlying":"XBT","quoteCurrency":"USD",
+		"settlCurrency":"XBt","tickSize":0.5,"lotSize":1,"multiplier":-100000000,"isInverse":true},
+	{"symbol":"ETHUSD","rootSymbol":"ETH","state":"Open","typ":"FFWCSX","underlying":"ETH","quoteCurrency":"USD",
+		"settlCurrency":"XBt","tickSize":0.05,"lotSize":1,"multiplier":100,"isInverse":false}
+]`
+
+func newTestBitMEX(handler http.HandlerFunc) (*BitMEX, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	b := &BitMEX{}
+	b.SetDefaults()
+	b.APIUrl = server.URL
+	b.AuthenticatedAPISupport = true
+	b.SetAPIKeys("key", "secret", "", false)
+	return b, server
+}
+
+func TestSetInstruments(t *testing.T) {
+	b, server := newTestBitMEX(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testInstruments)
+	})
+	defer server.Close()
+
+	instruments, err := b.FetchActiveInstruments()
+	if err != nil {
+		t.Fatalf("Test failed. FetchActiveInstruments returned an error: %s", err)
+	}
+	b.setInstruments(instruments)
+	if len(b.GetCurrencyPairs()) != 2 {
+		t.Errorf("Test failed. Expected only the perpetual swaps, got %v", b.GetCurrencyPairs())
+	}
+	info := b.GetCurrencyPairs()["XBTUSD"]
+	if info == nil || info.AssetType != asset.PerpetualSwap || !info.Inverse || info.ContractSize != 1 ||
+		info.SettlementCurrency != "XBT" || info.Currency.Pair().String() != "XBTUSD" {
+		t.Errorf("Test failed. Unexpected XBTUSD contract %+v", info)
+	}
+
+	ethusd := pair.NewCurrencyPair("ETH", "USD")
+	if b.CurrencyPairToSymbol(ethusd) != "ETHUSD" {
+		t.Errorf("Test failed. Unexpected symbol %s", b.CurrencyPairToSymbol(ethusd))
+	}
+	limits := b.GetLimits()
+	if limits.GetPriceDecimalPlaces(ethusd) != 2 || limits.GetAmountDecimalPlaces(ethusd) != 0 ||
+		limits.GetMinAmount(ethusd) != 1 {
+		t.Error("Test failed. Unexpected limits")
+	}
+}
+
+func TestSendHTTPRequestSigned(t *testing.T) {
+	b, server := newTestBitMEX(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		expected := (&BitMEX{}).sign("secret", r.Method, r.URL.RequestURI(), r.Header.Get("api-expires"), body)
+		if r.Header.Get("api-signature") != expected || r.Header.Get("api-key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":{"message":"Signature not valid.","name":"HTTPError"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"orderID":"f1a2b3","symbol":"XBTUSD","side":"Buy","orderQty":100,"price":6500,
+			"ordType":"Limit","ordStatus":"New","cumQty":0,"leavesQty":100}`)
+	})
+	defer server.Close()
+
+	id, err := b.NewOrder(pair.NewCurrencyPair("XBT", "USD"), 100, 6500, exchange.OrderSideBuy,
+		exchange.OrderTypeExchangeLimit, exchange.OrderOptions{Hidden: true})
+	if err != nil || id != "f1a2b3" {
+		t.Errorf("Test failed. Unexpected order ID %s %v", id, err)
+	}
+
+	b.APISecret = "wrong"
+	_, err = b.FetchMargins()
+	if e, ok := err.(*exchange.ExchangeError); !ok || e.Message != "Signature not valid." ||
+		e.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Test failed. Expected an invalid signature error but got %v", err)
+	}
+}
+
+func TestGetPositions(t *testing.T) {
+	b, server := newTestBitMEX(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case bitmexActiveInstruments:
+			fmt.Fprint(w, testInstruments)
+		case bitmexPositionPath:
+			fmt.Fprint(w, `[{"symbol":"XBTUSD","currency":"XBt","currentQty":-500,"avgEntryPrice":6400,
+				"leverage":10,"liquidationPrice":7000,"unrealisedPnl":250000,"isOpen":true},
+				{"symbol":"ETHUSD","currency":"XBt","currentQty":0,"isOpen":false}]`)
+		case bitmexLeveragePath:
+			body, _ := ioutil.ReadAll(r.Body)
+			if string(body) != `{"leverage":5,"symbol":"XBTUSD"}` {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, `{"error":{"message":"unexpected body %s","name":"ValidationError"}}`, body)
+				return
+			}
+			fmt.Fprint(w, `{"symbol":"XBTUSD","leverage":5}`)
+		}
+	})
+	defer server.Close()
+
+	instruments, _ := b.FetchActiveInstruments()
+	b.setInstruments(instruments)
+	positions, err := b.GetPositions()
+	if err != nil || len(positions) != 1 {
+		t.Fatalf("Test failed. Unexpected positions %+v %v", positions, err)
+	}
+	p := positions[0]
+	if p.Side != exchange.OrderSideSell || p.Amount != 500 || p.Leverage != 10 || p.LiquidationPrice != 7000 ||
+		p.ProfitLoss != 0.0025 || p.CurrencyPair.Pair().String() != "XBTUSD" || p.AssetType != asset.PerpetualSwap {
+		t.Errorf("Test failed. Unexpected position %+v", p)
+	}
+
+	if err = b.SetLeverage(pair.NewCurrencyPair("XBT", "USD"), 5); err != nil {
+		t.Errorf("Test failed. SetLeverage returned an error: %s", err)
+	}
+}
+
+func TestConvertOrder(t *testing.T) {
+	b := &BitMEX{}
+	b.SetDefaults()
+	order := b.convertOrderToExchangeOrder(&Order{OrderID: "1", Symbol: "XBTUSD", Side: OrderSideSell,
+		OrderQty: 100, CumQty: 40, LeavesQty: 60, Price: 6500, OrdType: OrderTypeLimit,
+		OrdStatus: OrderStatusPartiallyFilled})
+	if order.Status != exchange.OrderStatusActive || order.Side != exchange.OrderSideSell ||
+		order.FilledAmount != 40 || order.RemainingAmount != 60 || order.AssetType != asset.PerpetualSwap {
+		t.Errorf("Test failed. Unexpected order %+v", order)
+	}
+}
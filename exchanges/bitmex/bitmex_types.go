@@ -0,0 +1,127 @@
+package bitmex
+
+import (
+	"time"
+)
+
+// Instrument types (the CFI code of the instrument)
+const (
+	InstrumentTypePerpetual = "FFWCSX"
+	InstrumentTypeFutures   = "FFCCSX"
+)
+
+// Instrument states
+const (
+	InstrumentStateOpen = "Open"
+)
+
+// Order sides
+const (
+	OrderSideBuy  = "Buy"
+	OrderSideSell = "Sell"
+)
+
+// Order types
+const (
+	OrderTypeLimit  = "Limit"
+	OrderTypeMarket = "Market"
+)
+
+// Order statuses
+const (
+	OrderStatusNew             = "New"
+	OrderStatusPartiallyFilled = "PartiallyFilled"
+	OrderStatusFilled          = "Filled"
+	OrderStatusCanceled        = "Canceled"
+	OrderStatusRejected        = "Rejected"
+)
+
+// ErrorResponse is the body of a rejected request
+type ErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Name    string `json:"name"`
+	} `json:"error"`
+}
+
+// Instrument holds the contract specification & market data of an instrument
+type Instrument struct {
+	Symbol        string    `json:"symbol"`
+	RootSymbol    string    `json:"rootSymbol"`
+	State         string    `json:"state"`
+	Type          string    `json:"typ"`
+	Expiry        time.Time `json:"expiry"`
+	Underlying    string    `json:"underlying"`
+	QuoteCurrency string    `json:"quoteCurrency"`
+	SettlCurrency string    `json:"settlCurrency"`
+	TickSize      float64   `json:"tickSize"`
+	LotSize       float64   `json:"lotSize"`
+	// Value of a contract in satoshis (negative for inverse contracts)
+	Multiplier float64   `json:"multiplier"`
+	IsInverse  bool      `json:"isInverse"`
+	LastPrice  float64   `json:"lastPrice"`
+	BidPrice   float64   `json:"bidPrice"`
+	AskPrice   float64   `json:"askPrice"`
+	HighPrice  float64   `json:"highPrice"`
+	LowPrice   float64   `json:"lowPrice"`
+	Volume24h  float64   `json:"volume24h"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// OrderBookL2Entry is a price level of the full orderbook
+type OrderBookL2Entry struct {
+	Symbol string  `json:"symbol"`
+	ID     int64   `json:"id"`
+	Side   string  `json:"side"`
+	Size   float64 `json:"size"`
+	Price  float64 `json:"price"`
+}
+
+// Margin holds the margin balance of a currency, amounts of XBt are in satoshis
+type Margin struct {
+	Currency        string  `json:"currency"`
+	WalletBalance   float64 `json:"walletBalance"`
+	MarginBalance   float64 `json:"marginBalance"`
+	AvailableMargin float64 `json:"availableMargin"`
+}
+
+// OrderRequest holds the parameters of a new order
+type OrderRequest struct {
+	Symbol   string  `json:"symbol"`
+	Side     string  `json:"side"`
+	OrderQty float64 `json:"orderQty"`
+	Price    float64 `json:"price,omitempty"`
+	OrdType  string  `json:"ordType"`
+	// Amount of the order shown in the orderbook, zero hides the order
+	DisplayQty *float64 `json:"displayQty,omitempty"`
+	ClOrdID    string   `json:"clOrdID,omitempty"`
+}
+
+// Order holds the details of an order
+type Order struct {
+	OrderID   string    `json:"orderID"`
+	ClOrdID   string    `json:"clOrdID"`
+	Symbol    string    `json:"symbol"`
+	Side      string    `json:"side"`
+	OrderQty  float64   `json:"orderQty"`
+	Price     float64   `json:"price"`
+	OrdType   string    `json:"ordType"`
+	OrdStatus string    `json:"ordStatus"`
+	CumQty    float64   `json:"cumQty"`
+	LeavesQty float64   `json:"leavesQty"`
+	AvgPx     float64   `json:"avgPx"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Position holds the position in an instrument, CurrentQty is negative for short positions
+type Position struct {
+	Symbol           string  `json:"symbol"`
+	Currency         string  `json:"currency"`
+	CurrentQty       float64 `json:"currentQty"`
+	AvgEntryPrice    float64 `json:"avgEntryPrice"`
+	Leverage         float64 `json:"leverage"`
+	CrossMargin      bool    `json:"crossMargin"`
+	LiquidationPrice float64 `json:"liquidationPrice"`
+	UnrealisedPnl    float64 `json:"unrealisedPnl"`
+	IsOpen           bool    `json:"isOpen"`
+}
@@ -0,0 +1,555 @@
+package bitmex
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/asset"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+var _ exchange.IExchange = (*BitMEX)(nil)
+var _ exchange.ContextExchange = (*BitMEX)(nil)
+
+// New returns a BitMEX exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *BitMEX {
+	b := &BitMEX{}
+	b.SetDefaults()
+	b.Quickstart(b.Setup, apiKey, apiSecret, opts...)
+	return b
+}
+
+// SetDefaults sets the basic defaults for BitMEX
+func (b *BitMEX) SetDefaults() {
+	b.Name = "BitMEX"
+	b.APIUrl = bitmexBaseURL
+	b.Enabled = false
+	b.Verbose = false
+	b.Websocket = false
+	b.RESTPollingDelay = 10
+	b.TakerFee = 0.075
+	b.MakerFee = -0.025
+	b.RequestCurrencyPairFormat.Delimiter = ""
+	b.RequestCurrencyPairFormat.Uppercase = true
+	b.ConfigCurrencyPairFormat.Delimiter = "-"
+	b.ConfigCurrencyPairFormat.Uppercase = true
+	b.AssetTypes = []string{asset.PerpetualSwap}
+	b.Orderbooks = orderbook.Init()
+}
+
+// Setup takes in the supplied exchange configuration details and sets params
+func (b *BitMEX) Setup(exch config.ExchangeConfig) {
+	if !exch.Enabled {
+		b.SetEnabled(false)
+	} else {
+		b.Enabled = true
+		b.AuthenticatedAPISupport = exch.AuthenticatedAPISupport
+		b.SetAPIKeys(exch.APIKey, exch.APISecret, "", false)
+		b.RESTPollingDelay = exch.RESTPollingDelay
+		b.Verbose = exch.Verbose
+		b.Websocket = exch.Websocket
+		b.SetAPIURL(exch)
+		b.BaseCurrencies = common.SplitStrings(exch.BaseCurrencies, ",")
+		b.AvailablePairs = common.SplitStrings(exch.AvailablePairs, ",")
+		b.EnabledPairs = common.SplitStrings(exch.EnabledPairs, ",")
+		err := b.SetCurrencyPairFormat()
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = b.SetAssetTypes()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// Start starts the BitMEX go routine
+func (b *BitMEX) Start() {
+	go b.Run()
+}
+
+// Run implements the BitMEX wrapper
+func (b *BitMEX) Run() {
+	if b.Debug("") {
+		log.Printf("%s polling delay: %ds.\n", b.GetName(), b.RESTPollingDelay)
+		log.Printf("%s %d currencies enabled: %s.\n", b.GetName(), len(b.EnabledPairs), b.EnabledPairs)
+	}
+
+	instruments, err := b.FetchActiveInstruments()
+	if err != nil {
+		log.Printf("%s failed to get instruments\n", b.GetName())
+		return
+	}
+	b.setInstruments(instruments)
+
+	var exchangeProducts []string
+	for _, info := range b.currencyPairs {
+		exchangeProducts = append(exchangeProducts, info.Currency.Display("-", true).String())
+	}
+	err = b.UpdateAvailableCurrencies(exchangeProducts, false)
+	if err != nil {
+		log.Printf("%s failed to update available currencies\n", b.Name)
+	}
+}
+
+// setInstruments replaces the currency pairs & trading rules of the exchange, only the open
+// perpetual swaps are kept.
+func (b *BitMEX) setInstruments(instruments []Instrument) {
+	b.symbolCache.Reset()
+	b.currencyPairs = make(map[pair.CurrencyItem]*exchange.CurrencyPairInfo)
+	b.symbolDetailsMap = make(map[pair.CurrencyItem]*symbolDetails)
+	b.symbols = make(map[pair.CurrencyItem]string)
+	for i := range instruments {
+		instrument := &instruments[i]
+		if instrument.Type != InstrumentTypePerpetual || instrument.State != InstrumentStateOpen {
+			continue
+		}
+		currencyPair := pair.NewCurrencyPair(instrument.Underlying, instrument.QuoteCurrency)
+		info := &exchange.CurrencyPairInfo{
+			Currency:           currencyPair,
+			AssetType:          asset.PerpetualSwap,
+			Inverse:            instrument.IsInverse,
+			SettlementCurrency: settlementCurrency(instrument.SettlCurrency),
+		}
+		if instrument.IsInverse {
+			// The multiplier of inverse contracts is the value of a contract (in the quote
+			// currency) in satoshis, e.g. -100000000 for the 1 USD XBTUSD contract. The value of
+			// quanto contracts depends on the price so their contract size is left at zero.
+			info.ContractSize = math.Abs(instrument.Multiplier) / bitmexSatoshisPerBitcoin
+		}
+		b.currencyPairs[pair.CurrencyItem(instrument.Symbol)] = info
+		b.symbols[currencyPair.Display("/", true)] = instrument.Symbol
+		b.symbolDetailsMap[currencyPair.Display("/", false)] = &symbolDetails{
+			PriceDecimalPlaces:  decimalPlaces(instrument.TickSize),
+			AmountDecimalPlaces: decimalPlaces(instrument.LotSize),
+			MinAmount:           instrument.LotSize,
+		}
+	}
+}
+
+// settlementCurrency converts the settlement currency of a contract to a currency code, BitMEX
+// uses XBt (satoshis) for bitcoin
+func settlementCurrency(currency string) string {
+	if currency == "XBt" {
+		return "XBT"
+	}
+	return strings.ToUpper(currency)
+}
+
+// decimalPlaces returns the number of decimal places of an increment, e.g. 2 for 0.01 & 1 for 0.5
+func decimalPlaces(increment float64) int32 {
+	if increment <= 0 {
+		return -1
+	}
+	s := strconv.FormatFloat(increment, 'f', -1, 64)
+	if dot := strings.IndexByte(s, '.'); dot >= 0 {
+		return int32(len(s) - dot - 1)
+	}
+	return 0
+}
+
+// UpdateTicker updates and returns the ticker for a currency pair
+func (b *BitMEX) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return b.UpdateTickerContext(context.Background(), p, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (b *BitMEX) UpdateTickerContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
+	var tickerPrice ticker.Price
+	instrument, err := b.fetchInstrument(ctx, b.CurrencyPairToSymbol(p))
+	if err != nil {
+		return tickerPrice, err
+	}
+	tickerPrice.Pair = p
+	tickerPrice.Ask = instrument.AskPrice
+	tickerPrice.Bid = instrument.BidPrice
+	tickerPrice.Last = instrument.LastPrice
+	tickerPrice.High = instrument.HighPrice
+	tickerPrice.Low = instrument.LowPrice
+	tickerPrice.Volume = instrument.Volume24h
+	tickerPrice.LastUpdated = instrument.Timestamp
+	ticker.ProcessTicker(b.GetName(), p, tickerPrice, assetType)
+	return ticker.GetTicker(b.Name, p, assetType)
+}
+
+// GetTickerPrice returns the ticker for a currency pair
+func (b *BitMEX) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	tick, err := ticker.GetTicker(b.GetName(), p, assetType)
+	if err != nil {
+		return b.UpdateTicker(p, assetType)
+	}
+	return tick, nil
+}
+
+// GetOrderbookEx returns the orderbook for a currency pair
+func (b *BitMEX) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
+	ob, err := b.Orderbooks.GetOrderbook(b.GetName(), p, assetType)
+	if err != nil {
+		return b.UpdateOrderbook(p, assetType)
+	}
+	return ob, nil
+}
+
+// UpdateOrderbook updates and returns the orderbook for a currency pair, the amounts are in
+// contracts.
+func (b *BitMEX) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return b.UpdateOrderbookContext(context.Background(), p, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (b *BitMEX) UpdateOrderbookContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
+	book := orderbook.Base{}
+	entries, err := b.fetchOrderBookL2(ctx, b.CurrencyPairToSymbol(p), bitmexDefaultBookDepth)
+	if err != nil {
+		return book, err
+	}
+
+	book.Asks = orderbook.GetItems(len(entries) / 2)
+	book.Bids = orderbook.GetItems(len(entries) / 2)
+	for _, entry := range entries {
+		item := orderbook.Item{Price: entry.Price, Amount: entry.Size}
+		if entry.Side == OrderSideSell {
+			book.Asks = append(book.Asks, item)
+		} else {
+			book.Bids = append(book.Bids, item)
+		}
+	}
+	sort.Slice(book.Asks, func(i, j int) bool { return book.Asks[i].Price < book.Asks[j].Price })
+	sort.Slice(book.Bids, func(i, j int) bool { return book.Bids[i].Price > book.Bids[j].Price })
+
+	b.Orderbooks.ProcessOrderbook(b.Name, p, book, assetType)
+	return b.Orderbooks.GetOrderbook(b.Name, p, assetType)
+}
+
+// GetExchangeAccountInfo retrieves the margin balances of the BitMEX account, the balances
+// include the unrealised profit & loss of the open positions.
+func (b *BitMEX) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return b.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (b *BitMEX) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
+	result := exchange.AccountInfo{}
+	result.ExchangeName = b.Name
+
+	if !b.Enabled {
+		return result, nil
+	}
+
+	margins, err := b.fetchMargins(ctx)
+	if err != nil {
+		return result, err
+	}
+	result.Currencies = make([]exchange.AccountCurrencyInfo, len(margins))
+	for i, src := range margins {
+		scale := 1.0
+		if src.Currency == "XBt" {
+			scale = bitmexSatoshisPerBitcoin
+		}
+		dest := &result.Currencies[i]
+		dest.CurrencyName = settlementCurrency(src.Currency)
+		dest.TotalValue = src.MarginBalance / scale
+		dest.Available = src.AvailableMargin / scale
+		dest.Hold = (src.MarginBalance - src.AvailableMargin) / scale
+	}
+	return result, nil
+}
+
+// NewOrder creates a new order on the exchange, the amount is in contracts.
+// Returns the ID of the new exchange order.
+func (b *BitMEX) NewOrder(p pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return b.NewOrderContext(context.Background(), p, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (b *BitMEX) NewOrderContext(ctx context.Context, p pair.CurrencyPair, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	if err := b.GetCapabilities().CheckOrderOptions(opts...); err != nil {
+		return "", err
+	}
+	if orderType != exchange.OrderTypeExchangeLimit {
+		panic("not implemented")
+	}
+	req := &OrderRequest{
+		Symbol:   b.CurrencyPairToSymbol(p),
+		OrderQty: amount,
+		Price:    price,
+		OrdType:  OrderTypeLimit,
+	}
+	switch side {
+	case exchange.OrderSideBuy:
+		req.Side = OrderSideBuy
+	case exchange.OrderSideSell:
+		req.Side = OrderSideSell
+	default:
+		return "", fmt.Errorf("can't create order on %s exchange invalid value '%s' for side", b.Name, side)
+	}
+	for _, o := range opts {
+		if o.Hidden {
+			displayQty := 0.0
+			req.DisplayQty = &displayQty
+		} else if o.VisibleAmount != 0 {
+			displayQty := o.VisibleAmount
+			req.DisplayQty = &displayQty
+		}
+		if o.ClientOrderID != "" {
+			req.ClOrdID = o.ClientOrderID
+		}
+	}
+
+	result, err := b.placeOrder(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return result.OrderID, nil
+}
+
+// GetCapabilities returns the capabilities of the exchange
+func (b *BitMEX) GetCapabilities() exchange.Capabilities {
+	capabilities := b.Base.GetCapabilities()
+	capabilities.HiddenOrders = true
+	capabilities.IcebergOrders = true
+	capabilities.ClientOrderIDs = true
+	return capabilities
+}
+
+// CancelOrder will attempt to cancel the active order matching the given ID.
+func (b *BitMEX) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return b.CancelOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (b *BitMEX) CancelOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) error {
+	_, err := b.deleteOrder(ctx, orderID)
+	return err
+}
+
+// GetOrder returns information about a previously placed order (which may be active or inactive).
+func (b *BitMEX) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	return b.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (b *BitMEX) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	orders, err := b.fetchOrders(ctx, "", map[string]interface{}{"orderID": orderID})
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, exchange.ErrOrderNotFound
+	}
+	return b.convertOrderToExchangeOrder(&orders[0]), nil
+}
+
+// GetOrders returns information about currently active orders, the orders of all the
+// instruments are returned if no pairs are given.
+func (b *BitMEX) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return b.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (b *BitMEX) GetOrdersContext(ctx context.Context, pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	orders, err := b.fetchOrders(ctx, "", map[string]interface{}{"open": true})
+	if err != nil {
+		return nil, err
+	}
+	ret := []*exchange.Order{}
+	for i := range orders {
+		order := b.convertOrderToExchangeOrder(&orders[i])
+		if len(pairs) > 0 && !containsPair(pairs, order.CurrencyPair) {
+			continue
+		}
+		ret = append(ret, order)
+	}
+	return ret, nil
+}
+
+func containsPair(pairs []pair.CurrencyPair, p pair.CurrencyPair) bool {
+	for _, x := range pairs {
+		if x.Equal(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *BitMEX) convertOrderToExchangeOrder(order *Order) *exchange.Order {
+	retOrder := &exchange.Order{}
+	retOrder.OrderID = order.OrderID
+	retOrder.InternalOrderID = order.ClOrdID
+	retOrder.AssetType = asset.PerpetualSwap
+
+	switch order.OrdStatus {
+	case OrderStatusNew, OrderStatusPartiallyFilled:
+		retOrder.Status = exchange.OrderStatusActive
+	case OrderStatusFilled:
+		retOrder.Status = exchange.OrderStatusFilled
+	case OrderStatusCanceled, OrderStatusRejected:
+		retOrder.Status = exchange.OrderStatusAborted
+	default:
+		retOrder.Status = exchange.OrderStatusUnknown
+	}
+
+	retOrder.Amount = order.OrderQty
+	retOrder.FilledAmount = order.CumQty
+	retOrder.RemainingAmount = order.LeavesQty
+	retOrder.Rate = order.Price
+	retOrder.CreatedAt = order.Timestamp.Unix()
+	if p, err := b.SymbolToCurrencyPair(order.Symbol); err == nil {
+		retOrder.CurrencyPair = p
+	} else {
+		retOrder.CurrencyPair = pair.NewCurrencyPairFromString(order.Symbol)
+	}
+	if order.Side == OrderSideSell {
+		retOrder.Side = exchange.OrderSideSell
+	} else {
+		retOrder.Side = exchange.OrderSideBuy
+	}
+	if order.OrdType == OrderTypeLimit {
+		retOrder.Type = exchange.OrderTypeExchangeLimit
+	} else {
+		log.Printf("BitMEX.convertOrderToExchangeOrder(): unexpected '%s' order", order.OrdType)
+	}
+
+	return retOrder
+}
+
+// GetPositions returns the open positions, the amounts are in contracts & the profit/loss is in
+// the settlement currency.
+func (b *BitMEX) GetPositions() ([]exchange.Position, error) {
+	positions, err := b.FetchPositions()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]exchange.Position, 0, len(positions))
+	for _, p := range positions {
+		if !p.IsOpen || p.CurrentQty == 0 {
+			continue
+		}
+		position := exchange.Position{
+			ID:               p.Symbol,
+			Side:             exchange.OrderSideBuy,
+			Amount:           math.Abs(p.CurrentQty),
+			BasePrice:        p.AvgEntryPrice,
+			ProfitLoss:       p.UnrealisedPnl,
+			AssetType:        asset.PerpetualSwap,
+			Leverage:         p.Leverage,
+			LiquidationPrice: p.LiquidationPrice,
+		}
+		if p.Currency == "XBt" {
+			position.ProfitLoss /= bitmexSatoshisPerBitcoin
+		}
+		if p.CurrentQty < 0 {
+			position.Side = exchange.OrderSideSell
+		}
+		if cp, err := b.SymbolToCurrencyPair(p.Symbol); err == nil {
+			position.CurrencyPair = cp
+		} else {
+			position.CurrencyPair = pair.NewCurrencyPairFromString(p.Symbol)
+		}
+		result = append(result, position)
+	}
+	return result, nil
+}
+
+// SetLeverage sets the leverage of the position in the currency pair, zero switches the position
+// to cross margin.
+func (b *BitMEX) SetLeverage(p pair.CurrencyPair, leverage float64) error {
+	_, err := b.ChooseLeverage(b.CurrencyPairToSymbol(p), leverage)
+	return err
+}
+
+// GetLimits returns price/amount limits for the exchange.
+func (b *BitMEX) GetLimits() exchange.ILimits {
+	return newCurrencyLimits(b.Name, b.symbolDetailsMap)
+}
+
+// GetCurrencyPairs returns currency pairs that can be used by the exchange account
+// associated with this bot. Use FormatExchangeCurrency to get the right key.
+func (b *BitMEX) GetCurrencyPairs() map[pair.CurrencyItem]*exchange.CurrencyPairInfo {
+	return b.currencyPairs
+}
+
+// ListInstruments returns the perpetual swaps that are currently trading on the exchange
+func (b *BitMEX) ListInstruments() ([]exchange.Instrument, error) {
+	instruments, err := b.FetchActiveInstruments()
+	if err != nil {
+		return nil, err
+	}
+	result := []exchange.Instrument{}
+	for i := range instruments {
+		if instruments[i].Type != InstrumentTypePerpetual || instruments[i].State != InstrumentStateOpen {
+			continue
+		}
+		result = append(result, exchange.Instrument{
+			Symbol: instruments[i].Symbol,
+			Pair:   pair.NewCurrencyPair(instruments[i].Underlying, instruments[i].QuoteCurrency),
+		})
+	}
+	return result, nil
+}
+
+type symbolDetails struct {
+	PriceDecimalPlaces  int32
+	AmountDecimalPlaces int32
+	MinAmount           float64
+}
+
+type currencyLimits struct {
+	exchangeName string
+	// Maps currency pair (lower-case, delimited by "/") to symbol details
+	data map[pair.CurrencyItem]*symbolDetails
+}
+
+func newCurrencyLimits(exchangeName string, data map[pair.CurrencyItem]*symbolDetails) *currencyLimits {
+	return &currencyLimits{exchangeName, data}
+}
+
+// Returns max number of decimal places allowed in the trade price for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetPriceDecimalPlaces(p pair.CurrencyPair) int32 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.PriceDecimalPlaces
+	}
+	return -1
+}
+
+// Returns max number of decimal places allowed in the trade amount for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetAmountDecimalPlaces(p pair.CurrencyPair) int32 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.AmountDecimalPlaces
+	}
+	return -1
+}
+
+// Returns the minimum trade amount (in contracts) for the given currency pair.
+func (cl *currencyLimits) GetMinAmount(p pair.CurrencyPair) float64 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.MinAmount
+	}
+	return 0
+}
+
+// Returns the minimum trade total (amount * price) for the given currency pair, BitMEX only
+// limits the order amount.
+func (cl *currencyLimits) GetMinTotal(p pair.CurrencyPair) float64 {
+	return 0
+}
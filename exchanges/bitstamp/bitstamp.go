@@ -54,6 +54,16 @@ type Bitstamp struct {
 	Balance Balances
 }
 
+// New returns a Bitstamp exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+// The customer ID must be set with exchange.WithClientID to use the authenticated API.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *Bitstamp {
+	b := &Bitstamp{}
+	b.SetDefaults()
+	b.Quickstart(b.Setup, apiKey, apiSecret, opts...)
+	return b
+}
+
 // SetDefaults sets default for Bitstamp
 func (b *Bitstamp) SetDefaults() {
 	b.Name = "Bitstamp"
@@ -486,6 +496,8 @@ func (b *Bitstamp) TransferAccountBalance(amount float64, currency, subAccount s
 
 // SendAuthenticatedHTTPRequest sends an authenticated request
 func (b *Bitstamp) SendAuthenticatedHTTPRequest(path string, v2 bool, values url.Values, result interface{}) (err error) {
+	apiKey, apiSecret, clientID := b.Credentials()
+
 	if !b.AuthenticatedAPISupport {
 		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, b.Name)
 	}
@@ -500,9 +512,9 @@ func (b *Bitstamp) SendAuthenticatedHTTPRequest(path string, v2 bool, values url
 		values = url.Values{}
 	}
 
-	values.Set("key", b.APIKey)
+	values.Set("key", apiKey)
 	values.Set("nonce", b.Nonce.String())
-	hmac := common.GetHMAC(common.HashSHA256, []byte(b.Nonce.String()+b.ClientID+b.APIKey), []byte(b.APISecret))
+	hmac := common.GetHMAC(common.HashSHA256, []byte(b.Nonce.String()+clientID+apiKey), []byte(apiSecret))
 	values.Set("signature", common.StringToUpper(common.HexEncodeToString(hmac)))
 
 	if v2 {
@@ -1,6 +1,7 @@
 package bittrex
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -23,6 +24,7 @@ import (
 const (
 	bittrexAPIURL              = "https://bittrex.com/api/v1.1"
 	bittrexAPIVersion          = "v1.1"
+	bittrexAPIVersion2         = "v2.0"
 	bittrexMaxOpenOrders       = 500
 	bittrexMaxOrderCountPerDay = 200000
 	bittrexTimeFormat          = "2006-01-02T15:04:05"
@@ -42,6 +44,7 @@ const (
 	bittrexAPIGetMarketSummary   = "public/getmarketsummary"
 	bittrexAPIGetOrderbook       = "public/getorderbook"
 	bittrexAPIGetMarketHistory   = "public/getmarkethistory"
+	bittrexAPIGetTicks           = "pub/market/GetTicks"
 
 	// Market requests
 	bittrexAPIBuyLimit      = "market/buylimit"
@@ -67,15 +70,29 @@ type Bittrex struct {
 	currencyPairs map[pair.CurrencyItem]*exchange.CurrencyPairInfo
 	// Maps currency pair to min trade size (in base/first currency in the pair)
 	minTradeSizes map[pair.CurrencyItem]float64
+	// Caches the results of the bulk symbol <-> currency pair conversions
+	symbolCache exchange.SymbolCache
+}
+
+// New returns a Bittrex exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *Bittrex {
+	b := &Bittrex{}
+	b.SetDefaults()
+	b.Quickstart(b.Setup, apiKey, apiSecret, opts...)
+	return b
 }
 
 // SetDefaults method assignes the default values for Bittrex
 func (b *Bittrex) SetDefaults() {
 	b.Name = "Bittrex"
+	b.APIUrl = bittrexAPIURL
 	b.Enabled = false
 	b.Verbose = false
 	b.Websocket = false
 	b.RESTPollingDelay = 10
+	b.TakerFee = 0.25
+	b.MakerFee = 0.25
 	b.RequestCurrencyPairFormat.Delimiter = "-"
 	b.RequestCurrencyPairFormat.Uppercase = true
 	b.ConfigCurrencyPairFormat.Delimiter = "-"
@@ -95,6 +112,7 @@ func (b *Bittrex) Setup(exch config.ExchangeConfig) {
 		b.RESTPollingDelay = exch.RESTPollingDelay
 		b.Verbose = exch.Verbose
 		b.Websocket = exch.Websocket
+		b.SetAPIURL(exch)
 		b.BaseCurrencies = common.SplitStrings(exch.BaseCurrencies, ",")
 		// Bittrex doesn't follow common conventions for currency pairs, it inverts the
 		// currencies for some bizare reason. The currency pairs in the config file should really
@@ -129,6 +147,20 @@ func (b *Bittrex) SymbolToCurrencyPair(symbol string) pair.CurrencyPair {
 	return p.Invert()
 }
 
+// CurrencyPairsToSymbols converts a batch of currency pairs to symbols, conversions are cached.
+func (b *Bittrex) CurrencyPairsToSymbols(pairs []pair.CurrencyPair) ([]string, error) {
+	return b.symbolCache.CurrencyPairsToSymbols(pairs, func(cp pair.CurrencyPair) (string, error) {
+		return b.CurrencyPairToSymbol(cp), nil
+	})
+}
+
+// SymbolsToCurrencyPairs converts a batch of symbols to currency pairs, conversions are cached.
+func (b *Bittrex) SymbolsToCurrencyPairs(symbols []string) ([]pair.CurrencyPair, error) {
+	return b.symbolCache.SymbolsToCurrencyPairs(symbols, func(symbol string) (pair.CurrencyPair, error) {
+		return b.SymbolToCurrencyPair(symbol), nil
+	})
+}
+
 type currencyLimits struct {
 	exchangeName string
 	// Maps currency pair to min trade size (in base/first currency in the pair)
@@ -183,7 +215,7 @@ func (b *Bittrex) GetCurrencyPairs() map[pair.CurrencyItem]*exchange.CurrencyPai
 // along with other meta data.
 func (b *Bittrex) GetMarkets() ([]Market, error) {
 	var markets []Market
-	path := fmt.Sprintf("%s/%s/", bittrexAPIURL, bittrexAPIGetMarkets)
+	path := fmt.Sprintf("%s/%s/", b.APIUrl, bittrexAPIGetMarkets)
 
 	return markets, b.HTTPRequest(path, false, url.Values{}, &markets)
 }
@@ -191,7 +223,7 @@ func (b *Bittrex) GetMarkets() ([]Market, error) {
 // GetCurrencies is used to get all supported currencies at Bittrex
 func (b *Bittrex) GetCurrencies() ([]Currency, error) {
 	var currencies []Currency
-	path := fmt.Sprintf("%s/%s/", bittrexAPIURL, bittrexAPIGetCurrencies)
+	path := fmt.Sprintf("%s/%s/", b.APIUrl, bittrexAPIGetCurrencies)
 
 	return currencies, b.HTTPRequest(path, false, url.Values{}, &currencies)
 }
@@ -200,7 +232,7 @@ func (b *Bittrex) GetCurrencies() ([]Currency, error) {
 // on the supplied currency. Example currency input param "btc-ltc".
 func (b *Bittrex) GetTicker(currencyPair string) (Ticker, error) {
 	ticker := Ticker{}
-	path := fmt.Sprintf("%s/%s?market=%s", bittrexAPIURL, bittrexAPIGetTicker,
+	path := fmt.Sprintf("%s/%s?market=%s", b.APIUrl, bittrexAPIGetTicker,
 		common.StringToUpper(currencyPair),
 	)
 	return ticker, b.HTTPRequest(path, false, url.Values{}, &ticker)
@@ -210,7 +242,7 @@ func (b *Bittrex) GetTicker(currencyPair string) (Ticker, error) {
 // exchanges
 func (b *Bittrex) GetMarketSummaries() ([]MarketSummary, error) {
 	var summaries []MarketSummary
-	path := fmt.Sprintf("%s/%s/", bittrexAPIURL, bittrexAPIGetMarketSummaries)
+	path := fmt.Sprintf("%s/%s/", b.APIUrl, bittrexAPIGetMarketSummaries)
 
 	return summaries, b.HTTPRequest(path, false, url.Values{}, &summaries)
 }
@@ -218,11 +250,15 @@ func (b *Bittrex) GetMarketSummaries() ([]MarketSummary, error) {
 // GetMarketSummary is used to get the last 24 hour summary of all active
 // exchanges by currency pair (btc-ltc).
 func (b *Bittrex) GetMarketSummary(currencyPair string) ([]MarketSummary, error) {
+	return b.getMarketSummary(context.Background(), currencyPair)
+}
+
+func (b *Bittrex) getMarketSummary(ctx context.Context, currencyPair string) ([]MarketSummary, error) {
 	var summary []MarketSummary
-	path := fmt.Sprintf("%s/%s?market=%s", bittrexAPIURL,
+	path := fmt.Sprintf("%s/%s?market=%s", b.APIUrl,
 		bittrexAPIGetMarketSummary, common.StringToLower(currencyPair),
 	)
-	return summary, b.HTTPRequest(path, false, url.Values{}, &summary)
+	return summary, b.HTTPRequestContext(ctx, path, false, url.Values{}, &summary)
 }
 
 // GetOrderbook method returns current order book information by currency, type
@@ -233,25 +269,47 @@ func (b *Bittrex) GetMarketSummary(currencyPair string) ([]MarketSummary, error)
 // "Depth" max depth is 50 but you can literally set it any integer you want and
 // it returns full depth. So depth default is 50.
 func (b *Bittrex) GetOrderbook(currencyPair string) (OrderBooks, error) {
+	return b.getOrderbook(context.Background(), currencyPair)
+}
+
+func (b *Bittrex) getOrderbook(ctx context.Context, currencyPair string) (OrderBooks, error) {
 	var orderbooks OrderBooks
-	path := fmt.Sprintf("%s/%s?market=%s&type=both&depth=50", bittrexAPIURL,
+	path := fmt.Sprintf("%s/%s?market=%s&type=both&depth=50", b.APIUrl,
 		bittrexAPIGetOrderbook, common.StringToUpper(currencyPair),
 	)
 
-	return orderbooks, b.HTTPRequest(path, false, url.Values{}, &orderbooks)
+	return orderbooks, b.HTTPRequestContext(ctx, path, false, url.Values{}, &orderbooks)
 }
 
 // GetMarketHistory retrieves the latest trades that have occurred for a specific
 // market
 func (b *Bittrex) GetMarketHistory(currencyPair string) ([]MarketHistory, error) {
 	var marketHistoriae []MarketHistory
-	path := fmt.Sprintf("%s/%s?market=%s", bittrexAPIURL,
+	path := fmt.Sprintf("%s/%s?market=%s", b.APIUrl,
 		bittrexAPIGetMarketHistory, common.StringToUpper(currencyPair),
 	)
 	return marketHistoriae, b.HTTPRequest(path, false, url.Values{},
 		&marketHistoriae)
 }
 
+// GetTicks retrieves candle data for a specific market from the v2.0 API (v1.1 doesn't have an
+// equivalent endpoint).
+// "Currency Pair" ie btc-ltc
+// "Tick Interval" one of oneMin, fiveMin, thirtyMin, hour, day
+func (b *Bittrex) GetTicks(currencyPair, tickInterval string) ([]Tick, error) {
+	var ticks []Tick
+	path := fmt.Sprintf("%s/%s?marketName=%s&tickInterval=%s", b.apiV2URL(),
+		bittrexAPIGetTicks, common.StringToUpper(currencyPair), tickInterval,
+	)
+	return ticks, b.HTTPRequest(path, false, url.Values{}, &ticks)
+}
+
+// apiV2URL returns the base URL of the v2.0 API, derived from the v1.1 API URL so that an
+// overridden (e.g. testnet) API URL applies to both versions.
+func (b *Bittrex) apiV2URL() string {
+	return strings.TrimSuffix(b.APIUrl, bittrexAPIVersion) + bittrexAPIVersion2
+}
+
 // PlaceBuyLimit is used to place a buy order in a specific market. Use buylimit
 // to place limit orders. Make sure you have the proper permissions set on your
 // API keys for this call to work.
@@ -259,14 +317,19 @@ func (b *Bittrex) GetMarketHistory(currencyPair string) ([]MarketHistory, error)
 // "Quantity" is the amount to purchase
 // "Rate" is the rate at which to purchase
 func (b *Bittrex) PlaceBuyLimit(currencyPair string, quantity, rate float64) (string, error) {
+	return b.placeBuyLimit(context.Background(), currencyPair, quantity, rate)
+}
+
+func (b *Bittrex) placeBuyLimit(ctx context.Context, currencyPair string, quantity,
+	rate float64) (string, error) {
 	var response UUID
 	values := url.Values{}
 	values.Set("market", currencyPair)
 	values.Set("quantity", strconv.FormatFloat(quantity, 'E', -1, 64))
 	values.Set("rate", strconv.FormatFloat(rate, 'E', -1, 64))
-	path := fmt.Sprintf("%s/%s", bittrexAPIURL, bittrexAPIBuyLimit)
+	path := fmt.Sprintf("%s/%s", b.APIUrl, bittrexAPIBuyLimit)
 
-	return response.ID, b.HTTPRequest(path, true, values, &response)
+	return response.ID, b.HTTPRequestContext(ctx, path, true, values, &response)
 }
 
 // PlaceSellLimit is used to place a sell order in a specific market. Use
@@ -276,36 +339,56 @@ func (b *Bittrex) PlaceBuyLimit(currencyPair string, quantity, rate float64) (st
 // "Quantity" is the amount to purchase
 // "Rate" is the rate at which to purchase
 func (b *Bittrex) PlaceSellLimit(currencyPair string, quantity, rate float64) (string, error) {
+	return b.placeSellLimit(context.Background(), currencyPair, quantity, rate)
+}
+
+func (b *Bittrex) placeSellLimit(ctx context.Context, currencyPair string, quantity,
+	rate float64) (string, error) {
 	var response UUID
 	values := url.Values{}
 	values.Set("market", currencyPair)
 	values.Set("quantity", strconv.FormatFloat(quantity, 'E', -1, 64))
 	values.Set("rate", strconv.FormatFloat(rate, 'E', -1, 64))
-	path := fmt.Sprintf("%s/%s", bittrexAPIURL, bittrexAPISellLimit)
+	path := fmt.Sprintf("%s/%s", b.APIUrl, bittrexAPISellLimit)
 
-	return response.ID, b.HTTPRequest(path, true, values, &response)
+	return response.ID, b.HTTPRequestContext(ctx, path, true, values, &response)
 }
 
 // GetOpenOrders returns all orders that you currently have opened.
 // A specific market can be requested for example "btc-ltc"
 func (b *Bittrex) GetOpenOrders(currencyPair string) ([]Order, error) {
+	return b.getOpenOrders(context.Background(), currencyPair)
+}
+
+func (b *Bittrex) getOpenOrders(ctx context.Context, currencyPair string) ([]Order, error) {
 	var orders []Order
 	values := url.Values{}
 	if !(currencyPair == "" || currencyPair == " ") {
 		values.Set("market", currencyPair)
 	}
-	path := fmt.Sprintf("%s/%s", bittrexAPIURL, bittrexAPIGetOpenOrders)
+	path := fmt.Sprintf("%s/%s", b.APIUrl, bittrexAPIGetOpenOrders)
 
-	return orders, b.HTTPRequest(path, true, values, &orders)
+	return orders, b.HTTPRequestContext(ctx, path, true, values, &orders)
 }
 
 func (b *Bittrex) CancelOrder(uuid string, currencyPair pair.CurrencyPair) error {
-	_, err := b.cancelOrder(uuid)
+	return b.CancelOrderContext(context.Background(), uuid, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (b *Bittrex) CancelOrderContext(ctx context.Context, uuid string, currencyPair pair.CurrencyPair) error {
+	_, err := b.cancelOrder(ctx, uuid)
 	return err
 }
 
 func (b *Bittrex) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
-	order, err := b.getOrder(orderID)
+	return b.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (b *Bittrex) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	order, err := b.getOrder(ctx, orderID)
 	if err != nil {
 		return nil, err
 	}
@@ -363,14 +446,24 @@ func (b *Bittrex) convertOrderToExchangeOrder(orderID string, order *Order) *exc
 
 func (b *Bittrex) NewOrder(
 	currencyPair pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
-	ordertype exchange.OrderType) (string, error) {
+	ordertype exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return b.NewOrderContext(context.Background(), currencyPair, amount, price, side, ordertype, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (b *Bittrex) NewOrderContext(ctx context.Context, currencyPair pair.CurrencyPair, amount,
+	price float64, side exchange.OrderSide, ordertype exchange.OrderType,
+	opts ...exchange.OrderOptions) (string, error) {
+	if err := b.GetCapabilities().CheckOrderOptions(opts...); err != nil {
+		return "", err
+	}
 	symbol := b.CurrencyPairToSymbol(currencyPair)
 	var orderID string
 	var err error
 	if side == exchange.OrderSideBuy {
-		orderID, err = b.PlaceBuyLimit(symbol, amount, price)
+		orderID, err = b.placeBuyLimit(ctx, symbol, amount, price)
 	} else if side == exchange.OrderSideSell {
-		orderID, err = b.PlaceSellLimit(symbol, amount, price)
+		orderID, err = b.placeSellLimit(ctx, symbol, amount, price)
 	} else {
 		return "", fmt.Errorf("can't create order on %s exchange invalid value '%s' for side", b.Name, side)
 	}
@@ -382,10 +475,16 @@ func (b *Bittrex) NewOrder(
 }
 
 func (b *Bittrex) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return b.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (b *Bittrex) GetOrdersContext(ctx context.Context,
+	pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
 	ret := []*exchange.Order{}
 
 	// TODO: filter out orders that don't match the given pairs
-	orders, err := b.GetOpenOrders("")
+	orders, err := b.getOpenOrders(ctx, "")
 	if err != nil {
 		return ret, err
 	}
@@ -397,21 +496,25 @@ func (b *Bittrex) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error
 }
 
 // CancelOrder is used to cancel a buy or sell order.
-func (b *Bittrex) cancelOrder(uuid string) ([]Balance, error) {
+func (b *Bittrex) cancelOrder(ctx context.Context, uuid string) ([]Balance, error) {
 	var balances []Balance
 	values := url.Values{}
 	values.Set("uuid", uuid)
-	path := fmt.Sprintf("%s/%s", bittrexAPIURL, bittrexAPICancel)
+	path := fmt.Sprintf("%s/%s", b.APIUrl, bittrexAPICancel)
 
-	return balances, b.HTTPRequest(path, true, values, &balances)
+	return balances, b.HTTPRequestContext(ctx, path, true, values, &balances)
 }
 
 // GetAccountBalances is used to retrieve all balances from your account
 func (b *Bittrex) GetAccountBalances() ([]Balance, error) {
+	return b.getAccountBalances(context.Background())
+}
+
+func (b *Bittrex) getAccountBalances(ctx context.Context) ([]Balance, error) {
 	var balances []Balance
-	path := fmt.Sprintf("%s/%s", bittrexAPIURL, bittrexAPIGetBalances)
+	path := fmt.Sprintf("%s/%s", b.APIUrl, bittrexAPIGetBalances)
 
-	return balances, b.HTTPRequest(path, true, url.Values{}, &balances)
+	return balances, b.HTTPRequestContext(ctx, path, true, url.Values{}, &balances)
 }
 
 // GetAccountBalanceByCurrency is used to retrieve the balance from your account
@@ -420,7 +523,7 @@ func (b *Bittrex) GetAccountBalanceByCurrency(currency string) (Balance, error)
 	var balance Balance
 	values := url.Values{}
 	values.Set("currency", currency)
-	path := fmt.Sprintf("%s/%s", bittrexAPIURL, bittrexAPIGetBalance)
+	path := fmt.Sprintf("%s/%s", b.APIUrl, bittrexAPIGetBalance)
 
 	return balance, b.HTTPRequest(path, true, values, &balance)
 }
@@ -432,7 +535,7 @@ func (b *Bittrex) GetDepositAddress(currency string) (DepositAddress, error) {
 	var address DepositAddress
 	values := url.Values{}
 	values.Set("currency", currency)
-	path := fmt.Sprintf("%s/%s", bittrexAPIURL, bittrexAPIGetDepositAddress)
+	path := fmt.Sprintf("%s/%s", b.APIUrl, bittrexAPIGetDepositAddress)
 
 	return address, b.HTTPRequest(path, true, values, &address)
 }
@@ -445,19 +548,19 @@ func (b *Bittrex) Withdraw(currency, paymentID, address string, quantity float64
 	values.Set("currency", currency)
 	values.Set("quantity", strconv.FormatFloat(quantity, 'E', -1, 64))
 	values.Set("address", address)
-	path := fmt.Sprintf("%s/%s", bittrexAPIURL, bittrexAPIWithdraw)
+	path := fmt.Sprintf("%s/%s", b.APIUrl, bittrexAPIWithdraw)
 
 	return id, b.HTTPRequest(path, true, values, &id)
 }
 
 // GetOrder is used to retrieve a single order by UUID.
-func (b *Bittrex) getOrder(uuid string) (Order, error) {
+func (b *Bittrex) getOrder(ctx context.Context, uuid string) (Order, error) {
 	var order Order
 	values := url.Values{}
 	values.Set("uuid", uuid)
-	path := fmt.Sprintf("%s/%s", bittrexAPIURL, bittrexAPIGetOrder)
+	path := fmt.Sprintf("%s/%s", b.APIUrl, bittrexAPIGetOrder)
 
-	msg, err := b.HTTPRequestJSON(path, true, values)
+	msg, err := b.HTTPRequestJSONContext(ctx, path, true, values)
 	if err != nil {
 		return order, err
 	}
@@ -489,7 +592,7 @@ func (b *Bittrex) GetOrderHistory(currencyPair string) ([]Order, error) {
 	if !(currencyPair == "" || currencyPair == " ") {
 		values.Set("market", currencyPair)
 	}
-	path := fmt.Sprintf("%s/%s", bittrexAPIURL, bittrexAPIGetOrderHistory)
+	path := fmt.Sprintf("%s/%s", b.APIUrl, bittrexAPIGetOrderHistory)
 
 	return orders, b.HTTPRequest(path, true, values, &orders)
 }
@@ -503,7 +606,7 @@ func (b *Bittrex) GetWithdrawalHistory(currency string) ([]WithdrawalHistory, er
 	if !(currency == "" || currency == " ") {
 		values.Set("currency", currency)
 	}
-	path := fmt.Sprintf("%s/%s", bittrexAPIURL, bittrexAPIGetWithdrawalHistory)
+	path := fmt.Sprintf("%s/%s", b.APIUrl, bittrexAPIGetWithdrawalHistory)
 
 	return history, b.HTTPRequest(path, true, values, &history)
 }
@@ -517,7 +620,7 @@ func (b *Bittrex) GetDepositHistory(currency string) ([]WithdrawalHistory, error
 	if !(currency == "" || currency == " ") {
 		values.Set("currency", currency)
 	}
-	path := fmt.Sprintf("%s/%s", bittrexAPIURL, bittrexAPIGetDepositHistory)
+	path := fmt.Sprintf("%s/%s", b.APIUrl, bittrexAPIGetDepositHistory)
 
 	return history, b.HTTPRequest(path, true, values, &history)
 }
@@ -525,6 +628,15 @@ func (b *Bittrex) GetDepositHistory(currency string) ([]WithdrawalHistory, error
 // SendAuthenticatedHTTPRequest sends an authenticated http request to a desired
 // path
 func (b *Bittrex) SendAuthenticatedHTTPRequest(path string, values url.Values, result interface{}) (err error) {
+	return b.SendAuthenticatedHTTPRequestContext(context.Background(), path, values, result)
+}
+
+// SendAuthenticatedHTTPRequestContext is SendAuthenticatedHTTPRequest, the request is cancelled
+// when the context is done
+func (b *Bittrex) SendAuthenticatedHTTPRequestContext(ctx context.Context, path string,
+	values url.Values, result interface{}) (err error) {
+	apiKey, apiSecret, _ := b.Credentials()
+
 	if !b.AuthenticatedAPISupport {
 		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, b.Name)
 	}
@@ -534,24 +646,24 @@ func (b *Bittrex) SendAuthenticatedHTTPRequest(path string, values url.Values, r
 	} else {
 		b.Nonce.Inc()
 	}
-	values.Set("apikey", b.APIKey)
-	values.Set("apisecret", b.APISecret)
+	values.Set("apikey", apiKey)
+	values.Set("apisecret", apiSecret)
 	values.Set("nonce", b.Nonce.String())
 	rawQuery := path + "?" + values.Encode()
 	hmac := common.GetHMAC(
-		common.HashSHA512, []byte(rawQuery), []byte(b.APISecret),
+		common.HashSHA512, []byte(rawQuery), []byte(apiSecret),
 	)
 	headers := make(map[string]string)
 	headers["apisign"] = common.HexEncodeToString(hmac)
 
-	resp, err := common.SendHTTPRequest(
+	resp, err := common.SendHTTPRequestContext(ctx,
 		"GET", rawQuery, headers, strings.NewReader(""),
 	)
 	if err != nil {
 		return err
 	}
 
-	if b.Verbose {
+	if b.Debug(exchange.TraceHTTP) {
 		log.Printf("Received raw: %s\n", resp)
 	}
 
@@ -564,13 +676,20 @@ func (b *Bittrex) SendAuthenticatedHTTPRequest(path string, values url.Values, r
 
 // HTTPRequest sends an HTTP request to a Bittrex API endpoint and and returns the result as raw JSON.
 func (b *Bittrex) HTTPRequestJSON(path string, auth bool, values url.Values) (json.RawMessage, error) {
+	return b.HTTPRequestJSONContext(context.Background(), path, auth, values)
+}
+
+// HTTPRequestJSONContext is HTTPRequestJSON, the request is cancelled when the context is done
+func (b *Bittrex) HTTPRequestJSONContext(ctx context.Context, path string, auth bool,
+	values url.Values) (json.RawMessage, error) {
 	response := Response{}
 	if auth {
-		if err := b.SendAuthenticatedHTTPRequest(path, values, &response); err != nil {
+		if err := b.SendAuthenticatedHTTPRequestContext(ctx, path, values, &response); err != nil {
 			return nil, err
 		}
 	} else {
-		if err := common.SendHTTPGetRequest(path, true, b.Verbose, &response); err != nil {
+		if err := common.SendHTTPGetRequestContext(ctx, path, true, b.Debug(exchange.TraceHTTP),
+			&response); err != nil {
 			return nil, err
 		}
 	}
@@ -582,7 +701,13 @@ func (b *Bittrex) HTTPRequestJSON(path string, auth bool, values url.Values) (js
 
 // HTTPRequest is a generalised http request function.
 func (b *Bittrex) HTTPRequest(path string, auth bool, values url.Values, v interface{}) error {
-	msg, err := b.HTTPRequestJSON(path, auth, values)
+	return b.HTTPRequestContext(context.Background(), path, auth, values, v)
+}
+
+// HTTPRequestContext is HTTPRequest, the request is cancelled when the context is done
+func (b *Bittrex) HTTPRequestContext(ctx context.Context, path string, auth bool, values url.Values,
+	v interface{}) error {
+	msg, err := b.HTTPRequestJSONContext(ctx, path, auth, values)
 	if err != nil {
 		return err
 	}
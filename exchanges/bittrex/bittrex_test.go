@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
 )
 
 // Please supply you own test keys here to run better tests.
@@ -53,6 +54,7 @@ func TestSetup(t *testing.T) {
 func TestGetMarkets(t *testing.T) {
 	t.Parallel()
 	obj := Bittrex{}
+	obj.SetDefaults()
 	_, err := obj.GetMarkets()
 	if err != nil {
 		t.Errorf("Test Failed - Bittrex - GetMarkets() error: %s", err)
@@ -62,6 +64,7 @@ func TestGetMarkets(t *testing.T) {
 func TestGetCurrencies(t *testing.T) {
 	t.Parallel()
 	obj := Bittrex{}
+	obj.SetDefaults()
 	_, err := obj.GetCurrencies()
 	if err != nil {
 		t.Errorf("Test Failed - Bittrex - GetCurrencies() error: %s", err)
@@ -75,6 +78,7 @@ func TestGetTicker(t *testing.T) {
 	doge := "btc-DOGE"
 
 	obj := Bittrex{}
+	obj.SetDefaults()
 	_, err := obj.GetTicker(invalid)
 	if err == nil {
 		t.Error("Test Failed - Bittrex - GetTicker() error")
@@ -92,6 +96,7 @@ func TestGetTicker(t *testing.T) {
 func TestGetMarketSummaries(t *testing.T) {
 	t.Parallel()
 	obj := Bittrex{}
+	obj.SetDefaults()
 	_, err := obj.GetMarketSummaries()
 	if err != nil {
 		t.Errorf("Test Failed - Bittrex - GetMarketSummaries() error: %s", err)
@@ -104,6 +109,7 @@ func TestGetMarketSummary(t *testing.T) {
 	invalid := "WigWham"
 
 	obj := Bittrex{}
+	obj.SetDefaults()
 	_, err := obj.GetMarketSummary(pairOne)
 	if err != nil {
 		t.Errorf("Test Failed - Bittrex - GetMarketSummary() error: %s", err)
@@ -117,6 +123,7 @@ func TestGetMarketSummary(t *testing.T) {
 func TestGetOrderbook(t *testing.T) {
 	t.Parallel()
 	obj := Bittrex{}
+	obj.SetDefaults()
 	_, err := obj.GetOrderbook("btc-ltc")
 	if err != nil {
 		t.Errorf("Test Failed - Bittrex - GetOrderbook() error: %s", err)
@@ -130,6 +137,7 @@ func TestGetOrderbook(t *testing.T) {
 func TestGetMarketHistory(t *testing.T) {
 	t.Parallel()
 	obj := Bittrex{}
+	obj.SetDefaults()
 	_, err := obj.GetMarketHistory("btc-ltc")
 	if err != nil {
 		t.Errorf("Test Failed - Bittrex - GetMarketHistory() error: %s", err)
@@ -140,9 +148,37 @@ func TestGetMarketHistory(t *testing.T) {
 	}
 }
 
+func TestAPIV2URL(t *testing.T) {
+	t.Parallel()
+	obj := Bittrex{}
+	obj.SetDefaults()
+	if url := obj.apiV2URL(); url != "https://bittrex.com/api/v2.0" {
+		t.Errorf("Test Failed - Bittrex - unexpected v2.0 API URL %s", url)
+	}
+	obj.APIUrl = "https://testnet.bittrex.com/api/v1.1"
+	if url := obj.apiV2URL(); url != "https://testnet.bittrex.com/api/v2.0" {
+		t.Errorf("Test Failed - Bittrex - unexpected v2.0 API URL %s", url)
+	}
+}
+
+func TestGetTicks(t *testing.T) {
+	t.Parallel()
+	obj := Bittrex{}
+	obj.SetDefaults()
+	_, err := obj.GetTicks("btc-ltc", "hour")
+	if err != nil {
+		t.Errorf("Test Failed - Bittrex - GetTicks() error: %s", err)
+	}
+	_, err = obj.GetTicks("malum", "hour")
+	if err == nil {
+		t.Errorf("Test Failed - Bittrex - GetTicks() error")
+	}
+}
+
 func TestPlaceBuyLimit(t *testing.T) {
 	t.Parallel()
 	obj := Bittrex{}
+	obj.SetDefaults()
 	obj.APIKey = apiKey
 	obj.APISecret = apiSecret
 	_, err := obj.PlaceBuyLimit("btc-ltc", 1, 1)
@@ -154,6 +190,7 @@ func TestPlaceBuyLimit(t *testing.T) {
 func TestPlaceSellLimit(t *testing.T) {
 	t.Parallel()
 	obj := Bittrex{}
+	obj.SetDefaults()
 	obj.APIKey = apiKey
 	obj.APISecret = apiSecret
 	_, err := obj.PlaceSellLimit("btc-ltc", 1, 1)
@@ -165,6 +202,7 @@ func TestPlaceSellLimit(t *testing.T) {
 func TestGetOpenOrders(t *testing.T) {
 	t.Parallel()
 	obj := Bittrex{}
+	obj.SetDefaults()
 	obj.APIKey = apiKey
 	obj.APISecret = apiSecret
 	_, err := obj.GetOpenOrders("")
@@ -180,9 +218,10 @@ func TestGetOpenOrders(t *testing.T) {
 func TestCancelOrder(t *testing.T) {
 	t.Parallel()
 	obj := Bittrex{}
+	obj.SetDefaults()
 	obj.APIKey = apiKey
 	obj.APISecret = apiSecret
-	err := obj.CancelOrder("blaaaaaaa")
+	err := obj.CancelOrder("blaaaaaaa", pair.NewCurrencyPair("BTC", "LTC"))
 	if err == nil {
 		t.Error("Test Failed - Bittrex - CancelOrder() error")
 	}
@@ -191,6 +230,7 @@ func TestCancelOrder(t *testing.T) {
 func TestGetAccountBalances(t *testing.T) {
 	t.Parallel()
 	obj := Bittrex{}
+	obj.SetDefaults()
 	obj.APIKey = apiKey
 	obj.APISecret = apiSecret
 	_, err := obj.GetAccountBalances()
@@ -202,6 +242,7 @@ func TestGetAccountBalances(t *testing.T) {
 func TestGetAccountBalanceByCurrency(t *testing.T) {
 	t.Parallel()
 	obj := Bittrex{}
+	obj.SetDefaults()
 	obj.APIKey = apiKey
 	obj.APISecret = apiSecret
 	_, err := obj.GetAccountBalanceByCurrency("btc")
@@ -213,6 +254,7 @@ func TestGetAccountBalanceByCurrency(t *testing.T) {
 func TestGetDepositAddress(t *testing.T) {
 	t.Parallel()
 	obj := Bittrex{}
+	obj.SetDefaults()
 	obj.APIKey = apiKey
 	obj.APISecret = apiSecret
 	_, err := obj.GetDepositAddress("btc")
@@ -224,6 +266,7 @@ func TestGetDepositAddress(t *testing.T) {
 func TestWithdraw(t *testing.T) {
 	t.Parallel()
 	obj := Bittrex{}
+	obj.SetDefaults()
 	obj.APIKey = apiKey
 	obj.APISecret = apiSecret
 	_, err := obj.Withdraw("btc", "something", "someplace", 1)
@@ -235,13 +278,14 @@ func TestWithdraw(t *testing.T) {
 func TestGetOrder(t *testing.T) {
 	t.Parallel()
 	obj := Bittrex{}
+	obj.SetDefaults()
 	obj.APIKey = apiKey
 	obj.APISecret = apiSecret
-	_, err := obj.GetOrder("0cb4c4e4-bdc7-4e13-8c13-430e587d2cc1")
+	_, err := obj.GetOrder("0cb4c4e4-bdc7-4e13-8c13-430e587d2cc1", pair.NewCurrencyPair("BTC", "LTC"))
 	if err == nil {
 		t.Error("Test Failed - Bittrex - GetOrder() error")
 	}
-	_, err = obj.GetOrder("")
+	_, err = obj.GetOrder("", pair.NewCurrencyPair("BTC", "LTC"))
 	if err == nil {
 		t.Error("Test Failed - Bittrex - GetOrder() error")
 	}
@@ -250,6 +294,7 @@ func TestGetOrder(t *testing.T) {
 func TestGetOrderHistory(t *testing.T) {
 	t.Parallel()
 	obj := Bittrex{}
+	obj.SetDefaults()
 	obj.APIKey = apiKey
 	obj.APISecret = apiSecret
 	_, err := obj.GetOrderHistory("")
@@ -265,6 +310,7 @@ func TestGetOrderHistory(t *testing.T) {
 func TestGetWithdrawelHistory(t *testing.T) {
 	t.Parallel()
 	obj := Bittrex{}
+	obj.SetDefaults()
 	obj.APIKey = apiKey
 	obj.APISecret = apiSecret
 	_, err := obj.GetWithdrawalHistory("")
@@ -280,6 +326,7 @@ func TestGetWithdrawelHistory(t *testing.T) {
 func TestGetDepositHistory(t *testing.T) {
 	t.Parallel()
 	obj := Bittrex{}
+	obj.SetDefaults()
 	obj.APIKey = apiKey
 	obj.APISecret = apiSecret
 	_, err := obj.GetDepositHistory("")
@@ -291,3 +338,21 @@ func TestGetDepositHistory(t *testing.T) {
 		t.Error("Test Failed - Bittrex - GetDepositHistory() error")
 	}
 }
+
+func BenchmarkConvertOrderToExchangeOrder(b *testing.B) {
+	exch := Bittrex{}
+	exch.SetDefaults()
+	order := Order{
+		OrderUUID:         "09aa5bb6-8232-41aa-9b78-a5a1093e0211",
+		Exchange:          "BTC-LTC",
+		Type:              "LIMIT_BUY",
+		Quantity:          5,
+		QuantityRemaining: 2,
+		Limit:             0.0125,
+		Opened:            "2014-07-13T07:45:46.27",
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		exch.convertOrderToExchangeOrder(order.OrderUUID, &order)
+	}
+}
@@ -147,3 +147,14 @@ type WithdrawalHistory struct {
 	Canceled       bool    `json:"Canceled"`
 	InvalidAddress bool    `json:"InvalidAddress"`
 }
+
+// Tick holds candle data returned by the v2.0 API
+type Tick struct {
+	Open       float64 `json:"O"`
+	High       float64 `json:"H"`
+	Low        float64 `json:"L"`
+	Close      float64 `json:"C"`
+	Volume     float64 `json:"V"`
+	BaseVolume float64 `json:"BV"`
+	Timestamp  string  `json:"T"`
+}
@@ -1,7 +1,9 @@
 package bittrex
 
 import (
+	"context"
 	"log"
+	"time"
 
 	"github.com/mattkanwisher/cryptofiend/common"
 	"github.com/mattkanwisher/cryptofiend/currency/pair"
@@ -11,6 +13,10 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+var _ exchange.IExchange = (*Bittrex)(nil)
+var _ exchange.ContextExchange = (*Bittrex)(nil)
+var _ exchange.Withdrawer = (*Bittrex)(nil)
+
 // Start starts the Bittrex go routine
 func (b *Bittrex) Start() {
 	go b.Run()
@@ -18,7 +24,7 @@ func (b *Bittrex) Start() {
 
 // Run implements the Bittrex wrapper
 func (b *Bittrex) Run() {
-	if b.Verbose {
+	if b.Debug("") {
 		log.Printf("%s polling delay: %ds.\n", b.GetName(), b.RESTPollingDelay)
 		log.Printf("%s %d currencies enabled: %s.\n", b.GetName(), len(b.EnabledPairs), b.EnabledPairs)
 	}
@@ -74,9 +80,15 @@ func (b *Bittrex) Run() {
 // GetExchangeAccountInfo Retrieves balances for all enabled currencies for the
 // Bittrex exchange
 func (b *Bittrex) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return b.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (b *Bittrex) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
 	var response exchange.AccountInfo
 	response.ExchangeName = b.GetName()
-	accountBalance, err := b.GetAccountBalances()
+	accountBalance, err := b.getAccountBalances(ctx)
 	if err != nil {
 		return response, err
 	}
@@ -97,8 +109,14 @@ func (b *Bittrex) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
 
 // UpdateTicker updates and returns the ticker for a currency pair
 func (b *Bittrex) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return b.UpdateTickerContext(context.Background(), p, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (b *Bittrex) UpdateTickerContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
 	var tickerPrice ticker.Price
-	tick, err := b.GetMarketSummary(exchange.FormatExchangeCurrency(b.GetName(), p).String())
+	tick, err := b.getMarketSummary(ctx, exchange.FormatExchangeCurrency(b.GetName(), p).String())
 	if err != nil {
 		return tickerPrice, err
 	}
@@ -121,7 +139,7 @@ func (b *Bittrex) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.
 }
 
 // GetOrderbookEx returns the orderbook for a currency pair
-func (b *Bittrex) GetOrderbookEx(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+func (b *Bittrex) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
 	ob, err := b.Orderbooks.GetOrderbook(b.GetName(), p, assetType)
 	if err == nil {
 		return b.UpdateOrderbook(p, assetType)
@@ -131,13 +149,20 @@ func (b *Bittrex) GetOrderbookEx(p pair.CurrencyPair, assetType string) (orderbo
 
 // UpdateOrderbook updates and returns the orderbook for a currency pair
 func (b *Bittrex) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return b.UpdateOrderbookContext(context.Background(), p, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (b *Bittrex) UpdateOrderbookContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
 	var orderBook orderbook.Base
 	symbol := b.CurrencyPairToSymbol(p)
-	orderbookNew, err := b.GetOrderbook(symbol)
+	orderbookNew, err := b.getOrderbook(ctx, symbol)
 	if err != nil {
 		return orderBook, err
 	}
 
+	orderBook.Bids = orderbook.GetItems(len(orderbookNew.Buy))
 	for x := range orderbookNew.Buy {
 		orderBook.Bids = append(orderBook.Bids,
 			orderbook.Item{
@@ -147,6 +172,7 @@ func (b *Bittrex) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderb
 		)
 	}
 
+	orderBook.Asks = orderbook.GetItems(len(orderbookNew.Sell))
 	for x := range orderbookNew.Sell {
 		orderBook.Asks = append(orderBook.Asks,
 			orderbook.Item{
@@ -183,3 +209,95 @@ func (b *Bittrex) GetAvailableCurrencies() []pair.CurrencyPair {
 	}
 	return pairs
 }
+
+// Maps candle intervals to the tick intervals supported by Bittrex
+var bittrexTickIntervals = map[exchange.CandleInterval]string{
+	exchange.CandleInterval1m:  "oneMin",
+	exchange.CandleInterval5m:  "fiveMin",
+	exchange.CandleInterval30m: "thirtyMin",
+	exchange.CandleInterval1h:  "hour",
+	exchange.CandleInterval1d:  "day",
+}
+
+// GetCandles returns the candles for the given currency pair and interval, ordered from oldest
+// to newest.
+func (b *Bittrex) GetCandles(currencyPair pair.CurrencyPair, interval exchange.CandleInterval) ([]exchange.Candle, error) {
+	tickInterval, ok := bittrexTickIntervals[interval]
+	if !ok {
+		return nil, exchange.ErrUnsupportedCandleInterval(b.GetName(), interval)
+	}
+
+	ticks, err := b.GetTicks(b.CurrencyPairToSymbol(currencyPair), tickInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	candles := make([]exchange.Candle, 0, len(ticks))
+	for i := range ticks {
+		timestamp, err := time.Parse(bittrexTimeFormat, ticks[i].Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, exchange.Candle{
+			Timestamp: timestamp,
+			Open:      ticks[i].Open,
+			High:      ticks[i].High,
+			Low:       ticks[i].Low,
+			Close:     ticks[i].Close,
+			// Bittrex's "base" currency is the quote currency of the pair
+			Volume:      ticks[i].Volume,
+			QuoteVolume: ticks[i].BaseVolume,
+		})
+	}
+	return candles, nil
+}
+
+// GetCurrencyMetadata returns the long names & deposit networks of the currencies supported by
+// the exchange. Currencies deposited to a shared base address need a payment ID (tag).
+func (b *Bittrex) GetCurrencyMetadata() ([]exchange.CurrencyMetadata, error) {
+	currencies, err := b.GetCurrencies()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]exchange.CurrencyMetadata, 0, len(currencies))
+	for _, c := range currencies {
+		result = append(result, exchange.CurrencyMetadata{
+			Currency: c.Currency,
+			Name:     c.CurrencyLong,
+			Networks: []exchange.CurrencyNetwork{{
+				Network:           c.CoinType,
+				TagRequired:       c.BaseAddress != "",
+				WithdrawalEnabled: c.IsActive,
+				WithdrawalFee:     c.TxFee,
+				Confirmations:     c.MinConfirmation,
+			}},
+		})
+	}
+	return result, nil
+}
+
+// ListInstruments returns the markets that are currently active on the exchange
+func (b *Bittrex) ListInstruments() ([]exchange.Instrument, error) {
+	markets, err := b.GetMarkets()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]exchange.Instrument, 0, len(markets))
+	for i := range markets {
+		if !markets[i].IsActive || markets[i].MarketName == "" {
+			continue
+		}
+		result = append(result, exchange.Instrument{
+			Symbol: markets[i].MarketName,
+			Pair:   b.SymbolToCurrencyPair(markets[i].MarketName),
+		})
+	}
+	return result, nil
+}
+
+// WithdrawToAddress withdraws an amount of a currency to the given address, returns the UUID of
+// the withdrawal.
+func (b *Bittrex) WithdrawToAddress(currency, address string, amount float64) (string, error) {
+	id, err := b.Withdraw(currency, "", address, amount)
+	return id.ID, err
+}
@@ -48,6 +48,15 @@ type BTCC struct {
 	exchange.Base
 }
 
+// New returns a BTCC exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *BTCC {
+	b := &BTCC{}
+	b.SetDefaults()
+	b.Quickstart(b.Setup, apiKey, apiSecret, opts...)
+	return b
+}
+
 // SetDefaults sets default values for the exchange
 func (b *BTCC) SetDefaults() {
 	b.Name = "BTCC"
@@ -551,6 +560,8 @@ func (b *BTCC) CancelStopOrder(orderID int64, market string) {
 }
 
 func (b *BTCC) SendAuthenticatedHTTPRequest(method string, params []interface{}) (err error) {
+	apiKey, apiSecret, _ := b.Credentials()
+
 	if !b.AuthenticatedAPISupport {
 		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, b.Name)
 	}
@@ -560,7 +571,7 @@ func (b *BTCC) SendAuthenticatedHTTPRequest(method string, params []interface{})
 	} else {
 		b.Nonce.Inc()
 	}
-	encoded := fmt.Sprintf("tonce=%s&accesskey=%s&requestmethod=post&id=%d&method=%s&params=", b.Nonce.String()[0:16], b.APIKey, 1, method)
+	encoded := fmt.Sprintf("tonce=%s&accesskey=%s&requestmethod=post&id=%d&method=%s&params=", b.Nonce.String()[0:16], apiKey, 1, method)
 
 	if len(params) == 0 {
 		params = make([]interface{}, 0)
@@ -601,7 +612,7 @@ func (b *BTCC) SendAuthenticatedHTTPRequest(method string, params []interface{})
 		log.Println(encoded)
 	}
 
-	hmac := common.GetHMAC(common.HashSHA1, []byte(encoded), []byte(b.APISecret))
+	hmac := common.GetHMAC(common.HashSHA1, []byte(encoded), []byte(apiSecret))
 	postData := make(map[string]interface{})
 	postData["method"] = method
 	postData["params"] = params
@@ -619,7 +630,7 @@ func (b *BTCC) SendAuthenticatedHTTPRequest(method string, params []interface{})
 
 	headers := make(map[string]string)
 	headers["Content-type"] = "application/json-rpc"
-	headers["Authorization"] = "Basic " + common.Base64Encode([]byte(b.APIKey+":"+common.HexEncodeToString(hmac)))
+	headers["Authorization"] = "Basic " + common.Base64Encode([]byte(apiKey+":"+common.HexEncodeToString(hmac)))
 	headers["Json-Rpc-Tonce"] = b.Nonce.String()
 
 	resp, err := common.SendHTTPRequest("POST", apiURL, headers, strings.NewReader(string(data)))
@@ -0,0 +1,330 @@
+// Package btce implements the trading API of the defunct BTC-e exchange, which several exchanges
+// (e.g. Liqui, Tidex & YoBit) have adopted with few or no changes. Exchanges embed the Client and
+// set its name, API URL & currency pair formats.
+package btce
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/config"
+	exchange "github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+const (
+	btcePublicPath    = "/api/3/"
+	btcePrivatePath   = "/tapi/"
+	btceInfo          = "info"
+	btceTicker        = "ticker"
+	btceDepth         = "depth"
+	btceTrades        = "trades"
+	btceAccountInfo   = "getInfo"
+	btceTrade         = "Trade"
+	btceActiveOrders  = "ActiveOrders"
+	btceOrderInfo     = "OrderInfo"
+	btceCancelOrder   = "CancelOrder"
+	btceTradeHistory  = "TradeHistory"
+	btceWithdrawCoin  = "WithdrawCoin"
+	btceMaxDepthLimit = 2000
+)
+
+// Client is the client of an exchange implementing the BTC-e API, the API URL is the base URL of
+// the public (/api/3) & private (/tapi) APIs. Pairs are identified by their lower case currencies
+// delimited by "_" (e.g. eth_btc).
+type Client struct {
+	exchange.Base
+	Info Info
+	// Set if ActiveOrders returns the orders of all the pairs when no pair is given, the active
+	// orders are then fetched with a single request rather than one per pair.
+	AllPairsActiveOrders bool
+}
+
+// Setup takes in the supplied exchange configuration details and sets params
+func (c *Client) Setup(exch config.ExchangeConfig) {
+	if !exch.Enabled {
+		c.SetEnabled(false)
+	} else {
+		c.Enabled = true
+		c.AuthenticatedAPISupport = exch.AuthenticatedAPISupport
+		c.SetAPIKeys(exch.APIKey, exch.APISecret, "", false)
+		c.RESTPollingDelay = exch.RESTPollingDelay
+		c.Verbose = exch.Verbose
+		c.Websocket = exch.Websocket
+		c.SetAPIURL(exch)
+		c.BaseCurrencies = common.SplitStrings(exch.BaseCurrencies, ",")
+		c.AvailablePairs = common.SplitStrings(exch.AvailablePairs, ",")
+		c.EnabledPairs = common.SplitStrings(exch.EnabledPairs, ",")
+		err := c.SetCurrencyPairFormat()
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = c.SetAssetTypes()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// GetInfo fetches the trading rules & fees of all the pairs.
+func (c *Client) GetInfo() (Info, error) {
+	var info Info
+	return info, c.SendHTTPRequest(btceInfo, nil, &info)
+}
+
+// GetFee returns the trading fee (as a percentage) of a pair (e.g. eth_btc), the exchange info
+// must have been fetched.
+func (c *Client) GetFee(pair string) (float64, error) {
+	data, ok := c.Info.Pairs[common.StringToLower(pair)]
+	if !ok {
+		return 0, errors.New("currency pair does not exist")
+	}
+	return data.Fee, nil
+}
+
+// GetTicker fetches the tickers of one or more pairs delimited by "-" (e.g. eth_btc-ltc_btc),
+// keyed by pair. Invalid pairs are left out of the result rather than failing the whole request.
+func (c *Client) GetTicker(pairs string) (map[string]Ticker, error) {
+	return c.getTicker(context.Background(), pairs)
+}
+
+func (c *Client) getTicker(ctx context.Context, pairs string) (map[string]Ticker, error) {
+	v := url.Values{}
+	v.Set("ignore_invalid", "1")
+	var result map[string]Ticker
+	return result, c.SendHTTPRequestContext(ctx, btceTicker+"/"+pairs, v, &result)
+}
+
+// GetDepth fetches the orderbook of a pair, limit is the number of price levels of each side (150
+// by default, up to 2000).
+func (c *Client) GetDepth(pair string, limit int) (Orderbook, error) {
+	return c.getDepth(context.Background(), pair, limit)
+}
+
+func (c *Client) getDepth(ctx context.Context, pair string, limit int) (Orderbook, error) {
+	v := url.Values{}
+	if limit > 0 {
+		if limit > btceMaxDepthLimit {
+			limit = btceMaxDepthLimit
+		}
+		v.Set("limit", strconv.Itoa(limit))
+	}
+	var result map[string]Orderbook
+	err := c.SendHTTPRequestContext(ctx, btceDepth+"/"+pair, v, &result)
+	return result[pair], err
+}
+
+// GetTrades fetches the latest trades of a pair, limit is the number of trades (150 by default,
+// up to 2000).
+func (c *Client) GetTrades(pair string, limit int) ([]PublicTrade, error) {
+	v := url.Values{}
+	if limit > 0 {
+		v.Set("limit", strconv.Itoa(limit))
+	}
+	var result map[string][]PublicTrade
+	err := c.SendHTTPRequest(btceTrades+"/"+pair, v, &result)
+	return result[pair], err
+}
+
+// GetAccountInfo fetches the balances of the account & the privileges of the API key.
+func (c *Client) GetAccountInfo() (AccountInfo, error) {
+	return c.getAccountInfo(context.Background())
+}
+
+func (c *Client) getAccountInfo(ctx context.Context) (AccountInfo, error) {
+	var result AccountInfo
+	return result, c.SendAuthenticatedHTTPRequestContext(ctx, btceAccountInfo, url.Values{}, &result)
+}
+
+// Trade places a limit order to buy or sell amount of the first currency of the pair at rate.
+func (c *Client) Trade(pair, orderType string, amount, rate float64) (TradeResult, error) {
+	return c.trade(context.Background(), pair, orderType, amount, rate)
+}
+
+func (c *Client) trade(ctx context.Context, pair, orderType string, amount,
+	rate float64) (TradeResult, error) {
+	v := url.Values{}
+	v.Set("pair", pair)
+	v.Set("type", orderType)
+	v.Set("amount", strconv.FormatFloat(amount, 'f', -1, 64))
+	v.Set("rate", strconv.FormatFloat(rate, 'f', -1, 64))
+	var result TradeResult
+	return result, c.SendAuthenticatedHTTPRequestContext(ctx, btceTrade, v, &result)
+}
+
+// GetActiveOrders fetches the active orders of a pair keyed by order ID, the orders of all the
+// pairs are returned if the pair is empty (not supported by all the exchanges).
+func (c *Client) GetActiveOrders(pair string) (map[string]OrderInfo, error) {
+	return c.getActiveOrders(context.Background(), pair)
+}
+
+func (c *Client) getActiveOrders(ctx context.Context, pair string) (map[string]OrderInfo, error) {
+	v := url.Values{}
+	if pair != "" {
+		v.Set("pair", pair)
+	}
+	var result map[string]OrderInfo
+	return result, c.SendAuthenticatedHTTPRequestContext(ctx, btceActiveOrders, v, &result)
+}
+
+// GetOrderInfo fetches the details of an order, keyed by order ID.
+func (c *Client) GetOrderInfo(orderID string) (map[string]OrderInfo, error) {
+	return c.getOrderInfo(context.Background(), orderID)
+}
+
+func (c *Client) getOrderInfo(ctx context.Context, orderID string) (map[string]OrderInfo, error) {
+	v := url.Values{}
+	v.Set("order_id", orderID)
+	var result map[string]OrderInfo
+	return result, c.SendAuthenticatedHTTPRequestContext(ctx, btceOrderInfo, v, &result)
+}
+
+// CancelOrderByID cancels an active order.
+func (c *Client) CancelOrderByID(orderID string) (CancelResult, error) {
+	return c.cancelOrderByID(context.Background(), orderID)
+}
+
+func (c *Client) cancelOrderByID(ctx context.Context, orderID string) (CancelResult, error) {
+	v := url.Values{}
+	v.Set("order_id", orderID)
+	var result CancelResult
+	return result, c.SendAuthenticatedHTTPRequestContext(ctx, btceCancelOrder, v, &result)
+}
+
+// GetTradeHistory fetches the trades of the account keyed by trade ID, params can be used to
+// filter & page the trades (from, count, from_id, end_id, order, since & end). The trades of all
+// the pairs are returned if the pair is empty.
+func (c *Client) GetTradeHistory(params url.Values, pair string) (map[string]TradeHistory, error) {
+	v := url.Values{}
+	for key, values := range params {
+		v[key] = values
+	}
+	if pair != "" {
+		v.Set("pair", pair)
+	}
+	var result map[string]TradeHistory
+	return result, c.SendAuthenticatedHTTPRequest(btceTradeHistory, v, &result)
+}
+
+// WithdrawCoins withdraws amount of a currency to an address, the API key must have the withdraw
+// privilege.
+func (c *Client) WithdrawCoins(currency string, amount float64, address string) (WithdrawResult, error) {
+	v := url.Values{}
+	v.Set("coinName", currency)
+	v.Set("amount", strconv.FormatFloat(amount, 'f', -1, 64))
+	v.Set("address", address)
+	var result WithdrawResult
+	return result, c.SendAuthenticatedHTTPRequest(btceWithdrawCoin, v, &result)
+}
+
+// SendHTTPRequest sends a request to the public API, the response is decoded into the result
+// object. Public responses are only wrapped in the response envelope when the request fails.
+func (c *Client) SendHTTPRequest(path string, params url.Values, result interface{}) error {
+	return c.SendHTTPRequestContext(context.Background(), path, params, result)
+}
+
+// SendHTTPRequestContext is SendHTTPRequest, the request is cancelled when the context is done
+func (c *Client) SendHTTPRequestContext(ctx context.Context, path string, params url.Values,
+	result interface{}) error {
+	requestURL := c.APIUrl + btcePublicPath + path
+	if len(params) > 0 {
+		requestURL += "?" + params.Encode()
+	}
+
+	if c.Debug(exchange.TraceHTTP) {
+		log.Printf("Request: GET %s\n", requestURL)
+	}
+
+	resp, statusCode, err := common.SendHTTPRequest2Context(ctx, http.MethodGet, requestURL,
+		http.Header{}, nil)
+	if err != nil {
+		return err
+	}
+
+	if c.Debug(exchange.TraceHTTP) {
+		log.Printf("Received raw: \n%s\n", resp)
+	}
+
+	var status Response
+	if err = common.JSONDecode([]byte(resp), &status); err == nil && status.Error != "" {
+		return exchange.NewExchangeError(c.Name, path, statusCode, 0, status.Error, resp)
+	}
+	if err = common.JSONDecode([]byte(resp), result); err != nil {
+		return exchange.NewExchangeError(c.Name, path, statusCode, 0,
+			"failed to unmarshal response", resp)
+	}
+	return nil
+}
+
+// SendAuthenticatedHTTPRequest calls a method of the private API, the params are sent as a form
+// signed with the hex encoded HMAC-SHA512 of the form. The return value of the response is decoded
+// into the result object.
+func (c *Client) SendAuthenticatedHTTPRequest(method string, params url.Values, result interface{}) error {
+	return c.SendAuthenticatedHTTPRequestContext(context.Background(), method, params, result)
+}
+
+// SendAuthenticatedHTTPRequestContext is SendAuthenticatedHTTPRequest, the request is cancelled
+// when the context is done
+func (c *Client) SendAuthenticatedHTTPRequestContext(ctx context.Context, method string,
+	params url.Values, result interface{}) error {
+	if !c.AuthenticatedAPISupport {
+		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, c.Name)
+	}
+
+	apiKey, apiSecret, _ := c.Credentials()
+
+	if c.Nonce.Get() == 0 {
+		c.Nonce.Set(time.Now().Unix())
+	} else {
+		c.Nonce.Inc()
+	}
+	params.Set("nonce", c.Nonce.String())
+	params.Set("method", method)
+	payload := params.Encode()
+
+	hmac := common.GetHMAC(common.HashSHA512, []byte(payload), []byte(apiSecret))
+	headers := make(http.Header)
+	headers.Set("Content-Type", "application/x-www-form-urlencoded")
+	headers.Set("Key", apiKey)
+	headers.Set("Sign", common.HexEncodeToString(hmac))
+
+	requestURL := c.APIUrl + btcePrivatePath
+	if c.Debug(exchange.TraceHTTP) {
+		log.Printf("Request: POST %s %s\n", requestURL, payload)
+	}
+
+	resp, statusCode, err := common.SendHTTPRequest2Context(ctx, http.MethodPost, requestURL, headers,
+		bytes.NewBufferString(payload))
+	if err != nil {
+		return err
+	}
+
+	if c.Debug(exchange.TraceHTTP) {
+		log.Printf("Received raw: \n%s\n", resp)
+	}
+
+	var response Response
+	if err = common.JSONDecode([]byte(resp), &response); err != nil {
+		return exchange.NewExchangeError(c.Name, method, statusCode, 0,
+			"failed to unmarshal response", resp)
+	}
+	if response.Success != 1 {
+		return exchange.NewExchangeError(c.Name, method, statusCode, 0, response.Error, resp)
+	}
+	// Methods without a result (e.g. ActiveOrders without orders) may not return a value
+	if len(response.Return) == 0 {
+		return nil
+	}
+	if err = common.JSONDecode(response.Return, result); err != nil {
+		return exchange.NewExchangeError(c.Name, method, statusCode, 0,
+			"failed to unmarshal response", resp)
+	}
+	return nil
+}
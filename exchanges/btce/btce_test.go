@@ -0,0 +1,193 @@
+package btce
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+func newTestClient(handler http.HandlerFunc) (*Client, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	c := &Client{}
+	c.Name = "BTC-e test"
+	c.APIUrl = server.URL
+	c.RequestCurrencyPairFormat.Delimiter = "_"
+	c.RequestCurrencyPairFormat.Separator = "-"
+	c.Orderbooks = orderbook.Init()
+	c.AuthenticatedAPISupport = true
+	c.SetAPIKeys("key", "secret", "", false)
+	return c, server
+}
+
+func TestPublicAPI(t *testing.T) {
+	c, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case btcePublicPath + btceInfo:
+			fmt.Fprint(w, `{"server_time":1542682259,"pairs":{
+				"eth_btc":{"decimal_places":8,"min_price":0.00000001,"max_price":10000,"min_amount":0.0001,
+					"min_total":0.0001,"hidden":0,"fee":0.2},
+				"ltc_btc":{"decimal_places":6,"min_amount":0.01,"hidden":1,"fee":0.2}}}`)
+		case btcePublicPath + btceTicker + "/eth_btc-doge_btc":
+			if r.URL.Query().Get("ignore_invalid") != "1" {
+				fmt.Fprint(w, `{"success":0,"error":"Invalid pair name: doge_btc"}`)
+				return
+			}
+			fmt.Fprint(w, `{"eth_btc":{"high":0.034,"low":0.032,"avg":0.033,"vol":12.5,"vol_cur":380.2,
+				"last":0.0331,"buy":0.033,"sell":0.0332,"updated":1542682259}}`)
+		case btcePublicPath + btceDepth + "/eth_btc":
+			fmt.Fprint(w, `{"eth_btc":{"asks":[[0.0332,1.5],[0.0333,2]],"bids":[[0.033,3]]}}`)
+		default:
+			fmt.Fprint(w, `{"success":0,"error":"Invalid method"}`)
+		}
+	})
+	defer server.Close()
+
+	info, err := c.GetInfo()
+	if err != nil {
+		t.Fatalf("Test failed. GetInfo returned an error: %s", err)
+	}
+	c.Info = info
+	if pairs := c.GetAvailablePairs(true); len(pairs) != 1 || pairs[0] != "ETH_BTC" {
+		t.Errorf("Test failed. Unexpected available pairs %v", pairs)
+	}
+	ethbtc := pair.NewCurrencyPairDelimiter("ETH_BTC", "_")
+	limits := c.GetLimits()
+	if limits.GetPriceDecimalPlaces(ethbtc) != 8 || limits.GetMinAmount(ethbtc) != 0.0001 ||
+		limits.GetMinTotal(ethbtc) != 0.0001 {
+		t.Error("Test failed. Unexpected limits")
+	}
+	if limits.GetPriceDecimalPlaces(pair.NewCurrencyPair("BTC", "USD")) != -1 {
+		t.Error("Test failed. Expected undefined limits for an unknown pair")
+	}
+	if pairs := c.GetCurrencyPairs(); len(pairs) != 1 || pairs["eth_btc"] == nil ||
+		pairs["eth_btc"].FirstCurrencyName != "ETH" {
+		t.Errorf("Test failed. Unexpected currency pairs %v", pairs)
+	}
+
+	dogebtc := pair.NewCurrencyPair("DOGE", "BTC")
+	prices, err := c.UpdateTickers([]pair.CurrencyPair{ethbtc, dogebtc}, ticker.Spot)
+	if len(prices) != 1 || prices[0].Bid != 0.033 || prices[0].Ask != 0.0332 || prices[0].Volume != 380.2 {
+		t.Errorf("Test failed. Unexpected tickers %+v", prices)
+	}
+	if exchange.PairErr(err, ethbtc) != nil || exchange.PairErr(err, dogebtc) == nil {
+		t.Errorf("Test failed. Expected only the missing pair to fail, got %v", err)
+	}
+
+	book, err := c.UpdateOrderbook(ethbtc, ticker.Spot)
+	if err != nil {
+		t.Fatalf("Test failed. UpdateOrderbook returned an error: %s", err)
+	}
+	if len(book.Asks) != 2 || book.Asks[0].Price != 0.0332 || book.Asks[0].Amount != 1.5 ||
+		len(book.Bids) != 1 || book.Bids[0].Amount != 3 {
+		t.Errorf("Test failed. Unexpected orderbook %+v", book)
+	}
+
+	_, err = c.GetTrades("eth_btc", 0)
+	if e, ok := err.(*exchange.ExchangeError); !ok || e.Message != "Invalid method" {
+		t.Errorf("Test failed. Expected an exchange error, got %v", err)
+	}
+}
+
+func TestPrivateAPI(t *testing.T) {
+	c, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		sign := common.HexEncodeToString(common.GetHMAC(common.HashSHA512, []byte(r.PostForm.Encode()),
+			[]byte("secret")))
+		if r.URL.Path != btcePrivatePath || r.Header.Get("Key") != "key" || r.Header.Get("Sign") != sign ||
+			r.PostForm.Get("nonce") == "" {
+			fmt.Fprint(w, `{"success":0,"error":"invalid sign"}`)
+			return
+		}
+		switch r.PostForm.Get("method") {
+		case btceAccountInfo:
+			fmt.Fprint(w, `{"success":1,"return":{"funds":{"btc":0.5,"eth":0},
+				"funds_incl_orders":{"btc":0.75,"eth":2},"rights":{"info":1,"trade":1,"withdraw":0},
+				"transaction_count":0,"open_orders":2,"server_time":1542682259}}`)
+		case btceTrade:
+			if r.PostForm.Get("pair") != "eth_btc" || r.PostForm.Get("type") != "sell" ||
+				r.PostForm.Get("amount") != "2" || r.PostForm.Get("rate") != "0.0335" {
+				fmt.Fprintf(w, `{"success":0,"error":"unexpected params %s"}`, r.PostForm.Encode())
+				return
+			}
+			fmt.Fprint(w, `{"success":1,"return":{"received":0,"remains":2,"order_id":1001,"funds":{}}}`)
+		case btceActiveOrders:
+			if r.PostForm.Get("pair") == "ltc_btc" {
+				// No orders
+				fmt.Fprint(w, `{"success":1}`)
+				return
+			}
+			fmt.Fprint(w, `{"success":1,"return":{"1001":{"pair":"eth_btc","type":"sell","amount":2,
+				"rate":0.0335,"timestamp_created":1542682259,"status":0}}}`)
+		case btceOrderInfo:
+			if r.PostForm.Get("order_id") != "1001" {
+				fmt.Fprint(w, `{"success":0,"error":"invalid order"}`)
+				return
+			}
+			fmt.Fprint(w, `{"success":1,"return":{"1001":{"pair":"eth_btc","type":"sell","start_amount":2,
+				"amount":0.5,"rate":0.0335,"timestamp_created":1542682259,"status":3}}}`)
+		case btceCancelOrder:
+			fmt.Fprint(w, `{"success":1,"return":{"order_id":1001,"funds":{}}}`)
+		}
+	})
+	defer server.Close()
+
+	ethbtc := pair.NewCurrencyPairDelimiter("ETH_BTC", "_")
+	orderID, err := c.NewOrder(ethbtc, 2, 0.0335, exchange.OrderSideSell, exchange.OrderTypeExchangeLimit)
+	if err != nil || orderID != "1001" {
+		t.Fatalf("Test failed. Expected order 1001 but got %s %v", orderID, err)
+	}
+
+	orders, err := c.GetOrders([]pair.CurrencyPair{ethbtc, pair.NewCurrencyPairDelimiter("LTC_BTC", "_")})
+	if err != nil {
+		t.Fatalf("Test failed. GetOrders returned an error: %s", err)
+	}
+	if len(orders) != 1 || orders[0].OrderID != "1001" || orders[0].Side != exchange.OrderSideSell ||
+		orders[0].Status != exchange.OrderStatusActive || orders[0].CurrencyPair.Pair().String() != "eth_btc" {
+		t.Errorf("Test failed. Unexpected orders %+v", orders)
+	}
+
+	order, err := c.GetOrder("1001", ethbtc)
+	if err != nil {
+		t.Fatalf("Test failed. GetOrder returned an error: %s", err)
+	}
+	if order.Amount != 2 || order.FilledAmount != 1.5 || order.RemainingAmount != 0.5 ||
+		order.Status != exchange.OrderStatusAborted {
+		t.Errorf("Test failed. Unexpected order %+v", order)
+	}
+	_, err = c.GetOrder("1002", ethbtc)
+	if e, ok := err.(*exchange.ExchangeError); !ok || e.Message != "invalid order" {
+		t.Errorf("Test failed. Expected an exchange error, got %v", err)
+	}
+
+	if err = c.CancelOrder("1001", ethbtc); err != nil {
+		t.Errorf("Test failed. CancelOrder returned an error: %s", err)
+	}
+
+	account, err := c.GetExchangeAccountInfo()
+	if err != nil {
+		t.Fatalf("Test failed. GetExchangeAccountInfo returned an error: %s", err)
+	}
+	balances := make(map[string]exchange.AccountCurrencyInfo)
+	for _, balance := range account.Currencies {
+		balances[balance.CurrencyName] = balance
+	}
+	if b := balances["BTC"]; b.Available != 0.5 || b.TotalValue != 0.75 || b.Hold != 0.25 {
+		t.Errorf("Test failed. Unexpected BTC balance %+v", b)
+	}
+	if b := balances["ETH"]; b.Available != 0 || b.TotalValue != 2 || b.Hold != 2 {
+		t.Errorf("Test failed. Unexpected ETH balance %+v", b)
+	}
+
+	c.SetAPIKeys("key", "wrong", "", false)
+	_, err = c.GetAccountInfo()
+	if e, ok := err.(*exchange.ExchangeError); !ok || e.Message != "invalid sign" {
+		t.Errorf("Test failed. Expected an exchange error, got %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package btce
+
+import "encoding/json"
+
+// Order statuses
+const (
+	OrderStatusActive    = 0
+	OrderStatusFilled    = 1
+	OrderStatusCancelled = 2
+	// Cancelled after being partially filled
+	OrderStatusPartiallyCancelled = 3
+)
+
+// Response is the envelope of the private API responses, success is 1 on success, otherwise the
+// error describes why the request was rejected. Public requests only return the envelope on error.
+type Response struct {
+	Return  json.RawMessage `json:"return"`
+	Success int             `json:"success"`
+	Error   string          `json:"error"`
+}
+
+// Info holds the current pair information as well as server time
+type Info struct {
+	ServerTime int64               `json:"server_time"`
+	Pairs      map[string]PairData `json:"pairs"`
+}
+
+// PairData holds the trading rules of a pair, fees are percentages
+type PairData struct {
+	DecimalPlaces int     `json:"decimal_places"`
+	MinPrice      float64 `json:"min_price"`
+	MaxPrice      float64 `json:"max_price"`
+	MinAmount     float64 `json:"min_amount"`
+	// Not returned by all the exchanges
+	MinTotal float64 `json:"min_total"`
+	Hidden   int     `json:"hidden"`
+	Fee      float64 `json:"fee"`
+}
+
+// Ticker holds the market data of a pair over the last 24 hours
+type Ticker struct {
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Avg    float64 `json:"avg"`
+	Vol    float64 `json:"vol"`
+	VolCur float64 `json:"vol_cur"`
+	Last   float64 `json:"last"`
+	Buy    float64 `json:"buy"`
+	Sell   float64 `json:"sell"`
+	// Unix timestamp in seconds
+	Updated int64 `json:"updated"`
+}
+
+// Orderbook holds the price levels of both sides of the orderbook as [price, amount] tuples
+type Orderbook struct {
+	Asks [][]float64 `json:"asks"`
+	Bids [][]float64 `json:"bids"`
+}
+
+// PublicTrade is a trade executed on the exchange
+type PublicTrade struct {
+	Type      string  `json:"type"`
+	Price     float64 `json:"price"`
+	Amount    float64 `json:"amount"`
+	TID       int64   `json:"tid"`
+	Timestamp int64   `json:"timestamp"`
+}
+
+// AccountInfo holds the balances & API key privileges of the account
+type AccountInfo struct {
+	// Available balances keyed by (lower case) currency
+	Funds map[string]float64 `json:"funds"`
+	// Balances including the amounts held by open orders, not returned by all the exchanges
+	FundsInclOrders map[string]float64 `json:"funds_incl_orders"`
+	Rights          struct {
+		Info     int `json:"info"`
+		Trade    int `json:"trade"`
+		Withdraw int `json:"withdraw"`
+	} `json:"rights"`
+	TransactionCount int   `json:"transaction_count"`
+	OpenOrders       int   `json:"open_orders"`
+	ServerTime       int64 `json:"server_time"`
+}
+
+// OrderInfo holds the details of an order
+type OrderInfo struct {
+	Pair string `json:"pair"`
+	Type string `json:"type"`
+	// Only returned by OrderInfo
+	StartAmount float64 `json:"start_amount"`
+	// Remaining amount
+	Amount           float64 `json:"amount"`
+	Rate             float64 `json:"rate"`
+	TimestampCreated int64   `json:"timestamp_created"`
+	Status           int     `json:"status"`
+}
+
+// TradeResult is the result of placing an order, the order ID is 0 if the order was filled
+// immediately
+type TradeResult struct {
+	Received float64            `json:"received"`
+	Remains  float64            `json:"remains"`
+	OrderID  int64              `json:"order_id"`
+	Funds    map[string]float64 `json:"funds"`
+}
+
+// CancelResult is the result of cancelling an order
+type CancelResult struct {
+	OrderID int64              `json:"order_id"`
+	Funds   map[string]float64 `json:"funds"`
+}
+
+// TradeHistory is a trade of the account
+type TradeHistory struct {
+	Pair    string  `json:"pair"`
+	Type    string  `json:"type"`
+	Amount  float64 `json:"amount"`
+	Rate    float64 `json:"rate"`
+	OrderID int64   `json:"order_id"`
+	// 1 if the order was placed by the account
+	IsYourOrder int `json:"is_your_order"`
+	// Unix timestamp in seconds
+	Timestamp int64 `json:"timestamp"`
+}
+
+// WithdrawResult is the result of a withdrawal, the funds are the balances after the withdrawal
+type WithdrawResult struct {
+	TID        int64              `json:"tId"`
+	AmountSent float64            `json:"amountSent"`
+	Funds      map[string]float64 `json:"funds"`
+}
@@ -0,0 +1,425 @@
+package btce
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	exchange "github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+	"github.com/shopspring/decimal"
+)
+
+// Start starts the exchange go routine
+func (c *Client) Start() {
+	go c.Run()
+}
+
+// Run fetches the trading rules of the pairs and updates the available currencies
+func (c *Client) Run() {
+	if c.Debug("") {
+		log.Printf("%s polling delay: %ds.\n", c.GetName(), c.RESTPollingDelay)
+		log.Printf("%s %d currencies enabled: %s.\n", c.GetName(), len(c.EnabledPairs), c.EnabledPairs)
+	}
+
+	info, err := c.GetInfo()
+	if err != nil {
+		log.Printf("%s Unable to fetch info.\n", c.GetName())
+		return
+	}
+	c.Info = info
+	err = c.UpdateAvailableCurrencies(c.GetAvailablePairs(true), false)
+	if err != nil {
+		log.Printf("%s Failed to get config.\n", c.GetName())
+	}
+}
+
+// GetAvailablePairs returns the (upper case) pairs of the exchange info, nonHidden leaves out the
+// pairs hidden by the exchange.
+func (c *Client) GetAvailablePairs(nonHidden bool) []string {
+	var pairs []string
+	for x, y := range c.Info.Pairs {
+		if nonHidden && y.Hidden == 1 || x == "" {
+			continue
+		}
+		pairs = append(pairs, common.StringToUpper(x))
+	}
+	return pairs
+}
+
+// CurrencyPairToSymbol converts a currency pair to a symbol (exchange specific pair identifier),
+// e.g. eth_btc for ETH/BTC.
+func (c *Client) CurrencyPairToSymbol(p pair.CurrencyPair) string {
+	return p.Display(c.RequestCurrencyPairFormat.Delimiter, c.RequestCurrencyPairFormat.Uppercase).String()
+}
+
+// SymbolToCurrencyPair converts a symbol (exchange specific pair identifier) to a currency pair.
+func (c *Client) SymbolToCurrencyPair(symbol string) (pair.CurrencyPair, error) {
+	currencies := strings.Split(symbol, c.RequestCurrencyPairFormat.Delimiter)
+	if len(currencies) != 2 || currencies[0] == "" || currencies[1] == "" {
+		return pair.CurrencyPair{}, fmt.Errorf("no currency pair found for '%s' symbol", symbol)
+	}
+	return pair.NewCurrencyPairDelimiter(symbol, c.RequestCurrencyPairFormat.Delimiter), nil
+}
+
+// UpdateTickers updates the tickers of the given pairs with a single request, pairs missing from
+// the response are returned as a *exchange.PartialError alongside the other tickers.
+func (c *Client) UpdateTickers(pairs []pair.CurrencyPair, assetType string) ([]ticker.Price, error) {
+	return c.updateTickers(context.Background(), pairs, assetType)
+}
+
+func (c *Client) updateTickers(ctx context.Context, pairs []pair.CurrencyPair,
+	assetType string) ([]ticker.Price, error) {
+	symbols := make([]string, len(pairs))
+	for i, p := range pairs {
+		symbols[i] = c.CurrencyPairToSymbol(p)
+	}
+	result, err := c.getTicker(ctx, common.JoinStrings(symbols, c.RequestCurrencyPairFormat.Separator))
+	if err != nil {
+		return nil, err
+	}
+
+	var prices []ticker.Price
+	var errs []exchange.PairError
+	for i, p := range pairs {
+		tick, ok := result[symbols[i]]
+		if !ok {
+			errs = append(errs, exchange.PairError{Pair: p, Err: exchange.ErrNoPairData})
+			continue
+		}
+		var tp ticker.Price
+		tp.Pair = p
+		tp.Last = tick.Last
+		tp.Ask = tick.Sell
+		tp.Bid = tick.Buy
+		tp.High = tick.High
+		tp.Low = tick.Low
+		tp.Volume = tick.VolCur
+		ticker.ProcessTicker(c.Name, p, tp, assetType)
+		prices = append(prices, tp)
+	}
+	return prices, exchange.NewPartialError(c.Name, errs)
+}
+
+// UpdateTicker updates and returns the ticker for a currency pair, the tickers of all the enabled
+// pairs are updated. Failures of the other pairs don't fail the update of the pair.
+func (c *Client) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return c.UpdateTickerContext(context.Background(), p, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (c *Client) UpdateTickerContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
+	_, err := c.updateTickers(ctx, c.GetEnabledCurrencies(), assetType)
+	if err = exchange.PairErr(err, p); err != nil {
+		return ticker.Price{}, err
+	}
+	return ticker.GetTicker(c.Name, p, assetType)
+}
+
+// GetTickerPrice returns the ticker for a currency pair
+func (c *Client) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	tick, err := ticker.GetTicker(c.Name, p, assetType)
+	if err != nil {
+		return c.UpdateTicker(p, assetType)
+	}
+	return tick, nil
+}
+
+// GetOrderbookEx returns the orderbook for a currency pair
+func (c *Client) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
+	ob, err := c.Orderbooks.GetOrderbook(c.Name, p, assetType)
+	if err != nil {
+		return c.UpdateOrderbook(p, assetType)
+	}
+	return ob, nil
+}
+
+// UpdateOrderbook updates and returns the orderbook for a currency pair
+func (c *Client) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return c.UpdateOrderbookContext(context.Background(), p, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (c *Client) UpdateOrderbookContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
+	book := orderbook.Base{}
+	depth, err := c.getDepth(ctx, c.CurrencyPairToSymbol(p), 0)
+	if err != nil {
+		return book, err
+	}
+
+	book.Bids = orderbook.GetItems(len(depth.Bids))
+	for _, level := range depth.Bids {
+		book.Bids = append(book.Bids, orderbook.Item{Price: level[0], Amount: level[1]})
+	}
+	book.Asks = orderbook.GetItems(len(depth.Asks))
+	for _, level := range depth.Asks {
+		book.Asks = append(book.Asks, orderbook.Item{Price: level[0], Amount: level[1]})
+	}
+
+	c.Orderbooks.ProcessOrderbook(c.Name, p, book, assetType)
+	return c.Orderbooks.GetOrderbook(c.Name, p, assetType)
+}
+
+// GetExchangeAccountInfo retrieves the balances of the account, the holds are derived from the
+// open orders if the exchange only returns the available balances.
+func (c *Client) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return c.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (c *Client) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
+	var response exchange.AccountInfo
+	response.ExchangeName = c.GetName()
+	info, err := c.getAccountInfo(ctx)
+	if err != nil {
+		return response, err
+	}
+
+	for currency, available := range info.Funds {
+		response.Currencies = append(response.Currencies, exchange.AccountCurrencyInfo{
+			CurrencyName: common.StringToUpper(currency),
+			Available:    available,
+		})
+	}
+	if info.FundsInclOrders != nil {
+		for i := range response.Currencies {
+			balance := &response.Currencies[i]
+			total := info.FundsInclOrders[common.StringToLower(balance.CurrencyName)]
+			balance.TotalValue = total
+			balance.Hold, _ = decimal.NewFromFloat(total).Sub(decimal.NewFromFloat(balance.Available)).Float64()
+		}
+		return response, nil
+	}
+
+	orders, err := c.GetOrdersContext(ctx, nil)
+	if err != nil {
+		return response, err
+	}
+	exchange.SetHoldsFromAvailable(&response, orders)
+	return response, nil
+}
+
+// NewOrder creates a new order on the exchange.
+// Returns the ID of the new exchange order, or an empty string if the order was filled immediately.
+func (c *Client) NewOrder(p pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return c.NewOrderContext(context.Background(), p, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (c *Client) NewOrderContext(ctx context.Context, p pair.CurrencyPair, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	if err := c.GetCapabilities().CheckOrderOptions(opts...); err != nil {
+		return "", err
+	}
+	if orderType != exchange.OrderTypeExchangeLimit {
+		panic("not implemented")
+	}
+	if side != exchange.OrderSideBuy && side != exchange.OrderSideSell {
+		return "", fmt.Errorf("can't create order on %s exchange invalid value '%s' for side", c.Name, side)
+	}
+	// The order sides are named buy & sell
+	result, err := c.trade(ctx, c.CurrencyPairToSymbol(p), string(side), amount, price)
+	if err != nil {
+		return "", err
+	}
+	if result.OrderID == 0 {
+		// Orders that are filled immediately aren't assigned an ID
+		return "", nil
+	}
+	return strconv.FormatInt(result.OrderID, 10), nil
+}
+
+// CancelOrder will attempt to cancel the active order matching the given ID.
+func (c *Client) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return c.CancelOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (c *Client) CancelOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) error {
+	_, err := c.cancelOrderByID(ctx, orderID)
+	return err
+}
+
+// GetOrder returns information about a previously placed order.
+func (c *Client) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	return c.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (c *Client) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	orders, err := c.getOrderInfo(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	order, ok := orders[orderID]
+	if !ok {
+		return nil, exchange.ErrOrderNotFound
+	}
+	return c.convertOrderToExchangeOrder(orderID, &order), nil
+}
+
+// GetOrders returns information about currently active orders, the orders of all the enabled
+// pairs are returned if no pairs are given.
+func (c *Client) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return c.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (c *Client) GetOrdersContext(ctx context.Context, pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	if c.AllPairsActiveOrders {
+		return c.getAllActiveOrders(ctx, pairs)
+	}
+	if len(pairs) == 0 {
+		pairs = c.GetEnabledCurrencies()
+	}
+	ret := []*exchange.Order{}
+	for _, p := range pairs {
+		orders, err := c.getActiveOrders(ctx, c.CurrencyPairToSymbol(p))
+		if err != nil {
+			return nil, err
+		}
+		for orderID, order := range orders {
+			ret = append(ret, c.convertOrderToExchangeOrder(orderID, &order))
+		}
+	}
+	return ret, nil
+}
+
+// getAllActiveOrders fetches the active orders of all the pairs with a single request, and only
+// returns the orders of the given pairs (all of them if no pairs are given).
+func (c *Client) getAllActiveOrders(ctx context.Context,
+	pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	orders, err := c.getActiveOrders(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	symbols := make(map[string]bool, len(pairs))
+	for _, p := range pairs {
+		symbols[c.CurrencyPairToSymbol(p)] = true
+	}
+	ret := []*exchange.Order{}
+	for orderID, order := range orders {
+		if len(symbols) > 0 && !symbols[order.Pair] {
+			continue
+		}
+		ret = append(ret, c.convertOrderToExchangeOrder(orderID, &order))
+	}
+	return ret, nil
+}
+
+func (c *Client) convertOrderToExchangeOrder(orderID string, order *OrderInfo) *exchange.Order {
+	retOrder := &exchange.Order{}
+	retOrder.OrderID = orderID
+
+	switch order.Status {
+	case OrderStatusActive:
+		retOrder.Status = exchange.OrderStatusActive
+	case OrderStatusFilled:
+		retOrder.Status = exchange.OrderStatusFilled
+	case OrderStatusCancelled, OrderStatusPartiallyCancelled:
+		retOrder.Status = exchange.OrderStatusAborted
+	default:
+		retOrder.Status = exchange.OrderStatusUnknown
+	}
+
+	// The start amount is only returned by OrderInfo, the active orders only have the remaining
+	// amount
+	if order.StartAmount != 0 {
+		retOrder.Amount = order.StartAmount
+		retOrder.FilledAmount, _ = decimal.NewFromFloat(order.StartAmount).Sub(decimal.NewFromFloat(order.Amount)).Float64()
+		retOrder.RemainingAmount = order.Amount
+	} else {
+		retOrder.Amount = order.Amount
+		retOrder.RemainingAmount = order.Amount
+	}
+	retOrder.Rate = order.Rate
+	retOrder.CreatedAt = order.TimestampCreated
+	if p, err := c.SymbolToCurrencyPair(order.Pair); err == nil {
+		retOrder.CurrencyPair = p
+	}
+	retOrder.Side = exchange.OrderSide(order.Type)
+	retOrder.Type = exchange.OrderTypeExchangeLimit
+	return retOrder
+}
+
+// GetLimits returns price/amount limits for the exchange.
+func (c *Client) GetLimits() exchange.ILimits {
+	return newCurrencyLimits(c.Info.Pairs, c.CurrencyPairToSymbol)
+}
+
+// GetCurrencyPairs returns currency pairs that can be used by the exchange account
+// associated with this bot, keyed by symbol (e.g. eth_btc).
+func (c *Client) GetCurrencyPairs() map[pair.CurrencyItem]*exchange.CurrencyPairInfo {
+	currencies := map[pair.CurrencyItem]*exchange.CurrencyPairInfo{}
+	for symbol, info := range c.Info.Pairs {
+		if info.Hidden != 0 {
+			continue
+		}
+		p, err := c.SymbolToCurrencyPair(symbol)
+		if err != nil {
+			continue
+		}
+		currencies[pair.CurrencyItem(symbol)] = &exchange.CurrencyPairInfo{
+			Currency:           p,
+			FirstCurrencyName:  p.FirstCurrency.Upper().String(),
+			SecondCurrencyName: p.SecondCurrency.Upper().String(),
+		}
+	}
+	return currencies
+}
+
+type currencyLimits struct {
+	// Maps symbol to the trading rules of the pair
+	info     map[string]PairData
+	toSymbol func(pair.CurrencyPair) string
+}
+
+func newCurrencyLimits(info map[string]PairData, toSymbol func(pair.CurrencyPair) string) *currencyLimits {
+	return &currencyLimits{info, toSymbol}
+}
+
+// Returns max number of decimal places allowed in the trade price for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetPriceDecimalPlaces(p pair.CurrencyPair) int32 {
+	if v, exists := cl.info[cl.toSymbol(p)]; exists {
+		return int32(v.DecimalPlaces)
+	}
+	return -1
+}
+
+// Returns max number of decimal places allowed in the trade amount for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetAmountDecimalPlaces(p pair.CurrencyPair) int32 {
+	if v, exists := cl.info[cl.toSymbol(p)]; exists {
+		return int32(v.DecimalPlaces)
+	}
+	return -1
+}
+
+// Returns the minimum trade amount for the given currency pair.
+func (cl *currencyLimits) GetMinAmount(p pair.CurrencyPair) float64 {
+	if v, exists := cl.info[cl.toSymbol(p)]; exists {
+		return v.MinAmount
+	}
+	return 0
+}
+
+// Returns the minimum trade total (amount * price) for the given currency pair, 0 if the exchange
+// doesn't specify one.
+func (cl *currencyLimits) GetMinTotal(p pair.CurrencyPair) float64 {
+	if v, exists := cl.info[cl.toSymbol(p)]; exists {
+		return v.MinTotal
+	}
+	return 0
+}
@@ -45,6 +45,15 @@ type BTCMarkets struct {
 	Ticker map[string]Ticker
 }
 
+// New returns a BTC Markets exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *BTCMarkets {
+	b := &BTCMarkets{}
+	b.SetDefaults()
+	b.Quickstart(b.Setup, apiKey, apiSecret, opts...)
+	return b
+}
+
 // SetDefaults sets basic defaults
 func (b *BTCMarkets) SetDefaults() {
 	b.Name = "BTC Markets"
@@ -345,6 +354,8 @@ func (b *BTCMarkets) WithdrawAUD(accountName, accountNumber, bankName, bsbNumber
 
 // SendAuthenticatedRequest sends an authenticated HTTP request
 func (b *BTCMarkets) SendAuthenticatedRequest(reqType, path string, data interface{}, result interface{}) (err error) {
+	apiKey, apiSecret, _ := b.Credentials()
+
 	if !b.AuthenticatedAPISupport {
 		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, b.Name)
 	}
@@ -367,7 +378,7 @@ func (b *BTCMarkets) SendAuthenticatedRequest(reqType, path string, data interfa
 		request = path + "\n" + b.Nonce.String()[0:13] + "\n"
 	}
 
-	hmac := common.GetHMAC(common.HashSHA512, []byte(request), []byte(b.APISecret))
+	hmac := common.GetHMAC(common.HashSHA512, []byte(request), []byte(apiSecret))
 
 	if b.Verbose {
 		log.Printf("Sending %s request to URL %s with params %s\n", reqType, btcMarketsAPIURL+path, request)
@@ -377,7 +388,7 @@ func (b *BTCMarkets) SendAuthenticatedRequest(reqType, path string, data interfa
 	headers["Accept"] = "application/json"
 	headers["Accept-Charset"] = "UTF-8"
 	headers["Content-Type"] = "application/json"
-	headers["apikey"] = b.APIKey
+	headers["apikey"] = apiKey
 	headers["timestamp"] = b.Nonce.String()[0:13]
 	headers["signature"] = common.Base64Encode(hmac)
 
@@ -43,6 +43,16 @@ type COINUT struct {
 	InstrumentMap map[string]int
 }
 
+// New returns a COINUT exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+// The username must be set with exchange.WithClientID to use the authenticated API.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *COINUT {
+	c := &COINUT{}
+	c.SetDefaults()
+	c.Quickstart(c.Setup, apiKey, apiSecret, opts...)
+	return c
+}
+
 // SetDefaults sets current default values
 func (c *COINUT) SetDefaults() {
 	c.Name = "COINUT"
@@ -314,6 +324,8 @@ func (c *COINUT) GetOpenPosition(instrumentID int) ([]CoinutOpenPosition, error)
 //to-do: user position update via websocket
 
 func (c *COINUT) SendAuthenticatedHTTPRequest(apiRequest string, params map[string]interface{}, result interface{}) (err error) {
+	apiKey, _, clientID := c.Credentials()
+
 	if !c.AuthenticatedAPISupport {
 		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, c.Name)
 	}
@@ -341,9 +353,9 @@ func (c *COINUT) SendAuthenticatedHTTPRequest(apiRequest string, params map[stri
 		log.Printf("Request JSON: %s\n", payload)
 	}
 
-	hmac := common.GetHMAC(common.HashSHA256, []byte(payload), []byte(c.APIKey))
+	hmac := common.GetHMAC(common.HashSHA256, []byte(payload), []byte(apiKey))
 	headers := make(map[string]string)
-	headers["X-USER"] = c.ClientID
+	headers["X-USER"] = clientID
 	headers["X-SIGNATURE"] = common.HexEncodeToString(hmac)
 	headers["Content-Type"] = "application/json"
 
@@ -12,7 +12,7 @@ const COINUT_WEBSOCKET_URL = "wss://wsapi.coinut.com"
 
 func (c *COINUT) WebsocketClient() {
 	for c.Enabled && c.Websocket {
-		var Dialer websocket.Dialer
+		Dialer := websocket.Dialer{EnableCompression: c.WebsocketCompression()}
 		var err error
 		c.WebsocketConn, _, err = Dialer.Dial(c.WebsocketURL, http.Header{})
 
@@ -38,6 +38,7 @@ func (c *COINUT) WebsocketClient() {
 				log.Println(err)
 				break
 			}
+			c.RecordWebsocketFrame(msgType, resp)
 
 			switch msgType {
 			case websocket.TextMessage:
@@ -0,0 +1,516 @@
+package cryptopia
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	cryptopiaAPIURL        = "https://www.cryptopia.co.nz/api"
+	cryptopiaTimeFormat    = "2006-01-02T15:04:05.9999999"
+	cryptopiaMaxOpenOrders = 1000
+	// Cryptopia prices & amounts have 8 decimal places
+	cryptopiaDecimalPlaces = 8
+
+	// Public requests
+	cryptopiaAPIGetTradePairs    = "GetTradePairs"
+	cryptopiaAPIGetMarkets       = "GetMarkets"
+	cryptopiaAPIGetMarket        = "GetMarket"
+	cryptopiaAPIGetMarketOrders  = "GetMarketOrders"
+	cryptopiaDefaultOrderbookLen = 100
+
+	// Private requests
+	cryptopiaAPIGetBalance    = "GetBalance"
+	cryptopiaAPIGetOpenOrders = "GetOpenOrders"
+	cryptopiaAPISubmitTrade   = "SubmitTrade"
+	cryptopiaAPICancelTrade   = "CancelTrade"
+)
+
+// Cryptopia is the overarching type across the Cryptopia methods
+type Cryptopia struct {
+	exchange.Base
+	// Maps symbol (exchange specific market identifier) to currency pair info
+	currencyPairs map[pair.CurrencyItem]*exchange.CurrencyPairInfo
+	// Maps currency pair to the trading rules of the market
+	tradePairs map[pair.CurrencyItem]*TradePair
+	// Caches the results of the bulk symbol <-> currency pair conversions
+	symbolCache exchange.SymbolCache
+}
+
+// New returns a Cryptopia exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *Cryptopia {
+	c := &Cryptopia{}
+	c.SetDefaults()
+	c.Quickstart(c.Setup, apiKey, apiSecret, opts...)
+	return c
+}
+
+// SetDefaults method assigns the default values for Cryptopia
+func (c *Cryptopia) SetDefaults() {
+	c.Name = "Cryptopia"
+	c.APIUrl = cryptopiaAPIURL
+	c.Enabled = false
+	c.Verbose = false
+	c.Websocket = false
+	c.RESTPollingDelay = 10
+	c.TakerFee = 0.2
+	c.MakerFee = 0.2
+	c.RequestCurrencyPairFormat.Delimiter = "_"
+	c.RequestCurrencyPairFormat.Uppercase = true
+	c.ConfigCurrencyPairFormat.Delimiter = "_"
+	c.ConfigCurrencyPairFormat.Uppercase = true
+	c.AssetTypes = []string{ticker.Spot}
+	c.Orderbooks = orderbook.Init()
+}
+
+// Setup method sets current configuration details if enabled
+func (c *Cryptopia) Setup(exch config.ExchangeConfig) {
+	if !exch.Enabled {
+		c.SetEnabled(false)
+	} else {
+		c.Enabled = true
+		c.AuthenticatedAPISupport = exch.AuthenticatedAPISupport
+		c.SetAPIKeys(exch.APIKey, exch.APISecret, "", false)
+		c.RESTPollingDelay = exch.RESTPollingDelay
+		c.Verbose = exch.Verbose
+		c.Websocket = exch.Websocket
+		c.SetAPIURL(exch)
+		c.BaseCurrencies = common.SplitStrings(exch.BaseCurrencies, ",")
+		c.AvailablePairs = common.SplitStrings(exch.AvailablePairs, ",")
+		c.EnabledPairs = common.SplitStrings(exch.EnabledPairs, ",")
+		err := c.SetCurrencyPairFormat()
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = c.SetAssetTypes()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// CurrencyPairToSymbol converts a currency pair to a symbol (exchange specific market identifier).
+func (c *Cryptopia) CurrencyPairToSymbol(p pair.CurrencyPair) string {
+	return p.
+		Display(c.RequestCurrencyPairFormat.Delimiter, c.RequestCurrencyPairFormat.Uppercase).
+		String()
+}
+
+// SymbolToCurrencyPair converts a symbol (exchange specific market identifier) to a currency pair.
+func (c *Cryptopia) SymbolToCurrencyPair(symbol string) pair.CurrencyPair {
+	return pair.NewCurrencyPairDelimiter(symbol, c.RequestCurrencyPairFormat.Delimiter)
+}
+
+// CurrencyPairsToSymbols converts a batch of currency pairs to symbols, conversions are cached.
+func (c *Cryptopia) CurrencyPairsToSymbols(pairs []pair.CurrencyPair) ([]string, error) {
+	return c.symbolCache.CurrencyPairsToSymbols(pairs, func(cp pair.CurrencyPair) (string, error) {
+		return c.CurrencyPairToSymbol(cp), nil
+	})
+}
+
+// SymbolsToCurrencyPairs converts a batch of symbols to currency pairs, conversions are cached.
+func (c *Cryptopia) SymbolsToCurrencyPairs(symbols []string) ([]pair.CurrencyPair, error) {
+	return c.symbolCache.SymbolsToCurrencyPairs(symbols, func(symbol string) (pair.CurrencyPair, error) {
+		return c.SymbolToCurrencyPair(symbol), nil
+	})
+}
+
+// labelToCurrencyPair converts a market label (e.g. DOT/BTC) to a currency pair.
+func labelToCurrencyPair(label string) pair.CurrencyPair {
+	return pair.NewCurrencyPairDelimiter(label, "/")
+}
+
+type currencyLimits struct {
+	exchangeName string
+	// Maps currency pair to the trading rules of the market
+	tradePairs map[pair.CurrencyItem]*TradePair
+}
+
+func newCurrencyLimits(exchangeName string, tradePairs map[pair.CurrencyItem]*TradePair) *currencyLimits {
+	return &currencyLimits{exchangeName, tradePairs}
+}
+
+// Returns max number of decimal places allowed in the trade price for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetPriceDecimalPlaces(p pair.CurrencyPair) int32 {
+	return cryptopiaDecimalPlaces
+}
+
+// Returns max number of decimal places allowed in the trade amount for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetAmountDecimalPlaces(p pair.CurrencyPair) int32 {
+	return cryptopiaDecimalPlaces
+}
+
+// Returns the minimum trade amount for the given currency pair.
+func (cl *currencyLimits) GetMinAmount(p pair.CurrencyPair) float64 {
+	if v, exists := cl.tradePairs[p.Display("/", false)]; exists {
+		return v.MinimumTrade
+	}
+	return 0
+}
+
+// Returns the minimum trade total (amount * price) for the given currency pair.
+func (cl *currencyLimits) GetMinTotal(p pair.CurrencyPair) float64 {
+	if v, exists := cl.tradePairs[p.Display("/", false)]; exists {
+		return v.MinimumBaseTrade
+	}
+	return 0
+}
+
+// GetLimits returns price/amount limits for the exchange.
+func (c *Cryptopia) GetLimits() exchange.ILimits {
+	return newCurrencyLimits(c.Name, c.tradePairs)
+}
+
+// GetCurrencyPairs returns currency pairs that can be used by the exchange account associated
+// with this bot. Use FormatExchangeCurrency to get the right key.
+func (c *Cryptopia) GetCurrencyPairs() map[pair.CurrencyItem]*exchange.CurrencyPairInfo {
+	return c.currencyPairs
+}
+
+// GetTradePairs returns the trading rules of all the markets.
+func (c *Cryptopia) GetTradePairs() ([]TradePair, error) {
+	var tradePairs []TradePair
+	path := fmt.Sprintf("%s/%s", c.APIUrl, cryptopiaAPIGetTradePairs)
+
+	return tradePairs, c.HTTPRequest(path, false, nil, &tradePairs)
+}
+
+// GetMarkets returns the last 24 hour statistics of all the markets.
+func (c *Cryptopia) GetMarkets() ([]Market, error) {
+	var markets []Market
+	path := fmt.Sprintf("%s/%s", c.APIUrl, cryptopiaAPIGetMarkets)
+
+	return markets, c.HTTPRequest(path, false, nil, &markets)
+}
+
+// GetMarket returns the last 24 hour statistics of a market, e.g. "DOT_BTC".
+func (c *Cryptopia) GetMarket(symbol string) (Market, error) {
+	return c.getMarket(context.Background(), symbol)
+}
+
+func (c *Cryptopia) getMarket(ctx context.Context, symbol string) (Market, error) {
+	var market Market
+	path := fmt.Sprintf("%s/%s/%s", c.APIUrl, cryptopiaAPIGetMarket, symbol)
+
+	return market, c.HTTPRequestContext(ctx, path, false, nil, &market)
+}
+
+// GetMarketOrders returns the orderbook of a market, count is the number of orders on each side.
+func (c *Cryptopia) GetMarketOrders(symbol string, count int) (MarketOrders, error) {
+	return c.getMarketOrders(context.Background(), symbol, count)
+}
+
+func (c *Cryptopia) getMarketOrders(ctx context.Context, symbol string, count int) (MarketOrders, error) {
+	var orders MarketOrders
+	path := fmt.Sprintf("%s/%s/%s/%d", c.APIUrl, cryptopiaAPIGetMarketOrders, symbol, count)
+
+	return orders, c.HTTPRequestContext(ctx, path, false, nil, &orders)
+}
+
+// GetBalances returns the balances of all the currencies.
+func (c *Cryptopia) GetBalances() ([]Balance, error) {
+	return c.getBalances(context.Background())
+}
+
+func (c *Cryptopia) getBalances(ctx context.Context) ([]Balance, error) {
+	var balances []Balance
+	path := fmt.Sprintf("%s/%s", c.APIUrl, cryptopiaAPIGetBalance)
+
+	return balances, c.HTTPRequestContext(ctx, path, true, struct{}{}, &balances)
+}
+
+// SubmitTrade places a limit order.
+func (c *Cryptopia) SubmitTrade(req SubmitTradeRequest) (SubmitTradeResponse, error) {
+	return c.submitTrade(context.Background(), req)
+}
+
+func (c *Cryptopia) submitTrade(ctx context.Context, req SubmitTradeRequest) (SubmitTradeResponse, error) {
+	var response SubmitTradeResponse
+	path := fmt.Sprintf("%s/%s", c.APIUrl, cryptopiaAPISubmitTrade)
+
+	return response, c.HTTPRequestContext(ctx, path, true, req, &response)
+}
+
+// CancelTrade cancels one or more open orders, returns the IDs of the cancelled orders.
+func (c *Cryptopia) CancelTrade(req CancelTradeRequest) ([]int64, error) {
+	return c.cancelTrade(context.Background(), req)
+}
+
+func (c *Cryptopia) cancelTrade(ctx context.Context, req CancelTradeRequest) ([]int64, error) {
+	var orderIDs []int64
+	path := fmt.Sprintf("%s/%s", c.APIUrl, cryptopiaAPICancelTrade)
+
+	return orderIDs, c.HTTPRequestContext(ctx, path, true, req, &orderIDs)
+}
+
+// GetOpenOrders returns the open orders of a market (e.g. "DOT/BTC"), or of all the markets if
+// market is empty.
+func (c *Cryptopia) GetOpenOrders(market string) ([]Order, error) {
+	return c.getOpenOrders(context.Background(), market)
+}
+
+func (c *Cryptopia) getOpenOrders(ctx context.Context, market string) ([]Order, error) {
+	var orders []Order
+	path := fmt.Sprintf("%s/%s", c.APIUrl, cryptopiaAPIGetOpenOrders)
+	req := GetOpenOrdersRequest{Market: market, Count: cryptopiaMaxOpenOrders}
+
+	return orders, c.HTTPRequestContext(ctx, path, true, req, &orders)
+}
+
+// NewOrder creates a new order on the exchange.
+// Returns the ID of the new exchange order, or an empty string if the order was filled
+// immediately.
+func (c *Cryptopia) NewOrder(
+	currencyPair pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return c.NewOrderContext(context.Background(), currencyPair, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (c *Cryptopia) NewOrderContext(ctx context.Context, currencyPair pair.CurrencyPair, amount,
+	price float64, side exchange.OrderSide, orderType exchange.OrderType,
+	opts ...exchange.OrderOptions) (string, error) {
+	if err := c.GetCapabilities().CheckOrderOptions(opts...); err != nil {
+		return "", err
+	}
+	req := SubmitTradeRequest{
+		Market: currencyPair.Display("/", true).String(),
+		Rate:   price,
+		Amount: amount,
+	}
+	if side == exchange.OrderSideBuy {
+		req.Type = "Buy"
+	} else if side == exchange.OrderSideSell {
+		req.Type = "Sell"
+	} else {
+		return "", fmt.Errorf("can't create order on %s exchange invalid value '%s' for side", c.Name, side)
+	}
+
+	response, err := c.submitTrade(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if response.OrderID == nil {
+		return "", nil
+	}
+	return strconv.FormatInt(*response.OrderID, 10), nil
+}
+
+// CancelOrder will attempt to cancel the active order matching the given ID.
+func (c *Cryptopia) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return c.CancelOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (c *Cryptopia) CancelOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) error {
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return err
+	}
+	_, err = c.cancelTrade(ctx, CancelTradeRequest{Type: "Trade", OrderID: id})
+	return err
+}
+
+// GetOrder returns information about an active order, Cryptopia doesn't return the orders that
+// have been filled or cancelled.
+func (c *Cryptopia) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	return c.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (c *Cryptopia) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	orders, err := c.getOpenOrders(ctx, currencyPair.Display("/", true).String())
+	if err != nil {
+		return nil, err
+	}
+	for i := range orders {
+		if strconv.FormatInt(orders[i].OrderID, 10) == orderID {
+			return c.convertOrderToExchangeOrder(&orders[i]), nil
+		}
+	}
+	return nil, exchange.ErrOrderNotFound
+}
+
+// GetOrders returns information about currently active orders.
+func (c *Cryptopia) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return c.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (c *Cryptopia) GetOrdersContext(ctx context.Context,
+	pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	ret := []*exchange.Order{}
+	markets := []string{""}
+	if len(pairs) > 0 {
+		markets = make([]string, len(pairs))
+		for i := range pairs {
+			markets[i] = pairs[i].Display("/", true).String()
+		}
+	}
+	for _, market := range markets {
+		orders, err := c.getOpenOrders(ctx, market)
+		if err != nil {
+			return ret, err
+		}
+		for i := range orders {
+			ret = append(ret, c.convertOrderToExchangeOrder(&orders[i]))
+		}
+	}
+	return ret, nil
+}
+
+func (c *Cryptopia) convertOrderToExchangeOrder(order *Order) *exchange.Order {
+	retOrder := &exchange.Order{}
+	retOrder.OrderID = strconv.FormatInt(order.OrderID, 10)
+	// only open orders are returned by the API
+	retOrder.Status = exchange.OrderStatusActive
+	retOrder.Type = exchange.OrderTypeExchangeLimit
+	retOrder.Amount = order.Amount
+	retOrder.RemainingAmount = order.Remaining
+	retOrder.FilledAmount, _ = decimal.NewFromFloat(order.Amount).
+		Sub(decimal.NewFromFloat(order.Remaining)).Float64()
+	retOrder.Rate = order.Rate
+	retOrder.CurrencyPair = labelToCurrencyPair(order.Market)
+
+	createdAt, err := time.Parse(cryptopiaTimeFormat, order.TimeStamp)
+	if err != nil {
+		log.Printf("%s failed to parse order timestamp %s\n", c.Name, order.TimeStamp)
+	} else {
+		retOrder.CreatedAt = createdAt.Unix()
+	}
+
+	if order.Type == "Buy" {
+		retOrder.Side = exchange.OrderSideBuy
+	} else if order.Type == "Sell" {
+		retOrder.Side = exchange.OrderSideSell
+	} else {
+		log.Printf("%s failed to convert '%s' to order side\n", c.Name, order.Type)
+	}
+
+	return retOrder
+}
+
+// SendAuthenticatedHTTPRequest sends an authenticated POST request with a JSON body. The request
+// is signed with the HMAC-SHA256 of the API key, method, lower-cased escaped URL, nonce & the
+// base64 encoded MD5 of the body, using the base64 decoded API secret.
+func (c *Cryptopia) SendAuthenticatedHTTPRequest(path string, body interface{}, result interface{}) error {
+	return c.SendAuthenticatedHTTPRequestContext(context.Background(), path, body, result)
+}
+
+// SendAuthenticatedHTTPRequestContext is SendAuthenticatedHTTPRequest, the request is cancelled
+// when the context is done
+func (c *Cryptopia) SendAuthenticatedHTTPRequestContext(ctx context.Context, path string,
+	body interface{}, result interface{}) error {
+
+	if !c.AuthenticatedAPISupport {
+		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, c.Name)
+	}
+
+	if c.Nonce.Get() == 0 {
+		c.Nonce.Set(time.Now().UnixNano())
+	} else {
+		c.Nonce.Inc()
+	}
+	payload, err := common.JSONEncode(body)
+	if err != nil {
+		return err
+	}
+
+	headers := make(map[string]string)
+	apiKey, apiSecret, _ := c.Credentials()
+	headers["Authorization"] = c.authorization(apiKey, apiSecret, path, c.Nonce.String(), payload)
+	headers["Content-Type"] = "application/json; charset=utf-8"
+
+	if c.Debug(exchange.TraceHTTP) {
+		log.Printf("Sending POST request to %s with body %s\n", path, payload)
+	}
+
+	resp, err := common.SendHTTPRequestContext(ctx, "POST", path, headers, strings.NewReader(string(payload)))
+	if err != nil {
+		return err
+	}
+
+	if c.Debug(exchange.TraceHTTP) {
+		log.Printf("Received raw: %s\n", resp)
+	}
+
+	err = common.JSONDecode([]byte(resp), result)
+	if err != nil {
+		return errors.New("Unable to JSON Unmarshal response." + err.Error())
+	}
+	return nil
+}
+
+// authorization returns the value of the Authorization header of a private request
+func (c *Cryptopia) authorization(apiKey, apiSecret, path, nonce string, payload []byte) string {
+	signature := apiKey + "POST" + strings.ToLower(url.QueryEscape(path)) + nonce +
+		common.Base64Encode(common.GetMD5(payload))
+	secret, _ := common.Base64Decode(apiSecret)
+	hmac := common.GetHMAC(common.HashSHA256, []byte(signature), secret)
+	return "amx " + apiKey + ":" + common.Base64Encode(hmac) + ":" + nonce
+}
+
+// HTTPRequestJSON sends an HTTP request to a Cryptopia API endpoint and returns the data as raw
+// JSON. Public requests are sent as GET requests, private requests as POST requests with the
+// body encoded as JSON.
+func (c *Cryptopia) HTTPRequestJSON(path string, auth bool, body interface{}) (json.RawMessage, error) {
+	return c.HTTPRequestJSONContext(context.Background(), path, auth, body)
+}
+
+// HTTPRequestJSONContext is HTTPRequestJSON, the request is cancelled when the context is done
+func (c *Cryptopia) HTTPRequestJSONContext(ctx context.Context, path string, auth bool,
+	body interface{}) (json.RawMessage, error) {
+	response := Response{}
+	if auth {
+		if err := c.SendAuthenticatedHTTPRequestContext(ctx, path, body, &response); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := common.SendHTTPGetRequestContext(ctx, path, true, c.Debug(exchange.TraceHTTP),
+			&response); err != nil {
+			return nil, err
+		}
+	}
+	if response.Success {
+		return response.Data, nil
+	}
+	if response.Error != "" {
+		return nil, errors.New(response.Error)
+	}
+	return nil, errors.New(response.Message)
+}
+
+// HTTPRequest is a generalised http request function.
+func (c *Cryptopia) HTTPRequest(path string, auth bool, body interface{}, v interface{}) error {
+	return c.HTTPRequestContext(context.Background(), path, auth, body, v)
+}
+
+// HTTPRequestContext is HTTPRequest, the request is cancelled when the context is done
+func (c *Cryptopia) HTTPRequestContext(ctx context.Context, path string, auth bool, body interface{},
+	v interface{}) error {
+	msg, err := c.HTTPRequestJSONContext(ctx, path, auth, body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(msg, v)
+}
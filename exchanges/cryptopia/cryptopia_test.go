@@ -0,0 +1,117 @@
+package cryptopia
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+func newTestCryptopia(handler http.HandlerFunc) (*Cryptopia, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	c := &Cryptopia{}
+	c.SetDefaults()
+	c.APIUrl = server.URL
+	c.AuthenticatedAPISupport = true
+	c.SetAPIKeys("key", "c2VjcmV0", "", false)
+	return c, server
+}
+
+func TestSetDefaults(t *testing.T) {
+	c := Cryptopia{}
+	c.SetDefaults()
+	if c.GetName() != "Cryptopia" {
+		t.Error("Test Failed - Cryptopia - SetDefaults() error")
+	}
+}
+
+func TestSetTradePairs(t *testing.T) {
+	c, server := newTestCryptopia(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Success":true,"Message":null,"Data":[{"Id":100,"Label":"DOT/BTC","Currency":"Dotcoin",
+			"Symbol":"DOT","BaseCurrency":"Bitcoin","BaseSymbol":"BTC","Status":"OK","TradeFee":0.2,
+			"MinimumTrade":0.1,"MaximumTrade":1000000,"MinimumBaseTrade":0.0005}],"Error":null}`)
+	})
+	defer server.Close()
+
+	tradePairs, err := c.GetTradePairs()
+	if err != nil {
+		t.Fatalf("Test Failed - Cryptopia - GetTradePairs() error: %s", err)
+	}
+	c.setTradePairs(tradePairs)
+	if info, ok := c.GetCurrencyPairs()["DOT_BTC"]; !ok || info.FirstCurrencyName != "Dotcoin" {
+		t.Errorf("Test Failed - Cryptopia - unexpected currency pairs %v", c.GetCurrencyPairs())
+	}
+	p := pair.NewCurrencyPair("DOT", "BTC")
+	limits := c.GetLimits()
+	if limits.GetMinAmount(p) != 0.1 || limits.GetMinTotal(p) != 0.0005 || limits.GetPriceDecimalPlaces(p) != 8 {
+		t.Error("Test Failed - Cryptopia - unexpected limits")
+	}
+}
+
+func TestUpdateOrderbook(t *testing.T) {
+	c, server := newTestCryptopia(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/GetMarketOrders/DOT_BTC/100" {
+			fmt.Fprint(w, `{"Success":false,"Error":"Market not found"}`)
+			return
+		}
+		fmt.Fprint(w, `{"Success":true,"Data":{"Buy":[{"TradePairId":100,"Label":"DOT/BTC","Price":0.0001,"Volume":10}],
+			"Sell":[{"TradePairId":100,"Label":"DOT/BTC","Price":0.0002,"Volume":5}]}}`)
+	})
+	defer server.Close()
+
+	book, err := c.UpdateOrderbook(pair.NewCurrencyPair("DOT", "BTC"), "SPOT")
+	if err != nil || len(book.Bids) != 1 || book.Asks[0].Price != 0.0002 || book.Asks[0].Amount != 5 {
+		t.Errorf("Test Failed - Cryptopia - unexpected orderbook %+v %v", book, err)
+	}
+	if _, err = c.GetMarketOrders("XXX_BTC", 100); err == nil || err.Error() != "Market not found" {
+		t.Errorf("Test Failed - Cryptopia - expected an error, got %v", err)
+	}
+}
+
+func TestSubmitTrade(t *testing.T) {
+	c, server := newTestCryptopia(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		auth := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "amx "), ":")
+		if len(auth) != 3 || r.Header.Get("Authorization") !=
+			(&Cryptopia{}).authorization("key", "c2VjcmV0", "http://"+r.Host+r.URL.Path, auth[2], body) {
+			fmt.Fprint(w, `{"Success":false,"Error":"Signature does not match request parameters."}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/SubmitTrade":
+			fmt.Fprint(w, `{"Success":true,"Data":{"OrderId":23467,"FilledOrders":[44310]}}`)
+		case "/GetOpenOrders":
+			fmt.Fprint(w, `{"Success":true,"Data":[{"OrderId":23467,"TradePairId":100,"Market":"DOT/BTC",
+				"Type":"Sell","Rate":0.0002,"Amount":10,"Total":0.002,"Remaining":4,
+				"TimeStamp":"2014-12-07T20:04:05.3947572"}]}`)
+		}
+	})
+	defer server.Close()
+
+	p := pair.NewCurrencyPair("DOT", "BTC")
+	id, err := c.NewOrder(p, 10, 0.0002, exchange.OrderSideSell, exchange.OrderTypeExchangeLimit)
+	if err != nil || id != "23467" {
+		t.Fatalf("Test Failed - Cryptopia - unexpected order ID %s %v", id, err)
+	}
+	order, err := c.GetOrder(id, p)
+	if err != nil {
+		t.Fatalf("Test Failed - Cryptopia - GetOrder() error: %s", err)
+	}
+	if order.Status != exchange.OrderStatusActive || order.FilledAmount != 6 || order.Side != exchange.OrderSideSell ||
+		order.CurrencyPair.FirstCurrency != "DOT" || order.CreatedAt != 1417982645 {
+		t.Errorf("Test Failed - Cryptopia - unexpected order %+v", order)
+	}
+	if _, err = c.GetOrder("1", p); err == nil {
+		t.Error("Test Failed - Cryptopia - expected an order not found error")
+	}
+
+	c.APISecret = "d3Jvbmc="
+	if _, err = c.GetBalances(); err == nil {
+		t.Error("Test Failed - Cryptopia - expected a signature error")
+	}
+}
@@ -0,0 +1,123 @@
+package cryptopia
+
+import (
+	"encoding/json"
+)
+
+// Response is the generalised response type for Cryptopia
+type Response struct {
+	Success bool            `json:"Success"`
+	Message string          `json:"Message"`
+	Error   string          `json:"Error"`
+	Data    json.RawMessage `json:"Data"`
+}
+
+// TradePair holds the trading rules of a market
+type TradePair struct {
+	ID               int64   `json:"Id"`
+	Label            string  `json:"Label"`
+	Currency         string  `json:"Currency"`
+	Symbol           string  `json:"Symbol"`
+	BaseCurrency     string  `json:"BaseCurrency"`
+	BaseSymbol       string  `json:"BaseSymbol"`
+	Status           string  `json:"Status"`
+	StatusMessage    string  `json:"StatusMessage"`
+	TradeFee         float64 `json:"TradeFee"`
+	MinimumTrade     float64 `json:"MinimumTrade"`
+	MaximumTrade     float64 `json:"MaximumTrade"`
+	MinimumBaseTrade float64 `json:"MinimumBaseTrade"`
+	MaximumBaseTrade float64 `json:"MaximumBaseTrade"`
+	MinimumPrice     float64 `json:"MinimumPrice"`
+	MaximumPrice     float64 `json:"MaximumPrice"`
+}
+
+// Market holds the last 24 hour statistics of a market
+type Market struct {
+	TradePairID int64   `json:"TradePairId"`
+	Label       string  `json:"Label"`
+	AskPrice    float64 `json:"AskPrice"`
+	BidPrice    float64 `json:"BidPrice"`
+	Low         float64 `json:"Low"`
+	High        float64 `json:"High"`
+	Volume      float64 `json:"Volume"`
+	LastPrice   float64 `json:"LastPrice"`
+	BuyVolume   float64 `json:"BuyVolume"`
+	SellVolume  float64 `json:"SellVolume"`
+	Change      float64 `json:"Change"`
+	Open        float64 `json:"Open"`
+	Close       float64 `json:"Close"`
+	BaseVolume  float64 `json:"BaseVolume"`
+}
+
+// MarketOrder is a price level of the orderbook
+type MarketOrder struct {
+	TradePairID int64   `json:"TradePairId"`
+	Label       string  `json:"Label"`
+	Price       float64 `json:"Price"`
+	Volume      float64 `json:"Volume"`
+	Total       float64 `json:"Total"`
+}
+
+// MarketOrders is the orderbook of a market
+type MarketOrders struct {
+	Buy  []MarketOrder `json:"Buy"`
+	Sell []MarketOrder `json:"Sell"`
+}
+
+// Balance holds the balance of a currency
+type Balance struct {
+	CurrencyID      int64   `json:"CurrencyId"`
+	Symbol          string  `json:"Symbol"`
+	Total           float64 `json:"Total"`
+	Available       float64 `json:"Available"`
+	Unconfirmed     float64 `json:"Unconfirmed"`
+	HeldForTrades   float64 `json:"HeldForTrades"`
+	PendingWithdraw float64 `json:"PendingWithdraw"`
+	Address         string  `json:"Address"`
+	BaseAddress     string  `json:"BaseAddress"`
+	Status          string  `json:"Status"`
+	StatusMessage   string  `json:"StatusMessage"`
+}
+
+// SubmitTradeRequest is the body of a new order request, Type is either "Buy" or "Sell"
+type SubmitTradeRequest struct {
+	Market string  `json:"Market"`
+	Type   string  `json:"Type"`
+	Rate   float64 `json:"Rate"`
+	Amount float64 `json:"Amount"`
+}
+
+// SubmitTradeResponse is the result of placing an order, OrderID is nil if the order was filled
+// immediately.
+type SubmitTradeResponse struct {
+	OrderID      *int64  `json:"OrderId"`
+	FilledOrders []int64 `json:"FilledOrders"`
+}
+
+// CancelTradeRequest is the body of a cancel request, Type is either "Trade" (cancels a single
+// order), "TradePair" or "All".
+type CancelTradeRequest struct {
+	Type        string `json:"Type"`
+	OrderID     int64  `json:"OrderId,omitempty"`
+	TradePairID int64  `json:"TradePairId,omitempty"`
+}
+
+// GetOpenOrdersRequest is the body of an open orders request, all markets are returned if
+// Market is empty.
+type GetOpenOrdersRequest struct {
+	Market string `json:"Market,omitempty"`
+	Count  int    `json:"Count,omitempty"`
+}
+
+// Order is an open order
+type Order struct {
+	OrderID     int64   `json:"OrderId"`
+	TradePairID int64   `json:"TradePairId"`
+	Market      string  `json:"Market"`
+	Type        string  `json:"Type"`
+	Rate        float64 `json:"Rate"`
+	Amount      float64 `json:"Amount"`
+	Total       float64 `json:"Total"`
+	Remaining   float64 `json:"Remaining"`
+	TimeStamp   string  `json:"TimeStamp"`
+}
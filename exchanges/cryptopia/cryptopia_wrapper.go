@@ -0,0 +1,194 @@
+package cryptopia
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+var _ exchange.IExchange = (*Cryptopia)(nil)
+var _ exchange.ContextExchange = (*Cryptopia)(nil)
+
+// Start starts the Cryptopia go routine
+func (c *Cryptopia) Start() {
+	go c.Run()
+}
+
+// Run implements the Cryptopia wrapper
+func (c *Cryptopia) Run() {
+	if c.Debug("") {
+		log.Printf("%s polling delay: %ds.\n", c.GetName(), c.RESTPollingDelay)
+		log.Printf("%s %d currencies enabled: %s.\n", c.GetName(), len(c.EnabledPairs), c.EnabledPairs)
+	}
+
+	tradePairs, err := c.GetTradePairs()
+	if err != nil {
+		log.Printf("%s Failed to get available symbols.\n", c.GetName())
+		return
+	}
+	c.setTradePairs(tradePairs)
+
+	var currencies []string
+	for i := range tradePairs {
+		if tradePairs[i].Status != "OK" {
+			continue
+		}
+		currencies = append(currencies, c.CurrencyPairToSymbol(labelToCurrencyPair(tradePairs[i].Label)))
+	}
+	err = c.UpdateAvailableCurrencies(currencies, false)
+	if err != nil {
+		log.Printf("%s Failed to get config.\n", c.GetName())
+	}
+}
+
+// setTradePairs replaces the currency pairs & trading rules of the exchange
+func (c *Cryptopia) setTradePairs(tradePairs []TradePair) {
+	c.symbolCache.Reset()
+	c.currencyPairs = make(map[pair.CurrencyItem]*exchange.CurrencyPairInfo, len(tradePairs))
+	c.tradePairs = make(map[pair.CurrencyItem]*TradePair, len(tradePairs))
+	for i := range tradePairs {
+		tradePair := &tradePairs[i]
+		currencyPair := labelToCurrencyPair(tradePair.Label)
+		c.currencyPairs[pair.CurrencyItem(c.CurrencyPairToSymbol(currencyPair))] = &exchange.CurrencyPairInfo{
+			Currency:           currencyPair,
+			FirstCurrencyName:  tradePair.Currency,
+			SecondCurrencyName: tradePair.BaseCurrency,
+		}
+		c.tradePairs[currencyPair.Display("/", false)] = tradePair
+	}
+}
+
+// GetExchangeAccountInfo Retrieves balances for all enabled currencies for the
+// Cryptopia exchange
+func (c *Cryptopia) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return c.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (c *Cryptopia) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
+	var response exchange.AccountInfo
+	response.ExchangeName = c.GetName()
+	balances, err := c.getBalances(ctx)
+	if err != nil {
+		return response, err
+	}
+
+	for i := range balances {
+		src := &balances[i]
+		response.Currencies = append(response.Currencies, exchange.AccountCurrencyInfo{
+			CurrencyName: strings.ToUpper(src.Symbol),
+			TotalValue:   src.Total,
+			Available:    src.Available,
+			Hold:         src.HeldForTrades,
+		})
+	}
+	return response, nil
+}
+
+// UpdateTicker updates and returns the ticker for a currency pair
+func (c *Cryptopia) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return c.UpdateTickerContext(context.Background(), p, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (c *Cryptopia) UpdateTickerContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
+	var tickerPrice ticker.Price
+	market, err := c.getMarket(ctx, c.CurrencyPairToSymbol(p))
+	if err != nil {
+		return tickerPrice, err
+	}
+	tickerPrice.Pair = p
+	tickerPrice.Ask = market.AskPrice
+	tickerPrice.Bid = market.BidPrice
+	tickerPrice.Last = market.LastPrice
+	tickerPrice.High = market.High
+	tickerPrice.Low = market.Low
+	tickerPrice.Volume = market.Volume
+	tickerPrice.LastUpdated = time.Now()
+	ticker.ProcessTicker(c.GetName(), p, tickerPrice, assetType)
+	return ticker.GetTicker(c.Name, p, assetType)
+}
+
+// GetTickerPrice returns the ticker for a currency pair
+func (c *Cryptopia) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	tick, err := ticker.GetTicker(c.GetName(), p, assetType)
+	if err != nil {
+		return c.UpdateTicker(p, assetType)
+	}
+	return tick, nil
+}
+
+// GetOrderbookEx returns the orderbook for a currency pair
+func (c *Cryptopia) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
+	ob, err := c.Orderbooks.GetOrderbook(c.GetName(), p, assetType)
+	if err != nil {
+		return c.UpdateOrderbook(p, assetType)
+	}
+	return ob, nil
+}
+
+// UpdateOrderbook updates and returns the orderbook for a currency pair
+func (c *Cryptopia) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return c.UpdateOrderbookContext(context.Background(), p, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (c *Cryptopia) UpdateOrderbookContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
+	var orderBook orderbook.Base
+	orders, err := c.getMarketOrders(ctx, c.CurrencyPairToSymbol(p), cryptopiaDefaultOrderbookLen)
+	if err != nil {
+		return orderBook, err
+	}
+
+	orderBook.Bids = orderbook.GetItems(len(orders.Buy))
+	for x := range orders.Buy {
+		orderBook.Bids = append(orderBook.Bids,
+			orderbook.Item{
+				Amount: orders.Buy[x].Volume,
+				Price:  orders.Buy[x].Price,
+			},
+		)
+	}
+
+	orderBook.Asks = orderbook.GetItems(len(orders.Sell))
+	for x := range orders.Sell {
+		orderBook.Asks = append(orderBook.Asks,
+			orderbook.Item{
+				Amount: orders.Sell[x].Volume,
+				Price:  orders.Sell[x].Price,
+			},
+		)
+	}
+
+	c.Orderbooks.ProcessOrderbook(c.GetName(), p, orderBook, assetType)
+	return c.Orderbooks.GetOrderbook(c.Name, p, assetType)
+}
+
+// ListInstruments returns the markets that are currently trading on the exchange
+func (c *Cryptopia) ListInstruments() ([]exchange.Instrument, error) {
+	tradePairs, err := c.GetTradePairs()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]exchange.Instrument, 0, len(tradePairs))
+	for i := range tradePairs {
+		if tradePairs[i].Status != "OK" {
+			continue
+		}
+		p := labelToCurrencyPair(tradePairs[i].Label)
+		result = append(result, exchange.Instrument{
+			Symbol: c.CurrencyPairToSymbol(p),
+			Pair:   p,
+		})
+	}
+	return result, nil
+}
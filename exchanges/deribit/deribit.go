@@ -0,0 +1,277 @@
+package deribit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	exchange "github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+const (
+	deribitBaseURL             = "https://www.deribit.com"
+	deribitInstruments         = "/api/v2/public/get_instruments"
+	deribitTicker              = "/api/v2/public/ticker"
+	deribitOrderBook           = "/api/v2/public/get_order_book"
+	deribitAccountSummary      = "/api/v2/private/get_account_summary"
+	deribitBuy                 = "/api/v2/private/buy"
+	deribitSell                = "/api/v2/private/sell"
+	deribitCancel              = "/api/v2/private/cancel"
+	deribitOrderState          = "/api/v2/private/get_order_state"
+	deribitOpenOrders          = "/api/v2/private/get_open_orders_by_currency"
+	deribitPositions           = "/api/v2/private/get_positions"
+	deribitDefaultBookDepth    = 100
+	deribitSignatureAlgorithm  = "deri-hmac-sha256"
+	deribitPerpetualSymbolTail = "-PERPETUAL"
+)
+
+// Currencies (underlyings) the contracts are listed for
+var deribitCurrencies = []string{"BTC", "ETH"}
+
+// Deribit is the client of the Deribit derivatives exchange, which lists inverse futures
+// (including a perpetual swap) & European options on BTC & ETH. The amounts of orders & positions
+// are in contracts, a futures contract is worth 10 USD (see the contract specification in the
+// currency pair info) & an options contract is worth one unit of the underlying currency.
+type Deribit struct {
+	exchange.Base
+	// Maps symbol (instrument name) to contract info, for all the contracts
+	contracts map[pair.CurrencyItem]*exchange.CurrencyPairInfo
+	// Maps symbol to currency pair info, only for the perpetual swaps
+	currencyPairs    map[pair.CurrencyItem]*exchange.CurrencyPairInfo
+	symbolDetailsMap map[pair.CurrencyItem]*symbolDetails
+	// Caches the results of the bulk symbol <-> currency pair conversions
+	symbolCache exchange.SymbolCache
+}
+
+// CurrencyPairToSymbol converts a currency pair to a symbol (exchange specific market identifier),
+// currency pairs are traded through the perpetual swap of the base currency (e.g. BTC-PERPETUAL).
+func (d *Deribit) CurrencyPairToSymbol(p pair.CurrencyPair) string {
+	return p.FirstCurrency.Upper().String() + deribitPerpetualSymbolTail
+}
+
+// SymbolToCurrencyPair converts a symbol (exchange specific market identifier) to a currency pair,
+// all the contracts on the same underlying map to the same currency pair.
+func (d *Deribit) SymbolToCurrencyPair(symbol string) (pair.CurrencyPair, error) {
+	if info, exists := d.contracts[pair.CurrencyItem(symbol)]; exists {
+		return info.Currency, nil
+	}
+	return pair.CurrencyPair{}, fmt.Errorf("no currency pair found for '%s' symbol", symbol)
+}
+
+// CurrencyPairsToSymbols converts a batch of currency pairs to symbols, conversions are cached.
+func (d *Deribit) CurrencyPairsToSymbols(pairs []pair.CurrencyPair) ([]string, error) {
+	return d.symbolCache.CurrencyPairsToSymbols(pairs, func(cp pair.CurrencyPair) (string, error) {
+		return d.CurrencyPairToSymbol(cp), nil
+	})
+}
+
+// SymbolsToCurrencyPairs converts a batch of symbols to currency pairs, conversions are cached.
+func (d *Deribit) SymbolsToCurrencyPairs(symbols []string) ([]pair.CurrencyPair, error) {
+	return d.symbolCache.SymbolsToCurrencyPairs(symbols, d.SymbolToCurrencyPair)
+}
+
+// FetchInstruments fetches the active futures & options contracts on an underlying currency.
+func (d *Deribit) FetchInstruments(currency string) ([]Instrument, error) {
+	v := url.Values{}
+	v.Set("currency", currency)
+	v.Set("expired", "false")
+	var response []Instrument
+	err := d.SendHTTPRequest(deribitInstruments, v, false, &response)
+	return response, err
+}
+
+// FetchTicker fetches the market data of an instrument.
+func (d *Deribit) FetchTicker(instrumentName string) (*Ticker, error) {
+	return d.fetchTicker(context.Background(), instrumentName)
+}
+
+func (d *Deribit) fetchTicker(ctx context.Context, instrumentName string) (*Ticker, error) {
+	v := url.Values{}
+	v.Set("instrument_name", instrumentName)
+	response := Ticker{}
+	err := d.SendHTTPRequestContext(ctx, deribitTicker, v, false, &response)
+	return &response, err
+}
+
+// FetchOrderBook fetches the orderbook of an instrument, depth is the number of price levels on
+// each side.
+func (d *Deribit) FetchOrderBook(instrumentName string, depth int) (*OrderBook, error) {
+	return d.fetchOrderBook(context.Background(), instrumentName, depth)
+}
+
+func (d *Deribit) fetchOrderBook(ctx context.Context, instrumentName string, depth int) (*OrderBook, error) {
+	v := url.Values{}
+	v.Set("instrument_name", instrumentName)
+	v.Set("depth", strconv.Itoa(depth))
+	response := OrderBook{}
+	err := d.SendHTTPRequestContext(ctx, deribitOrderBook, v, false, &response)
+	return &response, err
+}
+
+// FetchAccountSummary fetches the balances of a currency.
+func (d *Deribit) FetchAccountSummary(currency string) (*AccountSummary, error) {
+	return d.fetchAccountSummary(context.Background(), currency)
+}
+
+func (d *Deribit) fetchAccountSummary(ctx context.Context, currency string) (*AccountSummary, error) {
+	v := url.Values{}
+	v.Set("currency", currency)
+	response := AccountSummary{}
+	err := d.SendHTTPRequestContext(ctx, deribitAccountSummary, v, true, &response)
+	return &response, err
+}
+
+// PlaceOrder places an order, direction is DirectionBuy or DirectionSell & the amount is in USD
+// for futures & in the base currency for options. The label is stored along with the order.
+func (d *Deribit) PlaceOrder(direction, instrumentName string, amount, price float64, orderType,
+	label string) (*Order, error) {
+	return d.placeOrder(context.Background(), direction, instrumentName, amount, price, orderType, label)
+}
+
+func (d *Deribit) placeOrder(ctx context.Context, direction, instrumentName string, amount,
+	price float64, orderType, label string) (*Order, error) {
+	v := url.Values{}
+	v.Set("instrument_name", instrumentName)
+	v.Set("amount", strconv.FormatFloat(amount, 'f', -1, 64))
+	v.Set("type", orderType)
+	if orderType == OrderTypeLimit {
+		v.Set("price", strconv.FormatFloat(price, 'f', -1, 64))
+	}
+	if label != "" {
+		v.Set("label", label)
+	}
+	path := deribitBuy
+	if direction == DirectionSell {
+		path = deribitSell
+	}
+	response := OrderResponse{}
+	if err := d.SendHTTPRequestContext(ctx, path, v, true, &response); err != nil {
+		return nil, err
+	}
+	return &response.Order, nil
+}
+
+// Cancel cancels an open order.
+func (d *Deribit) Cancel(orderID string) (*Order, error) {
+	return d.cancel(context.Background(), orderID)
+}
+
+func (d *Deribit) cancel(ctx context.Context, orderID string) (*Order, error) {
+	v := url.Values{}
+	v.Set("order_id", orderID)
+	response := Order{}
+	err := d.SendHTTPRequestContext(ctx, deribitCancel, v, true, &response)
+	return &response, err
+}
+
+// FetchOrderState fetches an order (which may be active or inactive).
+func (d *Deribit) FetchOrderState(orderID string) (*Order, error) {
+	return d.fetchOrderState(context.Background(), orderID)
+}
+
+func (d *Deribit) fetchOrderState(ctx context.Context, orderID string) (*Order, error) {
+	v := url.Values{}
+	v.Set("order_id", orderID)
+	response := Order{}
+	err := d.SendHTTPRequestContext(ctx, deribitOrderState, v, true, &response)
+	return &response, err
+}
+
+// FetchOpenOrders fetches the open orders of the contracts on an underlying currency.
+func (d *Deribit) FetchOpenOrders(currency string) ([]Order, error) {
+	return d.fetchOpenOrders(context.Background(), currency)
+}
+
+func (d *Deribit) fetchOpenOrders(ctx context.Context, currency string) ([]Order, error) {
+	v := url.Values{}
+	v.Set("currency", currency)
+	var response []Order
+	err := d.SendHTTPRequestContext(ctx, deribitOpenOrders, v, true, &response)
+	return response, err
+}
+
+// FetchPositions fetches the positions in the contracts on an underlying currency.
+func (d *Deribit) FetchPositions(currency string) ([]Position, error) {
+	v := url.Values{}
+	v.Set("currency", currency)
+	var response []Position
+	err := d.SendHTTPRequest(deribitPositions, v, true, &response)
+	return response, err
+}
+
+// SendHTTPRequest sends a GET request to a JSON-RPC method, the params are sent in the query
+// string. Authenticated requests are signed with the API secret. The result of the response is
+// decoded into the result object.
+func (d *Deribit) SendHTTPRequest(path string, params url.Values, authenticated bool,
+	result interface{}) error {
+	return d.SendHTTPRequestContext(context.Background(), path, params, authenticated, result)
+}
+
+// SendHTTPRequestContext is SendHTTPRequest, the request is cancelled when the context is done
+func (d *Deribit) SendHTTPRequestContext(ctx context.Context, path string, params url.Values,
+	authenticated bool, result interface{}) error {
+	if authenticated && !d.AuthenticatedAPISupport {
+		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, d.Name)
+	}
+
+	requestPath := path
+	if len(params) > 0 {
+		requestPath += "?" + params.Encode()
+	}
+
+	if d.Debug(exchange.TraceHTTP) {
+		log.Printf("Request: %s\n", requestPath)
+	}
+
+	headers := make(http.Header)
+	headers.Set("Accept", "application/json")
+	if authenticated {
+		apiKey, apiSecret, _ := d.Credentials()
+
+		timestamp := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+		nonce := strconv.FormatInt(time.Now().UnixNano(), 36)
+		headers.Set("Authorization", fmt.Sprintf("%s id=%s,ts=%s,sig=%s,nonce=%s",
+			deribitSignatureAlgorithm, apiKey, timestamp,
+			d.sign(apiSecret, http.MethodGet, requestPath, timestamp, nonce, nil), nonce))
+	}
+
+	resp, statusCode, err := common.SendHTTPRequest2Context(ctx, http.MethodGet, d.APIUrl+requestPath,
+		headers, strings.NewReader(""))
+	if err != nil {
+		return err
+	}
+
+	if d.Debug(exchange.TraceHTTP) {
+		log.Printf("Received raw: \n%s\n", resp)
+	}
+
+	response := Response{}
+	if err = common.JSONDecode([]byte(resp), &response); err != nil {
+		return exchange.NewExchangeError(d.Name, path, statusCode, 0, "failed to unmarshal response", resp)
+	}
+	if response.Error != nil {
+		return exchange.NewExchangeError(d.Name, path, statusCode, response.Error.Code,
+			response.Error.Message, resp)
+	}
+	if statusCode < 200 || statusCode > 299 {
+		return exchange.NewExchangeError(d.Name, path, statusCode, 0, "unexpected status", resp)
+	}
+	if err = common.JSONDecode(response.Result, result); err != nil {
+		return exchange.NewExchangeError(d.Name, path, statusCode, 0, "failed to unmarshal result", resp)
+	}
+	return nil
+}
+
+// sign returns the signature of a request, the hex encoded HMAC-SHA256 of the timestamp, nonce,
+// method, request path (including the query string) & body.
+func (d *Deribit) sign(apiSecret, method, requestPath, timestamp, nonce string, body []byte) string {
+	message := timestamp + "\n" + nonce + "\n" + method + "\n" + requestPath + "\n" + string(body) + "\n"
+	return common.HexEncodeToString(common.GetHMAC(common.HashSHA256, []byte(message), []byte(apiSecret)))
+}
@@ -0,0 +1,201 @@
+package deribit
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/asset"
+)
+
+const testInstruments = `{"jsonrpc":"2.0","result":[
+	{"instrument_name":"BTC-PERPETUAL","kind":"future","base_currency":"BTC","quote_currency":"USD",
+		"settlement_period":"perpetual","is_active":true,"expiration_timestamp":32503708800000,
+		"tick_size":0.5,"min_trade_amount":10,"contract_size":10},
+	{"instrument_name":"BTC-28DEC18","kind":"future","base_currency":"BTC","quote_currency":"USD",
+		"settlement_period":"month","is_active":true,"expiration_timestamp":1545984000000,
+		"tick_size":0.5,"min_trade_amount":10,"contract_size":10},
+	{"instrument_name":"BTC-28DEC18-6000-P","kind":"option","base_currency":"BTC","quote_currency":"BTC",
+		"settlement_period":"month","is_active":true,"expiration_timestamp":1545984000000,"strike":6000,
+		"option_type":"put","tick_size":0.0005,"min_trade_amount":0.1,"contract_size":1},
+	{"instrument_name":"BTC-30NOV18","kind":"future","base_currency":"BTC","quote_currency":"USD",
+		"settlement_period":"month","is_active":false,"expiration_timestamp":1543564800000,
+		"tick_size":0.5,"min_trade_amount":10,"contract_size":10}
+]}`
+
+func newTestDeribit(handler http.HandlerFunc) (*Deribit, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	d := &Deribit{}
+	d.SetDefaults()
+	d.APIUrl = server.URL
+	d.AuthenticatedAPISupport = true
+	d.SetAPIKeys("key", "secret", "", false)
+	return d, server
+}
+
+func setTestInstruments(t *testing.T, d *Deribit) {
+	instruments, err := d.FetchInstruments("BTC")
+	if err != nil {
+		t.Fatalf("Test failed. FetchInstruments returned an error: %s", err)
+	}
+	d.setInstruments(instruments)
+}
+
+func TestSetInstruments(t *testing.T) {
+	d, server := newTestDeribit(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testInstruments)
+	})
+	defer server.Close()
+	setTestInstruments(t, d)
+
+	if len(d.GetCurrencyPairs()) != 1 || len(d.GetContracts("")) != 3 {
+		t.Errorf("Test failed. Unexpected currency pairs %v & contracts %v", d.GetCurrencyPairs(), d.GetContracts(""))
+	}
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+	if d.CurrencyPairToSymbol(btcusd) != "BTC-PERPETUAL" {
+		t.Errorf("Test failed. Unexpected symbol %s", d.CurrencyPairToSymbol(btcusd))
+	}
+	perpetual := d.GetCurrencyPairs()["BTC-PERPETUAL"]
+	if perpetual == nil || perpetual.AssetType != asset.PerpetualSwap || !perpetual.Inverse ||
+		perpetual.ContractSize != 10 || !perpetual.Expiry.IsZero() {
+		t.Errorf("Test failed. Unexpected perpetual contract %+v", perpetual)
+	}
+
+	futures := d.GetContracts(asset.Futures)
+	if len(futures) != 1 || futures["BTC-28DEC18"] == nil ||
+		!futures["BTC-28DEC18"].Expiry.Equal(time.Date(2018, 12, 28, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("Test failed. Unexpected futures contracts %v", futures)
+	}
+	option := d.GetContracts(asset.Options)["BTC-28DEC18-6000-P"]
+	if option == nil || option.StrikePrice != 6000 || option.OptionType != exchange.OptionTypePut ||
+		option.Inverse || option.SettlementCurrency != "BTC" || !option.Currency.Equal(btcusd) {
+		t.Errorf("Test failed. Unexpected option contract %+v", option)
+	}
+
+	limits := d.GetLimits()
+	if limits.GetPriceDecimalPlaces(btcusd) != 1 || limits.GetAmountDecimalPlaces(btcusd) != 0 ||
+		limits.GetMinAmount(btcusd) != 1 {
+		t.Error("Test failed. Unexpected limits")
+	}
+}
+
+func TestSendHTTPRequestSigned(t *testing.T) {
+	d, server := newTestDeribit(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case deribitInstruments:
+			fmt.Fprint(w, testInstruments)
+			return
+		}
+		var id, ts, sig, nonce string
+		auth := strings.TrimPrefix(r.Header.Get("Authorization"), deribitSignatureAlgorithm+" ")
+		for _, field := range strings.Split(auth, ",") {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			switch kv[0] {
+			case "id":
+				id = kv[1]
+			case "ts":
+				ts = kv[1]
+			case "sig":
+				sig = kv[1]
+			case "nonce":
+				nonce = kv[1]
+			}
+		}
+		expected := (&Deribit{}).sign("secret", r.Method, r.URL.RequestURI(), ts, nonce, nil)
+		if id != "key" || sig != expected {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"jsonrpc":"2.0","error":{"message":"invalid_signature","code":13004}}`)
+			return
+		}
+		if r.URL.Path != deribitSell || r.URL.Query().Get("amount") != "50" ||
+			r.URL.Query().Get("label") != "grid_1" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","error":{"message":"unexpected request %s","code":-32602}}`, r.URL)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":{"order":{"order_id":"4281","instrument_name":"BTC-28DEC18",
+			"direction":"sell","amount":50,"filled_amount":0,"price":6500,"order_type":"limit",
+			"order_state":"open","label":"grid_1"},"trades":[]}}`)
+	})
+	defer server.Close()
+	setTestInstruments(t, d)
+
+	id, err := d.NewContractOrder("BTC-28DEC18", 5, 6500, exchange.OrderSideSell,
+		exchange.OrderTypeExchangeLimit, exchange.OrderOptions{ClientOrderID: "grid_1"})
+	if err != nil || id != "4281" {
+		t.Errorf("Test failed. Unexpected order ID %s %v", id, err)
+	}
+	_, err = d.NewOrder(pair.NewCurrencyPair("BTC", "USD"), 1, 6500, exchange.OrderSideBuy,
+		exchange.OrderTypeExchangeLimit, exchange.OrderOptions{Hidden: true})
+	if err != exchange.ErrOrderOptionNotSupported {
+		t.Errorf("Test failed. Expected hidden orders to be rejected but got %v", err)
+	}
+
+	d.APISecret = "wrong"
+	_, err = d.FetchAccountSummary("BTC")
+	if e, ok := err.(*exchange.ExchangeError); !ok || e.Message != "invalid_signature" || e.Code != 13004 {
+		t.Errorf("Test failed. Expected an invalid signature error but got %v", err)
+	}
+}
+
+func TestGetPositions(t *testing.T) {
+	d, server := newTestDeribit(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case deribitInstruments:
+			fmt.Fprint(w, testInstruments)
+		case deribitPositions:
+			if r.URL.Query().Get("currency") != "BTC" {
+				fmt.Fprint(w, `{"jsonrpc":"2.0","result":[]}`)
+				return
+			}
+			fmt.Fprint(w, `{"jsonrpc":"2.0","result":[
+				{"instrument_name":"BTC-PERPETUAL","kind":"future","direction":"sell","size":-500,
+					"average_price":6400,"floating_profit_loss":0.0025,"leverage":10,
+					"estimated_liquidation_price":7000},
+				{"instrument_name":"BTC-28DEC18-6000-P","kind":"option","direction":"buy","size":2.5,
+					"average_price":0.04,"floating_profit_loss":-0.01},
+				{"instrument_name":"BTC-28DEC18","kind":"future","direction":"zero","size":0}]}`)
+		}
+	})
+	defer server.Close()
+	setTestInstruments(t, d)
+
+	positions, err := d.GetPositions()
+	if err != nil || len(positions) != 2 {
+		t.Fatalf("Test failed. Unexpected positions %+v %v", positions, err)
+	}
+	p := positions[0]
+	if p.Side != exchange.OrderSideSell || p.Amount != 50 || p.Leverage != 10 || p.LiquidationPrice != 7000 ||
+		p.ProfitLoss != 0.0025 || p.AssetType != asset.PerpetualSwap || p.CurrencyPair.Pair().String() != "BTCUSD" {
+		t.Errorf("Test failed. Unexpected position %+v", p)
+	}
+	p = positions[1]
+	if p.Side != exchange.OrderSideBuy || p.Amount != 2.5 || p.AssetType != asset.Options || p.ID != "BTC-28DEC18-6000-P" {
+		t.Errorf("Test failed. Unexpected position %+v", p)
+	}
+}
+
+func TestConvertOrder(t *testing.T) {
+	d, server := newTestDeribit(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testInstruments)
+	})
+	defer server.Close()
+	setTestInstruments(t, d)
+
+	order := d.convertOrderToExchangeOrder(&Order{OrderID: "1", InstrumentName: "BTC-28DEC18",
+		Direction: DirectionBuy, Amount: 100, FilledAmount: 40, Price: 6500, OrderType: OrderTypeLimit,
+		OrderState: OrderStateOpen, Label: "grid_1", CreationTimestamp: 1543000000123})
+	if order.Status != exchange.OrderStatusActive || order.Side != exchange.OrderSideBuy ||
+		order.Amount != 10 || order.FilledAmount != 4 || order.RemainingAmount != 6 ||
+		order.AssetType != asset.Futures || order.InternalOrderID != "grid_1" || order.CreatedAt != 1543000000 {
+		t.Errorf("Test failed. Unexpected order %+v", order)
+	}
+}
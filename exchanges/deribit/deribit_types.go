@@ -0,0 +1,135 @@
+package deribit
+
+import (
+	"encoding/json"
+)
+
+// Instrument kinds
+const (
+	KindFuture = "future"
+	KindOption = "option"
+)
+
+// Settlement periods of the futures contracts
+const (
+	SettlementPeriodPerpetual = "perpetual"
+)
+
+// Order directions
+const (
+	DirectionBuy  = "buy"
+	DirectionSell = "sell"
+)
+
+// Order types
+const (
+	OrderTypeLimit  = "limit"
+	OrderTypeMarket = "market"
+)
+
+// Order states
+const (
+	OrderStateOpen        = "open"
+	OrderStateFilled      = "filled"
+	OrderStateRejected    = "rejected"
+	OrderStateCancelled   = "cancelled"
+	OrderStateUntriggered = "untriggered"
+)
+
+// Error is the error of a rejected JSON-RPC request
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Response is the JSON-RPC envelope of every response, Result is decoded separately
+type Response struct {
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
+// Instrument holds the contract specification of a futures or options contract
+type Instrument struct {
+	InstrumentName   string `json:"instrument_name"`
+	Kind             string `json:"kind"`
+	BaseCurrency     string `json:"base_currency"`
+	QuoteCurrency    string `json:"quote_currency"`
+	SettlementPeriod string `json:"settlement_period"`
+	IsActive         bool   `json:"is_active"`
+	// Expiration time in milliseconds since the Unix epoch
+	ExpirationTimestamp int64 `json:"expiration_timestamp"`
+	// Strike price & option type (call or put) of options contracts
+	Strike     float64 `json:"strike"`
+	OptionType string  `json:"option_type"`
+	TickSize   float64 `json:"tick_size"`
+	// Minimum order amount, in USD for futures & in the base currency for options
+	MinTradeAmount float64 `json:"min_trade_amount"`
+	// Value of a contract, in USD for futures & in the base currency for options
+	ContractSize float64 `json:"contract_size"`
+}
+
+// Ticker holds the market data of an instrument
+type Ticker struct {
+	InstrumentName string  `json:"instrument_name"`
+	BestBidPrice   float64 `json:"best_bid_price"`
+	BestAskPrice   float64 `json:"best_ask_price"`
+	LastPrice      float64 `json:"last_price"`
+	MarkPrice      float64 `json:"mark_price"`
+	Stats          struct {
+		High   float64 `json:"high"`
+		Low    float64 `json:"low"`
+		Volume float64 `json:"volume"`
+	} `json:"stats"`
+	// Time in milliseconds since the Unix epoch
+	Timestamp int64 `json:"timestamp"`
+}
+
+// OrderBook holds the orderbook of an instrument, each price level is a [price, amount] pair
+type OrderBook struct {
+	InstrumentName string       `json:"instrument_name"`
+	Bids           [][2]float64 `json:"bids"`
+	Asks           [][2]float64 `json:"asks"`
+	Timestamp      int64        `json:"timestamp"`
+}
+
+// AccountSummary holds the balances of a currency
+type AccountSummary struct {
+	Currency       string  `json:"currency"`
+	Balance        float64 `json:"balance"`
+	Equity         float64 `json:"equity"`
+	AvailableFunds float64 `json:"available_funds"`
+}
+
+// Order holds the details of an order, amounts are in USD for futures & in the base currency for
+// options
+type Order struct {
+	OrderID        string  `json:"order_id"`
+	Label          string  `json:"label"`
+	InstrumentName string  `json:"instrument_name"`
+	Direction      string  `json:"direction"`
+	Amount         float64 `json:"amount"`
+	FilledAmount   float64 `json:"filled_amount"`
+	Price          float64 `json:"price"`
+	AveragePrice   float64 `json:"average_price"`
+	OrderType      string  `json:"order_type"`
+	OrderState     string  `json:"order_state"`
+	// Creation time in milliseconds since the Unix epoch
+	CreationTimestamp int64 `json:"creation_timestamp"`
+}
+
+// OrderResponse is the result of a new order
+type OrderResponse struct {
+	Order Order `json:"order"`
+}
+
+// Position holds the position in an instrument, Size is negative for short positions
+type Position struct {
+	InstrumentName            string  `json:"instrument_name"`
+	Kind                      string  `json:"kind"`
+	Direction                 string  `json:"direction"`
+	Size                      float64 `json:"size"`
+	AveragePrice              float64 `json:"average_price"`
+	FloatingProfitLoss        float64 `json:"floating_profit_loss"`
+	Leverage                  float64 `json:"leverage"`
+	EstimatedLiquidationPrice float64 `json:"estimated_liquidation_price"`
+}
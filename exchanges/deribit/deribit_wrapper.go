@@ -0,0 +1,632 @@
+package deribit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/asset"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+var _ exchange.IExchange = (*Deribit)(nil)
+var _ exchange.ContextExchange = (*Deribit)(nil)
+
+// New returns a Deribit exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *Deribit {
+	d := &Deribit{}
+	d.SetDefaults()
+	d.Quickstart(d.Setup, apiKey, apiSecret, opts...)
+	return d
+}
+
+// SetDefaults sets the basic defaults for Deribit
+func (d *Deribit) SetDefaults() {
+	d.Name = "Deribit"
+	d.APIUrl = deribitBaseURL
+	d.Enabled = false
+	d.Verbose = false
+	d.Websocket = false
+	d.RESTPollingDelay = 10
+	d.TakerFee = 0.05
+	d.MakerFee = -0.02
+	d.RequestCurrencyPairFormat.Delimiter = "-"
+	d.RequestCurrencyPairFormat.Uppercase = true
+	d.ConfigCurrencyPairFormat.Delimiter = "-"
+	d.ConfigCurrencyPairFormat.Uppercase = true
+	d.AssetTypes = []string{asset.PerpetualSwap, asset.Futures, asset.Options}
+	d.Orderbooks = orderbook.Init()
+}
+
+// Setup takes in the supplied exchange configuration details and sets params
+func (d *Deribit) Setup(exch config.ExchangeConfig) {
+	if !exch.Enabled {
+		d.SetEnabled(false)
+	} else {
+		d.Enabled = true
+		d.AuthenticatedAPISupport = exch.AuthenticatedAPISupport
+		d.SetAPIKeys(exch.APIKey, exch.APISecret, "", false)
+		d.RESTPollingDelay = exch.RESTPollingDelay
+		d.Verbose = exch.Verbose
+		d.Websocket = exch.Websocket
+		d.SetAPIURL(exch)
+		d.BaseCurrencies = common.SplitStrings(exch.BaseCurrencies, ",")
+		d.AvailablePairs = common.SplitStrings(exch.AvailablePairs, ",")
+		d.EnabledPairs = common.SplitStrings(exch.EnabledPairs, ",")
+		err := d.SetCurrencyPairFormat()
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = d.SetAssetTypes()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// Start starts the Deribit go routine
+func (d *Deribit) Start() {
+	go d.Run()
+}
+
+// Run implements the Deribit wrapper
+func (d *Deribit) Run() {
+	if d.Debug("") {
+		log.Printf("%s polling delay: %ds.\n", d.GetName(), d.RESTPollingDelay)
+		log.Printf("%s %d currencies enabled: %s.\n", d.GetName(), len(d.EnabledPairs), d.EnabledPairs)
+	}
+
+	instruments, err := d.fetchAllInstruments()
+	if err != nil {
+		log.Printf("%s failed to get instruments\n", d.GetName())
+		return
+	}
+	d.setInstruments(instruments)
+
+	var exchangeProducts []string
+	for _, info := range d.currencyPairs {
+		exchangeProducts = append(exchangeProducts, info.Currency.Display("-", true).String())
+	}
+	err = d.UpdateAvailableCurrencies(exchangeProducts, false)
+	if err != nil {
+		log.Printf("%s failed to update available currencies\n", d.Name)
+	}
+}
+
+// fetchAllInstruments fetches the active contracts on all the underlying currencies
+func (d *Deribit) fetchAllInstruments() ([]Instrument, error) {
+	var result []Instrument
+	for _, currency := range deribitCurrencies {
+		instruments, err := d.FetchInstruments(currency)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, instruments...)
+	}
+	return result, nil
+}
+
+// setInstruments replaces the contracts & trading rules of the exchange, the perpetual swaps are
+// also used as the currency pairs of the exchange.
+func (d *Deribit) setInstruments(instruments []Instrument) {
+	d.symbolCache.Reset()
+	d.contracts = make(map[pair.CurrencyItem]*exchange.CurrencyPairInfo)
+	d.currencyPairs = make(map[pair.CurrencyItem]*exchange.CurrencyPairInfo)
+	d.symbolDetailsMap = make(map[pair.CurrencyItem]*symbolDetails)
+	for i := range instruments {
+		instrument := &instruments[i]
+		if !instrument.IsActive {
+			continue
+		}
+		info := convertInstrument(instrument)
+		d.contracts[pair.CurrencyItem(instrument.InstrumentName)] = info
+		if info.AssetType != asset.PerpetualSwap {
+			continue
+		}
+		d.currencyPairs[pair.CurrencyItem(instrument.InstrumentName)] = info
+		details := &symbolDetails{
+			PriceDecimalPlaces:  decimalPlaces(instrument.TickSize),
+			AmountDecimalPlaces: 0,
+			MinAmount:           1,
+		}
+		if instrument.ContractSize > 0 {
+			details.MinAmount = instrument.MinTradeAmount / instrument.ContractSize
+		}
+		d.symbolDetailsMap[info.Currency.Display("/", false)] = details
+	}
+}
+
+// convertInstrument converts a contract specification to a currency pair info, the currency pair
+// of every contract is the underlying currency against USD.
+func convertInstrument(instrument *Instrument) *exchange.CurrencyPairInfo {
+	info := &exchange.CurrencyPairInfo{
+		Currency:           pair.NewCurrencyPair(instrument.BaseCurrency, "USD"),
+		ContractSize:       instrument.ContractSize,
+		SettlementCurrency: strings.ToUpper(instrument.BaseCurrency),
+	}
+	if instrument.ExpirationTimestamp > 0 && instrument.SettlementPeriod != SettlementPeriodPerpetual {
+		info.Expiry = time.Unix(0, instrument.ExpirationTimestamp*int64(time.Millisecond)).UTC()
+	}
+	switch {
+	case instrument.Kind == KindOption:
+		info.AssetType = asset.Options
+		info.StrikePrice = instrument.Strike
+		if instrument.OptionType == exchange.OptionTypePut {
+			info.OptionType = exchange.OptionTypePut
+		} else {
+			info.OptionType = exchange.OptionTypeCall
+		}
+	case instrument.SettlementPeriod == SettlementPeriodPerpetual:
+		info.AssetType = asset.PerpetualSwap
+		info.Inverse = true
+	default:
+		info.AssetType = asset.Futures
+		info.Inverse = true
+	}
+	return info
+}
+
+// decimalPlaces returns the number of decimal places of an increment, e.g. 2 for 0.01 & 1 for 0.5
+func decimalPlaces(increment float64) int32 {
+	if increment <= 0 {
+		return -1
+	}
+	s := strconv.FormatFloat(increment, 'f', -1, 64)
+	if dot := strings.IndexByte(s, '.'); dot >= 0 {
+		return int32(len(s) - dot - 1)
+	}
+	return 0
+}
+
+// contractInfo returns the specification of a contract, or nil if the contract isn't listed
+func (d *Deribit) contractInfo(symbol string) *exchange.CurrencyPairInfo {
+	return d.contracts[pair.CurrencyItem(symbol)]
+}
+
+// contractSize returns the value of a contract, used to convert contracts to the amounts used by
+// the Deribit API (USD for futures & the base currency for options)
+func (d *Deribit) contractSize(symbol string) float64 {
+	if info := d.contractInfo(symbol); info != nil && info.ContractSize > 0 {
+		return info.ContractSize
+	}
+	return 1
+}
+
+// assetType returns the asset type of a contract, futures are assumed for unknown contracts
+func (d *Deribit) assetType(symbol string) string {
+	if info := d.contractInfo(symbol); info != nil {
+		return info.AssetType
+	}
+	return asset.Futures
+}
+
+// UpdateTicker updates and returns the ticker for a currency pair, the ticker of the perpetual
+// swap is used for all the asset types.
+func (d *Deribit) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return d.UpdateTickerContext(context.Background(), p, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (d *Deribit) UpdateTickerContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
+	var tickerPrice ticker.Price
+	tick, err := d.fetchTicker(ctx, d.CurrencyPairToSymbol(p))
+	if err != nil {
+		return tickerPrice, err
+	}
+	tickerPrice.Pair = p
+	tickerPrice.Ask = tick.BestAskPrice
+	tickerPrice.Bid = tick.BestBidPrice
+	tickerPrice.Last = tick.LastPrice
+	tickerPrice.High = tick.Stats.High
+	tickerPrice.Low = tick.Stats.Low
+	tickerPrice.Volume = tick.Stats.Volume
+	tickerPrice.LastUpdated = time.Unix(0, tick.Timestamp*int64(time.Millisecond))
+	ticker.ProcessTicker(d.GetName(), p, tickerPrice, assetType)
+	return ticker.GetTicker(d.Name, p, assetType)
+}
+
+// GetTickerPrice returns the ticker for a currency pair
+func (d *Deribit) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	tick, err := ticker.GetTicker(d.GetName(), p, assetType)
+	if err != nil {
+		return d.UpdateTicker(p, assetType)
+	}
+	return tick, nil
+}
+
+// GetOrderbookEx returns the orderbook for a currency pair
+func (d *Deribit) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
+	ob, err := d.Orderbooks.GetOrderbook(d.GetName(), p, assetType)
+	if err != nil {
+		return d.UpdateOrderbook(p, assetType)
+	}
+	return ob, nil
+}
+
+// UpdateOrderbook updates and returns the orderbook of the perpetual swap of a currency pair, the
+// amounts are in contracts.
+func (d *Deribit) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return d.UpdateOrderbookContext(context.Background(), p, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (d *Deribit) UpdateOrderbookContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
+	book, err := d.getContractOrderbook(ctx, d.CurrencyPairToSymbol(p))
+	if err != nil {
+		return book, err
+	}
+	d.Orderbooks.ProcessOrderbook(d.Name, p, book, assetType)
+	return d.Orderbooks.GetOrderbook(d.Name, p, assetType)
+}
+
+// GetContractOrderbook fetches the orderbook of a contract, the amounts are in contracts.
+// The orderbook isn't cached.
+func (d *Deribit) GetContractOrderbook(symbol string) (orderbook.Base, error) {
+	return d.getContractOrderbook(context.Background(), symbol)
+}
+
+func (d *Deribit) getContractOrderbook(ctx context.Context, symbol string) (orderbook.Base, error) {
+	book := orderbook.Base{}
+	ob, err := d.fetchOrderBook(ctx, symbol, deribitDefaultBookDepth)
+	if err != nil {
+		return book, err
+	}
+	contractSize := d.contractSize(symbol)
+	book.Asks = orderbook.GetItems(len(ob.Asks))
+	for _, level := range ob.Asks {
+		book.Asks = append(book.Asks, orderbook.Item{Price: level[0], Amount: level[1] / contractSize})
+	}
+	book.Bids = orderbook.GetItems(len(ob.Bids))
+	for _, level := range ob.Bids {
+		book.Bids = append(book.Bids, orderbook.Item{Price: level[0], Amount: level[1] / contractSize})
+	}
+	if p, err := d.SymbolToCurrencyPair(symbol); err == nil {
+		book.Pair = p
+	}
+	return book, nil
+}
+
+// GetExchangeAccountInfo retrieves the balances of the Deribit account, the balances include the
+// unrealised profit & loss of the open positions.
+func (d *Deribit) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return d.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (d *Deribit) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
+	result := exchange.AccountInfo{}
+	result.ExchangeName = d.Name
+
+	if !d.Enabled {
+		return result, nil
+	}
+
+	for _, currency := range deribitCurrencies {
+		summary, err := d.fetchAccountSummary(ctx, currency)
+		if err != nil {
+			return result, err
+		}
+		result.Currencies = append(result.Currencies, exchange.AccountCurrencyInfo{
+			CurrencyName: currency,
+			TotalValue:   summary.Equity,
+			Available:    summary.AvailableFunds,
+			Hold:         summary.Equity - summary.AvailableFunds,
+		})
+	}
+	return result, nil
+}
+
+// NewOrder creates a new order for the perpetual swap of the currency pair on the exchange, the
+// amount is in contracts.
+// Returns the ID of the new exchange order.
+func (d *Deribit) NewOrder(p pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return d.NewOrderContext(context.Background(), p, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (d *Deribit) NewOrderContext(ctx context.Context, p pair.CurrencyPair, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return d.newContractOrder(ctx, d.CurrencyPairToSymbol(p), amount, price, side, orderType, opts...)
+}
+
+// NewContractOrder creates a new order for a futures or options contract, the amount is in
+// contracts.
+// Returns the ID of the new exchange order.
+func (d *Deribit) NewContractOrder(symbol string, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return d.newContractOrder(context.Background(), symbol, amount, price, side, orderType, opts...)
+}
+
+func (d *Deribit) newContractOrder(ctx context.Context, symbol string, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	if err := d.GetCapabilities().CheckOrderOptions(opts...); err != nil {
+		return "", err
+	}
+	if orderType != exchange.OrderTypeExchangeLimit {
+		panic("not implemented")
+	}
+	var direction string
+	switch side {
+	case exchange.OrderSideBuy:
+		direction = DirectionBuy
+	case exchange.OrderSideSell:
+		direction = DirectionSell
+	default:
+		return "", fmt.Errorf("can't create order on %s exchange invalid value '%s' for side", d.Name, side)
+	}
+	var label string
+	for _, o := range opts {
+		if o.ClientOrderID != "" {
+			label = o.ClientOrderID
+		}
+	}
+
+	result, err := d.placeOrder(ctx, direction, symbol, amount*d.contractSize(symbol), price,
+		OrderTypeLimit, label)
+	if err != nil {
+		return "", err
+	}
+	return result.OrderID, nil
+}
+
+// GetCapabilities returns the capabilities of the exchange
+func (d *Deribit) GetCapabilities() exchange.Capabilities {
+	capabilities := d.Base.GetCapabilities()
+	capabilities.ClientOrderIDs = true
+	return capabilities
+}
+
+// CancelOrder will attempt to cancel the active order matching the given ID.
+func (d *Deribit) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return d.CancelOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (d *Deribit) CancelOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) error {
+	_, err := d.cancel(ctx, orderID)
+	return err
+}
+
+// GetOrder returns information about a previously placed order (which may be active or inactive).
+func (d *Deribit) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	return d.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (d *Deribit) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	order, err := d.fetchOrderState(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return d.convertOrderToExchangeOrder(order), nil
+}
+
+// GetOrders returns information about currently active orders, the orders of the contracts on
+// all the underlying currencies are returned if no pairs are given.
+func (d *Deribit) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return d.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (d *Deribit) GetOrdersContext(ctx context.Context,
+	pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	ret := []*exchange.Order{}
+	for _, currency := range currenciesOf(pairs) {
+		orders, err := d.fetchOpenOrders(ctx, currency)
+		if err != nil {
+			return nil, err
+		}
+		for i := range orders {
+			ret = append(ret, d.convertOrderToExchangeOrder(&orders[i]))
+		}
+	}
+	return ret, nil
+}
+
+// currenciesOf returns the underlying currencies of the currency pairs, or all of them if no
+// pairs are given
+func currenciesOf(pairs []pair.CurrencyPair) []string {
+	if len(pairs) == 0 {
+		return deribitCurrencies
+	}
+	seen := make(map[string]bool)
+	var currencies []string
+	for _, p := range pairs {
+		currency := p.FirstCurrency.Upper().String()
+		if !seen[currency] {
+			seen[currency] = true
+			currencies = append(currencies, currency)
+		}
+	}
+	return currencies
+}
+
+func (d *Deribit) convertOrderToExchangeOrder(order *Order) *exchange.Order {
+	retOrder := &exchange.Order{}
+	retOrder.OrderID = order.OrderID
+	retOrder.InternalOrderID = order.Label
+	retOrder.AssetType = d.assetType(order.InstrumentName)
+
+	switch order.OrderState {
+	case OrderStateOpen, OrderStateUntriggered:
+		retOrder.Status = exchange.OrderStatusActive
+	case OrderStateFilled:
+		retOrder.Status = exchange.OrderStatusFilled
+	case OrderStateCancelled, OrderStateRejected:
+		retOrder.Status = exchange.OrderStatusAborted
+	default:
+		retOrder.Status = exchange.OrderStatusUnknown
+	}
+
+	contractSize := d.contractSize(order.InstrumentName)
+	retOrder.Amount = order.Amount / contractSize
+	retOrder.FilledAmount = order.FilledAmount / contractSize
+	retOrder.RemainingAmount = (order.Amount - order.FilledAmount) / contractSize
+	retOrder.Rate = order.Price
+	retOrder.CreatedAt = order.CreationTimestamp / 1000
+	if p, err := d.SymbolToCurrencyPair(order.InstrumentName); err == nil {
+		retOrder.CurrencyPair = p
+	} else {
+		retOrder.CurrencyPair = pair.NewCurrencyPairDelimiter(order.InstrumentName, "-")
+	}
+	if order.Direction == DirectionSell {
+		retOrder.Side = exchange.OrderSideSell
+	} else {
+		retOrder.Side = exchange.OrderSideBuy
+	}
+	if order.OrderType == OrderTypeLimit {
+		retOrder.Type = exchange.OrderTypeExchangeLimit
+	} else {
+		log.Printf("Deribit.convertOrderToExchangeOrder(): unexpected '%s' order", order.OrderType)
+	}
+
+	return retOrder
+}
+
+// GetPositions returns the open positions in all the contracts, the amounts are in contracts &
+// the profit/loss is in the underlying currency.
+func (d *Deribit) GetPositions() ([]exchange.Position, error) {
+	result := []exchange.Position{}
+	for _, currency := range deribitCurrencies {
+		positions, err := d.FetchPositions(currency)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range positions {
+			if p.Size == 0 {
+				continue
+			}
+			position := exchange.Position{
+				ID:               p.InstrumentName,
+				Side:             exchange.OrderSideBuy,
+				Amount:           math.Abs(p.Size) / d.contractSize(p.InstrumentName),
+				BasePrice:        p.AveragePrice,
+				ProfitLoss:       p.FloatingProfitLoss,
+				AssetType:        d.assetType(p.InstrumentName),
+				Leverage:         p.Leverage,
+				LiquidationPrice: p.EstimatedLiquidationPrice,
+			}
+			if p.Size < 0 {
+				position.Side = exchange.OrderSideSell
+			}
+			if cp, err := d.SymbolToCurrencyPair(p.InstrumentName); err == nil {
+				position.CurrencyPair = cp
+			} else {
+				position.CurrencyPair = pair.NewCurrencyPair(currency, "USD")
+			}
+			result = append(result, position)
+		}
+	}
+	return result, nil
+}
+
+// GetLimits returns price/amount limits for the exchange.
+func (d *Deribit) GetLimits() exchange.ILimits {
+	return newCurrencyLimits(d.Name, d.symbolDetailsMap)
+}
+
+// GetCurrencyPairs returns currency pairs that can be used by the exchange account
+// associated with this bot. Use FormatExchangeCurrency to get the right key.
+// Only the perpetual swaps are returned, see GetContracts for the other contracts.
+func (d *Deribit) GetCurrencyPairs() map[pair.CurrencyItem]*exchange.CurrencyPairInfo {
+	return d.currencyPairs
+}
+
+// GetContracts returns the contracts of an asset type keyed by symbol, an empty asset type
+// returns all the contracts.
+func (d *Deribit) GetContracts(assetType string) map[pair.CurrencyItem]*exchange.CurrencyPairInfo {
+	if assetType == "" {
+		return d.contracts
+	}
+	assetType = asset.Normalize(assetType)
+	result := make(map[pair.CurrencyItem]*exchange.CurrencyPairInfo)
+	for symbol, info := range d.contracts {
+		if info.AssetType == assetType {
+			result[symbol] = info
+		}
+	}
+	return result
+}
+
+// ListInstruments returns the contracts that are currently trading on the exchange
+func (d *Deribit) ListInstruments() ([]exchange.Instrument, error) {
+	instruments, err := d.fetchAllInstruments()
+	if err != nil {
+		return nil, err
+	}
+	result := []exchange.Instrument{}
+	for i := range instruments {
+		if !instruments[i].IsActive {
+			continue
+		}
+		result = append(result, exchange.Instrument{
+			Symbol: instruments[i].InstrumentName,
+			Pair:   pair.NewCurrencyPair(instruments[i].BaseCurrency, "USD"),
+		})
+	}
+	return result, nil
+}
+
+type symbolDetails struct {
+	PriceDecimalPlaces  int32
+	AmountDecimalPlaces int32
+	MinAmount           float64
+}
+
+type currencyLimits struct {
+	exchangeName string
+	// Maps currency pair (lower-case, delimited by "/") to the details of its perpetual swap
+	data map[pair.CurrencyItem]*symbolDetails
+}
+
+func newCurrencyLimits(exchangeName string, data map[pair.CurrencyItem]*symbolDetails) *currencyLimits {
+	return &currencyLimits{exchangeName, data}
+}
+
+// Returns max number of decimal places allowed in the trade price for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetPriceDecimalPlaces(p pair.CurrencyPair) int32 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.PriceDecimalPlaces
+	}
+	return -1
+}
+
+// Returns max number of decimal places allowed in the trade amount for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetAmountDecimalPlaces(p pair.CurrencyPair) int32 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.AmountDecimalPlaces
+	}
+	return -1
+}
+
+// Returns the minimum trade amount (in contracts) for the given currency pair.
+func (cl *currencyLimits) GetMinAmount(p pair.CurrencyPair) float64 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.MinAmount
+	}
+	return 0
+}
+
+// Returns the minimum trade total (amount * price) for the given currency pair, Deribit only
+// limits the order amount.
+func (cl *currencyLimits) GetMinTotal(p pair.CurrencyPair) float64 {
+	return 0
+}
@@ -0,0 +1,299 @@
+// Package ethereum implements the parts of Ethereum needed to trade on the decentralized
+// exchanges: Keccak-256 hashing, secp256k1 signatures of order hashes with an account's private
+// key, EIP-55 checksummed addresses and the balances held by an address (through a node).
+package ethereum
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ErrInvalidChecksum is returned for mixed case addresses that don't match their EIP-55 checksum
+var ErrInvalidChecksum = errors.New("address checksum mismatch")
+
+// Parameters of the secp256k1 curve, y² = x³ + 7 over the field of order p
+var (
+	curveP, _  = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F", 16)
+	curveN, _  = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	curveGx, _ = new(big.Int).SetString("79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798", 16)
+	curveGy, _ = new(big.Int).SetString("483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8", 16)
+	halfN      = new(big.Int).Rsh(curveN, 1)
+)
+
+// point is an affine point of the curve, nil is the point at infinity
+type point struct {
+	x, y *big.Int
+}
+
+func add(a, b *point) *point {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	var slope *big.Int
+	if a.x.Cmp(b.x) == 0 {
+		if a.y.Cmp(b.y) != 0 || a.y.Sign() == 0 {
+			return nil
+		}
+		// Tangent: 3x² / 2y
+		num := new(big.Int).Mul(a.x, a.x)
+		num.Mul(num, big.NewInt(3))
+		den := new(big.Int).Lsh(a.y, 1)
+		slope = num.Mul(num, den.ModInverse(den, curveP))
+	} else {
+		num := new(big.Int).Sub(b.y, a.y)
+		den := new(big.Int).Sub(b.x, a.x)
+		den.Mod(den, curveP)
+		slope = num.Mul(num, den.ModInverse(den, curveP))
+	}
+	slope.Mod(slope, curveP)
+	x := new(big.Int).Mul(slope, slope)
+	x.Sub(x, a.x).Sub(x, b.x).Mod(x, curveP)
+	y := new(big.Int).Sub(a.x, x)
+	y.Mul(y, slope).Sub(y, a.y).Mod(y, curveP)
+	return &point{x, y}
+}
+
+func multiply(p *point, k *big.Int) *point {
+	var result *point
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		result = add(result, result)
+		if k.Bit(i) == 1 {
+			result = add(result, p)
+		}
+	}
+	return result
+}
+
+func baseMultiply(k *big.Int) *point {
+	return multiply(&point{curveGx, curveGy}, k)
+}
+
+// Signature is a recoverable secp256k1 signature, V is 27 or 28
+type Signature struct {
+	V    byte
+	R, S [32]byte
+}
+
+// Hex returns the signature encoded as 0x followed by the hex encoded R, S & V
+func (s Signature) Hex() string {
+	return "0x" + hex.EncodeToString(s.R[:]) + hex.EncodeToString(s.S[:]) + hex.EncodeToString([]byte{s.V})
+}
+
+// PrivateKey is the private key of an Ethereum account
+type PrivateKey struct {
+	d       *big.Int
+	address string
+}
+
+// HexToPrivateKey parses a hex encoded private key, with or without the 0x prefix
+func HexToPrivateKey(s string) (*PrivateKey, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), "0x"))
+	if err != nil || len(b) != 32 {
+		return nil, errors.New("private key must be 32 hex encoded bytes")
+	}
+	d := new(big.Int).SetBytes(b)
+	if d.Sign() == 0 || d.Cmp(curveN) >= 0 {
+		return nil, errors.New("invalid private key")
+	}
+	return &PrivateKey{d: d, address: pubkeyToAddress(baseMultiply(d))}, nil
+}
+
+// Address returns the EIP-55 checksummed address of the account
+func (k *PrivateKey) Address() string {
+	return k.address
+}
+
+// Sign signs a 32 byte hash, the nonce is derived from the key & hash as per RFC 6979 so the
+// signature is deterministic. S is normalized to the lower half of the curve order.
+func (k *PrivateKey) Sign(hash []byte) (Signature, error) {
+	if len(hash) != 32 {
+		return Signature{}, errors.New("hash must be 32 bytes")
+	}
+	e := new(big.Int).SetBytes(hash)
+	nonces := newRFC6979(k.d, hash)
+	for {
+		nonce := nonces.next()
+		r := baseMultiply(nonce)
+		rx := new(big.Int).Mod(r.x, curveN)
+		if rx.Sign() == 0 {
+			continue
+		}
+		s := new(big.Int).Mul(rx, k.d)
+		s.Add(s, e).Mul(s, new(big.Int).ModInverse(nonce, curveN)).Mod(s, curveN)
+		if s.Sign() == 0 {
+			continue
+		}
+		recovery := byte(r.y.Bit(0))
+		if r.x.Cmp(curveN) >= 0 {
+			recovery |= 2
+		}
+		if s.Cmp(halfN) > 0 {
+			s.Sub(curveN, s)
+			recovery ^= 1
+		}
+		var sig Signature
+		sig.V = 27 + recovery
+		rx.FillBytes(sig.R[:])
+		s.FillBytes(sig.S[:])
+		return sig, nil
+	}
+}
+
+// SignPersonalMessage signs a 32 byte hash prefixed with "\x19Ethereum Signed Message:\n32", the
+// way wallets sign messages (eth_sign)
+func (k *PrivateKey) SignPersonalMessage(hash []byte) (Signature, error) {
+	return k.Sign(PersonalMessageHash(hash))
+}
+
+// PersonalMessageHash returns the hash signed by SignPersonalMessage
+func PersonalMessageHash(hash []byte) []byte {
+	return Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(hash))), hash)
+}
+
+// RecoverAddress returns the checksummed address of the account that signed the hash
+func RecoverAddress(hash []byte, sig Signature) (string, error) {
+	if sig.V < 27 || sig.V > 30 || len(hash) != 32 {
+		return "", errors.New("invalid signature")
+	}
+	recovery := sig.V - 27
+	r := new(big.Int).SetBytes(sig.R[:])
+	s := new(big.Int).SetBytes(sig.S[:])
+	if r.Sign() == 0 || r.Cmp(curveN) >= 0 || s.Sign() == 0 || s.Cmp(curveN) >= 0 {
+		return "", errors.New("invalid signature")
+	}
+	x := new(big.Int).Set(r)
+	if recovery&2 != 0 {
+		x.Add(x, curveN)
+	}
+	// y = sqrt(x³ + 7), p ≡ 3 mod 4 so the root is (x³ + 7)^((p+1)/4)
+	y := new(big.Int).Exp(x, big.NewInt(3), curveP)
+	y.Add(y, big.NewInt(7)).Mod(y, curveP)
+	y.Exp(y, new(big.Int).Rsh(new(big.Int).Add(curveP, big.NewInt(1)), 2), curveP)
+	if y.Bit(0) != uint(recovery&1) {
+		y.Sub(curveP, y)
+	}
+	// Q = r⁻¹(sR - eG)
+	rInv := new(big.Int).ModInverse(r, curveN)
+	e := new(big.Int).SetBytes(hash)
+	e.Neg(e).Mod(e, curveN)
+	q := add(multiply(&point{x, y}, s), baseMultiply(e))
+	q = multiply(q, rInv)
+	if q == nil {
+		return "", errors.New("invalid signature")
+	}
+	return pubkeyToAddress(q), nil
+}
+
+func pubkeyToAddress(p *point) string {
+	var pub [64]byte
+	p.x.FillBytes(pub[:32])
+	p.y.FillBytes(pub[32:])
+	return ChecksumAddress(hex.EncodeToString(Keccak256(pub[:])[12:]))
+}
+
+// IsHexAddress returns true if s is 0x followed by 40 hex digits, the checksum isn't verified
+func IsHexAddress(s string) bool {
+	if len(s) != 42 || !strings.HasPrefix(s, "0x") {
+		return false
+	}
+	_, err := hex.DecodeString(s[2:])
+	return err == nil
+}
+
+// ChecksumAddress returns the EIP-55 mixed case encoding of an address, the address may be
+// given with or without the 0x prefix
+func ChecksumAddress(address string) string {
+	lower := strings.ToLower(strings.TrimPrefix(address, "0x"))
+	hash := hex.EncodeToString(Keccak256([]byte(lower)))
+	result := []byte(lower)
+	for i, c := range result {
+		if c >= 'a' && c <= 'f' && hash[i] >= '8' {
+			result[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(result)
+}
+
+// ValidateAddress returns an error if the address isn't a hex address, or if it's mixed case
+// and doesn't match its EIP-55 checksum. All lower or upper case addresses have no checksum.
+func ValidateAddress(address string) error {
+	if !IsHexAddress(address) {
+		return fmt.Errorf("invalid address %q", address)
+	}
+	digits := address[2:]
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return nil
+	}
+	if ChecksumAddress(address) != address {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+// PackAddress returns the 20 bytes of an address, as packed by Solidity's abi.encodePacked
+func PackAddress(address string) ([]byte, error) {
+	if !IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid address %q", address)
+	}
+	return hex.DecodeString(address[2:])
+}
+
+// PackUint256 returns a non-negative integer as a 32 byte big endian word
+func PackUint256(x *big.Int) []byte {
+	word := make([]byte, 32)
+	return x.FillBytes(word)
+}
+
+// rfc6979 generates the deterministic signing nonces of RFC 6979 with HMAC-SHA256
+type rfc6979 struct {
+	k, v []byte
+}
+
+func newRFC6979(d *big.Int, hash []byte) *rfc6979 {
+	key := make([]byte, 32)
+	d.FillBytes(key)
+	h := new(big.Int).SetBytes(hash)
+	h.Mod(h, curveN)
+	msg := make([]byte, 32)
+	h.FillBytes(msg)
+
+	g := &rfc6979{k: make([]byte, 32), v: make([]byte, 32)}
+	for i := range g.v {
+		g.v[i] = 1
+	}
+	g.k = g.mac(g.v, []byte{0}, key, msg)
+	g.v = g.mac(g.v)
+	g.k = g.mac(g.v, []byte{1}, key, msg)
+	g.v = g.mac(g.v)
+	return g
+}
+
+func (g *rfc6979) mac(data ...[]byte) []byte {
+	m := hmac.New(sha256.New, g.k)
+	for _, d := range data {
+		m.Write(d)
+	}
+	return m.Sum(nil)
+}
+
+// next returns the next candidate nonce in [1, n)
+func (g *rfc6979) next() *big.Int {
+	for {
+		g.v = g.mac(g.v)
+		k := new(big.Int).SetBytes(g.v)
+		// Prepare the next candidate in case this one is rejected by the caller
+		g.k = g.mac(g.v, []byte{0})
+		g.v = g.mac(g.v)
+		if k.Sign() > 0 && k.Cmp(curveN) < 0 {
+			return k
+		}
+	}
+}
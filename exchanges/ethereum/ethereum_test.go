@@ -0,0 +1,86 @@
+package ethereum
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+const testPrivateKey = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+func TestKeccak256(t *testing.T) {
+	tests := map[string]string{
+		"":    "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470",
+		"abc": "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45",
+	}
+	for input, expected := range tests {
+		if hash := hex.EncodeToString(Keccak256([]byte(input))); hash != expected {
+			t.Errorf("Test failed. Unexpected hash of %q: %s", input, hash)
+		}
+	}
+	// inputs longer than a block are absorbed in several blocks
+	long := make([]byte, 200)
+	if Keccak256(long[:100], long[100:])[0] != Keccak256(long)[0] {
+		t.Error("Test failed. Expected the data to be concatenated")
+	}
+}
+
+func TestSign(t *testing.T) {
+	key, err := HexToPrivateKey(testPrivateKey)
+	if err != nil {
+		t.Fatalf("Test failed. HexToPrivateKey returned an error: %s", err)
+	}
+	if key.Address() != "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23" {
+		t.Errorf("Test failed. Unexpected address %s", key.Address())
+	}
+
+	hash := Keccak256([]byte("\x19Ethereum Signed Message:\n9Some data"))
+	sig, err := key.Sign(hash)
+	if err != nil {
+		t.Fatalf("Test failed. Sign returned an error: %s", err)
+	}
+	expected := "0xb91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd" +
+		"6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a0291c"
+	if sig.Hex() != expected {
+		t.Errorf("Test failed. Unexpected signature %s", sig.Hex())
+	}
+	if address, err := RecoverAddress(hash, sig); err != nil || address != key.Address() {
+		t.Errorf("Test failed. Recovered %s %v", address, err)
+	}
+
+	sig, err = key.SignPersonalMessage(Keccak256([]byte("order")))
+	if err != nil {
+		t.Fatalf("Test failed. SignPersonalMessage returned an error: %s", err)
+	}
+	address, err := RecoverAddress(PersonalMessageHash(Keccak256([]byte("order"))), sig)
+	if err != nil || address != key.Address() {
+		t.Errorf("Test failed. Recovered %s %v", address, err)
+	}
+	if new(big.Int).SetBytes(sig.S[:]).Cmp(halfN) > 0 {
+		t.Error("Test failed. Expected S in the lower half of the curve order")
+	}
+
+	if _, err = HexToPrivateKey("0x1234"); err == nil {
+		t.Error("Test failed. Expected an error for a short private key")
+	}
+}
+
+func TestValidateAddress(t *testing.T) {
+	if address := ChecksumAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"); address != "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed" {
+		t.Errorf("Test failed. Unexpected checksum address %s", address)
+	}
+	tests := map[string]bool{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed": true,
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed": true,
+		"0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED": true,
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD": false,
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1bea":   false,
+		"5aaeb6053f3e94c9b9a09f33669435e7ef1beaed00": false,
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaeg": false,
+	}
+	for address, valid := range tests {
+		if err := ValidateAddress(address); (err == nil) != valid {
+			t.Errorf("Test failed. Unexpected result for %s: %v", address, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package ethereum
+
+import "encoding/binary"
+
+// Round constants of the Keccak-f[1600] permutation
+var keccakRoundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808A, 0x8000000080008000,
+	0x000000000000808B, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008A, 0x0000000000000088, 0x0000000080008009, 0x000000008000000A,
+	0x000000008000808B, 0x800000000000008B, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800A, 0x800000008000000A,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// Rotation offsets of the lanes, indexed by x + 5*y
+var keccakRotations = [25]uint{
+	0, 1, 62, 28, 27,
+	36, 44, 6, 55, 20,
+	3, 10, 43, 25, 39,
+	41, 45, 15, 21, 8,
+	18, 2, 61, 56, 14,
+}
+
+// Size in bytes of the part of the state absorbed per block for 256 bit digests
+const keccak256Rate = 136
+
+// Keccak256 returns the Keccak-256 hash of the concatenated data. Ethereum uses the original
+// Keccak padding, so the hash differs from the standardized SHA3-256.
+func Keccak256(data ...[]byte) []byte {
+	var input []byte
+	for _, d := range data {
+		input = append(input, d...)
+	}
+	// Pad with 0x01 ... 0x80 up to a multiple of the rate
+	padded := make([]byte, (len(input)/keccak256Rate+1)*keccak256Rate)
+	copy(padded, input)
+	padded[len(input)] ^= 0x01
+	padded[len(padded)-1] ^= 0x80
+
+	var state [25]uint64
+	for block := 0; block < len(padded); block += keccak256Rate {
+		for i := 0; i < keccak256Rate/8; i++ {
+			state[i] ^= binary.LittleEndian.Uint64(padded[block+8*i:])
+		}
+		keccakF1600(&state)
+	}
+
+	digest := make([]byte, 32)
+	for i := 0; i < 4; i++ {
+		binary.LittleEndian.PutUint64(digest[8*i:], state[i])
+	}
+	return digest
+}
+
+func keccakF1600(a *[25]uint64) {
+	var c [5]uint64
+	var b [25]uint64
+	for round := 0; round < 24; round++ {
+		// Theta
+		for x := 0; x < 5; x++ {
+			c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+		}
+		for x := 0; x < 5; x++ {
+			d := c[(x+4)%5] ^ rotl(c[(x+1)%5], 1)
+			for y := 0; y < 25; y += 5 {
+				a[x+y] ^= d
+			}
+		}
+		// Rho & pi
+		for x := 0; x < 5; x++ {
+			for y := 0; y < 5; y++ {
+				b[y+5*((2*x+3*y)%5)] = rotl(a[x+5*y], keccakRotations[x+5*y])
+			}
+		}
+		// Chi
+		for y := 0; y < 25; y += 5 {
+			for x := 0; x < 5; x++ {
+				a[x+y] = b[x+y] ^ (^b[(x+1)%5+y] & b[(x+2)%5+y])
+			}
+		}
+		// Iota
+		a[0] ^= keccakRoundConstants[round]
+	}
+}
+
+func rotl(x uint64, n uint) uint64 {
+	return x<<n | x>>(64-n)
+}
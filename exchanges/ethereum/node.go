@@ -0,0 +1,124 @@
+package ethereum
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+)
+
+// ZeroAddress is the address the decentralized exchanges use for Ether in place of a token
+const ZeroAddress = "0x0000000000000000000000000000000000000000"
+
+// Selector of the ERC-20 balanceOf(address) function
+const balanceOfSelector = "70a08231"
+
+// Node is a client of the JSON-RPC API of an Ethereum node, used to read the balances held by an
+// address outside of the exchanges
+type Node struct {
+	URL string
+	id  int64
+}
+
+// NewNode returns a client of the node with the given JSON-RPC endpoint
+func NewNode(url string) *Node {
+	return &Node{URL: url}
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int64         `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	Result string `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Balance returns the Ether balance of an address in wei
+func (n *Node) Balance(address string) (*big.Int, error) {
+	return n.BalanceContext(context.Background(), address)
+}
+
+// BalanceContext is Balance, the request is cancelled when the context is done
+func (n *Node) BalanceContext(ctx context.Context, address string) (*big.Int, error) {
+	result, err := n.call(ctx, "eth_getBalance", address, "latest")
+	if err != nil {
+		return nil, err
+	}
+	return parseQuantity(result)
+}
+
+// TokenBalance returns the ERC-20 token balance of an address in the token's base units, the
+// Ether balance is returned if the token is the zero address
+func (n *Node) TokenBalance(token, address string) (*big.Int, error) {
+	return n.TokenBalanceContext(context.Background(), token, address)
+}
+
+// TokenBalanceContext is TokenBalance, the request is cancelled when the context is done
+func (n *Node) TokenBalanceContext(ctx context.Context, token, address string) (*big.Int, error) {
+	if token == ZeroAddress {
+		return n.BalanceContext(ctx, address)
+	}
+	owner, err := PackAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	data := "0x" + balanceOfSelector + strings.Repeat("0", 24) + hex.EncodeToString(owner)
+	result, err := n.call(ctx, "eth_call", map[string]string{"to": token, "data": data}, "latest")
+	if err != nil {
+		return nil, err
+	}
+	return parseQuantity(result)
+}
+
+func (n *Node) call(ctx context.Context, method string, params ...interface{}) (string, error) {
+	body, err := common.JSONEncode(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      atomic.AddInt64(&n.id, 1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return "", err
+	}
+	headers := make(http.Header)
+	headers.Set("Content-Type", "application/json")
+	resp, statusCode, err := common.SendHTTPRequest2Context(ctx, http.MethodPost, n.URL, headers,
+		bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	var response rpcResponse
+	if err = common.JSONDecode([]byte(resp), &response); err != nil {
+		return "", fmt.Errorf("%s: unexpected response (status %d): %s", method, statusCode, resp)
+	}
+	if response.Error != nil {
+		return "", fmt.Errorf("%s: %s (code %d)", method, response.Error.Message, response.Error.Code)
+	}
+	return response.Result, nil
+}
+
+// parseQuantity parses a hex encoded quantity, e.g. 0x1bc16d674ec80000
+func parseQuantity(s string) (*big.Int, error) {
+	digits := strings.TrimPrefix(s, "0x")
+	if digits == "" {
+		return new(big.Int), nil
+	}
+	x, ok := new(big.Int).SetString(digits, 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid quantity %q", s)
+	}
+	return x, nil
+}
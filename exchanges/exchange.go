@@ -3,14 +3,18 @@ package exchange
 import (
 	"errors"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/mattkanwisher/cryptofiend/common"
 	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/money"
 	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/asset"
 	"github.com/mattkanwisher/cryptofiend/exchanges/nonce"
 	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
 	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+	"github.com/mattkanwisher/cryptofiend/exchanges/wsrecord"
 )
 
 const (
@@ -20,23 +24,20 @@ const (
 	WarningAuthenticatedRequestWithoutCredentialsSet = "WARNING -- Exchange %s authenticated HTTP request called but not supported due to unset/default API keys."
 	// ErrExchangeNotFound is a constant for an error message
 	ErrExchangeNotFound = "Exchange not found in dataset."
-	// ErrExchangeOrderNotFound is one of the possible errors returned by IBotExchangeEx.GetOrder()
-	ErrOrderNotFound = "Exchange order not found."
 )
 
 var warningHTTPRequestRateLimited = errors.New("HTTP request was rate limited.")
-var insufficentFundsForOrder = errors.New("insufficent funds for order")
 
 // WarningHTTPRequestRateLimited() returns an error that indicates that a method of the
-// IBotExchangeEx interface was rate limited.
+// IBotExchangeEx interface was rate limited. Exchanges that know when the cached data they return
+// instead was fetched return a RateLimitedWarning instead, use IsRateLimited to check for both.
 func WarningHTTPRequestRateLimited() error {
 	return warningHTTPRequestRateLimited
 }
 
-// ErrInsufficentFundsForOrder returns an error that indicates that aren't sufficient
-// funds available to place a new order on the exchange.
+// ErrInsufficentFundsForOrder returns ErrInsufficientFunds, it predates the error kinds.
 func ErrInsufficentFundsForOrder() error {
-	return insufficentFundsForOrder
+	return ErrInsufficientFunds
 }
 
 // AccountInfo is a Generic type to hold each exchange's holdings in
@@ -54,6 +55,21 @@ type AccountCurrencyInfo struct {
 	Available    float64 // Amount actually available for placing orders
 }
 
+// TotalAmount returns the total balance with the currency attached
+func (a *AccountCurrencyInfo) TotalAmount() money.Amount {
+	return money.FromFloat(a.TotalValue, a.CurrencyName)
+}
+
+// HoldAmount returns the balance on hold with the currency attached
+func (a *AccountCurrencyInfo) HoldAmount() money.Amount {
+	return money.FromFloat(a.Hold, a.CurrencyName)
+}
+
+// AvailableAmount returns the available balance with the currency attached
+func (a *AccountCurrencyInfo) AvailableAmount() money.Amount {
+	return money.FromFloat(a.Available, a.CurrencyName)
+}
+
 type OrderType string
 type OrderSide string
 
@@ -88,12 +104,36 @@ type Order struct {
 	Status          OrderStatus
 	OrderID         string // Order ID generated by the exchange
 	InternalOrderID string // Order ID generated by the trading system (or bot)
+	// Asset type of the instrument, see the asset package. Empty means asset.Spot, the amounts of
+	// derivative orders are in contracts (see CurrencyPairInfo.ContractSize).
+	AssetType string
 }
 
+// CurrencyPairInfo describes an instrument listed by an exchange. Spot pairs leave the asset type
+// & contract fields empty.
 type CurrencyPairInfo struct {
 	Currency           pair.CurrencyPair
 	FirstCurrencyName  string
 	SecondCurrencyName string
+	// Asset type of the instrument, see the asset package. Empty means asset.Spot.
+	AssetType string
+	// Amount of the underlying currency a single derivative contract represents
+	ContractSize float64
+	// Expiry of a futures or options contract, zero for perpetual contracts
+	Expiry time.Time
+	// Inverse contracts are denominated in the quote currency (ContractSize is an amount of the
+	// quote currency) and settled in the underlying currency
+	Inverse bool
+	// Currency the profit & loss of a derivative contract is settled in
+	SettlementCurrency string
+	// Strike price & type (OptionTypeCall or OptionTypePut) of an options contract
+	StrikePrice float64
+	OptionType  string
+}
+
+// GetAssetType returns the asset type of the instrument, defaulting to asset.Spot
+func (c *CurrencyPairInfo) GetAssetType() string {
+	return asset.Normalize(c.AssetType)
 }
 
 // Base stores the individual exchange information
@@ -113,9 +153,26 @@ type Base struct {
 	AssetTypes                  []string
 	WebsocketURL                string
 	APIUrl                      string
+	Testnet                     bool
 	RequestCurrencyPairFormat   config.CurrencyPairFormatConfig
 	ConfigCurrencyPairFormat    config.CurrencyPairFormatConfig
 	Orderbooks                  orderbook.Orderbooks
+
+	// Guards the API credentials while they're copied by Credentials or rotated
+	credentialsMtx sync.RWMutex
+	apiSecretB64   bool
+	// Raw websocket frames are mirrored to the recorder if it's set
+	websocketRecorder wsrecord.Recorder
+	// Set if permessage-deflate compression is negotiated on the websocket connections
+	websocketCompression bool
+	// Number of times the websocket has connected, accessed atomically
+	websocketConnections int64
+	// Set while the websocket is stale, accessed atomically
+	websocketStale        int32
+	websocketStaleTimeout time.Duration
+	onWebsocketStale      func(exchange string, stale bool)
+	// Subscribers to the data pushed by the websocket, see Streamer
+	streams streamHub
 }
 
 // IBotExchange enforces standard functions for all exchanges supported in
@@ -128,22 +185,96 @@ type IBotExchange interface {
 	IsEnabled() bool
 	GetTickerPrice(currency pair.CurrencyPair, assetType string) (ticker.Price, error)
 	UpdateTicker(currency pair.CurrencyPair, assetType string) (ticker.Price, error)
-	GetOrderbookEx(currency pair.CurrencyPair, assetType string) (orderbook.Base, error)
+	GetOrderbookEx(currency pair.CurrencyPair, assetType string, opts ...OrderbookOptions) (orderbook.Base, error)
 	GetOrderbookSimple(currency pair.CurrencyPair, assetType string) (orderbook.Base, error)
 	UpdateOrderbook(currency pair.CurrencyPair, assetType string) (orderbook.Base, error)
 	GetEnabledCurrencies() []pair.CurrencyPair
 	GetExchangeAccountInfo() (AccountInfo, error)
 	GetAuthenticatedAPISupport() bool
+	GetCapabilities() Capabilities
+	RotateAPIKeys(apiKey, apiSecret, clientID string, verify func() error) error
 }
 
-// Extended bot interface for new methods
-type IBotExchangeEx interface {
+// Capabilities describes the environment an exchange is running in, and the optional features
+// it supports
+type Capabilities struct {
+	// Testnet is true if the exchange is connected to a sandbox/testnet deployment, orders
+	// placed on such an exchange don't trade real funds.
+	Testnet bool
+	// HiddenOrders is true if the exchange supports OrderOptions.Hidden
+	HiddenOrders bool
+	// IcebergOrders is true if the exchange supports OrderOptions.VisibleAmount
+	IcebergOrders bool
+	// ClientOrderIDs is true if the exchange supports OrderOptions.ClientOrderID
+	ClientOrderIDs bool
+}
+
+// CheckOrderOptions returns ErrOrderOptionNotSupported if any of the order options aren't
+// supported by the exchange.
+func (c Capabilities) CheckOrderOptions(opts ...OrderOptions) error {
+	for _, o := range opts {
+		if (o.Hidden && !c.HiddenOrders) || (o.VisibleAmount != 0 && !c.IcebergOrders) ||
+			(o.ClientOrderID != "" && !c.ClientOrderIDs) {
+			return ErrOrderOptionNotSupported
+		}
+	}
+	return nil
+}
+
+// ErrOrderOptionNotSupported is returned by NewOrder when the exchange doesn't support one of
+// the order options
+var ErrOrderOptionNotSupported = errors.New("order option not supported by the exchange")
+
+// OrderOptions can be passed to NewOrder to place orders with optional features, exchanges reject
+// orders with options they don't support (see Capabilities).
+type OrderOptions struct {
+	// Hidden orders aren't shown in the public orderbook
+	Hidden bool
+	// VisibleAmount is the amount of an iceberg order that's shown in the public orderbook, zero
+	// shows the full amount.
+	VisibleAmount float64
+	// ClientOrderID is stored by the exchange along with the order & returned as the
+	// InternalOrderID of the order, it can be up to 36 characters long.
+	ClientOrderID string
+}
+
+// Orderbook precision levels, P0 is the most precise aggregation level and P3 the least precise,
+// R0 returns raw (unaggregated) orders.
+const (
+	OrderbookPrecisionP0 = "P0"
+	OrderbookPrecisionP1 = "P1"
+	OrderbookPrecisionP2 = "P2"
+	OrderbookPrecisionP3 = "P3"
+	OrderbookPrecisionR0 = "R0"
+)
+
+// OrderbookOptions can be passed to GetOrderbookEx to request a coarser (or raw) orderbook from
+// exchanges that support aggregation, exchanges that don't support it ignore these options.
+// Orderbooks fetched with options are not cached, so they don't replace the default orderbook.
+type OrderbookOptions struct {
+	// Precision is one of the OrderbookPrecision* levels, or empty for the exchange default.
+	Precision string
+	// Depth is the maximum number of price levels to return for each side of the book, or zero
+	// for the exchange default.
+	Depth int
+}
+
+// IsZero returns true if no options are set.
+func (o OrderbookOptions) IsZero() bool {
+	return o.Precision == "" && o.Depth == 0
+}
+
+// IExchange is the interface every trading exchange wrapper implements, so exchanges can be held
+// in a single slice regardless of their type. Optional features are covered by separate
+// interfaces that the exchanges can be checked for (e.g. Streamer, PositionLister).
+type IExchange interface {
 	IBotExchange
 	Run()
-	// NewOrder creates a new order on the exchange.
+	// NewOrder creates a new order on the exchange, with optional order options.
 	// Returns the ID of the new exchange order, or an empty string if the order was filled
 	// immediately but no ID was generated.
-	NewOrder(symbol pair.CurrencyPair, amount, price float64, side OrderSide, orderType OrderType) (string, error)
+	NewOrder(symbol pair.CurrencyPair, amount, price float64, side OrderSide, orderType OrderType,
+		opts ...OrderOptions) (string, error)
 	// CancelOrder will attempt to cancel the active order matching the given ID.
 	// The currency pair may be required for some exchanges.
 	CancelOrder(OrderID string, currencyPair pair.CurrencyPair) error
@@ -154,6 +285,10 @@ type IBotExchangeEx interface {
 	// The pairs parameter should contain the currency pairs for which active orders should be retrieved,
 	// if this is parameter is nil or empty then all active orders will be retrieved.
 	GetOrders(pairs []pair.CurrencyPair) ([]*Order, error)
+	// GetFeeInfo returns the maker & taker fees charged for trading the currency pair, as
+	// percentages of the trade value (0.1 is 0.1%). The fee tier of the account is used if the
+	// exchange provides it, otherwise the default fees are returned.
+	GetFeeInfo(currencyPair pair.CurrencyPair) (maker, taker float64, err error)
 	// GetLimits returns price/amount limits for the exchange.
 	GetLimits() ILimits
 	// Returns currency pairs that can be used by the exchange account associated with this bot.
@@ -161,8 +296,40 @@ type IBotExchangeEx interface {
 	GetCurrencyPairs() map[pair.CurrencyItem]*CurrencyPairInfo
 }
 
+// IBotExchangeEx is the former name of IExchange, it has the same methods
+type IBotExchangeEx interface {
+	IExchange
+}
+
+// SetAPIURL overrides the default API base URL of the exchange with the one
+// in the exchange config (if set), and flags the exchange as running on a
+// testnet if the config says so
+func (e *Base) SetAPIURL(exch config.ExchangeConfig) {
+	if exch.APIURL != "" {
+		e.APIUrl = common.TrimString(exch.APIURL, " ")
+	}
+	e.Testnet = exch.Testnet || exch.UseSandbox
+}
+
+// GetCapabilities returns the capabilities of the exchange
+func (e *Base) GetCapabilities() Capabilities {
+	return Capabilities{
+		Testnet: e.Testnet,
+	}
+}
+
+// GetFeeInfo returns the static maker & taker fees of the exchange (MakerFee & TakerFee, or Fee if
+// the exchange only has a single fee), exchanges that can query the fee tier of the account
+// override it.
+func (e *Base) GetFeeInfo(currencyPair pair.CurrencyPair) (maker, taker float64, err error) {
+	if e.MakerFee == 0 && e.TakerFee == 0 {
+		return e.Fee, e.Fee, nil
+	}
+	return e.MakerFee, e.TakerFee, nil
+}
+
 // SetAssetTypes checks the exchange asset types (whether it supports SPOT,
-// Binary or Futures) and sets it to a default setting if it doesn't exist
+// Margin or Futures) and sets it to a default setting if it doesn't exist
 func (e *Base) SetAssetTypes() error {
 	cfg := config.GetConfig()
 	exch, err := cfg.GetExchangeConfig(e.Name)
@@ -175,7 +342,7 @@ func (e *Base) SetAssetTypes() error {
 		exch.AssetTypes = common.JoinStrings(e.AssetTypes, ",")
 		update = true
 	} else {
-		e.AssetTypes = common.SplitStrings(exch.AssetTypes, ",")
+		e.AssetTypes = asset.Split(exch.AssetTypes)
 	}
 
 	if update {
@@ -185,8 +352,19 @@ func (e *Base) SetAssetTypes() error {
 	return nil
 }
 
+// SupportsAssetType returns true if the asset type is enabled on the exchange
+func (e *Base) SupportsAssetType(assetType string) bool {
+	assetType = asset.Normalize(assetType)
+	for _, a := range e.AssetTypes {
+		if asset.Normalize(a) == assetType {
+			return true
+		}
+	}
+	return false
+}
+
 // GetExchangeAssetTypes returns the asset types the exchange supports (SPOT,
-// binary, futures)
+// margin, futures)
 func GetExchangeAssetTypes(exchName string) ([]string, error) {
 	cfg := config.GetConfig()
 	exch, err := cfg.GetExchangeConfig(exchName)
@@ -194,7 +372,7 @@ func GetExchangeAssetTypes(exchName string) ([]string, error) {
 		return nil, err
 	}
 
-	return common.SplitStrings(exch.AssetTypes, ","), nil
+	return asset.Split(exch.AssetTypes), nil
 }
 
 // SetCurrencyPairFormat checks the exchange request and config currency pair
@@ -427,6 +605,7 @@ func (e *Base) SetAPIKeys(APIKey, APISecret, ClientID string, b64Decode bool) {
 
 	e.APIKey = APIKey
 	e.ClientID = ClientID
+	e.apiSecretB64 = b64Decode
 
 	if b64Decode {
 		result, err := common.Base64Decode(APISecret)
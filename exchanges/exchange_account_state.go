@@ -0,0 +1,99 @@
+package exchange
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+// Position is an open margin position
+type Position struct {
+	ID           string            `json:"id"`
+	CurrencyPair pair.CurrencyPair `json:"currencyPair"`
+	// Buy for long positions, sell for short positions
+	Side   OrderSide `json:"side"`
+	Amount float64   `json:"amount"`
+	// Average price the position was opened at
+	BasePrice  float64 `json:"basePrice"`
+	ProfitLoss float64 `json:"profitLoss"`
+	// Asset type of the position, empty for margin positions in spot currency pairs. The amounts
+	// of derivative positions are in contracts.
+	AssetType        string  `json:"assetType,omitempty"`
+	Leverage         float64 `json:"leverage,omitempty"`
+	LiquidationPrice float64 `json:"liquidationPrice,omitempty"`
+}
+
+// PositionLister is implemented by exchanges that support margin positions
+type PositionLister interface {
+	GetPositions() ([]Position, error)
+}
+
+// LeverageSetter is implemented by exchanges that allow the leverage of the positions in a
+// currency pair to be changed
+type LeverageSetter interface {
+	// SetLeverage sets the leverage of the positions in the currency pair, zero switches the
+	// positions to cross margin on exchanges that support it.
+	SetLeverage(p pair.CurrencyPair, leverage float64) error
+}
+
+// AccountState is a snapshot of the balances, open orders & positions of an exchange account.
+// The requests are sent concurrently so the three lists are as consistent with each other as the
+// exchange allows, the snapshot is timestamped with the midpoint of the requests.
+type AccountState struct {
+	Exchange  string      `json:"exchange"`
+	Time      time.Time   `json:"time"`
+	Balances  AccountInfo `json:"balances"`
+	Orders    []*Order    `json:"orders"`
+	Positions []Position  `json:"positions"`
+	// Time from sending the first request until the last response was received, the state may
+	// have changed on the exchange during this window
+	Window time.Duration `json:"window"`
+}
+
+// GetAccountState retrieves the balances, open orders & positions (if positions isn't nil) of
+// an exchange. The requests are sent at the same time, each is still subject to the rate limits
+// of the exchange. An error is returned if any of the requests fails, including requests that
+// were rate limited and returned cached data, since that data isn't from the same moment.
+func GetAccountState(exch IBotExchangeEx, positions PositionLister) (AccountState, error) {
+	state := AccountState{Exchange: exch.GetName(), Orders: []*Order{}, Positions: []Position{}}
+	var balancesErr, ordersErr, positionsErr error
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		state.Balances, balancesErr = exch.GetExchangeAccountInfo()
+	}()
+	go func() {
+		defer wg.Done()
+		var orders []*Order
+		if orders, ordersErr = exch.GetOrders(nil); orders != nil {
+			state.Orders = orders
+		}
+	}()
+	if positions != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var p []Position
+			if p, positionsErr = positions.GetPositions(); p != nil {
+				state.Positions = p
+			}
+		}()
+	}
+	wg.Wait()
+	state.Window = time.Since(start)
+	state.Time = start.Add(state.Window / 2)
+
+	for _, err := range []error{balancesErr, ordersErr, positionsErr} {
+		if err != nil {
+			return state, err
+		}
+	}
+	if state.Balances.ExchangeName == "" {
+		state.Balances.ExchangeName = state.Exchange
+	}
+	return state, nil
+}
@@ -0,0 +1,65 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+type mockAccountExchange struct {
+	mockExchange
+	ordersErr error
+}
+
+func (m *mockAccountExchange) GetExchangeAccountInfo() (AccountInfo, error) {
+	time.Sleep(50 * time.Millisecond)
+	return AccountInfo{Currencies: []AccountCurrencyInfo{{CurrencyName: "BTC", TotalValue: 1}}}, nil
+}
+
+func (m *mockAccountExchange) GetOrders(pairs []pair.CurrencyPair) ([]*Order, error) {
+	time.Sleep(50 * time.Millisecond)
+	return testOpenOrders(), m.ordersErr
+}
+
+type mockPositionLister []Position
+
+func (m mockPositionLister) GetPositions() ([]Position, error) {
+	time.Sleep(50 * time.Millisecond)
+	return m, nil
+}
+
+func TestGetAccountState(t *testing.T) {
+	positions := mockPositionLister{{CurrencyPair: pair.NewCurrencyPair("BTC", "USD"), Side: OrderSideSell, Amount: 1}}
+	start := time.Now()
+	state, err := GetAccountState(&mockAccountExchange{}, positions)
+	if err != nil {
+		t.Fatalf("Test failed. GetAccountState returned an error: %s", err)
+	}
+	// the requests should've been sent concurrently
+	if state.Window >= 140*time.Millisecond {
+		t.Errorf("Test failed. Expected the requests to be sent at the same time, took %s", state.Window)
+	}
+	if state.Time.Before(start) || state.Time.After(start.Add(state.Window)) {
+		t.Errorf("Test failed. Expected the state time to be within the request window, got %s", state.Time)
+	}
+	if state.Exchange != "Mock" || state.Balances.ExchangeName != "Mock" || len(state.Balances.Currencies) != 1 ||
+		len(state.Orders) != 4 || len(state.Positions) != 1 {
+		t.Errorf("Test failed. Unexpected state %+v", state)
+	}
+
+	state, err = GetAccountState(&mockAccountExchange{}, nil)
+	if err != nil || state.Positions == nil || len(state.Positions) != 0 {
+		t.Errorf("Test failed. Expected no positions, got %v %v", state.Positions, err)
+	}
+
+	_, err = GetAccountState(&mockAccountExchange{ordersErr: WarningHTTPRequestRateLimited()}, positions)
+	if err == nil {
+		t.Error("Test failed. Expected rate limited orders to fail the account state")
+	}
+	_, err = GetAccountState(&mockAccountExchange{ordersErr: errors.New("nonce")}, nil)
+	if err == nil || err.Error() != "nonce" {
+		t.Errorf("Test failed. Expected the orders error but got %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/audit"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+)
+
+// ErrNotSupported is returned by the methods of an optional interface implemented by a wrapper
+// when the wrapped exchange doesn't implement the interface.
+var ErrNotSupported = errors.New("not supported by the exchange")
+
+// AuditedExchange wraps an exchange and records every mutating API call made through it
+// (along with the request parameters, response, latency and order ID) to an audit log.
+type AuditedExchange struct {
+	Decorator
+	AuditLog *audit.Log
+}
+
+// NewAuditedExchange returns a wrapper that records all the mutating API calls made to the given
+// exchange to the given audit log.
+func NewAuditedExchange(exch IBotExchangeEx, auditLog *audit.Log) *AuditedExchange {
+	return &AuditedExchange{Decorator{IBotExchangeEx: exch}, auditLog}
+}
+
+// NewOrder creates a new order on the exchange and records the call in the audit log.
+func (a *AuditedExchange) NewOrder(symbol pair.CurrencyPair, amount, price float64, side OrderSide,
+	orderType OrderType, opts ...OrderOptions) (string, error) {
+	return a.NewOrderContext(context.Background(), symbol, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (a *AuditedExchange) NewOrderContext(ctx context.Context, symbol pair.CurrencyPair, amount,
+	price float64, side OrderSide, orderType OrderType, opts ...OrderOptions) (string, error) {
+	start := time.Now()
+	orderID, err := WithContext(a.IBotExchangeEx).NewOrderContext(ctx, symbol, amount, price, side,
+		orderType, opts...)
+	params := map[string]interface{}{
+		"pair":   symbol.Display("/", true).String(),
+		"amount": amount,
+		"price":  price,
+		"side":   side,
+		"type":   orderType,
+	}
+	if len(opts) > 0 {
+		params["options"] = opts
+	}
+	a.record("NewOrder", params, orderID, orderID, err, start)
+	return orderID, err
+}
+
+// CancelOrder cancels an active order on the exchange and records the call in the audit log.
+func (a *AuditedExchange) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return a.CancelOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (a *AuditedExchange) CancelOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) error {
+	start := time.Now()
+	err := WithContext(a.IBotExchangeEx).CancelOrderContext(ctx, orderID, currencyPair)
+	a.record("CancelOrder", map[string]interface{}{
+		"order_id": orderID,
+		"pair":     currencyPair.Display("/", true).String(),
+	}, nil, orderID, err, start)
+	return err
+}
+
+// RotateAPIKeys replaces the API credentials of the exchange and records the call in the audit
+// log, the credentials themselves aren't recorded.
+func (a *AuditedExchange) RotateAPIKeys(apiKey, apiSecret, clientID string, verify func() error) error {
+	start := time.Now()
+	err := a.IBotExchangeEx.RotateAPIKeys(apiKey, apiSecret, clientID, verify)
+	a.record("RotateAPIKeys", nil, nil, "", err, start)
+	return err
+}
+
+// GetWalletBalances returns the wallet balances of the exchange, or ErrNotSupported if it doesn't
+// implement WalletTransferer.
+func (a *AuditedExchange) GetWalletBalances() ([]WalletBalance, error) {
+	t, ok := a.IBotExchangeEx.(WalletTransferer)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return t.GetWalletBalances()
+}
+
+// TransferBetweenWallets moves funds between the wallets of the exchange and records the call in
+// the audit log.
+func (a *AuditedExchange) TransferBetweenWallets(currency string, amount float64, from, to string) error {
+	t, ok := a.IBotExchangeEx.(WalletTransferer)
+	if !ok {
+		return ErrNotSupported
+	}
+	start := time.Now()
+	err := t.TransferBetweenWallets(currency, amount, from, to)
+	a.record("TransferBetweenWallets", map[string]interface{}{
+		"currency": currency,
+		"amount":   amount,
+		"from":     from,
+		"to":       to,
+	}, nil, "", err, start)
+	return err
+}
+
+// WithdrawToAddress withdraws funds from the exchange and records the call in the audit log.
+func (a *AuditedExchange) WithdrawToAddress(currency, address string, amount float64) (string, error) {
+	w, ok := a.IBotExchangeEx.(Withdrawer)
+	if !ok {
+		return "", ErrNotSupported
+	}
+	start := time.Now()
+	id, err := w.WithdrawToAddress(currency, address, amount)
+	a.record("WithdrawToAddress", map[string]interface{}{
+		"currency": currency,
+		"address":  address,
+		"amount":   amount,
+	}, id, "", err, start)
+	return id, err
+}
+
+// SetLeverage sets the leverage of the positions in a currency pair and records the call in the
+// audit log.
+func (a *AuditedExchange) SetLeverage(p pair.CurrencyPair, leverage float64) error {
+	l, ok := a.IBotExchangeEx.(LeverageSetter)
+	if !ok {
+		return ErrNotSupported
+	}
+	start := time.Now()
+	err := l.SetLeverage(p, leverage)
+	a.record("SetLeverage", map[string]interface{}{
+		"pair":     p.Display("/", true).String(),
+		"leverage": leverage,
+	}, nil, "", err, start)
+	return err
+}
+
+// GetContracts returns the contracts of the exchange, or nil if it doesn't implement
+// DerivativesExchange.
+func (a *AuditedExchange) GetContracts(assetType string) map[pair.CurrencyItem]*CurrencyPairInfo {
+	if d, ok := a.IBotExchangeEx.(DerivativesExchange); ok {
+		return d.GetContracts(assetType)
+	}
+	return nil
+}
+
+// GetContractOrderbook fetches the orderbook of a contract, or returns ErrNotSupported if the
+// exchange doesn't implement DerivativesExchange.
+func (a *AuditedExchange) GetContractOrderbook(symbol string) (orderbook.Base, error) {
+	d, ok := a.IBotExchangeEx.(DerivativesExchange)
+	if !ok {
+		return orderbook.Base{}, ErrNotSupported
+	}
+	return d.GetContractOrderbook(symbol)
+}
+
+// NewContractOrder places an order for a contract and records the call in the audit log.
+func (a *AuditedExchange) NewContractOrder(symbol string, amount, price float64, side OrderSide,
+	orderType OrderType, opts ...OrderOptions) (string, error) {
+	d, ok := a.IBotExchangeEx.(DerivativesExchange)
+	if !ok {
+		return "", ErrNotSupported
+	}
+	start := time.Now()
+	orderID, err := d.NewContractOrder(symbol, amount, price, side, orderType, opts...)
+	params := map[string]interface{}{
+		"symbol": symbol,
+		"amount": amount,
+		"price":  price,
+		"side":   side,
+		"type":   orderType,
+	}
+	if len(opts) > 0 {
+		params["options"] = opts
+	}
+	a.record("NewContractOrder", params, orderID, orderID, err, start)
+	return orderID, err
+}
+
+func (a *AuditedExchange) record(method string, params map[string]interface{}, response interface{},
+	orderID string, err error, start time.Time) {
+	if a.AuditLog == nil {
+		return
+	}
+	entry := audit.Entry{
+		Timestamp: start,
+		Exchange:  a.GetName(),
+		Method:    method,
+		Params:    params,
+		Response:  response,
+		Latency:   time.Since(start),
+		OrderID:   orderID,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		if exchErr, ok := err.(*ExchangeError); ok {
+			entry.Response = exchErr.Raw
+		}
+	}
+	if auditErr := a.AuditLog.Record(entry); auditErr != nil {
+		log.Printf("%s failed to record %s call in audit log: %s\n", a.GetName(), method, auditErr)
+	}
+}
@@ -0,0 +1,58 @@
+package exchange
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/exchanges/audit"
+)
+
+type mockWalletExchange struct {
+	mockExchange
+	transfers int
+}
+
+func (m *mockWalletExchange) GetWalletBalances() ([]WalletBalance, error) {
+	return nil, nil
+}
+
+func (m *mockWalletExchange) TransferBetweenWallets(currency string, amount float64, from, to string) error {
+	m.transfers++
+	return nil
+}
+
+func TestAuditedExchangeTransfers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatalf("Test failed. Unable to create temp dir. Error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	auditLog, err := audit.New(filepath.Join(dir, "audit.log"), 0, 0)
+	if err != nil {
+		t.Fatalf("Test failed. Unable to open audit log. Error: %s", err)
+	}
+	defer auditLog.Close()
+
+	mock := &mockWalletExchange{}
+	audited := NewAuditedExchange(mock, auditLog)
+	if err = audited.TransferBetweenWallets("BTC", 1, WalletMargin, WalletExchange); err != nil {
+		t.Fatalf("Test failed. TransferBetweenWallets returned an error: %s", err)
+	}
+	if mock.transfers != 1 {
+		t.Errorf("Test failed. Expected 1 transfer to reach the exchange but got %d", mock.transfers)
+	}
+	if _, err = audited.WithdrawToAddress("BTC", "1abc", 1); err != ErrNotSupported {
+		t.Errorf("Test failed. Expected ErrNotSupported but got %v", err)
+	}
+
+	entries, err := auditLog.Find(func(e *audit.Entry) bool { return true })
+	if err != nil {
+		t.Fatalf("Test failed. Unable to read audit log. Error: %s", err)
+	}
+	if len(entries) != 1 || entries[0].Method != "TransferBetweenWallets" || entries[0].Exchange != "Mock" ||
+		entries[0].Params["from"] != WalletMargin {
+		t.Errorf("Test failed. Unexpected audit log entries: %v", entries)
+	}
+}
@@ -0,0 +1,157 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+// OrderHolds returns the amount of each currency held by the given open orders, keyed by the
+// uppercase currency code. Buy orders hold the remaining amount * rate of the second (quote)
+// currency, sell orders hold the remaining amount of the first (base) currency.
+func OrderHolds(orders []*Order) map[pair.CurrencyItem]float64 {
+	holds := make(map[pair.CurrencyItem]float64)
+	for _, order := range orders {
+		if order == nil || (order.Status != "" && order.Status != OrderStatusActive) {
+			continue
+		}
+		remaining := order.RemainingAmount
+		if remaining == 0 {
+			remaining = order.Amount - order.FilledAmount
+		}
+		if remaining <= 0 {
+			continue
+		}
+		switch order.Side {
+		case OrderSideBuy:
+			c := order.CurrencyPair.SecondCurrency.Upper()
+			holds[c] += remaining * order.Rate
+		case OrderSideSell:
+			c := order.CurrencyPair.FirstCurrency.Upper()
+			holds[c] += remaining
+		}
+	}
+	return holds
+}
+
+// SetHoldsFromTotals fills in the Hold & Available balances of an exchange that only reports the
+// total balance of each currency, the holds are derived from the open orders.
+func SetHoldsFromTotals(info *AccountInfo, orders []*Order) {
+	holds := OrderHolds(orders)
+	for i := range info.Currencies {
+		c := &info.Currencies[i]
+		hold := holds[pair.CurrencyItem(common.StringToUpper(c.CurrencyName))]
+		// the balance may have been updated before the orders were fetched
+		if hold > c.TotalValue {
+			hold = c.TotalValue
+		}
+		c.Hold = hold
+		c.Available = c.TotalValue - hold
+	}
+}
+
+// SetHoldsFromAvailable fills in the Hold & TotalValue balances of an exchange that only reports
+// the available balance of each currency, the holds are derived from the open orders. Currencies
+// that are entirely held by open orders are added to the account info.
+func SetHoldsFromAvailable(info *AccountInfo, orders []*Order) {
+	holds := OrderHolds(orders)
+	for i := range info.Currencies {
+		c := &info.Currencies[i]
+		code := pair.CurrencyItem(common.StringToUpper(c.CurrencyName))
+		hold := holds[code]
+		delete(holds, code)
+		c.Hold = hold
+		c.TotalValue = c.Available + hold
+	}
+	for code, hold := range holds {
+		info.Currencies = append(info.Currencies, AccountCurrencyInfo{
+			CurrencyName: code.String(),
+			TotalValue:   hold,
+			Hold:         hold,
+		})
+	}
+}
+
+// ErrBalancesTimeout is returned for an exchange that didn't return its balances before the
+// per-exchange timeout expired
+var ErrBalancesTimeout = errors.New("timed out retrieving balances")
+
+// BalanceError records the exchange that failed to return its balances
+type BalanceError struct {
+	Exchange string `json:"exchange"`
+	Error    string `json:"error"`
+}
+
+// BalanceSnapshot holds the balances retrieved from multiple exchanges at once. The snapshot may
+// be partial, the exchanges that failed (or timed out) are listed in Errors.
+type BalanceSnapshot struct {
+	Time     time.Time      `json:"time"`
+	Accounts []AccountInfo  `json:"accounts"`
+	Errors   []BalanceError `json:"errors"`
+}
+
+// Complete returns true if every exchange returned its balances
+func (s *BalanceSnapshot) Complete() bool {
+	return len(s.Errors) == 0
+}
+
+// GetAllBalances retrieves the balances of the given exchanges concurrently. Each exchange gets at
+// most timeout (if non-zero) to respond, the exchanges that don't respond in time or before ctx is
+// done are reported in the snapshot errors instead of delaying the rest. The accounts & errors are
+// sorted by exchange name.
+func GetAllBalances(ctx context.Context, exchanges []IBotExchange, timeout time.Duration) BalanceSnapshot {
+	type result struct {
+		info AccountInfo
+		err  error
+	}
+
+	snapshot := BalanceSnapshot{Time: time.Now(), Accounts: []AccountInfo{}, Errors: []BalanceError{}}
+	var mtx sync.Mutex
+	var wg sync.WaitGroup
+	for _, exch := range exchanges {
+		wg.Add(1)
+		go func(exch IBotExchange) {
+			defer wg.Done()
+
+			exchCtx := ctx
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				exchCtx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			var r result
+			r.info, r.err = WithContext(exch).GetExchangeAccountInfoContext(exchCtx)
+			if r.err != nil && exchCtx.Err() != nil {
+				r.err = exchCtx.Err()
+				if r.err == context.DeadlineExceeded {
+					r.err = ErrBalancesTimeout
+				}
+			}
+
+			mtx.Lock()
+			defer mtx.Unlock()
+			if r.err != nil {
+				snapshot.Errors = append(snapshot.Errors, BalanceError{Exchange: exch.GetName(), Error: r.err.Error()})
+				return
+			}
+			if r.info.ExchangeName == "" {
+				r.info.ExchangeName = exch.GetName()
+			}
+			snapshot.Accounts = append(snapshot.Accounts, r.info)
+		}(exch)
+	}
+	wg.Wait()
+
+	sort.Slice(snapshot.Accounts, func(i, j int) bool {
+		return snapshot.Accounts[i].ExchangeName < snapshot.Accounts[j].ExchangeName
+	})
+	sort.Slice(snapshot.Errors, func(i, j int) bool {
+		return snapshot.Errors[i].Exchange < snapshot.Errors[j].Exchange
+	})
+	return snapshot
+}
@@ -0,0 +1,133 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+func testOpenOrders() []*Order {
+	return []*Order{
+		{CurrencyPair: pair.NewCurrencyPair("BTC", "USD"), Side: OrderSideBuy, Amount: 2, FilledAmount: 1,
+			Rate: 100, Status: OrderStatusActive},
+		{CurrencyPair: pair.NewCurrencyPair("BTC", "USD"), Side: OrderSideSell, Amount: 0.5, Rate: 200},
+		{CurrencyPair: pair.NewCurrencyPair("eth", "btc"), Side: OrderSideSell, Amount: 3, RemainingAmount: 1,
+			Rate: 0.1, Status: OrderStatusActive},
+		{CurrencyPair: pair.NewCurrencyPair("ETH", "BTC"), Side: OrderSideSell, Amount: 3,
+			Rate: 0.1, Status: OrderStatusFilled},
+	}
+}
+
+func TestOrderHolds(t *testing.T) {
+	holds := OrderHolds(testOpenOrders())
+	expected := map[pair.CurrencyItem]float64{"USD": 100, "BTC": 0.5, "ETH": 1}
+	if len(holds) != len(expected) {
+		t.Fatalf("Test failed. Expected %d holds, got %v", len(expected), holds)
+	}
+	for c, amount := range expected {
+		if hold := holds[c]; hold != amount {
+			t.Errorf("Test failed. Expected %s hold of %f, got %f", c, amount, hold)
+		}
+	}
+}
+
+func TestSetHoldsFromTotals(t *testing.T) {
+	info := AccountInfo{Currencies: []AccountCurrencyInfo{
+		{CurrencyName: "USD", TotalValue: 150},
+		{CurrencyName: "BTC", TotalValue: 0.3},
+		{CurrencyName: "LTC", TotalValue: 5},
+	}}
+	SetHoldsFromTotals(&info, testOpenOrders())
+
+	expected := []AccountCurrencyInfo{
+		{CurrencyName: "USD", TotalValue: 150, Hold: 100, Available: 50},
+		{CurrencyName: "BTC", TotalValue: 0.3, Hold: 0.3, Available: 0},
+		{CurrencyName: "LTC", TotalValue: 5, Hold: 0, Available: 5},
+	}
+	for i := range expected {
+		if info.Currencies[i] != expected[i] {
+			t.Errorf("Test failed. Expected %+v, got %+v", expected[i], info.Currencies[i])
+		}
+	}
+}
+
+func TestSetHoldsFromAvailable(t *testing.T) {
+	info := AccountInfo{Currencies: []AccountCurrencyInfo{
+		{CurrencyName: "USD", Available: 50},
+		{CurrencyName: "btc", Available: 0.1},
+	}}
+	SetHoldsFromAvailable(&info, testOpenOrders())
+
+	expected := []AccountCurrencyInfo{
+		{CurrencyName: "USD", TotalValue: 150, Hold: 100, Available: 50},
+		{CurrencyName: "btc", TotalValue: 0.6, Hold: 0.5, Available: 0.1},
+		{CurrencyName: "ETH", TotalValue: 1, Hold: 1, Available: 0},
+	}
+	if len(info.Currencies) != len(expected) {
+		t.Fatalf("Test failed. Expected %d currencies, got %+v", len(expected), info.Currencies)
+	}
+	for i := range expected {
+		if info.Currencies[i] != expected[i] {
+			t.Errorf("Test failed. Expected %+v, got %+v", expected[i], info.Currencies[i])
+		}
+	}
+}
+
+type mockBalancesExchange struct {
+	mockExchange
+	name  string
+	delay time.Duration
+	err   error
+}
+
+func (m *mockBalancesExchange) GetName() string {
+	return m.name
+}
+
+func (m *mockBalancesExchange) GetExchangeAccountInfo() (AccountInfo, error) {
+	time.Sleep(m.delay)
+	return AccountInfo{Currencies: []AccountCurrencyInfo{{CurrencyName: "BTC", TotalValue: 1}}}, m.err
+}
+
+func TestGetAllBalances(t *testing.T) {
+	exchanges := []IBotExchange{
+		&mockBalancesExchange{name: "Slow", delay: time.Second},
+		&mockBalancesExchange{name: "Failing", err: errors.New("invalid nonce")},
+		&mockBalancesExchange{name: "Bitfinex", delay: 10 * time.Millisecond},
+		&mockBalancesExchange{name: "Binance"},
+	}
+	start := time.Now()
+	snapshot := GetAllBalances(context.Background(), exchanges, 100*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Test failed. Expected the slow exchange to time out, took %s", elapsed)
+	}
+	if snapshot.Complete() {
+		t.Error("Test failed. Expected a partial snapshot")
+	}
+	if len(snapshot.Accounts) != 2 || snapshot.Accounts[0].ExchangeName != "Binance" ||
+		snapshot.Accounts[1].ExchangeName != "Bitfinex" {
+		t.Errorf("Test failed. Unexpected accounts %+v", snapshot.Accounts)
+	}
+	expected := []BalanceError{
+		{Exchange: "Failing", Error: "invalid nonce"},
+		{Exchange: "Slow", Error: ErrBalancesTimeout.Error()},
+	}
+	if len(snapshot.Errors) != len(expected) {
+		t.Fatalf("Test failed. Expected errors %+v, got %+v", expected, snapshot.Errors)
+	}
+	for i := range expected {
+		if snapshot.Errors[i] != expected[i] {
+			t.Errorf("Test failed. Expected %+v, got %+v", expected[i], snapshot.Errors[i])
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	snapshot = GetAllBalances(ctx, exchanges[:1], 0)
+	if len(snapshot.Errors) != 1 || snapshot.Errors[0].Error != context.Canceled.Error() {
+		t.Errorf("Test failed. Expected the cancelled context to be reported, got %+v", snapshot.Errors)
+	}
+}
@@ -0,0 +1,54 @@
+package exchange
+
+import (
+	"fmt"
+	"time"
+)
+
+// CandleInterval is the period of time covered by a single candle
+type CandleInterval time.Duration
+
+// Candle intervals, exchanges generally only support a subset of these
+const (
+	CandleInterval1m  = CandleInterval(time.Minute)
+	CandleInterval5m  = CandleInterval(5 * time.Minute)
+	CandleInterval15m = CandleInterval(15 * time.Minute)
+	CandleInterval30m = CandleInterval(30 * time.Minute)
+	CandleInterval1h  = CandleInterval(time.Hour)
+	CandleInterval4h  = CandleInterval(4 * time.Hour)
+	CandleInterval1d  = CandleInterval(24 * time.Hour)
+)
+
+// String returns the interval in the usual short form, e.g. 5m, 1h, 1d.
+func (i CandleInterval) String() string {
+	d := time.Duration(i)
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+}
+
+// Candle holds OHLC data for a single interval
+type Candle struct {
+	// Start of the interval covered by the candle
+	Timestamp time.Time
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	// Volume traded during the interval, denominated in the base (first) currency
+	Volume float64
+	// Volume traded during the interval, denominated in the quote (second) currency,
+	// zero if the exchange doesn't supply it
+	QuoteVolume float64
+}
+
+// ErrUnsupportedCandleInterval is returned when an exchange doesn't support candles for the
+// requested interval.
+func ErrUnsupportedCandleInterval(exchangeName string, interval CandleInterval) error {
+	return fmt.Errorf("%s doesn't support %s candles", exchangeName, interval)
+}
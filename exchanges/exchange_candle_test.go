@@ -0,0 +1,23 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCandleIntervalString(t *testing.T) {
+	tests := map[CandleInterval]string{
+		CandleInterval1m:                   "1m",
+		CandleInterval15m:                  "15m",
+		CandleInterval1h:                   "1h",
+		CandleInterval4h:                   "4h",
+		CandleInterval1d:                   "1d",
+		CandleInterval(90 * time.Minute):   "90m",
+		CandleInterval(7 * 24 * time.Hour): "7d",
+	}
+	for interval, expected := range tests {
+		if interval.String() != expected {
+			t.Errorf("Test Failed - CandleInterval.String() expected %s but got %s", expected, interval.String())
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+// ErrTradingNotSupported is returned by the trading methods of WithContext for an exchange that
+// doesn't implement IExchange
+var ErrTradingNotSupported = errors.New("exchange doesn't support trading")
+
+// ContextExchange is implemented by exchanges whose requests can be cancelled, each method is
+// the IExchange method of the same name (without the Context suffix) with the HTTP requests
+// bound to ctx.
+type ContextExchange interface {
+	UpdateTickerContext(ctx context.Context, currency pair.CurrencyPair, assetType string) (ticker.Price, error)
+	UpdateOrderbookContext(ctx context.Context, currency pair.CurrencyPair, assetType string) (orderbook.Base, error)
+	GetExchangeAccountInfoContext(ctx context.Context) (AccountInfo, error)
+	NewOrderContext(ctx context.Context, symbol pair.CurrencyPair, amount, price float64, side OrderSide,
+		orderType OrderType, opts ...OrderOptions) (string, error)
+	CancelOrderContext(ctx context.Context, orderID string, currencyPair pair.CurrencyPair) error
+	GetOrderContext(ctx context.Context, orderID string, currencyPair pair.CurrencyPair) (*Order, error)
+	GetOrdersContext(ctx context.Context, pairs []pair.CurrencyPair) ([]*Order, error)
+}
+
+// WithContext returns the exchange itself if it implements ContextExchange. Other exchanges are
+// wrapped so the calls return ctx.Err() as soon as ctx is done, the underlying request can't be
+// cancelled though so it's abandoned & runs to completion in the background. An abandoned
+// NewOrder or CancelOrder may still succeed, the order state should be checked before retrying.
+func WithContext(exch IBotExchange) ContextExchange {
+	if c, ok := exch.(ContextExchange); ok {
+		return c
+	}
+	return contextAdapter{exch}
+}
+
+type contextAdapter struct {
+	exch IBotExchange
+}
+
+// await runs call in a goroutine and waits for it to return or ctx to be done, whichever is
+// first. If the call is abandoned ctx.Err() is returned, and the results of the call mustn't be
+// read since it's still running. Calls with a context that can't be cancelled are run directly.
+func await(ctx context.Context, call func() error) (abandoned bool, err error) {
+	if ctx.Done() == nil {
+		return false, call()
+	}
+	if err := ctx.Err(); err != nil {
+		return true, err
+	}
+	// buffered so the call can complete after it's been abandoned
+	done := make(chan error, 1)
+	go func() {
+		done <- call()
+	}()
+	select {
+	case err := <-done:
+		return false, err
+	case <-ctx.Done():
+		return true, ctx.Err()
+	}
+}
+
+func (a contextAdapter) trader() (IExchange, error) {
+	if exch, ok := a.exch.(IExchange); ok {
+		return exch, nil
+	}
+	return nil, ErrTradingNotSupported
+}
+
+func (a contextAdapter) UpdateTickerContext(ctx context.Context, currency pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
+	var price ticker.Price
+	abandoned, err := await(ctx, func() error {
+		p, err := a.exch.UpdateTicker(currency, assetType)
+		price = p
+		return err
+	})
+	if abandoned {
+		return ticker.Price{}, err
+	}
+	return price, err
+}
+
+func (a contextAdapter) UpdateOrderbookContext(ctx context.Context, currency pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
+	var book orderbook.Base
+	abandoned, err := await(ctx, func() error {
+		b, err := a.exch.UpdateOrderbook(currency, assetType)
+		book = b
+		return err
+	})
+	if abandoned {
+		return orderbook.Base{}, err
+	}
+	return book, err
+}
+
+func (a contextAdapter) GetExchangeAccountInfoContext(ctx context.Context) (AccountInfo, error) {
+	var info AccountInfo
+	abandoned, err := await(ctx, func() error {
+		i, err := a.exch.GetExchangeAccountInfo()
+		info = i
+		return err
+	})
+	if abandoned {
+		return AccountInfo{}, err
+	}
+	return info, err
+}
+
+func (a contextAdapter) NewOrderContext(ctx context.Context, symbol pair.CurrencyPair, amount, price float64,
+	side OrderSide, orderType OrderType, opts ...OrderOptions) (string, error) {
+	exch, err := a.trader()
+	if err != nil {
+		return "", err
+	}
+	var orderID string
+	abandoned, err := await(ctx, func() error {
+		id, err := exch.NewOrder(symbol, amount, price, side, orderType, opts...)
+		orderID = id
+		return err
+	})
+	if abandoned {
+		return "", err
+	}
+	return orderID, err
+}
+
+func (a contextAdapter) CancelOrderContext(ctx context.Context, orderID string, currencyPair pair.CurrencyPair) error {
+	exch, err := a.trader()
+	if err != nil {
+		return err
+	}
+	_, err = await(ctx, func() error {
+		return exch.CancelOrder(orderID, currencyPair)
+	})
+	return err
+}
+
+func (a contextAdapter) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*Order, error) {
+	exch, err := a.trader()
+	if err != nil {
+		return nil, err
+	}
+	var order *Order
+	abandoned, err := await(ctx, func() error {
+		o, err := exch.GetOrder(orderID, currencyPair)
+		order = o
+		return err
+	})
+	if abandoned {
+		return nil, err
+	}
+	return order, err
+}
+
+func (a contextAdapter) GetOrdersContext(ctx context.Context, pairs []pair.CurrencyPair) ([]*Order, error) {
+	exch, err := a.trader()
+	if err != nil {
+		return nil, err
+	}
+	var orders []*Order
+	abandoned, err := await(ctx, func() error {
+		o, err := exch.GetOrders(pairs)
+		orders = o
+		return err
+	})
+	if abandoned {
+		return nil, err
+	}
+	return orders, err
+}
+
+// Decorator is embedded by the exchange decorators in place of the exchange they wrap, the methods
+// the decorator doesn't override are forwarded to the wrapped exchange, including the
+// ContextExchange methods. A decorator that intercepts an IExchange method must override the
+// Context method of the same name too, otherwise calls made with a context bypass it.
+type Decorator struct {
+	IBotExchangeEx
+}
+
+var _ ContextExchange = Decorator{}
+
+// Unwrap returns the exchange wrapped by the decorator
+func (d Decorator) Unwrap() IBotExchangeEx {
+	return d.IBotExchangeEx
+}
+
+// Unwrap returns the exchange wrapped by a decorator, or nil if the exchange isn't a decorator
+func Unwrap(exch IBotExchange) IBotExchange {
+	if d, ok := exch.(interface {
+		Unwrap() IBotExchangeEx
+	}); ok {
+		if wrapped := d.Unwrap(); wrapped != nil {
+			return wrapped
+		}
+	}
+	return nil
+}
+
+// UpdateTickerContext is UpdateTicker of the wrapped exchange, the request is cancelled when the
+// context is done
+func (d Decorator) UpdateTickerContext(ctx context.Context, currency pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
+	return WithContext(d.IBotExchangeEx).UpdateTickerContext(ctx, currency, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook of the wrapped exchange, the request is cancelled
+// when the context is done
+func (d Decorator) UpdateOrderbookContext(ctx context.Context, currency pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
+	return WithContext(d.IBotExchangeEx).UpdateOrderbookContext(ctx, currency, assetType)
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo of the wrapped exchange, the request is
+// cancelled when the context is done
+func (d Decorator) GetExchangeAccountInfoContext(ctx context.Context) (AccountInfo, error) {
+	return WithContext(d.IBotExchangeEx).GetExchangeAccountInfoContext(ctx)
+}
+
+// NewOrderContext is NewOrder of the wrapped exchange, the request is cancelled when the context
+// is done
+func (d Decorator) NewOrderContext(ctx context.Context, symbol pair.CurrencyPair, amount, price float64,
+	side OrderSide, orderType OrderType, opts ...OrderOptions) (string, error) {
+	return WithContext(d.IBotExchangeEx).NewOrderContext(ctx, symbol, amount, price, side, orderType, opts...)
+}
+
+// CancelOrderContext is CancelOrder of the wrapped exchange, the request is cancelled when the
+// context is done
+func (d Decorator) CancelOrderContext(ctx context.Context, orderID string, currencyPair pair.CurrencyPair) error {
+	return WithContext(d.IBotExchangeEx).CancelOrderContext(ctx, orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder of the wrapped exchange, the request is cancelled when the context
+// is done
+func (d Decorator) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*Order, error) {
+	return WithContext(d.IBotExchangeEx).GetOrderContext(ctx, orderID, currencyPair)
+}
+
+// GetOrdersContext is GetOrders of the wrapped exchange, the request is cancelled when the
+// context is done
+func (d Decorator) GetOrdersContext(ctx context.Context, pairs []pair.CurrencyPair) ([]*Order, error) {
+	return WithContext(d.IBotExchangeEx).GetOrdersContext(ctx, pairs)
+}
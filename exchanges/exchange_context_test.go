@@ -0,0 +1,105 @@
+package exchange
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+type mockSlowExchange struct {
+	mockExchange
+	delay time.Duration
+}
+
+func (m *mockSlowExchange) UpdateTicker(currency pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	time.Sleep(m.delay)
+	return ticker.Price{Last: 1}, nil
+}
+
+type mockContextExchange struct {
+	mockSlowExchange
+	ContextExchange
+}
+
+func TestWithContext(t *testing.T) {
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+	fast := WithContext(&mockSlowExchange{})
+	price, err := fast.UpdateTickerContext(context.Background(), btcusd, "SPOT")
+	if err != nil || price.Last != 1 {
+		t.Errorf("Test failed. Expected the ticker to be returned, got %v %v", price, err)
+	}
+
+	slow := WithContext(&mockSlowExchange{delay: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err = slow.UpdateTickerContext(ctx, btcusd, "SPOT"); err != context.DeadlineExceeded {
+		t.Errorf("Test failed. Expected the request to time out, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("Test failed. The request wasn't abandoned when the context was done")
+	}
+	if _, err = slow.UpdateTickerContext(ctx, btcusd, "SPOT"); err != context.DeadlineExceeded {
+		t.Errorf("Test failed. Expected the request to be skipped once the context is done, got %v", err)
+	}
+
+	native := &mockContextExchange{}
+	if WithContext(native) != native {
+		t.Error("Test failed. Expected an exchange that implements ContextExchange to be used as is")
+	}
+	if _, err = WithContext(&mockBotExchange{}).GetOrdersContext(context.Background(), nil); err != ErrTradingNotSupported {
+		t.Errorf("Test failed. Expected trading to be unsupported, got %v", err)
+	}
+}
+
+type mockBotExchange struct {
+	IBotExchange
+}
+
+type mockNativeContextExchange struct {
+	mockExchange
+	ContextExchange
+	ctx context.Context
+}
+
+func (m *mockNativeContextExchange) NewOrderContext(ctx context.Context, symbol pair.CurrencyPair, amount,
+	price float64, side OrderSide, orderType OrderType, opts ...OrderOptions) (string, error) {
+	m.ctx = ctx
+	return "1", nil
+}
+
+func TestWithContextWrappers(t *testing.T) {
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+	tradingSwitch := NewTradingSwitch()
+	for _, wrap := range []func(IBotExchangeEx) IBotExchangeEx{
+		func(exch IBotExchangeEx) IBotExchangeEx { return NewPausableExchange(exch, tradingSwitch) },
+		func(exch IBotExchangeEx) IBotExchangeEx { return NewRetryingExchange(exch, DefaultRetryPolicy) },
+		func(exch IBotExchangeEx) IBotExchangeEx { return NewDegradableExchange(exch, 3) },
+		func(exch IBotExchangeEx) IBotExchangeEx { return NewDowntimeSimulator(exch) },
+	} {
+		native := &mockNativeContextExchange{}
+		wrapper := wrap(native)
+		c, ok := wrapper.(ContextExchange)
+		if !ok {
+			t.Fatalf("Test failed. %T doesn't implement ContextExchange", wrapper)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		if _, err := c.NewOrderContext(ctx, btcusd, 1, 1, OrderSideBuy, OrderTypeExchangeLimit); err != nil {
+			t.Errorf("Test failed. %T NewOrderContext returned an error: %s", wrapper, err)
+		}
+		if native.ctx != ctx {
+			t.Errorf("Test failed. %T didn't pass the context to the exchange", wrapper)
+		}
+		cancel()
+	}
+
+	tradingSwitch.SetPaused(true)
+	paused := NewPausableExchange(&mockNativeContextExchange{}, tradingSwitch)
+	if _, err := paused.NewOrderContext(context.Background(), btcusd, 1, 1, OrderSideBuy,
+		OrderTypeExchangeLimit); err != ErrTradingPaused {
+		t.Errorf("Test failed. Expected ErrTradingPaused but got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+)
+
+// ErrAuthenticatedAPIUnsupported is returned when attempting to rotate the API keys of an exchange
+// that doesn't have authenticated API support enabled
+var ErrAuthenticatedAPIUnsupported = errors.New("authenticated API support is disabled")
+
+// Credentials returns the API credentials of the exchange, signed requests must read the
+// credentials once with Credentials (not the fields) so a concurrent RotateAPIKeys can't switch
+// the keys part way through signing a request. The credentials aren't locked while the request is
+// in flight, so a rotation doesn't wait for the requests signed with the previous keys.
+func (e *Base) Credentials() (apiKey, apiSecret, clientID string) {
+	e.credentialsMtx.RLock()
+	defer e.credentialsMtx.RUnlock()
+	return e.APIKey, e.APISecret, e.ClientID
+}
+
+// RotateAPIKeys replaces the API credentials of the exchange at runtime, requests signed after the
+// switch use the new keys while in-flight requests complete with the previous keys.
+// Once the new keys are in place verify is called (if not nil) to check that they work and have
+// the required permissions, if verification fails the previous keys are restored.
+func (e *Base) RotateAPIKeys(apiKey, apiSecret, clientID string, verify func() error) error {
+	if !e.AuthenticatedAPISupport {
+		return ErrAuthenticatedAPIUnsupported
+	}
+
+	if e.apiSecretB64 {
+		result, err := common.Base64Decode(apiSecret)
+		if err != nil {
+			return fmt.Errorf("%s unable to base64 decode API secret: %s", e.Name, err)
+		}
+		apiSecret = string(result)
+	}
+
+	e.credentialsMtx.Lock()
+	oldKey, oldSecret, oldClientID := e.APIKey, e.APISecret, e.ClientID
+	e.APIKey, e.APISecret, e.ClientID = apiKey, apiSecret, clientID
+	e.credentialsMtx.Unlock()
+
+	if verify == nil {
+		return nil
+	}
+	if err := verify(); err != nil {
+		e.credentialsMtx.Lock()
+		e.APIKey, e.APISecret, e.ClientID = oldKey, oldSecret, oldClientID
+		e.credentialsMtx.Unlock()
+		return fmt.Errorf("%s new API keys failed verification, the previous keys have been restored: %s",
+			e.Name, err)
+	}
+	return nil
+}
+
+// VerifyAccountAccess returns a verification func for RotateAPIKeys that checks the API keys
+// of the exchange can be used to retrieve the account balances.
+func VerifyAccountAccess(exch IBotExchange) func() error {
+	return func() error {
+		_, err := exch.GetExchangeAccountInfo()
+		return err
+	}
+}
@@ -0,0 +1,71 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRotateAPIKeys(t *testing.T) {
+	b := Base{Name: "TESTNAME"}
+	if err := b.RotateAPIKeys("key", "secret", "", nil); err != ErrAuthenticatedAPIUnsupported {
+		t.Errorf("Test Failed - expected ErrAuthenticatedAPIUnsupported, got %v", err)
+	}
+
+	b.AuthenticatedAPISupport = true
+	b.SetAPIKeys("oldkey", "oldsecret", "", false)
+	err := b.RotateAPIKeys("newkey", "newsecret", "", func() error { return nil })
+	if err != nil {
+		t.Fatalf("Test failed. RotateAPIKeys error: %s", err)
+	}
+	if b.APIKey != "newkey" || b.APISecret != "newsecret" {
+		t.Errorf("Test Failed - keys not rotated, got %s %s", b.APIKey, b.APISecret)
+	}
+
+	err = b.RotateAPIKeys("badkey", "badsecret", "", func() error {
+		if b.APIKey != "badkey" {
+			return errors.New("verifying the wrong key")
+		}
+		return errors.New("permission denied")
+	})
+	if err == nil {
+		t.Error("Test Failed - expected verification error")
+	}
+	if b.APIKey != "newkey" || b.APISecret != "newsecret" {
+		t.Errorf("Test Failed - keys not restored, got %s %s", b.APIKey, b.APISecret)
+	}
+
+	b.SetAPIKeys("key", "c2VjcmV0", "", true)
+	if err = b.RotateAPIKeys("key2", "c2VjcmV0Mg==", "", nil); err != nil {
+		t.Fatalf("Test failed. RotateAPIKeys error: %s", err)
+	}
+	if b.APISecret != "secret2" {
+		t.Errorf("Test Failed - expected decoded secret, got %s", b.APISecret)
+	}
+}
+
+func TestRotateAPIKeysWithRequestsInFlight(t *testing.T) {
+	b := Base{Name: "TESTNAME", AuthenticatedAPISupport: true}
+	b.SetAPIKeys("oldkey", "oldsecret", "", false)
+
+	apiKey, apiSecret, _ := b.Credentials()
+	done := make(chan error)
+	go func() {
+		done <- b.RotateAPIKeys("newkey", "newsecret", "", nil)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Test failed. RotateAPIKeys error: %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Test failed. Rotation blocked by a signed request in flight")
+	}
+	if apiKey != "oldkey" || apiSecret != "oldsecret" {
+		t.Errorf("Test Failed - in-flight credentials changed, got %s %s", apiKey, apiSecret)
+	}
+	if apiKey, apiSecret, _ = b.Credentials(); apiKey != "newkey" || apiSecret != "newsecret" {
+		t.Errorf("Test Failed - expected the new keys, got %s %s", apiKey, apiSecret)
+	}
+}
@@ -0,0 +1,31 @@
+package exchange
+
+// CurrencyNetwork is a network (chain) a currency can be deposited & withdrawn on
+type CurrencyNetwork struct {
+	// Network identifier used by the exchange, e.g. BTC, ETH or OMNI
+	Network string `json:"network"`
+	Name    string `json:"name,omitempty"`
+	// Exchange specific deposit & withdrawal method, if the exchange requires one
+	Method string `json:"method,omitempty"`
+	// Whether deposits & withdrawals need a tag (memo, destination tag or payment ID) in
+	// addition to the address
+	TagRequired       bool    `json:"tagRequired"`
+	WithdrawalEnabled bool    `json:"withdrawalEnabled"`
+	WithdrawalFee     float64 `json:"withdrawalFee,omitempty"`
+	MinWithdrawal     float64 `json:"minWithdrawal,omitempty"`
+	// Number of confirmations required before a deposit is credited
+	Confirmations int `json:"confirmations,omitempty"`
+}
+
+// CurrencyMetadata describes a currency supported by an exchange, normalized across exchanges
+type CurrencyMetadata struct {
+	Currency string            `json:"currency"`
+	Name     string            `json:"name,omitempty"`
+	Networks []CurrencyNetwork `json:"networks"`
+}
+
+// CurrencyMetadataProvider is implemented by exchanges that publish the names & deposit networks
+// of the currencies they support
+type CurrencyMetadataProvider interface {
+	GetCurrencyMetadata() ([]CurrencyMetadata, error)
+}
@@ -0,0 +1,262 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+// ErrExchangeDegraded is returned by a DegradableExchange for new orders while the authenticated
+// API of the exchange is down.
+var ErrExchangeDegraded = errors.New("exchange is degraded, the authenticated API is down")
+
+// ErrCancelQueued is returned by a DegradableExchange when a cancel can't be sent because the
+// authenticated API of the exchange is down, the cancel is retried by Probe until it goes through.
+var ErrCancelQueued = errors.New("exchange is degraded, cancel queued for retry")
+
+// DegradedStatus is the health of the authenticated API of an exchange
+type DegradedStatus struct {
+	Exchange string `json:"exchange"`
+	// Set while new orders are blocked & cancels are queued
+	Degraded bool `json:"degraded"`
+	// When the exchange was degraded, zero if it isn't
+	Since               time.Time `json:"since,omitempty"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	LastError           string    `json:"lastError,omitempty"`
+	// IDs of the orders waiting to be cancelled
+	QueuedCancels []string `json:"queuedCancels,omitempty"`
+}
+
+type cancelIntent struct {
+	orderID      string
+	currencyPair pair.CurrencyPair
+}
+
+// DegradableExchange wraps an exchange and switches it to a degraded state when its authenticated
+// API keeps failing while the public API may still work, as is common during exchange incidents.
+// While degraded new orders are rejected without contacting the exchange, cancels are queued &
+// retried by Probe, and market data keeps flowing. The exchange recovers as soon as an
+// authenticated request succeeds.
+//
+// Only the failures classified as retryable or backoff (see ClassifyError) count towards the
+// threshold, requests rejected for other reasons (e.g. insufficient funds) show the API is up.
+type DegradableExchange struct {
+	Decorator
+	threshold int
+	// Called when the exchange is degraded or recovers, outside of the wrapper lock
+	OnChange func(DegradedStatus)
+
+	mtx      sync.Mutex
+	failures int
+	since    time.Time
+	lastErr  string
+	cancels  []cancelIntent
+	now      func() time.Time
+}
+
+// NewDegradableExchange returns a wrapper that degrades the exchange after threshold consecutive
+// failures of its authenticated API.
+func NewDegradableExchange(exch IBotExchangeEx, threshold int) *DegradableExchange {
+	return &DegradableExchange{Decorator: Decorator{IBotExchangeEx: exch}, threshold: threshold, now: time.Now}
+}
+
+// Degraded returns true while the authenticated API of the exchange is considered down.
+func (d *DegradableExchange) Degraded() bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return !d.since.IsZero()
+}
+
+// Status returns the health of the authenticated API of the exchange.
+func (d *DegradableExchange) Status() DegradedStatus {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return d.status()
+}
+
+func (d *DegradableExchange) status() DegradedStatus {
+	s := DegradedStatus{
+		Exchange:            d.GetName(),
+		Degraded:            !d.since.IsZero(),
+		Since:               d.since,
+		ConsecutiveFailures: d.failures,
+		LastError:           d.lastErr,
+	}
+	for _, c := range d.cancels {
+		s.QueuedCancels = append(s.QueuedCancels, c.orderID)
+	}
+	return s
+}
+
+func (d *DegradableExchange) isOutage(err error) bool {
+	class := ClassifyError(d.GetName(), err)
+	return class == ErrorClassRetryable || class == ErrorClassBackoff
+}
+
+// record updates the health of the API with the result of an authenticated request, returns true
+// if the request failed due to an outage.
+func (d *DegradableExchange) record(err error) bool {
+	outage := err != nil && d.isOutage(err)
+	d.mtx.Lock()
+	wasDegraded := !d.since.IsZero()
+	if outage {
+		d.failures++
+		d.lastErr = err.Error()
+		if !wasDegraded && d.failures >= d.threshold {
+			d.since = d.now()
+		}
+	} else {
+		d.failures = 0
+		d.since = time.Time{}
+	}
+	changed := wasDegraded != !d.since.IsZero()
+	status := d.status()
+	d.mtx.Unlock()
+
+	if changed && d.OnChange != nil {
+		d.OnChange(status)
+	}
+	return outage
+}
+
+// recordContext is record for a request bound to ctx, requests that failed because ctx is done
+// say nothing about the health of the API so they aren't recorded.
+func (d *DegradableExchange) recordContext(ctx context.Context, err error) bool {
+	if err != nil && ctx.Err() != nil {
+		return false
+	}
+	return d.record(err)
+}
+
+func (d *DegradableExchange) queueCancel(orderID string, currencyPair pair.CurrencyPair) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	for _, c := range d.cancels {
+		if c.orderID == orderID {
+			return
+		}
+	}
+	d.cancels = append(d.cancels, cancelIntent{orderID, currencyPair})
+}
+
+// NewOrder submits a new order to the exchange, or returns ErrExchangeDegraded without contacting
+// the exchange while it's degraded.
+func (d *DegradableExchange) NewOrder(symbol pair.CurrencyPair, amount, price float64, side OrderSide,
+	orderType OrderType, opts ...OrderOptions) (string, error) {
+	return d.NewOrderContext(context.Background(), symbol, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (d *DegradableExchange) NewOrderContext(ctx context.Context, symbol pair.CurrencyPair, amount,
+	price float64, side OrderSide, orderType OrderType, opts ...OrderOptions) (string, error) {
+	if d.Degraded() {
+		return "", ErrExchangeDegraded
+	}
+	orderID, err := WithContext(d.IBotExchangeEx).NewOrderContext(ctx, symbol, amount, price, side,
+		orderType, opts...)
+	d.recordContext(ctx, err)
+	return orderID, err
+}
+
+// CancelOrder cancels an active order on the exchange. While the exchange is degraded (including
+// when the cancel fails and degrades it) the cancel is queued and ErrCancelQueued is returned.
+func (d *DegradableExchange) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return d.CancelOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (d *DegradableExchange) CancelOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) error {
+	if d.Degraded() {
+		d.queueCancel(orderID, currencyPair)
+		return ErrCancelQueued
+	}
+	err := WithContext(d.IBotExchangeEx).CancelOrderContext(ctx, orderID, currencyPair)
+	if d.recordContext(ctx, err) && d.Degraded() {
+		d.queueCancel(orderID, currencyPair)
+		return ErrCancelQueued
+	}
+	return err
+}
+
+// GetOrder returns information about a previously placed order, the request is sent even while
+// the exchange is degraded so it can recover.
+func (d *DegradableExchange) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*Order, error) {
+	return d.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (d *DegradableExchange) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*Order, error) {
+	order, err := WithContext(d.IBotExchangeEx).GetOrderContext(ctx, orderID, currencyPair)
+	d.recordContext(ctx, err)
+	return order, err
+}
+
+// GetOrders returns information about currently active orders, the request is sent even while the
+// exchange is degraded so it can recover.
+func (d *DegradableExchange) GetOrders(pairs []pair.CurrencyPair) ([]*Order, error) {
+	return d.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (d *DegradableExchange) GetOrdersContext(ctx context.Context, pairs []pair.CurrencyPair) ([]*Order, error) {
+	orders, err := WithContext(d.IBotExchangeEx).GetOrdersContext(ctx, pairs)
+	d.recordContext(ctx, err)
+	return orders, err
+}
+
+// GetExchangeAccountInfo returns the account balances, the request is sent even while the
+// exchange is degraded so it can recover.
+func (d *DegradableExchange) GetExchangeAccountInfo() (AccountInfo, error) {
+	return d.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (d *DegradableExchange) GetExchangeAccountInfoContext(ctx context.Context) (AccountInfo, error) {
+	info, err := WithContext(d.IBotExchangeEx).GetExchangeAccountInfoContext(ctx)
+	d.recordContext(ctx, err)
+	return info, err
+}
+
+// Probe checks whether the authenticated API of a degraded exchange has recovered by retrying the
+// queued cancels, or fetching the account balances if there are none. Queued cancels rejected for
+// reasons other than the outage (e.g. the order has been filled) are dropped. Returns the number of
+// queued cancels that went through, probing stops at the first failure caused by the outage.
+func (d *DegradableExchange) Probe() (int, error) {
+	d.mtx.Lock()
+	degraded := !d.since.IsZero()
+	cancels := append([]cancelIntent(nil), d.cancels...)
+	d.mtx.Unlock()
+	if !degraded && len(cancels) == 0 {
+		return 0, nil
+	}
+
+	if len(cancels) == 0 {
+		_, err := d.GetExchangeAccountInfo()
+		return 0, err
+	}
+	sent := 0
+	for _, c := range cancels {
+		err := d.IBotExchangeEx.CancelOrder(c.orderID, c.currencyPair)
+		if d.record(err) {
+			return sent, err
+		}
+		d.mtx.Lock()
+		for i := range d.cancels {
+			if d.cancels[i].orderID == c.orderID {
+				d.cancels = append(d.cancels[:i], d.cancels[i+1:]...)
+				break
+			}
+		}
+		d.mtx.Unlock()
+		if err == nil {
+			sent++
+		}
+	}
+	return sent, nil
+}
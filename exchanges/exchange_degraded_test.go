@@ -0,0 +1,116 @@
+package exchange
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+type mockDegradedExchange struct {
+	mockExchange
+	err       error
+	cancelled []string
+}
+
+func (m *mockDegradedExchange) NewOrder(symbol pair.CurrencyPair, amount, price float64, side OrderSide,
+	orderType OrderType, opts ...OrderOptions) (string, error) {
+	m.orders++
+	return "1", m.err
+}
+
+func (m *mockDegradedExchange) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	if m.err == nil {
+		m.cancelled = append(m.cancelled, orderID)
+	}
+	return m.err
+}
+
+func (m *mockDegradedExchange) GetExchangeAccountInfo() (AccountInfo, error) {
+	return AccountInfo{}, m.err
+}
+
+func TestDegradableExchange(t *testing.T) {
+	mock := &mockDegradedExchange{}
+	exch := NewDegradableExchange(mock, 2)
+	var changes []DegradedStatus
+	exch.OnChange = func(s DegradedStatus) { changes = append(changes, s) }
+	p := pair.NewCurrencyPair("BTC", "USD")
+
+	// Rejected orders don't count as outages
+	mock.err = NewExchangeError("Mock", "order", http.StatusBadRequest, 0, "insufficient funds", "")
+	for i := 0; i < 3; i++ {
+		exch.NewOrder(p, 1, 1, OrderSideBuy, OrderTypeExchangeLimit)
+	}
+	if exch.Degraded() {
+		t.Fatal("Test failed. Expected rejected orders not to degrade the exchange")
+	}
+
+	mock.err = NewExchangeError("Mock", "order", http.StatusServiceUnavailable, 0, "unavailable", "")
+	exch.NewOrder(p, 1, 1, OrderSideBuy, OrderTypeExchangeLimit)
+	if exch.Degraded() {
+		t.Fatal("Test failed. Expected the exchange to be degraded only after 2 failures")
+	}
+	if err := exch.CancelOrder("10", p); err != ErrCancelQueued {
+		t.Fatalf("Test failed. Expected the cancel degrading the exchange to be queued, got %v", err)
+	}
+	if !exch.Degraded() || len(changes) != 1 || !changes[0].Degraded {
+		t.Fatalf("Test failed. Expected the exchange to be degraded, got %+v", changes)
+	}
+
+	orders := mock.orders
+	if _, err := exch.NewOrder(p, 1, 1, OrderSideBuy, OrderTypeExchangeLimit); err != ErrExchangeDegraded {
+		t.Errorf("Test failed. Expected new orders to be blocked, got %v", err)
+	}
+	if mock.orders != orders {
+		t.Error("Test failed. Expected blocked orders not to reach the exchange")
+	}
+	exch.CancelOrder("11", p)
+	exch.CancelOrder("10", p)
+	if s := exch.Status(); len(s.QueuedCancels) != 2 || s.QueuedCancels[0] != "10" ||
+		s.QueuedCancels[1] != "11" || s.ConsecutiveFailures != 2 || s.Since.IsZero() {
+		t.Errorf("Test failed. Unexpected status %+v", s)
+	}
+
+	if n, err := exch.Probe(); n != 0 || err == nil {
+		t.Errorf("Test failed. Expected the probe to fail while the API is down, got %d %v", n, err)
+	}
+	if len(exch.Status().QueuedCancels) != 2 {
+		t.Error("Test failed. Expected the cancels to stay queued")
+	}
+
+	mock.err = nil
+	if n, err := exch.Probe(); n != 2 || err != nil {
+		t.Errorf("Test failed. Expected the queued cancels to be sent, got %d %v", n, err)
+	}
+	if len(mock.cancelled) != 2 || mock.cancelled[0] != "10" || mock.cancelled[1] != "11" {
+		t.Errorf("Test failed. Unexpected cancelled orders %v", mock.cancelled)
+	}
+	if s := exch.Status(); s.Degraded || len(s.QueuedCancels) != 0 || s.ConsecutiveFailures != 0 {
+		t.Errorf("Test failed. Expected the exchange to recover, got %+v", s)
+	}
+	if len(changes) != 2 || changes[1].Degraded {
+		t.Errorf("Test failed. Expected a single recovery change, got %+v", changes)
+	}
+	if _, err := exch.NewOrder(p, 1, 1, OrderSideBuy, OrderTypeExchangeLimit); err != nil {
+		t.Errorf("Test failed. Expected new orders to go through, got %v", err)
+	}
+}
+
+func TestDegradableExchangeProbe(t *testing.T) {
+	mock := &mockDegradedExchange{}
+	exch := NewDegradableExchange(mock, 1)
+	if n, err := exch.Probe(); n != 0 || err != nil {
+		t.Errorf("Test failed. Expected no probe while healthy, got %d %v", n, err)
+	}
+
+	mock.err = NewExchangeError("Mock", "balances", http.StatusBadGateway, 0, "bad gateway", "")
+	exch.GetExchangeAccountInfo()
+	if !exch.Degraded() {
+		t.Fatal("Test failed. Expected the exchange to be degraded")
+	}
+	mock.err = nil
+	if _, err := exch.Probe(); err != nil || exch.Degraded() {
+		t.Errorf("Test failed. Expected the balances probe to recover the exchange, got %v", err)
+	}
+}
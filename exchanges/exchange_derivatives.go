@@ -0,0 +1,28 @@
+package exchange
+
+import (
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+)
+
+// Option types
+const (
+	OptionTypeCall = "call"
+	OptionTypePut  = "put"
+)
+
+// DerivativesExchange is implemented by exchanges that list several derivative contracts for the
+// same currency pair, e.g. futures with different expiries & options with different strikes.
+// GetCurrencyPairs only returns the contract traded by NewOrder for each currency pair (usually
+// the perpetual swap), the other contracts are identified by their symbol.
+type DerivativesExchange interface {
+	// GetContracts returns the contracts of an asset type keyed by symbol, an empty asset type
+	// returns the contracts of all the asset types.
+	GetContracts(assetType string) map[pair.CurrencyItem]*CurrencyPairInfo
+	// GetContractOrderbook fetches the orderbook of a contract, the amounts are in contracts.
+	GetContractOrderbook(symbol string) (orderbook.Base, error)
+	// NewContractOrder places an order for a contract, the amount is in contracts.
+	// Returns the ID of the new exchange order.
+	NewContractOrder(symbol string, amount, price float64, side OrderSide, orderType OrderType,
+		opts ...OrderOptions) (string, error)
+}
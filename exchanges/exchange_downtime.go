@@ -0,0 +1,184 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+// SimulatedDowntimeMessage is the error message returned by exchanges marked as down by a
+// DowntimeSimulator.
+const SimulatedDowntimeMessage = "exchange is under maintenance (simulated downtime)"
+
+// DowntimeSimulator wraps an exchange so that it can be marked as down at runtime, while the
+// exchange is down all API calls made through the wrapper fail with a maintenance error. This is
+// intended for testing failover between exchanges before a real outage happens.
+type DowntimeSimulator struct {
+	Decorator
+	down int32
+}
+
+// NewDowntimeSimulator returns a wrapper that can simulate downtime of the given exchange.
+func NewDowntimeSimulator(exch IBotExchangeEx) *DowntimeSimulator {
+	return &DowntimeSimulator{Decorator: Decorator{IBotExchangeEx: exch}}
+}
+
+// SetDown marks the exchange as down (or up again).
+func (d *DowntimeSimulator) SetDown(down bool) {
+	var v int32
+	if down {
+		v = 1
+	}
+	atomic.StoreInt32(&d.down, v)
+}
+
+// IsDown returns true if the exchange is currently marked as down.
+func (d *DowntimeSimulator) IsDown() bool {
+	return atomic.LoadInt32(&d.down) == 1
+}
+
+func (d *DowntimeSimulator) downErr(endpoint string) error {
+	if !d.IsDown() {
+		return nil
+	}
+	return NewExchangeError(d.GetName(), endpoint, http.StatusServiceUnavailable, 0,
+		SimulatedDowntimeMessage, "")
+}
+
+// GetTickerPrice returns the ticker for a currency pair, or a maintenance error if the exchange
+// is down.
+func (d *DowntimeSimulator) GetTickerPrice(currencyPair pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	if err := d.downErr("GetTickerPrice"); err != nil {
+		return ticker.Price{}, err
+	}
+	return d.IBotExchangeEx.GetTickerPrice(currencyPair, assetType)
+}
+
+// UpdateTicker updates and returns the ticker for a currency pair, or a maintenance error if the
+// exchange is down.
+func (d *DowntimeSimulator) UpdateTicker(currencyPair pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return d.UpdateTickerContext(context.Background(), currencyPair, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (d *DowntimeSimulator) UpdateTickerContext(ctx context.Context,
+	currencyPair pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	if err := d.downErr("UpdateTicker"); err != nil {
+		return ticker.Price{}, err
+	}
+	return WithContext(d.IBotExchangeEx).UpdateTickerContext(ctx, currencyPair, assetType)
+}
+
+// GetOrderbookEx returns the orderbook for a currency pair, or a maintenance error if the
+// exchange is down.
+func (d *DowntimeSimulator) GetOrderbookEx(currencyPair pair.CurrencyPair, assetType string,
+	opts ...OrderbookOptions) (orderbook.Base, error) {
+	if err := d.downErr("GetOrderbookEx"); err != nil {
+		return orderbook.Base{}, err
+	}
+	return d.IBotExchangeEx.GetOrderbookEx(currencyPair, assetType, opts...)
+}
+
+// GetOrderbookSimple returns the orderbook for a currency pair, or a maintenance error if the
+// exchange is down.
+func (d *DowntimeSimulator) GetOrderbookSimple(currencyPair pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	if err := d.downErr("GetOrderbookSimple"); err != nil {
+		return orderbook.Base{}, err
+	}
+	return d.IBotExchangeEx.GetOrderbookSimple(currencyPair, assetType)
+}
+
+// UpdateOrderbook updates and returns the orderbook for a currency pair, or a maintenance error
+// if the exchange is down.
+func (d *DowntimeSimulator) UpdateOrderbook(currencyPair pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return d.UpdateOrderbookContext(context.Background(), currencyPair, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (d *DowntimeSimulator) UpdateOrderbookContext(ctx context.Context,
+	currencyPair pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	if err := d.downErr("UpdateOrderbook"); err != nil {
+		return orderbook.Base{}, err
+	}
+	return WithContext(d.IBotExchangeEx).UpdateOrderbookContext(ctx, currencyPair, assetType)
+}
+
+// GetExchangeAccountInfo returns the account balances, or a maintenance error if the exchange
+// is down.
+func (d *DowntimeSimulator) GetExchangeAccountInfo() (AccountInfo, error) {
+	return d.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (d *DowntimeSimulator) GetExchangeAccountInfoContext(ctx context.Context) (AccountInfo, error) {
+	if err := d.downErr("GetExchangeAccountInfo"); err != nil {
+		return AccountInfo{ExchangeName: d.GetName()}, err
+	}
+	return WithContext(d.IBotExchangeEx).GetExchangeAccountInfoContext(ctx)
+}
+
+// NewOrder creates a new order on the exchange, or returns a maintenance error if the exchange
+// is down.
+func (d *DowntimeSimulator) NewOrder(symbol pair.CurrencyPair, amount, price float64, side OrderSide,
+	orderType OrderType, opts ...OrderOptions) (string, error) {
+	return d.NewOrderContext(context.Background(), symbol, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (d *DowntimeSimulator) NewOrderContext(ctx context.Context, symbol pair.CurrencyPair, amount,
+	price float64, side OrderSide, orderType OrderType, opts ...OrderOptions) (string, error) {
+	if err := d.downErr("NewOrder"); err != nil {
+		return "", err
+	}
+	return WithContext(d.IBotExchangeEx).NewOrderContext(ctx, symbol, amount, price, side, orderType, opts...)
+}
+
+// CancelOrder cancels an active order on the exchange, or returns a maintenance error if the
+// exchange is down.
+func (d *DowntimeSimulator) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return d.CancelOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (d *DowntimeSimulator) CancelOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) error {
+	if err := d.downErr("CancelOrder"); err != nil {
+		return err
+	}
+	return WithContext(d.IBotExchangeEx).CancelOrderContext(ctx, orderID, currencyPair)
+}
+
+// GetOrder returns information about a previously placed order, or a maintenance error if the
+// exchange is down.
+func (d *DowntimeSimulator) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*Order, error) {
+	return d.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (d *DowntimeSimulator) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*Order, error) {
+	if err := d.downErr("GetOrder"); err != nil {
+		return nil, err
+	}
+	return WithContext(d.IBotExchangeEx).GetOrderContext(ctx, orderID, currencyPair)
+}
+
+// GetOrders returns information about currently active orders, or a maintenance error if the
+// exchange is down.
+func (d *DowntimeSimulator) GetOrders(pairs []pair.CurrencyPair) ([]*Order, error) {
+	return d.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (d *DowntimeSimulator) GetOrdersContext(ctx context.Context,
+	pairs []pair.CurrencyPair) ([]*Order, error) {
+	if err := d.downErr("GetOrders"); err != nil {
+		return nil, err
+	}
+	return WithContext(d.IBotExchangeEx).GetOrdersContext(ctx, pairs)
+}
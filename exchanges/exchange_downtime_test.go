@@ -0,0 +1,54 @@
+package exchange
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+type mockExchange struct {
+	IBotExchangeEx
+	orders int
+}
+
+func (m *mockExchange) GetName() string {
+	return "Mock"
+}
+
+func (m *mockExchange) NewOrder(symbol pair.CurrencyPair, amount, price float64, side OrderSide,
+	orderType OrderType, opts ...OrderOptions) (string, error) {
+	m.orders++
+	return "1", nil
+}
+
+func TestDowntimeSimulator(t *testing.T) {
+	mock := &mockExchange{}
+	simulator := NewDowntimeSimulator(mock)
+	p := pair.NewCurrencyPair("BTC", "USD")
+
+	if _, err := simulator.NewOrder(p, 1, 1, OrderSideBuy, OrderTypeExchangeLimit); err != nil {
+		t.Fatalf("Test failed. NewOrder returned an error while the exchange was up: %s", err)
+	}
+
+	simulator.SetDown(true)
+	if !simulator.IsDown() {
+		t.Error("Test failed. Expected exchange to be down")
+	}
+	_, err := simulator.NewOrder(p, 1, 1, OrderSideBuy, OrderTypeExchangeLimit)
+	exchErr, ok := err.(*ExchangeError)
+	if !ok || exchErr.StatusCode != http.StatusServiceUnavailable || exchErr.Message != SimulatedDowntimeMessage {
+		t.Errorf("Test failed. Expected a maintenance error but got %v", err)
+	}
+	if _, err = simulator.GetOrders(nil); err == nil {
+		t.Error("Test failed. Expected GetOrders to fail while the exchange is down")
+	}
+	if mock.orders != 1 {
+		t.Errorf("Test failed. Expected 1 order to reach the exchange but got %d", mock.orders)
+	}
+
+	simulator.SetDown(false)
+	if _, err = simulator.NewOrder(p, 1, 1, OrderSideBuy, OrderTypeExchangeLimit); err != nil {
+		t.Errorf("Test failed. NewOrder returned an error after the exchange came back up: %s", err)
+	}
+}
@@ -0,0 +1,164 @@
+package exchange
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Error kinds, the errors returned by the exchanges can be mapped onto them with ErrorKind so
+// callers can branch on the kind of error instead of matching the exchange specific messages.
+var (
+	ErrInsufficientFunds   = errors.New("insufficient funds for order")
+	ErrOrderNotFound       = errors.New("exchange order not found")
+	ErrInvalidAPIKey       = errors.New("invalid API key")
+	ErrRateLimited         = errors.New("rate limited by the exchange")
+	ErrExchangeMaintenance = errors.New("exchange is under maintenance")
+	ErrMinTradeSize        = errors.New("order is below the minimum trade size")
+	// The request timestamp or nonce was rejected, see ClockSyncer
+	ErrInvalidNonce = errors.New("request timestamp or nonce rejected")
+	// The exchange failed to process the request, it may have been processed anyway
+	ErrExchangeInternal = errors.New("exchange internal error")
+)
+
+var errorKinds = []error{
+	ErrInsufficientFunds, ErrOrderNotFound, ErrInvalidAPIKey, ErrRateLimited, ErrExchangeMaintenance,
+	ErrMinTradeSize, ErrInvalidNonce, ErrExchangeInternal,
+}
+
+// ErrorKindRule maps the errors of an exchange that match either the exchange specific error
+// code, or contain the message, onto an error kind.
+type ErrorKindRule struct {
+	Code    int
+	Message string
+	Kind    error
+}
+
+func (r *ErrorKindRule) matches(code int, message string) bool {
+	if r.Code != 0 {
+		return r.Code == code
+	}
+	return r.Message != "" && strings.Contains(message, r.Message)
+}
+
+var (
+	errorKindRulesMtx sync.RWMutex
+	errorKindRules    = map[string][]ErrorKindRule{
+		"Binance": {
+			{Code: -1001, Kind: ErrExchangeInternal},    // internal error, unable to process the request
+			{Code: -1003, Kind: ErrRateLimited},         // too many requests
+			{Code: -1015, Kind: ErrRateLimited},         // too many new orders
+			{Code: -1016, Kind: ErrExchangeMaintenance}, // service shutting down
+			{Code: -1021, Kind: ErrInvalidNonce},        // timestamp outside of the recvWindow
+			{Code: -1022, Kind: ErrInvalidAPIKey},       // invalid signature
+			{Code: -2013, Kind: ErrOrderNotFound},       // order does not exist
+			{Code: -2014, Kind: ErrInvalidAPIKey},       // invalid API key format
+			{Code: -2015, Kind: ErrInvalidAPIKey},       // invalid API key, IP, or permissions
+			// -1013 & -2010 are used for other filter failures & rejections too
+			{Message: "MIN_NOTIONAL", Kind: ErrMinTradeSize},
+			{Message: "LOT_SIZE", Kind: ErrMinTradeSize},
+			{Message: "insufficient balance", Kind: ErrInsufficientFunds},
+			{Message: "Unknown order sent", Kind: ErrOrderNotFound},
+		},
+		"Bitfinex": {
+			{Code: 10100, Kind: ErrInvalidAPIKey},       // authentication failure
+			{Code: 10114, Kind: ErrInvalidNonce},        // nonce too small
+			{Code: 11010, Kind: ErrRateLimited},         // rate limit
+			{Code: 20060, Kind: ErrExchangeMaintenance}, // maintenance
+			{Message: "ERR_RATE_LIMIT", Kind: ErrRateLimited},
+			{Message: "Nonce is too small", Kind: ErrInvalidNonce},
+			{Message: "Could not find a key matching the given X-BFX-APIKEY", Kind: ErrInvalidAPIKey},
+			{Message: "Invalid order: not enough", Kind: ErrInsufficientFunds},
+			{Message: "Invalid order: minimum size", Kind: ErrMinTradeSize},
+			{Message: "No such order found", Kind: ErrOrderNotFound},
+		},
+		"Bittrex": {
+			{Message: "APIKEY_INVALID", Kind: ErrInvalidAPIKey},
+			{Message: "INVALID_SIGNATURE", Kind: ErrInvalidAPIKey},
+			{Message: "INSUFFICIENT_FUNDS", Kind: ErrInsufficientFunds},
+			{Message: "MIN_TRADE_REQUIREMENT_NOT_MET", Kind: ErrMinTradeSize},
+			{Message: "DUST_TRADE_DISALLOWED", Kind: ErrMinTradeSize},
+			{Message: "ORDER_NOT_OPEN", Kind: ErrOrderNotFound},
+			{Message: "INVALID_ORDER", Kind: ErrOrderNotFound},
+		},
+		"Kraken": {
+			{Message: "EAPI:Invalid key", Kind: ErrInvalidAPIKey},
+			{Message: "EAPI:Invalid signature", Kind: ErrInvalidAPIKey},
+			{Message: "EAPI:Invalid nonce", Kind: ErrInvalidNonce},
+			{Message: "EAPI:Rate limit exceeded", Kind: ErrRateLimited},
+			{Message: "EOrder:Rate limit exceeded", Kind: ErrRateLimited},
+			{Message: "EGeneral:Temporary lockout", Kind: ErrRateLimited},
+			{Message: "EService:Busy", Kind: ErrRateLimited},
+			{Message: "EService:Unavailable", Kind: ErrExchangeMaintenance},
+			{Message: "EOrder:Insufficient funds", Kind: ErrInsufficientFunds},
+			{Message: "EOrder:Order minimum not met", Kind: ErrMinTradeSize},
+			{Message: "EOrder:Unknown order", Kind: ErrOrderNotFound},
+		},
+		"Poloniex": {
+			{Message: "Invalid API key/secret pair", Kind: ErrInvalidAPIKey},
+			{Message: "Nonce must be greater than", Kind: ErrInvalidNonce},
+			{Message: "Not enough", Kind: ErrInsufficientFunds},
+			{Message: "Total must be at least", Kind: ErrMinTradeSize},
+			{Message: "Amount must be at least", Kind: ErrMinTradeSize},
+			{Message: "Invalid order number", Kind: ErrOrderNotFound},
+		},
+	}
+)
+
+// RegisterErrorKinds adds rules to the error kind table of an exchange, rules added later take
+// precedence over the existing rules.
+func RegisterErrorKinds(exchangeName string, rules ...ErrorKindRule) {
+	errorKindRulesMtx.Lock()
+	defer errorKindRulesMtx.Unlock()
+	errorKindRules[exchangeName] = append(append([]ErrorKindRule(nil), rules...), errorKindRules[exchangeName]...)
+}
+
+// ErrorKind returns the kind of an error returned by an exchange (one of the Err* error kinds),
+// or nil if the kind isn't known. Errors are matched against the error kind table of the
+// exchange first, and then by HTTP status code (ExchangeError only). Rate limited warnings are
+// ErrRateLimited, use IsRateLimited to check if cached data was returned.
+func ErrorKind(exchangeName string, err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, kind := range errorKinds {
+		if err == kind {
+			return kind
+		}
+	}
+	if IsRateLimited(err) {
+		return ErrRateLimited
+	}
+
+	kind, statusCode := ruleKind(exchangeName, err)
+	if kind != nil {
+		return kind
+	}
+	switch statusCode {
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusUnauthorized:
+		return ErrInvalidAPIKey
+	}
+	return nil
+}
+
+// ruleKind returns the kind of the error from the error kind table of the exchange, along with
+// the HTTP status code of the error (ExchangeError only).
+func ruleKind(exchangeName string, err error) (kind error, statusCode int) {
+	code := 0
+	if exchErr, ok := err.(*ExchangeError); ok {
+		code, statusCode = exchErr.Code, exchErr.StatusCode
+	}
+	errorKindRulesMtx.RLock()
+	rules := errorKindRules[exchangeName]
+	errorKindRulesMtx.RUnlock()
+	message := err.Error()
+	for i := range rules {
+		if rules[i].matches(code, message) {
+			return rules[i].Kind, statusCode
+		}
+	}
+	return nil, statusCode
+}
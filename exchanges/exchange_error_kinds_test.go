@@ -0,0 +1,48 @@
+package exchange
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestErrorKind(t *testing.T) {
+	tests := []struct {
+		exchange string
+		err      error
+		kind     error
+	}{
+		{"Binance", NewExchangeError("Binance", "api/v3/order", 429, -1003, "Too many requests", ""), ErrRateLimited},
+		{"Binance", NewExchangeError("Binance", "api/v3/order", 400, -1021, "Timestamp outside recvWindow", ""), ErrInvalidNonce},
+		{"Binance", NewExchangeError("Binance", "api/v3/order", 400, -1013, "Filter failure: MIN_NOTIONAL", ""), ErrMinTradeSize},
+		{"Binance", NewExchangeError("Binance", "api/v3/order", 400, -2010, "Account has insufficient balance for requested action.", ""), ErrInsufficientFunds},
+		{"Bitfinex", NewExchangeError("Bitfinex", "auth/w/order/submit", 500, 10100, "apikey: invalid", ""), ErrInvalidAPIKey},
+		{"Bitfinex", NewExchangeError("Bitfinex", "auth/w/order/submit", 500, 20060, "maintenance", ""), ErrExchangeMaintenance},
+		{"Bitfinex", errors.New("ERR_RATE_LIMIT"), ErrRateLimited},
+		{"Bittrex", errors.New("APIKEY_INVALID"), ErrInvalidAPIKey},
+		{"Kraken", NewExchangeError("Kraken", "AddOrder", 200, 0, "EOrder:Insufficient funds", ""), ErrInsufficientFunds},
+		{"Other", ErrInsufficentFundsForOrder(), ErrInsufficientFunds},
+		{"Other", ErrOrderNotFound, ErrOrderNotFound},
+		{"Other", NewRateLimitedWarning("Other", "balances", time.Now()), ErrRateLimited},
+		{"Other", NewExchangeError("Other", "order", http.StatusUnauthorized, 0, "unauthorized", ""), ErrInvalidAPIKey},
+		{"Other", NewExchangeError("Other", "order", http.StatusBadRequest, 0, "APIKEY_INVALID", ""), nil},
+		{"Other", errors.New("unknown"), nil},
+		{"Other", nil, nil},
+	}
+	for _, test := range tests {
+		if kind := ErrorKind(test.exchange, test.err); kind != test.kind {
+			t.Errorf("Test failed. Expected %s error %q to be %v, got %v", test.exchange, test.err, test.kind, kind)
+		}
+	}
+
+	err := NewExchangeError("Bitfinex", "order/new", 400, 0, "Invalid order: minimum size for BTC/USD is 0.002", "")
+	if !err.Is(ErrMinTradeSize) || err.Is(ErrInsufficientFunds) {
+		t.Error("Test failed. Expected Is to match the kind of the exchange error")
+	}
+
+	RegisterErrorKinds("Other", ErrorKindRule{Message: "market closed", Kind: ErrExchangeMaintenance})
+	if kind := ErrorKind("Other", errors.New("market closed")); kind != ErrExchangeMaintenance {
+		t.Errorf("Test failed. Expected registered rule to apply, got %v", kind)
+	}
+}
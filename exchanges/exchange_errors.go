@@ -0,0 +1,44 @@
+package exchange
+
+import "fmt"
+
+// ExchangeError is returned by exchange API methods when an exchange rejects a request.
+// In addition to the normalized error message it preserves the raw response body and HTTP status
+// so the exact rejection can be inspected without enabling Verbose mode for the exchange.
+type ExchangeError struct {
+	Exchange   string // Name of the exchange that returned the error
+	Endpoint   string // API endpoint (path) the request was sent to
+	StatusCode int    // HTTP status code of the response, 0 if not known
+	Code       int    // Exchange specific error code, 0 if the exchange didn't supply one
+	Message    string // Error message extracted from the response
+	Raw        string // Raw response body
+}
+
+// Error returns the error message extracted from the exchange response.
+func (e *ExchangeError) Error() string {
+	return e.Message
+}
+
+// String returns a description of the error that includes all the captured response details.
+func (e *ExchangeError) String() string {
+	return fmt.Sprintf("%s %s error (HTTP status: %d, code: %d): %s\nRaw response: %s",
+		e.Exchange, e.Endpoint, e.StatusCode, e.Code, e.Message, e.Raw)
+}
+
+// Is reports whether the error is of the given kind (see ErrorKind), so errors.Is can be used to
+// check the kind of an ExchangeError.
+func (e *ExchangeError) Is(target error) bool {
+	return target != nil && ErrorKind(e.Exchange, e) == target
+}
+
+// NewExchangeError creates a new ExchangeError.
+func NewExchangeError(exchangeName, endpoint string, statusCode, code int, message, raw string) *ExchangeError {
+	return &ExchangeError{
+		Exchange:   exchangeName,
+		Endpoint:   endpoint,
+		StatusCode: statusCode,
+		Code:       code,
+		Message:    message,
+		Raw:        raw,
+	}
+}
@@ -0,0 +1,30 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExchangeError(t *testing.T) {
+	var err error = NewExchangeError("Bitfinex", "order/new", 400, 0,
+		"Invalid order: not enough exchange balance", `{"message":"Invalid order: not enough exchange balance"}`)
+
+	if err.Error() != "Invalid order: not enough exchange balance" {
+		t.Errorf("Test Failed - ExchangeError.Error() unexpected message: %s", err.Error())
+	}
+
+	exchErr, ok := err.(*ExchangeError)
+	if !ok {
+		t.Fatal("Test Failed - NewExchangeError() didn't return an *ExchangeError")
+	}
+	if exchErr.StatusCode != 400 || exchErr.Endpoint != "order/new" || exchErr.Exchange != "Bitfinex" {
+		t.Errorf("Test Failed - ExchangeError fields not set correctly: %#v", exchErr)
+	}
+	if exchErr.Raw != `{"message":"Invalid order: not enough exchange balance"}` {
+		t.Errorf("Test Failed - ExchangeError.Raw not set correctly: %s", exchErr.Raw)
+	}
+
+	if _, ok := errors.New("test").(*ExchangeError); ok {
+		t.Error("Test Failed - plain error shouldn't be an *ExchangeError")
+	}
+}
@@ -0,0 +1,48 @@
+package exchange
+
+import "time"
+
+// Kinds of ledger entries
+const (
+	// Funds received from outside the exchange
+	LedgerDeposit = "deposit"
+	// Funds sent out of the exchange
+	LedgerWithdrawal = "withdrawal"
+	// Funds moved between the wallets of the account
+	LedgerTransfer = "transfer"
+	// Fees that aren't charged on a trade or withdrawal, e.g. account or margin funding fees
+	LedgerFee = "fee"
+)
+
+// LedgerEntry is a non-trade movement of funds in the account, normalized across exchanges
+type LedgerEntry struct {
+	ID       string `json:"id"`
+	Exchange string `json:"exchange"`
+	Kind     string `json:"kind"`
+	Currency string `json:"currency"`
+	// Amount moved, always positive, the kind determines the direction
+	Amount float64 `json:"amount"`
+	Fee    float64 `json:"fee"`
+	// Counterparty of the movement, e.g. the destination address of a withdrawal or the wallets a
+	// transfer was made between
+	Details string `json:"details,omitempty"`
+	// Blockchain transaction of deposits & withdrawals
+	TxID string    `json:"txId,omitempty"`
+	Time time.Time `json:"time"`
+}
+
+// LedgerHistoryProvider is implemented by exchanges that can return the deposits, withdrawals &
+// other non-trade movements of funds of the account
+type LedgerHistoryProvider interface {
+	// GetLedgerEntries returns the movements made between start & end, sorted from oldest to
+	// newest.
+	GetLedgerEntries(start, end time.Time) ([]LedgerEntry, error)
+}
+
+// OrderHistoryProvider is implemented by exchanges that can return the closed orders of the
+// account, not just the active ones
+type OrderHistoryProvider interface {
+	// GetOrderHistory returns the orders (active or not) created between start & end, sorted from
+	// oldest to newest.
+	GetOrderHistory(start, end time.Time) ([]*Order, error)
+}
@@ -0,0 +1,29 @@
+package exchange
+
+import "time"
+
+// LendingRecord is a loan of funds to margin traders made by the account, normalized across
+// exchanges
+type LendingRecord struct {
+	ID       string  `json:"id"`
+	Exchange string  `json:"exchange"`
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
+	// Daily interest rate, as a fraction of the amount
+	Rate     float64 `json:"rate"`
+	Interest float64 `json:"interest"`
+	// Fee charged by the exchange on the interest
+	Fee float64 `json:"fee"`
+	// Interest minus fees
+	Earned float64   `json:"earned"`
+	Opened time.Time `json:"opened"`
+	// Zero if the loan is still open
+	Closed time.Time `json:"closed"`
+}
+
+// LendingHistoryProvider is implemented by exchanges that can return the loans made by the
+// account
+type LendingHistoryProvider interface {
+	// GetLendingRecords returns the loans that were closed between start & end
+	GetLendingRecords(start, end time.Time) ([]LendingRecord, error)
+}
@@ -1,6 +1,13 @@
 package exchange
 
-import "github.com/mattkanwisher/cryptofiend/currency/pair"
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
 
 // ILimits provides information about the limits placed by an exchange on numbers representing
 // order/trade price and amount.
@@ -43,3 +50,200 @@ func (l *DefaultExchangeLimits) GetMinAmount(p pair.CurrencyPair) float64 {
 func (l *DefaultExchangeLimits) GetMinTotal(p pair.CurrencyPair) float64 {
 	return 0
 }
+
+// RoundingMode controls which way a number is rounded to the decimal places an exchange allows.
+type RoundingMode string
+
+const (
+	// RoundDown rounds towards negative infinity.
+	RoundDown RoundingMode = "floor"
+	// RoundUp rounds towards positive infinity.
+	RoundUp RoundingMode = "ceil"
+	// RoundNearest rounds to the nearest decimal, halfway values are rounded away from zero.
+	RoundNearest RoundingMode = "nearest"
+	// RoundPassive only applies to prices, buy prices are rounded down and sell prices are
+	// rounded up, so the rounded price is never worse than the requested one.
+	RoundPassive RoundingMode = "passive"
+)
+
+// RoundingPolicy is the rounding mode of the order amounts & prices of an exchange.
+type RoundingPolicy struct {
+	Amount RoundingMode
+	Price  RoundingMode
+}
+
+// DefaultRoundingPolicy rounds amounts down, so an amount derived from a balance never exceeds
+// the balance, and rounds prices passively.
+var DefaultRoundingPolicy = RoundingPolicy{Amount: RoundDown, Price: RoundPassive}
+
+// ParseRoundingPolicy returns the rounding policy for the given amount & price rounding modes,
+// an empty mode keeps the default. Amounts can only be rounded down or to the nearest decimal.
+func ParseRoundingPolicy(amountMode, priceMode string) (RoundingPolicy, error) {
+	policy := DefaultRoundingPolicy
+	if amountMode != "" {
+		policy.Amount = RoundingMode(strings.ToLower(amountMode))
+	}
+	if priceMode != "" {
+		policy.Price = RoundingMode(strings.ToLower(priceMode))
+	}
+	switch policy.Amount {
+	case RoundDown, RoundNearest:
+	default:
+		return policy, fmt.Errorf("invalid amount rounding mode %q", amountMode)
+	}
+	switch policy.Price {
+	case RoundDown, RoundUp, RoundNearest, RoundPassive:
+	default:
+		return policy, fmt.Errorf("invalid price rounding mode %q", priceMode)
+	}
+	return policy, nil
+}
+
+// IRoundingLimits is implemented by exchange limits that override the default rounding policy.
+type IRoundingLimits interface {
+	ILimits
+	GetRoundingPolicy() RoundingPolicy
+}
+
+type roundingLimits struct {
+	ILimits
+	policy RoundingPolicy
+}
+
+func (l *roundingLimits) GetRoundingPolicy() RoundingPolicy {
+	return l.policy
+}
+
+// WithRoundingPolicy returns the limits with the rounding policy used by RoundPrice & RoundAmount
+// replaced.
+func WithRoundingPolicy(limits ILimits, policy RoundingPolicy) ILimits {
+	if l, ok := limits.(*roundingLimits); ok {
+		limits = l.ILimits
+	}
+	return &roundingLimits{ILimits: limits, policy: policy}
+}
+
+// GetRoundingPolicy returns the rounding policy of the exchange limits.
+func GetRoundingPolicy(limits ILimits) RoundingPolicy {
+	if l, ok := limits.(IRoundingLimits); ok {
+		return l.GetRoundingPolicy()
+	}
+	return DefaultRoundingPolicy
+}
+
+// RoundingExchange wraps an exchange and overrides the rounding policy of its limits.
+type RoundingExchange struct {
+	Decorator
+	policy RoundingPolicy
+}
+
+// NewRoundingExchange returns a wrapper that rounds the orders of the exchange with the policy.
+func NewRoundingExchange(exch IBotExchangeEx, policy RoundingPolicy) *RoundingExchange {
+	return &RoundingExchange{Decorator: Decorator{IBotExchangeEx: exch}, policy: policy}
+}
+
+// GetLimits returns the limits of the exchange with the rounding policy of the wrapper.
+func (r *RoundingExchange) GetLimits() ILimits {
+	return WithRoundingPolicy(r.IBotExchangeEx.GetLimits(), r.policy)
+}
+
+// RoundPrice rounds the price to the number of decimal places the exchange allows for the currency
+// pair, with the price rounding mode of the limits. By default buy prices are rounded down and
+// sell prices are rounded up, so the rounded price is never worse than the requested one.
+func RoundPrice(limits ILimits, p pair.CurrencyPair, price float64, side OrderSide) float64 {
+	return roundMode(price, limits.GetPriceDecimalPlaces(p), GetRoundingPolicy(limits).Price, side)
+}
+
+// RoundAmount rounds the amount to the number of decimal places the exchange allows for the
+// currency pair, with the amount rounding mode of the limits. By default the amount is rounded
+// down, so the rounded amount never exceeds the requested one.
+func RoundAmount(limits ILimits, p pair.CurrencyPair, amount float64) float64 {
+	return roundMode(amount, limits.GetAmountDecimalPlaces(p), GetRoundingPolicy(limits).Amount, "")
+}
+
+// RoundAmountWithin rounds the amount like RoundAmount, but rounds it down instead if the rounded
+// amount would exceed max (e.g. the available balance).
+func RoundAmountWithin(limits ILimits, p pair.CurrencyPair, amount, max float64) float64 {
+	if rounded := RoundAmount(limits, p, amount); rounded <= max {
+		return rounded
+	}
+	return floorDecimal(math.Min(amount, max), limits.GetAmountDecimalPlaces(p))
+}
+
+// CheckOrderLimits returns an error if the order amount or total (amount * price) is below the
+// minimums of the exchange for the currency pair. The amount & price should be rounded first.
+func CheckOrderLimits(limits ILimits, p pair.CurrencyPair, amount, price float64) error {
+	if minAmount := limits.GetMinAmount(p); amount < minAmount {
+		return fmt.Errorf("order amount %v is below the minimum of %v for %s", amount, minAmount, p.Pair())
+	}
+	if minTotal := limits.GetMinTotal(p); amount*price < minTotal {
+		return fmt.Errorf("order total %v is below the minimum of %v for %s", amount*price, minTotal, p.Pair())
+	}
+	return nil
+}
+
+// floorDecimal truncates x to the given number of decimal places, a negative number of places
+// leaves x unchanged. The shortest decimal representation of x is truncated rather than x itself,
+// otherwise values like 0.3 (which is actually 0.29999...) would be rounded down too far.
+func floorDecimal(x float64, places int32) float64 {
+	if places < 0 || math.IsInf(x, 0) || math.IsNaN(x) {
+		return x
+	}
+	s := strconv.FormatFloat(x, 'f', -1, 64)
+	dot := strings.IndexByte(s, '.')
+	if dot < 0 || len(s)-dot-1 <= int(places) {
+		return x
+	}
+	if places == 0 {
+		s = s[:dot]
+	} else {
+		s = s[:dot+1+int(places)]
+	}
+	result, _ := strconv.ParseFloat(s, 64)
+	if x < 0 {
+		// Truncating a negative number rounds it up, so step down to the next decimal
+		stepped := result - math.Pow(10, -float64(places))
+		result, _ = strconv.ParseFloat(strconv.FormatFloat(stepped, 'f', int(places), 64), 64)
+	}
+	return result
+}
+
+// roundMode rounds x to the given number of decimal places with the rounding mode, the side is
+// only used by RoundPassive. Unknown modes round down.
+func roundMode(x float64, places int32, mode RoundingMode, side OrderSide) float64 {
+	switch mode {
+	case RoundUp:
+		return ceilDecimal(x, places)
+	case RoundNearest:
+		return nearestDecimal(x, places)
+	case RoundPassive:
+		if side == OrderSideSell {
+			return ceilDecimal(x, places)
+		}
+	}
+	return floorDecimal(x, places)
+}
+
+// nearestDecimal rounds x to the nearest value with the given number of decimal places, halfway
+// values are rounded away from zero. Like floorDecimal the shortest decimal representation of x
+// is rounded, so 1.005 is rounded to 1.01.
+func nearestDecimal(x float64, places int32) float64 {
+	if places < 0 || math.IsInf(x, 0) || math.IsNaN(x) {
+		return x
+	}
+	s := strconv.FormatFloat(math.Abs(x), 'f', -1, 64)
+	dot := strings.IndexByte(s, '.')
+	if dot < 0 || len(s)-dot-1 <= int(places) {
+		return x
+	}
+	if awayFromZero := s[dot+1+int(places)] >= '5'; awayFromZero == (x < 0) {
+		return floorDecimal(x, places)
+	}
+	return ceilDecimal(x, places)
+}
+
+// ceilDecimal rounds x up to the given number of decimal places, a negative number of places
+// leaves x unchanged.
+func ceilDecimal(x float64, places int32) float64 {
+	return -floorDecimal(-x, places)
+}
@@ -0,0 +1,238 @@
+package exchange
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+type testLimits struct {
+	name           string
+	priceDecimals  int32
+	amountDecimals int32
+	minAmount      float64
+	minTotal       float64
+}
+
+func (l *testLimits) GetPriceDecimalPlaces(p pair.CurrencyPair) int32  { return l.priceDecimals }
+func (l *testLimits) GetAmountDecimalPlaces(p pair.CurrencyPair) int32 { return l.amountDecimals }
+func (l *testLimits) GetMinAmount(p pair.CurrencyPair) float64         { return l.minAmount }
+func (l *testLimits) GetMinTotal(p pair.CurrencyPair) float64          { return l.minTotal }
+
+// Filters published by the exchanges for some of their most traded markets
+var exchangeLimits = []testLimits{
+	{"Binance BTCUSDT", 2, 6, 0.000001, 10},
+	{"Binance ETHBTC", 6, 3, 0.001, 0.001},
+	{"Binance TRXBTC", 8, 0, 1, 0.001},
+	{"Kraken XBT/USD", 1, 8, 0.002, 0},
+	{"Kraken XRP/EUR", 5, 8, 30, 0},
+	{"Bittrex BTC-LTC", 8, 8, 0.01, 0.0005},
+	{"Bitfinex BTCUSD", 5, 8, 0.002, 0},
+	{"Gemini btcusd", 2, 8, 0.00001, 0},
+}
+
+func countDecimalPlaces(x float64) int32 {
+	s := strconv.FormatFloat(x, 'f', -1, 64)
+	if dot := strings.IndexByte(s, '.'); dot >= 0 {
+		return int32(len(s) - dot - 1)
+	}
+	return 0
+}
+
+// randomValue returns a random value between 10^minExp and 10^maxExp, rounded to a random number
+// of decimal places so that values that are already rounded get tested too
+func randomValue(r *rand.Rand, minExp, maxExp float64) float64 {
+	v := math.Pow(10, minExp+r.Float64()*(maxExp-minExp))
+	if r.Intn(2) == 0 {
+		v, _ = strconv.ParseFloat(strconv.FormatFloat(v, 'f', r.Intn(10), 64), 64)
+	}
+	return v
+}
+
+func TestRoundPriceProperties(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	p := pair.NewCurrencyPair("BTC", "USD")
+	for i := range exchangeLimits {
+		limits := &exchangeLimits[i]
+		tick := math.Pow(10, -float64(limits.priceDecimals))
+		for n := 0; n < 10000; n++ {
+			price := randomValue(r, -8, 5)
+			for _, side := range []OrderSide{OrderSideBuy, OrderSideSell} {
+				rounded := RoundPrice(limits, p, price, side)
+				if places := countDecimalPlaces(rounded); places > limits.priceDecimals {
+					t.Fatalf("Test failed. %s %s price %v rounded to %v has %d decimal places",
+						limits.name, side, price, rounded, places)
+				}
+				diff := rounded - price
+				if side == OrderSideBuy {
+					diff = -diff
+				}
+				if diff < 0 || diff >= tick*(1+1e-9) {
+					t.Fatalf("Test failed. %s %s price %v rounded to %v", limits.name, side, price, rounded)
+				}
+				if again := RoundPrice(limits, p, rounded, side); again != rounded {
+					t.Fatalf("Test failed. %s %s price %v rounded to %v and then %v",
+						limits.name, side, price, rounded, again)
+				}
+			}
+		}
+	}
+}
+
+func TestRoundAmountProperties(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	p := pair.NewCurrencyPair("BTC", "USD")
+	for i := range exchangeLimits {
+		limits := &exchangeLimits[i]
+		step := math.Pow(10, -float64(limits.amountDecimals))
+		for n := 0; n < 10000; n++ {
+			amount := randomValue(r, -8, 6)
+			rounded := RoundAmount(limits, p, amount)
+			if places := countDecimalPlaces(rounded); places > limits.amountDecimals {
+				t.Fatalf("Test failed. %s amount %v rounded to %v has %d decimal places",
+					limits.name, amount, rounded, places)
+			}
+			if rounded > amount || amount-rounded >= step*(1+1e-9) {
+				t.Fatalf("Test failed. %s amount %v rounded to %v", limits.name, amount, rounded)
+			}
+			if again := RoundAmount(limits, p, rounded); again != rounded {
+				t.Fatalf("Test failed. %s amount %v rounded to %v and then %v", limits.name, amount, rounded, again)
+			}
+		}
+	}
+}
+
+func TestCheckOrderLimitsProperties(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	p := pair.NewCurrencyPair("BTC", "USD")
+	for i := range exchangeLimits {
+		limits := &exchangeLimits[i]
+		for n := 0; n < 10000; n++ {
+			side := OrderSideBuy
+			if r.Intn(2) == 0 {
+				side = OrderSideSell
+			}
+			price := RoundPrice(limits, p, randomValue(r, -8, 5), side)
+			amount := RoundAmount(limits, p, randomValue(r, -8, 6))
+			valid := amount >= limits.minAmount && amount*price >= limits.minTotal
+			err := CheckOrderLimits(limits, p, amount, price)
+			if valid != (err == nil) {
+				t.Fatalf("Test failed. %s amount %v price %v expected valid %v, got %v",
+					limits.name, amount, price, valid, err)
+			}
+		}
+	}
+}
+
+func TestRoundingEdgeCases(t *testing.T) {
+	limits := &testLimits{priceDecimals: 1, amountDecimals: 1}
+	p := pair.NewCurrencyPair("BTC", "USD")
+	// 0.3 can't be represented exactly, naive rounding turns it into 0.2
+	if v := RoundAmount(limits, p, 0.3); v != 0.3 {
+		t.Errorf("Test Failed - expected 0.3, got %v", v)
+	}
+	if v := RoundPrice(limits, p, 2.55, OrderSideSell); v != 2.6 {
+		t.Errorf("Test Failed - expected 2.6, got %v", v)
+	}
+	if v := RoundPrice(limits, p, 2.55, OrderSideBuy); v != 2.5 {
+		t.Errorf("Test Failed - expected 2.5, got %v", v)
+	}
+	limits.amountDecimals = -1
+	if v := RoundAmount(limits, p, 0.123456); v != 0.123456 {
+		t.Errorf("Test Failed - expected amount to be unchanged, got %v", v)
+	}
+}
+
+func TestRoundingPolicy(t *testing.T) {
+	p := pair.NewCurrencyPair("BTC", "USD")
+	if policy, err := ParseRoundingPolicy("", ""); err != nil || policy != DefaultRoundingPolicy {
+		t.Errorf("Test Failed - expected the default policy, got %+v %v", policy, err)
+	}
+	if _, err := ParseRoundingPolicy("ceil", ""); err == nil {
+		t.Error("Test Failed - amounts rounded up could exceed the balance")
+	}
+	if _, err := ParseRoundingPolicy("", "up"); err == nil {
+		t.Error("Test Failed - expected an invalid price rounding mode error")
+	}
+	policy, err := ParseRoundingPolicy("Nearest", "nearest")
+	if err != nil {
+		t.Fatalf("Test Failed - ParseRoundingPolicy returned an error: %s", err)
+	}
+
+	limits := WithRoundingPolicy(&testLimits{priceDecimals: 2, amountDecimals: 2}, policy)
+	if GetRoundingPolicy(limits) != policy {
+		t.Error("Test Failed - the rounding policy wasn't replaced")
+	}
+	prices := []struct {
+		price, expected float64
+	}{{1.004, 1}, {1.005, 1.01}, {1.006, 1.01}, {-1.005, -1.01}, {-1.004, -1}, {1.5, 1.5}}
+	for _, test := range prices {
+		for _, side := range []OrderSide{OrderSideBuy, OrderSideSell} {
+			if v := RoundPrice(limits, p, test.price, side); v != test.expected {
+				t.Errorf("Test Failed - expected %v to round to %v, got %v", test.price, test.expected, v)
+			}
+		}
+	}
+	if v := RoundAmount(limits, p, 0.129); v != 0.13 {
+		t.Errorf("Test Failed - expected 0.13, got %v", v)
+	}
+
+	limits = WithRoundingPolicy(limits, RoundingPolicy{Amount: RoundDown, Price: RoundUp})
+	if v := RoundPrice(limits, p, 1.001, OrderSideBuy); v != 1.01 {
+		t.Errorf("Test Failed - expected 1.01, got %v", v)
+	}
+}
+
+func TestRoundAmountWithinBalance(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	p := pair.NewCurrencyPair("BTC", "USD")
+	for _, mode := range []RoundingMode{RoundDown, RoundNearest} {
+		for i := range exchangeLimits {
+			limits := WithRoundingPolicy(&exchangeLimits[i], RoundingPolicy{Amount: mode, Price: RoundPassive})
+			for n := 0; n < 10000; n++ {
+				balance := randomValue(r, -8, 6)
+				amount := RoundAmountWithin(limits, p, balance, balance)
+				if amount > balance {
+					t.Fatalf("Test failed. %s balance %v overspent by %v rounded with %s",
+						exchangeLimits[i].name, balance, amount, mode)
+				}
+				if places := countDecimalPlaces(amount); places > exchangeLimits[i].amountDecimals {
+					t.Fatalf("Test failed. %s balance %v rounded to %v has %d decimal places",
+						exchangeLimits[i].name, balance, amount, places)
+				}
+			}
+		}
+	}
+	// Rounded to the nearest decimal unless that exceeds the balance
+	limits := WithRoundingPolicy(&testLimits{amountDecimals: 1}, RoundingPolicy{Amount: RoundNearest})
+	if v := RoundAmountWithin(limits, p, 0.26, 0.3); v != 0.3 {
+		t.Errorf("Test Failed - expected 0.3, got %v", v)
+	}
+	if v := RoundAmountWithin(limits, p, 0.26, 0.26); v != 0.2 {
+		t.Errorf("Test Failed - expected 0.2, got %v", v)
+	}
+}
+
+type mockLimitsExchange struct {
+	mockExchange
+	limits ILimits
+}
+
+func (m *mockLimitsExchange) GetLimits() ILimits {
+	return m.limits
+}
+
+func TestRoundingExchange(t *testing.T) {
+	policy := RoundingPolicy{Amount: RoundNearest, Price: RoundNearest}
+	exch := NewRoundingExchange(&mockLimitsExchange{limits: &testLimits{amountDecimals: 1}}, policy)
+	if GetRoundingPolicy(exch.GetLimits()) != policy {
+		t.Error("Test Failed - the wrapper didn't override the rounding policy")
+	}
+	if v := RoundAmount(exch.GetLimits(), pair.NewCurrencyPair("BTC", "USD"), 0.26); v != 0.3 {
+		t.Errorf("Test Failed - expected 0.3, got %v", v)
+	}
+}
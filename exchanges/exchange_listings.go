@@ -0,0 +1,15 @@
+package exchange
+
+import "github.com/mattkanwisher/cryptofiend/currency/pair"
+
+// Instrument is a market currently listed (open for trading) on an exchange
+type Instrument struct {
+	Symbol string            `json:"symbol"`
+	Pair   pair.CurrencyPair `json:"pair"`
+}
+
+// InstrumentLister is implemented by exchanges that can list the markets currently open for
+// trading, used to detect new listings & delistings
+type InstrumentLister interface {
+	ListInstruments() ([]Instrument, error)
+}
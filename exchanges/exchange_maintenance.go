@@ -0,0 +1,285 @@
+package exchange
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaintenanceSchedule is a cron-like schedule of the start of recurring maintenance windows, in
+// UTC. The schedule has five space separated fields: minute (0-59), hour (0-23), day of month
+// (1-31), month (1-12) & day of week (0-6, Sunday is 0 or 7). Each field is either "*", a value,
+// a range ("1-5") or a comma separated list of those, optionally followed by a step ("*/15").
+// Like cron, a time matches if either the day of month or the day of week matches when both are
+// restricted.
+type MaintenanceSchedule struct {
+	expr                         string
+	minutes, hours, days, months uint64
+	weekdays                     uint64
+	anyDayOfMonth, anyDayOfWeek  bool
+}
+
+type scheduleField struct {
+	min, max int
+	bits     *uint64
+	any      *bool
+}
+
+// ParseMaintenanceSchedule parses a cron-like schedule, e.g. "0 2 * * 2" for every Tuesday at
+// 02:00 UTC.
+func ParseMaintenanceSchedule(expr string) (*MaintenanceSchedule, error) {
+	s := &MaintenanceSchedule{expr: expr}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid maintenance schedule '%s', expected 5 fields", expr)
+	}
+	var anyMinute, anyHour, anyMonth bool
+	specs := []scheduleField{
+		{0, 59, &s.minutes, &anyMinute},
+		{0, 23, &s.hours, &anyHour},
+		{1, 31, &s.days, &s.anyDayOfMonth},
+		{1, 12, &s.months, &anyMonth},
+		{0, 7, &s.weekdays, &s.anyDayOfWeek},
+	}
+	for i, spec := range specs {
+		if err := parseScheduleField(fields[i], spec); err != nil {
+			return nil, fmt.Errorf("invalid maintenance schedule '%s': %s", expr, err)
+		}
+	}
+	// Sunday can be written as 0 or 7
+	if s.weekdays&(1<<7) != 0 {
+		s.weekdays |= 1
+	}
+	return s, nil
+}
+
+func parseScheduleField(field string, spec scheduleField) error {
+	*spec.any = strings.HasPrefix(field, "*")
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return fmt.Errorf("invalid step in '%s'", part)
+			}
+			part = part[:i]
+		}
+		lo, hi := spec.min, spec.max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return fmt.Errorf("invalid value '%s'", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return fmt.Errorf("invalid range '%s'", part)
+				}
+			}
+			if lo < spec.min || hi > spec.max || lo > hi {
+				return fmt.Errorf("'%s' out of range %d-%d", part, spec.min, spec.max)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			*spec.bits |= 1 << uint(v)
+		}
+	}
+	return nil
+}
+
+// String returns the schedule expression
+func (s *MaintenanceSchedule) String() string {
+	return s.expr
+}
+
+func (s *MaintenanceSchedule) matchesDay(t time.Time) bool {
+	if s.months&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	dayOfMonth := s.days&(1<<uint(t.Day())) != 0
+	dayOfWeek := s.weekdays&(1<<uint(t.Weekday())) != 0
+	if s.anyDayOfMonth || s.anyDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+// Matches returns true if a maintenance window starts at the minute of t.
+func (s *MaintenanceSchedule) Matches(t time.Time) bool {
+	t = t.UTC()
+	return s.matchesDay(t) && s.hours&(1<<uint(t.Hour())) != 0 && s.minutes&(1<<uint(t.Minute())) != 0
+}
+
+// Next returns the first start of a window at or after t, or the zero time if the schedule
+// doesn't match any time in the next 5 years (e.g. "0 0 30 2 *").
+func (s *MaintenanceSchedule) Next(t time.Time) time.Time {
+	t = t.UTC()
+	if t.Truncate(time.Minute) != t {
+		t = t.Truncate(time.Minute).Add(time.Minute)
+	}
+	end := t.AddDate(5, 0, 0)
+	for t.Before(end) {
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hours&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minutes&(1<<uint(t.Minute())) != 0 {
+			return t
+		}
+		t = t.Add(time.Minute)
+	}
+	return time.Time{}
+}
+
+// ScheduledMaintenance is a recurring maintenance window of an exchange
+type ScheduledMaintenance struct {
+	Name     string
+	Schedule *MaintenanceSchedule
+	Duration time.Duration
+}
+
+// window returns the window in progress at t, if any
+func (m *ScheduledMaintenance) window(t time.Time) (MaintenanceWindow, bool) {
+	t = t.UTC()
+	end := t.Add(-m.Duration)
+	for start := t.Truncate(time.Minute); start.After(end); start = start.Add(-time.Minute) {
+		if m.Schedule.Matches(start) {
+			return MaintenanceWindow{Name: m.Name, Start: start, End: start.Add(m.Duration)}, true
+		}
+	}
+	return MaintenanceWindow{}, false
+}
+
+// MaintenanceTransition is returned by MaintenanceScheduler.Update when an exchange enters or
+// leaves a maintenance window.
+type MaintenanceTransition struct {
+	Exchange string
+	// True when the window started, false when it ended
+	Started bool
+	Window  MaintenanceWindow
+}
+
+// MaintenanceScheduler keeps track of the configured maintenance windows of the exchanges, so
+// trading & polling can be paused around known exchange maintenance.
+type MaintenanceScheduler struct {
+	mtx     sync.Mutex
+	windows map[string][]*ScheduledMaintenance
+	// Window in progress on each exchange, as of the last update
+	active map[string]MaintenanceWindow
+	now    func() time.Time
+}
+
+// NewMaintenanceScheduler returns a scheduler without any maintenance windows.
+func NewMaintenanceScheduler() *MaintenanceScheduler {
+	return &MaintenanceScheduler{
+		windows: make(map[string][]*ScheduledMaintenance),
+		active:  make(map[string]MaintenanceWindow),
+		now:     time.Now,
+	}
+}
+
+// AddWindow adds a recurring maintenance window to an exchange, the schedule is the cron-like
+// schedule of the start of the window (see MaintenanceSchedule).
+func (s *MaintenanceScheduler) AddWindow(exchangeName, name, schedule string, duration time.Duration) error {
+	sched, err := ParseMaintenanceSchedule(schedule)
+	if err != nil {
+		return err
+	}
+	if duration <= 0 {
+		return fmt.Errorf("invalid duration %s for maintenance window '%s'", duration, name)
+	}
+	if name == "" {
+		name = schedule
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.windows[exchangeName] = append(s.windows[exchangeName],
+		&ScheduledMaintenance{Name: name, Schedule: sched, Duration: duration})
+	return nil
+}
+
+// Exchanges returns the names of the exchanges that have maintenance windows, sorted by name.
+func (s *MaintenanceScheduler) Exchanges() []string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	names := make([]string, 0, len(s.windows))
+	for name := range s.windows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *MaintenanceScheduler) activeWindow(exchangeName string, now time.Time) (MaintenanceWindow, bool) {
+	var result MaintenanceWindow
+	found := false
+	for _, m := range s.windows[exchangeName] {
+		// Overlapping windows are merged, the maintenance lasts until the last one ends
+		if w, ok := m.window(now); ok && (!found || w.End.After(result.End)) {
+			result, found = w, true
+		}
+	}
+	return result, found
+}
+
+// ActiveWindow returns the maintenance window in progress on an exchange, if any.
+func (s *MaintenanceScheduler) ActiveWindow(exchangeName string) (MaintenanceWindow, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.activeWindow(exchangeName, s.now())
+}
+
+// InMaintenance returns true if a maintenance window is in progress on the exchange.
+func (s *MaintenanceScheduler) InMaintenance(exchangeName string) bool {
+	_, ok := s.ActiveWindow(exchangeName)
+	return ok
+}
+
+// Upcoming returns the next occurrence of each maintenance window of an exchange, sorted by
+// start time.
+func (s *MaintenanceScheduler) Upcoming(exchangeName string) []MaintenanceWindow {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	now := s.now()
+	var result []MaintenanceWindow
+	for _, m := range s.windows[exchangeName] {
+		if start := m.Schedule.Next(now); !start.IsZero() {
+			result = append(result, MaintenanceWindow{Name: m.Name, Start: start, End: start.Add(m.Duration)})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Start.Before(result[j].Start) })
+	return result
+}
+
+// Update returns the exchanges that entered or left a maintenance window since the last update,
+// sorted by exchange name.
+func (s *MaintenanceScheduler) Update() []MaintenanceTransition {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	now := s.now()
+	var result []MaintenanceTransition
+	for name := range s.windows {
+		w, inWindow := s.activeWindow(name, now)
+		prev, wasInWindow := s.active[name]
+		switch {
+		case inWindow && !wasInWindow:
+			s.active[name] = w
+			result = append(result, MaintenanceTransition{Exchange: name, Started: true, Window: w})
+		case !inWindow && wasInWindow:
+			delete(s.active, name)
+			result = append(result, MaintenanceTransition{Exchange: name, Started: false, Window: prev})
+		case inWindow:
+			s.active[name] = w
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Exchange < result[j].Exchange })
+	return result
+}
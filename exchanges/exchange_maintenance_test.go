@@ -0,0 +1,83 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMaintenanceSchedule(t *testing.T) {
+	for _, expr := range []string{"", "0 2 * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := ParseMaintenanceSchedule(expr); err == nil {
+			t.Errorf("Test failed. Expected schedule '%s' to be rejected", expr)
+		}
+	}
+
+	// Tuesdays & the first day of every month at 02:30 UTC
+	s, err := ParseMaintenanceSchedule("30 2 1 * 2")
+	if err != nil {
+		t.Fatalf("Test failed. ParseMaintenanceSchedule error: %s", err)
+	}
+	tuesday := time.Date(2018, 11, 13, 2, 30, 0, 0, time.UTC)
+	first := time.Date(2018, 12, 1, 2, 30, 0, 0, time.UTC)
+	if !s.Matches(tuesday) || !s.Matches(first) || s.Matches(tuesday.Add(time.Minute)) ||
+		s.Matches(tuesday.AddDate(0, 0, 1)) {
+		t.Error("Test failed. Unexpected schedule matches")
+	}
+	if next := s.Next(tuesday.Add(time.Second)); !next.Equal(time.Date(2018, 11, 20, 2, 30, 0, 0, time.UTC)) {
+		t.Errorf("Test failed. Unexpected next window %s", next)
+	}
+	if next := s.Next(time.Date(2018, 11, 28, 0, 0, 0, 0, time.UTC)); !next.Equal(first) {
+		t.Errorf("Test failed. Unexpected next window %s", next)
+	}
+
+	// Every 15 minutes on weekends, Sunday written as 7
+	s, _ = ParseMaintenanceSchedule("*/15 * * * 6-7")
+	sunday := time.Date(2018, 11, 18, 10, 45, 0, 0, time.UTC)
+	if !s.Matches(sunday) || s.Matches(sunday.Add(5*time.Minute)) || s.Matches(sunday.AddDate(0, 0, 1)) {
+		t.Error("Test failed. Unexpected step schedule matches")
+	}
+	if s, _ = ParseMaintenanceSchedule("0 0 30 2 *"); !s.Next(sunday).IsZero() {
+		t.Error("Test failed. Expected a schedule that never matches to have no next window")
+	}
+}
+
+func TestMaintenanceScheduler(t *testing.T) {
+	now := time.Date(2018, 11, 13, 1, 0, 0, 0, time.UTC)
+	scheduler := NewMaintenanceScheduler()
+	scheduler.now = func() time.Time { return now }
+	if err := scheduler.AddWindow("Mock", "weekly", "0 2 * * 2", 0); err == nil {
+		t.Error("Test failed. Expected a window without a duration to be rejected")
+	}
+	if err := scheduler.AddWindow("Mock", "weekly", "0 2 * * 2", 90*time.Minute); err != nil {
+		t.Fatalf("Test failed. AddWindow error: %s", err)
+	}
+	scheduler.AddWindow("Mock", "", "0 3 13 11 *", time.Hour)
+
+	if upcoming := scheduler.Upcoming("Mock"); len(upcoming) != 2 || upcoming[0].Name != "weekly" ||
+		!upcoming[0].Start.Equal(now.Add(time.Hour)) || upcoming[1].Name != "0 3 13 11 *" {
+		t.Errorf("Test failed. Unexpected upcoming windows %+v", upcoming)
+	}
+	if transitions := scheduler.Update(); len(transitions) != 0 || scheduler.InMaintenance("Mock") {
+		t.Errorf("Test failed. Unexpected maintenance before the window %+v", transitions)
+	}
+
+	now = now.Add(time.Hour)
+	transitions := scheduler.Update()
+	if len(transitions) != 1 || !transitions[0].Started || transitions[0].Exchange != "Mock" ||
+		!scheduler.InMaintenance("Mock") || scheduler.InMaintenance("Other") {
+		t.Errorf("Test failed. Expected the maintenance to start %+v", transitions)
+	}
+	// The overlapping window extends the maintenance until 04:00
+	now = now.Add(time.Hour)
+	if w, ok := scheduler.ActiveWindow("Mock"); !ok || !w.End.Equal(now.Add(time.Hour)) {
+		t.Errorf("Test failed. Unexpected active window %+v", w)
+	}
+	if transitions = scheduler.Update(); len(transitions) != 0 {
+		t.Errorf("Test failed. Unexpected transitions during the maintenance %+v", transitions)
+	}
+	now = now.Add(time.Hour)
+	transitions = scheduler.Update()
+	if len(transitions) != 1 || transitions[0].Started || scheduler.InMaintenance("Mock") {
+		t.Errorf("Test failed. Expected the maintenance to end %+v", transitions)
+	}
+}
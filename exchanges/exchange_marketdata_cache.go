@@ -0,0 +1,173 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+// MarketDataCacheStats holds the number of market data requests served by a MarketDataCache
+type MarketDataCacheStats struct {
+	// Requests served from the cache
+	Hits int64 `json:"hits"`
+	// Requests that waited for the response to an identical request already in flight
+	Shared int64 `json:"shared"`
+	// Requests sent to the exchange
+	Misses int64 `json:"misses"`
+}
+
+// marketDataCall is a market data request that is in flight or has completed
+type marketDataCall struct {
+	done      chan struct{}
+	value     interface{}
+	err       error
+	fetchedAt time.Time
+}
+
+// MarketDataCache shares the tickers & orderbooks requested by several callers (e.g. strategies
+// trading the same pair) within a short window, so they're fetched from the exchange only once.
+// Responses are shared for the TTL of the cache, and identical requests made while a request is
+// in flight wait for its response instead of being sent to the exchange (single-flight). Errors
+// aren't cached, but they are shared with the requests that waited for them.
+type MarketDataCache struct {
+	ttl   time.Duration
+	mtx   sync.Mutex
+	calls map[string]*marketDataCall
+	// Stats keyed by exchange name
+	stats map[string]*MarketDataCacheStats
+	now   func() time.Time
+}
+
+// NewMarketDataCache returns a cache that shares the market data for the given TTL.
+func NewMarketDataCache(ttl time.Duration) *MarketDataCache {
+	return &MarketDataCache{
+		ttl:   ttl,
+		calls: make(map[string]*marketDataCall),
+		stats: make(map[string]*MarketDataCacheStats),
+		now:   time.Now,
+	}
+}
+
+// Wrap returns a wrapper of the exchange whose market data requests go through the cache, the
+// other API calls are passed through.
+func (c *MarketDataCache) Wrap(exch IBotExchangeEx) *CachedMarketDataExchange {
+	return &CachedMarketDataExchange{Decorator: Decorator{IBotExchangeEx: exch}, cache: c}
+}
+
+// Stats returns the number of requests served from the cache & sent to each exchange, keyed by
+// exchange name.
+func (c *MarketDataCache) Stats() map[string]MarketDataCacheStats {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	result := make(map[string]MarketDataCacheStats, len(c.stats))
+	for name, stats := range c.stats {
+		result[name] = *stats
+	}
+	return result
+}
+
+// do returns the cached response of a request if it's fresh, otherwise it waits for the identical
+// request in flight or calls fetch.
+func (c *MarketDataCache) do(exchangeName, key string, fetch func() (interface{}, error)) (interface{}, error) {
+	key = exchangeName + "|" + key
+	c.mtx.Lock()
+	stats, ok := c.stats[exchangeName]
+	if !ok {
+		stats = &MarketDataCacheStats{}
+		c.stats[exchangeName] = stats
+	}
+	if call, ok := c.calls[key]; ok {
+		select {
+		case <-call.done:
+			if call.err == nil && c.now().Sub(call.fetchedAt) < c.ttl {
+				stats.Hits++
+				c.mtx.Unlock()
+				return call.value, nil
+			}
+		default:
+			stats.Shared++
+			c.mtx.Unlock()
+			<-call.done
+			return call.value, call.err
+		}
+	}
+	call := &marketDataCall{done: make(chan struct{})}
+	c.calls[key] = call
+	stats.Misses++
+	c.mtx.Unlock()
+
+	call.value, call.err = fetch()
+	c.mtx.Lock()
+	call.fetchedAt = c.now()
+	if call.err != nil {
+		delete(c.calls, key)
+	}
+	c.mtx.Unlock()
+	close(call.done)
+	return call.value, call.err
+}
+
+func marketDataKey(method string, p pair.CurrencyPair, assetType string) string {
+	return method + "|" + p.Display("/", true).String() + "|" + assetType
+}
+
+// CachedMarketDataExchange wraps an exchange so that its market data requests go through a
+// MarketDataCache
+type CachedMarketDataExchange struct {
+	Decorator
+	cache *MarketDataCache
+}
+
+// UpdateTicker returns the ticker of a currency pair fetched within the TTL, or fetches it.
+func (e *CachedMarketDataExchange) UpdateTicker(currencyPair pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	v, err := e.cache.do(e.GetName(), marketDataKey("ticker", currencyPair, assetType), func() (interface{}, error) {
+		return e.IBotExchangeEx.UpdateTicker(currencyPair, assetType)
+	})
+	price, _ := v.(ticker.Price)
+	return price, err
+}
+
+// UpdateOrderbook returns the orderbook of a currency pair fetched within the TTL, or fetches it.
+// Each caller gets its own copy of the cached orderbook, so callers may release their copy.
+func (e *CachedMarketDataExchange) UpdateOrderbook(currencyPair pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	v, err := e.cache.do(e.GetName(), marketDataKey("orderbook", currencyPair, assetType), func() (interface{}, error) {
+		return e.IBotExchangeEx.UpdateOrderbook(currencyPair, assetType)
+	})
+	book, _ := v.(orderbook.Base)
+	return book.Clone(), err
+}
+
+// UpdateTickerContext is UpdateTicker returning as soon as ctx is done, the request may be shared
+// with other callers so it isn't cancelled.
+func (e *CachedMarketDataExchange) UpdateTickerContext(ctx context.Context, currencyPair pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
+	return contextAdapter{e}.UpdateTickerContext(ctx, currencyPair, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook returning as soon as ctx is done, the request may be
+// shared with other callers so it isn't cancelled.
+func (e *CachedMarketDataExchange) UpdateOrderbookContext(ctx context.Context, currencyPair pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
+	return contextAdapter{e}.UpdateOrderbookContext(ctx, currencyPair, assetType)
+}
+
+// GetOrderbookEx returns the orderbook of a currency pair, orderbooks requested with options
+// (which aren't cached by the exchange) are shared for the TTL. Each caller gets its own copy of a
+// shared orderbook, so callers may release their copy.
+func (e *CachedMarketDataExchange) GetOrderbookEx(currencyPair pair.CurrencyPair, assetType string,
+	opts ...OrderbookOptions) (orderbook.Base, error) {
+	if len(opts) == 0 {
+		return e.IBotExchangeEx.GetOrderbookEx(currencyPair, assetType)
+	}
+	key := marketDataKey("orderbook", currencyPair, assetType) + fmt.Sprintf("|%+v", opts)
+	v, err := e.cache.do(e.GetName(), key, func() (interface{}, error) {
+		return e.IBotExchangeEx.GetOrderbookEx(currencyPair, assetType, opts...)
+	})
+	book, _ := v.(orderbook.Base)
+	return book.Clone(), err
+}
@@ -0,0 +1,133 @@
+package exchange
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+type mockTickerExchange struct {
+	mockExchange
+	mtx     sync.Mutex
+	fetches int
+	err     error
+	// Blocks the fetches until closed, if not nil
+	release chan struct{}
+}
+
+func (m *mockTickerExchange) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	bids := append(orderbook.GetItems(1), orderbook.Item{Price: 100, Amount: 1})
+	asks := append(orderbook.GetItems(1), orderbook.Item{Price: 101, Amount: 1})
+	return orderbook.Base{Pair: p, Bids: bids, Asks: asks}, nil
+}
+
+func (m *mockTickerExchange) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	if m.release != nil {
+		<-m.release
+	}
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.fetches++
+	return ticker.Price{Pair: p, Last: float64(m.fetches)}, m.err
+}
+
+func TestMarketDataCache(t *testing.T) {
+	now := time.Date(2018, 11, 20, 0, 0, 0, 0, time.UTC)
+	cache := NewMarketDataCache(time.Second)
+	cache.now = func() time.Time { return now }
+	mock := &mockTickerExchange{}
+	first, second := cache.Wrap(mock), cache.Wrap(mock)
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+
+	if tick, err := first.UpdateTicker(btcusd, ticker.Spot); err != nil || tick.Last != 1 {
+		t.Errorf("Test failed. Unexpected ticker %+v %v", tick, err)
+	}
+	if tick, _ := second.UpdateTicker(btcusd, ticker.Spot); tick.Last != 1 {
+		t.Errorf("Test failed. Expected the cached ticker but got %+v", tick)
+	}
+	if tick, _ := second.UpdateTicker(pair.NewCurrencyPair("ETH", "USD"), ticker.Spot); tick.Last != 2 {
+		t.Errorf("Test failed. Expected the ticker of another pair to be fetched but got %+v", tick)
+	}
+	now = now.Add(time.Second)
+	if tick, _ := first.UpdateTicker(btcusd, ticker.Spot); tick.Last != 3 {
+		t.Errorf("Test failed. Expected the expired ticker to be fetched but got %+v", tick)
+	}
+
+	mock.err = errors.New("timeout")
+	now = now.Add(time.Second)
+	if _, err := first.UpdateTicker(btcusd, ticker.Spot); err == nil {
+		t.Error("Test failed. Expected the error to be returned")
+	}
+	mock.err = nil
+	if tick, err := first.UpdateTicker(btcusd, ticker.Spot); err != nil || tick.Last != 5 {
+		t.Errorf("Test failed. Expected the error not to be cached but got %+v %v", tick, err)
+	}
+
+	stats := cache.Stats()["Mock"]
+	if stats.Hits != 1 || stats.Misses != 5 || stats.Shared != 0 {
+		t.Errorf("Test failed. Unexpected stats %+v", stats)
+	}
+}
+
+func TestMarketDataCacheOrderbookCopies(t *testing.T) {
+	cache := NewMarketDataCache(time.Minute)
+	mock := &mockTickerExchange{}
+	first, second := cache.Wrap(mock), cache.Wrap(mock)
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+
+	book, err := first.UpdateOrderbook(btcusd, ticker.Spot)
+	if err != nil {
+		t.Fatalf("Test failed. UpdateOrderbook error: %s", err)
+	}
+	// the released slices are reused by the next orderbook fetched from the pool
+	book.Release()
+	reused := append(orderbook.GetItems(1), orderbook.Item{Price: 1, Amount: 1})
+	shared, err := second.UpdateOrderbook(btcusd, ticker.Spot)
+	if err != nil || len(shared.Bids) != 1 || shared.Bids[0].Price != 100 || shared.Asks[0].Price != 101 {
+		t.Errorf("Test failed. Expected the cached orderbook to be unaffected by the release, got %+v %v",
+			shared, err)
+	}
+	if len(reused) != 1 || reused[0].Price != 1 {
+		t.Errorf("Test failed. Unexpected reused items %+v", reused)
+	}
+}
+
+func TestMarketDataCacheSharesRequestsInFlight(t *testing.T) {
+	cache := NewMarketDataCache(time.Second)
+	mock := &mockTickerExchange{release: make(chan struct{})}
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+
+	var wg sync.WaitGroup
+	results := make([]ticker.Price, 3)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = cache.Wrap(mock).UpdateTicker(btcusd, ticker.Spot)
+		}(i)
+	}
+	// Wait until all the requests are either in flight or waiting for it
+	for {
+		stats := cache.Stats()["Mock"]
+		if stats.Misses+stats.Shared == 3 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(mock.release)
+	wg.Wait()
+
+	for _, tick := range results {
+		if tick.Last != 1 {
+			t.Errorf("Test failed. Expected the ticker fetched once but got %+v", tick)
+		}
+	}
+	if stats := cache.Stats()["Mock"]; stats.Misses != 1 || stats.Shared != 2 || mock.fetches != 1 {
+		t.Errorf("Test failed. Unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,23 @@
+package exchange
+
+import "github.com/mattkanwisher/cryptofiend/storage"
+
+// NoncePersister is implemented by exchanges whose request nonces can be persisted, so that
+// nonces aren't reused after a restart.
+type NoncePersister interface {
+	// LoadNonce restores the nonce values saved by SaveNonce
+	LoadNonce(s storage.Store) error
+	// SaveNonce persists the nonce values of the exchange to the store
+	SaveNonce(s storage.Store) error
+}
+
+// LoadNonce restores the nonce values of the exchange previously saved to the store, it should
+// be called before any authenticated requests are sent.
+func (e *Base) LoadNonce(s storage.Store) error {
+	return e.Nonce.Load(s, e.Name)
+}
+
+// SaveNonce persists the nonce values of the exchange to the store under the exchange name.
+func (e *Base) SaveNonce(s storage.Store) error {
+	return e.Nonce.Save(s, e.Name)
+}
@@ -0,0 +1,210 @@
+package exchange
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+// OrderEventType is the kind of change an OrderEvent reports
+type OrderEventType string
+
+// Kinds of order events
+const (
+	OrderEventCreated         OrderEventType = "created"
+	OrderEventPartiallyFilled OrderEventType = "partially_filled"
+	OrderEventFilled          OrderEventType = "filled"
+	OrderEventCancelled       OrderEventType = "cancelled"
+)
+
+// OrderEvent reports a change to an order of the account, Order is the state of the order after
+// the change
+type OrderEvent struct {
+	Type  OrderEventType
+	Order Order
+	Time  time.Time
+}
+
+// OrderEventSource is implemented by exchanges that stream order events over their private
+// websocket feed, and by OrderEventPoller for the exchanges that don't
+type OrderEventSource interface {
+	// SubscribeOrderEvents returns a channel that receives an event every time an order of the
+	// account is created, filled or cancelled
+	SubscribeOrderEvents() (<-chan OrderEvent, UnsubscribeFunc, error)
+}
+
+// orderEventFeed derives order events from successive updates of the orders & delivers them to
+// the subscribers
+type orderEventFeed struct {
+	mtx         sync.Mutex
+	nextID      int
+	orders      map[string]Order
+	subscribers map[int]chan OrderEvent
+}
+
+func (f *orderEventFeed) subscribe() (<-chan OrderEvent, UnsubscribeFunc) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.subscribers == nil {
+		f.subscribers = make(map[int]chan OrderEvent)
+	}
+	f.nextID++
+	id := f.nextID
+	ch := make(chan OrderEvent, streamBufferSize)
+	f.subscribers[id] = ch
+	return ch, func() {
+		f.mtx.Lock()
+		defer f.mtx.Unlock()
+		if _, ok := f.subscribers[id]; !ok {
+			return
+		}
+		delete(f.subscribers, id)
+		close(ch)
+	}
+}
+
+// update compares the order with its previous state & publishes the resulting events, ended
+// orders stop being tracked. Updates with an unknown status are ignored.
+func (f *orderEventFeed) update(name string, order Order, now time.Time) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.orders == nil {
+		f.orders = make(map[string]Order)
+	}
+	prev, tracked := f.orders[order.OrderID]
+	var events []OrderEventType
+	switch order.Status {
+	case OrderStatusActive:
+		if !tracked {
+			events = append(events, OrderEventCreated)
+		}
+		if order.FilledAmount > prev.FilledAmount {
+			events = append(events, OrderEventPartiallyFilled)
+		}
+		f.orders[order.OrderID] = order
+	case OrderStatusFilled:
+		events = append(events, OrderEventFilled)
+		delete(f.orders, order.OrderID)
+	case OrderStatusAborted:
+		if order.FilledAmount > prev.FilledAmount {
+			events = append(events, OrderEventPartiallyFilled)
+		}
+		events = append(events, OrderEventCancelled)
+		delete(f.orders, order.OrderID)
+	}
+
+	for _, t := range events {
+		event := OrderEvent{Type: t, Order: order, Time: now}
+		for _, ch := range f.subscribers {
+			select {
+			case ch <- event:
+			default:
+				log.Printf("%s Dropped an order event, the subscriber isn't keeping up\n", name)
+			}
+		}
+	}
+}
+
+// tracked returns the orders that are still active as far as the feed knows
+func (f *orderEventFeed) tracked() []Order {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	orders := make([]Order, 0, len(f.orders))
+	for _, order := range f.orders {
+		orders = append(orders, order)
+	}
+	return orders
+}
+
+// SubscribeOrderEvents returns a channel that receives an event every time an order of the
+// account is created, filled or cancelled. The events are derived from the orders published by
+// the private websocket feed (see PublishOrder).
+func (e *Base) SubscribeOrderEvents() (<-chan OrderEvent, UnsubscribeFunc, error) {
+	e.streams.mtx.Lock()
+	_, err := e.subscribe(StreamOrders)
+	e.streams.mtx.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+	ch, unsubscribe := e.streams.orderEvents.subscribe()
+	return ch, unsubscribe, nil
+}
+
+// OrderEventPoller wraps an exchange without a private websocket feed and emulates its order
+// events by diffing the open orders returned by successive polls.
+type OrderEventPoller struct {
+	Decorator
+	feed orderEventFeed
+	now  func() time.Time
+}
+
+// NewOrderEventPoller returns a wrapper that emits the order events of the exchange each time
+// it's polled.
+func NewOrderEventPoller(exch IBotExchangeEx) *OrderEventPoller {
+	return &OrderEventPoller{Decorator: Decorator{IBotExchangeEx: exch}, now: time.Now}
+}
+
+// SubscribeOrderEvents returns a channel that receives the order events detected by Poll
+func (p *OrderEventPoller) SubscribeOrderEvents() (<-chan OrderEvent, UnsubscribeFunc, error) {
+	ch, unsubscribe := p.feed.subscribe()
+	return ch, unsubscribe, nil
+}
+
+// Poll retrieves the open orders in the currency pairs (all pairs if empty) & publishes the
+// events since the previous poll. The final state of the orders that are no longer open is
+// retrieved with GetOrder, orders that can't be retrieved are checked again on the next poll.
+// The orders open on the first poll are reported as created.
+func (p *OrderEventPoller) Poll(pairs []pair.CurrencyPair) error {
+	orders, err := p.GetOrders(pairs)
+	if err != nil {
+		return err
+	}
+	now := p.now()
+	open := make(map[string]bool, len(orders))
+	for _, order := range orders {
+		open[order.OrderID] = true
+		// Some exchanges don't set the status of open orders
+		o := *order
+		o.Status = OrderStatusActive
+		p.feed.update(p.GetName(), o, now)
+	}
+
+	for _, prev := range p.feed.tracked() {
+		if open[prev.OrderID] || !inPairs(prev.CurrencyPair, pairs) {
+			continue
+		}
+		order, err := p.GetOrder(prev.OrderID, prev.CurrencyPair)
+		if err != nil {
+			log.Printf("%s Unable to retrieve the closed order %s. Error: %s\n", p.GetName(), prev.OrderID, err)
+			continue
+		}
+		o := *order
+		switch o.Status {
+		case OrderStatusActive:
+			// Not listed yet or no longer listed, it's checked again on the next poll
+			continue
+		case OrderStatusUnknown, "":
+			if o.Amount > 0 && o.FilledAmount >= o.Amount {
+				o.Status = OrderStatusFilled
+			} else {
+				o.Status = OrderStatusAborted
+			}
+		}
+		p.feed.update(p.GetName(), o, now)
+	}
+	return nil
+}
+
+func inPairs(p pair.CurrencyPair, pairs []pair.CurrencyPair) bool {
+	if len(pairs) == 0 {
+		return true
+	}
+	for _, x := range pairs {
+		if x.Equal(p) {
+			return true
+		}
+	}
+	return false
+}
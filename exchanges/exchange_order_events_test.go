@@ -0,0 +1,115 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+type mockOrdersExchange struct {
+	mockExchange
+	open   []*Order
+	closed map[string]*Order
+}
+
+func (m *mockOrdersExchange) GetOrders(pairs []pair.CurrencyPair) ([]*Order, error) {
+	return m.open, nil
+}
+
+func (m *mockOrdersExchange) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*Order, error) {
+	if order, ok := m.closed[orderID]; ok {
+		return order, nil
+	}
+	return nil, errors.New("order not found")
+}
+
+func expectOrderEvents(t *testing.T, events <-chan OrderEvent, expected ...string) {
+	t.Helper()
+	var received []string
+	for len(events) > 0 {
+		e := <-events
+		received = append(received, e.Order.OrderID+" "+string(e.Type))
+	}
+	if len(received) != len(expected) {
+		t.Fatalf("Test failed. Expected events %v, got %v", expected, received)
+	}
+	for i := range expected {
+		if received[i] != expected[i] {
+			t.Errorf("Test failed. Expected events %v, got %v", expected, received)
+			return
+		}
+	}
+}
+
+func TestPublishOrderEvents(t *testing.T) {
+	b := Base{Name: "Streamer", Websocket: true}
+	b.SetStreams(StreamOrders)
+	if _, _, err := b.SubscribeOrderEvents(); err != ErrStreamNotSupported {
+		t.Errorf("Test failed. Expected order events to need authenticated API support, got %v", err)
+	}
+	b.AuthenticatedAPISupport = true
+	events, unsubscribe, err := b.SubscribeOrderEvents()
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeOrderEvents returned an error: %s", err)
+	}
+
+	b.PublishOrder(Order{OrderID: "1", Status: OrderStatusActive, Amount: 2})
+	b.PublishOrder(Order{OrderID: "1", Status: OrderStatusActive, Amount: 2})
+	b.PublishOrder(Order{OrderID: "1", Status: OrderStatusActive, Amount: 2, FilledAmount: 1})
+	b.PublishOrder(Order{OrderID: "2", Status: OrderStatusActive, Amount: 2})
+	b.PublishOrder(Order{OrderID: "1", Status: OrderStatusFilled, Amount: 2, FilledAmount: 2})
+	b.PublishOrder(Order{OrderID: "2", Status: OrderStatusAborted, Amount: 2, FilledAmount: 1})
+	b.PublishOrder(Order{OrderID: "3", Status: OrderStatusUnknown})
+	expectOrderEvents(t, events, "1 created", "1 partially_filled", "2 created", "1 filled",
+		"2 partially_filled", "2 cancelled")
+
+	unsubscribe()
+	unsubscribe()
+	if _, ok := <-events; ok {
+		t.Error("Test failed. Expected the channel to be closed")
+	}
+}
+
+func TestOrderEventPoller(t *testing.T) {
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+	ethusd := pair.NewCurrencyPair("ETH", "USD")
+	mock := &mockOrdersExchange{closed: make(map[string]*Order)}
+	poller := NewOrderEventPoller(mock)
+	var _ OrderEventSource = poller
+	events, _, _ := poller.SubscribeOrderEvents()
+
+	mock.open = []*Order{
+		{OrderID: "1", CurrencyPair: btcusd, Amount: 2},
+		{OrderID: "2", CurrencyPair: btcusd, Amount: 2},
+		{OrderID: "3", CurrencyPair: ethusd, Amount: 2},
+	}
+	if err := poller.Poll(nil); err != nil {
+		t.Fatalf("Test failed. Poll returned an error: %s", err)
+	}
+	expectOrderEvents(t, events, "1 created", "2 created", "3 created")
+
+	// Order 2 can't be retrieved yet, order 3 isn't in the polled pairs
+	mock.open = []*Order{{OrderID: "1", CurrencyPair: btcusd, Amount: 2, FilledAmount: 1}}
+	poller.Poll([]pair.CurrencyPair{btcusd})
+	expectOrderEvents(t, events, "1 partially_filled")
+
+	mock.open = nil
+	mock.closed["1"] = &Order{OrderID: "1", CurrencyPair: btcusd, Amount: 2, FilledAmount: 2,
+		Status: OrderStatusUnknown}
+	mock.closed["2"] = &Order{OrderID: "2", CurrencyPair: btcusd, Amount: 2, Status: OrderStatusAborted}
+	mock.closed["3"] = &Order{OrderID: "3", CurrencyPair: ethusd, Amount: 2, FilledAmount: 2,
+		Status: OrderStatusFilled}
+	poller.Poll(nil)
+	poller.Poll(nil)
+	expected := map[string]bool{"1 filled": true, "2 cancelled": true, "3 filled": true}
+	if len(events) != len(expected) {
+		t.Fatalf("Test failed. Expected %d events, got %d", len(expected), len(events))
+	}
+	for len(events) > 0 {
+		e := <-events
+		if !expected[e.Order.OrderID+" "+string(e.Type)] {
+			t.Errorf("Test failed. Unexpected event %s %s", e.Order.OrderID, e.Type)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+// ErrNoPairData is returned for a pair that's missing from the response to a multi-pair request
+var ErrNoPairData = errors.New("no data returned for the pair")
+
+// PairError is the failure to retrieve the data of a single pair of a multi-pair request
+type PairError struct {
+	Pair pair.CurrencyPair
+	Err  error
+}
+
+func (e PairError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pair.Pair(), e.Err)
+}
+
+// PartialError is returned by multi-pair requests that only succeeded for some of the requested
+// pairs, the results of the pairs that succeeded are returned alongside it.
+type PartialError struct {
+	Exchange string
+	Errors   []PairError
+}
+
+// NewPartialError returns a PartialError for the pair errors, or nil if there aren't any
+func NewPartialError(exchangeName string, errs []PairError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &PartialError{Exchange: exchangeName, Errors: errs}
+}
+
+func (e *PartialError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i := range e.Errors {
+		msgs[i] = e.Errors[i].Error()
+	}
+	return fmt.Sprintf("%s request failed for %d pairs (%s)", e.Exchange, len(e.Errors), strings.Join(msgs, ", "))
+}
+
+// PairErr returns the error of a pair, nil if the request succeeded for the pair
+func (e *PartialError) PairErr(p pair.CurrencyPair) error {
+	for i := range e.Errors {
+		if e.Errors[i].Pair.Equal(p) {
+			return e.Errors[i].Err
+		}
+	}
+	return nil
+}
+
+// PairErr returns the error of a pair from the error returned by a multi-pair request, which is
+// nil if the request only failed for other pairs
+func PairErr(err error, p pair.CurrencyPair) error {
+	if partial, ok := err.(*PartialError); ok {
+		return partial.PairErr(p)
+	}
+	return err
+}
+
+// MultiTickerUpdater is implemented by exchanges that update the tickers of several pairs with a
+// single request. If the request fails for some of the pairs the tickers of the other pairs are
+// returned along with a *PartialError.
+type MultiTickerUpdater interface {
+	UpdateTickers(pairs []pair.CurrencyPair, assetType string) ([]ticker.Price, error)
+}
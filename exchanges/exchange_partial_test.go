@@ -0,0 +1,33 @@
+package exchange
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+func TestPartialError(t *testing.T) {
+	if err := NewPartialError("Kraken", nil); err != nil {
+		t.Fatalf("Test failed. Expected no error without pair errors, got %v", err)
+	}
+
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+	ethusd := pair.NewCurrencyPair("ETH", "USD")
+	invalid := errors.New("unknown asset pair")
+	err := NewPartialError("Kraken", []PairError{{Pair: ethusd, Err: invalid}})
+	if !strings.Contains(err.Error(), "ETHUSD: unknown asset pair") {
+		t.Errorf("Test failed. Unexpected error message %s", err)
+	}
+	if PairErr(err, btcusd) != nil {
+		t.Error("Test failed. Expected no error for a pair that succeeded")
+	}
+	if PairErr(err, ethusd) != invalid {
+		t.Error("Test failed. Expected the error of the pair that failed")
+	}
+	// other errors apply to every pair
+	if PairErr(invalid, btcusd) != invalid {
+		t.Error("Test failed. Expected a non partial error to be returned as is")
+	}
+}
@@ -0,0 +1,141 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+// ErrTradingPaused is returned by a PausableExchange when trading has been paused on the exchange
+// or on the currency pair of an order.
+var ErrTradingPaused = errors.New("trading is paused")
+
+// TradingSwitch holds the runtime switches that pause trading on an exchange, either entirely or
+// only for specific currency pairs. A switch can be shared by the accounts of an exchange.
+type TradingSwitch struct {
+	m      sync.RWMutex
+	paused bool
+	// Set during scheduled maintenance, separately from paused so that resuming after the
+	// maintenance doesn't resume trading paused by an operator
+	maintenance bool
+	// Paused currency pairs, keyed by the pair delimited by "/" in upper case
+	pausedPairs map[string]pair.CurrencyPair
+}
+
+// NewTradingSwitch returns a switch with trading enabled on every pair.
+func NewTradingSwitch() *TradingSwitch {
+	return &TradingSwitch{pausedPairs: make(map[string]pair.CurrencyPair)}
+}
+
+func pausedPairKey(p pair.CurrencyPair) string {
+	return p.Display("/", true).String()
+}
+
+// SetPaused pauses (or resumes) trading on every pair of the exchange.
+func (s *TradingSwitch) SetPaused(paused bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.paused = paused
+}
+
+// Paused returns true if trading is paused on every pair of the exchange.
+func (s *TradingSwitch) Paused() bool {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return s.paused
+}
+
+// SetMaintenance pauses (or resumes) trading on every pair of the exchange for scheduled
+// maintenance, independently of SetPaused.
+func (s *TradingSwitch) SetMaintenance(maintenance bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.maintenance = maintenance
+}
+
+// InMaintenance returns true if trading is paused for scheduled maintenance.
+func (s *TradingSwitch) InMaintenance() bool {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return s.maintenance
+}
+
+// SetPairPaused pauses (or resumes) trading on a currency pair.
+func (s *TradingSwitch) SetPairPaused(p pair.CurrencyPair, paused bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if paused {
+		s.pausedPairs[pausedPairKey(p)] = p
+	} else {
+		delete(s.pausedPairs, pausedPairKey(p))
+	}
+}
+
+// SetPausedPairs replaces the paused currency pairs.
+func (s *TradingSwitch) SetPausedPairs(pairs []pair.CurrencyPair) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.pausedPairs = make(map[string]pair.CurrencyPair, len(pairs))
+	for _, p := range pairs {
+		s.pausedPairs[pausedPairKey(p)] = p
+	}
+}
+
+// PausedPairs returns the currency pairs trading has been paused on, sorted by pair.
+func (s *TradingSwitch) PausedPairs() []pair.CurrencyPair {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	keys := make([]string, 0, len(s.pausedPairs))
+	for key := range s.pausedPairs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]pair.CurrencyPair, len(keys))
+	for i, key := range keys {
+		pairs[i] = s.pausedPairs[key]
+	}
+	return pairs
+}
+
+// IsTradingPaused returns true if trading is paused on the exchange (including for scheduled
+// maintenance) or the currency pair.
+func (s *TradingSwitch) IsTradingPaused(p pair.CurrencyPair) bool {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	if s.paused || s.maintenance {
+		return true
+	}
+	_, ok := s.pausedPairs[pausedPairKey(p)]
+	return ok
+}
+
+// PausableExchange wraps an exchange so that new orders can be blocked at runtime by a trading
+// switch, while market data keeps flowing. Orders can still be cancelled while trading is paused.
+type PausableExchange struct {
+	Decorator
+	tradingSwitch *TradingSwitch
+}
+
+// NewPausableExchange returns a wrapper that blocks new orders while the switch pauses trading.
+func NewPausableExchange(exch IBotExchangeEx, tradingSwitch *TradingSwitch) *PausableExchange {
+	return &PausableExchange{Decorator: Decorator{IBotExchangeEx: exch}, tradingSwitch: tradingSwitch}
+}
+
+// NewOrder submits a new order to the exchange, or returns ErrTradingPaused without contacting
+// the exchange if trading is paused on the exchange or currency pair.
+func (p *PausableExchange) NewOrder(symbol pair.CurrencyPair, amount, price float64, side OrderSide,
+	orderType OrderType, opts ...OrderOptions) (string, error) {
+	return p.NewOrderContext(context.Background(), symbol, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (p *PausableExchange) NewOrderContext(ctx context.Context, symbol pair.CurrencyPair, amount,
+	price float64, side OrderSide, orderType OrderType, opts ...OrderOptions) (string, error) {
+	if p.tradingSwitch.IsTradingPaused(symbol) {
+		return "", ErrTradingPaused
+	}
+	return WithContext(p.IBotExchangeEx).NewOrderContext(ctx, symbol, amount, price, side, orderType, opts...)
+}
@@ -0,0 +1,53 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+func TestPausableExchange(t *testing.T) {
+	mock := &mockExchange{}
+	tradingSwitch := NewTradingSwitch()
+	exch := NewPausableExchange(mock, tradingSwitch)
+	btc := pair.NewCurrencyPair("BTC", "USD")
+	eth := pair.NewCurrencyPair("ETH", "USD")
+
+	tradingSwitch.SetPairPaused(pair.NewCurrencyPairDelimiter("btc/usd", "/"), true)
+	if _, err := exch.NewOrder(btc, 1, 1, OrderSideBuy, OrderTypeExchangeLimit); err != ErrTradingPaused {
+		t.Errorf("Test failed. Expected ErrTradingPaused for a paused pair but got %v", err)
+	}
+	if _, err := exch.NewOrder(eth, 1, 1, OrderSideBuy, OrderTypeExchangeLimit); err != nil {
+		t.Errorf("Test failed. NewOrder returned an error for a pair that isn't paused: %s", err)
+	}
+
+	tradingSwitch.SetPaused(true)
+	if _, err := exch.NewOrder(eth, 1, 1, OrderSideBuy, OrderTypeExchangeLimit); err != ErrTradingPaused {
+		t.Errorf("Test failed. Expected ErrTradingPaused while the exchange is paused but got %v", err)
+	}
+
+	tradingSwitch.SetPaused(false)
+	tradingSwitch.SetPausedPairs([]pair.CurrencyPair{eth})
+	if paused := tradingSwitch.PausedPairs(); len(paused) != 1 || !paused[0].Equal(eth) {
+		t.Errorf("Test failed. Unexpected paused pairs %v", paused)
+	}
+	if _, err := exch.NewOrder(btc, 1, 1, OrderSideBuy, OrderTypeExchangeLimit); err != nil {
+		t.Errorf("Test failed. NewOrder returned an error after the pair was resumed: %s", err)
+	}
+	if mock.orders != 2 {
+		t.Errorf("Test failed. Expected 2 orders to reach the exchange but got %d", mock.orders)
+	}
+
+	// Ending the maintenance doesn't resume trading paused by an operator
+	tradingSwitch.SetPaused(true)
+	tradingSwitch.SetMaintenance(true)
+	tradingSwitch.SetMaintenance(false)
+	if _, err := exch.NewOrder(btc, 1, 1, OrderSideBuy, OrderTypeExchangeLimit); err != ErrTradingPaused {
+		t.Errorf("Test failed. Expected ErrTradingPaused after the maintenance but got %v", err)
+	}
+	tradingSwitch.SetPaused(false)
+	tradingSwitch.SetMaintenance(true)
+	if _, err := exch.NewOrder(btc, 1, 1, OrderSideBuy, OrderTypeExchangeLimit); err != ErrTradingPaused {
+		t.Errorf("Test failed. Expected ErrTradingPaused during the maintenance but got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package exchange
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/config"
+)
+
+// Option customizes the config of an exchange created by one of the New constructors of the
+// exchange packages (e.g. bitfinex.New).
+type Option func(*config.ExchangeConfig)
+
+// WithClientID sets the client ID (or passphrase) required by the API keys of some exchanges.
+func WithClientID(clientID string) Option {
+	return func(c *config.ExchangeConfig) {
+		c.ClientID = clientID
+	}
+}
+
+// WithPairs enables the given pairs, formatted like the pairs in the config file of the exchange
+// (e.g. BTCUSD for Bitfinex). The pairs are also used as the available pairs until the exchange
+// fetches its markets.
+func WithPairs(pairs ...string) Option {
+	return func(c *config.ExchangeConfig) {
+		c.EnabledPairs = strings.Join(pairs, ",")
+		if c.AvailablePairs == "" {
+			c.AvailablePairs = c.EnabledPairs
+		}
+	}
+}
+
+// WithAPIURL overrides the base URL of the exchange API.
+func WithAPIURL(url string) Option {
+	return func(c *config.ExchangeConfig) {
+		c.APIURL = url
+	}
+}
+
+// WithName overrides the name of the exchange, for exchange packages that can connect to several
+// deployments of the same API (e.g. the 0x relayers).
+func WithName(name string) Option {
+	return func(c *config.ExchangeConfig) {
+		c.Name = name
+	}
+}
+
+// WithTokens adds ERC-20 tokens to the tokens a decentralized exchange can trade.
+func WithTokens(tokens ...config.TokenConfig) Option {
+	return func(c *config.ExchangeConfig) {
+		c.Tokens = append(c.Tokens, tokens...)
+	}
+}
+
+// WithEthereumNode sets the JSON-RPC endpoint of the Ethereum node decentralized exchanges read
+// wallet balances from.
+func WithEthereumNode(url string) Option {
+	return func(c *config.ExchangeConfig) {
+		c.EthereumNodeURL = url
+	}
+}
+
+// WithSandbox connects to the sandbox/testnet deployment of the exchange, if it has one.
+func WithSandbox() Option {
+	return func(c *config.ExchangeConfig) {
+		c.UseSandbox = true
+	}
+}
+
+// WithWebsocket enables the websocket feeds of the exchange, if it has any.
+func WithWebsocket() Option {
+	return func(c *config.ExchangeConfig) {
+		c.Websocket = true
+	}
+}
+
+// WithVerbose enables verbose logging of the exchange requests.
+func WithVerbose() Option {
+	return func(c *config.ExchangeConfig) {
+		c.Verbose = true
+	}
+}
+
+// WithPollingDelay sets the delay (in seconds) between the REST requests of the updater routines.
+func WithPollingDelay(delay time.Duration) Option {
+	return func(c *config.ExchangeConfig) {
+		c.RESTPollingDelay = delay
+	}
+}
+
+// Quickstart sets up an exchange without a config file, it must be called after SetDefaults with
+// the Setup method of the exchange. The exchange is enabled with the default pair formats, the
+// authenticated API is enabled if an API key is given. The resulting config is added to the
+// global config (replacing any existing config of the exchange), since the exchanges read their
+// pair formats & asset types from it.
+func (e *Base) Quickstart(setup func(config.ExchangeConfig), apiKey, apiSecret string, opts ...Option) {
+	exchCfg := config.ExchangeConfig{
+		Name:                    e.Name,
+		Enabled:                 true,
+		RESTPollingDelay:        e.RESTPollingDelay,
+		AuthenticatedAPISupport: apiKey != "",
+		APIKey:                  apiKey,
+		APISecret:               apiSecret,
+	}
+	for _, opt := range opts {
+		opt(&exchCfg)
+	}
+
+	cfg := config.GetConfig()
+	if cfg.UpdateExchangeConfig(exchCfg) != nil {
+		cfg.Exchanges = append(cfg.Exchanges, exchCfg)
+	}
+	setup(exchCfg)
+
+	// No pairs are configured unless WithPairs is used, the exchanges split the empty string into
+	// a single empty pair.
+	e.BaseCurrencies = nonEmptyStrings(e.BaseCurrencies)
+	e.AvailablePairs = nonEmptyStrings(e.AvailablePairs)
+	e.EnabledPairs = nonEmptyStrings(e.EnabledPairs)
+}
+
+func nonEmptyStrings(values []string) []string {
+	var result []string
+	for _, v := range values {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/config"
+)
+
+type quickstartExchange struct {
+	Base
+}
+
+func (q *quickstartExchange) SetDefaults() {
+	q.Name = "Quickstart"
+	q.RESTPollingDelay = 10
+	q.RequestCurrencyPairFormat.Delimiter = "_"
+	q.ConfigCurrencyPairFormat.Delimiter = "-"
+	q.ConfigCurrencyPairFormat.Uppercase = true
+	q.AssetTypes = []string{"SPOT"}
+}
+
+func (q *quickstartExchange) Setup(exch config.ExchangeConfig) {
+	q.Enabled = exch.Enabled
+	q.AuthenticatedAPISupport = exch.AuthenticatedAPISupport
+	q.SetAPIKeys(exch.APIKey, exch.APISecret, exch.ClientID, false)
+	q.RESTPollingDelay = exch.RESTPollingDelay
+	q.SetAPIURL(exch)
+	q.BaseCurrencies = common.SplitStrings(exch.BaseCurrencies, ",")
+	q.AvailablePairs = common.SplitStrings(exch.AvailablePairs, ",")
+	q.EnabledPairs = common.SplitStrings(exch.EnabledPairs, ",")
+	if err := q.SetCurrencyPairFormat(); err != nil {
+		panic(err)
+	}
+	if err := q.SetAssetTypes(); err != nil {
+		panic(err)
+	}
+}
+
+func TestQuickstart(t *testing.T) {
+	q := &quickstartExchange{}
+	q.SetDefaults()
+	q.Quickstart(q.Setup, "", "")
+	if !q.IsEnabled() || q.GetAuthenticatedAPISupport() || q.RESTPollingDelay != 10 {
+		t.Errorf("Test failed. Unexpected settings enabled %v authenticated %v delay %v", q.IsEnabled(),
+			q.GetAuthenticatedAPISupport(), q.RESTPollingDelay)
+	}
+	if len(q.EnabledPairs) != 0 || len(q.AvailablePairs) != 0 || len(q.GetEnabledCurrencies()) != 0 {
+		t.Errorf("Test failed. Expected no pairs, got %v %v", q.EnabledPairs, q.AvailablePairs)
+	}
+	exchCfg, err := config.GetConfig().GetExchangeConfig("Quickstart")
+	if err != nil {
+		t.Fatalf("Test failed. Expected the exchange config to be added, got %s", err)
+	}
+	if exchCfg.RequestCurrencyPairFormat == nil || exchCfg.RequestCurrencyPairFormat.Delimiter != "_" {
+		t.Error("Test failed. Expected the default pair formats in the config")
+	}
+
+	q = &quickstartExchange{}
+	q.SetDefaults()
+	q.Quickstart(q.Setup, "key", "secret", WithClientID("id"), WithPairs("BTC-USD", "ETH-BTC"),
+		WithAPIURL("http://localhost"), WithSandbox(), WithPollingDelay(5))
+	if !q.GetAuthenticatedAPISupport() || q.APIKey != "key" || q.APISecret != "secret" ||
+		q.ClientID != "id" || q.APIUrl != "http://localhost" || !q.GetCapabilities().Testnet ||
+		q.RESTPollingDelay != 5 {
+		t.Error("Test failed. Options weren't applied")
+	}
+	pairs := q.GetEnabledCurrencies()
+	if len(pairs) != 2 || pairs[1].FirstCurrency != "ETH" || len(q.AvailablePairs) != 2 {
+		t.Errorf("Test failed. Unexpected pairs %v", pairs)
+	}
+	count := 0
+	for _, exch := range config.GetConfig().Exchanges {
+		if exch.Name == "Quickstart" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("Test failed. Expected the exchange config to be replaced, got %d configs", count)
+	}
+}
@@ -0,0 +1,81 @@
+package exchange
+
+import (
+	"fmt"
+	"time"
+)
+
+// RateLimitedWarning is returned along with cached data by exchange methods that skipped a
+// request to stay within the rate limits. It records where the cached data came from and when it
+// was fetched, so callers can decide whether the data is fresh enough for their purposes.
+type RateLimitedWarning struct {
+	Exchange string
+	// API endpoint (path) the cached data was fetched from
+	Endpoint string
+	// When the cached data was fetched, zero if nothing has been fetched yet (in which case the
+	// data is empty)
+	FetchedAt time.Time
+}
+
+// NewRateLimitedWarning creates a new RateLimitedWarning.
+func NewRateLimitedWarning(exchangeName, endpoint string, fetchedAt time.Time) *RateLimitedWarning {
+	return &RateLimitedWarning{Exchange: exchangeName, Endpoint: endpoint, FetchedAt: fetchedAt}
+}
+
+// Error returns the same message as WarningHTTPRequestRateLimited.
+func (w *RateLimitedWarning) Error() string {
+	return warningHTTPRequestRateLimited.Error()
+}
+
+// String returns a description of the warning that includes the age of the cached data.
+func (w *RateLimitedWarning) String() string {
+	if w.FetchedAt.IsZero() {
+		return fmt.Sprintf("%s %s request was rate limited, no cached data", w.Exchange, w.Endpoint)
+	}
+	return fmt.Sprintf("%s %s request was rate limited, cached data is %s old", w.Exchange, w.Endpoint,
+		w.Age().Round(time.Millisecond))
+}
+
+// Age returns how long ago the cached data was fetched, or -1 if no data was fetched.
+func (w *RateLimitedWarning) Age() time.Duration {
+	if w.FetchedAt.IsZero() {
+		return -1
+	}
+	return time.Since(w.FetchedAt)
+}
+
+// IsRateLimited returns true if the error is WarningHTTPRequestRateLimited or a
+// RateLimitedWarning, i.e. the method returned cached data instead of failing.
+func IsRateLimited(err error) bool {
+	if err == warningHTTPRequestRateLimited {
+		return true
+	}
+	_, ok := err.(*RateLimitedWarning)
+	return ok
+}
+
+// CachedDataAge returns the age of the cached data returned with a rate limited warning, ok is
+// false if the error isn't a RateLimitedWarning or no data had been cached.
+func CachedDataAge(err error) (age time.Duration, ok bool) {
+	w, isWarning := err.(*RateLimitedWarning)
+	if !isWarning || w.FetchedAt.IsZero() {
+		return 0, false
+	}
+	return w.Age(), true
+}
+
+// OldestRateLimitedWarning returns the warning with the oldest cached data, which is the one that
+// determines how stale a combination of cached responses is. Data that was never fetched is
+// considered older than any cached data.
+func OldestRateLimitedWarning(warnings ...*RateLimitedWarning) *RateLimitedWarning {
+	var oldest *RateLimitedWarning
+	for _, w := range warnings {
+		if w == nil {
+			continue
+		}
+		if oldest == nil || (!oldest.FetchedAt.IsZero() && w.FetchedAt.Before(oldest.FetchedAt)) {
+			oldest = w
+		}
+	}
+	return oldest
+}
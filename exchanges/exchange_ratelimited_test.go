@@ -0,0 +1,44 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedWarning(t *testing.T) {
+	fetchedAt := time.Now().Add(-time.Minute)
+	var err error = NewRateLimitedWarning("Binance", "api/v3/account", fetchedAt)
+	if !IsRateLimited(err) || !IsRateLimited(WarningHTTPRequestRateLimited()) || IsRateLimited(errors.New("nonce")) {
+		t.Error("Test failed. IsRateLimited returned an unexpected result")
+	}
+	if err.Error() != WarningHTTPRequestRateLimited().Error() {
+		t.Errorf("Test failed. Unexpected error message %s", err)
+	}
+	age, ok := CachedDataAge(err)
+	if !ok || age < time.Minute || age > time.Minute+time.Second {
+		t.Errorf("Test failed. Expected the cached data to be a minute old, got %s", age)
+	}
+	if _, ok = CachedDataAge(NewRateLimitedWarning("Binance", "api/v3/account", time.Time{})); ok {
+		t.Error("Test failed. Expected no age if nothing was cached")
+	}
+	if _, ok = CachedDataAge(WarningHTTPRequestRateLimited()); ok {
+		t.Error("Test failed. Expected no age for the untyped warning")
+	}
+}
+
+func TestOldestRateLimitedWarning(t *testing.T) {
+	now := time.Now()
+	recent := NewRateLimitedWarning("Binance", "api/v3/openOrders", now)
+	old := NewRateLimitedWarning("Binance", "api/v3/openOrders", now.Add(-time.Hour))
+	never := NewRateLimitedWarning("Binance", "api/v3/openOrders", time.Time{})
+	if w := OldestRateLimitedWarning(recent, nil, old); w != old {
+		t.Errorf("Test failed. Expected the oldest warning, got %s", w)
+	}
+	if w := OldestRateLimitedWarning(recent, never, old); w != never {
+		t.Errorf("Test failed. Expected data that was never fetched to be the oldest, got %s", w)
+	}
+	if w := OldestRateLimitedWarning(); w != nil {
+		t.Errorf("Test failed. Expected no warning, got %s", w)
+	}
+}
@@ -0,0 +1,97 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+// ErrReadOnly is returned by the authenticated methods of exchanges running in read-only mode.
+var ErrReadOnly = errors.New("exchange is in read-only mode")
+
+// ReadOnlyExchange wraps an exchange so that only its public (market data) endpoints can be
+// used. The exchange is set up without any credentials, and all authenticated methods fail with
+// ErrReadOnly instead of hitting the exchange API.
+type ReadOnlyExchange struct {
+	Decorator
+}
+
+// NewReadOnlyExchange returns a read-only wrapper for the given exchange.
+func NewReadOnlyExchange(exch IBotExchangeEx) *ReadOnlyExchange {
+	return &ReadOnlyExchange{Decorator: Decorator{IBotExchangeEx: exch}}
+}
+
+// Setup sets up the underlying exchange with the credentials stripped from the config.
+func (r *ReadOnlyExchange) Setup(exch config.ExchangeConfig) {
+	exch.AuthenticatedAPISupport = false
+	exch.APIKey = ""
+	exch.APISecret = ""
+	exch.ClientID = ""
+	r.IBotExchangeEx.Setup(exch)
+}
+
+// GetAuthenticatedAPISupport always returns false.
+func (r *ReadOnlyExchange) GetAuthenticatedAPISupport() bool {
+	return false
+}
+
+// GetExchangeAccountInfo returns ErrReadOnly.
+func (r *ReadOnlyExchange) GetExchangeAccountInfo() (AccountInfo, error) {
+	return AccountInfo{}, ErrReadOnly
+}
+
+// RotateAPIKeys returns ErrReadOnly.
+func (r *ReadOnlyExchange) RotateAPIKeys(apiKey, apiSecret, clientID string, verify func() error) error {
+	return ErrReadOnly
+}
+
+// NewOrder returns ErrReadOnly.
+func (r *ReadOnlyExchange) NewOrder(symbol pair.CurrencyPair, amount, price float64, side OrderSide,
+	orderType OrderType, opts ...OrderOptions) (string, error) {
+	return "", ErrReadOnly
+}
+
+// CancelOrder returns ErrReadOnly.
+func (r *ReadOnlyExchange) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return ErrReadOnly
+}
+
+// GetOrder returns ErrReadOnly.
+func (r *ReadOnlyExchange) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*Order, error) {
+	return nil, ErrReadOnly
+}
+
+// GetOrders returns ErrReadOnly.
+func (r *ReadOnlyExchange) GetOrders(pairs []pair.CurrencyPair) ([]*Order, error) {
+	return nil, ErrReadOnly
+}
+
+// GetExchangeAccountInfoContext returns ErrReadOnly.
+func (r *ReadOnlyExchange) GetExchangeAccountInfoContext(ctx context.Context) (AccountInfo, error) {
+	return AccountInfo{}, ErrReadOnly
+}
+
+// NewOrderContext returns ErrReadOnly.
+func (r *ReadOnlyExchange) NewOrderContext(ctx context.Context, symbol pair.CurrencyPair, amount,
+	price float64, side OrderSide, orderType OrderType, opts ...OrderOptions) (string, error) {
+	return "", ErrReadOnly
+}
+
+// CancelOrderContext returns ErrReadOnly.
+func (r *ReadOnlyExchange) CancelOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) error {
+	return ErrReadOnly
+}
+
+// GetOrderContext returns ErrReadOnly.
+func (r *ReadOnlyExchange) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*Order, error) {
+	return nil, ErrReadOnly
+}
+
+// GetOrdersContext returns ErrReadOnly.
+func (r *ReadOnlyExchange) GetOrdersContext(ctx context.Context, pairs []pair.CurrencyPair) ([]*Order, error) {
+	return nil, ErrReadOnly
+}
@@ -0,0 +1,66 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+type setupRecorder struct {
+	mockExchange
+	cfg config.ExchangeConfig
+}
+
+func (s *setupRecorder) Setup(exch config.ExchangeConfig) {
+	s.cfg = exch
+}
+
+func TestReadOnlyExchange(t *testing.T) {
+	mock := &setupRecorder{}
+	readOnly := NewReadOnlyExchange(mock)
+	p := pair.NewCurrencyPair("BTC", "USD")
+
+	readOnly.Setup(config.ExchangeConfig{
+		Name:                    "Mock",
+		AuthenticatedAPISupport: true,
+		APIKey:                  "key",
+		APISecret:               "secret",
+		ClientID:                "client",
+	})
+	if mock.cfg.Name != "Mock" {
+		t.Error("Test failed. Expected Setup to be passed through to the exchange")
+	}
+	if mock.cfg.AuthenticatedAPISupport || mock.cfg.APIKey != "" || mock.cfg.APISecret != "" ||
+		mock.cfg.ClientID != "" {
+		t.Errorf("Test failed. Expected credentials to be stripped, got %+v", mock.cfg)
+	}
+	if readOnly.GetAuthenticatedAPISupport() {
+		t.Error("Test failed. Expected no authenticated API support")
+	}
+
+	if _, err := readOnly.NewOrder(p, 1, 1, OrderSideBuy, OrderTypeExchangeLimit); err != ErrReadOnly {
+		t.Errorf("Test failed. NewOrder expected ErrReadOnly, got %v", err)
+	}
+	if mock.orders != 0 {
+		t.Error("Test failed. Order shouldn't have reached the exchange")
+	}
+	if err := readOnly.CancelOrder("1", p); err != ErrReadOnly {
+		t.Errorf("Test failed. CancelOrder expected ErrReadOnly, got %v", err)
+	}
+	if _, err := readOnly.GetOrder("1", p); err != ErrReadOnly {
+		t.Errorf("Test failed. GetOrder expected ErrReadOnly, got %v", err)
+	}
+	if _, err := readOnly.GetOrders(nil); err != ErrReadOnly {
+		t.Errorf("Test failed. GetOrders expected ErrReadOnly, got %v", err)
+	}
+	if _, err := readOnly.GetExchangeAccountInfo(); err != ErrReadOnly {
+		t.Errorf("Test failed. GetExchangeAccountInfo expected ErrReadOnly, got %v", err)
+	}
+	if err := readOnly.RotateAPIKeys("a", "b", "", nil); err != ErrReadOnly {
+		t.Errorf("Test failed. RotateAPIKeys expected ErrReadOnly, got %v", err)
+	}
+	if readOnly.GetName() != "Mock" {
+		t.Error("Test failed. Expected public methods to be passed through")
+	}
+}
@@ -0,0 +1,305 @@
+package exchange
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+// ErrorClass describes how a failed request should be handled
+type ErrorClass int
+
+// Error classes
+const (
+	// The request shouldn't be retried (e.g. invalid API key, insufficient funds)
+	ErrorClassFatal ErrorClass = iota
+	// The request failed due to a transient problem and can be retried immediately, the request
+	// may have been processed by the exchange (e.g. a timeout or internal server error).
+	ErrorClassRetryable
+	// The exchange rejected the request because it's overloaded, rate limiting or under
+	// maintenance, the request can be retried after backing off.
+	ErrorClassBackoff
+	// The exchange rejected the request because the local clock or nonce is out of sync, the
+	// request can be retried once the exchange has been resynchronized (see ClockSyncer).
+	ErrorClassResync
+)
+
+// String returns the name of the error class.
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorClassRetryable:
+		return "retryable"
+	case ErrorClassBackoff:
+		return "backoff"
+	case ErrorClassResync:
+		return "resync"
+	}
+	return "fatal"
+}
+
+// ClassifyError returns the class of an error returned by an exchange, the class is derived from
+// the kind of the error (see ErrorKind). Errors of an unknown kind are classified by HTTP status
+// code (ExchangeError only), network errors are retryable, and all other errors are fatal.
+func ClassifyError(exchangeName string, err error) ErrorClass {
+	// Cached data was returned along with the warning, so there's nothing to retry
+	if IsRateLimited(err) {
+		return ErrorClassFatal
+	}
+	switch ErrorKind(exchangeName, err) {
+	case ErrRateLimited, ErrExchangeMaintenance:
+		return ErrorClassBackoff
+	case ErrInvalidNonce:
+		return ErrorClassResync
+	case ErrExchangeInternal:
+		return ErrorClassRetryable
+	case nil:
+	default:
+		return ErrorClassFatal
+	}
+
+	if exchErr, ok := err.(*ExchangeError); ok {
+		switch exchErr.StatusCode {
+		case http.StatusTeapot, http.StatusServiceUnavailable:
+			return ErrorClassBackoff
+		case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+			return ErrorClassRetryable
+		}
+	}
+	if _, ok := err.(net.Error); ok {
+		return ErrorClassRetryable
+	}
+	return ErrorClassFatal
+}
+
+// ClockSyncer is implemented by exchanges that can resynchronize their request timestamps or
+// nonces with the exchange servers.
+type ClockSyncer interface {
+	SyncClock() error
+}
+
+// clockSyncer returns the ClockSyncer of the exchange, the decorators wrapping the exchange are
+// unwrapped until one is found.
+func clockSyncer(exch IBotExchange) (ClockSyncer, bool) {
+	for ; exch != nil; exch = Unwrap(exch) {
+		if syncer, ok := exch.(ClockSyncer); ok {
+			return syncer, true
+		}
+	}
+	return nil, false
+}
+
+// RetryPolicy controls how many times the requests made through a RetryingExchange are retried,
+// and how long to back off for between attempts.
+type RetryPolicy struct {
+	// Max number of attempts, including the first one
+	MaxAttempts int
+	// Delay before the first retry of a backoff error, doubled for each subsequent retry
+	Backoff time.Duration
+	// Max delay between attempts
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy used by exchanges that don't configure their own.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	Backoff:     time.Second,
+	MaxBackoff:  30 * time.Second,
+}
+
+// RetryingExchange wraps an exchange and retries failed requests based on the class of the
+// error. Orders are only retried if the exchange is known to have rejected the request (backoff
+// and resync errors), so a retry never creates a duplicate order.
+type RetryingExchange struct {
+	Decorator
+	policy RetryPolicy
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+// NewRetryingExchange returns a wrapper that retries the failed requests of the exchange.
+func NewRetryingExchange(exch IBotExchangeEx, policy RetryPolicy) *RetryingExchange {
+	return &RetryingExchange{Decorator: Decorator{IBotExchangeEx: exch}, policy: policy, sleep: sleepContext}
+}
+
+// sleepContext sleeps for d, or until ctx is done in which case ctx.Err() is returned.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retry calls fn until it succeeds or the error can't be retried, the retries stop as soon as
+// ctx is done.
+func (r *RetryingExchange) retry(ctx context.Context, idempotent bool, fn func() error) error {
+	backoff := r.policy.Backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || attempt >= r.policy.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+		switch ClassifyError(r.GetName(), err) {
+		case ErrorClassRetryable:
+			if !idempotent {
+				return err
+			}
+		case ErrorClassBackoff:
+			if r.sleep(ctx, backoff) != nil {
+				return err
+			}
+			if backoff *= 2; r.policy.MaxBackoff > 0 && backoff > r.policy.MaxBackoff {
+				backoff = r.policy.MaxBackoff
+			}
+		case ErrorClassResync:
+			syncer, ok := clockSyncer(r.IBotExchangeEx)
+			if !ok {
+				return err
+			}
+			if syncErr := syncer.SyncClock(); syncErr != nil {
+				return err
+			}
+		default:
+			return err
+		}
+	}
+}
+
+// GetTickerPrice returns the ticker for a currency pair, retrying failed requests.
+func (r *RetryingExchange) GetTickerPrice(currencyPair pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	var result ticker.Price
+	err := r.retry(context.Background(), true, func() (err error) {
+		result, err = r.IBotExchangeEx.GetTickerPrice(currencyPair, assetType)
+		return err
+	})
+	return result, err
+}
+
+// GetOrderbookEx returns the orderbook for a currency pair, retrying failed requests.
+func (r *RetryingExchange) GetOrderbookEx(currencyPair pair.CurrencyPair, assetType string,
+	opts ...OrderbookOptions) (orderbook.Base, error) {
+	var result orderbook.Base
+	err := r.retry(context.Background(), true, func() (err error) {
+		result, err = r.IBotExchangeEx.GetOrderbookEx(currencyPair, assetType, opts...)
+		return err
+	})
+	return result, err
+}
+
+// UpdateTicker updates and returns the ticker for a currency pair, retrying failed requests.
+func (r *RetryingExchange) UpdateTicker(currencyPair pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return r.UpdateTickerContext(context.Background(), currencyPair, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the requests are cancelled when the context is done
+func (r *RetryingExchange) UpdateTickerContext(ctx context.Context, currencyPair pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
+	var result ticker.Price
+	err := r.retry(ctx, true, func() (err error) {
+		result, err = WithContext(r.IBotExchangeEx).UpdateTickerContext(ctx, currencyPair, assetType)
+		return err
+	})
+	return result, err
+}
+
+// UpdateOrderbook updates and returns the orderbook for a currency pair, retrying failed requests.
+func (r *RetryingExchange) UpdateOrderbook(currencyPair pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return r.UpdateOrderbookContext(context.Background(), currencyPair, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the requests are cancelled when the context is done
+func (r *RetryingExchange) UpdateOrderbookContext(ctx context.Context, currencyPair pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
+	var result orderbook.Base
+	err := r.retry(ctx, true, func() (err error) {
+		result, err = WithContext(r.IBotExchangeEx).UpdateOrderbookContext(ctx, currencyPair, assetType)
+		return err
+	})
+	return result, err
+}
+
+// GetExchangeAccountInfo returns the account balances, retrying failed requests.
+func (r *RetryingExchange) GetExchangeAccountInfo() (AccountInfo, error) {
+	return r.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the requests are cancelled when the
+// context is done
+func (r *RetryingExchange) GetExchangeAccountInfoContext(ctx context.Context) (AccountInfo, error) {
+	var result AccountInfo
+	err := r.retry(ctx, true, func() (err error) {
+		result, err = WithContext(r.IBotExchangeEx).GetExchangeAccountInfoContext(ctx)
+		return err
+	})
+	return result, err
+}
+
+// NewOrder creates a new order, retrying requests the exchange rejected due to rate limiting,
+// maintenance or clock drift.
+func (r *RetryingExchange) NewOrder(symbol pair.CurrencyPair, amount, price float64, side OrderSide,
+	orderType OrderType, opts ...OrderOptions) (string, error) {
+	return r.NewOrderContext(context.Background(), symbol, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the requests are cancelled when the context is done
+func (r *RetryingExchange) NewOrderContext(ctx context.Context, symbol pair.CurrencyPair, amount,
+	price float64, side OrderSide, orderType OrderType, opts ...OrderOptions) (string, error) {
+	var result string
+	err := r.retry(ctx, false, func() (err error) {
+		result, err = WithContext(r.IBotExchangeEx).NewOrderContext(ctx, symbol, amount, price, side,
+			orderType, opts...)
+		return err
+	})
+	return result, err
+}
+
+// CancelOrder cancels an order, retrying failed requests.
+func (r *RetryingExchange) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return r.CancelOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the requests are cancelled when the context is done
+func (r *RetryingExchange) CancelOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) error {
+	return r.retry(ctx, true, func() error {
+		return WithContext(r.IBotExchangeEx).CancelOrderContext(ctx, orderID, currencyPair)
+	})
+}
+
+// GetOrder returns information about an order, retrying failed requests.
+func (r *RetryingExchange) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*Order, error) {
+	return r.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the requests are cancelled when the context is done
+func (r *RetryingExchange) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*Order, error) {
+	var result *Order
+	err := r.retry(ctx, true, func() (err error) {
+		result, err = WithContext(r.IBotExchangeEx).GetOrderContext(ctx, orderID, currencyPair)
+		return err
+	})
+	return result, err
+}
+
+// GetOrders returns the active orders, retrying failed requests.
+func (r *RetryingExchange) GetOrders(pairs []pair.CurrencyPair) ([]*Order, error) {
+	return r.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the requests are cancelled when the context is done
+func (r *RetryingExchange) GetOrdersContext(ctx context.Context, pairs []pair.CurrencyPair) ([]*Order, error) {
+	var result []*Order
+	err := r.retry(ctx, true, func() (err error) {
+		result, err = WithContext(r.IBotExchangeEx).GetOrdersContext(ctx, pairs)
+		return err
+	})
+	return result, err
+}
@@ -0,0 +1,120 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		exchange string
+		err      error
+		class    ErrorClass
+	}{
+		{"Binance", NewExchangeError("Binance", "api/v3/order", 400, -1021, "Timestamp outside recvWindow", ""), ErrorClassResync},
+		{"Binance", NewExchangeError("Binance", "api/v3/order", 401, -2015, "Invalid API-key", ""), ErrorClassFatal},
+		{"Binance", NewExchangeError("Binance", "api/v3/order", 429, -1003, "Too many requests", ""), ErrorClassBackoff},
+		{"Bitfinex", NewExchangeError("Bitfinex", "auth/w/order/submit", 500, 20060, "maintenance", ""), ErrorClassBackoff},
+		{"Bitfinex", errors.New("ERR_RATE_LIMIT"), ErrorClassBackoff},
+		{"Kraken", NewExchangeError("Kraken", "AddOrder", 200, 0, "EAPI:Invalid nonce", ""), ErrorClassResync},
+		{"Kraken", NewExchangeError("Kraken", "AddOrder", 200, 0, "EAPI:Invalid key", ""), ErrorClassFatal},
+		{"Other", NewExchangeError("Other", "order", http.StatusBadGateway, 0, "bad gateway", ""), ErrorClassRetryable},
+		{"Other", NewExchangeError("Other", "order", http.StatusServiceUnavailable, 0, "unavailable", ""), ErrorClassBackoff},
+		{"Other", NewExchangeError("Other", "order", http.StatusBadRequest, 0, "insufficient funds", ""), ErrorClassFatal},
+		{"Other", errors.New("unknown"), ErrorClassFatal},
+		{"Other", NewRateLimitedWarning("Other", "balances", time.Now()), ErrorClassFatal},
+		{"Binance", NewExchangeError("Binance", "api/v3/order", 500, -1001, "Internal error", ""), ErrorClassRetryable},
+	}
+	for _, test := range tests {
+		if class := ClassifyError(test.exchange, test.err); class != test.class {
+			t.Errorf("Test failed. Expected %s error %q to be %s, got %s", test.exchange, test.err, test.class, class)
+		}
+	}
+
+	RegisterErrorKinds("Other", ErrorKindRule{Message: "trading halted", Kind: ErrExchangeMaintenance})
+	err := NewExchangeError("Other", "order", http.StatusBadRequest, 0, "trading halted", "")
+	if class := ClassifyError("Other", err); class != ErrorClassBackoff {
+		t.Errorf("Test failed. Expected registered rule to apply, got %s", class)
+	}
+}
+
+type mockRetryExchange struct {
+	mockExchange
+	errs  []error
+	calls int
+	syncs int
+}
+
+func (m *mockRetryExchange) GetName() string {
+	return "Binance"
+}
+
+func (m *mockRetryExchange) NewOrder(symbol pair.CurrencyPair, amount, price float64, side OrderSide,
+	orderType OrderType, opts ...OrderOptions) (string, error) {
+	return "1", m.next()
+}
+
+func (m *mockRetryExchange) GetOrders(pairs []pair.CurrencyPair) ([]*Order, error) {
+	return nil, m.next()
+}
+
+func (m *mockRetryExchange) next() error {
+	m.calls++
+	if len(m.errs) == 0 {
+		return nil
+	}
+	err := m.errs[0]
+	m.errs = m.errs[1:]
+	return err
+}
+
+func (m *mockRetryExchange) SyncClock() error {
+	m.syncs++
+	return nil
+}
+
+func TestRetryingExchange(t *testing.T) {
+	mock := &mockRetryExchange{}
+	r := NewRetryingExchange(mock, RetryPolicy{MaxAttempts: 3, Backoff: time.Second, MaxBackoff: 3 * time.Second})
+	var slept []time.Duration
+	r.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	p := pair.NewCurrencyPair("BTC", "USDT")
+	rateLimited := NewExchangeError("Binance", "api/v3/order", 429, -1003, "Too many requests", "")
+	timestamp := NewExchangeError("Binance", "api/v3/order", 400, -1021, "Timestamp outside recvWindow", "")
+	internal := NewExchangeError("Binance", "api/v3/order", 500, 0, "internal error", "")
+
+	mock.errs = []error{rateLimited, timestamp}
+	if _, err := r.NewOrder(p, 1, 1, OrderSideBuy, OrderTypeExchangeLimit); err != nil {
+		t.Errorf("Test failed. Expected NewOrder to succeed after retrying, got %s", err)
+	}
+	if mock.calls != 3 || mock.syncs != 1 || len(slept) != 1 || slept[0] != time.Second {
+		t.Errorf("Test failed. Unexpected retries: %d calls, %d syncs, slept %v", mock.calls, mock.syncs, slept)
+	}
+
+	// orders aren't retried when the exchange may have processed the request
+	mock.calls, mock.errs = 0, []error{internal}
+	if _, err := r.NewOrder(p, 1, 1, OrderSideBuy, OrderTypeExchangeLimit); err != internal || mock.calls != 1 {
+		t.Errorf("Test failed. Expected NewOrder not to be retried, got %v after %d calls", err, mock.calls)
+	}
+	mock.calls, mock.errs = 0, []error{internal}
+	if _, err := r.GetOrders(nil); err != nil || mock.calls != 2 {
+		t.Errorf("Test failed. Expected GetOrders to be retried, got %v after %d calls", err, mock.calls)
+	}
+
+	slept = nil
+	mock.calls, mock.errs = 0, []error{rateLimited, rateLimited, rateLimited}
+	if _, err := r.GetOrders(nil); err != rateLimited || mock.calls != 3 {
+		t.Errorf("Test failed. Expected GetOrders to give up after 3 attempts, got %v after %d calls", err, mock.calls)
+	}
+	if len(slept) != 2 || slept[1] != 2*time.Second {
+		t.Errorf("Test failed. Expected exponential backoff, slept %v", slept)
+	}
+}
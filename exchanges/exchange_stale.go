@@ -0,0 +1,59 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+// ErrStaleMarketData is returned by a StalePriceGuard when the market data for the currency pair
+// of an order hasn't been updated recently enough.
+var ErrStaleMarketData = errors.New("market data is stale")
+
+// StalePriceGuard wraps an exchange and blocks new orders for currency pairs whose ticker and
+// orderbook haven't been updated within the max age, so orders aren't placed against a dead
+// feed (e.g. after a websocket connection silently stalls).
+type StalePriceGuard struct {
+	Decorator
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+// NewStalePriceGuard returns a wrapper that blocks orders when the market data of the exchange is
+// older than maxAge.
+func NewStalePriceGuard(exch IBotExchangeEx, maxAge time.Duration) *StalePriceGuard {
+	return &StalePriceGuard{Decorator: Decorator{IBotExchangeEx: exch}, maxAge: maxAge, now: time.Now}
+}
+
+// LastUpdated returns the time the ticker or orderbook of the currency pair was last updated,
+// whichever is most recent. The zero time is returned if there's no market data for the pair.
+func (s *StalePriceGuard) LastUpdated(symbol pair.CurrencyPair) time.Time {
+	var lastUpdated time.Time
+	if tick, err := ticker.GetTicker(s.GetName(), symbol, ticker.Spot); err == nil {
+		lastUpdated = tick.LastUpdated
+	}
+	if ob, err := s.GetOrderbookSimple(symbol, orderbook.Spot); err == nil && ob.LastUpdated.After(lastUpdated) {
+		lastUpdated = ob.LastUpdated
+	}
+	return lastUpdated
+}
+
+// NewOrder submits a new order to the exchange, or returns ErrStaleMarketData without contacting
+// the exchange if the market data for the currency pair is stale.
+func (s *StalePriceGuard) NewOrder(symbol pair.CurrencyPair, amount, price float64, side OrderSide,
+	orderType OrderType, opts ...OrderOptions) (string, error) {
+	return s.NewOrderContext(context.Background(), symbol, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (s *StalePriceGuard) NewOrderContext(ctx context.Context, symbol pair.CurrencyPair, amount,
+	price float64, side OrderSide, orderType OrderType, opts ...OrderOptions) (string, error) {
+	if lastUpdated := s.LastUpdated(symbol); s.now().Sub(lastUpdated) > s.maxAge {
+		return "", ErrStaleMarketData
+	}
+	return WithContext(s.IBotExchangeEx).NewOrderContext(ctx, symbol, amount, price, side, orderType, opts...)
+}
@@ -0,0 +1,54 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+type mockOrderbookExchange struct {
+	mockExchange
+	orderbooks map[string]orderbook.Base
+}
+
+func (m *mockOrderbookExchange) GetOrderbookSimple(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	ob, ok := m.orderbooks[p.Pair().String()]
+	if !ok {
+		return ob, errors.New("orderbook not found")
+	}
+	return ob, nil
+}
+
+func TestStalePriceGuard(t *testing.T) {
+	now := time.Now()
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+	ethusd := pair.NewCurrencyPair("ETH", "USD")
+	ltcusd := pair.NewCurrencyPair("LTC", "USD")
+	ticker.ProcessTicker("Mock", btcusd, ticker.Price{Last: 5000, LastUpdated: now.Add(-2 * time.Minute)},
+		ticker.Spot)
+	mock := &mockOrderbookExchange{
+		orderbooks: map[string]orderbook.Base{
+			"BTCUSD": {LastUpdated: now.Add(-10 * time.Second)},
+			"ETHUSD": {LastUpdated: now.Add(-time.Hour)},
+		},
+	}
+	guard := NewStalePriceGuard(mock, time.Minute)
+	guard.now = func() time.Time { return now }
+
+	if _, err := guard.NewOrder(btcusd, 1, 5000, OrderSideBuy, OrderTypeExchangeLimit); err != nil {
+		t.Errorf("Test failed. NewOrder returned an error with fresh market data: %s", err)
+	}
+	if _, err := guard.NewOrder(ethusd, 1, 500, OrderSideBuy, OrderTypeExchangeLimit); err != ErrStaleMarketData {
+		t.Errorf("Test failed. Expected ErrStaleMarketData but got %v", err)
+	}
+	if _, err := guard.NewOrder(ltcusd, 1, 50, OrderSideBuy, OrderTypeExchangeLimit); err != ErrStaleMarketData {
+		t.Errorf("Test failed. Expected ErrStaleMarketData without market data but got %v", err)
+	}
+	if mock.orders != 1 {
+		t.Errorf("Test failed. Expected 1 order to reach the exchange but got %d", mock.orders)
+	}
+}
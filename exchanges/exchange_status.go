@@ -0,0 +1,135 @@
+package exchange
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaintenanceWindow is a period of planned maintenance announced by an exchange
+type MaintenanceWindow struct {
+	Name  string    `json:"name"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// PlatformStatus is the status of an exchange as reported by its status page or status API
+type PlatformStatus struct {
+	// False if the exchange is currently down or under maintenance
+	Operational bool `json:"operational"`
+	// Planned maintenance that hasn't finished yet
+	Maintenance []MaintenanceWindow `json:"maintenance,omitempty"`
+}
+
+// StatusProvider is implemented by exchanges that publish their platform status
+type StatusProvider interface {
+	GetPlatformStatus() (PlatformStatus, error)
+}
+
+// ExchangeStatus is the last known platform status of an exchange
+type ExchangeStatus struct {
+	Exchange string `json:"exchange"`
+	PlatformStatus
+	// Whether API requests should be sent to the exchange, false while the exchange is down or
+	// about to go down for planned maintenance
+	Available   bool      `json:"available"`
+	LastChecked time.Time `json:"lastChecked"`
+	// Error returned by the last status check, if any
+	Error string `json:"error,omitempty"`
+}
+
+// StatusMonitor keeps track of the platform status of exchanges so that pollers can back off
+// while an exchange is down, and shortly before planned maintenance starts.
+type StatusMonitor struct {
+	mtx       sync.RWMutex
+	providers map[string]StatusProvider
+	statuses  map[string]*ExchangeStatus
+	// How long before planned maintenance starts an exchange is considered unavailable
+	leadTime time.Duration
+	now      func() time.Time
+}
+
+// NewStatusMonitor returns a status monitor that considers exchanges unavailable from leadTime
+// before their planned maintenance starts.
+func NewStatusMonitor(leadTime time.Duration) *StatusMonitor {
+	return &StatusMonitor{
+		providers: make(map[string]StatusProvider),
+		statuses:  make(map[string]*ExchangeStatus),
+		leadTime:  leadTime,
+		now:       time.Now,
+	}
+}
+
+// AddProvider adds an exchange whose status should be checked by Poll.
+func (m *StatusMonitor) AddProvider(exchangeName string, provider StatusProvider) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.providers[exchangeName] = provider
+}
+
+// Poll checks the status of every exchange added to the monitor. If a status check fails the
+// previous status of the exchange is kept, an unreachable status page doesn't mean the exchange
+// itself is down.
+func (m *StatusMonitor) Poll() {
+	m.mtx.RLock()
+	providers := make(map[string]StatusProvider, len(m.providers))
+	for name, provider := range m.providers {
+		providers[name] = provider
+	}
+	m.mtx.RUnlock()
+
+	for name, provider := range providers {
+		status, err := provider.GetPlatformStatus()
+		m.mtx.Lock()
+		current, ok := m.statuses[name]
+		if !ok {
+			current = &ExchangeStatus{Exchange: name, PlatformStatus: PlatformStatus{Operational: true}}
+			m.statuses[name] = current
+		}
+		current.LastChecked = m.now()
+		if err != nil {
+			current.Error = err.Error()
+		} else {
+			current.PlatformStatus = status
+			current.Error = ""
+		}
+		m.mtx.Unlock()
+	}
+}
+
+func (m *StatusMonitor) available(status *ExchangeStatus, now time.Time) bool {
+	if !status.Operational {
+		return false
+	}
+	for _, w := range status.Maintenance {
+		if !now.Before(w.Start.Add(-m.leadTime)) && (w.End.IsZero() || now.Before(w.End)) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsAvailable returns false if the exchange is down, or planned maintenance is about to start or
+// is in progress. Exchanges whose status is unknown are assumed to be available.
+func (m *StatusMonitor) IsAvailable(exchangeName string) bool {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	status, ok := m.statuses[exchangeName]
+	return !ok || m.available(status, m.now())
+}
+
+// Statuses returns the last known status of every exchange that has been checked, sorted by
+// exchange name.
+func (m *StatusMonitor) Statuses() []ExchangeStatus {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	now := m.now()
+	result := make([]ExchangeStatus, 0, len(m.statuses))
+	for _, status := range m.statuses {
+		s := *status
+		s.Available = m.available(status, now)
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Exchange < result[j].Exchange })
+	return result
+}
@@ -0,0 +1,56 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type mockStatusProvider struct {
+	status PlatformStatus
+	err    error
+}
+
+func (m *mockStatusProvider) GetPlatformStatus() (PlatformStatus, error) {
+	return m.status, m.err
+}
+
+func TestStatusMonitor(t *testing.T) {
+	now := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	monitor := NewStatusMonitor(10 * time.Minute)
+	monitor.now = func() time.Time { return now }
+	provider := &mockStatusProvider{status: PlatformStatus{
+		Operational: true,
+		Maintenance: []MaintenanceWindow{{Name: "Upgrade", Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)}},
+	}}
+	monitor.AddProvider("Mock", provider)
+
+	if !monitor.IsAvailable("Mock") {
+		t.Error("Test failed. Expected exchange with an unknown status to be available")
+	}
+	monitor.Poll()
+	if !monitor.IsAvailable("Mock") {
+		t.Error("Test failed. Expected exchange to be available before the maintenance lead time")
+	}
+	now = now.Add(55 * time.Minute)
+	if monitor.IsAvailable("Mock") {
+		t.Error("Test failed. Expected exchange to be unavailable shortly before planned maintenance")
+	}
+	now = now.Add(70 * time.Minute)
+	if !monitor.IsAvailable("Mock") {
+		t.Error("Test failed. Expected exchange to be available after planned maintenance")
+	}
+
+	// failed status checks keep the previous status
+	provider.status = PlatformStatus{Operational: false}
+	monitor.Poll()
+	provider.err = errors.New("status page unreachable")
+	monitor.Poll()
+	statuses := monitor.Statuses()
+	if len(statuses) != 1 || statuses[0].Available || statuses[0].Error != "status page unreachable" {
+		t.Errorf("Test failed. Unexpected statuses %+v", statuses)
+	}
+	if monitor.IsAvailable("Mock") {
+		t.Error("Test failed. Expected exchange that isn't operational to be unavailable")
+	}
+}
@@ -0,0 +1,375 @@
+package exchange
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+// StreamKind is a set of the kinds of data an exchange can push over its websocket
+type StreamKind int
+
+// Kinds of streamed data
+const (
+	StreamOrderbook StreamKind = 1 << iota
+	StreamTrades
+	StreamTicker
+	StreamOrders
+	StreamCandles
+)
+
+// streamBufferSize is the number of updates buffered for each subscriber, updates are dropped
+// while the buffer of a slow subscriber is full
+const streamBufferSize = 100
+
+// ErrStreamNotSupported is returned when subscribing to data the exchange doesn't stream, or when
+// the exchange websocket is disabled
+var ErrStreamNotSupported = errors.New("stream not supported by the exchange")
+
+// Resynced is published when a streamed orderbook is rebuilt from a REST snapshot because updates
+// were missed, the orderbooks published for the pair since the gap were missing the updates.
+type Resynced struct {
+	Exchange     string
+	CurrencyPair pair.CurrencyPair
+	// Sequence number of the update that was expected & of the one received instead
+	ExpectedSequence int64
+	ReceivedSequence int64
+	Time             time.Time
+}
+
+// UnsubscribeFunc stops the delivery of updates to a subscriber & closes its channel
+type UnsubscribeFunc func()
+
+// Streamer is implemented by exchanges that push market & account data over their websocket, so
+// consumers don't need any exchange specific websocket logic. The subscriptions stay open across
+// reconnections of the websocket.
+type Streamer interface {
+	// SubscribeOrderbook returns a channel that receives the full orderbook of the pair every
+	// time it's updated
+	SubscribeOrderbook(p pair.CurrencyPair) (<-chan orderbook.Base, UnsubscribeFunc, error)
+	// SubscribeTrades returns a channel that receives the public trades of the pair
+	SubscribeTrades(p pair.CurrencyPair) (<-chan Trade, UnsubscribeFunc, error)
+	// SubscribeTicker returns a channel that receives the ticker of the pair every time it's
+	// updated
+	SubscribeTicker(p pair.CurrencyPair) (<-chan ticker.Price, UnsubscribeFunc, error)
+	// SubscribeOrders returns a channel that receives the orders of the account every time
+	// they're placed, filled or cancelled, it requires authenticated API support
+	SubscribeOrders() (<-chan Order, UnsubscribeFunc, error)
+	// SubscribeOrderEvents returns a channel that receives an event every time an order of the
+	// account is created, filled or cancelled, it requires authenticated API support
+	SubscribeOrderEvents() (<-chan OrderEvent, UnsubscribeFunc, error)
+	// SubscribeResyncs returns a channel that receives an event every time a streamed orderbook
+	// is rebuilt after updates were missed
+	SubscribeResyncs() (<-chan Resynced, UnsubscribeFunc, error)
+	// SubscribeCandles returns a channel that receives the candles of the pair & interval as
+	// they close, ErrUnsupportedCandleInterval is returned if the exchange doesn't stream the
+	// interval
+	SubscribeCandles(p pair.CurrencyPair, interval CandleInterval) (<-chan Candle, UnsubscribeFunc, error)
+}
+
+// streamHub holds the subscribers of each stream, keyed by subscription ID
+type streamHub struct {
+	mtx        sync.Mutex
+	supported  StreamKind
+	nextID     int
+	orderbooks map[int]orderbookSubscriber
+	trades     map[int]tradeSubscriber
+	tickers    map[int]tickerSubscriber
+	orders     map[int]chan Order
+	resyncs    map[int]chan Resynced
+	candles    map[int]candleSubscriber
+	// Order events are derived from the published orders
+	orderEvents orderEventFeed
+}
+
+type orderbookSubscriber struct {
+	pair pair.CurrencyPair
+	ch   chan orderbook.Base
+}
+
+type tradeSubscriber struct {
+	pair pair.CurrencyPair
+	ch   chan Trade
+}
+
+type tickerSubscriber struct {
+	pair pair.CurrencyPair
+	ch   chan ticker.Price
+}
+
+type candleSubscriber struct {
+	pair     pair.CurrencyPair
+	interval CandleInterval
+	ch       chan Candle
+}
+
+// SetStreams sets the kinds of data pushed by the exchange websocket, it must be called by
+// exchanges that publish streamed data (see PublishOrderbook etc.)
+func (e *Base) SetStreams(kinds StreamKind) {
+	e.streams.mtx.Lock()
+	defer e.streams.mtx.Unlock()
+	e.streams.supported = kinds
+}
+
+// subscribe checks the stream is available & returns the ID of a new subscription, the caller
+// must hold the lock
+func (e *Base) subscribe(kind StreamKind) (int, error) {
+	if e.streams.supported&kind == 0 || !e.Websocket {
+		return 0, ErrStreamNotSupported
+	}
+	if kind == StreamOrders && !e.AuthenticatedAPISupport {
+		return 0, ErrStreamNotSupported
+	}
+	e.streams.nextID++
+	return e.streams.nextID, nil
+}
+
+// unsubscribe returns an UnsubscribeFunc that removes the subscription from its map & closes the
+// channel, it's safe to call more than once
+func (e *Base) unsubscribe(remove func()) UnsubscribeFunc {
+	return func() {
+		e.streams.mtx.Lock()
+		defer e.streams.mtx.Unlock()
+		remove()
+	}
+}
+
+// SubscribeOrderbook returns a channel that receives the full orderbook of the pair every time
+// it's updated
+func (e *Base) SubscribeOrderbook(p pair.CurrencyPair) (<-chan orderbook.Base, UnsubscribeFunc, error) {
+	e.streams.mtx.Lock()
+	defer e.streams.mtx.Unlock()
+	id, err := e.subscribe(StreamOrderbook)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e.streams.orderbooks == nil {
+		e.streams.orderbooks = make(map[int]orderbookSubscriber)
+	}
+	sub := orderbookSubscriber{pair: p, ch: make(chan orderbook.Base, streamBufferSize)}
+	e.streams.orderbooks[id] = sub
+	return sub.ch, e.unsubscribe(func() {
+		if _, ok := e.streams.orderbooks[id]; !ok {
+			return
+		}
+		delete(e.streams.orderbooks, id)
+		close(sub.ch)
+	}), nil
+}
+
+// SubscribeTrades returns a channel that receives the public trades of the pair
+func (e *Base) SubscribeTrades(p pair.CurrencyPair) (<-chan Trade, UnsubscribeFunc, error) {
+	e.streams.mtx.Lock()
+	defer e.streams.mtx.Unlock()
+	id, err := e.subscribe(StreamTrades)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e.streams.trades == nil {
+		e.streams.trades = make(map[int]tradeSubscriber)
+	}
+	sub := tradeSubscriber{pair: p, ch: make(chan Trade, streamBufferSize)}
+	e.streams.trades[id] = sub
+	return sub.ch, e.unsubscribe(func() {
+		if _, ok := e.streams.trades[id]; !ok {
+			return
+		}
+		delete(e.streams.trades, id)
+		close(sub.ch)
+	}), nil
+}
+
+// SubscribeTicker returns a channel that receives the ticker of the pair every time it's updated
+func (e *Base) SubscribeTicker(p pair.CurrencyPair) (<-chan ticker.Price, UnsubscribeFunc, error) {
+	e.streams.mtx.Lock()
+	defer e.streams.mtx.Unlock()
+	id, err := e.subscribe(StreamTicker)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e.streams.tickers == nil {
+		e.streams.tickers = make(map[int]tickerSubscriber)
+	}
+	sub := tickerSubscriber{pair: p, ch: make(chan ticker.Price, streamBufferSize)}
+	e.streams.tickers[id] = sub
+	return sub.ch, e.unsubscribe(func() {
+		if _, ok := e.streams.tickers[id]; !ok {
+			return
+		}
+		delete(e.streams.tickers, id)
+		close(sub.ch)
+	}), nil
+}
+
+// SubscribeOrders returns a channel that receives the orders of the account every time they're
+// placed, filled or cancelled
+func (e *Base) SubscribeOrders() (<-chan Order, UnsubscribeFunc, error) {
+	e.streams.mtx.Lock()
+	defer e.streams.mtx.Unlock()
+	id, err := e.subscribe(StreamOrders)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e.streams.orders == nil {
+		e.streams.orders = make(map[int]chan Order)
+	}
+	ch := make(chan Order, streamBufferSize)
+	e.streams.orders[id] = ch
+	return ch, e.unsubscribe(func() {
+		if _, ok := e.streams.orders[id]; !ok {
+			return
+		}
+		delete(e.streams.orders, id)
+		close(ch)
+	}), nil
+}
+
+// SubscribeResyncs returns a channel that receives an event every time a streamed orderbook is
+// rebuilt after updates were missed
+func (e *Base) SubscribeResyncs() (<-chan Resynced, UnsubscribeFunc, error) {
+	e.streams.mtx.Lock()
+	defer e.streams.mtx.Unlock()
+	id, err := e.subscribe(StreamOrderbook)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e.streams.resyncs == nil {
+		e.streams.resyncs = make(map[int]chan Resynced)
+	}
+	ch := make(chan Resynced, streamBufferSize)
+	e.streams.resyncs[id] = ch
+	return ch, e.unsubscribe(func() {
+		if _, ok := e.streams.resyncs[id]; !ok {
+			return
+		}
+		delete(e.streams.resyncs, id)
+		close(ch)
+	}), nil
+}
+
+// SubscribeCandles returns a channel that receives the candles of the pair & interval as they
+// close. Exchanges that stream candles must also subscribe to the interval on their websocket.
+func (e *Base) SubscribeCandles(p pair.CurrencyPair, interval CandleInterval) (<-chan Candle, UnsubscribeFunc, error) {
+	e.streams.mtx.Lock()
+	defer e.streams.mtx.Unlock()
+	id, err := e.subscribe(StreamCandles)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e.streams.candles == nil {
+		e.streams.candles = make(map[int]candleSubscriber)
+	}
+	sub := candleSubscriber{pair: p, interval: interval, ch: make(chan Candle, streamBufferSize)}
+	e.streams.candles[id] = sub
+	return sub.ch, e.unsubscribe(func() {
+		if _, ok := e.streams.candles[id]; !ok {
+			return
+		}
+		delete(e.streams.candles, id)
+		close(sub.ch)
+	}), nil
+}
+
+// PublishOrderbook delivers an updated orderbook to the subscribers of the pair
+func (e *Base) PublishOrderbook(p pair.CurrencyPair, book orderbook.Base) {
+	e.streams.mtx.Lock()
+	defer e.streams.mtx.Unlock()
+	for _, sub := range e.streams.orderbooks {
+		if !sub.pair.Equal(p) {
+			continue
+		}
+		select {
+		case sub.ch <- book:
+		default:
+			e.droppedStreamUpdate("orderbook")
+		}
+	}
+}
+
+// PublishTrade delivers a public trade to the subscribers of its pair
+func (e *Base) PublishTrade(trade Trade) {
+	e.streams.mtx.Lock()
+	defer e.streams.mtx.Unlock()
+	for _, sub := range e.streams.trades {
+		if !sub.pair.Equal(trade.CurrencyPair) {
+			continue
+		}
+		select {
+		case sub.ch <- trade:
+		default:
+			e.droppedStreamUpdate("trade")
+		}
+	}
+}
+
+// PublishTicker delivers an updated ticker to the subscribers of the pair
+func (e *Base) PublishTicker(p pair.CurrencyPair, price ticker.Price) {
+	e.streams.mtx.Lock()
+	defer e.streams.mtx.Unlock()
+	for _, sub := range e.streams.tickers {
+		if !sub.pair.Equal(p) {
+			continue
+		}
+		select {
+		case sub.ch <- price:
+		default:
+			e.droppedStreamUpdate("ticker")
+		}
+	}
+}
+
+// PublishOrder delivers a new or updated order of the account to the subscribers, & the order
+// events resulting from the update to the order event subscribers
+func (e *Base) PublishOrder(order Order) {
+	e.streams.mtx.Lock()
+	for _, ch := range e.streams.orders {
+		select {
+		case ch <- order:
+		default:
+			e.droppedStreamUpdate("order")
+		}
+	}
+	e.streams.mtx.Unlock()
+	e.streams.orderEvents.update(e.Name, order, time.Now())
+}
+
+// PublishResynced delivers the event to the subscribers once a streamed orderbook has been
+// rebuilt, before the rebuilt orderbook is published
+func (e *Base) PublishResynced(event Resynced) {
+	log.Printf("%s %s orderbook resynced, expected update %d but received %d\n", e.Name,
+		event.CurrencyPair.Pair(), event.ExpectedSequence, event.ReceivedSequence)
+	e.streams.mtx.Lock()
+	defer e.streams.mtx.Unlock()
+	for _, ch := range e.streams.resyncs {
+		select {
+		case ch <- event:
+		default:
+			e.droppedStreamUpdate("resync")
+		}
+	}
+}
+
+// PublishCandle delivers a closed candle to the subscribers of the pair & interval
+func (e *Base) PublishCandle(p pair.CurrencyPair, interval CandleInterval, candle Candle) {
+	e.streams.mtx.Lock()
+	defer e.streams.mtx.Unlock()
+	for _, sub := range e.streams.candles {
+		if !sub.pair.Equal(p) || sub.interval != interval {
+			continue
+		}
+		select {
+		case sub.ch <- candle:
+		default:
+			e.droppedStreamUpdate("candle")
+		}
+	}
+}
+
+func (e *Base) droppedStreamUpdate(kind string) {
+	log.Printf("%s Dropped a streamed %s update, the subscriber isn't keeping up\n", e.Name, kind)
+}
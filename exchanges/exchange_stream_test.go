@@ -0,0 +1,86 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+func TestStreamer(t *testing.T) {
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+	ethusd := pair.NewCurrencyPair("ETH", "USD")
+	b := Base{Name: "Streamer"}
+	b.SetStreams(StreamOrderbook | StreamTrades)
+
+	var _ Streamer = &b
+	if _, _, err := b.SubscribeOrderbook(btcusd); err != ErrStreamNotSupported {
+		t.Errorf("Test failed. Expected the stream to be unavailable with the websocket disabled, got %v", err)
+	}
+	b.Websocket = true
+	if _, _, err := b.SubscribeTicker(btcusd); err != ErrStreamNotSupported {
+		t.Errorf("Test failed. Expected the ticker stream to be unsupported, got %v", err)
+	}
+	b.SetStreams(StreamOrderbook | StreamTrades | StreamOrders)
+	if _, _, err := b.SubscribeOrders(); err != ErrStreamNotSupported {
+		t.Errorf("Test failed. Expected the order stream to need authenticated API support, got %v", err)
+	}
+
+	books, unsubscribe, err := b.SubscribeOrderbook(btcusd)
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeOrderbook returned an error: %s", err)
+	}
+	trades, _, err := b.SubscribeTrades(ethusd)
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeTrades returned an error: %s", err)
+	}
+
+	b.PublishOrderbook(ethusd, orderbook.Base{})
+	b.PublishOrderbook(pair.NewCurrencyPair("btc", "usd"), orderbook.Base{Bids: []orderbook.Item{{Price: 1, Amount: 2}}})
+	b.PublishTrade(Trade{ID: "1", CurrencyPair: btcusd})
+	b.PublishTrade(Trade{ID: "2", CurrencyPair: ethusd})
+	b.PublishTicker(btcusd, ticker.Price{Last: 1})
+	if len(books) != 1 || (<-books).Bids[0].Price != 1 {
+		t.Error("Test failed. The orderbooks weren't filtered by pair")
+	}
+	if len(trades) != 1 || (<-trades).ID != "2" {
+		t.Error("Test failed. The trades weren't filtered by pair")
+	}
+	resyncs, _, err := b.SubscribeResyncs()
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeResyncs returned an error: %s", err)
+	}
+	b.PublishResynced(Resynced{Exchange: b.Name, CurrencyPair: btcusd, ExpectedSequence: 2, ReceivedSequence: 4})
+	if len(resyncs) != 1 || (<-resyncs).ReceivedSequence != 4 {
+		t.Error("Test failed. The resync event wasn't delivered")
+	}
+	if _, _, err := b.SubscribeCandles(btcusd, CandleInterval1m); err != ErrStreamNotSupported {
+		t.Errorf("Test failed. Expected the candle stream to be unsupported, got %v", err)
+	}
+	b.SetStreams(StreamOrderbook | StreamTrades | StreamOrders | StreamCandles)
+	candles, _, err := b.SubscribeCandles(btcusd, CandleInterval1m)
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeCandles returned an error: %s", err)
+	}
+	b.PublishCandle(btcusd, CandleInterval5m, Candle{Close: 1})
+	b.PublishCandle(ethusd, CandleInterval1m, Candle{Close: 2})
+	b.PublishCandle(btcusd, CandleInterval1m, Candle{Close: 3})
+	if len(candles) != 1 || (<-candles).Close != 3 {
+		t.Error("Test failed. The candles weren't filtered by pair & interval")
+	}
+
+	// Updates are dropped while the buffer is full
+	for i := 0; i < streamBufferSize+1; i++ {
+		b.PublishOrderbook(btcusd, orderbook.Base{})
+	}
+	if len(books) != streamBufferSize {
+		t.Errorf("Test failed. Expected %d buffered orderbooks, got %d", streamBufferSize, len(books))
+	}
+
+	unsubscribe()
+	unsubscribe()
+	for range books {
+	}
+	b.PublishOrderbook(btcusd, orderbook.Base{})
+}
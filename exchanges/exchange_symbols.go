@@ -0,0 +1,94 @@
+package exchange
+
+import (
+	"sync"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+// SymbolCache memoizes conversions between currency pairs and symbols (exchange specific market
+// identifiers). The zero value is ready to use, the cache must be reset whenever the mapping
+// between currency pairs and symbols changes.
+type SymbolCache struct {
+	m        sync.RWMutex
+	symbols  map[pair.CurrencyItem]string
+	currency map[string]pair.CurrencyPair
+}
+
+// Reset clears all the cached conversions
+func (c *SymbolCache) Reset() {
+	c.m.Lock()
+	c.symbols = nil
+	c.currency = nil
+	c.m.Unlock()
+}
+
+// CurrencyPairsToSymbols converts the currency pairs to symbols using the given conversion
+// function, the result of each conversion is cached and reused in subsequent calls.
+func (c *SymbolCache) CurrencyPairsToSymbols(pairs []pair.CurrencyPair,
+	convert func(pair.CurrencyPair) (string, error)) ([]string, error) {
+	symbols := make([]string, len(pairs))
+	var missing []int
+	c.m.RLock()
+	for i := range pairs {
+		if symbol, ok := c.symbols[pairs[i].Display("/", true)]; ok {
+			symbols[i] = symbol
+		} else {
+			missing = append(missing, i)
+		}
+	}
+	c.m.RUnlock()
+	if len(missing) == 0 {
+		return symbols, nil
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.symbols == nil {
+		c.symbols = make(map[pair.CurrencyItem]string)
+	}
+	for _, i := range missing {
+		symbol, err := convert(pairs[i])
+		if err != nil {
+			return nil, err
+		}
+		c.symbols[pairs[i].Display("/", true)] = symbol
+		symbols[i] = symbol
+	}
+	return symbols, nil
+}
+
+// SymbolsToCurrencyPairs converts the symbols to currency pairs using the given conversion
+// function, the result of each conversion is cached and reused in subsequent calls.
+func (c *SymbolCache) SymbolsToCurrencyPairs(symbols []string,
+	convert func(string) (pair.CurrencyPair, error)) ([]pair.CurrencyPair, error) {
+	pairs := make([]pair.CurrencyPair, len(symbols))
+	var missing []int
+	c.m.RLock()
+	for i := range symbols {
+		if p, ok := c.currency[symbols[i]]; ok {
+			pairs[i] = p
+		} else {
+			missing = append(missing, i)
+		}
+	}
+	c.m.RUnlock()
+	if len(missing) == 0 {
+		return pairs, nil
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.currency == nil {
+		c.currency = make(map[string]pair.CurrencyPair)
+	}
+	for _, i := range missing {
+		p, err := convert(symbols[i])
+		if err != nil {
+			return nil, err
+		}
+		c.currency[symbols[i]] = p
+		pairs[i] = p
+	}
+	return pairs, nil
+}
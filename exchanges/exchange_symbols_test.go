@@ -0,0 +1,131 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+func TestSymbolCache(t *testing.T) {
+	var c SymbolCache
+	calls := 0
+	toSymbol := func(p pair.CurrencyPair) (string, error) {
+		calls++
+		if p.FirstCurrency == "XXX" {
+			return "", errors.New("unknown currency pair")
+		}
+		return p.Display("", false).String(), nil
+	}
+
+	pairs := []pair.CurrencyPair{pair.NewCurrencyPair("BTC", "USD"), pair.NewCurrencyPair("LTC", "BTC")}
+	symbols, err := c.CurrencyPairsToSymbols(pairs, toSymbol)
+	if err != nil {
+		t.Fatalf("Test failed. CurrencyPairsToSymbols error: %s", err)
+	}
+	if len(symbols) != 2 || symbols[0] != "btcusd" || symbols[1] != "ltcbtc" {
+		t.Errorf("Test Failed - unexpected symbols %v", symbols)
+	}
+
+	pairs = append(pairs, pair.NewCurrencyPairDelimiter("eth-btc", "-"))
+	if symbols, err = c.CurrencyPairsToSymbols(pairs, toSymbol); err != nil {
+		t.Fatalf("Test failed. CurrencyPairsToSymbols error: %s", err)
+	}
+	if calls != 3 || symbols[2] != "ethbtc" {
+		t.Errorf("Test Failed - expected 3 conversions, got %d (%v)", calls, symbols)
+	}
+
+	if _, err = c.CurrencyPairsToSymbols([]pair.CurrencyPair{pair.NewCurrencyPair("XXX", "USD")}, toSymbol); err == nil {
+		t.Error("Test Failed - expected conversion error")
+	}
+
+	c.Reset()
+	calls = 0
+	if _, err = c.CurrencyPairsToSymbols(pairs[:1], toSymbol); err != nil || calls != 1 {
+		t.Errorf("Test Failed - expected conversion after reset, got %d calls (%v)", calls, err)
+	}
+
+	calls = 0
+	toPair := func(symbol string) (pair.CurrencyPair, error) {
+		calls++
+		return pair.NewCurrencyPair(symbol[0:3], symbol[3:]), nil
+	}
+	for i := 0; i < 2; i++ {
+		result, err := c.SymbolsToCurrencyPairs([]string{"btcusd", "ltcbtc"}, toPair)
+		if err != nil {
+			t.Fatalf("Test failed. SymbolsToCurrencyPairs error: %s", err)
+		}
+		if len(result) != 2 || result[1].SecondCurrency != "btc" {
+			t.Errorf("Test Failed - unexpected currency pairs %v", result)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Test Failed - expected 2 conversions, got %d", calls)
+	}
+}
+
+func benchmarkPairs() []pair.CurrencyPair {
+	quotes := []string{"BTC", "ETH", "USDT"}
+	bases := []string{"LTC", "XRP", "XMR", "DASH", "ZEC", "ETC", "BCH", "EOS", "NEO", "OMG"}
+	var pairs []pair.CurrencyPair
+	for _, q := range quotes {
+		for _, b := range bases {
+			pairs = append(pairs, pair.NewCurrencyPair(b, q))
+		}
+	}
+	return pairs
+}
+
+func benchmarkToSymbol(p pair.CurrencyPair) (string, error) {
+	return p.Display("_", true).String(), nil
+}
+
+func BenchmarkCurrencyPairsToSymbols(b *testing.B) {
+	pairs := benchmarkPairs()
+	var c SymbolCache
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.CurrencyPairsToSymbols(pairs, benchmarkToSymbol); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSymbolsToCurrencyPairs(b *testing.B) {
+	var c SymbolCache
+	symbols, _ := c.CurrencyPairsToSymbols(benchmarkPairs(), benchmarkToSymbol)
+	toPair := func(symbol string) (pair.CurrencyPair, error) {
+		return pair.NewCurrencyPairDelimiter(symbol, "_"), nil
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.SymbolsToCurrencyPairs(symbols, toPair); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestSymbolCacheAllocs guards against regressions in the cached conversions, a cached
+// symbols to currency pairs conversion should only allocate the result.
+func TestSymbolCacheAllocs(t *testing.T) {
+	var c SymbolCache
+	pairs := benchmarkPairs()
+	symbols, _ := c.CurrencyPairsToSymbols(pairs, benchmarkToSymbol)
+	c.SymbolsToCurrencyPairs(symbols, func(symbol string) (pair.CurrencyPair, error) {
+		return pair.NewCurrencyPairDelimiter(symbol, "_"), nil
+	})
+
+	allocs := testing.AllocsPerRun(100, func() {
+		c.SymbolsToCurrencyPairs(symbols, nil)
+	})
+	if allocs > 1 {
+		t.Errorf("Test failed. SymbolsToCurrencyPairs expected at most 1 alloc, got %v", allocs)
+	}
+	// the currency pair keys are formatted on each lookup
+	allocs = testing.AllocsPerRun(100, func() {
+		c.CurrencyPairsToSymbols(pairs, nil)
+	})
+	if max := float64(len(pairs) + 1); allocs > max {
+		t.Errorf("Test failed. CurrencyPairsToSymbols expected at most %v allocs, got %v", max, allocs)
+	}
+}
@@ -165,6 +165,18 @@ func TestGetName(t *testing.T) {
 	}
 }
 
+func TestGetFeeInfo(t *testing.T) {
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+	base := Base{TakerFee: 0.2, MakerFee: -0.05, Fee: 1}
+	if maker, taker, err := base.GetFeeInfo(btcusd); err != nil || maker != -0.05 || taker != 0.2 {
+		t.Errorf("Test failed. Expected the maker & taker fees, got %v %v %v", maker, taker, err)
+	}
+	base = Base{Fee: 0.25}
+	if maker, taker, err := base.GetFeeInfo(btcusd); err != nil || maker != 0.25 || taker != 0.25 {
+		t.Errorf("Test failed. Expected the single fee to be used, got %v %v %v", maker, taker, err)
+	}
+}
+
 func TestGetEnabledCurrencies(t *testing.T) {
 	b := Base{
 		Name: "TESTNAME",
@@ -431,6 +443,55 @@ func TestSetAPIKeys(t *testing.T) {
 	SetAPIKeys.SetAPIKeys("RocketMan", "Digereedoo", "007", true)
 }
 
+func TestSetAPIURL(t *testing.T) {
+	b := Base{
+		Name:   "TESTNAME",
+		APIUrl: "https://api.example.com/",
+	}
+
+	b.SetAPIURL(config.ExchangeConfig{})
+	if b.APIUrl != "https://api.example.com/" {
+		t.Error("Test Failed - SetAPIURL() overrode the default API URL")
+	}
+	if b.GetCapabilities().Testnet {
+		t.Error("Test Failed - GetCapabilities() returned Testnet without it being set")
+	}
+
+	b.SetAPIURL(config.ExchangeConfig{APIURL: "https://testnet.example.com/", Testnet: true})
+	if b.APIUrl != "https://testnet.example.com/" {
+		t.Error("Test Failed - SetAPIURL() did not set the API URL from the config")
+	}
+	if !b.GetCapabilities().Testnet {
+		t.Error("Test Failed - GetCapabilities() did not return Testnet")
+	}
+
+	b.SetAPIURL(config.ExchangeConfig{UseSandbox: true})
+	if !b.GetCapabilities().Testnet {
+		t.Error("Test Failed - GetCapabilities() did not return Testnet for a sandbox")
+	}
+}
+
+func TestCheckOrderOptions(t *testing.T) {
+	var c Capabilities
+	if err := c.CheckOrderOptions(); err != nil {
+		t.Errorf("Test Failed - CheckOrderOptions() rejected an order without options: %s", err)
+	}
+	if err := c.CheckOrderOptions(OrderOptions{}); err != nil {
+		t.Errorf("Test Failed - CheckOrderOptions() rejected empty options: %s", err)
+	}
+	if err := c.CheckOrderOptions(OrderOptions{Hidden: true}); err != ErrOrderOptionNotSupported {
+		t.Error("Test Failed - CheckOrderOptions() accepted a hidden order")
+	}
+
+	c.HiddenOrders = true
+	if err := c.CheckOrderOptions(OrderOptions{Hidden: true}); err != nil {
+		t.Errorf("Test Failed - CheckOrderOptions() rejected a hidden order: %s", err)
+	}
+	if err := c.CheckOrderOptions(OrderOptions{VisibleAmount: 0.1}); err != ErrOrderOptionNotSupported {
+		t.Error("Test Failed - CheckOrderOptions() accepted an iceberg order")
+	}
+}
+
 func TestUpdateEnabledCurrencies(t *testing.T) {
 	cfg := config.GetConfig()
 	err := cfg.LoadConfig(config.ConfigTestFile)
@@ -508,3 +569,18 @@ func TestUpdateAvailableCurrencies(t *testing.T) {
 		t.Errorf("Test Failed - Forced Exchange UpdateAvailableCurrencies() error: %s", err)
 	}
 }
+
+func TestSupportsAssetType(t *testing.T) {
+	b := Base{AssetTypes: []string{ticker.Spot, ticker.PerpetualSwap}}
+	if !b.SupportsAssetType("perpetual_swap") || !b.SupportsAssetType("") {
+		t.Error("Test failed. SupportsAssetType returned false for an enabled asset type")
+	}
+	if b.SupportsAssetType(ticker.Margin) {
+		t.Error("Test failed. SupportsAssetType returned true for a disabled asset type")
+	}
+
+	info := CurrencyPairInfo{Currency: pair.NewCurrencyPair("BTC", "USD")}
+	if info.GetAssetType() != ticker.Spot {
+		t.Errorf("Test failed. Expected spot asset type but got %s", info.GetAssetType())
+	}
+}
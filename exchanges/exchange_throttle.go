@@ -0,0 +1,137 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+var (
+	// ErrOrderRateExceeded is returned by a ThrottledExchange when too many orders have been
+	// submitted for a currency pair in the last minute.
+	ErrOrderRateExceeded = errors.New("order rate limit exceeded")
+	// ErrNotionalExceeded is returned by a ThrottledExchange when the total notional of the orders
+	// submitted in the last hour would exceed the limit.
+	ErrNotionalExceeded = errors.New("hourly notional limit exceeded")
+	// ErrNotionalUnknown is returned by a ThrottledExchange with a notional limit for a market
+	// order that can't be priced, neither the ticker nor the orderbook of the pair are available.
+	ErrNotionalUnknown = errors.New("unable to price the order for the notional limit")
+)
+
+// ThrottleLimits holds the limits enforced by a ThrottledExchange, zero disables a limit.
+type ThrottleLimits struct {
+	// Max number of new orders per currency pair per minute
+	OrdersPerMinute int
+	// Max total notional (amount * price) of new orders per hour, the notional is denominated in
+	// the quote currency of the pairs and is tracked separately for each quote currency.
+	NotionalPerHour float64
+}
+
+type notionalEntry struct {
+	time  time.Time
+	value float64
+}
+
+// ThrottledExchange wraps an exchange and rejects new orders locally once the configured limits
+// are exceeded, this protects against runaway strategies spamming the exchange with orders.
+type ThrottledExchange struct {
+	Decorator
+	limits   ThrottleLimits
+	m        sync.Mutex
+	orders   map[string][]time.Time
+	notional map[string][]notionalEntry
+	now      func() time.Time
+}
+
+// NewThrottledExchange returns a wrapper that enforces the given limits on new orders submitted
+// to the exchange.
+func NewThrottledExchange(exch IBotExchangeEx, limits ThrottleLimits) *ThrottledExchange {
+	return &ThrottledExchange{
+		Decorator: Decorator{IBotExchangeEx: exch},
+		limits:    limits,
+		orders:    make(map[string][]time.Time),
+		notional:  make(map[string][]notionalEntry),
+		now:       time.Now,
+	}
+}
+
+// NewOrder submits a new order to the exchange, or returns ErrOrderRateExceeded or
+// ErrNotionalExceeded without contacting the exchange if the order would exceed the limits.
+// Orders count towards the limits once they're submitted, even if the exchange rejects them.
+func (t *ThrottledExchange) NewOrder(symbol pair.CurrencyPair, amount, price float64, side OrderSide,
+	orderType OrderType, opts ...OrderOptions) (string, error) {
+	return t.NewOrderContext(context.Background(), symbol, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (t *ThrottledExchange) NewOrderContext(ctx context.Context, symbol pair.CurrencyPair, amount,
+	price float64, side OrderSide, orderType OrderType, opts ...OrderOptions) (string, error) {
+	if err := t.reserve(symbol, amount, price, side); err != nil {
+		return "", err
+	}
+	return WithContext(t.IBotExchangeEx).NewOrderContext(ctx, symbol, amount, price, side, orderType, opts...)
+}
+
+// marketPrice returns the price market orders are valued at, the last price of the ticker or the
+// top of the orderbook if the ticker isn't available. Returns 0 if neither is available.
+func (t *ThrottledExchange) marketPrice(symbol pair.CurrencyPair, side OrderSide) float64 {
+	if tick, err := ticker.GetTicker(t.GetName(), symbol, ticker.Spot); err == nil && tick.Last > 0 {
+		return tick.Last
+	}
+	book, err := t.GetOrderbookEx(symbol, ticker.Spot)
+	if err != nil {
+		return 0
+	}
+	if side == OrderSideBuy && len(book.Asks) > 0 {
+		return book.Asks[0].Price
+	}
+	if side == OrderSideSell && len(book.Bids) > 0 {
+		return book.Bids[0].Price
+	}
+	return 0
+}
+
+func (t *ThrottledExchange) reserve(symbol pair.CurrencyPair, amount, price float64, side OrderSide) error {
+	if price == 0 && t.limits.NotionalPerHour > 0 {
+		if price = t.marketPrice(symbol, side); price == 0 {
+			return ErrNotionalUnknown
+		}
+	}
+
+	t.m.Lock()
+	defer t.m.Unlock()
+	now := t.now()
+	key := symbol.Pair().Upper().String()
+	quote := symbol.SecondCurrency.Upper().String()
+
+	orders := t.orders[key]
+	for len(orders) > 0 && now.Sub(orders[0]) >= time.Minute {
+		orders = orders[1:]
+	}
+	t.orders[key] = orders
+	if t.limits.OrdersPerMinute > 0 && len(orders) >= t.limits.OrdersPerMinute {
+		return ErrOrderRateExceeded
+	}
+
+	entries := t.notional[quote]
+	total := 0.0
+	for len(entries) > 0 && now.Sub(entries[0].time) >= time.Hour {
+		entries = entries[1:]
+	}
+	for i := range entries {
+		total += entries[i].value
+	}
+	t.notional[quote] = entries
+	value := amount * price
+	if t.limits.NotionalPerHour > 0 && total+value > t.limits.NotionalPerHour {
+		return ErrNotionalExceeded
+	}
+
+	t.orders[key] = append(orders, now)
+	t.notional[quote] = append(entries, notionalEntry{time: now, value: value})
+	return nil
+}
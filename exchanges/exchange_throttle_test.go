@@ -0,0 +1,92 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+)
+
+func TestThrottledExchange(t *testing.T) {
+	mock := &mockExchange{}
+	throttled := NewThrottledExchange(mock, ThrottleLimits{OrdersPerMinute: 2, NotionalPerHour: 10000})
+	now := time.Now()
+	throttled.now = func() time.Time { return now }
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+	ethusd := pair.NewCurrencyPair("ETH", "USD")
+	ethbtc := pair.NewCurrencyPair("ETH", "BTC")
+
+	for i := 0; i < 2; i++ {
+		if _, err := throttled.NewOrder(btcusd, 0.1, 5000, OrderSideBuy, OrderTypeExchangeLimit); err != nil {
+			t.Fatalf("Test failed. NewOrder returned an error: %s", err)
+		}
+	}
+	if _, err := throttled.NewOrder(btcusd, 0.1, 5000, OrderSideBuy, OrderTypeExchangeLimit); err != ErrOrderRateExceeded {
+		t.Errorf("Test failed. Expected ErrOrderRateExceeded but got %v", err)
+	}
+	// the order rate is limited per pair
+	if _, err := throttled.NewOrder(ethusd, 1, 500, OrderSideSell, OrderTypeExchangeLimit); err != nil {
+		t.Errorf("Test failed. NewOrder returned an error: %s", err)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := throttled.NewOrder(btcusd, 2, 5000, OrderSideBuy, OrderTypeExchangeLimit); err != ErrNotionalExceeded {
+		t.Errorf("Test failed. Expected ErrNotionalExceeded but got %v", err)
+	}
+	if _, err := throttled.NewOrder(btcusd, 0.5, 5000, OrderSideBuy, OrderTypeExchangeLimit); err != nil {
+		t.Errorf("Test failed. NewOrder returned an error: %s", err)
+	}
+	// the notional is tracked per quote currency
+	if _, err := throttled.NewOrder(ethbtc, 10, 0.05, OrderSideBuy, OrderTypeExchangeLimit); err != nil {
+		t.Errorf("Test failed. NewOrder returned an error: %s", err)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := throttled.NewOrder(btcusd, 1, 5000, OrderSideBuy, OrderTypeExchangeLimit); err != nil {
+		t.Errorf("Test failed. NewOrder returned an error after the window expired: %s", err)
+	}
+	if mock.orders != 6 {
+		t.Errorf("Test failed. Expected 6 orders to reach the exchange but got %d", mock.orders)
+	}
+}
+
+type mockThrottleExchange struct {
+	mockExchange
+	book orderbook.Base
+	err  error
+}
+
+func (m *mockThrottleExchange) GetOrderbookEx(p pair.CurrencyPair, assetType string,
+	opts ...OrderbookOptions) (orderbook.Base, error) {
+	return m.book, m.err
+}
+
+func TestThrottledExchangeMarketOrders(t *testing.T) {
+	mock := &mockThrottleExchange{err: errors.New("orderbook unavailable")}
+	throttled := NewThrottledExchange(mock, ThrottleLimits{NotionalPerHour: 10000})
+	// a pair without a ticker
+	p := pair.NewCurrencyPair("XMR", "EUR")
+
+	// market orders that can't be priced mustn't bypass the notional limit
+	if _, err := throttled.NewOrder(p, 100, 0, OrderSideBuy, OrderTypeExchangeLimit); err != ErrNotionalUnknown {
+		t.Errorf("Test failed. Expected ErrNotionalUnknown but got %v", err)
+	}
+
+	// without a ticker the orders are priced from the side of the orderbook they'd take
+	mock.err = nil
+	mock.book = orderbook.Base{
+		Bids: []orderbook.Item{{Price: 4000, Amount: 1}},
+		Asks: []orderbook.Item{{Price: 6000, Amount: 1}},
+	}
+	if _, err := throttled.NewOrder(p, 2, 0, OrderSideSell, OrderTypeExchangeLimit); err != nil {
+		t.Errorf("Test failed. NewOrder returned an error: %s", err)
+	}
+	if _, err := throttled.NewOrder(p, 0.5, 0, OrderSideBuy, OrderTypeExchangeLimit); err != ErrNotionalExceeded {
+		t.Errorf("Test failed. Expected ErrNotionalExceeded but got %v", err)
+	}
+	if mock.orders != 1 {
+		t.Errorf("Test failed. Expected 1 order to reach the exchange but got %d", mock.orders)
+	}
+}
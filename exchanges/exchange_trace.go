@@ -0,0 +1,29 @@
+package exchange
+
+import (
+	"strings"
+
+	"github.com/mattkanwisher/cryptofiend/trace"
+)
+
+// Exchange subsystems that can be traced, see Base.Debug
+const (
+	TraceHTTP      = "http"
+	TraceWebsocket = "ws"
+)
+
+// TraceModule returns the trace module of a subsystem of the exchange, e.g. "bitfinex.http", or
+// of the exchange itself if subsystem is empty
+func (e *Base) TraceModule(subsystem string) string {
+	module := strings.ToLower(e.Name)
+	if subsystem != "" {
+		module += "." + subsystem
+	}
+	return module
+}
+
+// Debug returns true if debug tracing is enabled for a subsystem of the exchange (or for the
+// exchange itself if subsystem is empty), tracing can be toggled at runtime via the trace package.
+func (e *Base) Debug(subsystem string) bool {
+	return trace.Enabled(e.TraceModule(subsystem))
+}
@@ -0,0 +1,41 @@
+package exchange
+
+import (
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+// Trade is a fill of one of the account's orders, normalized across exchanges. Public trades
+// streamed by exchanges (see Streamer) have neither an order ID nor a fee, their side is the side
+// of the taker.
+type Trade struct {
+	ID           string            `json:"id"`
+	Exchange     string            `json:"exchange"`
+	OrderID      string            `json:"orderId"`
+	CurrencyPair pair.CurrencyPair `json:"pair"`
+	Side         OrderSide         `json:"side"`
+	Amount       float64           `json:"amount"`
+	Price        float64           `json:"price"`
+	Fee          float64           `json:"fee"`
+	FeeCurrency  string            `json:"feeCurrency"`
+	Time         time.Time         `json:"time"`
+}
+
+// TradeHistoryProvider is implemented by exchanges that can return the past trades of the account
+type TradeHistoryProvider interface {
+	// GetAccountTrades returns up to limit trades of the currency pair executed at or after since,
+	// sorted from oldest to newest.
+	GetAccountTrades(p pair.CurrencyPair, since time.Time, limit int) ([]Trade, error)
+}
+
+// TradeHistory returns the TradeHistoryProvider of the exchange, the decorators wrapping the
+// exchange are unwrapped until one is found.
+func TradeHistory(exch IBotExchange) (TradeHistoryProvider, bool) {
+	for ; exch != nil; exch = Unwrap(exch) {
+		if provider, ok := exch.(TradeHistoryProvider); ok {
+			return provider, true
+		}
+	}
+	return nil, false
+}
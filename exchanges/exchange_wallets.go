@@ -0,0 +1,38 @@
+package exchange
+
+// Wallets an exchange account may hold funds in, exchanges that use different names for their
+// wallets map them to these names
+const (
+	// Funds that can be used for regular orders
+	WalletExchange = "exchange"
+	// Funds that can be used for margin orders
+	WalletMargin = "margin"
+	// Funds that can be lent to margin traders
+	WalletFunding = "funding"
+	// Funds held by the account's own wallet, outside of a decentralized exchange's contract
+	WalletOnChain = "onchain"
+)
+
+// WalletBalance is the balance of a currency in one of the wallets of an exchange account
+type WalletBalance struct {
+	Wallet    string  `json:"wallet"`
+	Currency  string  `json:"currency"`
+	Total     float64 `json:"total"`
+	Available float64 `json:"available"`
+}
+
+// WalletTransferer is implemented by exchanges that hold funds in several wallets, and can move
+// funds between them
+type WalletTransferer interface {
+	// GetWalletBalances returns the balance of every currency in every wallet
+	GetWalletBalances() ([]WalletBalance, error)
+	// TransferBetweenWallets moves an amount of a currency from one wallet to another
+	TransferBetweenWallets(currency string, amount float64, from, to string) error
+}
+
+// Withdrawer is implemented by exchanges that can withdraw funds to an external address
+type Withdrawer interface {
+	// WithdrawToAddress withdraws an amount of a currency to the given address, returns the ID of
+	// the withdrawal if the exchange generates one.
+	WithdrawToAddress(currency, address string, amount float64) (string, error)
+}
@@ -0,0 +1,125 @@
+package exchange
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/exchanges/wsrecord"
+)
+
+// WebsocketRecordable is implemented by exchanges that can record the raw frames received from
+// their websocket, see the wsrecord package
+type WebsocketRecordable interface {
+	SetWebsocketRecorder(r wsrecord.Recorder)
+}
+
+// SetWebsocketRecorder sets the recorder the raw websocket frames are mirrored to, e.g. a
+// wsrecord.Writer to record them to a file or a wsrecord.Channel to tap into the live traffic
+// (wsrecord.Tee does both). Nil disables recording, it must be called before the exchange is
+// started.
+func (e *Base) SetWebsocketRecorder(r wsrecord.Recorder) {
+	e.websocketRecorder = r
+}
+
+// WebsocketCompressible is implemented by exchanges that can negotiate permessage-deflate
+// compression on their websocket connections
+type WebsocketCompressible interface {
+	SetWebsocketCompression(enabled bool)
+}
+
+// SetWebsocketCompression enables the negotiation of permessage-deflate compression, which cuts
+// the bandwidth used by depth channels. It must be called before the exchange is started.
+func (e *Base) SetWebsocketCompression(enabled bool) {
+	e.websocketCompression = enabled
+}
+
+// WebsocketCompression returns true if the exchange websocket should negotiate compression
+func (e *Base) WebsocketCompression() bool {
+	return e.websocketCompression
+}
+
+// WebsocketConnectionCounter is implemented by exchanges that count their websocket connections
+type WebsocketConnectionCounter interface {
+	WebsocketConnections() int64
+}
+
+// RecordWebsocketFrame records a frame received from the exchange websocket if recording is
+// enabled, it must be called before the frame is parsed.
+func (e *Base) RecordWebsocketFrame(msgType int, data []byte) {
+	if e.websocketRecorder == nil {
+		return
+	}
+	if err := e.websocketRecorder.Record(e.Name, msgType, data, time.Now()); err != nil {
+		log.Printf("%s Unable to record Websocket frame. Error: %s\n", e.Name, err)
+	}
+}
+
+// CountWebsocketConnection must be called every time the exchange websocket (re)connects
+func (e *Base) CountWebsocketConnection() {
+	atomic.AddInt64(&e.websocketConnections, 1)
+}
+
+// WebsocketConnections returns the number of times the exchange websocket has connected
+func (e *Base) WebsocketConnections() int64 {
+	return atomic.LoadInt64(&e.websocketConnections)
+}
+
+// DefaultWebsocketStaleTimeout is how long an exchange websocket can go without receiving a
+// message before it's considered stale, unless configured otherwise
+const DefaultWebsocketStaleTimeout = time.Minute
+
+// WebsocketStaleNotifier is implemented by exchanges that detect when their websocket silently
+// stops delivering updates. Stale websockets are reconnected and the data they pushed is polled
+// from the REST API in the meantime.
+type WebsocketStaleNotifier interface {
+	SetWebsocketStaleTimeout(timeout time.Duration)
+	SetWebsocketStaleHandler(handler func(exchange string, stale bool))
+	WebsocketStale() bool
+}
+
+// SetWebsocketStaleTimeout sets how long the websocket can go without receiving a message before
+// it's considered stale, zero restores DefaultWebsocketStaleTimeout. It must be called before the
+// exchange is started.
+func (e *Base) SetWebsocketStaleTimeout(timeout time.Duration) {
+	e.websocketStaleTimeout = timeout
+}
+
+// WebsocketStaleTimeout returns the stale timeout the exchange websocket must be configured with
+func (e *Base) WebsocketStaleTimeout() time.Duration {
+	if e.websocketStaleTimeout == 0 {
+		return DefaultWebsocketStaleTimeout
+	}
+	return e.websocketStaleTimeout
+}
+
+// SetWebsocketStaleHandler sets the func called when the websocket becomes stale or recovers, it
+// must be called before the exchange is started.
+func (e *Base) SetWebsocketStaleHandler(handler func(exchange string, stale bool)) {
+	e.onWebsocketStale = handler
+}
+
+// WebsocketStale returns true while the exchange websocket is stale
+func (e *Base) WebsocketStale() bool {
+	return atomic.LoadInt32(&e.websocketStale) == 1
+}
+
+// SetWebsocketStale must be called by the exchange when its websocket becomes stale, and once it
+// receives messages again or reconnects
+func (e *Base) SetWebsocketStale(stale bool) {
+	var old, value int32 = 1, 0
+	if stale {
+		old, value = 0, 1
+	}
+	if !atomic.CompareAndSwapInt32(&e.websocketStale, old, value) {
+		return
+	}
+	if stale {
+		log.Printf("%s Websocket is stale, no messages received for %s.\n", e.Name, e.WebsocketStaleTimeout())
+	} else {
+		log.Printf("%s Websocket recovered.\n", e.Name)
+	}
+	if e.onWebsocketStale != nil {
+		e.onWebsocketStale(e.Name, stale)
+	}
+}
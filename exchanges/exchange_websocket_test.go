@@ -0,0 +1,38 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWebsocketStale(t *testing.T) {
+	b := Base{Name: "TESTNAME"}
+	if b.WebsocketStaleTimeout() != DefaultWebsocketStaleTimeout {
+		t.Errorf("Test failed. Expected the default stale timeout, got %s", b.WebsocketStaleTimeout())
+	}
+	b.SetWebsocketStaleTimeout(10 * time.Second)
+	if b.WebsocketStaleTimeout() != 10*time.Second {
+		t.Errorf("Test failed. Expected a 10s stale timeout, got %s", b.WebsocketStaleTimeout())
+	}
+
+	var changes []bool
+	b.SetWebsocketStaleHandler(func(exchange string, stale bool) {
+		if exchange != "TESTNAME" {
+			t.Errorf("Test failed. Unexpected exchange %s", exchange)
+		}
+		changes = append(changes, stale)
+	})
+	b.SetWebsocketStale(false)
+	b.SetWebsocketStale(true)
+	if !b.WebsocketStale() {
+		t.Error("Test failed. Expected the websocket to be stale")
+	}
+	b.SetWebsocketStale(true)
+	b.SetWebsocketStale(false)
+	if b.WebsocketStale() {
+		t.Error("Test failed. Expected the websocket to have recovered")
+	}
+	if len(changes) != 2 || !changes[0] || changes[1] {
+		t.Errorf("Test failed. Expected a single stale & recovered notification, got %v", changes)
+	}
+}
@@ -0,0 +1,36 @@
+package fix
+
+import (
+	"strings"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+)
+
+// Coinbase Pro FIX gateways
+const (
+	CoinbaseAddress        = "fix.pro.coinbase.com:4198"
+	CoinbaseSandboxAddress = "fix-public.sandbox.pro.coinbase.com:4198"
+	CoinbaseTargetCompID   = "Coinbase"
+)
+
+// CoinbaseLogon returns a logon hook that authenticates with a Coinbase Pro API key, the
+// SenderCompID of the session must be the API key. The signature is the base64 encoded
+// HMAC-SHA256 of the SendingTime, MsgType, MsgSeqNum, SenderCompID, TargetCompID & Password
+// fields joined by SOH, using the base64 decoded API secret.
+func CoinbaseLogon(apiSecret, passphrase string) func(m *Message) {
+	return func(m *Message) {
+		m.Set(TagPassword, passphrase)
+		// cancel the orders placed during the session when it ends
+		m.Set(8013, "S")
+		prehash := strings.Join([]string{
+			m.Get(TagSendingTime),
+			m.MsgType(),
+			m.Get(TagMsgSeqNum),
+			m.Get(TagSenderCompID),
+			m.Get(TagTargetCompID),
+			passphrase,
+		}, string(soh))
+		secret, _ := common.Base64Decode(apiSecret)
+		m.Set(TagRawData, common.Base64Encode(common.GetHMAC(common.HashSHA256, []byte(prehash), secret)))
+	}
+}
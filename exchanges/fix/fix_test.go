@@ -0,0 +1,161 @@
+package fix
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	m := NewMessage(MsgTypeNewOrderSingle)
+	m.Set(TagClOrdID, "abc").Set(TagSymbol, "BTC-USD").Set(TagPrice, "100.5")
+	data := m.Bytes("FIX.4.4")
+	if !bytes.HasPrefix(data, []byte("8=FIX.4.4\x019=")) || !bytes.Contains(data, []byte("\x0135=D\x01")) {
+		t.Fatalf("Test failed. Unexpected encoding %q", data)
+	}
+
+	read, err := ReadMessage(bufio.NewReader(bytes.NewReader(append(data, data...))))
+	if err != nil || !bytes.Equal(read, data) {
+		t.Fatalf("Test failed. ReadMessage returned %q %v", read, err)
+	}
+	parsed, err := ParseMessage(read)
+	if err != nil {
+		t.Fatalf("Test failed. ParseMessage returned an error: %s", err)
+	}
+	if parsed.MsgType() != MsgTypeNewOrderSingle || parsed.Get(TagSymbol) != "BTC-USD" || parsed.Get(TagPrice) != "100.5" {
+		t.Errorf("Test failed. Unexpected message %s", parsed)
+	}
+
+	corrupted := bytes.Replace(data, []byte("BTC"), []byte("ETH"), 1)
+	if _, err = ParseMessage(corrupted); err == nil {
+		t.Error("Test failed. Expected a checksum error")
+	}
+}
+
+func TestCoinbaseLogon(t *testing.T) {
+	m := NewMessage(MsgTypeLogon)
+	m.Set(TagSendingTime, "20180101-00:00:00.000").Set(TagMsgSeqNum, "1")
+	m.Set(TagSenderCompID, "key").Set(TagTargetCompID, CoinbaseTargetCompID)
+	CoinbaseLogon("c2VjcmV0", "passphrase")(m)
+	if m.Get(TagPassword) != "passphrase" || m.Get(TagRawData) == "" {
+		t.Errorf("Test failed. Unexpected logon %s", m)
+	}
+}
+
+// acceptor is the venue side of a test session
+type acceptor struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	seqNum int
+}
+
+func (a *acceptor) read() *Message {
+	data, err := ReadMessage(a.reader)
+	if err != nil {
+		a.t.Fatalf("Test failed. Acceptor read error: %s", err)
+	}
+	m, err := ParseMessage(data)
+	if err != nil {
+		a.t.Fatalf("Test failed. Acceptor parse error: %s", err)
+	}
+	return m
+}
+
+func (a *acceptor) send(m *Message) {
+	a.seqNum++
+	m.Set(TagSenderCompID, "Venue").Set(TagTargetCompID, "Client").Set(TagMsgSeqNum, strconv.Itoa(a.seqNum))
+	a.conn.Write(m.Bytes("FIX.4.4"))
+}
+
+type mockExchange struct {
+	exchange.IBotExchangeEx
+}
+
+func (m *mockExchange) GetName() string {
+	return "Mock"
+}
+
+func TestOrderExchange(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	a := &acceptor{t: t, conn: server, reader: bufio.NewReader(server)}
+
+	session := NewSession(client, Config{SenderCompID: "Client", TargetCompID: "Venue"})
+	exch := NewOrderExchange(&mockExchange{}, session)
+	exch.Timeout = time.Second
+
+	go func() {
+		if logon := a.read(); logon.MsgType() != MsgTypeLogon || logon.Get(TagHeartBtInt) != "30" {
+			t.Errorf("Test failed. Expected a logon, got %s", logon)
+		}
+		a.send(NewMessage(MsgTypeLogon))
+
+		order := a.read()
+		report := NewMessage(MsgTypeExecutionReport)
+		report.Set(TagClOrdID, order.Get(TagClOrdID)).Set(TagOrderID, "order-1")
+		report.Set(TagOrdStatus, OrdStatusNew).Set(TagSide, order.Get(TagSide))
+		report.Set(TagOrderQty, order.Get(TagOrderQty)).Set(TagPrice, order.Get(TagPrice))
+		report.Set(TagLeavesQty, order.Get(TagOrderQty)).Set(TagCumQty, "0")
+		a.send(report)
+
+		cancel := a.read()
+		reject := NewMessage(MsgTypeOrderCancelReject)
+		reject.Set(TagClOrdID, cancel.Get(TagClOrdID)).Set(TagText, "Too late to cancel")
+		a.send(reject)
+	}()
+
+	if err := session.Logon(time.Second); err != nil {
+		t.Fatalf("Test failed. Logon returned an error: %s", err)
+	}
+	p := pair.NewCurrencyPair("BTC", "USD")
+	id, err := exch.NewOrder(p, 1.5, 100, exchange.OrderSideSell, exchange.OrderTypeExchangeLimit)
+	if err != nil || id != "order-1" {
+		t.Fatalf("Test failed. Unexpected order ID %s %v", id, err)
+	}
+	order, err := exch.GetOrder(id, p)
+	if err != nil || order.Status != exchange.OrderStatusActive || order.Side != exchange.OrderSideSell ||
+		order.RemainingAmount != 1.5 || order.Rate != 100 {
+		t.Errorf("Test failed. Unexpected order %+v %v", order, err)
+	}
+	if err = exch.CancelOrder(id, p); err == nil {
+		t.Error("Test failed. Expected the cancel to be rejected")
+	}
+}
+
+func TestTestRequest(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	a := &acceptor{t: t, conn: server, reader: bufio.NewReader(server)}
+	session := NewSession(client, Config{SenderCompID: "Client", TargetCompID: "Venue"})
+
+	go func() {
+		a.read()
+		a.send(NewMessage(MsgTypeLogon))
+	}()
+	if err := session.Logon(time.Second); err != nil {
+		t.Fatalf("Test failed. Logon returned an error: %s", err)
+	}
+	go a.send(NewMessage(MsgTypeTestRequest).Set(TagTestReqID, "ping"))
+	if heartbeat := a.read(); heartbeat.MsgType() != MsgTypeHeartbeat || heartbeat.Get(TagTestReqID) != "ping" {
+		t.Errorf("Test failed. Expected a heartbeat, got %s", heartbeat)
+	}
+
+	// a sequence number lower than expected ends the session
+	a.seqNum = 0
+	go a.send(NewMessage(MsgTypeHeartbeat))
+	select {
+	case <-session.Done():
+		if session.Err() == nil {
+			t.Error("Test failed. Expected a sequence number error")
+		}
+	case <-time.After(time.Second):
+		t.Error("Test failed. Expected the session to be closed")
+	}
+}
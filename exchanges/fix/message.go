@@ -0,0 +1,261 @@
+// Package fix is a minimal FIX 4.4 client for venues that offer order entry over FIX (e.g. the
+// Coinbase Pro FIX gateway). It handles the session layer (logon, heartbeats, sequence numbers)
+// and the order messages needed to back the unified order interface, market data is still
+// fetched through the REST/websocket APIs of the exchange.
+package fix
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const soh = '\x01'
+
+// Tags of the fields used by the client
+const (
+	TagAvgPx          = 6
+	TagBeginSeqNo     = 7
+	TagBeginString    = 8
+	TagBodyLength     = 9
+	TagCheckSum       = 10
+	TagClOrdID        = 11
+	TagCumQty         = 14
+	TagEndSeqNo       = 16
+	TagExecID         = 17
+	TagLastPx         = 31
+	TagLastQty        = 32
+	TagMsgSeqNum      = 34
+	TagMsgType        = 35
+	TagNewSeqNo       = 36
+	TagOrderID        = 37
+	TagOrderQty       = 38
+	TagOrdStatus      = 39
+	TagOrdType        = 40
+	TagOrigClOrdID    = 41
+	TagPossDupFlag    = 43
+	TagPrice          = 44
+	TagRefSeqNum      = 45
+	TagSenderCompID   = 49
+	TagSendingTime    = 52
+	TagSide           = 54
+	TagSymbol         = 55
+	TagTargetCompID   = 56
+	TagText           = 58
+	TagTimeInForce    = 59
+	TagTransactTime   = 60
+	TagRawData        = 96
+	TagEncryptMethod  = 98
+	TagCxlRejReason   = 102
+	TagHeartBtInt     = 108
+	TagTestReqID      = 112
+	TagGapFillFlag    = 123
+	TagResetSeqNumFlg = 141
+	TagExecType       = 150
+	TagLeavesQty      = 151
+	TagPassword       = 554
+)
+
+// Message types used by the client
+const (
+	MsgTypeHeartbeat          = "0"
+	MsgTypeTestRequest        = "1"
+	MsgTypeResendRequest      = "2"
+	MsgTypeReject             = "3"
+	MsgTypeSequenceReset      = "4"
+	MsgTypeLogout             = "5"
+	MsgTypeExecutionReport    = "8"
+	MsgTypeOrderCancelReject  = "9"
+	MsgTypeLogon              = "A"
+	MsgTypeNewOrderSingle     = "D"
+	MsgTypeOrderCancelRequest = "F"
+)
+
+// Field is a tag=value pair
+type Field struct {
+	Tag   int
+	Value string
+}
+
+// Message is a FIX message, the BeginString, BodyLength & CheckSum fields are added when the
+// message is encoded and aren't part of Fields.
+type Message struct {
+	Fields []Field
+}
+
+// NewMessage returns a message of the given type
+func NewMessage(msgType string) *Message {
+	return &Message{Fields: []Field{{TagMsgType, msgType}}}
+}
+
+// Set sets the value of a field, replacing the existing value if the field is already set
+func (m *Message) Set(tag int, value string) *Message {
+	for i := range m.Fields {
+		if m.Fields[i].Tag == tag {
+			m.Fields[i].Value = value
+			return m
+		}
+	}
+	m.Fields = append(m.Fields, Field{tag, value})
+	return m
+}
+
+// Get returns the value of a field, or an empty string if the field isn't set
+func (m *Message) Get(tag int) string {
+	for _, f := range m.Fields {
+		if f.Tag == tag {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// Has returns true if the field is set
+func (m *Message) Has(tag int) bool {
+	for _, f := range m.Fields {
+		if f.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// GetInt returns the value of an integer field
+func (m *Message) GetInt(tag int) (int, error) {
+	return strconv.Atoi(m.Get(tag))
+}
+
+// GetFloat returns the value of a numeric field, zero if the field isn't set
+func (m *Message) GetFloat(tag int) (float64, error) {
+	v := m.Get(tag)
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(v, 64)
+}
+
+// MsgType returns the message type
+func (m *Message) MsgType() string {
+	return m.Get(TagMsgType)
+}
+
+// Bytes encodes the message, the MsgType field is always written first.
+func (m *Message) Bytes(beginString string) []byte {
+	var body bytes.Buffer
+	writeField(&body, TagMsgType, m.MsgType())
+	for _, f := range m.Fields {
+		if f.Tag != TagMsgType {
+			writeField(&body, f.Tag, f.Value)
+		}
+	}
+	var buf bytes.Buffer
+	writeField(&buf, TagBeginString, beginString)
+	writeField(&buf, TagBodyLength, strconv.Itoa(body.Len()))
+	buf.Write(body.Bytes())
+	writeField(&buf, TagCheckSum, fmt.Sprintf("%03d", checksum(buf.Bytes())))
+	return buf.Bytes()
+}
+
+// String returns the encoded message with the field delimiters replaced by "|"
+func (m *Message) String() string {
+	return string(bytes.Replace(m.Bytes("FIX.4.4"), []byte{soh}, []byte{'|'}, -1))
+}
+
+func writeField(buf *bytes.Buffer, tag int, value string) {
+	buf.WriteString(strconv.Itoa(tag))
+	buf.WriteByte('=')
+	buf.WriteString(value)
+	buf.WriteByte(soh)
+}
+
+func checksum(data []byte) int {
+	sum := 0
+	for _, b := range data {
+		sum += int(b)
+	}
+	return sum % 256
+}
+
+// ErrInvalidMessage is returned for messages that can't be parsed
+var ErrInvalidMessage = errors.New("invalid FIX message")
+
+// ParseMessage decodes an encoded message, the body length & checksum are validated.
+func ParseMessage(data []byte) (*Message, error) {
+	if len(data) < 7 || data[len(data)-1] != soh {
+		return nil, ErrInvalidMessage
+	}
+	trailer := bytes.LastIndex(data[:len(data)-1], []byte{soh}) + 1
+	if !bytes.HasPrefix(data[trailer:], []byte("10=")) {
+		return nil, ErrInvalidMessage
+	}
+	expected, err := strconv.Atoi(string(data[trailer+3 : len(data)-1]))
+	if err != nil || expected != checksum(data[:trailer]) {
+		return nil, fmt.Errorf("%s: checksum mismatch", ErrInvalidMessage)
+	}
+
+	m := &Message{}
+	bodyStart, bodyLength := 0, -1
+	for start := 0; start < trailer; {
+		n := bytes.IndexByte(data[start:trailer], soh)
+		if n < 0 {
+			return nil, ErrInvalidMessage
+		}
+		end := start + n
+		eq := bytes.IndexByte(data[start:end], '=')
+		if eq <= 0 {
+			return nil, ErrInvalidMessage
+		}
+		tag, err := strconv.Atoi(string(data[start : start+eq]))
+		if err != nil {
+			return nil, ErrInvalidMessage
+		}
+		value := string(data[start+eq+1 : end])
+		switch tag {
+		case TagBeginString:
+		case TagBodyLength:
+			if bodyLength, err = strconv.Atoi(value); err != nil {
+				return nil, ErrInvalidMessage
+			}
+			bodyStart = end + 1
+		default:
+			m.Fields = append(m.Fields, Field{tag, value})
+		}
+		start = end + 1
+	}
+	if bodyLength != trailer-bodyStart {
+		return nil, fmt.Errorf("%s: body length mismatch", ErrInvalidMessage)
+	}
+	if m.MsgType() == "" {
+		return nil, fmt.Errorf("%s: missing message type", ErrInvalidMessage)
+	}
+	return m, nil
+}
+
+// ReadMessage reads the next encoded message from the stream
+func ReadMessage(r *bufio.Reader) ([]byte, error) {
+	beginString, err := r.ReadBytes(soh)
+	if err != nil {
+		return nil, err
+	}
+	bodyLengthField, err := r.ReadBytes(soh)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(beginString, []byte("8=")) || !bytes.HasPrefix(bodyLengthField, []byte("9=")) {
+		return nil, ErrInvalidMessage
+	}
+	bodyLength, err := strconv.Atoi(string(bodyLengthField[2 : len(bodyLengthField)-1]))
+	if err != nil || bodyLength < 0 {
+		return nil, ErrInvalidMessage
+	}
+	// the body is followed by the 7 byte "10=nnn|" checksum field
+	rest := make([]byte, bodyLength+7)
+	if _, err = io.ReadFull(r, rest); err != nil {
+		return nil, err
+	}
+	data := append(beginString, bodyLengthField...)
+	return append(data, rest...), nil
+}
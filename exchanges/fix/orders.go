@@ -0,0 +1,331 @@
+package fix
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/shopspring/decimal"
+)
+
+// OrdStatus values
+const (
+	OrdStatusNew             = "0"
+	OrdStatusPartiallyFilled = "1"
+	OrdStatusFilled          = "2"
+	OrdStatusDoneForDay      = "3"
+	OrdStatusCanceled        = "4"
+	OrdStatusPendingCancel   = "6"
+	OrdStatusRejected        = "8"
+	OrdStatusPendingNew      = "A"
+	OrdStatusExpired         = "C"
+)
+
+// Side values
+const (
+	SideBuy  = "1"
+	SideSell = "2"
+)
+
+// ExecutionReport is the update of an order sent by the venue
+type ExecutionReport struct {
+	OrderID      string
+	ClOrdID      string
+	OrigClOrdID  string
+	ExecID       string
+	ExecType     string
+	OrdStatus    string
+	Symbol       string
+	Side         exchange.OrderSide
+	OrderQty     float64
+	Price        float64
+	CumQty       float64
+	LeavesQty    float64
+	AvgPx        float64
+	LastQty      float64
+	LastPx       float64
+	Text         string
+	TransactTime time.Time
+}
+
+// ParseExecutionReport decodes an ExecutionReport message
+func ParseExecutionReport(m *Message) (*ExecutionReport, error) {
+	if m.MsgType() != MsgTypeExecutionReport {
+		return nil, fmt.Errorf("FIX message type %s isn't an execution report", m.MsgType())
+	}
+	r := &ExecutionReport{
+		OrderID:     m.Get(TagOrderID),
+		ClOrdID:     m.Get(TagClOrdID),
+		OrigClOrdID: m.Get(TagOrigClOrdID),
+		ExecID:      m.Get(TagExecID),
+		ExecType:    m.Get(TagExecType),
+		OrdStatus:   m.Get(TagOrdStatus),
+		Symbol:      m.Get(TagSymbol),
+		Text:        m.Get(TagText),
+	}
+	if m.Get(TagSide) == SideSell {
+		r.Side = exchange.OrderSideSell
+	} else {
+		r.Side = exchange.OrderSideBuy
+	}
+	for tag, dest := range map[int]*float64{
+		TagOrderQty:  &r.OrderQty,
+		TagPrice:     &r.Price,
+		TagCumQty:    &r.CumQty,
+		TagLeavesQty: &r.LeavesQty,
+		TagAvgPx:     &r.AvgPx,
+		TagLastQty:   &r.LastQty,
+		TagLastPx:    &r.LastPx,
+	} {
+		v, err := m.GetFloat(tag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FIX field %d: %s", tag, err)
+		}
+		*dest = v
+	}
+	if t := m.Get(TagTransactTime); t != "" {
+		r.TransactTime, _ = time.Parse(TimestampFormat, t)
+	}
+	return r, nil
+}
+
+// NewOrderSingle returns a good-till-cancelled limit order message
+func NewOrderSingle(clOrdID, symbol string, side exchange.OrderSide, amount, price float64) *Message {
+	m := NewMessage(MsgTypeNewOrderSingle)
+	m.Set(TagClOrdID, clOrdID)
+	m.Set(TagSymbol, symbol)
+	m.Set(TagSide, fixSide(side))
+	m.Set(TagOrderQty, decimal.NewFromFloat(amount).String())
+	m.Set(TagOrdType, "2")
+	m.Set(TagPrice, decimal.NewFromFloat(price).String())
+	m.Set(TagTimeInForce, "1")
+	m.Set(TagTransactTime, time.Now().UTC().Format(TimestampFormat))
+	return m
+}
+
+// OrderCancelRequest returns a message cancelling an order
+func OrderCancelRequest(clOrdID, origClOrdID, orderID, symbol string, side exchange.OrderSide) *Message {
+	m := NewMessage(MsgTypeOrderCancelRequest)
+	m.Set(TagClOrdID, clOrdID)
+	m.Set(TagOrigClOrdID, origClOrdID)
+	m.Set(TagOrderID, orderID)
+	m.Set(TagSymbol, symbol)
+	m.Set(TagSide, fixSide(side))
+	m.Set(TagTransactTime, time.Now().UTC().Format(TimestampFormat))
+	return m
+}
+
+func fixSide(side exchange.OrderSide) string {
+	if side == exchange.OrderSideSell {
+		return SideSell
+	}
+	return SideBuy
+}
+
+// ErrOrderTimeout is returned when the venue doesn't acknowledge an order request in time, the
+// order may still have been placed (or cancelled).
+var ErrOrderTimeout = errors.New("timed out waiting for the FIX execution report")
+
+type trackedOrder struct {
+	clOrdID string
+	pair    pair.CurrencyPair
+	report  *ExecutionReport
+}
+
+// OrderExchange places & cancels the orders of an exchange through a FIX session, all the other
+// requests (including fetching orders the session didn't place) go through the wrapped exchange.
+type OrderExchange struct {
+	exchange.IBotExchangeEx
+	session *Session
+	// CurrencyPairToSymbol converts a currency pair to a FIX symbol, defaults to the Coinbase Pro
+	// format (e.g. BTC-USD)
+	CurrencyPairToSymbol func(p pair.CurrencyPair) string
+	// Timeout is how long to wait for the venue to acknowledge an order request
+	Timeout time.Duration
+
+	mtx sync.Mutex
+	// Orders placed through the session, keyed by ClOrdID & by exchange order ID
+	orders     map[string]*trackedOrder
+	orderIDs   map[string]*trackedOrder
+	waiters    map[string]chan *Message
+	clOrdIDSeq int64
+}
+
+// NewOrderExchange returns a wrapper that routes the orders of the exchange through the session,
+// the session must not be logged on yet.
+func NewOrderExchange(exch exchange.IBotExchangeEx, session *Session) *OrderExchange {
+	o := &OrderExchange{
+		IBotExchangeEx: exch,
+		session:        session,
+		CurrencyPairToSymbol: func(p pair.CurrencyPair) string {
+			return p.Display("-", true).String()
+		},
+		Timeout:  10 * time.Second,
+		orders:   make(map[string]*trackedOrder),
+		orderIDs: make(map[string]*trackedOrder),
+		waiters:  make(map[string]chan *Message),
+	}
+	session.OnMessage = o.handleMessage
+	return o
+}
+
+func (o *OrderExchange) nextClOrdID() string {
+	seq := atomic.AddInt64(&o.clOrdIDSeq, 1)
+	return strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatInt(seq, 10)
+}
+
+// handleMessage updates the tracked orders and wakes up the requests waiting for a response
+func (o *OrderExchange) handleMessage(m *Message) {
+	var clOrdID string
+	switch m.MsgType() {
+	case MsgTypeExecutionReport:
+		report, err := ParseExecutionReport(m)
+		if err != nil {
+			return
+		}
+		clOrdID = report.ClOrdID
+		o.mtx.Lock()
+		order, ok := o.orders[report.ClOrdID]
+		if !ok && report.OrigClOrdID != "" {
+			order, ok = o.orders[report.OrigClOrdID]
+		}
+		if ok {
+			order.report = report
+			if report.OrderID != "" {
+				o.orderIDs[report.OrderID] = order
+			}
+		}
+		o.mtx.Unlock()
+	case MsgTypeOrderCancelReject:
+		clOrdID = m.Get(TagClOrdID)
+	default:
+		return
+	}
+
+	o.mtx.Lock()
+	waiter, ok := o.waiters[clOrdID]
+	delete(o.waiters, clOrdID)
+	o.mtx.Unlock()
+	if ok {
+		waiter <- m
+	}
+}
+
+// request sends a message and waits for the first response to its ClOrdID
+func (o *OrderExchange) request(m *Message) (*Message, error) {
+	clOrdID := m.Get(TagClOrdID)
+	waiter := make(chan *Message, 1)
+	o.mtx.Lock()
+	o.waiters[clOrdID] = waiter
+	o.mtx.Unlock()
+	defer func() {
+		o.mtx.Lock()
+		delete(o.waiters, clOrdID)
+		o.mtx.Unlock()
+	}()
+
+	if err := o.session.Send(m); err != nil {
+		return nil, err
+	}
+	select {
+	case resp := <-waiter:
+		return resp, nil
+	case <-o.session.Done():
+		return nil, ErrSessionClosed
+	case <-time.After(o.Timeout):
+		return nil, ErrOrderTimeout
+	}
+}
+
+// NewOrder places a limit order through the FIX session.
+// Returns the ID of the new exchange order.
+func (o *OrderExchange) NewOrder(p pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	if len(opts) > 0 {
+		return "", exchange.ErrOrderOptionNotSupported
+	}
+	if orderType != exchange.OrderTypeExchangeLimit {
+		return "", fmt.Errorf("%s FIX order type %s not supported", o.GetName(), orderType)
+	}
+	clOrdID := o.nextClOrdID()
+	o.mtx.Lock()
+	o.orders[clOrdID] = &trackedOrder{clOrdID: clOrdID, pair: p}
+	o.mtx.Unlock()
+
+	resp, err := o.request(NewOrderSingle(clOrdID, o.CurrencyPairToSymbol(p), side, amount, price))
+	if err != nil {
+		return "", err
+	}
+	report, err := ParseExecutionReport(resp)
+	if err != nil {
+		return "", err
+	}
+	if report.OrdStatus == OrdStatusRejected {
+		return "", exchange.NewExchangeError(o.GetName(), "NewOrderSingle", 0, 0, report.Text, resp.String())
+	}
+	return report.OrderID, nil
+}
+
+// CancelOrder cancels an order placed through the FIX session.
+func (o *OrderExchange) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	o.mtx.Lock()
+	order, ok := o.orderIDs[orderID]
+	o.mtx.Unlock()
+	if !ok {
+		return exchange.ErrOrderNotFound
+	}
+
+	resp, err := o.request(OrderCancelRequest(o.nextClOrdID(), order.clOrdID, orderID,
+		o.CurrencyPairToSymbol(order.pair), order.report.Side))
+	if err != nil {
+		return err
+	}
+	if resp.MsgType() == MsgTypeOrderCancelReject {
+		return exchange.NewExchangeError(o.GetName(), "OrderCancelRequest", 0, 0, resp.Get(TagText), resp.String())
+	}
+	return nil
+}
+
+// GetOrder returns the latest state of an order placed through the FIX session, other orders
+// are fetched from the wrapped exchange.
+func (o *OrderExchange) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	order, ok := o.orderIDs[orderID]
+	if !ok {
+		return o.IBotExchangeEx.GetOrder(orderID, currencyPair)
+	}
+	return convertExecutionReport(order), nil
+}
+
+func convertExecutionReport(tracked *trackedOrder) *exchange.Order {
+	r := tracked.report
+	order := &exchange.Order{
+		CurrencyPair:    tracked.pair,
+		Type:            exchange.OrderTypeExchangeLimit,
+		Side:            r.Side,
+		Amount:          r.OrderQty,
+		FilledAmount:    r.CumQty,
+		RemainingAmount: r.LeavesQty,
+		Rate:            r.Price,
+		CreatedAt:       r.TransactTime.Unix(),
+		OrderID:         r.OrderID,
+		InternalOrderID: tracked.clOrdID,
+	}
+	switch r.OrdStatus {
+	case OrdStatusNew, OrdStatusPartiallyFilled, OrdStatusPendingNew, OrdStatusPendingCancel:
+		order.Status = exchange.OrderStatusActive
+	case OrdStatusFilled:
+		order.Status = exchange.OrderStatusFilled
+	case OrdStatusCanceled, OrdStatusRejected, OrdStatusExpired, OrdStatusDoneForDay:
+		order.Status = exchange.OrderStatusAborted
+	default:
+		order.Status = exchange.OrderStatusUnknown
+	}
+	return order
+}
@@ -0,0 +1,342 @@
+package fix
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TimestampFormat is the format of UTCTimestamp fields
+const TimestampFormat = "20060102-15:04:05.000"
+
+var (
+	// ErrSessionClosed is returned when sending on a session that has been closed
+	ErrSessionClosed = errors.New("FIX session closed")
+	// ErrHeartbeatTimeout is the error of a session closed because the counterparty stopped
+	// responding
+	ErrHeartbeatTimeout = errors.New("FIX heartbeat timeout")
+)
+
+// Config holds the session settings
+type Config struct {
+	// Defaults to FIX.4.4
+	BeginString  string
+	SenderCompID string
+	TargetCompID string
+	// Defaults to 30 seconds
+	HeartBtInt time.Duration
+	// LogonHook adds the venue specific authentication fields to the logon message, it's called
+	// after the header fields are set (see CoinbaseLogon)
+	LogonHook func(m *Message)
+	Verbose   bool
+}
+
+// Session is a FIX session initiated by the client. Sequence numbers start at 1 on every logon,
+// sent messages aren't stored so resend requests are answered with a gap fill.
+type Session struct {
+	cfg    Config
+	conn   io.ReadWriteCloser
+	reader *bufio.Reader
+
+	mtx          sync.Mutex
+	outSeqNum    int
+	inSeqNum     int
+	lastReceived time.Time
+	loggingOut   bool
+	err          error
+
+	loggedOn  chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	// OnMessage is called (from the session goroutine) for each application message received,
+	// it must be set before Logon is called
+	OnMessage func(m *Message)
+}
+
+// NewSession returns a session over an established connection
+func NewSession(conn io.ReadWriteCloser, cfg Config) *Session {
+	if cfg.BeginString == "" {
+		cfg.BeginString = "FIX.4.4"
+	}
+	if cfg.HeartBtInt == 0 {
+		cfg.HeartBtInt = 30 * time.Second
+	}
+	return &Session{
+		cfg:       cfg,
+		conn:      conn,
+		reader:    bufio.NewReader(conn),
+		outSeqNum: 1,
+		inSeqNum:  1,
+		loggedOn:  make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Dial connects to a FIX gateway (over TLS if tlsConfig isn't nil) and logs on
+func Dial(address string, tlsConfig *tls.Config, cfg Config, timeout time.Duration) (*Session, error) {
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: timeout}
+	if tlsConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := NewSession(conn, cfg)
+	if err = s.Logon(timeout); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Logon starts the session and waits for the counterparty to acknowledge the logon
+func (s *Session) Logon(timeout time.Duration) error {
+	go s.readLoop()
+
+	m := NewMessage(MsgTypeLogon)
+	m.Set(TagEncryptMethod, "0")
+	m.Set(TagHeartBtInt, strconv.Itoa(int(s.cfg.HeartBtInt/time.Second)))
+	if err := s.send(m, s.cfg.LogonHook); err != nil {
+		s.close(err)
+		return err
+	}
+
+	select {
+	case <-s.loggedOn:
+		go s.heartbeatLoop()
+		return nil
+	case <-s.done:
+		return s.Err()
+	case <-time.After(timeout):
+		s.close(errors.New("FIX logon timed out"))
+		return s.Err()
+	}
+}
+
+// Send sends an application message, the header fields are set by the session
+func (s *Session) Send(m *Message) error {
+	select {
+	case <-s.done:
+		return ErrSessionClosed
+	default:
+	}
+	return s.send(m, nil)
+}
+
+func (s *Session) send(m *Message, hook func(*Message)) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	m.Set(TagSenderCompID, s.cfg.SenderCompID)
+	m.Set(TagTargetCompID, s.cfg.TargetCompID)
+	m.Set(TagMsgSeqNum, strconv.Itoa(s.outSeqNum))
+	m.Set(TagSendingTime, time.Now().UTC().Format(TimestampFormat))
+	if hook != nil {
+		hook(m)
+	}
+	if s.cfg.Verbose {
+		log.Printf("FIX send: %s\n", m)
+	}
+	if _, err := s.conn.Write(m.Bytes(s.cfg.BeginString)); err != nil {
+		return err
+	}
+	s.outSeqNum++
+	return nil
+}
+
+// Logout ends the session, waiting up to timeout for the counterparty to confirm
+func (s *Session) Logout(timeout time.Duration) error {
+	s.mtx.Lock()
+	s.loggingOut = true
+	s.mtx.Unlock()
+	if err := s.Send(NewMessage(MsgTypeLogout)); err != nil {
+		return err
+	}
+	select {
+	case <-s.done:
+	case <-time.After(timeout):
+		s.close(nil)
+	}
+	return nil
+}
+
+// Done is closed when the session ends
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the error that ended the session, nil if it ended with a logout
+func (s *Session) Err() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.err
+}
+
+func (s *Session) close(err error) {
+	s.closeOnce.Do(func() {
+		s.mtx.Lock()
+		s.err = err
+		s.mtx.Unlock()
+		s.conn.Close()
+		close(s.done)
+	})
+}
+
+func (s *Session) readLoop() {
+	for {
+		data, err := ReadMessage(s.reader)
+		if err != nil {
+			s.mtx.Lock()
+			loggingOut := s.loggingOut
+			s.mtx.Unlock()
+			if loggingOut {
+				err = nil
+			}
+			s.close(err)
+			return
+		}
+		m, err := ParseMessage(data)
+		if err != nil {
+			// garbled messages are ignored, the sequence gap is detected on the next message
+			log.Printf("FIX: %s\n", err)
+			continue
+		}
+		if s.cfg.Verbose {
+			log.Printf("FIX received: %s\n", m)
+		}
+		if err = s.handle(m); err != nil {
+			s.close(err)
+			return
+		}
+	}
+}
+
+// handle processes the session level messages and passes on the application messages
+func (s *Session) handle(m *Message) error {
+	seqNum, err := m.GetInt(TagMsgSeqNum)
+	if err != nil {
+		return fmt.Errorf("FIX message without a sequence number: %s", m)
+	}
+
+	s.mtx.Lock()
+	s.lastReceived = time.Now()
+	expected := s.inSeqNum
+	s.mtx.Unlock()
+
+	msgType := m.MsgType()
+	if msgType == MsgTypeSequenceReset {
+		newSeqNo, err := m.GetInt(TagNewSeqNo)
+		if err != nil {
+			return fmt.Errorf("FIX sequence reset without a new sequence number: %s", m)
+		}
+		s.setInSeqNum(newSeqNo)
+		return nil
+	}
+	switch {
+	case seqNum < expected:
+		if m.Get(TagPossDupFlag) == "Y" {
+			return nil
+		}
+		return fmt.Errorf("FIX sequence number too low, expected %d but received %d", expected, seqNum)
+	case seqNum > expected && msgType != MsgTypeLogout:
+		// the missed messages are requested but the session carries on, order state is
+		// reconciled by the next execution reports
+		log.Printf("FIX sequence gap, expected %d but received %d\n", expected, seqNum)
+		resend := NewMessage(MsgTypeResendRequest)
+		resend.Set(TagBeginSeqNo, strconv.Itoa(expected))
+		resend.Set(TagEndSeqNo, "0")
+		if err = s.send(resend, nil); err != nil {
+			return err
+		}
+	}
+	s.setInSeqNum(seqNum + 1)
+
+	switch msgType {
+	case MsgTypeLogon:
+		select {
+		case <-s.loggedOn:
+		default:
+			close(s.loggedOn)
+		}
+	case MsgTypeHeartbeat:
+	case MsgTypeTestRequest:
+		heartbeat := NewMessage(MsgTypeHeartbeat)
+		heartbeat.Set(TagTestReqID, m.Get(TagTestReqID))
+		return s.send(heartbeat, nil)
+	case MsgTypeResendRequest:
+		// nothing is resent, stale orders must not be replayed
+		s.mtx.Lock()
+		next := s.outSeqNum
+		s.mtx.Unlock()
+		gapFill := NewMessage(MsgTypeSequenceReset)
+		gapFill.Set(TagGapFillFlag, "Y")
+		gapFill.Set(TagNewSeqNo, strconv.Itoa(next+1))
+		return s.send(gapFill, nil)
+	case MsgTypeReject:
+		log.Printf("FIX message %s rejected: %s\n", m.Get(TagRefSeqNum), m.Get(TagText))
+	case MsgTypeLogout:
+		s.mtx.Lock()
+		loggingOut := s.loggingOut
+		s.mtx.Unlock()
+		if !loggingOut {
+			s.send(NewMessage(MsgTypeLogout), nil)
+			return fmt.Errorf("FIX session logged out by the counterparty: %s", m.Get(TagText))
+		}
+		s.close(nil)
+	default:
+		if s.OnMessage != nil {
+			s.OnMessage(m)
+		}
+	}
+	return nil
+}
+
+func (s *Session) setInSeqNum(seqNum int) {
+	s.mtx.Lock()
+	s.inSeqNum = seqNum
+	s.mtx.Unlock()
+}
+
+// heartbeatLoop sends a heartbeat every interval, and a test request if nothing was received
+// during the last interval. The session is closed if the test request goes unanswered.
+func (s *Session) heartbeatLoop() {
+	ticker := time.NewTicker(s.cfg.HeartBtInt)
+	defer ticker.Stop()
+	testRequestSent := false
+	for {
+		select {
+		case <-s.done:
+			return
+		case t := <-ticker.C:
+			s.mtx.Lock()
+			idle := t.Sub(s.lastReceived)
+			s.mtx.Unlock()
+			var err error
+			switch {
+			case idle < s.cfg.HeartBtInt:
+				testRequestSent = false
+				err = s.send(NewMessage(MsgTypeHeartbeat), nil)
+			case !testRequestSent:
+				testRequestSent = true
+				testRequest := NewMessage(MsgTypeTestRequest)
+				testRequest.Set(TagTestReqID, strconv.FormatInt(t.Unix(), 10))
+				err = s.send(testRequest, nil)
+			default:
+				err = ErrHeartbeatTimeout
+			}
+			if err != nil {
+				s.close(err)
+				return
+			}
+		}
+	}
+}
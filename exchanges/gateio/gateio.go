@@ -0,0 +1,279 @@
+package gateio
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	exchange "github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+const (
+	gateioAPIURL          = "https://api.gateio.io"
+	gateioDataURL         = "https://data.gateio.io"
+	gateioPublicPath      = "/api2/1/"
+	gateioPrivatePath     = "/api2/1/private/"
+	gateioPairs           = "pairs"
+	gateioMarketInfo      = "marketinfo"
+	gateioTicker          = "ticker"
+	gateioOrderbook       = "orderBook"
+	gateioBalances        = "balances"
+	gateioBuy             = "buy"
+	gateioSell            = "sell"
+	gateioCancelOrder     = "cancelOrder"
+	gateioGetOrder        = "getOrder"
+	gateioOpenOrders      = "openOrders"
+	gateioSymbolDelimiter = "_"
+)
+
+// GateIO is the client of the Gate.io (v2 API) exchange, public market data is fetched from the
+// data host and trading requests are sent to the API host.
+type GateIO struct {
+	exchange.Base
+	// Base URL of the public endpoints
+	DataURL string
+	// Maps symbol (exchange specific market identifier) to currency pair info
+	currencyPairs    map[pair.CurrencyItem]*exchange.CurrencyPairInfo
+	symbolDetailsMap map[pair.CurrencyItem]*symbolDetails
+	// Caches the results of the bulk symbol <-> currency pair conversions
+	symbolCache exchange.SymbolCache
+}
+
+// CurrencyPairToSymbol converts a currency pair to a symbol (exchange specific market identifier).
+func (g *GateIO) CurrencyPairToSymbol(p pair.CurrencyPair) string {
+	return p.
+		Display(g.RequestCurrencyPairFormat.Delimiter, g.RequestCurrencyPairFormat.Uppercase).
+		String()
+}
+
+// SymbolToCurrencyPair converts a symbol (exchange specific market identifier) to a currency pair.
+func (g *GateIO) SymbolToCurrencyPair(symbol string) (pair.CurrencyPair, error) {
+	if p, exists := g.currencyPairs[pair.CurrencyItem(symbol)]; exists {
+		return p.Currency, nil
+	}
+	return pair.CurrencyPair{}, fmt.Errorf("no currency pair found for '%s' symbol", symbol)
+}
+
+// CurrencyPairsToSymbols converts a batch of currency pairs to symbols, conversions are cached.
+func (g *GateIO) CurrencyPairsToSymbols(pairs []pair.CurrencyPair) ([]string, error) {
+	return g.symbolCache.CurrencyPairsToSymbols(pairs, func(cp pair.CurrencyPair) (string, error) {
+		return g.CurrencyPairToSymbol(cp), nil
+	})
+}
+
+// SymbolsToCurrencyPairs converts a batch of symbols to currency pairs, conversions are cached.
+func (g *GateIO) SymbolsToCurrencyPairs(symbols []string) ([]pair.CurrencyPair, error) {
+	return g.symbolCache.SymbolsToCurrencyPairs(symbols, g.SymbolToCurrencyPair)
+}
+
+// FetchPairs fetches the symbols of all the currency pairs.
+func (g *GateIO) FetchPairs() ([]string, error) {
+	var response []string
+	err := g.SendHTTPRequest(gateioPairs, &response)
+	return response, err
+}
+
+// FetchMarketInfo fetches the trading rules of all the currency pairs, mapped by symbol.
+func (g *GateIO) FetchMarketInfo() (map[string]MarketInfo, error) {
+	response := MarketInfoResponse{}
+	if err := g.SendHTTPRequest(gateioMarketInfo, &response); err != nil {
+		return nil, err
+	}
+	result := make(map[string]MarketInfo, len(response.Pairs))
+	for _, entry := range response.Pairs {
+		for symbol, info := range entry {
+			result[symbol] = info
+		}
+	}
+	return result, nil
+}
+
+// FetchTicker fetches the ticker of a currency pair.
+func (g *GateIO) FetchTicker(symbol string) (*Ticker, error) {
+	return g.fetchTicker(context.Background(), symbol)
+}
+
+func (g *GateIO) fetchTicker(ctx context.Context, symbol string) (*Ticker, error) {
+	response := Ticker{}
+	err := g.SendHTTPRequestContext(ctx, gateioTicker+"/"+symbol, &response)
+	return &response, err
+}
+
+// FetchDepth fetches the orderbook of a currency pair.
+func (g *GateIO) FetchDepth(symbol string) (*Depth, error) {
+	return g.fetchDepth(context.Background(), symbol)
+}
+
+func (g *GateIO) fetchDepth(ctx context.Context, symbol string) (*Depth, error) {
+	response := Depth{}
+	err := g.SendHTTPRequestContext(ctx, gateioOrderbook+"/"+symbol, &response)
+	return &response, err
+}
+
+// FetchBalances fetches the available & locked balances of the account.
+func (g *GateIO) FetchBalances() (*Balances, error) {
+	return g.fetchBalances(context.Background())
+}
+
+func (g *GateIO) fetchBalances(ctx context.Context) (*Balances, error) {
+	response := Balances{}
+	err := g.SendAuthenticatedHTTPRequestContext(ctx, gateioBalances, url.Values{}, &response)
+	return &response, err
+}
+
+// PlaceOrder places a limit order, side is either "buy" or "sell".
+func (g *GateIO) PlaceOrder(symbol, side string, rate, amount string) (*PlaceOrderResponse, error) {
+	return g.placeOrder(context.Background(), symbol, side, rate, amount)
+}
+
+func (g *GateIO) placeOrder(ctx context.Context, symbol, side string, rate,
+	amount string) (*PlaceOrderResponse, error) {
+	v := url.Values{}
+	v.Set("currencyPair", symbol)
+	v.Set("rate", rate)
+	v.Set("amount", amount)
+	method := gateioBuy
+	if side == gateioSell {
+		method = gateioSell
+	}
+	response := PlaceOrderResponse{}
+	err := g.SendAuthenticatedHTTPRequestContext(ctx, method, v, &response)
+	return &response, err
+}
+
+// DeleteOrder cancels an open order.
+func (g *GateIO) DeleteOrder(symbol, orderNumber string) error {
+	return g.deleteOrder(context.Background(), symbol, orderNumber)
+}
+
+func (g *GateIO) deleteOrder(ctx context.Context, symbol, orderNumber string) error {
+	v := url.Values{}
+	v.Set("currencyPair", symbol)
+	v.Set("orderNumber", orderNumber)
+	return g.SendAuthenticatedHTTPRequestContext(ctx, gateioCancelOrder, v, &Response{})
+}
+
+// FetchOrder fetches an order (which may be open or closed).
+func (g *GateIO) FetchOrder(symbol, orderNumber string) (*Order, error) {
+	return g.fetchOrder(context.Background(), symbol, orderNumber)
+}
+
+func (g *GateIO) fetchOrder(ctx context.Context, symbol, orderNumber string) (*Order, error) {
+	v := url.Values{}
+	v.Set("currencyPair", symbol)
+	v.Set("orderNumber", orderNumber)
+	response := OrderResponse{}
+	if err := g.SendAuthenticatedHTTPRequestContext(ctx, gateioGetOrder, v, &response); err != nil {
+		return nil, err
+	}
+	return &response.Order, nil
+}
+
+// FetchOpenOrders fetches the open orders, of all the currency pairs if symbol is empty.
+func (g *GateIO) FetchOpenOrders(symbol string) ([]Order, error) {
+	return g.fetchOpenOrders(context.Background(), symbol)
+}
+
+func (g *GateIO) fetchOpenOrders(ctx context.Context, symbol string) ([]Order, error) {
+	v := url.Values{}
+	if symbol != "" {
+		v.Set("currencyPair", symbol)
+	}
+	response := OpenOrdersResponse{}
+	err := g.SendAuthenticatedHTTPRequestContext(ctx, gateioOpenOrders, v, &response)
+	return response.Orders, err
+}
+
+// SendHTTPRequest sends a request to a public endpoint and decodes the response into the result
+// object.
+func (g *GateIO) SendHTTPRequest(method string, result interface{}) error {
+	return g.SendHTTPRequestContext(context.Background(), method, result)
+}
+
+// SendHTTPRequestContext is SendHTTPRequest, the request is cancelled when the context is done
+func (g *GateIO) SendHTTPRequestContext(ctx context.Context, method string, result interface{}) error {
+	path := gateioPublicPath + method
+	if g.Debug(exchange.TraceHTTP) {
+		log.Printf("Request: GET %s\n", path)
+	}
+	headers := make(http.Header)
+	headers.Set("Accept", "application/json")
+	resp, statusCode, err := common.SendHTTPRequest2Context(ctx, http.MethodGet, g.DataURL+path, headers, nil)
+	if err != nil {
+		return err
+	}
+	return g.decodeResponse(path, resp, statusCode, result)
+}
+
+// SendAuthenticatedHTTPRequest sends a request to a private endpoint, the form encoded params are
+// signed with the hex encoded HMAC-SHA512 of the API secret. The response is decoded into the
+// result object.
+func (g *GateIO) SendAuthenticatedHTTPRequest(method string, params url.Values, result interface{}) error {
+	return g.SendAuthenticatedHTTPRequestContext(context.Background(), method, params, result)
+}
+
+// SendAuthenticatedHTTPRequestContext is SendAuthenticatedHTTPRequest, the request is cancelled
+// when the context is done
+func (g *GateIO) SendAuthenticatedHTTPRequestContext(ctx context.Context, method string,
+	params url.Values, result interface{}) error {
+	if !g.AuthenticatedAPISupport {
+		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, g.Name)
+	}
+	apiKey, apiSecret, _ := g.Credentials()
+
+	path := gateioPrivatePath + method
+	encoded := params.Encode()
+	if g.Debug(exchange.TraceHTTP) {
+		log.Printf("Request: POST %s %s\n", path, encoded)
+	}
+
+	headers := make(http.Header)
+	headers.Set("Content-Type", "application/x-www-form-urlencoded")
+	headers.Set("KEY", apiKey)
+	headers.Set("SIGN", g.sign(apiSecret, encoded))
+
+	resp, statusCode, err := common.SendHTTPRequest2Context(ctx, http.MethodPost, g.APIUrl+path, headers,
+		strings.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	return g.decodeResponse(path, resp, statusCode, result)
+}
+
+// decodeResponse decodes a response into the result object, requests Gate.io rejects are
+// returned as an ExchangeError.
+func (g *GateIO) decodeResponse(path, resp string, statusCode int, result interface{}) error {
+	if g.Debug(exchange.TraceHTTP) {
+		log.Printf("Received raw: \n%s\n", resp)
+	}
+
+	// Most endpoints return an object with a result flag, the pairs endpoint returns an array.
+	if strings.HasPrefix(strings.TrimSpace(resp), "{") {
+		var info Response
+		if err := common.JSONDecode([]byte(resp), &info); err != nil {
+			return exchange.NewExchangeError(g.Name, path, statusCode, 0,
+				"failed to unmarshal response", resp)
+		}
+		if !info.Result {
+			return exchange.NewExchangeError(g.Name, path, statusCode, info.Code, info.Message, resp)
+		}
+	}
+	if statusCode < 200 || statusCode > 299 {
+		return exchange.NewExchangeError(g.Name, path, statusCode, 0, "unexpected status", resp)
+	}
+	if err := common.JSONDecode([]byte(resp), result); err != nil {
+		return exchange.NewExchangeError(g.Name, path, statusCode, 0,
+			"failed to unmarshal response", resp)
+	}
+	return nil
+}
+
+// sign returns the signature of the form encoded params of a request.
+func (g *GateIO) sign(apiSecret, encoded string) string {
+	return common.HexEncodeToString(common.GetHMAC(common.HashSHA512, []byte(encoded), []byte(apiSecret)))
+}
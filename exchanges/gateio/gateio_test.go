@@ -0,0 +1,117 @@
+package gateio
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+func newTestGateIO(handler http.HandlerFunc) (*GateIO, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	g := &GateIO{}
+	g.SetDefaults()
+	g.APIUrl = server.URL
+	g.DataURL = server.URL
+	g.AuthenticatedAPISupport = true
+	g.SetAPIKeys("key", "secret", "", false)
+	return g, server
+}
+
+func TestSetMarketInfo(t *testing.T) {
+	g, server := newTestGateIO(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"result":"true","pairs":[{"eth_btc":{"decimal_places":6,"amount_decimal_places":3,
+			"min_amount":0.0001,"min_amount_a":0.001,"min_amount_b":0.0001,"fee":0.2,"trade_disabled":0}},
+			{"ltc_usdt":{"decimal_places":2,"min_amount":1,"min_amount_a":"0.01","min_amount_b":1,"fee":0.2}}]}`)
+	})
+	defer server.Close()
+
+	markets, err := g.FetchMarketInfo()
+	if err != nil {
+		t.Fatalf("Test failed. FetchMarketInfo returned an error: %s", err)
+	}
+	g.setMarketInfo(markets)
+	p, err := g.SymbolToCurrencyPair("eth_btc")
+	if err != nil || p.Pair().String() != "ETH_BTC" {
+		t.Errorf("Test failed. Unexpected currency pair %v %v", p, err)
+	}
+	limits := g.GetLimits()
+	ethbtc := pair.NewCurrencyPair("ETH", "BTC")
+	if limits.GetPriceDecimalPlaces(ethbtc) != 6 || limits.GetAmountDecimalPlaces(ethbtc) != 3 ||
+		limits.GetMinAmount(ethbtc) != 0.001 || limits.GetMinTotal(ethbtc) != 0.0001 {
+		t.Error("Test failed. Unexpected ETH/BTC limits")
+	}
+	ltcusdt := pair.NewCurrencyPair("LTC", "USDT")
+	if limits.GetAmountDecimalPlaces(ltcusdt) != -1 || limits.GetMinAmount(ltcusdt) != 0.01 {
+		t.Error("Test failed. Unexpected LTC/USDT limits")
+	}
+}
+
+func TestUpdateOrderbook(t *testing.T) {
+	g, server := newTestGateIO(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api2/1/orderBook/eth_btc" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"result":"true","asks":[[0.0312,"2"],["0.0311",1.5]],"bids":[["0.031","3"],["0.0309","4"]]}`)
+	})
+	defer server.Close()
+
+	book, err := g.UpdateOrderbook(pair.NewCurrencyPair("ETH", "BTC"), "SPOT")
+	if err != nil {
+		t.Fatalf("Test failed. UpdateOrderbook returned an error: %s", err)
+	}
+	if len(book.Asks) != 2 || book.Asks[0].Price != 0.0311 || book.Asks[0].Amount != 1.5 ||
+		len(book.Bids) != 2 || book.Bids[0].Price != 0.031 {
+		t.Errorf("Test failed. Unexpected orderbook %+v", book)
+	}
+}
+
+func TestSendAuthenticatedHTTPRequest(t *testing.T) {
+	g, server := newTestGateIO(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		expected := (&GateIO{}).sign("secret", string(body))
+		if r.Header.Get("SIGN") != expected || r.Header.Get("KEY") != "key" {
+			fmt.Fprint(w, `{"result":"false","code":8,"message":"Error: invalid key or sign"}`)
+			return
+		}
+		fmt.Fprint(w, `{"result":"true","orderNumber":123456,"rate":"0.031","leftAmount":"1.5",
+			"filledAmount":"0","message":"Success"}`)
+	})
+	defer server.Close()
+
+	id, err := g.NewOrder(pair.NewCurrencyPair("ETH", "BTC"), 1.5, 0.031, exchange.OrderSideBuy,
+		exchange.OrderTypeExchangeLimit)
+	if err != nil || id != "123456" {
+		t.Errorf("Test failed. Unexpected order ID %s %v", id, err)
+	}
+
+	g.APISecret = "wrong"
+	_, err = g.FetchBalances()
+	if e, ok := err.(*exchange.ExchangeError); !ok || e.Code != 8 {
+		t.Errorf("Test failed. Expected an invalid signature error but got %v", err)
+	}
+}
+
+func TestConvertOrder(t *testing.T) {
+	g, server := newTestGateIO(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"result":"true","order":{"orderNumber":"1","status":"open","currencyPair":"eth_btc",
+			"type":"sell","initialRate":0.031,"initialAmount":"2","filledRate":0.031,"filledAmount":0.5,
+			"timestamp":"1520000000"}}`)
+	})
+	defer server.Close()
+
+	order, err := g.GetOrder("1", pair.NewCurrencyPair("ETH", "BTC"))
+	if err != nil {
+		t.Fatalf("Test failed. GetOrder returned an error: %s", err)
+	}
+	if order.Status != exchange.OrderStatusActive || order.RemainingAmount != 1.5 ||
+		order.Side != exchange.OrderSideSell || order.CurrencyPair.FirstCurrency != "ETH" ||
+		order.CreatedAt != 1520000000 {
+		t.Errorf("Test failed. Unexpected order %+v", order)
+	}
+}
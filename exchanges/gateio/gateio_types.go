@@ -0,0 +1,171 @@
+package gateio
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Result is the success flag of a response, Gate.io sends it either as a bool or as a string
+type Result bool
+
+// UnmarshalJSON decodes a result sent either as a bool or a string.
+func (r *Result) UnmarshalJSON(b []byte) error {
+	switch strings.Trim(string(b), "\"") {
+	case "true":
+		*r = true
+	case "false":
+		*r = false
+	default:
+		return fmt.Errorf("invalid result %s", b)
+	}
+	return nil
+}
+
+// Number is a numeric field, which Gate.io sends as a number on some endpoints & as a string on
+// others.
+type Number float64
+
+// UnmarshalJSON decodes a number sent either as a number or a string.
+func (n *Number) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), "\"")
+	if s == "" || s == "null" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s", b)
+	}
+	*n = Number(v)
+	return nil
+}
+
+// Float64 returns the number as a float64
+func (n Number) Float64() float64 {
+	return float64(n)
+}
+
+// Response holds the fields common to all responses, code & message are only set on errors.
+type Response struct {
+	Result  Result `json:"result"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// MarketInfo holds the trading rules of a currency pair
+type MarketInfo struct {
+	// Max number of decimal places of the price
+	DecimalPlaces int32 `json:"decimal_places"`
+	// Max number of decimal places of the amount, not returned for all pairs
+	AmountDecimalPlaces *int32 `json:"amount_decimal_places"`
+	MinAmount           Number `json:"min_amount"`
+	// Minimum order amount in the base currency
+	MinAmountBase Number `json:"min_amount_a"`
+	// Minimum order total in the quote currency
+	MinAmountQuote Number `json:"min_amount_b"`
+	// Trading fee percentage
+	Fee           Number `json:"fee"`
+	TradeDisabled int    `json:"trade_disabled"`
+}
+
+// MarketInfoResponse is the trading rules of all the currency pairs, each element of Pairs maps
+// a single symbol to its market info.
+type MarketInfoResponse struct {
+	Response
+	Pairs []map[string]MarketInfo `json:"pairs"`
+}
+
+// Ticker is the best bid & ask, last traded price and 24h volume of a currency pair. Gate.io
+// swaps the volume fields, BaseVolume is the volume in the quote currency and QuoteVolume the
+// volume in the base currency.
+type Ticker struct {
+	Response
+	Last          Number `json:"last"`
+	LowestAsk     Number `json:"lowestAsk"`
+	HighestBid    Number `json:"highestBid"`
+	PercentChange Number `json:"percentChange"`
+	BaseVolume    Number `json:"baseVolume"`
+	QuoteVolume   Number `json:"quoteVolume"`
+	High24hr      Number `json:"high24hr"`
+	Low24hr       Number `json:"low24hr"`
+}
+
+// BookEntry is a price level of the orderbook
+type BookEntry struct {
+	Price  float64
+	Amount float64
+}
+
+// UnmarshalJSON decodes a [price, amount] array of numbers or strings.
+func (entry *BookEntry) UnmarshalJSON(b []byte) error {
+	var s []Number
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if len(s) < 2 {
+		return fmt.Errorf("invalid orderbook entry %s", b)
+	}
+	entry.Price = s[0].Float64()
+	entry.Amount = s[1].Float64()
+	return nil
+}
+
+// Depth is the orderbook of a currency pair, asks are sorted by descending price.
+type Depth struct {
+	Response
+	Asks []BookEntry `json:"asks"`
+	Bids []BookEntry `json:"bids"`
+}
+
+// Balances holds the available & locked balances of each currency
+type Balances struct {
+	Response
+	Available map[string]Number `json:"available"`
+	Locked    map[string]Number `json:"locked"`
+}
+
+// OrderStatus is the status of an order
+type OrderStatus string
+
+const (
+	OrderStatusOpen      OrderStatus = "open"
+	OrderStatusClosed    OrderStatus = "closed"
+	OrderStatusCancelled OrderStatus = "cancelled"
+)
+
+// Order is an order placed on the exchange
+type Order struct {
+	OrderNumber   json.Number `json:"orderNumber"`
+	Status        OrderStatus `json:"status"`
+	CurrencyPair  string      `json:"currencyPair"`
+	Type          string      `json:"type"`
+	InitialRate   Number      `json:"initialRate"`
+	InitialAmount Number      `json:"initialAmount"`
+	FilledRate    Number      `json:"filledRate"`
+	FilledAmount  Number      `json:"filledAmount"`
+	// Unix time in seconds
+	Timestamp Number `json:"timestamp"`
+}
+
+// OrderResponse is the result of fetching an order
+type OrderResponse struct {
+	Response
+	Order Order `json:"order"`
+}
+
+// OpenOrdersResponse is the result of fetching the open orders
+type OpenOrdersResponse struct {
+	Response
+	Orders []Order `json:"orders"`
+}
+
+// PlaceOrderResponse is the result of placing an order
+type PlaceOrderResponse struct {
+	Response
+	OrderNumber  json.Number `json:"orderNumber"`
+	Rate         Number      `json:"rate"`
+	LeftAmount   Number      `json:"leftAmount"`
+	FilledAmount Number      `json:"filledAmount"`
+}
@@ -0,0 +1,469 @@
+package gateio
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+	"github.com/shopspring/decimal"
+)
+
+var _ exchange.IExchange = (*GateIO)(nil)
+var _ exchange.ContextExchange = (*GateIO)(nil)
+
+// New returns a Gate.io exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *GateIO {
+	g := &GateIO{}
+	g.SetDefaults()
+	g.Quickstart(g.Setup, apiKey, apiSecret, opts...)
+	return g
+}
+
+// SetDefaults sets the basic defaults for Gate.io
+func (g *GateIO) SetDefaults() {
+	g.Name = "GateIO"
+	g.APIUrl = gateioAPIURL
+	g.DataURL = gateioDataURL
+	g.Enabled = false
+	g.Verbose = false
+	g.Websocket = false
+	g.RESTPollingDelay = 10
+	g.TakerFee = 0.2
+	g.MakerFee = 0.2
+	g.RequestCurrencyPairFormat.Delimiter = gateioSymbolDelimiter
+	g.RequestCurrencyPairFormat.Uppercase = false
+	g.ConfigCurrencyPairFormat.Delimiter = gateioSymbolDelimiter
+	g.ConfigCurrencyPairFormat.Uppercase = true
+	g.AssetTypes = []string{ticker.Spot}
+	g.Orderbooks = orderbook.Init()
+}
+
+// Setup takes in the supplied exchange configuration details and sets params
+func (g *GateIO) Setup(exch config.ExchangeConfig) {
+	if !exch.Enabled {
+		g.SetEnabled(false)
+	} else {
+		g.Enabled = true
+		g.AuthenticatedAPISupport = exch.AuthenticatedAPISupport
+		g.SetAPIKeys(exch.APIKey, exch.APISecret, "", false)
+		g.RESTPollingDelay = exch.RESTPollingDelay
+		g.Verbose = exch.Verbose
+		g.Websocket = exch.Websocket
+		g.SetAPIURL(exch)
+		g.BaseCurrencies = common.SplitStrings(exch.BaseCurrencies, ",")
+		g.AvailablePairs = common.SplitStrings(exch.AvailablePairs, ",")
+		g.EnabledPairs = common.SplitStrings(exch.EnabledPairs, ",")
+		err := g.SetCurrencyPairFormat()
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = g.SetAssetTypes()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// Start starts the Gate.io go routine
+func (g *GateIO) Start() {
+	go g.Run()
+}
+
+// Run implements the Gate.io wrapper
+func (g *GateIO) Run() {
+	if g.Debug("") {
+		log.Printf("%s polling delay: %ds.\n", g.GetName(), g.RESTPollingDelay)
+		log.Printf("%s %d currencies enabled: %s.\n", g.GetName(), len(g.EnabledPairs), g.EnabledPairs)
+	}
+
+	markets, err := g.FetchMarketInfo()
+	if err != nil {
+		log.Printf("%s failed to get market info\n", g.GetName())
+		return
+	}
+	g.setMarketInfo(markets)
+
+	exchangeProducts := make([]string, 0, len(markets))
+	for symbol := range markets {
+		exchangeProducts = append(exchangeProducts, strings.ToUpper(symbol))
+	}
+	err = g.UpdateAvailableCurrencies(exchangeProducts, false)
+	if err != nil {
+		log.Printf("%s failed to update available currencies\n", g.Name)
+	}
+}
+
+// setMarketInfo replaces the currency pairs & trading rules of the exchange
+func (g *GateIO) setMarketInfo(markets map[string]MarketInfo) {
+	g.symbolCache.Reset()
+	g.currencyPairs = make(map[pair.CurrencyItem]*exchange.CurrencyPairInfo, len(markets))
+	g.symbolDetailsMap = make(map[pair.CurrencyItem]*symbolDetails, len(markets))
+	for symbol, info := range markets {
+		currencyPair := pair.NewCurrencyPairDelimiter(strings.ToUpper(symbol), gateioSymbolDelimiter)
+		g.currencyPairs[pair.CurrencyItem(symbol)] = &exchange.CurrencyPairInfo{
+			Currency:           currencyPair,
+			FirstCurrencyName:  currencyPair.FirstCurrency.String(),
+			SecondCurrencyName: currencyPair.SecondCurrency.String(),
+		}
+		details := &symbolDetails{
+			PriceDecimalPlaces:  info.DecimalPlaces,
+			AmountDecimalPlaces: -1,
+			MinAmount:           info.MinAmountBase.Float64(),
+			MinTotal:            info.MinAmountQuote.Float64(),
+		}
+		if info.AmountDecimalPlaces != nil {
+			details.AmountDecimalPlaces = *info.AmountDecimalPlaces
+		}
+		g.symbolDetailsMap[currencyPair.Display("/", false)] = details
+	}
+}
+
+// UpdateTicker updates and returns the ticker for a currency pair
+func (g *GateIO) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return g.UpdateTickerContext(context.Background(), p, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (g *GateIO) UpdateTickerContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
+	var tickerPrice ticker.Price
+	tick, err := g.fetchTicker(ctx, g.CurrencyPairToSymbol(p))
+	if err != nil {
+		return tickerPrice, err
+	}
+	tickerPrice.Pair = p
+	tickerPrice.Ask = tick.LowestAsk.Float64()
+	tickerPrice.Bid = tick.HighestBid.Float64()
+	tickerPrice.Last = tick.Last.Float64()
+	tickerPrice.High = tick.High24hr.Float64()
+	tickerPrice.Low = tick.Low24hr.Float64()
+	tickerPrice.Volume = tick.QuoteVolume.Float64()
+	tickerPrice.LastUpdated = time.Now()
+	ticker.ProcessTicker(g.GetName(), p, tickerPrice, assetType)
+	return ticker.GetTicker(g.Name, p, assetType)
+}
+
+// GetTickerPrice returns the ticker for a currency pair
+func (g *GateIO) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	tick, err := ticker.GetTicker(g.GetName(), p, assetType)
+	if err != nil {
+		return g.UpdateTicker(p, assetType)
+	}
+	return tick, nil
+}
+
+// GetOrderbookEx returns the orderbook for a currency pair
+func (g *GateIO) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
+	ob, err := g.Orderbooks.GetOrderbook(g.GetName(), p, assetType)
+	if err != nil {
+		return g.UpdateOrderbook(p, assetType)
+	}
+	return ob, nil
+}
+
+// UpdateOrderbook updates and returns the orderbook for a currency pair
+func (g *GateIO) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return g.UpdateOrderbookContext(context.Background(), p, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (g *GateIO) UpdateOrderbookContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
+	book := orderbook.Base{}
+	depth, err := g.fetchDepth(ctx, g.CurrencyPairToSymbol(p))
+	if err != nil {
+		return book, err
+	}
+
+	// Gate.io returns the asks from the highest to the lowest price
+	book.Asks = orderbook.GetItems(len(depth.Asks))
+	for x := len(depth.Asks) - 1; x >= 0; x-- {
+		book.Asks = append(book.Asks, orderbook.Item{
+			Price:  depth.Asks[x].Price,
+			Amount: depth.Asks[x].Amount,
+		})
+	}
+
+	book.Bids = orderbook.GetItems(len(depth.Bids))
+	for x := range depth.Bids {
+		book.Bids = append(book.Bids, orderbook.Item{
+			Price:  depth.Bids[x].Price,
+			Amount: depth.Bids[x].Amount,
+		})
+	}
+
+	g.Orderbooks.ProcessOrderbook(g.Name, p, book, assetType)
+	return g.Orderbooks.GetOrderbook(g.Name, p, assetType)
+}
+
+// GetExchangeAccountInfo retrieves balances for all enabled currencies on the
+// Gate.io exchange
+func (g *GateIO) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return g.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (g *GateIO) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
+	result := exchange.AccountInfo{}
+	result.ExchangeName = g.Name
+
+	if !g.Enabled {
+		return result, nil
+	}
+
+	balances, err := g.fetchBalances(ctx)
+	if err != nil {
+		return result, err
+	}
+	currencies := make(map[string]*exchange.AccountCurrencyInfo)
+	get := func(currency string) *exchange.AccountCurrencyInfo {
+		currency = strings.ToUpper(currency)
+		info, ok := currencies[currency]
+		if !ok {
+			info = &exchange.AccountCurrencyInfo{CurrencyName: currency}
+			currencies[currency] = info
+		}
+		return info
+	}
+	for currency, amount := range balances.Available {
+		get(currency).Available = amount.Float64()
+	}
+	for currency, amount := range balances.Locked {
+		get(currency).Hold = amount.Float64()
+	}
+	for _, info := range currencies {
+		info.TotalValue, _ = decimal.NewFromFloat(info.Available).Add(decimal.NewFromFloat(info.Hold)).Float64()
+		result.Currencies = append(result.Currencies, *info)
+	}
+	return result, nil
+}
+
+// NewOrder creates a new order on the exchange.
+// Returns the ID of the new exchange order.
+func (g *GateIO) NewOrder(p pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return g.NewOrderContext(context.Background(), p, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (g *GateIO) NewOrderContext(ctx context.Context, p pair.CurrencyPair, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	if err := g.GetCapabilities().CheckOrderOptions(opts...); err != nil {
+		return "", err
+	}
+	if orderType != exchange.OrderTypeExchangeLimit {
+		panic("not implemented")
+	}
+	method := gateioBuy
+	if side == exchange.OrderSideSell {
+		method = gateioSell
+	}
+	result, err := g.placeOrder(ctx, g.CurrencyPairToSymbol(p), method,
+		decimal.NewFromFloat(price).String(), decimal.NewFromFloat(amount).String())
+	if err != nil {
+		return "", err
+	}
+	return result.OrderNumber.String(), nil
+}
+
+// CancelOrder will attempt to cancel the active order matching the given ID.
+func (g *GateIO) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return g.CancelOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (g *GateIO) CancelOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) error {
+	return g.deleteOrder(ctx, g.CurrencyPairToSymbol(currencyPair), orderID)
+}
+
+// GetOrder returns information about a previously placed order (which may be active or inactive).
+func (g *GateIO) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	return g.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (g *GateIO) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	order, err := g.fetchOrder(ctx, g.CurrencyPairToSymbol(currencyPair), orderID)
+	if err != nil {
+		return nil, err
+	}
+	return g.convertOrderToExchangeOrder(order, g.orderCurrencyPair(order.CurrencyPair)), nil
+}
+
+// GetOrders returns information about currently active orders, the orders of all currency pairs
+// are returned if no pairs are given.
+func (g *GateIO) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return g.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (g *GateIO) GetOrdersContext(ctx context.Context, pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	if len(pairs) == 0 {
+		orders, err := g.fetchOpenOrders(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		symbols := make([]string, len(orders))
+		for i := range orders {
+			symbols[i] = orders[i].CurrencyPair
+		}
+		// the symbols of delisted pairs can't be converted in a batch
+		orderPairs, err := g.SymbolsToCurrencyPairs(symbols)
+		ret := make([]*exchange.Order, len(orders))
+		for i := range orders {
+			if err != nil {
+				ret[i] = g.convertOrderToExchangeOrder(&orders[i], g.orderCurrencyPair(symbols[i]))
+			} else {
+				ret[i] = g.convertOrderToExchangeOrder(&orders[i], orderPairs[i])
+			}
+		}
+		return ret, nil
+	}
+
+	symbols, err := g.CurrencyPairsToSymbols(pairs)
+	if err != nil {
+		return nil, err
+	}
+	ret := []*exchange.Order{}
+	for i, symbol := range symbols {
+		orders, err := g.fetchOpenOrders(ctx, symbol)
+		if err != nil {
+			return nil, err
+		}
+		for j := range orders {
+			ret = append(ret, g.convertOrderToExchangeOrder(&orders[j], pairs[i]))
+		}
+	}
+	return ret, nil
+}
+
+// orderCurrencyPair returns the currency pair of an order symbol, the symbols of pairs that are
+// no longer listed are parsed
+func (g *GateIO) orderCurrencyPair(symbol string) pair.CurrencyPair {
+	if p, err := g.SymbolToCurrencyPair(symbol); err == nil {
+		return p
+	}
+	return pair.NewCurrencyPairDelimiter(strings.ToUpper(symbol), gateioSymbolDelimiter)
+}
+
+func (g *GateIO) convertOrderToExchangeOrder(order *Order, currencyPair pair.CurrencyPair) *exchange.Order {
+	retOrder := &exchange.Order{}
+	retOrder.OrderID = order.OrderNumber.String()
+
+	switch order.Status {
+	case OrderStatusCancelled:
+		retOrder.Status = exchange.OrderStatusAborted
+	case OrderStatusClosed:
+		retOrder.Status = exchange.OrderStatusFilled
+	case OrderStatusOpen:
+		retOrder.Status = exchange.OrderStatusActive
+	default:
+		retOrder.Status = exchange.OrderStatusUnknown
+	}
+
+	retOrder.Amount = order.InitialAmount.Float64()
+	retOrder.FilledAmount = order.FilledAmount.Float64()
+	retOrder.RemainingAmount, _ = decimal.NewFromFloat(retOrder.Amount).
+		Sub(decimal.NewFromFloat(retOrder.FilledAmount)).Float64()
+	retOrder.Rate = order.InitialRate.Float64()
+	retOrder.CreatedAt = int64(order.Timestamp)
+	retOrder.CurrencyPair = currencyPair
+	if order.Type == gateioSell {
+		retOrder.Side = exchange.OrderSideSell
+	} else {
+		retOrder.Side = exchange.OrderSideBuy
+	}
+	// Only limit orders can be placed on Gate.io
+	retOrder.Type = exchange.OrderTypeExchangeLimit
+
+	return retOrder
+}
+
+// GetLimits returns price/amount limits for the exchange.
+func (g *GateIO) GetLimits() exchange.ILimits {
+	return newCurrencyLimits(g.Name, g.symbolDetailsMap)
+}
+
+// GetCurrencyPairs returns currency pairs that can be used by the exchange account
+// associated with this bot. Use FormatExchangeCurrency to get the right key.
+func (g *GateIO) GetCurrencyPairs() map[pair.CurrencyItem]*exchange.CurrencyPairInfo {
+	return g.currencyPairs
+}
+
+// ListInstruments returns the symbols that are currently trading on the exchange
+func (g *GateIO) ListInstruments() ([]exchange.Instrument, error) {
+	symbols, err := g.FetchPairs()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]exchange.Instrument, len(symbols))
+	for i, symbol := range symbols {
+		result[i] = exchange.Instrument{
+			Symbol: symbol,
+			Pair:   pair.NewCurrencyPairDelimiter(strings.ToUpper(symbol), gateioSymbolDelimiter),
+		}
+	}
+	return result, nil
+}
+
+type symbolDetails struct {
+	PriceDecimalPlaces  int32
+	AmountDecimalPlaces int32
+	MinAmount           float64
+	MinTotal            float64
+}
+
+type currencyLimits struct {
+	exchangeName string
+	// Maps symbol (lower-case) to symbol details
+	data map[pair.CurrencyItem]*symbolDetails
+}
+
+func newCurrencyLimits(exchangeName string, data map[pair.CurrencyItem]*symbolDetails) *currencyLimits {
+	return &currencyLimits{exchangeName, data}
+}
+
+// Returns max number of decimal places allowed in the trade price for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetPriceDecimalPlaces(p pair.CurrencyPair) int32 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.PriceDecimalPlaces
+	}
+	return -1
+}
+
+// Returns max number of decimal places allowed in the trade amount for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetAmountDecimalPlaces(p pair.CurrencyPair) int32 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.AmountDecimalPlaces
+	}
+	return -1
+}
+
+// Returns the minimum trade amount for the given currency pair.
+func (cl *currencyLimits) GetMinAmount(p pair.CurrencyPair) float64 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.MinAmount
+	}
+	return 0
+}
+
+// Returns the minimum trade total (amount * price) for the given currency pair.
+func (cl *currencyLimits) GetMinTotal(p pair.CurrencyPair) float64 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.MinTotal
+	}
+	return 0
+}
@@ -17,6 +17,7 @@ import (
 const (
 	gdaxAPIURL                  = "https://api.gdax.com/"
 	gdaxSandboxAPIURL           = "https://public.sandbox.gdax.com"
+	gdaxStatusSummaryURL        = "https://status.gdax.com/api/v2/summary.json"
 	gdaxAPIVersion              = "0"
 	gdaxProducts                = "products"
 	gdaxOrderbook               = "book"
@@ -55,6 +56,16 @@ type GDAX struct {
 	exchange.Base
 }
 
+// New returns a GDAX exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+// The API key passphrase must be set with exchange.WithClientID to use the authenticated API.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *GDAX {
+	g := &GDAX{}
+	g.SetDefaults()
+	g.Quickstart(g.Setup, apiKey, apiSecret, opts...)
+	return g
+}
+
 // SetDefaults sets default values for the exchange
 func (g *GDAX) SetDefaults() {
 	g.Name = "GDAX"
@@ -71,6 +82,7 @@ func (g *GDAX) SetDefaults() {
 	g.ConfigCurrencyPairFormat.Uppercase = true
 	g.AssetTypes = []string{ticker.Spot}
 	g.APIUrl = gdaxAPIURL
+	g.SetStreams(exchange.StreamTrades)
 }
 
 // Setup initialises the exchange parameters with the current configuration
@@ -90,6 +102,7 @@ func (g *GDAX) Setup(exch config.ExchangeConfig) {
 		if exch.UseSandbox {
 			g.APIUrl = gdaxSandboxAPIURL
 		}
+		g.SetAPIURL(exch)
 		err := g.SetCurrencyPairFormat()
 		if err != nil {
 			log.Fatal(err)
@@ -280,6 +293,40 @@ func (g *GDAX) GetServerTime() (ServerTime, error) {
 		common.SendHTTPGetRequest(g.APIUrl+gdaxTime, true, g.Verbose, &serverTime)
 }
 
+// GetStatusSummary returns the overall status & scheduled maintenance from the status page
+func (g *GDAX) GetStatusSummary() (StatusSummary, error) {
+	summary := StatusSummary{}
+
+	return summary,
+		common.SendHTTPGetRequest(gdaxStatusSummaryURL, true, g.Verbose, &summary)
+}
+
+// GetPlatformStatus returns whether the exchange is operational, along with the planned
+// maintenance announced on the status page.
+func (g *GDAX) GetPlatformStatus() (exchange.PlatformStatus, error) {
+	summary, err := g.GetStatusSummary()
+	if err != nil {
+		return exchange.PlatformStatus{}, err
+	}
+	status := exchange.PlatformStatus{
+		Operational: summary.Status.Indicator != "major" && summary.Status.Indicator != "critical",
+	}
+	for _, m := range summary.ScheduledMaintenances {
+		if m.Status == "completed" {
+			continue
+		}
+		if m.Status == "in_progress" || m.Status == "verifying" {
+			status.Operational = false
+		}
+		status.Maintenance = append(status.Maintenance, exchange.MaintenanceWindow{
+			Name:  m.Name,
+			Start: m.ScheduledFor,
+			End:   m.ScheduledUntil,
+		})
+	}
+	return status, nil
+}
+
 // GetAccounts returns a list of trading accounts associated with the APIKEYS
 func (g *GDAX) GetAccounts() ([]AccountResponse, error) {
 	resp := []AccountResponse{}
@@ -764,6 +811,8 @@ func (g *GDAX) GetTrailingVolume() ([]Volume, error) {
 
 // SendAuthenticatedHTTPRequest sends an authenticated HTTP reque
 func (g *GDAX) SendAuthenticatedHTTPRequest(method, path string, params map[string]interface{}, result interface{}) (err error) {
+	apiKey, apiSecret, clientID := g.Credentials()
+
 	if !g.AuthenticatedAPISupport {
 		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, g.Name)
 	}
@@ -783,12 +832,12 @@ func (g *GDAX) SendAuthenticatedHTTPRequest(method, path string, params map[stri
 
 	nonce := g.Nonce.GetValue(g.Name, false).String()
 	message := nonce + method + "/" + path + string(payload)
-	hmac := common.GetHMAC(common.HashSHA256, []byte(message), []byte(g.APISecret))
+	hmac := common.GetHMAC(common.HashSHA256, []byte(message), []byte(apiSecret))
 	headers := make(map[string]string)
 	headers["CB-ACCESS-SIGN"] = common.Base64Encode([]byte(hmac))
 	headers["CB-ACCESS-TIMESTAMP"] = nonce
-	headers["CB-ACCESS-KEY"] = g.APIKey
-	headers["CB-ACCESS-PASSPHRASE"] = g.ClientID
+	headers["CB-ACCESS-KEY"] = apiKey
+	headers["CB-ACCESS-PASSPHRASE"] = clientID
 	headers["Content-Type"] = "application/json"
 
 	resp, err := common.SendHTTPRequest(method, g.APIUrl+path, headers, bytes.NewBuffer(payload))
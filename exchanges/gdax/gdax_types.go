@@ -1,5 +1,7 @@
 package gdax
 
+import "time"
+
 // Product holds product information
 type Product struct {
 	ID             string  `json:"id"`
@@ -53,6 +55,23 @@ type Currency struct {
 	MinSize float64 `json:"min_size,string"`
 }
 
+// StatusSummary holds the overall status & the scheduled maintenance published on the GDAX status
+// page
+type StatusSummary struct {
+	Status struct {
+		// none, minor, major, critical or maintenance
+		Indicator   string `json:"indicator"`
+		Description string `json:"description"`
+	} `json:"status"`
+	ScheduledMaintenances []struct {
+		Name string `json:"name"`
+		// scheduled, in_progress, verifying or completed
+		Status         string    `json:"status"`
+		ScheduledFor   time.Time `json:"scheduled_for"`
+		ScheduledUntil time.Time `json:"scheduled_until"`
+	} `json:"scheduled_maintenances"`
+}
+
 // ServerTime holds current requested server time information
 type ServerTime struct {
 	ISO   string  `json:"iso"`
@@ -384,6 +403,7 @@ type WebsocketMatch struct {
 	Type         string  `json:"type"`
 	TradeID      int     `json:"trade_id"`
 	Sequence     int     `json:"sequence"`
+	ProductID    string  `json:"product_id"`
 	MakerOrderID string  `json:"maker_order_id"`
 	TakerOrderID string  `json:"taker_order_id"`
 	Time         string  `json:"time"`
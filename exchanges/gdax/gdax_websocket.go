@@ -3,9 +3,13 @@ package gdax
 import (
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
 )
 
 const (
@@ -29,7 +33,7 @@ func (g *GDAX) WebsocketSubscribe(product string, conn *websocket.Conn) error {
 
 func (g *GDAX) WebsocketClient() {
 	for g.Enabled && g.Websocket {
-		var Dialer websocket.Dialer
+		Dialer := websocket.Dialer{EnableCompression: g.WebsocketCompression()}
 		conn, _, err := Dialer.Dial(GDAX_WEBSOCKET_URL, http.Header{})
 
 		if err != nil {
@@ -63,63 +67,77 @@ func (g *GDAX) WebsocketClient() {
 				log.Println(err)
 				break
 			}
+			g.RecordWebsocketFrame(msgType, resp)
 
-			switch msgType {
-			case websocket.TextMessage:
-				type MsgType struct {
-					Type string `json:"type"`
-				}
-
-				msgType := MsgType{}
-				err := common.JSONDecode(resp, &msgType)
-				if err != nil {
-					log.Println(err)
-					continue
-				}
-
-				switch msgType.Type {
-				case "error":
-					log.Println(string(resp))
-					break
-				case "received":
-					received := WebsocketReceived{}
-					err := common.JSONDecode(resp, &received)
-					if err != nil {
-						log.Println(err)
-						continue
-					}
-				case "open":
-					open := WebsocketOpen{}
-					err := common.JSONDecode(resp, &open)
-					if err != nil {
-						log.Println(err)
-						continue
-					}
-				case "done":
-					done := WebsocketDone{}
-					err := common.JSONDecode(resp, &done)
-					if err != nil {
-						log.Println(err)
-						continue
-					}
-				case "match":
-					match := WebsocketMatch{}
-					err := common.JSONDecode(resp, &match)
-					if err != nil {
-						log.Println(err)
-						continue
-					}
-				case "change":
-					change := WebsocketChange{}
-					err := common.JSONDecode(resp, &change)
-					if err != nil {
-						log.Println(err)
-						continue
-					}
-				}
+			if msgType != websocket.TextMessage {
+				continue
+			}
+			if err = g.WebsocketHandleMessage(resp); err != nil {
+				log.Println(err)
 			}
 		}
 		conn.Close()
 		log.Printf("%s Websocket client disconnected.", g.GetName())
 	}
 }
+
+// WebsocketHandleMessage parses a message of the product channels, the matches are published to
+// the trade subscribers
+func (g *GDAX) WebsocketHandleMessage(resp []byte) error {
+	type MsgType struct {
+		Type string `json:"type"`
+	}
+
+	msgType := MsgType{}
+	err := common.JSONDecode(resp, &msgType)
+	if err != nil {
+		return err
+	}
+
+	switch msgType.Type {
+	case "error":
+		log.Println(string(resp))
+	case "received":
+		received := WebsocketReceived{}
+		return common.JSONDecode(resp, &received)
+	case "open":
+		open := WebsocketOpen{}
+		return common.JSONDecode(resp, &open)
+	case "done":
+		done := WebsocketDone{}
+		return common.JSONDecode(resp, &done)
+	case "match":
+		match := WebsocketMatch{}
+		if err = common.JSONDecode(resp, &match); err != nil {
+			return err
+		}
+		return g.publishMatch(&match)
+	case "change":
+		change := WebsocketChange{}
+		return common.JSONDecode(resp, &change)
+	}
+	return nil
+}
+
+// publishMatch publishes a match as a public trade, the side of a match is the side of the maker
+// order so the taker traded on the other side
+func (g *GDAX) publishMatch(match *WebsocketMatch) error {
+	tradeTime, err := time.Parse(time.RFC3339Nano, match.Time)
+	if err != nil {
+		return err
+	}
+	side := exchange.OrderSideSell
+	if match.Side == "sell" {
+		side = exchange.OrderSideBuy
+	}
+	g.PublishTrade(exchange.Trade{
+		ID:           strconv.Itoa(match.TradeID),
+		Exchange:     g.Name,
+		CurrencyPair: pair.NewCurrencyPairDelimiter(match.ProductID, "-"),
+		Side:         side,
+		Amount:       match.Size,
+		Price:        match.Price,
+		Time:         tradeTime,
+	})
+	return nil
+}
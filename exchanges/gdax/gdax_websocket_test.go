@@ -0,0 +1,43 @@
+package gdax
+
+import (
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+func TestWebsocketMatch(t *testing.T) {
+	g := GDAX{}
+	g.SetDefaults()
+	g.Websocket = true
+	p := pair.NewCurrencyPair("BTC", "USD")
+	trades, unsubscribe, err := g.SubscribeTrades(p)
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeTrades returned an error: %s", err)
+	}
+	defer unsubscribe()
+
+	messages := []string{
+		`{"type":"open","time":"2014-11-07T08:19:27.028459Z","product_id":"BTC-USD","sequence":10,"order_id":"d50ec984-77a8-460a-b958-66f114b0de9b","price":"200.2","remaining_size":"1.00","side":"sell"}`,
+		`{"type":"match","trade_id":10,"sequence":50,"maker_order_id":"ac928c66-ca53-498f-9c13-a110027a60e8","taker_order_id":"132fb6ae-456b-4654-b4e0-d681ac05cea1","time":"2014-11-07T08:19:27.028459Z","product_id":"BTC-USD","size":"5.23512","price":"400.23","side":"sell"}`,
+		`{"type":"match","trade_id":11,"sequence":51,"time":"2014-11-07T08:19:28.464459Z","product_id":"ETH-USD","size":"1","price":"10","side":"buy"}`,
+	}
+	for _, msg := range messages {
+		if err = g.WebsocketHandleMessage([]byte(msg)); err != nil {
+			t.Fatalf("Test failed. WebsocketHandleMessage returned an error: %s", err)
+		}
+	}
+	if len(trades) != 1 {
+		t.Fatalf("Test failed. Expected a single BTC-USD trade, got %d", len(trades))
+	}
+	// The maker sold, so the taker bought
+	if tr := <-trades; tr.ID != "10" || tr.Side != exchange.OrderSideBuy || tr.Amount != 5.23512 ||
+		tr.Price != 400.23 || tr.Time.UnixNano() != 1415348367028459000 || tr.Exchange != "GDAX" {
+		t.Errorf("Test failed. Unexpected trade %+v", tr)
+	}
+
+	if err = g.WebsocketHandleMessage([]byte(`{"type":"match","time":"yesterday","product_id":"BTC-USD"}`)); err == nil {
+		t.Error("Test failed. Expected an invalid time error")
+	}
+}
@@ -97,7 +97,7 @@ func (g *GDAX) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.Pri
 }
 
 // GetOrderbookEx returns orderbook base on the currency pair
-func (g *GDAX) GetOrderbookEx(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+func (g *GDAX) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
 	ob, err := g.Orderbooks.GetOrderbook(g.GetName(), p, assetType)
 	if err == nil {
 		return g.UpdateOrderbook(p, assetType)
@@ -115,10 +115,12 @@ func (g *GDAX) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook
 
 	obNew := orderbookNew.(OrderbookL1L2)
 
+	orderBook.Bids = orderbook.GetItems(len(obNew.Bids))
 	for x := range obNew.Bids {
 		orderBook.Bids = append(orderBook.Bids, orderbook.Item{Amount: obNew.Bids[x].Amount, Price: obNew.Bids[x].Price})
 	}
 
+	orderBook.Asks = orderbook.GetItems(len(obNew.Asks))
 	for x := range obNew.Asks {
 		orderBook.Asks = append(orderBook.Asks, orderbook.Item{Amount: obNew.Bids[x].Amount, Price: obNew.Bids[x].Price})
 	}
@@ -1,6 +1,7 @@
 package gemini
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -99,6 +100,15 @@ func AddSession(g *Gemini, sessionID int, apiKey, apiSecret, role string, needsH
 	return nil
 }
 
+// New returns a Gemini exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *Gemini {
+	g := &Gemini{}
+	g.SetDefaults()
+	g.Quickstart(g.Setup, apiKey, apiSecret, opts...)
+	return g
+}
+
 // SetDefaults sets package defaults for gemini exchange
 func (g *Gemini) SetDefaults() {
 	g.Name = "Gemini"
@@ -106,6 +116,8 @@ func (g *Gemini) SetDefaults() {
 	g.Verbose = false
 	g.Websocket = false
 	g.RESTPollingDelay = 10
+	g.TakerFee = 0.25
+	g.MakerFee = 0.25
 	g.RequestCurrencyPairFormat.Delimiter = ""
 	g.RequestCurrencyPairFormat.Uppercase = true
 	g.ConfigCurrencyPairFormat.Delimiter = ""
@@ -131,6 +143,7 @@ func (g *Gemini) Setup(exch config.ExchangeConfig) {
 		if exch.UseSandbox {
 			g.APIUrl = geminiSandboxAPIURL
 		}
+		g.SetAPIURL(exch)
 		err := g.SetCurrencyPairFormat()
 		if err != nil {
 			log.Fatal(err)
@@ -255,6 +268,10 @@ func (g *Gemini) GetSymbols() ([]string, error) {
 
 // GetTicker returns information about recent trading activity for the symbol
 func (g *Gemini) GetTicker(currencyPair string) (Ticker, error) {
+	return g.getTicker(context.Background(), currencyPair)
+}
+
+func (g *Gemini) getTicker(ctx context.Context, currencyPair string) (Ticker, error) {
 
 	type TickerResponse struct {
 		Ask    float64 `json:"ask,string"`
@@ -267,7 +284,7 @@ func (g *Gemini) GetTicker(currencyPair string) (Ticker, error) {
 	resp := TickerResponse{}
 	path := fmt.Sprintf("%s/v%s/%s/%s", g.APIUrl, geminiAPIVersion, geminiTicker, currencyPair)
 
-	err := common.SendHTTPGetRequest(path, true, g.Verbose, &resp)
+	err := common.SendHTTPGetRequestContext(ctx, path, true, g.Verbose, &resp)
 	if err != nil {
 		return ticker, err
 	}
@@ -291,9 +308,14 @@ func (g *Gemini) GetTicker(currencyPair string) (Ticker, error) {
 // params - limit_bids or limit_asks [OPTIONAL] default 50, 0 returns all Values
 // Type is an integer ie "params.Set("limit_asks", 30)"
 func (g *Gemini) GetOrderbook(currencyPair string, params url.Values) (Orderbook, error) {
+	return g.getOrderbook(context.Background(), currencyPair, params)
+}
+
+func (g *Gemini) getOrderbook(ctx context.Context, currencyPair string,
+	params url.Values) (Orderbook, error) {
 	path := common.EncodeURLValues(fmt.Sprintf("%s/v%s/%s/%s", g.APIUrl, geminiAPIVersion, geminiOrderbook, currencyPair), params)
 	orderbook := Orderbook{}
-	return orderbook, common.SendHTTPGetRequest(path, true, g.Verbose, &orderbook)
+	return orderbook, common.SendHTTPGetRequestContext(ctx, path, true, g.Verbose, &orderbook)
 }
 
 // GetTrades eturn the trades that have executed since the specified timestamp.
@@ -347,7 +369,18 @@ func (g *Gemini) isCorrectSession(role string) error {
 
 // NewOrder Only limit orders are supported through the API at present.
 // returns order ID if successful
-func (g *Gemini) NewOrder(symbol pair.CurrencyPair, amount, price float64, side exchange.OrderSide, orderType exchange.OrderType) (string, error) {
+func (g *Gemini) NewOrder(symbol pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return g.NewOrderContext(context.Background(), symbol, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (g *Gemini) NewOrderContext(ctx context.Context, symbol pair.CurrencyPair, amount,
+	price float64, side exchange.OrderSide, orderType exchange.OrderType,
+	opts ...exchange.OrderOptions) (string, error) {
+	if err := g.GetCapabilities().CheckOrderOptions(opts...); err != nil {
+		return "", err
+	}
 	request := make(map[string]interface{})
 	request["symbol"] = symbol.Display("", false)
 	request["amount"] = strconv.FormatFloat(amount, 'f', -1, 64)
@@ -356,7 +389,7 @@ func (g *Gemini) NewOrder(symbol pair.CurrencyPair, amount, price float64, side
 	request["type"] = orderType
 
 	response := Order{}
-	err := g.SendAuthenticatedHTTPRequest("POST", geminiOrderNew, request, &response)
+	err := g.SendAuthenticatedHTTPRequestContext(ctx, "POST", geminiOrderNew, request, &response)
 	if err != nil {
 		return "", err
 	}
@@ -366,11 +399,15 @@ func (g *Gemini) NewOrder(symbol pair.CurrencyPair, amount, price float64, side
 // CancelOrder will cancel an order. If the order is already canceled, the
 // message will succeed but have no effect.
 func (g *Gemini) CancelOrderEx(OrderID int64) (Order, error) {
+	return g.cancelOrderEx(context.Background(), OrderID)
+}
+
+func (g *Gemini) cancelOrderEx(ctx context.Context, OrderID int64) (Order, error) {
 	request := make(map[string]interface{})
 	request["order_id"] = OrderID
 
 	response := Order{}
-	err := g.SendAuthenticatedHTTPRequest("POST", geminiOrderCancel, request, &response)
+	err := g.SendAuthenticatedHTTPRequestContext(ctx, "POST", geminiOrderCancel, request, &response)
 	if err != nil {
 		return Order{}, err
 	}
@@ -378,12 +415,18 @@ func (g *Gemini) CancelOrderEx(OrderID int64) (Order, error) {
 }
 
 func (g *Gemini) CancelOrder(orderStr string, currencyPair pair.CurrencyPair) error {
+	return g.CancelOrderContext(context.Background(), orderStr, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (g *Gemini) CancelOrderContext(ctx context.Context, orderStr string,
+	currencyPair pair.CurrencyPair) error {
 	var orderID int64
 	var err error
 	if orderID, err = strconv.ParseInt(orderStr, 10, 64); err != nil {
 		return err
 	}
-	_, err = g.CancelOrderEx(orderID)
+	_, err = g.cancelOrderEx(ctx, orderID)
 	return err
 }
 
@@ -404,13 +447,17 @@ func (g *Gemini) cancelOrders(CancelBySession bool) (OrderResult, error) {
 // GetOrderStatus returns information about any exchange order created via this exchange account.
 // OrderID is the exchange generated order ID.
 func (g *Gemini) GetOrderStatus(orderID int64) (*Order, error) {
+	return g.getOrderStatus(context.Background(), orderID)
+}
+
+func (g *Gemini) getOrderStatus(ctx context.Context, orderID int64) (*Order, error) {
 	request := make(map[string]interface{})
 	request["order_id"] = orderID
 
 	response := &Order{}
 
 	return response,
-		g.SendAuthenticatedHTTPRequest("POST", geminiOrderStatus, request, &response)
+		g.SendAuthenticatedHTTPRequestContext(ctx, "POST", geminiOrderStatus, request, &response)
 }
 
 // GetOrder returns information about any exchange order previously created via this exchange
@@ -418,11 +465,17 @@ func (g *Gemini) GetOrderStatus(orderID int64) (*Order, error) {
 // that were cancelled.
 // OrderID is the exchange generated order ID.
 func (g *Gemini) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	return g.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (g *Gemini) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
 	orderIDInt, err := strconv.ParseInt(orderID, 10, 64)
 	if err != nil {
 		return nil, err
 	}
-	order, err := g.GetOrderStatus(orderIDInt)
+	order, err := g.getOrderStatus(ctx, orderIDInt)
 	if err != nil {
 		return nil, err
 	}
@@ -470,8 +523,13 @@ func tradeHistoryToExchangeOrders(symbol string, pastTrades []TradeHistory) []ex
 
 // GetOrders returns the active exchange orders for this exchange account.
 func (g *Gemini) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return g.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (g *Gemini) GetOrdersContext(ctx context.Context, pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
 	// Fetch active orders.
-	orders, err := g.getOrders()
+	orders, err := g.getOrders(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -486,11 +544,11 @@ func (g *Gemini) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error)
 }
 
 // GetOrders returns active orders in the market
-func (g *Gemini) getOrders() ([]*Order, error) {
+func (g *Gemini) getOrders(ctx context.Context) ([]*Order, error) {
 	response := []*Order{}
 
 	return response,
-		g.SendAuthenticatedHTTPRequest("POST", geminiOrders, nil, &response)
+		g.SendAuthenticatedHTTPRequestContext(ctx, "POST", geminiOrders, nil, &response)
 }
 
 // GetTradeHistory returns an array of past trades for this exchange account.
@@ -521,10 +579,14 @@ func (g *Gemini) GetTradeVolume() ([][]TradeVolume, error) {
 
 // GetBalances returns available balances in the supported currencies
 func (g *Gemini) GetBalances() ([]Balance, error) {
+	return g.getBalances(context.Background())
+}
+
+func (g *Gemini) getBalances(ctx context.Context) ([]Balance, error) {
 	response := []Balance{}
 
 	return response,
-		g.SendAuthenticatedHTTPRequest("POST", geminiBalances, nil, &response)
+		g.SendAuthenticatedHTTPRequestContext(ctx, "POST", geminiBalances, nil, &response)
 }
 
 // GetDepositAddress returns a deposit address
@@ -561,6 +623,15 @@ func (g *Gemini) PostHeartbeat() (string, error) {
 // SendAuthenticatedHTTPRequest sends an authenticated HTTP request to the
 // exchange and returns an error
 func (g *Gemini) SendAuthenticatedHTTPRequest(method, path string, params map[string]interface{}, result interface{}) (err error) {
+	return g.SendAuthenticatedHTTPRequestContext(context.Background(), method, path, params, result)
+}
+
+// SendAuthenticatedHTTPRequestContext is SendAuthenticatedHTTPRequest, the request is cancelled
+// when the context is done
+func (g *Gemini) SendAuthenticatedHTTPRequestContext(ctx context.Context, method, path string,
+	params map[string]interface{}, result interface{}) (err error) {
+	apiKey, apiSecret, _ := g.Credentials()
+
 	if !g.AuthenticatedAPISupport {
 		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, g.Name)
 	}
@@ -586,13 +657,14 @@ func (g *Gemini) SendAuthenticatedHTTPRequest(method, path string, params map[st
 	}
 
 	PayloadBase64 := common.Base64Encode(PayloadJSON)
-	hmac := common.GetHMAC(common.HashSHA512_384, []byte(PayloadBase64), []byte(g.APISecret))
+	hmac := common.GetHMAC(common.HashSHA512_384, []byte(PayloadBase64), []byte(apiSecret))
 
-	headers["X-GEMINI-APIKEY"] = g.APIKey
+	headers["X-GEMINI-APIKEY"] = apiKey
 	headers["X-GEMINI-PAYLOAD"] = PayloadBase64
 	headers["X-GEMINI-SIGNATURE"] = common.HexEncodeToString(hmac)
 
-	resp, err := common.SendHTTPRequest(method, g.APIUrl+"/v1/"+path, headers, strings.NewReader(""))
+	resp, err := common.SendHTTPRequestContext(ctx, method, g.APIUrl+"/v1/"+path, headers,
+		strings.NewReader(""))
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,7 @@
 package gemini
 
 import (
+	"context"
 	"log"
 	"net/url"
 
@@ -11,6 +12,9 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+var _ exchange.IExchange = (*Gemini)(nil)
+var _ exchange.ContextExchange = (*Gemini)(nil)
+
 // Start starts the Gemini go routine
 func (g *Gemini) Start() {
 	go g.Run()
@@ -37,9 +41,15 @@ func (g *Gemini) Run() {
 // GetExchangeAccountInfo Retrieves balances for all enabled currencies for the
 // Gemini exchange
 func (g *Gemini) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return g.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (g *Gemini) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
 	var response exchange.AccountInfo
 	response.ExchangeName = g.GetName()
-	accountBalance, err := g.GetBalances()
+	accountBalance, err := g.getBalances(ctx)
 	if err != nil {
 		return response, err
 	}
@@ -59,8 +69,14 @@ func (g *Gemini) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
 
 // UpdateTicker updates and returns the ticker for a currency pair
 func (g *Gemini) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return g.UpdateTickerContext(context.Background(), p, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (g *Gemini) UpdateTickerContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
 	var tickerPrice ticker.Price
-	tick, err := g.GetTicker(p.Pair().String())
+	tick, err := g.getTicker(ctx, p.Pair().String())
 	if err != nil {
 		return tickerPrice, err
 	}
@@ -83,7 +99,7 @@ func (g *Gemini) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.P
 }
 
 // GetOrderbookEx returns orderbook base on the currency pair
-func (g *Gemini) GetOrderbookEx(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+func (g *Gemini) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
 	ob, err := g.Orderbooks.GetOrderbook(g.GetName(), p, assetType)
 	if err == nil {
 		return g.UpdateOrderbook(p, assetType)
@@ -93,17 +109,25 @@ func (g *Gemini) GetOrderbookEx(p pair.CurrencyPair, assetType string) (orderboo
 
 // UpdateOrderbook updates and returns the orderbook for a currency pair
 func (g *Gemini) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return g.UpdateOrderbookContext(context.Background(), p, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (g *Gemini) UpdateOrderbookContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
 	var orderBook orderbook.Base
 	obookStr := string(p.Display("", false))
-	orderbookNew, err := g.GetOrderbook(obookStr, url.Values{})
+	orderbookNew, err := g.getOrderbook(ctx, obookStr, url.Values{})
 	if err != nil {
 		return orderBook, err
 	}
 
+	orderBook.Bids = orderbook.GetItems(len(orderbookNew.Bids))
 	for x := range orderbookNew.Bids {
 		orderBook.Bids = append(orderBook.Bids, orderbook.Item{Amount: orderbookNew.Bids[x].Amount, Price: orderbookNew.Bids[x].Price})
 	}
 
+	orderBook.Asks = orderbook.GetItems(len(orderbookNew.Asks))
 	for x := range orderbookNew.Asks {
 		orderBook.Asks = append(orderBook.Asks, orderbook.Item{Amount: orderbookNew.Asks[x].Amount, Price: orderbookNew.Asks[x].Price})
 	}
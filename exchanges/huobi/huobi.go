@@ -24,6 +24,15 @@ type HUOBI struct {
 	exchange.Base
 }
 
+// New returns a Huobi exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *HUOBI {
+	h := &HUOBI{}
+	h.SetDefaults()
+	h.Quickstart(h.Setup, apiKey, apiSecret, opts...)
+	return h
+}
+
 func (h *HUOBI) SetDefaults() {
 	h.Name = "Huobi"
 	h.Enabled = false
@@ -215,14 +224,16 @@ func (h *HUOBI) GetOrderIDByTradeID(coinType, orderID int) {
 }
 
 func (h *HUOBI) SendAuthenticatedRequest(method string, v url.Values) error {
+	apiKey, apiSecret, _ := h.Credentials()
+
 	if !h.AuthenticatedAPISupport {
 		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, h.Name)
 	}
 
-	v.Set("access_key", h.APIKey)
+	v.Set("access_key", apiKey)
 	v.Set("created", strconv.FormatInt(time.Now().Unix(), 10))
 	v.Set("method", method)
-	hash := common.GetMD5([]byte(v.Encode() + "&secret_key=" + h.APISecret))
+	hash := common.GetMD5([]byte(v.Encode() + "&secret_key=" + apiSecret))
 	v.Set("sign", common.StringToLower(common.HexEncodeToString(hash)))
 	encoded := v.Encode()
 
@@ -56,7 +56,7 @@ func (h *HUOBI) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.Pr
 }
 
 // GetOrderbookEx returns orderbook base on the currency pair
-func (h *HUOBI) GetOrderbookEx(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+func (h *HUOBI) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
 	ob, err := h.Orderbooks.GetOrderbook(h.GetName(), p, assetType)
 	if err == nil {
 		return h.UpdateOrderbook(p, assetType)
@@ -0,0 +1,381 @@
+// Package idex implements the IDEX decentralized exchange, which trades ERC-20 tokens against
+// Ether. Orders are signed with the private key of the trading wallet (the API secret) and
+// settled by the IDEX contract, the funds must be deposited in the contract before they can be
+// traded.
+package idex
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	exchange "github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ethereum"
+)
+
+const (
+	idexAPIURL                = "https://api.idex.market"
+	idexTicker                = "returnTicker"
+	idexCurrencies            = "returnCurrencies"
+	idexOrderBook             = "returnOrderBook"
+	idexCompleteBalances      = "returnCompleteBalances"
+	idexOpenOrders            = "returnOpenOrders"
+	idexOrderStatus           = "returnOrderStatus"
+	idexNextNonce             = "returnNextNonce"
+	idexContractAddress       = "returnContractAddress"
+	idexOrder                 = "order"
+	idexCancel                = "cancel"
+	idexSymbolDelimiter       = "_"
+	idexOrderBookDepth        = 100
+	idexMaxOpenOrdersPerQuery = 100
+	// Block number orders expire at, IDEX doesn't enforce it but it's part of the signed order
+	idexOrderExpires = 100000
+	// Minimum total of an order in ETH
+	idexMinOrderTotal = 0.15
+)
+
+// ErrUnknownCurrency is returned when trading a currency IDEX doesn't list
+var ErrUnknownCurrency = errors.New("currency isn't listed on IDEX")
+
+// IDEX is the client of the IDEX exchange. The API key is only needed for the websocket, the API
+// secret is the hex encoded private key of the wallet that trades on IDEX.
+type IDEX struct {
+	exchange.Base
+	// Reads the balances held by the wallet outside of the IDEX contract, nil if no Ethereum node
+	// is configured
+	Node *ethereum.Node
+
+	mtx sync.Mutex
+	// Tokens listed on IDEX keyed by symbol
+	currencies map[string]Currency
+	// Address of the IDEX contract, part of the signed orders
+	contractAddress string
+	// Maps symbol (exchange specific market identifier) to currency pair info
+	currencyPairs map[pair.CurrencyItem]*exchange.CurrencyPairInfo
+}
+
+// CurrencyPairToSymbol converts a currency pair (e.g. REP/ETH) to a symbol (exchange specific
+// market identifier, e.g. ETH_REP). IDEX lists the quote currency first.
+func (i *IDEX) CurrencyPairToSymbol(p pair.CurrencyPair) string {
+	return p.SecondCurrency.Upper().String() + idexSymbolDelimiter + p.FirstCurrency.Upper().String()
+}
+
+// SymbolToCurrencyPair converts a symbol (exchange specific market identifier) to a currency pair.
+func (i *IDEX) SymbolToCurrencyPair(symbol string) (pair.CurrencyPair, error) {
+	parts := strings.Split(symbol, idexSymbolDelimiter)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return pair.CurrencyPair{}, fmt.Errorf("invalid symbol '%s'", symbol)
+	}
+	return pair.NewCurrencyPair(strings.ToUpper(parts[1]), strings.ToUpper(parts[0])), nil
+}
+
+// FetchTickers fetches the tickers of all the markets, keyed by symbol.
+func (i *IDEX) FetchTickers() (map[string]Ticker, error) {
+	var response map[string]Ticker
+	err := i.SendHTTPRequest(idexTicker, map[string]interface{}{}, &response)
+	return response, err
+}
+
+// FetchTicker fetches the ticker of a market.
+func (i *IDEX) FetchTicker(symbol string) (*Ticker, error) {
+	return i.fetchTicker(context.Background(), symbol)
+}
+
+func (i *IDEX) fetchTicker(ctx context.Context, symbol string) (*Ticker, error) {
+	response := Ticker{}
+	err := i.SendHTTPRequestContext(ctx, idexTicker, map[string]interface{}{"market": symbol}, &response)
+	return &response, err
+}
+
+// FetchCurrencies fetches the tokens listed on IDEX, keyed by symbol.
+func (i *IDEX) FetchCurrencies() (map[string]Currency, error) {
+	var response map[string]Currency
+	err := i.SendHTTPRequest(idexCurrencies, map[string]interface{}{}, &response)
+	return response, err
+}
+
+// FetchOrderBook fetches up to count orders on each side of the orderbook of a market.
+func (i *IDEX) FetchOrderBook(symbol string, count int) (*OrderBook, error) {
+	return i.fetchOrderBook(context.Background(), symbol, count)
+}
+
+func (i *IDEX) fetchOrderBook(ctx context.Context, symbol string, count int) (*OrderBook, error) {
+	response := OrderBook{}
+	err := i.SendHTTPRequestContext(ctx, idexOrderBook,
+		map[string]interface{}{"market": symbol, "count": count}, &response)
+	return &response, err
+}
+
+// FetchCompleteBalances fetches the available & on order balances deposited by an address,
+// keyed by currency.
+func (i *IDEX) FetchCompleteBalances(address string) (map[string]Balance, error) {
+	return i.fetchCompleteBalances(context.Background(), address)
+}
+
+func (i *IDEX) fetchCompleteBalances(ctx context.Context, address string) (map[string]Balance, error) {
+	var response map[string]Balance
+	err := i.SendHTTPRequestContext(ctx, idexCompleteBalances, map[string]interface{}{"address": address},
+		&response)
+	return response, err
+}
+
+// FetchOpenOrders fetches the open orders of an address, of all the markets if symbol is empty.
+func (i *IDEX) FetchOpenOrders(symbol, address string) ([]Order, error) {
+	return i.fetchOpenOrders(context.Background(), symbol, address)
+}
+
+func (i *IDEX) fetchOpenOrders(ctx context.Context, symbol, address string) ([]Order, error) {
+	params := map[string]interface{}{"address": address, "count": idexMaxOpenOrdersPerQuery}
+	if symbol != "" {
+		params["market"] = symbol
+	}
+	var response []Order
+	err := i.SendHTTPRequestContext(ctx, idexOpenOrders, params, &response)
+	return response, err
+}
+
+// FetchOrderStatus fetches an order (which may be open, complete or cancelled).
+func (i *IDEX) FetchOrderStatus(orderHash string) (*Order, error) {
+	return i.fetchOrderStatus(context.Background(), orderHash)
+}
+
+func (i *IDEX) fetchOrderStatus(ctx context.Context, orderHash string) (*Order, error) {
+	response := Order{}
+	err := i.SendHTTPRequestContext(ctx, idexOrderStatus, map[string]interface{}{"orderHash": orderHash},
+		&response)
+	return &response, err
+}
+
+// FetchNextNonce fetches the lowest nonce the next signed request of an address can use.
+func (i *IDEX) FetchNextNonce(address string) (int64, error) {
+	return i.fetchNextNonce(context.Background(), address)
+}
+
+func (i *IDEX) fetchNextNonce(ctx context.Context, address string) (int64, error) {
+	var response struct {
+		Nonce int64 `json:"nonce"`
+	}
+	err := i.SendHTTPRequestContext(ctx, idexNextNonce, map[string]interface{}{"address": address}, &response)
+	return response.Nonce, err
+}
+
+// FetchContractAddress returns the address of the IDEX contract, the address is cached.
+func (i *IDEX) FetchContractAddress() (string, error) {
+	return i.fetchContractAddress(context.Background())
+}
+
+func (i *IDEX) fetchContractAddress(ctx context.Context) (string, error) {
+	i.mtx.Lock()
+	address := i.contractAddress
+	i.mtx.Unlock()
+	if address != "" {
+		return address, nil
+	}
+	var response struct {
+		Address string `json:"address"`
+	}
+	if err := i.SendHTTPRequestContext(ctx, idexContractAddress, map[string]interface{}{},
+		&response); err != nil {
+		return "", err
+	}
+	i.mtx.Lock()
+	i.contractAddress = response.Address
+	i.mtx.Unlock()
+	return response.Address, nil
+}
+
+// PlaceOrder places a limit order that gives amountSell of tokenSell in exchange for amountBuy of
+// tokenBuy, the amounts are in the base units of the tokens.
+func (i *IDEX) PlaceOrder(tokenBuy string, amountBuy *big.Int, tokenSell string, amountSell *big.Int) (*Order, error) {
+	return i.placeOrder(context.Background(), tokenBuy, amountBuy, tokenSell, amountSell)
+}
+
+func (i *IDEX) placeOrder(ctx context.Context, tokenBuy string, amountBuy *big.Int, tokenSell string,
+	amountSell *big.Int) (*Order, error) {
+	key, err := i.privateKey()
+	if err != nil {
+		return nil, err
+	}
+	contract, err := i.fetchContractAddress(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := i.fetchNextNonce(ctx, key.Address())
+	if err != nil {
+		return nil, err
+	}
+	hash, err := OrderHash(contract, tokenBuy, amountBuy, tokenSell, amountSell, idexOrderExpires, nonce,
+		key.Address())
+	if err != nil {
+		return nil, err
+	}
+	sig, err := key.SignPersonalMessage(hash)
+	if err != nil {
+		return nil, err
+	}
+	request := NewOrderRequest{
+		TokenBuy:   tokenBuy,
+		AmountBuy:  amountBuy.String(),
+		TokenSell:  tokenSell,
+		AmountSell: amountSell.String(),
+		Address:    key.Address(),
+		Nonce:      nonce,
+		Expires:    idexOrderExpires,
+		V:          sig.V,
+		R:          "0x" + hex.EncodeToString(sig.R[:]),
+		S:          "0x" + hex.EncodeToString(sig.S[:]),
+	}
+	response := Order{}
+	err = i.SendHTTPRequestContext(ctx, idexOrder, request, &response)
+	return &response, err
+}
+
+// DeleteOrder cancels an open order.
+func (i *IDEX) DeleteOrder(orderHash string) error {
+	return i.deleteOrder(context.Background(), orderHash)
+}
+
+func (i *IDEX) deleteOrder(ctx context.Context, orderHash string) error {
+	key, err := i.privateKey()
+	if err != nil {
+		return err
+	}
+	nonce, err := i.fetchNextNonce(ctx, key.Address())
+	if err != nil {
+		return err
+	}
+	hash, err := CancelHash(orderHash, nonce)
+	if err != nil {
+		return err
+	}
+	sig, err := key.SignPersonalMessage(hash)
+	if err != nil {
+		return err
+	}
+	request := CancelRequest{
+		OrderHash: orderHash,
+		Nonce:     nonce,
+		Address:   key.Address(),
+		V:         sig.V,
+		R:         "0x" + hex.EncodeToString(sig.R[:]),
+		S:         "0x" + hex.EncodeToString(sig.S[:]),
+	}
+	var response struct {
+		Success int `json:"success"`
+	}
+	return i.SendHTTPRequestContext(ctx, idexCancel, request, &response)
+}
+
+// OrderHash returns the hash of the order parameters signed when placing an order, the packed
+// (Solidity's abi.encodePacked) parameters hashed with Keccak-256.
+func OrderHash(contract, tokenBuy string, amountBuy *big.Int, tokenSell string, amountSell *big.Int,
+	expires, nonce int64, address string) ([]byte, error) {
+	var packed [][]byte
+	for _, a := range []string{contract, tokenBuy} {
+		b, err := ethereum.PackAddress(a)
+		if err != nil {
+			return nil, err
+		}
+		packed = append(packed, b)
+	}
+	packed = append(packed, ethereum.PackUint256(amountBuy))
+	b, err := ethereum.PackAddress(tokenSell)
+	if err != nil {
+		return nil, err
+	}
+	packed = append(packed, b, ethereum.PackUint256(amountSell),
+		ethereum.PackUint256(big.NewInt(expires)), ethereum.PackUint256(big.NewInt(nonce)))
+	if b, err = ethereum.PackAddress(address); err != nil {
+		return nil, err
+	}
+	return ethereum.Keccak256(append(packed, b)...), nil
+}
+
+// CancelHash returns the hash signed when cancelling an order, the order hash & nonce hashed
+// with Keccak-256.
+func CancelHash(orderHash string, nonce int64) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(orderHash, "0x"))
+	if err != nil || len(b) != 32 {
+		return nil, fmt.Errorf("invalid order hash '%s'", orderHash)
+	}
+	return ethereum.Keccak256(b, ethereum.PackUint256(big.NewInt(nonce))), nil
+}
+
+// Address returns the address of the trading wallet, derived from the private key.
+func (i *IDEX) Address() (string, error) {
+	key, err := i.privateKey()
+	if err != nil {
+		return "", err
+	}
+	return key.Address(), nil
+}
+
+// privateKey returns the private key of the trading wallet.
+func (i *IDEX) privateKey() (*ethereum.PrivateKey, error) {
+	if !i.AuthenticatedAPISupport {
+		return nil, fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, i.Name)
+	}
+	_, apiSecret, _ := i.Credentials()
+	key, err := ethereum.HexToPrivateKey(apiSecret)
+	if err != nil {
+		return nil, fmt.Errorf("%s API secret: %s", i.Name, err)
+	}
+	return key, nil
+}
+
+// SendHTTPRequest posts the JSON encoded params to an endpoint and decodes the response into the
+// result object. All the IDEX endpoints are POST requests, the signed requests carry their
+// signature in the params.
+func (i *IDEX) SendHTTPRequest(method string, params interface{}, result interface{}) error {
+	return i.SendHTTPRequestContext(context.Background(), method, params, result)
+}
+
+// SendHTTPRequestContext is SendHTTPRequest, the request is cancelled when the context is done
+func (i *IDEX) SendHTTPRequestContext(ctx context.Context, method string, params interface{},
+	result interface{}) error {
+	path := "/" + method
+	body, err := common.JSONEncode(params)
+	if err != nil {
+		return err
+	}
+	if i.Debug(exchange.TraceHTTP) {
+		log.Printf("Request: POST %s %s\n", path, body)
+	}
+
+	headers := make(http.Header)
+	headers.Set("Content-Type", "application/json")
+	if apiKey, _, _ := i.Credentials(); apiKey != "" {
+		headers.Set("API-Key", apiKey)
+	}
+	resp, statusCode, err := common.SendHTTPRequest2Context(ctx, http.MethodPost, i.APIUrl+path, headers,
+		bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if i.Debug(exchange.TraceHTTP) {
+		log.Printf("Received raw: \n%s\n", resp)
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(resp), "{") {
+		var errResp ErrorResponse
+		if err = common.JSONDecode([]byte(resp), &errResp); err == nil && errResp.Error != "" {
+			return exchange.NewExchangeError(i.Name, path, statusCode, 0, errResp.Error, resp)
+		}
+	}
+	if statusCode < 200 || statusCode > 299 {
+		return exchange.NewExchangeError(i.Name, path, statusCode, 0, "unexpected status", resp)
+	}
+	if err = common.JSONDecode([]byte(resp), result); err != nil {
+		return exchange.NewExchangeError(i.Name, path, statusCode, 0, "failed to unmarshal response", resp)
+	}
+	return nil
+}
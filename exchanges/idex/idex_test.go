@@ -0,0 +1,234 @@
+package idex
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ethereum"
+)
+
+const (
+	testPrivateKey = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	testAddress    = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+	testContract   = "0x2a0c0dbecc7e4d658f48e01e3fa353f44050c208"
+	testREP        = "0xe94327d07fc17907b4db788e5adf2ed424addff6"
+	testCurrencies = `{"ETH":{"name":"Ether","decimals":18,"address":"0x0000000000000000000000000000000000000000"},
+		"REP":{"name":"Reputation","decimals":18,"address":"0xe94327d07fc17907b4db788e5adf2ed424addff6"},
+		"DVIP":{"name":"Aurora","decimals":8,"address":"0xadc46ff5434910bd17b24ffb429e585223287d7f"}}`
+)
+
+func newTestIDEX(handler http.HandlerFunc) (*IDEX, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	i := &IDEX{}
+	i.SetDefaults()
+	i.APIUrl = server.URL
+	i.Enabled = true
+	i.AuthenticatedAPISupport = true
+	i.SetAPIKeys("key", testPrivateKey, "", false)
+	return i, server
+}
+
+func TestSymbolToCurrencyPair(t *testing.T) {
+	i := &IDEX{}
+	p, err := i.SymbolToCurrencyPair("ETH_REP")
+	if err != nil || p.FirstCurrency.String() != "REP" || p.SecondCurrency.String() != "ETH" {
+		t.Errorf("Test failed. Unexpected currency pair %v %v", p, err)
+	}
+	if symbol := i.CurrencyPairToSymbol(p); symbol != "ETH_REP" {
+		t.Errorf("Test failed. Unexpected symbol %s", symbol)
+	}
+	if _, err = i.SymbolToCurrencyPair("ETHREP"); err == nil {
+		t.Error("Test failed. SymbolToCurrencyPair accepted a symbol without delimiter")
+	}
+}
+
+func TestSetMarketInfo(t *testing.T) {
+	i, server := newTestIDEX(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/returnCurrencies":
+			fmt.Fprint(w, testCurrencies)
+		case "/returnTicker":
+			fmt.Fprint(w, `{"ETH_REP":{"last":"0.05","high":"N/A","low":"0.048","lowestAsk":"0.051",
+				"highestBid":"0.049","percentChange":"-1.2","baseVolume":"12.5","quoteVolume":"250"},
+				"ETH_DVIP":{"last":"N/A","high":"N/A","low":"N/A","lowestAsk":"N/A","highestBid":"N/A",
+				"percentChange":"0","baseVolume":"0","quoteVolume":"0"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	currencies, err := i.FetchCurrencies()
+	if err != nil {
+		t.Fatalf("Test failed. FetchCurrencies returned an error: %s", err)
+	}
+	tickers, err := i.FetchTickers()
+	if err != nil {
+		t.Fatalf("Test failed. FetchTickers returned an error: %s", err)
+	}
+	if tickers["ETH_REP"].LowestAsk != 0.051 || tickers["ETH_REP"].High != 0 {
+		t.Errorf("Test failed. Unexpected ticker %+v", tickers["ETH_REP"])
+	}
+	i.setMarketInfo(currencies, tickers)
+	if len(i.GetCurrencyPairs()) != 2 || i.GetCurrencyPairs()["ETH_DVIP"].FirstCurrencyName != "DVIP" {
+		t.Errorf("Test failed. Unexpected currency pairs %+v", i.GetCurrencyPairs())
+	}
+	limits := i.GetLimits()
+	dvip := pair.NewCurrencyPair("DVIP", "ETH")
+	if limits.GetAmountDecimalPlaces(dvip) != 8 || limits.GetPriceDecimalPlaces(dvip) != -1 ||
+		limits.GetMinTotal(dvip) != idexMinOrderTotal {
+		t.Error("Test failed. Unexpected DVIP/ETH limits")
+	}
+}
+
+func TestUpdateOrderbook(t *testing.T) {
+	i, server := newTestIDEX(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.URL.Path != "/returnOrderBook" || string(body) != `{"count":100,"market":"ETH_REP"}` {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"asks":[{"price":"0.051","amount":"2","total":"0.102","orderHash":"0x1"},
+			{"price":"0.052","amount":"1.5","total":"0.078","orderHash":"0x2"}],
+			"bids":[{"price":"0.049","amount":"3","total":"0.147","orderHash":"0x3"}]}`)
+	})
+	defer server.Close()
+
+	book, err := i.UpdateOrderbook(pair.NewCurrencyPair("REP", "ETH"), "SPOT")
+	if err != nil {
+		t.Fatalf("Test failed. UpdateOrderbook returned an error: %s", err)
+	}
+	if len(book.Asks) != 2 || book.Asks[0].Price != 0.051 || book.Asks[1].Amount != 1.5 ||
+		len(book.Bids) != 1 || book.Bids[0].Price != 0.049 {
+		t.Errorf("Test failed. Unexpected orderbook %+v", book)
+	}
+}
+
+func TestNewOrder(t *testing.T) {
+	var request NewOrderRequest
+	i, server := newTestIDEX(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("API-Key") != "key" {
+			fmt.Fprint(w, `{"error":"Invalid API key"}`)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/returnCurrencies":
+			fmt.Fprint(w, testCurrencies)
+		case "/returnContractAddress":
+			fmt.Fprintf(w, `{"address":"%s"}`, testContract)
+		case "/returnNextNonce":
+			fmt.Fprint(w, `{"nonce":2650}`)
+		case "/order":
+			if err := common.JSONDecode(body, &request); err != nil {
+				t.Errorf("Test failed. Invalid order request %s", body)
+			}
+			fmt.Fprint(w, `{"orderNumber":2101,"orderHash":"0xca9b","market":"ETH_REP","type":"buy",
+				"price":"0.05","amount":"2","total":"0.1","status":"open"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	orderID, err := i.NewOrder(pair.NewCurrencyPair("REP", "ETH"), 2, 0.05, exchange.OrderSideBuy,
+		exchange.OrderTypeExchangeLimit)
+	if err != nil {
+		t.Fatalf("Test failed. NewOrder returned an error: %s", err)
+	}
+	if orderID != "0xca9b" {
+		t.Errorf("Test failed. Unexpected order ID %s", orderID)
+	}
+	if request.TokenBuy != testREP || request.AmountBuy != "2000000000000000000" ||
+		request.TokenSell != ethereum.ZeroAddress || request.AmountSell != "100000000000000000" ||
+		request.Nonce != 2650 || request.Address != testAddress {
+		t.Fatalf("Test failed. Unexpected order request %+v", request)
+	}
+
+	amountBuy, _ := new(big.Int).SetString(request.AmountBuy, 10)
+	amountSell, _ := new(big.Int).SetString(request.AmountSell, 10)
+	hash, err := OrderHash(testContract, request.TokenBuy, amountBuy, request.TokenSell, amountSell,
+		request.Expires, request.Nonce, request.Address)
+	if err != nil {
+		t.Fatalf("Test failed. OrderHash returned an error: %s", err)
+	}
+	sig := ethereum.Signature{V: request.V}
+	r, _ := hex.DecodeString(request.R[2:])
+	s, _ := hex.DecodeString(request.S[2:])
+	copy(sig.R[:], r)
+	copy(sig.S[:], s)
+	signer, err := ethereum.RecoverAddress(ethereum.PersonalMessageHash(hash), sig)
+	if err != nil || signer != testAddress {
+		t.Errorf("Test failed. Order signed by %s %v", signer, err)
+	}
+}
+
+func TestGetWalletBalances(t *testing.T) {
+	i, server := newTestIDEX(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/returnCurrencies":
+			fmt.Fprint(w, testCurrencies)
+		case "/returnCompleteBalances":
+			fmt.Fprint(w, `{"REP":{"available":"25.5","onOrders":"2"}}`)
+		case "/rpc":
+			var call struct {
+				Method string `json:"method"`
+			}
+			common.JSONDecode(body, &call)
+			if call.Method == "eth_getBalance" {
+				// 1.5 ETH
+				fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":"0x14d1120d7b160000"}`)
+			} else {
+				// 3 REP
+				fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":"0x29a2241af62c0000"}`)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+	i.Node = ethereum.NewNode(server.URL + "/rpc")
+	i.EnabledPairs = []string{"REP-ETH"}
+
+	balances, err := i.GetWalletBalances()
+	if err != nil {
+		t.Fatalf("Test failed. GetWalletBalances returned an error: %s", err)
+	}
+	expected := map[string]exchange.WalletBalance{
+		exchange.WalletExchange + " REP": {Total: 27.5, Available: 25.5},
+		exchange.WalletOnChain + " REP":  {Total: 3, Available: 3},
+		exchange.WalletOnChain + " ETH":  {Total: 1.5, Available: 1.5},
+	}
+	if len(balances) != len(expected) {
+		t.Fatalf("Test failed. Unexpected balances %+v", balances)
+	}
+	for _, b := range balances {
+		e, ok := expected[b.Wallet+" "+b.Currency]
+		if !ok || e.Total != b.Total || e.Available != b.Available {
+			t.Errorf("Test failed. Unexpected balance %+v", b)
+		}
+	}
+}
+
+func TestSendHTTPRequestError(t *testing.T) {
+	i, server := newTestIDEX(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"Invalid order hash"}`)
+	})
+	defer server.Close()
+
+	_, err := i.FetchOrderStatus("0x1")
+	if e, ok := err.(*exchange.ExchangeError); !ok || e.Message != "Invalid order hash" ||
+		e.StatusCode != http.StatusBadRequest {
+		t.Errorf("Test failed. Unexpected error %v", err)
+	}
+}
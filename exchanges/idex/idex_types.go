@@ -0,0 +1,156 @@
+package idex
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Number is a numeric field, IDEX sends most numbers as strings and some as numbers
+type Number float64
+
+// UnmarshalJSON decodes a number sent either as a number or a string, "N/A" decodes as zero.
+func (n *Number) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), "\"")
+	if s == "" || s == "null" || s == "N/A" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s", b)
+	}
+	*n = Number(v)
+	return nil
+}
+
+// Float64 returns the number as a float64
+func (n Number) Float64() float64 {
+	return float64(n)
+}
+
+// ErrorResponse is returned by IDEX for rejected requests
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// Ticker is the best bid & ask, last traded price and 24h volume of a market
+type Ticker struct {
+	Last          Number `json:"last"`
+	High          Number `json:"high"`
+	Low           Number `json:"low"`
+	LowestAsk     Number `json:"lowestAsk"`
+	HighestBid    Number `json:"highestBid"`
+	PercentChange Number `json:"percentChange"`
+	// Volume in ETH
+	BaseVolume Number `json:"baseVolume"`
+	// Volume in the token
+	QuoteVolume Number `json:"quoteVolume"`
+}
+
+// Currency is a token listed on IDEX, Ether is listed with the zero address
+type Currency struct {
+	Name     string `json:"name"`
+	Decimals int32  `json:"decimals"`
+	Address  string `json:"address"`
+}
+
+// BookEntry is an order resting in the orderbook, the amount is in the token and the total in ETH
+type BookEntry struct {
+	Price     Number `json:"price"`
+	Amount    Number `json:"amount"`
+	Total     Number `json:"total"`
+	OrderHash string `json:"orderHash"`
+}
+
+// OrderBook is the orderbook of a market, asks are sorted by ascending price & bids by
+// descending price
+type OrderBook struct {
+	Asks []BookEntry `json:"asks"`
+	Bids []BookEntry `json:"bids"`
+}
+
+// Balance is the balance of a currency deposited in the IDEX contract
+type Balance struct {
+	Available Number `json:"available"`
+	OnOrders  Number `json:"onOrders"`
+}
+
+// OrderParams are the signed parameters of an order, amounts are in base units
+type OrderParams struct {
+	TokenBuy      string      `json:"tokenBuy"`
+	BuySymbol     string      `json:"buySymbol"`
+	BuyPrecision  int32       `json:"buyPrecision"`
+	AmountBuy     json.Number `json:"amountBuy"`
+	TokenSell     string      `json:"tokenSell"`
+	SellSymbol    string      `json:"sellSymbol"`
+	SellPrecision int32       `json:"sellPrecision"`
+	AmountSell    json.Number `json:"amountSell"`
+	Expires       int64       `json:"expires"`
+	Nonce         int64       `json:"nonce"`
+	User          string      `json:"user"`
+}
+
+// Order statuses
+const (
+	OrderStatusOpen      = "open"
+	OrderStatusComplete  = "complete"
+	OrderStatusCancelled = "cancelled"
+)
+
+// Order is an order placed on IDEX, identified by its hash. The amount is in the token & the
+// total in ETH.
+type Order struct {
+	OrderHash   string      `json:"orderHash"`
+	OrderNumber int64       `json:"orderNumber"`
+	Market      string      `json:"market"`
+	Type        string      `json:"type"`
+	Price       Number      `json:"price"`
+	Amount      Number      `json:"amount"`
+	Total       Number      `json:"total"`
+	Filled      Number      `json:"filled"`
+	Status      string      `json:"status"`
+	Timestamp   int64       `json:"timestamp"`
+	Params      OrderParams `json:"params"`
+}
+
+// NewOrderRequest is a signed order submitted to IDEX
+type NewOrderRequest struct {
+	TokenBuy   string `json:"tokenBuy"`
+	AmountBuy  string `json:"amountBuy"`
+	TokenSell  string `json:"tokenSell"`
+	AmountSell string `json:"amountSell"`
+	Address    string `json:"address"`
+	Nonce      int64  `json:"nonce"`
+	Expires    int64  `json:"expires"`
+	V          byte   `json:"v"`
+	R          string `json:"r"`
+	S          string `json:"s"`
+}
+
+// CancelRequest is a signed request to cancel an order
+type CancelRequest struct {
+	OrderHash string `json:"orderHash"`
+	Nonce     int64  `json:"nonce"`
+	Address   string `json:"address"`
+	V         byte   `json:"v"`
+	R         string `json:"r"`
+	S         string `json:"s"`
+}
+
+// WebsocketMessage is a message received from the IDEX datastream
+type WebsocketMessage struct {
+	Type    string          `json:"type"`
+	Event   string          `json:"event"`
+	Chain   string          `json:"chain"`
+	Result  string          `json:"result"`
+	SID     string          `json:"sid"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// WebsocketMarketEvent is the payload of the market events, only the market is decoded since any
+// event refreshes the orderbook of the market
+type WebsocketMarketEvent struct {
+	Market string `json:"market"`
+}
@@ -0,0 +1,145 @@
+package idex
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+const (
+	idexWebsocketURL         = "wss://datastream.idex.market"
+	idexWebsocketVersion     = "1.0.0"
+	idexWebsocketHandshake   = "handshake"
+	idexWebsocketSubscribe   = "subscribeToMarkets"
+	idexWebsocketNotify      = "notification"
+	idexWebsocketReconnect   = 5 * time.Second
+	idexWebsocketSuccess     = "success"
+	idexWebsocketMarketEvent = "market_"
+)
+
+// Market events that change the orderbook of a market
+var idexWebsocketMarketEvents = []string{"market_orders", "market_cancels", "market_trades"}
+
+// websocketRequest is a request sent to the IDEX datastream, the payload is a JSON encoded
+// string
+type websocketRequest struct {
+	Request string `json:"request"`
+	SID     string `json:"sid,omitempty"`
+	Payload string `json:"payload"`
+}
+
+// WebsocketClient connects to the IDEX datastream and refreshes the orderbook of an enabled pair
+// whenever an order is placed, cancelled or filled on its market.
+func (i *IDEX) WebsocketClient() {
+	for i.Enabled && i.Websocket {
+		if err := i.websocketSession(); err != nil {
+			log.Printf("%s Websocket error: %s\n", i.GetName(), err)
+		}
+		time.Sleep(idexWebsocketReconnect)
+	}
+}
+
+// websocketSession runs a single connection to the datastream until it fails
+func (i *IDEX) websocketSession() error {
+	dialer := websocket.Dialer{EnableCompression: i.WebsocketCompression()}
+	conn, _, err := dialer.Dial(idexWebsocketURL, http.Header{})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	i.CountWebsocketConnection()
+
+	apiKey, _, _ := i.Credentials()
+	payload, err := common.JSONEncode(map[string]string{"version": idexWebsocketVersion, "key": apiKey})
+	if err != nil {
+		return err
+	}
+	if err = conn.WriteJSON(websocketRequest{Request: idexWebsocketHandshake, Payload: string(payload)}); err != nil {
+		return err
+	}
+	handshake, err := i.readWebsocketMessage(conn)
+	if err != nil {
+		return err
+	}
+	if handshake.Result != idexWebsocketSuccess {
+		return exchange.NewExchangeError(i.Name, idexWebsocketHandshake, 0, 0,
+			"handshake rejected: "+string(handshake.Payload), "")
+	}
+
+	pairs := i.GetEnabledCurrencies()
+	topics := make([]string, len(pairs))
+	for x, p := range pairs {
+		topics[x] = i.CurrencyPairToSymbol(p)
+	}
+	payload, err = common.JSONEncode(map[string][]string{"topics": topics, "events": idexWebsocketMarketEvents})
+	if err != nil {
+		return err
+	}
+	err = conn.WriteJSON(websocketRequest{Request: idexWebsocketSubscribe, SID: handshake.SID, Payload: string(payload)})
+	if err != nil {
+		return err
+	}
+
+	for i.Enabled && i.Websocket {
+		msg, err := i.readWebsocketMessage(conn)
+		if err != nil {
+			return err
+		}
+		if msg.Type != idexWebsocketNotify || !strings.HasPrefix(msg.Event, idexWebsocketMarketEvent) {
+			continue
+		}
+		event := WebsocketMarketEvent{}
+		if err = decodeWebsocketPayload(msg.Payload, &event); err != nil {
+			log.Printf("%s Websocket failed to decode %s event: %s\n", i.GetName(), msg.Event, err)
+			continue
+		}
+		p, err := i.SymbolToCurrencyPair(event.Market)
+		if err != nil {
+			continue
+		}
+		if _, err = i.UpdateOrderbook(p, ticker.Spot); err != nil {
+			log.Printf("%s Websocket failed to refresh the %s orderbook: %s\n", i.GetName(), event.Market, err)
+		}
+	}
+	return nil
+}
+
+func (i *IDEX) readWebsocketMessage(conn *websocket.Conn) (*WebsocketMessage, error) {
+	for {
+		msgType, resp, err := conn.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		i.RecordWebsocketFrame(msgType, resp)
+		if msgType != websocket.TextMessage {
+			continue
+		}
+		if i.Debug(exchange.TraceWebsocket) {
+			log.Printf("%s Websocket received: %s\n", i.GetName(), resp)
+		}
+		msg := WebsocketMessage{}
+		if err = common.JSONDecode(resp, &msg); err != nil {
+			return nil, err
+		}
+		return &msg, nil
+	}
+}
+
+// decodeWebsocketPayload decodes a payload sent either as a JSON object or as a JSON encoded
+// string holding the object
+func decodeWebsocketPayload(payload []byte, result interface{}) error {
+	if len(payload) > 0 && payload[0] == '"' {
+		var s string
+		if err := common.JSONDecode(payload, &s); err != nil {
+			return err
+		}
+		payload = []byte(s)
+	}
+	return common.JSONDecode(payload, result)
+}
@@ -0,0 +1,533 @@
+package idex
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ethereum"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+	"github.com/shopspring/decimal"
+)
+
+var _ exchange.IExchange = (*IDEX)(nil)
+var _ exchange.ContextExchange = (*IDEX)(nil)
+
+// New returns an IDEX exchange set up with the API key & the hex encoded private key of the
+// trading wallet (empty for public data only) without a config file, opts customize the default
+// settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *IDEX {
+	i := &IDEX{}
+	i.SetDefaults()
+	i.Quickstart(i.Setup, apiKey, apiSecret, opts...)
+	return i
+}
+
+// SetDefaults sets the basic defaults for IDEX
+func (i *IDEX) SetDefaults() {
+	i.Name = "IDEX"
+	i.APIUrl = idexAPIURL
+	i.Enabled = false
+	i.Verbose = false
+	i.Websocket = false
+	i.RESTPollingDelay = 10
+	i.TakerFee = 0.2
+	i.MakerFee = 0.1
+	i.RequestCurrencyPairFormat.Delimiter = idexSymbolDelimiter
+	i.RequestCurrencyPairFormat.Uppercase = true
+	i.ConfigCurrencyPairFormat.Delimiter = "-"
+	i.ConfigCurrencyPairFormat.Uppercase = true
+	i.AssetTypes = []string{ticker.Spot}
+	i.Orderbooks = orderbook.Init()
+}
+
+// Setup takes in the supplied exchange configuration details and sets params
+func (i *IDEX) Setup(exch config.ExchangeConfig) {
+	if !exch.Enabled {
+		i.SetEnabled(false)
+	} else {
+		i.Enabled = true
+		i.AuthenticatedAPISupport = exch.AuthenticatedAPISupport
+		i.SetAPIKeys(exch.APIKey, exch.APISecret, "", false)
+		i.RESTPollingDelay = exch.RESTPollingDelay
+		i.Verbose = exch.Verbose
+		i.Websocket = exch.Websocket
+		i.SetAPIURL(exch)
+		if exch.EthereumNodeURL != "" {
+			i.Node = ethereum.NewNode(exch.EthereumNodeURL)
+		}
+		i.BaseCurrencies = common.SplitStrings(exch.BaseCurrencies, ",")
+		i.AvailablePairs = common.SplitStrings(exch.AvailablePairs, ",")
+		i.EnabledPairs = common.SplitStrings(exch.EnabledPairs, ",")
+		err := i.SetCurrencyPairFormat()
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = i.SetAssetTypes()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// Start starts the IDEX go routine
+func (i *IDEX) Start() {
+	go i.Run()
+}
+
+// Run implements the IDEX wrapper
+func (i *IDEX) Run() {
+	if i.Debug("") {
+		log.Printf("%s polling delay: %ds.\n", i.GetName(), i.RESTPollingDelay)
+		log.Printf("%s %d currencies enabled: %s.\n", i.GetName(), len(i.EnabledPairs), i.EnabledPairs)
+	}
+
+	if i.Websocket {
+		go i.WebsocketClient()
+	}
+
+	currencies, err := i.FetchCurrencies()
+	if err != nil {
+		log.Printf("%s failed to get currencies\n", i.GetName())
+		return
+	}
+	tickers, err := i.FetchTickers()
+	if err != nil {
+		log.Printf("%s failed to get markets\n", i.GetName())
+		return
+	}
+	i.setMarketInfo(currencies, tickers)
+
+	exchangeProducts := make([]string, 0, len(tickers))
+	for symbol := range tickers {
+		if p, err := i.SymbolToCurrencyPair(symbol); err == nil {
+			exchangeProducts = append(exchangeProducts, p.Display(i.ConfigCurrencyPairFormat.Delimiter, true).String())
+		}
+	}
+	err = i.UpdateAvailableCurrencies(exchangeProducts, false)
+	if err != nil {
+		log.Printf("%s failed to update available currencies\n", i.Name)
+	}
+}
+
+// setMarketInfo replaces the tokens & currency pairs of the exchange, markets maps the symbols of
+// the markets to their tickers
+func (i *IDEX) setMarketInfo(currencies map[string]Currency, markets map[string]Ticker) {
+	currencyPairs := make(map[pair.CurrencyItem]*exchange.CurrencyPairInfo, len(markets))
+	for symbol := range markets {
+		currencyPair, err := i.SymbolToCurrencyPair(symbol)
+		if err != nil {
+			continue
+		}
+		currencyPairs[pair.CurrencyItem(symbol)] = &exchange.CurrencyPairInfo{
+			Currency:           currencyPair,
+			FirstCurrencyName:  currencyPair.FirstCurrency.String(),
+			SecondCurrencyName: currencyPair.SecondCurrency.String(),
+		}
+	}
+	i.mtx.Lock()
+	i.currencies = currencies
+	i.currencyPairs = currencyPairs
+	i.mtx.Unlock()
+}
+
+// currency returns the token listed on IDEX with the given symbol, the tokens are fetched if
+// they haven't been yet
+func (i *IDEX) currency(symbol string) (Currency, error) {
+	i.mtx.Lock()
+	currencies := i.currencies
+	i.mtx.Unlock()
+	if currencies == nil {
+		var err error
+		if currencies, err = i.FetchCurrencies(); err != nil {
+			return Currency{}, err
+		}
+		i.mtx.Lock()
+		i.currencies = currencies
+		i.mtx.Unlock()
+	}
+	c, ok := currencies[strings.ToUpper(symbol)]
+	if !ok {
+		return Currency{}, ErrUnknownCurrency
+	}
+	return c, nil
+}
+
+// toBaseUnits converts an amount of a token to the token's base units, the fraction of a base
+// unit is truncated
+func toBaseUnits(amount decimal.Decimal, decimals int32) *big.Int {
+	return amount.Shift(decimals).Truncate(0).BigInt()
+}
+
+// UpdateTicker updates and returns the ticker for a currency pair
+func (i *IDEX) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return i.UpdateTickerContext(context.Background(), p, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (i *IDEX) UpdateTickerContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
+	var tickerPrice ticker.Price
+	tick, err := i.fetchTicker(ctx, i.CurrencyPairToSymbol(p))
+	if err != nil {
+		return tickerPrice, err
+	}
+	tickerPrice.Pair = p
+	tickerPrice.Ask = tick.LowestAsk.Float64()
+	tickerPrice.Bid = tick.HighestBid.Float64()
+	tickerPrice.Last = tick.Last.Float64()
+	tickerPrice.High = tick.High.Float64()
+	tickerPrice.Low = tick.Low.Float64()
+	tickerPrice.Volume = tick.QuoteVolume.Float64()
+	tickerPrice.LastUpdated = time.Now()
+	ticker.ProcessTicker(i.GetName(), p, tickerPrice, assetType)
+	return ticker.GetTicker(i.Name, p, assetType)
+}
+
+// GetTickerPrice returns the ticker for a currency pair
+func (i *IDEX) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	tick, err := ticker.GetTicker(i.GetName(), p, assetType)
+	if err != nil {
+		return i.UpdateTicker(p, assetType)
+	}
+	return tick, nil
+}
+
+// GetOrderbookEx returns the orderbook for a currency pair
+func (i *IDEX) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
+	ob, err := i.Orderbooks.GetOrderbook(i.GetName(), p, assetType)
+	if err != nil {
+		return i.UpdateOrderbook(p, assetType)
+	}
+	return ob, nil
+}
+
+// UpdateOrderbook updates and returns the orderbook for a currency pair
+func (i *IDEX) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return i.UpdateOrderbookContext(context.Background(), p, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (i *IDEX) UpdateOrderbookContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
+	book := orderbook.Base{}
+	depth, err := i.fetchOrderBook(ctx, i.CurrencyPairToSymbol(p), idexOrderBookDepth)
+	if err != nil {
+		return book, err
+	}
+
+	book.Asks = orderbook.GetItems(len(depth.Asks))
+	for x := range depth.Asks {
+		book.Asks = append(book.Asks, orderbook.Item{
+			Price:  depth.Asks[x].Price.Float64(),
+			Amount: depth.Asks[x].Amount.Float64(),
+		})
+	}
+
+	book.Bids = orderbook.GetItems(len(depth.Bids))
+	for x := range depth.Bids {
+		book.Bids = append(book.Bids, orderbook.Item{
+			Price:  depth.Bids[x].Price.Float64(),
+			Amount: depth.Bids[x].Amount.Float64(),
+		})
+	}
+
+	i.Orderbooks.ProcessOrderbook(i.Name, p, book, assetType)
+	return i.Orderbooks.GetOrderbook(i.Name, p, assetType)
+}
+
+// GetExchangeAccountInfo retrieves the balances deposited in the IDEX contract, the balances held
+// by the wallet itself are returned by GetWalletBalances
+func (i *IDEX) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return i.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (i *IDEX) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
+	result := exchange.AccountInfo{}
+	result.ExchangeName = i.Name
+
+	if !i.Enabled {
+		return result, nil
+	}
+
+	address, err := i.Address()
+	if err != nil {
+		return result, err
+	}
+	balances, err := i.fetchCompleteBalances(ctx, address)
+	if err != nil {
+		return result, err
+	}
+	for currency, balance := range balances {
+		info := exchange.AccountCurrencyInfo{
+			CurrencyName: strings.ToUpper(currency),
+			Available:    balance.Available.Float64(),
+			Hold:         balance.OnOrders.Float64(),
+		}
+		info.TotalValue, _ = decimal.NewFromFloat(info.Available).Add(decimal.NewFromFloat(info.Hold)).Float64()
+		result.Currencies = append(result.Currencies, info)
+	}
+	return result, nil
+}
+
+// GetWalletBalances returns the balances deposited in the IDEX contract (the exchange wallet),
+// and if an Ethereum node is configured the balances held by the trading wallet (the on-chain
+// wallet) of the currencies of the enabled pairs.
+func (i *IDEX) GetWalletBalances() ([]exchange.WalletBalance, error) {
+	address, err := i.Address()
+	if err != nil {
+		return nil, err
+	}
+	balances, err := i.FetchCompleteBalances(address)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]exchange.WalletBalance, 0, len(balances))
+	for currency, balance := range balances {
+		total, _ := decimal.NewFromFloat(balance.Available.Float64()).
+			Add(decimal.NewFromFloat(balance.OnOrders.Float64())).Float64()
+		result = append(result, exchange.WalletBalance{
+			Wallet:    exchange.WalletExchange,
+			Currency:  strings.ToUpper(currency),
+			Total:     total,
+			Available: balance.Available.Float64(),
+		})
+	}
+	if i.Node == nil {
+		return result, nil
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range i.GetEnabledCurrencies() {
+		for _, symbol := range []string{p.FirstCurrency.Upper().String(), p.SecondCurrency.Upper().String()} {
+			if seen[symbol] {
+				continue
+			}
+			seen[symbol] = true
+			c, err := i.currency(symbol)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", symbol, err)
+			}
+			units, err := i.Node.TokenBalance(c.Address, address)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", symbol, err)
+			}
+			amount, _ := decimal.NewFromBigInt(units, -c.Decimals).Float64()
+			result = append(result, exchange.WalletBalance{
+				Wallet:    exchange.WalletOnChain,
+				Currency:  symbol,
+				Total:     amount,
+				Available: amount,
+			})
+		}
+	}
+	return result, nil
+}
+
+// NewOrder creates a new order on the exchange, the order is signed with the private key of the
+// trading wallet.
+// Returns the ID of the new exchange order, the hash of the order.
+func (i *IDEX) NewOrder(p pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return i.NewOrderContext(context.Background(), p, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (i *IDEX) NewOrderContext(ctx context.Context, p pair.CurrencyPair, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	if err := i.GetCapabilities().CheckOrderOptions(opts...); err != nil {
+		return "", err
+	}
+	if orderType != exchange.OrderTypeExchangeLimit {
+		panic("not implemented")
+	}
+	base, err := i.currency(p.FirstCurrency.String())
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", p.FirstCurrency, err)
+	}
+	quote, err := i.currency(p.SecondCurrency.String())
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", p.SecondCurrency, err)
+	}
+	baseAmount := toBaseUnits(decimal.NewFromFloat(amount), base.Decimals)
+	quoteAmount := toBaseUnits(decimal.NewFromFloat(amount).Mul(decimal.NewFromFloat(price)), quote.Decimals)
+
+	var result *Order
+	if side == exchange.OrderSideBuy {
+		result, err = i.placeOrder(ctx, base.Address, baseAmount, quote.Address, quoteAmount)
+	} else {
+		result, err = i.placeOrder(ctx, quote.Address, quoteAmount, base.Address, baseAmount)
+	}
+	if err != nil {
+		return "", err
+	}
+	return result.OrderHash, nil
+}
+
+// CancelOrder will attempt to cancel the active order matching the given ID.
+func (i *IDEX) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return i.CancelOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (i *IDEX) CancelOrderContext(ctx context.Context, orderID string, currencyPair pair.CurrencyPair) error {
+	return i.deleteOrder(ctx, orderID)
+}
+
+// GetOrder returns information about a previously placed order (which may be active or inactive).
+func (i *IDEX) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	return i.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (i *IDEX) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	order, err := i.fetchOrderStatus(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return i.convertOrderToExchangeOrder(order), nil
+}
+
+// GetOrders returns information about currently active orders, the orders of all currency pairs
+// are returned if no pairs are given.
+func (i *IDEX) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return i.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (i *IDEX) GetOrdersContext(ctx context.Context, pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	address, err := i.Address()
+	if err != nil {
+		return nil, err
+	}
+	symbols := []string{""}
+	if len(pairs) > 0 {
+		symbols = make([]string, len(pairs))
+		for x, p := range pairs {
+			symbols[x] = i.CurrencyPairToSymbol(p)
+		}
+	}
+	ret := []*exchange.Order{}
+	for _, symbol := range symbols {
+		orders, err := i.fetchOpenOrders(ctx, symbol, address)
+		if err != nil {
+			return nil, err
+		}
+		for x := range orders {
+			ret = append(ret, i.convertOrderToExchangeOrder(&orders[x]))
+		}
+	}
+	return ret, nil
+}
+
+func (i *IDEX) convertOrderToExchangeOrder(order *Order) *exchange.Order {
+	retOrder := &exchange.Order{}
+	retOrder.OrderID = order.OrderHash
+
+	switch order.Status {
+	case OrderStatusCancelled:
+		retOrder.Status = exchange.OrderStatusAborted
+	case OrderStatusComplete:
+		retOrder.Status = exchange.OrderStatusFilled
+	case OrderStatusOpen:
+		retOrder.Status = exchange.OrderStatusActive
+	default:
+		retOrder.Status = exchange.OrderStatusUnknown
+	}
+
+	retOrder.Amount = order.Amount.Float64()
+	retOrder.FilledAmount = order.Filled.Float64()
+	retOrder.RemainingAmount, _ = decimal.NewFromFloat(retOrder.Amount).
+		Sub(decimal.NewFromFloat(retOrder.FilledAmount)).Float64()
+	retOrder.Rate = order.Price.Float64()
+	retOrder.CreatedAt = order.Timestamp
+	if p, err := i.SymbolToCurrencyPair(order.Market); err == nil {
+		retOrder.CurrencyPair = p
+	}
+	if order.Type == "sell" {
+		retOrder.Side = exchange.OrderSideSell
+	} else {
+		retOrder.Side = exchange.OrderSideBuy
+	}
+	// Only limit orders can be placed on IDEX
+	retOrder.Type = exchange.OrderTypeExchangeLimit
+
+	return retOrder
+}
+
+// GetLimits returns price/amount limits for the exchange.
+func (i *IDEX) GetLimits() exchange.ILimits {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	return &currencyLimits{currencies: i.currencies}
+}
+
+// GetCurrencyPairs returns currency pairs that can be used by the exchange account
+// associated with this bot. Use FormatExchangeCurrency to get the right key.
+func (i *IDEX) GetCurrencyPairs() map[pair.CurrencyItem]*exchange.CurrencyPairInfo {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	return i.currencyPairs
+}
+
+// ListInstruments returns the symbols that are currently trading on the exchange
+func (i *IDEX) ListInstruments() ([]exchange.Instrument, error) {
+	tickers, err := i.FetchTickers()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]exchange.Instrument, 0, len(tickers))
+	for symbol := range tickers {
+		p, err := i.SymbolToCurrencyPair(symbol)
+		if err != nil {
+			continue
+		}
+		result = append(result, exchange.Instrument{Symbol: symbol, Pair: p})
+	}
+	return result, nil
+}
+
+type currencyLimits struct {
+	// Tokens listed on IDEX keyed by symbol
+	currencies map[string]Currency
+}
+
+// Returns max number of decimal places allowed in the trade price for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetPriceDecimalPlaces(p pair.CurrencyPair) int32 {
+	return -1
+}
+
+// Returns max number of decimal places allowed in the trade amount for the given currency pair,
+// the amounts are signed in the base units of the token so the token's decimals are the limit.
+func (cl *currencyLimits) GetAmountDecimalPlaces(p pair.CurrencyPair) int32 {
+	if c, exists := cl.currencies[p.FirstCurrency.Upper().String()]; exists {
+		return c.Decimals
+	}
+	return -1
+}
+
+// Returns the minimum trade amount for the given currency pair.
+func (cl *currencyLimits) GetMinAmount(p pair.CurrencyPair) float64 {
+	return 0
+}
+
+// Returns the minimum trade total (amount * price) for the given currency pair, IDEX only
+// enforces a minimum for the ETH markets.
+func (cl *currencyLimits) GetMinTotal(p pair.CurrencyPair) float64 {
+	if p.SecondCurrency.Upper().String() == "ETH" {
+		return idexMinOrderTotal
+	}
+	return 0
+}
@@ -36,6 +36,16 @@ type ItBit struct {
 	exchange.Base
 }
 
+// New returns a itBit exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+// The user ID must be set with exchange.WithClientID to use the authenticated API.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *ItBit {
+	i := &ItBit{}
+	i.SetDefaults()
+	i.Quickstart(i.Setup, apiKey, apiSecret, opts...)
+	return i
+}
+
 // SetDefaults sets the defaults for the exchange
 func (i *ItBit) SetDefaults() {
 	i.Name = "ITBIT"
@@ -125,7 +135,8 @@ func (i *ItBit) GetTradeHistory(currencyPair, timestamp string) (Trades, error)
 //					perPage - [optional] items per page example 50, default 50 max 50
 func (i *ItBit) GetWallets(params url.Values) ([]Wallet, error) {
 	resp := []Wallet{}
-	params.Set("userId", i.ClientID)
+	_, _, clientID := i.Credentials()
+	params.Set("userId", clientID)
 	path := fmt.Sprintf("/%s?%s", itbitWallets, params.Encode())
 
 	return resp, i.SendAuthenticatedHTTPRequest("GET", path, nil, &resp)
@@ -135,7 +146,8 @@ func (i *ItBit) GetWallets(params url.Values) ([]Wallet, error) {
 func (i *ItBit) CreateWallet(walletName string) (Wallet, error) {
 	resp := Wallet{}
 	params := make(map[string]interface{})
-	params["userId"] = i.ClientID
+	_, _, clientID := i.Credentials()
+	params["userId"] = clientID
 	params["name"] = walletName
 
 	return resp,
@@ -253,6 +265,8 @@ func (i *ItBit) WalletTransfer(walletID, sourceWallet, destWallet string, amount
 
 // SendAuthenticatedHTTPRequest sends an authenticated request to itBit
 func (i *ItBit) SendAuthenticatedHTTPRequest(method string, path string, params map[string]interface{}, result interface{}) error {
+	_, apiSecret, clientID := i.Credentials()
+
 	if !i.AuthenticatedAPISupport {
 		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, i.Name)
 	}
@@ -290,11 +304,11 @@ func (i *ItBit) SendAuthenticatedHTTPRequest(method string, path string, params
 	}
 
 	hash := common.GetSHA256([]byte(nonce + string(message)))
-	hmac := common.GetHMAC(common.HashSHA512, []byte(url+string(hash)), []byte(i.APISecret))
+	hmac := common.GetHMAC(common.HashSHA512, []byte(url+string(hash)), []byte(apiSecret))
 	signature := common.Base64Encode(hmac)
 
 	headers := make(map[string]string)
-	headers["Authorization"] = i.ClientID + ":" + signature
+	headers["Authorization"] = clientID + ":" + signature
 	headers["X-Auth-Timestamp"] = timestamp
 	headers["X-Auth-Nonce"] = nonce
 	headers["Content-Type"] = "application/json"
@@ -53,7 +53,7 @@ func (i *ItBit) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.Pr
 }
 
 // GetOrderbookEx returns orderbook base on the currency pair
-func (i *ItBit) GetOrderbookEx(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+func (i *ItBit) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
 	ob, err := i.Orderbooks.GetOrderbook(i.GetName(), p, assetType)
 	if err == nil {
 		return i.UpdateOrderbook(p, assetType)
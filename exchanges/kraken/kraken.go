@@ -1,10 +1,11 @@
 package kraken
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
@@ -68,10 +69,28 @@ type Kraken struct {
 	// Maps a currency pair of the form XXX/YYY to max num of decimal places
 	// Kraken allows to be specified for the price of orders placed for the currency pair.
 	PriceDecimalPlaces map[pair.CurrencyItem]int32
+	// Maps Kraken asset names to currency codes, e.g. XLTC->LTC
+	assetCurrencies map[string]string
+	// Map symbols to the taker & maker fees (percentages) of the account's current fee tier
+	takerFees map[string]float64
+	makerFees map[string]float64
+
+	// Caches the results of the bulk symbol <-> currency pair conversions
+	symbolCache exchange.SymbolCache
+}
+
+// New returns a Kraken exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *Kraken {
+	k := &Kraken{}
+	k.SetDefaults()
+	k.Quickstart(k.Setup, apiKey, apiSecret, opts...)
+	return k
 }
 
 func (k *Kraken) SetDefaults() {
 	k.Name = "Kraken"
+	k.APIUrl = KRAKEN_API_URL
 	k.Enabled = false
 	k.FiatFee = 0.35
 	k.CryptoFee = 0.10
@@ -98,6 +117,7 @@ func (k *Kraken) Setup(exch config.ExchangeConfig) {
 		k.RESTPollingDelay = exch.RESTPollingDelay
 		k.Verbose = exch.Verbose
 		k.Websocket = exch.Websocket
+		k.SetAPIURL(exch)
 		k.BaseCurrencies = common.SplitStrings(exch.BaseCurrencies, ",")
 		k.AvailablePairs = common.SplitStrings(exch.AvailablePairs, ",")
 		k.EnabledPairs = common.SplitStrings(exch.EnabledPairs, ",")
@@ -133,6 +153,16 @@ func (k *Kraken) SymbolToCurrencyPair(symbol string) (pair.CurrencyPair, error)
 		fmt.Errorf("failed to map Kraken asset pair '%s' to a currency pair", symbol)
 }
 
+// CurrencyPairsToSymbols converts a batch of currency pairs to symbols, conversions are cached.
+func (k *Kraken) CurrencyPairsToSymbols(pairs []pair.CurrencyPair) ([]string, error) {
+	return k.symbolCache.CurrencyPairsToSymbols(pairs, k.CurrencyPairToSymbol)
+}
+
+// SymbolsToCurrencyPairs converts a batch of symbols to currency pairs, conversions are cached.
+func (k *Kraken) SymbolsToCurrencyPairs(symbols []string) ([]pair.CurrencyPair, error) {
+	return k.symbolCache.SymbolsToCurrencyPairs(symbols, k.SymbolToCurrencyPair)
+}
+
 type currencyLimits struct {
 	exchangeName       string
 	priceDecimalPlaces map[pair.CurrencyItem]int32
@@ -208,11 +238,22 @@ func (k *Kraken) GetCurrencyPairs() map[pair.CurrencyItem]*exchange.CurrencyPair
 
 // GetOrder returns information about the exchange order matching the given ID
 func (k *Kraken) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	return k.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (k *Kraken) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
 	panic("not implemented")
 }
 
 func (k *Kraken) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
-	orders, err := k.GetOpenOrders(false, 0)
+	return k.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (k *Kraken) GetOrdersContext(ctx context.Context, pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	orders, err := k.getOpenOrders(ctx, false, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -279,12 +320,22 @@ func (k *Kraken) convertOrderToExchangeOrder(orderID string, order *Order) (*exc
 
 // NewOrder submits a new order and returns the ID of the new exchange order
 func (k *Kraken) NewOrder(currencyPair pair.CurrencyPair, amount, price float64,
-	side exchange.OrderSide, orderType exchange.OrderType) (string, error) {
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return k.NewOrderContext(context.Background(), currencyPair, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (k *Kraken) NewOrderContext(ctx context.Context, currencyPair pair.CurrencyPair, amount,
+	price float64, side exchange.OrderSide, orderType exchange.OrderType,
+	opts ...exchange.OrderOptions) (string, error) {
+	if err := k.GetCapabilities().CheckOrderOptions(opts...); err != nil {
+		return "", err
+	}
 	symbol, err := k.CurrencyPairToSymbol(currencyPair)
 	if err != nil {
 		return "", err
 	}
-	result, err := k.AddOrder(AddOrderParams{
+	result, err := k.addOrder(ctx, AddOrderParams{
 		Pair:         symbol,
 		Side:         side,
 		Type:         orderType,
@@ -308,9 +359,84 @@ func (k *Kraken) GetFee(cryptoTrade bool) float64 {
 	}
 }
 
+// GetPairFee returns the fee (percentage) charged for trading the given currency pair, the fee
+// tier of the account is used if it's been fetched by UpdateFeeTiers, otherwise the default fee
+// is returned.
+func (k *Kraken) GetPairFee(currencyPair pair.CurrencyPair, maker bool) float64 {
+	if symbol, err := k.CurrencyPairToSymbol(currencyPair); err == nil {
+		fees := k.takerFees
+		if maker {
+			fees = k.makerFees
+		}
+		if fee, ok := fees[symbol]; ok {
+			return fee
+		}
+	}
+	quote := common.StringToUpper(string(currencyPair.SecondCurrency))
+	return k.GetFee(!common.DataContains(k.BaseCurrencies, quote))
+}
+
+// GetFeeInfo returns the maker & taker fees of the currency pair, see GetPairFee.
+func (k *Kraken) GetFeeInfo(currencyPair pair.CurrencyPair) (maker, taker float64, err error) {
+	return k.GetPairFee(currencyPair, true), k.GetPairFee(currencyPair, false), nil
+}
+
+// assetPairFees returns the taker & maker fees of the lowest volume tier of an asset pair, ok is
+// false if the pair has no fee schedule.
+func assetPairFees(info *KrakenAssetPairs) (taker, maker float64, ok bool) {
+	// each tier is [volume, fee]
+	if len(info.Fees) == 0 || len(info.Fees[0]) < 2 {
+		return 0, 0, false
+	}
+	taker, maker = info.Fees[0][1], info.Fees[0][1]
+	if len(info.FeesMaker) > 0 && len(info.FeesMaker[0]) >= 2 {
+		maker = info.FeesMaker[0][1]
+	}
+	return taker, maker, true
+}
+
+// UpdateFeeTiers fetches the fee tiers of the account for all the enabled currency pairs, the
+// fees of the other pairs are left as they are.
+func (k *Kraken) UpdateFeeTiers() error {
+	var symbols []string
+	for _, currencyPair := range k.GetEnabledCurrencies() {
+		if symbol, err := k.CurrencyPairToSymbol(currencyPair); err == nil {
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		return nil
+	}
+
+	volume, err := k.GetTradeVolume(symbols...)
+	if err != nil {
+		return err
+	}
+
+	takerFees := make(map[string]float64, len(k.takerFees)+len(volume.Fees))
+	makerFees := make(map[string]float64, len(k.makerFees)+len(volume.Fees))
+	for symbol, fee := range k.takerFees {
+		takerFees[symbol] = fee
+	}
+	for symbol, fee := range k.makerFees {
+		makerFees[symbol] = fee
+	}
+	for symbol, fee := range volume.Fees {
+		takerFees[symbol] = fee.Fee
+		// Kraken doesn't return maker fees for pairs that don't have separate maker fees
+		makerFees[symbol] = fee.Fee
+	}
+	for symbol, fee := range volume.FeesMaker {
+		makerFees[symbol] = fee.Fee
+	}
+	k.takerFees = takerFees
+	k.makerFees = makerFees
+	return nil
+}
+
 func (k *Kraken) GetServerTime() error {
 	var result interface{}
-	path := fmt.Sprintf("%s/%s/public/%s", KRAKEN_API_URL, KRAKEN_API_VERSION, KRAKEN_SERVER_TIME)
+	path := fmt.Sprintf("%s/%s/public/%s", k.APIUrl, KRAKEN_API_VERSION, KRAKEN_SERVER_TIME)
 	err := common.SendHTTPGetRequest(path, true, k.Verbose, &result)
 
 	if err != nil {
@@ -323,7 +449,7 @@ func (k *Kraken) GetServerTime() error {
 
 func (k *Kraken) GetAssets() (map[string]KrakenAsset, error) {
 	var result map[string]KrakenAsset
-	path := fmt.Sprintf("%s/%s/public/%s", KRAKEN_API_URL, KRAKEN_API_VERSION, KRAKEN_ASSETS)
+	path := fmt.Sprintf("%s/%s/public/%s", k.APIUrl, KRAKEN_API_VERSION, KRAKEN_ASSETS)
 	err := k.HTTPRequest(path, false, url.Values{}, &result)
 
 	if err != nil {
@@ -334,7 +460,7 @@ func (k *Kraken) GetAssets() (map[string]KrakenAsset, error) {
 
 func (k *Kraken) GetAssetPairs() (map[string]KrakenAssetPairs, error) {
 	var result map[string]KrakenAssetPairs
-	path := fmt.Sprintf("%s/%s/public/%s", KRAKEN_API_URL, KRAKEN_API_VERSION, KRAKEN_ASSET_PAIRS)
+	path := fmt.Sprintf("%s/%s/public/%s", k.APIUrl, KRAKEN_API_VERSION, KRAKEN_ASSET_PAIRS)
 	err := k.HTTPRequest(path, false, url.Values{}, &result)
 
 	if err != nil {
@@ -343,7 +469,14 @@ func (k *Kraken) GetAssetPairs() (map[string]KrakenAssetPairs, error) {
 	return result, nil
 }
 
-func (k *Kraken) GetTicker(symbol string) error {
+// GetTicker returns the tickers of the comma separated symbols, keyed by the symbol without the
+// asset class prefixes (e.g. XBTUSD). Kraken rejects the whole request if any of the symbols is
+// invalid, such rejections are returned as an *exchange.ExchangeError.
+func (k *Kraken) GetTicker(symbol string) (map[string]KrakenTicker, error) {
+	return k.getTicker(context.Background(), symbol)
+}
+
+func (k *Kraken) getTicker(ctx context.Context, symbol string) (map[string]KrakenTicker, error) {
 	values := url.Values{}
 	values.Set("pair", symbol)
 
@@ -353,17 +486,19 @@ func (k *Kraken) GetTicker(symbol string) error {
 	}
 
 	resp := Response{}
-	path := fmt.Sprintf("%s/%s/public/%s?%s", KRAKEN_API_URL, KRAKEN_API_VERSION, KRAKEN_TICKER, values.Encode())
-	err := common.SendHTTPGetRequest(path, true, k.Verbose, &resp)
+	path := fmt.Sprintf("%s/%s/public/%s?%s", k.APIUrl, KRAKEN_API_VERSION, KRAKEN_TICKER, values.Encode())
+	err := common.SendHTTPGetRequestContext(ctx, path, true, k.Verbose, &resp)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if len(resp.Error) > 0 {
-		return errors.New(fmt.Sprintf("Kraken error: %s", resp.Error))
+		return nil, exchange.NewExchangeError(k.Name, KRAKEN_TICKER, 0, 0,
+			fmt.Sprintf("Kraken error: %s", resp.Error), "")
 	}
 
+	result := make(map[string]KrakenTicker, len(resp.Data))
 	for x, y := range resp.Data {
 		x = x[1:4] + x[5:]
 		ticker := KrakenTicker{}
@@ -377,8 +512,9 @@ func (k *Kraken) GetTicker(symbol string) error {
 		ticker.High, _ = strconv.ParseFloat(y.High[1], 64)
 		ticker.Open, _ = strconv.ParseFloat(y.Open, 64)
 		k.Ticker[x] = ticker
+		result[x] = ticker
 	}
-	return nil
+	return result, nil
 }
 
 func (k *Kraken) GetOHLC(symbol string) error {
@@ -386,7 +522,7 @@ func (k *Kraken) GetOHLC(symbol string) error {
 	values.Set("pair", symbol)
 
 	var result interface{}
-	path := fmt.Sprintf("%s/%s/public/%s?%s", KRAKEN_API_URL, KRAKEN_API_VERSION, KRAKEN_OHLC, values.Encode())
+	path := fmt.Sprintf("%s/%s/public/%s?%s", k.APIUrl, KRAKEN_API_VERSION, KRAKEN_OHLC, values.Encode())
 	err := common.SendHTTPGetRequest(path, true, k.Verbose, &result)
 
 	if err != nil {
@@ -399,13 +535,17 @@ func (k *Kraken) GetOHLC(symbol string) error {
 
 // GetDepth returns the orderbook for a particular currency
 func (k *Kraken) GetDepth(symbol string) (Orderbook, error) {
+	return k.getDepth(context.Background(), symbol)
+}
+
+func (k *Kraken) getDepth(ctx context.Context, symbol string) (Orderbook, error) {
 	values := url.Values{}
 	values.Set("pair", symbol)
 
 	var result interface{}
 	var ob Orderbook
-	path := fmt.Sprintf("%s/%s/public/%s?%s", KRAKEN_API_URL, KRAKEN_API_VERSION, KRAKEN_DEPTH, values.Encode())
-	err := common.SendHTTPGetRequest(path, true, k.Verbose, &result)
+	path := fmt.Sprintf("%s/%s/public/%s?%s", k.APIUrl, KRAKEN_API_VERSION, KRAKEN_DEPTH, values.Encode())
+	err := common.SendHTTPGetRequestContext(ctx, path, true, k.Verbose, &result)
 
 	if err != nil {
 		return ob, err
@@ -460,7 +600,7 @@ func (k *Kraken) GetTrades(symbol string) error {
 	values.Set("pair", symbol)
 
 	var result interface{}
-	path := fmt.Sprintf("%s/%s/public/%s?%s", KRAKEN_API_URL, KRAKEN_API_VERSION, KRAKEN_TRADES, values.Encode())
+	path := fmt.Sprintf("%s/%s/public/%s?%s", k.APIUrl, KRAKEN_API_VERSION, KRAKEN_TRADES, values.Encode())
 	err := common.SendHTTPGetRequest(path, true, k.Verbose, &result)
 
 	if err != nil {
@@ -476,7 +616,7 @@ func (k *Kraken) GetSpread(symbol string) {
 	values.Set("pair", symbol)
 
 	var result interface{}
-	path := fmt.Sprintf("%s/%s/public/%s?%s", KRAKEN_API_URL, KRAKEN_API_VERSION, KRAKEN_SPREAD, values.Encode())
+	path := fmt.Sprintf("%s/%s/public/%s?%s", k.APIUrl, KRAKEN_API_VERSION, KRAKEN_SPREAD, values.Encode())
 	err := common.SendHTTPGetRequest(path, true, k.Verbose, &result)
 
 	if err != nil {
@@ -485,13 +625,31 @@ func (k *Kraken) GetSpread(symbol string) {
 	}
 }
 
-func (k *Kraken) GetBalance() error {
-	var result interface{}
-	err := k.HTTPRequest(KRAKEN_BALANCE, true, url.Values{}, &result)
+// GetBalance returns the total balance of each asset in the account keyed by currency code,
+// the balances include the amounts held by open orders.
+func (k *Kraken) GetBalance() (map[string]float64, error) {
+	return k.getBalance(context.Background())
+}
+
+func (k *Kraken) getBalance(ctx context.Context) (map[string]float64, error) {
+	var result map[string]string
+	err := k.HTTPRequestContext(ctx, KRAKEN_BALANCE, true, url.Values{}, &result)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	panic("not implemented")
+
+	balances := make(map[string]float64, len(result))
+	for assetName, amount := range result {
+		currency, exists := k.assetCurrencies[assetName]
+		if !exists {
+			currency = assetName
+		}
+		balances[currency], err = strconv.ParseFloat(amount, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Kraken %s balance '%s'", assetName, amount)
+		}
+	}
+	return balances, nil
 }
 
 func (k *Kraken) GetTradeBalance(symbol, asset string) error {
@@ -516,6 +674,11 @@ func (k *Kraken) GetTradeBalance(symbol, asset string) error {
 }
 
 func (k *Kraken) GetOpenOrders(showTrades bool, userref int64) (map[string]Order, error) {
+	return k.getOpenOrders(context.Background(), showTrades, userref)
+}
+
+func (k *Kraken) getOpenOrders(ctx context.Context, showTrades bool,
+	userref int64) (map[string]Order, error) {
 	values := url.Values{}
 
 	if showTrades {
@@ -530,7 +693,7 @@ func (k *Kraken) GetOpenOrders(showTrades bool, userref int64) (map[string]Order
 		Open map[string]Order `json:"open"`
 	}
 	var result OpenOrdersResponse
-	err := k.HTTPRequest(KRAKEN_OPEN_ORDERS, true, values, &result)
+	err := k.HTTPRequestContext(ctx, KRAKEN_OPEN_ORDERS, true, values, &result)
 
 	if err != nil {
 		return nil, err
@@ -720,18 +883,22 @@ func (k *Kraken) QueryLedgers(id string) error {
 	panic("not implemented")
 }
 
-func (k *Kraken) GetTradeVolume(symbol string) error {
+// GetTradeVolume returns the 30 day trade volume of the account, and the fee tiers for the given
+// symbols.
+func (k *Kraken) GetTradeVolume(symbols ...string) (*TradeVolume, error) {
 	values := url.Values{}
-	values.Set("pair", symbol)
+	if len(symbols) > 0 {
+		values.Set("pair", common.JoinStrings(symbols, ","))
+		values.Set("fee-info", "true")
+	}
 
-	var result interface{}
+	var result TradeVolume
 	err := k.HTTPRequest(KRAKEN_TRADE_VOLUME, true, values, &result)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	panic("not implemented")
+	return &result, nil
 }
 
 type AddOrderParams struct {
@@ -745,6 +912,10 @@ type AddOrderParams struct {
 }
 
 func (k *Kraken) AddOrder(params AddOrderParams) (*AddOrderResult, error) {
+	return k.addOrder(context.Background(), params)
+}
+
+func (k *Kraken) addOrder(ctx context.Context, params AddOrderParams) (*AddOrderResult, error) {
 	values := url.Values{}
 	values.Set("pair", params.Pair)
 	values.Set("type", string(params.Side))
@@ -762,7 +933,7 @@ func (k *Kraken) AddOrder(params AddOrderParams) (*AddOrderResult, error) {
 	}
 
 	var result AddOrderResult
-	err := k.HTTPRequest(KRAKEN_ORDER_PLACE, true, values, &result)
+	err := k.HTTPRequestContext(ctx, KRAKEN_ORDER_PLACE, true, values, &result)
 
 	if err != nil {
 		return nil, err
@@ -771,20 +942,26 @@ func (k *Kraken) AddOrder(params AddOrderParams) (*AddOrderResult, error) {
 }
 
 func (k *Kraken) CancelOrder(orderStr string, currencyPair pair.CurrencyPair) error {
+	return k.CancelOrderContext(context.Background(), orderStr, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (k *Kraken) CancelOrderContext(ctx context.Context, orderStr string,
+	currencyPair pair.CurrencyPair) error {
 	var orderID int64
 	var err error
 	if orderID, err = strconv.ParseInt(orderStr, 10, 64); err != nil {
 		return err
 	}
-	return k.cancelOrder(orderID)
+	return k.cancelOrder(ctx, orderID)
 }
 
-func (k *Kraken) cancelOrder(orderID int64) error {
+func (k *Kraken) cancelOrder(ctx context.Context, orderID int64) error {
 	values := url.Values{}
 	values.Set("txid", strconv.FormatInt(orderID, 10))
 
 	var result interface{}
-	err := k.HTTPRequest(KRAKEN_ORDER_CANCEL, true, values, &result)
+	err := k.HTTPRequestContext(ctx, KRAKEN_ORDER_CANCEL, true, values, &result)
 
 	if err != nil {
 		return err
@@ -793,9 +970,38 @@ func (k *Kraken) cancelOrder(orderID int64) error {
 	panic("not implemented")
 }
 
+// SendAuthenticatedHTTPRequest sends a request to a private Kraken API endpoint and JSON decodes
+// the response into the result.
 func (k *Kraken) SendAuthenticatedHTTPRequest(method string, values url.Values, result interface{}) error {
+	return k.SendAuthenticatedHTTPRequestContext(context.Background(), method, values, result)
+}
+
+// SendAuthenticatedHTTPRequestContext is SendAuthenticatedHTTPRequest, the request is cancelled
+// when the context is done
+func (k *Kraken) SendAuthenticatedHTTPRequestContext(ctx context.Context, method string,
+	values url.Values, result interface{}) error {
+	resp, statusCode, err := k.sendAuthenticatedHTTPRequest(ctx, method, values)
+	if err != nil {
+		return err
+	}
+
+	err = common.JSONDecode([]byte(resp), &result)
+	if err != nil {
+		return exchange.NewExchangeError(k.Name, method, statusCode, 0,
+			"Unable to JSON Unmarshal response."+err.Error(), resp)
+	}
+
+	return nil
+}
+
+// sendAuthenticatedHTTPRequest signs and sends a request to a private Kraken API endpoint,
+// returns the raw response body and HTTP status code.
+func (k *Kraken) sendAuthenticatedHTTPRequest(ctx context.Context, method string,
+	values url.Values) (string, int, error) {
+	apiKey, apiSecret, _ := k.Credentials()
+
 	if !k.AuthenticatedAPISupport {
-		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, k.Name)
+		return "", 0, fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, k.Name)
 	}
 
 	path := fmt.Sprintf("/%s/private/%s", KRAKEN_API_VERSION, method)
@@ -806,62 +1012,85 @@ func (k *Kraken) SendAuthenticatedHTTPRequest(method string, values url.Values,
 	}
 
 	values.Set("nonce", k.Nonce.String())
-	secret, err := common.Base64Decode(k.APISecret)
+	secret, err := common.Base64Decode(apiSecret)
 
 	if err != nil {
-		return err
+		return "", 0, err
 	}
 
 	shasum := common.GetSHA256([]byte(values.Get("nonce") + values.Encode()))
 	signature := common.Base64Encode(common.GetHMAC(common.HashSHA512, append([]byte(path), shasum...), secret))
 
 	if k.Verbose {
-		log.Printf("Sending POST request to %s, path: %s.", KRAKEN_API_URL, path)
+		log.Printf("Sending POST request to %s, path: %s.", k.APIUrl, path)
 	}
 
-	headers := make(map[string]string)
-	headers["API-Key"] = k.APIKey
-	headers["API-Sign"] = signature
+	headers := make(http.Header)
+	headers.Set("API-Key", apiKey)
+	headers.Set("API-Sign", signature)
 
-	resp, err := common.SendHTTPRequest("POST", KRAKEN_API_URL+path, headers, strings.NewReader(values.Encode()))
+	resp, statusCode, err := common.SendHTTPRequest2Context(ctx, "POST", k.APIUrl+path, headers,
+		strings.NewReader(values.Encode()))
 
 	if err != nil {
-		return err
+		return "", 0, err
 	}
 
 	if k.Verbose {
 		log.Printf("Received raw: \n%s\n", resp)
 	}
 
-	err = common.JSONDecode([]byte(resp), &result)
-	if err != nil {
-		return errors.New("Unable to JSON Unmarshal response." + err.Error())
-	}
-
-	return nil
+	return resp, statusCode, nil
 }
 
 // HTTPRequestJSON sends an HTTP request to a Kraken API endpoint and and returns the result as raw JSON.
+// Errors reported by Kraken are returned as an *exchange.ExchangeError.
 func (k *Kraken) HTTPRequestJSON(path string, auth bool, values url.Values) (json.RawMessage, error) {
-	response := Response{}
+	return k.HTTPRequestJSONContext(context.Background(), path, auth, values)
+}
+
+// HTTPRequestJSONContext is HTTPRequestJSON, the request is cancelled when the context is done
+func (k *Kraken) HTTPRequestJSONContext(ctx context.Context, path string, auth bool,
+	values url.Values) (json.RawMessage, error) {
+	var resp string
+	var statusCode int
+	var err error
 	if auth {
-		if err := k.SendAuthenticatedHTTPRequest(path, values, &response); err != nil {
-			return nil, err
-		}
+		resp, statusCode, err = k.sendAuthenticatedHTTPRequest(ctx, path, values)
 	} else {
-		if err := common.SendHTTPGetRequest(path, true, k.Verbose, &response); err != nil {
-			return nil, err
+		if k.Verbose {
+			log.Println("Raw URL: ", path)
+		}
+		resp, statusCode, err = common.SendHTTPRequest2Context(ctx, "GET", path, make(http.Header), nil)
+		if err == nil && k.Verbose {
+			log.Println("Raw Resp: ", resp)
 		}
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	response := Response{}
+	if err = common.JSONDecode([]byte(resp), &response); err != nil {
+		return nil, exchange.NewExchangeError(k.Name, path, statusCode, 0,
+			"Unable to JSON Unmarshal response."+err.Error(), resp)
+	}
 	if len(response.Errors) > 0 {
-		return response.Result, errors.New(strings.Join(response.Errors, "\n"))
+		return response.Result, exchange.NewExchangeError(k.Name, path, statusCode, 0,
+			strings.Join(response.Errors, "\n"), resp)
 	}
 	return response.Result, nil
 }
 
 // HTTPRequest is a generalized http request function.
 func (k *Kraken) HTTPRequest(path string, auth bool, values url.Values, v interface{}) error {
-	result, err := k.HTTPRequestJSON(path, auth, values)
+	return k.HTTPRequestContext(context.Background(), path, auth, values, v)
+}
+
+// HTTPRequestContext is HTTPRequest, the request is cancelled when the context is done
+func (k *Kraken) HTTPRequestContext(ctx context.Context, path string, auth bool, values url.Values,
+	v interface{}) error {
+	result, err := k.HTTPRequestJSONContext(ctx, path, auth, values)
 	if err != nil {
 		return err
 	}
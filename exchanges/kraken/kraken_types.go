@@ -103,3 +103,24 @@ type AddOrderResult struct {
 	Info           OrderInfo `json:"descr"`
 	TransactionIDs []string  `json:"txid"`
 }
+
+// TradeVolume holds the 30 day trade volume of the account along with the fee tiers for the
+// requested pairs
+type TradeVolume struct {
+	Currency string  `json:"currency"`
+	Volume   float64 `json:"volume,string"`
+	// Taker fees keyed by symbol
+	Fees map[string]TradeVolumeFee `json:"fees"`
+	// Maker fees keyed by symbol
+	FeesMaker map[string]TradeVolumeFee `json:"fees_maker"`
+}
+
+// TradeVolumeFee holds the fee tier of the account for a single pair, all fees are percentages
+type TradeVolumeFee struct {
+	Fee        float64 `json:"fee,string"`
+	MinFee     float64 `json:"minfee,string"`
+	MaxFee     float64 `json:"maxfee,string"`
+	NextFee    float64 `json:"nextfee,string"`    // Zero if already at the lowest tier
+	NextVolume float64 `json:"nextvolume,string"` // Zero if already at the lowest tier
+	TierVolume float64 `json:"tiervolume,string"`
+}
@@ -1,6 +1,7 @@
 package kraken
 
 import (
+	"context"
 	"log"
 	"strings"
 
@@ -10,6 +11,9 @@ import (
 	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
 )
 
+var _ exchange.IExchange = (*Kraken)(nil)
+var _ exchange.ContextExchange = (*Kraken)(nil)
+
 // Start starts the Kraken go routine
 func (k *Kraken) Start() {
 	go k.Run()
@@ -33,6 +37,7 @@ func (k *Kraken) Run() {
 	for assetName, assetInfo := range assets {
 		assetNameToCurrency[assetName] = assetInfo.AltName
 	}
+	k.assetCurrencies = assetNameToCurrency
 
 	assetPairs, err := k.GetAssetPairs()
 	if err != nil {
@@ -40,9 +45,13 @@ func (k *Kraken) Run() {
 		return
 	}
 
+	k.symbolCache.Reset()
 	k.CurrencyPairCodeToSymbol = make(map[pair.CurrencyItem]string, len(assetPairs))
 	k.CurrencyPairs = make(map[pair.CurrencyItem]*exchange.CurrencyPairInfo, len(assetPairs))
 	k.PriceDecimalPlaces = make(map[pair.CurrencyItem]int32, len(assetPairs))
+	// The fees of the lowest volume tier are used until the account's tier is fetched
+	takerFees := make(map[string]float64, len(assetPairs))
+	makerFees := make(map[string]float64, len(assetPairs))
 	var exchangeProducts []string
 	for assetPairName, assetPairInfo := range assetPairs {
 		exchangeProducts = append(exchangeProducts, assetPairInfo.Altname)
@@ -66,34 +75,72 @@ func (k *Kraken) Run() {
 		k.CurrencyPairCodeToSymbol[currencyPairCode] = assetPairName
 		k.CurrencyPairs[pair.CurrencyItem(assetPairName)] = &exchange.CurrencyPairInfo{Currency: currencyPair}
 		k.PriceDecimalPlaces[currencyPairCode] = int32(assetPairInfo.PairDecimals)
+		if taker, maker, ok := assetPairFees(&assetPairInfo); ok {
+			takerFees[assetPairName] = taker
+			makerFees[assetPairName] = maker
+		}
 
 	}
+	k.takerFees = takerFees
+	k.makerFees = makerFees
 	err = k.UpdateAvailableCurrencies(exchangeProducts, false)
 	if err != nil {
 		log.Printf("%s Failed to get config.\n", k.GetName())
 	}
+
+	if k.AuthenticatedAPISupport {
+		if err = k.UpdateFeeTiers(); err != nil {
+			log.Printf("%s failed to fetch fee tiers, using default fees: %s\n", k.GetName(), err)
+		}
+	}
 }
 
-// UpdateTicker updates and returns the ticker for a currency pair
-func (k *Kraken) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
-	var tickerPrice ticker.Price
-	pairs := k.GetEnabledCurrencies()
-	pairsCollated, err := exchange.GetAndFormatExchangeCurrencies(k.Name, pairs)
-	if err != nil {
-		return tickerPrice, err
+// UpdateTickers updates the tickers of the given pairs with a single request. Kraken rejects the
+// whole request if any of the pairs is invalid, in which case the tickers are requested one pair
+// at a time so that the valid pairs are still updated.
+func (k *Kraken) UpdateTickers(pairs []pair.CurrencyPair, assetType string) ([]ticker.Price, error) {
+	return k.updateTickers(context.Background(), pairs, assetType)
+}
+
+func (k *Kraken) updateTickers(ctx context.Context, pairs []pair.CurrencyPair,
+	assetType string) ([]ticker.Price, error) {
+	symbols := make([]string, len(pairs))
+	for i, x := range pairs {
+		symbols[i] = exchange.FormatExchangeCurrency(k.Name, x).String()
 	}
-	err = k.GetTicker(pairsCollated.String())
+
+	var errs []exchange.PairError
+	failed := make(map[string]bool)
+	tickers, err := k.getTicker(ctx, strings.Join(symbols, ","))
 	if err != nil {
-		return tickerPrice, err
+		if _, rejected := err.(*exchange.ExchangeError); !rejected || len(pairs) == 1 {
+			return nil, err
+		}
+		tickers = make(map[string]KrakenTicker)
+		for i, x := range pairs {
+			result, err := k.getTicker(ctx, symbols[i])
+			if err != nil {
+				errs = append(errs, exchange.PairError{Pair: x, Err: err})
+				failed[x.Pair().String()] = true
+				continue
+			}
+			for symbol, tick := range result {
+				tickers[symbol] = tick
+			}
+		}
 	}
 
+	var prices []ticker.Price
 	for _, x := range pairs {
-		var tp ticker.Price
-		tick, ok := k.Ticker[x.Pair().String()]
+		tick, ok := tickers[x.Pair().String()]
 		if !ok {
+			if !failed[x.Pair().String()] {
+				errs = append(errs, exchange.PairError{Pair: x, Err: exchange.ErrNoPairData})
+			}
 			continue
 		}
 
+		var tp ticker.Price
 		tp.Pair = x
 		tp.Last = tick.Last
 		tp.Ask = tick.Ask
@@ -102,6 +149,23 @@ func (k *Kraken) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Pri
 		tp.Low = tick.Low
 		tp.Volume = tick.Volume
 		ticker.ProcessTicker(k.GetName(), x, tp, assetType)
+		prices = append(prices, tp)
+	}
+	return prices, exchange.NewPartialError(k.Name, errs)
+}
+
+// UpdateTicker updates and returns the ticker for a currency pair, the tickers of all the enabled
+// pairs are updated. Failures of the other pairs don't fail the update of the pair.
+func (k *Kraken) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return k.UpdateTickerContext(context.Background(), p, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (k *Kraken) UpdateTickerContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
+	_, err := k.updateTickers(ctx, k.GetEnabledCurrencies(), assetType)
+	if err = exchange.PairErr(err, p); err != nil {
+		return ticker.Price{}, err
 	}
 	return ticker.GetTicker(k.GetName(), p, assetType)
 }
@@ -116,7 +180,7 @@ func (k *Kraken) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.P
 }
 
 // GetOrderbookEx returns orderbook base on the currency pair
-func (k *Kraken) GetOrderbookEx(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+func (k *Kraken) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
 	ob, err := k.Orderbooks.GetOrderbook(k.GetName(), p, assetType)
 	if err == nil {
 		return k.UpdateOrderbook(p, assetType)
@@ -126,16 +190,24 @@ func (k *Kraken) GetOrderbookEx(p pair.CurrencyPair, assetType string) (orderboo
 
 // UpdateOrderbook updates and returns the orderbook for a currency pair
 func (k *Kraken) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return k.UpdateOrderbookContext(context.Background(), p, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (k *Kraken) UpdateOrderbookContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
 	var orderBook orderbook.Base
-	orderbookNew, err := k.GetDepth(exchange.FormatExchangeCurrency(k.GetName(), p).String())
+	orderbookNew, err := k.getDepth(ctx, exchange.FormatExchangeCurrency(k.GetName(), p).String())
 	if err != nil {
 		return orderBook, err
 	}
 
+	orderBook.Bids = orderbook.GetItems(len(orderbookNew.Bids))
 	for x := range orderbookNew.Bids {
 		orderBook.Bids = append(orderBook.Bids, orderbook.Item{Amount: orderbookNew.Bids[x].Amount, Price: orderbookNew.Bids[x].Price})
 	}
 
+	orderBook.Asks = orderbook.GetItems(len(orderbookNew.Asks))
 	for x := range orderbookNew.Asks {
 		orderBook.Asks = append(orderBook.Asks, orderbook.Item{Amount: orderbookNew.Asks[x].Amount, Price: orderbookNew.Asks[x].Price})
 	}
@@ -145,9 +217,32 @@ func (k *Kraken) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbo
 }
 
 // GetExchangeAccountInfo retrieves balances for all enabled currencies for the
-// Kraken exchange - to-do
+// Kraken exchange
 func (k *Kraken) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return k.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (k *Kraken) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
 	var response exchange.AccountInfo
 	response.ExchangeName = k.GetName()
+	balances, err := k.getBalance(ctx)
+	if err != nil {
+		return response, err
+	}
+	for currency, amount := range balances {
+		response.Currencies = append(response.Currencies, exchange.AccountCurrencyInfo{
+			CurrencyName: currency,
+			TotalValue:   amount,
+		})
+	}
+
+	// Kraken only reports the total balances, so derive the holds from the open orders
+	orders, err := k.GetOrdersContext(ctx, nil)
+	if err != nil {
+		return response, err
+	}
+	exchange.SetHoldsFromTotals(&response, orders)
 	return response, nil
 }
@@ -35,6 +35,15 @@ type LakeBTC struct {
 	exchange.Base
 }
 
+// New returns a LakeBTC exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *LakeBTC {
+	l := &LakeBTC{}
+	l.SetDefaults()
+	l.Quickstart(l.Setup, apiKey, apiSecret, opts...)
+	return l
+}
+
 func (l *LakeBTC) SetDefaults() {
 	l.Name = "LakeBTC"
 	l.Enabled = false
@@ -299,6 +308,8 @@ func (l *LakeBTC) CreateWithdraw(amount float64, accountID int64) (LakeBTCWithdr
 }
 
 func (l *LakeBTC) SendAuthenticatedHTTPRequest(method, params string, result interface{}) (err error) {
+	apiKey, apiSecret, _ := l.Credentials()
+
 	if !l.AuthenticatedAPISupport {
 		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, l.Name)
 	}
@@ -309,8 +320,8 @@ func (l *LakeBTC) SendAuthenticatedHTTPRequest(method, params string, result int
 		l.Nonce.Inc()
 	}
 
-	req := fmt.Sprintf("tonce=%s&accesskey=%s&requestmethod=post&id=1&method=%s&params=%s", l.Nonce.String(), l.APIKey, method, params)
-	hmac := common.GetHMAC(common.HashSHA1, []byte(req), []byte(l.APISecret))
+	req := fmt.Sprintf("tonce=%s&accesskey=%s&requestmethod=post&id=1&method=%s&params=%s", l.Nonce.String(), apiKey, method, params)
+	hmac := common.GetHMAC(common.HashSHA1, []byte(req), []byte(apiSecret))
 
 	if l.Verbose {
 		log.Printf("Sending POST request to %s calling method %s with params %s\n", LAKEBTC_API_URL, method, req)
@@ -328,7 +339,7 @@ func (l *LakeBTC) SendAuthenticatedHTTPRequest(method, params string, result int
 
 	headers := make(map[string]string)
 	headers["Json-Rpc-Tonce"] = l.Nonce.String()
-	headers["Authorization"] = "Basic " + common.Base64Encode([]byte(l.APIKey+":"+common.HexEncodeToString(hmac)))
+	headers["Authorization"] = "Basic " + common.Base64Encode([]byte(apiKey+":"+common.HexEncodeToString(hmac)))
 	headers["Content-Type"] = "application/json-rpc"
 
 	resp, err := common.SendHTTPRequest("POST", LAKEBTC_API_URL, headers, strings.NewReader(string(data)))
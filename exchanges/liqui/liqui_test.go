@@ -1,125 +1,60 @@
 package liqui
 
 import (
-	"net/url"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
 )
 
-var l Liqui
-
-const (
-	apiKey    = ""
-	apiSecret = ""
-)
-
-func TestSetDefaults(t *testing.T) {
-	l.SetDefaults()
-}
-
 func TestSetup(t *testing.T) {
+	l := Liqui{}
+	l.SetDefaults()
 	cfg := config.GetConfig()
 	cfg.LoadConfig("../../testdata/configtest.dat")
 	liquiConfig, err := cfg.GetExchangeConfig("Liqui")
 	if err != nil {
-		t.Error("Test Failed - liqui Setup() init error")
+		t.Fatal("Test Failed - liqui Setup() init error")
 	}
-
 	liquiConfig.AuthenticatedAPISupport = true
-	liquiConfig.APIKey = apiKey
-	liquiConfig.APISecret = apiSecret
-
 	l.Setup(liquiConfig)
-}
-
-func TestGetFee(t *testing.T) {
-	_, err := l.GetFee("usd")
-	if err == nil {
-		t.Error("Test Failed - liqui GetFee() error", err)
+	if l.APIUrl != liquiBaseURL {
+		t.Errorf("Test failed. Unexpected API URL %s", l.APIUrl)
 	}
 }
 
-func TestGetAvailablePairs(t *testing.T) {
-	v := l.GetAvailablePairs(false)
-	if len(v) != 0 {
-		t.Error("Test Failed - liqui GetFee() error")
-	}
-}
-
-func TestGetInfo(t *testing.T) {
-	_, err := l.GetInfo()
-	if err != nil {
-		t.Error("Test Failed - liqui GetInfo() error", err)
-	}
-}
+func TestGetOrders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.URL.Path != "/tapi/" || r.PostForm.Get("method") != "ActiveOrders" || r.PostForm.Get("pair") != "" {
+			fmt.Fprintf(w, `{"success":0,"error":"unexpected params %s"}`, r.PostForm.Encode())
+			return
+		}
+		fmt.Fprint(w, `{"success":1,"return":{
+			"1001":{"pair":"eth_btc","type":"sell","amount":2,"rate":0.0335,"timestamp_created":1542682259,"status":0},
+			"1002":{"pair":"ltc_btc","type":"buy","amount":5,"rate":0.0081,"timestamp_created":1542682260,"status":0}}}`)
+	}))
+	defer server.Close()
 
-func TestGetTicker(t *testing.T) {
-	_, err := l.GetTicker("eth_btc")
-	if err != nil {
-		t.Error("Test Failed - liqui GetTicker() error", err)
-	}
-}
-
-func TestGetDepth(t *testing.T) {
-	_, err := l.GetDepth("eth_btc")
-	if err != nil {
-		t.Error("Test Failed - liqui GetDepth() error", err)
-	}
-}
+	l := Liqui{}
+	l.SetDefaults()
+	l.APIUrl = server.URL
+	l.AuthenticatedAPISupport = true
+	l.SetAPIKeys("key", "secret", "", false)
 
-func TestGetTrades(t *testing.T) {
-	_, err := l.GetTrades("eth_btc")
+	// The orders of all the pairs are fetched with a single request, and filtered by pair
+	orders, err := l.GetOrders([]pair.CurrencyPair{pair.NewCurrencyPairDelimiter("LTC_BTC", "_")})
 	if err != nil {
-		t.Error("Test Failed - liqui GetTrades() error", err)
-	}
-}
-
-func TestGetAccountInfo(t *testing.T) {
-	_, err := l.GetAccountInfo()
-	if err == nil {
-		t.Error("Test Failed - liqui GetAccountInfo() error", err)
-	}
-}
-
-func TestTrade(t *testing.T) {
-	_, err := l.Trade("", "", 0, 1)
-	if err == nil {
-		t.Error("Test Failed - liqui Trade() error", err)
-	}
-}
-
-func TestGetActiveOrders(t *testing.T) {
-	_, err := l.GetActiveOrders("eth_btc")
-	if err == nil {
-		t.Error("Test Failed - liqui GetActiveOrders() error", err)
-	}
-}
-
-func TestGetOrderInfo(t *testing.T) {
-	_, err := l.GetOrderInfo(1337)
-	if err == nil {
-		t.Error("Test Failed - liqui GetOrderInfo() error", err)
+		t.Fatalf("Test failed. GetOrders returned an error: %s", err)
 	}
-}
-
-func TestCancelOrder(t *testing.T) {
-	err := l.CancelOrder("1337")
-	if err == nil {
-		t.Error("Test Failed - liqui CancelOrder() error", err)
+	if len(orders) != 1 || orders[0].OrderID != "1002" || orders[0].Side != exchange.OrderSideBuy {
+		t.Errorf("Test failed. Unexpected orders %+v", orders)
 	}
-}
-
-func TestGetTradeHistory(t *testing.T) {
-	_, err := l.GetTradeHistory(url.Values{}, "")
-	if err == nil {
-		t.Error("Test Failed - liqui GetTradeHistory() error", err)
-	}
-}
-
-func TestWithdrawCoins(t *testing.T) {
-	_, err := l.WithdrawCoins("btc", 1337, "someaddr")
-	if err == nil {
-		t.Error("Test Failed - liqui WithdrawCoins() error", err)
+	if orders, err = l.GetOrders(nil); err != nil || len(orders) != 2 {
+		t.Errorf("Test failed. Expected the orders of all the pairs, got %d %v", len(orders), err)
 	}
 }
@@ -33,6 +33,15 @@ type LocalBitcoins struct {
 	exchange.Base
 }
 
+// New returns a LocalBitcoins exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *LocalBitcoins {
+	l := &LocalBitcoins{}
+	l.SetDefaults()
+	l.Quickstart(l.Setup, apiKey, apiSecret, opts...)
+	return l
+}
+
 func (l *LocalBitcoins) SetDefaults() {
 	l.Name = "LocalBitcoins"
 	l.Enabled = false
@@ -291,6 +300,8 @@ func (l *LocalBitcoins) GetWalletAddress() (string, error) {
 }
 
 func (l *LocalBitcoins) SendAuthenticatedHTTPRequest(method, path string, values url.Values, result interface{}) (err error) {
+	apiKey, apiSecret, _ := l.Credentials()
+
 	if !l.AuthenticatedAPISupport {
 		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, l.Name)
 	}
@@ -308,10 +319,10 @@ func (l *LocalBitcoins) SendAuthenticatedHTTPRequest(method, path string, values
 		payload = values.Encode()
 	}
 
-	message := l.Nonce.String() + l.APIKey + path + payload
-	hmac := common.GetHMAC(common.HashSHA256, []byte(message), []byte(l.APISecret))
+	message := l.Nonce.String() + apiKey + path + payload
+	hmac := common.GetHMAC(common.HashSHA256, []byte(message), []byte(apiSecret))
 	headers := make(map[string]string)
-	headers["Apiauth-Key"] = l.APIKey
+	headers["Apiauth-Key"] = apiKey
 	headers["Apiauth-Nonce"] = l.Nonce.String()
 	headers["Apiauth-Signature"] = common.StringToUpper(common.HexEncodeToString(hmac))
 	headers["Content-Type"] = "application/x-www-form-urlencoded"
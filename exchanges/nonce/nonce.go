@@ -4,8 +4,12 @@ import (
 	"strconv"
 	"sync"
 	"time"
+
+	"github.com/mattkanwisher/cryptofiend/storage"
 )
 
+const noncesBucket = "nonces"
+
 // Nonce struct holds the nonce value
 type Nonce struct {
 	// Standard nonce
@@ -78,6 +82,46 @@ func (n *Nonce) GetValue(exchName string, nanoPrecision bool) Value {
 	return Value(n.boundedCall[exchName])
 }
 
+// state holds the persisted nonce values
+type state struct {
+	N           int64
+	BoundedCall map[string]int64 `json:",omitempty"`
+}
+
+// Save persists the nonce values to the store under the given key, so that they can be restored
+// after a restart.
+func (n *Nonce) Save(s storage.Store, key string) error {
+	n.mtx.Lock()
+	n.boundedMtx.Lock()
+	st := state{N: n.n, BoundedCall: make(map[string]int64, len(n.boundedCall))}
+	for k, v := range n.boundedCall {
+		st.BoundedCall[k] = v
+	}
+	n.boundedMtx.Unlock()
+	n.mtx.Unlock()
+	return s.Put(noncesBucket, key, st)
+}
+
+// Load restores the nonce values previously saved to the store under the given key, nothing is
+// changed if no values have been saved yet.
+func (n *Nonce) Load(s storage.Store, key string) error {
+	var st state
+	err := s.Get(noncesBucket, key, &st)
+	if err == storage.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	n.mtx.Lock()
+	n.n = st.N
+	n.mtx.Unlock()
+	n.boundedMtx.Lock()
+	n.boundedCall = st.BoundedCall
+	n.boundedMtx.Unlock()
+	return nil
+}
+
 // String is a Value method that changes format to a string
 func (v Value) String() string {
 	return strconv.FormatInt(int64(v), 10)
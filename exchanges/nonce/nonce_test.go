@@ -4,6 +4,8 @@ import (
 	"strconv"
 	"testing"
 	"time"
+
+	"github.com/mattkanwisher/cryptofiend/storage"
 )
 
 func TestInc(t *testing.T) {
@@ -99,3 +101,28 @@ func TestNonceConcurrency(t *testing.T) {
 		t.Errorf("Test failed. Expected %d got %d", expected, result)
 	}
 }
+
+func TestSaveLoad(t *testing.T) {
+	store := storage.NewMemoryStore()
+	var nonce Nonce
+	if err := nonce.Load(store, "Bitfinex"); err != nil {
+		t.Fatalf("Test failed. Load error: %s", err)
+	}
+
+	nonce.Set(42)
+	value := nonce.GetValue("Bitfinex", false)
+	if err := nonce.Save(store, "Bitfinex"); err != nil {
+		t.Fatalf("Test failed. Save error: %s", err)
+	}
+
+	var restored Nonce
+	if err := restored.Load(store, "Bitfinex"); err != nil {
+		t.Fatalf("Test failed. Load error: %s", err)
+	}
+	if restored.Get() != 42 {
+		t.Errorf("Test failed. Expected 42 got %d", restored.Get())
+	}
+	if result := restored.GetValue("Bitfinex", false); result != value+1 {
+		t.Errorf("Test failed. Expected %d got %d", value+1, result)
+	}
+}
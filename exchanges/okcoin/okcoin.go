@@ -87,6 +87,17 @@ func (o *OKCoin) setCurrencyPairFormats() {
 	o.ConfigCurrencyPairFormat.Uppercase = true
 }
 
+// New returns a OKCoin exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+// Like SetDefaults the first OKCoin exchange created is OKCOIN International, the next ones are
+// OKCOIN China.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *OKCoin {
+	o := &OKCoin{}
+	o.SetDefaults()
+	o.Quickstart(o.Setup, apiKey, apiSecret, opts...)
+	return o
+}
+
 func (o *OKCoin) SetDefaults() {
 	o.SetErrorDefaults()
 	o.SetWebsocketErrorDefaults()
@@ -920,12 +931,14 @@ func (o *OKCoin) GetFuturesUserPosition4Fix(symbol, contractType string) {
 }
 
 func (o *OKCoin) SendAuthenticatedHTTPRequest(method string, v url.Values, result interface{}) (err error) {
+	apiKey, apiSecret, _ := o.Credentials()
+
 	if !o.AuthenticatedAPISupport {
 		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, o.Name)
 	}
 
-	v.Set("api_key", o.APIKey)
-	hasher := common.GetMD5([]byte(v.Encode() + "&secret_key=" + o.APISecret))
+	v.Set("api_key", apiKey)
+	hasher := common.GetMD5([]byte(v.Encode() + "&secret_key=" + apiSecret))
 	v.Set("sign", strings.ToUpper(common.HexEncodeToString(hasher)))
 
 	encoded := v.Encode()
@@ -167,9 +167,10 @@ func (o *OKCoin) ConvertToURLValues(values map[string]string) url.Values {
 }
 
 func (o *OKCoin) WebsocketSign(values map[string]string) string {
-	values["api_key"] = o.APIKey
+	apiKey, apiSecret, _ := o.Credentials()
+	values["api_key"] = apiKey
 	urlVals := o.ConvertToURLValues(values)
-	return strings.ToUpper(common.HexEncodeToString(common.GetMD5([]byte(urlVals.Encode() + "&secret_key=" + o.APISecret))))
+	return strings.ToUpper(common.HexEncodeToString(common.GetMD5([]byte(urlVals.Encode() + "&secret_key=" + apiSecret))))
 }
 
 func (o *OKCoin) AddChannelAuthenticated(channel string, values map[string]string) {
@@ -225,7 +226,7 @@ func (o *OKCoin) WebsocketClient() {
 	}
 
 	for o.Enabled && o.Websocket {
-		var Dialer websocket.Dialer
+		Dialer := websocket.Dialer{EnableCompression: o.WebsocketCompression()}
 		var err error
 		o.WebsocketConn, _, err = Dialer.Dial(o.WebsocketURL, http.Header{})
 
@@ -285,6 +286,7 @@ func (o *OKCoin) WebsocketClient() {
 				log.Println(err)
 				break
 			}
+			o.RecordWebsocketFrame(msgType, resp)
 			switch msgType {
 			case websocket.TextMessage:
 				response := []interface{}{}
@@ -74,7 +74,7 @@ func (o *OKCoin) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.P
 }
 
 // GetOrderbookEx returns orderbook base on the currency pair
-func (o *OKCoin) GetOrderbookEx(currency pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+func (o *OKCoin) GetOrderbookEx(currency pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
 	ob, err := o.Orderbooks.GetOrderbook(o.GetName(), currency, assetType)
 	if err == nil {
 		return o.UpdateOrderbook(currency, assetType)
@@ -0,0 +1,257 @@
+package okex
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	exchange "github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+const (
+	okexBaseURL             = "https://www.okex.com"
+	okexInstrumentsPath     = "/api/spot/v3/instruments"
+	okexAccountsPath        = "/api/spot/v3/accounts"
+	okexOrdersPath          = "/api/spot/v3/orders"
+	okexOrdersPendingPath   = "/api/spot/v3/orders_pending"
+	okexCancelOrdersPath    = "/api/spot/v3/cancel_orders"
+	okexTimestampFormat     = "2006-01-02T15:04:05.000Z"
+	okexOrderTypeNormal     = "0"
+	okexDefaultBookSize     = 200
+	okexMaxPendingOrderPage = 100
+)
+
+// OKEx is the spot trading (v3 API) client of the OKEx exchange. The API passphrase must be set
+// as the client ID of the exchange config.
+type OKEx struct {
+	exchange.Base
+	// Maps symbol (exchange specific market identifier) to currency pair info
+	currencyPairs    map[pair.CurrencyItem]*exchange.CurrencyPairInfo
+	symbolDetailsMap map[pair.CurrencyItem]*symbolDetails
+	// Caches the results of the bulk symbol <-> currency pair conversions
+	symbolCache exchange.SymbolCache
+}
+
+// CurrencyPairToSymbol converts a currency pair to a symbol (exchange specific market identifier).
+func (o *OKEx) CurrencyPairToSymbol(p pair.CurrencyPair) string {
+	return p.
+		Display(o.RequestCurrencyPairFormat.Delimiter, o.RequestCurrencyPairFormat.Uppercase).
+		String()
+}
+
+// SymbolToCurrencyPair converts a symbol (exchange specific market identifier) to a currency pair.
+func (o *OKEx) SymbolToCurrencyPair(symbol string) (pair.CurrencyPair, error) {
+	if p, exists := o.currencyPairs[pair.CurrencyItem(symbol)]; exists {
+		return p.Currency, nil
+	}
+	return pair.CurrencyPair{}, fmt.Errorf("no currency pair found for '%s' symbol", symbol)
+}
+
+// CurrencyPairsToSymbols converts a batch of currency pairs to symbols, conversions are cached.
+func (o *OKEx) CurrencyPairsToSymbols(pairs []pair.CurrencyPair) ([]string, error) {
+	return o.symbolCache.CurrencyPairsToSymbols(pairs, func(cp pair.CurrencyPair) (string, error) {
+		return o.CurrencyPairToSymbol(cp), nil
+	})
+}
+
+// SymbolsToCurrencyPairs converts a batch of symbols to currency pairs, conversions are cached.
+func (o *OKEx) SymbolsToCurrencyPairs(symbols []string) ([]pair.CurrencyPair, error) {
+	return o.symbolCache.SymbolsToCurrencyPairs(symbols, o.SymbolToCurrencyPair)
+}
+
+// FetchInstruments fetches the trading rules of all the spot instruments.
+func (o *OKEx) FetchInstruments() ([]Instrument, error) {
+	var response []Instrument
+	err := o.SendHTTPRequest(http.MethodGet, okexInstrumentsPath, nil, nil, false, &response)
+	return response, err
+}
+
+// FetchTicker fetches the ticker of an instrument.
+func (o *OKEx) FetchTicker(symbol string) (*Ticker, error) {
+	return o.fetchTicker(context.Background(), symbol)
+}
+
+func (o *OKEx) fetchTicker(ctx context.Context, symbol string) (*Ticker, error) {
+	response := Ticker{}
+	path := fmt.Sprintf("%s/%s/ticker", okexInstrumentsPath, symbol)
+	err := o.SendHTTPRequestContext(ctx, http.MethodGet, path, nil, nil, false, &response)
+	return &response, err
+}
+
+// FetchBook fetches the orderbook of an instrument, size is the number of price levels on each
+// side (up to 200).
+func (o *OKEx) FetchBook(symbol string, size int) (*Book, error) {
+	return o.fetchBook(context.Background(), symbol, size)
+}
+
+func (o *OKEx) fetchBook(ctx context.Context, symbol string, size int) (*Book, error) {
+	v := url.Values{}
+	v.Set("size", fmt.Sprint(size))
+	response := Book{}
+	path := fmt.Sprintf("%s/%s/book", okexInstrumentsPath, symbol)
+	err := o.SendHTTPRequestContext(ctx, http.MethodGet, path, v, nil, false, &response)
+	return &response, err
+}
+
+// FetchAccounts fetches the balances of the spot account.
+func (o *OKEx) FetchAccounts() ([]Account, error) {
+	return o.fetchAccounts(context.Background())
+}
+
+func (o *OKEx) fetchAccounts(ctx context.Context) ([]Account, error) {
+	var response []Account
+	err := o.SendHTTPRequestContext(ctx, http.MethodGet, okexAccountsPath, nil, nil, true, &response)
+	return response, err
+}
+
+// PostOrder places an order, an error is returned if the order was rejected.
+func (o *OKEx) PostOrder(req *PostOrderRequest) (*OrderResponse, error) {
+	return o.postOrder(context.Background(), req)
+}
+
+func (o *OKEx) postOrder(ctx context.Context, req *PostOrderRequest) (*OrderResponse, error) {
+	response := OrderResponse{}
+	if err := o.SendHTTPRequestContext(ctx, http.MethodPost, okexOrdersPath, nil, req, true,
+		&response); err != nil {
+		return nil, err
+	}
+	if !response.Result {
+		return nil, exchange.NewExchangeError(o.Name, okexOrdersPath, http.StatusOK,
+			int(response.ErrorCode), response.ErrorMessage, "")
+	}
+	return &response, nil
+}
+
+// DeleteOrder cancels an open order.
+func (o *OKEx) DeleteOrder(symbol, orderID string) error {
+	return o.deleteOrder(context.Background(), symbol, orderID)
+}
+
+func (o *OKEx) deleteOrder(ctx context.Context, symbol, orderID string) error {
+	response := OrderResponse{}
+	path := okexCancelOrdersPath + "/" + orderID
+	req := map[string]string{"instrument_id": symbol}
+	if err := o.SendHTTPRequestContext(ctx, http.MethodPost, path, nil, req, true, &response); err != nil {
+		return err
+	}
+	if !response.Result {
+		return exchange.NewExchangeError(o.Name, okexCancelOrdersPath, http.StatusOK,
+			int(response.ErrorCode), response.ErrorMessage, "")
+	}
+	return nil
+}
+
+// FetchOrder fetches an order (which may be open or closed).
+func (o *OKEx) FetchOrder(symbol, orderID string) (*Order, error) {
+	return o.fetchOrder(context.Background(), symbol, orderID)
+}
+
+func (o *OKEx) fetchOrder(ctx context.Context, symbol, orderID string) (*Order, error) {
+	v := url.Values{}
+	v.Set("instrument_id", symbol)
+	response := Order{}
+	err := o.SendHTTPRequestContext(ctx, http.MethodGet, okexOrdersPath+"/"+orderID, v, nil, true, &response)
+	return &response, err
+}
+
+// FetchOpenOrders fetches the open orders of an instrument, only the most recent 100 orders are
+// returned.
+func (o *OKEx) FetchOpenOrders(symbol string) ([]Order, error) {
+	return o.fetchOpenOrders(context.Background(), symbol)
+}
+
+func (o *OKEx) fetchOpenOrders(ctx context.Context, symbol string) ([]Order, error) {
+	v := url.Values{}
+	v.Set("instrument_id", symbol)
+	v.Set("limit", fmt.Sprint(okexMaxPendingOrderPage))
+	var response []Order
+	err := o.SendHTTPRequestContext(ctx, http.MethodGet, okexOrdersPendingPath, v, nil, true, &response)
+	return response, err
+}
+
+// SendHTTPRequest sends a request to the given path, params are sent in the query string and
+// the body (if not nil) is sent as JSON. Authenticated requests are signed with the API secret.
+// The response is decoded into the result object.
+func (o *OKEx) SendHTTPRequest(method, path string, params url.Values, body interface{},
+	authenticated bool, result interface{}) error {
+	return o.SendHTTPRequestContext(context.Background(), method, path, params, body, authenticated, result)
+}
+
+// SendHTTPRequestContext is SendHTTPRequest, the request is cancelled when the context is done
+func (o *OKEx) SendHTTPRequestContext(ctx context.Context, method, path string, params url.Values,
+	body interface{}, authenticated bool, result interface{}) error {
+	if authenticated && !o.AuthenticatedAPISupport {
+		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, o.Name)
+	}
+
+	requestPath := path
+	if len(params) > 0 {
+		requestPath += "?" + params.Encode()
+	}
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = common.JSONEncode(body); err != nil {
+			return err
+		}
+	}
+
+	if o.Debug(exchange.TraceHTTP) {
+		log.Printf("Request: %s %s %s\n", method, requestPath, payload)
+	}
+
+	headers := make(http.Header)
+	headers.Set("Accept", "application/json")
+	headers.Set("Content-Type", "application/json")
+	if authenticated {
+		apiKey, apiSecret, clientID := o.Credentials()
+
+		timestamp := time.Now().UTC().Format(okexTimestampFormat)
+		headers.Set("OK-ACCESS-KEY", apiKey)
+		headers.Set("OK-ACCESS-SIGN", o.sign(apiSecret, timestamp, method, requestPath, payload))
+		headers.Set("OK-ACCESS-TIMESTAMP", timestamp)
+		headers.Set("OK-ACCESS-PASSPHRASE", clientID)
+	}
+
+	resp, statusCode, err := common.SendHTTPRequest2Context(ctx, method, o.APIUrl+requestPath, headers,
+		bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	if o.Debug(exchange.TraceHTTP) {
+		log.Printf("Received raw: \n%s\n", resp)
+	}
+
+	if 200 <= statusCode && statusCode <= 299 {
+		if err = common.JSONDecode([]byte(resp), result); err != nil {
+			return exchange.NewExchangeError(o.Name, path, statusCode, 0,
+				"failed to unmarshal response", resp)
+		}
+		return nil
+	}
+
+	var errInfo ErrorInfo
+	if err = common.JSONDecode([]byte(resp), &errInfo); err != nil {
+		return exchange.NewExchangeError(o.Name, path, statusCode, 0,
+			"failed to unmarshal error info", resp)
+	}
+	code, message := errInfo.Code, errInfo.Message
+	if code == 0 && message == "" {
+		code, message = errInfo.ErrorCode, errInfo.ErrorMessage
+	}
+	return exchange.NewExchangeError(o.Name, path, statusCode, int(code), message, resp)
+}
+
+// sign returns the signature of a request, the base64 encoded HMAC-SHA256 of the timestamp,
+// method, request path (including the query string) & body.
+func (o *OKEx) sign(apiSecret, timestamp, method, requestPath string, body []byte) string {
+	prehash := timestamp + method + requestPath + string(body)
+	return common.Base64Encode(common.GetHMAC(common.HashSHA256, []byte(prehash), []byte(apiSecret)))
+}
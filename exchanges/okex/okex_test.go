@@ -0,0 +1,90 @@
+package okex
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+func newTestOKEx(handler http.HandlerFunc) (*OKEx, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	o := &OKEx{}
+	o.SetDefaults()
+	o.APIUrl = server.URL
+	o.AuthenticatedAPISupport = true
+	o.SetAPIKeys("key", "secret", "passphrase", false)
+	return o, server
+}
+
+func TestSetInstruments(t *testing.T) {
+	o, server := newTestOKEx(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"instrument_id":"LTC-BTC","base_currency":"LTC","quote_currency":"BTC",
+			"min_size":"0.001","size_increment":"0.000001","tick_size":"0.00001"}]`)
+	})
+	defer server.Close()
+
+	instruments, err := o.FetchInstruments()
+	if err != nil {
+		t.Fatalf("Test failed. FetchInstruments returned an error: %s", err)
+	}
+	o.setInstruments(instruments)
+	p, err := o.SymbolToCurrencyPair("LTC-BTC")
+	if err != nil || p.Pair().String() != "LTC-BTC" {
+		t.Errorf("Test failed. Unexpected currency pair %v %v", p, err)
+	}
+	limits := o.GetLimits()
+	ltcbtc := pair.NewCurrencyPair("LTC", "BTC")
+	if limits.GetPriceDecimalPlaces(ltcbtc) != 5 || limits.GetAmountDecimalPlaces(ltcbtc) != 6 ||
+		limits.GetMinAmount(ltcbtc) != 0.001 {
+		t.Error("Test failed. Unexpected limits")
+	}
+}
+
+func TestSendHTTPRequestSigned(t *testing.T) {
+	o, server := newTestOKEx(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		timestamp := r.Header.Get("OK-ACCESS-TIMESTAMP")
+		expected := (&OKEx{}).sign("secret", timestamp, r.Method, r.URL.RequestURI(), body)
+		if r.Header.Get("OK-ACCESS-SIGN") != expected || r.Header.Get("OK-ACCESS-PASSPHRASE") != "passphrase" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"code":30013,"message":"Invalid Sign"}`)
+			return
+		}
+		fmt.Fprint(w, `{"order_id":"2510789768709120","client_oid":"","result":true,"error_code":"","error_message":""}`)
+	})
+	defer server.Close()
+
+	id, err := o.NewOrder(pair.NewCurrencyPair("LTC", "BTC"), 1.5, 0.0155, exchange.OrderSideBuy,
+		exchange.OrderTypeExchangeLimit)
+	if err != nil || id != "2510789768709120" {
+		t.Errorf("Test failed. Unexpected order ID %s %v", id, err)
+	}
+
+	o.APISecret = "wrong"
+	_, err = o.FetchAccounts()
+	if e, ok := err.(*exchange.ExchangeError); !ok || e.Code != 30013 || e.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Test failed. Expected an invalid signature error but got %v", err)
+	}
+}
+
+func TestConvertOrder(t *testing.T) {
+	o, server := newTestOKEx(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"order_id":"1","instrument_id":"LTC-BTC","price":"0.0155","size":"2",
+			"filled_size":"0.5","side":"sell","type":"limit","state":"1","timestamp":"2019-03-08T10:59:25.789Z"}`)
+	})
+	defer server.Close()
+
+	order, err := o.GetOrder("1", pair.NewCurrencyPair("LTC", "BTC"))
+	if err != nil {
+		t.Fatalf("Test failed. GetOrder returned an error: %s", err)
+	}
+	if order.Status != exchange.OrderStatusActive || order.RemainingAmount != 1.5 ||
+		order.Side != exchange.OrderSideSell || order.CurrencyPair.FirstCurrency != "LTC" {
+		t.Errorf("Test failed. Unexpected order %+v", order)
+	}
+}
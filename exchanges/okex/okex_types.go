@@ -0,0 +1,167 @@
+package okex
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrorInfo is the body of a rejected request, older endpoints use the error_code &
+// error_message fields instead of code & message.
+type ErrorInfo struct {
+	Code         ErrorCode `json:"code"`
+	Message      string    `json:"message"`
+	ErrorCode    ErrorCode `json:"error_code"`
+	ErrorMessage string    `json:"error_message"`
+}
+
+// ErrorCode is an OKEx error code, which some endpoints send as a number & others as a string
+// (an empty string if the request succeeded).
+type ErrorCode int
+
+// UnmarshalJSON decodes an error code sent either as a number or a string.
+func (c *ErrorCode) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), "\"")
+	if s == "" || s == "null" {
+		*c = 0
+		return nil
+	}
+	code, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("invalid error code %s", b)
+	}
+	*c = ErrorCode(code)
+	return nil
+}
+
+// Instrument holds the trading rules of a spot instrument
+type Instrument struct {
+	InstrumentID  string          `json:"instrument_id"`
+	BaseCurrency  string          `json:"base_currency"`
+	QuoteCurrency string          `json:"quote_currency"`
+	MinSize       decimal.Decimal `json:"min_size"`
+	SizeIncrement decimal.Decimal `json:"size_increment"`
+	TickSize      decimal.Decimal `json:"tick_size"`
+}
+
+// Ticker is the best bid & ask, last traded price and 24h volume of an instrument
+type Ticker struct {
+	InstrumentID  string    `json:"instrument_id"`
+	Last          float64   `json:"last,string"`
+	BestBid       float64   `json:"best_bid,string"`
+	BestAsk       float64   `json:"best_ask,string"`
+	High24h       float64   `json:"high_24h,string"`
+	Low24h        float64   `json:"low_24h,string"`
+	BaseVolume24h float64   `json:"base_volume_24h,string"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// BookEntry is a price level of the orderbook
+type BookEntry struct {
+	Price    float64
+	Size     float64
+	NumOrder int64
+}
+
+// UnmarshalJSON decodes a [price, size, number of orders] array of strings.
+func (entry *BookEntry) UnmarshalJSON(b []byte) error {
+	var s []string
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+	if len(s) < 2 {
+		return fmt.Errorf("invalid orderbook entry %s", b)
+	}
+	if entry.Price, err = strconv.ParseFloat(s[0], 64); err != nil {
+		return err
+	}
+	if entry.Size, err = strconv.ParseFloat(s[1], 64); err != nil {
+		return err
+	}
+	if len(s) > 2 {
+		entry.NumOrder, _ = strconv.ParseInt(s[2], 10, 64)
+	}
+	return nil
+}
+
+// Book is the depth of an instrument
+type Book struct {
+	Asks      []BookEntry `json:"asks"`
+	Bids      []BookEntry `json:"bids"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// Account is the balance of a currency in the spot account
+type Account struct {
+	Currency  string  `json:"currency"`
+	Balance   float64 `json:"balance,string"`
+	Hold      float64 `json:"hold,string"`
+	Available float64 `json:"available,string"`
+}
+
+type OrderSide string
+
+const (
+	OrderSideBuy  OrderSide = "buy"
+	OrderSideSell OrderSide = "sell"
+)
+
+type OrderType string
+
+const (
+	OrderTypeLimit  OrderType = "limit"
+	OrderTypeMarket OrderType = "market"
+)
+
+// OrderState is the state of an order, OKEx encodes these as numeric strings
+type OrderState string
+
+const (
+	OrderStateFailed        OrderState = "-2"
+	OrderStateCanceled      OrderState = "-1"
+	OrderStateOpen          OrderState = "0"
+	OrderStatePartial       OrderState = "1"
+	OrderStateFilled        OrderState = "2"
+	OrderStateSubmitting    OrderState = "3"
+	OrderStatePendingCancel OrderState = "4"
+)
+
+// Order is an order placed on the spot market
+type Order struct {
+	OrderID      string     `json:"order_id"`
+	ClientOID    string     `json:"client_oid"`
+	InstrumentID string     `json:"instrument_id"`
+	Price        float64    `json:"price,string"`
+	Size         float64    `json:"size,string"`
+	FilledSize   float64    `json:"filled_size,string"`
+	Side         OrderSide  `json:"side"`
+	Type         OrderType  `json:"type"`
+	State        OrderState `json:"state"`
+	Timestamp    time.Time  `json:"timestamp"`
+}
+
+// PostOrderRequest is the body of a new order request
+type PostOrderRequest struct {
+	ClientOID    string    `json:"client_oid,omitempty"`
+	Type         OrderType `json:"type"`
+	Side         OrderSide `json:"side"`
+	InstrumentID string    `json:"instrument_id"`
+	// 0 = normal, 1 = post only, 2 = fill or kill, 3 = immediate or cancel
+	OrderType string `json:"order_type"`
+	Price     string `json:"price,omitempty"`
+	Size      string `json:"size"`
+}
+
+// OrderResponse is the result of placing or canceling an order
+type OrderResponse struct {
+	OrderID      string    `json:"order_id"`
+	ClientOID    string    `json:"client_oid"`
+	Result       bool      `json:"result"`
+	ErrorCode    ErrorCode `json:"error_code"`
+	ErrorMessage string    `json:"error_message"`
+}
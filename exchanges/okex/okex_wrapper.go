@@ -0,0 +1,432 @@
+package okex
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+	"github.com/shopspring/decimal"
+)
+
+var _ exchange.IExchange = (*OKEx)(nil)
+var _ exchange.ContextExchange = (*OKEx)(nil)
+
+// New returns a OKEx exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+// The API key passphrase must be set with exchange.WithClientID to use the authenticated API.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *OKEx {
+	o := &OKEx{}
+	o.SetDefaults()
+	o.Quickstart(o.Setup, apiKey, apiSecret, opts...)
+	return o
+}
+
+// SetDefaults sets the basic defaults for OKEx
+func (o *OKEx) SetDefaults() {
+	o.Name = "OKEx"
+	o.APIUrl = okexBaseURL
+	o.Enabled = false
+	o.Verbose = false
+	o.Websocket = false
+	o.RESTPollingDelay = 10
+	o.TakerFee = 0.15
+	o.MakerFee = 0.1
+	o.RequestCurrencyPairFormat.Delimiter = "-"
+	o.RequestCurrencyPairFormat.Uppercase = true
+	o.ConfigCurrencyPairFormat.Delimiter = "-"
+	o.ConfigCurrencyPairFormat.Uppercase = true
+	o.AssetTypes = []string{ticker.Spot}
+	o.Orderbooks = orderbook.Init()
+}
+
+// Setup takes in the supplied exchange configuration details and sets params
+func (o *OKEx) Setup(exch config.ExchangeConfig) {
+	if !exch.Enabled {
+		o.SetEnabled(false)
+	} else {
+		o.Enabled = true
+		o.AuthenticatedAPISupport = exch.AuthenticatedAPISupport
+		o.SetAPIKeys(exch.APIKey, exch.APISecret, exch.ClientID, false)
+		o.RESTPollingDelay = exch.RESTPollingDelay
+		o.Verbose = exch.Verbose
+		o.Websocket = exch.Websocket
+		o.SetAPIURL(exch)
+		o.BaseCurrencies = common.SplitStrings(exch.BaseCurrencies, ",")
+		o.AvailablePairs = common.SplitStrings(exch.AvailablePairs, ",")
+		o.EnabledPairs = common.SplitStrings(exch.EnabledPairs, ",")
+		err := o.SetCurrencyPairFormat()
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = o.SetAssetTypes()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// Start starts the OKEx go routine
+func (o *OKEx) Start() {
+	go o.Run()
+}
+
+// Run implements the OKEx wrapper
+func (o *OKEx) Run() {
+	if o.Debug("") {
+		log.Printf("%s polling delay: %ds.\n", o.GetName(), o.RESTPollingDelay)
+		log.Printf("%s %d currencies enabled: %s.\n", o.GetName(), len(o.EnabledPairs), o.EnabledPairs)
+	}
+
+	instruments, err := o.FetchInstruments()
+	if err != nil {
+		log.Printf("%s failed to get instruments\n", o.GetName())
+		return
+	}
+	o.setInstruments(instruments)
+
+	exchangeProducts := make([]string, len(instruments))
+	for i := range instruments {
+		exchangeProducts[i] = instruments[i].InstrumentID
+	}
+	err = o.UpdateAvailableCurrencies(exchangeProducts, false)
+	if err != nil {
+		log.Printf("%s failed to update available currencies\n", o.Name)
+	}
+}
+
+// setInstruments replaces the currency pairs & trading rules of the exchange
+func (o *OKEx) setInstruments(instruments []Instrument) {
+	o.symbolCache.Reset()
+	o.currencyPairs = make(map[pair.CurrencyItem]*exchange.CurrencyPairInfo, len(instruments))
+	o.symbolDetailsMap = make(map[pair.CurrencyItem]*symbolDetails, len(instruments))
+	for i := range instruments {
+		instrument := &instruments[i]
+		currencyPair := pair.NewCurrencyPairDelimiter(instrument.InstrumentID, "-")
+		o.currencyPairs[pair.CurrencyItem(instrument.InstrumentID)] = &exchange.CurrencyPairInfo{
+			Currency:           currencyPair,
+			FirstCurrencyName:  instrument.BaseCurrency,
+			SecondCurrencyName: instrument.QuoteCurrency,
+		}
+		minAmount, _ := instrument.MinSize.Float64()
+		o.symbolDetailsMap[currencyPair.Display("/", false)] = &symbolDetails{
+			PriceDecimalPlaces:  decimalPlaces(instrument.TickSize),
+			AmountDecimalPlaces: decimalPlaces(instrument.SizeIncrement),
+			MinAmount:           minAmount,
+		}
+	}
+}
+
+// decimalPlaces returns the number of decimal places of an increment, e.g. 2 for 0.01
+func decimalPlaces(increment decimal.Decimal) int32 {
+	if increment.Sign() <= 0 {
+		return -1
+	}
+	places := int32(0)
+	for !increment.Equal(increment.Truncate(places)) {
+		places++
+	}
+	return places
+}
+
+// UpdateTicker updates and returns the ticker for a currency pair
+func (o *OKEx) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return o.UpdateTickerContext(context.Background(), p, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (o *OKEx) UpdateTickerContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
+	var tickerPrice ticker.Price
+	tick, err := o.fetchTicker(ctx, o.CurrencyPairToSymbol(p))
+	if err != nil {
+		return tickerPrice, err
+	}
+	tickerPrice.Pair = p
+	tickerPrice.Ask = tick.BestAsk
+	tickerPrice.Bid = tick.BestBid
+	tickerPrice.Last = tick.Last
+	tickerPrice.High = tick.High24h
+	tickerPrice.Low = tick.Low24h
+	tickerPrice.Volume = tick.BaseVolume24h
+	tickerPrice.LastUpdated = tick.Timestamp
+	ticker.ProcessTicker(o.GetName(), p, tickerPrice, assetType)
+	return ticker.GetTicker(o.Name, p, assetType)
+}
+
+// GetTickerPrice returns the ticker for a currency pair
+func (o *OKEx) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	tick, err := ticker.GetTicker(o.GetName(), p, assetType)
+	if err != nil {
+		return o.UpdateTicker(p, assetType)
+	}
+	return tick, nil
+}
+
+// GetOrderbookEx returns the orderbook for a currency pair
+func (o *OKEx) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
+	ob, err := o.Orderbooks.GetOrderbook(o.GetName(), p, assetType)
+	if err != nil {
+		return o.UpdateOrderbook(p, assetType)
+	}
+	return ob, nil
+}
+
+// UpdateOrderbook updates and returns the orderbook for a currency pair
+func (o *OKEx) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return o.UpdateOrderbookContext(context.Background(), p, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (o *OKEx) UpdateOrderbookContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
+	book := orderbook.Base{}
+	depth, err := o.fetchBook(ctx, o.CurrencyPairToSymbol(p), okexDefaultBookSize)
+	if err != nil {
+		return book, err
+	}
+
+	book.Asks = orderbook.GetItems(len(depth.Asks))
+	for x := range depth.Asks {
+		book.Asks = append(book.Asks, orderbook.Item{
+			Price:  depth.Asks[x].Price,
+			Amount: depth.Asks[x].Size,
+		})
+	}
+
+	book.Bids = orderbook.GetItems(len(depth.Bids))
+	for x := range depth.Bids {
+		book.Bids = append(book.Bids, orderbook.Item{
+			Price:  depth.Bids[x].Price,
+			Amount: depth.Bids[x].Size,
+		})
+	}
+
+	o.Orderbooks.ProcessOrderbook(o.Name, p, book, assetType)
+	return o.Orderbooks.GetOrderbook(o.Name, p, assetType)
+}
+
+// GetExchangeAccountInfo retrieves balances for all enabled currencies on the
+// OKEx exchange
+func (o *OKEx) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return o.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (o *OKEx) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
+	result := exchange.AccountInfo{}
+	result.ExchangeName = o.Name
+
+	if !o.Enabled {
+		return result, nil
+	}
+
+	accounts, err := o.fetchAccounts(ctx)
+	if err != nil {
+		return result, err
+	}
+	result.Currencies = make([]exchange.AccountCurrencyInfo, len(accounts))
+	for i, src := range accounts {
+		dest := &result.Currencies[i]
+		dest.CurrencyName = strings.ToUpper(src.Currency)
+		dest.Hold = src.Hold
+		dest.Available = src.Available
+		dest.TotalValue = src.Balance
+	}
+	return result, nil
+}
+
+// NewOrder creates a new order on the exchange.
+// Returns the ID of the new exchange order.
+func (o *OKEx) NewOrder(p pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return o.NewOrderContext(context.Background(), p, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (o *OKEx) NewOrderContext(ctx context.Context, p pair.CurrencyPair, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	if err := o.GetCapabilities().CheckOrderOptions(opts...); err != nil {
+		return "", err
+	}
+	if orderType != exchange.OrderTypeExchangeLimit {
+		panic("not implemented")
+	}
+	result, err := o.postOrder(ctx, &PostOrderRequest{
+		Type:         OrderTypeLimit,
+		Side:         OrderSide(side),
+		InstrumentID: o.CurrencyPairToSymbol(p),
+		OrderType:    okexOrderTypeNormal,
+		Price:        decimal.NewFromFloat(price).String(),
+		Size:         decimal.NewFromFloat(amount).String(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.OrderID, nil
+}
+
+// CancelOrder will attempt to cancel the active order matching the given ID.
+func (o *OKEx) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return o.CancelOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (o *OKEx) CancelOrderContext(ctx context.Context, orderID string, currencyPair pair.CurrencyPair) error {
+	return o.deleteOrder(ctx, o.CurrencyPairToSymbol(currencyPair), orderID)
+}
+
+// GetOrder returns information about a previously placed order (which may be active or inactive).
+func (o *OKEx) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	return o.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (o *OKEx) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	order, err := o.fetchOrder(ctx, o.CurrencyPairToSymbol(currencyPair), orderID)
+	if err != nil {
+		return nil, err
+	}
+	return o.convertOrderToExchangeOrder(order), nil
+}
+
+// GetOrders returns information about currently active orders, OKEx requires the pairs to be
+// specified so the orders of all the enabled pairs are returned if no pairs are given.
+func (o *OKEx) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return o.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (o *OKEx) GetOrdersContext(ctx context.Context, pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	if len(pairs) == 0 {
+		pairs = o.GetEnabledCurrencies()
+	}
+	ret := []*exchange.Order{}
+	for _, p := range pairs {
+		orders, err := o.fetchOpenOrders(ctx, o.CurrencyPairToSymbol(p))
+		if err != nil {
+			return nil, err
+		}
+		for i := range orders {
+			ret = append(ret, o.convertOrderToExchangeOrder(&orders[i]))
+		}
+	}
+	return ret, nil
+}
+
+func (o *OKEx) convertOrderToExchangeOrder(order *Order) *exchange.Order {
+	retOrder := &exchange.Order{}
+	retOrder.OrderID = order.OrderID
+
+	switch order.State {
+	case OrderStateFailed, OrderStateCanceled, OrderStatePendingCancel:
+		retOrder.Status = exchange.OrderStatusAborted
+	case OrderStateFilled:
+		retOrder.Status = exchange.OrderStatusFilled
+	case OrderStateOpen, OrderStatePartial, OrderStateSubmitting:
+		retOrder.Status = exchange.OrderStatusActive
+	default:
+		retOrder.Status = exchange.OrderStatusUnknown
+	}
+
+	retOrder.Amount = order.Size
+	retOrder.FilledAmount = order.FilledSize
+	retOrder.RemainingAmount, _ = decimal.NewFromFloat(order.Size).Sub(decimal.NewFromFloat(order.FilledSize)).Float64()
+	retOrder.Rate = order.Price
+	retOrder.CreatedAt = order.Timestamp.Unix()
+	if p, err := o.SymbolToCurrencyPair(order.InstrumentID); err == nil {
+		retOrder.CurrencyPair = p
+	} else {
+		retOrder.CurrencyPair = pair.NewCurrencyPairDelimiter(order.InstrumentID, "-")
+	}
+	retOrder.Side = exchange.OrderSide(order.Side)
+	if order.Type == OrderTypeLimit {
+		retOrder.Type = exchange.OrderTypeExchangeLimit
+	} else {
+		log.Printf("OKEx.convertOrderToExchangeOrder(): unexpected '%s' order", order.Type)
+	}
+
+	return retOrder
+}
+
+// GetLimits returns price/amount limits for the exchange.
+func (o *OKEx) GetLimits() exchange.ILimits {
+	return newCurrencyLimits(o.Name, o.symbolDetailsMap)
+}
+
+// GetCurrencyPairs returns currency pairs that can be used by the exchange account
+// associated with this bot. Use FormatExchangeCurrency to get the right key.
+func (o *OKEx) GetCurrencyPairs() map[pair.CurrencyItem]*exchange.CurrencyPairInfo {
+	return o.currencyPairs
+}
+
+// ListInstruments returns the symbols that are currently trading on the exchange
+func (o *OKEx) ListInstruments() ([]exchange.Instrument, error) {
+	instruments, err := o.FetchInstruments()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]exchange.Instrument, len(instruments))
+	for i := range instruments {
+		result[i] = exchange.Instrument{
+			Symbol: instruments[i].InstrumentID,
+			Pair:   pair.NewCurrencyPair(instruments[i].BaseCurrency, instruments[i].QuoteCurrency),
+		}
+	}
+	return result, nil
+}
+
+type symbolDetails struct {
+	PriceDecimalPlaces  int32
+	AmountDecimalPlaces int32
+	MinAmount           float64
+}
+
+type currencyLimits struct {
+	exchangeName string
+	// Maps symbol (lower-case) to symbol details
+	data map[pair.CurrencyItem]*symbolDetails
+}
+
+func newCurrencyLimits(exchangeName string, data map[pair.CurrencyItem]*symbolDetails) *currencyLimits {
+	return &currencyLimits{exchangeName, data}
+}
+
+// Returns max number of decimal places allowed in the trade price for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetPriceDecimalPlaces(p pair.CurrencyPair) int32 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.PriceDecimalPlaces
+	}
+	return -1
+}
+
+// Returns max number of decimal places allowed in the trade amount for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetAmountDecimalPlaces(p pair.CurrencyPair) int32 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.AmountDecimalPlaces
+	}
+	return -1
+}
+
+// Returns the minimum trade amount for the given currency pair.
+func (cl *currencyLimits) GetMinAmount(p pair.CurrencyPair) float64 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.MinAmount
+	}
+	return 0
+}
+
+// Returns the minimum trade total (amount * price) for the given currency pair, OKEx only limits
+// the order amount.
+func (cl *currencyLimits) GetMinTotal(p pair.CurrencyPair) float64 {
+	return 0
+}
@@ -0,0 +1,50 @@
+package orderbook
+
+// MetricsDepth is the number of price levels on each side of the book used to calculate the
+// orderbook imbalance.
+const MetricsDepth = 5
+
+// Metrics holds standard signals derived from the top of an orderbook.
+type Metrics struct {
+	// WeightedMid is the mid price weighted by the amounts at the best bid & ask, this leans
+	// towards the side with less liquidity (where the price is more likely to move).
+	WeightedMid float64 `json:"WeightedMid"`
+	// Imbalance is (bid volume - ask volume) / (bid volume + ask volume) over the top
+	// MetricsDepth levels of the book, it ranges from -1 (all asks) to 1 (all bids).
+	Imbalance float64 `json:"Imbalance"`
+}
+
+// CalculateMetrics calculates the metrics of an orderbook, the bids are expected to be sorted
+// from highest to lowest price, and the asks from lowest to highest price.
+// Returns false if either side of the book is empty.
+func CalculateMetrics(o *Base) (Metrics, bool) {
+	var m Metrics
+	if len(o.Bids) == 0 || len(o.Asks) == 0 {
+		return m, false
+	}
+
+	bid, ask := o.Bids[0], o.Asks[0]
+	if bid.Amount+ask.Amount > 0 {
+		m.WeightedMid = (bid.Price*ask.Amount + ask.Price*bid.Amount) / (bid.Amount + ask.Amount)
+	} else {
+		m.WeightedMid = (bid.Price + ask.Price) / 2
+	}
+
+	bidVolume := sumAmounts(o.Bids, MetricsDepth)
+	askVolume := sumAmounts(o.Asks, MetricsDepth)
+	if bidVolume+askVolume > 0 {
+		m.Imbalance = (bidVolume - askVolume) / (bidVolume + askVolume)
+	}
+	return m, true
+}
+
+func sumAmounts(items []Item, depth int) float64 {
+	if len(items) > depth {
+		items = items[:depth]
+	}
+	var total float64
+	for i := range items {
+		total += items[i].Amount
+	}
+	return total
+}
@@ -0,0 +1,40 @@
+package orderbook
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateMetrics(t *testing.T) {
+	t.Parallel()
+	base := Base{
+		Bids: []Item{{Price: 99, Amount: 3}, {Price: 98, Amount: 1}},
+		Asks: []Item{{Price: 101, Amount: 1}, {Price: 102, Amount: 1}, {Price: 103, Amount: 1},
+			{Price: 104, Amount: 1}, {Price: 105, Amount: 1}, {Price: 106, Amount: 10}},
+	}
+
+	m, ok := CalculateMetrics(&base)
+	if !ok {
+		t.Fatal("Test failed. Expected metrics to be calculated")
+	}
+	// (99*1 + 101*3) / 4
+	if math.Abs(m.WeightedMid-100.5) > 1e-9 {
+		t.Errorf("Test failed. Expected weighted mid 100.5, got %f", m.WeightedMid)
+	}
+	// only the top 5 asks are counted: (4 - 5) / (4 + 5)
+	if math.Abs(m.Imbalance-(-1.0/9)) > 1e-9 {
+		t.Errorf("Test failed. Expected imbalance %f, got %f", -1.0/9, m.Imbalance)
+	}
+
+	base.Bids[0].Amount = 0
+	base.Asks[0].Amount = 0
+	m, _ = CalculateMetrics(&base)
+	if m.WeightedMid != 100 {
+		t.Errorf("Test failed. Expected plain mid 100 without top of book amounts, got %f",
+			m.WeightedMid)
+	}
+
+	if _, ok := CalculateMetrics(&Base{Bids: base.Bids}); ok {
+		t.Error("Test failed. Expected no metrics for a one-sided book")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/asset"
 )
 
 // Const values for orderbook package
@@ -15,7 +16,12 @@ const (
 	ErrPrimaryCurrencyNotFound      = "Error primary currency for orderbook not found."
 	ErrSecondaryCurrencyNotFound    = "Error secondary currency for orderbook not found."
 
-	Spot = "SPOT"
+	// Asset types the orderbooks are keyed by, see the asset package
+	Spot          = asset.Spot
+	Margin        = asset.Margin
+	Futures       = asset.Futures
+	PerpetualSwap = asset.PerpetualSwap
+	Options       = asset.Options
 )
 
 // CalculateTotalBids returns the total amount of bids and the total orderbook
@@ -49,6 +55,59 @@ func (o *Base) Update(Bids, Asks []Item) {
 	o.LastUpdated = time.Now()
 }
 
+// Reuses the bid & ask slices of orderbooks that are no longer referenced to reduce GC pressure
+// when polling many orderbooks.
+var itemPool sync.Pool
+
+// GetItems returns an empty slice with at least the given capacity, the slice may have been
+// previously released via PutItems or Base.Release.
+func GetItems(capacity int) []Item {
+	if v := itemPool.Get(); v != nil {
+		items := *(v.(*[]Item))
+		if cap(items) >= capacity {
+			return items[:0]
+		}
+	}
+	return make([]Item, 0, capacity)
+}
+
+// PutItems releases a slice back to the pool so that it can be reused by GetItems, the slice
+// must not be used after it has been released.
+func PutItems(items []Item) {
+	if cap(items) == 0 {
+		return
+	}
+	items = items[:0]
+	itemPool.Put(&items)
+}
+
+// Release returns the bids & asks of the orderbook to the pool, it should only be called when
+// nothing else references the orderbook slices.
+func (o *Base) Release() {
+	PutItems(o.Bids)
+	PutItems(o.Asks)
+	o.Bids = nil
+	o.Asks = nil
+}
+
+// sharesItems returns true if both slices share the same backing array
+func sharesItems(a, b []Item) bool {
+	return cap(a) > 0 && cap(b) > 0 && &a[:cap(a)][cap(a)-1] == &b[:cap(b)][cap(b)-1]
+}
+
+// Clone returns a copy of the orderbook that doesn't share the bid & ask slices, the copy can be
+// released independently of the orderbook.
+func (o *Base) Clone() Base {
+	c := *o
+	if o.Bids != nil {
+		c.Bids = append(GetItems(len(o.Bids)), o.Bids...)
+	}
+	if o.Asks != nil {
+		c.Asks = append(GetItems(len(o.Asks)), o.Asks...)
+	}
+	return c
+}
+
 // Stores the order books, and provides helper methods
 type Orderbooks struct {
 	m          sync.Mutex
@@ -71,7 +130,8 @@ type Base struct {
 }
 
 // GetOrderbook checks and returns the orderbook given an exchange name and
-// currency pair if it exists
+// currency pair if it exists. The returned orderbook is a copy that can be released once the
+// caller is done with it.
 func (o *Orderbooks) GetOrderbook(_ string, p pair.CurrencyPair, orderbookType string) (Base, error) {
 	o.m.Lock()
 	defer o.m.Unlock()
@@ -87,7 +147,8 @@ func (o *Orderbooks) GetOrderbook(_ string, p pair.CurrencyPair, orderbookType s
 		return Base{}, err
 	}
 
-	return o.orderbooks[fp.GetFirstCurrency()][fp.GetSecondCurrency()][orderbookType], nil
+	ob := o.orderbooks[fp.GetFirstCurrency()][fp.GetSecondCurrency()][orderbookType]
+	return ob.Clone(), nil
 }
 
 // FirstCurrencyExists checks to see if the first currency of the orderbook map
@@ -113,7 +174,9 @@ func (o *Orderbooks) SecondCurrencyExists(p pair.CurrencyPair) bool {
 }
 
 // ProcessOrderbook processes incoming orderbooks, creating or updating the
-// Orderbook list
+// Orderbook list. The bid & ask slices of the new orderbook are owned by the
+// Orderbooks from then on and must not be modified or released by the caller,
+// the slices of the orderbook it replaces are released.
 func (o *Orderbooks) ProcessOrderbook(_ string, p pair.CurrencyPair, orderbookNew Base, orderbookType string) {
 	o.m.Lock()
 	defer o.m.Unlock()
@@ -131,6 +194,11 @@ func (o *Orderbooks) ProcessOrderbook(_ string, p pair.CurrencyPair, orderbookNe
 			o.orderbooks[fp.FirstCurrency][fp.SecondCurrency] = b
 			return
 		} else {
+			if old, ok := o.orderbooks[fp.FirstCurrency][fp.SecondCurrency][orderbookType]; ok {
+				if !sharesItems(old.Bids, orderbookNew.Bids) && !sharesItems(old.Asks, orderbookNew.Asks) {
+					old.Release()
+				}
+			}
 			o.orderbooks[fp.FirstCurrency][fp.SecondCurrency][orderbookType] = orderbookNew
 			return
 		}
@@ -154,8 +222,7 @@ func (o *Orderbooks) formatCurrency(currency pair.CurrencyItem) pair.CurrencyIte
 
 // Init creates a new set of Orderbooks
 func Init() Orderbooks {
-	obs := Orderbooks{}
-	obs.m = sync.Mutex{}
-	obs.orderbooks = make(map[pair.CurrencyItem]map[pair.CurrencyItem]map[string]Base)
-	return obs
+	return Orderbooks{
+		orderbooks: make(map[pair.CurrencyItem]map[pair.CurrencyItem]map[string]Base),
+	}
 }
@@ -81,7 +81,7 @@ func TestGetOrderbook(t *testing.T) {
 	}
 
 	o := Init()
-	o.CreateNewOrderbook("Exchange", currency, base, Spot)
+	o.ProcessOrderbook("Exchange", currency, base, Spot)
 
 	result, err := o.GetOrderbook("Exchange", currency, Spot)
 	if err != nil {
@@ -93,11 +93,6 @@ func TestGetOrderbook(t *testing.T) {
 		t.Fatal("Test failed. TestGetOrderbook failed. Mismatched pairs")
 	}
 
-	_, err = o.GetOrderbook("nonexistent", currency, Spot)
-	if err == nil {
-		t.Fatal("Test failed. TestGetOrderbook retrieved non-existent orderbook")
-	}
-
 	currency.FirstCurrency = "blah"
 	_, err = o.GetOrderbook("Exchange", currency, Spot)
 	if err == nil {
@@ -111,30 +106,6 @@ func TestGetOrderbook(t *testing.T) {
 	}
 }
 
-func TestGetOrderbookByExchange(t *testing.T) {
-	currency := pair.NewCurrencyPair("BTC", "USD")
-	base := Base{
-		Pair:         currency,
-		CurrencyPair: currency.Pair().String(),
-		Asks:         []Item{Item{Price: 100, Amount: 10}},
-		Bids:         []Item{Item{Price: 200, Amount: 10}},
-	}
-
-	o := Init()
-	o.CreateNewOrderbook("Exchange", currency, base, Spot)
-
-	_, err := o.GetOrderbookByExchange("Exchange")
-	if err != nil {
-		t.Fatalf("Test failed. TestGetOrderbookByExchange failed to get orderbook. Error %s",
-			err)
-	}
-
-	_, err = o.GetOrderbookByExchange("nonexistant")
-	if err == nil {
-		t.Fatal("Test failed. TestGetOrderbookByExchange retrieved non-existant orderbook")
-	}
-}
-
 func TestFirstCurrencyExists(t *testing.T) {
 	currency := pair.NewCurrencyPair("BTC", "AUD")
 	base := Base{
@@ -145,14 +116,14 @@ func TestFirstCurrencyExists(t *testing.T) {
 	}
 
 	o := Init()
-	o.CreateNewOrderbook("Exchange", currency, base, Spot)
+	o.ProcessOrderbook("Exchange", currency, base, Spot)
 
-	if !o.FirstCurrencyExists("Exchange", currency.FirstCurrency) {
+	if !o.FirstCurrencyExists(currency.FirstCurrency) {
 		t.Fatal("Test failed. TestFirstCurrencyExists expected first currency doesn't exist")
 	}
 
 	var item pair.CurrencyItem = "blah"
-	if o.FirstCurrencyExists("Exchange", item) {
+	if o.FirstCurrencyExists(item) {
 		t.Fatal("Test failed. TestFirstCurrencyExists unexpected first currency exists")
 	}
 }
@@ -167,50 +138,18 @@ func TestSecondCurrencyExists(t *testing.T) {
 	}
 
 	o := Init()
-	o.CreateNewOrderbook("Exchange", currency, base, Spot)
+	o.ProcessOrderbook("Exchange", currency, base, Spot)
 
-	if !o.SecondCurrencyExists("Exchange", currency) {
+	if !o.SecondCurrencyExists(currency) {
 		t.Fatal("Test failed. TestSecondCurrencyExists expected first currency doesn't exist")
 	}
 
 	currency.SecondCurrency = "blah"
-	if o.SecondCurrencyExists("Exchange", currency) {
+	if o.SecondCurrencyExists(currency) {
 		t.Fatal("Test failed. TestSecondCurrencyExists unexpected first currency exists")
 	}
 }
 
-func TestCreateNewOrderbook(t *testing.T) {
-	currency := pair.NewCurrencyPair("BTC", "USD")
-	base := Base{
-		Pair:         currency,
-		CurrencyPair: currency.Pair().String(),
-		Asks:         []Item{Item{Price: 100, Amount: 10}},
-		Bids:         []Item{Item{Price: 200, Amount: 10}},
-	}
-
-	o := Init()
-	o.CreateNewOrderbook("Exchange", currency, base, Spot)
-
-	result, err := o.GetOrderbook("Exchange", currency, Spot)
-	if err != nil {
-		t.Fatal("Test failed. TestCreateNewOrderbook failed to create new orderbook")
-	}
-
-	if result.Pair.Pair() != currency.Pair() {
-		t.Fatal("Test failed. TestCreateNewOrderbook result pair is incorrect")
-	}
-
-	a, b := result.CalculateTotalAsks()
-	if a != 10 && b != 1000 {
-		t.Fatal("Test failed. TestCreateNewOrderbook CalculateTotalAsks value is incorrect")
-	}
-
-	a, b = result.CalculateTotalBids()
-	if a != 10 && b != 2000 {
-		t.Fatal("Test failed. TestCreateNewOrderbook CalculateTotalBids value is incorrect")
-	}
-}
-
 func TestProcessOrderbook(t *testing.T) {
 	o := Init()
 
@@ -270,3 +209,96 @@ func TestProcessOrderbook(t *testing.T) {
 		t.Fatal("Test failed. TestProcessOrderbook CalculateTotalsBids incorrect values")
 	}
 }
+
+func TestOrderbookItemOwnership(t *testing.T) {
+	o := Init()
+	currency := pair.NewCurrencyPair("BTC", "USD")
+
+	bids := GetItems(1)
+	if len(bids) != 0 || cap(bids) < 1 {
+		t.Fatalf("Test failed. GetItems returned len %d cap %d", len(bids), cap(bids))
+	}
+	bids = append(bids, Item{Price: 200, Amount: 10})
+	o.ProcessOrderbook("Exchange", currency, Base{Bids: bids, Asks: []Item{{Price: 201, Amount: 1}}}, Spot)
+
+	result, err := o.GetOrderbook("Exchange", currency, Spot)
+	if err != nil {
+		t.Fatalf("Test failed. GetOrderbook error: %s", err)
+	}
+	if sharesItems(result.Bids, bids) {
+		t.Error("Test Failed - GetOrderbook should return a copy of the bids")
+	}
+	result.Bids[0].Price = 1
+	result.Release()
+	if result.Bids != nil || result.Asks != nil {
+		t.Error("Test Failed - Release should clear the bids & asks")
+	}
+
+	result, err = o.GetOrderbook("Exchange", currency, Spot)
+	if err != nil {
+		t.Fatalf("Test failed. GetOrderbook error: %s", err)
+	}
+	if len(result.Bids) != 1 || result.Bids[0].Price != 200 {
+		t.Errorf("Test Failed - stored orderbook was modified: %v", result.Bids)
+	}
+
+	// Reprocessing the same slices mustn't release them
+	o.ProcessOrderbook("Exchange", currency, Base{Bids: bids}, Spot)
+	result, _ = o.GetOrderbook("Exchange", currency, Spot)
+	if len(result.Bids) != 1 || result.Bids[0].Price != 200 {
+		t.Errorf("Test Failed - unexpected bids %v", result.Bids)
+	}
+}
+
+func benchmarkOrderbook(p pair.CurrencyPair, depth int) Base {
+	base := Base{Pair: p, CurrencyPair: p.Pair().String()}
+	base.Bids = GetItems(depth)
+	base.Asks = GetItems(depth)
+	for i := 0; i < depth; i++ {
+		base.Bids = append(base.Bids, Item{Price: 1000 - float64(i), Amount: 1})
+		base.Asks = append(base.Asks, Item{Price: 1001 + float64(i), Amount: 1})
+	}
+	return base
+}
+
+func BenchmarkProcessOrderbook(b *testing.B) {
+	p := pair.NewCurrencyPair("BTC", "USD")
+	o := Init()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		o.ProcessOrderbook("Bench", p, benchmarkOrderbook(p, 100), Spot)
+	}
+}
+
+func BenchmarkGetOrderbook(b *testing.B) {
+	p := pair.NewCurrencyPair("BTC", "USD")
+	o := Init()
+	o.ProcessOrderbook("Bench", p, benchmarkOrderbook(p, 100), Spot)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ob, err := o.GetOrderbook("Bench", p, Spot)
+		if err != nil {
+			b.Fatal(err)
+		}
+		ob.Release()
+	}
+}
+
+// TestOrderbookPoolAllocs guards against regressions in the orderbook pooling, once the pool is
+// warm updating & reading an orderbook shouldn't allocate new bid/ask slices.
+func TestOrderbookPoolAllocs(t *testing.T) {
+	p := pair.NewCurrencyPair("BTC", "USD")
+	o := Init()
+	o.ProcessOrderbook("Bench", p, benchmarkOrderbook(p, 100), Spot)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		o.ProcessOrderbook("Bench", p, benchmarkOrderbook(p, 100), Spot)
+		ob, _ := o.GetOrderbook("Bench", p, Spot)
+		ob.Release()
+	})
+	// 100 levels per side would need 4 slice allocations without the pool, the remaining
+	// allocations are the pointers boxed by the pool
+	if allocs > 12 {
+		t.Errorf("Test failed. Expected at most 12 allocs per orderbook update, got %v", allocs)
+	}
+}
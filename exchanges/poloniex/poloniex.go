@@ -2,8 +2,10 @@ package poloniex
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"math"
 	"net/url"
 	"strconv"
 	"strings"
@@ -58,12 +60,30 @@ const (
 type Poloniex struct {
 	exchange.Base
 	currencyPairs map[pair.CurrencyItem]*exchange.CurrencyPairInfo
+	// Open orders tracked by the account notifications of the push API
+	orders orderCache
+	// Orderbooks maintained by the orderbook channels of the push API
+	books bookStreams
+	// Caches the results of the bulk symbol <-> currency pair conversions
+	symbolCache exchange.SymbolCache
+}
+
+// New returns a Poloniex exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *Poloniex {
+	p := &Poloniex{}
+	p.SetDefaults()
+	p.Quickstart(p.Setup, apiKey, apiSecret, opts...)
+	return p
 }
 
 func (p *Poloniex) SetDefaults() {
 	p.Name = "Poloniex"
+	p.APIUrl = POLONIEX_API_URL
 	p.Enabled = false
 	p.Fee = 0
+	p.TakerFee = 0.25
+	p.MakerFee = 0.15
 	p.Verbose = false
 	p.Websocket = false
 	p.RESTPollingDelay = 10
@@ -73,6 +93,7 @@ func (p *Poloniex) SetDefaults() {
 	p.ConfigCurrencyPairFormat.Uppercase = true
 	p.AssetTypes = []string{ticker.Spot}
 	p.Orderbooks = orderbook.Init()
+	p.SetStreams(exchange.StreamOrderbook | exchange.StreamTrades | exchange.StreamOrders)
 }
 
 func (p *Poloniex) Setup(exch config.ExchangeConfig) {
@@ -85,6 +106,7 @@ func (p *Poloniex) Setup(exch config.ExchangeConfig) {
 		p.RESTPollingDelay = exch.RESTPollingDelay
 		p.Verbose = exch.Verbose
 		p.Websocket = exch.Websocket
+		p.SetAPIURL(exch)
 
 		p.Base.CommonSetup(exch)
 		err := p.SetCurrencyPairFormat()
@@ -114,6 +136,20 @@ func (p *Poloniex) SymbolToCurrencyPair(symbol string) pair.CurrencyPair {
 	return cp.Invert()
 }
 
+// CurrencyPairsToSymbols converts a batch of currency pairs to symbols, conversions are cached.
+func (p *Poloniex) CurrencyPairsToSymbols(pairs []pair.CurrencyPair) ([]string, error) {
+	return p.symbolCache.CurrencyPairsToSymbols(pairs, func(cp pair.CurrencyPair) (string, error) {
+		return p.CurrencyPairToSymbol(cp), nil
+	})
+}
+
+// SymbolsToCurrencyPairs converts a batch of symbols to currency pairs, conversions are cached.
+func (p *Poloniex) SymbolsToCurrencyPairs(symbols []string) ([]pair.CurrencyPair, error) {
+	return p.symbolCache.SymbolsToCurrencyPairs(symbols, func(symbol string) (pair.CurrencyPair, error) {
+		return p.SymbolToCurrencyPair(symbol), nil
+	})
+}
+
 // GetLimits returns price/amount limits for the exchange.
 func (p *Poloniex) GetLimits() exchange.ILimits {
 	return &exchange.DefaultExchangeLimits{}
@@ -130,13 +166,17 @@ func (p *Poloniex) GetFee() float64 {
 }
 
 func (p *Poloniex) GetTicker() (map[string]PoloniexTicker, error) {
+	return p.getTicker(context.Background())
+}
+
+func (p *Poloniex) getTicker(ctx context.Context) (map[string]PoloniexTicker, error) {
 	type response struct {
 		Data map[string]PoloniexTicker
 	}
 
 	resp := response{}
-	path := fmt.Sprintf("%s/public?command=returnTicker", POLONIEX_API_URL)
-	err := common.SendHTTPGetRequest(path, true, p.Verbose, &resp.Data)
+	path := fmt.Sprintf("%s/public?command=returnTicker", p.APIUrl)
+	err := common.SendHTTPGetRequestStreamContext(ctx, path, p.Debug(exchange.TraceHTTP), &resp.Data)
 
 	if err != nil {
 		return resp.Data, err
@@ -146,8 +186,8 @@ func (p *Poloniex) GetTicker() (map[string]PoloniexTicker, error) {
 
 func (p *Poloniex) GetVolume() (interface{}, error) {
 	var resp interface{}
-	path := fmt.Sprintf("%s/public?command=return24hVolume", POLONIEX_API_URL)
-	err := common.SendHTTPGetRequest(path, true, p.Verbose, &resp)
+	path := fmt.Sprintf("%s/public?command=return24hVolume", p.APIUrl)
+	err := common.SendHTTPGetRequest(path, true, p.Debug(exchange.TraceHTTP), &resp)
 
 	if err != nil {
 		return resp, err
@@ -156,6 +196,11 @@ func (p *Poloniex) GetVolume() (interface{}, error) {
 }
 
 func (p *Poloniex) GetOrderbook(currencyPair string, depth int) (PoloniexOrderbook, error) {
+	return p.getOrderbook(context.Background(), currencyPair, depth)
+}
+
+func (p *Poloniex) getOrderbook(ctx context.Context, currencyPair string,
+	depth int) (PoloniexOrderbook, error) {
 	vals := url.Values{}
 	vals.Set("currencyPair", currencyPair)
 
@@ -164,14 +209,47 @@ func (p *Poloniex) GetOrderbook(currencyPair string, depth int) (PoloniexOrderbo
 	}
 
 	resp := PoloniexOrderbookResponse{}
-	path := fmt.Sprintf("%s/public?command=returnOrderBook&%s", POLONIEX_API_URL, vals.Encode())
-	err := common.SendHTTPGetRequest(path, true, p.Verbose, &resp)
+	path := fmt.Sprintf("%s/public?command=returnOrderBook&%s", p.APIUrl, vals.Encode())
+	err := common.SendHTTPGetRequestStreamContext(ctx, path, p.Debug(exchange.TraceHTTP), &resp)
 
 	if err != nil {
 		return PoloniexOrderbook{}, err
 	}
 
-	ob := PoloniexOrderbook{}
+	return convertOrderbookResponse(&resp)
+}
+
+// GetAllOrderbooks returns the orderbooks of all markets (keyed by symbol) using a single request.
+func (p *Poloniex) GetAllOrderbooks(depth int) (map[string]PoloniexOrderbook, error) {
+	vals := url.Values{}
+	vals.Set("currencyPair", "all")
+
+	if depth != 0 {
+		vals.Set("depth", strconv.Itoa(depth))
+	}
+
+	resp := map[string]PoloniexOrderbookResponse{}
+	path := fmt.Sprintf("%s/public?command=returnOrderBook&%s", p.APIUrl, vals.Encode())
+	err := common.SendHTTPGetRequest(path, true, p.Debug(exchange.TraceHTTP), &resp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	orderbooks := make(map[string]PoloniexOrderbook, len(resp))
+	for symbol := range resp {
+		r := resp[symbol]
+		ob, err := convertOrderbookResponse(&r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s orderbook: %s", symbol, err)
+		}
+		orderbooks[symbol] = ob
+	}
+	return orderbooks, nil
+}
+
+func convertOrderbookResponse(resp *PoloniexOrderbookResponse) (PoloniexOrderbook, error) {
+	ob := PoloniexOrderbook{Seq: resp.Seq}
 	for x := range resp.Asks {
 		data := resp.Asks[x]
 		price, err := strconv.ParseFloat(data[0].(string), 64)
@@ -207,8 +285,8 @@ func (p *Poloniex) GetTradeHistory(currencyPair, start, end string) ([]PoloniexT
 	}
 
 	resp := []PoloniexTradeHistory{}
-	path := fmt.Sprintf("%s/public?command=returnTradeHistory&%s", POLONIEX_API_URL, vals.Encode())
-	err := common.SendHTTPGetRequest(path, true, p.Verbose, &resp)
+	path := fmt.Sprintf("%s/public?command=returnTradeHistory&%s", p.APIUrl, vals.Encode())
+	err := common.SendHTTPGetRequest(path, true, p.Debug(exchange.TraceHTTP), &resp)
 
 	if err != nil {
 		return nil, err
@@ -233,8 +311,8 @@ func (p *Poloniex) GetChartData(currencyPair, start, end, period string) ([]Polo
 	}
 
 	resp := []PoloniexChartData{}
-	path := fmt.Sprintf("%s/public?command=returnChartData&%s", POLONIEX_API_URL, vals.Encode())
-	err := common.SendHTTPGetRequest(path, true, p.Verbose, &resp)
+	path := fmt.Sprintf("%s/public?command=returnChartData&%s", p.APIUrl, vals.Encode())
+	err := common.SendHTTPGetRequest(path, true, p.Debug(exchange.TraceHTTP), &resp)
 
 	if err != nil {
 		return nil, err
@@ -247,8 +325,8 @@ func (p *Poloniex) GetCurrencies() (map[string]PoloniexCurrencies, error) {
 		Data map[string]PoloniexCurrencies
 	}
 	resp := Response{}
-	path := fmt.Sprintf("%s/public?command=returnCurrencies", POLONIEX_API_URL)
-	err := common.SendHTTPGetRequest(path, true, p.Verbose, &resp.Data)
+	path := fmt.Sprintf("%s/public?command=returnCurrencies", p.APIUrl)
+	err := common.SendHTTPGetRequest(path, true, p.Debug(exchange.TraceHTTP), &resp.Data)
 
 	if err != nil {
 		return resp.Data, err
@@ -258,8 +336,8 @@ func (p *Poloniex) GetCurrencies() (map[string]PoloniexCurrencies, error) {
 
 func (p *Poloniex) GetLoanOrders(currency string) (PoloniexLoanOrders, error) {
 	resp := PoloniexLoanOrders{}
-	path := fmt.Sprintf("%s/public?command=returnLoanOrders&currency=%s", POLONIEX_API_URL, currency)
-	err := common.SendHTTPGetRequest(path, true, p.Verbose, &resp)
+	path := fmt.Sprintf("%s/public?command=returnLoanOrders&currency=%s", p.APIUrl, currency)
+	err := common.SendHTTPGetRequest(path, true, p.Debug(exchange.TraceHTTP), &resp)
 
 	if err != nil {
 		return resp, err
@@ -291,8 +369,13 @@ type PoloniexCompleteBalances struct {
 }
 
 func (p *Poloniex) GetCompleteBalances() (PoloniexCompleteBalances, error) {
+	return p.getCompleteBalances(context.Background())
+}
+
+func (p *Poloniex) getCompleteBalances(ctx context.Context) (PoloniexCompleteBalances, error) {
 	var result interface{}
-	err := p.SendAuthenticatedHTTPRequest("POST", POLONIEX_BALANCES_COMPLETE, url.Values{}, &result)
+	err := p.SendAuthenticatedHTTPRequestContext(ctx, "POST", POLONIEX_BALANCES_COMPLETE, url.Values{},
+		&result)
 
 	if err != nil {
 		return PoloniexCompleteBalances{}, err
@@ -395,11 +478,15 @@ func (p *Poloniex) GetOpenOrders(currency string) (PoloniexOpenOrdersResponse, e
 }
 
 func (p *Poloniex) GetAllOpenOrders() (PoloniexOpenOrdersResponseAll, error) {
+	return p.getAllOpenOrders(context.Background())
+}
+
+func (p *Poloniex) getAllOpenOrders(ctx context.Context) (PoloniexOpenOrdersResponseAll, error) {
 	values := url.Values{}
 
 	values.Set("currencyPair", "all")
 	result := PoloniexOpenOrdersResponseAll{}
-	err := p.SendAuthenticatedHTTPRequest("POST", POLONIEX_ORDERS, values, &result.Data)
+	err := p.SendAuthenticatedHTTPRequestContext(ctx, "POST", POLONIEX_ORDERS, values, &result.Data)
 
 	if err != nil {
 		return result, err
@@ -443,10 +530,15 @@ func (p *Poloniex) GetAuthenticatedTradeHistory(currency, start, end string) (in
 }
 
 func (p *Poloniex) GetOrderTrades(orderID string) (PoloniexAuthentictedOrderTradesResponse, error) {
+	return p.getOrderTrades(context.Background(), orderID)
+}
+
+func (p *Poloniex) getOrderTrades(ctx context.Context,
+	orderID string) (PoloniexAuthentictedOrderTradesResponse, error) {
 	result := PoloniexAuthentictedOrderTradesResponse{}
 	values := url.Values{}
 	values.Set("orderNumber", orderID)
-	err := p.SendAuthenticatedHTTPRequest("POST", POLONIEX_ORDER_TRADES, values, &result.Data)
+	err := p.SendAuthenticatedHTTPRequestContext(ctx, "POST", POLONIEX_ORDER_TRADES, values, &result.Data)
 
 	if err != nil {
 		return result, err
@@ -456,6 +548,11 @@ func (p *Poloniex) GetOrderTrades(orderID string) (PoloniexAuthentictedOrderTrad
 }
 
 func (p *Poloniex) PlaceOrder(currency string, rate, amount float64, immediate, fillOrKill bool, orderType exchange.OrderSide) (PoloniexOrderResponse, error) {
+	return p.placeOrder(context.Background(), currency, rate, amount, immediate, fillOrKill, orderType)
+}
+
+func (p *Poloniex) placeOrder(ctx context.Context, currency string, rate, amount float64, immediate,
+	fillOrKill bool, orderType exchange.OrderSide) (PoloniexOrderResponse, error) {
 	result := PoloniexOrderResponse{}
 	values := url.Values{}
 
@@ -471,7 +568,7 @@ func (p *Poloniex) PlaceOrder(currency string, rate, amount float64, immediate,
 		values.Set("fillOrKill", "1")
 	}
 
-	err := p.SendAuthenticatedHTTPRequest("POST", string(orderType), values, &result)
+	err := p.SendAuthenticatedHTTPRequestContext(ctx, "POST", string(orderType), values, &result)
 
 	if err != nil {
 		return result, err
@@ -481,10 +578,16 @@ func (p *Poloniex) PlaceOrder(currency string, rate, amount float64, immediate,
 }
 
 func (p *Poloniex) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
-	response, err := p.GetOrderTrades(orderID)
+	return p.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (p *Poloniex) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	response, err := p.getOrderTrades(ctx, orderID)
 	if err != nil {
 		if strings.HasPrefix(strings.ToLower(err.Error()), "order not found") {
-			return nil, errors.New(exchange.ErrOrderNotFound)
+			return nil, exchange.ErrOrderNotFound
 		}
 		return nil, err
 	}
@@ -537,7 +640,8 @@ func (p *Poloniex) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*ex
 	return order, nil
 }
 
-func (p *Poloniex) convertOrderToExchangeOrder(order *PoloniexOrder, symbol string) *exchange.Order {
+func (p *Poloniex) convertOrderToExchangeOrder(order *PoloniexOrder,
+	currencyPair pair.CurrencyPair) *exchange.Order {
 	ll := log.WithField("exchange", p.Name).WithField("orderID", order.OrderNumber)
 
 	retOrder := &exchange.Order{}
@@ -562,24 +666,43 @@ func (p *Poloniex) convertOrderToExchangeOrder(order *PoloniexOrder, symbol stri
 	} else {
 		retOrder.CreatedAt = orderDate.Unix()
 	}
-	retOrder.CurrencyPair = p.SymbolToCurrencyPair(symbol)
+	retOrder.CurrencyPair = currencyPair
 	retOrder.Side = exchange.OrderSide(order.Type) //no conversion neccessary this exchange uses the word buy/sell
 
 	return retOrder
 }
 
+// GetOrders returns the open orders of the account, the orders tracked by the account
+// notifications are returned instead of polling the exchange while the push API is connected.
 func (p *Poloniex) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return p.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (p *Poloniex) GetOrdersContext(ctx context.Context,
+	pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
 	ret := []*exchange.Order{}
 
-	activeorders, err := p.GetAllOpenOrders()
+	activeorders, ok := p.orders.getOpenOrders()
+	if !ok {
+		var err error
+		if activeorders, err = p.getAllOpenOrders(ctx); err != nil {
+			return ret, err
+		}
+	}
+
+	symbols := make([]string, 0, len(activeorders.Data))
+	for symbol := range activeorders.Data {
+		symbols = append(symbols, symbol)
+	}
+	orderPairs, err := p.SymbolsToCurrencyPairs(symbols)
 	if err != nil {
 		return ret, err
 	}
-
-	for symbol, orders := range activeorders.Data {
-		for _, order := range orders {
+	for i, symbol := range symbols {
+		for _, order := range activeorders.Data[symbol] {
 			// TODO: filter out orders that don't match the given pairs
-			retOrder := p.convertOrderToExchangeOrder(order, symbol)
+			retOrder := p.convertOrderToExchangeOrder(order, orderPairs[i])
 			ret = append(ret, retOrder)
 		}
 	}
@@ -588,7 +711,17 @@ func (p *Poloniex) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, erro
 
 func (p *Poloniex) NewOrder(
 	currencyPair pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
-	orderType exchange.OrderType) (string, error) {
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return p.NewOrderContext(context.Background(), currencyPair, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (p *Poloniex) NewOrderContext(ctx context.Context, currencyPair pair.CurrencyPair, amount,
+	price float64, side exchange.OrderSide, orderType exchange.OrderType,
+	opts ...exchange.OrderOptions) (string, error) {
+	if err := p.GetCapabilities().CheckOrderOptions(opts...); err != nil {
+		return "", err
+	}
 	/*
 		You may optionally set "fillOrKill", "immediateOrCancel", "postOnly".
 		- A fill-or-kill order will either fill in its entirety or be completely aborted.
@@ -603,7 +736,7 @@ func (p *Poloniex) NewOrder(
 	fillOrKill := false
 
 	symbol := p.CurrencyPairToSymbol(currencyPair)
-	response, err := p.PlaceOrder(symbol, price, amount, immediate, fillOrKill, side)
+	response, err := p.placeOrder(ctx, symbol, price, amount, immediate, fillOrKill, side)
 
 	if err != nil {
 		return "", err
@@ -619,21 +752,27 @@ func (p *Poloniex) NewOrder(
 }
 
 func (p *Poloniex) CancelOrder(orderstr string, currencyPair pair.CurrencyPair) error {
+	return p.CancelOrderContext(context.Background(), orderstr, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (p *Poloniex) CancelOrderContext(ctx context.Context, orderstr string,
+	currencyPair pair.CurrencyPair) error {
 	var err error
 	var orderID int64
 	if orderID, err = strconv.ParseInt(orderstr, 10, 64); err != nil {
 		return err
 	}
-	_, err = p.cancelOrder(orderID)
+	_, err = p.cancelOrder(ctx, orderID)
 	return err
 }
 
-func (p *Poloniex) cancelOrder(orderID int64) (bool, error) {
+func (p *Poloniex) cancelOrder(ctx context.Context, orderID int64) (bool, error) {
 	result := PoloniexGenericResponse{}
 	values := url.Values{}
 	values.Set("orderNumber", strconv.FormatInt(orderID, 10))
 
-	err := p.SendAuthenticatedHTTPRequest("POST", POLONIEX_ORDER_CANCEL, values, &result)
+	err := p.SendAuthenticatedHTTPRequestContext(ctx, "POST", POLONIEX_ORDER_CANCEL, values, &result)
 
 	if err != nil {
 		return false, err
@@ -690,7 +829,9 @@ func (p *Poloniex) Withdraw(currency, address string, amount float64) (bool, err
 	return true, nil
 }
 
-func (p *Poloniex) GetFeeInfo() (PoloniexFee, error) {
+// GetAccountFeeInfo returns the fee tier & 30 day trade volume of the account, the fees are
+// fractions of the trade value (0.0015 is 0.15%)
+func (p *Poloniex) GetAccountFeeInfo() (PoloniexFee, error) {
 	result := PoloniexFee{}
 	err := p.SendAuthenticatedHTTPRequest("POST", POLONIEX_FEE_INFO, url.Values{}, &result)
 
@@ -938,6 +1079,38 @@ func (p *Poloniex) GetLendingHistory(start, end string) ([]PoloniexLendingHistor
 	return resp, nil
 }
 
+// GetLendingRecords returns the loans that were closed between start & end
+func (p *Poloniex) GetLendingRecords(start, end time.Time) ([]exchange.LendingRecord, error) {
+	history, err := p.GetLendingHistory(strconv.FormatInt(start.Unix(), 10), strconv.FormatInt(end.Unix(), 10))
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]exchange.LendingRecord, 0, len(history))
+	for i := range history {
+		loan := &history[i]
+		record := exchange.LendingRecord{
+			ID:       strconv.FormatInt(loan.ID, 10),
+			Exchange: p.GetName(),
+			Currency: loan.Currency,
+			Amount:   loan.Amount,
+			Rate:     loan.Rate,
+			Interest: loan.Interest,
+			// the fee is reported as a negative amount
+			Fee:    math.Abs(loan.Fee),
+			Earned: loan.Earned,
+		}
+		if record.Opened, err = time.Parse(POLONIEX_TIME_FORMAT, loan.Open); err != nil {
+			return nil, err
+		}
+		if record.Closed, err = time.Parse(POLONIEX_TIME_FORMAT, loan.Close); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
 func (p *Poloniex) ToggleAutoRenew(orderNumber int64) (bool, error) {
 	values := url.Values{}
 	values.Set("orderNumber", strconv.FormatInt(orderNumber, 10))
@@ -957,12 +1130,21 @@ func (p *Poloniex) ToggleAutoRenew(orderNumber int64) (bool, error) {
 }
 
 func (p *Poloniex) SendAuthenticatedHTTPRequest(method, endpoint string, values url.Values, result interface{}) error {
+	return p.SendAuthenticatedHTTPRequestContext(context.Background(), method, endpoint, values, result)
+}
+
+// SendAuthenticatedHTTPRequestContext is SendAuthenticatedHTTPRequest, the request is cancelled
+// when the context is done
+func (p *Poloniex) SendAuthenticatedHTTPRequestContext(ctx context.Context, method, endpoint string,
+	values url.Values, result interface{}) error {
+	apiKey, apiSecret, _ := p.Credentials()
+
 	if !p.AuthenticatedAPISupport {
 		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, p.Name)
 	}
 	headers := make(map[string]string)
 	headers["Content-Type"] = "application/x-www-form-urlencoded"
-	headers["Key"] = p.APIKey
+	headers["Key"] = apiKey
 
 	if p.Nonce.Get() == 0 {
 		p.Nonce.Set(time.Now().UnixNano())
@@ -972,17 +1154,18 @@ func (p *Poloniex) SendAuthenticatedHTTPRequest(method, endpoint string, values
 	values.Set("nonce", p.Nonce.String())
 	values.Set("command", endpoint)
 
-	hmac := common.GetHMAC(common.HashSHA512, []byte(values.Encode()), []byte(p.APISecret))
+	hmac := common.GetHMAC(common.HashSHA512, []byte(values.Encode()), []byte(apiSecret))
 	headers["Sign"] = common.HexEncodeToString(hmac)
 
-	path := fmt.Sprintf("%s/%s", POLONIEX_API_URL, POLONIEX_API_TRADING_ENDPOINT)
-	resp, err := common.SendHTTPRequest(method, path, headers, bytes.NewBufferString(values.Encode()))
+	path := fmt.Sprintf("%s/%s", p.APIUrl, POLONIEX_API_TRADING_ENDPOINT)
+	resp, err := common.SendHTTPRequestContext(ctx, method, path, headers,
+		bytes.NewBufferString(values.Encode()))
 
 	if err != nil {
 		return err
 	}
 
-	if p.Verbose {
+	if p.Debug(exchange.TraceHTTP) {
 		log.Printf("Received raw: %s\n", resp)
 	}
 
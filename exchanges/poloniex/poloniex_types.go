@@ -1,6 +1,7 @@
 package poloniex
 
 type PoloniexTicker struct {
+	ID            int64   `json:"id"` // currency pair ID used by the push API
 	Last          float64 `json:"last,string"`
 	LowestAsk     float64 `json:"lowestAsk,string"`
 	HighestBid    float64 `json:"highestBid,string"`
@@ -16,6 +17,7 @@ type PoloniexOrderbookResponse struct {
 	Asks     [][]interface{} `json:"asks"`
 	Bids     [][]interface{} `json:"bids"`
 	IsFrozen string          `json:"isFrozen"`
+	Seq      int64           `json:"seq"` // sequence number of the last update of the push API channel
 }
 
 type PoloniexOrderbookItem struct {
@@ -26,6 +28,7 @@ type PoloniexOrderbookItem struct {
 type PoloniexOrderbook struct {
 	Asks []PoloniexOrderbookItem `json:"asks"`
 	Bids []PoloniexOrderbookItem `json:"bids"`
+	Seq  int64                   `json:"seq"`
 }
 
 type PoloniexTradeHistory struct {
@@ -248,3 +251,19 @@ type PoloniexLendingHistory struct {
 	Open     string  `json:"open"`
 	Close    string  `json:"close"`
 }
+
+// PoloniexAccountSubscription authenticates the subscription to the account notifications channel
+type PoloniexAccountSubscription struct {
+	Command string `json:"command"`
+	Channel int    `json:"channel"`
+	Key     string `json:"key"`
+	Payload string `json:"payload"`
+	Sign    string `json:"sign"`
+}
+
+// PoloniexPushSubscription subscribes to a public channel of the push API, e.g. the orderbook of
+// a currency pair
+type PoloniexPushSubscription struct {
+	Command string `json:"command"`
+	Channel string `json:"channel"`
+}
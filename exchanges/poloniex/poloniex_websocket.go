@@ -1,10 +1,23 @@
 package poloniex
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"sort"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/beatgammit/turnpike"
+	"github.com/gorilla/websocket"
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/stream"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+	"github.com/shopspring/decimal"
 )
 
 const (
@@ -12,8 +25,31 @@ const (
 	POLONIEX_WEBSOCKET_REALM    = "realm1"
 	POLONIEX_WEBSOCKET_TICKER   = "ticker"
 	POLONIEX_WEBSOCKET_TROLLBOX = "trollbox"
+
+	// Push API, the account notifications aren't published through WAMP
+	POLONIEX_PUSH_ADDRESS         = "wss://api2.poloniex.com"
+	POLONIEX_PUSH_ACCOUNT_CHANNEL = 1000
+	POLONIEX_PUSH_PING_INTERVAL   = 30 * time.Second // The push API also heartbeats every second
+	POLONIEX_PUSH_NEW_ORDER       = "n"
+	POLONIEX_PUSH_ORDER_UPDATE    = "o"
+	POLONIEX_PUSH_ORDER_TYPE_BUY  = 1
+	POLONIEX_PUSH_ORDER_CANCEL    = "c"
+	POLONIEX_PUSH_SUBSCRIBE       = "subscribe"
+	POLONIEX_PUSH_SUBSCRIBE_NONCE = "nonce="
+	POLONIEX_PUSH_HEARTBEAT       = 1010
+	// Orderbook channels, the channel IDs are the currency pair IDs
+	POLONIEX_PUSH_BOOK_SNAPSHOT     = "i"
+	POLONIEX_PUSH_BOOK_UPDATE       = "o"
+	POLONIEX_PUSH_BOOK_TRADE        = "t"
+	POLONIEX_PUSH_BOOK_SIDE_BID     = 1
+	POLONIEX_PUSH_BOOK_MAX_BUFFERED = 1000 // Updates buffered while waiting for a REST snapshot
+	POLONIEX_PUSH_BOOK_DEPTH        = 1000 // Depth of the REST snapshots
 )
 
+// errBookGap is returned when an orderbook update doesn't follow the previous one, the orderbook
+// must be synced with a new snapshot
+var errBookGap = errors.New("orderbook update out of sequence")
+
 type PoloniexWebsocketTicker struct {
 	CurrencyPair  string
 	Last          float64
@@ -133,6 +169,62 @@ func PoloniexOnDepthOrTrade(args []interface{}, kwargs map[string]interface{}) {
 	}
 }
 
+// PoloniexWebsocketEvent is a WAMP event received from the websocket, recorded as the raw frame
+// payload since the frames themselves are handled by turnpike
+type PoloniexWebsocketEvent struct {
+	Topic  string                 `json:"topic"`
+	Args   []interface{}          `json:"args"`
+	Kwargs map[string]interface{} `json:"kwargs,omitempty"`
+}
+
+// websocketHandler returns the handler for the events published to a topic
+func websocketHandler(topic string) turnpike.EventHandler {
+	switch topic {
+	case POLONIEX_WEBSOCKET_TICKER:
+		return PoloniexOnTicker
+	case POLONIEX_WEBSOCKET_TROLLBOX:
+		return PoloniexOnTrollbox
+	default:
+		return PoloniexOnDepthOrTrade
+	}
+}
+
+// websocketSubscribe subscribes to a topic, recording the events received if websocket recording
+// is enabled
+func (p *Poloniex) websocketSubscribe(c *turnpike.Client, topic string) error {
+	return c.Subscribe(topic, func(args []interface{}, kwargs map[string]interface{}) {
+		event := PoloniexWebsocketEvent{Topic: topic, Args: args, Kwargs: kwargs}
+		if data, err := json.Marshal(event); err != nil {
+			log.Printf("%s Unable to record Websocket event. Error: %s\n", p.GetName(), err)
+		} else {
+			p.RecordWebsocketFrame(websocket.TextMessage, data)
+		}
+		if err := handleWebsocketEvent(event); err != nil {
+			log.Printf("%s Unable to handle Websocket event. Error: %s\n", p.GetName(), err)
+		}
+	})
+}
+
+// WebsocketHandleEvent parses a JSON encoded PoloniexWebsocketEvent, a malformed event is
+// returned as an error
+func (p *Poloniex) WebsocketHandleEvent(data []byte) error {
+	var event PoloniexWebsocketEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return err
+	}
+	return handleWebsocketEvent(event)
+}
+
+func handleWebsocketEvent(event PoloniexWebsocketEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed %s event: %v", event.Topic, r)
+		}
+	}()
+	websocketHandler(event.Topic)(event.Args, event.Kwargs)
+	return nil
+}
+
 func (p *Poloniex) WebsocketClient() {
 	for p.Enabled && p.Websocket {
 		c, err := turnpike.NewWebsocketClient(turnpike.JSON, POLONIEX_WEBSOCKET_ADDRESS, nil)
@@ -140,8 +232,9 @@ func (p *Poloniex) WebsocketClient() {
 			log.Printf("%s Unable to connect to Websocket. Error: %s\n", p.GetName(), err)
 			continue
 		}
+		p.CountWebsocketConnection()
 
-		if p.Verbose {
+		if p.Debug(exchange.TraceWebsocket) {
 			log.Printf("%s Connected to Websocket.\n", p.GetName())
 		}
 
@@ -151,28 +244,28 @@ func (p *Poloniex) WebsocketClient() {
 			continue
 		}
 
-		if p.Verbose {
+		if p.Debug(exchange.TraceWebsocket) {
 			log.Printf("%s Joined Websocket realm.\n", p.GetName())
 		}
 
 		c.ReceiveDone = make(chan bool)
 
-		if err := c.Subscribe(POLONIEX_WEBSOCKET_TICKER, PoloniexOnTicker); err != nil {
+		if err := p.websocketSubscribe(c, POLONIEX_WEBSOCKET_TICKER); err != nil {
 			log.Printf("%s Error subscribing to ticker channel: %s\n", p.GetName(), err)
 		}
 
-		if err := c.Subscribe(POLONIEX_WEBSOCKET_TROLLBOX, PoloniexOnTrollbox); err != nil {
+		if err := p.websocketSubscribe(c, POLONIEX_WEBSOCKET_TROLLBOX); err != nil {
 			log.Printf("%s Error subscribing to trollbox channel: %s\n", p.GetName(), err)
 		}
 
 		for x := range p.EnabledPairs {
 			currency := p.EnabledPairs[x]
-			if err := c.Subscribe(currency, PoloniexOnDepthOrTrade); err != nil {
+			if err := p.websocketSubscribe(c, currency); err != nil {
 				log.Printf("%s Error subscribing to %s channel: %s\n", p.GetName(), currency, err)
 			}
 		}
 
-		if p.Verbose {
+		if p.Debug(exchange.TraceWebsocket) {
 			log.Printf("%s Subscribed to websocket channels.\n", p.GetName())
 		}
 
@@ -180,3 +273,624 @@ func (p *Poloniex) WebsocketClient() {
 		log.Printf("%s Websocket client disconnected.\n", p.GetName())
 	}
 }
+
+// orderCache holds the open orders of the account, updated by the account notifications of the
+// push API. It's only used while the push API is connected.
+type orderCache struct {
+	mtx  sync.Mutex
+	live bool
+	// Maps the currency pair IDs used by the push API to symbols
+	pairIDs map[int64]string
+	// Maps order numbers to orders & their symbol
+	orders  map[int64]*PoloniexOrder
+	symbols map[int64]string
+}
+
+func (c *orderCache) setPairIDs(pairIDs map[int64]string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.pairIDs = pairIDs
+}
+
+// getOpenOrders returns a copy of the open orders keyed by symbol, false is returned if the push
+// API isn't connected
+func (c *orderCache) getOpenOrders() (PoloniexOpenOrdersResponseAll, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	result := PoloniexOpenOrdersResponseAll{Data: make(map[string][]*PoloniexOrder)}
+	if !c.live {
+		return result, false
+	}
+	for orderNumber, order := range c.orders {
+		copied := *order
+		symbol := c.symbols[orderNumber]
+		result.Data[symbol] = append(result.Data[symbol], &copied)
+	}
+	return result, true
+}
+
+// seed replaces the cached orders with the open orders fetched from the REST API, and starts
+// serving them
+func (c *orderCache) seed(open PoloniexOpenOrdersResponseAll) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.orders = make(map[int64]*PoloniexOrder)
+	c.symbols = make(map[int64]string)
+	for symbol, orders := range open.Data {
+		for _, order := range orders {
+			c.orders[order.OrderNumber] = order
+			c.symbols[order.OrderNumber] = symbol
+		}
+	}
+	c.live = true
+}
+
+// reset stops serving the cached orders, the REST API is polled until the cache is seeded again
+func (c *orderCache) reset() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.live = false
+	c.orders = nil
+	c.symbols = nil
+}
+
+// addOrder adds an order placed on the pair with the given ID, returns the symbol of the pair or
+// an empty string if the cache isn't live
+func (c *orderCache) addOrder(pairID int64, order *PoloniexOrder) (string, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if !c.live {
+		return "", nil
+	}
+	symbol, ok := c.pairIDs[pairID]
+	if !ok {
+		return "", fmt.Errorf("unknown currency pair ID %d", pairID)
+	}
+	c.orders[order.OrderNumber] = order
+	c.symbols[order.OrderNumber] = symbol
+	return symbol, nil
+}
+
+// updateOrder sets the remaining amount of an order after a fill or cancel, the order is removed
+// once nothing remains. Returns a copy of the updated order & its symbol, false if the order isn't
+// cached.
+func (c *orderCache) updateOrder(orderNumber int64, amount float64, cancelled bool) (PoloniexOrder, string, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	order, ok := c.orders[orderNumber]
+	if !c.live || !ok {
+		return PoloniexOrder{}, "", false
+	}
+	symbol := c.symbols[orderNumber]
+	// Once an order has trades the REST API returns the filled amount as the order amount,
+	// see convertOrderToExchangeOrder. Cancels don't change the filled amount.
+	if !cancelled {
+		order.Amount, _ = decimal.NewFromFloat(order.StartingAmount).Sub(decimal.NewFromFloat(amount)).Float64()
+		order.Total = order.Amount * order.Rate
+	}
+	if amount <= 0 {
+		delete(c.orders, orderNumber)
+		delete(c.symbols, orderNumber)
+	}
+	return *order, symbol, true
+}
+
+// WebsocketAccountClient connects to the push API and subscribes to the account notifications,
+// the orders returned by GetOrders are updated by the notifications instead of being polled while
+// it's connected.
+func (p *Poloniex) WebsocketAccountClient() {
+	var conn *stream.Conn
+	conn = stream.NewConn(stream.Config{
+		URL:               POLONIEX_PUSH_ADDRESS,
+		PingInterval:      POLONIEX_PUSH_PING_INTERVAL,
+		StaleTimeout:      p.WebsocketStaleTimeout(),
+		EnableCompression: p.WebsocketCompression(),
+		OnConnect:         p.websocketAccountConnected,
+		OnMessage: func(msgType int, resp []byte) {
+			if !p.Enabled || !p.Websocket || !p.AuthenticatedAPISupport {
+				conn.Close()
+				return
+			}
+			p.websocketAccountMessage(msgType, resp)
+		},
+		OnStateChange: func(state stream.State, err error) {
+			switch state {
+			case stream.Connected:
+				p.SetWebsocketStale(false)
+			case stream.Stale:
+				// The orders are polled until the connection is re-established
+				p.SetWebsocketStale(true)
+				p.orders.reset()
+				conn.Reconnect()
+			case stream.Disconnected, stream.Closed:
+				// Notifications may be missed until the cache is seeded again
+				p.orders.reset()
+				if err != nil {
+					log.Printf("%s Account notifications error: %s\n", p.GetName(), err)
+				}
+			}
+		},
+	})
+	conn.Run()
+}
+
+// websocketAccountConnected subscribes to the account notifications with a new nonce
+func (p *Poloniex) websocketAccountConnected(conn *stream.Conn) error {
+	p.CountWebsocketConnection()
+
+	if err := conn.Send(p.accountSubscription(time.Now().UnixNano())); err != nil {
+		return err
+	}
+	// The notifications are read once the cache is seeded, so none of them are missed
+	open, err := p.GetAllOpenOrders()
+	if err != nil {
+		return err
+	}
+	p.orders.seed(open)
+	return nil
+}
+
+func (p *Poloniex) websocketAccountMessage(msgType int, resp []byte) {
+	p.RecordWebsocketFrame(msgType, resp)
+	if msgType != websocket.TextMessage {
+		return
+	}
+	if p.Debug(exchange.TraceWebsocket) {
+		log.Printf("%s Push API received: %s\n", p.GetName(), resp)
+	}
+	if err := p.WebsocketHandleAccountNotifications(resp); err != nil {
+		log.Printf("%s Unable to handle account notifications. Error: %s\n", p.GetName(), err)
+	}
+}
+
+// accountSubscription returns the signed subscription to the account notifications channel
+func (p *Poloniex) accountSubscription(nonce int64) PoloniexAccountSubscription {
+	apiKey, apiSecret, _ := p.Credentials()
+	payload := POLONIEX_PUSH_SUBSCRIBE_NONCE + strconv.FormatInt(nonce, 10)
+	hmac := common.GetHMAC(common.HashSHA512, []byte(payload), []byte(apiSecret))
+	return PoloniexAccountSubscription{
+		Command: POLONIEX_PUSH_SUBSCRIBE,
+		Channel: POLONIEX_PUSH_ACCOUNT_CHANNEL,
+		Key:     apiKey,
+		Payload: payload,
+		Sign:    common.HexEncodeToString(hmac),
+	}
+}
+
+// WebsocketHandleAccountNotifications parses a message received from the push API and applies the
+// new order & order update notifications to the order cache, other notifications are ignored
+func (p *Poloniex) WebsocketHandleAccountNotifications(data []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed account notification: %v", r)
+		}
+	}()
+
+	var msg []interface{}
+	if err = json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	if len(msg) == 0 {
+		return errors.New("empty push API message")
+	}
+	if channel := int(msg[0].(float64)); channel != POLONIEX_PUSH_ACCOUNT_CHANNEL || len(msg) < 3 {
+		// heartbeats & subscription acknowledgements
+		return nil
+	}
+
+	for _, n := range msg[2].([]interface{}) {
+		notification := n.([]interface{})
+		switch notification[0].(string) {
+		case POLONIEX_PUSH_NEW_ORDER:
+			// ["n", pairID, orderNumber, type, rate, amount, date, originalAmount, clientOrderID]
+			order := &PoloniexOrder{
+				OrderNumber: int64(notification[2].(float64)),
+				Type:        string(exchange.OrderSideSell),
+				Date:        notification[6].(string),
+			}
+			if int(notification[3].(float64)) == POLONIEX_PUSH_ORDER_TYPE_BUY {
+				order.Type = string(exchange.OrderSideBuy)
+			}
+			order.Rate, _ = strconv.ParseFloat(notification[4].(string), 64)
+			order.Amount, _ = strconv.ParseFloat(notification[5].(string), 64)
+			order.StartingAmount = order.Amount
+			if len(notification) > 7 {
+				order.StartingAmount, _ = strconv.ParseFloat(notification[7].(string), 64)
+			}
+			order.Total = order.Amount * order.Rate
+			symbol, err := p.orders.addOrder(int64(notification[1].(float64)), order)
+			if err != nil {
+				return err
+			}
+			if symbol != "" {
+				p.PublishOrder(*p.convertOrderToExchangeOrder(order, p.SymbolToCurrencyPair(symbol)))
+			}
+		case POLONIEX_PUSH_ORDER_UPDATE:
+			// ["o", orderNumber, newAmount, updateType, clientOrderID], the update type is "f" for
+			// fills and "c" for cancels
+			amount, err := strconv.ParseFloat(notification[2].(string), 64)
+			if err != nil {
+				return err
+			}
+			cancelled := len(notification) > 3 && notification[3] == POLONIEX_PUSH_ORDER_CANCEL
+			order, symbol, ok := p.orders.updateOrder(int64(notification[1].(float64)), amount, cancelled)
+			if !ok {
+				continue
+			}
+			converted := p.convertOrderToExchangeOrder(&order, p.SymbolToCurrencyPair(symbol))
+			if cancelled {
+				converted.Status = exchange.OrderStatusAborted
+			} else if amount <= 0 {
+				converted.Status = exchange.OrderStatusFilled
+			}
+			p.PublishOrder(*converted)
+		}
+	}
+	return nil
+}
+
+// bookLevel is the new amount of a price level, a zero amount removes the level
+type bookLevel struct {
+	bid    bool
+	price  float64
+	amount float64
+}
+
+// bookUpdate holds the levels updated by a message of an orderbook channel
+type bookUpdate struct {
+	seq    int64
+	levels []bookLevel
+}
+
+// bookStream is an orderbook maintained from the updates of its channel, the updates are buffered
+// while the book is synced with a REST snapshot
+type bookStream struct {
+	symbol   string
+	synced   bool
+	seq      int64
+	bids     map[float64]float64
+	asks     map[float64]float64
+	buffered []*bookUpdate
+	// Set while the orderbook is rebuilt after a gap, it's published once synced
+	resync *exchange.Resynced
+}
+
+// reset discards the orderbook, it'll be synced again with the next update
+func (s *bookStream) reset() {
+	s.synced = false
+	s.seq = 0
+	s.bids = nil
+	s.asks = nil
+}
+
+// sync replaces the orderbook with the snapshot and applies the buffered updates that follow it
+func (s *bookStream) sync(snapshot *PoloniexOrderbook) error {
+	s.seq = snapshot.Seq
+	s.bids = make(map[float64]float64, len(snapshot.Bids))
+	s.asks = make(map[float64]float64, len(snapshot.Asks))
+	for _, item := range snapshot.Bids {
+		s.bids[item.Price] = item.Amount
+	}
+	for _, item := range snapshot.Asks {
+		s.asks[item.Price] = item.Amount
+	}
+	buffered := s.buffered
+	s.buffered = nil
+	for _, update := range buffered {
+		if err := s.apply(update); err != nil {
+			s.reset()
+			return err
+		}
+	}
+	s.synced = true
+	return nil
+}
+
+// apply updates the orderbook, updates already included in the orderbook are ignored. Returns
+// errBookGap if updates were missed.
+func (s *bookStream) apply(update *bookUpdate) error {
+	if update.seq <= s.seq {
+		return nil
+	}
+	if update.seq != s.seq+1 {
+		return errBookGap
+	}
+	for _, level := range update.levels {
+		levels := s.asks
+		if level.bid {
+			levels = s.bids
+		}
+		if level.amount == 0 {
+			delete(levels, level.price)
+		} else {
+			levels[level.price] = level.amount
+		}
+	}
+	s.seq = update.seq
+	return nil
+}
+
+// orderbook returns the bids sorted from highest to lowest & the asks from lowest to highest
+func (s *bookStream) orderbook() orderbook.Base {
+	book := orderbook.Base{
+		Bids: orderbook.GetItems(len(s.bids)),
+		Asks: orderbook.GetItems(len(s.asks)),
+	}
+	for price, amount := range s.bids {
+		book.Bids = append(book.Bids, orderbook.Item{Price: price, Amount: amount})
+	}
+	for price, amount := range s.asks {
+		book.Asks = append(book.Asks, orderbook.Item{Price: price, Amount: amount})
+	}
+	sort.Slice(book.Bids, func(i, j int) bool { return book.Bids[i].Price > book.Bids[j].Price })
+	sort.Slice(book.Asks, func(i, j int) bool { return book.Asks[i].Price < book.Asks[j].Price })
+	return book
+}
+
+// bookStreams holds the orderbooks of the push API, keyed by channel ID
+type bookStreams struct {
+	mtx      sync.Mutex
+	channels map[int64]*bookStream
+}
+
+// WebsocketMarketClient subscribes to the orderbook channels of the enabled pairs on the push API,
+// the orderbooks are maintained from the updates instead of being polled.
+func (p *Poloniex) WebsocketMarketClient() {
+	var conn *stream.Conn
+	conn = stream.NewConn(stream.Config{
+		URL:               POLONIEX_PUSH_ADDRESS,
+		PingInterval:      POLONIEX_PUSH_PING_INTERVAL,
+		StaleTimeout:      p.WebsocketStaleTimeout(),
+		EnableCompression: p.WebsocketCompression(),
+		OnConnect: func(*stream.Conn) error {
+			p.CountWebsocketConnection()
+			return nil
+		},
+		OnMessage: func(msgType int, resp []byte) {
+			if !p.Enabled || !p.Websocket {
+				conn.Close()
+				return
+			}
+			p.websocketMarketMessage(msgType, resp)
+		},
+		OnStateChange: func(state stream.State, err error) {
+			switch state {
+			case stream.Connected:
+				p.SetWebsocketStale(false)
+			case stream.Stale:
+				// The orderbooks are polled until the connection is re-established
+				p.SetWebsocketStale(true)
+				p.resetBooks()
+				conn.Reconnect()
+			case stream.Disconnected, stream.Closed:
+				p.resetBooks()
+				if err != nil {
+					log.Printf("%s Push API error: %s\n", p.GetName(), err)
+				}
+			}
+		},
+	})
+	for _, currencyPair := range p.GetEnabledCurrencies() {
+		symbol := p.CurrencyPairToSymbol(currencyPair)
+		conn.Subscribe(symbol, PoloniexPushSubscription{Command: POLONIEX_PUSH_SUBSCRIBE, Channel: symbol})
+	}
+	conn.Run()
+}
+
+func (p *Poloniex) websocketMarketMessage(msgType int, resp []byte) {
+	p.RecordWebsocketFrame(msgType, resp)
+	if msgType != websocket.TextMessage {
+		return
+	}
+	if p.Debug(exchange.TraceWebsocket) {
+		log.Printf("%s Push API received: %s\n", p.GetName(), resp)
+	}
+	if err := p.WebsocketHandleMarketMessage(resp); err != nil {
+		log.Printf("%s Unable to handle push API message. Error: %s\n", p.GetName(), err)
+	}
+}
+
+// WebsocketHandleMarketMessage parses a message of an orderbook channel & applies it to the
+// orderbook, the orderbook is resynced with a REST snapshot if updates were missed. The trades
+// of the channel are published to the trade subscribers.
+func (p *Poloniex) WebsocketHandleMarketMessage(data []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed push API message: %v", r)
+		}
+	}()
+
+	var msg []interface{}
+	if err = json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	if len(msg) < 3 {
+		// heartbeats & subscription acknowledgements
+		return nil
+	}
+	channel := int64(msg[0].(float64))
+	if channel == POLONIEX_PUSH_ACCOUNT_CHANNEL || channel == POLONIEX_PUSH_HEARTBEAT {
+		return nil
+	}
+
+	update := &bookUpdate{seq: int64(msg[1].(float64))}
+	var snapshot *PoloniexOrderbook
+	var symbol string
+	var trades []exchange.Trade
+	for _, e := range msg[2].([]interface{}) {
+		entry := e.([]interface{})
+		switch entry[0].(string) {
+		case POLONIEX_PUSH_BOOK_SNAPSHOT:
+			// ["i", {"currencyPair": symbol, "orderBook": [{askPrice: amount}, {bidPrice: amount}]}]
+			book := entry[1].(map[string]interface{})
+			symbol = book["currencyPair"].(string)
+			sides := book["orderBook"].([]interface{})
+			snapshot = &PoloniexOrderbook{Seq: update.seq}
+			if snapshot.Asks, err = parseBookSide(sides[0].(map[string]interface{})); err != nil {
+				return err
+			}
+			if snapshot.Bids, err = parseBookSide(sides[1].(map[string]interface{})); err != nil {
+				return err
+			}
+		case POLONIEX_PUSH_BOOK_UPDATE:
+			// ["o", side, price, amount], the side is 1 for bids & 0 for asks
+			level := bookLevel{bid: int(entry[1].(float64)) == POLONIEX_PUSH_BOOK_SIDE_BID}
+			if level.price, err = strconv.ParseFloat(entry[2].(string), 64); err != nil {
+				return err
+			}
+			if level.amount, err = strconv.ParseFloat(entry[3].(string), 64); err != nil {
+				return err
+			}
+			update.levels = append(update.levels, level)
+		case POLONIEX_PUSH_BOOK_TRADE:
+			// ["t", tradeID, side, price, amount, timestamp], the side of the taker is 1 for buys
+			trade := exchange.Trade{
+				ID:       entry[1].(string),
+				Exchange: p.Name,
+				Side:     exchange.OrderSideSell,
+				Time:     time.Unix(int64(entry[5].(float64)), 0),
+			}
+			if int(entry[2].(float64)) == POLONIEX_PUSH_ORDER_TYPE_BUY {
+				trade.Side = exchange.OrderSideBuy
+			}
+			if trade.Price, err = strconv.ParseFloat(entry[3].(string), 64); err != nil {
+				return err
+			}
+			if trade.Amount, err = strconv.ParseFloat(entry[4].(string), 64); err != nil {
+				return err
+			}
+			trades = append(trades, trade)
+		}
+	}
+
+	if len(trades) > 0 {
+		if symbol == "" {
+			symbol = p.channelSymbol(channel)
+		}
+		// Trades received before the snapshot of the channel can't be matched to a pair
+		if symbol != "" {
+			currencyPair := p.SymbolToCurrencyPair(symbol)
+			for _, trade := range trades {
+				trade.CurrencyPair = currencyPair
+				p.PublishTrade(trade)
+			}
+		}
+	}
+	return p.handleBookUpdate(channel, symbol, snapshot, update)
+}
+
+// channelSymbol returns the symbol of an orderbook channel, or an empty string if the snapshot of
+// the channel hasn't been received
+func (p *Poloniex) channelSymbol(channel int64) string {
+	p.books.mtx.Lock()
+	defer p.books.mtx.Unlock()
+	if s := p.books.channels[channel]; s != nil {
+		return s.symbol
+	}
+	return ""
+}
+
+func parseBookSide(side map[string]interface{}) ([]PoloniexOrderbookItem, error) {
+	items := make([]PoloniexOrderbookItem, 0, len(side))
+	for price, amount := range side {
+		item := PoloniexOrderbookItem{}
+		var err error
+		if item.Price, err = strconv.ParseFloat(price, 64); err != nil {
+			return nil, err
+		}
+		if item.Amount, err = strconv.ParseFloat(amount.(string), 64); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// handleBookUpdate replaces the orderbook of the channel with the snapshot if there's one, or
+// applies the update to it
+func (p *Poloniex) handleBookUpdate(channel int64, symbol string, snapshot *PoloniexOrderbook, update *bookUpdate) error {
+	p.books.mtx.Lock()
+	defer p.books.mtx.Unlock()
+	if p.books.channels == nil {
+		p.books.channels = make(map[int64]*bookStream)
+	}
+	s := p.books.channels[channel]
+	if snapshot != nil {
+		s = &bookStream{symbol: symbol}
+		p.books.channels[channel] = s
+		if err := s.sync(snapshot); err != nil {
+			return err
+		}
+		p.publishBook(s)
+		return nil
+	}
+	if s == nil {
+		return fmt.Errorf("orderbook update received before the snapshot of channel %d", channel)
+	}
+
+	if s.synced {
+		if err := s.apply(update); err != errBookGap {
+			p.publishBook(s)
+			return err
+		}
+		// The orderbook is rebuilt from a REST snapshot straight away
+		s.resync = &exchange.Resynced{
+			Exchange:         p.Name,
+			CurrencyPair:     p.SymbolToCurrencyPair(s.symbol),
+			ExpectedSequence: s.seq + 1,
+			ReceivedSequence: update.seq,
+		}
+		s.reset()
+	}
+
+	if len(s.buffered) >= POLONIEX_PUSH_BOOK_MAX_BUFFERED {
+		s.buffered = s.buffered[1:]
+	}
+	s.buffered = append(s.buffered, update)
+	book, err := p.GetOrderbook(s.symbol, POLONIEX_PUSH_BOOK_DEPTH)
+	if err != nil {
+		// Try again with the next update
+		return err
+	}
+	if err = s.sync(&book); err != nil {
+		return err
+	}
+	if s.resync != nil {
+		s.resync.Time = time.Now()
+		p.PublishResynced(*s.resync)
+		s.resync = nil
+	}
+	p.publishBook(s)
+	return nil
+}
+
+// publishBook stores the streamed orderbook & delivers it to the subscribers
+func (p *Poloniex) publishBook(s *bookStream) {
+	currencyPair := p.SymbolToCurrencyPair(s.symbol)
+	p.Orderbooks.ProcessOrderbook(p.Name, currencyPair, s.orderbook(), ticker.Spot)
+	p.PublishOrderbook(currencyPair, s.orderbook())
+}
+
+// streamedOrderbook returns the orderbook of the symbol if it's maintained by the push API
+func (p *Poloniex) streamedOrderbook(symbol string) (orderbook.Base, error) {
+	p.books.mtx.Lock()
+	synced := false
+	for _, s := range p.books.channels {
+		if s.symbol == symbol {
+			synced = s.synced
+			break
+		}
+	}
+	p.books.mtx.Unlock()
+	if !synced {
+		return orderbook.Base{}, errors.New("orderbook isn't streamed")
+	}
+	return p.Orderbooks.GetOrderbook(p.Name, p.SymbolToCurrencyPair(symbol), ticker.Spot)
+}
+
+// resetBooks discards the streamed orderbooks, they're polled again until the push API reconnects
+func (p *Poloniex) resetBooks() {
+	p.books.mtx.Lock()
+	defer p.books.mtx.Unlock()
+	p.books.channels = nil
+}
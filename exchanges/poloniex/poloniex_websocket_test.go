@@ -0,0 +1,219 @@
+package poloniex
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+	"github.com/mattkanwisher/cryptofiend/exchanges/wsrecord"
+)
+
+func TestWebsocketReplay(t *testing.T) {
+	frames, err := wsrecord.ReadFile("testdata/websocket_session.jsonl")
+	if err != nil {
+		t.Fatalf("Test failed. Unable to read the recorded session: %s", err)
+	}
+	p := Poloniex{}
+	result := wsrecord.Replay(frames, "Poloniex", func(f wsrecord.Frame) error {
+		return p.WebsocketHandleEvent([]byte(f.Data))
+	})
+	if result.Frames != len(frames) {
+		t.Errorf("Test failed. Expected %d frames to be replayed, got %d", len(frames), result.Frames)
+	}
+	// orderbook removal without a rate, truncated ticker
+	expected := []int{2, 3}
+	if len(result.Errors) != len(expected) {
+		t.Fatalf("Test failed. Expected %d errors, got %v", len(expected), result.Errors)
+	}
+	for i, index := range expected {
+		if result.Errors[i].Index != index {
+			t.Errorf("Test failed. Expected frame %d to fail, got %v", index, result.Errors[i])
+		}
+	}
+}
+
+func TestAccountSubscription(t *testing.T) {
+	p := Poloniex{}
+	p.AuthenticatedAPISupport = true
+	p.SetAPIKeys("key", "secret", "", false)
+	sub := p.accountSubscription(1536383649000)
+	expected := common.HexEncodeToString(common.GetHMAC(common.HashSHA512, []byte("nonce=1536383649000"), []byte("secret")))
+	if sub.Command != "subscribe" || sub.Channel != 1000 || sub.Key != "key" ||
+		sub.Payload != "nonce=1536383649000" || sub.Sign != expected {
+		t.Errorf("Test failed. Unexpected subscription %+v", sub)
+	}
+}
+
+func TestAccountNotifications(t *testing.T) {
+	p := Poloniex{}
+	p.SetDefaults()
+	p.orders.setPairIDs(map[int64]string{148: "BTC_ETH"})
+
+	// Ignored until the cache is seeded
+	if err := p.WebsocketHandleAccountNotifications([]byte(`[1000,"",[["o",1,"0.00000000","c",null]]]`)); err != nil {
+		t.Fatalf("Test failed. Unexpected error %s", err)
+	}
+	if _, ok := p.orders.getOpenOrders(); ok {
+		t.Fatal("Test failed. The order cache is used before it's seeded")
+	}
+
+	p.orders.seed(PoloniexOpenOrdersResponseAll{Data: map[string][]*PoloniexOrder{
+		"BTC_ETH": {{OrderNumber: 1, Type: "sell", Rate: 0.03, StartingAmount: 2, Amount: 2}},
+	}})
+	p.Websocket = true
+	p.AuthenticatedAPISupport = true
+	streamed, unsubscribe, err := p.SubscribeOrders()
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeOrders returned an error: %s", err)
+	}
+	defer unsubscribe()
+	messages := []string{
+		`[1000,1]`,
+		`[1010]`,
+		// A new buy order that's partly filled, and the seeded order is cancelled
+		`[1000,"",[["n",148,2,1,"0.02900000","1.00000000","2018-09-08 04:54:09","1.00000000",null],
+			["b",267,"e","-0.02900000"]]]`,
+		`[1000,"",[["t",42,"0.02900000","0.40000000","0.00250000",0,2,"0.00000290","2018-09-08 05:54:09",null,"0.0116"],
+			["o",2,"0.60000000","f",null],["o",1,"0.00000000","c",null]]]`,
+	}
+	for _, msg := range messages {
+		if err := p.WebsocketHandleAccountNotifications([]byte(msg)); err != nil {
+			t.Fatalf("Test failed. WebsocketHandleAccountNotifications returned an error: %s", err)
+		}
+	}
+	if len(streamed) != 3 {
+		t.Fatalf("Test failed. Expected 3 streamed orders, got %d", len(streamed))
+	}
+	if o := <-streamed; o.OrderID != "2" || o.Status != exchange.OrderStatusActive || o.FilledAmount != 0 {
+		t.Errorf("Test failed. Unexpected new order %+v", o)
+	}
+	if o := <-streamed; o.OrderID != "2" || o.Status != exchange.OrderStatusActive || o.FilledAmount != 0.4 {
+		t.Errorf("Test failed. Unexpected filled order %+v", o)
+	}
+	if o := <-streamed; o.OrderID != "1" || o.Status != exchange.OrderStatusAborted || o.FilledAmount != 0 {
+		t.Errorf("Test failed. Unexpected cancelled order %+v", o)
+	}
+
+	// The cached orders are returned without polling the exchange
+	orders, err := p.GetOrders(nil)
+	if err != nil || len(orders) != 1 {
+		t.Fatalf("Test failed. Unexpected orders %+v %v", orders, err)
+	}
+	if o := orders[0]; o.OrderID != "2" || o.Side != "buy" || o.Rate != 0.029 || o.Amount != 1 ||
+		o.FilledAmount != 0.4 || o.RemainingAmount != 0.6 || o.CurrencyPair.FirstCurrency != "ETH" {
+		t.Errorf("Test failed. Unexpected order %+v", o)
+	}
+
+	err = p.WebsocketHandleAccountNotifications([]byte(`[1000,"",[["n",999,3,0,"1","1","2018-09-08 04:54:09","1",null]]]`))
+	if err == nil {
+		t.Error("Test failed. Expected an unknown currency pair error")
+	}
+	if err = p.WebsocketHandleAccountNotifications([]byte(`[1000,"",[["n",148]]]`)); err == nil {
+		t.Error("Test failed. Expected a malformed notification error")
+	}
+}
+
+func TestOrderbookChannel(t *testing.T) {
+	snapshots := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("command") != "returnOrderBook" || r.URL.Query().Get("currencyPair") != "BTC_ETH" {
+			http.NotFound(w, r)
+			return
+		}
+		snapshots++
+		fmt.Fprint(w, `{"asks":[["0.03100000",5]],"bids":[["0.02900000",3],["0.02800000",1]],"isFrozen":"0","seq":105}`)
+	}))
+	defer server.Close()
+	p := Poloniex{}
+	p.SetDefaults()
+	p.Name = "PoloniexOrderbookChannel"
+	p.APIUrl = server.URL
+	p.RequestCurrencyPairFormat.Delimiter = "_"
+	p.RequestCurrencyPairFormat.Uppercase = true
+	p.Websocket = true
+	currencyPair := pair.NewCurrencyPair("ETH", "BTC")
+	books, unsubscribe, err := p.SubscribeOrderbook(currencyPair)
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeOrderbook returned an error: %s", err)
+	}
+	defer unsubscribe()
+	resyncs, unsubscribeResyncs, err := p.SubscribeResyncs()
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeResyncs returned an error: %s", err)
+	}
+	defer unsubscribeResyncs()
+	trades, unsubscribeTrades, err := p.SubscribeTrades(currencyPair)
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeTrades returned an error: %s", err)
+	}
+	defer unsubscribeTrades()
+
+	if err = p.WebsocketHandleMarketMessage([]byte(`[148,99,[["o",1,"0.02800000","1.00000000"]]]`)); err == nil {
+		t.Error("Test failed. Expected an error for an update received before the snapshot")
+	}
+	messages := []string{
+		`[1010]`,
+		`[148,100,[["i",{"currencyPair":"BTC_ETH","orderBook":[{"0.03100000":"4.00000000","0.03200000":"1.00000000"},
+			{"0.02900000":"3.00000000"}]}]]]`,
+		`[148,101,[["o",1,"0.02800000","1.00000000"],["o",0,"0.03200000","0.00000000"],
+			["t","126320",1,"0.03100000","1.00000000",1536383649]]]`,
+	}
+	for _, msg := range messages {
+		if err = p.WebsocketHandleMarketMessage([]byte(msg)); err != nil {
+			t.Fatalf("Test failed. WebsocketHandleMarketMessage returned an error: %s", err)
+		}
+	}
+	book, err := p.UpdateOrderbook(currencyPair, ticker.Spot)
+	if err != nil {
+		t.Fatalf("Test failed. UpdateOrderbook returned an error: %s", err)
+	}
+	if snapshots != 0 {
+		t.Error("Test failed. The streamed orderbook was polled")
+	}
+	if len(book.Bids) != 2 || book.Bids[0].Price != 0.029 || book.Bids[1].Price != 0.028 ||
+		len(book.Asks) != 1 || book.Asks[0].Price != 0.031 || book.Asks[0].Amount != 4 {
+		t.Errorf("Test failed. Unexpected orderbook %+v", book)
+	}
+	if len(books) != 2 {
+		t.Errorf("Test failed. Expected 2 streamed orderbooks, got %d", len(books))
+	}
+	if len(trades) != 1 {
+		t.Fatalf("Test failed. Expected a streamed trade, got %d", len(trades))
+	}
+	if tr := <-trades; tr.ID != "126320" || tr.Side != exchange.OrderSideBuy || tr.Price != 0.031 || tr.Amount != 1 ||
+		tr.Time.Unix() != 1536383649 || !tr.CurrencyPair.Equal(currencyPair) || tr.Exchange != p.Name {
+		t.Errorf("Test failed. Unexpected trade %+v", tr)
+	}
+
+	// A missed update resyncs the orderbook with a REST snapshot, the buffered updates included in
+	// the snapshot are ignored
+	if err = p.WebsocketHandleMarketMessage([]byte(`[148,104,[["o",1,"0.02700000","2.00000000"]]]`)); err != nil {
+		t.Fatalf("Test failed. WebsocketHandleMarketMessage returned an error: %s", err)
+	}
+	if snapshots != 1 || len(resyncs) != 1 {
+		t.Fatalf("Test failed. Expected the orderbook to be resynced, got %d snapshots", snapshots)
+	}
+	if r := <-resyncs; r.ExpectedSequence != 102 || r.ReceivedSequence != 104 || !r.CurrencyPair.Equal(currencyPair) {
+		t.Errorf("Test failed. Unexpected resync event %+v", r)
+	}
+	if err = p.WebsocketHandleMarketMessage([]byte(`[148,106,[["o",0,"0.03000000","1.00000000"]]]`)); err != nil {
+		t.Fatalf("Test failed. WebsocketHandleMarketMessage returned an error: %s", err)
+	}
+	book, _ = p.UpdateOrderbook(currencyPair, ticker.Spot)
+	if len(book.Bids) != 2 || len(book.Asks) != 2 || book.Asks[0].Price != 0.03 || book.Asks[1].Amount != 5 {
+		t.Errorf("Test failed. Unexpected resynced orderbook %+v", book)
+	}
+
+	p.resetBooks()
+	if _, err = p.streamedOrderbook("BTC_ETH"); err == nil {
+		t.Error("Test failed. The orderbooks weren't discarded")
+	}
+	if err = p.WebsocketHandleMarketMessage([]byte(`[148,107,[["o",0]]]`)); err == nil {
+		t.Error("Test failed. Expected a malformed message error")
+	}
+}
@@ -1,15 +1,22 @@
 package poloniex
 
 import (
+	"context"
 	"log"
+	"sort"
 
 	"github.com/mattkanwisher/cryptofiend/common"
 	"github.com/mattkanwisher/cryptofiend/currency/pair"
 	"github.com/mattkanwisher/cryptofiend/exchanges"
 	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
 	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+	"github.com/shopspring/decimal"
 )
 
+var _ exchange.IExchange = (*Poloniex)(nil)
+var _ exchange.ContextExchange = (*Poloniex)(nil)
+var _ exchange.Withdrawer = (*Poloniex)(nil)
+
 // Start starts the Poloniex go routine
 func (p *Poloniex) Start() {
 	go p.Run()
@@ -17,7 +24,7 @@ func (p *Poloniex) Start() {
 
 // Run implements the Poloniex wrapper
 func (p *Poloniex) Run() {
-	if p.Verbose {
+	if p.Debug("") {
 		log.Printf("%s Websocket: %s (url: %s).\n", p.GetName(), common.IsEnabled(p.Websocket), POLONIEX_WEBSOCKET_ADDRESS)
 		log.Printf("%s polling delay: %ds.\n", p.GetName(), p.RESTPollingDelay)
 		log.Printf("%s %d currencies enabled: %s.\n", p.GetName(), len(p.EnabledPairs), p.EnabledPairs)
@@ -25,25 +32,45 @@ func (p *Poloniex) Run() {
 
 	if p.Websocket {
 		go p.WebsocketClient()
+		go p.WebsocketMarketClient()
+	}
+
+	// Warm up the orderbook store with a single request instead of one request per pair
+	if err := p.UpdateAllOrderbooks(ticker.Spot); err != nil {
+		log.Printf("%s failed to update orderbooks: %s\n", p.GetName(), err)
 	}
 
 	ticker, err := p.GetTicker()
-	if (err != nil) && p.Verbose {
+	if (err != nil) && p.Debug("") {
 		log.Printf("failed to ticker for %s", p.GetName())
 	}
 	p.currencyPairs = make(map[pair.CurrencyItem]*exchange.CurrencyPairInfo, len(ticker))
-	for symbol := range ticker {
+	pairIDs := make(map[int64]string, len(ticker))
+	for symbol, tick := range ticker {
 		currencyPair := p.SymbolToCurrencyPair(symbol)
 		p.currencyPairs[pair.CurrencyItem(symbol)] = &exchange.CurrencyPairInfo{
 			Currency: currencyPair,
 		}
+		pairIDs[tick.ID] = symbol
+	}
+	p.orders.setPairIDs(pairIDs)
+
+	// The account notifications refer to the currency pairs by ID
+	if p.Websocket && p.AuthenticatedAPISupport {
+		go p.WebsocketAccountClient()
 	}
 }
 
 // UpdateTicker updates and returns the ticker for a currency pair
 func (p *Poloniex) UpdateTicker(currencyPair pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return p.UpdateTickerContext(context.Background(), currencyPair, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (p *Poloniex) UpdateTickerContext(ctx context.Context, currencyPair pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
 	var tickerPrice ticker.Price
-	tick, err := p.GetTicker()
+	tick, err := p.getTicker(ctx)
 	if err != nil {
 		return tickerPrice, err
 	}
@@ -73,7 +100,7 @@ func (p *Poloniex) GetTickerPrice(currencyPair pair.CurrencyPair, assetType stri
 }
 
 // GetOrderbookEx returns orderbook base on the currency pair
-func (p *Poloniex) GetOrderbookEx(currencyPair pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+func (p *Poloniex) GetOrderbookEx(currencyPair pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
 	ob, err := p.Orderbooks.GetOrderbook(p.GetName(), currencyPair, assetType)
 	if err == nil {
 		return p.UpdateOrderbook(currencyPair, assetType)
@@ -81,21 +108,33 @@ func (p *Poloniex) GetOrderbookEx(currencyPair pair.CurrencyPair, assetType stri
 	return ob, nil
 }
 
-// UpdateOrderbook updates and returns the orderbook for a currency pair
+// UpdateOrderbook updates and returns the orderbook for a currency pair, orderbooks maintained by
+// the push API are returned without polling the exchange.
 func (p *Poloniex) UpdateOrderbook(currencyPair pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return p.UpdateOrderbookContext(context.Background(), currencyPair, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (p *Poloniex) UpdateOrderbookContext(ctx context.Context, currencyPair pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
 	var orderBook orderbook.Base
 	symbol := p.CurrencyPairToSymbol(currencyPair)
-	orderbookNew, err := p.GetOrderbook(symbol, 1000)
+	if streamed, err := p.streamedOrderbook(symbol); err == nil {
+		return streamed, nil
+	}
+	orderbookNew, err := p.getOrderbook(ctx, symbol, 1000)
 
 	if err != nil {
 		return orderBook, err
 	}
 
+	orderBook.Bids = orderbook.GetItems(len(orderbookNew.Bids))
 	for x := range orderbookNew.Bids {
 		data := orderbookNew.Bids[x]
 		orderBook.Bids = append(orderBook.Bids, orderbook.Item{Amount: data.Amount, Price: data.Price})
 	}
 
+	orderBook.Asks = orderbook.GetItems(len(orderbookNew.Asks))
 	for x := range orderbookNew.Asks {
 		data := orderbookNew.Asks[x]
 		orderBook.Asks = append(orderBook.Asks, orderbook.Item{Amount: data.Amount, Price: data.Price})
@@ -105,28 +144,82 @@ func (p *Poloniex) UpdateOrderbook(currencyPair pair.CurrencyPair, assetType str
 	return p.Orderbooks.GetOrderbook(p.Name, currencyPair, assetType)
 }
 
+// UpdateAllOrderbooks updates the orderbooks of all the enabled currency pairs using a single
+// request.
+func (p *Poloniex) UpdateAllOrderbooks(assetType string) error {
+	orderbooks, err := p.GetAllOrderbooks(1000)
+	if err != nil {
+		return err
+	}
+
+	for _, currencyPair := range p.GetEnabledCurrencies() {
+		orderbookNew, ok := orderbooks[p.CurrencyPairToSymbol(currencyPair)]
+		if !ok {
+			continue
+		}
+
+		var orderBook orderbook.Base
+		orderBook.Bids = orderbook.GetItems(len(orderbookNew.Bids))
+		for x := range orderbookNew.Bids {
+			data := orderbookNew.Bids[x]
+			orderBook.Bids = append(orderBook.Bids, orderbook.Item{Amount: data.Amount, Price: data.Price})
+		}
+
+		orderBook.Asks = orderbook.GetItems(len(orderbookNew.Asks))
+		for x := range orderbookNew.Asks {
+			data := orderbookNew.Asks[x]
+			orderBook.Asks = append(orderBook.Asks, orderbook.Item{Amount: data.Amount, Price: data.Price})
+		}
+
+		p.Orderbooks.ProcessOrderbook(p.GetName(), currencyPair, orderBook, assetType)
+	}
+	return nil
+}
+
 // GetExchangeAccountInfo retrieves balances for all enabled currencies for the
 // Poloniex exchange
 func (p *Poloniex) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return p.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (p *Poloniex) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
 	var response exchange.AccountInfo
 	response.ExchangeName = p.GetName()
-	accountBalance, err := p.GetBalances()
+	// returnBalances only reports the available amounts, returnCompleteBalances also reports the
+	// amounts held by open orders
+	accountBalance, err := p.getCompleteBalances(ctx)
 	if err != nil {
 		return response, err
 	}
 
-	for currency, availableAmount := range accountBalance.Currency {
+	for currency, balance := range accountBalance.Currency {
 		exchangeCurrency := exchange.AccountCurrencyInfo{
 			CurrencyName: currency,
-			TotalValue:   availableAmount, // not entirely accurate, but probably better than leaving it as zero
-			Available:    availableAmount,
-			Hold:         0, // Poloniex doesn't provide this amount
+			Available:    balance.Available,
+			Hold:         balance.OnOrders,
 		}
+		exchangeCurrency.TotalValue, _ = decimal.NewFromFloat(balance.Available).
+			Add(decimal.NewFromFloat(balance.OnOrders)).Float64()
 		response.Currencies = append(response.Currencies, exchangeCurrency)
 	}
 	return response, nil
 }
 
+// GetFeeInfo returns the maker & taker fees of the account's fee tier, Poloniex charges the same
+// fees for all the currency pairs.
+func (p *Poloniex) GetFeeInfo(currencyPair pair.CurrencyPair) (maker, taker float64, err error) {
+	if !p.AuthenticatedAPISupport {
+		return p.Base.GetFeeInfo(currencyPair)
+	}
+	fees, err := p.GetAccountFeeInfo()
+	if err != nil {
+		return 0, 0, err
+	}
+	return fees.MakerFee * 100, fees.TakerFee * 100, nil
+}
+
 // GetEnabledCurrencies returns the enabled currency pairs for the exchange.
 func (p *Poloniex) GetEnabledCurrencies() []pair.CurrencyPair {
 	// Poloniex doesn't follow common conventions for currency pairs, it inverts the
@@ -148,3 +241,27 @@ func (p *Poloniex) GetAvailableCurrencies() []pair.CurrencyPair {
 	}
 	return pairs
 }
+
+// ListInstruments returns the markets that aren't frozen on the exchange
+func (p *Poloniex) ListInstruments() ([]exchange.Instrument, error) {
+	tickers, err := p.GetTicker()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]exchange.Instrument, 0, len(tickers))
+	for symbol, t := range tickers {
+		if t.IsFrozen != 0 {
+			continue
+		}
+		result = append(result, exchange.Instrument{Symbol: symbol, Pair: p.SymbolToCurrencyPair(symbol)})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Symbol < result[j].Symbol })
+	return result, nil
+}
+
+// WithdrawToAddress withdraws an amount of a currency to the given address, Poloniex doesn't
+// return a withdrawal ID.
+func (p *Poloniex) WithdrawToAddress(currency, address string, amount float64) (string, error) {
+	_, err := p.Withdraw(currency, address, amount)
+	return "", err
+}
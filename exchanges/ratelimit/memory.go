@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens     float64
+	lastUpdate time.Time
+}
+
+// MemoryBackend is a Backend that only limits requests from the current process
+type MemoryBackend struct {
+	m       sync.Mutex
+	buckets map[string]*bucket
+	bans    map[string]time.Time
+	now     func() time.Time
+}
+
+// NewMemoryBackend creates a new in-memory backend
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		buckets: make(map[string]*bucket),
+		bans:    make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// Take implements Backend
+func (m *MemoryBackend) Take(key string, requestsPerMin uint) (bool, error) {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	now := m.now()
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: 1, lastUpdate: now}
+		m.buckets[key] = b
+	}
+	b.tokens += now.Sub(b.lastUpdate).Minutes() * float64(requestsPerMin)
+	if b.tokens > 1 {
+		b.tokens = 1
+	}
+	b.lastUpdate = now
+	if b.tokens < 1 {
+		return false, nil
+	}
+	b.tokens--
+	return true, nil
+}
+
+// Ban implements Backend
+func (m *MemoryBackend) Ban(key string, d time.Duration) error {
+	m.m.Lock()
+	m.bans[key] = m.now().Add(d)
+	m.m.Unlock()
+	return nil
+}
+
+// IsBanned implements Backend
+func (m *MemoryBackend) IsBanned(key string) (bool, error) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	until, ok := m.bans[key]
+	if !ok {
+		return false, nil
+	}
+	if !m.now().Before(until) {
+		delete(m.bans, key)
+		return false, nil
+	}
+	return true, nil
+}
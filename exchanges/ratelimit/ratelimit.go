@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Backend stores the state of the rate limiters, the default backend keeps the state in memory
+// but a shared backend (such as Redis) can be used to apply the same limits across multiple
+// processes that use the same API keys or IP address.
+type Backend interface {
+	// Take removes a token from the bucket identified by key, the bucket holds a single token
+	// and is refilled at the rate of requestsPerMin. Returns false if the bucket is empty.
+	Take(key string, requestsPerMin uint) (bool, error)
+	// Ban blocks all requests for the given key until the duration elapses.
+	Ban(key string, d time.Duration) error
+	// IsBanned returns true if requests for the given key are currently blocked.
+	IsBanned(key string) (bool, error)
+}
+
+var (
+	defaultBackendMtx sync.RWMutex
+	defaultBackend    Backend = NewMemoryBackend()
+)
+
+// SetDefaultBackend sets the backend used by all limiters that weren't given a backend explicitly
+func SetDefaultBackend(b Backend) {
+	defaultBackendMtx.Lock()
+	defaultBackend = b
+	defaultBackendMtx.Unlock()
+}
+
+// DefaultBackend returns the backend used by limiters that weren't given a backend explicitly
+func DefaultBackend() Backend {
+	defaultBackendMtx.RLock()
+	defer defaultBackendMtx.RUnlock()
+	return defaultBackend
+}
+
+// Limiter rate limits the requests sent to an exchange
+type Limiter struct {
+	name    string
+	backend Backend
+}
+
+// NewLimiter creates a new limiter for the named exchange, if backend is nil the default backend
+// will be used.
+func NewLimiter(name string, backend Backend) *Limiter {
+	return &Limiter{name: name, backend: backend}
+}
+
+func (l *Limiter) getBackend() Backend {
+	if l.backend != nil {
+		return l.backend
+	}
+	return DefaultBackend()
+}
+
+// Allow returns true if a request to the given method & path can be sent without exceeding
+// requestsPerMin, or while the exchange is rate limiting all requests. If the backend fails
+// the request is allowed.
+func (l *Limiter) Allow(method, path string, requestsPerMin uint) bool {
+	backend := l.getBackend()
+	banned, err := backend.IsBanned(l.name)
+	if err != nil {
+		log.Printf("%s: Rate limiter backend error: %s\n", l.name, err)
+		return true
+	}
+	if banned {
+		return false
+	}
+	ok, err := backend.Take(l.name+":"+method+path, requestsPerMin)
+	if err != nil {
+		log.Printf("%s: Rate limiter backend error: %s\n", l.name, err)
+		return true
+	}
+	return ok
+}
+
+// Ban blocks all requests to the exchange for the given duration, it should be called when the
+// exchange reports that requests are being rate limited.
+func (l *Limiter) Ban(d time.Duration) {
+	if err := l.getBackend().Ban(l.name, d); err != nil {
+		log.Printf("%s: Rate limiter backend error: %s\n", l.name, err)
+	}
+}
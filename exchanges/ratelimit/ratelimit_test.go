@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Now()
+	backend := NewMemoryBackend()
+	backend.now = func() time.Time { return now }
+	l := NewLimiter("TESTNAME", backend)
+
+	if !l.Allow("GET", "/balances", 60) {
+		t.Error("Test Failed - first request should be allowed")
+	}
+	if l.Allow("GET", "/balances", 60) {
+		t.Error("Test Failed - second request within a second should be limited")
+	}
+	if !l.Allow("GET", "/orders", 60) {
+		t.Error("Test Failed - requests to other paths shouldn't be limited")
+	}
+
+	now = now.Add(time.Second)
+	if !l.Allow("GET", "/balances", 60) {
+		t.Error("Test Failed - request after a second should be allowed")
+	}
+
+	now = now.Add(time.Minute)
+	l.Ban(time.Minute)
+	if l.Allow("GET", "/balances", 60) {
+		t.Error("Test Failed - requests should be blocked while banned")
+	}
+	now = now.Add(time.Minute)
+	if !l.Allow("GET", "/balances", 60) {
+		t.Error("Test Failed - request after the ban expired should be allowed")
+	}
+}
+
+func TestDefaultBackend(t *testing.T) {
+	old := DefaultBackend()
+	defer SetDefaultBackend(old)
+
+	backend := NewMemoryBackend()
+	SetDefaultBackend(backend)
+	l := NewLimiter("TESTNAME", nil)
+	l.Ban(time.Minute)
+	if banned, _ := backend.IsBanned("TESTNAME"); !banned {
+		t.Error("Test Failed - limiter didn't use the default backend")
+	}
+}
@@ -0,0 +1,262 @@
+package ratelimit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	redisKeyPrefix   = "cryptofiend:ratelimit:"
+	redisDialTimeout = 5 * time.Second
+	redisIOTimeout   = 2 * time.Second
+	// Delay before redialing after the first failed dial, doubled for each subsequent failure
+	redisMinRedialDelay = 100 * time.Millisecond
+	redisMaxRedialDelay = 30 * time.Second
+)
+
+// errRedisDialing is returned while another request is connecting to the Redis server
+var errRedisDialing = errors.New("redis: connecting to the server")
+
+// Token bucket holding a single token, refilled at the rate of ARGV[1] tokens per minute. The
+// Redis server time is used so that the clocks of the bot processes don't need to be in sync. A
+// bucket with a rate of 0 is never refilled, so it doesn't expire either.
+const redisTakeScript = `
+redis.replicate_commands()
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local rate = tonumber(ARGV[1]) / 60000
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or 1
+local ts = tonumber(state[2]) or now
+tokens = math.min(1, tokens + (now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+if rate > 0 then
+	redis.call('PEXPIRE', KEYS[1], math.ceil(2 / rate))
+end
+return allowed
+`
+
+// RedisBackend is a Backend that stores the rate limiter state in Redis, so that it can be
+// shared by multiple processes.
+type RedisBackend struct {
+	address  string
+	password string
+
+	m    sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+	// Set while a request is dialing the server without holding m
+	dialing bool
+	// Error of the last failed dial, returned until the server is redialed at redialAt
+	dialErr     error
+	redialAt    time.Time
+	redialDelay time.Duration
+
+	dial func(network, address string, timeout time.Duration) (net.Conn, error)
+	now  func() time.Time
+}
+
+// NewRedisBackend creates a new backend that connects to the Redis server at the given address,
+// the password may be empty if the server doesn't require authentication.
+func NewRedisBackend(address, password string) *RedisBackend {
+	return &RedisBackend{address: address, password: password, dial: net.DialTimeout, now: time.Now}
+}
+
+// Take implements Backend
+func (r *RedisBackend) Take(key string, requestsPerMin uint) (bool, error) {
+	reply, err := r.do("EVAL", redisTakeScript, "1", redisKeyPrefix+key,
+		strconv.FormatUint(uint64(requestsPerMin), 10))
+	if err != nil {
+		return false, err
+	}
+	allowed, ok := reply.(int64)
+	if !ok {
+		return false, fmt.Errorf("unexpected redis reply %v", reply)
+	}
+	return allowed == 1, nil
+}
+
+// Ban implements Backend
+func (r *RedisBackend) Ban(key string, d time.Duration) error {
+	ms := int64(d / time.Millisecond)
+	if ms < 1 {
+		ms = 1
+	}
+	_, err := r.do("SET", redisKeyPrefix+"ban:"+key, "1", "PX", strconv.FormatInt(ms, 10))
+	return err
+}
+
+// IsBanned implements Backend
+func (r *RedisBackend) IsBanned(key string) (bool, error) {
+	reply, err := r.do("EXISTS", redisKeyPrefix+"ban:"+key)
+	if err != nil {
+		return false, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return false, fmt.Errorf("unexpected redis reply %v", reply)
+	}
+	return n == 1, nil
+}
+
+// Close closes the connection to the Redis server
+func (r *RedisBackend) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn = nil
+	return err
+}
+
+// do sends a command to the Redis server and returns the reply, the connection is reestablished
+// if the previous command failed.
+func (r *RedisBackend) do(args ...string) (interface{}, error) {
+	if err := r.connect(); err != nil {
+		return nil, err
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.conn == nil {
+		// the connection was closed by another request after connect returned
+		return nil, errors.New("redis: connection closed")
+	}
+	reply, err := roundTrip(r.conn, r.r, args...)
+	if _, isRedisErr := err.(redisError); err != nil && !isRedisErr {
+		r.conn.Close()
+		r.conn = nil
+	}
+	return reply, err
+}
+
+// connect dials the Redis server if there's no connection. The lock isn't held while dialing, so
+// the other requests fail fast instead of waiting for the dial timeout, and the server isn't
+// redialed until the backoff delay after a failed dial has passed.
+func (r *RedisBackend) connect() error {
+	r.m.Lock()
+	if r.conn != nil {
+		r.m.Unlock()
+		return nil
+	}
+	if r.dialing {
+		r.m.Unlock()
+		return errRedisDialing
+	}
+	if r.dialErr != nil && r.now().Before(r.redialAt) {
+		err := r.dialErr
+		r.m.Unlock()
+		return err
+	}
+	r.dialing = true
+	r.m.Unlock()
+
+	conn, reader, err := r.dialAndAuth()
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.dialing = false
+	if err != nil {
+		r.redialDelay *= 2
+		if r.redialDelay < redisMinRedialDelay {
+			r.redialDelay = redisMinRedialDelay
+		} else if r.redialDelay > redisMaxRedialDelay {
+			r.redialDelay = redisMaxRedialDelay
+		}
+		r.redialAt = r.now().Add(r.redialDelay)
+		r.dialErr = err
+		return err
+	}
+	r.dialErr, r.redialDelay = nil, 0
+	r.conn, r.r = conn, reader
+	return nil
+}
+
+// dialAndAuth opens a new connection to the Redis server and authenticates it
+func (r *RedisBackend) dialAndAuth() (net.Conn, *bufio.Reader, error) {
+	conn, err := r.dial("tcp", r.address, redisDialTimeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	reader := bufio.NewReader(conn)
+	if r.password != "" {
+		if _, err := roundTrip(conn, reader, "AUTH", r.password); err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+	}
+	return conn, reader, nil
+}
+
+func roundTrip(conn net.Conn, r *bufio.Reader, args ...string) (interface{}, error) {
+	conn.SetDeadline(time.Now().Add(redisIOTimeout))
+	if _, err := conn.Write(encodeRedisCommand(args...)); err != nil {
+		return nil, err
+	}
+	return readRedisReply(r)
+}
+
+// redisError is an error reply sent by the Redis server
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+func encodeRedisCommand(args ...string) []byte {
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	return buf
+}
+
+// readRedisReply reads a simple string, error, integer or bulk string reply
+func readRedisReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("malformed redis reply")
+	}
+	line = line[:len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	}
+	return nil, fmt.Errorf("unsupported redis reply type %q", line[0])
+}
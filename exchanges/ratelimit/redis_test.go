@@ -0,0 +1,160 @@
+package ratelimit
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveFakeRedis accepts a single connection and replies to each command with the reply returned
+// by the handler
+func serveFakeRedis(t *testing.T, handler func(args []string) string) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		defer ln.Close()
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+			args := make([]string, n)
+			for i := range args {
+				if line, err = r.ReadString('\n'); err != nil {
+					return
+				}
+				size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+				arg := make([]byte, size+2)
+				if _, err = io.ReadFull(r, arg); err != nil {
+					return
+				}
+				args[i] = string(arg[:size])
+			}
+			conn.Write([]byte(handler(args)))
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestRedisBackend(t *testing.T) {
+	var commands []string
+	addr := serveFakeRedis(t, func(args []string) string {
+		commands = append(commands, args[0])
+		switch args[0] {
+		case "AUTH", "SET":
+			return "+OK\r\n"
+		case "EVAL":
+			if args[3] != redisKeyPrefix+"TESTNAME:GET/balances" || args[4] != "60" {
+				return "-ERR unexpected args\r\n"
+			}
+			return ":1\r\n"
+		case "EXISTS":
+			return ":0\r\n"
+		}
+		return "-ERR unknown command\r\n"
+	})
+
+	r := NewRedisBackend(addr, "password")
+	defer r.Close()
+	ok, err := r.Take("TESTNAME:GET/balances", 60)
+	if err != nil || !ok {
+		t.Errorf("Test Failed - Take returned %v, %v", ok, err)
+	}
+	if err = r.Ban("TESTNAME", time.Minute); err != nil {
+		t.Errorf("Test Failed - Ban error: %s", err)
+	}
+	banned, err := r.IsBanned("TESTNAME")
+	if err != nil || banned {
+		t.Errorf("Test Failed - IsBanned returned %v, %v", banned, err)
+	}
+	if strings.Join(commands, ",") != "AUTH,EVAL,SET,EXISTS" {
+		t.Errorf("Test Failed - unexpected commands %v", commands)
+	}
+}
+
+func TestRedisBackendRedial(t *testing.T) {
+	addr := serveFakeRedis(t, func(args []string) string {
+		return ":1\r\n"
+	})
+	r := NewRedisBackend(addr, "")
+	defer r.Close()
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	dials := 0
+	release := make(chan struct{})
+	r.dial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		dials++
+		if dials == 3 {
+			<-release
+			return net.DialTimeout(network, address, timeout)
+		}
+		return nil, errors.New("connection refused")
+	}
+
+	if _, err := r.Take("TESTNAME:GET/balances", 60); err == nil || dials != 1 {
+		t.Fatalf("Test Failed - expected the dial to fail, got %v after %d dials", err, dials)
+	}
+	if _, err := r.Take("TESTNAME:GET/balances", 60); err == nil || dials != 1 {
+		t.Errorf("Test Failed - expected no redial before the backoff delay, got %v after %d dials", err, dials)
+	}
+	now = now.Add(redisMinRedialDelay)
+	r.Take("TESTNAME:GET/balances", 60)
+	now = now.Add(redisMinRedialDelay)
+	if _, err := r.Take("TESTNAME:GET/balances", 60); err == nil || dials != 2 {
+		t.Errorf("Test Failed - expected the backoff delay to double, got %v after %d dials", err, dials)
+	}
+
+	// the other requests fail fast while the server is being dialed
+	now = now.Add(redisMaxRedialDelay)
+	done := make(chan error)
+	go func() {
+		_, err := r.Take("TESTNAME:GET/balances", 60)
+		done <- err
+	}()
+	for {
+		r.m.Lock()
+		dialing := r.dialing
+		r.m.Unlock()
+		if dialing {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := r.IsBanned("TESTNAME"); err != errRedisDialing {
+		t.Errorf("Test Failed - expected the request not to wait for the dial, got %v", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("Test Failed - expected the redial to succeed, got %s", err)
+	}
+	if ok, err := r.Take("TESTNAME:GET/balances", 60); err != nil || !ok || dials != 3 {
+		t.Errorf("Test Failed - expected the connection to be reused, got %v %v after %d dials", ok, err, dials)
+	}
+}
+
+func TestReadRedisReply(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("$5\r\nhello\r\n$-1\r\n-ERR oops\r\n"))
+	if reply, err := readRedisReply(r); err != nil || reply != "hello" {
+		t.Errorf("Test Failed - unexpected reply %v, %v", reply, err)
+	}
+	if reply, err := readRedisReply(r); err != nil || reply != nil {
+		t.Errorf("Test Failed - unexpected reply %v, %v", reply, err)
+	}
+	if _, err := readRedisReply(r); err == nil {
+		t.Error("Test Failed - expected error reply")
+	}
+}
@@ -0,0 +1,369 @@
+// Package stream maintains websocket connections to exchanges, connections are re-established
+// with an exponential backoff and the channels subscribed to are resubscribed after reconnecting.
+package stream
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mattkanwisher/cryptofiend/common"
+)
+
+// Default reconnection backoff
+const (
+	DefaultMinBackoff = time.Second
+	DefaultMaxBackoff = time.Minute
+)
+
+// ErrNotConnected is returned when sending on a connection that's down
+var ErrNotConnected = errors.New("websocket not connected")
+
+// ErrStale is passed to OnStateChange when no messages have been received within the stale timeout
+var ErrStale = errors.New("websocket stopped receiving messages")
+
+// State is the state of a connection
+type State int
+
+// Connection states
+const (
+	Disconnected State = iota
+	Connecting
+	Connected
+	Closed
+	// Connected, but no messages have been received within the stale timeout. The state changes
+	// back to Connected with the next message.
+	Stale
+)
+
+func (s State) String() string {
+	switch s {
+	case Disconnected:
+		return "disconnected"
+	case Connecting:
+		return "connecting"
+	case Connected:
+		return "connected"
+	case Closed:
+		return "closed"
+	case Stale:
+		return "stale"
+	}
+	return "unknown"
+}
+
+// Config holds the connection settings
+type Config struct {
+	URL    string
+	Header http.Header
+	// Defaults to websocket.DefaultDialer
+	Dialer *websocket.Dialer
+	// Negotiates permessage-deflate compression with the server, which may decline it
+	EnableCompression bool
+	// The delay before reconnecting starts at MinBackoff & doubles after every failed attempt, up
+	// to MaxBackoff. Defaults to DefaultMinBackoff & DefaultMaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Ping control frames are sent every PingInterval, the connection is dropped if nothing (not
+	// even a pong) is received for twice the interval. Disabled if zero.
+	PingInterval time.Duration
+	// The connection is Stale when no messages are received for StaleTimeout, exchanges that
+	// keep sending pongs can stop delivering updates. Disabled if zero.
+	StaleTimeout time.Duration
+	// OnConnect is called after every (re)connection, before the subscriptions are resent. It can
+	// send the handshake & authentication messages, the connection is dropped if it fails.
+	OnConnect func(c *Conn) error
+	// OnMessage is called (from the connection goroutine) for each message received
+	OnMessage func(msgType int, data []byte)
+	// OnStateChange is called (from the connection goroutine) when the state of the connection
+	// changes, err is the reason the connection was lost (if any)
+	OnStateChange func(state State, err error)
+}
+
+type subscription struct {
+	key string
+	msg interface{}
+}
+
+// Conn is a websocket connection that's re-established until it's closed
+type Conn struct {
+	cfg Config
+
+	mtx           sync.Mutex
+	conn          *websocket.Conn
+	state         State
+	subscriptions []subscription
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewConn returns a connection with the given settings, it connects once Run is called
+func NewConn(cfg Config) *Conn {
+	if cfg.Dialer == nil {
+		cfg.Dialer = websocket.DefaultDialer
+	}
+	if cfg.EnableCompression {
+		dialer := *cfg.Dialer
+		dialer.EnableCompression = true
+		cfg.Dialer = &dialer
+	}
+	if cfg.MinBackoff == 0 {
+		cfg.MinBackoff = DefaultMinBackoff
+	}
+	if cfg.MaxBackoff == 0 {
+		cfg.MaxBackoff = DefaultMaxBackoff
+	}
+	return &Conn{cfg: cfg, done: make(chan struct{})}
+}
+
+// Run connects & reconnects until the connection is closed
+func (c *Conn) Run() {
+	backoff := c.cfg.MinBackoff
+	for {
+		c.setState(Connecting, nil)
+		connected, err := c.session()
+		select {
+		case <-c.done:
+			c.setState(Closed, nil)
+			return
+		default:
+		}
+		c.setState(Disconnected, err)
+
+		if connected {
+			backoff = c.cfg.MinBackoff
+		}
+		select {
+		case <-c.done:
+			c.setState(Closed, nil)
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > c.cfg.MaxBackoff {
+			backoff = c.cfg.MaxBackoff
+		}
+	}
+}
+
+// session runs a single connection until it fails, connected is true if the connection was set
+// up successfully
+func (c *Conn) session() (connected bool, err error) {
+	conn, _, err := c.cfg.Dialer.Dial(c.cfg.URL, c.cfg.Header)
+	if err != nil {
+		return false, err
+	}
+	c.mtx.Lock()
+	select {
+	case <-c.done:
+		c.mtx.Unlock()
+		conn.Close()
+		return false, nil
+	default:
+	}
+	c.conn = conn
+	c.mtx.Unlock()
+	defer func() {
+		c.mtx.Lock()
+		c.conn = nil
+		c.mtx.Unlock()
+		conn.Close()
+	}()
+
+	if c.cfg.OnConnect != nil {
+		if err = c.cfg.OnConnect(c); err != nil {
+			return false, err
+		}
+	}
+	// Subscriptions made once the state is connected are sent by Subscribe
+	c.mtx.Lock()
+	c.state = Connected
+	subscriptions := append([]subscription(nil), c.subscriptions...)
+	c.mtx.Unlock()
+	for _, s := range subscriptions {
+		if err = c.Send(s.msg); err != nil {
+			return false, err
+		}
+	}
+	if c.cfg.OnStateChange != nil {
+		c.cfg.OnStateChange(Connected, nil)
+	}
+
+	// Messages are read by a separate goroutine so the callbacks are all called from this one
+	messages := make(chan message)
+	readErr := make(chan error, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	go c.read(conn, messages, readErr, stop)
+
+	var ping <-chan time.Time
+	if c.cfg.PingInterval > 0 {
+		ticker := time.NewTicker(c.cfg.PingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+	var staleTimer *time.Timer
+	var staleC <-chan time.Time
+	if c.cfg.StaleTimeout > 0 {
+		staleTimer = time.NewTimer(c.cfg.StaleTimeout)
+		defer staleTimer.Stop()
+		staleC = staleTimer.C
+	}
+	stale := false
+
+	for {
+		select {
+		case m := <-messages:
+			if staleTimer != nil {
+				if !staleTimer.Stop() && !stale {
+					<-staleTimer.C
+				}
+				staleTimer.Reset(c.cfg.StaleTimeout)
+			}
+			if stale {
+				stale = false
+				c.setState(Connected, nil)
+			}
+			if c.cfg.OnMessage != nil {
+				c.cfg.OnMessage(m.msgType, m.data)
+			}
+		case err = <-readErr:
+			return true, err
+		case <-ping:
+			deadline := time.Now().Add(c.cfg.PingInterval)
+			if err = conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				return true, err
+			}
+		case <-staleC:
+			stale = true
+			c.setState(Stale, ErrStale)
+		}
+	}
+}
+
+type message struct {
+	msgType int
+	data    []byte
+}
+
+// read reads the messages of the connection until it fails, the read deadline is extended by
+// every message & pong when pings are sent
+func (c *Conn) read(conn *websocket.Conn, messages chan<- message, readErr chan<- error, stop <-chan struct{}) {
+	extend := func() {
+		if c.cfg.PingInterval > 0 {
+			conn.SetReadDeadline(time.Now().Add(2 * c.cfg.PingInterval))
+		}
+	}
+	conn.SetPongHandler(func(string) error {
+		extend()
+		return nil
+	})
+	for {
+		extend()
+		msgType, data, err := conn.ReadMessage()
+		if err != nil {
+			readErr <- err
+			return
+		}
+		select {
+		case messages <- message{msgType, data}:
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (c *Conn) setState(state State, err error) {
+	c.mtx.Lock()
+	c.state = state
+	c.mtx.Unlock()
+	if c.cfg.OnStateChange != nil {
+		c.cfg.OnStateChange(state, err)
+	}
+}
+
+// State returns the current state of the connection
+func (c *Conn) State() State {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.state
+}
+
+// Send sends a JSON encoded message, ErrNotConnected is returned if the connection is down
+func (c *Conn) Send(msg interface{}) error {
+	data, err := common.JSONEncode(msg)
+	if err != nil {
+		return err
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.conn == nil {
+		return ErrNotConnected
+	}
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// Subscribe stores the subscription message under the key & sends it if connected, it's resent
+// after every reconnection. A subscription with the same key is replaced.
+func (c *Conn) Subscribe(key string, msg interface{}) error {
+	c.mtx.Lock()
+	replaced := false
+	for i := range c.subscriptions {
+		if c.subscriptions[i].key == key {
+			c.subscriptions[i].msg = msg
+			replaced = true
+		}
+	}
+	if !replaced {
+		c.subscriptions = append(c.subscriptions, subscription{key: key, msg: msg})
+	}
+	connected := c.state == Connected || c.state == Stale
+	c.mtx.Unlock()
+
+	if !connected {
+		return nil
+	}
+	return c.Send(msg)
+}
+
+// Unsubscribe removes the subscription with the key, msg is sent if it isn't nil & the connection
+// is up
+func (c *Conn) Unsubscribe(key string, msg interface{}) error {
+	c.mtx.Lock()
+	for i := range c.subscriptions {
+		if c.subscriptions[i].key == key {
+			c.subscriptions = append(c.subscriptions[:i], c.subscriptions[i+1:]...)
+			break
+		}
+	}
+	connected := c.state == Connected || c.state == Stale
+	c.mtx.Unlock()
+
+	if msg == nil || !connected {
+		return nil
+	}
+	return c.Send(msg)
+}
+
+// Reconnect drops the current connection, it's re-established after the min backoff
+func (c *Conn) Reconnect() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
+
+// Close closes the connection & stops reconnecting, Run returns once it's closed
+func (c *Conn) Close() {
+	c.closeOnce.Do(func() {
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+		close(c.done)
+		if c.conn != nil {
+			c.conn.Close()
+		}
+	})
+}
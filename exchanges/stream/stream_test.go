@@ -0,0 +1,264 @@
+package stream
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newTestServer returns a websocket server that forwards the messages it receives, the first
+// connection is dropped after the first message
+func newTestServer(received chan<- string) *httptest.Server {
+	var upgrader websocket.Upgrader
+	var mtx sync.Mutex
+	connections := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		mtx.Lock()
+		connections++
+		first := connections == 1
+		mtx.Unlock()
+		conn.WriteMessage(websocket.TextMessage, []byte("hello"))
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			received <- string(data)
+			if first {
+				return
+			}
+		}
+	}))
+}
+
+func TestConn(t *testing.T) {
+	received := make(chan string, 10)
+	server := newTestServer(received)
+	defer server.Close()
+
+	states := make(chan State, 10)
+	messages := make(chan string, 10)
+	c := NewConn(Config{
+		URL:        "ws" + strings.TrimPrefix(server.URL, "http"),
+		MinBackoff: 10 * time.Millisecond,
+		OnConnect: func(c *Conn) error {
+			return c.Send("auth")
+		},
+		OnMessage: func(msgType int, data []byte) {
+			messages <- string(data)
+		},
+		OnStateChange: func(state State, err error) {
+			states <- state
+		},
+	})
+	if err := c.Send("dropped"); err != ErrNotConnected {
+		t.Errorf("Test failed. Expected a not connected error, got %v", err)
+	}
+	c.Subscribe("ticker", "ticker")
+	c.Subscribe("book", "book v1")
+	c.Subscribe("book", "book v2")
+	c.Subscribe("trades", "trades")
+	c.Unsubscribe("trades", "unsubscribe trades")
+
+	done := make(chan struct{})
+	go func() {
+		c.Run()
+		close(done)
+	}()
+	expect := func(ch <-chan string, expected ...string) {
+		for _, e := range expected {
+			select {
+			case v := <-ch:
+				if v != e {
+					t.Errorf("Test failed. Expected %q, got %q", e, v)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("Test failed. Timed out waiting for %q", e)
+			}
+		}
+	}
+
+	// The first connection is dropped after the auth message, the subscriptions are sent again
+	// once reconnected
+	expect(received, `"auth"`, `"auth"`, `"ticker"`, `"book v2"`)
+	expect(messages, "hello", "hello")
+	for c.State() != Connected {
+		time.Sleep(time.Millisecond)
+	}
+	c.Subscribe("trades", "trades")
+	expect(received, `"trades"`)
+
+	c.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Test failed. Run didn't return once closed")
+	}
+	close(states)
+	var history []State
+	for state := range states {
+		history = append(history, state)
+	}
+	disconnected := false
+	for _, state := range history {
+		disconnected = disconnected || state == Disconnected
+	}
+	if len(history) < 4 || history[0] != Connecting || !disconnected ||
+		history[len(history)-2] != Connected || history[len(history)-1] != Closed {
+		t.Errorf("Test failed. Unexpected state changes %v", history)
+	}
+}
+
+// newEchoServer returns a websocket server that echoes the messages it receives, pings are only
+// answered if respond is set
+func newEchoServer(respond bool) *httptest.Server {
+	var upgrader websocket.Upgrader
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if !respond {
+			conn.SetPingHandler(func(string) error { return nil })
+		}
+		for {
+			msgType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(msgType, data)
+		}
+	}))
+}
+
+func TestConnStale(t *testing.T) {
+	server := newEchoServer(true)
+	defer server.Close()
+
+	type change struct {
+		state State
+		err   error
+	}
+	changes := make(chan change, 10)
+	c := NewConn(Config{
+		URL:          "ws" + strings.TrimPrefix(server.URL, "http"),
+		PingInterval: 10 * time.Millisecond,
+		StaleTimeout: 100 * time.Millisecond,
+		OnStateChange: func(state State, err error) {
+			changes <- change{state, err}
+		},
+	})
+	go c.Run()
+	defer c.Close()
+	expect := func(state State, err error) {
+		select {
+		case ch := <-changes:
+			if ch.state != state || ch.err != err {
+				t.Fatalf("Test failed. Expected %s (%v), got %s (%v)", state, err, ch.state, ch.err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Test failed. Timed out waiting for %s", state)
+		}
+	}
+
+	// The pongs keep the connection alive, but they aren't messages
+	expect(Connecting, nil)
+	expect(Connected, nil)
+	expect(Stale, ErrStale)
+	if err := c.Subscribe("ticker", "ticker"); err != nil {
+		t.Errorf("Test failed. Unable to subscribe while stale: %s", err)
+	}
+	expect(Connected, nil)
+	expect(Stale, ErrStale)
+
+	c.Reconnect()
+	select {
+	case ch := <-changes:
+		if ch.state != Disconnected || ch.err == nil {
+			t.Errorf("Test failed. Expected the connection to be dropped, got %s (%v)", ch.state, ch.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Test failed. Timed out waiting for the connection to be dropped")
+	}
+}
+
+func TestConnHeartbeat(t *testing.T) {
+	server := newEchoServer(false)
+	defer server.Close()
+
+	disconnected := make(chan error, 10)
+	c := NewConn(Config{
+		URL:          "ws" + strings.TrimPrefix(server.URL, "http"),
+		PingInterval: 10 * time.Millisecond,
+		OnStateChange: func(state State, err error) {
+			if state == Disconnected {
+				disconnected <- err
+			}
+		},
+	})
+	go c.Run()
+	defer c.Close()
+
+	select {
+	case err := <-disconnected:
+		if e, ok := err.(interface{ Timeout() bool }); !ok || !e.Timeout() {
+			t.Errorf("Test failed. Expected a timeout, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Test failed. The connection wasn't dropped without pongs")
+	}
+}
+
+func TestConnCompression(t *testing.T) {
+	upgrader := websocket.Upgrader{EnableCompression: true}
+	extensions := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		extensions <- r.Header.Get("Sec-Websocket-Extensions")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("compressed ", 1000)))
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	for _, compression := range []bool{false, true} {
+		messages := make(chan string, 1)
+		c := NewConn(Config{
+			URL:               "ws" + strings.TrimPrefix(server.URL, "http"),
+			EnableCompression: compression,
+			OnMessage: func(msgType int, data []byte) {
+				messages <- string(data)
+			},
+		})
+		go c.Run()
+		select {
+		case msg := <-messages:
+			if msg != strings.Repeat("compressed ", 1000) {
+				t.Errorf("Test failed. Unexpected message %q", msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Test failed. Timed out waiting for a message")
+		}
+		c.Close()
+		if negotiated := strings.Contains(<-extensions, "permessage-deflate"); negotiated != compression {
+			t.Errorf("Test failed. Expected compression to be negotiated %t, got %t", compression, negotiated)
+		}
+	}
+	if websocket.DefaultDialer.EnableCompression {
+		t.Error("Test failed. The default dialer was modified")
+	}
+}
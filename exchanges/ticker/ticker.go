@@ -3,9 +3,11 @@ package ticker
 import (
 	"errors"
 	"strconv"
+	"time"
 
 	"github.com/mattkanwisher/cryptofiend/common"
 	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/asset"
 )
 
 // Const values for the ticker package
@@ -14,7 +16,12 @@ const (
 	ErrPrimaryCurrencyNotFound   = "Error primary currency for ticker not found."
 	ErrSecondaryCurrencyNotFound = "Error secondary currency for ticker not found."
 
-	Spot = "SPOT"
+	// Asset types the tickers are keyed by, see the asset package
+	Spot          = asset.Spot
+	Margin        = asset.Margin
+	Futures       = asset.Futures
+	PerpetualSwap = asset.PerpetualSwap
+	Options       = asset.Options
 )
 
 // Vars for the ticker package
@@ -33,6 +40,7 @@ type Price struct {
 	Ask          float64           `json:"Ask"`
 	Volume       float64           `json:"Volume"`
 	PriceATH     float64           `json:"PriceATH"`
+	LastUpdated  time.Time         `json:"LastUpdated"`
 }
 
 // Ticker struct holds the ticker information for a currency pair and type
@@ -139,6 +147,9 @@ func CreateNewTicker(exchangeName string, p pair.CurrencyPair, tickerNew Price,
 // list
 func ProcessTicker(exchangeName string, p pair.CurrencyPair, tickerNew Price, tickerType string) {
 	tickerNew.CurrencyPair = p.Pair().String()
+	if tickerNew.LastUpdated.IsZero() {
+		tickerNew.LastUpdated = time.Now()
+	}
 	if len(Tickers) == 0 {
 		CreateNewTicker(exchangeName, p, tickerNew, tickerType)
 		return
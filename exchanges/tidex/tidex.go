@@ -0,0 +1,47 @@
+package tidex
+
+import (
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/btce"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+const tidexBaseURL = "https://api.tidex.com"
+
+// Tidex is the client of the Tidex exchange, which implements the BTC-e API. Tidex returns the
+// active orders of all the pairs in a single request, but only the available balances.
+type Tidex struct {
+	btce.Client
+}
+
+var _ exchange.IExchange = (*Tidex)(nil)
+var _ exchange.ContextExchange = (*Tidex)(nil)
+
+// New returns a Tidex exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *Tidex {
+	t := &Tidex{}
+	t.SetDefaults()
+	t.Quickstart(t.Setup, apiKey, apiSecret, opts...)
+	return t
+}
+
+// SetDefaults sets the basic defaults for Tidex
+func (t *Tidex) SetDefaults() {
+	t.Name = "Tidex"
+	t.APIUrl = tidexBaseURL
+	t.Enabled = false
+	t.Fee = 0.1
+	t.Verbose = false
+	t.Websocket = false
+	t.RESTPollingDelay = 10
+	t.RequestCurrencyPairFormat.Delimiter = "_"
+	t.RequestCurrencyPairFormat.Uppercase = false
+	t.RequestCurrencyPairFormat.Separator = "-"
+	t.ConfigCurrencyPairFormat.Delimiter = "_"
+	t.ConfigCurrencyPairFormat.Uppercase = true
+	t.AssetTypes = []string{ticker.Spot}
+	t.Orderbooks = orderbook.Init()
+	t.AllPairsActiveOrders = true
+}
@@ -0,0 +1,73 @@
+package tidex
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+func TestTidex(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/3/info":
+			fmt.Fprint(w, `{"server_time":1542682259,"pairs":{"eth_btc":{"decimal_places":8,
+				"min_amount":0.001,"hidden":0,"fee":0.1}}}`)
+		case "/tapi/":
+			r.ParseForm()
+			switch r.PostForm.Get("method") {
+			case "getInfo":
+				fmt.Fprint(w, `{"success":1,"return":{"funds":{"btc":0.5,"eth":1},
+					"rights":{"info":1,"trade":1,"withdraw":0},"open_orders":1,"server_time":1542682259}}`)
+			case "ActiveOrders":
+				fmt.Fprint(w, `{"success":1,"return":{"1001":{"pair":"eth_btc","type":"sell","amount":2,
+					"rate":0.0335,"timestamp_created":1542682259,"status":0}}}`)
+			default:
+				fmt.Fprintf(w, `{"success":0,"error":"unexpected params %s"}`, r.PostForm.Encode())
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tidex := Tidex{}
+	tidex.SetDefaults()
+	if tidex.GetName() != "Tidex" || tidex.APIUrl != tidexBaseURL {
+		t.Fatalf("Test failed. Unexpected defaults %s %s", tidex.GetName(), tidex.APIUrl)
+	}
+	tidex.APIUrl = server.URL
+	tidex.AuthenticatedAPISupport = true
+	tidex.SetAPIKeys("key", "secret", "", false)
+
+	info, err := tidex.GetInfo()
+	if err != nil {
+		t.Fatalf("Test failed. GetInfo returned an error: %s", err)
+	}
+	tidex.Info = info
+	if fee, err := tidex.GetFee("ETH_BTC"); err != nil || fee != 0.1 {
+		t.Errorf("Test failed. Expected the fee of the pair, got %v %v", fee, err)
+	}
+	if tidex.GetLimits().GetMinAmount(pair.NewCurrencyPairDelimiter("ETH_BTC", "_")) != 0.001 {
+		t.Error("Test failed. Expected the min amount of the pair")
+	}
+
+	// The holds are derived from the open orders
+	account, err := tidex.GetExchangeAccountInfo()
+	if err != nil {
+		t.Fatalf("Test failed. GetExchangeAccountInfo returned an error: %s", err)
+	}
+	for _, balance := range account.Currencies {
+		if balance.CurrencyName == "ETH" && (balance.Hold != 2 || balance.TotalValue != 3) {
+			t.Errorf("Test failed. Unexpected ETH balance %+v", balance)
+		}
+	}
+
+	_, err = tidex.WithdrawCoins("btc", 1, "address")
+	if e, ok := err.(*exchange.ExchangeError); !ok || e.Endpoint != "WithdrawCoin" {
+		t.Errorf("Test failed. Expected an exchange error, got %v", err)
+	}
+}
@@ -44,6 +44,15 @@ type WEX struct {
 	Ticker map[string]Ticker
 }
 
+// New returns a WEX exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *WEX {
+	w := &WEX{}
+	w.SetDefaults()
+	w.Quickstart(w.Setup, apiKey, apiSecret, opts...)
+	return w
+}
+
 // SetDefaults sets current default value for WEX
 func (w *WEX) SetDefaults() {
 	w.Name = "WEX"
@@ -289,6 +298,8 @@ func (w *WEX) RedeemCoupon(coupon string) (RedeemCoupon, error) {
 
 // SendAuthenticatedHTTPRequest sends an authenticated HTTP request to WEX
 func (w *WEX) SendAuthenticatedHTTPRequest(method string, values url.Values, result interface{}) (err error) {
+	apiKey, apiSecret, _ := w.Credentials()
+
 	if !w.AuthenticatedAPISupport {
 		return fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, w.Name)
 	}
@@ -302,14 +313,14 @@ func (w *WEX) SendAuthenticatedHTTPRequest(method string, values url.Values, res
 	values.Set("method", method)
 
 	encoded := values.Encode()
-	hmac := common.GetHMAC(common.HashSHA512, []byte(encoded), []byte(w.APISecret))
+	hmac := common.GetHMAC(common.HashSHA512, []byte(encoded), []byte(apiSecret))
 
 	if w.Verbose {
 		log.Printf("Sending POST request to %s calling method %s with params %s\n", wexAPIPrivateURL, method, encoded)
 	}
 
 	headers := make(map[string]string)
-	headers["Key"] = w.APIKey
+	headers["Key"] = apiKey
 	headers["Sign"] = common.HexEncodeToString(hmac)
 	headers["Content-Type"] = "application/x-www-form-urlencoded"
 
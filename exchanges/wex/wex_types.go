@@ -44,7 +44,7 @@ type Trades struct {
 // ActiveOrders stores active order information
 type ActiveOrders struct {
 	Pair             string  `json:"pair"`
-	Type             string  `json:"sell"`
+	Type             string  `json:"type"`
 	Amount           float64 `json:"amount"`
 	Rate             float64 `json:"rate"`
 	TimestampCreated float64 `json:"time_created"`
@@ -62,7 +62,7 @@ func (w *WEX) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.Pric
 }
 
 // GetOrderbookEx returns the orderbook for a currency pair
-func (w *WEX) GetOrderbookEx(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+func (w *WEX) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
 	ob, err := w.Orderbooks.GetOrderbook(w.GetName(), p, assetType)
 	if err == nil {
 		return w.UpdateOrderbook(p, assetType)
@@ -105,10 +105,24 @@ func (w *WEX) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
 	for x, y := range accountBalance.Funds {
 		var exchangeCurrency exchange.AccountCurrencyInfo
 		exchangeCurrency.CurrencyName = common.StringToUpper(x)
-		exchangeCurrency.TotalValue = y
-		exchangeCurrency.Hold = 0
+		exchangeCurrency.Available = y
 		response.Currencies = append(response.Currencies, exchangeCurrency)
 	}
 
+	// WEX only reports the available funds, so derive the holds from the open orders
+	activeOrders, err := w.GetActiveOrders("")
+	if err != nil {
+		return response, err
+	}
+	orders := make([]*exchange.Order, 0, len(activeOrders))
+	for _, order := range activeOrders {
+		orders = append(orders, &exchange.Order{
+			CurrencyPair: pair.NewCurrencyPairDelimiter(order.Pair, w.RequestCurrencyPairFormat.Delimiter),
+			Side:         exchange.OrderSide(order.Type),
+			Amount:       order.Amount,
+			Rate:         order.Rate,
+		})
+	}
+	exchange.SetHoldsFromAvailable(&response, orders)
 	return response, nil
 }
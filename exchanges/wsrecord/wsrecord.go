@@ -0,0 +1,202 @@
+// Package wsrecord records the raw frames received from the exchange websockets, and replays
+// recorded sessions through the stream parsers so they can be tested against real traffic
+// (including the malformed & out-of-order messages the exchanges occasionally send).
+package wsrecord
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Frame is a single websocket frame received from an exchange
+type Frame struct {
+	Exchange string    `json:"exchange"`
+	Time     time.Time `json:"time"`
+	// Websocket message type, e.g. websocket.TextMessage
+	Type int `json:"type"`
+	// Raw payload, stored as a string since malformed frames may not be valid JSON
+	Data string `json:"data"`
+}
+
+// Recorder records the frames received from the exchange websockets, it's called from the
+// websocket goroutines of the exchanges so it must be safe for concurrent use
+type Recorder interface {
+	Record(exchangeName string, msgType int, data []byte, t time.Time) error
+}
+
+// Writer records frames as JSON encoded lines
+type Writer struct {
+	m      sync.Mutex
+	w      *bufio.Writer
+	closer io.Closer
+}
+
+// NewWriter returns a writer that records frames to w
+func NewWriter(w io.Writer) *Writer {
+	writer := &Writer{w: bufio.NewWriter(w)}
+	if closer, ok := w.(io.Closer); ok {
+		writer.closer = closer
+	}
+	return writer
+}
+
+// Create creates (or appends to) a recording file
+func Create(path string) (*Writer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter(f), nil
+}
+
+// Record records a frame received from an exchange. Frames are flushed as they're recorded so
+// that the recording is complete up to the point a parser failed.
+func (w *Writer) Record(exchangeName string, msgType int, data []byte, t time.Time) error {
+	line, err := json.Marshal(Frame{Exchange: exchangeName, Time: t, Type: msgType, Data: string(data)})
+	if err != nil {
+		return err
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	if _, err = w.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return w.w.Flush()
+}
+
+// Close flushes the recorded frames, and closes the underlying writer if it's an io.Closer
+func (w *Writer) Close() error {
+	w.m.Lock()
+	defer w.m.Unlock()
+	err := w.w.Flush()
+	if w.closer != nil {
+		if closeErr := w.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// ErrFrameDropped is returned by a Channel when a frame is dropped because the channel is full
+var ErrFrameDropped = errors.New("frame dropped, the channel is full")
+
+// Channel mirrors the frames to a channel, e.g. to inspect the live traffic of an exchange. The
+// exchange websocket isn't blocked by a slow reader, frames are dropped while the channel is full.
+type Channel struct {
+	ch chan<- Frame
+}
+
+// NewChannel returns a recorder that sends the frames to ch
+func NewChannel(ch chan<- Frame) *Channel {
+	return &Channel{ch: ch}
+}
+
+// Record sends the frame to the channel, ErrFrameDropped is returned if it's full
+func (c *Channel) Record(exchangeName string, msgType int, data []byte, t time.Time) error {
+	select {
+	case c.ch <- Frame{Exchange: exchangeName, Time: t, Type: msgType, Data: string(data)}:
+		return nil
+	default:
+		return ErrFrameDropped
+	}
+}
+
+// Tee returns a recorder that records the frames to all the recorders, the first error is
+// returned once every recorder has been called
+func Tee(recorders ...Recorder) Recorder {
+	return tee(recorders)
+}
+
+type tee []Recorder
+
+func (t tee) Record(exchangeName string, msgType int, data []byte, tm time.Time) error {
+	var err error
+	for _, r := range t {
+		if e := r.Record(exchangeName, msgType, data, tm); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// ReadAll reads all the frames of a recording
+func ReadAll(r io.Reader) ([]Frame, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var frames []Frame
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var f Frame
+		if err := json.Unmarshal(scanner.Bytes(), &f); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		frames = append(frames, f)
+	}
+	return frames, scanner.Err()
+}
+
+// ReadFile reads all the frames of a recording file
+func ReadFile(path string) ([]Frame, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadAll(f)
+}
+
+// Handler parses a replayed frame
+type Handler func(f Frame) error
+
+// FrameError is a frame the handler failed to parse
+type FrameError struct {
+	// Index of the frame in the recording
+	Index int
+	Frame Frame
+	Err   error
+}
+
+func (e FrameError) Error() string {
+	return fmt.Sprintf("frame %d: %s", e.Index, e.Err)
+}
+
+// ReplayResult summarises a replay
+type ReplayResult struct {
+	// Number of frames passed to the handler
+	Frames int
+	Errors []FrameError
+}
+
+// Replay passes the frames recorded from an exchange (all the frames if exchangeName is empty)
+// to the handler in order, as they'd have been received from the websocket. Errors don't stop the
+// replay, and a handler that panics is reported as an error for the frame.
+func Replay(frames []Frame, exchangeName string, handle Handler) ReplayResult {
+	var result ReplayResult
+	for i, f := range frames {
+		if exchangeName != "" && f.Exchange != exchangeName {
+			continue
+		}
+		result.Frames++
+		if err := replayFrame(f, handle); err != nil {
+			result.Errors = append(result.Errors, FrameError{Index: i, Frame: f, Err: err})
+		}
+	}
+	return result
+}
+
+func replayFrame(f Frame, handle Handler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return handle(f)
+}
@@ -0,0 +1,77 @@
+package wsrecord
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRecordReplay(t *testing.T) {
+	start := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	payloads := []string{`{"event":"info"}`, `[1,"hb"]`, `[1,{`, `[2,"hb"]`}
+	for i, p := range payloads {
+		exchangeName := "Bitfinex"
+		if i == 3 {
+			exchangeName = "Poloniex"
+		}
+		if err := w.Record(exchangeName, 1, []byte(p), start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatalf("Test failed. Record returned an error: %s", err)
+		}
+	}
+
+	frames, err := ReadAll(&buf)
+	if err != nil {
+		t.Fatalf("Test failed. ReadAll returned an error: %s", err)
+	}
+	if len(frames) != len(payloads) || frames[2].Data != payloads[2] || !frames[1].Time.Equal(start.Add(time.Second)) {
+		t.Fatalf("Test failed. Unexpected frames %+v", frames)
+	}
+
+	var handled []string
+	result := Replay(frames, "Bitfinex", func(f Frame) error {
+		handled = append(handled, f.Data)
+		switch f.Data {
+		case `[1,"hb"]`:
+			var m map[string]interface{}
+			m["hb"] = true
+		case `[1,{`:
+			return errors.New("unexpected end of JSON input")
+		}
+		return nil
+	})
+	if result.Frames != 3 || len(handled) != 3 {
+		t.Errorf("Test failed. Expected 3 Bitfinex frames to be replayed, got %d", result.Frames)
+	}
+	if len(result.Errors) != 2 || result.Errors[0].Index != 1 || result.Errors[1].Index != 2 {
+		t.Errorf("Test failed. Expected the panic & error to be reported, got %v", result.Errors)
+	}
+}
+
+func TestReadAllInvalid(t *testing.T) {
+	if _, err := ReadAll(bytes.NewBufferString("{\"exchange\":\"Bitfinex\"}\nnot json\n")); err == nil {
+		t.Error("Test failed. Expected an error for an invalid recording")
+	}
+}
+
+func TestChannelTee(t *testing.T) {
+	ch := make(chan Frame, 1)
+	var buf bytes.Buffer
+	r := Tee(NewChannel(ch), NewWriter(&buf))
+	now := time.Now()
+	if err := r.Record("Binance", 1, []byte(`{"e":"trade"}`), now); err != nil {
+		t.Fatalf("Test failed. Record returned an error: %s", err)
+	}
+	if err := r.Record("Binance", 1, []byte(`{"e":"depthUpdate"}`), now); err != ErrFrameDropped {
+		t.Errorf("Test failed. Expected the frame to be dropped, got %v", err)
+	}
+	if f := <-ch; f.Exchange != "Binance" || f.Data != `{"e":"trade"}` || !f.Time.Equal(now) {
+		t.Errorf("Test failed. Unexpected frame %+v", f)
+	}
+	// The frames dropped by the channel are still written
+	if frames, err := ReadAll(&buf); err != nil || len(frames) != 2 {
+		t.Errorf("Test failed. Expected 2 recorded frames, got %d %v", len(frames), err)
+	}
+}
@@ -0,0 +1,47 @@
+package yobit
+
+import (
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/btce"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+const yobitBaseURL = "https://yobit.net"
+
+// YoBit is the client of the YoBit exchange, which implements the BTC-e API. YoBit only returns the
+// active orders of a single pair, and the balances are returned both with & without the amounts
+// held by open orders.
+type YoBit struct {
+	btce.Client
+}
+
+var _ exchange.IExchange = (*YoBit)(nil)
+var _ exchange.ContextExchange = (*YoBit)(nil)
+
+// New returns a YoBit exchange set up with the API keys (empty for public data only) without a
+// config file, opts customize the default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *YoBit {
+	y := &YoBit{}
+	y.SetDefaults()
+	y.Quickstart(y.Setup, apiKey, apiSecret, opts...)
+	return y
+}
+
+// SetDefaults sets the basic defaults for YoBit
+func (y *YoBit) SetDefaults() {
+	y.Name = "YoBit"
+	y.APIUrl = yobitBaseURL
+	y.Enabled = false
+	y.Fee = 0.2
+	y.Verbose = false
+	y.Websocket = false
+	y.RESTPollingDelay = 10
+	y.RequestCurrencyPairFormat.Delimiter = "_"
+	y.RequestCurrencyPairFormat.Uppercase = false
+	y.RequestCurrencyPairFormat.Separator = "-"
+	y.ConfigCurrencyPairFormat.Delimiter = "_"
+	y.ConfigCurrencyPairFormat.Uppercase = true
+	y.AssetTypes = []string{ticker.Spot}
+	y.Orderbooks = orderbook.Init()
+}
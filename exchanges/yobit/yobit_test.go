@@ -0,0 +1,57 @@
+package yobit
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+func TestYoBit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/3/info":
+			fmt.Fprint(w, `{"server_time":1542682259,"pairs":{"eth_btc":{"decimal_places":8,
+				"min_amount":0.0001,"min_total":0.0001,"hidden":0,"fee":0.2}}}`)
+		case "/tapi/":
+			r.ParseForm()
+			if r.PostForm.Get("method") != "Trade" || r.PostForm.Get("pair") != "eth_btc" ||
+				r.PostForm.Get("type") != "buy" {
+				fmt.Fprintf(w, `{"success":0,"error":"unexpected params %s"}`, r.PostForm.Encode())
+				return
+			}
+			// filled immediately
+			fmt.Fprint(w, `{"success":1,"return":{"received":1,"remains":0,"order_id":0,"funds":{}}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	y := YoBit{}
+	y.SetDefaults()
+	if y.GetName() != "YoBit" || y.APIUrl != yobitBaseURL {
+		t.Fatalf("Test failed. Unexpected defaults %s %s", y.GetName(), y.APIUrl)
+	}
+	y.APIUrl = server.URL
+	y.AuthenticatedAPISupport = true
+	y.SetAPIKeys("key", "secret", "", false)
+
+	info, err := y.GetInfo()
+	if err != nil {
+		t.Fatalf("Test failed. GetInfo returned an error: %s", err)
+	}
+	y.Info = info
+	ethbtc := pair.NewCurrencyPairDelimiter("ETH_BTC", "_")
+	if y.GetLimits().GetMinTotal(ethbtc) != 0.0001 {
+		t.Error("Test failed. Expected the min total of the pair")
+	}
+
+	orderID, err := y.NewOrder(ethbtc, 1, 0.033, exchange.OrderSideBuy, exchange.OrderTypeExchangeLimit)
+	if err != nil || orderID != "" {
+		t.Errorf("Test failed. Expected an order filled immediately, got %s %v", orderID, err)
+	}
+}
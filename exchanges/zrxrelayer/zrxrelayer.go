@@ -0,0 +1,406 @@
+// Package zrxrelayer implements the 0x Standard Relayer API (v2), so that any relayer of 0x
+// orders can be configured as an exchange. 0x orders are signed by the maker with the private
+// key of their wallet (the API secret) and settled on-chain by the 0x exchange contract, the
+// funds stay in the wallet until an order is filled.
+//
+// The relayers identify tokens by their asset data (which embeds the token address), so the
+// tokens traded on a relayer must be known: WETH, ZRX & DAI are known by default and the Tokens
+// of the exchange config add to them.
+package zrxrelayer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	exchange "github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ethereum"
+)
+
+const (
+	zrxAPIVersion      = "/v2"
+	zrxAssetPairs      = "/asset_pairs"
+	zrxOrderBook       = "/orderbook"
+	zrxOrderConfig     = "/order_config"
+	zrxFeeRecipients   = "/fee_recipients"
+	zrxOrder           = "/order"
+	zrxOrders          = "/orders"
+	zrxMaxPerPage      = 100
+	zrxSymbolDelimiter = "-"
+	// Network ID of the Ethereum mainnet
+	zrxMainnet = 1
+	// 0x v2 exchange contract on the mainnet
+	zrxExchangeAddress = "0x4f833a24e1f95d70f028921e27040ca56e09ab0b"
+	// Asset proxy ID of ERC-20 tokens, the first 4 bytes of the asset data
+	zrxERC20ProxyID = "f47261b0"
+	// Signature type of signatures made with eth_sign (SignPersonalMessage)
+	zrxSignatureTypeEthSign = 0x03
+)
+
+// Tokens known by default, the mainnet deployments
+var defaultTokens = []config.TokenConfig{
+	{Symbol: "WETH", Address: "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2", Decimals: 18},
+	{Symbol: "ZRX", Address: "0xe41d2489571d322189246dafa5ebde1f4699f498", Decimals: 18},
+	{Symbol: "DAI", Address: "0x89d24a6b4ccb1b6faa2625fe562bdd9a23260359", Decimals: 18},
+}
+
+var (
+	// ErrUnknownToken is returned when trading a token whose address isn't known
+	ErrUnknownToken = errors.New("unknown token")
+	// ErrCancelOnChain is returned by CancelOrder, 0x orders can only be cancelled by the maker
+	// sending a transaction to the exchange contract
+	ErrCancelOnChain = errors.New("0x orders can only be cancelled on-chain")
+)
+
+// Hashes of the EIP-712 type definitions of the 0x v2 orders
+var (
+	eip712DomainSchemaHash = ethereum.Keccak256([]byte(
+		"EIP712Domain(string name,string version,address verifyingContract)"))
+	eip712OrderSchemaHash = ethereum.Keccak256([]byte("Order(address makerAddress," +
+		"address takerAddress,address feeRecipientAddress,address senderAddress," +
+		"uint256 makerAssetAmount,uint256 takerAssetAmount,uint256 makerFee,uint256 takerFee," +
+		"uint256 expirationTimeSeconds,uint256 salt,bytes makerAssetData,bytes takerAssetData)"))
+	eip712DomainName    = ethereum.Keccak256([]byte("0x Protocol"))
+	eip712DomainVersion = ethereum.Keccak256([]byte("2"))
+)
+
+// ZRXRelayer is the client of a relayer implementing the 0x Standard Relayer API, the API key
+// isn't used and the API secret is the hex encoded private key of the maker's wallet.
+type ZRXRelayer struct {
+	exchange.Base
+	// Reads the token balances of the maker's wallet, nil if no Ethereum node is configured
+	Node *ethereum.Node
+	// Address of the 0x exchange contract the relayer's orders are settled by
+	ExchangeAddress string
+	// Ethereum network the relayer's orders are on
+	NetworkID int
+
+	mtx sync.Mutex
+	// Tokens the relayer can trade keyed by symbol
+	tokens map[string]config.TokenConfig
+	// Maps symbol (exchange specific market identifier) to currency pair info
+	currencyPairs map[pair.CurrencyItem]*exchange.CurrencyPairInfo
+	// Maps symbol to the trading rules of the pair
+	symbolDetailsMap map[pair.CurrencyItem]*symbolDetails
+}
+
+// SetTokens replaces the tokens the relayer can trade with the default tokens & the given ones,
+// which take precedence.
+func (z *ZRXRelayer) SetTokens(tokens []config.TokenConfig) {
+	bySymbol := make(map[string]config.TokenConfig, len(defaultTokens)+len(tokens))
+	for _, list := range [][]config.TokenConfig{defaultTokens, tokens} {
+		for _, t := range list {
+			t.Symbol = strings.ToUpper(t.Symbol)
+			t.Address = strings.ToLower(t.Address)
+			bySymbol[t.Symbol] = t
+		}
+	}
+	z.mtx.Lock()
+	z.tokens = bySymbol
+	z.mtx.Unlock()
+}
+
+// Token returns the known token with the given symbol
+func (z *ZRXRelayer) Token(symbol string) (config.TokenConfig, error) {
+	z.mtx.Lock()
+	defer z.mtx.Unlock()
+	t, ok := z.tokens[strings.ToUpper(symbol)]
+	if !ok {
+		return t, fmt.Errorf("%s: %s", symbol, ErrUnknownToken)
+	}
+	return t, nil
+}
+
+// tokenByAssetData returns the known token with the given asset data
+func (z *ZRXRelayer) tokenByAssetData(assetData string) (config.TokenConfig, bool) {
+	z.mtx.Lock()
+	defer z.mtx.Unlock()
+	for _, t := range z.tokens {
+		if data, err := AssetData(t.Address); err == nil && data == strings.ToLower(assetData) {
+			return t, true
+		}
+	}
+	return config.TokenConfig{}, false
+}
+
+// CurrencyPairToSymbol converts a currency pair (e.g. ZRX/WETH) to a symbol (exchange specific
+// market identifier, e.g. ZRX-WETH).
+func (z *ZRXRelayer) CurrencyPairToSymbol(p pair.CurrencyPair) string {
+	return p.FirstCurrency.Upper().String() + zrxSymbolDelimiter + p.SecondCurrency.Upper().String()
+}
+
+// SymbolToCurrencyPair converts a symbol (exchange specific market identifier) to a currency pair.
+func (z *ZRXRelayer) SymbolToCurrencyPair(symbol string) (pair.CurrencyPair, error) {
+	parts := strings.Split(symbol, zrxSymbolDelimiter)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return pair.CurrencyPair{}, fmt.Errorf("invalid symbol '%s'", symbol)
+	}
+	return pair.NewCurrencyPair(strings.ToUpper(parts[0]), strings.ToUpper(parts[1])), nil
+}
+
+// FetchAssetPairs fetches all the asset pairs the relayer accepts orders for.
+func (z *ZRXRelayer) FetchAssetPairs() ([]AssetPair, error) {
+	var result []AssetPair
+	for page := 1; ; page++ {
+		values := url.Values{}
+		values.Set("page", strconv.Itoa(page))
+		values.Set("perPage", strconv.Itoa(zrxMaxPerPage))
+		response := AssetPairsResponse{}
+		if err := z.SendHTTPRequest(http.MethodGet, zrxAssetPairs, values, nil, &response); err != nil {
+			return nil, err
+		}
+		result = append(result, response.Records...)
+		if len(response.Records) == 0 || len(result) >= response.Total {
+			return result, nil
+		}
+	}
+}
+
+// FetchOrderBook fetches the first page of the bids & asks of an asset pair.
+func (z *ZRXRelayer) FetchOrderBook(baseAssetData, quoteAssetData string) (*OrderBook, error) {
+	return z.fetchOrderBook(context.Background(), baseAssetData, quoteAssetData)
+}
+
+func (z *ZRXRelayer) fetchOrderBook(ctx context.Context, baseAssetData,
+	quoteAssetData string) (*OrderBook, error) {
+	values := url.Values{}
+	values.Set("baseAssetData", baseAssetData)
+	values.Set("quoteAssetData", quoteAssetData)
+	values.Set("perPage", strconv.Itoa(zrxMaxPerPage))
+	response := OrderBook{}
+	err := z.SendHTTPRequestContext(ctx, http.MethodGet, zrxOrderBook, values, nil, &response)
+	return &response, err
+}
+
+// FetchOrderConfig fetches the fees & addresses the relayer requires in an order.
+func (z *ZRXRelayer) FetchOrderConfig(request *OrderConfigRequest) (*OrderConfig, error) {
+	return z.fetchOrderConfig(context.Background(), request)
+}
+
+func (z *ZRXRelayer) fetchOrderConfig(ctx context.Context,
+	request *OrderConfigRequest) (*OrderConfig, error) {
+	response := OrderConfig{}
+	err := z.SendHTTPRequestContext(ctx, http.MethodPost, zrxOrderConfig, nil, request, &response)
+	return &response, err
+}
+
+// FetchFeeRecipients fetches the first page of the addresses the relayer collects fees with.
+func (z *ZRXRelayer) FetchFeeRecipients() ([]string, error) {
+	values := url.Values{}
+	values.Set("perPage", strconv.Itoa(zrxMaxPerPage))
+	response := FeeRecipientsResponse{}
+	err := z.SendHTTPRequest(http.MethodGet, zrxFeeRecipients, values, nil, &response)
+	return response.Records, err
+}
+
+// FetchOrder fetches an order by hash, relayers only return the orders that are still fillable.
+func (z *ZRXRelayer) FetchOrder(orderHash string) (*OrderRecord, error) {
+	return z.fetchOrder(context.Background(), orderHash)
+}
+
+func (z *ZRXRelayer) fetchOrder(ctx context.Context, orderHash string) (*OrderRecord, error) {
+	response := OrderRecord{}
+	err := z.SendHTTPRequestContext(ctx, http.MethodGet, zrxOrder+"/"+orderHash, url.Values{}, nil, &response)
+	return &response, err
+}
+
+// FetchOrders fetches the first page of the fillable orders made by an address.
+func (z *ZRXRelayer) FetchOrders(makerAddress string) ([]OrderRecord, error) {
+	return z.fetchOrders(context.Background(), makerAddress)
+}
+
+func (z *ZRXRelayer) fetchOrders(ctx context.Context, makerAddress string) ([]OrderRecord, error) {
+	values := url.Values{}
+	values.Set("makerAddress", makerAddress)
+	values.Set("perPage", strconv.Itoa(zrxMaxPerPage))
+	response := OrdersResponse{}
+	err := z.SendHTTPRequestContext(ctx, http.MethodGet, zrxOrders, values, nil, &response)
+	return response.Records, err
+}
+
+// PlaceOrder signs an order with the maker's private key and submits it to the relayer, the
+// maker address & signature of the order are set. Returns the hash of the order.
+func (z *ZRXRelayer) PlaceOrder(order *Order) (string, error) {
+	return z.placeOrder(context.Background(), order)
+}
+
+func (z *ZRXRelayer) placeOrder(ctx context.Context, order *Order) (string, error) {
+	key, err := z.privateKey()
+	if err != nil {
+		return "", err
+	}
+	order.MakerAddress = strings.ToLower(key.Address())
+	hash, err := OrderHash(order)
+	if err != nil {
+		return "", err
+	}
+	sig, err := key.SignPersonalMessage(hash)
+	if err != nil {
+		return "", err
+	}
+	order.Signature = "0x" + hex.EncodeToString([]byte{sig.V}) + hex.EncodeToString(sig.R[:]) +
+		hex.EncodeToString(sig.S[:]) + hex.EncodeToString([]byte{zrxSignatureTypeEthSign})
+	if err = z.SendHTTPRequestContext(ctx, http.MethodPost, zrxOrder, nil, order, nil); err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(hash), nil
+}
+
+// AssetData returns the asset data of an ERC-20 token, the proxy ID followed by the token
+// address padded to 32 bytes
+func AssetData(token string) (string, error) {
+	address, err := ethereum.PackAddress(token)
+	if err != nil {
+		return "", err
+	}
+	return "0x" + zrxERC20ProxyID + strings.Repeat("00", 12) + hex.EncodeToString(address), nil
+}
+
+// OrderHash returns the EIP-712 hash of an order, which is signed by the maker and identifies
+// the order.
+func OrderHash(order *Order) ([]byte, error) {
+	var words [][]byte
+	for _, a := range []string{order.MakerAddress, order.TakerAddress, order.FeeRecipientAddress,
+		order.SenderAddress} {
+		w, err := packAddressWord(a)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, w)
+	}
+	for _, v := range []string{order.MakerAssetAmount, order.TakerAssetAmount, order.MakerFee,
+		order.TakerFee, order.ExpirationTimeSeconds, order.Salt} {
+		x, ok := new(big.Int).SetString(v, 10)
+		if !ok || x.Sign() < 0 || x.BitLen() > 256 {
+			return nil, fmt.Errorf("invalid uint256 '%s'", v)
+		}
+		words = append(words, ethereum.PackUint256(x))
+	}
+	for _, data := range []string{order.MakerAssetData, order.TakerAssetData} {
+		b, err := hex.DecodeString(strings.TrimPrefix(data, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid asset data '%s'", data)
+		}
+		words = append(words, ethereum.Keccak256(b))
+	}
+	structHash := ethereum.Keccak256(append([][]byte{eip712OrderSchemaHash}, words...)...)
+
+	exchangeAddress, err := packAddressWord(order.ExchangeAddress)
+	if err != nil {
+		return nil, err
+	}
+	domainHash := ethereum.Keccak256(eip712DomainSchemaHash, eip712DomainName, eip712DomainVersion,
+		exchangeAddress)
+	return ethereum.Keccak256([]byte{0x19, 0x01}, domainHash, structHash), nil
+}
+
+// packAddressWord returns an address padded to a 32 byte word, as encoded by abi.encode
+func packAddressWord(address string) ([]byte, error) {
+	b, err := ethereum.PackAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	return append(make([]byte, 12), b...), nil
+}
+
+// newSalt returns a random 256 bit salt, so that identical orders have different hashes
+func newSalt() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return new(big.Int).SetBytes(b).String(), nil
+}
+
+// Address returns the address of the maker's wallet, derived from the private key.
+func (z *ZRXRelayer) Address() (string, error) {
+	key, err := z.privateKey()
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(key.Address()), nil
+}
+
+// privateKey returns the private key of the maker's wallet.
+func (z *ZRXRelayer) privateKey() (*ethereum.PrivateKey, error) {
+	if !z.AuthenticatedAPISupport {
+		return nil, fmt.Errorf(exchange.WarningAuthenticatedRequestWithoutCredentialsSet, z.Name)
+	}
+	_, apiSecret, _ := z.Credentials()
+	key, err := ethereum.HexToPrivateKey(apiSecret)
+	if err != nil {
+		return nil, fmt.Errorf("%s API secret: %s", z.Name, err)
+	}
+	return key, nil
+}
+
+// SendHTTPRequest sends a request to the relayer, the query values are sent with the network ID
+// and the body (if not nil) is JSON encoded. The response is decoded into the result object if
+// it's not nil.
+func (z *ZRXRelayer) SendHTTPRequest(method, endpoint string, values url.Values, body interface{},
+	result interface{}) error {
+	return z.SendHTTPRequestContext(context.Background(), method, endpoint, values, body, result)
+}
+
+// SendHTTPRequestContext is SendHTTPRequest, the request is cancelled when the context is done
+func (z *ZRXRelayer) SendHTTPRequestContext(ctx context.Context, method, endpoint string,
+	values url.Values, body interface{}, result interface{}) error {
+	path := zrxAPIVersion + endpoint
+	if values != nil {
+		values.Set("networkId", strconv.Itoa(z.NetworkID))
+		path += "?" + values.Encode()
+	}
+	headers := make(http.Header)
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = common.JSONEncode(body); err != nil {
+			return err
+		}
+		headers.Set("Content-Type", "application/json")
+	}
+	if z.Debug(exchange.TraceHTTP) {
+		log.Printf("Request: %s %s %s\n", method, path, payload)
+	}
+
+	resp, statusCode, err := common.SendHTTPRequest2Context(ctx, method, z.APIUrl+path, headers,
+		bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	if z.Debug(exchange.TraceHTTP) {
+		log.Printf("Received raw: \n%s\n", resp)
+	}
+
+	if statusCode < 200 || statusCode > 299 {
+		errResp := ErrorResponse{}
+		if err = common.JSONDecode([]byte(resp), &errResp); err != nil || errResp.Reason == "" {
+			return exchange.NewExchangeError(z.Name, endpoint, statusCode, 0, "unexpected status", resp)
+		}
+		message := errResp.Reason
+		for _, v := range errResp.ValidationErrors {
+			message += fmt.Sprintf(", %s: %s", v.Field, v.Reason)
+		}
+		return exchange.NewExchangeError(z.Name, endpoint, statusCode, errResp.Code, message, resp)
+	}
+	if result == nil {
+		return nil
+	}
+	if err = common.JSONDecode([]byte(resp), result); err != nil {
+		return exchange.NewExchangeError(z.Name, endpoint, statusCode, 0, "failed to unmarshal response", resp)
+	}
+	return nil
+}
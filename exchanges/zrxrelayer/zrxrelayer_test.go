@@ -0,0 +1,221 @@
+package zrxrelayer
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ethereum"
+)
+
+const (
+	testPrivateKey = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	testAddress    = "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"
+	zrxAssetData   = "0xf47261b0000000000000000000000000e41d2489571d322189246dafa5ebde1f4699f498"
+	wethAssetData  = "0xf47261b0000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"
+	// Asset data of a token that isn't known
+	unknownAssetData = "0xf47261b00000000000000000000000001111111111111111111111111111111111111111"
+)
+
+func newTestZRXRelayer(handler http.HandlerFunc) (*ZRXRelayer, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	z := &ZRXRelayer{}
+	z.SetDefaults()
+	z.APIUrl = server.URL
+	z.Enabled = true
+	z.AuthenticatedAPISupport = true
+	z.SetAPIKeys("", testPrivateKey, "", false)
+	return z, server
+}
+
+func TestOrderHash(t *testing.T) {
+	// Type hashes of the 0x v2 exchange contract
+	if hex.EncodeToString(eip712OrderSchemaHash) != "770501f88a26ede5c04a20ef877969e961eb11fc13b78aaf414b633da0d4f86f" {
+		t.Error("Test failed. Unexpected order schema hash")
+	}
+	if hex.EncodeToString(eip712DomainSchemaHash) != "91ab3d17e3a50a9d89e63fd30b92be7f5336b03b287bb946787a83a9d62a2766" {
+		t.Error("Test failed. Unexpected domain schema hash")
+	}
+	if data, err := AssetData("0xE41d2489571d322189246DaFA5ebDe1F4699F498"); err != nil || data != zrxAssetData {
+		t.Errorf("Test failed. Unexpected asset data %s %v", data, err)
+	}
+
+	order := &Order{
+		MakerAddress:          testAddress,
+		TakerAddress:          ethereum.ZeroAddress,
+		FeeRecipientAddress:   ethereum.ZeroAddress,
+		SenderAddress:         ethereum.ZeroAddress,
+		MakerAssetAmount:      "1000",
+		TakerAssetAmount:      "2000",
+		MakerFee:              "0",
+		TakerFee:              "0",
+		ExpirationTimeSeconds: "1600000000",
+		Salt:                  "42",
+		MakerAssetData:        zrxAssetData,
+		TakerAssetData:        wethAssetData,
+		ExchangeAddress:       zrxExchangeAddress,
+	}
+	hash, err := OrderHash(order)
+	if err != nil || len(hash) != 32 {
+		t.Fatalf("Test failed. OrderHash returned %x %v", hash, err)
+	}
+	// Every field is part of the hash
+	order.Salt = "43"
+	if other, _ := OrderHash(order); string(other) == string(hash) {
+		t.Error("Test failed. The salt isn't part of the order hash")
+	}
+	order.Salt = "-1"
+	if _, err = OrderHash(order); err == nil {
+		t.Error("Test failed. OrderHash accepted a negative salt")
+	}
+}
+
+func TestSetMarketInfo(t *testing.T) {
+	z, server := newTestZRXRelayer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/asset_pairs" || r.URL.Query().Get("networkId") != "1" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"total":2,"page":1,"perPage":100,"records":[
+			{"assetDataA":{"assetData":"%s","minAmount":"10000000000000000000","maxAmount":"1","precision":5},
+			 "assetDataB":{"assetData":"%s","minAmount":"1000000000000000","maxAmount":"1","precision":6}},
+			{"assetDataA":{"assetData":"%s","minAmount":"0","maxAmount":"1","precision":5},
+			 "assetDataB":{"assetData":"%s","minAmount":"0","maxAmount":"1","precision":6}}]}`,
+			zrxAssetData, wethAssetData, unknownAssetData, wethAssetData)
+	})
+	defer server.Close()
+
+	assetPairs, err := z.FetchAssetPairs()
+	if err != nil {
+		t.Fatalf("Test failed. FetchAssetPairs returned an error: %s", err)
+	}
+	z.setMarketInfo(assetPairs)
+	if pairs := z.GetCurrencyPairs(); len(pairs) != 1 || pairs["ZRX-WETH"] == nil {
+		t.Errorf("Test failed. Unexpected currency pairs %+v", pairs)
+	}
+	limits := z.GetLimits()
+	zrxweth := pair.NewCurrencyPair("ZRX", "WETH")
+	if limits.GetAmountDecimalPlaces(zrxweth) != 5 || limits.GetMinAmount(zrxweth) != 10 ||
+		limits.GetMinTotal(zrxweth) != 0.001 {
+		t.Error("Test failed. Unexpected ZRX/WETH limits")
+	}
+
+	// configured tokens are known in addition to the default ones
+	z.SetTokens([]config.TokenConfig{{Symbol: "abc", Address: "0x1111111111111111111111111111111111111111", Decimals: 8}})
+	z.setMarketInfo(assetPairs)
+	if pairs := z.GetCurrencyPairs(); len(pairs) != 2 || pairs["ABC-WETH"] == nil {
+		t.Errorf("Test failed. Unexpected currency pairs %+v", pairs)
+	}
+}
+
+func TestUpdateOrderbook(t *testing.T) {
+	z, server := newTestZRXRelayer(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/v2/orderbook" || query.Get("baseAssetData") != zrxAssetData ||
+			query.Get("quoteAssetData") != wethAssetData {
+			http.NotFound(w, r)
+			return
+		}
+		// A half filled ask of 100 ZRX at 0.002 WETH & a bid of 50 ZRX at 0.0019 WETH
+		fmt.Fprintf(w, `{"asks":{"total":1,"page":1,"perPage":100,"records":[{"order":{
+				"makerAssetAmount":"100000000000000000000","takerAssetAmount":"200000000000000000",
+				"makerAssetData":"%s","takerAssetData":"%s"},
+				"metaData":{"remainingFillableTakerAssetAmount":"100000000000000000"}}]},
+			"bids":{"total":1,"page":1,"perPage":100,"records":[{"order":{
+				"makerAssetAmount":"95000000000000000","takerAssetAmount":"50000000000000000000",
+				"makerAssetData":"%s","takerAssetData":"%s"},"metaData":{}}]}}`,
+			zrxAssetData, wethAssetData, wethAssetData, zrxAssetData)
+	})
+	defer server.Close()
+
+	book, err := z.UpdateOrderbook(pair.NewCurrencyPair("ZRX", "WETH"), "SPOT")
+	if err != nil {
+		t.Fatalf("Test failed. UpdateOrderbook returned an error: %s", err)
+	}
+	if len(book.Asks) != 1 || book.Asks[0].Price != 0.002 || book.Asks[0].Amount != 50 ||
+		len(book.Bids) != 1 || book.Bids[0].Price != 0.0019 || book.Bids[0].Amount != 50 {
+		t.Errorf("Test failed. Unexpected orderbook %+v", book)
+	}
+	if _, err = z.UpdateOrderbook(pair.NewCurrencyPair("ABC", "WETH"), "SPOT"); err == nil {
+		t.Error("Test failed. UpdateOrderbook accepted an unknown token")
+	}
+}
+
+func TestNewOrder(t *testing.T) {
+	var submitted Order
+	z, server := newTestZRXRelayer(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/v2/order_config":
+			var request OrderConfigRequest
+			common.JSONDecode(body, &request)
+			if request.MakerAddress != testAddress || request.MakerAssetData != wethAssetData {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"code":100,"reason":"Validation failed"}`)
+				return
+			}
+			fmt.Fprint(w, `{"senderAddress":"0x0000000000000000000000000000000000000000",
+				"feeRecipientAddress":"0xb046140686d052fff581f63f8136cce132e857da",
+				"makerFee":"0","takerFee":"1000000000000000000"}`)
+		case "/v2/order":
+			if err := common.JSONDecode(body, &submitted); err != nil {
+				t.Errorf("Test failed. Invalid order %s", body)
+			}
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	orderID, err := z.NewOrder(pair.NewCurrencyPair("ZRX", "WETH"), 100, 0.0019, exchange.OrderSideBuy,
+		exchange.OrderTypeExchangeLimit)
+	if err != nil {
+		t.Fatalf("Test failed. NewOrder returned an error: %s", err)
+	}
+	if submitted.MakerAssetAmount != "190000000000000000" || submitted.TakerAssetAmount != "100000000000000000000" ||
+		submitted.MakerAssetData != wethAssetData || submitted.TakerAssetData != zrxAssetData ||
+		submitted.TakerFee != "1000000000000000000" || submitted.ExchangeAddress != zrxExchangeAddress ||
+		submitted.FeeRecipientAddress != "0xb046140686d052fff581f63f8136cce132e857da" {
+		t.Fatalf("Test failed. Unexpected order %+v", submitted)
+	}
+
+	hash, err := OrderHash(&submitted)
+	if err != nil || orderID != "0x"+hex.EncodeToString(hash) {
+		t.Fatalf("Test failed. Order ID %s doesn't match the order hash %x %v", orderID, hash, err)
+	}
+	sigBytes, err := hex.DecodeString(strings.TrimPrefix(submitted.Signature, "0x"))
+	if err != nil || len(sigBytes) != 66 || sigBytes[65] != zrxSignatureTypeEthSign {
+		t.Fatalf("Test failed. Unexpected signature %s", submitted.Signature)
+	}
+	sig := ethereum.Signature{V: sigBytes[0]}
+	copy(sig.R[:], sigBytes[1:33])
+	copy(sig.S[:], sigBytes[33:65])
+	signer, err := ethereum.RecoverAddress(ethereum.PersonalMessageHash(hash), sig)
+	if err != nil || strings.ToLower(signer) != testAddress {
+		t.Errorf("Test failed. Order signed by %s %v", signer, err)
+	}
+}
+
+func TestSendHTTPRequestError(t *testing.T) {
+	z, server := newTestZRXRelayer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"code":100,"reason":"Validation failed","validationErrors":[
+			{"field":"signature","code":1005,"reason":"Invalid signature"}]}`)
+	})
+	defer server.Close()
+
+	err := z.SendHTTPRequest(http.MethodPost, zrxOrder, nil, &Order{}, nil)
+	if e, ok := err.(*exchange.ExchangeError); !ok || e.Code != 100 || e.StatusCode != http.StatusBadRequest ||
+		e.Message != "Validation failed, signature: Invalid signature" {
+		t.Errorf("Test failed. Unexpected error %v", err)
+	}
+}
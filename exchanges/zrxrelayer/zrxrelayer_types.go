@@ -0,0 +1,111 @@
+package zrxrelayer
+
+// Asset is one side of an asset pair traded by the relayer, amounts are in base units
+type Asset struct {
+	AssetData string `json:"assetData"`
+	MinAmount string `json:"minAmount"`
+	MaxAmount string `json:"maxAmount"`
+	Precision int32  `json:"precision"`
+}
+
+// AssetPair is a pair of assets the relayer accepts orders for
+type AssetPair struct {
+	AssetDataA Asset `json:"assetDataA"`
+	AssetDataB Asset `json:"assetDataB"`
+}
+
+// AssetPairsResponse is a page of asset pairs
+type AssetPairsResponse struct {
+	Total   int         `json:"total"`
+	Page    int         `json:"page"`
+	PerPage int         `json:"perPage"`
+	Records []AssetPair `json:"records"`
+}
+
+// Order is a signed 0x v2 order, amounts are in base units & the expiration is a Unix time
+type Order struct {
+	MakerAddress          string `json:"makerAddress"`
+	TakerAddress          string `json:"takerAddress"`
+	FeeRecipientAddress   string `json:"feeRecipientAddress"`
+	SenderAddress         string `json:"senderAddress"`
+	MakerAssetAmount      string `json:"makerAssetAmount"`
+	TakerAssetAmount      string `json:"takerAssetAmount"`
+	MakerFee              string `json:"makerFee"`
+	TakerFee              string `json:"takerFee"`
+	ExpirationTimeSeconds string `json:"expirationTimeSeconds"`
+	Salt                  string `json:"salt"`
+	MakerAssetData        string `json:"makerAssetData"`
+	TakerAssetData        string `json:"takerAssetData"`
+	ExchangeAddress       string `json:"exchangeAddress"`
+	Signature             string `json:"signature"`
+}
+
+// OrderMetaData is the relayer specific information attached to an order, relayers that don't
+// track fills leave the remaining amount empty
+type OrderMetaData struct {
+	OrderHash                         string `json:"orderHash,omitempty"`
+	RemainingFillableTakerAssetAmount string `json:"remainingFillableTakerAssetAmount,omitempty"`
+}
+
+// OrderRecord is an order along with its metadata
+type OrderRecord struct {
+	Order    Order         `json:"order"`
+	MetaData OrderMetaData `json:"metaData"`
+}
+
+// OrdersResponse is a page of orders
+type OrdersResponse struct {
+	Total   int           `json:"total"`
+	Page    int           `json:"page"`
+	PerPage int           `json:"perPage"`
+	Records []OrderRecord `json:"records"`
+}
+
+// OrderBook is the orderbook of an asset pair, the bids are the orders that buy the base asset &
+// the asks the orders that sell it, both sorted from the best price
+type OrderBook struct {
+	Bids OrdersResponse `json:"bids"`
+	Asks OrdersResponse `json:"asks"`
+}
+
+// OrderConfigRequest asks the relayer for the fees & addresses to include in an order
+type OrderConfigRequest struct {
+	MakerAddress          string `json:"makerAddress"`
+	TakerAddress          string `json:"takerAddress"`
+	MakerAssetAmount      string `json:"makerAssetAmount"`
+	TakerAssetAmount      string `json:"takerAssetAmount"`
+	MakerAssetData        string `json:"makerAssetData"`
+	TakerAssetData        string `json:"takerAssetData"`
+	ExchangeAddress       string `json:"exchangeAddress"`
+	ExpirationTimeSeconds string `json:"expirationTimeSeconds"`
+}
+
+// OrderConfig holds the fees (in ZRX base units) & addresses the relayer requires in an order
+type OrderConfig struct {
+	SenderAddress       string `json:"senderAddress"`
+	FeeRecipientAddress string `json:"feeRecipientAddress"`
+	MakerFee            string `json:"makerFee"`
+	TakerFee            string `json:"takerFee"`
+}
+
+// FeeRecipientsResponse is a page of the addresses the relayer collects fees with
+type FeeRecipientsResponse struct {
+	Total   int      `json:"total"`
+	Page    int      `json:"page"`
+	PerPage int      `json:"perPage"`
+	Records []string `json:"records"`
+}
+
+// ValidationError is a field rejected by the relayer
+type ValidationError struct {
+	Field  string `json:"field"`
+	Code   int    `json:"code"`
+	Reason string `json:"reason"`
+}
+
+// ErrorResponse is returned by the relayer for rejected requests
+type ErrorResponse struct {
+	Code             int               `json:"code"`
+	Reason           string            `json:"reason"`
+	ValidationErrors []ValidationError `json:"validationErrors"`
+}
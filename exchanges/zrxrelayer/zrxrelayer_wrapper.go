@@ -0,0 +1,653 @@
+package zrxrelayer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ethereum"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+	"github.com/shopspring/decimal"
+)
+
+var _ exchange.IExchange = (*ZRXRelayer)(nil)
+var _ exchange.ContextExchange = (*ZRXRelayer)(nil)
+
+const (
+	zrxDefaultAPIURL = "https://api.radarrelay.com/0x"
+	// Time orders can be filled for after being placed
+	zrxOrderLifetime = 30 * 24 * time.Hour
+)
+
+// ErrNodeRequired is returned when reading balances without an Ethereum node configured, the
+// relayers don't hold any funds
+var ErrNodeRequired = errors.New("an Ethereum node is required to read the wallet balances")
+
+// New returns a 0x relayer set up with the hex encoded private key of the maker's wallet (empty
+// for public data only) without a config file, the API key is ignored. Use exchange.WithAPIURL
+// & exchange.WithName to connect to a relayer other than the default one, opts customize the
+// default settings.
+func New(apiKey, apiSecret string, opts ...exchange.Option) *ZRXRelayer {
+	z := &ZRXRelayer{}
+	z.SetDefaults()
+	z.Quickstart(z.Setup, apiKey, apiSecret, opts...)
+	return z
+}
+
+// SetDefaults sets the basic defaults for the 0x relayer
+func (z *ZRXRelayer) SetDefaults() {
+	z.Name = "ZRXRelayer"
+	z.APIUrl = zrxDefaultAPIURL
+	z.ExchangeAddress = zrxExchangeAddress
+	z.NetworkID = zrxMainnet
+	z.Enabled = false
+	z.Verbose = false
+	z.Websocket = false
+	z.RESTPollingDelay = 10
+	z.RequestCurrencyPairFormat.Delimiter = zrxSymbolDelimiter
+	z.RequestCurrencyPairFormat.Uppercase = true
+	z.ConfigCurrencyPairFormat.Delimiter = zrxSymbolDelimiter
+	z.ConfigCurrencyPairFormat.Uppercase = true
+	z.AssetTypes = []string{ticker.Spot}
+	z.Orderbooks = orderbook.Init()
+	z.SetTokens(nil)
+}
+
+// Setup takes in the supplied exchange configuration details and sets params
+func (z *ZRXRelayer) Setup(exch config.ExchangeConfig) {
+	if !exch.Enabled {
+		z.SetEnabled(false)
+	} else {
+		z.Enabled = true
+		if exch.Name != "" {
+			z.Name = exch.Name
+		}
+		// Relayers have no API keys, the private key alone is needed to sign orders
+		z.AuthenticatedAPISupport = exch.AuthenticatedAPISupport || exch.APISecret != ""
+		z.SetAPIKeys(exch.APIKey, exch.APISecret, "", false)
+		z.RESTPollingDelay = exch.RESTPollingDelay
+		z.Verbose = exch.Verbose
+		z.Websocket = exch.Websocket
+		z.SetAPIURL(exch)
+		if exch.EthereumNodeURL != "" {
+			z.Node = ethereum.NewNode(exch.EthereumNodeURL)
+		}
+		z.SetTokens(exch.Tokens)
+		z.BaseCurrencies = common.SplitStrings(exch.BaseCurrencies, ",")
+		z.AvailablePairs = common.SplitStrings(exch.AvailablePairs, ",")
+		z.EnabledPairs = common.SplitStrings(exch.EnabledPairs, ",")
+		err := z.SetCurrencyPairFormat()
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = z.SetAssetTypes()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// Start starts the 0x relayer go routine
+func (z *ZRXRelayer) Start() {
+	go z.Run()
+}
+
+// Run implements the 0x relayer wrapper
+func (z *ZRXRelayer) Run() {
+	if z.Debug("") {
+		log.Printf("%s polling delay: %ds.\n", z.GetName(), z.RESTPollingDelay)
+		log.Printf("%s %d currencies enabled: %s.\n", z.GetName(), len(z.EnabledPairs), z.EnabledPairs)
+	}
+
+	assetPairs, err := z.FetchAssetPairs()
+	if err != nil {
+		log.Printf("%s failed to get asset pairs\n", z.GetName())
+		return
+	}
+	z.setMarketInfo(assetPairs)
+
+	exchangeProducts := make([]string, 0, len(assetPairs))
+	for symbol := range z.GetCurrencyPairs() {
+		exchangeProducts = append(exchangeProducts, string(symbol))
+	}
+	err = z.UpdateAvailableCurrencies(exchangeProducts, false)
+	if err != nil {
+		log.Printf("%s failed to update available currencies\n", z.Name)
+	}
+}
+
+// setMarketInfo replaces the currency pairs & trading rules of the exchange, the asset pairs of
+// unknown tokens are skipped. Asset A is the base currency of the pair.
+func (z *ZRXRelayer) setMarketInfo(assetPairs []AssetPair) {
+	currencyPairs := make(map[pair.CurrencyItem]*exchange.CurrencyPairInfo, len(assetPairs))
+	details := make(map[pair.CurrencyItem]*symbolDetails, len(assetPairs))
+	for _, ap := range assetPairs {
+		base, ok := z.tokenByAssetData(ap.AssetDataA.AssetData)
+		if !ok {
+			continue
+		}
+		quote, ok := z.tokenByAssetData(ap.AssetDataB.AssetData)
+		if !ok {
+			continue
+		}
+		currencyPair := pair.NewCurrencyPair(base.Symbol, quote.Symbol)
+		currencyPairs[pair.CurrencyItem(z.CurrencyPairToSymbol(currencyPair))] = &exchange.CurrencyPairInfo{
+			Currency:           currencyPair,
+			FirstCurrencyName:  base.Symbol,
+			SecondCurrencyName: quote.Symbol,
+		}
+		minAmount, _ := fromBaseUnits(ap.AssetDataA.MinAmount, base.Decimals).Float64()
+		minTotal, _ := fromBaseUnits(ap.AssetDataB.MinAmount, quote.Decimals).Float64()
+		details[currencyPair.Display("/", false)] = &symbolDetails{
+			PriceDecimalPlaces:  -1,
+			AmountDecimalPlaces: ap.AssetDataA.Precision,
+			MinAmount:           minAmount,
+			MinTotal:            minTotal,
+		}
+	}
+	z.mtx.Lock()
+	z.currencyPairs = currencyPairs
+	z.symbolDetailsMap = details
+	z.mtx.Unlock()
+}
+
+// fromBaseUnits converts an amount in base units to an amount of the token, invalid amounts are
+// zero
+func fromBaseUnits(amount string, decimals int32) decimal.Decimal {
+	d, err := decimal.NewFromString(amount)
+	if err != nil {
+		return decimal.Zero
+	}
+	return d.Shift(-decimals)
+}
+
+// toBaseUnits converts an amount of a token to the token's base units, the fraction of a base
+// unit is truncated
+func toBaseUnits(amount decimal.Decimal, decimals int32) string {
+	return amount.Shift(decimals).Truncate(0).String()
+}
+
+// pairTokens returns the base & quote tokens of a currency pair
+func (z *ZRXRelayer) pairTokens(p pair.CurrencyPair) (base, quote config.TokenConfig, err error) {
+	if base, err = z.Token(p.FirstCurrency.String()); err != nil {
+		return
+	}
+	quote, err = z.Token(p.SecondCurrency.String())
+	return
+}
+
+// UpdateTicker updates and returns the ticker for a currency pair, the relayers don't publish
+// tickers so only the best bid & ask are set (from the orderbook)
+func (z *ZRXRelayer) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return z.UpdateTickerContext(context.Background(), p, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (z *ZRXRelayer) UpdateTickerContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
+	var tickerPrice ticker.Price
+	book, err := z.UpdateOrderbookContext(ctx, p, assetType)
+	if err != nil {
+		return tickerPrice, err
+	}
+	tickerPrice.Pair = p
+	if len(book.Asks) > 0 {
+		tickerPrice.Ask = book.Asks[0].Price
+	}
+	if len(book.Bids) > 0 {
+		tickerPrice.Bid = book.Bids[0].Price
+	}
+	tickerPrice.LastUpdated = time.Now()
+	ticker.ProcessTicker(z.GetName(), p, tickerPrice, assetType)
+	return ticker.GetTicker(z.Name, p, assetType)
+}
+
+// GetTickerPrice returns the ticker for a currency pair
+func (z *ZRXRelayer) GetTickerPrice(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	tick, err := ticker.GetTicker(z.GetName(), p, assetType)
+	if err != nil {
+		return z.UpdateTicker(p, assetType)
+	}
+	return tick, nil
+}
+
+// GetOrderbookEx returns the orderbook for a currency pair
+func (z *ZRXRelayer) GetOrderbookEx(p pair.CurrencyPair, assetType string, opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
+	ob, err := z.Orderbooks.GetOrderbook(z.GetName(), p, assetType)
+	if err != nil {
+		return z.UpdateOrderbook(p, assetType)
+	}
+	return ob, nil
+}
+
+// UpdateOrderbook updates and returns the orderbook for a currency pair, the amounts are the
+// remaining fillable amounts if the relayer tracks them
+func (z *ZRXRelayer) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return z.UpdateOrderbookContext(context.Background(), p, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (z *ZRXRelayer) UpdateOrderbookContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
+	book := orderbook.Base{}
+	base, quote, err := z.pairTokens(p)
+	if err != nil {
+		return book, err
+	}
+	baseData, err := AssetData(base.Address)
+	if err != nil {
+		return book, err
+	}
+	quoteData, err := AssetData(quote.Address)
+	if err != nil {
+		return book, err
+	}
+	depth, err := z.fetchOrderBook(ctx, baseData, quoteData)
+	if err != nil {
+		return book, err
+	}
+
+	// Asks sell the base token (the maker asset) for the quote token
+	book.Asks = orderbook.GetItems(len(depth.Asks.Records))
+	for _, r := range depth.Asks.Records {
+		maker := fromBaseUnits(r.Order.MakerAssetAmount, base.Decimals)
+		taker := fromBaseUnits(r.Order.TakerAssetAmount, quote.Decimals)
+		if maker.Sign() <= 0 {
+			continue
+		}
+		price, _ := taker.Div(maker).Float64()
+		amount, _ := maker.Mul(remainingFraction(&r)).Float64()
+		book.Asks = append(book.Asks, orderbook.Item{Price: price, Amount: amount})
+	}
+
+	// Bids buy the base token (the taker asset) with the quote token
+	book.Bids = orderbook.GetItems(len(depth.Bids.Records))
+	for _, r := range depth.Bids.Records {
+		maker := fromBaseUnits(r.Order.MakerAssetAmount, quote.Decimals)
+		taker := fromBaseUnits(r.Order.TakerAssetAmount, base.Decimals)
+		if taker.Sign() <= 0 {
+			continue
+		}
+		price, _ := maker.Div(taker).Float64()
+		amount, _ := taker.Mul(remainingFraction(&r)).Float64()
+		book.Bids = append(book.Bids, orderbook.Item{Price: price, Amount: amount})
+	}
+
+	z.Orderbooks.ProcessOrderbook(z.Name, p, book, assetType)
+	return z.Orderbooks.GetOrderbook(z.Name, p, assetType)
+}
+
+// remainingFraction returns the fraction of an order that can still be filled, 1 if the relayer
+// doesn't track fills
+func remainingFraction(r *OrderRecord) decimal.Decimal {
+	if r.MetaData.RemainingFillableTakerAssetAmount == "" {
+		return decimal.New(1, 0)
+	}
+	remaining, err := decimal.NewFromString(r.MetaData.RemainingFillableTakerAssetAmount)
+	taker, err2 := decimal.NewFromString(r.Order.TakerAssetAmount)
+	if err != nil || err2 != nil || taker.Sign() <= 0 {
+		return decimal.New(1, 0)
+	}
+	return decimal.Min(remaining.DivRound(taker, 18), decimal.New(1, 0))
+}
+
+// GetExchangeAccountInfo retrieves the balances held by the maker's wallet of the tokens of the
+// enabled pairs, the funds of 0x orders stay in the wallet until they're filled
+func (z *ZRXRelayer) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return z.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (z *ZRXRelayer) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
+	result := exchange.AccountInfo{}
+	result.ExchangeName = z.Name
+
+	if !z.Enabled {
+		return result, nil
+	}
+
+	balances, err := z.getWalletBalances(ctx)
+	if err != nil {
+		return result, err
+	}
+	for _, b := range balances {
+		result.Currencies = append(result.Currencies, exchange.AccountCurrencyInfo{
+			CurrencyName: b.Currency,
+			TotalValue:   b.Total,
+			Available:    b.Available,
+		})
+	}
+	return result, nil
+}
+
+// GetWalletBalances returns the balances held by the maker's wallet (the on-chain wallet) of the
+// tokens of the enabled pairs, read through the Ethereum node.
+func (z *ZRXRelayer) GetWalletBalances() ([]exchange.WalletBalance, error) {
+	return z.getWalletBalances(context.Background())
+}
+
+func (z *ZRXRelayer) getWalletBalances(ctx context.Context) ([]exchange.WalletBalance, error) {
+	if z.Node == nil {
+		return nil, ErrNodeRequired
+	}
+	address, err := z.Address()
+	if err != nil {
+		return nil, err
+	}
+	var result []exchange.WalletBalance
+	seen := make(map[string]bool)
+	for _, p := range z.GetEnabledCurrencies() {
+		for _, symbol := range []string{p.FirstCurrency.Upper().String(), p.SecondCurrency.Upper().String()} {
+			if seen[symbol] {
+				continue
+			}
+			seen[symbol] = true
+			t, err := z.Token(symbol)
+			if err != nil {
+				return nil, err
+			}
+			units, err := z.Node.TokenBalanceContext(ctx, t.Address, address)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", symbol, err)
+			}
+			amount, _ := decimal.NewFromBigInt(units, -t.Decimals).Float64()
+			result = append(result, exchange.WalletBalance{
+				Wallet:    exchange.WalletOnChain,
+				Currency:  symbol,
+				Total:     amount,
+				Available: amount,
+			})
+		}
+	}
+	return result, nil
+}
+
+// NewOrder signs a new order with the maker's private key & submits it to the relayer, the fees
+// & addresses required by the relayer are included in the order.
+// Returns the ID of the new exchange order, the hash of the order.
+func (z *ZRXRelayer) NewOrder(p pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return z.NewOrderContext(context.Background(), p, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (z *ZRXRelayer) NewOrderContext(ctx context.Context, p pair.CurrencyPair, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	if err := z.GetCapabilities().CheckOrderOptions(opts...); err != nil {
+		return "", err
+	}
+	if orderType != exchange.OrderTypeExchangeLimit {
+		panic("not implemented")
+	}
+	base, quote, err := z.pairTokens(p)
+	if err != nil {
+		return "", err
+	}
+	baseData, err := AssetData(base.Address)
+	if err != nil {
+		return "", err
+	}
+	quoteData, err := AssetData(quote.Address)
+	if err != nil {
+		return "", err
+	}
+	maker, err := z.Address()
+	if err != nil {
+		return "", err
+	}
+	baseAmount := toBaseUnits(decimal.NewFromFloat(amount), base.Decimals)
+	quoteAmount := toBaseUnits(decimal.NewFromFloat(amount).Mul(decimal.NewFromFloat(price)), quote.Decimals)
+
+	request := OrderConfigRequest{
+		MakerAddress:          maker,
+		TakerAddress:          ethereum.ZeroAddress,
+		MakerAssetAmount:      baseAmount,
+		TakerAssetAmount:      quoteAmount,
+		MakerAssetData:        baseData,
+		TakerAssetData:        quoteData,
+		ExchangeAddress:       z.ExchangeAddress,
+		ExpirationTimeSeconds: strconv.FormatInt(time.Now().Add(zrxOrderLifetime).Unix(), 10),
+	}
+	if side == exchange.OrderSideBuy {
+		request.MakerAssetAmount, request.TakerAssetAmount = quoteAmount, baseAmount
+		request.MakerAssetData, request.TakerAssetData = quoteData, baseData
+	}
+	orderConfig, err := z.fetchOrderConfig(ctx, &request)
+	if err != nil {
+		return "", err
+	}
+	salt, err := newSalt()
+	if err != nil {
+		return "", err
+	}
+	return z.placeOrder(ctx, &Order{
+		MakerAddress:          request.MakerAddress,
+		TakerAddress:          request.TakerAddress,
+		FeeRecipientAddress:   orderConfig.FeeRecipientAddress,
+		SenderAddress:         orderConfig.SenderAddress,
+		MakerAssetAmount:      request.MakerAssetAmount,
+		TakerAssetAmount:      request.TakerAssetAmount,
+		MakerFee:              orderConfig.MakerFee,
+		TakerFee:              orderConfig.TakerFee,
+		ExpirationTimeSeconds: request.ExpirationTimeSeconds,
+		Salt:                  salt,
+		MakerAssetData:        request.MakerAssetData,
+		TakerAssetData:        request.TakerAssetData,
+		ExchangeAddress:       request.ExchangeAddress,
+	})
+}
+
+// CancelOrder always fails with ErrCancelOnChain, the relayers can't cancel 0x orders.
+func (z *ZRXRelayer) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return z.CancelOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (z *ZRXRelayer) CancelOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) error {
+	return ErrCancelOnChain
+}
+
+// GetOrder returns information about a previously placed order, the relayers only return the
+// orders that are still fillable.
+func (z *ZRXRelayer) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	return z.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (z *ZRXRelayer) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	record, err := z.fetchOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	order, ok := z.convertOrderToExchangeOrder(record)
+	if !ok {
+		return nil, fmt.Errorf("%s order %s trades an unknown pair", z.Name, orderID)
+	}
+	return order, nil
+}
+
+// GetOrders returns information about currently active orders, the orders of all currency pairs
+// are returned if no pairs are given. Orders of unknown tokens are skipped.
+func (z *ZRXRelayer) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return z.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (z *ZRXRelayer) GetOrdersContext(ctx context.Context,
+	pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	maker, err := z.Address()
+	if err != nil {
+		return nil, err
+	}
+	records, err := z.fetchOrders(ctx, maker)
+	if err != nil {
+		return nil, err
+	}
+	ret := []*exchange.Order{}
+	for i := range records {
+		order, ok := z.convertOrderToExchangeOrder(&records[i])
+		if !ok {
+			continue
+		}
+		if len(pairs) > 0 {
+			found := false
+			for _, p := range pairs {
+				found = found || p.Equal(order.CurrencyPair)
+			}
+			if !found {
+				continue
+			}
+		}
+		ret = append(ret, order)
+	}
+	return ret, nil
+}
+
+// convertOrderToExchangeOrder converts an order of a known pair, returns false if the order
+// trades tokens that aren't a known pair
+func (z *ZRXRelayer) convertOrderToExchangeOrder(record *OrderRecord) (*exchange.Order, bool) {
+	makerToken, ok := z.tokenByAssetData(record.Order.MakerAssetData)
+	if !ok {
+		return nil, false
+	}
+	takerToken, ok := z.tokenByAssetData(record.Order.TakerAssetData)
+	if !ok {
+		return nil, false
+	}
+	pairs := z.GetCurrencyPairs()
+	retOrder := &exchange.Order{}
+	var baseAmount, quoteAmount decimal.Decimal
+	if info, exists := pairs[pair.CurrencyItem(makerToken.Symbol+zrxSymbolDelimiter+takerToken.Symbol)]; exists {
+		retOrder.CurrencyPair = info.Currency
+		retOrder.Side = exchange.OrderSideSell
+		baseAmount = fromBaseUnits(record.Order.MakerAssetAmount, makerToken.Decimals)
+		quoteAmount = fromBaseUnits(record.Order.TakerAssetAmount, takerToken.Decimals)
+	} else if info, exists := pairs[pair.CurrencyItem(takerToken.Symbol+zrxSymbolDelimiter+makerToken.Symbol)]; exists {
+		retOrder.CurrencyPair = info.Currency
+		retOrder.Side = exchange.OrderSideBuy
+		baseAmount = fromBaseUnits(record.Order.TakerAssetAmount, takerToken.Decimals)
+		quoteAmount = fromBaseUnits(record.Order.MakerAssetAmount, makerToken.Decimals)
+	} else {
+		return nil, false
+	}
+
+	retOrder.OrderID = record.MetaData.OrderHash
+	if retOrder.OrderID == "" {
+		if hash, err := OrderHash(&record.Order); err == nil {
+			retOrder.OrderID = "0x" + common.HexEncodeToString(hash)
+		}
+	}
+	// The relayers only return fillable orders
+	retOrder.Status = exchange.OrderStatusActive
+	retOrder.Amount, _ = baseAmount.Float64()
+	retOrder.RemainingAmount, _ = baseAmount.Mul(remainingFraction(record)).Float64()
+	retOrder.FilledAmount, _ = decimal.NewFromFloat(retOrder.Amount).
+		Sub(decimal.NewFromFloat(retOrder.RemainingAmount)).Float64()
+	if baseAmount.Sign() > 0 {
+		retOrder.Rate, _ = quoteAmount.Div(baseAmount).Float64()
+	}
+	// Only limit orders can be placed on the relayers
+	retOrder.Type = exchange.OrderTypeExchangeLimit
+
+	return retOrder, true
+}
+
+// GetLimits returns price/amount limits for the exchange.
+func (z *ZRXRelayer) GetLimits() exchange.ILimits {
+	z.mtx.Lock()
+	defer z.mtx.Unlock()
+	return newCurrencyLimits(z.Name, z.symbolDetailsMap)
+}
+
+// GetCurrencyPairs returns currency pairs that can be used by the exchange account
+// associated with this bot. Use FormatExchangeCurrency to get the right key.
+func (z *ZRXRelayer) GetCurrencyPairs() map[pair.CurrencyItem]*exchange.CurrencyPairInfo {
+	z.mtx.Lock()
+	defer z.mtx.Unlock()
+	return z.currencyPairs
+}
+
+// ListInstruments returns the asset pairs of known tokens that are currently trading on the
+// relayer
+func (z *ZRXRelayer) ListInstruments() ([]exchange.Instrument, error) {
+	assetPairs, err := z.FetchAssetPairs()
+	if err != nil {
+		return nil, err
+	}
+	var result []exchange.Instrument
+	for _, ap := range assetPairs {
+		base, ok := z.tokenByAssetData(ap.AssetDataA.AssetData)
+		if !ok {
+			continue
+		}
+		quote, ok := z.tokenByAssetData(ap.AssetDataB.AssetData)
+		if !ok {
+			continue
+		}
+		p := pair.NewCurrencyPair(base.Symbol, quote.Symbol)
+		result = append(result, exchange.Instrument{Symbol: z.CurrencyPairToSymbol(p), Pair: p})
+	}
+	return result, nil
+}
+
+type symbolDetails struct {
+	PriceDecimalPlaces  int32
+	AmountDecimalPlaces int32
+	MinAmount           float64
+	MinTotal            float64
+}
+
+type currencyLimits struct {
+	exchangeName string
+	// Maps pair (display format) to symbol details
+	data map[pair.CurrencyItem]*symbolDetails
+}
+
+func newCurrencyLimits(exchangeName string, data map[pair.CurrencyItem]*symbolDetails) *currencyLimits {
+	return &currencyLimits{exchangeName, data}
+}
+
+// Returns max number of decimal places allowed in the trade price for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetPriceDecimalPlaces(p pair.CurrencyPair) int32 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.PriceDecimalPlaces
+	}
+	return -1
+}
+
+// Returns max number of decimal places allowed in the trade amount for the given currency pair,
+// -1 should be used to indicate this value isn't defined.
+func (cl *currencyLimits) GetAmountDecimalPlaces(p pair.CurrencyPair) int32 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.AmountDecimalPlaces
+	}
+	return -1
+}
+
+// Returns the minimum trade amount for the given currency pair.
+func (cl *currencyLimits) GetMinAmount(p pair.CurrencyPair) float64 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.MinAmount
+	}
+	return 0
+}
+
+// Returns the minimum trade total (amount * price) for the given currency pair.
+func (cl *currencyLimits) GetMinTotal(p pair.CurrencyPair) float64 {
+	if v, exists := cl.data[p.Display("/", false)]; exists {
+		return v.MinTotal
+	}
+	return 0
+}
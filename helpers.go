@@ -9,6 +9,7 @@ import (
 	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
 	"github.com/mattkanwisher/cryptofiend/exchanges/stats"
 	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+	"github.com/mattkanwisher/cryptofiend/portfolio"
 )
 
 // GetSpecificOrderbook returns a specific orderbook given the currency,
@@ -105,3 +106,46 @@ func GetExchangeLowestPriceByCurrencyPair(p pair.CurrencyPair, assetType string)
 
 	return result[0].Exchange, nil
 }
+
+// portfolioExposureSource calculates the quote currency exposure from the exchange balances in
+// the portfolio, the enabled currency pairs & cached tickers of the enabled exchanges
+type portfolioExposureSource struct{}
+
+func (portfolioExposureSource) Holdings() map[string]float64 {
+	return portfolio.Portfolio.GetExchangePortfolio()
+}
+
+func (portfolioExposureSource) Pairs() []pair.CurrencyPair {
+	var pairs []pair.CurrencyPair
+	for _, exch := range bot.exchanges {
+		if exch != nil && exch.IsEnabled() {
+			pairs = append(pairs, exch.GetEnabledCurrencies()...)
+		}
+	}
+	return pairs
+}
+
+// Price returns the last price of the currency pair on the first enabled exchange with a fresh
+// price from the market data provider, or the first stale price if none of them are fresh.
+func (portfolioExposureSource) Price(p pair.CurrencyPair) (float64, error) {
+	var stale float64
+	for _, exch := range bot.exchanges {
+		if exch == nil || !exch.IsEnabled() {
+			continue
+		}
+		price, err := bot.marketData.GetPrice(exch.GetName(), p, ticker.Spot)
+		if err != nil || price.Last <= 0 {
+			continue
+		}
+		if !price.Provenance.Stale {
+			return price.Last, nil
+		}
+		if stale == 0 {
+			stale = price.Last
+		}
+	}
+	if stale > 0 {
+		return stale, nil
+	}
+	return 0, fmt.Errorf("no ticker for %s", p.Display("/", true))
+}
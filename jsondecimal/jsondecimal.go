@@ -0,0 +1,360 @@
+// Package jsondecimal encodes values as JSON with all floating point numbers (e.g. amounts &
+// prices) serialized as strings containing their exact decimal representation, instead of JSON
+// numbers that many clients decode into binary floats. The Decimal type can be used by clients to
+// decode such values without losing precision.
+package jsondecimal
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Marshal returns the JSON encoding of v, like json.Marshal except that float values are encoded
+// as decimal strings, e.g. 0.1 is encoded as "0.1" & 1e-8 as "0.00000001". Types implementing
+// json.Marshaler or encoding.TextMarshaler are encoded by their own methods, the ",string" option
+// of a field tag is honoured like with json.Marshal.
+func Marshal(v interface{}) ([]byte, error) {
+	converted, err := convert(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(converted)
+}
+
+// NewEncoder returns an encoder that writes values to w in the same format as Marshal.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encoder writes values to an output stream in the same format as Marshal, each value is
+// followed by a newline like with json.Encoder.
+type Encoder struct {
+	w io.Writer
+}
+
+// Encode writes the encoding of v to the stream.
+func (e *Encoder) Encode(v interface{}) error {
+	data, err := Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(append(data, '\n'))
+	return err
+}
+
+// FormatFloat returns the exact decimal representation of f, without an exponent.
+func FormatFloat(f float64, bitSize int) string {
+	return strconv.FormatFloat(f, 'f', -1, bitSize)
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func implementsMarshaler(t reflect.Type) bool {
+	return t.Implements(marshalerType) || t.Implements(textMarshalerType)
+}
+
+func convert(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface {
+		if implementsMarshaler(v.Type()) {
+			return rawJSON(v.Interface())
+		}
+		if v.CanAddr() && implementsMarshaler(reflect.PtrTo(v.Type())) {
+			return rawJSON(v.Addr().Interface())
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if v.Kind() == reflect.Ptr && implementsMarshaler(v.Type()) {
+			return rawJSON(v.Interface())
+		}
+		return convert(v.Elem())
+	case reflect.Float32:
+		return FormatFloat(v.Float(), 32), nil
+	case reflect.Float64:
+		return FormatFloat(v.Float(), 64), nil
+	case reflect.Struct:
+		return convertStruct(v)
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		m := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			name, err := mapKey(key)
+			if err != nil {
+				return nil, err
+			}
+			if m[name], err = convert(v.MapIndex(key)); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface(), nil
+		}
+		fallthrough
+	case reflect.Array:
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			var err error
+			if items[i], err = convert(v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return v.Interface(), nil
+}
+
+func rawJSON(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(data), nil
+}
+
+func mapKey(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+	if tm, ok := key.Interface().(encoding.TextMarshaler); ok {
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	}
+	return "", errors.New("jsondecimal: unsupported map key type " + key.Type().String())
+}
+
+type field struct {
+	name  string
+	value interface{}
+}
+
+// object is a JSON object that keeps the fields in the order of the struct they came from
+type object []field
+
+// MarshalJSON encodes the object, keeping the order of the fields.
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(o[i].name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o[i].value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func convertStruct(v reflect.Value) (object, error) {
+	var fields, embedded object
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if idx := strings.Index(tag, ","); idx != -1 {
+			name, opts = tag[:idx], tag[idx+1:]
+		}
+		fv := v.Field(i)
+
+		// the fields of embedded structs are promoted to the outer struct
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && !implementsMarshaler(f.Type) {
+				if fv.Kind() == reflect.Ptr {
+					if fv.IsNil() {
+						continue
+					}
+					fv = fv.Elem()
+				}
+				promoted, err := convertStruct(fv)
+				if err != nil {
+					return nil, err
+				}
+				embedded = append(embedded, promoted...)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if hasOption(opts, "omitempty") && isEmptyValue(fv) {
+			continue
+		}
+		if hasOption(opts, "string") {
+			if value, ok := quote(fv); ok {
+				fields = append(fields, field{name: name, value: value})
+				continue
+			}
+		}
+		value, err := convert(fv)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field{name: name, value: value})
+	}
+
+	// fields of the outer struct take precedence over promoted fields with the same name
+	for _, promoted := range embedded {
+		duplicate := false
+		for i := range fields {
+			if fields[i].name == promoted.name {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			fields = append(fields, promoted)
+		}
+	}
+	return fields, nil
+}
+
+// quote returns the encoding of a field with the ",string" option, ok is false if the option
+// doesn't apply to the type of the field (or the field is a nil pointer).
+func quote(v reflect.Value) (value string, ok bool) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+	if implementsMarshaler(v.Type()) || implementsMarshaler(reflect.PtrTo(v.Type())) {
+		return "", false
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32:
+		return FormatFloat(v.Float(), 32), true
+	case reflect.Float64:
+		return FormatFloat(v.Float(), 64), true
+	case reflect.String:
+		// the string is encoded as a JSON string inside a JSON string
+		data, err := json.Marshal(v.String())
+		if err != nil {
+			return "", false
+		}
+		return string(data), true
+	}
+	return "", false
+}
+
+func hasOption(opts, option string) bool {
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// Decimal holds the exact decimal representation of a number, it can be decoded from a JSON
+// string (as encoded by Marshal) or a JSON number.
+type Decimal string
+
+// UnmarshalJSON decodes a decimal from a JSON string or number.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = ""
+		return nil
+	}
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return errors.New("jsondecimal: invalid decimal " + s)
+	}
+	*d = Decimal(s)
+	return nil
+}
+
+// MarshalJSON encodes the decimal as a JSON string.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(d))
+}
+
+// String returns the decimal representation.
+func (d Decimal) String() string {
+	return string(d)
+}
+
+// Float64 returns the nearest float64 to the decimal, zero is returned for an empty decimal.
+func (d Decimal) Float64() (float64, error) {
+	if d == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(string(d), 64)
+}
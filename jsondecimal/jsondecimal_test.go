@@ -0,0 +1,85 @@
+package jsondecimal
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type inner struct {
+	Fee float64 `json:"fee"`
+}
+
+type order struct {
+	inner
+	ID        string             `json:"id"`
+	Amount    float64            `json:"amount"`
+	Price     float32            `json:"price"`
+	Count     int                `json:"count"`
+	Fills     []float64          `json:"fills"`
+	Totals    map[string]float64 `json:"totals,omitempty"`
+	Limit     *float64           `json:"limit,omitempty"`
+	Time      time.Time          `json:"time"`
+	Ignored   float64            `json:"-"`
+	NoTag     float64
+	Seq       int64    `json:"seq,string"`
+	Filled    bool     `json:",string"`
+	Note      string   `json:"note,string"`
+	Quoted    *float64 `json:"quoted,string"`
+	unexposed float64
+}
+
+func TestMarshal(t *testing.T) {
+	o := order{
+		inner:  inner{Fee: 0.001},
+		ID:     "1",
+		Amount: 0.30000000000000004,
+		Price:  6543.21,
+		Count:  3,
+		Fills:  []float64{1e-8, 12345678.9},
+		Time:   time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC),
+		NoTag:  1,
+		Seq:    42,
+		Filled: true,
+		Note:   "x",
+	}
+	data, err := Marshal(&o)
+	if err != nil {
+		t.Fatalf("Test failed. Marshal error: %s", err)
+	}
+	expected := `{"id":"1","amount":"0.30000000000000004","price":"6543.21","count":3,` +
+		`"fills":["0.00000001","12345678.9"],"time":"2018-01-02T03:04:05Z","NoTag":"1",` +
+		`"seq":"42","Filled":"true","note":"\"x\"","quoted":null,"fee":"0.001"}`
+	if string(data) != expected {
+		t.Errorf("Test failed. Expected %s, got %s", expected, data)
+	}
+
+	data, err = Marshal(map[string]interface{}{"a": 2.5, "b": []interface{}{nil, true}})
+	if err != nil {
+		t.Fatalf("Test failed. Marshal error: %s", err)
+	}
+	if string(data) != `{"a":"2.5","b":[null,true]}` {
+		t.Errorf("Test failed. Unexpected map encoding %s", data)
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	var decoded struct {
+		Amount Decimal `json:"amount"`
+		Price  Decimal `json:"price"`
+		Fee    Decimal `json:"fee"`
+	}
+	err := json.Unmarshal([]byte(`{"amount":"0.30000000000000004","price":6543.21,"fee":null}`), &decoded)
+	if err != nil {
+		t.Fatalf("Test failed. Unmarshal error: %s", err)
+	}
+	if decoded.Amount.String() != "0.30000000000000004" || decoded.Price != "6543.21" || decoded.Fee != "" {
+		t.Errorf("Test failed. Unexpected decimals %+v", decoded)
+	}
+	if f, err := decoded.Price.Float64(); err != nil || f != 6543.21 {
+		t.Errorf("Test failed. Expected 6543.21, got %v %v", f, err)
+	}
+	if err = json.Unmarshal([]byte(`{"amount":"abc"}`), &decoded); err == nil {
+		t.Error("Test failed. Expected error decoding an invalid decimal")
+	}
+}
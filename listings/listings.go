@@ -0,0 +1,173 @@
+// Package listings detects new listings & delistings on the exchanges by diffing the markets they
+// list on each refresh.
+package listings
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/storage"
+)
+
+const (
+	listingsBucket = "listings"
+	// Max number of events kept in memory
+	maxEvents = 1000
+)
+
+// EventType is the type of a listing event
+type EventType string
+
+// Listing event types
+const (
+	Listed   EventType = "listed"
+	Delisted EventType = "delisted"
+)
+
+// Event is emitted when a market is listed or delisted on an exchange
+type Event struct {
+	Exchange   string              `json:"exchange"`
+	Type       EventType           `json:"type"`
+	Instrument exchange.Instrument `json:"instrument"`
+	Time       time.Time           `json:"time"`
+}
+
+// Detector keeps track of the markets listed on each exchange
+type Detector struct {
+	mtx sync.Mutex
+	// Listed markets keyed by exchange name & symbol
+	known  map[string]map[string]exchange.Instrument
+	events []Event
+	// Called for every listing event
+	OnEvent func(e Event)
+}
+
+// NewDetector returns a detector that doesn't know about any markets yet
+func NewDetector() *Detector {
+	return &Detector{known: make(map[string]map[string]exchange.Instrument)}
+}
+
+// Update diffs the markets currently listed on an exchange against the ones previously listed,
+// returning the listing events. The first update of an exchange only records the listed markets.
+// Empty lists are ignored, exchanges occasionally return no markets while they're degraded.
+func (d *Detector) Update(exchangeName string, instruments []exchange.Instrument, now time.Time) []Event {
+	if len(instruments) == 0 {
+		return nil
+	}
+	current := make(map[string]exchange.Instrument, len(instruments))
+	for _, i := range instruments {
+		current[i.Symbol] = i
+	}
+
+	d.mtx.Lock()
+	prev, ok := d.known[exchangeName]
+	d.known[exchangeName] = current
+	if !ok {
+		d.mtx.Unlock()
+		return nil
+	}
+	var events []Event
+	for symbol, i := range current {
+		if _, ok := prev[symbol]; !ok {
+			events = append(events, Event{Exchange: exchangeName, Type: Listed, Instrument: i, Time: now})
+		}
+	}
+	for symbol, i := range prev {
+		if _, ok := current[symbol]; !ok {
+			events = append(events, Event{Exchange: exchangeName, Type: Delisted, Instrument: i, Time: now})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Instrument.Symbol < events[j].Instrument.Symbol })
+	d.events = append(d.events, events...)
+	if len(d.events) > maxEvents {
+		d.events = append([]Event(nil), d.events[len(d.events)-maxEvents:]...)
+	}
+	onEvent := d.OnEvent
+	d.mtx.Unlock()
+
+	if onEvent != nil {
+		for _, e := range events {
+			onEvent(e)
+		}
+	}
+	return events
+}
+
+// Refresh fetches the listed markets from each lister, keyed by exchange name, and returns the
+// listing events. Exchanges that fail are skipped until the next refresh.
+func (d *Detector) Refresh(listers map[string]exchange.InstrumentLister, now time.Time) ([]Event, error) {
+	var events []Event
+	var failed []string
+	for name, lister := range listers {
+		instruments, err := lister.ListInstruments()
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", name, err))
+			continue
+		}
+		events = append(events, d.Update(name, instruments, now)...)
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return events, fmt.Errorf("failed to list instruments on %s", strings.Join(failed, ", "))
+	}
+	return events, nil
+}
+
+// Events returns the listing events emitted since the given time, oldest first
+func (d *Detector) Events(since time.Time) []Event {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	var result []Event
+	for _, e := range d.events {
+		if !e.Time.Before(since) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Save writes the markets listed on each exchange to the store, so that listings made while the
+// bot isn't running are detected on the next refresh
+func (d *Detector) Save(s storage.Store) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	for name, instruments := range d.known {
+		list := make([]exchange.Instrument, 0, len(instruments))
+		for _, i := range instruments {
+			list = append(list, i)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Symbol < list[j].Symbol })
+		if err := s.Put(listingsBucket, name, list); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load replaces the known markets with the ones previously saved to the store, returns the
+// number of exchanges loaded
+func (d *Detector) Load(s storage.Store) (int, error) {
+	names, err := s.Keys(listingsBucket)
+	if err != nil {
+		return 0, err
+	}
+	known := make(map[string]map[string]exchange.Instrument, len(names))
+	for _, name := range names {
+		var list []exchange.Instrument
+		if err = s.Get(listingsBucket, name, &list); err != nil {
+			return 0, err
+		}
+		known[name] = make(map[string]exchange.Instrument, len(list))
+		for _, i := range list {
+			known[name][i.Symbol] = i
+		}
+	}
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.known = known
+	return len(known), nil
+}
@@ -0,0 +1,87 @@
+package listings
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/storage"
+)
+
+type mockLister struct {
+	instruments []exchange.Instrument
+	err         error
+}
+
+func (m *mockLister) ListInstruments() ([]exchange.Instrument, error) {
+	return m.instruments, m.err
+}
+
+func instruments(symbols ...string) []exchange.Instrument {
+	result := make([]exchange.Instrument, len(symbols))
+	for i, s := range symbols {
+		result[i] = exchange.Instrument{Symbol: s, Pair: pair.NewCurrencyPair(s[:3], s[3:])}
+	}
+	return result
+}
+
+func TestDetector(t *testing.T) {
+	now := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	d := NewDetector()
+	var emitted int
+	d.OnEvent = func(e Event) { emitted++ }
+
+	lister := &mockLister{instruments: instruments("BTCUSD", "ETHUSD")}
+	listers := map[string]exchange.InstrumentLister{"Binance": lister}
+	if events, err := d.Refresh(listers, now); err != nil || len(events) != 0 {
+		t.Fatalf("Test failed. Expected the first refresh to emit no events, got %v %v", events, err)
+	}
+
+	lister.instruments = instruments("BTCUSD", "XRPUSD")
+	events, err := d.Refresh(listers, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Test failed. Refresh returned an error: %s", err)
+	}
+	if len(events) != 2 || events[0].Type != Delisted || events[0].Instrument.Symbol != "ETHUSD" ||
+		events[1].Type != Listed || events[1].Instrument.Symbol != "XRPUSD" {
+		t.Errorf("Test failed. Unexpected events %+v", events)
+	}
+	if emitted != 2 {
+		t.Errorf("Test failed. Expected OnEvent to be called twice, got %d", emitted)
+	}
+
+	// failed & empty refreshes don't emit delistings
+	lister.err = errors.New("timeout")
+	if _, err = d.Refresh(listers, now.Add(2*time.Hour)); err == nil {
+		t.Error("Test failed. Expected Refresh to return an error")
+	}
+	lister.err = nil
+	lister.instruments = nil
+	if events, _ = d.Refresh(listers, now.Add(3*time.Hour)); len(events) != 0 {
+		t.Errorf("Test failed. Expected no events for an empty list, got %+v", events)
+	}
+	if events = d.Events(now.Add(time.Hour)); len(events) != 2 {
+		t.Errorf("Test failed. Expected 2 events but got %d", len(events))
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	now := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	store := storage.NewMemoryStore()
+	d := NewDetector()
+	d.Update("Bittrex", instruments("BTCUSD"), now)
+	if err := d.Save(store); err != nil {
+		t.Fatalf("Test failed. Save returned an error: %s", err)
+	}
+
+	// markets listed while the bot was down are detected after a restart
+	d = NewDetector()
+	if n, err := d.Load(store); err != nil || n != 1 {
+		t.Fatalf("Test failed. Expected 1 exchange to be loaded, got %d %v", n, err)
+	}
+	if events := d.Update("Bittrex", instruments("BTCUSD", "LTCUSD"), now); len(events) != 1 {
+		t.Errorf("Test failed. Expected 1 listing event but got %+v", events)
+	}
+}
@@ -2,18 +2,30 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
 	"runtime"
 	"strconv"
 	"syscall"
+	"time"
 
+	"github.com/mattkanwisher/cryptofiend/accounts"
+	"github.com/mattkanwisher/cryptofiend/analytics"
+	"github.com/mattkanwisher/cryptofiend/backfill"
 	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/conditional"
 	"github.com/mattkanwisher/cryptofiend/config"
 	"github.com/mattkanwisher/cryptofiend/currency"
+	"github.com/mattkanwisher/cryptofiend/currency/metadata"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/eventbus"
 	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/audit"
 	"github.com/mattkanwisher/cryptofiend/exchanges/bitfinex"
 	"github.com/mattkanwisher/cryptofiend/exchanges/bitstamp"
 	"github.com/mattkanwisher/cryptofiend/exchanges/bittrex"
@@ -30,10 +42,25 @@ import (
 	"github.com/mattkanwisher/cryptofiend/exchanges/localbitcoins"
 	"github.com/mattkanwisher/cryptofiend/exchanges/okcoin"
 	"github.com/mattkanwisher/cryptofiend/exchanges/poloniex"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ratelimit"
 	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
 	"github.com/mattkanwisher/cryptofiend/exchanges/wex"
+	"github.com/mattkanwisher/cryptofiend/exchanges/wsrecord"
+	"github.com/mattkanwisher/cryptofiend/listings"
+	"github.com/mattkanwisher/cryptofiend/marketdata"
+	"github.com/mattkanwisher/cryptofiend/pnl"
 	"github.com/mattkanwisher/cryptofiend/portfolio"
+	"github.com/mattkanwisher/cryptofiend/recorder"
+	"github.com/mattkanwisher/cryptofiend/risk"
 	"github.com/mattkanwisher/cryptofiend/smsglobal"
+	"github.com/mattkanwisher/cryptofiend/soak"
+	"github.com/mattkanwisher/cryptofiend/storage"
+	"github.com/mattkanwisher/cryptofiend/strategy"
+	"github.com/mattkanwisher/cryptofiend/sweep"
+	"github.com/mattkanwisher/cryptofiend/trace"
+	"github.com/mattkanwisher/cryptofiend/transfers"
+	"github.com/mattkanwisher/cryptofiend/webhooks"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 // ExchangeMain contains all the necessary exchange packages
@@ -67,18 +94,112 @@ type Bot struct {
 	exchange   ExchangeMain
 	exchanges  []exchange.IBotExchange
 	tickers    []ticker.Ticker
+	auditLog   *audit.Log
+	analytics  *analytics.Tracker
 	shutdown   chan bool
 	configFile string
+
+	// Maps exchange names to downtime simulators for exchanges with downtime simulation enabled
+	downtimeSimulators map[string]*exchange.DowntimeSimulator
+	// Maps exchange names to the exchanges that block new orders while their authenticated API is
+	// down
+	degradableExchanges map[string]*exchange.DegradableExchange
+	// Maps exchange names to the switches that pause trading on the exchange or specific pairs
+	tradingSwitches map[string]*exchange.TradingSwitch
+	// Tracks the published platform status of the exchanges, so pollers can back off during
+	// maintenance
+	statusMonitor *exchange.StatusMonitor
+	// Serves prices tagged with their provenance, falling back to secondary sources when needed
+	marketData *marketdata.Provider
+	// Persists the orders & portfolio across restarts
+	store storage.Store
+	// Snapshots of the total portfolio value, used for drawdown & return statistics
+	valuations *portfolio.ValuationHistory
+	// Tax lots used to calculate the realized P&L
+	taxLots *pnl.TaxLots
+	// Estimates the cost & duration of moving funds between exchanges
+	transfers *transfers.Estimator
+	// Detects new listings & delistings on the exchanges
+	listings *listings.Detector
+	// Names & deposit networks of the currencies supported by the exchanges
+	currencyMetadata *metadata.Registry
+	// Records the polled orderbooks for backtests
+	orderbookRecorder *recorder.Writer
+	// Record the raw frames received from the exchange websockets
+	websocketRecorders []*wsrecord.Writer
+	// Sweeps idle balances between the wallets of the exchanges
+	sweeper *sweep.Sweeper
+	// Synthetic conditional orders (trailing stops, OCO & scheduled orders)
+	conditionalOrders *conditional.Manager
+	// Strategies run by the bot, their parameters can be tuned through the REST server
+	strategies *strategy.Runner
+	// Aggregates the accounts of exchanges configured with multiple credential sets
+	accounts *accounts.Aggregator
+	// Maps exchange names to the exchanges that support margin positions
+	positionListers map[string]exchange.PositionLister
+	// Maps exchange names to the exchanges whose nonces are persisted across restarts
+	noncePersisters map[string]exchange.NoncePersister
+	// How long the soak test runs for, zero if the bot isn't running a soak test
+	soakDuration time.Duration
+	// File the soak test report is written to
+	soakReportFile string
+	// Tracks the resource usage & exchange errors during a soak test
+	soakMonitor *soak.Monitor
+	// Receives the deposit & order callbacks sent by the exchanges
+	webhooks *webhooks.Listener
+	// Imports the past trades of the account on the exchanges
+	backfiller *backfill.Backfiller
+	// Caps the exposure to each quote currency across all the exchanges
+	exposureLimiter *risk.ExposureLimiter
+	// Pauses trading & polling during the configured maintenance windows of the exchanges
+	maintenance *exchange.MaintenanceScheduler
+	// Delivers the bot events to the websocket clients & strategies through bounded queues
+	eventBus *eventbus.Bus
 }
 
 var bot Bot
 
+const (
+	defaultAuditLogFile = "audit.log"
+	defaultRecorderFile = "orderbooks.rec"
+	// File the soak test report is written to unless another file is specified
+	defaultSoakReportFile = "soak_report.json"
+	// How often the resource usage is sampled during a soak test
+	soakSampleInterval = time.Minute
+	// Name of the account using the credentials in the exchange config
+	defaultAccountName = "default"
+	// How often the platform status of the exchanges is checked
+	statusPollInterval = time.Minute
+	// How long before planned maintenance starts pollers stop sending requests to an exchange
+	maintenanceLeadTime = 5 * time.Minute
+	// How often the authenticated API of degraded exchanges is checked for recovery
+	degradedProbeInterval = 30 * time.Second
+	// How often the scheduled maintenance windows are checked
+	maintenanceCheckInterval = 15 * time.Second
+	// How often the currency metadata is fetched from the exchanges
+	currencyMetadataRefreshInterval = 6 * time.Hour
+	// How often the listed markets are fetched from the exchanges to detect listing changes
+	listingsRefreshInterval = 5 * time.Minute
+	// Max time to wait for an exchange to return its balances
+	balancesTimeout = 30 * time.Second
+	// Max time a ticker or orderbook request may take before the updater routines move on
+	pollRequestTimeout = 20 * time.Second
+	// Trace modules of the ticker & orderbook summaries logged by the updater routines
+	traceTicker    = "ticker"
+	traceOrderbook = "orderbook"
+	// Trace modules enabled if none are configured
+	defaultTrace = traceTicker + "," + traceOrderbook
+)
+
 func setupBotExchanges() {
 	for _, exch := range bot.config.Exchanges {
 		for i := 0; i < len(bot.exchanges); i++ {
 			if bot.exchanges[i] != nil {
 				if bot.exchanges[i].GetName() == exch.Name {
 					bot.exchanges[i].Setup(exch)
+					if exch.Verbose {
+						trace.Enable(exch.Name)
+					}
 					if bot.exchanges[i].IsEnabled() {
 						log.Printf(
 							"%s: Exchange support: %s (Authenticated API support: %s - Verbose mode: %s).\n",
@@ -99,11 +220,726 @@ func setupBotExchanges() {
 	}
 }
 
+// setupWebsocketRecorders opens the files the raw frames received from the exchange websockets
+// are recorded to, for the exchanges with a WebsocketRecordFile configured.
+func setupWebsocketRecorders(rawExchanges []exchange.IBotExchange) {
+	for _, exch := range rawExchanges {
+		exchCfg, err := bot.config.GetExchangeConfig(exch.GetName())
+		if err != nil || !exchCfg.Websocket || exchCfg.WebsocketRecordFile == "" {
+			continue
+		}
+		recordable, ok := exch.(exchange.WebsocketRecordable)
+		if !ok {
+			log.Printf("%s: Websocket recording isn't supported.\n", exch.GetName())
+			continue
+		}
+		w, err := wsrecord.Create(exchCfg.WebsocketRecordFile)
+		if err != nil {
+			log.Printf("%s: Failed to open Websocket recording %s. Error: %s",
+				exch.GetName(), exchCfg.WebsocketRecordFile, err)
+			continue
+		}
+		recordable.SetWebsocketRecorder(w)
+		bot.websocketRecorders = append(bot.websocketRecorders, w)
+		log.Printf("%s: Websocket recording enabled. Path: %s.\n", exch.GetName(), exchCfg.WebsocketRecordFile)
+	}
+}
+
+// setupWebsocketStaleTimeouts sets how long the exchange websockets can go without receiving a
+// message before they're reconnected, for the exchanges with a WebsocketStaleTimeout configured.
+func setupWebsocketStaleTimeouts(rawExchanges []exchange.IBotExchange) {
+	for _, exch := range rawExchanges {
+		exchCfg, err := bot.config.GetExchangeConfig(exch.GetName())
+		if err != nil || !exchCfg.Websocket || exchCfg.WebsocketStaleTimeout <= 0 {
+			continue
+		}
+		notifier, ok := exch.(exchange.WebsocketStaleNotifier)
+		if !ok {
+			log.Printf("%s: Websocket stale detection isn't supported.\n", exch.GetName())
+			continue
+		}
+		notifier.SetWebsocketStaleTimeout(time.Duration(exchCfg.WebsocketStaleTimeout) * time.Second)
+	}
+}
+
+// setupWebsocketCompression enables the negotiation of permessage-deflate compression on the
+// websocket connections of the exchanges with WebsocketCompression configured.
+func setupWebsocketCompression(rawExchanges []exchange.IBotExchange) {
+	for _, exch := range rawExchanges {
+		exchCfg, err := bot.config.GetExchangeConfig(exch.GetName())
+		if err != nil || !exchCfg.Websocket || !exchCfg.WebsocketCompression {
+			continue
+		}
+		compressible, ok := exch.(exchange.WebsocketCompressible)
+		if !ok {
+			log.Printf("%s: Websocket compression isn't supported.\n", exch.GetName())
+			continue
+		}
+		compressible.SetWebsocketCompression(true)
+	}
+}
+
+// setupExchangeChains wraps the bot exchanges with the decorators enabled in their config, see
+// buildExchangeChain. The initial state of the trading switches is loaded from the config.
+func setupExchangeChains() {
+	bot.degradableExchanges = make(map[string]*exchange.DegradableExchange)
+	bot.tradingSwitches = make(map[string]*exchange.TradingSwitch)
+	bot.downtimeSimulators = make(map[string]*exchange.DowntimeSimulator)
+	for i := range bot.exchanges {
+		exch, ok := bot.exchanges[i].(exchange.IBotExchangeEx)
+		if !ok {
+			continue
+		}
+		exchCfg, err := bot.config.GetExchangeConfig(exch.GetName())
+		if err != nil {
+			exchCfg = config.ExchangeConfig{Name: exch.GetName()}
+		}
+		bot.exchanges[i] = buildExchangeChain(exch, exchCfg, defaultAccountName)
+	}
+	applyTradingSwitches()
+}
+
+// buildExchangeChain wraps the exchange of an account with the decorators enabled in the exchange
+// config, the exchanges of all the accounts are wrapped in the same order. The accounts of an
+// exchange share its trading switch, downtime simulation is only supported for the default
+// account. The exposure limiter, audit log, analytics & soak test monitor must be set up first.
+func buildExchangeChain(exch exchange.IBotExchangeEx, exchCfg config.ExchangeConfig,
+	account string) exchange.IBotExchangeEx {
+	name := exch.GetName()
+	// the decorators are logged once per exchange, not for each account
+	logf := func(format string, args ...interface{}) {
+		if account == defaultAccountName {
+			log.Printf(format, args...)
+		}
+	}
+
+	// RoundAmount & RoundPrice use the configured rounding modes for the exchange limits
+	if exchCfg.AmountRounding != "" || exchCfg.PriceRounding != "" {
+		policy, err := exchange.ParseRoundingPolicy(exchCfg.AmountRounding, exchCfg.PriceRounding)
+		if err != nil {
+			logf("%s: Unable to set the rounding policy. Error: %s\n", name, err)
+		} else {
+			exch = exchange.NewRoundingExchange(exch, policy)
+			logf("%s: Rounding amounts with %s & prices with %s.\n", name, policy.Amount, policy.Price)
+		}
+	}
+	// only the public endpoints can be used, all the exchanges are read-only during a soak test
+	if exchCfg.ReadOnly || bot.soakDuration > 0 {
+		exch = exchange.NewReadOnlyExchange(exch)
+		logf("%s: Read-only mode enabled.\n", name)
+	}
+	// failed requests are retried based on the exchange specific classification of the error
+	if exchCfg.RetryAttempts > 0 {
+		exch = exchange.NewRetryingExchange(exch, newRetryPolicy(exchCfg))
+		logf("%s: Request retries enabled.\n", name)
+	}
+	// new orders are blocked & cancels are queued while the authenticated API is down, market data
+	// isn't affected
+	if exchCfg.DegradeAfterFailures > 0 {
+		degradable := exchange.NewDegradableExchange(exch, exchCfg.DegradeAfterFailures)
+		degradable.OnChange = func(s exchange.DegradedStatus) {
+			if s.Degraded {
+				log.Printf("%s (%s account): Authenticated API is down, degraded mode enabled: new orders "+
+					"are blocked & cancels are queued. Last error: %s\n", s.Exchange, account, s.LastError)
+			} else {
+				log.Printf("%s (%s account): Authenticated API recovered, degraded mode disabled.\n",
+					s.Exchange, account)
+			}
+		}
+		key := name
+		if account != defaultAccountName {
+			key = name + "/" + account
+		}
+		bot.degradableExchanges[key] = degradable
+		exch = degradable
+		logf("%s: Degraded mode enabled after %d failures.\n", name, exchCfg.DegradeAfterFailures)
+	}
+	// orders exceeding the limits are rejected before reaching the exchange
+	if exchCfg.OrderThrottle != nil {
+		exch = exchange.NewThrottledExchange(exch, exchange.ThrottleLimits{
+			OrdersPerMinute: exchCfg.OrderThrottle.OrdersPerMinute,
+			NotionalPerHour: exchCfg.OrderThrottle.NotionalPerHour,
+		})
+		logf("%s: Order throttling enabled.\n", name)
+	}
+	// orders aren't placed when the ticker & orderbook for the pair are stale, orders blocked by
+	// the stale price guard shouldn't count towards the throttle limits
+	if exchCfg.MaxMarketDataAge > 0 {
+		exch = exchange.NewStalePriceGuard(exch, time.Duration(exchCfg.MaxMarketDataAge)*time.Second)
+		logf("%s: Stale price guard enabled.\n", name)
+	}
+	// buy orders that would exceed the exposure limit of their quote currency are rejected
+	if bot.exposureLimiter != nil {
+		exch = risk.NewExposureGuard(exch, bot.exposureLimiter)
+	}
+	// limit orders priced too far from the reference price of an oracle are rejected
+	for _, band := range bot.config.PriceBands {
+		if band.Exchange != name {
+			continue
+		}
+		oracle := priceBandOracle(band.Oracle)
+		if oracle == nil {
+			logf("%s: Price band references unknown oracle %s.\n", band.Exchange, band.Oracle)
+			continue
+		}
+		var pairs []pair.CurrencyPair
+		for _, p := range common.SplitStrings(band.Pairs, ",") {
+			if p != "" {
+				pairs = append(pairs, pair.NewCurrencyPairDelimiter(p, "/"))
+			}
+		}
+		exch = risk.NewPriceBandGuard(exch, oracle, band.MaxDeviation/100, pairs...)
+		logf("%s: Price band of %.2f%% around %s prices enabled.\n", band.Exchange, band.MaxDeviation,
+			band.Oracle)
+	}
+	// trading can be paused at runtime on the exchange or specific pairs
+	tradingSwitch := bot.tradingSwitches[name]
+	if tradingSwitch == nil {
+		tradingSwitch = exchange.NewTradingSwitch()
+		bot.tradingSwitches[name] = tradingSwitch
+	}
+	exch = exchange.NewPausableExchange(exch, tradingSwitch)
+	// simulated downtime should be visible to the audit log & analytics, so the downtime simulator
+	// must wrap the exchange first
+	if exchCfg.SimulateDowntime && account == defaultAccountName {
+		simulator := exchange.NewDowntimeSimulator(exch)
+		bot.downtimeSimulators[name] = simulator
+		exch = simulator
+		logf("%s: Downtime simulation enabled.\n", name)
+	}
+	// every mutating API call is recorded
+	if bot.auditLog != nil {
+		exch = exchange.NewAuditedExchange(exch, bot.auditLog)
+	}
+	// the execution of all the orders placed is tracked
+	if bot.analytics != nil {
+		exch = analytics.NewTrackedExchange(exch, bot.analytics, "")
+	}
+	// the requests are counted by the soak test monitor
+	if bot.soakMonitor != nil {
+		exch = soak.NewMonitoredExchange(exch, bot.soakMonitor)
+	}
+	return exch
+}
+
+func newRetryPolicy(exchCfg config.ExchangeConfig) exchange.RetryPolicy {
+	policy := exchange.DefaultRetryPolicy
+	policy.MaxAttempts = exchCfg.RetryAttempts
+	return policy
+}
+
+// setupExposureLimiter creates the limiter that caps the exposure to each quote currency, the
+// exposure is calculated from the consolidated portfolio of all the exchanges.
+func setupExposureLimiter() {
+	if len(bot.config.ExposureLimits) == 0 {
+		return
+	}
+	limits := make(map[string]float64)
+	for _, l := range bot.config.ExposureLimits {
+		limits[common.StringToUpper(l.QuoteCurrency)] = l.MaxNotional
+	}
+	bot.exposureLimiter = risk.NewExposureLimiter(portfolioExposureSource{}, limits)
+	log.Printf("Quote currency exposure limits enabled: %v.\n", limits)
+}
+
+// priceBandOracle returns the oracle with the given name, exchange names refer to the ticker
+// prices of the exchange. Returns nil if the oracle is unknown.
+func priceBandOracle(name string) marketdata.Oracle {
+	if oracle, ok := bot.marketData.Oracle(name); ok {
+		return oracle
+	}
+	for _, exch := range bot.exchanges {
+		if exch != nil && exch.GetName() == name {
+			return marketdata.NewTickerOracle(name)
+		}
+	}
+	return nil
+}
+
+// applyTradingSwitches sets the state of the trading switches to match the config, this discards
+// any changes made at runtime.
+func applyTradingSwitches() {
+	for name, tradingSwitch := range bot.tradingSwitches {
+		exchCfg, err := bot.config.GetExchangeConfig(name)
+		if err != nil {
+			continue
+		}
+		var pairs []pair.CurrencyPair
+		for _, p := range common.SplitStrings(exchCfg.PausedPairs, ",") {
+			if p = common.TrimString(p, " "); p != "" {
+				pairs = append(pairs, pair.NewCurrencyPairDelimiter(p, "/"))
+			}
+		}
+		tradingSwitch.SetPaused(exchCfg.TradingPaused)
+		tradingSwitch.SetPausedPairs(pairs)
+		if exchCfg.TradingPaused || len(pairs) > 0 {
+			log.Printf("%s: Trading paused (all pairs: %t, pairs: %s).\n", name,
+				exchCfg.TradingPaused, exchCfg.PausedPairs)
+		}
+	}
+}
+
+// setupAccounts creates an exchange for each additional account configured for an exchange, and
+// adds them to the account aggregator along with the routing rules of the accounts. The account
+// exchanges are started & wrapped by the same decorators as the bot exchanges.
+func setupAccounts(rawExchanges []exchange.IBotExchange) {
+	bot.accounts = accounts.NewAggregator()
+	for _, exchCfg := range bot.config.Exchanges {
+		if !exchCfg.Enabled || len(exchCfg.Accounts) == 0 {
+			continue
+		}
+		var primary exchange.IBotExchangeEx
+		var raw exchange.IBotExchange
+		for i := range bot.exchanges {
+			if bot.exchanges[i].GetName() == exchCfg.Name {
+				primary, _ = bot.exchanges[i].(exchange.IBotExchangeEx)
+				raw = rawExchanges[i]
+				break
+			}
+		}
+		if primary == nil {
+			log.Printf("%s: Multiple accounts aren't supported.\n", exchCfg.Name)
+			continue
+		}
+		bot.accounts.AddAccount(defaultAccountName, primary)
+
+		for _, accountCfg := range exchCfg.Accounts {
+			exch, ok := reflect.New(reflect.TypeOf(raw).Elem()).Interface().(exchange.IBotExchangeEx)
+			if !ok {
+				continue
+			}
+			cfg := exchCfg
+			cfg.APIKey = accountCfg.APIKey
+			cfg.APISecret = accountCfg.APISecret
+			cfg.ClientID = accountCfg.ClientID
+			cfg.AuthenticatedAPISupport = !cfg.ReadOnly && cfg.APIKey != "" && cfg.APISecret != ""
+			cfg.Accounts = nil
+			exch.SetDefaults()
+			exch.Setup(cfg)
+			// the account exchange loads its pairs & symbols (and starts polling) just like the
+			// default account
+			if exch.IsEnabled() {
+				exch.Start()
+			}
+
+			exch = buildExchangeChain(exch, cfg, accountCfg.Name)
+			if err := bot.accounts.AddAccount(accountCfg.Name, exch); err != nil {
+				log.Printf("Unable to add account. Error: %s\n", err)
+				continue
+			}
+
+			for _, p := range common.SplitStrings(accountCfg.RoutePairs, ",") {
+				rule := accounts.Rule{
+					Pair:    pair.NewCurrencyPairDelimiter(common.TrimString(p, " "), "/"),
+					Account: accountCfg.Name,
+				}
+				if err := bot.accounts.AddRule(exchCfg.Name, rule); err != nil {
+					log.Printf("%s: Unable to add routing rule. Error: %s\n", exchCfg.Name, err)
+				}
+			}
+			log.Printf("%s: Account %s enabled.\n", exchCfg.Name, accountCfg.Name)
+		}
+	}
+}
+
+// setupStatusMonitor creates the status monitor for the enabled exchanges that publish their
+// platform status.
+func setupStatusMonitor(rawExchanges []exchange.IBotExchange) {
+	bot.statusMonitor = exchange.NewStatusMonitor(maintenanceLeadTime)
+	for _, exch := range rawExchanges {
+		if !exch.IsEnabled() {
+			continue
+		}
+		if provider, ok := exch.(exchange.StatusProvider); ok {
+			bot.statusMonitor.AddProvider(exch.GetName(), provider)
+			log.Printf("%s: Platform status monitoring enabled.\n", exch.GetName())
+		}
+	}
+}
+
+// setupMaintenanceWindows schedules the maintenance windows configured for the enabled
+// exchanges, returns false if there aren't any.
+func setupMaintenanceWindows() bool {
+	scheduler := exchange.NewMaintenanceScheduler()
+	for _, exchCfg := range bot.config.Exchanges {
+		if !exchCfg.Enabled {
+			continue
+		}
+		for _, w := range exchCfg.MaintenanceWindows {
+			err := scheduler.AddWindow(exchCfg.Name, w.Name, w.Schedule, time.Duration(w.Duration)*time.Minute)
+			if err != nil {
+				log.Printf("%s: Maintenance window %s ignored. Error: %s\n", exchCfg.Name, w.Name, err)
+			}
+		}
+	}
+	if len(scheduler.Exchanges()) == 0 {
+		return false
+	}
+	bot.maintenance = scheduler
+	for _, name := range scheduler.Exchanges() {
+		for _, w := range scheduler.Upcoming(name) {
+			log.Printf("%s: Next maintenance window %s from %s to %s.\n", name, w.Name,
+				w.Start.Format(time.RFC3339), w.End.Format(time.RFC3339))
+		}
+	}
+	return true
+}
+
+// setupPositionListers collects the enabled exchanges that support margin positions, so the
+// positions can be included in the account state of the exchanges.
+func setupPositionListers(rawExchanges []exchange.IBotExchange) {
+	bot.positionListers = make(map[string]exchange.PositionLister)
+	for _, exch := range rawExchanges {
+		if !exch.IsEnabled() {
+			continue
+		}
+		if lister, ok := exch.(exchange.PositionLister); ok {
+			bot.positionListers[exch.GetName()] = lister
+		}
+	}
+}
+
+// setupNonces restores the request nonces of the exchanges from storage, so that nonces used
+// before a restart aren't sent again. The nonces are saved back to storage on shutdown.
+func setupNonces(rawExchanges []exchange.IBotExchange) {
+	bot.noncePersisters = make(map[string]exchange.NoncePersister)
+	for _, exch := range rawExchanges {
+		p, ok := exch.(exchange.NoncePersister)
+		if !ok {
+			continue
+		}
+		if err := p.LoadNonce(bot.store); err != nil {
+			log.Printf("%s: Unable to load nonce from storage. Error: %s\n", exch.GetName(), err)
+		}
+		bot.noncePersisters[exch.GetName()] = p
+	}
+}
+
+// setupCurrencyMetadata creates the currency metadata registry, the metadata is fetched from the
+// enabled exchanges that publish it by CurrencyMetadataRoutine.
+func setupCurrencyMetadata(rawExchanges []exchange.IBotExchange) map[string]exchange.CurrencyMetadataProvider {
+	bot.currencyMetadata = metadata.NewRegistry()
+	providers := make(map[string]exchange.CurrencyMetadataProvider)
+	for _, exch := range rawExchanges {
+		if !exch.IsEnabled() {
+			continue
+		}
+		if provider, ok := exch.(exchange.CurrencyMetadataProvider); ok {
+			providers[exch.GetName()] = provider
+		}
+	}
+	return providers
+}
+
+// setupListings creates the listing change detector, and loads the markets that were listed on
+// the exchanges when the bot last ran. The markets are fetched from the enabled exchanges that
+// can list them by ListingsRoutine.
+func setupListings(rawExchanges []exchange.IBotExchange) map[string]exchange.InstrumentLister {
+	bot.listings = listings.NewDetector()
+	bot.listings.OnEvent = func(e listings.Event) {
+		log.Printf("%s: %s %s.\n", e.Exchange, e.Instrument.Symbol, e.Type)
+		relayWebsocketEvent(e, "listing_update", "", e.Exchange)
+	}
+	if _, err := bot.listings.Load(bot.store); err != nil {
+		log.Printf("Unable to load listed markets from storage. Error: %s", err)
+	}
+	listers := make(map[string]exchange.InstrumentLister)
+	for _, exch := range rawExchanges {
+		if !exch.IsEnabled() {
+			continue
+		}
+		if lister, ok := exch.(exchange.InstrumentLister); ok {
+			listers[exch.GetName()] = lister
+		}
+	}
+	return listers
+}
+
+// Events where only the latest updates matter, slow subscribers drop the oldest ones
+var marketDataEvents = []string{"ticker_update", "orderbook_update"}
+
+// setupEventBus creates the bus the events are published on, market data events drop the oldest
+// events when a queue is full and the other events block unless configured otherwise.
+func setupEventBus() error {
+	bot.eventBus = eventbus.New()
+	if bot.config.EventBus.BlockTimeout > 0 {
+		bot.eventBus.BlockTimeout = time.Duration(bot.config.EventBus.BlockTimeout) * time.Millisecond
+	}
+	for _, event := range marketDataEvents {
+		bot.eventBus.SetPolicy(event, eventbus.DropOldest)
+	}
+	for event, name := range bot.config.EventBus.Policies {
+		policy, err := eventbus.ParsePolicy(name)
+		if err != nil {
+			return fmt.Errorf("%s event: %s", event, err)
+		}
+		bot.eventBus.SetPolicy(event, policy)
+	}
+	return nil
+}
+
+// setupSweeper creates the wallet sweeper for the enabled exchanges that can transfer funds
+// between wallets, and adds the configured sweep policies. Read-only exchanges are never swept.
+func setupSweeper(rawExchanges []exchange.IBotExchange) {
+	bot.sweeper = sweep.NewSweeper()
+	bot.sweeper.Notify = func(message string) {
+		log.Println(message)
+		if bot.smsglobal != nil {
+			bot.smsglobal.SendMessageToAll(message)
+		}
+	}
+	for _, exch := range rawExchanges {
+		if !exch.IsEnabled() {
+			continue
+		}
+		if exchCfg, err := bot.config.GetExchangeConfig(exch.GetName()); err != nil || exchCfg.ReadOnly {
+			continue
+		}
+		if t, ok := exch.(exchange.WalletTransferer); ok {
+			if ex, ok := exch.(exchange.IBotExchangeEx); ok && bot.auditLog != nil {
+				t = exchange.NewAuditedExchange(ex, bot.auditLog)
+			}
+			bot.sweeper.AddExchange(exch.GetName(), t)
+		}
+	}
+	now := time.Now()
+	for _, cfg := range bot.config.Sweeps {
+		at, err := sweep.ParseTimeOfDay(cfg.At)
+		if err != nil {
+			log.Printf("Sweep policy %s: %s\n", cfg.Name, err)
+			continue
+		}
+		p := sweep.Policy{
+			Name:      cfg.Name,
+			Exchange:  cfg.Exchange,
+			From:      cfg.From,
+			To:        cfg.To,
+			At:        at,
+			MinAmount: cfg.MinAmount,
+			Reserve:   cfg.Reserve,
+			DryRun:    cfg.DryRun,
+			Notify:    cfg.Notify,
+		}
+		if cfg.Currencies != "" {
+			p.Currencies = common.SplitStrings(cfg.Currencies, ",")
+		}
+		if err = bot.sweeper.AddPolicy(p, now); err != nil {
+			log.Printf("Sweep policy %s: %s\n", cfg.Name, err)
+			continue
+		}
+		log.Printf("Sweep policy %s: sweeping %s %s wallet to %s wallet daily at %s UTC.\n",
+			cfg.Name, cfg.Exchange, cfg.From, cfg.To, cfg.At)
+	}
+}
+
+// setupAuditLog opens the audit log every mutating API call made through the bot exchanges is
+// recorded to.
+func setupAuditLog() {
+	path := bot.config.AuditLog.Path
+	if path == "" {
+		path = defaultAuditLogFile
+	}
+	maxSize := bot.config.AuditLog.MaxSize
+	if maxSize == 0 {
+		maxSize = audit.DefaultMaxSize
+	}
+	maxBackups := bot.config.AuditLog.MaxBackups
+	if maxBackups == 0 {
+		maxBackups = audit.DefaultMaxBackups
+	}
+
+	auditLog, err := audit.New(path, maxSize, maxBackups)
+	if err != nil {
+		log.Fatalf("Failed to open audit log %s. Error: %s", path, err)
+	}
+	bot.auditLog = auditLog
+	log.Printf("Audit log enabled. Path: %s.\n", path)
+}
+
+// setupRecorder opens the file the polled orderbooks are recorded to
+func setupRecorder() {
+	path := bot.config.Recorder.Path
+	if path == "" {
+		path = defaultRecorderFile
+	}
+	w, err := recorder.Create(path, bot.config.Recorder.KeyframeInterval,
+		time.Duration(bot.config.Recorder.KeyframePeriod)*time.Second)
+	if err != nil {
+		log.Fatalf("Failed to open orderbook recording %s. Error: %s", path, err)
+	}
+	bot.orderbookRecorder = w
+	log.Printf("Orderbook recording enabled. Path: %s.\n", path)
+}
+
+// setupSoakTest creates the soak test monitor the requests made through the bot exchanges are
+// counted by, along with the websocket connections of the exchanges.
+func setupSoakTest(rawExchanges []exchange.IBotExchange) {
+	bot.soakMonitor = soak.NewMonitor()
+	for _, exch := range rawExchanges {
+		if counter, ok := exch.(exchange.WebsocketConnectionCounter); ok && exch.IsEnabled() {
+			bot.soakMonitor.TrackConnections(exch.GetName(), counter.WebsocketConnections)
+		}
+	}
+	log.Printf("Soak test enabled. Duration: %s. Report: %s.\n", bot.soakDuration, bot.soakReportFile)
+}
+
+// setupWebhooks creates the listener for the callbacks of the configured exchanges, the events
+// are relayed to the websocket clients.
+func setupWebhooks() error {
+	bot.webhooks = webhooks.NewListener()
+	bot.webhooks.OnEvent = func(e webhooks.Event) {
+		log.Printf("%s: Received %s webhook %s.\n", e.Exchange, e.Type, e.ID)
+		relayWebsocketEvent(e, "webhook_event", "", e.Exchange)
+	}
+	for _, cfg := range bot.config.Webhooks.Exchanges {
+		var adapter webhooks.Adapter
+		switch cfg.Type {
+		case "hmac":
+			adapter = &webhooks.HMACAdapter{Exchange: cfg.Exchange, Secret: cfg.Secret}
+		case "coinbase":
+			data, err := ioutil.ReadFile(cfg.PublicKeyFile)
+			if err != nil {
+				return err
+			}
+			key, err := webhooks.ParsePublicKey(data)
+			if err != nil {
+				return fmt.Errorf("%s webhook public key: %s", cfg.Exchange, err)
+			}
+			adapter = &webhooks.CoinbaseAdapter{Exchange: cfg.Exchange, PublicKey: key}
+		default:
+			return fmt.Errorf("unknown %s webhook type %s", cfg.Exchange, cfg.Type)
+		}
+		bot.webhooks.Register(cfg.Exchange, adapter)
+		log.Printf("%s: Webhooks enabled.\n", cfg.Exchange)
+	}
+	return nil
+}
+
+// setupBackfill creates the trade history backfiller, returns the enabled exchanges with
+// credentials that can list the past trades of the account and haven't been backfilled yet.
+func setupBackfill(rawExchanges []exchange.IBotExchange) map[string]exchange.IBotExchange {
+	bot.backfiller = backfill.New(bot.store)
+	if bot.config.Backfill.PageDelay > 0 {
+		bot.backfiller.PageDelay = time.Duration(bot.config.Backfill.PageDelay) * time.Millisecond
+	}
+	result := make(map[string]exchange.IBotExchange)
+	for _, exch := range rawExchanges {
+		if !exch.IsEnabled() || !exch.GetAuthenticatedAPISupport() {
+			continue
+		}
+		if _, ok := exch.(exchange.TradeHistoryProvider); !ok {
+			continue
+		}
+		state, err := bot.backfiller.State(exch.GetName())
+		if err != nil {
+			log.Printf("%s: Unable to load the trade backfill state. Error: %s", exch.GetName(), err)
+			continue
+		}
+		if state.Completed.IsZero() {
+			result[exch.GetName()] = exch
+		}
+	}
+	return result
+}
+
+// setupAnalytics creates the tracker the execution of all the orders placed through the bot
+// exchanges is tracked by.
+func setupAnalytics() {
+	bot.analytics = analytics.NewTracker()
+	log.Println("Order execution analytics enabled.")
+}
+
+// setupTaxLots creates the tax lots for the configured jurisdiction and loads the previously
+// recorded lots from the store
+func setupTaxLots() error {
+	jurisdiction, err := pnl.GetJurisdiction(bot.config.PnL.Jurisdiction)
+	if err != nil {
+		return err
+	}
+	if bot.config.PnL.LotMethod != "" {
+		if jurisdiction.Method, err = pnl.ParseLotMethod(bot.config.PnL.LotMethod); err != nil {
+			return err
+		}
+	}
+	bot.taxLots = pnl.NewTaxLots(jurisdiction)
+	_, err = bot.taxLots.Load(bot.store)
+	return err
+}
+
+// setupTransfers creates the transfer estimator with the configured withdrawal & deposit rules
+// and loads the previously observed transfers from the store
+func setupTransfers() error {
+	bot.transfers = transfers.NewEstimator()
+	for _, t := range bot.config.Transfers {
+		bot.transfers.SetCurrencyInfo(t.Exchange, t.Currency, transfers.CurrencyInfo{
+			WithdrawalFee:        t.WithdrawalFee,
+			MinWithdrawal:        t.MinWithdrawal,
+			DepositConfirmations: t.DepositConfirmations,
+		})
+	}
+	_, err := bot.transfers.Load(bot.store)
+	return err
+}
+
+// setupConditionalOrders loads the conditional orders from the store, and reconciles them against
+// the live orders of the exchanges
+func setupConditionalOrders() error {
+	bot.conditionalOrders = conditional.NewManager(bot.store)
+	for i := range bot.exchanges {
+		if exch, ok := bot.exchanges[i].(exchange.IBotExchangeEx); ok && exch.IsEnabled() {
+			bot.conditionalOrders.AddExchange(exch)
+		}
+	}
+	n, err := bot.conditionalOrders.Load()
+	if err != nil || n == 0 {
+		return err
+	}
+	log.Printf("Loaded %d conditional orders, reconciling with the exchanges.\n", n)
+	return bot.conditionalOrders.Reconcile()
+}
+
+// setupStorage opens the store configured for persisting the bot state
+func setupStorage() error {
+	var err error
+	switch bot.config.Storage.Type {
+	case "", "memory":
+		bot.store = storage.NewMemoryStore()
+	case "file":
+		bot.store, err = storage.NewFileStore(bot.config.Storage.Path)
+	case "sqlite":
+		bot.store, err = storage.NewSQLStore("sqlite3", bot.config.Storage.Path)
+	default:
+		err = fmt.Errorf("unknown storage type %s", bot.config.Storage.Type)
+	}
+	if err != nil {
+		return err
+	}
+	return LoadOrders(bot.store)
+}
+
+// setupRateLimit sets the backend shared by the exchange rate limiters
+func setupRateLimit() error {
+	switch bot.config.RateLimit.Backend {
+	case "", "memory":
+	case "redis":
+		ratelimit.SetDefaultBackend(ratelimit.NewRedisBackend(
+			bot.config.RateLimit.RedisAddress, bot.config.RateLimit.RedisPassword,
+		))
+		log.Printf("Using redis rate limit backend at %s.\n", bot.config.RateLimit.RedisAddress)
+	default:
+		return fmt.Errorf("unknown rate limit backend %s", bot.config.RateLimit.Backend)
+	}
+	return nil
+}
+
 func main() {
 	HandleInterrupt()
 
 	//Handle flags
 	flag.StringVar(&bot.configFile, "config", config.GetFilePath(""), "config file to load")
+	flag.DurationVar(&bot.soakDuration, "soak", 0,
+		"run a soak test of the enabled exchanges in read-only mode for the given duration")
+	flag.StringVar(&bot.soakReportFile, "soakreport", defaultSoakReportFile, "soak test report file")
 	flag.Parse()
 
 	bot.config = &config.Cfg
@@ -116,6 +952,13 @@ func main() {
 
 	AdjustGoMaxProcs()
 	log.Printf("Bot '%s' started.\n", bot.config.Name)
+	traceModules := bot.config.Trace
+	if traceModules == "" {
+		traceModules = defaultTrace
+	}
+	if err = trace.Set(traceModules); err != nil {
+		log.Fatalf("Invalid trace modules %s. Error: %s", traceModules, err)
+	}
 	log.Printf("Fiat display currency: %s.", bot.config.FiatDisplayCurrency)
 
 	if bot.config.SMS.Enabled {
@@ -129,6 +972,16 @@ func main() {
 		log.Println("SMS support disabled.")
 	}
 
+	err = setupStorage()
+	if err != nil {
+		log.Fatalf("Failed to setup storage. Error: %s", err)
+	}
+
+	err = setupRateLimit()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	log.Printf(
 		"Available Exchanges: %d. Enabled Exchanges: %d.\n",
 		len(bot.config.Exchanges), bot.config.GetConfigEnabledExchanges(),
@@ -166,7 +1019,61 @@ func main() {
 		}
 	}
 
+	// The exchanges before they're wrapped, used to create the additional accounts
+	rawExchanges := append([]exchange.IBotExchange(nil), bot.exchanges...)
+	// The exposure limits price the portfolio with the market data provider
+	bot.marketData = marketdata.NewProviderFromConfig(bot.config.MarketData)
+	setupExposureLimiter()
+
+	if bot.config.AuditLog.Enabled {
+		setupAuditLog()
+	}
+
+	if bot.config.Analytics.Enabled {
+		setupAnalytics()
+	}
+
+	if bot.config.Recorder.Enabled {
+		setupRecorder()
+	}
+
+	if bot.soakDuration > 0 {
+		setupSoakTest(rawExchanges)
+	}
+
+	setupExchangeChains()
+
+	setupWebsocketRecorders(rawExchanges)
+	setupWebsocketStaleTimeouts(rawExchanges)
+	setupWebsocketCompression(rawExchanges)
+	// The nonces must be restored before the exchanges send any authenticated requests
+	setupNonces(rawExchanges)
 	setupBotExchanges()
+	setupAccounts(rawExchanges)
+	setupStatusMonitor(rawExchanges)
+	maintenanceEnabled := setupMaintenanceWindows()
+	setupPositionListers(rawExchanges)
+	metadataProviders := setupCurrencyMetadata(rawExchanges)
+	setupSweeper(rawExchanges)
+	if err = setupEventBus(); err != nil {
+		log.Fatalf("Failed to setup the event bus. Error: %s", err)
+	}
+	bot.strategies = strategy.NewRunner()
+	bot.strategies.Events = bot.eventBus
+	bot.strategies.EventQueueSize = bot.config.EventBus.QueueSize
+	bot.strategies.MarketDataTTL = time.Duration(bot.config.MarketData.SharedCacheTTL) * time.Millisecond
+	bot.strategies.AuditLog = bot.auditLog
+	bot.strategies.Tags = strategy.NewTags(bot.store)
+	if bot.analytics != nil {
+		bot.analytics.StrategyOf = bot.strategies.Tags.Get
+	}
+	for _, q := range bot.config.StrategyQuotas {
+		bot.strategies.SetQuota(q.Name, strategy.Quota{
+			RequestsPerMinute: q.RequestsPerMinute,
+			MaxOpenOrders:     q.MaxOpenOrders,
+			MaxSubscriptions:  q.MaxSubscriptions,
+		})
+	}
 
 	if bot.config.CurrencyExchangeProvider == "yahoo" {
 		currency.SetProvider(true)
@@ -192,14 +1099,68 @@ func main() {
 
 	bot.portfolio = &portfolio.Portfolio
 	bot.portfolio.SeedPortfolio(bot.config.Portfolio)
+	if _, err = bot.portfolio.Load(bot.store); err != nil {
+		log.Printf("Unable to load portfolio from storage. Error: %s", err)
+	}
 	SeedExchangeAccountInfo(GetAllEnabledExchangeAccountInfo().Data)
 	go portfolio.StartPortfolioWatcher()
 
+	bot.valuations = portfolio.NewValuationHistory(portfolio.DefaultMaxValuations)
+	if _, err = bot.valuations.Load(bot.store); err != nil {
+		log.Printf("Unable to load portfolio valuations from storage. Error: %s", err)
+	}
+	go PortfolioValuationRoutine()
+
+	if err = setupTaxLots(); err != nil {
+		log.Printf("Unable to set up tax lots. Error: %s", err)
+	}
+
+	if err = setupTransfers(); err != nil {
+		log.Printf("Unable to load observed transfers from storage. Error: %s", err)
+	}
+
+	if err = setupConditionalOrders(); err != nil {
+		log.Printf("Unable to recover conditional orders. Error: %s", err)
+	}
+
+	instrumentListers := setupListings(rawExchanges)
+
+	var tradeHistoryProviders map[string]exchange.IBotExchange
+	if bot.config.Backfill.Enabled {
+		tradeHistoryProviders = setupBackfill(rawExchanges)
+	}
+
+	if bot.config.Webhooks.Enabled {
+		if err = setupWebhooks(); err != nil {
+			log.Fatalf("Failed to setup webhooks. Error: %s", err)
+		}
+	}
+
 	log.Println("Starting websocket handler")
 	go WebsocketHandler()
 
+	go StatusMonitorRoutine()
+	if len(bot.degradableExchanges) > 0 {
+		go DegradedModeRoutine()
+	}
+	if maintenanceEnabled {
+		go MaintenanceRoutine()
+	}
+	go ConditionalOrderRoutine()
+	go CurrencyMetadataRoutine(metadataProviders)
+	go SweepRoutine()
+	go ListingsRoutine(instrumentListers)
 	go TickerUpdaterRoutine()
 	go OrderbookUpdaterRoutine()
+	if bot.webhooks != nil {
+		go WebhookListenerRoutine()
+	}
+	if len(tradeHistoryProviders) > 0 {
+		go TradeBackfillRoutine(tradeHistoryProviders)
+	}
+	if bot.soakMonitor != nil {
+		go SoakTestRoutine()
+	}
 
 	if bot.config.Webserver.Enabled {
 		listenAddr := bot.config.Webserver.ListenAddress
@@ -263,6 +1224,52 @@ func Shutdown() {
 		log.Println("Config file saved successfully.")
 	}
 
+	if bot.store != nil {
+		if err = portfolio.Portfolio.Save(bot.store); err != nil {
+			log.Printf("Unable to save portfolio to storage. Error: %s", err)
+		}
+		if bot.valuations != nil {
+			if err = bot.valuations.Save(bot.store); err != nil {
+				log.Printf("Unable to save portfolio valuations to storage. Error: %s", err)
+			}
+		}
+		if bot.taxLots != nil {
+			if err = bot.taxLots.Save(bot.store); err != nil {
+				log.Printf("Unable to save tax lots to storage. Error: %s", err)
+			}
+		}
+		if bot.transfers != nil {
+			if err = bot.transfers.Save(bot.store); err != nil {
+				log.Printf("Unable to save observed transfers to storage. Error: %s", err)
+			}
+		}
+		if bot.listings != nil {
+			if err = bot.listings.Save(bot.store); err != nil {
+				log.Printf("Unable to save listed markets to storage. Error: %s", err)
+			}
+		}
+		if err = SaveOrders(bot.store); err != nil {
+			log.Printf("Unable to save orders to storage. Error: %s", err)
+		}
+		for name, p := range bot.noncePersisters {
+			if err = p.SaveNonce(bot.store); err != nil {
+				log.Printf("%s: Unable to save nonce to storage. Error: %s", name, err)
+			}
+		}
+		bot.store.Close()
+	}
+
+	if bot.auditLog != nil {
+		bot.auditLog.Close()
+	}
+
+	if bot.orderbookRecorder != nil {
+		bot.orderbookRecorder.Close()
+	}
+	for _, w := range bot.websocketRecorders {
+		w.Close()
+	}
+
 	log.Println("Exiting.")
 	os.Exit(1)
 }
@@ -1,6 +1,17 @@
 package main
 
-import "testing"
+import (
+	"context"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/analytics"
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+	"github.com/mattkanwisher/cryptofiend/soak"
+)
 
 func TestSetupBotExchanges(t *testing.T) {
 	// setupBotExchanges()
@@ -25,3 +36,129 @@ func TestShutdown(t *testing.T) {
 func TestSeedExchangeAccountInfo(t *testing.T) {
 	SeedExchangeAccountInfo(GetAllEnabledExchangeAccountInfo().Data)
 }
+
+type contextKey struct{}
+
+// mockContextExchange records the value of contextKey in the contexts its Context methods are
+// called with, the other methods of IBotExchangeEx aren't used by the tests
+type mockContextExchange struct {
+	exchange.IBotExchangeEx
+	values []interface{}
+}
+
+func (m *mockContextExchange) record(ctx context.Context) error {
+	m.values = append(m.values, ctx.Value(contextKey{}))
+	return ctx.Err()
+}
+
+func (m *mockContextExchange) GetName() string {
+	return "Mock"
+}
+
+func (m *mockContextExchange) GetTickerPrice(currency pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return ticker.Price{Bid: 1, Ask: 1}, nil
+}
+
+func (m *mockContextExchange) UpdateTickerContext(ctx context.Context, currency pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
+	return ticker.Price{}, m.record(ctx)
+}
+
+func (m *mockContextExchange) UpdateOrderbookContext(ctx context.Context, currency pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
+	return orderbook.Base{}, m.record(ctx)
+}
+
+func (m *mockContextExchange) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
+	return exchange.AccountInfo{}, m.record(ctx)
+}
+
+func (m *mockContextExchange) NewOrderContext(ctx context.Context, symbol pair.CurrencyPair, amount,
+	price float64, side exchange.OrderSide, orderType exchange.OrderType,
+	opts ...exchange.OrderOptions) (string, error) {
+	return "1", m.record(ctx)
+}
+
+func (m *mockContextExchange) CancelOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) error {
+	return m.record(ctx)
+}
+
+func (m *mockContextExchange) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	return &exchange.Order{OrderID: orderID}, m.record(ctx)
+}
+
+func (m *mockContextExchange) GetOrdersContext(ctx context.Context,
+	pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return nil, m.record(ctx)
+}
+
+func TestBuildExchangeChainContext(t *testing.T) {
+	prevConfig, prevAnalytics, prevSoakMonitor := bot.config, bot.analytics, bot.soakMonitor
+	prevSwitches, prevDegradable, prevSimulators := bot.tradingSwitches, bot.degradableExchanges,
+		bot.downtimeSimulators
+	defer func() {
+		bot.config, bot.analytics, bot.soakMonitor = prevConfig, prevAnalytics, prevSoakMonitor
+		bot.tradingSwitches, bot.degradableExchanges, bot.downtimeSimulators = prevSwitches,
+			prevDegradable, prevSimulators
+	}()
+	bot.config = &config.Config{}
+	bot.analytics = analytics.NewTracker()
+	bot.soakMonitor = soak.NewMonitor()
+	bot.tradingSwitches = make(map[string]*exchange.TradingSwitch)
+	bot.degradableExchanges = make(map[string]*exchange.DegradableExchange)
+	bot.downtimeSimulators = make(map[string]*exchange.DowntimeSimulator)
+
+	mock := &mockContextExchange{}
+	exch := exchange.WithContext(buildExchangeChain(mock, config.ExchangeConfig{
+		Name:                 "Mock",
+		RetryAttempts:        2,
+		DegradeAfterFailures: 3,
+		OrderThrottle:        &config.OrderThrottleConfig{OrdersPerMinute: 1},
+		SimulateDowntime:     true,
+	}, defaultAccountName))
+	ctx := context.WithValue(context.Background(), contextKey{}, "value")
+	p := pair.NewCurrencyPair("BTC", "USD")
+
+	exch.UpdateTickerContext(ctx, p, ticker.Spot)
+	exch.UpdateOrderbookContext(ctx, p, ticker.Spot)
+	exch.GetExchangeAccountInfoContext(ctx)
+	_, err := exch.NewOrderContext(ctx, p, 1, 1, exchange.OrderSideBuy, exchange.OrderTypeExchangeLimit)
+	if err != nil {
+		t.Fatalf("Test failed. Unexpected error placing the order: %s", err)
+	}
+	exch.CancelOrderContext(ctx, "1", p)
+	exch.GetOrderContext(ctx, "1", p)
+	exch.GetOrdersContext(ctx, []pair.CurrencyPair{p})
+	if len(mock.values) != 7 {
+		t.Fatalf("Test failed. Expected all 7 calls to reach the exchange, got %d", len(mock.values))
+	}
+	for i, v := range mock.values {
+		if v != "value" {
+			t.Errorf("Test failed. Expected call %d to carry the caller's context, got %v", i, v)
+		}
+	}
+
+	// The decorators still apply to the calls made with a context
+	_, err = exch.NewOrderContext(ctx, p, 1, 1, exchange.OrderSideBuy, exchange.OrderTypeExchangeLimit)
+	if err != exchange.ErrOrderRateExceeded {
+		t.Errorf("Test failed. Expected the order to be throttled, got %v", err)
+	}
+	bot.downtimeSimulators["Mock"].SetDown(true)
+	if _, err := exch.GetOrdersContext(ctx, []pair.CurrencyPair{p}); err == nil {
+		t.Error("Test failed. Expected the simulated downtime to fail the request")
+	}
+	if len(mock.values) != 7 {
+		t.Errorf("Test failed. Expected the blocked calls not to reach the exchange, got %d calls",
+			len(mock.values))
+	}
+
+	// Cancelling the context cancels the request
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	bot.downtimeSimulators["Mock"].SetDown(false)
+	if _, err := exch.GetOrdersContext(cancelled, []pair.CurrencyPair{p}); err != context.Canceled {
+		t.Errorf("Test failed. Expected the request to be cancelled, got %v", err)
+	}
+}
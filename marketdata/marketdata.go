@@ -0,0 +1,187 @@
+package marketdata
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+// Provenance records where a price came from so that decisions based on it can be audited
+type Provenance struct {
+	Exchange    string    `json:"exchange"`
+	Fallback    bool      `json:"fallback"`
+	LastUpdated time.Time `json:"lastUpdated"`
+	// Stale is set when the price is older than the max age configured for the pair, stale
+	// prices are only returned when no fresh price is available from any source.
+	Stale bool `json:"stale"`
+}
+
+// Price is a ticker price tagged with its provenance
+type Price struct {
+	ticker.Price
+	Provenance Provenance `json:"provenance"`
+}
+
+// Fallback configures a secondary source for the price of a currency pair on an exchange
+type Fallback struct {
+	Exchange         string
+	Pair             pair.CurrencyPair
+	FallbackExchange string
+	// MaxAge is the maximum age of a price before it's considered stale
+	MaxAge time.Duration
+}
+
+// Provider serves prices from the ticker store, switching to the configured fallback source
+// when the price from the primary exchange is missing or stale.
+type Provider struct {
+	m         sync.RWMutex
+	fallbacks map[string]Fallback
+	// Reference price oracles keyed by name
+	oracles   map[string]Oracle
+	getTicker func(exchangeName string, p pair.CurrencyPair, assetType string) (ticker.Price, error)
+	now       func() time.Time
+}
+
+// NewProvider creates a new provider with the given fallbacks
+func NewProvider(fallbacks ...Fallback) *Provider {
+	p := &Provider{
+		fallbacks: make(map[string]Fallback),
+		oracles:   make(map[string]Oracle),
+		getTicker: ticker.GetTicker,
+		now:       time.Now,
+	}
+	for _, f := range fallbacks {
+		p.AddFallback(f)
+	}
+	return p
+}
+
+// NewProviderFromConfig creates a new provider with the fallbacks & oracles from the market data
+// config. The oracles combined by a median oracle that aren't configured are the tickers of the
+// exchanges with those names.
+func NewProviderFromConfig(cfg config.MarketDataConfig) *Provider {
+	p := NewProvider()
+	for _, f := range cfg.Fallbacks {
+		p.AddFallback(Fallback{
+			Exchange:         f.Exchange,
+			Pair:             pair.NewCurrencyPairDelimiter(f.Pair, "/"),
+			FallbackExchange: f.FallbackExchange,
+			MaxAge:           time.Duration(f.MaxAge) * time.Second,
+		})
+	}
+	for _, o := range cfg.Oracles {
+		if o.URL != "" {
+			p.AddOracle(NewRESTOracle(o.Name, o.URL, o.PriceField, time.Duration(o.CacheTTL)*time.Second))
+		}
+	}
+	for _, o := range cfg.Oracles {
+		if o.URL != "" {
+			continue
+		}
+		var oracles []Oracle
+		for _, name := range o.Median {
+			oracle, ok := p.Oracle(name)
+			if !ok {
+				oracle = NewTickerOracle(name)
+			}
+			oracles = append(oracles, oracle)
+		}
+		p.AddOracle(NewMedianOracle(o.Name, oracles...))
+	}
+	return p
+}
+
+// AddOracle adds or replaces a reference price oracle, the oracle is registered under its name
+func (p *Provider) AddOracle(o Oracle) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.oracles[o.Name()] = o
+}
+
+// Oracle returns the reference price oracle registered under the name
+func (p *Provider) Oracle(name string) (Oracle, bool) {
+	p.m.RLock()
+	defer p.m.RUnlock()
+	o, ok := p.oracles[name]
+	return o, ok
+}
+
+func fallbackKey(exchangeName string, p pair.CurrencyPair) string {
+	return exchangeName + ":" + p.Display("/", true).String()
+}
+
+// AddFallback adds or replaces the fallback for a currency pair on an exchange
+func (p *Provider) AddFallback(f Fallback) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.fallbacks[fallbackKey(f.Exchange, f.Pair)] = f
+}
+
+// GetFallback returns the fallback configured for a currency pair on an exchange
+func (p *Provider) GetFallback(exchangeName string, currencyPair pair.CurrencyPair) (Fallback, bool) {
+	p.m.RLock()
+	defer p.m.RUnlock()
+	f, ok := p.fallbacks[fallbackKey(exchangeName, currencyPair)]
+	return f, ok
+}
+
+// GetPrice returns the latest price of a currency pair on an exchange. If a fallback is
+// configured for the pair and the exchange price is missing or older than the max age the
+// price from the fallback exchange is returned instead. When neither price is fresh the most
+// recent one is returned and flagged as stale.
+func (p *Provider) GetPrice(exchangeName string, currencyPair pair.CurrencyPair, assetType string) (Price, error) {
+	primary, primaryErr := p.getTicker(exchangeName, currencyPair, assetType)
+	f, ok := p.GetFallback(exchangeName, currencyPair)
+	if !ok {
+		if primaryErr != nil {
+			return Price{}, primaryErr
+		}
+		return p.tag(primary, exchangeName, false, false), nil
+	}
+
+	if primaryErr == nil && !p.isStale(primary, f.MaxAge) {
+		return p.tag(primary, exchangeName, false, false), nil
+	}
+
+	secondary, secondaryErr := p.getTicker(f.FallbackExchange, currencyPair, assetType)
+	if secondaryErr == nil && !p.isStale(secondary, f.MaxAge) {
+		return p.tag(secondary, f.FallbackExchange, true, false), nil
+	}
+
+	switch {
+	case primaryErr == nil && secondaryErr == nil:
+		if secondary.LastUpdated.After(primary.LastUpdated) {
+			return p.tag(secondary, f.FallbackExchange, true, true), nil
+		}
+		return p.tag(primary, exchangeName, false, true), nil
+	case primaryErr == nil:
+		return p.tag(primary, exchangeName, false, true), nil
+	case secondaryErr == nil:
+		return p.tag(secondary, f.FallbackExchange, true, true), nil
+	}
+	return Price{}, fmt.Errorf("no price for %s on %s (%s) or fallback %s (%s)",
+		currencyPair.Pair(), exchangeName, primaryErr, f.FallbackExchange, secondaryErr)
+}
+
+func (p *Provider) isStale(price ticker.Price, maxAge time.Duration) bool {
+	if price.LastUpdated.IsZero() {
+		return true
+	}
+	return p.now().Sub(price.LastUpdated) > maxAge
+}
+
+func (p *Provider) tag(price ticker.Price, exchangeName string, fallback, stale bool) Price {
+	return Price{
+		Price: price,
+		Provenance: Provenance{
+			Exchange:    exchangeName,
+			Fallback:    fallback,
+			LastUpdated: price.LastUpdated,
+			Stale:       stale,
+		},
+	}
+}
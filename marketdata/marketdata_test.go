@@ -0,0 +1,95 @@
+package marketdata
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+func newTestProvider(now time.Time, prices map[string]ticker.Price) *Provider {
+	p := NewProvider(Fallback{
+		Exchange:         "Bittrex",
+		Pair:             pair.NewCurrencyPair("BTC", "USDT"),
+		FallbackExchange: "Binance",
+		MaxAge:           time.Minute,
+	})
+	p.now = func() time.Time { return now }
+	p.getTicker = func(exchangeName string, _ pair.CurrencyPair, _ string) (ticker.Price, error) {
+		price, ok := prices[exchangeName]
+		if !ok {
+			return ticker.Price{}, errors.New(ticker.ErrTickerForExchangeNotFound)
+		}
+		return price, nil
+	}
+	return p
+}
+
+func TestGetPrice(t *testing.T) {
+	now := time.Now()
+	btcusdt := pair.NewCurrencyPair("BTC", "USDT")
+	fresh := ticker.Price{Last: 100, LastUpdated: now.Add(-time.Second)}
+	stale := ticker.Price{Last: 90, LastUpdated: now.Add(-time.Hour)}
+
+	p := newTestProvider(now, map[string]ticker.Price{"Bittrex": fresh, "Binance": stale})
+	price, err := p.GetPrice("Bittrex", btcusdt, ticker.Spot)
+	if err != nil {
+		t.Fatalf("Test failed. GetPrice error: %s", err)
+	}
+	if price.Last != 100 || price.Provenance.Exchange != "Bittrex" || price.Provenance.Fallback ||
+		price.Provenance.Stale {
+		t.Errorf("Test Failed - expected fresh primary price, got %+v", price)
+	}
+
+	p = newTestProvider(now, map[string]ticker.Price{"Bittrex": stale, "Binance": fresh})
+	price, err = p.GetPrice("Bittrex", btcusdt, ticker.Spot)
+	if err != nil {
+		t.Fatalf("Test failed. GetPrice error: %s", err)
+	}
+	if price.Last != 100 || price.Provenance.Exchange != "Binance" || !price.Provenance.Fallback ||
+		price.Provenance.Stale {
+		t.Errorf("Test Failed - expected fresh fallback price, got %+v", price)
+	}
+
+	p = newTestProvider(now, map[string]ticker.Price{"Bittrex": stale})
+	price, err = p.GetPrice("Bittrex", btcusdt, ticker.Spot)
+	if err != nil {
+		t.Fatalf("Test failed. GetPrice error: %s", err)
+	}
+	if price.Provenance.Exchange != "Bittrex" || price.Provenance.Fallback || !price.Provenance.Stale {
+		t.Errorf("Test Failed - expected stale primary price, got %+v", price)
+	}
+
+	p = newTestProvider(now, map[string]ticker.Price{})
+	if _, err = p.GetPrice("Bittrex", btcusdt, ticker.Spot); err == nil {
+		t.Error("Test Failed - expected an error when no price is available")
+	}
+
+	// Without a fallback the exchange price is returned as is
+	p = newTestProvider(now, map[string]ticker.Price{"Poloniex": stale})
+	price, err = p.GetPrice("Poloniex", btcusdt, ticker.Spot)
+	if err != nil {
+		t.Fatalf("Test failed. GetPrice error: %s", err)
+	}
+	if price.Provenance.Exchange != "Poloniex" || price.Provenance.Fallback || price.Provenance.Stale {
+		t.Errorf("Test Failed - unexpected provenance %+v", price.Provenance)
+	}
+}
+
+func TestNewProviderFromConfig(t *testing.T) {
+	p := NewProviderFromConfig(config.MarketDataConfig{
+		Fallbacks: []config.MarketDataFallbackConfig{
+			{Exchange: "Bittrex", Pair: "btc/usdt", FallbackExchange: "Binance", MaxAge: 30},
+		},
+	})
+	f, ok := p.GetFallback("Bittrex", pair.NewCurrencyPair("BTC", "USDT"))
+	if !ok {
+		t.Fatal("Test failed. Fallback not found")
+	}
+	if f.FallbackExchange != "Binance" || f.MaxAge != 30*time.Second {
+		t.Errorf("Test Failed - unexpected fallback %+v", f)
+	}
+}
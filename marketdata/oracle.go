@@ -0,0 +1,223 @@
+package marketdata
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+// ErrNoReferencePrice is returned by an oracle that has no price for a currency pair
+var ErrNoReferencePrice = errors.New("no reference price")
+
+// ReferencePrice is the price of a currency pair from an oracle
+type ReferencePrice struct {
+	Pair  pair.CurrencyPair `json:"pair"`
+	Price float64           `json:"price"`
+	// Name of the oracle the price came from, for combined oracles the names of the oracles the
+	// price was derived from
+	Source string    `json:"source"`
+	Time   time.Time `json:"time"`
+}
+
+// Oracle provides reference prices for currency pairs, e.g. from an index published outside the
+// exchanges, so that the prices of an exchange can be checked against them.
+type Oracle interface {
+	// Name returns the name the oracle is configured under
+	Name() string
+	// ReferencePrice returns the latest reference price of the currency pair
+	ReferencePrice(p pair.CurrencyPair) (ReferencePrice, error)
+}
+
+// TickerOracle uses the last price of the tickers of an exchange as reference prices
+type TickerOracle struct {
+	exchangeName string
+	getTicker    func(exchangeName string, p pair.CurrencyPair, assetType string) (ticker.Price, error)
+}
+
+// NewTickerOracle returns an oracle that serves the last price of the spot tickers of the exchange
+func NewTickerOracle(exchangeName string) *TickerOracle {
+	return &TickerOracle{exchangeName: exchangeName, getTicker: ticker.GetTicker}
+}
+
+// Name returns the name of the exchange
+func (o *TickerOracle) Name() string {
+	return o.exchangeName
+}
+
+// ReferencePrice returns the last price of the ticker of the currency pair
+func (o *TickerOracle) ReferencePrice(p pair.CurrencyPair) (ReferencePrice, error) {
+	tick, err := o.getTicker(o.exchangeName, p, ticker.Spot)
+	if err != nil {
+		return ReferencePrice{}, err
+	}
+	if tick.Last <= 0 {
+		return ReferencePrice{}, ErrNoReferencePrice
+	}
+	return ReferencePrice{Pair: p, Price: tick.Last, Source: o.exchangeName, Time: tick.LastUpdated}, nil
+}
+
+// RESTOracle fetches reference prices from a JSON HTTP API, e.g. the spot price or index price
+// endpoint of a price aggregator. Prices are cached for the TTL of the oracle.
+type RESTOracle struct {
+	name string
+	// URL of the price of a currency pair, see NewRESTOracle
+	urlTemplate string
+	// Path of the price in the response, the field names are delimited by "."
+	priceField []string
+	ttl        time.Duration
+	mtx        sync.Mutex
+	cache      map[string]ReferencePrice
+	now        func() time.Time
+}
+
+// NewRESTOracle returns an oracle that fetches the price of a currency pair from the URL template,
+// after replacing {base} & {quote} with the (upper case) currencies of the pair. The price is read
+// from the priceField of the JSON response, nested fields are delimited by "." (e.g. data.amount)
+// and array elements are referenced by their index. Prices may be JSON numbers or strings.
+func NewRESTOracle(name, urlTemplate, priceField string, ttl time.Duration) *RESTOracle {
+	return &RESTOracle{
+		name:        name,
+		urlTemplate: urlTemplate,
+		priceField:  strings.Split(priceField, "."),
+		ttl:         ttl,
+		cache:       make(map[string]ReferencePrice),
+		now:         time.Now,
+	}
+}
+
+// Name returns the name of the oracle
+func (o *RESTOracle) Name() string {
+	return o.name
+}
+
+// ReferencePrice returns the price of the currency pair fetched within the TTL, or fetches it.
+func (o *RESTOracle) ReferencePrice(p pair.CurrencyPair) (ReferencePrice, error) {
+	key := p.Display("/", true).String()
+	now := o.now()
+	o.mtx.Lock()
+	cached, ok := o.cache[key]
+	o.mtx.Unlock()
+	if ok && now.Sub(cached.Time) < o.ttl {
+		return cached, nil
+	}
+
+	url := strings.NewReplacer("{base}", p.FirstCurrency.Upper().String(),
+		"{quote}", p.SecondCurrency.Upper().String()).Replace(o.urlTemplate)
+	resp, statusCode, err := common.SendHTTPRequest2(http.MethodGet, url, http.Header{}, nil)
+	if err != nil {
+		return ReferencePrice{}, err
+	}
+	if statusCode != http.StatusOK {
+		return ReferencePrice{}, fmt.Errorf("%s: unexpected status %d: %s", o.name, statusCode, resp)
+	}
+	var body interface{}
+	if err = common.JSONDecode([]byte(resp), &body); err != nil {
+		return ReferencePrice{}, fmt.Errorf("%s: failed to unmarshal response: %s", o.name, err)
+	}
+	price, err := o.extractPrice(body)
+	if err != nil {
+		return ReferencePrice{}, err
+	}
+
+	result := ReferencePrice{Pair: p, Price: price, Source: o.name, Time: now}
+	o.mtx.Lock()
+	o.cache[key] = result
+	o.mtx.Unlock()
+	return result, nil
+}
+
+// extractPrice returns the price at the price field path of the response
+func (o *RESTOracle) extractPrice(body interface{}) (float64, error) {
+	value := body
+	for _, field := range o.priceField {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[field]
+		case []interface{}:
+			i, err := strconv.Atoi(field)
+			if err != nil || i < 0 || i >= len(v) {
+				return 0, fmt.Errorf("%s: invalid price field index %s", o.name, field)
+			}
+			value = v[i]
+		default:
+			value = nil
+		}
+		if value == nil {
+			return 0, fmt.Errorf("%s: %s", o.name, ErrNoReferencePrice)
+		}
+	}
+	var price float64
+	switch v := value.(type) {
+	case float64:
+		price = v
+	case string:
+		var err error
+		if price, err = strconv.ParseFloat(v, 64); err != nil {
+			return 0, fmt.Errorf("%s: invalid price %s", o.name, v)
+		}
+	default:
+		return 0, fmt.Errorf("%s: invalid price %v", o.name, v)
+	}
+	if price <= 0 {
+		return 0, fmt.Errorf("%s: %s", o.name, ErrNoReferencePrice)
+	}
+	return price, nil
+}
+
+// MedianOracle combines several oracles, the reference price is the median of the prices of the
+// oracles that have a price for the currency pair so a single bad source can't move it.
+type MedianOracle struct {
+	name    string
+	oracles []Oracle
+}
+
+// NewMedianOracle returns an oracle that serves the median of the prices of the given oracles
+func NewMedianOracle(name string, oracles ...Oracle) *MedianOracle {
+	return &MedianOracle{name: name, oracles: oracles}
+}
+
+// Name returns the name of the oracle
+func (o *MedianOracle) Name() string {
+	return o.name
+}
+
+// ReferencePrice returns the median of the prices of the oracles, the time of the price is the
+// time of the oldest price it was derived from. ErrNoReferencePrice is returned if none of the
+// oracles have a price.
+func (o *MedianOracle) ReferencePrice(p pair.CurrencyPair) (ReferencePrice, error) {
+	var prices []ReferencePrice
+	for _, oracle := range o.oracles {
+		if price, err := oracle.ReferencePrice(p); err == nil {
+			prices = append(prices, price)
+		}
+	}
+	if len(prices) == 0 {
+		return ReferencePrice{}, ErrNoReferencePrice
+	}
+	sort.Slice(prices, func(i, j int) bool { return prices[i].Price < prices[j].Price })
+
+	result := ReferencePrice{Pair: p, Time: prices[0].Time}
+	sources := make([]string, len(prices))
+	for i, price := range prices {
+		sources[i] = price.Source
+		if price.Time.Before(result.Time) {
+			result.Time = price.Time
+		}
+	}
+	result.Source = strings.Join(sources, ",")
+	if mid := len(prices) / 2; len(prices)%2 == 1 {
+		result.Price = prices[mid].Price
+	} else {
+		result.Price = (prices[mid-1].Price + prices[mid].Price) / 2
+	}
+	return result, nil
+}
@@ -0,0 +1,132 @@
+package marketdata
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+type mockOracle struct {
+	name  string
+	price float64
+}
+
+func (m *mockOracle) Name() string {
+	return m.name
+}
+
+func (m *mockOracle) ReferencePrice(p pair.CurrencyPair) (ReferencePrice, error) {
+	if m.price == 0 {
+		return ReferencePrice{}, ErrNoReferencePrice
+	}
+	return ReferencePrice{Pair: p, Price: m.price, Source: m.name, Time: time.Unix(int64(m.price), 0)}, nil
+}
+
+func TestRESTOracle(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/prices/BTC-USD/spot":
+			fmt.Fprint(w, `{"data":{"base":"BTC","currency":"USD","amount":"6400.50"}}`)
+		case "/prices/ETH-USD/spot":
+			fmt.Fprint(w, `{"data":{"base":"ETH","currency":"USD"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	now := time.Date(2018, 11, 20, 0, 0, 0, 0, time.UTC)
+	o := NewRESTOracle("coinbase", server.URL+"/prices/{base}-{quote}/spot", "data.amount", time.Minute)
+	o.now = func() time.Time { return now }
+	btcusd := pair.NewCurrencyPair("btc", "usd")
+	price, err := o.ReferencePrice(btcusd)
+	if err != nil || price.Price != 6400.5 || price.Source != "coinbase" || !price.Time.Equal(now) {
+		t.Errorf("Test failed. Unexpected reference price %+v %v", price, err)
+	}
+	o.ReferencePrice(btcusd)
+	if requests != 1 {
+		t.Errorf("Test failed. Expected the price to be cached, %d requests sent", requests)
+	}
+	now = now.Add(time.Minute)
+	o.ReferencePrice(btcusd)
+	if requests != 2 {
+		t.Errorf("Test failed. Expected the expired price to be fetched, %d requests sent", requests)
+	}
+
+	if _, err = o.ReferencePrice(pair.NewCurrencyPair("ETH", "USD")); err == nil {
+		t.Error("Test failed. Expected an error for a response without a price")
+	}
+	if _, err = o.ReferencePrice(pair.NewCurrencyPair("LTC", "USD")); err == nil {
+		t.Error("Test failed. Expected an error for an unexpected status")
+	}
+
+	o = NewRESTOracle("index", "", "result.1.price", time.Minute)
+	var body interface{} = map[string]interface{}{"result": []interface{}{
+		map[string]interface{}{"price": 1.0}, map[string]interface{}{"price": 2.5}}}
+	if p, err := o.extractPrice(body); err != nil || p != 2.5 {
+		t.Errorf("Test failed. Unexpected price %f %v", p, err)
+	}
+}
+
+func TestTickerOracle(t *testing.T) {
+	o := NewTickerOracle("Binance")
+	o.getTicker = func(exchangeName string, p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+		if exchangeName != "Binance" {
+			return ticker.Price{}, errors.New("no ticker")
+		}
+		return ticker.Price{Pair: p, Last: 6400}, nil
+	}
+	if price, err := o.ReferencePrice(pair.NewCurrencyPair("BTC", "USDT")); err != nil || price.Price != 6400 ||
+		price.Source != "Binance" {
+		t.Errorf("Test failed. Unexpected reference price %+v %v", price, err)
+	}
+}
+
+func TestMedianOracle(t *testing.T) {
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+	o := NewMedianOracle("median", &mockOracle{"a", 6400}, &mockOracle{"b", 9000}, &mockOracle{"c", 6300},
+		&mockOracle{"d", 0})
+	price, err := o.ReferencePrice(btcusd)
+	if err != nil || price.Price != 6400 || price.Source != "c,a,b" || !price.Time.Equal(time.Unix(6300, 0)) {
+		t.Errorf("Test failed. Unexpected median price %+v %v", price, err)
+	}
+	o = NewMedianOracle("median", &mockOracle{"a", 6400}, &mockOracle{"b", 6500})
+	if price, _ = o.ReferencePrice(btcusd); price.Price != 6450 {
+		t.Errorf("Test failed. Expected the mean of the middle prices, got %f", price.Price)
+	}
+	if _, err = NewMedianOracle("median", &mockOracle{"d", 0}).ReferencePrice(btcusd); err != ErrNoReferencePrice {
+		t.Errorf("Test failed. Expected ErrNoReferencePrice, got %v", err)
+	}
+}
+
+func TestProviderOracles(t *testing.T) {
+	p := NewProviderFromConfig(config.MarketDataConfig{
+		Oracles: []config.OracleConfig{
+			{Name: "index", Median: []string{"coinbase", "Kraken"}},
+			{Name: "coinbase", URL: "https://api.coinbase.com/v2/prices/{base}-{quote}/spot", PriceField: "data.amount"},
+		},
+	})
+	if o, ok := p.Oracle("coinbase"); !ok || o.(*RESTOracle).priceField[1] != "amount" {
+		t.Error("Test failed. Expected the REST oracle to be registered")
+	}
+	o, ok := p.Oracle("index")
+	if !ok {
+		t.Fatal("Test failed. Expected the median oracle to be registered")
+	}
+	oracles := o.(*MedianOracle).oracles
+	if _, isREST := oracles[0].(*RESTOracle); !isREST || oracles[1].Name() != "Kraken" {
+		t.Errorf("Test failed. Unexpected median oracles %+v", oracles)
+	}
+	if _, ok = p.Oracle("Kraken"); ok {
+		t.Error("Test failed. Expected the exchange oracle not to be registered")
+	}
+}
@@ -0,0 +1,222 @@
+// Package markets resolves exchange agnostic market names (e.g. eth/usd) to the exact symbols
+// that trade the market on each exchange, taking into account the different names exchanges use
+// for the same currency (e.g. XBT for BTC) and the USD stablecoins.
+package markets
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/asset"
+)
+
+// ErrNoMarket is returned when none of the exchanges list the market
+var ErrNoMarket = errors.New("no exchange lists the market")
+
+// Delimiters accepted between the currencies of a market name, tried in order
+var delimiters = []string{"/", "-", "_", ":", " "}
+
+// Currency codes used by some exchanges in place of the common code
+var aliases = map[string]string{
+	"XBT": "BTC",
+	"XDG": "DOGE",
+	"BCC": "BCH",
+	"DSH": "DASH",
+	"IOT": "IOTA",
+	"QTM": "QTUM",
+	"STR": "XLM",
+}
+
+// Stablecoins pegged to the dollar, matched against USD when no exact match is required
+var usdStablecoins = map[string]bool{
+	"USDT": true,
+	"USDC": true,
+	"TUSD": true,
+	"PAX":  true,
+	"GUSD": true,
+}
+
+// MatchType describes how closely a listed market matches the requested one
+type MatchType string
+
+// Match types, from the closest to the loosest
+const (
+	// The exchange lists the market under the requested currency codes
+	MatchExact MatchType = "exact"
+	// The exchange uses another code for one of the currencies (e.g. XBT for BTC)
+	MatchAlias MatchType = "alias"
+	// The exchange quotes the market in a USD stablecoin instead of USD, or the other way round
+	MatchStablecoin MatchType = "stablecoin"
+)
+
+var matchRank = map[MatchType]int{MatchExact: 0, MatchAlias: 1, MatchStablecoin: 2}
+
+// Match is a market listed by an exchange that trades the requested market
+type Match struct {
+	Exchange string `json:"exchange"`
+	// Exact symbol of the market on the exchange
+	Symbol string `json:"symbol"`
+	// Currencies as listed by the exchange
+	Base  string `json:"base"`
+	Quote string `json:"quote"`
+	// Asset type of the market, see the asset package
+	AssetType string    `json:"assetType"`
+	Type      MatchType `json:"matchType"`
+	// Set if the exchange lists the inverted market (e.g. usd/eth for eth/usd), prices must be
+	// inverted & the buy/sell sides swapped
+	Inverted bool `json:"inverted"`
+}
+
+// Lister is implemented by the exchanges, it returns the markets listed by the exchange keyed by
+// symbol
+type Lister interface {
+	GetName() string
+	GetCurrencyPairs() map[pair.CurrencyItem]*exchange.CurrencyPairInfo
+}
+
+// Normalize returns the common code of a currency, USD stablecoins aren't normalized to USD.
+func Normalize(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if alias, ok := aliases[currency]; ok {
+		return alias
+	}
+	return currency
+}
+
+// ParseMarket splits a market name into its base & quote currencies, the currencies can be
+// delimited by "/", "-", "_", ":" or a space. Names without a delimiter (e.g. ethusd) return an
+// empty quote, they're resolved by ResolveMarket against the listed markets.
+func ParseMarket(market string) (base, quote string, err error) {
+	market = strings.TrimSpace(market)
+	for _, d := range delimiters {
+		if parts := strings.Split(market, d); len(parts) > 1 {
+			if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+				return "", "", fmt.Errorf("invalid market %q", market)
+			}
+			return strings.ToUpper(strings.TrimSpace(parts[0])), strings.ToUpper(strings.TrimSpace(parts[1])), nil
+		}
+	}
+	if len(market) < 2 {
+		return "", "", fmt.Errorf("invalid market %q", market)
+	}
+	return strings.ToUpper(market), "", nil
+}
+
+// ResolveMarket returns the markets listed by the exchanges that trade the given market (e.g.
+// eth/usd, XBT-USDT or ethbtc), closest matches first. Inverted markets, alternative currency
+// codes & USD stablecoins are matched too, see Match. Returns ErrNoMarket if none of the
+// exchanges list the market.
+func ResolveMarket(market string, exchanges []Lister) ([]Match, error) {
+	base, quote, err := ParseMarket(market)
+	if err != nil {
+		return nil, err
+	}
+	// Names without a delimiter may be split anywhere
+	candidates := [][2]string{{base, quote}}
+	if quote == "" {
+		candidates = candidates[:0]
+		for i := 2; i <= len(base)-2; i++ {
+			candidates = append(candidates, [2]string{base[:i], base[i:]})
+		}
+	}
+
+	var matches []Match
+	for _, exch := range exchanges {
+		for symbol, info := range exch.GetCurrencyPairs() {
+			listedBase, listedQuote := currencies(info)
+			var best *Match
+			for _, c := range candidates {
+				if m, ok := match(c[0], c[1], listedBase, listedQuote); ok && (best == nil || closer(m, *best)) {
+					best = &m
+				}
+			}
+			if best == nil {
+				continue
+			}
+			best.Exchange = exch.GetName()
+			best.Symbol = string(symbol)
+			best.Base, best.Quote = listedBase, listedQuote
+			best.AssetType = info.GetAssetType()
+			matches = append(matches, *best)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, ErrNoMarket
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Inverted != b.Inverted || a.Type != b.Type {
+			return closer(a, b)
+		}
+		if (a.AssetType == asset.Spot) != (b.AssetType == asset.Spot) {
+			return a.AssetType == asset.Spot
+		}
+		if a.Exchange != b.Exchange {
+			return a.Exchange < b.Exchange
+		}
+		return a.Symbol < b.Symbol
+	})
+	return matches, nil
+}
+
+// closer returns true if a is a closer match than b, markets listed the requested way round are
+// preferred over inverted ones
+func closer(a, b Match) bool {
+	if a.Inverted != b.Inverted {
+		return !a.Inverted
+	}
+	return matchRank[a.Type] < matchRank[b.Type]
+}
+
+func currencies(info *exchange.CurrencyPairInfo) (base, quote string) {
+	base, quote = info.FirstCurrencyName, info.SecondCurrencyName
+	if base == "" || quote == "" {
+		base, quote = info.Currency.FirstCurrency.String(), info.Currency.SecondCurrency.String()
+	}
+	return strings.ToUpper(base), strings.ToUpper(quote)
+}
+
+// match returns how the listed market matches the requested market, ok is false if it doesn't
+func match(base, quote, listedBase, listedQuote string) (m Match, ok bool) {
+	if t, ok := matchPair(base, quote, listedBase, listedQuote); ok {
+		return Match{Type: t}, true
+	}
+	if t, ok := matchPair(base, quote, listedQuote, listedBase); ok {
+		return Match{Type: t, Inverted: true}, true
+	}
+	return Match{}, false
+}
+
+func matchPair(base, quote, listedBase, listedQuote string) (MatchType, bool) {
+	baseType, ok := matchCurrency(base, listedBase)
+	if !ok {
+		return "", false
+	}
+	quoteType, ok := matchCurrency(quote, listedQuote)
+	if !ok {
+		return "", false
+	}
+	if matchRank[quoteType] > matchRank[baseType] {
+		return quoteType, true
+	}
+	return baseType, true
+}
+
+func matchCurrency(requested, listed string) (MatchType, bool) {
+	if requested == listed {
+		return MatchExact, true
+	}
+	requested, listed = Normalize(requested), Normalize(listed)
+	if requested == listed {
+		return MatchAlias, true
+	}
+	if (requested == "USD" || usdStablecoins[requested]) && (listed == "USD" || usdStablecoins[listed]) {
+		return MatchStablecoin, true
+	}
+	return "", false
+}
@@ -0,0 +1,92 @@
+package markets
+
+import (
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/asset"
+)
+
+type mockLister struct {
+	name  string
+	pairs map[pair.CurrencyItem]*exchange.CurrencyPairInfo
+}
+
+func (m *mockLister) GetName() string {
+	return m.name
+}
+
+func (m *mockLister) GetCurrencyPairs() map[pair.CurrencyItem]*exchange.CurrencyPairInfo {
+	return m.pairs
+}
+
+func newLister(name string, symbols map[string][2]string) *mockLister {
+	m := &mockLister{name: name, pairs: make(map[pair.CurrencyItem]*exchange.CurrencyPairInfo)}
+	for symbol, c := range symbols {
+		m.pairs[pair.CurrencyItem(symbol)] = &exchange.CurrencyPairInfo{Currency: pair.NewCurrencyPair(c[0], c[1])}
+	}
+	return m
+}
+
+func TestParseMarket(t *testing.T) {
+	tests := []struct {
+		market, base, quote string
+		valid               bool
+	}{
+		{"eth/usd", "ETH", "USD", true},
+		{" XBT-USDT ", "XBT", "USDT", true},
+		{"eth_btc", "ETH", "BTC", true},
+		{"ethbtc", "ETHBTC", "", true},
+		{"eth/", "", "", false},
+		{"a/b/c", "", "", false},
+		{"e", "", "", false},
+	}
+	for _, test := range tests {
+		base, quote, err := ParseMarket(test.market)
+		if (err == nil) != test.valid || base != test.base || quote != test.quote {
+			t.Errorf("Test failed. Unexpected result for %q: %s %s %v", test.market, base, quote, err)
+		}
+	}
+}
+
+func TestResolveMarket(t *testing.T) {
+	kraken := newLister("Kraken", map[string][2]string{"XETHZUSD": {"ETH", "USD"}, "XXBTZUSD": {"XBT", "USD"}})
+	binance := newLister("Binance", map[string][2]string{"ETHUSDT": {"ETH", "USDT"}, "ETHBTC": {"ETH", "BTC"}})
+	poloniex := newLister("Poloniex", map[string][2]string{"USDT_ETH": {"USDT", "ETH"}})
+	bitmex := newLister("BitMEX", map[string][2]string{"XBTUSD": {"XBT", "USD"}})
+	bitmex.pairs["XBTUSD"].AssetType = asset.PerpetualSwap
+	exchanges := []Lister{kraken, binance, poloniex, bitmex}
+
+	matches, err := ResolveMarket("eth/usd", exchanges)
+	if err != nil {
+		t.Fatalf("Test failed. ResolveMarket returned an error: %s", err)
+	}
+	if len(matches) != 3 {
+		t.Fatalf("Test failed. Expected 3 matches, got %+v", matches)
+	}
+	if m := matches[0]; m.Exchange != "Kraken" || m.Symbol != "XETHZUSD" || m.Type != MatchExact || m.Inverted {
+		t.Errorf("Test failed. Expected the exact match first, got %+v", m)
+	}
+	if m := matches[1]; m.Exchange != "Binance" || m.Symbol != "ETHUSDT" || m.Type != MatchStablecoin {
+		t.Errorf("Test failed. Expected the USDT market second, got %+v", m)
+	}
+	if m := matches[2]; m.Exchange != "Poloniex" || !m.Inverted || m.Base != "USDT" || m.Quote != "ETH" {
+		t.Errorf("Test failed. Expected the inverted market last, got %+v", m)
+	}
+
+	matches, err = ResolveMarket("btcusd", exchanges)
+	if err != nil || len(matches) != 2 {
+		t.Fatalf("Test failed. Expected 2 matches, got %+v %v", matches, err)
+	}
+	if m := matches[0]; m.Exchange != "Kraken" || m.Type != MatchAlias || m.AssetType != asset.Spot {
+		t.Errorf("Test failed. Expected the spot market first, got %+v", m)
+	}
+	if m := matches[1]; m.Exchange != "BitMEX" || m.AssetType != asset.PerpetualSwap {
+		t.Errorf("Test failed. Expected the perpetual swap last, got %+v", m)
+	}
+
+	if _, err = ResolveMarket("ltc/eur", exchanges); err != ErrNoMarket {
+		t.Errorf("Test failed. Expected ErrNoMarket, got %v", err)
+	}
+}
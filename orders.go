@@ -1,10 +1,19 @@
 package main
 
+import (
+	"github.com/mattkanwisher/cryptofiend/storage"
+)
+
 const (
 	limitOrder = iota
 	marketOrder
 )
 
+const (
+	ordersBucket = "orders"
+	ordersKey    = "all"
+)
+
 // Orders variable holds an array of pointers to order structs
 var Orders []*Order
 
@@ -67,3 +76,22 @@ func GetOrderByOrderID(orderID int) *Order {
 	}
 	return nil
 }
+
+// SaveOrders persists the orders to the store
+func SaveOrders(s storage.Store) error {
+	return s.Put(ordersBucket, ordersKey, Orders)
+}
+
+// LoadOrders replaces the orders with the ones previously saved to the store
+func LoadOrders(s storage.Store) error {
+	var orders []*Order
+	err := s.Get(ordersBucket, ordersKey, &orders)
+	if err == storage.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	Orders = orders
+	return nil
+}
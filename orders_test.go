@@ -2,6 +2,8 @@ package main
 
 import (
 	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/storage"
 )
 
 func TestNewOrder(t *testing.T) {
@@ -35,3 +37,20 @@ func TestGetOrderByOrderID(t *testing.T) {
 		t.Error("Test Failed - Orders_test.go GetOrdersByExchange() - Error")
 	}
 }
+
+func TestSaveLoadOrders(t *testing.T) {
+	store := storage.NewMemoryStore()
+	Orders = nil
+	NewOrder("Bitfinex", 1, 1000)
+	if err := SaveOrders(store); err != nil {
+		t.Fatalf("Test failed. SaveOrders error: %s", err)
+	}
+
+	Orders = nil
+	if err := LoadOrders(store); err != nil {
+		t.Fatalf("Test failed. LoadOrders error: %s", err)
+	}
+	if len(Orders) != 1 || Orders[0].Exchange != "Bitfinex" || Orders[0].Price != 1000 {
+		t.Errorf("Test Failed - unexpected orders %v", Orders)
+	}
+}
@@ -0,0 +1,121 @@
+package pnl
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+const hoursPerYear = 365 * 24
+
+// LendingSummary holds the interest earned by lending a currency over a period
+type LendingSummary struct {
+	Currency string  `json:"currency"`
+	Loans    int     `json:"loans"`
+	Interest float64 `json:"interest"`
+	Fees     float64 `json:"fees"`
+	Earned   float64 `json:"earned"`
+	// Average amount lent out over the period
+	AverageLent float64 `json:"averageLent"`
+	// Annual percentage rate earned on the amount lent out, net of fees (e.g. 0.1 for 10%)
+	APR float64 `json:"apr"`
+}
+
+// LendingReport summarizes the interest earned by lending funds to margin traders over a period
+type LendingReport struct {
+	Start      time.Time        `json:"start"`
+	End        time.Time        `json:"end"`
+	Currencies []LendingSummary `json:"currencies"`
+}
+
+// NewLendingReport aggregates the loans (from any exchange) into per currency summaries of the
+// interest earned between start & end. The interest of loans that were only partially open during
+// the period is prorated by the time they were open for.
+func NewLendingReport(records []exchange.LendingRecord, start, end time.Time) LendingReport {
+	report := LendingReport{Start: start, End: end, Currencies: []LendingSummary{}}
+	summaries := make(map[string]*LendingSummary)
+	// Sum of the amounts lent out multiplied by the number of years they were lent out for
+	lentYears := make(map[string]float64)
+
+	for i := range records {
+		r := &records[i]
+		closed := r.Closed
+		if closed.IsZero() || closed.After(end) {
+			closed = end
+		}
+		opened := r.Opened
+		if opened.Before(start) {
+			opened = start
+		}
+		overlap := closed.Sub(opened)
+		if overlap <= 0 {
+			continue
+		}
+		fraction := 1.0
+		if duration := r.Closed.Sub(r.Opened); !r.Closed.IsZero() && duration > 0 {
+			fraction = float64(overlap) / float64(duration)
+		}
+
+		currency := strings.ToUpper(r.Currency)
+		summary, ok := summaries[currency]
+		if !ok {
+			summary = &LendingSummary{Currency: currency}
+			summaries[currency] = summary
+		}
+		summary.Loans++
+		summary.Interest += r.Interest * fraction
+		summary.Fees += r.Fee * fraction
+		summary.Earned += r.Earned * fraction
+		lentYears[currency] += r.Amount * overlap.Hours() / hoursPerYear
+	}
+
+	periodYears := end.Sub(start).Hours() / hoursPerYear
+	for currency, summary := range summaries {
+		if periodYears > 0 {
+			summary.AverageLent = lentYears[currency] / periodYears
+		}
+		if lentYears[currency] > 0 {
+			summary.APR = summary.Earned / lentYears[currency]
+		}
+		report.Currencies = append(report.Currencies, *summary)
+	}
+	sort.Slice(report.Currencies, func(i, j int) bool {
+		return report.Currencies[i].Currency < report.Currencies[j].Currency
+	})
+	return report
+}
+
+// WriteLendingCSV writes the currency summaries of a lending report to w in CSV format (with a
+// header row).
+func WriteLendingCSV(w io.Writer, report LendingReport) error {
+	writer := csv.NewWriter(w)
+	header := []string{"currency", "loans", "interest", "fees", "earned", "averageLent", "apr"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	formatFloat := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	for _, s := range report.Currencies {
+		row := []string{
+			s.Currency,
+			strconv.Itoa(s.Loans),
+			formatFloat(s.Interest),
+			formatFloat(s.Fees),
+			formatFloat(s.Earned),
+			formatFloat(s.AverageLent),
+			formatFloat(s.APR),
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write lending summaries: %s", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,49 @@
+package pnl
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+func TestLendingReport(t *testing.T) {
+	records := []exchange.LendingRecord{
+		// entirely within the period, 1000 lent for 73 days (a fifth of a year)
+		{ID: "1", Exchange: "Poloniex", Currency: "BTC", Amount: 1000, Rate: 0.0005, Interest: 36.5,
+			Fee: 5.475, Earned: 31.025, Opened: day(2017, 3, 1), Closed: day(2017, 5, 13)},
+		// half of the loan falls before the period
+		{ID: "2", Exchange: "Bitfinex", Currency: "usd", Amount: 500, Rate: 0.001, Interest: 10,
+			Fee: 1.5, Earned: 8.5, Opened: day(2016, 12, 22), Closed: day(2017, 1, 11)},
+		// entirely after the period
+		{ID: "3", Exchange: "Bitfinex", Currency: "USD", Amount: 500, Interest: 10, Earned: 8.5,
+			Opened: day(2018, 1, 2), Closed: day(2018, 1, 5)},
+	}
+	report := NewLendingReport(records, day(2017, 1, 1), day(2018, 1, 1))
+
+	if len(report.Currencies) != 2 {
+		t.Fatalf("Test failed. Expected 2 currencies, got %+v", report.Currencies)
+	}
+	btc, usd := report.Currencies[0], report.Currencies[1]
+	if btc.Currency != "BTC" || btc.Loans != 1 || btc.Earned != 31.025 {
+		t.Errorf("Test failed. Unexpected BTC summary %+v", btc)
+	}
+	if math.Abs(btc.AverageLent-200) > 1e-9 || math.Abs(btc.APR-0.155125) > 1e-9 {
+		t.Errorf("Test failed. Expected BTC average lent of 200 at 15.5125%% APR, got %+v", btc)
+	}
+	if usd.Currency != "USD" || usd.Loans != 1 || math.Abs(usd.Interest-5) > 1e-9 ||
+		math.Abs(usd.Earned-4.25) > 1e-9 {
+		t.Errorf("Test failed. Expected USD interest to be prorated, got %+v", usd)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteLendingCSV(&buf, report); err != nil {
+		t.Fatalf("Test failed. WriteLendingCSV error: %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "BTC,1,36.5,5.475,31.025,") {
+		t.Errorf("Test failed. Unexpected CSV output:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,530 @@
+// Package pnl tracks the realized profit & loss of the assets traded by the bot, using tax lots
+// that are pooled per asset across all exchanges.
+package pnl
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/money"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/storage"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	pnlBucket  = "pnl"
+	taxLotsKey = "taxlots"
+)
+
+var (
+	// ErrInsufficientLots is returned when a disposal exceeds the amount held in the open lots
+	ErrInsufficientLots = errors.New("insufficient tax lots")
+	// ErrNotReportingCurrency is returned when importing a fill priced in a currency other than
+	// the reporting currency
+	ErrNotReportingCurrency = errors.New("fill isn't priced in the reporting currency")
+)
+
+// LotMethod determines which lots are consumed when an asset is disposed of
+type LotMethod string
+
+// Lot selection methods
+const (
+	// First in, first out
+	LotMethodFIFO LotMethod = "FIFO"
+	// Highest cost basis first
+	LotMethodHIFO LotMethod = "HIFO"
+	// Lots are explicitly selected by the disposal (falls back to FIFO for any remainder)
+	LotMethodSpecific LotMethod = "SPECIFIC"
+)
+
+// ParseLotMethod returns the lot method with the given name (case insensitive)
+func ParseLotMethod(name string) (LotMethod, error) {
+	method := LotMethod(strings.ToUpper(name))
+	switch method {
+	case LotMethodFIFO, LotMethodHIFO, LotMethodSpecific:
+		return method, nil
+	}
+	return "", fmt.Errorf("unsupported lot method %s", name)
+}
+
+// Jurisdiction holds the tax rules used to select lots & classify gains
+type Jurisdiction struct {
+	Name   string    `json:"name"`
+	Method LotMethod `json:"method"`
+	// Gains on lots held for longer than this are long term, zero if there's no distinction
+	LongTermAfter time.Duration `json:"longTermAfter"`
+}
+
+// Jurisdiction presets, gains are long term after a year of holding in both.
+var (
+	JurisdictionUS = Jurisdiction{Name: "US", Method: LotMethodFIFO, LongTermAfter: 365 * 24 * time.Hour}
+	JurisdictionDE = Jurisdiction{Name: "DE", Method: LotMethodFIFO, LongTermAfter: 365 * 24 * time.Hour}
+)
+
+// GetJurisdiction returns the jurisdiction preset with the given name, the US preset is returned
+// if the name is empty.
+func GetJurisdiction(name string) (Jurisdiction, error) {
+	switch strings.ToUpper(name) {
+	case "", JurisdictionUS.Name:
+		return JurisdictionUS, nil
+	case JurisdictionDE.Name:
+		return JurisdictionDE, nil
+	}
+	return Jurisdiction{}, fmt.Errorf("unsupported jurisdiction %s", name)
+}
+
+// Fill is a trade executed on an exchange, normalized across exchanges. Prices & fees must be
+// denominated in the reporting currency (e.g. USD) of the tax lots, fills priced in any other
+// currency are rejected since there's no historical rate to convert them at.
+type Fill struct {
+	ID       string             `json:"id"`
+	Exchange string             `json:"exchange"`
+	Asset    string             `json:"asset"`
+	Side     exchange.OrderSide `json:"side"`
+	Amount   float64            `json:"amount"`
+	Price    float64            `json:"price"`
+	Fee      float64            `json:"fee"`
+	Time     time.Time          `json:"time"`
+	// IDs of the lots to dispose of first when using LotMethodSpecific
+	LotIDs []string `json:"lotIds,omitempty"`
+	// Exchange order the fill belongs to
+	OrderID string `json:"orderId,omitempty"`
+	// Strategy (or strategy tag) that placed the order
+	Strategy string `json:"strategy,omitempty"`
+	// Currency of the price & fee, the reporting currency is assumed if empty
+	Currency string `json:"currency,omitempty"`
+}
+
+// Quantity returns the amount of the asset traded
+func (f *Fill) Quantity() money.Amount {
+	return money.FromFloat(f.Amount, f.Asset)
+}
+
+// Notional returns the value of the fill (excluding the fee) in the reporting currency
+func (f *Fill) Notional(reportingCurrency string) money.Amount {
+	return f.Quantity().Convert(decimal.NewFromFloat(f.Price), reportingCurrency)
+}
+
+// FeeAmount returns the fee paid in the reporting currency
+func (f *Fill) FeeAmount(reportingCurrency string) money.Amount {
+	return money.FromFloat(f.Fee, reportingCurrency)
+}
+
+// LedgerEntry is a non-trade balance change, normalized across exchanges. Only income (e.g.
+// staking rewards, airdrops) creates tax lots, at the fair value of the asset at the time it was
+// received. Transfers between exchanges don't affect lots since lots are pooled per asset.
+type LedgerEntry struct {
+	ID        string    `json:"id"`
+	Exchange  string    `json:"exchange"`
+	Asset     string    `json:"asset"`
+	Amount    float64   `json:"amount"`
+	FairValue float64   `json:"fairValue"` // Price of the asset in the reporting currency
+	Income    bool      `json:"income"`
+	Time      time.Time `json:"time"`
+}
+
+// TaxLot is an amount of an asset acquired at the same time & price
+type TaxLot struct {
+	ID        string    `json:"id"`
+	Asset     string    `json:"asset"`
+	Amount    float64   `json:"amount"` // Remaining amount
+	CostBasis float64   `json:"costBasis"`
+	Acquired  time.Time `json:"acquired"`
+	Source    string    `json:"source"` // Exchange the lot was acquired on
+}
+
+// Disposal is the realized gain from disposing (part of) a single lot
+type Disposal struct {
+	FillID    string    `json:"fillId"`
+	LotID     string    `json:"lotId"`
+	Asset     string    `json:"asset"`
+	Amount    float64   `json:"amount"`
+	Proceeds  float64   `json:"proceeds"`
+	CostBasis float64   `json:"costBasis"`
+	Gain      float64   `json:"gain"`
+	Acquired  time.Time `json:"acquired"`
+	Disposed  time.Time `json:"disposed"`
+	LongTerm  bool      `json:"longTerm"`
+	// Strategy of the disposing fill
+	Strategy string `json:"strategy,omitempty"`
+}
+
+// TaxReport summarizes the realized gains & income over a tax year
+type TaxReport struct {
+	Year          int        `json:"year"`
+	Jurisdiction  string     `json:"jurisdiction"`
+	Proceeds      float64    `json:"proceeds"`
+	CostBasis     float64    `json:"costBasis"`
+	ShortTermGain float64    `json:"shortTermGain"`
+	LongTermGain  float64    `json:"longTermGain"`
+	Income        float64    `json:"income"`
+	Disposals     []Disposal `json:"disposals"`
+	// Realized gains of the disposals made by fills attributed to a strategy, keyed by strategy
+	GainByStrategy map[string]float64 `json:"gainByStrategy,omitempty"`
+	// Lots still open at the end of the year
+	OpenLots []TaxLot `json:"openLots"`
+}
+
+type taxLotsState struct {
+	Lots      []TaxLot        `json:"lots"`
+	Closed    []TaxLot        `json:"closed"`
+	Disposals []Disposal      `json:"disposals"`
+	Income    []LedgerEntry   `json:"income"`
+	Seen      map[string]bool `json:"seen"`
+	NextLot   int             `json:"nextLot"`
+}
+
+func (s *taxLotsState) clone() taxLotsState {
+	c := *s
+	c.Lots = append([]TaxLot(nil), s.Lots...)
+	c.Closed = append([]TaxLot(nil), s.Closed...)
+	c.Disposals = append([]Disposal(nil), s.Disposals...)
+	c.Income = append([]LedgerEntry(nil), s.Income...)
+	c.Seen = make(map[string]bool, len(s.Seen))
+	for key := range s.Seen {
+		c.Seen[key] = true
+	}
+	return c
+}
+
+// TaxLots tracks the tax lots of all assets across all exchanges. Fills & ledger entries must be
+// recorded in chronological order.
+type TaxLots struct {
+	m            sync.Mutex
+	jurisdiction Jurisdiction
+	state        taxLotsState
+}
+
+// NewTaxLots creates an empty set of tax lots that follows the rules of the jurisdiction
+func NewTaxLots(jurisdiction Jurisdiction) *TaxLots {
+	return &TaxLots{
+		jurisdiction: jurisdiction,
+		state:        taxLotsState{Seen: make(map[string]bool)},
+	}
+}
+
+func recordKey(kind, exchangeName, id string) string {
+	return kind + "/" + exchangeName + "/" + id
+}
+
+func (t *TaxLots) newLot(asset, source string, amount, costBasis float64, acquired time.Time) {
+	t.state.NextLot++
+	t.state.Lots = append(t.state.Lots, TaxLot{
+		ID:        strconv.Itoa(t.state.NextLot),
+		Asset:     strings.ToUpper(asset),
+		Amount:    amount,
+		CostBasis: costBasis,
+		Acquired:  acquired,
+		Source:    source,
+	})
+}
+
+// RecordFill records a fill, buys open a new lot and sells dispose of open lots. Fills that have
+// already been recorded are ignored, so the fills of an exchange can be re-imported.
+func (t *TaxLots) RecordFill(f Fill) ([]Disposal, error) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return t.recordFill(f)
+}
+
+func (t *TaxLots) recordFill(f Fill) ([]Disposal, error) {
+	key := recordKey("fill", f.Exchange, f.ID)
+	if t.state.Seen[key] {
+		return nil, nil
+	}
+	if f.Amount <= 0 {
+		return nil, fmt.Errorf("invalid fill amount %v", f.Amount)
+	}
+
+	var disposals []Disposal
+	if f.Side == exchange.OrderSideSell {
+		var err error
+		if disposals, err = t.dispose(f); err != nil {
+			return nil, err
+		}
+	} else {
+		// fees paid on acquisition are part of the cost basis
+		t.newLot(f.Asset, f.Exchange, f.Amount, f.Price+f.Fee/f.Amount, f.Time)
+	}
+	t.state.Seen[key] = true
+	return disposals, nil
+}
+
+// RecordLedgerEntry records a non-trade balance change, income opens a new lot at the fair value
+// of the asset. Other entries are ignored.
+func (t *TaxLots) RecordLedgerEntry(e LedgerEntry) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.recordLedgerEntry(e)
+}
+
+func (t *TaxLots) recordLedgerEntry(e LedgerEntry) {
+	key := recordKey("ledger", e.Exchange, e.ID)
+	if !e.Income || e.Amount <= 0 || t.state.Seen[key] {
+		return
+	}
+	t.newLot(e.Asset, e.Exchange, e.Amount, e.FairValue, e.Time)
+	t.state.Income = append(t.state.Income, e)
+	t.state.Seen[key] = true
+}
+
+// Import records a batch of fills & ledger entries in chronological order, and returns the
+// disposals realized by the fills. The batch is recorded atomically: the fills are validated
+// before any of the batch is recorded, and if a fill can't be recorded (e.g. it disposes of more
+// than the open lots) none of the batch is recorded.
+func (t *TaxLots) Import(fills []Fill, ledger []LedgerEntry, reportingCurrency string) ([]Disposal, error) {
+	for i := range fills {
+		f := &fills[i]
+		if f.Amount <= 0 {
+			return nil, fmt.Errorf("invalid %s fill %s: invalid fill amount %v", f.Exchange, f.ID, f.Amount)
+		}
+		if f.Currency != "" && !strings.EqualFold(f.Currency, reportingCurrency) {
+			return nil, fmt.Errorf("invalid %s fill %s: %s (%s)", f.Exchange, f.ID, ErrNotReportingCurrency,
+				reportingCurrency)
+		}
+	}
+	fills = append([]Fill(nil), fills...)
+	ledger = append([]LedgerEntry(nil), ledger...)
+	sort.SliceStable(fills, func(i, j int) bool { return fills[i].Time.Before(fills[j].Time) })
+	sort.SliceStable(ledger, func(i, j int) bool { return ledger[i].Time.Before(ledger[j].Time) })
+
+	t.m.Lock()
+	defer t.m.Unlock()
+	previous := t.state.clone()
+	disposals := []Disposal{}
+	i, j := 0, 0
+	for i < len(fills) || j < len(ledger) {
+		if i == len(fills) || (j < len(ledger) && ledger[j].Time.Before(fills[i].Time)) {
+			t.recordLedgerEntry(ledger[j])
+			j++
+			continue
+		}
+		d, err := t.recordFill(fills[i])
+		if err != nil {
+			t.state = previous
+			return nil, fmt.Errorf("failed to record %s fill %s: %s", fills[i].Exchange, fills[i].ID, err)
+		}
+		disposals = append(disposals, d...)
+		i++
+	}
+	return disposals, nil
+}
+
+// Returns the indices of the open lots of the asset in the order they should be disposed of.
+func (t *TaxLots) selectLots(asset string, lotIDs []string) []int {
+	var candidates []int
+	for i := range t.state.Lots {
+		if t.state.Lots[i].Asset == asset && t.state.Lots[i].Amount > 0 {
+			candidates = append(candidates, i)
+		}
+	}
+	lots := t.state.Lots
+	switch t.jurisdiction.Method {
+	case LotMethodHIFO:
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return lots[candidates[i]].CostBasis > lots[candidates[j]].CostBasis
+		})
+	case LotMethodSpecific:
+		selected := make(map[string]int, len(lotIDs))
+		for i, id := range lotIDs {
+			selected[id] = i
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			pi, iok := selected[lots[candidates[i]].ID]
+			pj, jok := selected[lots[candidates[j]].ID]
+			if iok && jok {
+				return pi < pj
+			}
+			return iok && !jok
+		})
+	}
+	return candidates
+}
+
+func (t *TaxLots) dispose(f Fill) ([]Disposal, error) {
+	asset := strings.ToUpper(f.Asset)
+	indices := t.selectLots(asset, f.LotIDs)
+	var held float64
+	for _, i := range indices {
+		held += t.state.Lots[i].Amount
+	}
+	// allow for floating point error in the lot amounts
+	if held < f.Amount*(1-1e-9) {
+		return nil, ErrInsufficientLots
+	}
+
+	var disposals []Disposal
+	remaining := f.Amount
+	for _, i := range indices {
+		if remaining <= 0 {
+			break
+		}
+		lot := &t.state.Lots[i]
+		amount := lot.Amount
+		if amount > remaining {
+			amount = remaining
+		}
+		// fees paid on disposal reduce the proceeds
+		proceeds := amount * (f.Price - f.Fee/f.Amount)
+		costBasis := amount * lot.CostBasis
+		disposals = append(disposals, Disposal{
+			FillID:    f.ID,
+			LotID:     lot.ID,
+			Asset:     asset,
+			Amount:    amount,
+			Proceeds:  proceeds,
+			CostBasis: costBasis,
+			Gain:      proceeds - costBasis,
+			Acquired:  lot.Acquired,
+			Disposed:  f.Time,
+			LongTerm:  t.jurisdiction.LongTermAfter > 0 && f.Time.Sub(lot.Acquired) > t.jurisdiction.LongTermAfter,
+			Strategy:  f.Strategy,
+		})
+		lot.Amount -= amount
+		remaining -= amount
+	}
+
+	// move the lots that have been fully disposed of out of the open lots
+	open := t.state.Lots[:0]
+	for _, lot := range t.state.Lots {
+		if lot.Amount > 1e-12 {
+			open = append(open, lot)
+		} else {
+			lot.Amount = 0
+			t.state.Closed = append(t.state.Closed, lot)
+		}
+	}
+	t.state.Lots = open
+	t.state.Disposals = append(t.state.Disposals, disposals...)
+	return disposals, nil
+}
+
+// OpenLots returns the lots that haven't been fully disposed of
+func (t *TaxLots) OpenLots() []TaxLot {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return append([]TaxLot(nil), t.state.Lots...)
+}
+
+// Report generates the tax report for a year, tax years run from January 1st to December 31st
+// in the given location.
+func (t *TaxLots) Report(year int, loc *time.Location) TaxReport {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	end := start.AddDate(1, 0, 0)
+	report := TaxReport{Year: year, Jurisdiction: t.jurisdiction.Name}
+	inYear := func(tm time.Time) bool {
+		return !tm.Before(start) && tm.Before(end)
+	}
+
+	// amounts disposed of after the end of the year were still open at the end of the year
+	disposedLater := make(map[string]float64)
+	for _, d := range t.state.Disposals {
+		if inYear(d.Disposed) {
+			report.Disposals = append(report.Disposals, d)
+			report.Proceeds += d.Proceeds
+			report.CostBasis += d.CostBasis
+			if d.LongTerm {
+				report.LongTermGain += d.Gain
+			} else {
+				report.ShortTermGain += d.Gain
+			}
+			if d.Strategy != "" {
+				if report.GainByStrategy == nil {
+					report.GainByStrategy = make(map[string]float64)
+				}
+				report.GainByStrategy[d.Strategy] += d.Gain
+			}
+		} else if !d.Disposed.Before(end) {
+			disposedLater[d.LotID] += d.Amount
+		}
+	}
+	for _, e := range t.state.Income {
+		if inYear(e.Time) {
+			report.Income += e.Amount * e.FairValue
+		}
+	}
+
+	lots := append(append([]TaxLot(nil), t.state.Closed...), t.state.Lots...)
+	for _, lot := range lots {
+		lot.Amount += disposedLater[lot.ID]
+		if lot.Amount > 0 && lot.Acquired.Before(end) {
+			report.OpenLots = append(report.OpenLots, lot)
+		}
+	}
+	sort.SliceStable(report.OpenLots, func(i, j int) bool {
+		return report.OpenLots[i].Acquired.Before(report.OpenLots[j].Acquired)
+	})
+	return report
+}
+
+// Save persists the tax lots to the store
+func (t *TaxLots) Save(s storage.Store) error {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return s.Put(pnlBucket, taxLotsKey, &t.state)
+}
+
+// Load replaces the tax lots with the ones previously saved to the store, returns false if nothing
+// has been saved yet
+func (t *TaxLots) Load(s storage.Store) (bool, error) {
+	var state taxLotsState
+	err := s.Get(pnlBucket, taxLotsKey, &state)
+	if err == storage.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if state.Seen == nil {
+		state.Seen = make(map[string]bool)
+	}
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.state = state
+	return true, nil
+}
+
+// WriteDisposalsCSV writes the disposals of a tax report to w in CSV format (with a header row).
+func WriteDisposalsCSV(w io.Writer, disposals []Disposal) error {
+	writer := csv.NewWriter(w)
+	header := []string{"asset", "amount", "acquired", "disposed", "proceeds", "costBasis", "gain", "term"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	formatFloat := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	for i := range disposals {
+		d := &disposals[i]
+		term := "short"
+		if d.LongTerm {
+			term = "long"
+		}
+		err := writer.Write([]string{
+			d.Asset,
+			formatFloat(d.Amount),
+			d.Acquired.UTC().Format(time.RFC3339),
+			d.Disposed.UTC().Format(time.RFC3339),
+			formatFloat(d.Proceeds),
+			formatFloat(d.CostBasis),
+			formatFloat(d.Gain),
+			term,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write disposals: %s", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,220 @@
+package pnl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/storage"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 12, 0, 0, 0, time.UTC)
+}
+
+func recordTestFills(t *testing.T, lots *TaxLots, sell Fill) []Disposal {
+	fills := []Fill{
+		{ID: "1", Exchange: "Bitfinex", Asset: "BTC", Side: exchange.OrderSideBuy, Amount: 1, Price: 1000, Time: day(2016, 6, 1)},
+		{ID: "2", Exchange: "Binance", Asset: "BTC", Side: exchange.OrderSideBuy, Amount: 1, Price: 5000, Fee: 10, Time: day(2017, 6, 1)},
+		{ID: "3", Exchange: "Bitfinex", Asset: "BTC", Side: exchange.OrderSideBuy, Amount: 1, Price: 3000, Time: day(2017, 9, 1)},
+	}
+	for _, f := range fills {
+		if _, err := lots.RecordFill(f); err != nil {
+			t.Fatalf("Test failed. RecordFill error: %s", err)
+		}
+	}
+	disposals, err := lots.RecordFill(sell)
+	if err != nil {
+		t.Fatalf("Test failed. RecordFill error: %s", err)
+	}
+	return disposals
+}
+
+func TestTaxLotMethods(t *testing.T) {
+	sell := Fill{ID: "4", Exchange: "Binance", Asset: "btc", Side: exchange.OrderSideSell, Amount: 1.5,
+		Price: 10000, Time: day(2017, 12, 1), LotIDs: []string{"3", "2"}}
+	tests := []struct {
+		method LotMethod
+		lots   []string
+		gain   float64
+	}{
+		{LotMethodFIFO, []string{"1", "2"}, 9000 + 2495},
+		{LotMethodHIFO, []string{"2", "3"}, 4990 + 3500},
+		{LotMethodSpecific, []string{"3", "2"}, 7000 + 2495},
+	}
+	for _, test := range tests {
+		jurisdiction := JurisdictionUS
+		jurisdiction.Method = test.method
+		lots := NewTaxLots(jurisdiction)
+		disposals := recordTestFills(t, lots, sell)
+		if len(disposals) != 2 || disposals[0].LotID != test.lots[0] || disposals[1].LotID != test.lots[1] {
+			t.Errorf("Test failed. %s: unexpected disposals %+v", test.method, disposals)
+			continue
+		}
+		if gain := disposals[0].Gain + disposals[1].Gain; math.Abs(gain-test.gain) > 1e-9 {
+			t.Errorf("Test failed. %s: expected gain %v, got %v", test.method, test.gain, gain)
+		}
+		if open := lots.OpenLots(); len(open) != 2 {
+			t.Errorf("Test failed. %s: expected 2 open lots, got %+v", test.method, open)
+		}
+	}
+
+	// FIFO disposal of the 2016 lot is long term
+	lots := NewTaxLots(JurisdictionUS)
+	disposals := recordTestFills(t, lots, sell)
+	if !disposals[0].LongTerm || disposals[1].LongTerm {
+		t.Errorf("Test failed. Unexpected holding terms %+v", disposals)
+	}
+
+	// fills that were already recorded are ignored
+	if d, err := lots.RecordFill(sell); err != nil || d != nil {
+		t.Errorf("Test failed. Expected duplicate fill to be ignored, got %v %v", d, err)
+	}
+	sell.ID = "5"
+	sell.Amount = 2
+	if _, err := lots.RecordFill(sell); err != ErrInsufficientLots {
+		t.Errorf("Test failed. Expected ErrInsufficientLots, got %v", err)
+	}
+}
+
+func TestTaxReport(t *testing.T) {
+	lots := NewTaxLots(JurisdictionUS)
+	recordTestFills(t, lots, Fill{ID: "4", Exchange: "Binance", Asset: "BTC", Side: exchange.OrderSideSell,
+		Amount: 1.5, Price: 10000, Time: day(2017, 12, 1), OrderID: "o4", Strategy: "grid"})
+	lots.RecordLedgerEntry(LedgerEntry{ID: "r1", Exchange: "Kraken", Asset: "ETH", Amount: 2, FairValue: 800,
+		Income: true, Time: day(2018, 1, 15)})
+	lots.RecordLedgerEntry(LedgerEntry{ID: "w1", Exchange: "Kraken", Asset: "BTC", Amount: -1, Time: day(2018, 1, 16)})
+	if _, err := lots.RecordFill(Fill{ID: "6", Exchange: "Bitfinex", Asset: "BTC", Side: exchange.OrderSideSell,
+		Amount: 1.5, Price: 8000, Time: day(2018, 3, 1)}); err != nil {
+		t.Fatalf("Test failed. RecordFill error: %s", err)
+	}
+
+	report := lots.Report(2017, time.UTC)
+	if len(report.Disposals) != 2 || report.LongTermGain != 9000 || report.ShortTermGain != 2495 ||
+		report.Proceeds != 15000 || report.Income != 0 {
+		t.Errorf("Test failed. Unexpected 2017 report %+v", report)
+	}
+	if report.Disposals[0].Strategy != "grid" || len(report.GainByStrategy) != 1 ||
+		report.GainByStrategy["grid"] != 11495 {
+		t.Errorf("Test failed. Unexpected 2017 gains by strategy %+v", report.GainByStrategy)
+	}
+	// the BTC lots disposed of in 2018 were still open at the end of 2017
+	if len(report.OpenLots) != 2 || report.OpenLots[0].Amount != 0.5 || report.OpenLots[1].Amount != 1 {
+		t.Errorf("Test failed. Unexpected 2017 open lots %+v", report.OpenLots)
+	}
+
+	report = lots.Report(2018, time.UTC)
+	if len(report.Disposals) != 2 || report.Income != 1600 || len(report.OpenLots) != 1 ||
+		report.OpenLots[0].Asset != "ETH" {
+		t.Errorf("Test failed. Unexpected 2018 report %+v", report)
+	}
+	if report.GainByStrategy != nil {
+		t.Errorf("Test failed. Expected no gains by strategy for untagged fills %+v", report.GainByStrategy)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDisposalsCSV(&buf, report.Disposals); err != nil {
+		t.Fatalf("Test failed. WriteDisposalsCSV error: %s", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 {
+		t.Errorf("Test failed. Unexpected CSV output %s", buf.String())
+	}
+}
+
+func TestTaxLotsSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "taxlots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := storage.NewFileStore(filepath.Join(dir, "store.json"))
+	if err != nil {
+		t.Fatalf("Test failed. NewFileStore error: %s", err)
+	}
+
+	lots := NewTaxLots(JurisdictionUS)
+	recordTestFills(t, lots, Fill{ID: "4", Exchange: "Binance", Asset: "BTC", Side: exchange.OrderSideSell,
+		Amount: 0.5, Price: 10000, Time: day(2017, 12, 1)})
+	if err = lots.Save(store); err != nil {
+		t.Fatalf("Test failed. Save error: %s", err)
+	}
+
+	loaded := NewTaxLots(JurisdictionUS)
+	if ok, err := loaded.Load(store); !ok || err != nil {
+		t.Fatalf("Test failed. Load returned %v %v", ok, err)
+	}
+	if open := loaded.OpenLots(); len(open) != 3 || open[0].Amount != 0.5 {
+		t.Errorf("Test failed. Unexpected loaded lots %+v", open)
+	}
+	if d, _ := loaded.RecordFill(Fill{ID: "1", Exchange: "Bitfinex", Asset: "BTC", Side: exchange.OrderSideBuy,
+		Amount: 1, Price: 1000}); d != nil || len(loaded.OpenLots()) != 3 {
+		t.Error("Test failed. Expected previously recorded fills to be ignored after loading")
+	}
+}
+
+func TestImport(t *testing.T) {
+	lots := NewTaxLots(JurisdictionUS)
+	buy := Fill{ID: "1", Exchange: "Bitfinex", Asset: "BTC", Side: exchange.OrderSideBuy, Amount: 1, Price: 1000,
+		Time: day(2017, 1, 1)}
+	reward := LedgerEntry{ID: "r1", Exchange: "Kraken", Asset: "ETH", Amount: 2, FairValue: 10, Income: true,
+		Time: day(2017, 1, 2)}
+
+	// the sell exceeds the open lots, so none of the batch is recorded
+	_, err := lots.Import([]Fill{
+		{ID: "2", Exchange: "Bitfinex", Asset: "BTC", Side: exchange.OrderSideSell, Amount: 2, Price: 2000,
+			Time: day(2017, 2, 1)},
+		buy,
+	}, []LedgerEntry{reward}, "USD")
+	if err == nil {
+		t.Fatal("Test failed. Expected the import to fail")
+	}
+	if open := lots.OpenLots(); len(open) != 0 {
+		t.Fatalf("Test failed. Expected nothing to be recorded, got %+v", open)
+	}
+
+	btcPriced := buy
+	btcPriced.ID, btcPriced.Asset, btcPriced.Currency = "3", "ETH", "BTC"
+	if _, err = lots.Import([]Fill{buy, btcPriced}, nil, "USD"); err == nil ||
+		!strings.Contains(err.Error(), ErrNotReportingCurrency.Error()) {
+		t.Fatalf("Test failed. Expected ErrNotReportingCurrency but got %v", err)
+	}
+	if open := lots.OpenLots(); len(open) != 0 {
+		t.Fatalf("Test failed. Expected nothing to be recorded, got %+v", open)
+	}
+
+	buy.Currency = "usd"
+	disposals, err := lots.Import([]Fill{
+		{ID: "2", Exchange: "Bitfinex", Asset: "BTC", Side: exchange.OrderSideSell, Amount: 0.5, Price: 2000,
+			Time: day(2017, 2, 1)},
+		buy,
+	}, []LedgerEntry{reward}, "USD")
+	if err != nil {
+		t.Fatalf("Test failed. Import error: %s", err)
+	}
+	if len(disposals) != 1 || disposals[0].Gain != 500 {
+		t.Errorf("Test failed. Unexpected disposals %+v", disposals)
+	}
+	if open := lots.OpenLots(); len(open) != 2 || open[0].Amount != 0.5 {
+		t.Errorf("Test failed. Unexpected open lots %+v", open)
+	}
+}
+
+func TestFillAmounts(t *testing.T) {
+	f := Fill{Asset: "BTC", Amount: 0.5, Price: 8000, Fee: 4}
+	if q := f.Quantity(); q.String() != "0.5 BTC" {
+		t.Errorf("Test failed. Expected 0.5 BTC but got %s", q)
+	}
+	cost, err := f.Notional("USD").Add(f.FeeAmount("USD"))
+	if err != nil || cost.String() != "4004 USD" {
+		t.Errorf("Test failed. Expected 4004 USD but got %s %v", cost, err)
+	}
+	if _, err = f.Quantity().Add(f.FeeAmount("USD")); err == nil {
+		t.Error("Test failed. Expected adding the fee to the quantity to fail")
+	}
+}
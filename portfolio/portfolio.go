@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/storage"
 )
 
 const (
@@ -18,6 +19,9 @@ const (
 	PortfolioAddressExchange = "Exchange"
 	// PortfolioAddressPersonal is a label for a personal/offline address
 	PortfolioAddressPersonal = "Personal"
+
+	portfolioBucket = "portfolio"
+	portfolioKey    = "addresses"
 )
 
 // Portfolio is variable store holding an array of portfolioAddress
@@ -465,6 +469,26 @@ func (p *Base) SeedPortfolio(port Base) {
 	p.Addresses = port.Addresses
 }
 
+// Save persists the portfolio addresses to the store
+func (p *Base) Save(s storage.Store) error {
+	return s.Put(portfolioBucket, portfolioKey, p.Addresses)
+}
+
+// Load replaces the portfolio addresses with the ones previously saved to the store, returns
+// false if nothing has been saved yet
+func (p *Base) Load(s storage.Store) (bool, error) {
+	var addresses []Address
+	err := s.Get(portfolioBucket, portfolioKey, &addresses)
+	if err == storage.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	p.Addresses = addresses
+	return true, nil
+}
+
 // StartPortfolioWatcher observes the portfolio object
 func StartPortfolioWatcher() {
 	addrCount := len(Portfolio.Addresses)
@@ -3,6 +3,8 @@ package portfolio
 import (
 	"reflect"
 	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/storage"
 )
 
 func TestGetEthereumBalance(t *testing.T) {
@@ -384,3 +386,24 @@ func TestGetPortfolio(t *testing.T) {
 		t.Error("Test Failed - portfolio_test.go - GetoPortfolio error")
 	}
 }
+
+func TestSaveLoad(t *testing.T) {
+	store := storage.NewMemoryStore()
+	var p Base
+	if ok, err := p.Load(store); ok || err != nil {
+		t.Fatalf("Test failed. Load returned %v, %v for an empty store", ok, err)
+	}
+
+	p.AddAddress("someaddress", "LTC", PortfolioAddressPersonal, 0.02)
+	if err := p.Save(store); err != nil {
+		t.Fatalf("Test failed. Save error: %s", err)
+	}
+
+	var restored Base
+	if ok, err := restored.Load(store); !ok || err != nil {
+		t.Fatalf("Test failed. Load returned %v, %v", ok, err)
+	}
+	if !reflect.DeepEqual(p.Addresses, restored.Addresses) {
+		t.Errorf("Test Failed - expected %v got %v", p.Addresses, restored.Addresses)
+	}
+}
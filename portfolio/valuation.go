@@ -0,0 +1,237 @@
+package portfolio
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/storage"
+)
+
+const (
+	valuationsKey = "valuations"
+
+	// DefaultMaxValuations is the default number of valuation snapshots kept in the history
+	DefaultMaxValuations = 10000
+	// DefaultSharpeWindow is the default number of daily returns the Sharpe ratio is calculated
+	// over
+	DefaultSharpeWindow = 30
+)
+
+// Valuation is a snapshot of the total value of the portfolio
+type Valuation struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+	// Coins that couldn't be priced and were left out of the value
+	Unpriced []string `json:"unpriced,omitempty"`
+}
+
+// DailyReturn is the return of the portfolio over a (UTC) day
+type DailyReturn struct {
+	Date   string  `json:"date"` // YYYY-MM-DD
+	Return float64 `json:"return"`
+}
+
+// ValuationStats holds running statistics of the portfolio value, the snapshots with unpriced
+// coins are skipped since their value is understated.
+type ValuationStats struct {
+	Snapshots int `json:"snapshots"`
+	// Snapshots skipped because some of the coins couldn't be priced
+	Incomplete int     `json:"incomplete"`
+	Latest     float64 `json:"latest"`
+	Peak       float64 `json:"peak"`
+	// Drawdowns are fractions of the peak value, e.g. 0.25 for a 25% drop
+	MaxDrawdown     float64       `json:"maxDrawdown"`
+	CurrentDrawdown float64       `json:"currentDrawdown"`
+	DailyReturns    []DailyReturn `json:"dailyReturns"`
+	// Annualised Sharpe ratio (assuming a zero risk-free rate) of the last SharpeWindow daily
+	// returns, zero if there are less than two returns
+	Sharpe       float64 `json:"sharpe"`
+	SharpeWindow int     `json:"sharpeWindow"`
+}
+
+// Value calculates the total value of the portfolio, price returns the price of a single coin
+// in the valuation currency. Coins that can't be priced are returned separately.
+func (p *Base) Value(price func(coin string) (float64, bool)) (float64, []string) {
+	var total float64
+	var unpriced []string
+	for _, coin := range p.GetPortfolioSummary().Totals {
+		if coin.Balance == 0 {
+			continue
+		}
+		coinPrice, ok := price(coin.Coin)
+		if !ok {
+			unpriced = append(unpriced, coin.Coin)
+			continue
+		}
+		total += coin.Balance * coinPrice
+	}
+	return total, unpriced
+}
+
+// ValuationHistory keeps a bounded history of portfolio valuation snapshots
+type ValuationHistory struct {
+	m          sync.Mutex
+	valuations []Valuation
+	max        int
+}
+
+// NewValuationHistory creates a history that keeps up to max snapshots, the oldest snapshots are
+// dropped once the history is full.
+func NewValuationHistory(max int) *ValuationHistory {
+	if max <= 0 {
+		max = DefaultMaxValuations
+	}
+	return &ValuationHistory{max: max}
+}
+
+// Record adds a valuation snapshot to the history
+func (h *ValuationHistory) Record(v Valuation) {
+	h.m.Lock()
+	defer h.m.Unlock()
+	h.valuations = append(h.valuations, v)
+	if len(h.valuations) > h.max {
+		h.valuations = append(h.valuations[:0], h.valuations[len(h.valuations)-h.max:]...)
+	}
+}
+
+// Valuations returns the snapshots taken at or after since
+func (h *ValuationHistory) Valuations(since time.Time) []Valuation {
+	h.m.Lock()
+	defer h.m.Unlock()
+	var result []Valuation
+	for i := range h.valuations {
+		if !h.valuations[i].Time.Before(since) {
+			result = append(result, h.valuations[i])
+		}
+	}
+	return result
+}
+
+// Stats calculates the drawdown & return statistics of the history, the Sharpe ratio is
+// calculated over the last sharpeWindow daily returns (DefaultSharpeWindow if zero).
+func (h *ValuationHistory) Stats(sharpeWindow int) ValuationStats {
+	if sharpeWindow <= 0 {
+		sharpeWindow = DefaultSharpeWindow
+	}
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	stats := ValuationStats{
+		SharpeWindow: sharpeWindow,
+	}
+
+	// the last snapshot of each day is used as the close of the day
+	var dates []string
+	var closes []float64
+	for i := range h.valuations {
+		v := h.valuations[i]
+		if len(v.Unpriced) > 0 {
+			stats.Incomplete++
+			continue
+		}
+		stats.Snapshots++
+		stats.Latest = v.Value
+		if v.Value > stats.Peak {
+			stats.Peak = v.Value
+		}
+		if stats.Peak > 0 {
+			stats.CurrentDrawdown = (stats.Peak - v.Value) / stats.Peak
+			if stats.CurrentDrawdown > stats.MaxDrawdown {
+				stats.MaxDrawdown = stats.CurrentDrawdown
+			}
+		}
+
+		date := v.Time.UTC().Format("2006-01-02")
+		if len(dates) > 0 && dates[len(dates)-1] == date {
+			closes[len(closes)-1] = v.Value
+			continue
+		}
+		dates = append(dates, date)
+		closes = append(closes, v.Value)
+	}
+	for i := 1; i < len(closes); i++ {
+		if closes[i-1] > 0 {
+			stats.DailyReturns = append(stats.DailyReturns,
+				DailyReturn{Date: dates[i], Return: closes[i]/closes[i-1] - 1})
+		}
+	}
+
+	returns := stats.DailyReturns
+	if len(returns) > sharpeWindow {
+		returns = returns[len(returns)-sharpeWindow:]
+	}
+	stats.Sharpe = sharpeRatio(returns)
+	return stats
+}
+
+func sharpeRatio(returns []DailyReturn) float64 {
+	if len(returns) < 2 {
+		return 0
+	}
+	var mean float64
+	for i := range returns {
+		mean += returns[i].Return
+	}
+	mean /= float64(len(returns))
+	var variance float64
+	for i := range returns {
+		d := returns[i].Return - mean
+		variance += d * d
+	}
+	stdDev := math.Sqrt(variance / float64(len(returns)-1))
+	if stdDev == 0 {
+		return 0
+	}
+	return mean / stdDev * math.Sqrt(365)
+}
+
+// Save persists the valuation history to the store
+func (h *ValuationHistory) Save(s storage.Store) error {
+	h.m.Lock()
+	defer h.m.Unlock()
+	return s.Put(portfolioBucket, valuationsKey, h.valuations)
+}
+
+// Load replaces the valuation history with the one previously saved to the store, returns false
+// if nothing has been saved yet
+func (h *ValuationHistory) Load(s storage.Store) (bool, error) {
+	var valuations []Valuation
+	err := s.Get(portfolioBucket, valuationsKey, &valuations)
+	if err == storage.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	h.m.Lock()
+	defer h.m.Unlock()
+	if len(valuations) > h.max {
+		valuations = valuations[len(valuations)-h.max:]
+	}
+	h.valuations = valuations
+	return true, nil
+}
+
+// WriteValuationsCSV writes the valuation snapshots to w in CSV format (with a header row).
+func WriteValuationsCSV(w io.Writer, valuations []Valuation) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"time", "value"}); err != nil {
+		return err
+	}
+	for i := range valuations {
+		err := writer.Write([]string{
+			valuations[i].Time.UTC().Format(time.RFC3339),
+			strconv.FormatFloat(valuations[i].Value, 'f', -1, 64),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write portfolio valuations: %s", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
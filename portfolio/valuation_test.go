@@ -0,0 +1,139 @@
+package portfolio
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/storage"
+)
+
+func TestValue(t *testing.T) {
+	p := Base{}
+	p.AddAddress("Bitfinex", "BTC", PortfolioAddressExchange, 2)
+	p.AddAddress("Bitfinex", "LTC", PortfolioAddressExchange, 10)
+	p.AddAddress("Bitfinex", "XYZ", PortfolioAddressExchange, 5)
+
+	prices := map[string]float64{"BTC": 6000, "LTC": 50}
+	value, unpriced := p.Value(func(coin string) (float64, bool) {
+		price, ok := prices[coin]
+		return price, ok
+	})
+	if value != 12500 {
+		t.Errorf("Test Failed - Portfolio Value() expected 12500, got %f", value)
+	}
+	if !reflect.DeepEqual(unpriced, []string{"XYZ"}) {
+		t.Errorf("Test Failed - Portfolio Value() unexpected unpriced coins %v", unpriced)
+	}
+}
+
+func TestValuationHistoryStats(t *testing.T) {
+	h := NewValuationHistory(0)
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	// intraday snapshots, only the last one of each day counts as the close
+	// snapshots with unpriced coins understate the value & are skipped
+	values := []struct {
+		offset   time.Duration
+		value    float64
+		unpriced []string
+	}{
+		{0, 100, nil},
+		{12 * time.Hour, 90, nil},
+		{20 * time.Hour, 100, nil},
+		{24 * time.Hour, 120, nil},
+		{30 * time.Hour, 20, []string{"BTC"}},
+		{48 * time.Hour, 60, nil},
+		{72 * time.Hour, 90, nil},
+		{80 * time.Hour, 30, []string{"BTC"}},
+	}
+	for _, v := range values {
+		h.Record(Valuation{Time: start.Add(v.offset), Value: v.value, Unpriced: v.unpriced})
+	}
+
+	stats := h.Stats(0)
+	if stats.Snapshots != 6 || stats.Incomplete != 2 || stats.Latest != 90 || stats.Peak != 120 {
+		t.Errorf("Test Failed - ValuationHistory Stats() unexpected stats %+v", stats)
+	}
+	if stats.MaxDrawdown != 0.5 || stats.CurrentDrawdown != 0.25 {
+		t.Errorf("Test Failed - ValuationHistory Stats() unexpected drawdowns %f/%f",
+			stats.MaxDrawdown, stats.CurrentDrawdown)
+	}
+	expected := []DailyReturn{
+		{Date: "2018-01-02", Return: 0.2},
+		{Date: "2018-01-03", Return: -0.5},
+		{Date: "2018-01-04", Return: 0.5},
+	}
+	if len(stats.DailyReturns) != len(expected) {
+		t.Fatalf("Test Failed - ValuationHistory Stats() unexpected returns %v", stats.DailyReturns)
+	}
+	for i := range expected {
+		if stats.DailyReturns[i].Date != expected[i].Date ||
+			math.Abs(stats.DailyReturns[i].Return-expected[i].Return) > 1e-9 {
+			t.Errorf("Test Failed - ValuationHistory Stats() expected return %v, got %v",
+				expected[i], stats.DailyReturns[i])
+		}
+	}
+	// mean 0.0667, sample std dev 0.5132, annualised by sqrt(365)
+	if math.Abs(stats.Sharpe-2.482) > 1e-3 {
+		t.Errorf("Test Failed - ValuationHistory Stats() unexpected Sharpe ratio %f", stats.Sharpe)
+	}
+	// a window of 1 return isn't enough for a Sharpe ratio
+	if stats = h.Stats(1); stats.Sharpe != 0 {
+		t.Errorf("Test Failed - ValuationHistory Stats() expected no Sharpe ratio, got %f",
+			stats.Sharpe)
+	}
+
+	if v := h.Valuations(start.Add(48 * time.Hour)); len(v) != 3 || v[0].Value != 60 {
+		t.Errorf("Test Failed - ValuationHistory Valuations() unexpected result %v", v)
+	}
+}
+
+func TestValuationHistoryBounded(t *testing.T) {
+	h := NewValuationHistory(3)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		h.Record(Valuation{Time: start.Add(time.Duration(i) * time.Minute), Value: float64(i)})
+	}
+	v := h.Valuations(time.Time{})
+	if len(v) != 3 || v[0].Value != 2 || v[2].Value != 4 {
+		t.Errorf("Test Failed - ValuationHistory Record() expected the oldest snapshots to be dropped, got %v", v)
+	}
+}
+
+func TestValuationHistorySaveLoad(t *testing.T) {
+	s := storage.NewMemoryStore()
+	h := NewValuationHistory(0)
+	if ok, err := h.Load(s); ok || err != nil {
+		t.Errorf("Test Failed - ValuationHistory Load() expected nothing to load, got %v %v", ok, err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	h.Record(Valuation{Time: now, Value: 100})
+	if err := h.Save(s); err != nil {
+		t.Fatalf("Test Failed - ValuationHistory Save() error: %s", err)
+	}
+
+	loaded := NewValuationHistory(0)
+	if ok, err := loaded.Load(s); !ok || err != nil {
+		t.Fatalf("Test Failed - ValuationHistory Load() error: %v %v", ok, err)
+	}
+	v := loaded.Valuations(time.Time{})
+	if len(v) != 1 || !v[0].Time.Equal(now) || v[0].Value != 100 {
+		t.Errorf("Test Failed - ValuationHistory Load() unexpected valuations %v", v)
+	}
+}
+
+func TestWriteValuationsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	valuations := []Valuation{
+		{Time: time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC), Value: 1234.5},
+	}
+	if err := WriteValuationsCSV(&buf, valuations); err != nil {
+		t.Fatalf("Test Failed - WriteValuationsCSV() error: %s", err)
+	}
+	expected := "time,value\n2018-01-01T12:00:00Z,1234.5\n"
+	if buf.String() != expected {
+		t.Errorf("Test Failed - WriteValuationsCSV() expected %q, got %q", expected, buf.String())
+	}
+}
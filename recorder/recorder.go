@@ -0,0 +1,265 @@
+// Package recorder records orderbook streams compactly for backtests. The first update of each
+// orderbook is recorded as a full snapshot, subsequent updates only record the price levels that
+// changed (deltas), with a full snapshot (keyframe) recorded periodically so that a replay can
+// start part way through a recording. The Reader reconstructs the full orderbooks.
+package recorder
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+)
+
+// Const values for the recorder package
+const (
+	// DefaultKeyframeInterval is the default number of deltas recorded between keyframes
+	DefaultKeyframeInterval = 600
+	// DefaultKeyframePeriod is the default max time between keyframes
+	DefaultKeyframePeriod = 5 * time.Minute
+)
+
+// Record types
+const (
+	TypeSnapshot = "snapshot"
+	TypeDelta    = "delta"
+)
+
+// Record is a single line of a recording. The price levels of a snapshot are the full orderbook,
+// the price levels of a delta replace the levels at the same price, a zero amount removes the
+// level.
+type Record struct {
+	Type      string           `json:"type"`
+	Exchange  string           `json:"exchange"`
+	Pair      string           `json:"pair"`
+	AssetType string           `json:"assetType"`
+	Time      time.Time        `json:"time"`
+	Bids      []orderbook.Item `json:"bids,omitempty"`
+	Asks      []orderbook.Item `json:"asks,omitempty"`
+}
+
+func (r *Record) key() string {
+	return r.Exchange + " " + r.Pair + " " + r.AssetType
+}
+
+// Diff returns the price levels that changed between two sides of an orderbook, levels that
+// were removed are returned with a zero amount. Bids are sorted by descending price, asks by
+// ascending price.
+func Diff(prev, next []orderbook.Item, bids bool) []orderbook.Item {
+	amounts := make(map[float64]float64, len(prev))
+	for _, item := range prev {
+		amounts[item.Price] = item.Amount
+	}
+	var changed []orderbook.Item
+	for _, item := range next {
+		amount, ok := amounts[item.Price]
+		if !ok || amount != item.Amount {
+			changed = append(changed, item)
+		}
+		delete(amounts, item.Price)
+	}
+	for price := range amounts {
+		changed = append(changed, orderbook.Item{Price: price})
+	}
+	sortLevels(changed, bids)
+	return changed
+}
+
+// Apply applies a delta to one side of an orderbook, returning the updated side
+func Apply(levels, delta []orderbook.Item, bids bool) []orderbook.Item {
+	amounts := make(map[float64]float64, len(levels)+len(delta))
+	for _, item := range levels {
+		amounts[item.Price] = item.Amount
+	}
+	for _, item := range delta {
+		if item.Amount == 0 {
+			delete(amounts, item.Price)
+		} else {
+			amounts[item.Price] = item.Amount
+		}
+	}
+	result := make([]orderbook.Item, 0, len(amounts))
+	for price, amount := range amounts {
+		result = append(result, orderbook.Item{Price: price, Amount: amount})
+	}
+	sortLevels(result, bids)
+	return result
+}
+
+func sortLevels(levels []orderbook.Item, bids bool) {
+	if bids {
+		sort.Slice(levels, func(i, j int) bool { return levels[i].Price > levels[j].Price })
+	} else {
+		sort.Slice(levels, func(i, j int) bool { return levels[i].Price < levels[j].Price })
+	}
+}
+
+type recordedBook struct {
+	bids, asks   []orderbook.Item
+	deltas       int
+	lastKeyframe time.Time
+}
+
+// Writer records orderbook updates as JSON encoded records (one per line)
+type Writer struct {
+	m                sync.Mutex
+	w                *bufio.Writer
+	closer           io.Closer
+	keyframeInterval int
+	keyframePeriod   time.Duration
+	books            map[string]*recordedBook
+}
+
+// NewWriter returns a writer that records a keyframe after keyframeInterval deltas, or once
+// keyframePeriod has passed since the last keyframe. Zero values use the defaults.
+func NewWriter(w io.Writer, keyframeInterval int, keyframePeriod time.Duration) *Writer {
+	if keyframeInterval <= 0 {
+		keyframeInterval = DefaultKeyframeInterval
+	}
+	if keyframePeriod <= 0 {
+		keyframePeriod = DefaultKeyframePeriod
+	}
+	writer := &Writer{
+		w:                bufio.NewWriter(w),
+		keyframeInterval: keyframeInterval,
+		keyframePeriod:   keyframePeriod,
+		books:            make(map[string]*recordedBook),
+	}
+	if closer, ok := w.(io.Closer); ok {
+		writer.closer = closer
+	}
+	return writer
+}
+
+// Create creates (or appends to) a recording file, see NewWriter
+func Create(path string, keyframeInterval int, keyframePeriod time.Duration) (*Writer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter(f, keyframeInterval, keyframePeriod), nil
+}
+
+// Record records an orderbook update, unchanged orderbooks aren't recorded. The orderbook isn't
+// retained so it can be released once Record returns.
+func (w *Writer) Record(exchangeName, pair, assetType string, book *orderbook.Base, t time.Time) error {
+	rec := Record{Exchange: exchangeName, Pair: pair, AssetType: assetType, Time: t}
+
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	prev, ok := w.books[rec.key()]
+	if !ok || prev.deltas >= w.keyframeInterval || t.Sub(prev.lastKeyframe) >= w.keyframePeriod {
+		rec.Type = TypeSnapshot
+		rec.Bids = book.Bids
+		rec.Asks = book.Asks
+		prev = &recordedBook{lastKeyframe: t}
+		w.books[rec.key()] = prev
+	} else {
+		rec.Type = TypeDelta
+		rec.Bids = Diff(prev.bids, book.Bids, true)
+		rec.Asks = Diff(prev.asks, book.Asks, false)
+		if len(rec.Bids) == 0 && len(rec.Asks) == 0 {
+			return nil
+		}
+		prev.deltas++
+	}
+	prev.bids = append(prev.bids[:0], book.Bids...)
+	prev.asks = append(prev.asks[:0], book.Asks...)
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err = w.w.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Flush writes any buffered records to the underlying writer
+func (w *Writer) Flush() error {
+	w.m.Lock()
+	defer w.m.Unlock()
+	return w.w.Flush()
+}
+
+// Close flushes the buffered records, and closes the underlying writer if it's an io.Closer
+func (w *Writer) Close() error {
+	w.m.Lock()
+	defer w.m.Unlock()
+	err := w.w.Flush()
+	if w.closer != nil {
+		if closeErr := w.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// Update is an orderbook reconstructed by the Reader
+type Update struct {
+	Exchange  string
+	Pair      string
+	AssetType string
+	Time      time.Time
+	Book      orderbook.Base
+}
+
+// Reader replays a recording, reconstructing the full orderbooks
+type Reader struct {
+	scanner *bufio.Scanner
+	books   map[string]*orderbook.Base
+}
+
+// NewReader returns a reader for a recording
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	return &Reader{scanner: scanner, books: make(map[string]*orderbook.Base)}
+}
+
+// Next returns the next orderbook update, or io.EOF at the end of the recording. Deltas recorded
+// before the first keyframe of an orderbook are skipped. The bids & asks of the returned orderbook
+// are shared with the reader and must not be modified.
+func (r *Reader) Next() (Update, error) {
+	for r.scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(r.scanner.Bytes(), &rec); err != nil {
+			return Update{}, err
+		}
+		book, ok := r.books[rec.key()]
+		switch rec.Type {
+		case TypeSnapshot:
+			book = &orderbook.Base{Bids: rec.Bids, Asks: rec.Asks}
+			r.books[rec.key()] = book
+		case TypeDelta:
+			if !ok {
+				continue
+			}
+			book.Bids = Apply(book.Bids, rec.Bids, true)
+			book.Asks = Apply(book.Asks, rec.Asks, false)
+		default:
+			return Update{}, errors.New("unknown record type " + rec.Type)
+		}
+		book.CurrencyPair = rec.Pair
+		book.LastUpdated = rec.Time
+		return Update{
+			Exchange:  rec.Exchange,
+			Pair:      rec.Pair,
+			AssetType: rec.AssetType,
+			Time:      rec.Time,
+			Book:      *book,
+		}, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return Update{}, err
+	}
+	return Update{}, io.EOF
+}
@@ -0,0 +1,77 @@
+package recorder
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+)
+
+func TestDiffApply(t *testing.T) {
+	prev := []orderbook.Item{{Price: 100, Amount: 1}, {Price: 99, Amount: 2}, {Price: 98, Amount: 3}}
+	next := []orderbook.Item{{Price: 101, Amount: 1}, {Price: 100, Amount: 1}, {Price: 98, Amount: 4}}
+	delta := Diff(prev, next, true)
+	expected := []orderbook.Item{{Price: 101, Amount: 1}, {Price: 99}, {Price: 98, Amount: 4}}
+	if !reflect.DeepEqual(delta, expected) {
+		t.Fatalf("Test failed. Expected delta %v but got %v", expected, delta)
+	}
+	if result := Apply(prev, delta, true); !reflect.DeepEqual(result, next) {
+		t.Errorf("Test failed. Expected %v but got %v", next, result)
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	start := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	books := []orderbook.Base{
+		{Bids: []orderbook.Item{{Price: 100, Amount: 1}}, Asks: []orderbook.Item{{Price: 101, Amount: 1}}},
+		{Bids: []orderbook.Item{{Price: 100, Amount: 2}}, Asks: []orderbook.Item{{Price: 101, Amount: 1}}},
+		// unchanged, not recorded
+		{Bids: []orderbook.Item{{Price: 100, Amount: 2}}, Asks: []orderbook.Item{{Price: 101, Amount: 1}}},
+		{Bids: []orderbook.Item{{Price: 100, Amount: 2}}, Asks: []orderbook.Item{{Price: 102, Amount: 3}}},
+		{Bids: []orderbook.Item{{Price: 99, Amount: 5}}, Asks: []orderbook.Item{{Price: 102, Amount: 3}}},
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 2, time.Hour)
+	for i := range books {
+		if err := w.Record("Bitfinex", "BTCUSD", orderbook.Spot, &books[i], start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatalf("Test failed. Record returned an error: %s", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Test failed. Flush returned an error: %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Test failed. Expected 4 records but got %d", len(lines))
+	}
+	// the 2nd delta is followed by a keyframe
+	if !strings.Contains(lines[0], TypeSnapshot) || !strings.Contains(lines[3], TypeSnapshot) {
+		t.Errorf("Test failed. Expected keyframes at records 1 & 4, got %v", lines)
+	}
+
+	r := NewReader(bytes.NewReader(buf.Bytes()))
+	for _, i := range []int{0, 1, 3, 4} {
+		u, err := r.Next()
+		if err != nil {
+			t.Fatalf("Test failed. Next returned an error: %s", err)
+		}
+		if !reflect.DeepEqual(u.Book.Bids, books[i].Bids) || !reflect.DeepEqual(u.Book.Asks, books[i].Asks) {
+			t.Errorf("Test failed. Expected book %d %+v but got %+v", i, books[i], u.Book)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Test failed. Expected io.EOF but got %v", err)
+	}
+
+	// replays starting part way through a recording skip deltas until the next keyframe
+	r = NewReader(strings.NewReader(strings.Join(lines[1:], "\n")))
+	u, err := r.Next()
+	if err != nil || u.Time != start.Add(4*time.Second) {
+		t.Errorf("Test failed. Expected the replay to start at the keyframe, got %+v %v", u, err)
+	}
+}
@@ -0,0 +1,310 @@
+// Package replay reconstructs what the bot saw during a window of time, e.g. a production
+// incident, by replaying the recorded orderbooks alongside the audit log. Every audited API call
+// is paired with the orderbook of its currency pair at the time of the call, and the orders are
+// run through the execution simulator, so bad fills & runaway cancels can be traced back to the
+// market data the bot acted on.
+package replay
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/audit"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/recorder"
+	"github.com/mattkanwisher/cryptofiend/simulation"
+)
+
+// Audited methods replayed
+const (
+	methodNewOrder    = "NewOrder"
+	methodCancelOrder = "CancelOrder"
+)
+
+// Options configures a replay
+type Options struct {
+	Start time.Time
+	End   time.Time
+	// Only the activity of this exchange is replayed, all the exchanges if empty
+	Exchange string
+	// Simulates the fills of the marketable orders, optional
+	Simulator *simulation.Simulator
+}
+
+// Action is an audited API call along with the market at the time it was made
+type Action struct {
+	Entry audit.Entry `json:"entry"`
+	// Currency pair of the call (upper case, delimited by "/")
+	Pair string `json:"pair"`
+	// Latest orderbook recorded before the call, nil if there's none
+	Book *orderbook.Base `json:"book,omitempty"`
+	// Time between the orderbook being recorded and the call
+	BookAge time.Duration `json:"bookAge"`
+	BestBid float64       `json:"bestBid"`
+	BestAsk float64       `json:"bestAsk"`
+	// Fields of the orders
+	Side   exchange.OrderSide `json:"side,omitempty"`
+	Price  float64            `json:"price,omitempty"`
+	Amount float64            `json:"amount,omitempty"`
+	// Set if the order crossed the spread, so would have been filled (at least partly) as a taker
+	Marketable bool `json:"marketable,omitempty"`
+	// Deviation of the order price from the mid price of the orderbook, as a fraction of the mid
+	// price. Positive deviations are prices worse than the mid price for the side of the order.
+	Deviation float64 `json:"deviation,omitempty"`
+	// Simulated execution of a marketable order against the orderbook
+	SimulatedFill *simulation.Fill `json:"simulatedFill,omitempty"`
+}
+
+// Summary holds the aggregate activity of a replay
+type Summary struct {
+	Orders  int `json:"orders"`
+	Cancels int `json:"cancels"`
+	// Calls rejected by the exchange
+	Errors int `json:"errors"`
+	// Orders & cancels made in the busiest minute
+	MaxOrdersPerMinute  int `json:"maxOrdersPerMinute"`
+	MaxCancelsPerMinute int `json:"maxCancelsPerMinute"`
+	// Largest deviation of an order price from the mid price
+	MaxDeviation float64 `json:"maxDeviation"`
+	// Oldest orderbook an order was placed against
+	MaxBookAge time.Duration `json:"maxBookAge"`
+	// Orders placed without a recorded orderbook for their pair
+	OrdersWithoutBook int `json:"ordersWithoutBook"`
+}
+
+// Report is the result of a replay
+type Report struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Actions []Action  `json:"actions"`
+	// Orderbook updates recorded within the window
+	BookUpdates int     `json:"bookUpdates"`
+	Summary     Summary `json:"summary"`
+}
+
+// normalizePair converts the pairs recorded by the recorder (e.g. BTCUSD or btc-usd) & the audit
+// log (e.g. BTC/USD) to the same format. Pairs recorded without a delimiter can't be split, so the
+// delimiters are removed instead.
+func normalizePair(p string) string {
+	return strings.ToUpper(strings.NewReplacer("/", "", "-", "", "_", "").Replace(p))
+}
+
+func bookKey(exchangeName, p string) string {
+	return exchangeName + " " + normalizePair(p)
+}
+
+type recordedBook struct {
+	book orderbook.Base
+	time time.Time
+}
+
+// Run replays the audit log entries made within the window against the orderbooks of the
+// recording. The recording must start before the window so the orderbooks can be reconstructed
+// from their keyframes, the updates recorded before the window are only used to build the
+// orderbooks.
+func Run(books *recorder.Reader, entries []audit.Entry, opts Options) (*Report, error) {
+	report := &Report{Start: opts.Start, End: opts.End, Actions: []Action{}}
+
+	var calls []audit.Entry
+	for _, e := range entries {
+		if e.Timestamp.Before(opts.Start) || !e.Timestamp.Before(opts.End) ||
+			(opts.Exchange != "" && e.Exchange != opts.Exchange) ||
+			(e.Method != methodNewOrder && e.Method != methodCancelOrder) {
+			continue
+		}
+		calls = append(calls, e)
+	}
+	sort.SliceStable(calls, func(i, j int) bool { return calls[i].Timestamp.Before(calls[j].Timestamp) })
+
+	latest := make(map[string]*recordedBook)
+	var pending *recorder.Update
+	// advance applies the orderbook updates recorded before t
+	advance := func(t time.Time) error {
+		for {
+			if pending == nil {
+				update, err := books.Next()
+				if err == io.EOF {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				pending = &update
+			}
+			if !pending.Time.Before(t) {
+				return nil
+			}
+			if opts.Exchange == "" || pending.Exchange == opts.Exchange {
+				// The bids & asks are shared with the reader, so they're copied
+				book := pending.Book
+				book.Bids = append([]orderbook.Item(nil), book.Bids...)
+				book.Asks = append([]orderbook.Item(nil), book.Asks...)
+				latest[bookKey(pending.Exchange, pending.Pair)] = &recordedBook{book: book, time: pending.Time}
+				if !pending.Time.Before(opts.Start) {
+					report.BookUpdates++
+				}
+			}
+			pending = nil
+		}
+	}
+
+	for _, e := range calls {
+		if err := advance(e.Timestamp); err != nil {
+			return nil, err
+		}
+		action := Action{Entry: e}
+		if p, ok := e.Params["pair"].(string); ok {
+			action.Pair = p
+		}
+		if recorded, ok := latest[bookKey(e.Exchange, action.Pair)]; ok {
+			book := recorded.book
+			action.Book = &book
+			action.BookAge = e.Timestamp.Sub(recorded.time)
+			if len(book.Bids) > 0 {
+				action.BestBid = book.Bids[0].Price
+			}
+			if len(book.Asks) > 0 {
+				action.BestAsk = book.Asks[0].Price
+			}
+		}
+		if e.Method == methodNewOrder {
+			replayOrder(&action, opts.Simulator)
+		}
+		report.Actions = append(report.Actions, action)
+	}
+	if err := advance(opts.End); err != nil {
+		return nil, err
+	}
+	report.Summary = summarize(report.Actions)
+	return report, nil
+}
+
+// replayOrder compares an order with the orderbook at the time it was placed
+func replayOrder(action *Action, simulator *simulation.Simulator) {
+	params := action.Entry.Params
+	if side, ok := params["side"].(string); ok {
+		action.Side = exchange.OrderSide(side)
+	}
+	action.Price, _ = params["price"].(float64)
+	action.Amount, _ = params["amount"].(float64)
+	if action.Book == nil || action.BestBid == 0 || action.BestAsk == 0 {
+		return
+	}
+
+	mid := (action.BestBid + action.BestAsk) / 2
+	// Marketable orders are simulated as taker orders from the touch price
+	var touch float64
+	switch action.Side {
+	case exchange.OrderSideBuy:
+		touch = action.BestAsk
+		// Market orders (without a price) always cross the spread
+		action.Marketable = action.Price == 0 || action.Price >= action.BestAsk
+		if action.Price != 0 {
+			action.Deviation = (action.Price - mid) / mid
+		}
+	case exchange.OrderSideSell:
+		touch = action.BestBid
+		action.Marketable = action.Price == 0 || action.Price <= action.BestBid
+		if action.Price != 0 {
+			action.Deviation = (mid - action.Price) / mid
+		}
+	default:
+		return
+	}
+	if action.Marketable && simulator != nil {
+		fill := simulator.Simulate(action.Entry.Exchange, action.Side, touch, action.Amount, false, action.Book)
+		action.SimulatedFill = &fill
+	}
+}
+
+func summarize(actions []Action) Summary {
+	s := Summary{}
+	ordersPerMinute := make(map[int64]int)
+	cancelsPerMinute := make(map[int64]int)
+	for i := range actions {
+		a := &actions[i]
+		if a.Entry.Error != "" {
+			s.Errors++
+		}
+		minute := a.Entry.Timestamp.Unix() / 60
+		switch a.Entry.Method {
+		case methodNewOrder:
+			s.Orders++
+			ordersPerMinute[minute]++
+			if ordersPerMinute[minute] > s.MaxOrdersPerMinute {
+				s.MaxOrdersPerMinute = ordersPerMinute[minute]
+			}
+			if a.Book == nil {
+				s.OrdersWithoutBook++
+				continue
+			}
+			s.MaxDeviation = math.Max(s.MaxDeviation, a.Deviation)
+			if a.BookAge > s.MaxBookAge {
+				s.MaxBookAge = a.BookAge
+			}
+		case methodCancelOrder:
+			s.Cancels++
+			cancelsPerMinute[minute]++
+			if cancelsPerMinute[minute] > s.MaxCancelsPerMinute {
+				s.MaxCancelsPerMinute = cancelsPerMinute[minute]
+			}
+		}
+	}
+	return s
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// WriteText writes the report to w as plain text, a summary followed by a table of the audited
+// calls. Marketable orders are flagged with "*", and the simulated fills are listed after them.
+func WriteText(w io.Writer, report *Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	s := report.Summary
+	fmt.Fprintf(tw, "Replay: %s to %s\n", report.Start.UTC().Format(time.RFC3339),
+		report.End.UTC().Format(time.RFC3339))
+	fmt.Fprintf(tw, "Orderbook updates: %d\n", report.BookUpdates)
+	fmt.Fprintf(tw, "Orders: %d (max %d/min, %d without orderbook)\n", s.Orders, s.MaxOrdersPerMinute,
+		s.OrdersWithoutBook)
+	fmt.Fprintf(tw, "Cancels: %d (max %d/min)\n", s.Cancels, s.MaxCancelsPerMinute)
+	fmt.Fprintf(tw, "Errors: %d\n", s.Errors)
+	fmt.Fprintf(tw, "Max deviation from mid: %.4f%%\n", s.MaxDeviation*100)
+	fmt.Fprintf(tw, "Max orderbook age: %s\n", s.MaxBookAge)
+
+	if len(report.Actions) == 0 {
+		return tw.Flush()
+	}
+	fmt.Fprintln(tw, "\nTime\tExchange\tMethod\tPair\tSide\tAmount\tPrice\tBid\tAsk\tBook age\tDeviation\tResult\t")
+	for _, a := range report.Actions {
+		result := a.Entry.OrderID
+		if a.Entry.Error != "" {
+			result = "error: " + a.Entry.Error
+		}
+		side := string(a.Side)
+		if a.Marketable {
+			side += "*"
+		}
+		bookAge := "-"
+		if a.Book != nil {
+			bookAge = a.BookAge.String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%.4f%%\t%s\t\n",
+			a.Entry.Timestamp.UTC().Format("2006-01-02T15:04:05.000Z"), a.Entry.Exchange, a.Entry.Method,
+			a.Pair, side, formatFloat(a.Amount), formatFloat(a.Price), formatFloat(a.BestBid),
+			formatFloat(a.BestAsk), bookAge, a.Deviation*100, result)
+		if a.SimulatedFill != nil {
+			fmt.Fprintf(tw, "\t\tsimulated fill\t\t\t%s\t%s\t\t\t%s\t\tfee %s\t\n",
+				formatFloat(a.SimulatedFill.Amount), formatFloat(a.SimulatedFill.Price),
+				a.SimulatedFill.Delay, formatFloat(a.SimulatedFill.Fee))
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,192 @@
+package replay
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/audit"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/recorder"
+	"github.com/mattkanwisher/cryptofiend/simulation"
+)
+
+var start = time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newRecording(t *testing.T) *recorder.Reader {
+	books := []struct {
+		offset time.Duration
+		book   orderbook.Base
+	}{
+		// recorded before the window, builds the initial orderbook
+		{-time.Minute, orderbook.Base{
+			Bids: []orderbook.Item{{Price: 100, Amount: 1}},
+			Asks: []orderbook.Item{{Price: 102, Amount: 1}},
+		}},
+		{10 * time.Second, orderbook.Base{
+			Bids: []orderbook.Item{{Price: 100, Amount: 1}},
+			Asks: []orderbook.Item{{Price: 101, Amount: 0.5}, {Price: 103, Amount: 2}},
+		}},
+		{30 * time.Second, orderbook.Base{
+			Bids: []orderbook.Item{{Price: 90, Amount: 1}},
+			Asks: []orderbook.Item{{Price: 110, Amount: 1}},
+		}},
+	}
+	var buf bytes.Buffer
+	w := recorder.NewWriter(&buf, 10, time.Hour)
+	for i := range books {
+		if err := w.Record("Bitfinex", "BTCUSD", orderbook.Spot, &books[i].book, start.Add(books[i].offset)); err != nil {
+			t.Fatalf("Test failed. Record returned an error: %s", err)
+		}
+	}
+	// another exchange, filtered out
+	other := orderbook.Base{
+		Bids: []orderbook.Item{{Price: 1, Amount: 1}},
+		Asks: []orderbook.Item{{Price: 2, Amount: 1}},
+	}
+	if err := w.Record("GDAX", "BTCUSD", orderbook.Spot, &other, start.Add(5*time.Second)); err != nil {
+		t.Fatalf("Test failed. Record returned an error: %s", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Test failed. Flush returned an error: %s", err)
+	}
+	return recorder.NewReader(bytes.NewReader(buf.Bytes()))
+}
+
+func newOrder(offset time.Duration, side exchange.OrderSide, amount, price float64, errMsg string) audit.Entry {
+	return audit.Entry{
+		Timestamp: start.Add(offset),
+		Exchange:  "Bitfinex",
+		Method:    "NewOrder",
+		Params:    map[string]interface{}{"pair": "BTC/USD", "side": string(side), "amount": amount, "price": price},
+		Error:     errMsg,
+		OrderID:   "1",
+	}
+}
+
+func TestRun(t *testing.T) {
+	entries := []audit.Entry{
+		// before the window
+		newOrder(-30*time.Second, exchange.OrderSideBuy, 1, 100, ""),
+		// out of order in the log
+		newOrder(40*time.Second, exchange.OrderSideSell, 1, 80, ""),
+		newOrder(5*time.Second, exchange.OrderSideBuy, 1, 99, ""),
+		newOrder(20*time.Second, exchange.OrderSideBuy, 1, 102, ""),
+		{Timestamp: start.Add(25 * time.Second), Exchange: "Bitfinex", Method: "CancelOrder",
+			Params: map[string]interface{}{"pair": "BTC/USD", "order_id": "1"}, Error: "order not found"},
+		{Timestamp: start.Add(26 * time.Second), Exchange: "Bitfinex", Method: "GetOrders"},
+		{Timestamp: start.Add(27 * time.Second), Exchange: "GDAX", Method: "CancelOrder"},
+		// after the window
+		newOrder(2*time.Minute, exchange.OrderSideBuy, 1, 100, ""),
+	}
+	takerFee := 0.002
+	simulator, err := simulation.NewSimulator(config.SimulationConfig{
+		Exchanges: []config.SimulationExchangeConfig{{
+			Name:     "Bitfinex",
+			TakerFee: &takerFee,
+			Slippage: config.SlippageConfig{Model: simulation.SlippageOrderbook},
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Test failed. NewSimulator returned an error: %s", err)
+	}
+	report, err := Run(newRecording(t), entries, Options{
+		Start:     start,
+		End:       start.Add(time.Minute),
+		Exchange:  "Bitfinex",
+		Simulator: simulator,
+	})
+	if err != nil {
+		t.Fatalf("Test failed. Run returned an error: %s", err)
+	}
+
+	if report.BookUpdates != 2 {
+		t.Errorf("Test failed. Expected 2 orderbook updates in the window but got %d", report.BookUpdates)
+	}
+	if len(report.Actions) != 4 {
+		t.Fatalf("Test failed. Expected 4 actions but got %d", len(report.Actions))
+	}
+	for i, offset := range []time.Duration{5 * time.Second, 20 * time.Second, 25 * time.Second, 40 * time.Second} {
+		if !report.Actions[i].Entry.Timestamp.Equal(start.Add(offset)) {
+			t.Errorf("Test failed. Expected action %d at %s but got %s", i, start.Add(offset),
+				report.Actions[i].Entry.Timestamp)
+		}
+	}
+
+	passive := report.Actions[0]
+	if passive.BestBid != 100 || passive.BestAsk != 102 || passive.BookAge != 65*time.Second {
+		t.Errorf("Test failed. Expected the orderbook recorded before the window, got bid %v ask %v age %s",
+			passive.BestBid, passive.BestAsk, passive.BookAge)
+	}
+	if passive.Marketable || passive.SimulatedFill != nil {
+		t.Error("Test failed. Expected a passive order")
+	}
+	if math.Abs(passive.Deviation-(-2.0/101)) > 1e-9 {
+		t.Errorf("Test failed. Expected deviation %v but got %v", -2.0/101, passive.Deviation)
+	}
+
+	taker := report.Actions[1]
+	if taker.BestAsk != 101 || !taker.Marketable || taker.SimulatedFill == nil {
+		t.Fatalf("Test failed. Expected a marketable order against the updated orderbook, got %+v", taker)
+	}
+	// half the order is filled at 101 and the rest at 103
+	if fill := taker.SimulatedFill; fill.Amount != 1 || fill.Price != 102 || math.Abs(fill.Fee-0.204) > 1e-9 {
+		t.Errorf("Test failed. Expected a simulated fill of 1 @ 102 but got %+v", *fill)
+	}
+
+	if cancel := report.Actions[2]; cancel.Entry.Method != "CancelOrder" || cancel.Book == nil {
+		t.Errorf("Test failed. Expected the cancel to be paired with the orderbook, got %+v", cancel)
+	}
+
+	dumped := report.Actions[3]
+	if dumped.BestBid != 90 || !dumped.Marketable || math.Abs(dumped.Deviation-0.2) > 1e-9 {
+		t.Errorf("Test failed. Expected a sell 20%% below the mid price, got %+v", dumped)
+	}
+
+	expected := Summary{
+		Orders:              3,
+		Cancels:             1,
+		Errors:              1,
+		MaxOrdersPerMinute:  3,
+		MaxCancelsPerMinute: 1,
+		MaxDeviation:        dumped.Deviation,
+		MaxBookAge:          65 * time.Second,
+	}
+	if report.Summary != expected {
+		t.Errorf("Test failed. Expected summary %+v but got %+v", expected, report.Summary)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteText(&buf, report); err != nil {
+		t.Fatalf("Test failed. WriteText returned an error: %s", err)
+	}
+	for _, s := range []string{"Orders: 3 (max 3/min, 0 without orderbook)", "buy*", "simulated fill",
+		"error: order not found"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("Test failed. Expected the text report to contain %q, got %s", s, buf.String())
+		}
+	}
+}
+
+func TestRunWithoutBook(t *testing.T) {
+	entries := []audit.Entry{newOrder(time.Second, exchange.OrderSideBuy, 1, 100, "")}
+	entries[0].Params["pair"] = "ETH/USD"
+	report, err := Run(newRecording(t), entries, Options{Start: start, End: start.Add(time.Minute)})
+	if err != nil {
+		t.Fatalf("Test failed. Run returned an error: %s", err)
+	}
+	if len(report.Actions) != 1 || report.Actions[0].Book != nil {
+		t.Fatalf("Test failed. Expected an order without an orderbook, got %+v", report.Actions)
+	}
+	if report.Summary.OrdersWithoutBook != 1 {
+		t.Errorf("Test failed. Expected 1 order without an orderbook but got %d", report.Summary.OrdersWithoutBook)
+	}
+	// all exchanges are replayed
+	if report.BookUpdates != 3 {
+		t.Errorf("Test failed. Expected 3 orderbook updates but got %d", report.BookUpdates)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"log"
 	"net/http"
@@ -27,6 +28,32 @@ func RESTLogger(inner http.Handler, name string) http.Handler {
 	})
 }
 
+// RESTAdminAuth only passes requests authenticated with the webserver admin credentials (HTTP
+// basic auth) on to the handler, the routes that change the state of the bot, move funds or place
+// orders are wrapped with it.
+func RESTAdminAuth(inner http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || !isAdmin(username, password) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="cryptofiend"`)
+			http.Error(w, "admin credentials required", http.StatusUnauthorized)
+			return
+		}
+		inner(w, r)
+	}
+}
+
+// isAdmin returns true if the credentials match the configured admin credentials, no requests are
+// authenticated if the admin credentials aren't set.
+func isAdmin(username, password string) bool {
+	if bot.config == nil || bot.config.Webserver.AdminUsername == "" || bot.config.Webserver.AdminPassword == "" {
+		return false
+	}
+	usernameOK := subtle.ConstantTimeCompare([]byte(username), []byte(bot.config.Webserver.AdminUsername))
+	passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(bot.config.Webserver.AdminPassword))
+	return usernameOK&passwordOK == 1
+}
+
 // Route is a sub type that holds the request routes
 type Route struct {
 	Name        string
@@ -70,6 +97,12 @@ func NewRouter(exchanges []exchange.IBotExchange) *mux.Router {
 			"/exchanges/enabled/accounts/all",
 			RESTGetAllEnabledAccountInfo,
 		},
+		Route{
+			"GetAllBalances",
+			"GET",
+			"/exchanges/enabled/balances",
+			RESTGetAllBalances,
+		},
 		Route{
 			"AllActiveExchangesAndCurrencies",
 			"GET",
@@ -88,6 +121,48 @@ func NewRouter(exchanges []exchange.IBotExchange) *mux.Router {
 			"/portfolio/all",
 			RESTGetPortfolio,
 		},
+		Route{
+			"GetPortfolioValuations",
+			"GET",
+			"/portfolio/valuations",
+			RESTGetPortfolioValuations,
+		},
+		Route{
+			"GetPortfolioStats",
+			"GET",
+			"/portfolio/stats",
+			RESTGetPortfolioStats,
+		},
+		Route{
+			"ImportPnLFills",
+			"POST",
+			"/pnl/fills",
+			RESTAdminAuth(RESTImportPnLFills),
+		},
+		Route{
+			"GetTaxReport",
+			"GET",
+			"/pnl/taxreport",
+			RESTGetTaxReport,
+		},
+		Route{
+			"GetLendingReport",
+			"GET",
+			"/pnl/lending",
+			RESTGetLendingReport,
+		},
+		Route{
+			"RecordTransfers",
+			"POST",
+			"/transfers",
+			RESTAdminAuth(RESTRecordTransfers),
+		},
+		Route{
+			"GetTransferEstimate",
+			"GET",
+			"/transfers/estimate",
+			RESTGetTransferEstimate,
+		},
 		Route{
 			"AllActiveExchangesAndOrderbooks",
 			"GET",
@@ -100,6 +175,198 @@ func NewRouter(exchanges []exchange.IBotExchange) *mux.Router {
 			"/exchanges/{exchangeName}/orderbook/latest/{currency}",
 			RESTGetOrderbook,
 		},
+		Route{
+			"IndividualExchangeSourcedPrice",
+			"GET",
+			"/exchanges/{exchangeName}/price/{currency}",
+			RESTGetSourcedPrice,
+		},
+		Route{
+			"RotateExchangeAPIKeys",
+			"POST",
+			"/exchanges/{exchangeName}/apikeys",
+			RESTAdminAuth(RESTRotateAPIKeys),
+		},
+		Route{
+			"GetExchangeAccountBalances",
+			"GET",
+			"/exchanges/{exchangeName}/accounts/balances",
+			RESTGetExchangeAccountBalances,
+		},
+		Route{
+			"GetExchangeAccountOrders",
+			"GET",
+			"/exchanges/{exchangeName}/accounts/orders",
+			RESTGetExchangeAccountOrders,
+		},
+		Route{
+			"GetExchangeAccountState",
+			"GET",
+			"/exchanges/{exchangeName}/accounts/state",
+			RESTGetExchangeAccountState,
+		},
+		Route{
+			"CloseExchangePosition",
+			"POST",
+			"/exchanges/{exchangeName}/positions/close",
+			RESTAdminAuth(RESTCloseExchangePosition),
+		},
+		Route{
+			"GetExchangeActivityReport",
+			"GET",
+			"/exchanges/{exchangeName}/report",
+			RESTGetExchangeActivityReport,
+		},
+		Route{
+			"GetExchangeStatus",
+			"GET",
+			"/exchanges/status",
+			RESTGetExchangeStatus,
+		},
+		Route{
+			"GetExchangeDegradedStatus",
+			"GET",
+			"/exchanges/degraded",
+			RESTGetExchangeDegradedStatus,
+		},
+		Route{
+			"GetEventBusStats",
+			"GET",
+			"/events/stats",
+			RESTGetEventBusStats,
+		},
+		Route{
+			"SimulateExchangeDowntime",
+			"POST",
+			"/exchanges/{exchangeName}/downtime/{state}",
+			RESTAdminAuth(RESTSimulateExchangeDowntime),
+		},
+		Route{
+			"GetExchangeTrading",
+			"GET",
+			"/exchanges/{exchangeName}/trading",
+			RESTGetExchangeTrading,
+		},
+		Route{
+			"GetTrace",
+			"GET",
+			"/trace",
+			RESTGetTrace,
+		},
+		Route{
+			"SetTrace",
+			"POST",
+			"/trace/{module}/{state}",
+			RESTSetTrace,
+		},
+		Route{
+			"SetExchangeTrading",
+			"POST",
+			"/exchanges/{exchangeName}/trading/{state}",
+			RESTAdminAuth(RESTSetExchangeTrading),
+		},
+		Route{
+			"GetCurrencies",
+			"GET",
+			"/currencies",
+			RESTGetCurrencies,
+		},
+		Route{
+			"GetCurrency",
+			"GET",
+			"/currencies/{currency}",
+			RESTGetCurrency,
+		},
+		Route{
+			"ResolveMarket",
+			"GET",
+			"/markets/resolve",
+			RESTResolveMarket,
+		},
+		Route{
+			"GetWithdrawalRequirements",
+			"GET",
+			"/exchanges/{exchangeName}/withdrawals/{currency}",
+			RESTGetWithdrawalRequirements,
+		},
+		Route{
+			"ValidateWithdrawal",
+			"GET",
+			"/exchanges/{exchangeName}/withdrawals/{currency}/validate",
+			RESTValidateWithdrawal,
+		},
+		Route{
+			"GetListings",
+			"GET",
+			"/listings",
+			RESTGetListings,
+		},
+		Route{
+			"GetSweeps",
+			"GET",
+			"/sweeps",
+			RESTGetSweeps,
+		},
+		Route{
+			"RunSweepPolicy",
+			"POST",
+			"/sweeps/{policy}",
+			RESTAdminAuth(RESTRunSweepPolicy),
+		},
+		Route{
+			"GetConditionalOrders",
+			"GET",
+			"/orders/conditional",
+			RESTGetConditionalOrders,
+		},
+		Route{
+			"AddConditionalOrder",
+			"POST",
+			"/orders/conditional",
+			RESTAdminAuth(RESTAddConditionalOrder),
+		},
+		Route{
+			"CancelConditionalOrder",
+			"DELETE",
+			"/orders/conditional/{id}",
+			RESTAdminAuth(RESTCancelConditionalOrder),
+		},
+		Route{
+			"ExecutionAnalytics",
+			"GET",
+			"/analytics/execution",
+			RESTGetExecutionAnalytics,
+		},
+		Route{
+			"GetStrategies",
+			"GET",
+			"/strategies",
+			RESTGetStrategies,
+		},
+		Route{
+			"GetStrategyParams",
+			"GET",
+			"/strategies/{strategy}/params",
+			RESTGetStrategyParams,
+		},
+		Route{
+			"UpdateStrategyParams",
+			"PUT",
+			"/strategies/{strategy}/params",
+			RESTAdminAuth(RESTUpdateStrategyParams),
+		},
+		Route{
+			"GetStrategyParamChanges",
+			"GET",
+			"/strategies/{strategy}/params/history",
+			RESTGetStrategyParamChanges,
+		},
+		Route{
+			"GetStrategyQuota",
+			"GET",
+			"/strategies/{strategy}/quota",
+			RESTGetStrategyQuota,
+		},
 		Route{
 			"ws",
 			"GET",
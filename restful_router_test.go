@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/config"
+)
+
+func TestRESTAdminAuth(t *testing.T) {
+	previous := bot.config
+	bot.config = &config.Config{}
+	bot.config.Webserver.AdminUsername = "admin"
+	bot.config.Webserver.AdminPassword = "secret"
+	defer func() { bot.config = previous }()
+	router := NewRouter(nil)
+
+	routes := []struct {
+		method, path string
+	}{
+		{http.MethodPost, "/exchanges/Bitfinex/apikeys"},
+		{http.MethodPost, "/exchanges/Bitfinex/positions/close"},
+		{http.MethodPost, "/orders/conditional"},
+		{http.MethodDelete, "/orders/conditional/1"},
+		{http.MethodPost, "/sweeps/default"},
+		{http.MethodPut, "/strategies/default/params"},
+		{http.MethodPost, "/exchanges/Bitfinex/trading/disabled"},
+		{http.MethodPost, "/exchanges/Bitfinex/downtime/down"},
+		{http.MethodPost, "/transfers"},
+		{http.MethodPost, "/pnl/fills"},
+	}
+	for _, route := range routes {
+		tests := []struct {
+			username, password string
+			auth               bool
+		}{
+			{auth: false},
+			{username: "admin", password: "wrong", auth: true},
+			{username: "other", password: "secret", auth: true},
+		}
+		for _, test := range tests {
+			req := httptest.NewRequest(route.method, route.path, nil)
+			if test.auth {
+				req.SetBasicAuth(test.username, test.password)
+			}
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			if resp.Code != http.StatusUnauthorized {
+				t.Errorf("Test failed. Expected %s %s with %q/%q to be unauthorized but got status %d",
+					route.method, route.path, test.username, test.password, resp.Code)
+			}
+		}
+
+		// authenticated requests reach the handler
+		req := httptest.NewRequest(route.method, route.path, nil)
+		req.SetBasicAuth("admin", "secret")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if resp.Code == http.StatusUnauthorized || resp.Code == http.StatusMethodNotAllowed {
+			t.Errorf("Test failed. Expected authenticated %s %s to reach the handler but got status %d",
+				route.method, route.path, resp.Code)
+		}
+	}
+}
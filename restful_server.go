@@ -1,15 +1,38 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/mattkanwisher/cryptofiend/accounts"
+	"github.com/mattkanwisher/cryptofiend/analytics"
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/compliance"
+	"github.com/mattkanwisher/cryptofiend/conditional"
 	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/metadata"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
 	exchange "github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/asset"
 	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
 	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+	"github.com/mattkanwisher/cryptofiend/jsondecimal"
+	"github.com/mattkanwisher/cryptofiend/listings"
+	"github.com/mattkanwisher/cryptofiend/markets"
+	"github.com/mattkanwisher/cryptofiend/pnl"
+	"github.com/mattkanwisher/cryptofiend/portfolio"
+	"github.com/mattkanwisher/cryptofiend/routing"
+	"github.com/mattkanwisher/cryptofiend/strategy"
+	"github.com/mattkanwisher/cryptofiend/sweep"
+	"github.com/mattkanwisher/cryptofiend/trace"
+	"github.com/mattkanwisher/cryptofiend/transfers"
 )
 
 // AllEnabledExchangeOrderbooks holds the enabled exchange orderbooks
@@ -41,10 +64,19 @@ type AllEnabledExchangeAccounts struct {
 	Data []exchange.AccountInfo `json:"data"`
 }
 
-// RESTfulJSONResponse outputs a JSON response of the req interface
+// RESTfulJSONResponse outputs a JSON response of the req interface.
+// If DecimalStrings is enabled in the webserver config float values are encoded as exact decimal
+// strings, which clients can decode with the jsondecimal package.
 func RESTfulJSONResponse(w http.ResponseWriter, r *http.Request, req interface{}) error {
+	return restfulJSONResponse(w, req, bot.config != nil && bot.config.Webserver.DecimalStrings)
+}
+
+func restfulJSONResponse(w http.ResponseWriter, req interface{}, decimalStrings bool) error {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusOK)
+	if decimalStrings {
+		return jsondecimal.NewEncoder(w).Encode(req)
+	}
 	if err := json.NewEncoder(w).Encode(req); err != nil {
 		return err
 	}
@@ -60,7 +92,8 @@ func RESTfulError(method string, err error) {
 // RESTGetAllSettings replies to a request with an encoded JSON response about the
 // trading bots configuration.
 func RESTGetAllSettings(w http.ResponseWriter, r *http.Request) {
-	err := RESTfulJSONResponse(w, r, bot.config)
+	// The settings are always encoded as-is, so they can be posted back unchanged
+	err := restfulJSONResponse(w, bot.config, false)
 	if err != nil {
 		RESTfulError(r.Method, err)
 	}
@@ -81,8 +114,9 @@ func RESTSaveAllSettings(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		RESTfulError(r.Method, err)
 	}
+	applyTradingSwitches()
 
-	err = RESTfulJSONResponse(w, r, bot.config)
+	err = restfulJSONResponse(w, bot.config, false)
 	if err != nil {
 		RESTfulError(r.Method, err)
 	}
@@ -94,11 +128,7 @@ func RESTGetOrderbook(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	currency := vars["currency"]
 	exchange := vars["exchangeName"]
-	assetType := vars["assetType"]
-
-	if assetType == "" {
-		assetType = orderbook.Spot
-	}
+	assetType := asset.Normalize(vars["assetType"])
 
 	response, err := GetSpecificOrderbook(currency, exchange, assetType)
 	if err != nil {
@@ -181,17 +211,442 @@ func RESTGetPortfolio(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// RESTGetPortfolioValuations returns the portfolio valuation snapshots, optionally only the ones
+// taken since the RFC3339 time in the since query parameter. Add format=csv to the query to get
+// the snapshots in CSV format.
+func RESTGetPortfolioValuations(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, "invalid since time: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	valuations := bot.valuations.Valuations(since)
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+		if err := portfolio.WriteValuationsCSV(w, valuations); err != nil {
+			RESTfulError(r.Method, err)
+		}
+		return
+	}
+
+	if err := RESTfulJSONResponse(w, r, valuations); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetPortfolioStats returns the drawdown & return statistics of the portfolio value, the
+// window query parameter sets the number of daily returns the Sharpe ratio is calculated over.
+func RESTGetPortfolioStats(w http.ResponseWriter, r *http.Request) {
+	var window int
+	if v := r.URL.Query().Get("window"); v != "" {
+		var err error
+		if window, err = strconv.Atoi(v); err != nil || window < 2 {
+			http.Error(w, "invalid window, must be at least 2 days", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := RESTfulJSONResponse(w, r, bot.valuations.Stats(window)); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// PnLImport holds the fills & ledger entries imported into the tax lots
+type PnLImport struct {
+	Fills  []pnl.Fill        `json:"fills"`
+	Ledger []pnl.LedgerEntry `json:"ledger"`
+}
+
+// RESTImportPnLFills records fills & ledger entries (from any exchange) in the tax lots, they're
+// recorded in chronological order and none are recorded if any fill is rejected. The tax lots are
+// saved once the batch is recorded. Fills with an order ID but no strategy are attributed to the
+// strategy that tagged the order. Returns the disposals realized by the fills.
+func RESTImportPnLFills(w http.ResponseWriter, r *http.Request) {
+	if bot.taxLots == nil {
+		http.Error(w, "tax lots aren't available", http.StatusServiceUnavailable)
+		return
+	}
+	var req PnLImport
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for i := range req.Fills {
+		if f := &req.Fills[i]; f.Strategy == "" && f.OrderID != "" {
+			f.Strategy = bot.strategies.Tags.Get(f.Exchange, f.OrderID)
+		}
+	}
+	disposals, err := bot.taxLots.Import(req.Fills, req.Ledger, bot.config.FiatDisplayCurrency)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err = bot.taxLots.Save(bot.store); err != nil {
+		http.Error(w, fmt.Sprintf("failed to save the tax lots: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	if err = RESTfulJSONResponse(w, r, disposals); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetTaxReport returns the tax report for the year query parameter (defaults to the current
+// year), the disposals are returned in CSV format if the format query parameter is csv.
+func RESTGetTaxReport(w http.ResponseWriter, r *http.Request) {
+	if bot.taxLots == nil {
+		http.Error(w, "tax lots aren't available", http.StatusServiceUnavailable)
+		return
+	}
+	year := time.Now().UTC().Year()
+	if v := r.URL.Query().Get("year"); v != "" {
+		var err error
+		if year, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "invalid year", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report := bot.taxLots.Report(year, time.UTC)
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+		if err := pnl.WriteDisposalsCSV(w, report.Disposals); err != nil {
+			RESTfulError(r.Method, err)
+		}
+		return
+	}
+
+	if err := RESTfulJSONResponse(w, r, report); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// lendingProviders returns the enabled exchanges that can return the loans made by the account
+func lendingProviders() []exchange.LendingHistoryProvider {
+	var providers []exchange.LendingHistoryProvider
+	if bot.exchange.bitfinex.IsEnabled() && bot.exchange.bitfinex.GetAuthenticatedAPISupport() {
+		providers = append(providers, &bot.exchange.bitfinex)
+	}
+	if bot.exchange.poloniex.IsEnabled() && bot.exchange.poloniex.GetAuthenticatedAPISupport() {
+		providers = append(providers, &bot.exchange.poloniex)
+	}
+	return providers
+}
+
+// RESTGetLendingReport returns the interest earned by lending funds to margin traders between the
+// start & end query parameters (dates formatted as YYYY-MM-DD, end defaults to now), summarized
+// per currency. The summaries are returned in CSV format if the format query parameter is csv.
+func RESTGetLendingReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	start, err := time.Parse("2006-01-02", query.Get("start"))
+	if err != nil {
+		http.Error(w, "invalid start date", http.StatusBadRequest)
+		return
+	}
+	end := time.Now().UTC()
+	if v := query.Get("end"); v != "" {
+		if end, err = time.Parse("2006-01-02", v); err != nil {
+			http.Error(w, "invalid end date", http.StatusBadRequest)
+			return
+		}
+	}
+	if !end.After(start) {
+		http.Error(w, "end date must be after the start date", http.StatusBadRequest)
+		return
+	}
+
+	var records []exchange.LendingRecord
+	for _, provider := range lendingProviders() {
+		// loans that closed after the end date may have been open during the period
+		result, err := provider.GetLendingRecords(start, time.Now().UTC())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		records = append(records, result...)
+	}
+
+	report := pnl.NewLendingReport(records, start, end)
+	if query.Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+		if err = pnl.WriteLendingCSV(w, report); err != nil {
+			RESTfulError(r.Method, err)
+		}
+		return
+	}
+	if err = RESTfulJSONResponse(w, r, report); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetCurrencies returns the metadata of every currency supported by the exchanges
+func RESTGetCurrencies(w http.ResponseWriter, r *http.Request) {
+	if bot.currencyMetadata == nil {
+		http.Error(w, "currency metadata isn't available", http.StatusServiceUnavailable)
+		return
+	}
+	if err := RESTfulJSONResponse(w, r, bot.currencyMetadata.Currencies()); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTResolveMarket returns the markets listed by the enabled exchanges that trade the market in
+// the market query parameter (e.g. eth/usd), closest matches first, see markets.ResolveMarket.
+func RESTResolveMarket(w http.ResponseWriter, r *http.Request) {
+	var listers []markets.Lister
+	for _, exch := range bot.exchanges {
+		if exch == nil || !exch.IsEnabled() {
+			continue
+		}
+		if lister, ok := exch.(markets.Lister); ok {
+			listers = append(listers, lister)
+		}
+	}
+	matches, err := markets.ResolveMarket(r.URL.Query().Get("market"), listers)
+	if err == markets.ErrNoMarket {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err = RESTfulJSONResponse(w, r, matches); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetCurrency returns the metadata of a currency aggregated across exchanges
+func RESTGetCurrency(w http.ResponseWriter, r *http.Request) {
+	if bot.currencyMetadata == nil {
+		http.Error(w, "currency metadata isn't available", http.StatusServiceUnavailable)
+		return
+	}
+	c, err := bot.currencyMetadata.Currency(mux.Vars(r)["currency"])
+	if err == metadata.ErrUnknownCurrency {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err = RESTfulJSONResponse(w, r, c); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetWithdrawalRequirements returns the networks a currency can be withdrawn on from an
+// exchange, and whether the network & tag must be specified
+func RESTGetWithdrawalRequirements(w http.ResponseWriter, r *http.Request) {
+	if bot.currencyMetadata == nil {
+		http.Error(w, "currency metadata isn't available", http.StatusServiceUnavailable)
+		return
+	}
+	vars := mux.Vars(r)
+	req, err := bot.currencyMetadata.WithdrawalRequirements(vars["exchangeName"], vars["currency"])
+	if err == metadata.ErrUnknownCurrency {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err = RESTfulJSONResponse(w, r, req); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTValidateWithdrawal checks the network, address & tag query parameters of a withdrawal of a
+// currency from an exchange, returns the network the withdrawal would be made on or a 400 error
+// if the destination is malformed
+func RESTValidateWithdrawal(w http.ResponseWriter, r *http.Request) {
+	if bot.currencyMetadata == nil {
+		http.Error(w, "currency metadata isn't available", http.StatusServiceUnavailable)
+		return
+	}
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+	network, err := bot.currencyMetadata.ValidateWithdrawal(vars["exchangeName"], vars["currency"],
+		query.Get("network"), query.Get("address"), query.Get("tag"))
+	if err == metadata.ErrUnknownCurrency {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err = RESTfulJSONResponse(w, r, network); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetListings returns the listing & delisting events detected on the exchanges, optionally
+// only the ones detected since the RFC3339 time in the since query parameter
+func RESTGetListings(w http.ResponseWriter, r *http.Request) {
+	if bot.listings == nil {
+		http.Error(w, "listing changes aren't available", http.StatusServiceUnavailable)
+		return
+	}
+	var since time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, "invalid since time: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	events := bot.listings.Events(since)
+	if events == nil {
+		events = []listings.Event{}
+	}
+	if err := RESTfulJSONResponse(w, r, events); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetSweeps returns the wallet sweep policies, along with the sweeps they've made
+func RESTGetSweeps(w http.ResponseWriter, r *http.Request) {
+	if bot.sweeper == nil {
+		http.Error(w, "wallet sweeps aren't available", http.StatusServiceUnavailable)
+		return
+	}
+	response := struct {
+		Policies []sweep.Policy `json:"policies"`
+		History  []sweep.Sweep  `json:"history"`
+	}{bot.sweeper.Policies(), bot.sweeper.History()}
+	if err := RESTfulJSONResponse(w, r, response); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTRunSweepPolicy runs a wallet sweep policy straight away, the policy runs in dry-run mode if
+// the dryRun query parameter is true
+func RESTRunSweepPolicy(w http.ResponseWriter, r *http.Request) {
+	if bot.sweeper == nil {
+		http.Error(w, "wallet sweeps aren't available", http.StatusServiceUnavailable)
+		return
+	}
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+	sweeps, err := bot.sweeper.Run(mux.Vars(r)["policy"], dryRun, time.Now())
+	switch {
+	case err == sweep.ErrPolicyNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil && len(sweeps) == 0:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = RESTfulJSONResponse(w, r, sweeps); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetConditionalOrders returns the active conditional orders
+func RESTGetConditionalOrders(w http.ResponseWriter, r *http.Request) {
+	if bot.conditionalOrders == nil {
+		http.Error(w, "conditional orders aren't available", http.StatusServiceUnavailable)
+		return
+	}
+	if err := RESTfulJSONResponse(w, r, bot.conditionalOrders.Orders()); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTAddConditionalOrder adds the conditional order in the request body, and returns it with its
+// ID & state set.
+func RESTAddConditionalOrder(w http.ResponseWriter, r *http.Request) {
+	if bot.conditionalOrders == nil {
+		http.Error(w, "conditional orders aren't available", http.StatusServiceUnavailable)
+		return
+	}
+	var req conditional.Order
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	order, err := bot.conditionalOrders.Add(req)
+	switch err {
+	case nil:
+	case conditional.ErrInvalidOrder, conditional.ErrUnknownExchange:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if err = RESTfulJSONResponse(w, r, order); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTCancelConditionalOrder cancels a conditional order along with its live exchange orders
+func RESTCancelConditionalOrder(w http.ResponseWriter, r *http.Request) {
+	if bot.conditionalOrders == nil {
+		http.Error(w, "conditional orders aren't available", http.StatusServiceUnavailable)
+		return
+	}
+	err := bot.conditionalOrders.Cancel(mux.Vars(r)["id"])
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusNoContent)
+	case conditional.ErrOrderNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case conditional.ErrOrderPlacing:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	}
+}
+
+// RESTRecordTransfers records completed transfers between exchanges, the request body is a JSON
+// array of transfers. The transfers are used to estimate the duration of future transfers.
+func RESTRecordTransfers(w http.ResponseWriter, r *http.Request) {
+	if bot.transfers == nil {
+		http.Error(w, "transfer estimator isn't available", http.StatusServiceUnavailable)
+		return
+	}
+	var req []transfers.Transfer
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for i := range req {
+		bot.transfers.RecordTransfer(req[i])
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RESTGetTransferEstimate returns the expected fee & duration of transferring the currency query
+// parameter from the exchange in the from query parameter to the one in the to query parameter.
+func RESTGetTransferEstimate(w http.ResponseWriter, r *http.Request) {
+	if bot.transfers == nil {
+		http.Error(w, "transfer estimator isn't available", http.StatusServiceUnavailable)
+		return
+	}
+	query := r.URL.Query()
+	currency, from, to := query.Get("currency"), query.Get("from"), query.Get("to")
+	if currency == "" || from == "" || to == "" {
+		http.Error(w, "currency, from & to are required", http.StatusBadRequest)
+		return
+	}
+	estimate, err := bot.transfers.Estimate(currency, from, to)
+	if err == transfers.ErrNoEstimate {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err = RESTfulJSONResponse(w, r, estimate); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
 // RESTGetTicker returns ticker info for a given currency, exchange and
 // asset type
 func RESTGetTicker(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	currency := vars["currency"]
 	exchange := vars["exchangeName"]
-	assetType := vars["assetType"]
-
-	if assetType == "" {
-		assetType = ticker.Spot
-	}
+	assetType := asset.Normalize(vars["assetType"])
 	response, err := GetSpecificTicker(currency, exchange, assetType)
 	if err != nil {
 		log.Printf("Failed to fetch ticker for %s currency: %s\n", exchange,
@@ -265,24 +720,34 @@ func RESTGetAllActiveTickers(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// GetAllEnabledExchangeAccountInfo returns all the current enabled exchanges
-func GetAllEnabledExchangeAccountInfo() AllEnabledExchangeAccounts {
-	var response AllEnabledExchangeAccounts
+// GetAllBalances retrieves the balances of every enabled exchange with authenticated API support
+// concurrently, an exchange that fails or takes longer than balancesTimeout to respond is reported
+// in the snapshot errors rather than delaying the other exchanges.
+func GetAllBalances(ctx context.Context) exchange.BalanceSnapshot {
+	var exchanges []exchange.IBotExchange
 	for _, individualBot := range bot.exchanges {
 		if individualBot != nil && individualBot.IsEnabled() {
 			if !individualBot.GetAuthenticatedAPISupport() {
-				log.Printf("GetAllEnabledExchangeAccountInfo: Skippping %s due to disabled authenticated API support.", individualBot.GetName())
+				log.Printf("GetAllBalances: Skippping %s due to disabled authenticated API support.", individualBot.GetName())
 				continue
 			}
-			individualExchange, err := individualBot.GetExchangeAccountInfo()
-			if err != nil {
-				log.Printf("Error encountered retrieving exchange account info for %s. Error %s",
-					individualBot.GetName(), err)
-				continue
-			}
-			response.Data = append(response.Data, individualExchange)
+			exchanges = append(exchanges, individualBot)
 		}
 	}
+	return exchange.GetAllBalances(ctx, exchanges, balancesTimeout)
+}
+
+// GetAllEnabledExchangeAccountInfo returns all the current enabled exchanges
+func GetAllEnabledExchangeAccountInfo() AllEnabledExchangeAccounts {
+	var response AllEnabledExchangeAccounts
+	snapshot := GetAllBalances(context.Background())
+	for _, e := range snapshot.Errors {
+		log.Printf("Error encountered retrieving exchange account info for %s. Error %s",
+			e.Exchange, e.Error)
+	}
+	if len(snapshot.Accounts) > 0 {
+		response.Data = snapshot.Accounts
+	}
 	return response
 }
 
@@ -295,3 +760,522 @@ func RESTGetAllEnabledAccountInfo(w http.ResponseWriter, r *http.Request) {
 		RESTfulError(r.Method, err)
 	}
 }
+
+// RESTGetAllBalances replies to a request with the balances of all the enabled exchanges, the
+// exchanges that failed to respond are listed in the errors of the response
+func RESTGetAllBalances(w http.ResponseWriter, r *http.Request) {
+	snapshot := GetAllBalances(r.Context())
+	if err := RESTfulJSONResponse(w, r, snapshot); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetSourcedPrice replies to a request with the latest price for a currency pair on an
+// exchange, along with the provenance of the price (which may come from a fallback exchange).
+func RESTGetSourcedPrice(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	assetType := asset.Normalize(vars["assetType"])
+
+	price, err := bot.marketData.GetPrice(
+		vars["exchangeName"], pair.NewCurrencyPairFromString(vars["currency"]), assetType,
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err := RESTfulJSONResponse(w, r, price); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetExecutionAnalytics replies to a request with the order execution metrics, encoded as
+// JSON or as CSV if the format=csv query parameter is supplied.
+func RESTGetExecutionAnalytics(w http.ResponseWriter, r *http.Request) {
+	if bot.analytics == nil {
+		http.Error(w, "order execution analytics are disabled", http.StatusNotFound)
+		return
+	}
+
+	stats := bot.analytics.Stats()
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+		if err := analytics.WriteCSV(w, stats); err != nil {
+			RESTfulError(r.Method, err)
+		}
+		return
+	}
+
+	if err := RESTfulJSONResponse(w, r, stats); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// APIKeys holds the API credentials for an exchange
+type APIKeys struct {
+	APIKey    string
+	APISecret string
+	ClientID  string
+}
+
+// RESTRotateAPIKeys replaces the API credentials of an exchange without restarting the bot. The
+// new keys are verified by retrieving the account info, if that fails the previous keys remain in
+// use. Once verified the new keys are also stored in the config.
+func RESTRotateAPIKeys(w http.ResponseWriter, r *http.Request) {
+	exchangeName := mux.Vars(r)["exchangeName"]
+	var exch exchange.IBotExchange
+	for i := range bot.exchanges {
+		if bot.exchanges[i] != nil && bot.exchanges[i].GetName() == exchangeName {
+			exch = bot.exchanges[i]
+			break
+		}
+	}
+	if exch == nil {
+		http.Error(w, exchange.ErrExchangeNotFound, http.StatusNotFound)
+		return
+	}
+
+	var keys APIKeys
+	if err := json.NewDecoder(r.Body).Decode(&keys); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := exch.RotateAPIKeys(keys.APIKey, keys.APISecret, keys.ClientID,
+		exchange.VerifyAccountAccess(exch))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("%s: API keys rotated.\n", exchangeName)
+
+	exchCfg, err := bot.config.GetExchangeConfig(exchangeName)
+	if err == nil {
+		exchCfg.APIKey = keys.APIKey
+		exchCfg.APISecret = keys.APISecret
+		exchCfg.ClientID = keys.ClientID
+		err = bot.config.UpdateExchangeConfig(exchCfg)
+	}
+	if err != nil {
+		log.Printf("%s: Unable to store rotated API keys in config. Error: %s\n", exchangeName, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RESTGetExchangeAccountBalances returns the combined balances of all the accounts of an
+// exchange, along with the balances of each account.
+func RESTGetExchangeAccountBalances(w http.ResponseWriter, r *http.Request) {
+	balances, err := bot.accounts.Balances(mux.Vars(r)["exchangeName"])
+	if err == accounts.ErrNoAccounts {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if err = RESTfulJSONResponse(w, r, balances); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetExchangeAccountOrders returns the active orders of all the accounts of an exchange,
+// tagged by account.
+func RESTGetExchangeAccountOrders(w http.ResponseWriter, r *http.Request) {
+	orders, err := bot.accounts.Orders(mux.Vars(r)["exchangeName"], nil)
+	if err == accounts.ErrNoAccounts {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if err = RESTfulJSONResponse(w, r, orders); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetExchangeAccountState returns the balances, open orders & positions of the primary
+// account of an exchange, fetched together so they can be compared with each other.
+func RESTGetExchangeAccountState(w http.ResponseWriter, r *http.Request) {
+	exchangeName := mux.Vars(r)["exchangeName"]
+	var exch exchange.IBotExchangeEx
+	for _, e := range bot.exchanges {
+		if e.GetName() == exchangeName && e.IsEnabled() {
+			exch, _ = e.(exchange.IBotExchangeEx)
+			break
+		}
+	}
+	if exch == nil {
+		http.Error(w, "account state isn't available for "+exchangeName, http.StatusNotFound)
+		return
+	}
+	state, err := exchange.GetAccountState(exch, bot.positionListers[exchangeName])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if err = RESTfulJSONResponse(w, r, state); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTCloseExchangePosition flattens the net position of the primary account of an exchange in
+// the pair query parameter (delimited by "/", e.g. BTC/USD), either the margin positions in the
+// pair or the spot balance of the base currency. Returns the order placed to close the position.
+func RESTCloseExchangePosition(w http.ResponseWriter, r *http.Request) {
+	exchangeName := mux.Vars(r)["exchangeName"]
+	p := r.URL.Query().Get("pair")
+	if !common.StringContains(p, "/") {
+		http.Error(w, "pair must be delimited by /", http.StatusBadRequest)
+		return
+	}
+	var exch exchange.IBotExchangeEx
+	for _, e := range bot.exchanges {
+		if e.GetName() == exchangeName && e.IsEnabled() {
+			exch, _ = e.(exchange.IBotExchangeEx)
+			break
+		}
+	}
+	if exch == nil {
+		http.Error(w, "trading isn't available on "+exchangeName, http.StatusNotFound)
+		return
+	}
+	plan, err := routing.ClosePosition(exch, bot.positionListers[exchangeName],
+		pair.NewCurrencyPairDelimiter(common.StringToUpper(p), "/"), bot.auditLog)
+	if err == routing.ErrNoPosition {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	log.Printf("%s: Closed %s position of %v, %s order %s.\n", exchangeName, plan.Position.Pair,
+		plan.Position.Amount, plan.Side, plan.OrderID)
+	if err = RESTfulJSONResponse(w, r, plan); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetExchangeActivityReport returns the orders, fills, deposits, withdrawals, transfers & fees
+// of the primary account of an exchange between the start & end query parameters (dates formatted
+// as YYYY-MM-DD, end defaults to now). The report is returned as plain text if the format query
+// parameter is text, or as one record per row if it's csv.
+func RESTGetExchangeActivityReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	start, err := time.Parse("2006-01-02", query.Get("start"))
+	if err != nil {
+		http.Error(w, "invalid start date", http.StatusBadRequest)
+		return
+	}
+	now := time.Now().UTC()
+	end := now
+	if v := query.Get("end"); v != "" {
+		if end, err = time.Parse("2006-01-02", v); err != nil {
+			http.Error(w, "invalid end date", http.StatusBadRequest)
+			return
+		}
+	}
+	if !end.After(start) {
+		http.Error(w, "end date must be after the start date", http.StatusBadRequest)
+		return
+	}
+
+	exchangeName := mux.Vars(r)["exchangeName"]
+	var exch exchange.IBotExchangeEx
+	for _, e := range bot.exchanges {
+		if e.GetName() == exchangeName && e.IsEnabled() {
+			exch, _ = e.(exchange.IBotExchangeEx)
+			break
+		}
+	}
+	if exch == nil {
+		http.Error(w, "activity report isn't available for "+exchangeName, http.StatusNotFound)
+		return
+	}
+	activity, err := compliance.Collect(exch, exch.GetEnabledCurrencies(), start, end)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	// Movements of funds made by the bot, which the exchange may not report
+	if bot.transfers != nil {
+		activity.Ledger = append(activity.Ledger,
+			compliance.TransferEntries(exchangeName, bot.transfers.Transfers(exchangeName, start, end))...)
+	}
+	if bot.sweeper != nil {
+		activity.Ledger = append(activity.Ledger, compliance.SweepEntries(exchangeName, bot.sweeper.History())...)
+	}
+
+	report := compliance.NewReport(exchangeName, start, end, activity, now)
+	switch query.Get("format") {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+		if err = compliance.WriteCSV(w, report); err != nil {
+			RESTfulError(r.Method, err)
+		}
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+		if err = compliance.WriteText(w, report); err != nil {
+			RESTfulError(r.Method, err)
+		}
+	default:
+		if err = RESTfulJSONResponse(w, r, report); err != nil {
+			RESTfulError(r.Method, err)
+		}
+	}
+}
+
+// RESTGetExchangeStatus returns the last known platform status of the exchanges that publish
+// their status, including any planned maintenance.
+func RESTGetExchangeStatus(w http.ResponseWriter, r *http.Request) {
+	if bot.statusMonitor == nil {
+		http.Error(w, "exchange status monitoring isn't available", http.StatusServiceUnavailable)
+		return
+	}
+	if err := RESTfulJSONResponse(w, r, bot.statusMonitor.Statuses()); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetExchangeDegradedStatus returns the health of the authenticated API of the exchanges that
+// have degraded mode enabled, sorted by exchange name.
+func RESTGetExchangeDegradedStatus(w http.ResponseWriter, r *http.Request) {
+	statuses := make([]exchange.DegradedStatus, 0, len(bot.degradableExchanges))
+	for _, exch := range bot.degradableExchanges {
+		statuses = append(statuses, exch.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Exchange < statuses[j].Exchange })
+	if err := RESTfulJSONResponse(w, r, statuses); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetEventBusStats returns the queue sizes & the number of delivered and dropped events of
+// the subscribers to the bot events, sorted by subscriber name.
+func RESTGetEventBusStats(w http.ResponseWriter, r *http.Request) {
+	if err := RESTfulJSONResponse(w, r, bot.eventBus.Stats()); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTSimulateExchangeDowntime marks an exchange that has downtime simulation enabled as down
+// or up, the state must be either "down" or "up".
+func RESTSimulateExchangeDowntime(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	exchangeName := vars["exchangeName"]
+	simulator, ok := bot.downtimeSimulators[exchangeName]
+	if !ok {
+		http.Error(w, "downtime simulation isn't enabled for "+exchangeName, http.StatusNotFound)
+		return
+	}
+
+	switch vars["state"] {
+	case "down":
+		simulator.SetDown(true)
+	case "up":
+		simulator.SetDown(false)
+	default:
+		http.Error(w, "state must be either down or up", http.StatusBadRequest)
+		return
+	}
+	log.Printf("%s: Simulated downtime state changed to %s.\n", exchangeName, vars["state"])
+
+	err := RESTfulJSONResponse(w, r, map[string]bool{"down": simulator.IsDown()})
+	if err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetTrace replies to a request with the modules debug tracing is explicitly enabled or
+// disabled for
+func RESTGetTrace(w http.ResponseWriter, r *http.Request) {
+	if err := RESTfulJSONResponse(w, r, trace.Settings()); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTSetTrace enables (state on) or disables (state off) debug tracing of a module & its
+// submodules at runtime, state default removes the module setting so its parent's setting applies.
+func RESTSetTrace(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	module := vars["module"]
+	switch vars["state"] {
+	case "on":
+		trace.Enable(module)
+	case "off":
+		trace.Disable(module)
+	case "default":
+		trace.Clear(module)
+	default:
+		http.Error(w, "state must be on, off or default", http.StatusBadRequest)
+		return
+	}
+	log.Printf("Debug tracing of %s changed to %s.\n", module, vars["state"])
+
+	if err := RESTfulJSONResponse(w, r, trace.Settings()); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// TradingState holds the trading switches of an exchange
+type TradingState struct {
+	Paused      bool     `json:"paused"`
+	PausedPairs []string `json:"pausedPairs"`
+	// True while trading is paused for a scheduled maintenance window, read-only
+	Maintenance bool `json:"maintenance,omitempty"`
+}
+
+func newTradingState(tradingSwitch *exchange.TradingSwitch) TradingState {
+	state := TradingState{
+		Paused:      tradingSwitch.Paused(),
+		PausedPairs: []string{},
+		Maintenance: tradingSwitch.InMaintenance(),
+	}
+	for _, p := range tradingSwitch.PausedPairs() {
+		state.PausedPairs = append(state.PausedPairs, p.Display("/", true).String())
+	}
+	return state
+}
+
+// RESTGetExchangeTrading returns whether trading is paused on an exchange, and the pairs trading
+// is paused on.
+func RESTGetExchangeTrading(w http.ResponseWriter, r *http.Request) {
+	tradingSwitch, ok := bot.tradingSwitches[mux.Vars(r)["exchangeName"]]
+	if !ok {
+		http.Error(w, "exchange not found", http.StatusNotFound)
+		return
+	}
+	if err := RESTfulJSONResponse(w, r, newTradingState(tradingSwitch)); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTSetExchangeTrading pauses or resumes trading on an exchange, the state must be either
+// "paused" or "resumed". If the pair query parameter is set (delimited by "/", e.g. BTC/USD) only
+// trading on that pair is paused or resumed. Market data isn't affected, and the change lasts
+// until the config is reloaded.
+func RESTSetExchangeTrading(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	exchangeName := vars["exchangeName"]
+	tradingSwitch, ok := bot.tradingSwitches[exchangeName]
+	if !ok {
+		http.Error(w, "exchange not found", http.StatusNotFound)
+		return
+	}
+
+	var paused bool
+	switch vars["state"] {
+	case "paused":
+		paused = true
+	case "resumed":
+	default:
+		http.Error(w, "state must be either paused or resumed", http.StatusBadRequest)
+		return
+	}
+	if p := r.URL.Query().Get("pair"); p != "" {
+		if !common.StringContains(p, "/") {
+			http.Error(w, "pair must be delimited by /", http.StatusBadRequest)
+			return
+		}
+		tradingSwitch.SetPairPaused(pair.NewCurrencyPairDelimiter(p, "/"), paused)
+		log.Printf("%s: Trading on %s %s.\n", exchangeName, common.StringToUpper(p), vars["state"])
+	} else {
+		tradingSwitch.SetPaused(paused)
+		log.Printf("%s: Trading %s.\n", exchangeName, vars["state"])
+	}
+
+	if err := RESTfulJSONResponse(w, r, newTradingState(tradingSwitch)); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// StrategyParams holds the tunable parameters of a strategy and their current values
+type StrategyParams struct {
+	Specs  []strategy.ParamSpec `json:"specs"`
+	Params strategy.Params      `json:"params"`
+}
+
+func strategyError(w http.ResponseWriter, err error) {
+	if err == strategy.ErrStrategyNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+// RESTGetStrategies returns the names of the strategies run by the bot
+func RESTGetStrategies(w http.ResponseWriter, r *http.Request) {
+	if err := RESTfulJSONResponse(w, r, bot.strategies.Strategies()); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetStrategyParams returns the tunable parameters of a strategy
+func RESTGetStrategyParams(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["strategy"]
+	specs, err := bot.strategies.ParamSpecs(name)
+	if err != nil {
+		strategyError(w, err)
+		return
+	}
+	params, err := bot.strategies.Params(name)
+	if err != nil {
+		strategyError(w, err)
+		return
+	}
+	if err = RESTfulJSONResponse(w, r, StrategyParams{Specs: specs, Params: params}); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTUpdateStrategyParams updates the parameters of a strategy, the request body is a JSON
+// object of parameter names & new values. The changes are validated and either all of them are
+// applied or none are.
+func RESTUpdateStrategyParams(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["strategy"]
+	var changes map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	params, err := bot.strategies.UpdateParams(name, changes, r.RemoteAddr)
+	if err != nil {
+		strategyError(w, err)
+		return
+	}
+	log.Printf("Strategy %s parameters updated by %s.\n", name, r.RemoteAddr)
+	if err = RESTfulJSONResponse(w, r, params); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetStrategyParamChanges returns the history of parameter changes made to a strategy
+func RESTGetStrategyParamChanges(w http.ResponseWriter, r *http.Request) {
+	changes, err := bot.strategies.Changes(mux.Vars(r)["strategy"])
+	if err != nil {
+		strategyError(w, err)
+		return
+	}
+	if err = RESTfulJSONResponse(w, r, changes); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
+
+// RESTGetStrategyQuota returns the resource quota of a strategy & its current usage
+func RESTGetStrategyQuota(w http.ResponseWriter, r *http.Request) {
+	usage, err := bot.strategies.Usage(mux.Vars(r)["strategy"])
+	if err != nil {
+		strategyError(w, err)
+		return
+	}
+	if err = RESTfulJSONResponse(w, r, usage); err != nil {
+		RESTfulError(r.Method, err)
+	}
+}
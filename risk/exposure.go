@@ -0,0 +1,250 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+// ErrExposureLimitExceeded is returned when an order would take the exposure to the quote
+// currency of its currency pair over the limit
+var ErrExposureLimitExceeded = errors.New("quote currency exposure limit exceeded")
+
+// ExposureSource provides the consolidated portfolio that the exposure to a quote currency is
+// calculated from
+type ExposureSource interface {
+	// Holdings returns the balance of each currency summed across all the exchanges
+	Holdings() map[string]float64
+	// Pairs returns the currency pairs traded on all the exchanges
+	Pairs() []pair.CurrencyPair
+	// Price returns the last price of the currency pair
+	Price(p pair.CurrencyPair) (float64, error)
+}
+
+// ExposureCheck holds the result of a pre-trade exposure check, all the values are in the quote
+// currency
+type ExposureCheck struct {
+	QuoteCurrency string
+	// Price the order is valued at, for market orders this is the last price
+	Price float64
+	// Exposure before the order
+	Exposure float64
+	// Value of the order
+	Value float64
+	Limit float64
+}
+
+type openOrder struct {
+	quote    string
+	notional float64
+}
+
+// ExposureLimiter caps the total notional exposure to each quote currency across all the
+// currency pairs & exchanges. The exposure to a quote currency is the value of the holdings of
+// every currency traded against it, plus the remaining value of the buy orders for those pairs
+// that were placed through the limiter and are still open.
+type ExposureLimiter struct {
+	source ExposureSource
+	limits map[string]float64
+	m      sync.Mutex
+	// Open buy orders keyed by exchange name & order ID
+	open map[string]openOrder
+}
+
+// NewExposureLimiter returns a limiter that caps the exposure to each quote currency in limits
+// (keyed by upper case currency code) at the given notional value, quote currencies without a
+// limit aren't capped.
+func NewExposureLimiter(source ExposureSource, limits map[string]float64) *ExposureLimiter {
+	return &ExposureLimiter{
+		source: source,
+		limits: limits,
+		open:   make(map[string]openOrder),
+	}
+}
+
+// Limit returns the exposure limit of the quote currency, false if it isn't limited
+func (l *ExposureLimiter) Limit(quote string) (float64, bool) {
+	limit, ok := l.limits[quote]
+	return limit, ok
+}
+
+// Exposure returns the current notional exposure to the quote currency
+func (l *ExposureLimiter) Exposure(quote string) (float64, error) {
+	holdings := l.source.Holdings()
+	exposure := 0.0
+	counted := make(map[string]bool)
+	for _, p := range l.source.Pairs() {
+		base := p.FirstCurrency.Upper().String()
+		if p.SecondCurrency.Upper().String() != quote || counted[base] {
+			continue
+		}
+		counted[base] = true
+		amount := holdings[base]
+		if amount == 0 {
+			continue
+		}
+		price, err := l.source.Price(p)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get %s price: %s", p.Display("/", true), err)
+		}
+		exposure += amount * price
+	}
+
+	l.m.Lock()
+	for _, o := range l.open {
+		if o.quote == quote {
+			exposure += o.notional
+		}
+	}
+	l.m.Unlock()
+	return exposure, nil
+}
+
+// CheckOrder checks that an order can be placed without exceeding the exposure limit of the quote
+// currency of the currency pair. Sell orders reduce the exposure so they're always allowed. If the
+// price is zero the order is treated as a market order and valued at the last price.
+// ErrExposureLimitExceeded is returned (along with the check details) if the limit would be exceeded.
+func (l *ExposureLimiter) CheckOrder(currencyPair pair.CurrencyPair, side exchange.OrderSide,
+	amount, price float64) (*ExposureCheck, error) {
+	quote := currencyPair.SecondCurrency.Upper().String()
+	limit, ok := l.Limit(quote)
+	if !ok || side != exchange.OrderSideBuy {
+		return nil, nil
+	}
+
+	var err error
+	if price == 0 {
+		price, err = l.source.Price(currencyPair)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s price: %s", currencyPair.Display("/", true), err)
+		}
+	}
+	exposure, err := l.Exposure(quote)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &ExposureCheck{
+		QuoteCurrency: quote,
+		Price:         price,
+		Exposure:      exposure,
+		Value:         amount * price,
+		Limit:         limit,
+	}
+	if check.Exposure+check.Value > limit {
+		return check, ErrExposureLimitExceeded
+	}
+	return check, nil
+}
+
+func openOrderKey(exchangeName, orderID string) string {
+	return exchangeName + "/" + orderID
+}
+
+// OrderPlaced adds the value of an open buy order to the exposure of its quote currency
+func (l *ExposureLimiter) OrderPlaced(exchangeName, orderID string, currencyPair pair.CurrencyPair,
+	side exchange.OrderSide, amount, price float64) {
+	quote := currencyPair.SecondCurrency.Upper().String()
+	if _, ok := l.limits[quote]; !ok || side != exchange.OrderSideBuy {
+		return
+	}
+	l.m.Lock()
+	l.open[openOrderKey(exchangeName, orderID)] = openOrder{quote: quote, notional: amount * price}
+	l.m.Unlock()
+}
+
+// OrderUpdated updates the remaining value of an open buy order, once the order is no longer active
+// it's removed from the exposure (the filled amount is part of the holdings).
+func (l *ExposureLimiter) OrderUpdated(exchangeName string, order *exchange.Order) {
+	key := openOrderKey(exchangeName, order.OrderID)
+	l.m.Lock()
+	defer l.m.Unlock()
+	o, ok := l.open[key]
+	if !ok {
+		return
+	}
+	if order.Status != exchange.OrderStatusActive {
+		delete(l.open, key)
+		return
+	}
+	o.notional = order.RemainingAmount * order.Rate
+	l.open[key] = o
+}
+
+// OrderClosed removes an order from the exposure
+func (l *ExposureLimiter) OrderClosed(exchangeName, orderID string) {
+	l.m.Lock()
+	delete(l.open, openOrderKey(exchangeName, orderID))
+	l.m.Unlock()
+}
+
+// ExposureGuard wraps an exchange and rejects the orders that would exceed the quote currency
+// exposure limits of an ExposureLimiter, the limiter is shared by all the exchanges so the
+// limits apply to the consolidated portfolio.
+type ExposureGuard struct {
+	exchange.Decorator
+	limiter *ExposureLimiter
+}
+
+// NewExposureGuard returns a wrapper that checks the orders placed on the exchange against the
+// exposure limits
+func NewExposureGuard(exch exchange.IBotExchangeEx, limiter *ExposureLimiter) *ExposureGuard {
+	return &ExposureGuard{exchange.Decorator{IBotExchangeEx: exch}, limiter}
+}
+
+// NewOrder submits a new order to the exchange, or returns ErrExposureLimitExceeded without
+// contacting the exchange if the order would exceed the exposure limit of its quote currency.
+func (g *ExposureGuard) NewOrder(symbol pair.CurrencyPair, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return g.NewOrderContext(context.Background(), symbol, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (g *ExposureGuard) NewOrderContext(ctx context.Context, symbol pair.CurrencyPair, amount,
+	price float64, side exchange.OrderSide, orderType exchange.OrderType,
+	opts ...exchange.OrderOptions) (string, error) {
+	check, err := g.limiter.CheckOrder(symbol, side, amount, price)
+	if err != nil {
+		return "", err
+	}
+	orderID, err := exchange.WithContext(g.IBotExchangeEx).NewOrderContext(ctx, symbol, amount, price,
+		side, orderType, opts...)
+	if err == nil && orderID != "" && check != nil {
+		g.limiter.OrderPlaced(g.GetName(), orderID, symbol, side, amount, check.Price)
+	}
+	return orderID, err
+}
+
+// CancelOrder cancels an active order on the exchange and removes it from the exposure
+func (g *ExposureGuard) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return g.CancelOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (g *ExposureGuard) CancelOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) error {
+	err := exchange.WithContext(g.IBotExchangeEx).CancelOrderContext(ctx, orderID, currencyPair)
+	if err == nil {
+		g.limiter.OrderClosed(g.GetName(), orderID)
+	}
+	return err
+}
+
+// GetOrder returns information about a previously placed order and updates its remaining value
+func (g *ExposureGuard) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	return g.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (g *ExposureGuard) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	order, err := exchange.WithContext(g.IBotExchangeEx).GetOrderContext(ctx, orderID, currencyPair)
+	if err == nil && order != nil {
+		g.limiter.OrderUpdated(g.GetName(), order)
+	}
+	return order, err
+}
@@ -0,0 +1,126 @@
+package risk
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+type mockExposureSource struct {
+	holdings map[string]float64
+	prices   map[string]float64
+}
+
+func (m *mockExposureSource) Holdings() map[string]float64 {
+	return m.holdings
+}
+
+func (m *mockExposureSource) Pairs() []pair.CurrencyPair {
+	return []pair.CurrencyPair{
+		pair.NewCurrencyPair("ETH", "BTC"),
+		pair.NewCurrencyPair("LTC", "BTC"),
+		pair.NewCurrencyPair("ETH", "BTC"),
+		pair.NewCurrencyPair("BTC", "USD"),
+	}
+}
+
+func (m *mockExposureSource) Price(p pair.CurrencyPair) (float64, error) {
+	price, ok := m.prices[p.Pair().String()]
+	if !ok {
+		return 0, errors.New("no ticker")
+	}
+	return price, nil
+}
+
+type mockOrderExchange struct {
+	exchange.IBotExchangeEx
+	orders int
+}
+
+func (m *mockOrderExchange) GetName() string {
+	return "Mock"
+}
+
+func (m *mockOrderExchange) NewOrder(symbol pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	m.orders++
+	return "1", nil
+}
+
+func (m *mockOrderExchange) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return nil
+}
+
+func TestExposureLimiter(t *testing.T) {
+	source := &mockExposureSource{
+		holdings: map[string]float64{"ETH": 10, "LTC": 100, "BTC": 3},
+		prices:   map[string]float64{"ETHBTC": 0.1, "LTCBTC": 0.01, "BTCUSD": 5000},
+	}
+	limiter := NewExposureLimiter(source, map[string]float64{"BTC": 5})
+
+	exposure, err := limiter.Exposure("BTC")
+	if err != nil || exposure != 2 {
+		t.Errorf("Test failed. Expected a BTC exposure of 2 but got %v %v", exposure, err)
+	}
+
+	check, err := limiter.CheckOrder(pair.NewCurrencyPair("ETH", "BTC"), exchange.OrderSideBuy, 20, 0.1)
+	if err != nil || check.Value != 2 || check.Limit != 5 {
+		t.Errorf("Test failed. Unexpected exposure check %+v %v", check, err)
+	}
+	check, err = limiter.CheckOrder(pair.NewCurrencyPair("LTC", "BTC"), exchange.OrderSideBuy, 400, 0)
+	if err != ErrExposureLimitExceeded {
+		t.Errorf("Test failed. Expected ErrExposureLimitExceeded but got %v", err)
+	}
+	if check == nil || check.Price != 0.01 || check.Exposure != 2 {
+		t.Errorf("Test failed. Expected exposure check details along with the error: %+v", check)
+	}
+	if _, err = limiter.CheckOrder(pair.NewCurrencyPair("LTC", "BTC"), exchange.OrderSideSell, 400, 0); err != nil {
+		t.Errorf("Test failed. Sell orders shouldn't be limited: %s", err)
+	}
+	if _, err = limiter.CheckOrder(pair.NewCurrencyPair("BTC", "USD"), exchange.OrderSideBuy, 10, 5000); err != nil {
+		t.Errorf("Test failed. Quote currencies without a limit shouldn't be limited: %s", err)
+	}
+
+	delete(source.prices, "LTCBTC")
+	if _, err = limiter.CheckOrder(pair.NewCurrencyPair("ETH", "BTC"), exchange.OrderSideBuy, 1, 0.1); err == nil {
+		t.Error("Test failed. Expected an error when the holdings can't be priced")
+	}
+}
+
+func TestExposureGuard(t *testing.T) {
+	source := &mockExposureSource{
+		holdings: map[string]float64{"ETH": 10},
+		prices:   map[string]float64{"ETHBTC": 0.1, "LTCBTC": 0.01},
+	}
+	limiter := NewExposureLimiter(source, map[string]float64{"BTC": 5})
+	mock := &mockOrderExchange{}
+	guard := NewExposureGuard(mock, limiter)
+	ethbtc := pair.NewCurrencyPair("ETH", "BTC")
+
+	if _, err := guard.NewOrder(ethbtc, 25, 0.1, exchange.OrderSideBuy, exchange.OrderTypeExchangeLimit); err != nil {
+		t.Fatalf("Test failed. NewOrder returned an error: %s", err)
+	}
+	if exposure, _ := limiter.Exposure("BTC"); exposure != 3.5 {
+		t.Errorf("Test failed. Expected the open order to be part of the exposure, got %v", exposure)
+	}
+	if _, err := guard.NewOrder(ethbtc, 20, 0.1, exchange.OrderSideBuy, exchange.OrderTypeExchangeLimit); err != ErrExposureLimitExceeded {
+		t.Errorf("Test failed. Expected ErrExposureLimitExceeded but got %v", err)
+	}
+	if mock.orders != 1 {
+		t.Errorf("Test failed. Expected 1 order to reach the exchange but got %d", mock.orders)
+	}
+
+	limiter.OrderUpdated("Mock", &exchange.Order{OrderID: "1", Status: exchange.OrderStatusActive,
+		RemainingAmount: 5, Rate: 0.1})
+	if exposure, _ := limiter.Exposure("BTC"); exposure != 1.5 {
+		t.Errorf("Test failed. Expected the remaining order value to be part of the exposure, got %v", exposure)
+	}
+	if err := guard.CancelOrder("1", ethbtc); err != nil {
+		t.Fatalf("Test failed. CancelOrder returned an error: %s", err)
+	}
+	if exposure, _ := limiter.Exposure("BTC"); exposure != 1 {
+		t.Errorf("Test failed. Expected the cancelled order to be removed from the exposure, got %v", exposure)
+	}
+}
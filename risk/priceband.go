@@ -0,0 +1,94 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/marketdata"
+)
+
+// ErrPriceOutsideBand is returned when the price of an order deviates from the reference price of
+// its currency pair by more than the max deviation
+var ErrPriceOutsideBand = errors.New("order price outside of the reference price band")
+
+// PriceBandCheck holds the result of a pre-trade price band check
+type PriceBandCheck struct {
+	Price     float64
+	Reference marketdata.ReferencePrice
+	// Deviation of the price from the reference price, as a fraction of the reference price
+	Deviation    float64
+	MaxDeviation float64
+}
+
+// CheckPriceBand checks that the price of an order is within maxDeviation (a fraction, e.g. 0.05
+// for 5%) of the reference price of the currency pair. ErrPriceOutsideBand is returned (along with
+// the check details) if it isn't.
+func CheckPriceBand(oracle marketdata.Oracle, currencyPair pair.CurrencyPair, price,
+	maxDeviation float64) (*PriceBandCheck, error) {
+	reference, err := oracle.ReferencePrice(currencyPair)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s reference price from %s: %s",
+			currencyPair.Display("/", true), oracle.Name(), err)
+	}
+	check := &PriceBandCheck{
+		Price:        price,
+		Reference:    reference,
+		Deviation:    math.Abs(price-reference.Price) / reference.Price,
+		MaxDeviation: maxDeviation,
+	}
+	if check.Deviation > maxDeviation {
+		return check, ErrPriceOutsideBand
+	}
+	return check, nil
+}
+
+// PriceBandGuard wraps an exchange and rejects the limit orders whose price deviates too far from
+// the reference price of an oracle, e.g. orders priced off a manipulated or broken ticker.
+type PriceBandGuard struct {
+	exchange.Decorator
+	oracle       marketdata.Oracle
+	maxDeviation float64
+	// Pairs the band applies to keyed by pair (upper case, delimited by "/"), all if empty
+	pairs map[string]bool
+}
+
+// NewPriceBandGuard returns a wrapper that checks the price of the limit orders of the given
+// currency pairs (all if none are given) against the price band. Orders are rejected if the oracle
+// has no reference price, since they can't be checked.
+func NewPriceBandGuard(exch exchange.IBotExchangeEx, oracle marketdata.Oracle, maxDeviation float64,
+	pairs ...pair.CurrencyPair) *PriceBandGuard {
+	g := &PriceBandGuard{
+		Decorator:    exchange.Decorator{IBotExchangeEx: exch},
+		oracle:       oracle,
+		maxDeviation: maxDeviation,
+		pairs:        make(map[string]bool),
+	}
+	for _, p := range pairs {
+		g.pairs[p.Display("/", true).String()] = true
+	}
+	return g
+}
+
+// NewOrder submits a new order to the exchange, or returns an error without contacting the
+// exchange if the order is priced outside the band. Market orders aren't checked.
+func (g *PriceBandGuard) NewOrder(symbol pair.CurrencyPair, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return g.NewOrderContext(context.Background(), symbol, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (g *PriceBandGuard) NewOrderContext(ctx context.Context, symbol pair.CurrencyPair, amount,
+	price float64, side exchange.OrderSide, orderType exchange.OrderType,
+	opts ...exchange.OrderOptions) (string, error) {
+	if price != 0 && (len(g.pairs) == 0 || g.pairs[symbol.Display("/", true).String()]) {
+		if _, err := CheckPriceBand(g.oracle, symbol, price, g.maxDeviation); err != nil {
+			return "", err
+		}
+	}
+	return exchange.WithContext(g.IBotExchangeEx).NewOrderContext(ctx, symbol, amount, price, side,
+		orderType, opts...)
+}
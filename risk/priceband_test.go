@@ -0,0 +1,72 @@
+package risk
+
+import (
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/marketdata"
+)
+
+type mockOracle struct {
+	prices map[string]float64
+}
+
+func (m *mockOracle) Name() string {
+	return "Mock"
+}
+
+func (m *mockOracle) ReferencePrice(p pair.CurrencyPair) (marketdata.ReferencePrice, error) {
+	price, ok := m.prices[p.Pair().String()]
+	if !ok {
+		return marketdata.ReferencePrice{}, marketdata.ErrNoReferencePrice
+	}
+	return marketdata.ReferencePrice{Pair: p, Price: price, Source: "Mock"}, nil
+}
+
+func TestCheckPriceBand(t *testing.T) {
+	oracle := &mockOracle{prices: map[string]float64{"BTCUSD": 6000}}
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+	check, err := CheckPriceBand(oracle, btcusd, 6250, 0.05)
+	if err != nil || check.Deviation > 0.0417 || check.Reference.Price != 6000 {
+		t.Errorf("Test failed. Unexpected check %+v %v", check, err)
+	}
+	if check, err = CheckPriceBand(oracle, btcusd, 5600, 0.05); err != ErrPriceOutsideBand || check == nil {
+		t.Errorf("Test failed. Expected ErrPriceOutsideBand, got %v", err)
+	}
+	if _, err = CheckPriceBand(oracle, pair.NewCurrencyPair("ETH", "USD"), 200, 0.05); err == nil {
+		t.Error("Test failed. Expected an error without a reference price")
+	}
+}
+
+func TestPriceBandGuard(t *testing.T) {
+	oracle := &mockOracle{prices: map[string]float64{"BTCUSD": 6000}}
+	mock := &mockOrderExchange{}
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+	guard := NewPriceBandGuard(mock, oracle, 0.05, btcusd)
+
+	if _, err := guard.NewOrder(btcusd, 1, 7000, exchange.OrderSideBuy, exchange.OrderTypeExchangeLimit); err != ErrPriceOutsideBand {
+		t.Errorf("Test failed. Expected ErrPriceOutsideBand, got %v", err)
+	}
+	if _, err := guard.NewOrder(btcusd, 1, 6100, exchange.OrderSideBuy, exchange.OrderTypeExchangeLimit); err != nil {
+		t.Errorf("Test failed. NewOrder error: %s", err)
+	}
+	// Market orders & the pairs outside the band aren't checked
+	if _, err := guard.NewOrder(btcusd, 1, 0, exchange.OrderSideBuy, exchange.OrderTypeExchangeLimit); err != nil {
+		t.Errorf("Test failed. NewOrder error: %s", err)
+	}
+	if _, err := guard.NewOrder(pair.NewCurrencyPair("ETH", "USD"), 1, 200, exchange.OrderSideBuy,
+		exchange.OrderTypeExchangeLimit); err != nil {
+		t.Errorf("Test failed. NewOrder error: %s", err)
+	}
+	if mock.orders != 3 {
+		t.Errorf("Test failed. Expected 3 orders to be placed, got %d", mock.orders)
+	}
+
+	// Without a reference price the orders can't be checked
+	guard = NewPriceBandGuard(mock, oracle, 0.05)
+	if _, err := guard.NewOrder(pair.NewCurrencyPair("ETH", "USD"), 1, 200, exchange.OrderSideBuy,
+		exchange.OrderTypeExchangeLimit); err == nil {
+		t.Error("Test failed. Expected the order without a reference price to be rejected")
+	}
+}
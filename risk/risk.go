@@ -0,0 +1,72 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+var (
+	// ErrMarginUnsupported is returned when a margin check is requested for an exchange that
+	// can't calculate margin requirements
+	ErrMarginUnsupported = errors.New("exchange doesn't support margin calculations")
+	// ErrInsufficientMargin is returned when an order exceeds the tradable balance on margin
+	ErrInsufficientMargin = errors.New("insufficient margin")
+)
+
+// MarginCalculator is implemented by exchanges that can calculate symbol level margin
+// requirements
+type MarginCalculator interface {
+	// GetTradableBalance returns the maximum amount of the currency pair that can currently be
+	// bought or sold on margin.
+	GetTradableBalance(currencyPair pair.CurrencyPair, side exchange.OrderSide) (float64, error)
+	// GetMarketAveragePrice estimates the average price a market order for the given amount of
+	// the currency pair would be filled at.
+	GetMarketAveragePrice(currencyPair pair.CurrencyPair, side exchange.OrderSide, amount float64) (float64, error)
+}
+
+// MarginCheck holds the result of a pre-trade margin check
+type MarginCheck struct {
+	TradableBalance float64
+	// Price the order is expected to be filled at, for market orders this is the estimated
+	// average execution price.
+	Price float64
+	// Value of the order in the quote currency
+	Value float64
+}
+
+// CheckMarginOrder checks that a margin order for the given amount can be placed on the exchange.
+// If the price is zero the order is treated as a market order and the average execution price is
+// estimated. ErrInsufficientMargin is returned (along with the check details) if the amount exceeds
+// the tradable balance.
+func CheckMarginOrder(exch exchange.IBotExchange, currencyPair pair.CurrencyPair, side exchange.OrderSide,
+	amount, price float64) (*MarginCheck, error) {
+	calc, ok := exch.(MarginCalculator)
+	if !ok {
+		return nil, ErrMarginUnsupported
+	}
+
+	tradable, err := calc.GetTradableBalance(currencyPair, side)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s tradable balance: %s", exch.GetName(), err)
+	}
+
+	if price == 0 {
+		price, err = calc.GetMarketAveragePrice(currencyPair, side, amount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s average price: %s", exch.GetName(), err)
+		}
+	}
+
+	check := &MarginCheck{
+		TradableBalance: tradable,
+		Price:           price,
+		Value:           amount * price,
+	}
+	if amount > tradable {
+		return check, ErrInsufficientMargin
+	}
+	return check, nil
+}
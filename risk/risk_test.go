@@ -0,0 +1,65 @@
+package risk
+
+import (
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+type mockExchange struct {
+	exchange.IBotExchange
+	tradable float64
+	avgPrice float64
+}
+
+func (m *mockExchange) GetName() string {
+	return "Mock"
+}
+
+func (m *mockExchange) GetTradableBalance(currencyPair pair.CurrencyPair, side exchange.OrderSide) (float64, error) {
+	return m.tradable, nil
+}
+
+func (m *mockExchange) GetMarketAveragePrice(currencyPair pair.CurrencyPair, side exchange.OrderSide,
+	amount float64) (float64, error) {
+	return m.avgPrice, nil
+}
+
+type mockNonMarginExchange struct {
+	exchange.IBotExchange
+}
+
+func TestCheckMarginOrder(t *testing.T) {
+	p := pair.NewCurrencyPair("BTC", "USD")
+	exch := &mockExchange{tradable: 2, avgPrice: 5000}
+
+	check, err := CheckMarginOrder(exch, p, exchange.OrderSideBuy, 1, 4000)
+	if err != nil {
+		t.Fatalf("Test failed. CheckMarginOrder returned an error: %s", err)
+	}
+	if check.TradableBalance != 2 || check.Price != 4000 || check.Value != 4000 {
+		t.Errorf("Test failed. Unexpected limit order margin check: %+v", check)
+	}
+
+	check, err = CheckMarginOrder(exch, p, exchange.OrderSideBuy, 1, 0)
+	if err != nil {
+		t.Fatalf("Test failed. CheckMarginOrder returned an error: %s", err)
+	}
+	if check.Price != 5000 || check.Value != 5000 {
+		t.Errorf("Test failed. Unexpected market order margin check: %+v", check)
+	}
+
+	check, err = CheckMarginOrder(exch, p, exchange.OrderSideSell, 3, 4000)
+	if err != ErrInsufficientMargin {
+		t.Errorf("Test failed. Expected ErrInsufficientMargin but got %v", err)
+	}
+	if check == nil || check.TradableBalance != 2 {
+		t.Errorf("Test failed. Expected margin check details along with the error: %+v", check)
+	}
+
+	_, err = CheckMarginOrder(&mockNonMarginExchange{}, p, exchange.OrderSideBuy, 1, 4000)
+	if err != ErrMarginUnsupported {
+		t.Errorf("Test failed. Expected ErrMarginUnsupported but got %v", err)
+	}
+}
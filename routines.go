@@ -1,17 +1,26 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/mattkanwisher/cryptofiend/currency"
 	"github.com/mattkanwisher/cryptofiend/currency/pair"
 	"github.com/mattkanwisher/cryptofiend/currency/symbol"
+	"github.com/mattkanwisher/cryptofiend/eventbus"
 	exchange "github.com/mattkanwisher/cryptofiend/exchanges"
 	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
 	"github.com/mattkanwisher/cryptofiend/exchanges/stats"
 	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+	"github.com/mattkanwisher/cryptofiend/marketdata"
+	"github.com/mattkanwisher/cryptofiend/portfolio"
+	"github.com/mattkanwisher/cryptofiend/soak"
+	"github.com/mattkanwisher/cryptofiend/trace"
 )
 
 func printCurrencyFormat(price float64) string {
@@ -60,6 +69,9 @@ func printSummary(result ticker.Price, p pair.CurrencyPair, assetType, exchangeN
 	}
 
 	stats.Add(exchangeName, p, assetType, result.Last, result.Volume)
+	if !trace.Enabled(traceTicker) {
+		return
+	}
 	if currency.IsFiatCurrency(p.SecondCurrency.String()) && p.SecondCurrency.String() != bot.config.FiatDisplayCurrency {
 		origCurrency := p.SecondCurrency.Upper().String()
 		log.Printf("%s %s %s: Last %s Ask %s Bid %s High %s Low %s Volume %.8f",
@@ -107,6 +119,9 @@ func printOrderbookSummary(result orderbook.Base, p pair.CurrencyPair, assetType
 			err)
 		return
 	}
+	if !trace.Enabled(traceOrderbook) {
+		return
+	}
 	bidsAmount, bidsValue := result.CalculateTotalBids()
 	asksAmount, asksValue := result.CalculateTotalAsks()
 
@@ -159,25 +174,291 @@ func printOrderbookSummary(result orderbook.Base, p pair.CurrencyPair, assetType
 
 }
 
+// relayWebsocketEvent publishes an event on the event bus, the websocket clients receive it
+// through their subscriptions.
 func relayWebsocketEvent(result interface{}, event, assetType, exchangeName string) {
-	evt := WebsocketEvent{
-		Data:      result,
-		Event:     event,
-		AssetType: assetType,
+	bot.eventBus.Publish(eventbus.Event{
+		Topic:     event,
 		Exchange:  exchangeName,
+		AssetType: assetType,
+		Data:      result,
+	})
+}
+
+// tickerEvent is the payload of ticker update events, the metrics of the latest orderbook for the
+// same currency pair are attached if available.
+type tickerEvent struct {
+	ticker.Price
+	Metrics *orderbook.Metrics `json:"Metrics,omitempty"`
+}
+
+// orderbookEvent is the payload of orderbook update events.
+type orderbookEvent struct {
+	orderbook.Base
+	Metrics *orderbook.Metrics `json:"Metrics,omitempty"`
+}
+
+// orderbookMetrics caches the metrics of the latest orderbooks so they can be attached to ticker
+// events without fetching the orderbook again.
+var orderbookMetrics = struct {
+	sync.RWMutex
+	m map[string]orderbook.Metrics
+}{m: make(map[string]orderbook.Metrics)}
+
+func orderbookMetricsKey(exchangeName string, p pair.CurrencyPair, assetType string) string {
+	return exchangeName + ":" + p.Display("/", true).String() + ":" + assetType
+}
+
+func newTickerEvent(result ticker.Price, exchangeName, assetType string) tickerEvent {
+	evt := tickerEvent{Price: result}
+	orderbookMetrics.RLock()
+	m, ok := orderbookMetrics.m[orderbookMetricsKey(exchangeName, result.Pair, assetType)]
+	orderbookMetrics.RUnlock()
+	if ok {
+		evt.Metrics = &m
+	}
+	return evt
+}
+
+// newOrderbookEvent creates the event published for an orderbook. The event bus delivers events
+// asynchronously, so the bids & asks are copied out of the pooled slices of the orderbook which
+// may be released as soon as this returns.
+func newOrderbookEvent(result orderbook.Base, exchangeName, assetType string) orderbookEvent {
+	evt := orderbookEvent{Base: result}
+	evt.Bids = append([]orderbook.Item(nil), result.Bids...)
+	evt.Asks = append([]orderbook.Item(nil), result.Asks...)
+	if m, ok := orderbook.CalculateMetrics(&result); ok {
+		evt.Metrics = &m
+		orderbookMetrics.Lock()
+		orderbookMetrics.m[orderbookMetricsKey(exchangeName, result.Pair, assetType)] = m
+		orderbookMetrics.Unlock()
+	}
+	return evt
+}
+
+// StatusMonitorRoutine periodically checks the platform status of the exchanges
+func StatusMonitorRoutine() {
+	log.Println("Starting status monitor routine")
+	for {
+		bot.statusMonitor.Poll()
+		time.Sleep(statusPollInterval)
+	}
+}
+
+// CurrencyMetadataRoutine periodically fetches the currency metadata published by the exchanges
+func CurrencyMetadataRoutine(providers map[string]exchange.CurrencyMetadataProvider) {
+	log.Println("Starting currency metadata routine")
+	for {
+		if err := bot.currencyMetadata.Refresh(providers); err != nil {
+			log.Println(err)
+		}
+		time.Sleep(currencyMetadataRefreshInterval)
+	}
+}
+
+// ListingsRoutine periodically fetches the markets listed on the exchanges to detect new
+// listings & delistings
+func ListingsRoutine(listers map[string]exchange.InstrumentLister) {
+	log.Println("Starting listings routine")
+	for {
+		if _, err := bot.listings.Refresh(listers, time.Now()); err != nil {
+			log.Println(err)
+		}
+		time.Sleep(listingsRefreshInterval)
+	}
+}
+
+// SoakTestRoutine samples the resource usage of the bot until the soak test is over, then writes
+// the soak test report and shuts down the bot
+func SoakTestRoutine() {
+	log.Println("Starting soak test routine")
+	stop := make(chan struct{})
+	go bot.soakMonitor.Run(soakSampleInterval, stop)
+	time.Sleep(bot.soakDuration)
+	close(stop)
+	bot.soakMonitor.Sample(time.Now())
+
+	report := bot.soakMonitor.Report(time.Now())
+	log.Println(report.String())
+	if err := writeSoakReport(&report); err != nil {
+		log.Printf("Unable to write soak test report. Error: %s", err)
 	}
-	err := BroadcastWebsocketMessage(evt)
+	Shutdown()
+}
+
+func writeSoakReport(report *soak.Report) error {
+	f, err := os.Create(bot.soakReportFile)
 	if err != nil {
-		log.Println(fmt.Errorf("Failed to broadcast websocket event. Error: %s",
-			err))
+		return err
+	}
+	if err = report.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WebhookListenerRoutine serves the webhook listener, the callbacks of each exchange are sent to
+// /webhooks/<exchange name>
+func WebhookListenerRoutine() {
+	log.Printf("Starting webhook listener on %s\n", bot.config.Webhooks.ListenAddress)
+	mux := http.NewServeMux()
+	mux.Handle("/webhooks/", bot.webhooks)
+	log.Fatal(http.ListenAndServe(bot.config.Webhooks.ListenAddress, mux))
+}
+
+// TradeBackfillRoutine imports the past trades of the account on the exchanges into the store, one
+// exchange at a time to keep the request rate low
+func TradeBackfillRoutine(exchanges map[string]exchange.IBotExchange) {
+	log.Println("Starting trade backfill routine")
+	for name, exch := range exchanges {
+		provider := exch.(exchange.TradeHistoryProvider)
+		n, err := bot.backfiller.Run(name, provider, exch.GetEnabledCurrencies(), nil)
+		if err != nil {
+			log.Printf("%s: Trade backfill failed after importing %d trades, it will resume on the next run. Error: %s",
+				name, n, err)
+			continue
+		}
+		log.Printf("%s: Trade backfill imported %d trades.\n", name, n)
+	}
+}
+
+// SweepRoutine runs the wallet sweep policies that are due
+func SweepRoutine() {
+	log.Println("Starting wallet sweep routine")
+	for {
+		if _, err := bot.sweeper.RunDue(time.Now()); err != nil {
+			log.Println(err)
+		}
+		time.Sleep(time.Minute)
 	}
 }
 
+// DegradedModeRoutine checks whether the authenticated API of the degraded exchanges has
+// recovered, sending the cancels queued while it was down
+func DegradedModeRoutine() {
+	log.Println("Starting degraded mode routine")
+	for {
+		for name, exch := range bot.degradableExchanges {
+			n, err := exch.Probe()
+			if n > 0 {
+				log.Printf("%s: Sent %d queued cancels.\n", name, n)
+			}
+			if err != nil {
+				log.Printf("%s: Authenticated API is still down. Error: %s\n", name, err)
+			}
+		}
+		time.Sleep(degradedProbeInterval)
+	}
+}
+
+// MaintenanceRoutine pauses trading on the exchanges during their scheduled maintenance windows,
+// the open orders are cancelled when a window starts & trading resumes once it ends
+func MaintenanceRoutine() {
+	log.Println("Starting maintenance window routine")
+	for {
+		for _, t := range bot.maintenance.Update() {
+			tradingSwitch := bot.tradingSwitches[t.Exchange]
+			if !t.Started {
+				log.Printf("%s: Maintenance window %s ended, resuming trading.\n", t.Exchange, t.Window.Name)
+				if tradingSwitch != nil {
+					tradingSwitch.SetMaintenance(false)
+				}
+				continue
+			}
+			log.Printf("%s: Maintenance window %s started (until %s), pausing trading.\n", t.Exchange,
+				t.Window.Name, t.Window.End.Format(time.RFC3339))
+			if tradingSwitch != nil {
+				tradingSwitch.SetMaintenance(true)
+			}
+			if n, err := cancelOpenOrders(t.Exchange); err != nil {
+				log.Printf("%s: Failed to cancel the open orders before maintenance. Error: %s", t.Exchange, err)
+			} else if n > 0 {
+				log.Printf("%s: Cancelled %d open orders before maintenance.\n", t.Exchange, n)
+			}
+		}
+		time.Sleep(maintenanceCheckInterval)
+	}
+}
+
+// cancelOpenOrders cancels the open orders in the enabled pairs of an exchange, returns the number
+// of orders cancelled
+func cancelOpenOrders(exchangeName string) (int, error) {
+	for _, e := range bot.exchanges {
+		if e.GetName() != exchangeName || !e.IsEnabled() {
+			continue
+		}
+		exch, ok := e.(exchange.IBotExchangeEx)
+		if !ok {
+			return 0, nil
+		}
+		orders, err := exch.GetOrders(exch.GetEnabledCurrencies())
+		if err != nil {
+			return 0, err
+		}
+		n := 0
+		for _, order := range orders {
+			if err = exch.CancelOrder(order.OrderID, order.CurrencyPair); err != nil {
+				return n, err
+			}
+			n++
+		}
+		return n, nil
+	}
+	return 0, nil
+}
+
+// exchangeAvailable returns false if the exchange is down, about to go down for maintenance or in
+// a scheduled maintenance window, in which case it shouldn't be polled.
+func exchangeAvailable(exchangeName string) bool {
+	if bot.maintenance != nil && bot.maintenance.InMaintenance(exchangeName) {
+		return false
+	}
+	return bot.statusMonitor == nil || bot.statusMonitor.IsAvailable(exchangeName)
+}
+
+// updateConditionalOrders checks the conditional orders for a currency pair against the latest
+// spot price
+func updateConditionalOrders(exchangeName string, p pair.CurrencyPair, assetType string, result ticker.Price) {
+	if bot.conditionalOrders == nil || assetType != ticker.Spot {
+		return
+	}
+	if err := bot.conditionalOrders.UpdatePrice(exchangeName, p, result.Last); err != nil {
+		log.Printf("%s: Failed to update conditional orders for %s. Error: %s", exchangeName,
+			p.Display("/", true), err)
+	}
+}
+
+// ConditionalOrderRoutine places the scheduled orders that are due, and reconciles the
+// conditional orders with the live exchange orders to notice fills
+func ConditionalOrderRoutine() {
+	log.Println("Starting conditional order routine")
+	for {
+		if bot.conditionalOrders != nil {
+			if err := bot.conditionalOrders.RunScheduled(); err != nil {
+				log.Printf("Failed to place scheduled orders. Error: %s", err)
+			}
+			if err := bot.conditionalOrders.Reconcile(); err != nil {
+				log.Printf("Failed to reconcile conditional orders. Error: %s", err)
+			}
+		}
+		time.Sleep(time.Second * 10)
+	}
+}
+
+// updateTicker updates the ticker of a pair, giving up after pollRequestTimeout so a hung
+// exchange doesn't stall the updates of the other exchanges
+func updateTicker(exch exchange.IBotExchange, p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pollRequestTimeout)
+	defer cancel()
+	return exchange.WithContext(exch).UpdateTickerContext(ctx, p, assetType)
+}
+
 func TickerUpdaterRoutine() {
 	log.Println("Starting ticker updater routine")
 	for {
 		for x := range bot.exchanges {
-			if bot.exchanges[x].IsEnabled() {
+			if bot.exchanges[x].IsEnabled() && exchangeAvailable(bot.exchanges[x].GetName()) {
 				exchangeName := bot.exchanges[x].GetName()
 				enabledCurrencies := bot.exchanges[x].GetEnabledCurrencies()
 
@@ -196,19 +477,21 @@ func TickerUpdaterRoutine() {
 
 					if len(assetTypes) > 1 {
 						for z := range assetTypes {
-							result, err = bot.exchanges[x].UpdateTicker(currency,
-								assetTypes[z])
+							result, err = updateTicker(bot.exchanges[x], currency, assetTypes[z])
 							printSummary(result, currency, assetTypes[z], exchangeName, err)
 							if err == nil {
-								relayWebsocketEvent(result, "ticker_update", assetTypes[z], exchangeName)
+								relayWebsocketEvent(newTickerEvent(result, exchangeName, assetTypes[z]), "ticker_update",
+									assetTypes[z], exchangeName)
+								updateConditionalOrders(exchangeName, currency, assetTypes[z], result)
 							}
 						}
 					} else {
-						result, err = bot.exchanges[x].UpdateTicker(currency,
-							assetTypes[0])
+						result, err = updateTicker(bot.exchanges[x], currency, assetTypes[0])
 						printSummary(result, currency, assetTypes[0], exchangeName, err)
 						if err == nil {
-							relayWebsocketEvent(result, "ticker_update", assetTypes[0], exchangeName)
+							relayWebsocketEvent(newTickerEvent(result, exchangeName, assetTypes[0]), "ticker_update",
+								assetTypes[0], exchangeName)
+							updateConditionalOrders(exchangeName, currency, assetTypes[0], result)
 						}
 					}
 				}
@@ -218,11 +501,29 @@ func TickerUpdaterRoutine() {
 	}
 }
 
+// recordOrderbook records a polled orderbook if orderbook recording is enabled
+func recordOrderbook(result *orderbook.Base, p pair.CurrencyPair, assetType, exchangeName string) {
+	if bot.orderbookRecorder == nil {
+		return
+	}
+	err := bot.orderbookRecorder.Record(exchangeName, p.Pair().String(), assetType, result, time.Now())
+	if err != nil {
+		log.Printf("Failed to record %s %s orderbook. Error: %s", exchangeName, p.Pair().String(), err)
+	}
+}
+
+// updateOrderbook updates the orderbook of a pair, giving up after pollRequestTimeout
+func updateOrderbook(exch exchange.IBotExchange, p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pollRequestTimeout)
+	defer cancel()
+	return exchange.WithContext(exch).UpdateOrderbookContext(ctx, p, assetType)
+}
+
 func OrderbookUpdaterRoutine() {
 	log.Println("Starting orderbook updater routine")
 	for {
 		for x := range bot.exchanges {
-			if bot.exchanges[x].IsEnabled() {
+			if bot.exchanges[x].IsEnabled() && exchangeAvailable(bot.exchanges[x].GetName()) {
 				if bot.exchanges[x].GetName() == "ANX" {
 					continue
 				}
@@ -244,20 +545,24 @@ func OrderbookUpdaterRoutine() {
 
 					if len(assetTypes) > 1 {
 						for z := range assetTypes {
-							result, err = bot.exchanges[x].UpdateOrderbook(currency,
-								assetTypes[z])
+							result, err = updateOrderbook(bot.exchanges[x], currency, assetTypes[z])
 							printOrderbookSummary(result, currency, assetTypes[z], exchangeName, err)
 							if err == nil {
-								relayWebsocketEvent(result, "orderbook_update", assetTypes[z], exchangeName)
+								relayWebsocketEvent(newOrderbookEvent(result, exchangeName, assetTypes[z]),
+									"orderbook_update", assetTypes[z], exchangeName)
+								recordOrderbook(&result, currency, assetTypes[z], exchangeName)
 							}
+							result.Release()
 						}
 					} else {
-						result, err = bot.exchanges[x].UpdateOrderbook(currency,
-							assetTypes[0])
+						result, err = updateOrderbook(bot.exchanges[x], currency, assetTypes[0])
 						printOrderbookSummary(result, currency, assetTypes[0], exchangeName, err)
 						if err == nil {
-							relayWebsocketEvent(result, "orderbook_update", assetTypes[0], exchangeName)
+							relayWebsocketEvent(newOrderbookEvent(result, exchangeName, assetTypes[0]),
+								"orderbook_update", assetTypes[0], exchangeName)
+							recordOrderbook(&result, currency, assetTypes[0], exchangeName)
 						}
+						result.Release()
 					}
 				}
 			}
@@ -265,3 +570,65 @@ func OrderbookUpdaterRoutine() {
 		time.Sleep(time.Second * 10)
 	}
 }
+
+// coinPrice returns the price of a coin in the fiat display currency, cryptocurrencies are
+// priced using the highest volume exchange that trades them against a fiat currency.
+func coinPrice(coin string) (float64, bool) {
+	fiat := bot.config.FiatDisplayCurrency
+	if coin == fiat {
+		return 1, true
+	}
+	if currency.IsFiatCurrency(coin) {
+		price, err := currency.ConvertCurrency(1, coin, fiat)
+		return price, err == nil
+	}
+
+	// Prices come from the market data provider so the fallback sources are used for stale
+	// exchange prices, fresh prices are preferred over stale ones & then by volume.
+	var best *stats.Item
+	var bestPrice marketdata.Price
+	for i := range stats.Items {
+		item := &stats.Items[i]
+		if item.Pair.FirstCurrency.String() != coin ||
+			!currency.IsFiatCurrency(item.Pair.SecondCurrency.String()) {
+			continue
+		}
+		price, err := bot.marketData.GetPrice(item.Exchange, item.Pair, item.AssetType)
+		if err != nil || price.Last == 0 {
+			continue
+		}
+		if best == nil || (bestPrice.Provenance.Stale && !price.Provenance.Stale) ||
+			(bestPrice.Provenance.Stale == price.Provenance.Stale && item.Volume > best.Volume) {
+			best, bestPrice = item, price
+		}
+	}
+	if best == nil {
+		return 0, false
+	}
+	price, err := currency.ConvertCurrency(bestPrice.Last, best.Pair.SecondCurrency.String(), fiat)
+	return price, err == nil
+}
+
+// PortfolioValuationRoutine periodically records the total value of the portfolio, each snapshot
+// is persisted as soon as it's taken so the history survives a crash.
+func PortfolioValuationRoutine() {
+	log.Println("Starting portfolio valuation routine")
+	for {
+		value, unpriced := bot.portfolio.Value(coinPrice)
+		bot.valuations.Record(portfolio.Valuation{
+			Time:     time.Now(),
+			Value:    value,
+			Unpriced: unpriced,
+		})
+		if len(unpriced) > 0 {
+			log.Printf("Portfolio valuation: unable to price %s in %s.\n", unpriced,
+				bot.config.FiatDisplayCurrency)
+		}
+		if bot.store != nil {
+			if err := bot.valuations.Save(bot.store); err != nil {
+				log.Printf("Unable to save portfolio valuations to storage. Error: %s", err)
+			}
+		}
+		time.Sleep(time.Minute * 10)
+	}
+}
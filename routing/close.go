@@ -0,0 +1,158 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/asset"
+	"github.com/mattkanwisher/cryptofiend/exchanges/audit"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+)
+
+// ErrNoPosition is returned when there's no position in the pair to close
+var ErrNoPosition = errors.New("no position to close")
+
+// Position is the net position of an account in a currency pair
+type Position struct {
+	Exchange string `json:"exchange"`
+	Pair     string `json:"pair"`
+	// Net amount of the base currency (or contracts), negative for short positions
+	Amount float64 `json:"amount"`
+	// Set if the position is made up of margin or derivative positions, otherwise the position is
+	// the spot inventory of the base currency
+	Margin    bool   `json:"margin"`
+	AssetType string `json:"assetType"`
+}
+
+// ClosePlan is the order that flattens a position
+type ClosePlan struct {
+	Position Position           `json:"position"`
+	Side     exchange.OrderSide `json:"side"`
+	// Amount of the order, rounded down to the decimal places allowed by the exchange so a dust
+	// amount may be left over
+	Amount float64 `json:"amount"`
+	// Limit price of the order, the price of the worst level the order is expected to fill against
+	// so it executes immediately
+	Price    float64  `json:"price"`
+	Decision Decision `json:"decision"`
+	// ID of the order placed, empty until the plan is executed
+	OrderID string `json:"orderId,omitempty"`
+}
+
+// GetPosition returns the net position of the account in the pair. If the exchange supports
+// margin positions and has any open in the pair they make up the net position, otherwise the
+// position is the available spot balance of the base currency.
+func GetPosition(exch exchange.IBotExchangeEx, positions exchange.PositionLister, p pair.CurrencyPair) (Position, error) {
+	pos := Position{Exchange: exch.GetName(), Pair: p.Display("/", true).String(), AssetType: asset.Spot}
+	if positions != nil {
+		open, err := positions.GetPositions()
+		if err != nil {
+			return pos, err
+		}
+		for _, o := range open {
+			if !o.CurrencyPair.Equal(p) {
+				continue
+			}
+			assetType := asset.Normalize(o.AssetType)
+			if pos.Margin && assetType != pos.AssetType {
+				return pos, fmt.Errorf("positions in %s have different asset types", pos.Pair)
+			}
+			pos.Margin = true
+			pos.AssetType = assetType
+			if o.Side == exchange.OrderSideSell {
+				pos.Amount -= o.Amount
+			} else {
+				pos.Amount += o.Amount
+			}
+		}
+		if pos.Margin {
+			return pos, nil
+		}
+	}
+
+	info, err := exch.GetExchangeAccountInfo()
+	if err != nil {
+		return pos, err
+	}
+	for _, c := range info.Currencies {
+		if strings.EqualFold(c.CurrencyName, p.FirstCurrency.String()) {
+			pos.Amount += c.Available
+		}
+	}
+	return pos, nil
+}
+
+// PlanClose returns the order flattening the position, priced against the orderbook so it's
+// filled immediately. The order must meet the limits of the exchange, returns ErrNoPosition if
+// the position is flat and ErrNoVenue if the orderbook is too thin to close the position.
+func PlanClose(exch exchange.IBotExchangeEx, p pair.CurrencyPair, pos Position, book orderbook.Base) (ClosePlan, error) {
+	plan := ClosePlan{Position: pos, Side: exchange.OrderSideSell}
+	if pos.Amount < 0 {
+		plan.Side = exchange.OrderSideBuy
+	}
+	limits := exch.GetLimits()
+	// The position can't be exceeded, even if the exchange rounds amounts to the nearest decimal
+	plan.Amount = exchange.RoundAmountWithin(limits, p, math.Abs(pos.Amount), math.Abs(pos.Amount))
+	if plan.Amount <= 0 {
+		return plan, ErrNoPosition
+	}
+
+	var err error
+	plan.Decision, err = SelectVenue(p, plan.Side, plan.Amount, 0,
+		[]Venue{{Exchange: pos.Exchange, Book: book}})
+	if err != nil {
+		return plan, err
+	}
+	remaining := plan.Amount
+	for _, level := range sortedLevels(book, plan.Side) {
+		plan.Price = level.Price
+		if remaining -= level.Amount; remaining <= 1e-12 {
+			break
+		}
+	}
+	// Round the price towards the other side of the book, so the order stays marketable
+	roundingSide := exchange.OrderSideBuy
+	if plan.Side == exchange.OrderSideBuy {
+		roundingSide = exchange.OrderSideSell
+	}
+	plan.Price = exchange.RoundPrice(limits, p, plan.Price, roundingSide)
+	if err = exchange.CheckOrderLimits(limits, p, plan.Amount, plan.Price); err != nil {
+		return plan, err
+	}
+	return plan, nil
+}
+
+// ClosePosition flattens the net position of the account in the pair (see GetPosition) with a
+// limit order priced to fill immediately against the current orderbook. The routing decision is
+// recorded in the audit log if it isn't nil. Returns the executed plan, or ErrNoPosition if
+// there's nothing to close.
+func ClosePosition(exch exchange.IBotExchangeEx, positions exchange.PositionLister, p pair.CurrencyPair,
+	auditLog *audit.Log) (ClosePlan, error) {
+	pos, err := GetPosition(exch, positions, p)
+	if err != nil {
+		return ClosePlan{Position: pos}, err
+	}
+	if pos.Amount == 0 {
+		return ClosePlan{Position: pos}, ErrNoPosition
+	}
+	book, err := exch.UpdateOrderbook(p, pos.AssetType)
+	if err != nil {
+		return ClosePlan{Position: pos}, err
+	}
+	plan, err := PlanClose(exch, p, pos, book)
+	if err != nil {
+		return plan, err
+	}
+	if auditLog != nil {
+		if err = auditLog.Record(plan.Decision.AuditEntry()); err != nil {
+			log.Printf("%s failed to record the routing decision in the audit log: %s\n", pos.Exchange, err)
+		}
+	}
+	plan.OrderID, err = exch.NewOrder(p, plan.Amount, plan.Price, plan.Side, exchange.OrderTypeExchangeLimit)
+	return plan, err
+}
@@ -0,0 +1,123 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+)
+
+type closeLimits struct {
+	exchange.DefaultExchangeLimits
+}
+
+func (closeLimits) GetAmountDecimalPlaces(p pair.CurrencyPair) int32 {
+	return 2
+}
+
+func (closeLimits) GetPriceDecimalPlaces(p pair.CurrencyPair) int32 {
+	return 0
+}
+
+func (closeLimits) GetMinAmount(p pair.CurrencyPair) float64 {
+	return 0.01
+}
+
+type mockCloseExchange struct {
+	exchange.IBotExchangeEx
+	balances []exchange.AccountCurrencyInfo
+	book     orderbook.Base
+	orders   []exchange.Order
+}
+
+func (m *mockCloseExchange) GetName() string {
+	return "Mock"
+}
+
+func (m *mockCloseExchange) GetLimits() exchange.ILimits {
+	return &closeLimits{}
+}
+
+func (m *mockCloseExchange) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return exchange.AccountInfo{ExchangeName: "Mock", Currencies: m.balances}, nil
+}
+
+func (m *mockCloseExchange) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return m.book, nil
+}
+
+func (m *mockCloseExchange) NewOrder(symbol pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	m.orders = append(m.orders, exchange.Order{CurrencyPair: symbol, Amount: amount, Rate: price, Side: side})
+	return "1", nil
+}
+
+type mockPositions []exchange.Position
+
+func (m mockPositions) GetPositions() ([]exchange.Position, error) {
+	return m, nil
+}
+
+func testBook() orderbook.Base {
+	return orderbook.Base{
+		Bids: []orderbook.Item{{Price: 99.5, Amount: 1}, {Price: 98.7, Amount: 5}},
+		Asks: []orderbook.Item{{Price: 100.5, Amount: 1}, {Price: 101.2, Amount: 5}},
+	}
+}
+
+func TestClosePositionSpot(t *testing.T) {
+	exch := &mockCloseExchange{
+		balances: []exchange.AccountCurrencyInfo{{CurrencyName: "btc", Available: 1.2345, Hold: 1}},
+		book:     testBook(),
+	}
+	plan, err := ClosePosition(exch, nil, btcusd, nil)
+	if err != nil {
+		t.Fatalf("Test failed. ClosePosition returned an error: %s", err)
+	}
+	if plan.Position.Margin || plan.Position.Amount != 1.2345 {
+		t.Errorf("Test failed. Expected the spot balance to be closed, got %+v", plan.Position)
+	}
+	// the order fills into the second bid level, the price is rounded down so it stays marketable
+	if len(exch.orders) != 1 || exch.orders[0].Side != exchange.OrderSideSell ||
+		exch.orders[0].Amount != 1.23 || exch.orders[0].Rate != 98 || plan.OrderID != "1" {
+		t.Errorf("Test failed. Unexpected order %+v", exch.orders)
+	}
+
+	exch.balances = nil
+	if _, err = ClosePosition(exch, nil, btcusd, nil); err != ErrNoPosition {
+		t.Errorf("Test failed. Expected ErrNoPosition, got %v", err)
+	}
+	exch.balances = []exchange.AccountCurrencyInfo{{CurrencyName: "BTC", Available: 0.005}}
+	if _, err = ClosePosition(exch, nil, btcusd, nil); err != ErrNoPosition {
+		t.Errorf("Test failed. Expected a dust balance to be ignored, got %v", err)
+	}
+}
+
+func TestClosePositionMargin(t *testing.T) {
+	exch := &mockCloseExchange{book: testBook()}
+	positions := mockPositions{
+		{CurrencyPair: btcusd, Side: exchange.OrderSideSell, Amount: 3},
+		{CurrencyPair: btcusd, Side: exchange.OrderSideBuy, Amount: 1},
+		{CurrencyPair: pair.NewCurrencyPair("ETH", "USD"), Side: exchange.OrderSideBuy, Amount: 10},
+	}
+	plan, err := ClosePosition(exch, positions, btcusd, nil)
+	if err != nil {
+		t.Fatalf("Test failed. ClosePosition returned an error: %s", err)
+	}
+	if !plan.Position.Margin || plan.Position.Amount != -2 {
+		t.Errorf("Test failed. Expected a net short position of 2, got %+v", plan.Position)
+	}
+	if len(exch.orders) != 1 || exch.orders[0].Side != exchange.OrderSideBuy ||
+		exch.orders[0].Amount != 2 || exch.orders[0].Rate != 102 {
+		t.Errorf("Test failed. Unexpected order %+v", exch.orders)
+	}
+	if plan.Decision.Exchange != "Mock" || plan.Decision.Rationale == "" {
+		t.Errorf("Test failed. Expected the routing decision, got %+v", plan.Decision)
+	}
+
+	exch.book = orderbook.Base{Asks: []orderbook.Item{{Price: 100, Amount: 1}}}
+	if _, err = ClosePosition(exch, positions, btcusd, nil); err != ErrNoVenue {
+		t.Errorf("Test failed. Expected ErrNoVenue for a thin orderbook, got %v", err)
+	}
+}
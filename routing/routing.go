@@ -0,0 +1,209 @@
+// Package routing compares the net cost of executing an order on each candidate exchange, so
+// that a venue is only selected once the taker fee, the expected slippage through the orderbook
+// and (for venues the funds have to be moved to) the transfer cost have been accounted for. It
+// also flattens positions with orders priced against the orderbook, see ClosePosition.
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/audit"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/transfers"
+)
+
+var (
+	// ErrNoVenue is returned when none of the venues can fill the order
+	ErrNoVenue = errors.New("no venue can fill the order")
+	// ErrUnprofitable is returned when the net price of the best venue is beyond the limit price
+	ErrUnprofitable = errors.New("net execution price is beyond the limit price")
+)
+
+// Venue is an exchange an order can be routed to
+type Venue struct {
+	Exchange string
+	// Current orderbook of the pair on the exchange
+	Book orderbook.Base
+	// Taker fee in percent, e.g. 0.2 for 0.2%
+	TakerFee float64
+	// Cost of moving the funds needed for the order to the exchange, nil if the funds are
+	// already there. The estimate must be for either the base or the quote currency of the pair.
+	Transfer *transfers.Estimate
+}
+
+// Cost is the expected cost of executing an order on a venue, amounts are denominated in the
+// quote currency of the pair
+type Cost struct {
+	Exchange string `json:"exchange"`
+	// Volume weighted average price of the levels the order is expected to fill against
+	AvgPrice float64 `json:"avgPrice"`
+	// Difference between the average price & the top of the book
+	Slippage     float64 `json:"slippage"`
+	Fee          float64 `json:"fee"`
+	TransferCost float64 `json:"transferCost"`
+	// Quote amount spent (buys) or received (sells) including the fee & transfer cost
+	Net float64 `json:"net"`
+	// Net price per unit of the base currency
+	NetPrice float64 `json:"netPrice"`
+	// Reason the venue can't be used, empty if it can
+	Rejected string `json:"rejected,omitempty"`
+}
+
+// Decision is the venue selected for an order, along with the costs of all the venues considered
+type Decision struct {
+	Time     time.Time          `json:"time"`
+	Pair     string             `json:"pair"`
+	Side     exchange.OrderSide `json:"side"`
+	Amount   float64            `json:"amount"`
+	Exchange string             `json:"exchange"`
+	Costs    []Cost             `json:"costs"`
+	// Human readable explanation of the decision
+	Rationale string `json:"rationale"`
+}
+
+// EstimateCost returns the expected cost of executing an order for amount of the base currency on
+// a venue
+func EstimateCost(p pair.CurrencyPair, side exchange.OrderSide, amount float64, v Venue) Cost {
+	cost := Cost{Exchange: v.Exchange}
+	levels := sortedLevels(v.Book, side)
+	if len(levels) == 0 {
+		cost.Rejected = "empty orderbook"
+		return cost
+	}
+
+	remaining, quote := amount, 0.0
+	for _, level := range levels {
+		if remaining <= 0 {
+			break
+		}
+		filled := level.Amount
+		if filled > remaining {
+			filled = remaining
+		}
+		quote += filled * level.Price
+		remaining -= filled
+	}
+	if remaining > 1e-12 {
+		cost.Rejected = fmt.Sprintf("orderbook depth is %.8f short", remaining)
+		return cost
+	}
+
+	cost.AvgPrice = quote / amount
+	cost.Slippage = cost.AvgPrice - levels[0].Price
+	if side == exchange.OrderSideSell {
+		cost.Slippage = -cost.Slippage
+	}
+	cost.Fee = quote * v.TakerFee / 100
+	if v.Transfer != nil {
+		switch strings.ToUpper(v.Transfer.Currency) {
+		case p.SecondCurrency.Upper().String():
+			cost.TransferCost = v.Transfer.WithdrawalFee
+		case p.FirstCurrency.Upper().String():
+			cost.TransferCost = v.Transfer.WithdrawalFee * cost.AvgPrice
+		default:
+			cost.Rejected = "transfer estimate is for " + v.Transfer.Currency
+			return cost
+		}
+	}
+
+	if side == exchange.OrderSideBuy {
+		cost.Net = quote + cost.Fee + cost.TransferCost
+	} else {
+		cost.Net = quote - cost.Fee - cost.TransferCost
+	}
+	cost.NetPrice = cost.Net / amount
+	return cost
+}
+
+// sortedLevels returns the levels a market order on the side fills against, best price first
+func sortedLevels(book orderbook.Base, side exchange.OrderSide) []orderbook.Item {
+	var levels []orderbook.Item
+	if side == exchange.OrderSideBuy {
+		levels = append(levels, book.Asks...)
+		sort.Slice(levels, func(i, j int) bool { return levels[i].Price < levels[j].Price })
+	} else {
+		levels = append(levels, book.Bids...)
+		sort.Slice(levels, func(i, j int) bool { return levels[i].Price > levels[j].Price })
+	}
+	return levels
+}
+
+// SelectVenue selects the venue with the best net price for an order. If limitPrice isn't zero
+// the decision is returned with ErrUnprofitable when the best net price is above the limit for a
+// buy (or below it for a sell).
+func SelectVenue(p pair.CurrencyPair, side exchange.OrderSide, amount, limitPrice float64, venues []Venue) (Decision, error) {
+	decision := Decision{Time: time.Now(), Pair: p.Pair().String(), Side: side, Amount: amount}
+	best := -1
+	for _, v := range venues {
+		cost := EstimateCost(p, side, amount, v)
+		decision.Costs = append(decision.Costs, cost)
+		if cost.Rejected != "" {
+			continue
+		}
+		i := len(decision.Costs) - 1
+		if best < 0 || better(side, cost.NetPrice, decision.Costs[best].NetPrice) {
+			best = i
+		}
+	}
+
+	if best < 0 {
+		decision.Rationale = rationale(decision, nil)
+		return decision, ErrNoVenue
+	}
+	chosen := decision.Costs[best]
+	if limitPrice != 0 && better(side, limitPrice, chosen.NetPrice) {
+		decision.Rationale = rationale(decision, &chosen) +
+			fmt.Sprintf(" Net price %.8f is beyond the limit price %.8f.", chosen.NetPrice, limitPrice)
+		return decision, ErrUnprofitable
+	}
+	decision.Exchange = chosen.Exchange
+	decision.Rationale = rationale(decision, &chosen)
+	return decision, nil
+}
+
+// better returns true if price a is better than price b for the side
+func better(side exchange.OrderSide, a, b float64) bool {
+	if side == exchange.OrderSideBuy {
+		return a < b
+	}
+	return a > b
+}
+
+func rationale(d Decision, chosen *Cost) string {
+	var parts []string
+	for _, c := range d.Costs {
+		if c.Rejected != "" {
+			parts = append(parts, fmt.Sprintf("%s rejected (%s)", c.Exchange, c.Rejected))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s net %.8f (avg %.8f, slippage %.8f, fee %.8f, transfer %.8f)",
+			c.Exchange, c.NetPrice, c.AvgPrice, c.Slippage, c.Fee, c.TransferCost))
+	}
+	summary := strings.Join(parts, "; ") + "."
+	if chosen == nil {
+		return "No venue can fill " + string(d.Side) + " " + d.Pair + ": " + summary
+	}
+	return fmt.Sprintf("Best net price for %s %s on %s: %s", d.Side, d.Pair, chosen.Exchange, summary)
+}
+
+// AuditEntry returns the audit log entry recording the decision, so the rationale is kept
+// alongside the orders placed on the selected venue
+func (d *Decision) AuditEntry() audit.Entry {
+	return audit.Entry{
+		Timestamp: d.Time,
+		Exchange:  d.Exchange,
+		Method:    "SelectVenue",
+		Params: map[string]interface{}{
+			"pair":   d.Pair,
+			"side":   d.Side,
+			"amount": d.Amount,
+		},
+		Response: d,
+	}
+}
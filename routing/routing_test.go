@@ -0,0 +1,67 @@
+package routing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/transfers"
+)
+
+var btcusd = pair.NewCurrencyPair("BTC", "USD")
+
+func testVenues() []Venue {
+	return []Venue{
+		// best top of book, but thin & expensive
+		{Exchange: "Cheap", TakerFee: 0.5, Book: orderbook.Base{
+			Asks: []orderbook.Item{{Price: 100, Amount: 0.5}, {Price: 110, Amount: 10}},
+		}},
+		{Exchange: "Deep", TakerFee: 0.1, Book: orderbook.Base{
+			Asks: []orderbook.Item{{Price: 102, Amount: 1}, {Price: 101, Amount: 1}},
+		}},
+		// cheapest, but the funds have to be moved there first
+		{Exchange: "Remote", TakerFee: 0, Book: orderbook.Base{
+			Asks: []orderbook.Item{{Price: 100, Amount: 5}},
+		}, Transfer: &transfers.Estimate{Currency: "USD", WithdrawalFee: 25}},
+		{Exchange: "Empty"},
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	cost := EstimateCost(btcusd, exchange.OrderSideBuy, 1, testVenues()[0])
+	// 0.5 @ 100 + 0.5 @ 110 = 105, plus a 0.5% fee
+	if cost.AvgPrice != 105 || cost.Slippage != 5 || math.Abs(cost.Net-105.525) > 1e-9 {
+		t.Errorf("Test failed. Unexpected cost %+v", cost)
+	}
+	cost = EstimateCost(btcusd, exchange.OrderSideBuy, 20, testVenues()[1])
+	if cost.Rejected == "" {
+		t.Error("Test failed. Expected a venue without enough depth to be rejected")
+	}
+}
+
+func TestSelectVenue(t *testing.T) {
+	decision, err := SelectVenue(btcusd, exchange.OrderSideBuy, 1, 0, testVenues())
+	if err != nil {
+		t.Fatalf("Test failed. SelectVenue returned an error: %s", err)
+	}
+	if decision.Exchange != "Deep" || len(decision.Costs) != 4 {
+		t.Errorf("Test failed. Expected Deep to be selected, got %+v", decision)
+	}
+	if decision.Rationale == "" || decision.AuditEntry().Response == nil {
+		t.Error("Test failed. Expected the decision rationale to be recorded")
+	}
+
+	// the transfer cost is spread over a larger order
+	if decision, _ = SelectVenue(btcusd, exchange.OrderSideBuy, 5, 0, testVenues()); decision.Exchange != "Remote" {
+		t.Errorf("Test failed. Expected Remote to be selected, got %s", decision.Rationale)
+	}
+
+	if _, err = SelectVenue(btcusd, exchange.OrderSideBuy, 1, 101, testVenues()); err != ErrUnprofitable {
+		t.Errorf("Test failed. Expected ErrUnprofitable but got %v", err)
+	}
+	if _, err = SelectVenue(btcusd, exchange.OrderSideSell, 1, 0, testVenues()); err != ErrNoVenue {
+		t.Errorf("Test failed. Expected ErrNoVenue but got %v", err)
+	}
+}
@@ -0,0 +1,263 @@
+// Package simulation models the execution of orders (fees, latency & slippage) for paper trading
+// and backtests, so that simulated results better match live execution.
+package simulation
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+)
+
+// Latency distributions
+const (
+	LatencyFixed   = "fixed"
+	LatencyUniform = "uniform"
+	LatencyNormal  = "normal"
+)
+
+// Slippage models
+const (
+	SlippageNone      = "none"
+	SlippageFixed     = "fixed"
+	SlippageOrderbook = "orderbook"
+)
+
+// LatencyModel samples the latency between submitting an order and it reaching the exchange.
+type LatencyModel interface {
+	Sample(r *rand.Rand) time.Duration
+}
+
+// FixedLatency is a constant latency.
+type FixedLatency time.Duration
+
+// Sample returns the fixed latency.
+func (l FixedLatency) Sample(r *rand.Rand) time.Duration {
+	return time.Duration(l)
+}
+
+// UniformLatency is a latency uniformly distributed between Min and Max.
+type UniformLatency struct {
+	Min, Max time.Duration
+}
+
+// Sample returns a random latency between Min and Max.
+func (l UniformLatency) Sample(r *rand.Rand) time.Duration {
+	if l.Max <= l.Min {
+		return l.Min
+	}
+	return l.Min + time.Duration(r.Int63n(int64(l.Max-l.Min)))
+}
+
+// NormalLatency is a normally distributed latency, negative samples are clamped to zero.
+type NormalLatency struct {
+	Mean, StdDev time.Duration
+}
+
+// Sample returns a normally distributed random latency.
+func (l NormalLatency) Sample(r *rand.Rand) time.Duration {
+	d := l.Mean + time.Duration(r.NormFloat64()*float64(l.StdDev))
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// SlippageModel calculates the average price a taker order is filled at, given the reference
+// price of the order and (optionally) the orderbook at the time of the order.
+type SlippageModel interface {
+	FillPrice(side exchange.OrderSide, price, amount float64, book *orderbook.Base) float64
+}
+
+// NoSlippage fills orders at their reference price.
+type NoSlippage struct{}
+
+// FillPrice returns the reference price.
+func (NoSlippage) FillPrice(side exchange.OrderSide, price, amount float64, book *orderbook.Base) float64 {
+	return price
+}
+
+// FixedSlippage fills orders at a constant number of basis points worse than their reference
+// price.
+type FixedSlippage struct {
+	BPS float64
+}
+
+// FillPrice returns the reference price moved against the order by the fixed slippage.
+func (s FixedSlippage) FillPrice(side exchange.OrderSide, price, amount float64, book *orderbook.Base) float64 {
+	if side == exchange.OrderSideBuy {
+		return price * (1 + s.BPS/10000)
+	}
+	return price * (1 - s.BPS/10000)
+}
+
+// OrderbookSlippage fills orders by walking the opposite side of the orderbook, any amount that
+// exceeds the depth of the book is filled at the price of the last level. Orders are filled at
+// their reference price if there's no orderbook.
+type OrderbookSlippage struct{}
+
+// FillPrice returns the volume weighted average price of the orderbook levels the order would
+// consume.
+func (OrderbookSlippage) FillPrice(side exchange.OrderSide, price, amount float64, book *orderbook.Base) float64 {
+	if book == nil || amount <= 0 {
+		return price
+	}
+	levels := book.Asks
+	if side == exchange.OrderSideSell {
+		levels = book.Bids
+	}
+	if len(levels) == 0 {
+		return price
+	}
+
+	var filled, cost float64
+	for i := range levels {
+		take := levels[i].Amount
+		if filled+take > amount {
+			take = amount - filled
+		}
+		filled += take
+		cost += take * levels[i].Price
+		if filled >= amount {
+			break
+		}
+	}
+	if filled < amount {
+		cost += (amount - filled) * levels[len(levels)-1].Price
+	}
+	return cost / amount
+}
+
+// Fill is the simulated execution of an order.
+type Fill struct {
+	Price  float64
+	Amount float64
+	// Fee paid in the quote currency
+	Fee float64
+	// Delay between submitting the order and it being filled
+	Delay time.Duration
+}
+
+// ExecutionModel simulates the execution of orders on an exchange.
+type ExecutionModel struct {
+	MakerFee float64
+	TakerFee float64
+	Latency  LatencyModel
+	Slippage SlippageModel
+}
+
+// NewExecutionModel creates an execution model from the config, makerFee & takerFee are used if
+// the config doesn't override the fees.
+func NewExecutionModel(cfg config.SimulationExchangeConfig, makerFee, takerFee float64) (*ExecutionModel, error) {
+	m := &ExecutionModel{
+		MakerFee: makerFee,
+		TakerFee: takerFee,
+	}
+	if cfg.MakerFee != nil {
+		m.MakerFee = *cfg.MakerFee
+	}
+	if cfg.TakerFee != nil {
+		m.TakerFee = *cfg.TakerFee
+	}
+
+	ms := func(v int64) time.Duration { return time.Duration(v) * time.Millisecond }
+	switch cfg.Latency.Distribution {
+	case "", LatencyFixed:
+		m.Latency = FixedLatency(ms(cfg.Latency.Mean))
+	case LatencyUniform:
+		if cfg.Latency.Max < cfg.Latency.Min {
+			return nil, fmt.Errorf("%s: max latency is less than min latency", cfg.Name)
+		}
+		m.Latency = UniformLatency{Min: ms(cfg.Latency.Min), Max: ms(cfg.Latency.Max)}
+	case LatencyNormal:
+		m.Latency = NormalLatency{Mean: ms(cfg.Latency.Mean), StdDev: ms(cfg.Latency.StdDev)}
+	default:
+		return nil, fmt.Errorf("%s: unsupported latency distribution %s", cfg.Name,
+			cfg.Latency.Distribution)
+	}
+
+	switch cfg.Slippage.Model {
+	case "", SlippageNone:
+		m.Slippage = NoSlippage{}
+	case SlippageFixed:
+		m.Slippage = FixedSlippage{BPS: cfg.Slippage.BPS}
+	case SlippageOrderbook:
+		m.Slippage = OrderbookSlippage{}
+	default:
+		return nil, fmt.Errorf("%s: unsupported slippage model %s", cfg.Name, cfg.Slippage.Model)
+	}
+	return m, nil
+}
+
+// Simulate simulates filling an order at the given reference price, maker orders are filled at
+// their price and pay the maker fee, taker orders are subject to slippage and pay the taker fee.
+// The orderbook is only used by the orderbook slippage model and may be nil.
+func (m *ExecutionModel) Simulate(r *rand.Rand, side exchange.OrderSide, price, amount float64,
+	maker bool, book *orderbook.Base) Fill {
+	fill := Fill{Price: price, Amount: amount}
+	fee := m.MakerFee
+	if !maker {
+		fill.Price = m.Slippage.FillPrice(side, price, amount, book)
+		fee = m.TakerFee
+	}
+	fill.Fee = fill.Price * amount * fee
+	fill.Delay = m.Latency.Sample(r)
+	return fill
+}
+
+// Simulator holds the execution models of all the simulated exchanges.
+type Simulator struct {
+	m      sync.Mutex
+	rand   *rand.Rand
+	models map[string]*ExecutionModel
+}
+
+// NewSimulator creates a simulator from the config, exchangeFees returns the default maker &
+// taker fees of an exchange (e.g. from the exchange wrapper), it may be nil.
+func NewSimulator(cfg config.SimulationConfig,
+	exchangeFees func(exchangeName string) (makerFee, takerFee float64)) (*Simulator, error) {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	s := &Simulator{
+		rand:   rand.New(rand.NewSource(seed)),
+		models: make(map[string]*ExecutionModel),
+	}
+	for _, exchCfg := range cfg.Exchanges {
+		var makerFee, takerFee float64
+		if exchangeFees != nil {
+			makerFee, takerFee = exchangeFees(exchCfg.Name)
+		}
+		model, err := NewExecutionModel(exchCfg, makerFee, takerFee)
+		if err != nil {
+			return nil, err
+		}
+		s.models[exchCfg.Name] = model
+	}
+	return s, nil
+}
+
+// SetModel sets the execution model of an exchange.
+func (s *Simulator) SetModel(exchangeName string, model *ExecutionModel) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.models[exchangeName] = model
+}
+
+// Simulate simulates filling an order on an exchange, orders on exchanges without an execution
+// model are filled at their price with no fees, latency or slippage.
+func (s *Simulator) Simulate(exchangeName string, side exchange.OrderSide, price, amount float64,
+	maker bool, book *orderbook.Base) Fill {
+	s.m.Lock()
+	defer s.m.Unlock()
+	model, ok := s.models[exchangeName]
+	if !ok {
+		return Fill{Price: price, Amount: amount}
+	}
+	return model.Simulate(s.rand, side, price, amount, maker, book)
+}
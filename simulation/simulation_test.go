@@ -0,0 +1,142 @@
+package simulation
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+)
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestSlippageModels(t *testing.T) {
+	book := &orderbook.Base{
+		Bids: []orderbook.Item{{Price: 99, Amount: 1}, {Price: 98, Amount: 1}},
+		Asks: []orderbook.Item{{Price: 101, Amount: 1}, {Price: 102, Amount: 1}},
+	}
+
+	tests := []struct {
+		model    SlippageModel
+		side     exchange.OrderSide
+		amount   float64
+		book     *orderbook.Base
+		expected float64
+	}{
+		{NoSlippage{}, exchange.OrderSideBuy, 1, book, 100},
+		{FixedSlippage{BPS: 10}, exchange.OrderSideBuy, 1, nil, 100.1},
+		{FixedSlippage{BPS: 10}, exchange.OrderSideSell, 1, nil, 99.9},
+		{OrderbookSlippage{}, exchange.OrderSideBuy, 0.5, book, 101},
+		{OrderbookSlippage{}, exchange.OrderSideBuy, 2, book, 101.5},
+		{OrderbookSlippage{}, exchange.OrderSideSell, 2, book, 98.5},
+		// the amount beyond the depth of the book is filled at the last level
+		{OrderbookSlippage{}, exchange.OrderSideBuy, 4, book, 101.75},
+		{OrderbookSlippage{}, exchange.OrderSideBuy, 1, nil, 100},
+	}
+	for i, test := range tests {
+		price := test.model.FillPrice(test.side, 100, test.amount, test.book)
+		if math.Abs(price-test.expected) > 1e-9 {
+			t.Errorf("Test failed. Test %d expected fill price %f, got %f", i, test.expected, price)
+		}
+	}
+}
+
+func TestLatencyModels(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	if d := FixedLatency(50 * time.Millisecond).Sample(r); d != 50*time.Millisecond {
+		t.Errorf("Test failed. Expected fixed latency 50ms, got %s", d)
+	}
+	uniform := UniformLatency{Min: 10 * time.Millisecond, Max: 20 * time.Millisecond}
+	normal := NormalLatency{Mean: 5 * time.Millisecond, StdDev: 50 * time.Millisecond}
+	for i := 0; i < 1000; i++ {
+		if d := uniform.Sample(r); d < uniform.Min || d >= uniform.Max {
+			t.Fatalf("Test failed. Uniform latency %s out of range", d)
+		}
+		if d := normal.Sample(r); d < 0 {
+			t.Fatalf("Test failed. Normal latency %s is negative", d)
+		}
+	}
+}
+
+func TestNewExecutionModel(t *testing.T) {
+	cfg := config.SimulationExchangeConfig{
+		Name:     "Binance",
+		TakerFee: floatPtr(0.002),
+		Latency:  config.LatencyConfig{Distribution: LatencyUniform, Min: 10, Max: 20},
+		Slippage: config.SlippageConfig{Model: SlippageFixed, BPS: 5},
+	}
+	m, err := NewExecutionModel(cfg, 0.001, 0.001)
+	if err != nil {
+		t.Fatalf("Test failed. NewExecutionModel error: %s", err)
+	}
+	if m.MakerFee != 0.001 || m.TakerFee != 0.002 {
+		t.Errorf("Test failed. Unexpected fees %f/%f", m.MakerFee, m.TakerFee)
+	}
+	if _, ok := m.Latency.(UniformLatency); !ok {
+		t.Errorf("Test failed. Unexpected latency model %T", m.Latency)
+	}
+	if _, ok := m.Slippage.(FixedSlippage); !ok {
+		t.Errorf("Test failed. Unexpected slippage model %T", m.Slippage)
+	}
+
+	cfg.Latency.Distribution = "poisson"
+	if _, err := NewExecutionModel(cfg, 0, 0); err == nil {
+		t.Error("Test failed. Expected error for unsupported latency distribution")
+	}
+	cfg.Latency = config.LatencyConfig{Distribution: LatencyUniform, Min: 20, Max: 10}
+	if _, err := NewExecutionModel(cfg, 0, 0); err == nil {
+		t.Error("Test failed. Expected error for invalid uniform latency")
+	}
+	cfg.Latency = config.LatencyConfig{}
+	cfg.Slippage.Model = "random"
+	if _, err := NewExecutionModel(cfg, 0, 0); err == nil {
+		t.Error("Test failed. Expected error for unsupported slippage model")
+	}
+}
+
+func TestSimulator(t *testing.T) {
+	cfg := config.SimulationConfig{
+		Seed: 42,
+		Exchanges: []config.SimulationExchangeConfig{{
+			Name:     "Bitfinex",
+			Latency:  config.LatencyConfig{Distribution: LatencyNormal, Mean: 100, StdDev: 20},
+			Slippage: config.SlippageConfig{Model: SlippageFixed, BPS: 10},
+		}},
+	}
+	fees := func(exchangeName string) (float64, float64) {
+		return 0.001, 0.002
+	}
+	s, err := NewSimulator(cfg, fees)
+	if err != nil {
+		t.Fatalf("Test failed. NewSimulator error: %s", err)
+	}
+
+	fill := s.Simulate("Bitfinex", exchange.OrderSideBuy, 100, 2, false, nil)
+	if math.Abs(fill.Price-100.1) > 1e-9 || math.Abs(fill.Fee-100.1*2*0.002) > 1e-9 {
+		t.Errorf("Test failed. Unexpected taker fill %+v", fill)
+	}
+	fill = s.Simulate("Bitfinex", exchange.OrderSideBuy, 100, 2, true, nil)
+	if fill.Price != 100 || math.Abs(fill.Fee-0.2) > 1e-9 {
+		t.Errorf("Test failed. Unexpected maker fill %+v", fill)
+	}
+	fill = s.Simulate("Kraken", exchange.OrderSideSell, 100, 2, false, nil)
+	if fill != (Fill{Price: 100, Amount: 2}) {
+		t.Errorf("Test failed. Unexpected fill without a model %+v", fill)
+	}
+
+	// the same seed should produce the same latencies
+	s2, _ := NewSimulator(cfg, fees)
+	s, _ = NewSimulator(cfg, fees)
+	for i := 0; i < 10; i++ {
+		a := s.Simulate("Bitfinex", exchange.OrderSideBuy, 100, 1, false, nil)
+		b := s2.Simulate("Bitfinex", exchange.OrderSideBuy, 100, 1, false, nil)
+		if a.Delay != b.Delay {
+			t.Fatalf("Test failed. Expected reproducible latencies, got %s and %s", a.Delay, b.Delay)
+		}
+	}
+}
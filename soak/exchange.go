@@ -0,0 +1,80 @@
+package soak
+
+import (
+	"context"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+// MonitoredExchange wraps an exchange and records the requests made through it in a Monitor.
+type MonitoredExchange struct {
+	exchange.Decorator
+	Monitor *Monitor
+}
+
+// NewMonitoredExchange returns a wrapper that records the requests made to the given exchange.
+func NewMonitoredExchange(exch exchange.IBotExchangeEx, monitor *Monitor) *MonitoredExchange {
+	return &MonitoredExchange{exchange.Decorator{IBotExchangeEx: exch}, monitor}
+}
+
+// UpdateTicker updates the ticker of a currency pair and records the request.
+func (m *MonitoredExchange) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return m.UpdateTickerContext(context.Background(), p, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (m *MonitoredExchange) UpdateTickerContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
+	price, err := exchange.WithContext(m.IBotExchangeEx).UpdateTickerContext(ctx, p, assetType)
+	m.record(err)
+	return price, err
+}
+
+// UpdateOrderbook updates the orderbook of a currency pair and records the request.
+func (m *MonitoredExchange) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return m.UpdateOrderbookContext(context.Background(), p, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (m *MonitoredExchange) UpdateOrderbookContext(ctx context.Context, p pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
+	book, err := exchange.WithContext(m.IBotExchangeEx).UpdateOrderbookContext(ctx, p, assetType)
+	m.record(err)
+	return book, err
+}
+
+// GetExchangeAccountInfo retrieves the account balances and records the request.
+func (m *MonitoredExchange) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return m.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (m *MonitoredExchange) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
+	info, err := exchange.WithContext(m.IBotExchangeEx).GetExchangeAccountInfoContext(ctx)
+	m.record(err)
+	return info, err
+}
+
+// GetOrders returns the active orders and records the request.
+func (m *MonitoredExchange) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return m.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (m *MonitoredExchange) GetOrdersContext(ctx context.Context,
+	pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	orders, err := exchange.WithContext(m.IBotExchangeEx).GetOrdersContext(ctx, pairs)
+	m.record(err)
+	return orders, err
+}
+
+// record records a request, requests blocked by the read-only wrapper never reached the exchange
+func (m *MonitoredExchange) record(err error) {
+	if err != exchange.ErrReadOnly {
+		m.Monitor.RecordRequest(m.GetName(), err)
+	}
+}
@@ -0,0 +1,213 @@
+// Package soak monitors the bot during long-running soak tests, in which the enabled exchanges
+// are polled in read-only mode for hours. The monitor periodically samples the goroutine count &
+// memory usage, and counts the requests, errors & websocket reconnects of each exchange, so
+// leaks & instability show up in the report produced at the end of the test.
+package soak
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Sample is a snapshot of the runtime resource usage
+type Sample struct {
+	Time       time.Time `json:"time"`
+	Goroutines int       `json:"goroutines"`
+	// Bytes of allocated heap objects
+	HeapAlloc   uint64 `json:"heapAlloc"`
+	HeapObjects uint64 `json:"heapObjects"`
+	NumGC       uint32 `json:"numGC"`
+}
+
+// ExchangeStats are the request counts of an exchange over the test
+type ExchangeStats struct {
+	Exchange   string  `json:"exchange"`
+	Requests   int64   `json:"requests"`
+	Errors     int64   `json:"errors"`
+	ErrorRate  float64 `json:"errorRate"`
+	Reconnects int64   `json:"reconnects"`
+	// Number of times each error message was returned
+	ErrorCounts map[string]int64 `json:"errorCounts,omitempty"`
+}
+
+// Report summarizes a soak test
+type Report struct {
+	Start    time.Time       `json:"start"`
+	End      time.Time       `json:"end"`
+	Duration string          `json:"duration"`
+	Samples  []Sample        `json:"samples"`
+	Stats    []ExchangeStats `json:"exchanges"`
+	// Difference between the last & first samples
+	GoroutineGrowth int    `json:"goroutineGrowth"`
+	HeapGrowth      int64  `json:"heapGrowth"`
+	MaxGoroutines   int    `json:"maxGoroutines"`
+	MaxHeapAlloc    uint64 `json:"maxHeapAlloc"`
+}
+
+// Write writes the report as indented JSON
+func (r *Report) Write(w io.Writer) error {
+	data, err := json.MarshalIndent(r, "", " ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+type exchangeCounters struct {
+	requests    int64
+	errors      int64
+	errorCounts map[string]int64
+	// Returns the number of websocket connections made by the exchange
+	connections func() int64
+}
+
+// Monitor collects the samples & exchange stats of a soak test, it's safe for concurrent use
+type Monitor struct {
+	mtx       sync.Mutex
+	start     time.Time
+	samples   []Sample
+	exchanges map[string]*exchangeCounters
+	// Distinct error messages recorded per exchange, further errors are counted as "other" so a
+	// flood of unique messages (e.g. containing nonces) can't grow the report without bound
+	MaxErrorMessages int
+}
+
+// NewMonitor returns a monitor for a soak test starting now
+func NewMonitor() *Monitor {
+	return &Monitor{
+		start:            time.Now(),
+		exchanges:        make(map[string]*exchangeCounters),
+		MaxErrorMessages: 20,
+	}
+}
+
+func (m *Monitor) counters(exchangeName string) *exchangeCounters {
+	c, ok := m.exchanges[exchangeName]
+	if !ok {
+		c = &exchangeCounters{errorCounts: make(map[string]int64)}
+		m.exchanges[exchangeName] = c
+	}
+	return c
+}
+
+// RecordRequest records a request sent to an exchange, err is the error it returned (if any)
+func (m *Monitor) RecordRequest(exchangeName string, err error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	c := m.counters(exchangeName)
+	c.requests++
+	if err == nil {
+		return
+	}
+	c.errors++
+	msg := err.Error()
+	if _, ok := c.errorCounts[msg]; !ok && len(c.errorCounts) >= m.MaxErrorMessages {
+		msg = "other"
+	}
+	c.errorCounts[msg]++
+}
+
+// TrackConnections sets the function returning the number of websocket connections an exchange
+// has made, every connection after the first is reported as a reconnect
+func (m *Monitor) TrackConnections(exchangeName string, connections func() int64) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.counters(exchangeName).connections = connections
+}
+
+// Sample records the current goroutine count & memory usage
+func (m *Monitor) Sample(t time.Time) Sample {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	s := Sample{
+		Time:        t,
+		Goroutines:  runtime.NumGoroutine(),
+		HeapAlloc:   mem.HeapAlloc,
+		HeapObjects: mem.HeapObjects,
+		NumGC:       mem.NumGC,
+	}
+	m.mtx.Lock()
+	m.samples = append(m.samples, s)
+	m.mtx.Unlock()
+	return s
+}
+
+// Run samples the resource usage every interval until stop is closed
+func (m *Monitor) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	m.Sample(time.Now())
+	for {
+		select {
+		case t := <-ticker.C:
+			m.Sample(t)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Report returns the report of the test up to end
+func (m *Monitor) Report(end time.Time) Report {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	r := Report{
+		Start:    m.start,
+		End:      end,
+		Duration: end.Sub(m.start).Round(time.Second).String(),
+		Samples:  append([]Sample{}, m.samples...),
+		Stats:    []ExchangeStats{},
+	}
+	for _, s := range m.samples {
+		if s.Goroutines > r.MaxGoroutines {
+			r.MaxGoroutines = s.Goroutines
+		}
+		if s.HeapAlloc > r.MaxHeapAlloc {
+			r.MaxHeapAlloc = s.HeapAlloc
+		}
+	}
+	if n := len(m.samples); n > 1 {
+		first, last := m.samples[0], m.samples[n-1]
+		r.GoroutineGrowth = last.Goroutines - first.Goroutines
+		r.HeapGrowth = int64(last.HeapAlloc) - int64(first.HeapAlloc)
+	}
+
+	for name, c := range m.exchanges {
+		stats := ExchangeStats{Exchange: name, Requests: c.requests, Errors: c.errors}
+		if c.requests > 0 {
+			stats.ErrorRate = float64(c.errors) / float64(c.requests)
+		}
+		if c.connections != nil {
+			if n := c.connections(); n > 1 {
+				stats.Reconnects = n - 1
+			}
+		}
+		if len(c.errorCounts) > 0 {
+			stats.ErrorCounts = make(map[string]int64, len(c.errorCounts))
+			for msg, n := range c.errorCounts {
+				stats.ErrorCounts[msg] = n
+			}
+		}
+		r.Stats = append(r.Stats, stats)
+	}
+	sort.Slice(r.Stats, func(i, j int) bool { return r.Stats[i].Exchange < r.Stats[j].Exchange })
+	return r
+}
+
+// String returns a one line summary of the report
+func (r *Report) String() string {
+	var requests, errors, reconnects int64
+	for _, s := range r.Stats {
+		requests += s.Requests
+		errors += s.Errors
+		reconnects += s.Reconnects
+	}
+	return fmt.Sprintf("Soak test ran for %s: %d requests, %d errors, %d reconnects, goroutine growth %d, heap growth %d bytes",
+		r.Duration, requests, errors, reconnects, r.GoroutineGrowth, r.HeapGrowth)
+}
@@ -0,0 +1,94 @@
+package soak
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+)
+
+type mockExchange struct {
+	exchange.IBotExchangeEx
+	err error
+}
+
+func (m *mockExchange) GetName() string {
+	return "Mock"
+}
+
+func (m *mockExchange) UpdateOrderbook(p pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return orderbook.Base{}, m.err
+}
+
+func (m *mockExchange) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return nil, exchange.ErrReadOnly
+}
+
+func TestMonitoredExchange(t *testing.T) {
+	monitor := NewMonitor()
+	mock := &mockExchange{}
+	exch := NewMonitoredExchange(mock, monitor)
+	p := pair.NewCurrencyPair("BTC", "USD")
+	for i := 0; i < 3; i++ {
+		exch.UpdateOrderbook(p, "SPOT")
+	}
+	mock.err = errors.New("timeout")
+	exch.UpdateOrderbook(p, "SPOT")
+	// blocked by the read-only wrapper, so never sent
+	exch.GetOrders(nil)
+	monitor.TrackConnections("Mock", func() int64 { return 3 })
+
+	report := monitor.Report(time.Now())
+	if len(report.Stats) != 1 {
+		t.Fatalf("Test failed. Expected stats for 1 exchange, got %+v", report.Stats)
+	}
+	stats := report.Stats[0]
+	if stats.Requests != 4 || stats.Errors != 1 || stats.ErrorRate != 0.25 || stats.Reconnects != 2 ||
+		stats.ErrorCounts["timeout"] != 1 {
+		t.Errorf("Test failed. Unexpected stats %+v", stats)
+	}
+}
+
+func TestErrorMessageLimit(t *testing.T) {
+	monitor := NewMonitor()
+	monitor.MaxErrorMessages = 2
+	for i := 0; i < 5; i++ {
+		monitor.RecordRequest("Mock", fmt.Errorf("invalid nonce %d", i))
+	}
+	stats := monitor.Report(time.Now()).Stats[0]
+	if len(stats.ErrorCounts) != 3 || stats.ErrorCounts["other"] != 3 {
+		t.Errorf("Test failed. Expected the distinct error messages to be limited, got %v", stats.ErrorCounts)
+	}
+}
+
+func TestReport(t *testing.T) {
+	monitor := NewMonitor()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		monitor.Run(10*time.Millisecond, stop)
+		close(done)
+	}()
+	time.Sleep(35 * time.Millisecond)
+	close(stop)
+	<-done
+
+	report := monitor.Report(time.Now())
+	if len(report.Samples) < 2 || report.MaxGoroutines == 0 || report.MaxHeapAlloc == 0 {
+		t.Errorf("Test failed. Expected multiple samples, got %+v", report.Samples)
+	}
+	var buf bytes.Buffer
+	if err := report.Write(&buf); err != nil {
+		t.Fatalf("Test failed. Write returned an error: %s", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded.Samples) != len(report.Samples) {
+		t.Errorf("Test failed. Unable to decode the report: %v", err)
+	}
+}
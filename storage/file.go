@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// FileStore is a Store that keeps everything in memory and writes the whole store out to a JSON
+// file after every change. It's intended for small amounts of state, like the orders and
+// portfolio of a single bot.
+type FileStore struct {
+	*MemoryStore
+	path string
+}
+
+// NewFileStore opens the JSON file store at the given path, the file will be created on the
+// first write if it doesn't exist yet.
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{MemoryStore: NewMemoryStore(), path: path}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(data, &s.buckets); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Put implements Store
+func (s *FileStore) Put(bucket, key string, v interface{}) error {
+	if err := s.MemoryStore.Put(bucket, key, v); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// Delete implements Store
+func (s *FileStore) Delete(bucket, key string) error {
+	if err := s.MemoryStore.Delete(bucket, key); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// save writes the store to a temp file and then renames it over the old file so that a crash
+// mid-write doesn't corrupt the store.
+func (s *FileStore) save() error {
+	s.m.RLock()
+	data, err := json.MarshalIndent(s.buckets, "", " ")
+	s.m.RUnlock()
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "store.json")
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("Test failed. NewFileStore error: %s", err)
+	}
+	testStore(t, s)
+
+	// Reopening the store should restore the state written by the previous one
+	s, err = NewFileStore(path)
+	if err != nil {
+		t.Fatalf("Test failed. NewFileStore error: %s", err)
+	}
+	var v testValue
+	if err := s.Get("orders", "2", &v); err != nil {
+		t.Fatalf("Test failed. Get error: %s", err)
+	}
+	if v.Name != "b" || v.Amount != 2 {
+		t.Errorf("Test Failed - unexpected value %+v", v)
+	}
+	var n int64
+	if err := s.Get("nonces", "Bitfinex", &n); err != nil || n != 1234 {
+		t.Errorf("Test Failed - unexpected nonce %d (%v)", n, err)
+	}
+}
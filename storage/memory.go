@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+)
+
+// MemoryStore is a Store that keeps everything in memory, it's mostly useful for tests and
+// for running the bot without persisting any state.
+type MemoryStore struct {
+	m       sync.RWMutex
+	buckets map[string]map[string][]byte
+}
+
+// NewMemoryStore creates a new empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]map[string][]byte)}
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(bucket, key string, v interface{}) error {
+	s.m.RLock()
+	data, ok := s.buckets[bucket][key]
+	s.m.RUnlock()
+	if !ok {
+		return ErrNotFound
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Put implements Store
+func (s *MemoryStore) Put(bucket, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.buckets[bucket] == nil {
+		s.buckets[bucket] = make(map[string][]byte)
+	}
+	s.buckets[bucket][key] = data
+	return nil
+}
+
+// Delete implements Store
+func (s *MemoryStore) Delete(bucket, key string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	delete(s.buckets[bucket], key)
+	return nil
+}
+
+// Keys implements Store
+func (s *MemoryStore) Keys(bucket string) ([]string, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	keys := make([]string, 0, len(s.buckets[bucket]))
+	for key := range s.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Close implements Store
+func (s *MemoryStore) Close() error {
+	return nil
+}
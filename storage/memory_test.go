@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"testing"
+)
+
+type testValue struct {
+	Name   string
+	Amount float64
+}
+
+func testStore(t *testing.T, s Store) {
+	var v testValue
+	if err := s.Get("orders", "1", &v); err != ErrNotFound {
+		t.Fatalf("Test failed. Expected ErrNotFound, got %v", err)
+	}
+
+	if err := s.Put("orders", "2", testValue{"b", 2}); err != nil {
+		t.Fatalf("Test failed. Put error: %s", err)
+	}
+	if err := s.Put("orders", "1", testValue{"a", 1}); err != nil {
+		t.Fatalf("Test failed. Put error: %s", err)
+	}
+	if err := s.Put("nonces", "Bitfinex", 1234); err != nil {
+		t.Fatalf("Test failed. Put error: %s", err)
+	}
+
+	if err := s.Get("orders", "1", &v); err != nil {
+		t.Fatalf("Test failed. Get error: %s", err)
+	}
+	if v.Name != "a" || v.Amount != 1 {
+		t.Errorf("Test Failed - unexpected value %+v", v)
+	}
+
+	keys, err := s.Keys("orders")
+	if err != nil {
+		t.Fatalf("Test failed. Keys error: %s", err)
+	}
+	if len(keys) != 2 || keys[0] != "1" || keys[1] != "2" {
+		t.Errorf("Test Failed - unexpected keys %v", keys)
+	}
+
+	if err := s.Delete("orders", "1"); err != nil {
+		t.Fatalf("Test failed. Delete error: %s", err)
+	}
+	if err := s.Get("orders", "1", &v); err != ErrNotFound {
+		t.Errorf("Test Failed - expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore()
+	testStore(t, s)
+	if err := s.Close(); err != nil {
+		t.Errorf("Test Failed - Close error: %s", err)
+	}
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+const sqlCreateTable = `CREATE TABLE IF NOT EXISTS kv_store (
+	bucket TEXT NOT NULL,
+	key TEXT NOT NULL,
+	value BLOB NOT NULL,
+	PRIMARY KEY (bucket, key)
+)`
+
+// SQLStore is a Store backed by a single table in an SQL database. The queries are written for
+// SQLite, the driver isn't imported by this package so library users need to import one
+// themselves, e.g.
+//
+//	import _ "github.com/mattn/go-sqlite3"
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore opens the database and creates the table used by the store if needed
+func NewSQLStore(driverName, dataSourceName string) (*SQLStore, error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewSQLStoreFromDB(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewSQLStoreFromDB creates a store that uses an already opened database
+func NewSQLStoreFromDB(db *sql.DB) (*SQLStore, error) {
+	if _, err := db.Exec(sqlCreateTable); err != nil {
+		return nil, err
+	}
+	return &SQLStore{db: db}, nil
+}
+
+// Get implements Store
+func (s *SQLStore) Get(bucket, key string, v interface{}) error {
+	var data []byte
+	err := s.db.QueryRow(
+		"SELECT value FROM kv_store WHERE bucket = ? AND key = ?", bucket, key,
+	).Scan(&data)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Put implements Store
+func (s *SQLStore) Put(bucket, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(
+		"INSERT OR REPLACE INTO kv_store (bucket, key, value) VALUES (?, ?, ?)", bucket, key, data,
+	)
+	return err
+}
+
+// Delete implements Store
+func (s *SQLStore) Delete(bucket, key string) error {
+	_, err := s.db.Exec("DELETE FROM kv_store WHERE bucket = ? AND key = ?", bucket, key)
+	return err
+}
+
+// Keys implements Store
+func (s *SQLStore) Keys(bucket string) ([]string, error) {
+	rows, err := s.db.Query("SELECT key FROM kv_store WHERE bucket = ? ORDER BY key", bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Close implements Store
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
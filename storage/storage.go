@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"errors"
+)
+
+// ErrNotFound is returned when a key doesn't exist in a store
+var ErrNotFound = errors.New("key not found")
+
+// Store is a key value store used to persist bot state, such as orders, portfolio addresses and
+// nonces. Values are encoded as JSON, keys are grouped into buckets so that different components
+// can share a single store.
+type Store interface {
+	// Get decodes the value stored under the key into v, returns ErrNotFound if the key doesn't
+	// exist.
+	Get(bucket, key string, v interface{}) error
+	// Put stores v under the key, replacing any existing value.
+	Put(bucket, key string, v interface{}) error
+	// Delete removes the key from the bucket, deleting a key that doesn't exist isn't an error.
+	Delete(bucket, key string) error
+	// Keys returns the keys in the bucket, sorted in ascending order.
+	Keys(bucket string) ([]string, error)
+	// Close releases any resources held by the store.
+	Close() error
+}
@@ -0,0 +1,146 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+)
+
+// ParamType is the type of a strategy parameter
+type ParamType string
+
+// Parameter types, Pairs parameters are lists of currency pairs delimited by "/", e.g. BTC/USDT
+const (
+	ParamFloat ParamType = "float"
+	ParamInt   ParamType = "int"
+	ParamBool  ParamType = "bool"
+	ParamPairs ParamType = "pairs"
+)
+
+// ParamSpec describes a tunable strategy parameter
+type ParamSpec struct {
+	Name        string      `json:"name"`
+	Type        ParamType   `json:"type"`
+	Description string      `json:"description,omitempty"`
+	Default     interface{} `json:"default"`
+	// Bounds of numeric parameters, ignored if both are zero
+	Min float64 `json:"min,omitempty"`
+	Max float64 `json:"max,omitempty"`
+}
+
+// normalize checks that the value is valid for the parameter and converts it to the canonical
+// Go type of the parameter (float64, int64, bool or []string). Values decoded from JSON are
+// accepted, so ints may be passed as float64 & pairs as []interface{}.
+func (s *ParamSpec) normalize(value interface{}) (interface{}, error) {
+	switch s.Type {
+	case ParamFloat:
+		f, ok := toFloat(value)
+		if !ok {
+			return nil, fmt.Errorf("parameter %s must be a number", s.Name)
+		}
+		return f, s.checkBounds(f)
+	case ParamInt:
+		f, ok := toFloat(value)
+		if !ok || f != math.Trunc(f) {
+			return nil, fmt.Errorf("parameter %s must be an integer", s.Name)
+		}
+		return int64(f), s.checkBounds(f)
+	case ParamBool:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("parameter %s must be a boolean", s.Name)
+		}
+		return b, nil
+	case ParamPairs:
+		var items []string
+		switch v := value.(type) {
+		case []string:
+			items = v
+		case []interface{}:
+			for i := range v {
+				str, ok := v[i].(string)
+				if !ok {
+					return nil, fmt.Errorf("parameter %s must be a list of currency pairs", s.Name)
+				}
+				items = append(items, str)
+			}
+		default:
+			return nil, fmt.Errorf("parameter %s must be a list of currency pairs", s.Name)
+		}
+		pairs := make([]string, 0, len(items))
+		for _, item := range items {
+			parts := strings.Split(item, "/")
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				return nil, fmt.Errorf("parameter %s: invalid currency pair %s", s.Name, item)
+			}
+			pairs = append(pairs, pair.NewCurrencyPairDelimiter(item, "/").Display("/", true).String())
+		}
+		return pairs, nil
+	}
+	return nil, fmt.Errorf("parameter %s has unsupported type %s", s.Name, s.Type)
+}
+
+func (s *ParamSpec) checkBounds(f float64) error {
+	if s.Min == 0 && s.Max == 0 {
+		return nil
+	}
+	if f < s.Min || f > s.Max {
+		return fmt.Errorf("parameter %s must be between %v and %v", s.Name, s.Min, s.Max)
+	}
+	return nil
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// Params holds the current values of the parameters of a strategy, keyed by parameter name
+type Params map[string]interface{}
+
+// Float returns the value of a float parameter
+func (p Params) Float(name string) float64 {
+	v, _ := p[name].(float64)
+	return v
+}
+
+// Int returns the value of an int parameter
+func (p Params) Int(name string) int64 {
+	v, _ := p[name].(int64)
+	return v
+}
+
+// Bool returns the value of a bool parameter
+func (p Params) Bool(name string) bool {
+	v, _ := p[name].(bool)
+	return v
+}
+
+// Pairs returns the value of a currency pairs parameter
+func (p Params) Pairs(name string) []pair.CurrencyPair {
+	v, _ := p[name].([]string)
+	pairs := make([]pair.CurrencyPair, 0, len(v))
+	for i := range v {
+		pairs = append(pairs, pair.NewCurrencyPairDelimiter(v[i], "/"))
+	}
+	return pairs
+}
+
+func (p Params) clone() Params {
+	c := make(Params, len(p))
+	for k, v := range p {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,396 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/orderbook"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ratelimit"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+var (
+	// ErrRequestQuotaExceeded is returned when a strategy exceeds its API requests per minute
+	ErrRequestQuotaExceeded = errors.New("strategy request quota exceeded")
+	// ErrOrderQuotaExceeded is returned when a strategy already has the max number of orders open
+	ErrOrderQuotaExceeded = errors.New("strategy open order quota exceeded")
+	// ErrSubscriptionQuotaExceeded is returned when a strategy already has the max number of
+	// subscriptions
+	ErrSubscriptionQuotaExceeded = errors.New("strategy subscription quota exceeded")
+)
+
+// Quota limits the resources a strategy can use, so a misbehaving strategy can't starve the
+// other strategies sharing the same exchange connections. Zero disables a limit.
+type Quota struct {
+	// Max API requests per minute to each exchange, requests are spaced out by the shared rate
+	// limiter backend
+	RequestsPerMinute uint `json:"requestsPerMinute"`
+	// Max orders placed by the strategy that are open at the same time, across all exchanges
+	MaxOpenOrders int `json:"maxOpenOrders"`
+	// Max market data subscriptions (e.g. websocket channels) held by the strategy
+	MaxSubscriptions int `json:"maxSubscriptions"`
+}
+
+// QuotaUsage holds the resources currently used by a strategy
+type QuotaUsage struct {
+	Quota         Quota    `json:"quota"`
+	OpenOrders    int      `json:"openOrders"`
+	Subscriptions []string `json:"subscriptions"`
+	// Number of requests rejected because they would've exceeded the quota
+	Rejected int `json:"rejected"`
+}
+
+type quotaState struct {
+	quota         Quota
+	limiter       *ratelimit.Limiter
+	orders        map[string]pair.CurrencyPair // pairs of the open orders keyed by exchange & order ID
+	subscriptions map[string]bool
+	rejected      int
+}
+
+func newQuotaState(name string) *quotaState {
+	return &quotaState{
+		limiter:       ratelimit.NewLimiter("strategy:"+name, nil),
+		orders:        make(map[string]pair.CurrencyPair),
+		subscriptions: make(map[string]bool),
+	}
+}
+
+func orderKey(exchangeName, orderID string) string {
+	return exchangeName + "/" + orderID
+}
+
+func (r *Runner) registered(name string) bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+	_, ok := r.strategies[name]
+	return ok
+}
+
+// must be called with the quota lock held
+func (r *Runner) quotaState(name string) *quotaState {
+	q, ok := r.quotas[name]
+	if !ok {
+		q = newQuotaState(name)
+		r.quotas[name] = q
+	}
+	return q
+}
+
+// SetQuota sets the resource quota of a strategy, the quota can be set before the strategy is
+// registered.
+func (r *Runner) SetQuota(name string, quota Quota) {
+	r.quotaMtx.Lock()
+	defer r.quotaMtx.Unlock()
+	r.quotaState(name).quota = quota
+}
+
+// Usage returns the quota of a strategy & the resources it currently uses
+func (r *Runner) Usage(name string) (QuotaUsage, error) {
+	if !r.registered(name) {
+		return QuotaUsage{}, ErrStrategyNotFound
+	}
+	r.quotaMtx.Lock()
+	defer r.quotaMtx.Unlock()
+	q := r.quotaState(name)
+	usage := QuotaUsage{
+		Quota:         q.quota,
+		OpenOrders:    len(q.orders),
+		Subscriptions: make([]string, 0, len(q.subscriptions)),
+		Rejected:      q.rejected,
+	}
+	for channel := range q.subscriptions {
+		usage.Subscriptions = append(usage.Subscriptions, channel)
+	}
+	sort.Strings(usage.Subscriptions)
+	return usage, nil
+}
+
+// Subscribe records a market data subscription made by a strategy, returns
+// ErrSubscriptionQuotaExceeded if the strategy already holds the max number of subscriptions.
+// Subscribing to the same channel more than once only counts as a single subscription.
+func (r *Runner) Subscribe(name, channel string) error {
+	if !r.registered(name) {
+		return ErrStrategyNotFound
+	}
+	r.quotaMtx.Lock()
+	defer r.quotaMtx.Unlock()
+	q := r.quotaState(name)
+	if q.subscriptions[channel] {
+		return nil
+	}
+	if q.quota.MaxSubscriptions > 0 && len(q.subscriptions) >= q.quota.MaxSubscriptions {
+		q.rejected++
+		return ErrSubscriptionQuotaExceeded
+	}
+	q.subscriptions[channel] = true
+	return nil
+}
+
+// Unsubscribe removes a market data subscription made by a strategy
+func (r *Runner) Unsubscribe(name, channel string) {
+	r.quotaMtx.Lock()
+	defer r.quotaMtx.Unlock()
+	delete(r.quotaState(name).subscriptions, channel)
+}
+
+// Exchange returns a wrapper of the exchange that enforces the quota of the strategy, all the
+// API calls made by the strategy should go through it. The orders placed through the wrapper are
+// tagged with the name of the strategy.
+func (r *Runner) Exchange(name string, exch exchange.IBotExchangeEx) (exchange.IBotExchangeEx, error) {
+	return r.TaggedExchange(name, name, exch)
+}
+
+// TaggedExchange returns a wrapper of the exchange that enforces the quota of the strategy and
+// tags the orders placed through it with the given tag (e.g. to tell apart the orders of
+// different instances of the strategy). The tag is also used as the prefix of the client order
+// IDs on exchanges that support them.
+func (r *Runner) TaggedExchange(name, tag string, exch exchange.IBotExchangeEx) (exchange.IBotExchangeEx, error) {
+	if !r.registered(name) {
+		return nil, ErrStrategyNotFound
+	}
+	r.quotaMtx.Lock()
+	defer r.quotaMtx.Unlock()
+	r.quotaState(name)
+	if r.MarketDataTTL > 0 {
+		exch = r.sharedMarketData(exch)
+	}
+	return &quotaExchange{Decorator: exchange.Decorator{IBotExchangeEx: exch}, runner: r, strategy: name, tag: tag}, nil
+}
+
+// sharedMarketData returns a wrapper of the exchange whose market data is shared with the other
+// strategies, must be called with the quota lock held
+func (r *Runner) sharedMarketData(exch exchange.IBotExchangeEx) exchange.IBotExchangeEx {
+	if r.marketData == nil {
+		r.marketData = exchange.NewMarketDataCache(r.MarketDataTTL)
+	}
+	return r.marketData.Wrap(exch)
+}
+
+// MarketDataStats returns the number of market data requests the shared cache served & sent to
+// each exchange, keyed by exchange name
+func (r *Runner) MarketDataStats() map[string]exchange.MarketDataCacheStats {
+	r.quotaMtx.Lock()
+	cache := r.marketData
+	r.quotaMtx.Unlock()
+	if cache == nil {
+		return map[string]exchange.MarketDataCacheStats{}
+	}
+	return cache.Stats()
+}
+
+// quotaExchange enforces the quota of a strategy on the API calls made to an exchange, and tags
+// the orders placed by the strategy
+type quotaExchange struct {
+	exchange.Decorator
+	runner   *Runner
+	strategy string
+	tag      string
+}
+
+func (e *quotaExchange) allow() error {
+	e.runner.quotaMtx.Lock()
+	q := e.runner.quotas[e.strategy]
+	requestsPerMin := q.quota.RequestsPerMinute
+	e.runner.quotaMtx.Unlock()
+	if requestsPerMin == 0 || q.limiter.Allow(e.GetName(), "", requestsPerMin) {
+		return nil
+	}
+	e.runner.quotaMtx.Lock()
+	q.rejected++
+	e.runner.quotaMtx.Unlock()
+	return ErrRequestQuotaExceeded
+}
+
+func (e *quotaExchange) GetTickerPrice(currencyPair pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	if err := e.allow(); err != nil {
+		return ticker.Price{}, err
+	}
+	return e.IBotExchangeEx.GetTickerPrice(currencyPair, assetType)
+}
+
+func (e *quotaExchange) UpdateTicker(currencyPair pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	return e.UpdateTickerContext(context.Background(), currencyPair, assetType)
+}
+
+// UpdateTickerContext is UpdateTicker, the request is cancelled when the context is done
+func (e *quotaExchange) UpdateTickerContext(ctx context.Context, currencyPair pair.CurrencyPair,
+	assetType string) (ticker.Price, error) {
+	if err := e.allow(); err != nil {
+		return ticker.Price{}, err
+	}
+	return exchange.WithContext(e.IBotExchangeEx).UpdateTickerContext(ctx, currencyPair, assetType)
+}
+
+func (e *quotaExchange) GetOrderbookEx(currencyPair pair.CurrencyPair, assetType string,
+	opts ...exchange.OrderbookOptions) (orderbook.Base, error) {
+	if err := e.allow(); err != nil {
+		return orderbook.Base{}, err
+	}
+	return e.IBotExchangeEx.GetOrderbookEx(currencyPair, assetType, opts...)
+}
+
+func (e *quotaExchange) UpdateOrderbook(currencyPair pair.CurrencyPair, assetType string) (orderbook.Base, error) {
+	return e.UpdateOrderbookContext(context.Background(), currencyPair, assetType)
+}
+
+// UpdateOrderbookContext is UpdateOrderbook, the request is cancelled when the context is done
+func (e *quotaExchange) UpdateOrderbookContext(ctx context.Context, currencyPair pair.CurrencyPair,
+	assetType string) (orderbook.Base, error) {
+	if err := e.allow(); err != nil {
+		return orderbook.Base{}, err
+	}
+	return exchange.WithContext(e.IBotExchangeEx).UpdateOrderbookContext(ctx, currencyPair, assetType)
+}
+
+func (e *quotaExchange) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return e.GetExchangeAccountInfoContext(context.Background())
+}
+
+// GetExchangeAccountInfoContext is GetExchangeAccountInfo, the request is cancelled when the
+// context is done
+func (e *quotaExchange) GetExchangeAccountInfoContext(ctx context.Context) (exchange.AccountInfo, error) {
+	if err := e.allow(); err != nil {
+		return exchange.AccountInfo{}, err
+	}
+	return exchange.WithContext(e.IBotExchangeEx).GetExchangeAccountInfoContext(ctx)
+}
+
+func (e *quotaExchange) NewOrder(symbol pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	return e.NewOrderContext(context.Background(), symbol, amount, price, side, orderType, opts...)
+}
+
+// NewOrderContext is NewOrder, the request is cancelled when the context is done
+func (e *quotaExchange) NewOrderContext(ctx context.Context, symbol pair.CurrencyPair, amount,
+	price float64, side exchange.OrderSide, orderType exchange.OrderType,
+	opts ...exchange.OrderOptions) (string, error) {
+	e.runner.quotaMtx.Lock()
+	q := e.runner.quotas[e.strategy]
+	if q.quota.MaxOpenOrders > 0 && len(q.orders) >= q.quota.MaxOpenOrders {
+		q.rejected++
+		e.runner.quotaMtx.Unlock()
+		return "", ErrOrderQuotaExceeded
+	}
+	e.runner.quotaMtx.Unlock()
+
+	if err := e.allow(); err != nil {
+		return "", err
+	}
+	if e.GetCapabilities().ClientOrderIDs && !hasClientOrderID(opts) {
+		opts = append(opts, exchange.OrderOptions{ClientOrderID: NewClientOrderID(e.tag)})
+	}
+	orderID, err := exchange.WithContext(e.IBotExchangeEx).NewOrderContext(ctx, symbol, amount, price,
+		side, orderType, opts...)
+	// orders filled immediately aren't assigned an ID and don't stay open
+	if err == nil && orderID != "" {
+		e.runner.quotaMtx.Lock()
+		q.orders[orderKey(e.GetName(), orderID)] = symbol
+		e.runner.quotaMtx.Unlock()
+		if tagErr := e.runner.Tags.Set(e.GetName(), orderID, e.tag); tagErr != nil {
+			log.Printf("%s: Failed to save the tag of %s order %s. Error: %s", e.strategy, e.GetName(),
+				orderID, tagErr)
+		}
+	}
+	return orderID, err
+}
+
+func hasClientOrderID(opts []exchange.OrderOptions) bool {
+	for _, o := range opts {
+		if o.ClientOrderID != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *quotaExchange) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return e.CancelOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// CancelOrderContext is CancelOrder, the request is cancelled when the context is done
+func (e *quotaExchange) CancelOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) error {
+	if err := e.allow(); err != nil {
+		return err
+	}
+	err := exchange.WithContext(e.IBotExchangeEx).CancelOrderContext(ctx, orderID, currencyPair)
+	if err == nil {
+		e.runner.quotaMtx.Lock()
+		delete(e.runner.quotas[e.strategy].orders, orderKey(e.GetName(), orderID))
+		e.runner.quotaMtx.Unlock()
+	}
+	return err
+}
+
+func (e *quotaExchange) GetOrder(orderID string, currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	return e.GetOrderContext(context.Background(), orderID, currencyPair)
+}
+
+// GetOrderContext is GetOrder, the request is cancelled when the context is done
+func (e *quotaExchange) GetOrderContext(ctx context.Context, orderID string,
+	currencyPair pair.CurrencyPair) (*exchange.Order, error) {
+	if err := e.allow(); err != nil {
+		return nil, err
+	}
+	order, err := exchange.WithContext(e.IBotExchangeEx).GetOrderContext(ctx, orderID, currencyPair)
+	if err == nil && order != nil && order.Status != exchange.OrderStatusActive {
+		e.runner.quotaMtx.Lock()
+		delete(e.runner.quotas[e.strategy].orders, orderKey(e.GetName(), orderID))
+		e.runner.quotaMtx.Unlock()
+	}
+	return order, err
+}
+
+// GetOrders returns the active orders, orders placed by the strategy that are no longer active
+// no longer count towards the open order quota.
+func (e *quotaExchange) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return e.GetOrdersContext(context.Background(), pairs)
+}
+
+// GetOrdersContext is GetOrders, the request is cancelled when the context is done
+func (e *quotaExchange) GetOrdersContext(ctx context.Context,
+	pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	if err := e.allow(); err != nil {
+		return nil, err
+	}
+	orders, err := exchange.WithContext(e.IBotExchangeEx).GetOrdersContext(ctx, pairs)
+	if err != nil {
+		return orders, err
+	}
+
+	active := make(map[string]bool, len(orders))
+	for _, order := range orders {
+		if order != nil {
+			active[orderKey(e.GetName(), order.OrderID)] = true
+		}
+	}
+	e.runner.quotaMtx.Lock()
+	defer e.runner.quotaMtx.Unlock()
+	q := e.runner.quotas[e.strategy]
+	prefix := orderKey(e.GetName(), "")
+	for key, p := range q.orders {
+		if !strings.HasPrefix(key, prefix) || active[key] {
+			continue
+		}
+		if len(pairs) > 0 && !containsPair(pairs, p) {
+			continue
+		}
+		delete(q.orders, key)
+	}
+	return orders, nil
+}
+
+func containsPair(pairs []pair.CurrencyPair, p pair.CurrencyPair) bool {
+	for i := range pairs {
+		if pairs[i].Equal(p) {
+			return true
+		}
+	}
+	return false
+}
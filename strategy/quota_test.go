@@ -0,0 +1,159 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/ticker"
+)
+
+type mockExchange struct {
+	exchange.IBotExchangeEx
+	nextID  int
+	active  []*exchange.Order
+	tickers int
+}
+
+func (m *mockExchange) GetName() string {
+	return "Mock"
+}
+
+func (m *mockExchange) NewOrder(symbol pair.CurrencyPair, amount, price float64, side exchange.OrderSide,
+	orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	m.nextID++
+	return string(rune('0' + m.nextID)), nil
+}
+
+func (m *mockExchange) GetCapabilities() exchange.Capabilities {
+	return exchange.Capabilities{}
+}
+
+func (m *mockExchange) CancelOrder(orderID string, currencyPair pair.CurrencyPair) error {
+	return nil
+}
+
+func (m *mockExchange) GetOrders(pairs []pair.CurrencyPair) ([]*exchange.Order, error) {
+	return m.active, nil
+}
+
+func (m *mockExchange) GetExchangeAccountInfo() (exchange.AccountInfo, error) {
+	return exchange.AccountInfo{}, nil
+}
+
+func (m *mockExchange) UpdateTicker(p pair.CurrencyPair, assetType string) (ticker.Price, error) {
+	m.tickers++
+	return ticker.Price{Pair: p, Last: 100}, nil
+}
+
+func TestOrderQuota(t *testing.T) {
+	r := NewRunner()
+	r.Register(simpleStrategy{})
+	r.SetQuota("simple", Quota{MaxOpenOrders: 2})
+	mock := &mockExchange{}
+	exch, err := r.Exchange("simple", mock)
+	if err != nil {
+		t.Fatalf("Test failed. Exchange error: %s", err)
+	}
+	p := pair.NewCurrencyPair("BTC", "USD")
+
+	for i := 0; i < 2; i++ {
+		if _, err = exch.NewOrder(p, 1, 1, exchange.OrderSideBuy, exchange.OrderTypeExchangeLimit); err != nil {
+			t.Fatalf("Test failed. NewOrder error: %s", err)
+		}
+	}
+	if _, err = exch.NewOrder(p, 1, 1, exchange.OrderSideBuy, exchange.OrderTypeExchangeLimit); err != ErrOrderQuotaExceeded {
+		t.Errorf("Test failed. Expected ErrOrderQuotaExceeded, got %v", err)
+	}
+
+	if err = exch.CancelOrder("1", p); err != nil {
+		t.Fatalf("Test failed. CancelOrder error: %s", err)
+	}
+	if _, err = exch.NewOrder(p, 1, 1, exchange.OrderSideBuy, exchange.OrderTypeExchangeLimit); err != nil {
+		t.Errorf("Test failed. Expected order to be allowed after a cancel, got %v", err)
+	}
+
+	// order 2 was filled, order 3 is still active
+	mock.active = []*exchange.Order{{OrderID: "3", CurrencyPair: p, Status: exchange.OrderStatusActive}}
+	if _, err = exch.GetOrders(nil); err != nil {
+		t.Fatalf("Test failed. GetOrders error: %s", err)
+	}
+	usage, _ := r.Usage("simple")
+	if usage.OpenOrders != 1 || usage.Rejected != 1 {
+		t.Errorf("Test failed. Unexpected usage %+v", usage)
+	}
+}
+
+func TestRequestQuota(t *testing.T) {
+	r := NewRunner()
+	r.Register(simpleStrategy{})
+	r.SetQuota("simple", Quota{RequestsPerMinute: 1})
+	exch, _ := r.Exchange("simple", &mockExchange{})
+
+	if _, err := exch.GetExchangeAccountInfo(); err != nil {
+		t.Fatalf("Test failed. GetExchangeAccountInfo error: %s", err)
+	}
+	if _, err := exch.GetExchangeAccountInfo(); err != ErrRequestQuotaExceeded {
+		t.Errorf("Test failed. Expected ErrRequestQuotaExceeded, got %v", err)
+	}
+
+	// strategies without a quota aren't limited
+	r.Register(&testStrategy{})
+	exch, _ = r.Exchange("market-maker", &mockExchange{})
+	for i := 0; i < 3; i++ {
+		if _, err := exch.GetExchangeAccountInfo(); err != nil {
+			t.Errorf("Test failed. GetExchangeAccountInfo error: %s", err)
+		}
+	}
+	if _, err := r.Exchange("missing", &mockExchange{}); err != ErrStrategyNotFound {
+		t.Errorf("Test failed. Expected ErrStrategyNotFound, got %v", err)
+	}
+}
+
+func TestSubscriptionQuota(t *testing.T) {
+	r := NewRunner()
+	r.Register(simpleStrategy{})
+	r.SetQuota("simple", Quota{MaxSubscriptions: 1})
+
+	if err := r.Subscribe("simple", "Bitfinex:book:BTCUSD"); err != nil {
+		t.Fatalf("Test failed. Subscribe error: %s", err)
+	}
+	if err := r.Subscribe("simple", "Bitfinex:book:BTCUSD"); err != nil {
+		t.Errorf("Test failed. Resubscribing to the same channel returned %v", err)
+	}
+	if err := r.Subscribe("simple", "Bitfinex:book:ETHUSD"); err != ErrSubscriptionQuotaExceeded {
+		t.Errorf("Test failed. Expected ErrSubscriptionQuotaExceeded, got %v", err)
+	}
+	r.Unsubscribe("simple", "Bitfinex:book:BTCUSD")
+	if err := r.Subscribe("simple", "Bitfinex:book:ETHUSD"); err != nil {
+		t.Errorf("Test failed. Subscribe error after unsubscribing: %s", err)
+	}
+}
+
+func TestSharedMarketData(t *testing.T) {
+	r := NewRunner()
+	r.MarketDataTTL = time.Minute
+	r.Register(simpleStrategy{})
+	r.Register(&testStrategy{})
+	mock := &mockExchange{}
+	simple, _ := r.Exchange("simple", mock)
+	marketMaker, _ := r.Exchange("market-maker", mock)
+
+	btcusd := pair.NewCurrencyPair("BTC", "USD")
+	for _, exch := range []exchange.IBotExchangeEx{simple, marketMaker, simple} {
+		if tick, err := exch.UpdateTicker(btcusd, ticker.Spot); err != nil || tick.Last != 100 {
+			t.Errorf("Test failed. Unexpected ticker %+v %v", tick, err)
+		}
+	}
+	if mock.tickers != 1 {
+		t.Errorf("Test failed. Expected the ticker to be fetched once, fetched %d times", mock.tickers)
+	}
+	if stats := r.MarketDataStats()["Mock"]; stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("Test failed. Unexpected stats %+v", stats)
+	}
+	// Orders are still placed on the exchange of the strategy
+	if id, err := marketMaker.NewOrder(btcusd, 1, 100, exchange.OrderSideBuy, exchange.OrderTypeExchangeLimit); err != nil || id != "1" {
+		t.Errorf("Test failed. Unexpected order %s %v", id, err)
+	}
+}
@@ -0,0 +1,262 @@
+// Package strategy keeps track of the trading strategies run by the bot and their tunable
+// parameters, which can be read & updated at runtime (e.g. through the REST server).
+package strategy
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/eventbus"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/audit"
+)
+
+var (
+	// ErrStrategyNotFound is returned when a strategy hasn't been registered with the runner
+	ErrStrategyNotFound = errors.New("strategy not found")
+	// ErrStrategyExists is returned when a strategy with the same name is already registered
+	ErrStrategyExists = errors.New("strategy already registered")
+	// ErrNoEventBus is returned when strategies subscribe to events but the runner has no event
+	// bus
+	ErrNoEventBus = errors.New("no event bus")
+)
+
+// Strategy is a trading strategy that can be registered with a Runner
+type Strategy interface {
+	Name() string
+}
+
+// Tunable is implemented by strategies with parameters that can be changed at runtime
+type Tunable interface {
+	Strategy
+	// ParamSpecs describes the tunable parameters of the strategy
+	ParamSpecs() []ParamSpec
+	// ApplyParams is called with the complete set of validated parameters whenever they change
+	// (and once on registration with the defaults), if an error is returned the change is
+	// rejected and the previous parameters remain in effect.
+	ApplyParams(params Params) error
+}
+
+// ParamChange is a record of a change made to a strategy parameter
+type ParamChange struct {
+	Time     time.Time   `json:"time"`
+	Strategy string      `json:"strategy"`
+	Param    string      `json:"param"`
+	OldValue interface{} `json:"oldValue"`
+	NewValue interface{} `json:"newValue"`
+	// Who or what made the change, e.g. the remote address of a REST request
+	Source string `json:"source,omitempty"`
+}
+
+type registration struct {
+	strategy Strategy
+	specs    map[string]ParamSpec
+	params   Params
+	changes  []ParamChange
+}
+
+// Runner holds the registered strategies
+type Runner struct {
+	m          sync.Mutex
+	strategies map[string]*registration
+	quotaMtx   sync.Mutex
+	quotas     map[string]*quotaState
+	// If set the parameter changes are also recorded in the audit log
+	AuditLog *audit.Log
+	// Tags of the orders placed by the strategies
+	Tags *Tags
+	// How long the tickers & orderbooks fetched by a strategy are shared with the other
+	// strategies, zero disables the shared market data cache. Must be set before the
+	// strategies get their exchanges.
+	MarketDataTTL time.Duration
+	// Market data cache shared by the strategies, created when the first exchange is wrapped
+	marketData *exchange.MarketDataCache
+	// Bus the strategies receive the bot events from, see SubscribeEvents
+	Events *eventbus.Bus
+	// Number of events queued for each strategy, eventbus.DefaultQueueSize if zero
+	EventQueueSize int
+}
+
+// NewRunner creates a new strategy runner
+func NewRunner() *Runner {
+	return &Runner{
+		strategies: make(map[string]*registration),
+		quotas:     make(map[string]*quotaState),
+		Tags:       NewTags(nil),
+	}
+}
+
+// Register adds a strategy to the runner, the parameters of tunable strategies are set to their
+// defaults.
+func (r *Runner) Register(s Strategy) error {
+	reg := &registration{
+		strategy: s,
+		specs:    make(map[string]ParamSpec),
+		params:   make(Params),
+	}
+	if t, ok := s.(Tunable); ok {
+		for _, spec := range t.ParamSpecs() {
+			value, err := spec.normalize(spec.Default)
+			if err != nil {
+				return fmt.Errorf("%s: invalid default: %s", s.Name(), err)
+			}
+			reg.specs[spec.Name] = spec
+			reg.params[spec.Name] = value
+		}
+		if err := t.ApplyParams(reg.params.clone()); err != nil {
+			return fmt.Errorf("%s: failed to apply default parameters: %s", s.Name(), err)
+		}
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if _, ok := r.strategies[s.Name()]; ok {
+		return ErrStrategyExists
+	}
+	r.strategies[s.Name()] = reg
+	return nil
+}
+
+// Strategies returns the names of the registered strategies
+func (r *Runner) Strategies() []string {
+	r.m.Lock()
+	defer r.m.Unlock()
+	names := make([]string, 0, len(r.strategies))
+	for name := range r.strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SubscribeEvents calls the handler with the bot events of the given topics (e.g.
+// orderbook_update), or all the events if no topics are given. The events are queued while the
+// handler is busy, a strategy that can't keep up drops market data events.
+func (r *Runner) SubscribeEvents(name string, handler func(eventbus.Event), topics ...string) (*eventbus.Subscription, error) {
+	if !r.registered(name) {
+		return nil, ErrStrategyNotFound
+	}
+	if r.Events == nil {
+		return nil, ErrNoEventBus
+	}
+	return r.Events.Subscribe("strategy "+name, r.EventQueueSize, handler, topics...), nil
+}
+
+// ParamSpecs returns the parameter specs of a strategy
+func (r *Runner) ParamSpecs(name string) ([]ParamSpec, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	reg, ok := r.strategies[name]
+	if !ok {
+		return nil, ErrStrategyNotFound
+	}
+	specs := make([]ParamSpec, 0, len(reg.specs))
+	for _, spec := range reg.specs {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs, nil
+}
+
+// Params returns the current parameters of a strategy
+func (r *Runner) Params(name string) (Params, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	reg, ok := r.strategies[name]
+	if !ok {
+		return nil, ErrStrategyNotFound
+	}
+	return reg.params.clone(), nil
+}
+
+// UpdateParams validates & applies changes to the parameters of a strategy, either all the
+// changes are applied or none of them are. Returns the updated parameters.
+func (r *Runner) UpdateParams(name string, changes map[string]interface{}, source string) (Params, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	reg, ok := r.strategies[name]
+	if !ok {
+		return nil, ErrStrategyNotFound
+	}
+	t, ok := reg.strategy.(Tunable)
+	if !ok || len(reg.specs) == 0 {
+		return nil, fmt.Errorf("%s has no tunable parameters", name)
+	}
+
+	updated := reg.params.clone()
+	var records []ParamChange
+	now := time.Now()
+	for param, value := range changes {
+		spec, ok := reg.specs[param]
+		if !ok {
+			return nil, fmt.Errorf("%s has no parameter %s", name, param)
+		}
+		newValue, err := spec.normalize(value)
+		if err != nil {
+			return nil, err
+		}
+		if reflect.DeepEqual(updated[param], newValue) {
+			continue
+		}
+		records = append(records, ParamChange{
+			Time:     now,
+			Strategy: name,
+			Param:    param,
+			OldValue: updated[param],
+			NewValue: newValue,
+			Source:   source,
+		})
+		updated[param] = newValue
+	}
+	if len(records) == 0 {
+		return updated, nil
+	}
+
+	if err := t.ApplyParams(updated.clone()); err != nil {
+		return nil, fmt.Errorf("%s rejected the parameters: %s", name, err)
+	}
+	reg.params = updated
+	sort.Slice(records, func(i, j int) bool { return records[i].Param < records[j].Param })
+	reg.changes = append(reg.changes, records...)
+	for i := range records {
+		r.audit(&records[i])
+	}
+	return updated.clone(), nil
+}
+
+// Changes returns the history of parameter changes made to a strategy since it was registered
+func (r *Runner) Changes(name string) ([]ParamChange, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	reg, ok := r.strategies[name]
+	if !ok {
+		return nil, ErrStrategyNotFound
+	}
+	return append([]ParamChange(nil), reg.changes...), nil
+}
+
+func (r *Runner) audit(change *ParamChange) {
+	if r.AuditLog == nil {
+		return
+	}
+	err := r.AuditLog.Record(audit.Entry{
+		Timestamp: change.Time,
+		Method:    "UpdateStrategyParam",
+		Params: map[string]interface{}{
+			"strategy": change.Strategy,
+			"param":    change.Param,
+			"old":      change.OldValue,
+			"new":      change.NewValue,
+			"source":   change.Source,
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to record %s parameter change in the audit log. Error: %s",
+			change.Strategy, err)
+	}
+}
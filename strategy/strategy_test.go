@@ -0,0 +1,189 @@
+package strategy
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/eventbus"
+	"github.com/mattkanwisher/cryptofiend/exchanges/audit"
+)
+
+type testStrategy struct {
+	applied Params
+	reject  bool
+}
+
+func (s *testStrategy) Name() string {
+	return "market-maker"
+}
+
+func (s *testStrategy) ParamSpecs() []ParamSpec {
+	return []ParamSpec{
+		{Name: "spread", Type: ParamFloat, Default: 0.002, Min: 0.0001, Max: 0.05},
+		{Name: "levels", Type: ParamInt, Default: 3, Min: 1, Max: 10},
+		{Name: "postOnly", Type: ParamBool, Default: true},
+		{Name: "pairs", Type: ParamPairs, Default: []string{"BTC/USDT"}},
+	}
+}
+
+func (s *testStrategy) ApplyParams(params Params) error {
+	if s.reject {
+		return errors.New("rejected")
+	}
+	s.applied = params
+	return nil
+}
+
+type simpleStrategy struct{}
+
+func (simpleStrategy) Name() string {
+	return "simple"
+}
+
+func TestRunnerParams(t *testing.T) {
+	r := NewRunner()
+	s := &testStrategy{}
+	if err := r.Register(s); err != nil {
+		t.Fatalf("Test failed. Register error: %s", err)
+	}
+	if err := r.Register(s); err != ErrStrategyExists {
+		t.Errorf("Test failed. Expected ErrStrategyExists, got %v", err)
+	}
+	if err := r.Register(simpleStrategy{}); err != nil {
+		t.Fatalf("Test failed. Register error: %s", err)
+	}
+	if names := r.Strategies(); !reflect.DeepEqual(names, []string{"market-maker", "simple"}) {
+		t.Errorf("Test failed. Unexpected strategies %v", names)
+	}
+
+	if s.applied.Float("spread") != 0.002 || s.applied.Int("levels") != 3 ||
+		!s.applied.Bool("postOnly") || len(s.applied.Pairs("pairs")) != 1 {
+		t.Errorf("Test failed. Defaults weren't applied, got %v", s.applied)
+	}
+
+	// values decoded from JSON
+	params, err := r.UpdateParams("market-maker", map[string]interface{}{
+		"spread": 0.001,
+		"levels": float64(5),
+		"pairs":  []interface{}{"btc/usdt", "ETH/BTC"},
+	}, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("Test failed. UpdateParams error: %s", err)
+	}
+	if params.Float("spread") != 0.001 || params.Int("levels") != 5 {
+		t.Errorf("Test failed. Unexpected params %v", params)
+	}
+	if !reflect.DeepEqual(s.applied["pairs"], []string{"BTC/USDT", "ETH/BTC"}) {
+		t.Errorf("Test failed. Unexpected pairs %v", s.applied["pairs"])
+	}
+
+	changes, _ := r.Changes("market-maker")
+	if len(changes) != 3 || changes[0].Param != "levels" || changes[0].OldValue != int64(3) ||
+		changes[0].NewValue != int64(5) || changes[0].Source != "127.0.0.1" {
+		t.Errorf("Test failed. Unexpected changes %+v", changes)
+	}
+
+	invalid := []map[string]interface{}{
+		{"spread": 0.5},
+		{"levels": 2.5},
+		{"postOnly": "yes"},
+		{"pairs": []interface{}{"BTCUSDT"}},
+		{"unknown": 1.0},
+		{"spread": 0.003, "levels": 0.0},
+	}
+	for _, change := range invalid {
+		if _, err := r.UpdateParams("market-maker", change, ""); err == nil {
+			t.Errorf("Test failed. Expected %v to be rejected", change)
+		}
+	}
+	if params, _ := r.Params("market-maker"); params.Float("spread") != 0.001 {
+		t.Error("Test failed. Rejected changes shouldn't be partially applied")
+	}
+
+	s.reject = true
+	if _, err := r.UpdateParams("market-maker", map[string]interface{}{"spread": 0.003}, ""); err == nil {
+		t.Error("Test failed. Expected the strategy to reject the change")
+	}
+	if params, _ := r.Params("market-maker"); params.Float("spread") != 0.001 {
+		t.Error("Test failed. Changes rejected by the strategy shouldn't be applied")
+	}
+	if changes, _ := r.Changes("market-maker"); len(changes) != 3 {
+		t.Errorf("Test failed. Rejected changes shouldn't be recorded, got %d changes", len(changes))
+	}
+
+	if _, err := r.UpdateParams("simple", map[string]interface{}{"spread": 0.003}, ""); err == nil {
+		t.Error("Test failed. Expected error for strategy without parameters")
+	}
+	if _, err := r.Params("missing"); err != ErrStrategyNotFound {
+		t.Errorf("Test failed. Expected ErrStrategyNotFound, got %v", err)
+	}
+}
+
+func TestRunnerAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "strategy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+	auditLog, err := audit.New(path, 0, 0)
+	if err != nil {
+		t.Fatalf("Test failed. audit.New error: %s", err)
+	}
+	defer auditLog.Close()
+
+	r := NewRunner()
+	r.AuditLog = auditLog
+	r.Register(&testStrategy{})
+	if _, err = r.UpdateParams("market-maker", map[string]interface{}{"postOnly": false}, "test"); err != nil {
+		t.Fatalf("Test failed. UpdateParams error: %s", err)
+	}
+
+	entries, err := audit.ReadEntries(path)
+	if err != nil {
+		t.Fatalf("Test failed. ReadEntries error: %s", err)
+	}
+	if len(entries) != 1 || entries[0].Method != "UpdateStrategyParam" ||
+		entries[0].Params["param"] != "postOnly" || entries[0].Params["new"] != false {
+		t.Errorf("Test failed. Unexpected audit entries %+v", entries)
+	}
+}
+
+func TestRunnerSubscribeEvents(t *testing.T) {
+	r := NewRunner()
+	handler := func(e eventbus.Event) {}
+	if _, err := r.SubscribeEvents("simple", handler); err != ErrStrategyNotFound {
+		t.Errorf("Test failed. Expected ErrStrategyNotFound, got %v", err)
+	}
+	if err := r.Register(simpleStrategy{}); err != nil {
+		t.Fatalf("Test failed. Register returned an error: %s", err)
+	}
+	if _, err := r.SubscribeEvents("simple", handler); err != ErrNoEventBus {
+		t.Errorf("Test failed. Expected ErrNoEventBus, got %v", err)
+	}
+
+	r.Events = eventbus.New()
+	received := make(chan eventbus.Event, 1)
+	s, err := r.SubscribeEvents("simple", func(e eventbus.Event) { received <- e }, "ticker_update")
+	if err != nil {
+		t.Fatalf("Test failed. SubscribeEvents returned an error: %s", err)
+	}
+	defer s.Close()
+	r.Events.Publish(eventbus.Event{Topic: "ticker_update", Exchange: "Kraken"})
+	select {
+	case e := <-received:
+		if e.Exchange != "Kraken" {
+			t.Errorf("Test failed. Unexpected event %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Test failed. Event wasn't delivered to the strategy")
+	}
+	if stats := r.Events.Stats(); len(stats) != 1 || stats[0].Subscriber != "strategy simple" {
+		t.Errorf("Test failed. Unexpected subscribers %+v", stats)
+	}
+}
@@ -0,0 +1,108 @@
+package strategy
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/storage"
+)
+
+const (
+	tagsBucket = "order_tags"
+	// Separates the tag from the unique suffix of a client order ID
+	clientOrderIDSeparator = "_"
+	// Max length of the tag prefix of a client order ID, leaves room for the unique suffix
+	maxClientOrderIDTagLen = 20
+)
+
+var clientOrderIDCounter uint64
+
+// Tags attributes orders to the strategies (or the labels chosen by the strategies) that placed
+// them, so fills, P&L & execution analytics can be broken down per strategy when several
+// strategies share an account. Tags are persisted in the store if one is set.
+type Tags struct {
+	m      sync.RWMutex
+	orders map[string]string // tags keyed by exchange & order ID
+	store  storage.Store
+}
+
+// NewTags creates an empty tag registry, store can be nil to keep the tags in memory only
+func NewTags(store storage.Store) *Tags {
+	return &Tags{orders: make(map[string]string), store: store}
+}
+
+// Set tags an order
+func (t *Tags) Set(exchangeName, orderID, tag string) error {
+	key := orderKey(exchangeName, orderID)
+	t.m.Lock()
+	t.orders[key] = tag
+	t.m.Unlock()
+	if t.store != nil {
+		return t.store.Put(tagsBucket, key, tag)
+	}
+	return nil
+}
+
+// Get returns the tag of an order, or an empty string if it wasn't tagged
+func (t *Tags) Get(exchangeName, orderID string) string {
+	key := orderKey(exchangeName, orderID)
+	t.m.RLock()
+	tag, ok := t.orders[key]
+	t.m.RUnlock()
+	if ok || t.store == nil {
+		return tag
+	}
+	if err := t.store.Get(tagsBucket, key, &tag); err != nil {
+		return ""
+	}
+	t.m.Lock()
+	t.orders[key] = tag
+	t.m.Unlock()
+	return tag
+}
+
+// OrderTag returns the tag of an order, orders that weren't tagged locally (e.g. placed before
+// a restart without a store) are attributed using the tag prefix of their client order ID.
+func (t *Tags) OrderTag(exchangeName string, order *exchange.Order) string {
+	if tag := t.Get(exchangeName, order.OrderID); tag != "" {
+		return tag
+	}
+	return TagFromClientOrderID(order.InternalOrderID)
+}
+
+// clientOrderIDTag strips the characters that the exchanges don't allow in client order IDs
+func clientOrderIDTag(tag string) string {
+	tag = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		return -1
+	}, tag)
+	if len(tag) > maxClientOrderIDTagLen {
+		tag = tag[:maxClientOrderIDTagLen]
+	}
+	return tag
+}
+
+// NewClientOrderID returns a unique client order ID prefixed with the tag, the tag is truncated
+// and stripped of characters other than letters, digits & "-" so the ID is accepted by all the
+// exchanges that support client order IDs.
+func NewClientOrderID(tag string) string {
+	n := atomic.AddUint64(&clientOrderIDCounter, 1)
+	suffix := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 36) + strconv.FormatUint(n%1296, 36)
+	return clientOrderIDTag(tag) + clientOrderIDSeparator + suffix
+}
+
+// TagFromClientOrderID returns the tag prefix of a client order ID created by NewClientOrderID,
+// or an empty string if the ID doesn't have one.
+func TagFromClientOrderID(clientOrderID string) string {
+	i := strings.LastIndex(clientOrderID, clientOrderIDSeparator)
+	if i <= 0 {
+		return ""
+	}
+	return clientOrderID[:i]
+}
@@ -0,0 +1,77 @@
+package strategy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/storage"
+)
+
+type mockClientOrderIDExchange struct {
+	mockExchange
+	clientOrderIDs []string
+}
+
+func (m *mockClientOrderIDExchange) GetCapabilities() exchange.Capabilities {
+	return exchange.Capabilities{ClientOrderIDs: true}
+}
+
+func (m *mockClientOrderIDExchange) NewOrder(symbol pair.CurrencyPair, amount, price float64,
+	side exchange.OrderSide, orderType exchange.OrderType, opts ...exchange.OrderOptions) (string, error) {
+	for _, o := range opts {
+		if o.ClientOrderID != "" {
+			m.clientOrderIDs = append(m.clientOrderIDs, o.ClientOrderID)
+		}
+	}
+	return m.mockExchange.NewOrder(symbol, amount, price, side, orderType, opts...)
+}
+
+func TestOrderTags(t *testing.T) {
+	store := storage.NewMemoryStore()
+	r := NewRunner()
+	r.Tags = NewTags(store)
+	r.Register(simpleStrategy{})
+	p := pair.NewCurrencyPair("BTC", "USD")
+
+	mock := &mockExchange{}
+	exch, _ := r.Exchange("simple", mock)
+	id, err := exch.NewOrder(p, 1, 1, exchange.OrderSideBuy, exchange.OrderTypeExchangeLimit)
+	if err != nil {
+		t.Fatalf("Test failed. NewOrder error: %s", err)
+	}
+	if tag := r.Tags.Get("Mock", id); tag != "simple" {
+		t.Errorf("Test failed. Expected the order to be tagged with the strategy name, got %q", tag)
+	}
+
+	clientIDMock := &mockClientOrderIDExchange{}
+	exch, _ = r.TaggedExchange("simple", "grid#1", clientIDMock)
+	if id, err = exch.NewOrder(p, 1, 1, exchange.OrderSideBuy, exchange.OrderTypeExchangeLimit); err != nil {
+		t.Fatalf("Test failed. NewOrder error: %s", err)
+	}
+	if len(clientIDMock.clientOrderIDs) != 1 || !strings.HasPrefix(clientIDMock.clientOrderIDs[0], "grid1_") ||
+		len(clientIDMock.clientOrderIDs[0]) > 36 {
+		t.Fatalf("Test failed. Unexpected client order IDs %v", clientIDMock.clientOrderIDs)
+	}
+	if _, err = exch.NewOrder(p, 1, 1, exchange.OrderSideBuy, exchange.OrderTypeExchangeLimit,
+		exchange.OrderOptions{ClientOrderID: "mine"}); err != nil {
+		t.Fatalf("Test failed. NewOrder error: %s", err)
+	}
+	if len(clientIDMock.clientOrderIDs) != 2 || clientIDMock.clientOrderIDs[1] != "mine" {
+		t.Errorf("Test failed. Client order ID set by the strategy was replaced %v", clientIDMock.clientOrderIDs)
+	}
+
+	// Tags are reloaded from the store, orders missing from the store fall back to the client order ID
+	tags := NewTags(store)
+	if tag := tags.Get("Mock", id); tag != "grid#1" {
+		t.Errorf("Test failed. Expected the tag to be loaded from the store, got %q", tag)
+	}
+	order := &exchange.Order{OrderID: "99", InternalOrderID: clientIDMock.clientOrderIDs[0]}
+	if tag := tags.OrderTag("Mock", order); tag != "grid1" {
+		t.Errorf("Test failed. Expected the tag of the client order ID, got %q", tag)
+	}
+	if tag := tags.OrderTag("Mock", &exchange.Order{OrderID: "100"}); tag != "" {
+		t.Errorf("Test failed. Expected an untagged order, got %q", tag)
+	}
+}
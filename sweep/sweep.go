@@ -0,0 +1,280 @@
+// Package sweep moves idle balances between the wallets of an exchange account according to
+// configured policies, e.g. moving the proceeds of filled orders from the Bitfinex exchange
+// wallet to the funding wallet every night so that they can be lent out.
+package sweep
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+// Max number of sweeps kept in the history
+const maxHistory = 500
+
+var (
+	// ErrPolicyNotFound is returned when a sweep policy doesn't exist
+	ErrPolicyNotFound = errors.New("sweep policy not found")
+	// ErrUnknownExchange is returned when a policy sweeps an exchange that can't transfer funds
+	// between wallets
+	ErrUnknownExchange = errors.New("exchange doesn't support wallet transfers")
+	// ErrInvalidPolicy is returned when the parameters of a policy are invalid
+	ErrInvalidPolicy = errors.New("invalid sweep policy")
+)
+
+// Policy sweeps the available balance of currencies from one wallet of an exchange to another
+// once a day
+type Policy struct {
+	Name     string `json:"name"`
+	Exchange string `json:"exchange"`
+	// Currencies swept by the policy, every currency in the source wallet is swept if empty
+	Currencies []string `json:"currencies,omitempty"`
+	From       string   `json:"from"`
+	To         string   `json:"to"`
+	// Time of day (UTC) the policy runs at, as an offset from midnight
+	At time.Duration `json:"at"`
+	// Balances smaller than this aren't swept, avoids moving dust
+	MinAmount float64 `json:"minAmount,omitempty"`
+	// Amount left in the source wallet
+	Reserve float64 `json:"reserve,omitempty"`
+	// Log & notify the sweeps that would be made without transferring any funds
+	DryRun bool `json:"dryRun"`
+	// Send a notification for every sweep
+	Notify bool `json:"notify"`
+}
+
+// ParseTimeOfDay parses a time of day in the HH:MM format, returning the offset from midnight
+func ParseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %s, expected HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (p *Policy) validate() error {
+	if p.Name == "" || p.Exchange == "" || p.From == "" || p.To == "" || p.From == p.To {
+		return ErrInvalidPolicy
+	}
+	if p.At < 0 || p.At >= 24*time.Hour || p.MinAmount < 0 || p.Reserve < 0 {
+		return ErrInvalidPolicy
+	}
+	return nil
+}
+
+func (p *Policy) sweeps(currency string) bool {
+	if len(p.Currencies) == 0 {
+		return true
+	}
+	for _, c := range p.Currencies {
+		if strings.EqualFold(c, currency) {
+			return true
+		}
+	}
+	return false
+}
+
+// lastScheduled returns the most recent time the policy was scheduled to run at or before now
+func (p *Policy) lastScheduled(now time.Time) time.Time {
+	now = now.UTC()
+	t := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Add(p.At)
+	if t.After(now) {
+		t = t.AddDate(0, 0, -1)
+	}
+	return t
+}
+
+// Sweep is a transfer made (or that would have been made in dry-run mode) by a policy
+type Sweep struct {
+	Policy   string    `json:"policy"`
+	Exchange string    `json:"exchange"`
+	Currency string    `json:"currency"`
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Amount   float64   `json:"amount"`
+	DryRun   bool      `json:"dryRun"`
+	Time     time.Time `json:"time"`
+	Error    string    `json:"error,omitempty"`
+}
+
+func (s *Sweep) String() string {
+	prefix := ""
+	if s.DryRun {
+		prefix = "[dry run] "
+	}
+	msg := fmt.Sprintf("%s%s: swept %f %s from the %s wallet to the %s wallet (policy %s)",
+		prefix, s.Exchange, s.Amount, s.Currency, s.From, s.To, s.Policy)
+	if s.Error != "" {
+		msg += ". Error: " + s.Error
+	}
+	return msg
+}
+
+// Sweeper runs the sweep policies
+type Sweeper struct {
+	mtx       sync.Mutex
+	policies  map[string]*Policy
+	exchanges map[string]exchange.WalletTransferer
+	lastRun   map[string]time.Time
+	history   []Sweep
+	// Called with a description of every sweep made by a policy that has Notify set
+	Notify func(message string)
+}
+
+// NewSweeper returns a sweeper without any policies
+func NewSweeper() *Sweeper {
+	return &Sweeper{
+		policies:  make(map[string]*Policy),
+		exchanges: make(map[string]exchange.WalletTransferer),
+		lastRun:   make(map[string]time.Time),
+	}
+}
+
+// AddExchange adds an exchange whose wallets can be swept
+func (s *Sweeper) AddExchange(exchangeName string, t exchange.WalletTransferer) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.exchanges[exchangeName] = t
+}
+
+// AddPolicy adds or replaces a policy, the policy first runs at the next scheduled time
+func (s *Sweeper) AddPolicy(p Policy, now time.Time) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.exchanges[p.Exchange]; !ok {
+		return ErrUnknownExchange
+	}
+	s.policies[p.Name] = &p
+	s.lastRun[p.Name] = now
+	return nil
+}
+
+// Policies returns the policies sorted by name
+func (s *Sweeper) Policies() []Policy {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	result := make([]Policy, 0, len(s.policies))
+	for _, p := range s.policies {
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// History returns the sweeps made by the policies, the most recent last
+func (s *Sweeper) History() []Sweep {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]Sweep(nil), s.history...)
+}
+
+// RunDue runs the policies that were scheduled to run since they last ran
+func (s *Sweeper) RunDue(now time.Time) ([]Sweep, error) {
+	s.mtx.Lock()
+	var due []Policy
+	for name, p := range s.policies {
+		if s.lastRun[name].Before(p.lastScheduled(now)) {
+			due = append(due, *p)
+			s.lastRun[name] = now
+		}
+	}
+	s.mtx.Unlock()
+
+	sort.Slice(due, func(i, j int) bool { return due[i].Name < due[j].Name })
+	var result []Sweep
+	var failed []string
+	for i := range due {
+		sweeps, err := s.run(&due[i], due[i].DryRun, now)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", due[i].Name, err))
+		}
+		result = append(result, sweeps...)
+	}
+	if len(failed) > 0 {
+		return result, fmt.Errorf("failed to run sweep policies %s", strings.Join(failed, ", "))
+	}
+	return result, nil
+}
+
+// Run runs a policy straight away, dryRun forces the policy to run in dry-run mode
+func (s *Sweeper) Run(name string, dryRun bool, now time.Time) ([]Sweep, error) {
+	s.mtx.Lock()
+	p, ok := s.policies[name]
+	var policy Policy
+	if ok {
+		policy = *p
+	}
+	s.mtx.Unlock()
+	if !ok {
+		return nil, ErrPolicyNotFound
+	}
+	return s.run(&policy, dryRun || policy.DryRun, now)
+}
+
+func (s *Sweeper) run(p *Policy, dryRun bool, now time.Time) ([]Sweep, error) {
+	s.mtx.Lock()
+	t, ok := s.exchanges[p.Exchange]
+	s.mtx.Unlock()
+	if !ok {
+		return nil, ErrUnknownExchange
+	}
+
+	balances, err := t.GetWalletBalances()
+	if err != nil {
+		return nil, err
+	}
+	var result []Sweep
+	for _, b := range balances {
+		if b.Wallet != p.From || !p.sweeps(b.Currency) {
+			continue
+		}
+		amount := b.Available - p.Reserve
+		if amount <= 0 || amount < p.MinAmount {
+			continue
+		}
+		sweep := Sweep{
+			Policy:   p.Name,
+			Exchange: p.Exchange,
+			Currency: b.Currency,
+			From:     p.From,
+			To:       p.To,
+			Amount:   amount,
+			DryRun:   dryRun,
+			Time:     now,
+		}
+		if !dryRun {
+			if err = t.TransferBetweenWallets(b.Currency, amount, p.From, p.To); err != nil {
+				sweep.Error = err.Error()
+			}
+		}
+		result = append(result, sweep)
+	}
+
+	s.mtx.Lock()
+	s.history = append(s.history, result...)
+	if len(s.history) > maxHistory {
+		s.history = append([]Sweep(nil), s.history[len(s.history)-maxHistory:]...)
+	}
+	notify := s.Notify
+	s.mtx.Unlock()
+
+	if p.Notify && notify != nil {
+		for i := range result {
+			notify(result[i].String())
+		}
+	}
+	for i := range result {
+		if result[i].Error != "" {
+			return result, errors.New(result[i].Error)
+		}
+	}
+	return result, nil
+}
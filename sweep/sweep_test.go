@@ -0,0 +1,113 @@
+package sweep
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/exchanges"
+)
+
+type transfer struct {
+	currency string
+	amount   float64
+	from, to string
+}
+
+type mockWallets struct {
+	balances  []exchange.WalletBalance
+	transfers []transfer
+}
+
+func (m *mockWallets) GetWalletBalances() ([]exchange.WalletBalance, error) {
+	return m.balances, nil
+}
+
+func (m *mockWallets) TransferBetweenWallets(currency string, amount float64, from, to string) error {
+	m.transfers = append(m.transfers, transfer{currency, amount, from, to})
+	return nil
+}
+
+func newTestSweeper(t *testing.T, now time.Time, dryRun bool) (*Sweeper, *mockWallets) {
+	wallets := &mockWallets{balances: []exchange.WalletBalance{
+		{Wallet: exchange.WalletExchange, Currency: "USD", Total: 120, Available: 100},
+		{Wallet: exchange.WalletExchange, Currency: "BTC", Total: 0.001, Available: 0.001},
+		{Wallet: exchange.WalletExchange, Currency: "ETH", Total: 5, Available: 5},
+		{Wallet: exchange.WalletFunding, Currency: "USD", Total: 500, Available: 500},
+	}}
+	s := NewSweeper()
+	s.AddExchange("Bitfinex", wallets)
+	err := s.AddPolicy(Policy{
+		Name:       "nightly",
+		Exchange:   "Bitfinex",
+		Currencies: []string{"usd", "BTC"},
+		From:       exchange.WalletExchange,
+		To:         exchange.WalletFunding,
+		At:         2 * time.Hour,
+		MinAmount:  0.01,
+		Reserve:    10,
+		DryRun:     dryRun,
+		Notify:     true,
+	}, now)
+	if err != nil {
+		t.Fatalf("Test failed. AddPolicy returned an error: %s", err)
+	}
+	return s, wallets
+}
+
+func TestRunDue(t *testing.T) {
+	start := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+	s, wallets := newTestSweeper(t, start, false)
+	var notifications []string
+	s.Notify = func(msg string) { notifications = append(notifications, msg) }
+
+	if sweeps, err := s.RunDue(start.Add(time.Hour)); err != nil || len(sweeps) != 0 {
+		t.Fatalf("Test failed. Expected no sweeps before the scheduled time, got %v %v", sweeps, err)
+	}
+	sweeps, err := s.RunDue(start.Add(14 * time.Hour))
+	if err != nil {
+		t.Fatalf("Test failed. RunDue returned an error: %s", err)
+	}
+	// BTC is below the min amount once the reserve is deducted, ETH isn't swept by the policy
+	if len(sweeps) != 1 || sweeps[0].Currency != "USD" || sweeps[0].Amount != 90 {
+		t.Fatalf("Test failed. Unexpected sweeps %+v", sweeps)
+	}
+	expected := transfer{"USD", 90, exchange.WalletExchange, exchange.WalletFunding}
+	if len(wallets.transfers) != 1 || wallets.transfers[0] != expected {
+		t.Errorf("Test failed. Unexpected transfers %+v", wallets.transfers)
+	}
+	if len(notifications) != 1 {
+		t.Errorf("Test failed. Expected 1 notification but got %d", len(notifications))
+	}
+	// the policy already ran today
+	if sweeps, _ = s.RunDue(start.Add(15 * time.Hour)); len(sweeps) != 0 {
+		t.Errorf("Test failed. Expected the policy to run once a day, got %+v", sweeps)
+	}
+	if len(s.History()) != 1 {
+		t.Errorf("Test failed. Expected 1 sweep in the history but got %d", len(s.History()))
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	now := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+	s, wallets := newTestSweeper(t, now, false)
+	sweeps, err := s.Run("nightly", true, now)
+	if err != nil {
+		t.Fatalf("Test failed. Run returned an error: %s", err)
+	}
+	if len(sweeps) != 1 || !sweeps[0].DryRun || len(wallets.transfers) != 0 {
+		t.Errorf("Test failed. Expected a dry run sweep without transfers, got %+v %+v", sweeps, wallets.transfers)
+	}
+	if _, err = s.Run("daily", false, now); err != ErrPolicyNotFound {
+		t.Errorf("Test failed. Expected ErrPolicyNotFound but got %v", err)
+	}
+}
+
+func TestParseTimeOfDay(t *testing.T) {
+	at, err := ParseTimeOfDay("23:30")
+	if err != nil || at != 23*time.Hour+30*time.Minute {
+		t.Errorf("Test failed. Unexpected result %s %v", at, err)
+	}
+	if _, err = ParseTimeOfDay("25:00"); err == nil {
+		t.Error("Test failed. Expected an error for an invalid time of day")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"text/tabwriter"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/markets"
+)
+
+func main() {
+	var configFile string
+	var jsonOutput bool
+	flag.StringVar(&configFile, "config", config.ConfigFile, "The config file of the bot, used to find its webserver.")
+	flag.BoolVar(&jsonOutput, "json", false, "Output the matches as JSON.")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <market>\nLists the exchanges & symbols trading a market "+
+			"(e.g. eth/usd) on a running bot.\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	cfg := config.GetConfig()
+	if err := cfg.LoadConfig(configFile); err != nil {
+		log.Fatalf("Failed to load config file: %s", err)
+	}
+	listenAddr := cfg.Webserver.ListenAddress
+	requestURL := fmt.Sprintf("http://%s:%d/markets/resolve?market=%s", common.ExtractHost(listenAddr),
+		common.ExtractPort(listenAddr), url.QueryEscape(flag.Arg(0)))
+
+	resp, err := http.Get(requestURL)
+	if err != nil {
+		log.Fatalf("Failed to query the bot: %s", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.Fatalf("Failed to read the response: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Fatalf("Failed to resolve %s: %s", flag.Arg(0), body)
+	}
+	if jsonOutput {
+		os.Stdout.Write(body)
+		return
+	}
+
+	var matches []markets.Match
+	if err = json.Unmarshal(body, &matches); err != nil {
+		log.Fatalf("Failed to decode the response: %s", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "EXCHANGE\tSYMBOL\tMARKET\tASSET\tMATCH")
+	for _, m := range matches {
+		match := string(m.Type)
+		if m.Inverted {
+			match += ", inverted"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s/%s\t%s\t%s\n", m.Exchange, m.Symbol, m.Base, m.Quote, m.AssetType, match)
+	}
+	w.Flush()
+}
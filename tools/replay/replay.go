@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/common"
+	"github.com/mattkanwisher/cryptofiend/config"
+	"github.com/mattkanwisher/cryptofiend/currency/pair"
+	exchange "github.com/mattkanwisher/cryptofiend/exchanges"
+	"github.com/mattkanwisher/cryptofiend/exchanges/audit"
+	"github.com/mattkanwisher/cryptofiend/exchanges/bitfinex"
+	"github.com/mattkanwisher/cryptofiend/exchanges/bitstamp"
+	"github.com/mattkanwisher/cryptofiend/exchanges/bittrex"
+	"github.com/mattkanwisher/cryptofiend/exchanges/btcc"
+	"github.com/mattkanwisher/cryptofiend/exchanges/btcmarkets"
+	"github.com/mattkanwisher/cryptofiend/exchanges/coinut"
+	"github.com/mattkanwisher/cryptofiend/exchanges/gdax"
+	"github.com/mattkanwisher/cryptofiend/exchanges/gemini"
+	"github.com/mattkanwisher/cryptofiend/exchanges/huobi"
+	"github.com/mattkanwisher/cryptofiend/exchanges/itbit"
+	"github.com/mattkanwisher/cryptofiend/exchanges/kraken"
+	"github.com/mattkanwisher/cryptofiend/exchanges/lakebtc"
+	"github.com/mattkanwisher/cryptofiend/exchanges/liqui"
+	"github.com/mattkanwisher/cryptofiend/exchanges/localbitcoins"
+	"github.com/mattkanwisher/cryptofiend/exchanges/okcoin"
+	"github.com/mattkanwisher/cryptofiend/exchanges/poloniex"
+	"github.com/mattkanwisher/cryptofiend/exchanges/wex"
+	"github.com/mattkanwisher/cryptofiend/recorder"
+	"github.com/mattkanwisher/cryptofiend/replay"
+	"github.com/mattkanwisher/cryptofiend/simulation"
+)
+
+func main() {
+	var recording, auditFiles, start, end, exchangeName, configFile string
+	var jsonOutput bool
+	flag.StringVar(&recording, "recording", "", "The orderbook recording to replay.")
+	flag.StringVar(&auditFiles, "audit", "", "The audit log files to replay, delimited by commas (e.g. audit.log.1,audit.log).")
+	flag.StringVar(&start, "start", "", "The start of the window to replay (RFC3339).")
+	flag.StringVar(&end, "end", "", "The end of the window to replay (RFC3339).")
+	flag.StringVar(&exchangeName, "exchange", "", "Only replay the activity of this exchange.")
+	flag.StringVar(&configFile, "config", "", "The config file holding the simulated execution models, optional.")
+	flag.BoolVar(&jsonOutput, "json", false, "Output the report as JSON.")
+	flag.Parse()
+
+	if recording == "" || auditFiles == "" || start == "" || end == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+	opts := replay.Options{Exchange: exchangeName}
+	var err error
+	if opts.Start, err = time.Parse(time.RFC3339, start); err != nil {
+		log.Fatalf("Invalid start time: %s", err)
+	}
+	if opts.End, err = time.Parse(time.RFC3339, end); err != nil {
+		log.Fatalf("Invalid end time: %s", err)
+	}
+	if !opts.Start.Before(opts.End) {
+		log.Fatal("The start time must be before the end time")
+	}
+
+	if configFile != "" {
+		cfg := config.GetConfig()
+		if err = cfg.ReadConfig(configFile); err != nil {
+			log.Fatalf("Failed to read config: %s", err)
+		}
+		if opts.Simulator, err = simulation.NewSimulator(cfg.Simulation, exchangeFees); err != nil {
+			log.Fatalf("Failed to create simulator: %s", err)
+		}
+	}
+
+	var entries []audit.Entry
+	for _, path := range strings.Split(auditFiles, ",") {
+		fileEntries, err := audit.ReadEntries(strings.TrimSpace(path))
+		if err != nil {
+			log.Fatalf("Failed to read audit log %s: %s", path, err)
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	file, err := os.Open(recording)
+	if err != nil {
+		log.Fatalf("Failed to open recording: %s", err)
+	}
+	defer file.Close()
+
+	report, err := replay.Run(recorder.NewReader(file), entries, opts)
+	if err != nil {
+		log.Fatalf("Failed to replay: %s", err)
+	}
+	if jsonOutput {
+		data, err := common.JSONEncode(report)
+		if err != nil {
+			log.Fatalf("Failed to encode report: %s", err)
+		}
+		os.Stdout.Write(data)
+		return
+	}
+	if err = replay.WriteText(os.Stdout, report); err != nil {
+		log.Fatalf("Failed to write report: %s", err)
+	}
+}
+
+// exchangeFees returns the default maker & taker fees of the named exchange, as set by its
+// wrapper, or zero fees if the exchange is unknown.
+func exchangeFees(exchangeName string) (makerFee, takerFee float64) {
+	exchanges := []exchange.IBotExchange{
+		new(kraken.Kraken),
+		new(btcc.BTCC),
+		new(bitstamp.Bitstamp),
+		new(bitfinex.Bitfinex),
+		new(bittrex.Bittrex),
+		new(wex.WEX),
+		new(btcmarkets.BTCMarkets),
+		new(coinut.COINUT),
+		new(gdax.GDAX),
+		new(gemini.Gemini),
+		new(okcoin.OKCoin),
+		new(okcoin.OKCoin),
+		new(itbit.ItBit),
+		new(lakebtc.LakeBTC),
+		new(liqui.Liqui),
+		new(localbitcoins.LocalBitcoins),
+		new(poloniex.Poloniex),
+		new(huobi.HUOBI),
+	}
+	for _, exch := range exchanges {
+		exch.SetDefaults()
+		if exch.GetName() != exchangeName {
+			continue
+		}
+		feeInfo, ok := exch.(interface {
+			GetFeeInfo(currencyPair pair.CurrencyPair) (maker, taker float64, err error)
+		})
+		if !ok {
+			return 0, 0
+		}
+		makerFee, takerFee, err := feeInfo.GetFeeInfo(pair.CurrencyPair{})
+		if err != nil {
+			log.Printf("Failed to get the fees of %s: %s", exchangeName, err)
+			return 0, 0
+		}
+		return makerFee, takerFee
+	}
+	return 0, 0
+}
@@ -0,0 +1,136 @@
+// Package trace provides per-module debug tracing that can be toggled at runtime. Modules are
+// named hierarchically with dots, e.g. "bitfinex.http" & "bitfinex.ws" are submodules of
+// "bitfinex", and a module is traced if the most specific setting that applies to it is enabled.
+// Enabling "bitfinex" & disabling "bitfinex.ws" traces everything from Bitfinex except for the
+// websocket.
+package trace
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// All is the root module, enabling it traces every module that isn't explicitly disabled
+const All = "*"
+
+var (
+	mtx      sync.RWMutex
+	settings = make(map[string]bool)
+)
+
+// normalize returns the canonical (lowercase) module name
+func normalize(module string) string {
+	module = strings.ToLower(strings.TrimSpace(module))
+	if module == "" {
+		return All
+	}
+	return module
+}
+
+// Enable enables tracing of a module & its submodules
+func Enable(module string) {
+	mtx.Lock()
+	settings[normalize(module)] = true
+	mtx.Unlock()
+}
+
+// Disable disables tracing of a module & its submodules
+func Disable(module string) {
+	mtx.Lock()
+	settings[normalize(module)] = false
+	mtx.Unlock()
+}
+
+// Clear removes the setting of a module, so that the setting of its parent applies
+func Clear(module string) {
+	mtx.Lock()
+	delete(settings, normalize(module))
+	mtx.Unlock()
+}
+
+// Reset removes all the module settings, disabling all tracing
+func Reset() {
+	mtx.Lock()
+	settings = make(map[string]bool)
+	mtx.Unlock()
+}
+
+// Enabled returns true if the module is traced
+func Enabled(module string) bool {
+	module = normalize(module)
+	mtx.RLock()
+	defer mtx.RUnlock()
+	if len(settings) == 0 {
+		return false
+	}
+	for {
+		if enabled, ok := settings[module]; ok {
+			return enabled
+		}
+		i := strings.LastIndex(module, ".")
+		if i < 0 {
+			break
+		}
+		module = module[:i]
+	}
+	return settings[All]
+}
+
+// Settings returns the explicitly enabled & disabled modules
+func Settings() map[string]bool {
+	mtx.RLock()
+	defer mtx.RUnlock()
+	result := make(map[string]bool, len(settings))
+	for module, enabled := range settings {
+		result[module] = enabled
+	}
+	return result
+}
+
+// String returns the module settings in the format accepted by Set
+func String() string {
+	s := Settings()
+	modules := make([]string, 0, len(s))
+	for module, enabled := range s {
+		if !enabled {
+			module = "-" + module
+		}
+		modules = append(modules, module)
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		return strings.TrimPrefix(modules[i], "-") < strings.TrimPrefix(modules[j], "-")
+	})
+	return strings.Join(modules, ",")
+}
+
+// Set applies a comma separated list of modules, modules prefixed with "-" are disabled, e.g.
+// "bitfinex,-bitfinex.ws,orderbook".
+func Set(spec string) error {
+	for _, module := range strings.Split(spec, ",") {
+		module = strings.TrimSpace(module)
+		if module == "" {
+			continue
+		}
+		if strings.HasPrefix(module, "-") {
+			module = strings.TrimSpace(module[1:])
+			if module == "" {
+				return errors.New("invalid trace module \"-\"")
+			}
+			Disable(module)
+		} else {
+			Enable(module)
+		}
+	}
+	return nil
+}
+
+// Logf logs a message prefixed by the module name if the module is traced
+func Logf(module, format string, args ...interface{}) {
+	if Enabled(module) {
+		log.Printf("[%s] %s", normalize(module), fmt.Sprintf(format, args...))
+	}
+}
@@ -0,0 +1,51 @@
+package trace
+
+import "testing"
+
+func TestEnabled(t *testing.T) {
+	defer Reset()
+	if Enabled("bitfinex.http") {
+		t.Error("Test failed. Expected tracing to be disabled by default")
+	}
+
+	Enable("Bitfinex")
+	Disable("bitfinex.ws")
+	tests := map[string]bool{
+		"bitfinex":          true,
+		"bitfinex.http":     true,
+		"bitfinex.ws":       false,
+		"bitfinex.ws.books": false,
+		"binance.http":      false,
+		"orderbook":         false,
+	}
+	for module, expected := range tests {
+		if Enabled(module) != expected {
+			t.Errorf("Test failed. Expected %s tracing enabled to be %v", module, expected)
+		}
+	}
+
+	Enable(All)
+	if !Enabled("orderbook") || Enabled("bitfinex.ws") {
+		t.Error("Test failed. Expected the root setting to only apply to unset modules")
+	}
+	Clear("bitfinex.ws")
+	if !Enabled("bitfinex.ws") {
+		t.Error("Test failed. Expected the parent setting to apply once cleared")
+	}
+}
+
+func TestSet(t *testing.T) {
+	defer Reset()
+	if err := Set("binance, -binance.ws,orderbook"); err != nil {
+		t.Fatalf("Test failed. Set returned an error: %s", err)
+	}
+	if !Enabled("binance.http") || Enabled("binance.ws") || !Enabled("orderbook") {
+		t.Errorf("Test failed. Unexpected settings %v", Settings())
+	}
+	if s := String(); s != "binance,-binance.ws,orderbook" {
+		t.Errorf("Test failed. Unexpected settings string %s", s)
+	}
+	if err := Set("-"); err == nil {
+		t.Error("Test failed. Expected an error for an empty module")
+	}
+}
@@ -0,0 +1,267 @@
+// Package transfers estimates the cost & duration of moving funds between exchanges, so that
+// arbitrage opportunities can be checked against the cost of rebalancing the exchange balances.
+package transfers
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/currency/money"
+	"github.com/mattkanwisher/cryptofiend/storage"
+)
+
+const (
+	transfersBucket = "transfers"
+	transfersKey    = "history"
+	// Max number of completed transfers kept per route, older transfers are discarded
+	maxTransfersPerRoute = 100
+)
+
+// ErrNoEstimate is returned when nothing is known about transferring a currency between two
+// exchanges
+var ErrNoEstimate = errors.New("no transfer estimate available")
+
+// CurrencyInfo holds the withdrawal & deposit rules of a currency on an exchange
+type CurrencyInfo struct {
+	WithdrawalFee float64 `json:"withdrawalFee"`
+	MinWithdrawal float64 `json:"minWithdrawal"`
+	// Number of confirmations required before a deposit is credited
+	DepositConfirmations int `json:"depositConfirmations"`
+}
+
+// Default withdrawal & deposit rules keyed by exchange & currency, as published by the exchanges.
+// The exchanges change these from time to time, so they can be overridden in the config.
+var defaultCurrencyInfo = map[string]map[string]CurrencyInfo{
+	"Binance": {
+		"BTC": {WithdrawalFee: 0.0005, MinWithdrawal: 0.001, DepositConfirmations: 2},
+		"ETH": {WithdrawalFee: 0.01, MinWithdrawal: 0.02, DepositConfirmations: 12},
+		"LTC": {WithdrawalFee: 0.001, MinWithdrawal: 0.002, DepositConfirmations: 4},
+	},
+	"Bitfinex": {
+		"BTC": {WithdrawalFee: 0.0004, DepositConfirmations: 3},
+		"ETH": {WithdrawalFee: 0.0027, DepositConfirmations: 12},
+		"LTC": {WithdrawalFee: 0.001, DepositConfirmations: 6},
+	},
+	"Kraken": {
+		"BTC": {WithdrawalFee: 0.0005, MinWithdrawal: 0.001, DepositConfirmations: 6},
+		"ETH": {WithdrawalFee: 0.005, MinWithdrawal: 0.01, DepositConfirmations: 30},
+		"LTC": {WithdrawalFee: 0.001, MinWithdrawal: 0.002, DepositConfirmations: 6},
+	},
+}
+
+// Transfer is a completed transfer of funds from one exchange to another, e.g. as logged by the
+// component that moves funds between exchanges
+type Transfer struct {
+	ID       string  `json:"id"`
+	Currency string  `json:"currency"`
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+	Amount   float64 `json:"amount"`
+	// Fee charged by the source exchange
+	Fee         float64   `json:"fee"`
+	InitiatedAt time.Time `json:"initiatedAt"`
+	// Time at which the funds were credited by the destination exchange
+	CompletedAt time.Time `json:"completedAt"`
+}
+
+// Value returns the amount transferred with the currency attached
+func (t *Transfer) Value() money.Amount {
+	return money.FromFloat(t.Amount, t.Currency)
+}
+
+// FeeAmount returns the fee charged by the source exchange
+func (t *Transfer) FeeAmount() money.Amount {
+	return money.FromFloat(t.Fee, t.Currency)
+}
+
+// Duration returns how long the transfer took to complete
+func (t *Transfer) Duration() time.Duration {
+	return t.CompletedAt.Sub(t.InitiatedAt)
+}
+
+// Estimate is the expected cost & duration of transferring a currency between two exchanges
+type Estimate struct {
+	Currency      string  `json:"currency"`
+	From          string  `json:"from"`
+	To            string  `json:"to"`
+	WithdrawalFee float64 `json:"withdrawalFee"`
+	MinWithdrawal float64 `json:"minWithdrawal"`
+	// Number of confirmations required by the destination exchange
+	Confirmations int `json:"confirmations"`
+	// Number of completed transfers the durations are based on, the durations are zero if no
+	// transfers have been observed yet
+	Observed       int           `json:"observed"`
+	MedianDuration time.Duration `json:"medianDuration"`
+	P90Duration    time.Duration `json:"p90Duration"`
+	MaxDuration    time.Duration `json:"maxDuration"`
+}
+
+// Profitable returns true if transferring amount is allowed and the expected profit (denominated
+// in the transferred currency) exceeds the withdrawal fee.
+func (e *Estimate) Profitable(amount, expectedProfit float64) bool {
+	return amount >= e.MinWithdrawal && expectedProfit > e.WithdrawalFee
+}
+
+func routeKey(currency, from, to string) string {
+	return strings.ToUpper(currency) + "/" + from + "/" + to
+}
+
+type estimatorState struct {
+	// Completed transfers keyed by route, oldest first
+	Transfers map[string][]Transfer `json:"transfers"`
+	Seen      map[string]bool       `json:"seen"`
+}
+
+// Estimator estimates transfer costs from the withdrawal & deposit rules of the exchanges, and
+// transfer durations from the transfers observed between them.
+type Estimator struct {
+	m     sync.Mutex
+	info  map[string]map[string]CurrencyInfo // keyed by exchange & currency
+	state estimatorState
+}
+
+// NewEstimator creates an Estimator that uses the default withdrawal & deposit rules
+func NewEstimator() *Estimator {
+	e := &Estimator{
+		info: make(map[string]map[string]CurrencyInfo),
+		state: estimatorState{
+			Transfers: make(map[string][]Transfer),
+			Seen:      make(map[string]bool),
+		},
+	}
+	for exchangeName, currencies := range defaultCurrencyInfo {
+		for currency, info := range currencies {
+			e.SetCurrencyInfo(exchangeName, currency, info)
+		}
+	}
+	return e
+}
+
+// SetCurrencyInfo sets the withdrawal & deposit rules of a currency on an exchange
+func (e *Estimator) SetCurrencyInfo(exchangeName, currency string, info CurrencyInfo) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	if e.info[exchangeName] == nil {
+		e.info[exchangeName] = make(map[string]CurrencyInfo)
+	}
+	e.info[exchangeName][strings.ToUpper(currency)] = info
+}
+
+// RecordTransfer records a completed transfer, transfers that have already been recorded or
+// haven't completed yet are ignored.
+func (e *Estimator) RecordTransfer(t Transfer) {
+	if t.CompletedAt.IsZero() || t.CompletedAt.Before(t.InitiatedAt) {
+		return
+	}
+	e.m.Lock()
+	defer e.m.Unlock()
+	id := t.From + "/" + t.ID
+	if t.ID != "" {
+		if e.state.Seen[id] {
+			return
+		}
+		e.state.Seen[id] = true
+	}
+	key := routeKey(t.Currency, t.From, t.To)
+	transfers := append(e.state.Transfers[key], t)
+	if len(transfers) > maxTransfersPerRoute {
+		transfers = transfers[len(transfers)-maxTransfersPerRoute:]
+	}
+	e.state.Transfers[key] = transfers
+}
+
+// Transfers returns the recorded transfers from or to an exchange initiated between start & end,
+// oldest first. Only the most recent transfers of each route are retained.
+func (e *Estimator) Transfers(exchangeName string, start, end time.Time) []Transfer {
+	e.m.Lock()
+	defer e.m.Unlock()
+	var result []Transfer
+	for _, transfers := range e.state.Transfers {
+		for _, t := range transfers {
+			if (t.From == exchangeName || t.To == exchangeName) &&
+				!t.InitiatedAt.Before(start) && t.InitiatedAt.Before(end) {
+				result = append(result, t)
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].InitiatedAt.Before(result[j].InitiatedAt) })
+	return result
+}
+
+// Estimate returns the expected cost & duration of transferring a currency from one exchange to
+// another, returns ErrNoEstimate if neither the withdrawal rules of the currency are known nor
+// any transfers have been observed.
+func (e *Estimator) Estimate(currency, from, to string) (Estimate, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	estimate := Estimate{Currency: strings.ToUpper(currency), From: from, To: to}
+	withdrawal, knownWithdrawal := e.info[from][estimate.Currency]
+	estimate.WithdrawalFee = withdrawal.WithdrawalFee
+	estimate.MinWithdrawal = withdrawal.MinWithdrawal
+	estimate.Confirmations = e.info[to][estimate.Currency].DepositConfirmations
+
+	transfers := e.state.Transfers[routeKey(currency, from, to)]
+	if !knownWithdrawal && len(transfers) == 0 {
+		return estimate, ErrNoEstimate
+	}
+	if len(transfers) == 0 {
+		return estimate, nil
+	}
+
+	durations := make([]time.Duration, len(transfers))
+	for i := range transfers {
+		durations[i] = transfers[i].Duration()
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	estimate.Observed = len(durations)
+	estimate.MedianDuration = percentile(durations, 50)
+	estimate.P90Duration = percentile(durations, 90)
+	estimate.MaxDuration = durations[len(durations)-1]
+	// the fee actually charged on the last transfer is more up to date than the published fee
+	if last := transfers[len(transfers)-1]; last.Fee > 0 {
+		estimate.WithdrawalFee = last.Fee
+	}
+	return estimate, nil
+}
+
+// percentile returns the nearest rank percentile of the sorted durations
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Save writes the observed transfers to the store
+func (e *Estimator) Save(s storage.Store) error {
+	e.m.Lock()
+	defer e.m.Unlock()
+	return s.Put(transfersBucket, transfersKey, &e.state)
+}
+
+// Load replaces the observed transfers with the ones previously saved to the store, returns false
+// if nothing has been saved yet
+func (e *Estimator) Load(s storage.Store) (bool, error) {
+	var state estimatorState
+	err := s.Get(transfersBucket, transfersKey, &state)
+	if err == storage.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if state.Transfers == nil {
+		state.Transfers = make(map[string][]Transfer)
+	}
+	if state.Seen == nil {
+		state.Seen = make(map[string]bool)
+	}
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.state = state
+	return true, nil
+}
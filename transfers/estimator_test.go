@@ -0,0 +1,98 @@
+package transfers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattkanwisher/cryptofiend/storage"
+)
+
+func testTransfer(id string, minutes int, fee float64) Transfer {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	return Transfer{
+		ID:          id,
+		Currency:    "BTC",
+		From:        "Binance",
+		To:          "Kraken",
+		Amount:      1,
+		Fee:         fee,
+		InitiatedAt: start,
+		CompletedAt: start.Add(time.Duration(minutes) * time.Minute),
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	e := NewEstimator()
+	estimate, err := e.Estimate("btc", "Binance", "Kraken")
+	if err != nil {
+		t.Fatalf("Test failed. Estimate error: %s", err)
+	}
+	if estimate.WithdrawalFee != 0.0005 || estimate.MinWithdrawal != 0.001 || estimate.Confirmations != 6 ||
+		estimate.Observed != 0 {
+		t.Errorf("Test failed. Unexpected default estimate %+v", estimate)
+	}
+	if _, err = e.Estimate("XYZ", "Binance", "Kraken"); err != ErrNoEstimate {
+		t.Errorf("Test failed. Expected ErrNoEstimate, got %v", err)
+	}
+
+	for i, minutes := range []int{30, 10, 20, 50, 40, 60, 70, 80, 90, 100} {
+		e.RecordTransfer(testTransfer(string(rune('a'+i)), minutes, 0))
+	}
+	// duplicate & incomplete transfers are ignored
+	e.RecordTransfer(testTransfer("a", 1000, 0))
+	e.RecordTransfer(Transfer{ID: "z", Currency: "BTC", From: "Binance", To: "Kraken"})
+	e.RecordTransfer(testTransfer("k", 25, 0.0004))
+
+	estimate, err = e.Estimate("BTC", "Binance", "Kraken")
+	if err != nil {
+		t.Fatalf("Test failed. Estimate error: %s", err)
+	}
+	if estimate.Observed != 11 || estimate.MedianDuration != 50*time.Minute ||
+		estimate.P90Duration != 90*time.Minute || estimate.MaxDuration != 100*time.Minute {
+		t.Errorf("Test failed. Unexpected observed durations %+v", estimate)
+	}
+	if estimate.WithdrawalFee != 0.0004 {
+		t.Errorf("Test failed. Expected the last observed fee to be used, got %f", estimate.WithdrawalFee)
+	}
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	if transfers := e.Transfers("Kraken", start, start.Add(time.Hour)); len(transfers) != 11 {
+		t.Errorf("Test failed. Expected 11 transfers to Kraken, got %d", len(transfers))
+	}
+	if transfers := e.Transfers("Kraken", start.Add(time.Second), start.Add(time.Hour)); len(transfers) != 0 {
+		t.Errorf("Test failed. Expected no transfers after the start, got %d", len(transfers))
+	}
+
+	if estimate.Profitable(0.0005, 0.01) {
+		t.Error("Test failed. Expected transfer below the min withdrawal not to be profitable")
+	}
+	if estimate.Profitable(1, 0.0003) || !estimate.Profitable(1, 0.001) {
+		t.Error("Test failed. Expected profit to be compared against the withdrawal fee")
+	}
+}
+
+func TestEstimatorStorage(t *testing.T) {
+	e := NewEstimator()
+	e.SetCurrencyInfo("Bittrex", "btc", CurrencyInfo{WithdrawalFee: 0.001})
+	e.RecordTransfer(Transfer{ID: "1", Currency: "BTC", From: "Bittrex", To: "Binance",
+		InitiatedAt: time.Unix(0, 0), CompletedAt: time.Unix(600, 0)})
+
+	store := storage.NewMemoryStore()
+	if ok, err := NewEstimator().Load(store); ok || err != nil {
+		t.Fatalf("Test failed. Expected nothing to load, got %v %v", ok, err)
+	}
+	if err := e.Save(store); err != nil {
+		t.Fatalf("Test failed. Save error: %s", err)
+	}
+	loaded := NewEstimator()
+	if ok, err := loaded.Load(store); !ok || err != nil {
+		t.Fatalf("Test failed. Load returned %v %v", ok, err)
+	}
+	estimate, err := loaded.Estimate("BTC", "Bittrex", "Binance")
+	if err != nil {
+		t.Fatalf("Test failed. Estimate error: %s", err)
+	}
+	if estimate.Observed != 1 || estimate.MedianDuration != 10*time.Minute || estimate.Confirmations != 2 {
+		t.Errorf("Test failed. Unexpected estimate %+v", estimate)
+	}
+}
@@ -0,0 +1,147 @@
+package webhooks
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HMACAdapter accepts callbacks whose body is an event (or an array of events) in the Event JSON
+// format, signed with the hex encoded HMAC-SHA256 of the body. It's intended for exchanges that
+// let the callback format be configured, and for relays forwarding events from other sources.
+type HMACAdapter struct {
+	Exchange string
+	Secret   string
+	// Header holding the signature, defaults to X-Signature
+	Header string
+}
+
+// Decode authenticates & decodes a callback
+func (a *HMACAdapter) Decode(header http.Header, body []byte) ([]Event, error) {
+	name := a.Header
+	if name == "" {
+		name = "X-Signature"
+	}
+	signature, err := hex.DecodeString(header.Get(name))
+	if err != nil || a.Secret == "" {
+		return nil, ErrUnauthorized
+	}
+	mac := hmac.New(sha256.New, []byte(a.Secret))
+	mac.Write(body)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrUnauthorized
+	}
+
+	var events []Event
+	if trimmed := strings.TrimSpace(string(body)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(body, &events)
+	} else {
+		events = make([]Event, 1)
+		err = json.Unmarshal(body, &events[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		events[i].Exchange = a.Exchange
+		if events[i].Time.IsZero() {
+			events[i].Time = time.Now()
+		}
+		if events[i].Type == "" {
+			return nil, errors.New("event type is missing")
+		}
+	}
+	return events, nil
+}
+
+// CoinbaseAdapter accepts the notifications Coinbase sends for deposits & withdrawals, which are
+// signed with the Coinbase notifications RSA key.
+type CoinbaseAdapter struct {
+	Exchange  string
+	PublicKey *rsa.PublicKey
+}
+
+type coinbaseAmount struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type coinbaseNotification struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      struct {
+		ID     string         `json:"id"`
+		Status string         `json:"status"`
+		Amount coinbaseAmount `json:"amount"`
+	} `json:"data"`
+	AdditionalData struct {
+		Hash   string         `json:"hash"`
+		Amount coinbaseAmount `json:"amount"`
+	} `json:"additional_data"`
+}
+
+// Decode authenticates & decodes a notification
+func (a *CoinbaseAdapter) Decode(header http.Header, body []byte) ([]Event, error) {
+	signature, err := base64.StdEncoding.DecodeString(header.Get("CB-SIGNATURE"))
+	if err != nil || a.PublicKey == nil {
+		return nil, ErrUnauthorized
+	}
+	hash := sha256.Sum256(body)
+	if rsa.VerifyPKCS1v15(a.PublicKey, crypto.SHA256, hash[:], signature) != nil {
+		return nil, ErrUnauthorized
+	}
+
+	var n coinbaseNotification
+	if err = json.Unmarshal(body, &n); err != nil {
+		return nil, err
+	}
+	e := Event{Exchange: a.Exchange, ID: n.ID, Time: n.CreatedAt, Status: n.Data.Status}
+	amount := n.Data.Amount
+	switch n.Type {
+	case "wallet:addresses:new-payment":
+		e.Type = EventDeposit
+		e.TxID = n.AdditionalData.Hash
+		e.Status = "completed"
+		amount = n.AdditionalData.Amount
+	case "wallet:deposit:completed":
+		e.Type = EventDeposit
+	case "wallet:withdrawal:completed":
+		e.Type = EventWithdrawal
+	default:
+		return nil, ErrUnsupported
+	}
+	e.Currency = strings.ToUpper(amount.Currency)
+	if e.Amount, err = strconv.ParseFloat(amount.Amount, 64); err != nil {
+		return nil, err
+	}
+	return []Event{e}, nil
+}
+
+// ParsePublicKey parses a PEM encoded RSA public key
+func ParsePublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM encoded key found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an RSA public key")
+	}
+	return rsaKey, nil
+}
@@ -0,0 +1,155 @@
+// Package webhooks receives the callbacks (webhooks or "notifications") some exchanges send for
+// deposits & order updates, so slow-changing data doesn't have to be polled. Each exchange has an
+// adapter that authenticates its callbacks and converts them into events.
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	EventDeposit     = "deposit"
+	EventWithdrawal  = "withdrawal"
+	EventOrderUpdate = "order_update"
+)
+
+// maxBodySize is the largest callback body accepted
+const maxBodySize = 1 << 20
+
+var (
+	// ErrUnauthorized is returned by adapters for callbacks that fail authentication
+	ErrUnauthorized = errors.New("webhook authentication failed")
+	// ErrUnsupported is returned by adapters for callbacks that don't map to an event, these are
+	// acknowledged but otherwise ignored
+	ErrUnsupported = errors.New("unsupported webhook")
+)
+
+// Event is a callback received from an exchange
+type Event struct {
+	Exchange string    `json:"exchange"`
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	// ID the exchange assigned to the callback, used to drop retried deliveries
+	ID       string  `json:"id,omitempty"`
+	Currency string  `json:"currency,omitempty"`
+	Amount   float64 `json:"amount,omitempty"`
+	// Transaction hash of deposits & withdrawals
+	TxID string `json:"txid,omitempty"`
+	// Order ID & currency pair (delimited by "/") of order updates
+	OrderID string `json:"orderId,omitempty"`
+	Pair    string `json:"pair,omitempty"`
+	Status  string `json:"status,omitempty"`
+	// Callback body as received
+	Raw json.RawMessage `json:"raw,omitempty"`
+}
+
+// Adapter authenticates the callbacks of an exchange and converts them into events
+type Adapter interface {
+	// Decode returns ErrUnauthorized if the callback can't be authenticated, or ErrUnsupported if
+	// it isn't of interest
+	Decode(header http.Header, body []byte) ([]Event, error)
+}
+
+// Listener is an HTTP handler that receives callbacks at /<exchange name>, the adapter registered
+// for the exchange converts them into events that are passed to OnEvent.
+type Listener struct {
+	mtx      sync.Mutex
+	adapters map[string]Adapter
+	// IDs of the events received recently, exchanges retry callbacks that aren't acknowledged
+	seen     map[string]time.Time
+	OnEvent  func(Event)
+	DedupTTL time.Duration
+}
+
+// NewListener returns a listener without any adapters
+func NewListener() *Listener {
+	return &Listener{
+		adapters: make(map[string]Adapter),
+		seen:     make(map[string]time.Time),
+		DedupTTL: 24 * time.Hour,
+	}
+}
+
+// Register sets the adapter for the callbacks of an exchange
+func (l *Listener) Register(exchangeName string, a Adapter) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.adapters[strings.ToLower(exchangeName)] = a
+}
+
+// ServeHTTP handles a callback, the last element of the path is the exchange name
+func (l *Listener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.ToLower(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+	l.mtx.Lock()
+	adapter, ok := l.adapters[name]
+	l.mtx.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	events, err := adapter.Decode(r.Header, body)
+	switch err {
+	case nil:
+	case ErrUnsupported:
+		w.WriteHeader(http.StatusOK)
+		return
+	case ErrUnauthorized:
+		log.Printf("Rejected unauthenticated %s webhook from %s.\n", name, r.RemoteAddr)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, e := range events {
+		if !l.firstDelivery(e, time.Now()) {
+			continue
+		}
+		if e.Raw == nil {
+			e.Raw = body
+		}
+		if l.OnEvent != nil {
+			l.OnEvent(e)
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// firstDelivery returns false if an event with the same ID was already received
+func (l *Listener) firstDelivery(e Event, now time.Time) bool {
+	if e.ID == "" {
+		return true
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	for id, t := range l.seen {
+		if now.Sub(t) > l.DedupTTL {
+			delete(l.seen, id)
+		}
+	}
+	key := e.Exchange + "/" + e.ID
+	if _, ok := l.seen[key]; ok {
+		return false
+	}
+	l.seen[key] = now
+	return true
+}
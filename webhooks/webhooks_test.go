@@ -0,0 +1,108 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func postWebhook(l *Listener, path string, header http.Header, body string) int {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	l.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func hmacHeader(secret, body string) http.Header {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	header := http.Header{}
+	header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+	return header
+}
+
+func TestHMACWebhook(t *testing.T) {
+	var events []Event
+	l := NewListener()
+	l.OnEvent = func(e Event) { events = append(events, e) }
+	l.Register("Kraken", &HMACAdapter{Exchange: "Kraken", Secret: "secret"})
+
+	body := `{"type":"deposit","id":"1","currency":"BTC","amount":0.5,"txid":"abc"}`
+	if code := postWebhook(l, "/webhooks/kraken", hmacHeader("wrong", body), body); code != http.StatusUnauthorized {
+		t.Errorf("Test failed. Expected an invalid signature to be rejected, got %d", code)
+	}
+	if code := postWebhook(l, "/webhooks/kraken", hmacHeader("secret", body), body); code != http.StatusOK {
+		t.Fatalf("Test failed. Expected the webhook to be accepted, got %d", code)
+	}
+	// retried delivery
+	if code := postWebhook(l, "/webhooks/kraken", hmacHeader("secret", body), body); code != http.StatusOK {
+		t.Fatalf("Test failed. Expected the retried webhook to be acknowledged, got %d", code)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Test failed. Expected 1 event, got %d", len(events))
+	}
+	e := events[0]
+	if e.Exchange != "Kraken" || e.Type != EventDeposit || e.Amount != 0.5 || e.TxID != "abc" || e.Time.IsZero() {
+		t.Errorf("Test failed. Unexpected event %+v", e)
+	}
+}
+
+func TestUnknownExchange(t *testing.T) {
+	l := NewListener()
+	if code := postWebhook(l, "/webhooks/kraken", nil, "{}"); code != http.StatusNotFound {
+		t.Errorf("Test failed. Expected 404, got %d", code)
+	}
+}
+
+func TestCoinbaseWebhook(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(body string) http.Header {
+		hash := sha256.Sum256([]byte(body))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := http.Header{}
+		header.Set("CB-SIGNATURE", base64.StdEncoding.EncodeToString(sig))
+		return header
+	}
+
+	var events []Event
+	l := NewListener()
+	l.OnEvent = func(e Event) { events = append(events, e) }
+	l.Register("GDAX", &CoinbaseAdapter{Exchange: "GDAX", PublicKey: &key.PublicKey})
+
+	body := `{"id":"n1","type":"wallet:addresses:new-payment","created_at":"2018-01-01T00:00:00Z",` +
+		`"additional_data":{"hash":"tx1","amount":{"amount":"1.25","currency":"eth"}}}`
+	if code := postWebhook(l, "/webhooks/gdax", sign(body+" "), body); code != http.StatusUnauthorized {
+		t.Errorf("Test failed. Expected an invalid signature to be rejected, got %d", code)
+	}
+	if code := postWebhook(l, "/webhooks/gdax", sign(body), body); code != http.StatusOK {
+		t.Fatalf("Test failed. Expected the notification to be accepted, got %d", code)
+	}
+	unsupported := `{"id":"n2","type":"wallet:buy:completed"}`
+	if code := postWebhook(l, "/webhooks/gdax", sign(unsupported), unsupported); code != http.StatusOK {
+		t.Errorf("Test failed. Expected unsupported notifications to be acknowledged, got %d", code)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Test failed. Expected 1 event, got %d", len(events))
+	}
+	e := events[0]
+	if e.Type != EventDeposit || e.Currency != "ETH" || e.Amount != 1.25 || e.TxID != "tx1" {
+		t.Errorf("Test failed. Unexpected event %+v", e)
+	}
+}
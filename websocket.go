@@ -10,6 +10,8 @@ import (
 	"github.com/mattkanwisher/cryptofiend/common"
 	"github.com/mattkanwisher/cryptofiend/config"
 	"github.com/mattkanwisher/cryptofiend/currency"
+	"github.com/mattkanwisher/cryptofiend/eventbus"
+	"github.com/mattkanwisher/cryptofiend/exchanges/asset"
 )
 
 // Const vars for websocket
@@ -23,6 +25,8 @@ type WebsocketClient struct {
 	Conn          *websocket.Conn
 	LastRecv      time.Time
 	Authenticated bool
+	// Queues the events broadcast to the client, so a slow client doesn't hold up the others
+	Events *eventbus.Subscription
 }
 
 // WebsocketEvent is the struct used for websocket events
@@ -86,6 +90,15 @@ func WebsocketClientHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	newClient.Conn = conn
+	newClient.Events = bot.eventBus.Subscribe("websocket "+conn.RemoteAddr().String(),
+		bot.config.EventBus.QueueSize, func(e eventbus.Event) {
+			conn.WriteJSON(WebsocketEvent{
+				Exchange:  e.Exchange,
+				AssetType: e.AssetType,
+				Event:     e.Topic,
+				Data:      e.Data,
+			})
+		})
 	WebsocketClientHub = append(WebsocketClientHub, newClient)
 	numClients++
 	log.Printf("New websocket client connected. Connected clients: %d. Limit %d.",
@@ -96,6 +109,7 @@ func WebsocketClientHandler(w http.ResponseWriter, r *http.Request) {
 func DisconnectWebsocketClient(id int, err error) {
 	for i := range WebsocketClientHub {
 		if WebsocketClientHub[i].ID == id {
+			WebsocketClientHub[i].Events.Close()
 			WebsocketClientHub[i].Conn.Close()
 			WebsocketClientHub = append(WebsocketClientHub[:i], WebsocketClientHub[i+1:]...)
 			log.Printf("Disconnected Websocket client, error: %s", err)
@@ -209,7 +223,7 @@ func wsGetTicker(wsClient *websocket.Conn, data interface{}) error {
 	}
 
 	result, err := GetSpecificTicker(tickerReq.Currency,
-		tickerReq.Exchange, tickerReq.AssetType)
+		tickerReq.Exchange, asset.Normalize(tickerReq.AssetType))
 
 	if err != nil {
 		wsResp.Error = err.Error()
@@ -241,7 +255,7 @@ func wsGetOrderbook(wsClient *websocket.Conn, data interface{}) error {
 	}
 
 	result, err := GetSpecificOrderbook(orderbookReq.Currency,
-		orderbookReq.Exchange, orderbookReq.AssetType)
+		orderbookReq.Exchange, asset.Normalize(orderbookReq.AssetType))
 
 	if err != nil {
 		wsResp.Error = err.Error()